@@ -40,8 +40,9 @@ $ldflags = "-w -s " +
     "-X 'github.com/mo-amir99/lms-server-go/pkg/health.BuildTime=$BuildTime'"
 
 go build -ldflags="$ldflags" -o bin\lms-server.exe .\cmd\app
+go build -ldflags="-w -s" -o bin\lmsctl.exe .\cmd\lmsctl
 
-Write-Host "Build complete: bin\lms-server.exe" -ForegroundColor Green
+Write-Host "Build complete: bin\lms-server.exe, bin\lmsctl.exe" -ForegroundColor Green
 
 # Build Docker image if requested
 if ($Docker) {
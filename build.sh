@@ -22,7 +22,9 @@ CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build \
     -X 'github.com/mo-amir99/lms-server-go/pkg/health.BuildTime=${BUILD_TIME}'" \
     -o bin/lms-server ./cmd/app
 
-echo "Build complete: bin/lms-server"
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags="-w -s" -o bin/lmsctl ./cmd/lmsctl
+
+echo "Build complete: bin/lms-server, bin/lmsctl"
 
 # Build Docker image if requested
 if [ "$2" == "docker" ]; then
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"log"
 	"log/slog"
 	"net/http"
@@ -12,24 +13,45 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
+	"github.com/mo-amir99/lms-server-go/internal/features/contentsync"
+	"github.com/mo-amir99/lms-server-go/internal/features/dashboard"
+	"github.com/mo-amir99/lms-server-go/internal/features/emailqueue"
+	"github.com/mo-amir99/lms-server-go/internal/features/eventstream"
+	"github.com/mo-amir99/lms-server-go/internal/features/idempotency"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
 	"github.com/mo-amir99/lms-server-go/internal/features/meeting"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	"github.com/mo-amir99/lms-server-go/internal/features/scheduledsession"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/userwatch"
+	"github.com/mo-amir99/lms-server-go/internal/features/webhook"
 	"github.com/mo-amir99/lms-server-go/internal/http/routes"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
 	"github.com/mo-amir99/lms-server-go/pkg/config"
 	"github.com/mo-amir99/lms-server-go/pkg/database"
 	"github.com/mo-amir99/lms-server-go/pkg/email"
-
-	// "github.com/mo-amir99/lms-server-go/pkg/jobs" // Uncomment to enable background jobs
+	"github.com/mo-amir99/lms-server-go/pkg/eventbus"
+	"github.com/mo-amir99/lms-server-go/pkg/health"
+	"github.com/mo-amir99/lms-server-go/pkg/jobs"
 	"github.com/mo-amir99/lms-server-go/pkg/logger"
 	"github.com/mo-amir99/lms-server-go/pkg/metrics"
 	"github.com/mo-amir99/lms-server-go/pkg/middleware"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	socketioserver "github.com/mo-amir99/lms-server-go/pkg/socketio"
 	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+	"github.com/mo-amir99/lms-server-go/pkg/tracing"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 func main() {
+	mode := flag.String("mode", "", "process mode: all, api or worker (defaults to LMS_SERVER_MODE, then all)")
+	flag.Parse()
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -37,16 +59,36 @@ func main() {
 	if err != nil {
 		log.Fatalf("load config: %v", err)
 	}
+	if *mode != "" {
+		if cfg.Mode, err = config.ParseMode(*mode); err != nil {
+			log.Fatalf("parse mode: %v", err)
+		}
+	}
 
-	appLogger, err := logger.New(cfg.LogLevel)
+	appLogger, err := logger.New(cfg.LogLevel, cfg.Log)
 	if err != nil {
 		log.Fatalf("init logger: %v", err)
 	}
 
+	// Secrets are redacted, see config.Config.LogValue
+	appLogger.Info("configuration loaded", slog.Any("config", cfg))
+
+	// SIGHUP reloads the log level, rate limits and streaming limits without a restart
+	configWatcher := config.NewWatcher(cfg, appLogger)
+	configWatcher.OnReload(func(next *config.Config) {
+		if err := logger.SetLevel(next.LogLevel); err != nil {
+			appLogger.Warn("failed to apply log level", slog.String("error", err.Error()))
+		}
+	})
+	go configWatcher.Run(ctx)
+
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Spans are exported over OTLP when a collector endpoint is configured
+	shutdownTracing := tracing.Setup(cfg.Tracing, health.Version, appLogger)
+
 	db, err := database.Connect(ctx, cfg.Database, appLogger)
 	if err != nil {
 		appLogger.Error("database connection failed", slog.String("error", err.Error()))
@@ -84,6 +126,8 @@ func main() {
 		cfg.Bunny.Storage.APIKey,
 		cfg.Bunny.Storage.BaseURL,
 		cfg.Bunny.Storage.CDNURL,
+		cfg.Bunny.Storage.TokenKey,
+		cfg.Bunny.Storage.TokenExpiresIn,
 	)
 
 	// Initialize Bunny Statistics client (optional)
@@ -104,6 +148,13 @@ func main() {
 		cfg.Email.From,
 		cfg.Email.Secure,
 	)
+	if cfg.Email.TemplatesDir != "" {
+		if err := email.LoadTemplates(cfg.Email.TemplatesDir); err != nil {
+			appLogger.Error("email templates failed to load", slog.String("dir", cfg.Email.TemplatesDir), slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		appLogger.Info("email templates loaded", slog.String("dir", cfg.Email.TemplatesDir))
+	}
 
 	// Initialize Meeting cache for WebRTC meetings
 	meetingCache := meeting.NewCache()
@@ -111,18 +162,123 @@ func main() {
 	// Initialize stream cache for live streaming
 	streamCache := streamcache.Global()
 
-	// Initialize Socket.IO server for live streaming
-	socketIOServer, err := socketioserver.NewServer(db, appLogger, streamCache, cfg.JWTSecret)
-	if err != nil {
-		appLogger.Error("socket.io server initialization failed", slog.String("error", err.Error()))
-		os.Exit(1)
+	// Dashboard and course list queries are cached; Redis shares entries and invalidations across instances
+	var queryCache *cache.Store
+	if cfg.Cache.TTL > 0 {
+		var cacheClient cache.Client = cache.NewMemoryCache()
+		if cfg.Cache.RedisAddr != "" {
+			redisClient, err := cache.NewRedisClient(cfg.Cache.RedisAddr, cfg.Cache.RedisPassword, cfg.Cache.RedisDB)
+			if err != nil {
+				appLogger.Error("redis connection failed", slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+			cacheClient = redisClient
+		}
+		defer cacheClient.Close()
+
+		queryCache = cache.NewStore(cacheClient, time.Duration(cfg.Cache.TTL)*time.Second, appLogger)
 	}
-	defer socketIOServer.Close()
 
-	appLogger.Info("socket.io server initialized")
+	// Rate limit buckets are shared across instances through Redis when configured;
+	// while Redis is unreachable each instance limits on its own
+	var rateLimitStore middleware.Limiter = middleware.NewRateLimiter(cfg.RateLimit.IPPerMinute, time.Minute)
+	if cfg.RateLimit.Backend == config.RateLimitBackendRedis {
+		rateLimitRedis := redis.NewClient(&redis.Options{
+			Addr:     cfg.Cache.RedisAddr,
+			Password: cfg.Cache.RedisPassword,
+			DB:       cfg.Cache.RedisDB,
+		})
+		defer rateLimitRedis.Close()
+
+		rateLimitStore = middleware.NewRedisRateLimiter(rateLimitRedis, cfg.RateLimit.IPPerMinute, time.Minute, appLogger)
+	}
+
+	// Features publish live events to the bus; API processes deliver them to
+	// users over Socket.IO and the server-sent events fallback alike
+	liveEvents := eventbus.New(appLogger)
+
+	// Outbound email is persisted and delivered by a background job with retries
+	emailQueue := emailqueue.NewQueue(db, appLogger)
+
+	// Notification emails are opt-in; in-app notifications are always stored
+	var notificationEmail *emailqueue.Queue
+	if cfg.Email.NotificationsEnabled {
+		notificationEmail = emailQueue
+	}
+	notificationService := notification.NewService(db, appLogger, notificationEmail)
+	notificationService.UseEvents(liveEvents)
+	liveEvents.Subscribe(announcement.TopicPublished, "notification", notificationService.AnnouncementPublished)
+
+	// Subscription state changes are published to the outbox and fanned out to subscribers
+	events := outbox.NewDispatcher()
+	auditLog := outbox.AuditLog(appLogger)
+	dashboardCache := func(ctx context.Context, _ outbox.Event) error {
+		queryCache.Invalidate(ctx, cache.NamespaceDashboard)
+		return nil
+	}
+	for _, topic := range []string{subscription.TopicActivated, subscription.TopicRenewed, subscription.TopicExpired} {
+		events.Subscribe(topic, "notification", notificationService.SubscriptionChanged)
+		events.Subscribe(topic, "audit", auditLog)
+		events.Subscribe(topic, "dashboard-cache", dashboardCache)
+	}
+	// Staff resets and grants of student watches are kept in the audit log
+	for _, topic := range []string{userwatch.TopicReset, userwatch.TopicGranted} {
+		events.Subscribe(topic, "audit", auditLog)
+	}
+
+	// Deleted lessons' videos and files are removed from Bunny once the deletion commits
+	events.Subscribe(lesson.TopicDeleted, "storage-cleanup", lesson.CleanupDeleted(streamClient, storageClient, appLogger))
+
+	// Tenant webhooks receive the events their endpoints listen to, signed and retried
+	webhooks := webhook.NewService(db, appLogger)
+	for _, topic := range webhook.OutboxTopics {
+		events.Subscribe(topic, "webhooks", webhooks.Enqueue)
+	}
+	for _, topic := range webhook.LiveTopics {
+		liveEvents.Subscribe(topic, "webhooks", webhooks.Relay)
+	}
+
+	scheduler := jobs.NewScheduler(appLogger)
+
+	// Queues, retries and periodic maintenance only touch the database and
+	// external services, so worker processes can run them apart from the API
+	if cfg.RunsWorkers() {
+		scheduler.AddJob(emailqueue.NewJob(db, appLogger, emailClient), 15*time.Second)
+		scheduler.AddJob(outbox.NewJob(db, appLogger, events), 5*time.Second)
+		scheduler.AddJob(webhook.NewJob(webhooks), 5*time.Second)
+
+		// Expired subscriptions go through a grace period with dunning emails before deactivation
+		scheduler.AddJob(
+			subscription.NewExpirationJob(db, appLogger, emailQueue, cfg.Subscription),
+			time.Hour,
+		)
 
-	// Background jobs are disabled by default - uncomment below to enable
-	// scheduler := jobs.NewScheduler(appLogger)
+		// Dashboards read per-subscription counts from a summary table refreshed in the background
+		scheduler.AddJob(dashboard.NewStatsJob(db, appLogger), 5*time.Minute)
+
+		// Deletions are remembered for delta sync only within the configured retention
+		scheduler.AddJob(contentsync.NewPruneJob(db, appLogger, cfg.Sync.TombstoneRetentionDays), 24*time.Hour)
+
+		// Responses are replayed to retried purchases for a day
+		scheduler.AddJob(idempotency.NewPruneJob(db, appLogger), time.Hour)
+
+		// Replaced lesson videos are swapped in once Bunny has finished processing them
+		scheduler.AddJob(lesson.NewReplacementJob(db, appLogger, streamClient, queryCache), time.Minute)
+	}
+
+	// Scheduled sessions need a clock: reminders and go-live transitions run every
+	// minute. Going live opens streams and meetings in this process's caches, so
+	// the job runs where sockets are served
+	if cfg.ServesHTTP() {
+		scheduler.AddJob(
+			scheduledsession.NewJob(db, appLogger, notificationService, meetingCache, streamCache),
+			time.Minute,
+		)
+	}
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	// The remaining background jobs are disabled by default - uncomment below to enable
 	// ... see commented section for job configuration
 
 	/*
@@ -139,16 +295,51 @@ func main() {
 			jobs.NewStorageCleanupJob(db, appLogger),
 			24*time.Hour, // Check daily
 		)
+	*/
 
-		scheduler.AddJob(
-			jobs.NewSubscriptionExpirationJob(db, emailClient, appLogger),
-			6*time.Hour, // Check every 6 hours
-		)
+	// Worker processes stop here: they bind no port and run the jobs until signalled
+	if !cfg.ServesHTTP() {
+		appLogger.Info("worker started", slog.String("env", cfg.Env), slog.String("mode", cfg.Mode))
 
-		// Start background jobs
-		scheduler.Start()
-		defer scheduler.Stop()
-	*/
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			appLogger.Error("tracing shutdown failed", slog.String("error", err.Error()))
+		}
+		appLogger.Info("worker stopped")
+		return
+	}
+
+	// Initialize Socket.IO server for live streaming
+	socketIOServer, err := socketioserver.NewServer(db, appLogger, streamCache, cfg.JWTSecret)
+	if err != nil {
+		appLogger.Error("socket.io server initialization failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer socketIOServer.Close()
+
+	appLogger.Info("socket.io server initialized")
+
+	eventStreams := eventstream.NewHub()
+	liveEvents.Subscribe(eventbus.AllTopics, "socketio", eventbus.Deliver(socketIOServer))
+	liveEvents.Subscribe(eventbus.AllTopics, "event-streams", eventbus.Deliver(eventStreams))
+	socketIOServer.OnStreamStarted(webhooks.StreamStarted)
+
+	// Live session gauges are sampled from the in-memory registries on each scrape
+	metrics.RegisterSocketConnections(socketIOServer.ConnectionCount)
+	metrics.RegisterEventStreams(eventStreams.Count)
+	metrics.RegisterLiveStreams(streamCache.Stats)
+	metrics.RegisterMeetings(meetingCache.Counts)
+
+	if err := validation.Setup(); err != nil {
+		appLogger.Error("request validator setup failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// JSON bodies get a tighter default limit than the global one, see request.Limit
+	request.Configure(cfg.API.StrictJSON, int64(cfg.API.MaxJSONBodyKB)<<10)
 
 	router := gin.New()
 
@@ -163,6 +354,7 @@ func main() {
 
 	// Now apply full middleware stack for all other routes
 	router.Use(middleware.RequestID())                        // Add request IDs for tracing
+	router.Use(middleware.Tracing())                          // Start a trace span per request
 	router.Use(middleware.Compression(middleware.BestSpeed))  // Compress responses (gzip)
 	router.Use(middleware.RequestLogger(appLogger))           // Log all requests
 	router.Use(middleware.SecurityHeaders())                  // Add security headers
@@ -171,15 +363,11 @@ func main() {
 	router.Use(metrics.Middleware())                          // Collect Prometheus metrics
 	router.Use(request.Handler(appLogger))                    // Request context handler
 
-	// Rate limiting (100 requests per minute per IP)
-	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
-	router.Use(rateLimiter.Middleware())
-
-	routes.Register(router, cfg, db, appLogger, streamClient, storageClient, statsClient, emailClient, meetingCache)
+	routes.Register(router, cfg, configWatcher, db, appLogger, streamClient, storageClient, statsClient, emailQueue, meetingCache, socketIOServer, liveEvents, eventStreams, notificationService, queryCache, rateLimitStore)
 
 	srv := &http.Server{
 		Addr:              cfg.ServerAddress(),
-		Handler:           router,
+		Handler:           middleware.VersionedAPI(router, routes.LegacyAPIPrefix, routes.APIPrefix, routes.APIVersion, cfg.API.LegacySunset),
 		ReadTimeout:       2 * time.Minute, // Increased for file uploads
 		ReadHeaderTimeout: 10 * time.Second,
 		WriteTimeout:      2 * time.Minute, // Increased for file uploads
@@ -191,6 +379,7 @@ func main() {
 		appLogger.Info("server starting",
 			slog.String("addr", cfg.ServerAddress()),
 			slog.String("env", cfg.Env),
+			slog.String("mode", cfg.Mode),
 			slog.String("log_level", cfg.LogLevel),
 		)
 
@@ -207,9 +396,20 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Sockets are hijacked connections that srv.Shutdown does not wait for; drain
+	// them first so clients are told to reconnect and live streams are persisted
+	socketIOServer.Drain(shutdownCtx)
+
+	// Event streams are open requests srv.Shutdown would wait out; end them
+	eventStreams.Close()
+
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		appLogger.Error("server shutdown failed", slog.String("error", err.Error()))
 	} else {
 		appLogger.Info("server stopped gracefully")
 	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		appLogger.Error("tracing shutdown failed", slog.String("error", err.Error()))
+	}
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/tenantbackup"
+)
+
+// runTenantExport writes a backup archive of one subscription. The archive is
+// written next to --out and renamed once complete, so an interrupted export
+// never leaves a partial archive behind.
+func runTenantExport(ctx context.Context, args []string) error {
+	flags := newFlags("tenant export")
+	subscriptionFlag := flags.String("subscription", "", "ID of the subscription to export (required)")
+	out := flags.String("out", "", "archive file to write (default: backup-<subscription>.zip)")
+	assets := flags.Bool("assets", false, "add a manifest of the Bunny videos and files the rows refer to")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *subscriptionFlag == "" {
+		fmt.Fprintln(flags.Output(), "--subscription is required")
+		flags.Usage()
+		return errUsage
+	}
+	subscriptionID, err := uuid.Parse(*subscriptionFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --subscription: %w", err)
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("backup-%s.zip", subscriptionID)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".backup-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	cfg := app.cfg
+	manifest, err := tenantbackup.Export(app.db.WithContext(ctx), subscriptionID, file, tenantbackup.ExportOptions{
+		Assets:        *assets,
+		LibraryID:     cfg.Bunny.Stream.LibraryID,
+		StorageZone:   cfg.Bunny.Storage.StorageZone,
+		StorageCDNURL: cfg.Bunny.Storage.CDNURL,
+	})
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	rows := 0
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+	fmt.Printf("✅ Exported %s: %d rows across %d tables to %s\n", manifest.Identifier, rows, len(manifest.Tables), path)
+	fmt.Println("⚠️  The archive holds password hashes and personal data; store it accordingly.")
+	return nil
+}
+
+// runTenantImport restores a backup archive written by 'tenant export'. The
+// subscription must not exist in this database yet.
+func runTenantImport(ctx context.Context, args []string) error {
+	flags := newFlags("tenant import")
+	in := flags.String("in", "", "archive file to restore (required)")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *in == "" {
+		fmt.Fprintln(flags.Output(), "--in is required")
+		flags.Usage()
+		return errUsage
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	manifest, err := tenantbackup.Restore(app.db.WithContext(ctx), file, info.Size())
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+	fmt.Printf("✅ Restored %s (%s): %d rows across %d tables\n", manifest.Identifier, manifest.SubscriptionID, rows, len(manifest.Tables))
+	if manifest.Assets {
+		fmt.Println("Bunny assets are not copied; see assets.json in the archive for what to transfer.")
+	}
+	return nil
+}
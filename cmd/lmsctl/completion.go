@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call. It
+// takes the words after "lmsctl", the last one being the word under the
+// cursor, and prints the candidates one per line.
+const completeCommand = "__complete"
+
+var completionScripts = map[string]string{
+	"bash": `_lmsctl() {
+	local IFS=$'\n'
+	COMPREPLY=($(lmsctl __complete "${COMP_WORDS[@]:1:COMP_CWORD}"))
+}
+complete -o default -F _lmsctl lmsctl
+`,
+	"zsh": `#compdef lmsctl
+_lmsctl() {
+	local -a candidates
+	candidates=(${(f)"$(lmsctl __complete "${(@)words[2,CURRENT]}")"})
+	compadd -a candidates
+}
+compdef _lmsctl lmsctl
+`,
+	"fish": `complete -c lmsctl -a '(lmsctl __complete (commandline -opc)[2..-1] (commandline -ct))'
+`,
+}
+
+// runCompletion prints the completion script of a shell. Source it, for
+// example with 'source <(lmsctl completion bash)'.
+func runCompletion(ctx context.Context, args []string) error {
+	flags := newFlags("completion")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lmsctl completion bash|zsh|fish")
+	}
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	script, ok := completionScripts[flags.Arg(0)]
+	if flags.NArg() != 1 || !ok {
+		flags.Usage()
+		return errUsage
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// complete prints the commands or flags that may replace the last word.
+func complete(ctx context.Context, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	typed, partial := words[:len(words)-1], words[len(words)-1]
+
+	if cmd, _, ok := find(typed); ok {
+		if strings.HasPrefix(partial, "-") {
+			for _, name := range flagNames(ctx, cmd) {
+				if strings.HasPrefix(name, partial) {
+					fmt.Println(name)
+				}
+			}
+		}
+		return
+	}
+
+	var next []string
+	for _, cmd := range commands {
+		path := strings.Fields(cmd.path)
+		if len(path) > len(typed) && slices.Equal(path[:len(typed)], typed) &&
+			strings.HasPrefix(path[len(typed)], partial) && !slices.Contains(next, path[len(typed)]) {
+			next = append(next, path[len(typed)])
+		}
+	}
+	for _, word := range next {
+		fmt.Println(word)
+	}
+}
+
+// flagNames returns the flags of a command. Every command creates and parses
+// its flags before doing anything else, so asking it for -h is harmless.
+func flagNames(ctx context.Context, cmd command) []string {
+	flagOutput, defined = io.Discard, nil
+	defer func() { flagOutput = os.Stderr }()
+
+	_ = cmd.run(ctx, []string{"-h"})
+	if defined == nil {
+		return nil
+	}
+
+	var names []string
+	defined.VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	return names
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mo-amir99/lms-server-go/internal/bootstrap"
+	"github.com/mo-amir99/lms-server-go/pkg/database"
+	"github.com/mo-amir99/lms-server-go/pkg/database/migrations"
+)
+
+// runMigrate creates or updates every model's table, then applies the SQL
+// migrations in file name order. A failing SQL file is reported and the rest
+// still run, since each is written to be re-applied. The migrations are built
+// into the binary; --dir or LMS_DB_MIGRATIONS_DIR reads them from disk instead.
+func runMigrate(ctx context.Context, args []string) error {
+	flags := newFlags("migrate")
+	dir := flags.String("dir", "", "read the SQL migrations from this directory instead of the binary (default: LMS_DB_MIGRATIONS_DIR)")
+	skipSQL := flags.Bool("skip-sql", false, "only run AutoMigrate")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if *dir == "" {
+		*dir = app.cfg.Database.MigrationsDir
+	}
+	var files []migrations.SQLFile
+	if !*skipSQL {
+		if files, err = migrations.SQLFiles(*dir); err != nil {
+			return err
+		}
+	}
+
+	db := app.db.WithContext(ctx)
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		return fmt.Errorf("create uuid extension: %w", err)
+	}
+
+	app.logger.Info("running AutoMigrate")
+	if err := db.AutoMigrate(database.Models()...); err != nil {
+		return fmt.Errorf("auto migrate: %w", err)
+	}
+
+	if *skipSQL {
+		fmt.Println("✅ Tables created/updated (SQL migrations skipped)")
+		return nil
+	}
+
+	failed := 0
+	for _, file := range files {
+		if err := db.Exec(file.SQL).Error; err != nil {
+			failed++
+			app.logger.Error("migration failed", slog.String("file", file.Name), slog.String("error", err.Error()))
+			continue
+		}
+		app.logger.Info("migration applied", slog.String("file", file.Name))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d SQL migrations failed", failed, len(files))
+	}
+	fmt.Printf("✅ Tables created/updated and %d SQL migrations applied\n", len(files))
+	return nil
+}
+
+// runSeed creates the default super admin, or resets it to its default
+// credentials. Those credentials are public, so production needs --force.
+func runSeed(ctx context.Context, args []string) error {
+	flags := newFlags("seed")
+	force := flags.Bool("force", false, "seed even in production")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if app.cfg.IsProduction() && !*force {
+		return errors.New("refusing to seed default credentials in production; pass --force to override")
+	}
+
+	if err := bootstrap.EnsureDefaultSuperAdmin(app.db.WithContext(ctx), app.logger); err != nil {
+		return err
+	}
+	fmt.Println("✅ Default super admin is in place")
+	return nil
+}
+
+// runDrop drops every table of the current schema. It asks for confirmation
+// unless --yes is given.
+func runDrop(ctx context.Context, args []string) error {
+	flags := newFlags("drop")
+	yes := flags.Bool("yes", false, "skip the confirmation prompt")
+	noInput := flags.Bool("no-input", false, "fail instead of prompting")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if !*yes {
+		fmt.Printf("⚠️  This drops every table of database %q. It cannot be undone.\n", app.cfg.Database.Name)
+		confirmed, err := newPrompter(*noInput).confirm("DROP ALL TABLES")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Cancelled; the database is unchanged")
+			return nil
+		}
+	}
+
+	db := app.db.WithContext(ctx)
+	var tables []string
+	if err := db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema()").Scan(&tables).Error; err != nil {
+		return fmt.Errorf("list tables: %w", err)
+	}
+
+	for _, table := range tables {
+		quoted := `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+		if err := db.Exec("DROP TABLE IF EXISTS " + quoted + " CASCADE").Error; err != nil {
+			return fmt.Errorf("drop %s: %w", table, err)
+		}
+		app.logger.Info("dropped table", slog.String("table", table))
+	}
+
+	fmt.Printf("✅ Dropped %d tables; run 'lmsctl migrate' to recreate them\n", len(tables))
+	return nil
+}
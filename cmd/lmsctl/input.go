@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// prompter asks for values that were not given as flags. With noInput set it
+// fails instead, so scripts never hang waiting on stdin.
+type prompter struct {
+	noInput bool
+	reader  *bufio.Reader
+}
+
+func newPrompter(noInput bool) *prompter {
+	return &prompter{noInput: noInput, reader: bufio.NewReader(os.Stdin)}
+}
+
+// value returns current, or asks for it when it is empty. flagName is named in
+// the error when prompting is disabled.
+func (p *prompter) value(current, label, flagName string) (string, error) {
+	if current != "" {
+		return current, nil
+	}
+	if p.noInput {
+		return "", fmt.Errorf("--%s is required with --no-input", flagName)
+	}
+
+	fmt.Printf("%s: ", label)
+	return p.line()
+}
+
+// optional is like value, but leaves the value empty when prompting is
+// disabled.
+func (p *prompter) optional(current, label string) (string, error) {
+	if current != "" || p.noInput {
+		return current, nil
+	}
+
+	fmt.Printf("%s (optional): ", label)
+	return p.line()
+}
+
+// confirm asks the user to type phrase. It reports false when they type
+// anything else.
+func (p *prompter) confirm(phrase string) (bool, error) {
+	if p.noInput {
+		return false, fmt.Errorf("confirmation is required; pass --yes with --no-input")
+	}
+
+	fmt.Printf("Type '%s' to confirm: ", phrase)
+	answer, err := p.line()
+	return answer == phrase, err
+}
+
+// line reads one line from stdin, such as a password piped in with
+// --password-stdin.
+func (p *prompter) line() (string, error) {
+	text, err := p.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || text == "") {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+	return strings.TrimSpace(text), nil
+}
@@ -0,0 +1,237 @@
+// Command lmsctl administers an LMS deployment: it migrates and seeds the
+// database, manages super admins and tenants, backs tenants up and restores
+// them, and reconciles storage usage.
+// Every subcommand loads the same configuration as the server, from the
+// environment and .env, and can run without prompts for use in scripts.
+// 'lmsctl completion bash|zsh|fish' prints a shell completion script.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/database"
+	"github.com/mo-amir99/lms-server-go/pkg/logger"
+)
+
+// command is one subcommand. Its path may have several words, as in
+// "tenant create".
+type command struct {
+	path    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{path: "config", summary: "Print the effective configuration with secrets redacted", run: runConfig},
+	{path: "migrate", summary: "Create or update every table and apply the SQL migrations", run: runMigrate},
+	{path: "seed", summary: "Create or reset the default super admin", run: runSeed},
+	{path: "superadmin", summary: "Create a super admin account", run: runSuperAdmin},
+	{path: "drop", summary: "Drop every table in the database", run: runDrop},
+	{path: "tenant create", summary: "Create a subscription and its owner", run: runTenantCreate},
+	{path: "tenant export", summary: "Write a backup archive of a subscription", run: runTenantExport},
+	{path: "tenant import", summary: "Restore a subscription from a backup archive", run: runTenantImport},
+	{path: "storage reconcile", summary: "Recalculate course storage usage from Bunny", run: runStorageReconcile},
+}
+
+// help and completion list the other commands, so they are added here to
+// avoid an initialization cycle.
+func init() {
+	commands = append(commands,
+		command{path: "help", summary: "Show the commands, or the flags of one command", run: runHelp},
+		command{path: "completion", summary: "Print a bash, zsh or fish completion script", run: runCompletion},
+	)
+}
+
+// errUsage is returned after a command printed its own usage.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		complete(ctx, os.Args[2:])
+		return
+	}
+
+	cmd, args, ok := find(os.Args[1:])
+	if !ok {
+		group := ""
+		if len(os.Args) > 1 && isGroup(os.Args[1]) {
+			group = os.Args[1]
+		}
+		usage(os.Stderr, group)
+		os.Exit(2)
+	}
+
+	if err := cmd.run(ctx, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "lmsctl %s: %v\n", cmd.path, err)
+		os.Exit(1)
+	}
+}
+
+// find returns the command named by the leading arguments and the arguments
+// left for it.
+func find(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.path)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+// isGroup reports whether name is the first word of nested commands, such
+// as "tenant".
+func isGroup(name string) bool {
+	for _, cmd := range commands {
+		if strings.HasPrefix(cmd.path, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// usage lists the commands, or only those of a group such as "tenant".
+func usage(w io.Writer, group string) {
+	name := "<command>"
+	if group != "" {
+		name = group + " <command>"
+	}
+	fmt.Fprintf(w, "Usage: lmsctl %s [flags]\n", name)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		if group == "" || strings.HasPrefix(cmd.path, group+" ") {
+			fmt.Fprintf(w, "  %-18s %s\n", cmd.path, cmd.summary)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'lmsctl help <command>' for the flags of a command.")
+}
+
+// runHelp prints the command list, a group's commands, or the flags of one
+// command.
+func runHelp(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		usage(os.Stdout, "")
+		return nil
+	}
+	if len(args) == 1 && isGroup(args[0]) {
+		usage(os.Stdout, args[0])
+		return nil
+	}
+
+	cmd, rest, ok := find(args)
+	if !ok || len(rest) > 0 {
+		usage(os.Stderr, "")
+		return errUsage
+	}
+	flagOutput = os.Stdout
+	return cmd.run(ctx, []string{"-h"})
+}
+
+// flagOutput receives flag usage and errors. Completion discards it while it
+// collects a command's flags.
+var flagOutput io.Writer = os.Stderr
+
+// defined is the flag set most recently created by newFlags.
+var defined *flag.FlagSet
+
+// newFlags creates the flag set of a command.
+func newFlags(path string) *flag.FlagSet {
+	flags := flag.NewFlagSet("lmsctl "+path, flag.ContinueOnError)
+	flags.SetOutput(flagOutput)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lmsctl %s [flags]\n\n", path)
+		flags.PrintDefaults()
+	}
+	defined = flags
+	return flags
+}
+
+// parse parses the flags of a command. The flag package has already reported
+// the problem when it fails.
+func parse(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return errUsage
+}
+
+// runConfig prints the configuration the server would run with. Loading it
+// reports every invalid setting, so it doubles as a pre-deploy check.
+func runConfig(ctx context.Context, args []string) error {
+	flags := newFlags("config")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range cfg.Summary() {
+		value := setting.Value
+		if value == "" {
+			value = "(not set)"
+		}
+		fmt.Printf("%-32s %s\n", setting.Name, value)
+	}
+	return nil
+}
+
+// env is what every command that touches the database shares.
+type env struct {
+	cfg    *config.Config
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+// connect loads the configuration and opens the database the way the server
+// does. close must be called when the command is done.
+func connect(ctx context.Context) (*env, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+
+	appLogger, err := logger.New(cfg.LogLevel, cfg.Log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init logger: %w", err)
+	}
+
+	db, err := database.Connect(ctx, cfg.Database, appLogger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect database: %w", err)
+	}
+
+	closeDB := func() {
+		if err := database.Close(db, appLogger); err != nil {
+			appLogger.Error("database close failed", slog.String("error", err.Error()))
+		}
+	}
+	return &env{cfg: cfg, logger: appLogger, db: db}, closeDB, nil
+}
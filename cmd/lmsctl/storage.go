@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+)
+
+// runStorageReconcile recalculates the storage usage of every course of one
+// subscription, or of every active subscription, from Bunny. Quota warnings
+// are stored as in-app notifications as usual.
+func runStorageReconcile(ctx context.Context, args []string) error {
+	flags := newFlags("storage reconcile")
+	subscriptionFlag := flags.String("subscription", "", "ID of the subscription to reconcile (default: every active subscription)")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	var only uuid.UUID
+	if *subscriptionFlag != "" {
+		parsed, err := uuid.Parse(*subscriptionFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --subscription: %w", err)
+		}
+		only = parsed
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	cfg := app.cfg
+	streamClient := bunny.NewStreamClient(
+		cfg.Bunny.Stream.LibraryID,
+		cfg.Bunny.Stream.APIKey,
+		cfg.Bunny.Stream.BaseURL,
+		cfg.Bunny.Stream.SecurityKey,
+		cfg.Bunny.Stream.DeliveryURL,
+		cfg.Bunny.Stream.ExpiresIn,
+	)
+	storageClient := bunny.NewStorageClient(
+		cfg.Bunny.Storage.StorageZone,
+		cfg.Bunny.Storage.APIKey,
+		cfg.Bunny.Storage.BaseURL,
+		cfg.Bunny.Storage.CDNURL,
+		cfg.Bunny.Storage.TokenKey,
+		cfg.Bunny.Storage.TokenExpiresIn,
+	)
+
+	db := app.db.WithContext(ctx)
+	usage := storageusage.NewService(db, app.logger, streamClient, storageClient, nil)
+	usage.UseNotifier(notification.NewService(db, app.logger, nil))
+
+	subscriptionIDs := []uuid.UUID{only}
+	if only == uuid.Nil {
+		subscriptionIDs = nil
+		if err := db.Model(&subscription.Subscription{}).
+			Where("is_active = ?", true).
+			Order("created_at").
+			Pluck("id", &subscriptionIDs).Error; err != nil {
+			return fmt.Errorf("list subscriptions: %w", err)
+		}
+	}
+
+	courses, failed := 0, 0
+	for _, id := range subscriptionIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stats, err := usage.UpdateSubscriptionCourses(ctx, id)
+		courses += len(stats)
+		if err != nil {
+			failed++
+			app.logger.Error("storage reconcile failed", slog.String("subscriptionId", id.String()), slog.String("error", err.Error()))
+		}
+	}
+
+	fmt.Printf("Reconciled %d courses across %d subscriptions\n", courses, len(subscriptionIDs))
+	if failed > 0 {
+		return errors.New("some courses could not be reconciled; see the log")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// accountFlags are the flags of commands that create an account.
+type accountFlags struct {
+	name          *string
+	email         *string
+	phone         *string
+	passwordStdin *bool
+	noInput       *bool
+}
+
+func addAccountFlags(flags *flag.FlagSet, prefix string) accountFlags {
+	return accountFlags{
+		name:          flags.String(prefix+"name", "", "full name"),
+		email:         flags.String(prefix+"email", "", "email address"),
+		phone:         flags.String(prefix+"phone", "", "phone number (optional)"),
+		passwordStdin: flags.Bool("password-stdin", false, "read the password from the first line of stdin"),
+		noInput:       flags.Bool("no-input", false, "fail instead of prompting for missing values"),
+	}
+}
+
+// input completes the account from prompts and builds the create input.
+func (a accountFlags) input(prefix string, userType types.UserType) (user.CreateInput, error) {
+	prompt := newPrompter(*a.noInput)
+
+	name, err := prompt.value(*a.name, "Full name", prefix+"name")
+	if err != nil {
+		return user.CreateInput{}, err
+	}
+	email, err := prompt.value(*a.email, "Email", prefix+"email")
+	if err != nil {
+		return user.CreateInput{}, err
+	}
+	phone, err := prompt.optional(*a.phone, "Phone")
+	if err != nil {
+		return user.CreateInput{}, err
+	}
+
+	var password string
+	if *a.passwordStdin {
+		password, err = prompt.line()
+	} else {
+		password, err = prompt.value("", "Password (min 8 chars)", "password-stdin")
+	}
+	if err != nil {
+		return user.CreateInput{}, err
+	}
+
+	input := user.CreateInput{FullName: name, Email: email, Password: password, UserType: userType}
+	if phone != "" {
+		input.Phone = &phone
+	}
+	return input, nil
+}
+
+// runSuperAdmin creates a super admin. Values missing from the flags are
+// prompted for.
+func runSuperAdmin(ctx context.Context, args []string) error {
+	flags := newFlags("superadmin")
+	account := addAccountFlags(flags, "")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	input, err := account.input("", user.UserTypeSuperAdmin)
+	if err != nil {
+		return err
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	created, err := user.Create(app.db.WithContext(ctx), input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Super admin created")
+	fmt.Printf("   ID: %s\n", created.ID)
+	fmt.Printf("   Email: %s\n", created.Email)
+	return nil
+}
+
+// runTenantCreate creates a subscription owned by a new instructor account,
+// or by an existing account without a subscription when --owner-email matches
+// one.
+func runTenantCreate(ctx context.Context, args []string) error {
+	flags := newFlags("tenant create")
+	identifier := flags.String("identifier", "", "unique subscription identifier (required)")
+	displayName := flags.String("display-name", "", "subscription display name")
+	packageID := flags.String("package", "", "ID of the subscription package to apply")
+	days := flags.Int("days", 30, "days until the subscription ends")
+	owner := addAccountFlags(flags, "owner-")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *identifier == "" {
+		fmt.Fprintln(flags.Output(), "--identifier is required")
+		flags.Usage()
+		return errUsage
+	}
+	if *days < 1 {
+		return errors.New("--days must be at least 1")
+	}
+
+	var pkg uuid.UUID
+	if *packageID != "" {
+		parsed, err := uuid.Parse(*packageID)
+		if err != nil {
+			return fmt.Errorf("invalid --package: %w", err)
+		}
+		pkg = parsed
+	}
+
+	app, closeDB, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	db := app.db.WithContext(ctx)
+	end := time.Now().UTC().AddDate(0, 0, *days)
+	input := subscription.CreateInput{
+		IdentifierName:  *identifier,
+		SubscriptionEnd: &end,
+	}
+	if *displayName != "" {
+		input.DisplayName = displayName
+	}
+
+	email, err := newPrompter(*owner.noInput).value(*owner.email, "Owner email", "owner-email")
+	if err != nil {
+		return err
+	}
+	*owner.email = email
+
+	// Prompts are answered before the transaction opens
+	account, err := user.GetByEmail(db, email)
+	var ownerInput *user.CreateInput
+	switch {
+	case err == nil:
+		if account.SubscriptionID != nil {
+			return subscription.ErrUserHasSubscription
+		}
+	case errors.Is(err, user.ErrUserNotFound):
+		newOwner, err := owner.input("owner-", user.UserTypeInstructor)
+		if err != nil {
+			return err
+		}
+		ownerInput = &newOwner
+	default:
+		return err
+	}
+
+	var created subscription.Subscription
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if ownerInput != nil {
+			newOwner, err := user.Create(tx, *ownerInput)
+			if err != nil {
+				return fmt.Errorf("create owner: %w", err)
+			}
+			account = newOwner
+		}
+
+		var err error
+		input.UserID = account.ID
+		if pkg != uuid.Nil {
+			created, err = subscription.CreateFromPackage(tx, subscription.CreateFromPackageInput{CreateInput: input, PackageID: pkg})
+		} else {
+			created, err = subscription.Create(tx, input)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Tenant created")
+	fmt.Printf("   Subscription: %s (%s)\n", created.ID, created.IdentifierName)
+	fmt.Printf("   Owner: %s (%s)\n", account.Email, account.ID)
+	fmt.Printf("   Ends: %s\n", created.SubscriptionEnd.Format(time.DateOnly))
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package authz centralises role-based authorization policies so handlers share one
+// definition of who may view, manage and list which users and subscription resources.
+package authz
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// roleOrder lists roles from least to most privileged.
+var roleOrder = []types.UserType{
+	types.UserTypeReferrer,
+	types.UserTypeStudent,
+	types.UserTypeAssistant,
+	types.UserTypeInstructor,
+	types.UserTypeAdmin,
+	types.UserTypeSuperAdmin,
+}
+
+// Subject is the authenticated actor a policy is evaluated for.
+type Subject struct {
+	ID             uuid.UUID
+	Role           types.UserType
+	SubscriptionID *uuid.UUID
+	Permissions    []Permission
+}
+
+// Target is the user account a policy is evaluated against.
+type Target struct {
+	ID             uuid.UUID
+	Role           types.UserType
+	SubscriptionID *uuid.UUID
+}
+
+// SubjectFrom builds a subject from the user stored by the auth middleware.
+func SubjectFrom(u *middleware.User) Subject {
+	if u == nil {
+		return Subject{}
+	}
+	permissions := make([]Permission, len(u.Permissions))
+	for i, p := range u.Permissions {
+		permissions[i] = Permission(p)
+	}
+	return Subject{ID: u.ID, Role: u.UserType, SubscriptionID: u.SubscriptionID, Permissions: permissions}
+}
+
+// Rank returns the position of a role in the hierarchy, or -1 for unknown roles.
+func Rank(role types.UserType) int {
+	for i, r := range roleOrder {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// Outranks reports whether actor sits strictly above target in the hierarchy.
+func Outranks(actor, target types.UserType) bool {
+	actorRank, targetRank := Rank(actor), Rank(target)
+	if actorRank == -1 || targetRank == -1 {
+		return false
+	}
+	return targetRank < actorRank
+}
+
+// RolesBelow returns every role the given role outranks.
+func RolesBelow(role types.UserType) []types.UserType {
+	rank := Rank(role)
+	if rank <= 0 {
+		return []types.UserType{}
+	}
+	roles := make([]types.UserType, rank)
+	copy(roles, roleOrder[:rank])
+	return roles
+}
+
+// IsPlatformAdmin reports whether the role administers the whole platform.
+func IsPlatformAdmin(role types.UserType) bool {
+	return role == types.UserTypeAdmin || role == types.UserTypeSuperAdmin
+}
+
+// IsSubscriptionStaff reports whether the role runs a single subscription.
+func IsSubscriptionStaff(role types.UserType) bool {
+	return role == types.UserTypeInstructor || role == types.UserTypeAssistant
+}
+
+// IsStaff reports whether the role may moderate and manage subscription content.
+func IsStaff(role types.UserType) bool {
+	return IsSubscriptionStaff(role) || IsPlatformAdmin(role)
+}
+
+// InSubscription reports whether the subject belongs to the given subscription.
+func (s Subject) InSubscription(subscriptionID *uuid.UUID) bool {
+	return s.SubscriptionID != nil && subscriptionID != nil && *s.SubscriptionID == *subscriptionID
+}
+
+// CanView reports whether the subject may read the target's account.
+func CanView(s Subject, t Target) bool {
+	switch {
+	case s.ID == t.ID:
+		return true
+	case IsPlatformAdmin(s.Role):
+		return true
+	case IsSubscriptionStaff(s.Role):
+		return s.InSubscription(t.SubscriptionID)
+	default:
+		return false
+	}
+}
+
+// CanManage reports whether the subject may modify or delete the target's account.
+// Everyone may manage themselves; otherwise admins manage anyone below superadmin and
+// subscription staff manage lower roles within their own subscription.
+func CanManage(s Subject, t Target) bool {
+	switch {
+	case s.Role == types.UserTypeSuperAdmin:
+		return true
+	case s.ID == t.ID:
+		return true
+	case IsPlatformAdmin(t.Role):
+		return false
+	case s.Role == types.UserTypeAdmin:
+		return true
+	case IsSubscriptionStaff(s.Role):
+		return s.InSubscription(t.SubscriptionID) && Outranks(s.Role, t.Role)
+	default:
+		return false
+	}
+}
+
+// CanDelete reports whether the subject may delete the target's account. It follows
+// CanManage except that only students and superadmins may delete themselves, so staff
+// cannot orphan the subscription or platform they run.
+func CanDelete(s Subject, t Target) bool {
+	if s.ID == t.ID {
+		return s.Role == types.UserTypeStudent || s.Role == types.UserTypeSuperAdmin
+	}
+	return CanManage(s, t)
+}
+
+// CanAssignRole reports whether the subject may move the target to the given role.
+// Nobody but a superadmin may change their own role.
+func CanAssignRole(s Subject, t Target, role types.UserType) bool {
+	if s.Role == types.UserTypeSuperAdmin {
+		return Rank(role) != -1
+	}
+	if s.ID == t.ID {
+		return t.Role == role
+	}
+	return CanManage(s, t) && Outranks(s.Role, t.Role) && Outranks(s.Role, role)
+}
+
+// CanCreate reports whether the subject may create an account with the given role.
+func CanCreate(s Subject, role types.UserType) bool {
+	if s.Role == types.UserTypeSuperAdmin {
+		return Rank(role) != -1
+	}
+	return IsStaff(s.Role) && Outranks(s.Role, role)
+}
+
+// CanChangeSubscription reports whether the subject may move accounts between subscriptions.
+func CanChangeSubscription(s Subject) bool {
+	return IsPlatformAdmin(s.Role)
+}
+
+// CanModerate reports whether the subject may moderate content authored by others.
+func CanModerate(s Subject) bool {
+	return Can(s, PermContentModerate)
+}
+
+// CanControlSession reports whether the subject may control a live session hosted by hostID.
+func CanControlSession(s Subject, hostID string) bool {
+	return s.ID.String() == hostID || IsPlatformAdmin(s.Role)
+}
+
+// ScopeQuery restricts a query over a subscription-owned table to rows the subject may
+// see. Platform admins are unrestricted; everyone else is limited to their subscription.
+// The scoped query is a new session, so several queries may be built from it.
+func ScopeQuery(s Subject, query *gorm.DB, column string) *gorm.DB {
+	if IsPlatformAdmin(s.Role) {
+		return query
+	}
+	if s.SubscriptionID == nil {
+		return query.Where("1 = 0").Session(&gorm.Session{})
+	}
+	return query.Where(column+" = ?", *s.SubscriptionID).Session(&gorm.Session{})
+}
@@ -0,0 +1,300 @@
+package authz
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	ref = types.UserTypeReferrer
+	stu = types.UserTypeStudent
+	ast = types.UserTypeAssistant
+	ins = types.UserTypeInstructor
+	adm = types.UserTypeAdmin
+	sup = types.UserTypeSuperAdmin
+)
+
+var allRoles = []types.UserType{ref, stu, ast, ins, adm, sup}
+
+// pair builds a subject and a distinct target holding the given roles. The target
+// shares the subject's subscription unless sameSubscription is false.
+func pair(subjectRole, targetRole types.UserType, sameSubscription bool) (Subject, Target) {
+	subjectSub, targetSub := uuid.New(), uuid.New()
+	if sameSubscription {
+		targetSub = subjectSub
+	}
+	return Subject{ID: uuid.New(), Role: subjectRole, SubscriptionID: &subjectSub},
+		Target{ID: uuid.New(), Role: targetRole, SubscriptionID: &targetSub}
+}
+
+// self builds a subject and the target describing that same account.
+func self(role types.UserType) (Subject, Target) {
+	sub := uuid.New()
+	id := uuid.New()
+	return Subject{ID: id, Role: role, SubscriptionID: &sub}, Target{ID: id, Role: role, SubscriptionID: &sub}
+}
+
+func TestCanView(t *testing.T) {
+	tests := []struct {
+		name             string
+		sameSubscription bool
+		allowed          map[types.UserType][]types.UserType
+	}{
+		{
+			name:             "same subscription",
+			sameSubscription: true,
+			allowed: map[types.UserType][]types.UserType{
+				ast: allRoles,
+				ins: allRoles,
+				adm: allRoles,
+				sup: allRoles,
+			},
+		},
+		{
+			name:             "other subscription",
+			sameSubscription: false,
+			allowed: map[types.UserType][]types.UserType{
+				adm: allRoles,
+				sup: allRoles,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, subjectRole := range allRoles {
+			for _, targetRole := range allRoles {
+				s, target := pair(subjectRole, targetRole, tt.sameSubscription)
+				want := slices.Contains(tt.allowed[subjectRole], targetRole)
+				if got := CanView(s, target); got != want {
+					t.Errorf("%s: CanView(%s, %s) = %v, want %v", tt.name, subjectRole, targetRole, got, want)
+				}
+			}
+		}
+	}
+
+	for _, role := range allRoles {
+		if s, target := self(role); !CanView(s, target) {
+			t.Errorf("CanView(%s, self) = false, want true", role)
+		}
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	tests := []struct {
+		name             string
+		sameSubscription bool
+		allowed          map[types.UserType][]types.UserType
+	}{
+		{
+			name:             "same subscription",
+			sameSubscription: true,
+			allowed: map[types.UserType][]types.UserType{
+				ast: {ref, stu},
+				ins: {ref, stu, ast},
+				adm: {ref, stu, ast, ins},
+				sup: allRoles,
+			},
+		},
+		{
+			name:             "other subscription",
+			sameSubscription: false,
+			allowed: map[types.UserType][]types.UserType{
+				adm: {ref, stu, ast, ins},
+				sup: allRoles,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, subjectRole := range allRoles {
+			for _, targetRole := range allRoles {
+				s, target := pair(subjectRole, targetRole, tt.sameSubscription)
+				want := slices.Contains(tt.allowed[subjectRole], targetRole)
+				if got := CanManage(s, target); got != want {
+					t.Errorf("%s: CanManage(%s, %s) = %v, want %v", tt.name, subjectRole, targetRole, got, want)
+				}
+			}
+		}
+	}
+
+	for _, role := range allRoles {
+		if s, target := self(role); !CanManage(s, target) {
+			t.Errorf("CanManage(%s, self) = false, want true", role)
+		}
+	}
+}
+
+func TestCanDelete(t *testing.T) {
+	tests := []struct {
+		name             string
+		sameSubscription bool
+		allowed          map[types.UserType][]types.UserType
+	}{
+		{
+			name:             "same subscription",
+			sameSubscription: true,
+			allowed: map[types.UserType][]types.UserType{
+				ast: {ref, stu},
+				ins: {ref, stu, ast},
+				adm: {ref, stu, ast, ins},
+				sup: allRoles,
+			},
+		},
+		{
+			name:             "other subscription",
+			sameSubscription: false,
+			allowed: map[types.UserType][]types.UserType{
+				adm: {ref, stu, ast, ins},
+				sup: allRoles,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, subjectRole := range allRoles {
+			for _, targetRole := range allRoles {
+				s, target := pair(subjectRole, targetRole, tt.sameSubscription)
+				want := slices.Contains(tt.allowed[subjectRole], targetRole)
+				if got := CanDelete(s, target); got != want {
+					t.Errorf("%s: CanDelete(%s, %s) = %v, want %v", tt.name, subjectRole, targetRole, got, want)
+				}
+			}
+		}
+	}
+
+	selfDelete := []types.UserType{stu, sup}
+	for _, role := range allRoles {
+		s, target := self(role)
+		want := slices.Contains(selfDelete, role)
+		if got := CanDelete(s, target); got != want {
+			t.Errorf("CanDelete(%s, self) = %v, want %v", role, got, want)
+		}
+	}
+}
+
+func TestCanAssignRole(t *testing.T) {
+	// Each subject may move the listed targets to any of the listed roles.
+	type grant struct {
+		targets []types.UserType
+		roles   []types.UserType
+	}
+
+	tests := []struct {
+		name             string
+		sameSubscription bool
+		allowed          map[types.UserType]grant
+	}{
+		{
+			name:             "same subscription",
+			sameSubscription: true,
+			allowed: map[types.UserType]grant{
+				ast: {targets: []types.UserType{ref, stu}, roles: []types.UserType{ref, stu}},
+				ins: {targets: []types.UserType{ref, stu, ast}, roles: []types.UserType{ref, stu, ast}},
+				adm: {targets: []types.UserType{ref, stu, ast, ins}, roles: []types.UserType{ref, stu, ast, ins}},
+				sup: {targets: allRoles, roles: allRoles},
+			},
+		},
+		{
+			name:             "other subscription",
+			sameSubscription: false,
+			allowed: map[types.UserType]grant{
+				adm: {targets: []types.UserType{ref, stu, ast, ins}, roles: []types.UserType{ref, stu, ast, ins}},
+				sup: {targets: allRoles, roles: allRoles},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, subjectRole := range allRoles {
+			for _, targetRole := range allRoles {
+				for _, role := range allRoles {
+					s, target := pair(subjectRole, targetRole, tt.sameSubscription)
+					g := tt.allowed[subjectRole]
+					want := slices.Contains(g.targets, targetRole) && slices.Contains(g.roles, role)
+					if got := CanAssignRole(s, target, role); got != want {
+						t.Errorf("%s: CanAssignRole(%s, %s, %s) = %v, want %v",
+							tt.name, subjectRole, targetRole, role, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	for _, subjectRole := range allRoles {
+		for _, role := range allRoles {
+			s, target := self(subjectRole)
+			want := subjectRole == sup || role == subjectRole
+			if got := CanAssignRole(s, target, role); got != want {
+				t.Errorf("CanAssignRole(%s, self, %s) = %v, want %v", subjectRole, role, got, want)
+			}
+		}
+	}
+
+	s, target := pair(sup, stu, true)
+	if CanAssignRole(s, target, types.UserType("owner")) {
+		t.Error("CanAssignRole accepted an unknown role")
+	}
+}
+
+func TestCanCreate(t *testing.T) {
+	allowed := map[types.UserType][]types.UserType{
+		ast: {ref, stu},
+		ins: {ref, stu, ast},
+		adm: {ref, stu, ast, ins},
+		sup: allRoles,
+	}
+
+	for _, subjectRole := range allRoles {
+		for _, role := range allRoles {
+			s, _ := self(subjectRole)
+			want := slices.Contains(allowed[subjectRole], role)
+			if got := CanCreate(s, role); got != want {
+				t.Errorf("CanCreate(%s, %s) = %v, want %v", subjectRole, role, got, want)
+			}
+		}
+	}
+}
+
+func TestScopeQuery(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+
+	sub := uuid.New()
+	tests := []struct {
+		name    string
+		subject Subject
+		where   string
+	}{
+		{name: "superadmin", subject: Subject{Role: sup, SubscriptionID: &sub}, where: ""},
+		{name: "admin", subject: Subject{Role: adm}, where: ""},
+		{name: "instructor", subject: Subject{Role: ins, SubscriptionID: &sub}, where: "WHERE courses.subscription_id = $1"},
+		{name: "student", subject: Subject{Role: stu, SubscriptionID: &sub}, where: "WHERE courses.subscription_id = $1"},
+		{name: "no subscription", subject: Subject{Role: ast}, where: "WHERE 1 = 0"},
+	}
+
+	for _, tt := range tests {
+		stmt := ScopeQuery(tt.subject, db.Table("courses"), "courses.subscription_id").Find(&[]map[string]interface{}{}).Statement
+		sql := stmt.SQL.String()
+		if tt.where == "" {
+			if strings.Contains(sql, "WHERE") {
+				t.Errorf("%s: got %q, want no WHERE clause", tt.name, sql)
+			}
+			continue
+		}
+		if !strings.Contains(sql, tt.where) {
+			t.Errorf("%s: got %q, want %q", tt.name, sql, tt.where)
+		}
+		if strings.Contains(tt.where, "$1") && (len(stmt.Vars) != 1 || stmt.Vars[0] != sub) {
+			t.Errorf("%s: vars = %v, want [%s]", tt.name, stmt.Vars, sub)
+		}
+	}
+}
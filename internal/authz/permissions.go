@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Permission is a granular capability that custom subscription roles can grant.
+type Permission string
+
+const (
+	// PermContentManage allows creating and editing courses, lessons, chapters and attachments.
+	PermContentManage Permission = "content.manage"
+	// PermContentModerate allows removing threads, replies and comments written by others.
+	PermContentModerate Permission = "content.moderate"
+	// PermReportsView allows reading watch reports of other users.
+	PermReportsView Permission = "reports.view"
+	// PermContentPublish allows approving courses and lessons for students.
+	PermContentPublish Permission = "content.publish"
+)
+
+// Permissions lists every permission a custom role may grant.
+var Permissions = []Permission{
+	PermContentManage,
+	PermContentModerate,
+	PermReportsView,
+	PermContentPublish,
+}
+
+// ValidPermission reports whether p is a known permission.
+func ValidPermission(p Permission) bool {
+	for _, known := range Permissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the subject holds the permission, either through its built-in
+// role (staff hold every permission) or through its custom subscription role.
+func Can(s Subject, p Permission) bool {
+	if IsStaff(s.Role) {
+		return true
+	}
+	if s.Role == types.UserTypeReferrer {
+		return false
+	}
+	for _, granted := range s.Permissions {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CanPublish reports whether the subject may publish content to students.
+// Assistants manage content but submit it for review, so unlike the other
+// permissions this one is not implied by every staff role.
+func CanPublish(s Subject) bool {
+	if s.Role == types.UserTypeAssistant {
+		return false
+	}
+	return Can(s, PermContentPublish)
+}
+
+// Require aborts the request unless the authenticated user holds the permission.
+// It must run after the auth middleware has loaded the user.
+func Require(p Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usr, ok := middleware.GetUserFromContext(c)
+		if !ok {
+			response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+			c.Abort()
+			return
+		}
+
+		if !Can(SubjectFrom(usr), p) {
+			response.Error(c, http.StatusForbidden, "Access denied: Insufficient permissions.", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// WithPermission returns a copy of the middleware chain extended with Require(p).
+func WithPermission(chain []gin.HandlerFunc, p Permission) []gin.HandlerFunc {
+	handlers := make([]gin.HandlerFunc, 0, len(chain)+1)
+	handlers = append(handlers, chain...)
+	return append(handlers, Require(p))
+}
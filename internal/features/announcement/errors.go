@@ -1,8 +1,25 @@
 package announcement
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
-	ErrAnnouncementNotFound = errors.New("announcement not found")
-	ErrTitleRequired        = errors.New("announcement title is required")
+	ErrAnnouncementNotFound  = errors.New("announcement not found")
+	ErrTitleRequired         = errors.New("announcement title is required")
+	ErrInvalidTargetCourse   = errors.New("target course does not belong to subscription")
+	ErrInvalidTargetUser     = errors.New("target user does not belong to subscription")
+	ErrInvalidTargetUserType = errors.New("invalid target user type")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrAnnouncementNotFound, Status: http.StatusNotFound, Code: "ANNOUNCEMENT_NOT_FOUND", Message: "Announcement not found."},
+	{Err: ErrTitleRequired, Status: http.StatusBadRequest, Code: "ANNOUNCEMENT_TITLE_REQUIRED", Message: "Announcement title is required."},
+	{Err: ErrInvalidTargetCourse, Status: http.StatusBadRequest, Code: "INVALID_TARGET_COURSE", Message: "Target courses must belong to this subscription."},
+	{Err: ErrInvalidTargetUser, Status: http.StatusBadRequest, Code: "INVALID_TARGET_USER", Message: "Target users must belong to this subscription."},
+	{Err: ErrInvalidTargetUserType, Status: http.StatusBadRequest, Code: "INVALID_TARGET_USER_TYPE", Message: "Target user types must be student, assistant or instructor."},
+}
@@ -1,7 +1,7 @@
 package announcement
 
 import (
-	"errors"
+	"context"
 	"net/http"
 
 	"log/slog"
@@ -11,16 +11,23 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/eventbus"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
+// TopicPublished is published on the event bus, addressed to the audience of
+// an announcement, when it is published.
+const TopicPublished = "announcement.published"
+
 // Handler processes announcement HTTP requests.
 type Handler struct {
 	db     *gorm.DB
 	logger *slog.Logger
+	events *eventbus.Bus
 }
 
 // NewHandler constructs an announcement handler instance.
@@ -28,6 +35,11 @@ func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
 	return &Handler{db: db, logger: logger}
 }
 
+// UseEvents sets the bus published announcements are announced on.
+func (h *Handler) UseEvents(events *eventbus.Bus) {
+	h.events = events
+}
+
 // List returns paginated announcements for a subscription.
 func (h *Handler) List(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -53,6 +65,7 @@ func (h *Handler) List(c *gin.Context) {
 	// For students, add role-based filtering
 	if usr.UserType == types.UserTypeStudent {
 		filters.UserID = &usr.ID
+		filters.UserType = usr.UserType
 	}
 
 	announcements, total, err := List(h.db, filters, params)
@@ -62,9 +75,30 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	parts := []interface{}{c.Request.URL.RawQuery, total}
+	for _, announcement := range announcements {
+		parts = append(parts, announcement.ID, announcement.UpdatedAt)
+	}
+	if response.NotModified(c, response.ETag(parts...)) {
+		return
+	}
+
 	response.Success(c, http.StatusOK, announcements, "", pagination.MetadataFrom(total, params))
 }
 
+type createRequest struct {
+	Title    string  `json:"title" binding:"required,notblank"`
+	Content  *string `json:"content"`
+	ImageURL *string `json:"imageUrl"`
+	OnClick  *string `json:"onClick"`
+	Public   *bool   `json:"isPublic"`
+	Active   *bool   `json:"isActive"`
+
+	TargetCourseIDs []string `json:"targetCourseIds"`
+	TargetUserTypes []string `json:"targetUserTypes"`
+	TargetUserIDs   []string `json:"targetUserIds" binding:"max=1000"`
+}
+
 // Create inserts a new announcement.
 func (h *Handler) Create(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -73,22 +107,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Title    string  `json:"title"`
-		Content  *string `json:"content"`
-		ImageURL *string `json:"imageUrl"`
-		OnClick  *string `json:"onClick"`
-		Public   *bool   `json:"isPublic"`
-		Active   *bool   `json:"isActive"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid announcement payload", err)
-		return
-	}
-
-	if req.Title == "" {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Title is required", nil)
+	if !request.BindJSON(h.logger, c, &req, "invalid announcement payload") {
 		return
 	}
 
@@ -100,6 +121,11 @@ func (h *Handler) Create(c *gin.Context) {
 		OnClick:        req.OnClick,
 		Public:         req.Public,
 		Active:         req.Active,
+		Targets: Targets{
+			CourseIDs: req.TargetCourseIDs,
+			UserTypes: req.TargetUserTypes,
+			UserIDs:   req.TargetUserIDs,
+		},
 	})
 
 	if err != nil {
@@ -107,6 +133,10 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	if announcement.Active {
+		h.publish(c.Request.Context(), announcement)
+	}
+
 	response.Created(c, announcement, "")
 }
 
@@ -124,9 +154,26 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if response.NotModified(c, response.ETag(announcement.ID, announcement.UpdatedAt)) {
+		return
+	}
+
 	response.Success(c, http.StatusOK, announcement, "", nil)
 }
 
+type updateRequest struct {
+	Title    *string                     `json:"title" binding:"omitnil,notblank"`
+	Content  validation.Optional[string] `json:"content" binding:"omitnil,notblank"`
+	ImageURL validation.Optional[string] `json:"imageUrl" binding:"omitnil,notblank"`
+	OnClick  validation.Optional[string] `json:"onClick" binding:"omitnil,notblank"`
+	Public   *bool                       `json:"isPublic"`
+	Active   *bool                       `json:"isActive"`
+
+	TargetCourseIDs validation.Optional[[]string] `json:"targetCourseIds"`
+	TargetUserTypes validation.Optional[[]string] `json:"targetUserTypes"`
+	TargetUserIDs   validation.Optional[[]string] `json:"targetUserIds"`
+}
+
 // Update modifies an existing announcement.
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("announcementId"))
@@ -135,75 +182,47 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid announcement payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid announcement payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["title"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "title must be a string", err)
-			return
-		}
-		input.Title = &str
-	}
-
-	if value, ok := body["content"]; ok {
-		input.ContentProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "content must be a string", err)
-				return
-			}
-			input.Content = &str
-		}
-	}
-
-	if value, ok := body["imageUrl"]; ok {
-		input.ImageProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "imageUrl must be a string", err)
-				return
-			}
-			input.ImageURL = &str
-		}
+	input := UpdateInput{
+		Title:           request.Trimmed(req.Title),
+		ContentProvided: req.Content.Set,
+		Content:         request.Trimmed(req.Content.Ptr()),
+		ImageProvided:   req.ImageURL.Set,
+		ImageURL:        request.Trimmed(req.ImageURL.Ptr()),
+		OnClickProvided: req.OnClick.Set,
+		OnClick:         request.Trimmed(req.OnClick.Ptr()),
+		Public:          req.Public,
+		Active:          req.Active,
 	}
 
-	if value, ok := body["onClick"]; ok {
-		input.OnClickProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "onClick must be a string", err)
-				return
-			}
-			input.OnClick = &str
+	// A null target list clears it, like an empty one.
+	for _, field := range []struct {
+		value  validation.Optional[[]string]
+		target **[]string
+	}{
+		{req.TargetCourseIDs, &input.TargetCourseIDs},
+		{req.TargetUserTypes, &input.TargetUserTypes},
+		{req.TargetUserIDs, &input.TargetUserIDs},
+	} {
+		if field.value.Set {
+			values := request.TrimStrings(field.value.Value)
+			*field.target = &values
 		}
 	}
 
-	if value, ok := body["isPublic"]; ok {
-		val, err := request.ReadBool(value)
+	// Activating a hidden announcement publishes it
+	wasActive := true
+	if req.Active != nil && *req.Active {
+		current, err := Get(h.db, id)
 		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isPublic must be boolean", err)
+			h.respondError(c, err, "failed to load announcement")
 			return
 		}
-		input.Public = &val
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
+		wasActive = current.Active
 	}
 
 	announcement, err := Update(h.db, id, input)
@@ -212,6 +231,10 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
+	if !wasActive && announcement.Active {
+		h.publish(c.Request.Context(), announcement)
+	}
+
 	response.Success(c, http.StatusOK, announcement, "", nil)
 }
 
@@ -231,20 +254,26 @@ func (h *Handler) Delete(c *gin.Context) {
 	response.Success(c, http.StatusOK, true, "", nil)
 }
 
-func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrAnnouncementNotFound):
-		status = http.StatusNotFound
-		message = "Announcement not found."
-	case errors.Is(err, ErrTitleRequired):
-		status = http.StatusBadRequest
-		message = "Announcement title is required."
+// publish announces the announcement to everyone who can see it. Failures are
+// logged; clients still find it when they next list announcements.
+func (h *Handler) publish(ctx context.Context, announcement Announcement) {
+	if h.events == nil {
+		return
 	}
 
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	audience, err := Audience(h.db, announcement)
+	if err != nil {
+		h.logger.Error("failed to load announcement audience", slog.String("announcementId", announcement.ID.String()), slog.String("error", err.Error()))
+		return
+	}
+	h.events.Publish(ctx, eventbus.Event{
+		Topic:          TopicPublished,
+		SubscriptionID: announcement.SubscriptionID,
+		Users:          audience,
+		Payload:        announcement,
+	})
 }
 
-
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -2,6 +2,7 @@ package announcement
 
 import (
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
@@ -19,6 +20,12 @@ type Announcement struct {
 	OnClick        *string   `gorm:"type:varchar(255);column:on_click" json:"onClick,omitempty"`
 	Public         bool      `gorm:"type:boolean;not null;default:true;column:is_public" json:"isPublic"`
 	Active         bool      `gorm:"type:boolean;not null;default:true;column:is_active;index;index:idx_subscription_active,priority:2" json:"isActive"`
+
+	// Targets narrow who sees the announcement. When any target is set the
+	// announcement is shown only to matching users, regardless of isPublic.
+	TargetCourseIDs pq.StringArray `gorm:"type:uuid[];not null;default:'{}';column:target_course_ids" json:"targetCourseIds"`
+	TargetUserTypes pq.StringArray `gorm:"type:text[];not null;default:'{}';column:target_user_types" json:"targetUserTypes"`
+	TargetUserIDs   pq.StringArray `gorm:"type:uuid[];not null;default:'{}';column:target_user_ids" json:"targetUserIds"`
 }
 
 // TableName overrides the default table name.
@@ -29,7 +36,15 @@ type ListFilters struct {
 	SubscriptionID uuid.UUID
 	ActiveOnly     bool
 	PublicOnly     bool
-	UserID         *uuid.UUID // For filtering by group access
+	UserID         *uuid.UUID // For filtering by group access and targets
+	UserType       types.UserType
+}
+
+// Targets lists the audiences an announcement is restricted to.
+type Targets struct {
+	CourseIDs []string
+	UserTypes []string
+	UserIDs   []string
 }
 
 // CreateInput carries data for creating a new announcement.
@@ -41,6 +56,7 @@ type CreateInput struct {
 	OnClick        *string
 	Public         *bool
 	Active         *bool
+	Targets        Targets
 }
 
 // UpdateInput captures mutable announcement fields.
@@ -54,6 +70,75 @@ type UpdateInput struct {
 	OnClickProvided bool
 	Public          *bool
 	Active          *bool
+	TargetCourseIDs *[]string
+	TargetUserTypes *[]string
+	TargetUserIDs   *[]string
+}
+
+// targetableUserTypes are the subscription roles an announcement may target.
+var targetableUserTypes = map[types.UserType]bool{
+	types.UserTypeStudent:    true,
+	types.UserTypeAssistant:  true,
+	types.UserTypeInstructor: true,
+}
+
+// VisibleTo restricts query to the announcements a subscription member may
+// see: untargeted public announcements, announcements granted through one of
+// their access groups, and announcements targeting them directly, their user
+// type, or a course they can access.
+func VisibleTo(query *gorm.DB, subscriptionID, userID uuid.UUID, userType types.UserType) *gorm.DB {
+	uid := userID.String()
+	return query.Where(`(
+		id IN (SELECT UNNEST(announcements) FROM group_access WHERE subscription_id = ? AND ? = ANY(users))
+		OR (is_public = TRUE AND cardinality(target_course_ids) = 0 AND cardinality(target_user_types) = 0 AND cardinality(target_user_ids) = 0)
+		OR ?::uuid = ANY(target_user_ids)
+		OR ? = ANY(target_user_types)
+		OR target_course_ids && ARRAY(
+			SELECT UNNEST(courses) FROM group_access WHERE subscription_id = ? AND ? = ANY(users)
+			UNION
+			SELECT course_id FROM lessons WHERE id IN (SELECT UNNEST(lessons) FROM group_access WHERE subscription_id = ? AND ? = ANY(users))
+		)`,
+		subscriptionID, uid,
+		uid,
+		string(userType),
+		subscriptionID, uid,
+		subscriptionID, uid,
+	)
+}
+
+// Audience returns the active members of the subscription who can see the
+// announcement: staff see every announcement, students those VisibleTo lets
+// through.
+func Audience(db *gorm.DB, announcement Announcement) ([]uuid.UUID, error) {
+	untargeted := len(announcement.TargetCourseIDs) == 0 && len(announcement.TargetUserTypes) == 0 && len(announcement.TargetUserIDs) == 0
+
+	var ids []uuid.UUID
+	err := db.Table("users AS u").
+		Where("u.subscription_id = ? AND u.is_active = TRUE AND u.anonymized_at IS NULL", announcement.SubscriptionID).
+		Where(`(
+			u.user_type <> ?
+			OR ?
+			OR u.id = ANY(?::uuid[])
+			OR u.user_type = ANY(?::text[])
+			OR EXISTS (
+				SELECT 1 FROM group_access g
+				WHERE g.subscription_id = u.subscription_id AND u.id = ANY(g.users) AND (
+					?::uuid = ANY(g.announcements)
+					OR g.courses && ?::uuid[]
+					OR EXISTS (SELECT 1 FROM lessons l WHERE l.id = ANY(g.lessons) AND l.course_id = ANY(?::uuid[]))
+				)
+			)
+		)`,
+			types.UserTypeStudent,
+			announcement.Public && untargeted,
+			announcement.TargetUserIDs,
+			announcement.TargetUserTypes,
+			announcement.ID,
+			announcement.TargetCourseIDs,
+			announcement.TargetCourseIDs,
+		).
+		Pluck("u.id", &ids).Error
+	return ids, err
 }
 
 // List retrieves paginated announcements with filters.
@@ -68,25 +153,9 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Announc
 		query = query.Where("is_public = ?", true)
 	}
 
-	// For students, filter by public announcements or group access
+	// For students, filter by public announcements, group access and targets
 	if filters.UserID != nil {
-		// Get announcement IDs from group access for this user
-		var groupAnnouncementIDs []string
-		err := db.Table("group_access").
-			Where("subscription_id = ? AND ? = ANY(users)", filters.SubscriptionID, filters.UserID.String()).
-			Select("UNNEST(announcements) as announcement_id").
-			Pluck("announcement_id", &groupAnnouncementIDs).Error
-
-		if err != nil && err != gorm.ErrRecordNotFound {
-			return nil, 0, err
-		}
-
-		// Show public announcements OR announcements user has access to via groups
-		if len(groupAnnouncementIDs) > 0 {
-			query = query.Where("is_public = ? OR id IN ?", true, groupAnnouncementIDs)
-		} else {
-			query = query.Where("is_public = ?", true)
-		}
+		query = VisibleTo(query, filters.SubscriptionID, *filters.UserID, filters.UserType)
 	}
 
 	var total int64
@@ -142,6 +211,14 @@ func Create(db *gorm.DB, input CreateInput) (Announcement, error) {
 		Active:         active,
 	}
 
+	targets, err := normalizeTargets(db, input.SubscriptionID, input.Targets)
+	if err != nil {
+		return Announcement{}, err
+	}
+	announcement.TargetCourseIDs = pq.StringArray(targets.CourseIDs)
+	announcement.TargetUserTypes = pq.StringArray(targets.UserTypes)
+	announcement.TargetUserIDs = pq.StringArray(targets.UserIDs)
+
 	if err := db.Create(&announcement).Error; err != nil {
 		return Announcement{}, err
 	}
@@ -183,6 +260,31 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Announcement, error)
 		announcement.Active = *input.Active
 	}
 
+	if input.TargetCourseIDs != nil || input.TargetUserTypes != nil || input.TargetUserIDs != nil {
+		targets := Targets{
+			CourseIDs: announcement.TargetCourseIDs,
+			UserTypes: announcement.TargetUserTypes,
+			UserIDs:   announcement.TargetUserIDs,
+		}
+		if input.TargetCourseIDs != nil {
+			targets.CourseIDs = *input.TargetCourseIDs
+		}
+		if input.TargetUserTypes != nil {
+			targets.UserTypes = *input.TargetUserTypes
+		}
+		if input.TargetUserIDs != nil {
+			targets.UserIDs = *input.TargetUserIDs
+		}
+
+		normalized, err := normalizeTargets(db, announcement.SubscriptionID, targets)
+		if err != nil {
+			return announcement, err
+		}
+		announcement.TargetCourseIDs = pq.StringArray(normalized.CourseIDs)
+		announcement.TargetUserTypes = pq.StringArray(normalized.UserTypes)
+		announcement.TargetUserIDs = pq.StringArray(normalized.UserIDs)
+	}
+
 	if err := db.Save(&announcement).Error; err != nil {
 		return announcement, err
 	}
@@ -201,3 +303,71 @@ func Delete(db *gorm.DB, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// normalizeTargets deduplicates the targets and checks that every course and
+// user belongs to the subscription.
+func normalizeTargets(db *gorm.DB, subscriptionID uuid.UUID, targets Targets) (Targets, error) {
+	courseIDs, err := uniqueUUIDs(targets.CourseIDs, ErrInvalidTargetCourse)
+	if err != nil {
+		return Targets{}, err
+	}
+	userIDs, err := uniqueUUIDs(targets.UserIDs, ErrInvalidTargetUser)
+	if err != nil {
+		return Targets{}, err
+	}
+
+	userTypes := make([]string, 0, len(targets.UserTypes))
+	seen := make(map[string]bool, len(targets.UserTypes))
+	for _, value := range targets.UserTypes {
+		if !targetableUserTypes[types.UserType(value)] {
+			return Targets{}, ErrInvalidTargetUserType
+		}
+		if !seen[value] {
+			seen[value] = true
+			userTypes = append(userTypes, value)
+		}
+	}
+
+	if len(courseIDs) > 0 {
+		var count int64
+		if err := db.Table("courses").
+			Where("subscription_id = ? AND id IN ?", subscriptionID, courseIDs).
+			Count(&count).Error; err != nil {
+			return Targets{}, err
+		}
+		if int(count) != len(courseIDs) {
+			return Targets{}, ErrInvalidTargetCourse
+		}
+	}
+
+	if len(userIDs) > 0 {
+		var count int64
+		if err := db.Table("users").
+			Where("subscription_id = ? AND id IN ?", subscriptionID, userIDs).
+			Count(&count).Error; err != nil {
+			return Targets{}, err
+		}
+		if int(count) != len(userIDs) {
+			return Targets{}, ErrInvalidTargetUser
+		}
+	}
+
+	return Targets{CourseIDs: courseIDs, UserTypes: userTypes, UserIDs: userIDs}, nil
+}
+
+func uniqueUUIDs(values []string, invalid error) ([]string, error) {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, invalid
+		}
+		key := id.String()
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
@@ -2,6 +2,8 @@ package announcement
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes attaches announcement endpoints to the router.
@@ -13,4 +15,9 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff, a
 	announcements.GET("/:announcementId", append(acAll, handler.GetByID)...)
 	announcements.PUT("/:announcementId", append(acStaff, handler.Update)...)
 	announcements.DELETE("/:announcementId", append(acAdmin, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Announcement{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Announcement{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Announcement{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Announcement{}})
 }
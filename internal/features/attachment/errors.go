@@ -2,7 +2,9 @@ package attachment
 
 import (
 	"errors"
+	"net/http"
 
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
@@ -11,6 +13,7 @@ var (
 	ErrNameRequired       = errors.New("attachment name is required")
 	ErrTypeRequired       = errors.New("attachment type is required")
 	ErrInvalidType        = errors.New("invalid attachment type")
+	ErrLessonNotFound     = errors.New("lesson not found")
 )
 
 // ValidTypes returns all valid attachment types.
@@ -23,3 +26,12 @@ func ValidTypes() []string {
 		string(types.AttachmentTypeLink),
 	}
 }
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrAttachmentNotFound, Status: http.StatusNotFound, Code: "ATTACHMENT_NOT_FOUND", Message: "Attachment not found."},
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrNameRequired, Status: http.StatusBadRequest, Code: "ATTACHMENT_NAME_REQUIRED", Message: "Attachment name is required."},
+	{Err: ErrTypeRequired, Status: http.StatusBadRequest, Code: "ATTACHMENT_TYPE_REQUIRED", Message: "Attachment type is required."},
+	{Err: ErrInvalidType, Status: http.StatusBadRequest, Code: "INVALID_ATTACHMENT_TYPE", Message: "Invalid attachment type."},
+}
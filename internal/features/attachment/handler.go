@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"path/filepath"
@@ -19,26 +20,30 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
 	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
 	"github.com/mo-amir99/lms-server-go/pkg/cleanup"
+	"github.com/mo-amir99/lms-server-go/pkg/filetype"
+	"github.com/mo-amir99/lms-server-go/pkg/imaging"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
-var fileAttachmentTypes = map[string]struct{}{
-	"pdf":   {},
-	"audio": {},
-	"image": {},
-}
-
 // Handler processes attachment HTTP requests.
 type Handler struct {
 	db            *gorm.DB
 	logger        *slog.Logger
 	storageClient *bunny.StorageClient
 	storageUsage  *storageusage.Service
+	uploads       *uploadStore
+	scanner       *scanner
+	notifier      *notification.Service
 }
 
 // NewHandler constructs an attachment handler instance.
@@ -48,6 +53,7 @@ func NewHandler(db *gorm.DB, logger *slog.Logger, storageClient *bunny.StorageCl
 		logger:        logger,
 		storageClient: storageClient,
 		storageUsage:  storageUsage,
+		uploads:       newUploadStore(),
 	}
 }
 
@@ -59,15 +65,43 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	student, err := h.readsAsStudent(c, lessonID)
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return
+	}
+
 	attachments, err := GetByLesson(h.db, lessonID)
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load attachments", err)
 		return
 	}
+	if student {
+		visible := attachments[:0]
+		for _, item := range attachments {
+			if item.Active {
+				visible = append(visible, item)
+			}
+		}
+		attachments = visible
+	}
+
+	for i := range attachments {
+		h.signPath(&attachments[i])
+	}
 
 	response.Success(c, http.StatusOK, attachments, "", nil)
 }
 
+type createRequest struct {
+	Name      string           `json:"name" binding:"required"`
+	Type      string           `json:"type" binding:"required"`
+	Path      *string          `json:"path"`
+	Order     *int             `json:"order"`
+	Active    *bool            `json:"isActive"`
+	Questions *json.RawMessage `json:"questions"`
+}
+
 // Create inserts a new attachment.
 // For file-based attachments (pdf, audio, image), expects multipart/form-data with a 'file' field.
 // For link and mcq attachments, expects application/json.
@@ -100,6 +134,7 @@ func (h *Handler) Create(c *gin.Context) {
 	var order *int
 	var active *bool
 	var questionsJSON *types.JSON
+	var variants types.ImageVariants
 	isFileAttachment := false
 
 	if isMultipart {
@@ -164,10 +199,34 @@ func (h *Handler) Create(c *gin.Context) {
 			}
 			defer file.Close()
 
+			fileContentType, ok := h.checkFile(c, attachmentType, header.Filename, header.Size, seekOpener(file))
+			if !ok {
+				return
+			}
+
+			if !h.checkSubscriptionStorage(c, subscriptionID, float64(header.Size)/(1024*1024*1024)) {
+				return
+			}
+
+			if !h.scanUpload(c, scanTarget{
+				SubscriptionID: subscriptionID,
+				CourseID:       courseID,
+				LessonID:       lessonID,
+				FileName:       header.Filename,
+				Type:           attachmentType,
+				Size:           header.Size,
+			}, seekOpener(file)) {
+				return
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read uploaded file", err)
+				return
+			}
+
 			// Construct remote path
 			folderMap := map[string]string{"pdf": "pdfs", "audio": "audios", "image": "images"}
-			ext := filepath.Ext(header.Filename)
-			randomName := fmt.Sprintf("%d_%d%s", time.Now().Unix(), time.Now().Nanosecond(), ext)
+			ext := strings.ToLower(filepath.Ext(header.Filename))
+			randomName := fmt.Sprintf("%d_%d", time.Now().Unix(), time.Now().Nanosecond())
 			identifier := strings.TrimSpace(storageMeta.IdentifierName)
 			if identifier == "" {
 				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "subscription identifier is missing", nil)
@@ -177,14 +236,23 @@ func (h *Handler) Create(c *gin.Context) {
 			remotePath := fmt.Sprintf("%s/%s/attachments/%s/%s",
 				identifier, courseIDStr, folderMap[attachmentType], randomName)
 
-			// Upload to Bunny Storage
-			cdnURL, err := h.storageClient.UploadStream(c.Request.Context(), remotePath, file, header.Header.Get("Content-Type"))
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to upload to CDN", err)
-				return
-			}
+			if attachmentType == "image" {
+				cdnURL, imageVariants, ok := h.uploadImage(c, file, remotePath)
+				if !ok {
+					return
+				}
+				path = &cdnURL
+				variants = imageVariants
+			} else {
+				// Upload to Bunny Storage
+				cdnURL, err := h.storageClient.UploadStream(c.Request.Context(), remotePath+ext, file, fileContentType)
+				if err != nil {
+					response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to upload to CDN", err)
+					return
+				}
 
-			path = &cdnURL
+				path = &cdnURL
+			}
 
 		} else if attachmentType == "link" {
 			// For link type, path should be in form data
@@ -199,17 +267,9 @@ func (h *Handler) Create(c *gin.Context) {
 
 	} else {
 		// Parse JSON (for link and mcq types without files)
-		var req struct {
-			Name      string           `json:"name" binding:"required"`
-			Type      string           `json:"type" binding:"required"`
-			Path      *string          `json:"path"`
-			Order     *int             `json:"order"`
-			Active    *bool            `json:"isActive"`
-			Questions *json.RawMessage `json:"questions"`
-		}
+		var req createRequest
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid attachment payload", err)
+		if !request.BindJSON(h.logger, c, &req, "invalid attachment payload") {
 			return
 		}
 
@@ -238,6 +298,7 @@ func (h *Handler) Create(c *gin.Context) {
 		Order:     order,
 		Active:    active,
 		Questions: questionsJSON,
+		Variants:  variants,
 	})
 
 	if err != nil {
@@ -245,7 +306,7 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Exec(`UPDATE lessons SET attachments = array_append(COALESCE(attachments, '{}'::uuid[]), ?) WHERE id = ?`, attachment.ID, lessonID).Error; err != nil {
+	if err := h.db.Exec(`UPDATE lessons SET attachments = array_append(COALESCE(attachments, '{}'::uuid[]), ?), updated_at = NOW() WHERE id = ?`, attachment.ID, lessonID).Error; err != nil {
 		h.logger.Error("failed to append attachment id to lesson", "lessonId", lessonID, "attachmentId", attachment.ID, "error", err)
 	}
 
@@ -253,6 +314,7 @@ func (h *Handler) Create(c *gin.Context) {
 		h.refreshCourseStorage(c.Request.Context(), courseID)
 	}
 
+	h.signPath(&attachment)
 	response.Created(c, attachment, "")
 }
 
@@ -270,9 +332,28 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
+	student, err := h.readsAsStudent(c, attachment.LessonID)
+	if err == nil && student && !attachment.Active {
+		err = ErrAttachmentNotFound
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to load attachment")
+		return
+	}
+
+	h.signPath(&attachment)
 	response.Success(c, http.StatusOK, attachment, "", nil)
 }
 
+type updateRequest struct {
+	Name      *string                              `json:"name" binding:"omitnil,notblank"`
+	Type      *string                              `json:"type" binding:"omitnil,notblank"`
+	Path      validation.Optional[string]          `json:"path" binding:"omitnil,notblank"`
+	Order     validation.Optional[int]             `json:"order"`
+	Active    *bool                                `json:"isActive"`
+	Questions validation.Optional[json.RawMessage] `json:"questions"`
+}
+
 // Update modifies an existing attachment.
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("attachmentId"))
@@ -281,69 +362,25 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid attachment payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid attachment payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["name"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "name must be a string", err)
-			return
-		}
-		input.Name = &str
-	}
-
-	if value, ok := body["type"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "type must be a string", err)
-			return
-		}
-		input.Type = &str
-	}
-
-	if value, ok := body["path"]; ok {
-		input.PathProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "path must be a string", err)
-				return
-			}
-			input.Path = &str
-		}
-	}
-
-	if value, ok := body["order"]; ok {
-		input.OrderProvided = true
-		if value != nil {
-			val, err := request.ReadInt(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "order must be an integer", err)
-				return
-			}
-			input.Order = &val
-		}
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
+	input := UpdateInput{
+		Name:          request.Trimmed(req.Name),
+		Type:          request.Trimmed(req.Type),
+		Path:          request.Trimmed(req.Path.Ptr()),
+		PathProvided:  req.Path.Set,
+		Order:         req.Order.Ptr(),
+		OrderProvided: req.Order.Set,
+		Active:        req.Active,
 	}
 
-	if value, ok := body["questions"]; ok {
-		parsed, err := normalizeQuestions(value)
+	if req.Questions.Set {
+		parsed, err := normalizeQuestions(req.Questions.Value)
 		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid questions payload", err)
+			request.RespondInvalid(c, validation.FieldError{Field: "questions", Rule: "json", Message: err.Error()})
 			return
 		}
 		input.QuestionsProvided = true
@@ -356,6 +393,7 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
+	h.signPath(&attachment)
 	response.Success(c, http.StatusOK, attachment, "", nil)
 }
 
@@ -390,38 +428,44 @@ func (h *Handler) Delete(c *gin.Context) {
 	if err := cleanup.DeleteAttachmentFile(c.Request.Context(), h.storageClient, h.logger, id, attachment.Type, attachment.Path, false); err != nil {
 		h.logger.Warn("failed to delete attachment file", "attachmentId", id, "error", err)
 	}
+	cleanup.DeleteImageVariants(c.Request.Context(), h.storageClient, h.logger, id, attachment.Variants, attachment.Path)
 
 	if isFileAttachmentType(attachment.Type) {
 		h.refreshCourseStorage(c.Request.Context(), courseID)
 	}
 
-	if err := h.db.Exec(`UPDATE lessons SET attachments = array_remove(COALESCE(attachments, '{}'::uuid[]), ?) WHERE id = ?`, id, attachment.LessonID).Error; err != nil {
+	if err := h.db.Exec(`UPDATE lessons SET attachments = array_remove(COALESCE(attachments, '{}'::uuid[]), ?), updated_at = NOW() WHERE id = ?`, id, attachment.LessonID).Error; err != nil {
 		h.logger.Error("failed to remove attachment id from lesson", "lessonId", attachment.LessonID, "attachmentId", id, "error", err)
 	}
 
 	response.Success(c, http.StatusOK, true, "", nil)
 }
 
+// readsAsStudent reports whether the requester reads attachments as a
+// student. For students it returns ErrLessonNotFound unless the lesson and its
+// course are active and published.
+func (h *Handler) readsAsStudent(c *gin.Context, lessonID uuid.UUID) (bool, error) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if ok && authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		return false, nil
+	}
+
+	var visible int64
+	if err := h.db.Table("lessons").
+		Joins("JOIN courses ON courses.id = lessons.course_id").
+		Where("lessons.id = ? AND lessons.is_active AND lessons.status = ? AND courses.is_active AND courses.status = ?",
+			lessonID, types.ContentStatusPublished, types.ContentStatusPublished).
+		Count(&visible).Error; err != nil {
+		return true, err
+	}
+	if visible == 0 {
+		return true, ErrLessonNotFound
+	}
+	return true, nil
+}
+
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrAttachmentNotFound):
-		status = http.StatusNotFound
-		message = "Attachment not found."
-	case errors.Is(err, ErrNameRequired):
-		status = http.StatusBadRequest
-		message = "Attachment name is required."
-	case errors.Is(err, ErrTypeRequired):
-		status = http.StatusBadRequest
-		message = "Attachment type is required."
-	case errors.Is(err, ErrInvalidType):
-		status = http.StatusBadRequest
-		message = "Invalid attachment type."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
 
 func normalizeQuestions(value interface{}) (*types.JSON, error) {
@@ -480,10 +524,88 @@ func isFileAttachmentType(t string) bool {
 	if t == "" {
 		return false
 	}
-	_, ok := fileAttachmentTypes[strings.ToLower(t)]
+	_, ok := filetype.For(strings.ToLower(t))
 	return ok
 }
 
+// SignPath swaps a stored CDN path, and the URLs of image variants, for
+// token-authenticated URLs on file attachments. The stored value is left
+// untouched; only the response copy is signed.
+func SignPath(storageClient *bunny.StorageClient, attachment *Attachment) {
+	if storageClient == nil || !isFileAttachmentType(attachment.Type) {
+		return
+	}
+	if len(attachment.Variants) > 0 {
+		signed := make(types.ImageVariants, len(attachment.Variants))
+		for i, variant := range attachment.Variants {
+			variant.URL = storageClient.SignedURL(variant.URL)
+			signed[i] = variant
+		}
+		attachment.Variants = signed
+	}
+	if attachment.Path == nil {
+		return
+	}
+	signed := storageClient.SignedURL(*attachment.Path)
+	attachment.Path = &signed
+}
+
+// checkFile enforces the upload policy of attachmentType on the file's name,
+// size and sniffed content, returning the detected content type. Rejections
+// are answered with 415 or 413.
+func (h *Handler) checkFile(c *gin.Context, attachmentType, fileName string, size int64, open opener) (string, bool) {
+	policy, _ := filetype.For(attachmentType)
+	if err := policy.CheckName(fileName, size); err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return "", false
+	}
+
+	r, release, err := open()
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read uploaded file", err)
+		return "", false
+	}
+	defer release()
+
+	head, err := filetype.Head(r)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read uploaded file", err)
+		return "", false
+	}
+	contentType, err := policy.CheckContent(head)
+	if err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return "", false
+	}
+	return contentType, true
+}
+
+// uploadImage stores an image attachment as resized variants without metadata
+// under basePath. The largest variant becomes the attachment's path. ok is
+// false once an error has been answered.
+func (h *Handler) uploadImage(c *gin.Context, r io.Reader, basePath string) (path string, variants types.ImageVariants, ok bool) {
+	resized, err := imaging.Resize(r, imaging.Breakpoints)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+			request.RespondInvalid(c, validation.FieldError{Field: "file", Rule: "image", Message: err.Error()})
+			return "", nil, false
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "image could not be read", err)
+		return "", nil, false
+	}
+
+	variants, err = imaging.Upload(c.Request.Context(), h.storageClient, basePath, resized)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to upload to CDN", err)
+		return "", nil, false
+	}
+	return imaging.Largest(variants), variants, true
+}
+
+func (h *Handler) signPath(attachment *Attachment) {
+	SignPath(h.storageClient, attachment)
+}
+
 func round2(value float64) float64 {
 	return math.Round(value*100) / 100
 }
@@ -497,6 +619,27 @@ func (h *Handler) refreshCourseStorage(ctx context.Context, courseID uuid.UUID)
 	}
 }
 
+// checkSubscriptionStorage answers 413 and returns false when incomingGB would
+// take the subscription past its combined storage limit.
+func (h *Handler) checkSubscriptionStorage(c *gin.Context, subscriptionID uuid.UUID, incomingGB float64) bool {
+	limitGB, usedGB, err := subscription.CheckStorage(h.db, subscriptionID, incomingGB)
+	if errors.Is(err, subscription.ErrStorageLimitExceeded) {
+		currentUsage := round2(usedGB)
+		response.ErrorWithData(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage limit exceeded. Subscription storage limit is %.2fGB, current usage is %.2fGB.", limitGB, currentUsage),
+			gin.H{
+				"subscriptionLimitGB": limitGB,
+				"currentUsageGB":      currentUsage,
+			}, nil)
+		return false
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check subscription storage", err)
+		return false
+	}
+	return true
+}
+
 type courseStorageMeta struct {
 	IdentifierName   string
 	CourseLimitInGB  float64
@@ -18,6 +18,8 @@ type Attachment struct {
 	Order     int        `gorm:"type:int;not null;default:0;index:idx_lesson_order" json:"order"`
 	Active    bool       `gorm:"type:boolean;not null;default:true;column:is_active;index:idx_lesson_active" json:"isActive"`
 	Questions types.JSON `gorm:"type:jsonb" json:"questions,omitempty"` // JSON array of MCQ questions
+	// Resized copies of image attachments, smallest first; Path is the largest
+	Variants types.ImageVariants `gorm:"type:jsonb" json:"variants,omitempty"`
 }
 
 // TableName overrides the default table name.
@@ -32,6 +34,7 @@ type CreateInput struct {
 	Order     *int
 	Active    *bool
 	Questions *types.JSON
+	Variants  types.ImageVariants
 }
 
 // UpdateInput captures mutable attachment fields.
@@ -107,6 +110,7 @@ func Create(db *gorm.DB, input CreateInput) (Attachment, error) {
 		Path:     input.Path,
 		Order:    order,
 		Active:   active,
+		Variants: input.Variants,
 	}
 
 	if input.Questions != nil {
@@ -151,6 +155,8 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Attachment, error) {
 
 	if input.PathProvided {
 		attachment.Path = input.Path
+		// Variants of the replaced image no longer match the path
+		attachment.Variants = nil
 	}
 
 	if input.OrderProvided {
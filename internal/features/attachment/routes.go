@@ -2,15 +2,36 @@ package attachment
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 )
 
 // RegisterRoutes sets up attachment endpoints under /subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/attachments.
 func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff []gin.HandlerFunc) {
 	attachments := router.Group("/subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/attachments")
+	// MCQ attachments carry their questions in the JSON body
+	questions := request.Limit(request.Limits{MaxBytes: 5 << 20})
 
 	attachments.GET("", append(acAll, handler.List)...)
 	attachments.GET("/:attachmentId", append(acAll, handler.GetByID)...)
-	attachments.POST("", append(acStaff, handler.Create)...)
-	attachments.PUT("/:attachmentId", append(acStaff, handler.Update)...)
+	attachments.POST("", append(acStaff, questions, handler.Create)...)
+	attachments.PUT("/:attachmentId", append(acStaff, questions, handler.Update)...)
 	attachments.DELETE("/:attachmentId", append(acStaff, handler.Delete)...)
+
+	// Chunked uploads for large files (init -> parts -> complete)
+	attachments.POST("/uploads", append(acStaff, handler.InitUpload)...)
+	attachments.GET("/uploads/:uploadId", append(acStaff, handler.GetUploadStatus)...)
+	attachments.PUT("/uploads/:uploadId/parts/:partNumber", append(acStaff, handler.UploadPart)...)
+	attachments.POST("/uploads/:uploadId/complete", append(acStaff, handler.CompleteUpload)...)
+	attachments.DELETE("/uploads/:uploadId", append(acStaff, handler.AbortUpload)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Attachment{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Attachment{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Attachment{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Attachment{}})
+	openapi.Describe(handler.InitUpload, openapi.Spec{Request: initUploadRequest{}, Response: UploadStatus{}})
+	openapi.Describe(handler.GetUploadStatus, openapi.Spec{Response: UploadStatus{}})
+	openapi.Describe(handler.UploadPart, openapi.Spec{RequestType: "application/octet-stream", Response: UploadStatus{}})
+	openapi.Describe(handler.CompleteUpload, openapi.Spec{Response: Attachment{}})
 }
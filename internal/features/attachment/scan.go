@@ -0,0 +1,198 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/clamav"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Quarantine records an upload the virus scanner flagged. Blocked uploads are
+// kept under QuarantinePath on the server that received them and never reach
+// Bunny Storage; in detect-only mode the file was stored anyway.
+type Quarantine struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	CourseID       uuid.UUID  `gorm:"type:uuid;not null;column:course_id" json:"courseId"`
+	LessonID       uuid.UUID  `gorm:"type:uuid;not null;column:lesson_id" json:"lessonId"`
+	UploadedBy     *uuid.UUID `gorm:"type:uuid;column:uploaded_by" json:"uploadedBy,omitempty"`
+	FileName       string     `gorm:"type:varchar(255);not null;column:file_name" json:"fileName"`
+	Type           string     `gorm:"type:varchar(50);not null" json:"type"`
+	Size           int64      `gorm:"type:bigint;not null;default:0" json:"size"`
+	Signature      string     `gorm:"type:varchar(255);not null" json:"signature"`
+	Blocked        bool       `gorm:"type:boolean;not null;default:true" json:"blocked"`
+	QuarantinePath *string    `gorm:"type:text;column:quarantine_path" json:"-"`
+}
+
+// TableName overrides the default table name.
+func (Quarantine) TableName() string { return "attachment_quarantine" }
+
+// scanner holds the antivirus settings of the handler.
+type scanner struct {
+	client     *clamav.Client
+	detectOnly bool
+	dir        string
+}
+
+// scanTarget describes an upload being scanned.
+type scanTarget struct {
+	SubscriptionID uuid.UUID
+	CourseID       uuid.UUID
+	LessonID       uuid.UUID
+	FileName       string
+	Type           string
+	Size           int64
+}
+
+// opener returns a fresh reader over the upload and a function releasing it.
+type opener func() (io.Reader, func(), error)
+
+// UseScanner scans file uploads with clamd before they are stored. In
+// detect-only mode infected files are recorded but not rejected.
+func (h *Handler) UseScanner(client *clamav.Client, detectOnly bool, quarantineDir string) {
+	h.scanner = &scanner{client: client, detectOnly: detectOnly, dir: quarantineDir}
+}
+
+// UseNotifier sets the service that tells uploaders about infected files.
+func (h *Handler) UseNotifier(notifier *notification.Service) {
+	h.notifier = notifier
+}
+
+// scanUpload runs the virus scanner over an upload before it is stored. It
+// returns false once the request has been answered: infected files are
+// quarantined and rejected unless scanning is detect-only, and uploads are
+// refused while clamd is unreachable. Files over clamd's size limit pass unscanned.
+func (h *Handler) scanUpload(c *gin.Context, target scanTarget, open opener) bool {
+	if h.scanner == nil {
+		return true
+	}
+
+	r, release, err := open()
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read upload for scanning", err)
+		return false
+	}
+	result, err := h.scanner.client.Scan(c.Request.Context(), r)
+	release()
+
+	switch {
+	case errors.Is(err, clamav.ErrStreamTooLarge):
+		h.logger.Warn("attachment too large to scan", "lessonId", target.LessonID, "fileName", target.FileName, "size", target.Size)
+		return true
+	case err != nil && h.scanner.detectOnly:
+		h.logger.Warn("attachment scan failed", "lessonId", target.LessonID, "fileName", target.FileName, "error", err)
+		return true
+	case err != nil:
+		response.ErrorWithLog(h.logger, c, http.StatusServiceUnavailable, "File scanning is unavailable. Please try again later.", err)
+		return false
+	case !result.Infected:
+		return true
+	}
+
+	record := Quarantine{
+		SubscriptionID: target.SubscriptionID,
+		CourseID:       target.CourseID,
+		LessonID:       target.LessonID,
+		FileName:       target.FileName,
+		Type:           target.Type,
+		Size:           target.Size,
+		Signature:      result.Signature,
+		Blocked:        !h.scanner.detectOnly,
+	}
+	record.ID = uuid.New()
+	usr, hasUser := middleware.GetUserFromContext(c)
+	if hasUser {
+		record.UploadedBy = &usr.ID
+	}
+
+	if record.Blocked {
+		path, err := h.quarantine(record.ID, open)
+		if err != nil {
+			h.logger.Error("failed to quarantine infected attachment", "quarantineId", record.ID, "error", err)
+		} else {
+			record.QuarantinePath = &path
+		}
+	}
+	if err := h.db.Create(&record).Error; err != nil {
+		h.logger.Error("failed to record infected attachment", "quarantineId", record.ID, "error", err)
+	}
+
+	h.logger.Warn("infected attachment upload",
+		"quarantineId", record.ID,
+		"lessonId", target.LessonID,
+		"fileName", target.FileName,
+		"signature", result.Signature,
+		"blocked", record.Blocked)
+
+	if hasUser && h.notifier != nil {
+		message := fmt.Sprintf("\"%s\" was flagged as %s and was not uploaded.", target.FileName, result.Signature)
+		if !record.Blocked {
+			message = fmt.Sprintf("\"%s\" was flagged as %s. It was uploaded, but please replace it with a clean copy.", target.FileName, result.Signature)
+		}
+		h.notifier.Notify([]uuid.UUID{usr.ID}, notification.Notification{
+			SubscriptionID: &target.SubscriptionID,
+			CourseID:       &target.CourseID,
+			LessonID:       &target.LessonID,
+			Type:           notification.TypeMalwareDetected,
+			Title:          "A virus was found in your upload",
+			Message:        message,
+		})
+	}
+
+	if !record.Blocked {
+		return true
+	}
+
+	response.ErrorWithData(h.logger, c, http.StatusUnprocessableEntity,
+		"The file contains malware and was rejected.",
+		gin.H{"signature": result.Signature, "quarantineId": record.ID}, nil)
+	return false
+}
+
+// quarantine copies the upload into the quarantine directory, readable only by the server.
+func (h *Handler) quarantine(id uuid.UUID, open opener) (string, error) {
+	if err := os.MkdirAll(h.scanner.dir, 0o700); err != nil {
+		return "", err
+	}
+
+	r, release, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	path := filepath.Join(h.scanner.dir, id.String())
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	_, copyErr := io.Copy(f, r)
+	closeErr := f.Close()
+	if err := errors.Join(copyErr, closeErr); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// seekOpener reopens a multipart file from its start for each read.
+func seekOpener(file io.ReadSeeker) opener {
+	return func() (io.Reader, func(), error) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		return file, func() {}, nil
+	}
+}
@@ -0,0 +1,550 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/cleanup"
+	"github.com/mo-amir99/lms-server-go/pkg/filetype"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	// uploadChunkSize is the part size clients should use; it stays well under the request size limit.
+	uploadChunkSize int64 = 8 << 20
+	// maxUploadSize caps a single chunked attachment upload.
+	maxUploadSize int64 = 2 << 30
+	// uploadSessionTTL is how long an idle upload session is kept before its parts are discarded.
+	uploadSessionTTL = 6 * time.Hour
+)
+
+// uploadSession tracks a chunked attachment upload. Parts are staged on local disk, so a
+// session is bound to the instance that created it (use sticky sessions behind a balancer).
+type uploadSession struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	CourseID       uuid.UUID
+	LessonID       uuid.UUID
+	Name           string
+	Type           string
+	FileName       string
+	ContentType    string
+	TotalSize      int64
+	TotalParts     int
+	Order          *int
+	Active         *bool
+	Dir            string
+	RemotePath     string
+	Parts          map[int]int64
+	UpdatedAt      time.Time
+
+	// completing is set while CompleteUpload validates and uploads the parts
+	// outside mu; parts cannot change and the sweeper leaves the session alone.
+	completing bool
+	mu         sync.Mutex
+}
+
+// UploadStatus is the client-facing view of an upload session.
+type UploadStatus struct {
+	UploadID      uuid.UUID `json:"uploadId"`
+	ChunkSize     int64     `json:"chunkSize"`
+	TotalSize     int64     `json:"totalSize"`
+	TotalParts    int       `json:"totalParts"`
+	ReceivedParts []int     `json:"receivedParts"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+func (s *uploadSession) status() UploadStatus {
+	received := make([]int, 0, len(s.Parts))
+	for part := range s.Parts {
+		received = append(received, part)
+	}
+	sort.Ints(received)
+
+	return UploadStatus{
+		UploadID:      s.ID,
+		ChunkSize:     uploadChunkSize,
+		TotalSize:     s.TotalSize,
+		TotalParts:    s.TotalParts,
+		ReceivedParts: received,
+		ExpiresAt:     s.UpdatedAt.Add(uploadSessionTTL),
+	}
+}
+
+func (s *uploadSession) partPath(part int) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%06d.part", part))
+}
+
+// open reads the staged parts in order as one stream; release closes them.
+func (s *uploadSession) open() (io.Reader, func(), error) {
+	files := make([]*os.File, 0, s.TotalParts)
+	readers := make([]io.Reader, 0, s.TotalParts)
+	release := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	for part := 1; part <= s.TotalParts; part++ {
+		f, err := os.Open(s.partPath(part))
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+	return io.MultiReader(readers...), release, nil
+}
+
+// expectedPartSize returns the exact byte size of a given 1-based part.
+func (s *uploadSession) expectedPartSize(part int) int64 {
+	if part < s.TotalParts {
+		return uploadChunkSize
+	}
+	return s.TotalSize - uploadChunkSize*int64(s.TotalParts-1)
+}
+
+// uploadStore keeps in-flight upload sessions in memory.
+type uploadStore struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*uploadSession
+	baseDir  string
+}
+
+func newUploadStore() *uploadStore {
+	store := &uploadStore{
+		sessions: make(map[uuid.UUID]*uploadSession),
+		baseDir:  filepath.Join(os.TempDir(), "lms-attachment-uploads"),
+	}
+
+	go store.cleanup()
+
+	return store
+}
+
+func (s *uploadStore) add(session *uploadSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+}
+
+func (s *uploadStore) get(id uuid.UUID) (*uploadSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	return session, ok
+}
+
+func (s *uploadStore) remove(id uuid.UUID) {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if ok {
+		_ = os.RemoveAll(session.Dir)
+	}
+}
+
+func (s *uploadStore) cleanup() {
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		sessions := make([]*uploadSession, 0, len(s.sessions))
+		for _, session := range s.sessions {
+			sessions = append(sessions, session)
+		}
+		s.mu.Unlock()
+
+		for _, session := range sessions {
+			session.mu.Lock()
+			expired := !session.completing && time.Since(session.UpdatedAt) > uploadSessionTTL
+			session.mu.Unlock()
+
+			if expired {
+				s.remove(session.ID)
+			}
+		}
+	}
+}
+
+type initUploadRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Type        string `json:"type" binding:"required"`
+	FileName    string `json:"fileName" binding:"required"`
+	ContentType string `json:"contentType"`
+	TotalSize   int64  `json:"totalSize" binding:"required"`
+	Order       *int   `json:"order"`
+	Active      *bool  `json:"isActive"`
+}
+
+// InitUpload starts a chunked upload for a large file attachment (pdf, audio, image).
+func (h *Handler) InitUpload(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return
+	}
+
+	var req initUploadRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid upload payload") {
+		return
+	}
+
+	attachmentType := strings.ToLower(req.Type)
+	if !isFileAttachmentType(attachmentType) {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "chunked uploads are only supported for pdf, audio and image attachments", nil)
+		return
+	}
+
+	if req.TotalSize <= 0 || req.TotalSize > maxUploadSize {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest,
+			fmt.Sprintf("totalSize must be between 1 byte and %d bytes", maxUploadSize), nil)
+		return
+	}
+	policy, _ := filetype.For(attachmentType)
+	if err := policy.CheckName(req.FileName, req.TotalSize); err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return
+	}
+
+	meta, err := h.loadCourseStorageMeta(subscriptionID, courseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.ErrorWithLog(h.logger, c, http.StatusNotFound, "subscription or course not found", err)
+		} else {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load course storage metadata", err)
+		}
+		return
+	}
+
+	// The session is trusted from here on, so the lesson must belong to the course
+	var lessonCount int64
+	if err := h.db.Table("lessons").Where("id = ? AND course_id = ?", lessonID, courseID).Count(&lessonCount).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load lesson", err)
+		return
+	}
+	if lessonCount == 0 {
+		h.respondError(c, ErrLessonNotFound, "failed to load lesson")
+		return
+	}
+
+	incomingGB := float64(req.TotalSize) / (1024 * 1024 * 1024)
+	if meta.CourseLimitInGB > 0 && meta.StorageUsageInGB+incomingGB > meta.CourseLimitInGB {
+		currentUsage := round2(meta.StorageUsageInGB)
+		response.ErrorWithData(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage limit exceeded. Course storage limit is %.2fGB, current usage is %.2fGB.", meta.CourseLimitInGB, currentUsage),
+			gin.H{
+				"courseLimitGB":  meta.CourseLimitInGB,
+				"currentUsageGB": currentUsage,
+			}, nil)
+		return
+	}
+	if !h.checkSubscriptionStorage(c, subscriptionID, incomingGB) {
+		return
+	}
+
+	identifier := strings.TrimSpace(meta.IdentifierName)
+	if identifier == "" {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "subscription identifier is missing", nil)
+		return
+	}
+
+	uploadID := uuid.New()
+	dir := filepath.Join(h.uploads.baseDir, uploadID.String())
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to prepare upload", err)
+		return
+	}
+
+	folderMap := map[string]string{"pdf": "pdfs", "audio": "audios", "image": "images"}
+	now := time.Now()
+	randomName := fmt.Sprintf("%d_%d%s", now.Unix(), now.Nanosecond(), strings.ToLower(filepath.Ext(req.FileName)))
+
+	session := &uploadSession{
+		ID:             uploadID,
+		SubscriptionID: subscriptionID,
+		CourseID:       courseID,
+		LessonID:       lessonID,
+		Name:           req.Name,
+		Type:           attachmentType,
+		FileName:       req.FileName,
+		ContentType:    req.ContentType,
+		TotalSize:      req.TotalSize,
+		TotalParts:     int((req.TotalSize + uploadChunkSize - 1) / uploadChunkSize),
+		Order:          req.Order,
+		Active:         req.Active,
+		Dir:            dir,
+		RemotePath:     fmt.Sprintf("%s/%s/attachments/%s/%s", identifier, courseID.String(), folderMap[attachmentType], randomName),
+		Parts:          make(map[int]int64),
+		UpdatedAt:      now,
+	}
+	h.uploads.add(session)
+
+	response.Created(c, session.status(), "Upload initialized")
+}
+
+// UploadPart stores a single chunk. The request body is the raw chunk bytes; parts are 1-based.
+// Re-sending a part overwrites it, so clients can safely retry failed chunks.
+func (h *Handler) UploadPart(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	part, err := strconv.Atoi(c.Param("partNumber"))
+	if err != nil || part < 1 || part > session.TotalParts {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest,
+			fmt.Sprintf("partNumber must be between 1 and %d", session.TotalParts), err)
+		return
+	}
+
+	expected := session.expectedPartSize(part)
+
+	// Each request stages into its own temp file so a retry racing the original
+	// upload of the same part cannot truncate or interleave its bytes
+	file, err := os.CreateTemp(session.Dir, filepath.Base(session.partPath(part))+".*.tmp")
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to store part", err)
+		return
+	}
+	tmpPath := file.Name()
+
+	written, copyErr := io.Copy(file, io.LimitReader(c.Request.Body, expected+1))
+	closeErr := file.Close()
+	if copyErr != nil || closeErr != nil {
+		_ = os.Remove(tmpPath)
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "failed to read part body", errors.Join(copyErr, closeErr))
+		return
+	}
+
+	if written != expected {
+		_ = os.Remove(tmpPath)
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest,
+			fmt.Sprintf("part %d must be exactly %d bytes, received %d", part, expected, written), nil)
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.completing {
+		_ = os.Remove(tmpPath)
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "upload is being completed", nil)
+		return
+	}
+
+	if err := os.Rename(tmpPath, session.partPath(part)); err != nil {
+		_ = os.Remove(tmpPath)
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to store part", err)
+		return
+	}
+
+	session.Parts[part] = written
+	session.UpdatedAt = time.Now()
+
+	response.Success(c, http.StatusOK, session.status(), "", nil)
+}
+
+// GetUploadStatus reports which parts have been received so clients can resume.
+func (h *Handler) GetUploadStatus(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	session.mu.Lock()
+	status := session.status()
+	session.mu.Unlock()
+
+	response.Success(c, http.StatusOK, status, "", nil)
+}
+
+// CompleteUpload assembles the parts, streams them to Bunny Storage and creates the attachment.
+func (h *Handler) CompleteUpload(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if !h.beginCompletion(c, session) {
+		return
+	}
+	// A failed attempt hands the session back so the client can retry; on
+	// success the session is removed below.
+	defer session.endCompletion()
+
+	// The content type given at init is only a hint; the stored type is sniffed.
+	contentType, ok := h.checkFile(c, session.Type, session.FileName, session.TotalSize, session.open)
+	if !ok {
+		return
+	}
+
+	if !h.scanUpload(c, scanTarget{
+		SubscriptionID: session.SubscriptionID,
+		CourseID:       session.CourseID,
+		LessonID:       session.LessonID,
+		FileName:       session.FileName,
+		Type:           session.Type,
+		Size:           session.TotalSize,
+	}, session.open) {
+		return
+	}
+
+	content, release, err := session.open()
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read staged part", err)
+		return
+	}
+	defer release()
+
+	var cdnURL string
+	var variants types.ImageVariants
+	if session.Type == "image" {
+		basePath := strings.TrimSuffix(session.RemotePath, filepath.Ext(session.RemotePath))
+		var ok bool
+		if cdnURL, variants, ok = h.uploadImage(c, content, basePath); !ok {
+			return
+		}
+	} else {
+		uploaded, err := h.storageClient.UploadSizedStream(c.Request.Context(), session.RemotePath, content, session.TotalSize, contentType)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to upload to CDN", err)
+			return
+		}
+		cdnURL = uploaded
+	}
+
+	attachment, err := Create(h.db, CreateInput{
+		LessonID: session.LessonID,
+		Name:     session.Name,
+		Type:     session.Type,
+		Path:     &cdnURL,
+		Order:    session.Order,
+		Active:   session.Active,
+		Variants: variants,
+	})
+	if err != nil {
+		if len(variants) > 0 {
+			cleanup.DeleteImageVariants(c.Request.Context(), h.storageClient, h.logger, session.ID, variants, nil)
+		} else if delErr := h.storageClient.DeleteFile(c.Request.Context(), session.RemotePath); delErr != nil {
+			h.logger.Warn("failed to remove uploaded file after attachment creation failure", "path", session.RemotePath, "error", delErr)
+		}
+		h.respondError(c, err, "failed to create attachment")
+		return
+	}
+
+	if err := h.db.Exec(`UPDATE lessons SET attachments = array_append(COALESCE(attachments, '{}'::uuid[]), ?), updated_at = NOW() WHERE id = ?`, attachment.ID, session.LessonID).Error; err != nil {
+		h.logger.Error("failed to append attachment id to lesson", "lessonId", session.LessonID, "attachmentId", attachment.ID, "error", err)
+	}
+
+	h.refreshCourseStorage(c.Request.Context(), session.CourseID)
+	h.uploads.remove(session.ID)
+
+	h.signPath(&attachment)
+	response.Created(c, attachment, "")
+}
+
+// beginCompletion claims the session for CompleteUpload once every part has
+// arrived. The checks, virus scan and upload that follow run without holding
+// the session lock, so status polls and the sweeper are never blocked by them.
+func (h *Handler) beginCompletion(c *gin.Context, session *uploadSession) bool {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.completing {
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "upload is already being completed", nil)
+		return false
+	}
+
+	var missing []int
+	for part := 1; part <= session.TotalParts; part++ {
+		if _, ok := session.Parts[part]; !ok {
+			missing = append(missing, part)
+		}
+	}
+	if len(missing) > 0 {
+		response.ErrorWithData(h.logger, c, http.StatusBadRequest, "upload is missing parts",
+			gin.H{"missingParts": missing}, nil)
+		return false
+	}
+
+	session.completing = true
+	return true
+}
+
+func (s *uploadSession) endCompletion() {
+	s.mu.Lock()
+	s.completing = false
+	s.UpdatedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// AbortUpload discards an in-progress upload and its staged parts.
+func (h *Handler) AbortUpload(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	session.mu.Lock()
+	completing := session.completing
+	session.mu.Unlock()
+	if completing {
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "upload is being completed", nil)
+		return
+	}
+
+	h.uploads.remove(session.ID)
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+func (h *Handler) loadUploadSession(c *gin.Context) (*uploadSession, bool) {
+	uploadID, err := uuid.Parse(c.Param("uploadId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid upload id", err)
+		return nil, false
+	}
+
+	session, ok := h.uploads.get(uploadID)
+	if !ok ||
+		session.SubscriptionID.String() != c.Param("subscriptionId") ||
+		session.CourseID.String() != c.Param("courseId") ||
+		session.LessonID.String() != c.Param("lessonId") {
+		response.ErrorWithLog(h.logger, c, http.StatusNotFound, "Upload not found.", nil)
+		return nil, false
+	}
+
+	return session, true
+}
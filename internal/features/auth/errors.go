@@ -1,6 +1,12 @@
 package auth
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
 	ErrInvalidCredentials       = errors.New("invalid email or password")
@@ -16,3 +22,20 @@ var (
 	ErrInvalidVerificationToken = errors.New("invalid verification token")
 	ErrVerificationTokenExpired = errors.New("verification token expired")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrInvalidCredentials, Status: http.StatusUnauthorized, Code: "INVALID_CREDENTIALS", Message: "Invalid email or password"},
+	{Err: ErrMissingFields, Status: http.StatusBadRequest, Code: "MISSING_FIELDS", Message: "Missing required fields"},
+	{Err: ErrInvalidEmail, Status: http.StatusBadRequest, Code: "INVALID_EMAIL", Message: "Invalid email format"},
+	{Err: ErrWeakPassword, Status: http.StatusBadRequest, Code: "WEAK_PASSWORD", Message: "Password must be at least 8 characters long"},
+	{Err: ErrDeviceRequired, Status: http.StatusBadRequest, Code: "DEVICE_REQUIRED", Message: "Device ID is required for this subscription"},
+	{Err: ErrDeviceMismatch, Status: http.StatusForbidden, Code: "DEVICE_MISMATCH", Message: "Device mismatch detected. Please contact support for device reset"},
+	{Err: ErrInactiveAccount, Status: http.StatusForbidden, Code: "ACCOUNT_INACTIVE", Message: "Your account is inactive. Please contact support"},
+	{Err: ErrInactiveSubscription, Status: http.StatusForbidden, Code: "SUBSCRIPTION_INACTIVE", Message: "Your subscription is inactive. Please contact support"},
+	{Err: ErrInvalidToken, Status: http.StatusUnauthorized, Code: "TOKEN_INVALID", Message: "Invalid or expired token"},
+	{Err: ErrInvalidTokenType, Status: http.StatusBadRequest, Code: "INVALID_TOKEN_TYPE", Message: "Invalid token type"},
+	{Err: ErrInvalidVerificationToken, Status: http.StatusBadRequest, Code: "INVALID_VERIFICATION_TOKEN", Message: "Invalid or malformed verification token"},
+	{Err: ErrVerificationTokenExpired, Status: http.StatusBadRequest, Code: "VERIFICATION_TOKEN_EXPIRED", Message: "Verification token has expired. Please request a new verification email."},
+	{Err: user.ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found"},
+}
@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -12,41 +11,57 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/features/emailqueue"
 	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
 	"github.com/mo-amir99/lms-server-go/pkg/config"
 	"github.com/mo-amir99/lms-server-go/pkg/email"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
 // Handler processes authentication HTTP requests.
 type Handler struct {
-	db          *gorm.DB
-	logger      *slog.Logger
-	cfg         *config.Config
-	emailClient *email.Client
+	db         *gorm.DB
+	logger     *slog.Logger
+	cfg        *config.Config
+	mail       *emailqueue.Queue
+	queryCache *cache.Store
+	sessions   user.SessionCloser
 }
 
 // NewHandler constructs an auth handler instance.
-func NewHandler(db *gorm.DB, logger *slog.Logger, cfg *config.Config, emailClient *email.Client) *Handler {
+func NewHandler(db *gorm.DB, logger *slog.Logger, cfg *config.Config, mail *emailqueue.Queue) *Handler {
 	return &Handler{
-		db:          db,
-		logger:      logger,
-		cfg:         cfg,
-		emailClient: emailClient,
+		db:     db,
+		logger: logger,
+		cfg:    cfg,
+		mail:   mail,
 	}
 }
 
+// UseCache sets the query cache whose dashboard counts registrations invalidate.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+// UseSessionCloser sets where live connections are closed after a password reset.
+func (h *Handler) UseSessionCloser(sessions user.SessionCloser) {
+	h.sessions = sessions
+}
+
+type registerRequest struct {
+	FullName string  `json:"fullName" binding:"required"`
+	Email    string  `json:"email" binding:"required,email"`
+	Password string  `json:"password" binding:"required"`
+	Phone    *string `json:"phone"`
+}
+
 // Register creates a new user account.
 func (h *Handler) Register(c *gin.Context) {
-	var req struct {
-		FullName string  `json:"fullName" binding:"required"`
-		Email    string  `json:"email" binding:"required,email"`
-		Password string  `json:"password" binding:"required"`
-		Phone    *string `json:"phone"`
-	}
+	var req registerRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid registration payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid registration payload") {
 		return
 	}
 
@@ -64,28 +79,25 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	// Send welcome email asynchronously
-	go func() {
-		if err := h.emailClient.SendWelcome(req.Email, req.FullName); err != nil {
-			h.logger.Error("failed to send welcome email",
-				slog.String("email", req.Email),
-				slog.String("error", err.Error()))
-		}
-	}()
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+
+	// Queue the welcome email; the delivery job retries SMTP failures
+	h.mail.EnqueueOrLog(emailqueue.KindWelcome, email.WelcomeMessage(req.Email, req.FullName))
 
 	response.Created(c, authResp, "Registration successful")
 }
 
+type loginRequest struct {
+	Email    string  `json:"email" binding:"required"`
+	Password string  `json:"password" binding:"required"`
+	DeviceID *string `json:"deviceId"`
+}
+
 // Login authenticates a user and returns JWT tokens.
 func (h *Handler) Login(c *gin.Context) {
-	var req struct {
-		Email    string  `json:"email" binding:"required"`
-		Password string  `json:"password" binding:"required"`
-		DeviceID *string `json:"deviceId"`
-	}
+	var req loginRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid login payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid login payload") {
 		return
 	}
 
@@ -124,14 +136,15 @@ func (h *Handler) Logout(c *gin.Context) {
 	response.Success(c, http.StatusOK, true, "Logout successful", nil)
 }
 
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 // RequestPasswordReset sends a password reset email.
 func (h *Handler) RequestPasswordReset(c *gin.Context) {
-	var req struct {
-		Email string `json:"email" binding:"required,email"`
-	}
+	var req requestPasswordResetRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid email", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid email") {
 		return
 	}
 
@@ -142,52 +155,53 @@ func (h *Handler) RequestPasswordReset(c *gin.Context) {
 		return
 	}
 
-	// Send password reset email asynchronously (only if user was found)
+	// Queue the password reset email (only if user was found)
 	if resetInfo != nil {
 		resetURL := h.buildPublicURL("reset-password.html")
-		go func() {
-			if err := h.emailClient.SendPasswordReset(resetInfo.Email, resetInfo.Token, resetURL); err != nil {
-				h.logger.Error("failed to send password reset email",
-					slog.String("email", resetInfo.Email),
-					slog.String("error", err.Error()))
-			}
-		}()
+		h.mail.EnqueueOrLog(emailqueue.KindPasswordReset, email.PasswordResetMessage(resetInfo.Email, resetInfo.Token, resetURL))
 		h.logger.Info("password reset requested", slog.String("email", req.Email))
 	}
 
 	response.Success(c, http.StatusOK, true, "If the email exists in our system, a password reset link has been sent.", nil)
 }
 
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
 // ResetPassword changes a user's password using a reset token.
 func (h *Handler) ResetPassword(c *gin.Context) {
-	var req struct {
-		Token       string `json:"token" binding:"required"`
-		NewPassword string `json:"newPassword" binding:"required"`
-	}
+	var req resetPasswordRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid reset payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid reset payload") {
 		return
 	}
 
 	tokenCfg := h.getTokenConfig()
 
-	if err := ResetPassword(h.db, req.Token, req.NewPassword, tokenCfg); err != nil {
+	userID, err := ResetPassword(h.db, req.Token, req.NewPassword, tokenCfg)
+	if err != nil {
 		h.respondError(c, err, "password reset failed")
 		return
 	}
 
+	if h.sessions != nil {
+		h.sessions.DisconnectUser(userID)
+	}
+
 	response.Success(c, http.StatusOK, true, "Password reset successful. Please login with your new password.", nil)
 }
 
+type requestEmailVerificationRequest struct {
+	Email string `json:"email"`
+}
+
 // RequestEmailVerification sends an email verification link when appropriate.
 func (h *Handler) RequestEmailVerification(c *gin.Context) {
-	var req struct {
-		Email string `json:"email"`
-	}
+	var req requestEmailVerificationRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Email is required", err)
+	if !request.BindJSON(h.logger, c, &req, "Email is required") {
 		return
 	}
 
@@ -214,23 +228,20 @@ func (h *Handler) RequestEmailVerification(c *gin.Context) {
 	}
 
 	verificationURL := h.buildPublicURL("verify-email.html")
-	go func(emailAddr, token, baseURL string) {
-		if err := h.emailClient.SendEmailVerification(emailAddr, token, baseURL); err != nil {
-			h.logger.Error("failed to send email verification", slog.String("email", emailAddr), slog.String("error", err.Error()))
-		}
-	}(info.Email, info.Token, verificationURL)
+	h.mail.EnqueueOrLog(emailqueue.KindEmailVerification, email.EmailVerificationMessage(info.Email, info.Token, verificationURL))
 
 	response.Success(c, http.StatusOK, true, "If the email exists in our system, a verification link has been sent.", nil)
 }
 
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
 // VerifyEmail validates the verification token and marks the user as verified.
 func (h *Handler) VerifyEmail(c *gin.Context) {
-	var req struct {
-		Token string `json:"token"`
-	}
+	var req verifyEmailRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Verification token is required", err)
+	if !request.BindJSON(h.logger, c, &req, "Verification token is required") {
 		return
 	}
 
@@ -254,15 +265,16 @@ func (h *Handler) VerifyEmail(c *gin.Context) {
 	response.Success(c, http.StatusOK, true, "Email verification successful", nil)
 }
 
+type resetDeviceRequest struct {
+	UserID         string `json:"userId" binding:"required"`
+	SubscriptionID string `json:"subscriptionId" binding:"required"`
+}
+
 // ResetDevice clears a student's device binding.
 func (h *Handler) ResetDevice(c *gin.Context) {
-	var req struct {
-		UserID         string `json:"userId" binding:"required"`
-		SubscriptionID string `json:"subscriptionId" binding:"required"`
-	}
+	var req resetDeviceRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid device reset payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid device reset payload") {
 		return
 	}
 
@@ -286,14 +298,15 @@ func (h *Handler) ResetDevice(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"userId": userID}, "Device reset successful", nil)
 }
 
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
 // RefreshToken generates new tokens using a refresh token.
 func (h *Handler) RefreshToken(c *gin.Context) {
-	var req struct {
-		RefreshToken string `json:"refreshToken" binding:"required"`
-	}
+	var req refreshTokenRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid refresh token payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid refresh token payload") {
 		return
 	}
 
@@ -309,63 +322,23 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 }
 
 func (h *Handler) getTokenConfig() TokenConfig {
+	return TokenConfigFrom(h.cfg)
+}
+
+// TokenConfigFrom derives the token lifetimes and secrets from application config.
+func TokenConfigFrom(cfg *config.Config) TokenConfig {
 	return TokenConfig{
-		JWTSecret:               h.cfg.JWTSecret,
-		JWTRefreshSecret:        h.cfg.JWTRefreshSecret,
-		AccessTokenExpiry:       time.Duration(h.cfg.AccessTokenExpiry) * time.Minute,
-		RefreshTokenExpiry:      time.Duration(h.cfg.RefreshTokenExpiry) * time.Hour,
-		PasswordResetExpiry:     time.Duration(h.cfg.PasswordResetExpiry) * time.Hour,
-		EmailVerificationExpiry: time.Duration(h.cfg.EmailVerificationExpiry) * time.Hour,
+		JWTSecret:               cfg.JWTSecret,
+		JWTRefreshSecret:        cfg.JWTRefreshSecret,
+		AccessTokenExpiry:       time.Duration(cfg.AccessTokenExpiry) * time.Minute,
+		RefreshTokenExpiry:      time.Duration(cfg.RefreshTokenExpiry) * time.Hour,
+		PasswordResetExpiry:     time.Duration(cfg.PasswordResetExpiry) * time.Hour,
+		EmailVerificationExpiry: time.Duration(cfg.EmailVerificationExpiry) * time.Hour,
 	}
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrInvalidCredentials):
-		status = http.StatusUnauthorized
-		message = "Invalid email or password"
-	case errors.Is(err, ErrMissingFields):
-		status = http.StatusBadRequest
-		message = "Missing required fields"
-	case errors.Is(err, ErrInvalidEmail):
-		status = http.StatusBadRequest
-		message = "Invalid email format"
-	case errors.Is(err, ErrWeakPassword):
-		status = http.StatusBadRequest
-		message = "Password must be at least 8 characters long"
-	case errors.Is(err, ErrDeviceRequired):
-		status = http.StatusBadRequest
-		message = "Device ID is required for this subscription"
-	case errors.Is(err, ErrDeviceMismatch):
-		status = http.StatusForbidden
-		message = "Device mismatch detected. Please contact support for device reset"
-	case errors.Is(err, ErrInactiveAccount):
-		status = http.StatusForbidden
-		message = "Your account is inactive. Please contact support"
-	case errors.Is(err, ErrInactiveSubscription):
-		status = http.StatusForbidden
-		message = "Your subscription is inactive. Please contact support"
-	case errors.Is(err, ErrInvalidToken):
-		status = http.StatusUnauthorized
-		message = "Invalid or expired token"
-	case errors.Is(err, ErrInvalidTokenType):
-		status = http.StatusBadRequest
-		message = "Invalid token type"
-	case errors.Is(err, ErrInvalidVerificationToken):
-		status = http.StatusBadRequest
-		message = "Invalid or malformed verification token"
-	case errors.Is(err, ErrVerificationTokenExpired):
-		status = http.StatusBadRequest
-		message = "Verification token has expired. Please request a new verification email."
-	case errors.Is(err, user.ErrUserNotFound):
-		status = http.StatusNotFound
-		message = "User not found"
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
 
 func (h *Handler) buildPublicURL(page string) string {
@@ -1,10 +1,16 @@
 package auth
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
 
-// RegisterRoutes attaches authentication endpoints to the router.
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
-	auth := router.Group("/auth")
+	"github.com/mo-amir99/lms-server-go/internal/utils/jwt"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches authentication endpoints to the router behind the
+// limited middleware.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, limited []gin.HandlerFunc) {
+	auth := router.Group("/auth", limited...)
 	{
 		auth.POST("/register", handler.Register)
 		auth.POST("/login", handler.Login)
@@ -23,4 +29,13 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 		auth.POST("/verifyEmail", handler.VerifyEmail)
 		auth.POST("/resetDevice", handler.ResetDevice)
 	}
+
+	openapi.Describe(handler.Register, openapi.Spec{Request: registerRequest{}, Response: AuthResponse{}})
+	openapi.Describe(handler.Login, openapi.Spec{Request: loginRequest{}, Response: AuthResponse{}})
+	openapi.Describe(handler.ResetPassword, openapi.Spec{Request: resetPasswordRequest{}})
+	openapi.Describe(handler.ResetDevice, openapi.Spec{Request: resetDeviceRequest{}})
+	openapi.Describe(handler.RequestEmailVerification, openapi.Spec{Request: requestEmailVerificationRequest{}})
+	openapi.Describe(handler.VerifyEmail, openapi.Spec{Request: verifyEmailRequest{}})
+	openapi.Describe(handler.RefreshToken, openapi.Spec{Request: refreshTokenRequest{}, Response: jwt.TokenPair{}})
+	openapi.Describe(handler.RequestPasswordReset, openapi.Spec{Request: requestPasswordResetRequest{}})
 }
@@ -69,20 +69,24 @@ func Register(db *gorm.DB, input RegisterInput, cfg TokenConfig) (*AuthResponse,
 		return nil, err
 	}
 
-	// Generate tokens
-	accessToken, err := jwt.GenerateAccessToken(newUser.ID, cfg.JWTSecret, cfg.AccessTokenExpiry)
+	return IssueTokens(db, newUser, cfg)
+}
+
+// IssueTokens generates a token pair for a freshly created user and stores the refresh token.
+func IssueTokens(db *gorm.DB, newUser user.User, cfg TokenConfig) (*AuthResponse, error) {
+	accessToken, err := jwt.GenerateAccessToken(newUser.ID, newUser.TokenVersion, cfg.JWTSecret, cfg.AccessTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := jwt.GenerateRefreshToken(newUser.ID, cfg.JWTRefreshSecret, cfg.RefreshTokenExpiry)
+	refreshToken, err := jwt.GenerateRefreshToken(newUser.ID, newUser.TokenVersion, cfg.JWTRefreshSecret, cfg.RefreshTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
 
 	// Store refresh token
 	newUser.RefreshToken = &refreshToken
-	if err := db.Save(newUser).Error; err != nil {
+	if err := db.Save(&newUser).Error; err != nil {
 		return nil, err
 	}
 
@@ -110,23 +114,31 @@ func Login(db *gorm.DB, input LoginInput, cfg TokenConfig) (*AuthResponse, error
 		return nil, ErrInvalidCredentials
 	}
 
+	return SignIn(db, usr, input.DeviceID, cfg)
+}
+
+// SignIn issues tokens to a user whose identity was already proven, by password
+// or by a single sign-on provider. It applies the subscription's device lock
+// and refuses inactive accounts and subscriptions. The user's subscription
+// must be preloaded.
+func SignIn(db *gorm.DB, usr user.User, deviceID *string, cfg TokenConfig) (*AuthResponse, error) {
 	// Check device lock for students with subscription requirements
 	isStudent := usr.UserType == user.UserTypeStudent
 	requiresSameDevice := isStudent && usr.Subscription != nil && usr.Subscription.RequireSameDeviceID
 
 	if requiresSameDevice {
-		if input.DeviceID == nil || *input.DeviceID == "" {
+		if deviceID == nil || *deviceID == "" {
 			return nil, ErrDeviceRequired
 		}
 
 		// Check device mismatch
-		if usr.DeviceID != nil && *usr.DeviceID != *input.DeviceID {
+		if usr.DeviceID != nil && *usr.DeviceID != *deviceID {
 			return nil, ErrDeviceMismatch
 		}
 
 		// Bind device on first login
 		if usr.DeviceID == nil {
-			usr.DeviceID = input.DeviceID
+			usr.DeviceID = deviceID
 		}
 	}
 
@@ -146,12 +158,12 @@ func Login(db *gorm.DB, input LoginInput, cfg TokenConfig) (*AuthResponse, error
 	}
 
 	// Generate tokens
-	accessToken, err := jwt.GenerateAccessToken(usr.ID, cfg.JWTSecret, cfg.AccessTokenExpiry)
+	accessToken, err := jwt.GenerateAccessToken(usr.ID, usr.TokenVersion, cfg.JWTSecret, cfg.AccessTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := jwt.GenerateRefreshToken(usr.ID, cfg.JWTRefreshSecret, cfg.RefreshTokenExpiry)
+	refreshToken, err := jwt.GenerateRefreshToken(usr.ID, usr.TokenVersion, cfg.JWTRefreshSecret, cfg.RefreshTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
@@ -253,36 +265,34 @@ func RequestPasswordReset(db *gorm.DB, email string, cfg TokenConfig) (*Password
 	}, nil
 }
 
-// ResetPassword updates a user's password using a reset token.
-func ResetPassword(db *gorm.DB, token, newPassword string, cfg TokenConfig) error {
+// ResetPassword updates a user's password using a reset token and returns the
+// user whose sessions were revoked.
+func ResetPassword(db *gorm.DB, token, newPassword string, cfg TokenConfig) (uuid.UUID, error) {
 	if len(newPassword) < 8 {
-		return ErrWeakPassword
+		return uuid.Nil, ErrWeakPassword
 	}
 
 	claims, err := jwt.VerifyToken(token, cfg.JWTSecret)
 	if err != nil {
-		return ErrInvalidToken
+		return uuid.Nil, ErrInvalidToken
 	}
 
 	if claims.Purpose != "password-reset" {
-		return ErrInvalidTokenType
+		return uuid.Nil, ErrInvalidTokenType
 	}
 
 	usr, err := user.Get(db, claims.UserID)
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 
-	// Update password
-	updatedUser, err := user.Update(db, usr.ID, user.UpdateInput{
+	// Update password; this also revokes every token issued before
+	if _, err := user.Update(db, usr.ID, user.UpdateInput{
 		Password: &newPassword,
-	})
-	if err != nil {
-		return err
+	}); err != nil {
+		return uuid.Nil, err
 	}
-
-	// Clear refresh token for security
-	return db.Model(&user.User{}).Where("id = ?", updatedUser.ID).Update("refresh_token", nil).Error
+	return usr.ID, nil
 }
 
 // RefreshAccessToken generates a new access token using a refresh token.
@@ -297,19 +307,23 @@ func RefreshAccessToken(db *gorm.DB, refreshToken string, cfg TokenConfig) (*jwt
 		return nil, err
 	}
 
-	// Verify stored refresh token matches
-	if usr.RefreshToken == nil || *usr.RefreshToken != refreshToken {
+	// Verify stored refresh token matches and was not revoked since
+	if usr.RefreshToken == nil || *usr.RefreshToken != refreshToken || claims.TokenVersion != usr.TokenVersion {
 		return nil, ErrInvalidToken
 	}
 
+	if !usr.Active && usr.UserType != user.UserTypeAdmin && usr.UserType != user.UserTypeSuperAdmin {
+		return nil, ErrInactiveAccount
+	}
+
 	// Generate new access token
-	accessToken, err := jwt.GenerateAccessToken(usr.ID, cfg.JWTSecret, cfg.AccessTokenExpiry)
+	accessToken, err := jwt.GenerateAccessToken(usr.ID, usr.TokenVersion, cfg.JWTSecret, cfg.AccessTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate new refresh token
-	newRefreshToken, err := jwt.GenerateRefreshToken(usr.ID, cfg.JWTRefreshSecret, cfg.RefreshTokenExpiry)
+	newRefreshToken, err := jwt.GenerateRefreshToken(usr.ID, usr.TokenVersion, cfg.JWTRefreshSecret, cfg.RefreshTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,23 @@
+package bookmark
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrBookmarkNotFound  = errors.New("bookmark not found")
+	ErrTargetRequired    = errors.New("exactly one of courseId or lessonId is required")
+	ErrTargetNotFound    = errors.New("course or lesson not found")
+	ErrAlreadyBookmarked = errors.New("already bookmarked")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrBookmarkNotFound, Status: http.StatusNotFound, Code: "BOOKMARK_NOT_FOUND", Message: "Bookmark not found."},
+	{Err: ErrTargetRequired, Status: http.StatusBadRequest, Code: "BOOKMARK_TARGET_REQUIRED", Message: "Provide either courseId or lessonId."},
+	{Err: ErrTargetNotFound, Status: http.StatusNotFound, Code: "BOOKMARK_TARGET_NOT_FOUND", Message: "Course or lesson not found."},
+	{Err: ErrAlreadyBookmarked, Status: http.StatusConflict, Code: "ALREADY_BOOKMARKED", Message: "Already bookmarked."},
+}
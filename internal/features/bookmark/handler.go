@@ -0,0 +1,157 @@
+package bookmark
+
+import (
+	"net/http"
+	"slices"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/contentsync"
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Handler processes bookmark HTTP requests.
+type Handler struct {
+	db            *gorm.DB
+	logger        *slog.Logger
+	storageClient *bunny.StorageClient
+}
+
+// NewHandler constructs a bookmark handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger, storageClient *bunny.StorageClient) *Handler {
+	return &Handler{db: db, logger: logger, storageClient: storageClient}
+}
+
+type createRequest struct {
+	CourseID *uuid.UUID `json:"courseId"`
+	LessonID *uuid.UUID `json:"lessonId"`
+}
+
+// List returns the current user's bookmarks with the course and lesson details.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, usr, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	params := pagination.Extract(c)
+	entries, total, err := List(h.db, usr.ID, subscriptionID, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list bookmarks", err)
+		return
+	}
+
+	for i := range entries {
+		signed := course.Course{Image: entries[i].CourseImage, ImageVariants: entries[i].ImageVariants}
+		course.SignImage(h.storageClient, &signed)
+		entries[i].CourseImage, entries[i].ImageVariants = signed.Image, signed.ImageVariants
+	}
+
+	response.Success(c, http.StatusOK, entries, "", pagination.MetadataFrom(total, params))
+}
+
+// Create bookmarks a course or a lesson. Students may only bookmark inside
+// published courses they reach through their access groups; a lesson itself
+// may still be unpublished, in which case they are told when it is published.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, usr, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	var req createRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid bookmark payload") {
+		return
+	}
+	if (req.CourseID == nil) == (req.LessonID == nil) {
+		h.respondError(c, ErrTargetRequired, "invalid bookmark payload")
+		return
+	}
+
+	found, err := findTarget(h.db, subscriptionID, req.CourseID, req.LessonID)
+	if err != nil {
+		h.respondError(c, err, "failed to load bookmark target")
+		return
+	}
+
+	if !authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		if !found.CourseActive || found.CourseStatus != types.ContentStatusPublished {
+			h.respondError(c, ErrTargetNotFound, "failed to load bookmark target")
+			return
+		}
+		accessible, err := contentsync.AccessibleCourseIDs(h.db, subscriptionID, usr.ID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check course access", err)
+			return
+		}
+		if !slices.Contains(accessible, found.CourseID.String()) {
+			h.respondError(c, ErrTargetNotFound, "failed to load bookmark target")
+			return
+		}
+	}
+
+	bookmark := Bookmark{
+		UserID:         usr.ID,
+		SubscriptionID: subscriptionID,
+		CourseID:       found.CourseID,
+		LessonID:       req.LessonID,
+	}
+	if err := Create(h.db, &bookmark); err != nil {
+		h.respondError(c, err, "failed to create bookmark")
+		return
+	}
+
+	response.Created(c, bookmark, "")
+}
+
+// Delete removes one of the current user's bookmarks.
+func (h *Handler) Delete(c *gin.Context) {
+	_, usr, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("bookmarkId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid bookmark id", err)
+		return
+	}
+
+	if err := Delete(h.db, usr.ID, id); err != nil {
+		h.respondError(c, err, "failed to delete bookmark")
+		return
+	}
+
+	response.NoContent(c, "Bookmark removed.")
+}
+
+func (h *Handler) params(c *gin.Context) (uuid.UUID, *middleware.User, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, nil, false
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return uuid.Nil, nil, false
+	}
+
+	return subscriptionID, usr, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,142 @@
+package bookmark
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Kinds of bookmarked content.
+const (
+	KindCourse = "course"
+	KindLesson = "lesson"
+)
+
+// Bookmark is a course or lesson a user saved for later. Lesson bookmarks also
+// carry the lesson's course.
+type Bookmark struct {
+	types.BaseModel
+
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index" json:"userId"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	CourseID       uuid.UUID  `gorm:"type:uuid;not null;column:course_id" json:"courseId"`
+	LessonID       *uuid.UUID `gorm:"type:uuid;column:lesson_id" json:"lessonId,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Bookmark) TableName() string { return "bookmarks" }
+
+// Entry is a bookmark with what it points at. Available is false while the
+// course or lesson is hidden from students, e.g. a lesson back in draft.
+type Entry struct {
+	ID            uuid.UUID           `json:"id"`
+	Kind          string              `json:"kind"`
+	CourseID      uuid.UUID           `json:"courseId"`
+	CourseName    string              `json:"courseName"`
+	CourseImage   *string             `json:"courseImage,omitempty"`
+	ImageVariants types.ImageVariants `json:"courseImageVariants,omitempty"`
+	LessonID      *uuid.UUID          `json:"lessonId,omitempty"`
+	LessonName    *string             `json:"lessonName,omitempty"`
+	ThumbnailURL  *string             `json:"thumbnailUrl,omitempty"`
+	Duration      *int                `json:"duration,omitempty"`
+	Available     bool                `json:"available"`
+	CreatedAt     time.Time           `json:"createdAt"`
+}
+
+// List returns the user's bookmarks in the subscription, newest first.
+func List(db *gorm.DB, userID, subscriptionID uuid.UUID, params pagination.Params) ([]Entry, int64, error) {
+	query := db.Table("bookmarks").
+		Joins("JOIN courses ON courses.id = bookmarks.course_id").
+		Joins("LEFT JOIN lessons ON lessons.id = bookmarks.lesson_id").
+		Where("bookmarks.user_id = ? AND bookmarks.subscription_id = ?", userID, subscriptionID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]Entry, 0)
+	err := query.
+		Select(`bookmarks.id, bookmarks.course_id, bookmarks.lesson_id, bookmarks.created_at,
+			CASE WHEN bookmarks.lesson_id IS NULL THEN ? ELSE ? END AS kind,
+			courses.name AS course_name, courses.image AS course_image, courses.image_variants,
+			lessons.name AS lesson_name, lessons.thumbnail_url, lessons.duration,
+			courses.is_active AND courses.status = ? AND (lessons.id IS NULL OR (lessons.is_active AND lessons.status = ?)) AS available`,
+			KindCourse, KindLesson, types.ContentStatusPublished, types.ContentStatusPublished).
+		Order("bookmarks.created_at DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Scan(&entries).Error
+	return entries, total, err
+}
+
+// Create saves a bookmark, refusing one the user already has.
+func Create(db *gorm.DB, bookmark *Bookmark) error {
+	query := db.Model(&Bookmark{}).Where("user_id = ?", bookmark.UserID)
+	if bookmark.LessonID != nil {
+		query = query.Where("lesson_id = ?", *bookmark.LessonID)
+	} else {
+		query = query.Where("course_id = ? AND lesson_id IS NULL", bookmark.CourseID)
+	}
+
+	var existing int64
+	if err := query.Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return ErrAlreadyBookmarked
+	}
+	return db.Create(bookmark).Error
+}
+
+// Delete removes one of the user's bookmarks.
+func Delete(db *gorm.DB, userID, id uuid.UUID) error {
+	result := db.Delete(&Bookmark{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// LessonBookmarkers returns the users who bookmarked the lesson.
+func LessonBookmarkers(db *gorm.DB, lessonID uuid.UUID) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := db.Model(&Bookmark{}).Where("lesson_id = ?", lessonID).Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// target is the course a bookmark points into.
+type target struct {
+	CourseID       uuid.UUID
+	SubscriptionID uuid.UUID
+	CourseActive   bool
+	CourseStatus   types.ContentStatus
+}
+
+// findTarget resolves the course behind a course or lesson bookmark within the subscription.
+func findTarget(db *gorm.DB, subscriptionID uuid.UUID, courseID, lessonID *uuid.UUID) (target, error) {
+	query := db.Table("courses").
+		Select("courses.id AS course_id, courses.subscription_id, courses.is_active AS course_active, courses.status AS course_status").
+		Where("courses.subscription_id = ?", subscriptionID)
+	if lessonID != nil {
+		query = query.Joins("JOIN lessons ON lessons.course_id = courses.id").Where("lessons.id = ?", *lessonID)
+	} else {
+		query = query.Where("courses.id = ?", *courseID)
+	}
+
+	var found []target
+	if err := query.Limit(1).Scan(&found).Error; err != nil {
+		return target{}, err
+	}
+	if len(found) == 0 {
+		return target{}, ErrTargetNotFound
+	}
+	return found[0], nil
+}
@@ -0,0 +1,19 @@
+package bookmark
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches bookmark endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll []gin.HandlerFunc) {
+	bookmarks := router.Group("/subscriptions/:subscriptionId/bookmarks")
+
+	bookmarks.GET("", append(acAll, handler.List)...)
+	bookmarks.POST("", append(acAll, handler.Create)...)
+	bookmarks.DELETE("/:bookmarkId", append(acAll, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Entry{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Bookmark{}})
+}
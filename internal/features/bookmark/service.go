@@ -0,0 +1,60 @@
+package bookmark
+
+import (
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+)
+
+// Service tells students when content they bookmarked becomes available.
+type Service struct {
+	db       *gorm.DB
+	logger   *slog.Logger
+	notifier *notification.Service
+}
+
+// NewService constructs a bookmark notification service.
+func NewService(db *gorm.DB, logger *slog.Logger, notifier *notification.Service) *Service {
+	return &Service{db: db, logger: logger, notifier: notifier}
+}
+
+// LessonPublished notifies the users who bookmarked the lesson. Nothing is sent
+// while the lesson's course is still hidden from students. It is registered as
+// a lesson publish hook.
+func (s *Service) LessonPublished(published lesson.Lesson) {
+	if s.notifier == nil || !published.VisibleToStudents() {
+		return
+	}
+
+	parent, err := course.Get(s.db, published.CourseID)
+	if err != nil {
+		s.logger.Error("failed to load course of published lesson", "lessonId", published.ID, "error", err)
+		return
+	}
+	if !parent.VisibleToStudents() {
+		return
+	}
+
+	recipients, err := LessonBookmarkers(s.db, published.ID)
+	if err != nil {
+		s.logger.Error("failed to load lesson bookmarks", "lessonId", published.ID, "error", err)
+		return
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	s.notifier.Notify(recipients, notification.Notification{
+		SubscriptionID: &parent.SubscriptionID,
+		CourseID:       &parent.ID,
+		LessonID:       &published.ID,
+		Type:           notification.TypeBookmarkPublished,
+		Title:          "A bookmarked lesson is now available",
+		Message:        fmt.Sprintf("\"%s\" in %s has been published.", published.Name, parent.Name),
+	})
+}
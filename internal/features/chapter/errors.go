@@ -0,0 +1,29 @@
+package chapter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrChapterNotFound   = errors.New("chapter not found")
+	ErrTitleRequired     = errors.New("chapter title is required")
+	ErrTitleTooLong      = errors.New("chapter title cannot exceed 100 characters")
+	ErrStartTimeInvalid  = errors.New("chapter start time cannot be negative")
+	ErrDuplicateStart    = errors.New("chapters cannot share the same start time")
+	ErrTooManyChapters   = errors.New("a lesson cannot have more than 100 chapters")
+	ErrLessonNotInCourse = errors.New("lesson not found")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrChapterNotFound, Status: http.StatusNotFound, Code: "CHAPTER_NOT_FOUND", Message: "Chapter not found."},
+	{Err: ErrLessonNotInCourse, Status: http.StatusNotFound, Code: "LESSON_NOT_IN_COURSE", Message: "Lesson not found."},
+	{Err: ErrTitleRequired, Status: http.StatusBadRequest, Code: "CHAPTER_TITLE_REQUIRED", Message: "Chapter title is required."},
+	{Err: ErrTitleTooLong, Status: http.StatusBadRequest, Code: "CHAPTER_TITLE_TOO_LONG", Message: "Chapter title cannot exceed 100 characters."},
+	{Err: ErrStartTimeInvalid, Status: http.StatusBadRequest, Code: "CHAPTER_START_TIME_INVALID", Message: "Chapter start time cannot be negative."},
+	{Err: ErrDuplicateStart, Status: http.StatusConflict, Code: "CHAPTER_START_TAKEN", Message: "Another chapter already starts at this time."},
+	{Err: ErrTooManyChapters, Status: http.StatusBadRequest, Code: "TOO_MANY_CHAPTERS", Message: "A lesson cannot have more than 100 chapters."},
+}
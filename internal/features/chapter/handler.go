@@ -0,0 +1,176 @@
+package chapter
+
+import (
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes lesson chapter HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a chapter handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// List returns all chapters for a lesson ordered by start time.
+func (h *Handler) List(c *gin.Context) {
+	lessonID, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	chapters, err := GetByLesson(h.db, lessonID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load chapters", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, chapters, "", nil)
+}
+
+// Create inserts a new chapter.
+func (h *Handler) Create(c *gin.Context) {
+	lessonID, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	var req Input
+	if !request.BindJSON(h.logger, c, &req, "invalid chapter payload") {
+		return
+	}
+
+	chapter, err := Create(h.db, lessonID, req)
+	if err != nil {
+		h.respondError(c, err, "failed to create chapter")
+		return
+	}
+
+	response.Created(c, chapter, "")
+}
+
+type replaceRequest struct {
+	Chapters []Input `json:"chapters"`
+}
+
+// Replace swaps the full chapter list of a lesson in one request.
+func (h *Handler) Replace(c *gin.Context) {
+	lessonID, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	var req replaceRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid chapters payload") {
+		return
+	}
+
+	chapters, err := Replace(h.db, lessonID, req.Chapters)
+	if err != nil {
+		h.respondError(c, err, "failed to replace chapters")
+		return
+	}
+
+	response.Success(c, http.StatusOK, chapters, "", nil)
+}
+
+// Update modifies an existing chapter.
+func (h *Handler) Update(c *gin.Context) {
+	lessonID, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("chapterId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid chapter id", err)
+		return
+	}
+
+	var input UpdateInput
+	if !request.BindJSON(h.logger, c, &input, "invalid chapter payload") {
+		return
+	}
+
+	chapter, err := Update(h.db, lessonID, id, input)
+	if err != nil {
+		h.respondError(c, err, "failed to update chapter")
+		return
+	}
+
+	response.Success(c, http.StatusOK, chapter, "", nil)
+}
+
+// Delete removes a chapter.
+func (h *Handler) Delete(c *gin.Context) {
+	lessonID, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("chapterId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid chapter id", err)
+		return
+	}
+
+	if err := Delete(h.db, lessonID, id); err != nil {
+		h.respondError(c, err, "failed to delete chapter")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// resolveLesson validates route ids and ensures the lesson belongs to the course and subscription.
+func (h *Handler) resolveLesson(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return uuid.Nil, false
+	}
+
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return uuid.Nil, false
+	}
+
+	var count int64
+	err = h.db.Table("lessons").
+		Joins("JOIN courses ON courses.id = lessons.course_id").
+		Where("lessons.id = ? AND courses.id = ? AND courses.subscription_id = ?", lessonID, courseID, subscriptionID).
+		Count(&count).Error
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load lesson", err)
+		return uuid.Nil, false
+	}
+	if count == 0 {
+		h.respondError(c, ErrLessonNotInCourse, "failed to load lesson")
+		return uuid.Nil, false
+	}
+
+	return lessonID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,212 @@
+package chapter
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const maxChaptersPerLesson = 100
+
+// Chapter marks a titled section of a lesson video starting at a given offset.
+type Chapter struct {
+	types.BaseModel
+
+	LessonID  uuid.UUID `gorm:"type:uuid;not null;column:lesson_id;uniqueIndex:idx_lesson_chapter_start,priority:1" json:"lessonId"`
+	Title     string    `gorm:"type:varchar(100);not null" json:"title"`
+	StartTime int       `gorm:"type:int;not null;default:0;column:start_time;uniqueIndex:idx_lesson_chapter_start,priority:2" json:"startTime"` // seconds
+}
+
+// TableName overrides the default table name.
+func (Chapter) TableName() string { return "lesson_chapters" }
+
+// Input carries chapter fields for create and bulk replace.
+type Input struct {
+	Title     string `json:"title"`
+	StartTime int    `json:"startTime"`
+}
+
+// UpdateInput captures mutable chapter fields.
+type UpdateInput struct {
+	Title     *string `json:"title"`
+	StartTime *int    `json:"startTime"`
+}
+
+// GetByLesson retrieves a lesson's chapters ordered by start time.
+func GetByLesson(db *gorm.DB, lessonID uuid.UUID) ([]Chapter, error) {
+	chapters := make([]Chapter, 0)
+	err := db.Where("lesson_id = ?", lessonID).
+		Order("start_time ASC").
+		Find(&chapters).Error
+	return chapters, err
+}
+
+// Get retrieves a chapter by ID scoped to its lesson.
+func Get(db *gorm.DB, lessonID, id uuid.UUID) (Chapter, error) {
+	var chapter Chapter
+	if err := db.First(&chapter, "id = ? AND lesson_id = ?", id, lessonID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return chapter, ErrChapterNotFound
+		}
+		return chapter, err
+	}
+	return chapter, nil
+}
+
+// Create inserts a single chapter.
+func Create(db *gorm.DB, lessonID uuid.UUID, input Input) (Chapter, error) {
+	title, err := normalizeTitle(input.Title)
+	if err != nil {
+		return Chapter{}, err
+	}
+	if input.StartTime < 0 {
+		return Chapter{}, ErrStartTimeInvalid
+	}
+
+	var existing int64
+	if err := db.Model(&Chapter{}).Where("lesson_id = ?", lessonID).Count(&existing).Error; err != nil {
+		return Chapter{}, err
+	}
+	if existing >= maxChaptersPerLesson {
+		return Chapter{}, ErrTooManyChapters
+	}
+
+	if taken, err := startTimeTaken(db, lessonID, input.StartTime, uuid.Nil); err != nil {
+		return Chapter{}, err
+	} else if taken {
+		return Chapter{}, ErrDuplicateStart
+	}
+
+	chapter := Chapter{
+		LessonID:  lessonID,
+		Title:     title,
+		StartTime: input.StartTime,
+	}
+
+	if err := db.Create(&chapter).Error; err != nil {
+		return Chapter{}, err
+	}
+
+	return chapter, nil
+}
+
+// Update modifies an existing chapter.
+func Update(db *gorm.DB, lessonID, id uuid.UUID, input UpdateInput) (Chapter, error) {
+	chapter, err := Get(db, lessonID, id)
+	if err != nil {
+		return chapter, err
+	}
+
+	if input.Title != nil {
+		title, err := normalizeTitle(*input.Title)
+		if err != nil {
+			return chapter, err
+		}
+		chapter.Title = title
+	}
+
+	if input.StartTime != nil {
+		if *input.StartTime < 0 {
+			return chapter, ErrStartTimeInvalid
+		}
+		if taken, err := startTimeTaken(db, lessonID, *input.StartTime, chapter.ID); err != nil {
+			return chapter, err
+		} else if taken {
+			return chapter, ErrDuplicateStart
+		}
+		chapter.StartTime = *input.StartTime
+	}
+
+	if err := db.Save(&chapter).Error; err != nil {
+		return chapter, err
+	}
+
+	return chapter, nil
+}
+
+// Delete removes a chapter.
+func Delete(db *gorm.DB, lessonID, id uuid.UUID) error {
+	result := db.Delete(&Chapter{}, "id = ? AND lesson_id = ?", id, lessonID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrChapterNotFound
+	}
+	return nil
+}
+
+// Replace atomically swaps all chapters of a lesson with the provided set.
+func Replace(db *gorm.DB, lessonID uuid.UUID, inputs []Input) ([]Chapter, error) {
+	if len(inputs) > maxChaptersPerLesson {
+		return nil, ErrTooManyChapters
+	}
+
+	chapters := make([]Chapter, 0, len(inputs))
+	seen := make(map[int]struct{}, len(inputs))
+	for _, input := range inputs {
+		title, err := normalizeTitle(input.Title)
+		if err != nil {
+			return nil, err
+		}
+		if input.StartTime < 0 {
+			return nil, ErrStartTimeInvalid
+		}
+		if _, dup := seen[input.StartTime]; dup {
+			return nil, ErrDuplicateStart
+		}
+		seen[input.StartTime] = struct{}{}
+
+		chapters = append(chapters, Chapter{
+			LessonID:  lessonID,
+			Title:     title,
+			StartTime: input.StartTime,
+		})
+	}
+
+	sort.Slice(chapters, func(i, j int) bool { return chapters[i].StartTime < chapters[j].StartTime })
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("lesson_id = ?", lessonID).Delete(&Chapter{}).Error; err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			return nil
+		}
+		return tx.Create(&chapters).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
+}
+
+func normalizeTitle(title string) (string, error) {
+	trimmed := strings.TrimSpace(title)
+	if trimmed == "" {
+		return "", ErrTitleRequired
+	}
+	if utf8.RuneCountInString(trimmed) > 100 {
+		return "", ErrTitleTooLong
+	}
+	return trimmed, nil
+}
+
+func startTimeTaken(db *gorm.DB, lessonID uuid.UUID, startTime int, excludeID uuid.UUID) (bool, error) {
+	query := db.Model(&Chapter{}).Where("lesson_id = ? AND start_time = ?", lessonID, startTime)
+	if excludeID != uuid.Nil {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
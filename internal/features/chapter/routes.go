@@ -0,0 +1,23 @@
+package chapter
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches lesson chapter endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff []gin.HandlerFunc) {
+	chapters := router.Group("/subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/chapters")
+
+	chapters.GET("", append(acAll, handler.List)...)
+	chapters.POST("", append(acStaff, handler.Create)...)
+	chapters.PUT("", append(acStaff, handler.Replace)...)
+	chapters.PUT("/:chapterId", append(acStaff, handler.Update)...)
+	chapters.DELETE("/:chapterId", append(acStaff, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Chapter{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: Input{}, Response: Chapter{}})
+	openapi.Describe(handler.Replace, openapi.Spec{Request: replaceRequest{}, Response: []Chapter{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: UpdateInput{}, Response: Chapter{}})
+}
@@ -0,0 +1,30 @@
+package classroom
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrNotConfigured  = errors.New("google classroom integration is not configured")
+	ErrNotConnected   = errors.New("google classroom is not connected")
+	ErrInvalidState   = errors.New("invalid or expired oauth state")
+	ErrAccessRevoked  = errors.New("google classroom access was revoked")
+	ErrCourseNotFound = errors.New("classroom course not found")
+	ErrNoCourses      = errors.New("no courses selected")
+	ErrTooManyCourses = errors.New("too many courses selected")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrNotConfigured, Status: http.StatusServiceUnavailable, Code: "CLASSROOM_NOT_CONFIGURED", Message: "Google Classroom integration is not configured."},
+	{Err: ErrNotConnected, Status: http.StatusNotFound, Code: "CLASSROOM_NOT_CONNECTED", Message: "Google Classroom is not connected."},
+	{Err: ErrAccessRevoked, Status: http.StatusConflict, Code: "CLASSROOM_ACCESS_REVOKED", Message: "Google Classroom access was revoked. Connect the account again."},
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Classroom course not found or not visible to the connected account."},
+	{Err: ErrNoCourses, Status: http.StatusBadRequest, Code: "CLASSROOM_NO_COURSES", Message: "Select at least one course."},
+	{Err: ErrTooManyCourses, Status: http.StatusBadRequest, Code: "TOO_MANY_COURSES", Message: "Too many courses selected."},
+	{Err: subscription.ErrSubscriptionNotFound, Status: http.StatusNotFound, Code: "SUBSCRIPTION_NOT_FOUND", Message: "Subscription not found."},
+}
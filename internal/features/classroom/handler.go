@@ -0,0 +1,340 @@
+package classroom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// returnPath is the frontend page the OAuth callback sends the user back to.
+const returnPath = "/settings/integrations"
+
+// Handler processes Google Classroom integration HTTP requests.
+type Handler struct {
+	db          *gorm.DB
+	logger      *slog.Logger
+	oauth       *oauth2.Config
+	frontendURL string
+	queryCache  *cache.Store
+}
+
+// NewHandler constructs a Google Classroom handler instance. Without an OAuth
+// client in the configuration every endpoint answers that the integration is
+// not configured.
+func NewHandler(db *gorm.DB, logger *slog.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		db:          db,
+		logger:      logger,
+		oauth:       newOAuthConfig(cfg.Classroom),
+		frontendURL: strings.TrimRight(cfg.Email.FrontendURL, "/"),
+	}
+}
+
+// UseCache sets the query cache whose dashboard counts imports invalidate.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+type importRequest struct {
+	CourseIDs []string `json:"courseIds" binding:"required"`
+}
+
+type connectResponse struct {
+	AuthURL string `json:"authUrl"`
+}
+
+type statusResponse struct {
+	Configured  bool       `json:"configured"`
+	Connected   bool       `json:"connected"`
+	GoogleEmail *string    `json:"googleEmail,omitempty"`
+	ConnectedBy *uuid.UUID `json:"connectedBy,omitempty"`
+	ConnectedAt *time.Time `json:"connectedAt,omitempty"`
+}
+
+// Status reports whether the subscription has connected a Google account.
+func (h *Handler) Status(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	status := statusResponse{Configured: h.oauth != nil}
+	connection, err := GetConnected(h.db, subscriptionID)
+	switch {
+	case err == nil:
+		status.Connected = true
+		status.GoogleEmail = connection.GoogleEmail
+		status.ConnectedBy = connection.ConnectedBy
+		status.ConnectedAt = connection.ConnectedAt
+	case !errors.Is(err, ErrNotConnected):
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load classroom connection", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, status, "", nil)
+}
+
+// Connect starts the OAuth flow and returns the Google consent URL.
+func (h *Handler) Connect(c *gin.Context) {
+	if !h.configured(c) {
+		return
+	}
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start classroom connection", err)
+		return
+	}
+	state := hex.EncodeToString(buf)
+
+	if err := Begin(h.db, subscriptionID, usr.ID, state, time.Now().UTC()); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start classroom connection", err)
+		return
+	}
+
+	// Offline access with a forced consent screen makes Google return a refresh token
+	authURL := h.oauth.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent"))
+	response.Success(c, http.StatusOK, connectResponse{AuthURL: authURL}, "", nil)
+}
+
+// Callback completes the OAuth flow Google redirects back to and sends the
+// user to the frontend with ?classroom=connected or ?classroom=error.
+// GET /integrations/google-classroom/callback
+func (h *Handler) Callback(c *gin.Context) {
+	if h.oauth == nil {
+		h.redirect(c, "error")
+		return
+	}
+
+	now := time.Now().UTC()
+	connection, err := ByState(h.db, c.Query("state"), now)
+	if err != nil {
+		h.logger.Warn("classroom callback rejected", slog.String("error", err.Error()))
+		h.redirect(c, "error")
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		// The user declined the consent screen
+		h.redirect(c, "error")
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.oauth.Exchange(ctx, code)
+	if err != nil {
+		h.logger.Error("failed to exchange classroom code", slog.String("subscriptionId", connection.SubscriptionID.String()), slog.String("error", err.Error()))
+		h.redirect(c, "error")
+		return
+	}
+
+	// The address only labels the connection, so failing to read it is not fatal
+	var email *string
+	if api, err := newClient(ctx, h.oauth.TokenSource(ctx, token)); err == nil {
+		if address, err := api.Email(ctx); err == nil && address != "" {
+			email = &address
+		}
+	}
+
+	if err := Complete(h.db, &connection, email, token, now); err != nil {
+		h.logger.Error("failed to store classroom connection", slog.String("subscriptionId", connection.SubscriptionID.String()), slog.String("error", err.Error()))
+		h.redirect(c, "error")
+		return
+	}
+
+	h.redirect(c, "connected")
+}
+
+// Disconnect forgets the subscription's Google account. Imported students and
+// groups are kept.
+func (h *Handler) Disconnect(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	if err := Disconnect(h.db, subscriptionID); err != nil {
+		h.respondError(c, err, "failed to disconnect google classroom")
+		return
+	}
+
+	response.NoContent(c, "Google Classroom disconnected.")
+}
+
+// Courses lists the active classes the connected account teaches.
+func (h *Handler) Courses(c *gin.Context) {
+	api, _, ok := h.client(c)
+	if !ok {
+		return
+	}
+
+	courses, err := api.Courses(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "failed to list classroom courses")
+		return
+	}
+
+	response.Success(c, http.StatusOK, courses, "", nil)
+}
+
+// Preview reports what importing the selected classes would do without
+// changing anything: which students are created or matched by email, which
+// groups are created and who cannot be imported.
+func (h *Handler) Preview(c *gin.Context) {
+	h.runImport(c, true)
+}
+
+// Import creates the missing students and groups of the selected classes and
+// adds every student to their class's group. Generated passwords are returned
+// once in the report.
+func (h *Handler) Import(c *gin.Context) {
+	h.runImport(c, false)
+}
+
+func (h *Handler) runImport(c *gin.Context, dryRun bool) {
+	var req importRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid classroom import payload") {
+		return
+	}
+	courseIDs, err := normalizeCourseIDs(req.CourseIDs)
+	if err != nil {
+		h.respondError(c, err, "invalid classroom import")
+		return
+	}
+
+	api, sub, ok := h.client(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	rosters := make([]roster, 0, len(courseIDs))
+	for _, courseID := range courseIDs {
+		course, students, err := api.Roster(ctx, courseID)
+		if err != nil {
+			h.respondError(c, err, "failed to load classroom roster")
+			return
+		}
+		rosters = append(rosters, roster{Course: course, Students: students})
+	}
+
+	report, err := plan(h.db, sub, rosters)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to plan classroom import", err)
+		return
+	}
+
+	if !dryRun {
+		if err := apply(h.db, sub, &report); err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to import classroom roster", err)
+			return
+		}
+		if report.Created > 0 {
+			h.queryCache.Invalidate(ctx, cache.NamespaceDashboard)
+		}
+	}
+
+	response.Success(c, http.StatusOK, report, "", nil)
+}
+
+// normalizeCourseIDs trims and deduplicates the selected classes.
+func normalizeCourseIDs(ids []string) ([]string, error) {
+	courseIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !slices.Contains(courseIDs, id) {
+			courseIDs = append(courseIDs, id)
+		}
+	}
+	switch {
+	case len(courseIDs) == 0:
+		return nil, ErrNoCourses
+	case len(courseIDs) > maxCourses:
+		return nil, ErrTooManyCourses
+	}
+	return courseIDs, nil
+}
+
+// client loads the subscription and a Classroom client of its connection.
+func (h *Handler) client(c *gin.Context) (*client, subscription.Subscription, bool) {
+	if !h.configured(c) {
+		return nil, subscription.Subscription{}, false
+	}
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return nil, subscription.Subscription{}, false
+	}
+
+	sub, err := subscription.Get(h.db, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load subscription")
+		return nil, subscription.Subscription{}, false
+	}
+
+	connection, err := GetConnected(h.db, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load classroom connection")
+		return nil, subscription.Subscription{}, false
+	}
+
+	api, err := connectedClient(c.Request.Context(), h.db, h.oauth, connection)
+	if err != nil {
+		h.respondError(c, err, "failed to reach google classroom")
+		return nil, subscription.Subscription{}, false
+	}
+	return api, sub, true
+}
+
+func (h *Handler) configured(c *gin.Context) bool {
+	if h.oauth == nil {
+		h.respondError(c, ErrNotConfigured, "")
+		return false
+	}
+	return true
+}
+
+func (h *Handler) redirect(c *gin.Context, outcome string) {
+	c.Redirect(http.StatusFound, h.frontendURL+returnPath+"?"+url.Values{"classroom": {outcome}}.Encode())
+}
+
+func (h *Handler) subscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+	return subscriptionID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
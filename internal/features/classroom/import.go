@@ -0,0 +1,363 @@
+package classroom
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	// maxCourses caps the classes of one import.
+	maxCourses = 20
+	// maxFullNameLength and maxGroupNameLength match users.full_name and group_access.name.
+	maxFullNameLength  = 30
+	maxGroupNameLength = 100
+)
+
+// Per-student outcomes. A preview reports new and existing; an import turns
+// them into created and added.
+const (
+	StatusNew      = "new"
+	StatusExisting = "existing"
+	StatusMember   = "member"
+	StatusCreated  = "created"
+	StatusAdded    = "added"
+	StatusFailed   = "failed"
+)
+
+// StudentResult is what importing one roster entry does or did.
+type StudentResult struct {
+	GoogleID string     `json:"googleId"`
+	FullName string     `json:"fullName"`
+	Email    string     `json:"email,omitempty"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	UserID   *uuid.UUID `json:"userId,omitempty"`
+	Password string     `json:"password,omitempty"` // only returned when the account was created
+}
+
+// CourseResult is what importing one class does or did. The class fills the
+// group access entry it was imported into before, or a new one named after it.
+type CourseResult struct {
+	CourseID  string          `json:"courseId"`
+	Name      string          `json:"name"`
+	GroupID   *uuid.UUID      `json:"groupId,omitempty"`
+	GroupName string          `json:"groupName"`
+	NewGroup  bool            `json:"newGroup"`
+	Students  []StudentResult `json:"students"`
+}
+
+// Report is the outcome of a preview or an import.
+type Report struct {
+	DryRun  bool           `json:"dryRun"`
+	Courses []CourseResult `json:"courses"`
+	Created int            `json:"created"`
+	Added   int            `json:"added"`
+	Failed  int            `json:"failed"`
+	Matched int            `json:"matched"` // existing accounts found by email
+}
+
+// roster is a class fetched from Google with its students.
+type roster struct {
+	Course   Course
+	Students []Student
+}
+
+// account is the part of a user a roster entry is matched against.
+type account struct {
+	ID             uuid.UUID
+	Email          string
+	UserType       types.UserType
+	SubscriptionID *uuid.UUID
+}
+
+// plan matches the rosters against the subscription's users and groups
+// without writing anything. Students are matched by email; each new account
+// counts once against the student limit however many classes list it.
+func plan(db *gorm.DB, sub subscription.Subscription, rosters []roster) (Report, error) {
+	report := Report{DryRun: true, Courses: make([]CourseResult, 0, len(rosters))}
+
+	courseIDs := make([]string, len(rosters))
+	emails := make([]string, 0)
+	for i, r := range rosters {
+		courseIDs[i] = r.Course.ID
+		for _, student := range r.Students {
+			if student.Email != "" {
+				emails = append(emails, student.Email)
+			}
+		}
+	}
+
+	links, err := Links(db, sub.ID, courseIDs)
+	if err != nil {
+		return report, err
+	}
+	groups, err := loadGroups(db, sub.ID, links)
+	if err != nil {
+		return report, err
+	}
+	accounts, err := loadAccounts(db, emails)
+	if err != nil {
+		return report, err
+	}
+	remaining, err := remainingStudents(db, sub)
+	if err != nil {
+		return report, err
+	}
+
+	planned := map[string]bool{}
+	matched := map[uuid.UUID]bool{}
+
+	for _, r := range rosters {
+		result := CourseResult{CourseID: r.Course.ID, Name: r.Course.Name, Students: make([]StudentResult, 0, len(r.Students))}
+
+		var members []string
+		if group, ok := groups[links[r.Course.ID]]; ok {
+			result.GroupID = &group.ID
+			result.GroupName = group.Name
+			members = group.Users
+		} else {
+			result.GroupName = groupName(r.Course)
+			result.NewGroup = true
+		}
+
+		seen := map[string]bool{}
+		for _, student := range r.Students {
+			row := StudentResult{GoogleID: student.GoogleID, FullName: fullName(student), Email: student.Email}
+
+			switch existing, found := accounts[student.Email]; {
+			case student.Email == "":
+				row.Status = StatusFailed
+				row.Error = "Google Classroom did not share this student's email."
+			case seen[student.Email]:
+				continue
+			case found && (existing.SubscriptionID == nil || *existing.SubscriptionID != sub.ID || existing.UserType != types.UserTypeStudent):
+				row.Status = StatusFailed
+				row.Error = "Email belongs to another account."
+			case found:
+				id := existing.ID
+				row.UserID = &id
+				row.Status = StatusExisting
+				if slices.Contains(members, id.String()) {
+					row.Status = StatusMember
+				}
+				matched[id] = true
+			case planned[student.Email]:
+				row.Status = StatusNew
+			case remaining == 0:
+				row.Status = StatusFailed
+				row.Error = "Student limit reached for this subscription."
+			default:
+				row.Status = StatusNew
+				planned[student.Email] = true
+				if remaining > 0 {
+					remaining--
+				}
+			}
+
+			if student.Email != "" {
+				seen[student.Email] = true
+			}
+			switch row.Status {
+			case StatusFailed:
+				report.Failed++
+			case StatusNew, StatusExisting:
+				report.Added++
+			}
+			result.Students = append(result.Students, row)
+		}
+
+		report.Courses = append(report.Courses, result)
+	}
+
+	report.Created = len(planned)
+	report.Matched = len(matched)
+	return report, nil
+}
+
+// apply carries out a plan: it creates the missing groups and accounts and
+// adds every student to their class's group. Each student is committed on
+// their own, so one failure is reported without undoing the rest.
+func apply(db *gorm.DB, sub subscription.Subscription, report *Report) error {
+	report.DryRun = false
+	report.Created, report.Added = 0, 0
+	created := map[string]uuid.UUID{}
+
+	for i := range report.Courses {
+		course := &report.Courses[i]
+
+		if course.GroupID == nil {
+			group := groupaccess.GroupAccess{SubscriptionID: sub.ID, Name: course.GroupName}
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&group).Error; err != nil {
+					return err
+				}
+				return Link(tx, sub.ID, course.CourseID, group.ID)
+			}); err != nil {
+				return err
+			}
+			course.GroupID = &group.ID
+		}
+
+		for j := range course.Students {
+			row := &course.Students[j]
+			if row.Status == StatusFailed || row.Status == StatusMember {
+				continue
+			}
+
+			if id, ok := created[row.Email]; ok {
+				row.UserID = &id
+				row.Status = StatusExisting
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if row.Status == StatusNew {
+					password, err := user.GeneratePassword()
+					if err != nil {
+						return err
+					}
+					account, err := user.Create(tx, user.CreateInput{
+						SubscriptionID: &sub.ID,
+						FullName:       row.FullName,
+						Email:          row.Email,
+						Password:       password,
+						UserType:       types.UserTypeStudent,
+					})
+					if err != nil {
+						return err
+					}
+					row.UserID = &account.ID
+					row.Password = password
+				}
+				return groupaccess.AddUser(tx, sub, *course.GroupID, *row.UserID)
+			})
+			if err != nil {
+				if row.Status == StatusNew {
+					row.UserID = nil
+					row.Password = ""
+				}
+				row.Status = StatusFailed
+				row.Error = importError(err)
+				report.Failed++
+				continue
+			}
+
+			if row.Status == StatusNew {
+				row.Status = StatusCreated
+				created[row.Email] = *row.UserID
+				report.Created++
+			} else {
+				row.Status = StatusAdded
+			}
+			report.Added++
+		}
+	}
+	return nil
+}
+
+// importError is the message reported for a student that could not be imported.
+func importError(err error) string {
+	switch {
+	case errors.Is(err, user.ErrEmailTaken):
+		return "Email already exists."
+	case errors.Is(err, groupaccess.ErrPointsLimit):
+		return "Subscription points limit reached."
+	case errors.Is(err, groupaccess.ErrGroupNotFound):
+		return "Group no longer exists."
+	}
+	return "Failed to import student."
+}
+
+// loadGroups returns the linked groups by ID.
+func loadGroups(db *gorm.DB, subscriptionID uuid.UUID, links map[string]uuid.UUID) (map[uuid.UUID]groupaccess.GroupAccess, error) {
+	groups := make(map[uuid.UUID]groupaccess.GroupAccess, len(links))
+	if len(links) == 0 {
+		return groups, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(links))
+	for _, id := range links {
+		ids = append(ids, id)
+	}
+
+	var found []groupaccess.GroupAccess
+	if err := db.Where("subscription_id = ? AND id IN ?", subscriptionID, ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, group := range found {
+		groups[group.ID] = group
+	}
+	return groups, nil
+}
+
+// loadAccounts returns the users with the given emails, by lowercase email.
+func loadAccounts(db *gorm.DB, emails []string) (map[string]account, error) {
+	accounts := make(map[string]account, len(emails))
+	if len(emails) == 0 {
+		return accounts, nil
+	}
+
+	var found []account
+	if err := db.Table("users").
+		Select("id, LOWER(email) AS email, user_type, subscription_id").
+		Where("LOWER(email) IN ?", emails).
+		Scan(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range found {
+		accounts[a.Email] = a
+	}
+	return accounts, nil
+}
+
+// remainingStudents returns how many more students the subscription may have,
+// or -1 when it is unlimited.
+func remainingStudents(db *gorm.DB, sub subscription.Subscription) (int, error) {
+	if sub.SubscriptionPoints <= 0 {
+		return -1, nil
+	}
+
+	var current int64
+	if err := db.Table("users").
+		Where("subscription_id = ? AND user_type = ?", sub.ID, types.UserTypeStudent).
+		Count(&current).Error; err != nil {
+		return 0, err
+	}
+	return max(sub.SubscriptionPoints-int(current), 0), nil
+}
+
+// fullName falls back to the email's local part for profiles without a name.
+func fullName(student Student) string {
+	name := student.FullName
+	if name == "" {
+		name, _, _ = strings.Cut(student.Email, "@")
+	}
+	return truncate(name, maxFullNameLength)
+}
+
+func groupName(course Course) string {
+	name := strings.TrimSpace(course.Name)
+	if course.Section != "" {
+		name += " - " + strings.TrimSpace(course.Section)
+	}
+	if name == "" {
+		name = "Classroom " + course.ID
+	}
+	return truncate(name, maxGroupNameLength)
+}
+
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string([]rune(text)[:limit]))
+}
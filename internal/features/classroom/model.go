@@ -0,0 +1,190 @@
+package classroom
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// stateTTL is how long the consent screen may stay open before the callback
+// is refused.
+const stateTTL = 15 * time.Minute
+
+// Connection is the Google account a subscription imports rosters from. Until
+// the OAuth callback stores its tokens it only holds the pending state.
+type Connection struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;uniqueIndex" json:"subscriptionId"`
+	ConnectedBy    *uuid.UUID `gorm:"type:uuid;column:connected_by" json:"connectedBy,omitempty"`
+	GoogleEmail    *string    `gorm:"type:varchar(255);column:google_email" json:"googleEmail,omitempty"`
+	AccessToken    *string    `gorm:"type:text;column:access_token" json:"-"`
+	RefreshToken   *string    `gorm:"type:text;column:refresh_token" json:"-"`
+	TokenType      *string    `gorm:"type:varchar(20);column:token_type" json:"-"`
+	TokenExpiry    *time.Time `gorm:"type:timestamp;column:token_expiry" json:"-"`
+	State          *string    `gorm:"type:varchar(64);column:state" json:"-"`
+	StateExpiresAt *time.Time `gorm:"type:timestamp;column:state_expires_at" json:"-"`
+	RequestedBy    *uuid.UUID `gorm:"type:uuid;column:requested_by" json:"-"`
+	ConnectedAt    *time.Time `gorm:"type:timestamp;column:connected_at" json:"connectedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Connection) TableName() string { return "classroom_connections" }
+
+// Connected reports whether the OAuth flow completed.
+func (c Connection) Connected() bool {
+	return c.RefreshToken != nil || c.AccessToken != nil
+}
+
+// Token returns the stored OAuth token.
+func (c Connection) Token() *oauth2.Token {
+	token := &oauth2.Token{}
+	if c.AccessToken != nil {
+		token.AccessToken = *c.AccessToken
+	}
+	if c.RefreshToken != nil {
+		token.RefreshToken = *c.RefreshToken
+	}
+	if c.TokenType != nil {
+		token.TokenType = *c.TokenType
+	}
+	if c.TokenExpiry != nil {
+		token.Expiry = *c.TokenExpiry
+	}
+	return token
+}
+
+// CourseLink remembers the group access entry a Classroom class was imported into.
+type CourseLink struct {
+	SubscriptionID uuid.UUID `gorm:"type:uuid;primaryKey;column:subscription_id" json:"subscriptionId"`
+	CourseID       string    `gorm:"type:varchar(64);primaryKey;column:course_id" json:"courseId"`
+	GroupID        uuid.UUID `gorm:"type:uuid;not null;column:group_id" json:"groupId"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+// TableName overrides the default table name.
+func (CourseLink) TableName() string { return "classroom_course_links" }
+
+// Get loads the subscription's connection, pending or completed.
+func Get(db *gorm.DB, subscriptionID uuid.UUID) (Connection, error) {
+	var connection Connection
+	err := db.First(&connection, "subscription_id = ?", subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return connection, ErrNotConnected
+	}
+	return connection, err
+}
+
+// GetConnected loads the subscription's connection once the OAuth flow completed.
+func GetConnected(db *gorm.DB, subscriptionID uuid.UUID) (Connection, error) {
+	connection, err := Get(db, subscriptionID)
+	if err == nil && !connection.Connected() {
+		err = ErrNotConnected
+	}
+	return connection, err
+}
+
+// Begin stores a fresh OAuth state for the subscription. A connected account
+// stays usable until the callback replaces its tokens.
+func Begin(db *gorm.DB, subscriptionID, userID uuid.UUID, state string, now time.Time) error {
+	expiresAt := now.Add(stateTTL)
+	connection := Connection{SubscriptionID: subscriptionID, State: &state, StateExpiresAt: &expiresAt, RequestedBy: &userID}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "subscription_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"state":            state,
+			"state_expires_at": expiresAt,
+			"requested_by":     userID,
+			"updated_at":       now,
+		}),
+	}).Create(&connection).Error
+}
+
+// ByState loads the connection waiting for the callback carrying state.
+func ByState(db *gorm.DB, state string, now time.Time) (Connection, error) {
+	var connection Connection
+	err := db.First(&connection, "state = ? AND state_expires_at > ?", state, now).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return connection, ErrInvalidState
+	}
+	return connection, err
+}
+
+// Complete stores the tokens of a finished OAuth flow, credits the user who
+// started it and clears its state. Google only returns a refresh token on
+// first consent, so an existing one is kept when the new token has none.
+func Complete(db *gorm.DB, connection *Connection, email *string, token *oauth2.Token, now time.Time) error {
+	updates := map[string]interface{}{
+		"connected_by":     connection.RequestedBy,
+		"requested_by":     nil,
+		"google_email":     email,
+		"access_token":     token.AccessToken,
+		"token_type":       token.TokenType,
+		"token_expiry":     token.Expiry,
+		"state":            nil,
+		"state_expires_at": nil,
+		"connected_at":     now,
+		"updated_at":       now,
+	}
+	if token.RefreshToken != "" {
+		updates["refresh_token"] = token.RefreshToken
+	}
+	if err := db.Model(&Connection{}).Where("id = ?", connection.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return db.First(connection, "id = ?", connection.ID).Error
+}
+
+// SaveToken stores a token refreshed while calling Google.
+func SaveToken(db *gorm.DB, connectionID uuid.UUID, token *oauth2.Token) error {
+	updates := map[string]interface{}{
+		"access_token": token.AccessToken,
+		"token_type":   token.TokenType,
+		"token_expiry": token.Expiry,
+		"updated_at":   time.Now(),
+	}
+	if token.RefreshToken != "" {
+		updates["refresh_token"] = token.RefreshToken
+	}
+	return db.Model(&Connection{}).Where("id = ?", connectionID).Updates(updates).Error
+}
+
+// Disconnect removes the subscription's connection. Imported users and groups stay.
+func Disconnect(db *gorm.DB, subscriptionID uuid.UUID) error {
+	result := db.Delete(&Connection{}, "subscription_id = ?", subscriptionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// Links returns the groups the given classes were imported into, by course ID.
+// Links whose group was deleted are dropped with it.
+func Links(db *gorm.DB, subscriptionID uuid.UUID, courseIDs []string) (map[string]uuid.UUID, error) {
+	var links []CourseLink
+	if err := db.Where("subscription_id = ? AND course_id IN ?", subscriptionID, courseIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	groups := make(map[string]uuid.UUID, len(links))
+	for _, link := range links {
+		groups[link.CourseID] = link.GroupID
+	}
+	return groups, nil
+}
+
+// Link records the group a class was imported into.
+func Link(db *gorm.DB, subscriptionID uuid.UUID, courseID string, groupID uuid.UUID) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "course_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"group_id"}),
+	}).Create(&CourseLink{SubscriptionID: subscriptionID, CourseID: courseID, GroupID: groupID}).Error
+}
@@ -0,0 +1,29 @@
+package classroom
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes registers Google Classroom integration routes. The OAuth
+// callback is public: Google redirects the browser to it without our token.
+// Middleware is passed as parameters to avoid import cycles
+func RegisterRoutes(r *gin.RouterGroup, handler *Handler, acAdminInstructor []gin.HandlerFunc) {
+	r.GET("/integrations/google-classroom/callback", handler.Callback)
+
+	integration := r.Group("/subscriptions/:subscriptionId/integrations/google-classroom")
+
+	integration.GET("", append(acAdminInstructor, handler.Status)...)
+	integration.DELETE("", append(acAdminInstructor, handler.Disconnect)...)
+	integration.POST("/connect", append(acAdminInstructor, handler.Connect)...)
+	integration.GET("/courses", append(acAdminInstructor, handler.Courses)...)
+	integration.POST("/import/preview", append(acAdminInstructor, handler.Preview)...)
+	integration.POST("/import", append(acAdminInstructor, handler.Import)...)
+
+	openapi.Describe(handler.Status, openapi.Spec{Response: statusResponse{}})
+	openapi.Describe(handler.Connect, openapi.Spec{Response: connectResponse{}})
+	openapi.Describe(handler.Courses, openapi.Spec{Response: []Course{}})
+	openapi.Describe(handler.Preview, openapi.Spec{Request: importRequest{}, Response: Report{}})
+	openapi.Describe(handler.Import, openapi.Spec{Request: importRequest{}, Response: Report{}})
+}
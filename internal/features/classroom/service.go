@@ -0,0 +1,162 @@
+package classroom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/classroom/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+)
+
+// scopes are read-only: classes, their rosters and the members' emails, which
+// students are matched by.
+var scopes = []string{
+	classroom.ClassroomCoursesReadonlyScope,
+	classroom.ClassroomRostersReadonlyScope,
+	classroom.ClassroomProfileEmailsScope,
+}
+
+// Course is a Google Classroom class the connected account teaches.
+type Course struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Section string `json:"section,omitempty"`
+	Link    string `json:"link,omitempty"`
+}
+
+// Student is a member of a class roster.
+type Student struct {
+	GoogleID string
+	FullName string
+	Email    string
+}
+
+// newOAuthConfig returns the OAuth client, or nil while it is not configured.
+func newOAuthConfig(cfg config.ClassroomConfig) *oauth2.Config {
+	if cfg.ClientID == "" {
+		return nil
+	}
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       scopes,
+		Endpoint:     google.Endpoint,
+	}
+}
+
+// client calls the Classroom API as a connected account.
+type client struct {
+	api *classroom.Service
+}
+
+// newClient returns a client authenticated by source.
+func newClient(ctx context.Context, source oauth2.TokenSource) (*client, error) {
+	api, err := classroom.NewService(ctx, option.WithTokenSource(source))
+	if err != nil {
+		return nil, err
+	}
+	return &client{api: api}, nil
+}
+
+// connectedClient refreshes the connection's token if it expired, stores the
+// new one and returns a client using it.
+func connectedClient(ctx context.Context, db *gorm.DB, oauth *oauth2.Config, connection Connection) (*client, error) {
+	stored := connection.Token()
+	source := oauth.TokenSource(ctx, stored)
+
+	token, err := source.Token()
+	if err != nil {
+		return nil, mapAPIError(err)
+	}
+	if token.AccessToken != stored.AccessToken {
+		if err := SaveToken(db, connection.ID, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return newClient(ctx, source)
+}
+
+// Email returns the connected account's email address.
+func (c *client) Email(ctx context.Context) (string, error) {
+	profile, err := c.api.UserProfiles.Get("me").Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	return profile.EmailAddress, nil
+}
+
+// Courses lists the active classes the account teaches.
+func (c *client) Courses(ctx context.Context) ([]Course, error) {
+	courses := make([]Course, 0)
+	err := c.api.Courses.List().TeacherId("me").CourseStates("ACTIVE").PageSize(100).
+		Pages(ctx, func(page *classroom.ListCoursesResponse) error {
+			for _, course := range page.Courses {
+				courses = append(courses, toCourse(course))
+			}
+			return nil
+		})
+	return courses, mapAPIError(err)
+}
+
+// Roster returns a class and its students.
+func (c *client) Roster(ctx context.Context, courseID string) (Course, []Student, error) {
+	course, err := c.api.Courses.Get(courseID).Context(ctx).Do()
+	if err != nil {
+		return Course{}, nil, courseError(err)
+	}
+
+	students := make([]Student, 0)
+	err = c.api.Courses.Students.List(courseID).PageSize(100).
+		Pages(ctx, func(page *classroom.ListStudentsResponse) error {
+			for _, student := range page.Students {
+				if student.Profile == nil {
+					continue
+				}
+				entry := Student{
+					GoogleID: student.UserId,
+					Email:    strings.ToLower(strings.TrimSpace(student.Profile.EmailAddress)),
+				}
+				if student.Profile.Name != nil {
+					entry.FullName = strings.TrimSpace(student.Profile.Name.FullName)
+				}
+				students = append(students, entry)
+			}
+			return nil
+		})
+	if err != nil {
+		return Course{}, nil, courseError(err)
+	}
+	return toCourse(course), students, nil
+}
+
+func toCourse(course *classroom.Course) Course {
+	return Course{ID: course.Id, Name: course.Name, Section: course.Section, Link: course.AlternateLink}
+}
+
+// courseError reports classes the account cannot see as ErrCourseNotFound.
+func courseError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusForbidden) {
+		return ErrCourseNotFound
+	}
+	return mapAPIError(err)
+}
+
+// mapAPIError reports refresh tokens Google no longer accepts as ErrAccessRevoked.
+func mapAPIError(err error) error {
+	var retrieve *oauth2.RetrieveError
+	if errors.As(err, &retrieve) && retrieve.ErrorCode == "invalid_grant" {
+		return ErrAccessRevoked
+	}
+	return err
+}
@@ -1,9 +1,21 @@
 package comment
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
 	ErrCommentNotFound = errors.New("comment not found")
 	ErrContentRequired = errors.New("comment content is required")
 	ErrUnauthorized    = errors.New("not authorized to perform this action")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrCommentNotFound, Status: http.StatusNotFound, Code: "COMMENT_NOT_FOUND", Message: "Comment not found."},
+	{Err: ErrContentRequired, Status: http.StatusBadRequest, Code: "COMMENT_CONTENT_REQUIRED", Message: "Comment content is required."},
+	{Err: ErrUnauthorized, Status: http.StatusForbidden, Message: "Not authorized."},
+}
@@ -1,7 +1,6 @@
 package comment
 
 import (
-	"errors"
 	"net/http"
 
 	"log/slog"
@@ -10,20 +9,37 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
-	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
+// Live events emitted to sockets watching a lesson.
+const (
+	EventCommentAdded   = "commentAdded"
+	EventCommentDeleted = "commentDeleted"
+)
+
+// LessonBroadcaster pushes live updates to clients watching a lesson.
+type LessonBroadcaster interface {
+	EmitToLesson(lessonID uuid.UUID, event string, payload any)
+}
+
 // Handler processes comment HTTP requests.
 type Handler struct {
-	db     *gorm.DB
-	logger *slog.Logger
+	db       *gorm.DB
+	logger   *slog.Logger
+	events   LessonBroadcaster
+	notifier *notification.Service
 }
 
-// NewHandler constructs a comment handler instance.
-func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
-	return &Handler{db: db, logger: logger}
+// NewHandler constructs a comment handler instance. events and notifier may be nil
+// to disable live updates and mention notifications.
+func NewHandler(db *gorm.DB, logger *slog.Logger, events LessonBroadcaster, notifier *notification.Service) *Handler {
+	return &Handler{db: db, logger: logger, events: events, notifier: notifier}
 }
 
 // List returns all comments for a lesson.
@@ -34,6 +50,25 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	// Lessons with long threads can be paged with ?cursor=; without it the
+	// full list is returned as before.
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
+	if params.Cursor {
+		comments, err := ListByLesson(h.db, lessonID, params)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load comments", err)
+			return
+		}
+
+		comments, meta := pagination.Page(comments, 0, params)
+		response.Success(c, http.StatusOK, comments, "", meta)
+		return
+	}
+
 	comments, err := GetByLesson(h.db, lessonID)
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load comments", err)
@@ -43,6 +78,11 @@ func (h *Handler) List(c *gin.Context) {
 	response.Success(c, http.StatusOK, comments, "", nil)
 }
 
+type createRequest struct {
+	Content string  `json:"content" binding:"required"`
+	Parent  *string `json:"parent"`
+}
+
 // Create inserts a new comment.
 func (h *Handler) Create(c *gin.Context) {
 	lessonID, err := uuid.Parse(c.Param("lessonId"))
@@ -57,13 +97,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Content string  `json:"content" binding:"required"`
-		Parent  *string `json:"parent"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid comment payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid comment payload") {
 		return
 	}
 
@@ -91,6 +127,26 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	if h.events != nil {
+		h.events.EmitToLesson(lessonID, EventCommentAdded, comment)
+	}
+
+	if h.notifier != nil {
+		subscriptionID, subErr := uuid.Parse(c.Param("subscriptionId"))
+		courseID, courseErr := uuid.Parse(c.Param("courseId"))
+		if subErr == nil && courseErr == nil {
+			h.notifier.CommentCreated(notification.CommentEvent{
+				SubscriptionID: subscriptionID,
+				CourseID:       courseID,
+				LessonID:       lessonID,
+				CommentID:      comment.ID,
+				ActorID:        currentUser.ID,
+				ActorName:      currentUser.FullName,
+				Text:           req.Content,
+			})
+		}
+	}
+
 	response.Created(c, comment, "")
 }
 
@@ -122,11 +178,7 @@ func (h *Handler) Delete(c *gin.Context) {
 	}
 
 	// Check authorization: owner, instructor, assistant, admin, or superadmin can delete
-	canDelete := currentUser.ID == comment.UserID ||
-		currentUser.UserType == types.UserTypeInstructor ||
-		currentUser.UserType == types.UserTypeAssistant ||
-		currentUser.UserType == types.UserTypeAdmin ||
-		currentUser.UserType == types.UserTypeSuperAdmin
+	canDelete := currentUser.ID == comment.UserID || authz.CanModerate(authz.SubjectFrom(currentUser))
 
 	if !canDelete {
 		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "not authorized", nil)
@@ -138,24 +190,17 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
+	if h.events != nil {
+		h.events.EmitToLesson(lessonID, EventCommentDeleted, gin.H{
+			"lessonId":  lessonID,
+			"commentId": commentID,
+			"parentId":  comment.ParentID,
+		})
+	}
+
 	response.Success(c, http.StatusOK, true, "", nil)
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrCommentNotFound):
-		status = http.StatusNotFound
-		message = "Comment not found."
-	case errors.Is(err, ErrContentRequired):
-		status = http.StatusBadRequest
-		message = "Comment content is required."
-	case errors.Is(err, ErrUnauthorized):
-		status = http.StatusForbidden
-		message = "Not authorized."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
@@ -25,6 +26,11 @@ type Comment struct {
 // TableName overrides the default table name.
 func (Comment) TableName() string { return "comments" }
 
+// CursorKey returns the keyset position of the comment for cursor pagination.
+func (c Comment) CursorKey() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
 // CreateInput carries data for creating a new comment.
 type CreateInput struct {
 	LessonID uuid.UUID
@@ -44,6 +50,13 @@ func GetByLesson(db *gorm.DB, lessonID uuid.UUID) ([]Comment, error) {
 	return comments, err
 }
 
+// ListByLesson retrieves one cursor page of a lesson's comments, newest first.
+func ListByLesson(db *gorm.DB, lessonID uuid.UUID, params pagination.Params) ([]Comment, error) {
+	comments := make([]Comment, 0)
+	err := pagination.Apply(db.Where("lesson_id = ?", lessonID), params).Find(&comments).Error
+	return comments, err
+}
+
 // Get retrieves a comment by ID.
 func Get(db *gorm.DB, id uuid.UUID) (Comment, error) {
 	var comment Comment
@@ -78,27 +91,8 @@ func Create(db *gorm.DB, input CreateInput) (Comment, error) {
 	return comment, nil
 }
 
-// Delete removes a comment and all its children recursively.
+// Delete removes a comment; its replies go with it through their foreign key.
 func Delete(db *gorm.DB, id, lessonID uuid.UUID) error {
-	return deleteWithChildren(db, id, lessonID)
-}
-
-// deleteWithChildren recursively deletes a comment and all its child comments.
-func deleteWithChildren(db *gorm.DB, id, lessonID uuid.UUID) error {
-	// Find all children
-	var children []Comment
-	if err := db.Where("parent_id = ? AND lesson_id = ?", id, lessonID).Find(&children).Error; err != nil {
-		return err
-	}
-
-	// Recursively delete children
-	for _, child := range children {
-		if err := deleteWithChildren(db, child.ID, lessonID); err != nil {
-			return err
-		}
-	}
-
-	// Delete the comment itself
 	result := db.Where("id = ? AND lesson_id = ?", id, lessonID).Delete(&Comment{})
 	if result.Error != nil {
 		return result.Error
@@ -2,6 +2,8 @@ package comment
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes attaches comment endpoints to the router.
@@ -11,4 +13,7 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll []gin.Handl
 	comments.GET("", append(acAll, handler.List)...)
 	comments.POST("", append(acAll, handler.Create)...)
 	comments.DELETE("/:commentId", append(acAll, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Comment{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Comment{}})
 }
@@ -0,0 +1,130 @@
+package contentsync
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// checkpointSkew moves the returned checkpoint back so rows written by
+// transactions still open while the feed was read are picked up next time.
+const checkpointSkew = 30 * time.Second
+
+// Handler serves the delta sync feed for offline clients.
+type Handler struct {
+	db            *gorm.DB
+	logger        *slog.Logger
+	storageClient *bunny.StorageClient
+	retention     time.Duration
+}
+
+// NewHandler constructs a sync handler. Deletions are remembered for
+// retentionDays; older checkpoints receive a full sync.
+func NewHandler(db *gorm.DB, logger *slog.Logger, storageClient *bunny.StorageClient, retentionDays int) *Handler {
+	return &Handler{
+		db:            db,
+		logger:        logger,
+		storageClient: storageClient,
+		retention:     time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+type syncResponse struct {
+	// Checkpoint is the since value of the client's next sync.
+	Checkpoint time.Time `json:"checkpoint"`
+	// FullSync asks the client to replace its copy rather than apply a delta.
+	FullSync bool `json:"fullSync"`
+	Feed
+}
+
+// Sync returns courses, lessons, attachments and announcements created, updated
+// or deleted since the client's last checkpoint. Clients drop the lessons and
+// attachments of a deleted course or lesson themselves.
+// GET /subscriptions/:subscriptionId/sync?since=<RFC3339 checkpoint>
+func (h *Handler) Sync(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		if since, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "since", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
+			return
+		}
+	}
+
+	// Read from the primary: a lagging replica could skip rows older than the checkpoint
+	db := h.db.WithContext(c.Request.Context())
+	checkpoint := time.Now().UTC().Add(-checkpointSkew)
+
+	// Tombstones older than the retention window are pruned, so such clients start over
+	fullSync := since.IsZero() || since.Before(time.Now().Add(-h.retention))
+
+	scope := Scope{SubscriptionID: subscriptionID}
+	if usr.UserType == types.UserTypeStudent {
+		scope.Student = true
+		scope.UserID = usr.ID
+		scope.UserType = usr.UserType
+		scope.EnrolledAt = usr.CreatedAt
+
+		if scope.CourseIDs, err = AccessibleCourseIDs(db, subscriptionID, usr.ID); err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load sync changes", err)
+			return
+		}
+
+		// Group edits can grant or revoke whole courses without touching them
+		if !fullSync {
+			if fullSync, err = GroupsChangedSince(db, subscriptionID, since); err != nil {
+				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load sync changes", err)
+				return
+			}
+		}
+	}
+	if fullSync {
+		since = time.Time{}
+	}
+
+	feed, err := Load(db, scope, since)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load sync changes", err)
+		return
+	}
+
+	h.sign(&feed)
+	response.Success(c, http.StatusOK, syncResponse{Checkpoint: checkpoint, FullSync: fullSync, Feed: feed}, "", nil)
+}
+
+func (h *Handler) sign(feed *Feed) {
+	for _, courses := range [][]course.Course{feed.Courses.Created, feed.Courses.Updated} {
+		for i := range courses {
+			course.SignImage(h.storageClient, &courses[i])
+		}
+	}
+	for _, attachments := range [][]attachment.Attachment{feed.Attachments.Created, feed.Attachments.Updated} {
+		for i := range attachments {
+			attachment.SignPath(h.storageClient, &attachments[i])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package contentsync
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PruneJob deletes tombstones older than the retention window.
+type PruneJob struct {
+	db        *gorm.DB
+	logger    *slog.Logger
+	retention time.Duration
+}
+
+// NewPruneJob constructs the tombstone pruning job.
+func NewPruneJob(db *gorm.DB, logger *slog.Logger, retentionDays int) *PruneJob {
+	return &PruneJob{db: db, logger: logger, retention: time.Duration(retentionDays) * 24 * time.Hour}
+}
+
+// Name returns the job name.
+func (j *PruneJob) Name() string {
+	return "sync-tombstone-prune"
+}
+
+// Execute removes expired tombstones.
+func (j *PruneJob) Execute(ctx context.Context) error {
+	pruned, err := PruneTombstones(j.db.WithContext(ctx), time.Now().Add(-j.retention))
+	if err != nil {
+		return fmt.Errorf("prune sync tombstones: %w", err)
+	}
+
+	if pruned > 0 {
+		j.logger.Info("sync tombstones pruned", slog.Int64("count", pruned))
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package contentsync
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
+	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Entity types recorded in tombstones, as passed by the triggers of migration 037.
+const (
+	EntityCourse       = "course"
+	EntityLesson       = "lesson"
+	EntityAttachment   = "attachment"
+	EntityAnnouncement = "announcement"
+	EntityGroupAccess  = "group_access"
+)
+
+// Tombstone remembers a deleted row so offline clients can drop their copy.
+// Rows are written by database triggers, never by the application.
+type Tombstone struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;column:subscription_id;index:idx_sync_tombstones_subscription_deleted,priority:1" json:"subscriptionId"`
+	EntityType     string    `gorm:"type:varchar(20);not null;column:entity_type" json:"entityType"`
+	EntityID       uuid.UUID `gorm:"type:uuid;not null;column:entity_id" json:"entityId"`
+	DeletedAt      time.Time `gorm:"not null;default:now();column:deleted_at;index:idx_sync_tombstones_subscription_deleted,priority:2" json:"deletedAt"`
+}
+
+// TableName overrides the default table name.
+func (Tombstone) TableName() string { return "sync_tombstones" }
+
+// Scope limits a sync to what a subscription member may see.
+type Scope struct {
+	SubscriptionID uuid.UUID
+	// CourseIDs restricts the sync to these courses; nil means every course.
+	CourseIDs []string
+	// Student filters announcements to those visible to UserID.
+	Student  bool
+	UserID   uuid.UUID
+	UserType types.UserType
+	// EnrolledAt measures student drip schedules; see lesson.ApplyDrip.
+	EnrolledAt time.Time
+}
+
+// ChangeSet lists the rows of one entity created, updated and deleted since a checkpoint.
+type ChangeSet[T any] struct {
+	Created []T         `json:"created"`
+	Updated []T         `json:"updated"`
+	Deleted []uuid.UUID `json:"deleted"`
+}
+
+func newChangeSet[T any]() ChangeSet[T] {
+	return ChangeSet[T]{Created: []T{}, Updated: []T{}, Deleted: []uuid.UUID{}}
+}
+
+// add files a row touched since the checkpoint. Rows the member can no longer
+// see, such as deactivated ones, are reported as deleted.
+func (s *ChangeSet[T]) add(item T, base types.BaseModel, visible bool, since time.Time) {
+	switch {
+	case !visible:
+		s.Deleted = append(s.Deleted, base.ID)
+	case base.CreatedAt.After(since):
+		s.Created = append(s.Created, item)
+	default:
+		s.Updated = append(s.Updated, item)
+	}
+}
+
+// Feed is the content changed since a checkpoint.
+type Feed struct {
+	Courses       ChangeSet[course.Course]             `json:"courses"`
+	Lessons       ChangeSet[lesson.Lesson]             `json:"lessons"`
+	Attachments   ChangeSet[attachment.Attachment]     `json:"attachments"`
+	Announcements ChangeSet[announcement.Announcement] `json:"announcements"`
+}
+
+// Visibility of synced rows. Status is checked alongside is_active, so drafts
+// never reach members even while marked active.
+const (
+	courseVisible     = "courses.is_active AND courses.status = 'published'"
+	lessonVisible     = courseVisible + " AND lessons.is_active AND lessons.status = 'published'"
+	attachmentVisible = lessonVisible + " AND attachments.is_active"
+)
+
+type syncedLesson struct {
+	lesson.Lesson
+	Visible bool `gorm:"column:visible;->"`
+}
+
+type syncedAttachment struct {
+	attachment.Attachment
+	Visible bool `gorm:"column:visible;->"`
+}
+
+// Load collects the changes since the checkpoint, or every visible row when
+// since is zero. A course or lesson that changed brings all of its children
+// along, so content reappearing after reactivation reaches clients again.
+func Load(db *gorm.DB, scope Scope, since time.Time) (Feed, error) {
+	feed := Feed{
+		Courses:       newChangeSet[course.Course](),
+		Lessons:       newChangeSet[lesson.Lesson](),
+		Attachments:   newChangeSet[attachment.Attachment](),
+		Announcements: newChangeSet[announcement.Announcement](),
+	}
+	full := since.IsZero()
+
+	var courses []course.Course
+	query := scoped(db.Model(&course.Course{}), scope)
+	if full {
+		query = query.Where(courseVisible)
+	} else {
+		query = query.Where("courses.updated_at > ?", since)
+	}
+	if err := query.Order("courses.\"order\" ASC").Find(&courses).Error; err != nil {
+		return feed, err
+	}
+	for _, item := range courses {
+		feed.Courses.add(item, item.BaseModel, item.VisibleToStudents(), since)
+	}
+
+	var lessons []syncedLesson
+	query = scoped(db.Table("lessons").
+		Select("lessons.*, "+lessonVisible+" AS visible").
+		Joins("JOIN courses ON courses.id = lessons.course_id"), scope)
+	if full {
+		query = query.Where(lessonVisible)
+	} else {
+		query = query.Where("lessons.updated_at > ? OR courses.updated_at > ?", since, since)
+	}
+	if err := query.Order("lessons.\"order\" ASC").Find(&lessons).Error; err != nil {
+		return feed, err
+	}
+	for _, item := range lessons {
+		feed.Lessons.add(item.Lesson, item.BaseModel, item.Visible, since)
+	}
+	if scope.Student {
+		// Locked lessons carry availableAt, so clients can unlock them on time
+		// without the row changing again
+		now := time.Now().UTC()
+		lesson.ApplyDrip(feed.Lessons.Created, scope.EnrolledAt, now)
+		lesson.ApplyDrip(feed.Lessons.Updated, scope.EnrolledAt, now)
+	}
+
+	var attachments []syncedAttachment
+	query = scoped(db.Table("attachments").
+		Select("attachments.*, "+attachmentVisible+" AS visible").
+		Joins("JOIN lessons ON lessons.id = attachments.lesson_id").
+		Joins("JOIN courses ON courses.id = lessons.course_id"), scope)
+	if full {
+		query = query.Where(attachmentVisible)
+	} else {
+		query = query.Where("attachments.updated_at > ? OR lessons.updated_at > ? OR courses.updated_at > ?", since, since, since)
+	}
+	if err := query.Order("attachments.\"order\" ASC").Find(&attachments).Error; err != nil {
+		return feed, err
+	}
+	for _, item := range attachments {
+		feed.Attachments.add(item.Attachment, item.BaseModel, item.Visible, since)
+	}
+
+	if err := loadAnnouncements(db, scope, since, &feed.Announcements); err != nil {
+		return feed, err
+	}
+
+	if full {
+		return feed, nil
+	}
+	return feed, addTombstones(db, scope.SubscriptionID, since, &feed)
+}
+
+// scoped restricts a query joined with courses to the scope's courses.
+func scoped(query *gorm.DB, scope Scope) *gorm.DB {
+	query = query.Where("courses.subscription_id = ?", scope.SubscriptionID)
+	if scope.CourseIDs != nil {
+		query = query.Where("courses.id IN ?", scope.CourseIDs)
+	}
+	return query
+}
+
+func loadAnnouncements(db *gorm.DB, scope Scope, since time.Time, changes *ChangeSet[announcement.Announcement]) error {
+	visibleTo := func(query *gorm.DB) *gorm.DB {
+		query = query.Where("is_active = ?", true)
+		if scope.Student {
+			query = announcement.VisibleTo(query, scope.SubscriptionID, scope.UserID, scope.UserType)
+		}
+		return query
+	}
+
+	query := db.Model(&announcement.Announcement{}).Where("subscription_id = ?", scope.SubscriptionID)
+	if since.IsZero() {
+		query = visibleTo(query)
+	} else {
+		query = query.Where("updated_at > ?", since)
+	}
+
+	var announcements []announcement.Announcement
+	if err := query.Order("created_at DESC").Find(&announcements).Error; err != nil {
+		return err
+	}
+	if len(announcements) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(announcements))
+	for i, item := range announcements {
+		ids[i] = item.ID
+	}
+	var visibleIDs []uuid.UUID
+	if err := visibleTo(db.Model(&announcement.Announcement{}).Where("id IN ?", ids)).
+		Pluck("id", &visibleIDs).Error; err != nil {
+		return err
+	}
+	visible := make(map[uuid.UUID]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+
+	for _, item := range announcements {
+		changes.add(item, item.BaseModel, visible[item.ID], since)
+	}
+	return nil
+}
+
+// addTombstones reports rows deleted since the checkpoint. Members may receive
+// ids they never saw; clients ignore those.
+func addTombstones(db *gorm.DB, subscriptionID uuid.UUID, since time.Time, feed *Feed) error {
+	var tombstones []Tombstone
+	if err := db.Where("subscription_id = ? AND deleted_at > ?", subscriptionID, since).
+		Order("deleted_at ASC").
+		Find(&tombstones).Error; err != nil {
+		return err
+	}
+
+	for _, tombstone := range tombstones {
+		switch tombstone.EntityType {
+		case EntityCourse:
+			feed.Courses.Deleted = append(feed.Courses.Deleted, tombstone.EntityID)
+		case EntityLesson:
+			feed.Lessons.Deleted = append(feed.Lessons.Deleted, tombstone.EntityID)
+		case EntityAttachment:
+			feed.Attachments.Deleted = append(feed.Attachments.Deleted, tombstone.EntityID)
+		case EntityAnnouncement:
+			feed.Announcements.Deleted = append(feed.Announcements.Deleted, tombstone.EntityID)
+		}
+	}
+	return nil
+}
+
+// AccessibleCourseIDs returns the courses a student reaches through their access
+// groups, directly or through one of the course's lessons, as on the student dashboard.
+func AccessibleCourseIDs(db *gorm.DB, subscriptionID, userID uuid.UUID) ([]string, error) {
+	ids := make([]string, 0)
+	err := db.Raw(`
+		SELECT UNNEST(courses)::text FROM group_access WHERE subscription_id = ? AND ? = ANY(users)
+		UNION
+		SELECT course_id::text FROM lessons
+		WHERE is_active = TRUE AND status = 'published' AND id IN (SELECT UNNEST(lessons) FROM group_access WHERE subscription_id = ? AND ? = ANY(users))`,
+		subscriptionID, userID.String(),
+		subscriptionID, userID.String(),
+	).Scan(&ids).Error
+	return ids, err
+}
+
+// GroupsChangedSince reports whether any access group of the subscription was
+// edited or deleted after since, which may change what its students can see.
+func GroupsChangedSince(db *gorm.DB, subscriptionID uuid.UUID, since time.Time) (bool, error) {
+	var changed bool
+	err := db.Raw(`SELECT
+		EXISTS (SELECT 1 FROM group_access WHERE subscription_id = ? AND updated_at > ?)
+		OR EXISTS (SELECT 1 FROM sync_tombstones WHERE subscription_id = ? AND entity_type = ? AND deleted_at > ?)`,
+		subscriptionID, since,
+		subscriptionID, EntityGroupAccess, since,
+	).Scan(&changed).Error
+	return changed, err
+}
+
+// PruneTombstones deletes tombstones recorded before the cutoff.
+func PruneTombstones(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("deleted_at < ?", before).Delete(&Tombstone{})
+	return result.RowsAffected, result.Error
+}
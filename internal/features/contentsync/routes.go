@@ -0,0 +1,14 @@
+package contentsync
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the delta sync endpoint to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll []gin.HandlerFunc) {
+	router.GET("/subscriptions/:subscriptionId/sync", append(acAll, handler.Sync)...)
+
+	openapi.Describe(handler.Sync, openapi.Spec{Response: syncResponse{}})
+}
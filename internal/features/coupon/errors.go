@@ -0,0 +1,64 @@
+package coupon
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrCouponNotFound       = errors.New("coupon not found")
+	ErrCodeRequired         = errors.New("coupon code is required")
+	ErrCodeTaken            = errors.New("coupon code already exists")
+	ErrInvalidDiscountType  = errors.New("invalid discount type")
+	ErrInvalidDiscountValue = errors.New("invalid discount value")
+	ErrInvalidLimit         = errors.New("usage limits must be at least 1")
+	ErrInvalidPackage       = errors.New("package not found")
+	ErrInvalidWindow        = errors.New("coupon expiry must be after its start")
+	ErrCouponInactive       = errors.New("coupon is not active")
+	ErrCouponNotStarted     = errors.New("coupon is not valid yet")
+	ErrCouponExpired        = errors.New("coupon has expired")
+	ErrCouponExhausted      = errors.New("coupon usage limit reached")
+	ErrUserLimitReached     = errors.New("coupon already used the maximum number of times by this user")
+	ErrPackageNotEligible   = errors.New("coupon does not apply to this package")
+)
+
+// IsRejection reports whether err means the coupon cannot be used, as opposed
+// to an infrastructure failure. Callers applying coupons during checkout use it
+// to answer with a client error.
+func IsRejection(err error) bool {
+	for _, target := range []error{
+		ErrCouponNotFound,
+		ErrCouponInactive,
+		ErrCouponNotStarted,
+		ErrCouponExpired,
+		ErrCouponExhausted,
+		ErrUserLimitReached,
+		ErrPackageNotEligible,
+		ErrInvalidPackage,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrCouponNotFound, Status: http.StatusNotFound, Code: "COUPON_NOT_FOUND", Message: "Coupon not found."},
+	{Err: ErrCodeTaken, Status: http.StatusConflict, Code: "COUPON_CODE_TAKEN", Message: "Coupon code already exists."},
+	{Err: ErrCodeRequired, Status: http.StatusBadRequest, Code: "COUPON_CODE_REQUIRED"},
+	{Err: ErrInvalidDiscountType, Status: http.StatusBadRequest, Code: "INVALID_DISCOUNT_TYPE"},
+	{Err: ErrInvalidDiscountValue, Status: http.StatusBadRequest, Code: "INVALID_DISCOUNT_VALUE"},
+	{Err: ErrInvalidLimit, Status: http.StatusBadRequest, Code: "INVALID_COUPON_LIMIT"},
+	{Err: ErrInvalidPackage, Status: http.StatusBadRequest, Code: "INVALID_COUPON_PACKAGE"},
+	{Err: ErrInvalidWindow, Status: http.StatusBadRequest, Code: "INVALID_COUPON_WINDOW"},
+	{Err: ErrCouponInactive, Status: http.StatusUnprocessableEntity, Code: "COUPON_INACTIVE"},
+	{Err: ErrCouponNotStarted, Status: http.StatusUnprocessableEntity, Code: "COUPON_NOT_STARTED"},
+	{Err: ErrCouponExpired, Status: http.StatusUnprocessableEntity, Code: "COUPON_EXPIRED"},
+	{Err: ErrCouponExhausted, Status: http.StatusUnprocessableEntity, Code: "COUPON_EXHAUSTED"},
+	{Err: ErrUserLimitReached, Status: http.StatusUnprocessableEntity, Code: "COUPON_USER_LIMIT_REACHED"},
+	{Err: ErrPackageNotEligible, Status: http.StatusUnprocessableEntity, Code: "PACKAGE_NOT_ELIGIBLE"},
+}
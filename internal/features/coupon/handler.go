@@ -0,0 +1,285 @@
+package coupon
+
+import (
+	"net/http"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Handler processes coupon HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a coupon handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// List returns paginated coupons.
+func (h *Handler) List(c *gin.Context) {
+	params := pagination.Extract(c)
+
+	coupons, total, err := List(h.db, params, c.Query("filterKeyword"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list coupons", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, coupons, "", pagination.MetadataFrom(total, params))
+}
+
+type createRequest struct {
+	Code           string   `json:"code" binding:"required,notblank"`
+	Description    *string  `json:"description"`
+	DiscountType   string   `json:"discountType" binding:"required,oneof=percentage fixed"`
+	DiscountValue  float64  `json:"discountValue" binding:"required,gt=0"`
+	MaxRedemptions *int     `json:"maxRedemptions" binding:"omitnil,gte=0"`
+	PerUserLimit   *int     `json:"perUserLimit" binding:"omitnil,gte=0"`
+	PackageIDs     []string `json:"packageIds" binding:"max=100"`
+	StartsAt       *string  `json:"startsAt" binding:"omitempty,rfc3339"`
+	ExpiresAt      *string  `json:"expiresAt" binding:"omitempty,rfc3339"`
+	Active         *bool    `json:"isActive"`
+}
+
+// Create inserts a new coupon.
+func (h *Handler) Create(c *gin.Context) {
+	var req createRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid coupon payload") {
+		return
+	}
+
+	startsAt, err := request.ParseRFC3339Ptr(req.StartsAt)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "startsAt must be RFC3339", err)
+		return
+	}
+
+	expiresAt, err := request.ParseRFC3339Ptr(req.ExpiresAt)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "expiresAt must be RFC3339", err)
+		return
+	}
+
+	coupon, err := Create(h.db, CreateInput{
+		Code:           req.Code,
+		Description:    req.Description,
+		DiscountType:   req.DiscountType,
+		DiscountValue:  types.NewMoney(req.DiscountValue),
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		PackageIDs:     req.PackageIDs,
+		StartsAt:       startsAt,
+		ExpiresAt:      expiresAt,
+		Active:         req.Active,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to create coupon")
+		return
+	}
+
+	response.Created(c, coupon, "")
+}
+
+// GetByID fetches a single coupon.
+func (h *Handler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("couponId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid coupon id", err)
+		return
+	}
+
+	coupon, err := Get(h.db, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load coupon")
+		return
+	}
+
+	response.Success(c, http.StatusOK, coupon, "", nil)
+}
+
+type updateRequest struct {
+	Description    validation.Optional[string]   `json:"description" binding:"omitnil,notblank"`
+	DiscountType   *string                       `json:"discountType" binding:"omitnil,oneof=percentage fixed"`
+	DiscountValue  *float64                      `json:"discountValue" binding:"omitnil,gt=0"`
+	MaxRedemptions validation.Optional[int]      `json:"maxRedemptions" binding:"omitnil,gte=0"`
+	PerUserLimit   validation.Optional[int]      `json:"perUserLimit" binding:"omitnil,gte=0"`
+	PackageIDs     validation.Optional[[]string] `json:"packageIds"`
+	StartsAt       validation.Optional[string]   `json:"startsAt" binding:"omitnil,rfc3339"`
+	ExpiresAt      validation.Optional[string]   `json:"expiresAt" binding:"omitnil,rfc3339"`
+	Active         *bool                         `json:"isActive"`
+}
+
+// Update modifies an existing coupon.
+func (h *Handler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("couponId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid coupon id", err)
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid coupon payload") {
+		return
+	}
+
+	input := UpdateInput{
+		DescriptionProvided:    req.Description.Set,
+		Description:            request.Trimmed(req.Description.Ptr()),
+		DiscountType:           req.DiscountType,
+		MaxRedemptionsProvided: req.MaxRedemptions.Set,
+		MaxRedemptions:         req.MaxRedemptions.Ptr(),
+		PerUserLimitProvided:   req.PerUserLimit.Set,
+		PerUserLimit:           req.PerUserLimit.Ptr(),
+		StartsAtProvided:       req.StartsAt.Set,
+		ExpiresAtProvided:      req.ExpiresAt.Set,
+		Active:                 req.Active,
+	}
+
+	if req.DiscountValue != nil {
+		m := types.NewMoney(*req.DiscountValue)
+		input.DiscountValue = &m
+	}
+	if req.PackageIDs.Set {
+		ids := request.TrimStrings(req.PackageIDs.Value)
+		input.PackageIDs = &ids
+	}
+
+	if value := req.StartsAt.Ptr(); value != nil {
+		parsed, err := time.Parse(time.RFC3339, *value)
+		if err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "startsAt", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
+			return
+		}
+		input.StartsAt = &parsed
+	}
+	if value := req.ExpiresAt.Ptr(); value != nil {
+		parsed, err := time.Parse(time.RFC3339, *value)
+		if err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "expiresAt", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
+			return
+		}
+		input.ExpiresAt = &parsed
+	}
+
+	coupon, err := Update(h.db, id, input)
+	if err != nil {
+		h.respondError(c, err, "failed to update coupon")
+		return
+	}
+
+	response.Success(c, http.StatusOK, coupon, "", nil)
+}
+
+// Delete removes a coupon.
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("couponId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid coupon id", err)
+		return
+	}
+
+	if err := Delete(h.db, id); err != nil {
+		h.respondError(c, err, "failed to delete coupon")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// Redemptions lists the recorded uses of a coupon.
+func (h *Handler) Redemptions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("couponId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid coupon id", err)
+		return
+	}
+
+	params := pagination.Extract(c)
+
+	redemptions, total, err := ListRedemptions(h.db, id, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list coupon redemptions", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, redemptions, "", pagination.MetadataFrom(total, params))
+}
+
+type validateRequest struct {
+	Code      string   `json:"code" binding:"required"`
+	PackageID *string  `json:"packageId"`
+	Amount    *float64 `json:"amount"`
+}
+
+// Validate checks a coupon for the current user and returns the discount it
+// would grant, without consuming it.
+func (h *Handler) Validate(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req validateRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid coupon payload") {
+		return
+	}
+
+	input := ApplyInput{Code: req.Code, UserID: currentUser.ID}
+
+	if req.PackageID != nil && *req.PackageID != "" {
+		packageID, err := uuid.Parse(*req.PackageID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid package id", err)
+			return
+		}
+		input.PackageID = &packageID
+	}
+
+	switch {
+	case req.Amount != nil:
+		if *req.Amount < 0 {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "amount cannot be negative", nil)
+			return
+		}
+		input.Amount = types.NewMoney(*req.Amount)
+	case input.PackageID != nil:
+		price, err := PackagePrice(h.db, *input.PackageID)
+		if err != nil {
+			h.respondError(c, err, "failed to load package price")
+			return
+		}
+		input.Amount = price
+	default:
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "amount or packageId is required", nil)
+		return
+	}
+
+	quote, err := Apply(h.db, input, time.Now().UTC())
+	if err != nil {
+		h.respondError(c, err, "failed to validate coupon")
+		return
+	}
+
+	response.Success(c, http.StatusOK, quote, "", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
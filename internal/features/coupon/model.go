@@ -0,0 +1,490 @@
+package coupon
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	packageModel "github.com/mo-amir99/lms-server-go/internal/features/package"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Discount types.
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+// Coupon is a discount code that can be applied to package payments and purchases.
+type Coupon struct {
+	types.BaseModel
+
+	Code            string         `gorm:"type:varchar(50);not null;uniqueIndex" json:"code"`
+	Description     *string        `gorm:"type:varchar(500)" json:"description,omitempty"`
+	DiscountType    string         `gorm:"type:varchar(20);not null;column:discount_type" json:"discountType"`
+	DiscountValue   types.Money    `gorm:"type:numeric(10,2);not null;column:discount_value" json:"discountValue"`
+	MaxRedemptions  *int           `gorm:"type:int;column:max_redemptions" json:"maxRedemptions,omitempty"`
+	PerUserLimit    *int           `gorm:"type:int;column:per_user_limit" json:"perUserLimit,omitempty"`
+	RedemptionCount int            `gorm:"type:int;not null;default:0;column:redemption_count" json:"redemptionCount"`
+	PackageIDs      pq.StringArray `gorm:"type:uuid[];not null;default:'{}';column:package_ids" json:"packageIds"`
+	StartsAt        *time.Time     `gorm:"type:timestamp;column:starts_at" json:"startsAt,omitempty"`
+	ExpiresAt       *time.Time     `gorm:"type:timestamp;column:expires_at;index" json:"expiresAt,omitempty"`
+	Active          bool           `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
+}
+
+// TableName overrides the default table name.
+func (Coupon) TableName() string { return "coupons" }
+
+// Redemption records one use of a coupon.
+type Redemption struct {
+	types.BaseModel
+
+	CouponID       uuid.UUID   `gorm:"type:uuid;not null;column:coupon_id;index:idx_coupon_redemptions_coupon_user,priority:1" json:"couponId"`
+	UserID         uuid.UUID   `gorm:"type:uuid;not null;column:user_id;index:idx_coupon_redemptions_coupon_user,priority:2" json:"userId"`
+	PackageID      *uuid.UUID  `gorm:"type:uuid;column:package_id" json:"packageId,omitempty"`
+	PaymentID      *uuid.UUID  `gorm:"type:uuid;column:payment_id" json:"paymentId,omitempty"`
+	PurchaseID     *uuid.UUID  `gorm:"type:uuid;column:purchase_id" json:"purchaseId,omitempty"`
+	OriginalAmount types.Money `gorm:"type:numeric(10,2);not null;column:original_amount" json:"originalAmount"`
+	Discount       types.Money `gorm:"type:numeric(10,2);not null" json:"discount"`
+}
+
+// TableName overrides the default table name.
+func (Redemption) TableName() string { return "coupon_redemptions" }
+
+// Quote is the result of applying a coupon to an amount.
+type Quote struct {
+	CouponID       uuid.UUID   `json:"couponId"`
+	Code           string      `json:"code"`
+	OriginalAmount types.Money `json:"originalAmount"`
+	Discount       types.Money `json:"discount"`
+	FinalAmount    types.Money `json:"finalAmount"`
+}
+
+// CreateInput carries data for creating a coupon.
+type CreateInput struct {
+	Code           string
+	Description    *string
+	DiscountType   string
+	DiscountValue  types.Money
+	MaxRedemptions *int
+	PerUserLimit   *int
+	PackageIDs     []string
+	StartsAt       *time.Time
+	ExpiresAt      *time.Time
+	Active         *bool
+}
+
+// UpdateInput captures mutable coupon fields.
+type UpdateInput struct {
+	Description            *string
+	DescriptionProvided    bool
+	DiscountType           *string
+	DiscountValue          *types.Money
+	MaxRedemptions         *int
+	MaxRedemptionsProvided bool
+	PerUserLimit           *int
+	PerUserLimitProvided   bool
+	PackageIDs             *[]string
+	StartsAt               *time.Time
+	StartsAtProvided       bool
+	ExpiresAt              *time.Time
+	ExpiresAtProvided      bool
+	Active                 *bool
+}
+
+// ApplyInput identifies who applies a coupon and to what.
+type ApplyInput struct {
+	Code      string
+	UserID    uuid.UUID
+	PackageID *uuid.UUID
+	Amount    types.Money
+}
+
+// RedeemInput extends ApplyInput with the record the redemption belongs to.
+type RedeemInput struct {
+	ApplyInput
+	PaymentID  *uuid.UUID
+	PurchaseID *uuid.UUID
+}
+
+// NormalizeCode trims and upper-cases a coupon code.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// List retrieves paginated coupons, newest first.
+func List(db *gorm.DB, params pagination.Params, keyword string) ([]Coupon, int64, error) {
+	query := db.Model(&Coupon{})
+	if keyword != "" {
+		query = query.Where("code ILIKE ?", "%"+NormalizeCode(keyword)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var coupons []Coupon
+	err := query.
+		Order("created_at DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Find(&coupons).Error
+
+	return coupons, total, err
+}
+
+// Get retrieves a coupon by ID.
+func Get(db *gorm.DB, id uuid.UUID) (Coupon, error) {
+	var coupon Coupon
+	if err := db.First(&coupon, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return coupon, ErrCouponNotFound
+		}
+		return coupon, err
+	}
+	return coupon, nil
+}
+
+// GetByCode retrieves a coupon by its code.
+func GetByCode(db *gorm.DB, code string) (Coupon, error) {
+	var coupon Coupon
+	if err := db.First(&coupon, "code = ?", NormalizeCode(code)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return coupon, ErrCouponNotFound
+		}
+		return coupon, err
+	}
+	return coupon, nil
+}
+
+// Create inserts a new coupon.
+func Create(db *gorm.DB, input CreateInput) (Coupon, error) {
+	code := NormalizeCode(input.Code)
+	if code == "" {
+		return Coupon{}, ErrCodeRequired
+	}
+
+	packageIDs, err := normalizePackages(db, input.PackageIDs)
+	if err != nil {
+		return Coupon{}, err
+	}
+
+	coupon := Coupon{
+		Code:           code,
+		Description:    input.Description,
+		DiscountType:   input.DiscountType,
+		DiscountValue:  input.DiscountValue,
+		MaxRedemptions: input.MaxRedemptions,
+		PerUserLimit:   input.PerUserLimit,
+		PackageIDs:     pq.StringArray(packageIDs),
+		StartsAt:       input.StartsAt,
+		ExpiresAt:      input.ExpiresAt,
+		Active:         true,
+	}
+	if input.Active != nil {
+		coupon.Active = *input.Active
+	}
+
+	if err := validate(coupon); err != nil {
+		return Coupon{}, err
+	}
+
+	var count int64
+	if err := db.Model(&Coupon{}).Where("code = ?", code).Count(&count).Error; err != nil {
+		return Coupon{}, err
+	}
+	if count > 0 {
+		return Coupon{}, ErrCodeTaken
+	}
+
+	if err := db.Create(&coupon).Error; err != nil {
+		return Coupon{}, err
+	}
+
+	// GORM omits false for columns with a default, so persist the flag explicitly.
+	if !coupon.Active {
+		if err := db.Model(&coupon).Update("is_active", false).Error; err != nil {
+			return Coupon{}, err
+		}
+	}
+
+	return coupon, nil
+}
+
+// Update modifies an existing coupon.
+func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Coupon, error) {
+	coupon, err := Get(db, id)
+	if err != nil {
+		return coupon, err
+	}
+
+	if input.DescriptionProvided {
+		coupon.Description = input.Description
+	}
+	if input.DiscountType != nil {
+		coupon.DiscountType = *input.DiscountType
+	}
+	if input.DiscountValue != nil {
+		coupon.DiscountValue = *input.DiscountValue
+	}
+	if input.MaxRedemptionsProvided {
+		coupon.MaxRedemptions = input.MaxRedemptions
+	}
+	if input.PerUserLimitProvided {
+		coupon.PerUserLimit = input.PerUserLimit
+	}
+	if input.PackageIDs != nil {
+		packageIDs, err := normalizePackages(db, *input.PackageIDs)
+		if err != nil {
+			return coupon, err
+		}
+		coupon.PackageIDs = pq.StringArray(packageIDs)
+	}
+	if input.StartsAtProvided {
+		coupon.StartsAt = input.StartsAt
+	}
+	if input.ExpiresAtProvided {
+		coupon.ExpiresAt = input.ExpiresAt
+	}
+	if input.Active != nil {
+		coupon.Active = *input.Active
+	}
+
+	if err := validate(coupon); err != nil {
+		return coupon, err
+	}
+
+	if err := db.Save(&coupon).Error; err != nil {
+		return coupon, err
+	}
+
+	return coupon, nil
+}
+
+// Delete removes a coupon.
+func Delete(db *gorm.DB, id uuid.UUID) error {
+	result := db.Delete(&Coupon{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+// ListRedemptions returns the paginated redemptions of a coupon.
+func ListRedemptions(db *gorm.DB, couponID uuid.UUID, params pagination.Params) ([]Redemption, int64, error) {
+	query := db.Model(&Redemption{}).Where("coupon_id = ?", couponID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var redemptions []Redemption
+	err := query.
+		Order("created_at DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Find(&redemptions).Error
+
+	return redemptions, total, err
+}
+
+// Apply checks that the coupon can be used and computes the discount without
+// recording a redemption.
+func Apply(db *gorm.DB, input ApplyInput, now time.Time) (Quote, error) {
+	coupon, err := GetByCode(db, input.Code)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	if err := checkEligibility(db, coupon, input, now); err != nil {
+		return Quote{}, err
+	}
+
+	return quote(coupon, input.Amount), nil
+}
+
+// Redeem applies the coupon and records the redemption, consuming one use.
+// Run it inside the transaction that creates the payment or purchase so a
+// failed checkout does not burn the coupon.
+func Redeem(db *gorm.DB, input RedeemInput, now time.Time) (Quote, error) {
+	// Lock the coupon row so concurrent redemptions are checked one at a time;
+	// otherwise two checkouts could both pass the per-user limit.
+	var locked Coupon
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&locked, "code = ?", NormalizeCode(input.Code)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Quote{}, ErrCouponNotFound
+		}
+		return Quote{}, err
+	}
+
+	if err := checkEligibility(db, locked, input.ApplyInput, now); err != nil {
+		return Quote{}, err
+	}
+	q := quote(locked, input.Amount)
+
+	if err := db.Model(&Coupon{}).
+		Where("id = ?", q.CouponID).
+		Update("redemption_count", gorm.Expr("redemption_count + 1")).Error; err != nil {
+		return Quote{}, err
+	}
+
+	redemption := Redemption{
+		CouponID:       q.CouponID,
+		UserID:         input.UserID,
+		PackageID:      input.PackageID,
+		PaymentID:      input.PaymentID,
+		PurchaseID:     input.PurchaseID,
+		OriginalAmount: q.OriginalAmount,
+		Discount:       q.Discount,
+	}
+	if err := db.Create(&redemption).Error; err != nil {
+		return Quote{}, err
+	}
+
+	return q, nil
+}
+
+// PackagePrice returns the list price used when a coupon is applied to a
+// package without an explicit amount.
+func PackagePrice(db *gorm.DB, packageID uuid.UUID) (types.Money, error) {
+	var pkg packageModel.Package
+	if err := db.First(&pkg, "id = ?", packageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.Money{}, ErrInvalidPackage
+		}
+		return types.Money{}, err
+	}
+
+	price := pkg.Price
+	if price.IsZero() && pkg.SubscriptionPoints != nil && pkg.SubscriptionPointPrice != nil {
+		price = pkg.SubscriptionPointPrice.Mul(float64(*pkg.SubscriptionPoints))
+	}
+	return price.Mul(1 - pkg.DiscountPercentage/100).Round(2), nil
+}
+
+func checkEligibility(db *gorm.DB, coupon Coupon, input ApplyInput, now time.Time) error {
+	if !coupon.Active {
+		return ErrCouponInactive
+	}
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return ErrCouponNotStarted
+	}
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		return ErrCouponExpired
+	}
+	if coupon.MaxRedemptions != nil && coupon.RedemptionCount >= *coupon.MaxRedemptions {
+		return ErrCouponExhausted
+	}
+
+	if len(coupon.PackageIDs) > 0 {
+		if input.PackageID == nil {
+			return ErrPackageNotEligible
+		}
+		eligible := false
+		for _, id := range coupon.PackageIDs {
+			if id == input.PackageID.String() {
+				eligible = true
+				break
+			}
+		}
+		if !eligible {
+			return ErrPackageNotEligible
+		}
+	}
+
+	if coupon.PerUserLimit != nil {
+		var used int64
+		if err := db.Model(&Redemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, input.UserID).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(*coupon.PerUserLimit) {
+			return ErrUserLimitReached
+		}
+	}
+
+	return nil
+}
+
+func quote(coupon Coupon, amount types.Money) Quote {
+	discount := coupon.DiscountValue
+	if coupon.DiscountType == DiscountPercentage {
+		discount = amount.Mul(coupon.DiscountValue.Float64() / 100).Round(2)
+	}
+	if discount.GreaterThan(amount) {
+		discount = amount
+	}
+
+	return Quote{
+		CouponID:       coupon.ID,
+		Code:           coupon.Code,
+		OriginalAmount: amount,
+		Discount:       discount,
+		FinalAmount:    amount.Sub(discount),
+	}
+}
+
+func validate(coupon Coupon) error {
+	switch coupon.DiscountType {
+	case DiscountPercentage:
+		if !coupon.DiscountValue.GreaterThan(types.NewMoney(0)) || coupon.DiscountValue.GreaterThan(types.NewMoney(100)) {
+			return ErrInvalidDiscountValue
+		}
+	case DiscountFixed:
+		if !coupon.DiscountValue.GreaterThan(types.NewMoney(0)) {
+			return ErrInvalidDiscountValue
+		}
+	default:
+		return ErrInvalidDiscountType
+	}
+
+	if (coupon.MaxRedemptions != nil && *coupon.MaxRedemptions < 1) || (coupon.PerUserLimit != nil && *coupon.PerUserLimit < 1) {
+		return ErrInvalidLimit
+	}
+
+	if coupon.StartsAt != nil && coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(*coupon.StartsAt) {
+		return ErrInvalidWindow
+	}
+
+	return nil
+}
+
+func normalizePackages(db *gorm.DB, ids []string) ([]string, error) {
+	result := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, value := range ids {
+		id, err := uuid.Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, ErrInvalidPackage
+		}
+		if !seen[id.String()] {
+			seen[id.String()] = true
+			result = append(result, id.String())
+		}
+	}
+
+	if len(result) > 0 {
+		var count int64
+		if err := db.Model(&packageModel.Package{}).Where("id IN ?", result).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(result) {
+			return nil, ErrInvalidPackage
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package coupon
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches coupon endpoints under /coupons.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly, allUsers []gin.HandlerFunc) {
+	coupons := router.Group("/coupons")
+
+	coupons.POST("/validate", append(allUsers, handler.Validate)...)
+
+	coupons.GET("", append(adminOnly, handler.List)...)
+	coupons.POST("", append(adminOnly, handler.Create)...)
+	coupons.GET("/:couponId", append(adminOnly, handler.GetByID)...)
+	coupons.PUT("/:couponId", append(adminOnly, handler.Update)...)
+	coupons.DELETE("/:couponId", append(adminOnly, handler.Delete)...)
+	coupons.GET("/:couponId/redemptions", append(adminOnly, handler.Redemptions)...)
+
+	openapi.Describe(handler.Validate, openapi.Spec{Request: validateRequest{}, Response: Quote{}})
+	openapi.Describe(handler.List, openapi.Spec{Response: []Coupon{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Coupon{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Coupon{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Coupon{}})
+	openapi.Describe(handler.Redemptions, openapi.Spec{Response: []Redemption{}})
+}
@@ -0,0 +1,153 @@
+package course
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// CatalogSection is one storefront shelf. Category is nil for the courses
+// without one, which come last.
+type CatalogSection struct {
+	Category *CatalogCategory `json:"category"`
+	Courses  []CatalogCourse  `json:"courses"`
+}
+
+// CatalogCategory is the public part of a category.
+type CatalogCategory struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// CatalogCourse is the public part of a course.
+type CatalogCourse struct {
+	ID            uuid.UUID           `json:"id"`
+	Name          string              `json:"name"`
+	Description   *string             `json:"description,omitempty"`
+	Image         *string             `json:"image,omitempty"`
+	ImageVariants types.ImageVariants `json:"imageVariants,omitempty"`
+	Price         *types.Money        `json:"price,omitempty"`
+	Tags          []string            `json:"tags"`
+	UpdatedAt     time.Time           `json:"updatedAt"`
+}
+
+// Catalog returns the subscription's public, published courses grouped by category.
+func Catalog(db *gorm.DB, subscriptionID uuid.UUID) ([]CatalogSection, error) {
+	categories, err := ListCategories(db, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var courses []Course
+	if err := db.Where("subscription_id = ? AND is_public AND is_active AND status = ?", subscriptionID, types.ContentStatusPublished).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Order("\"order\" ASC, name ASC").
+		Find(&courses).Error; err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[uuid.UUID][]CatalogCourse)
+	uncategorized := make([]CatalogCourse, 0)
+	for _, course := range courses {
+		entry := CatalogCourse{
+			ID:            course.ID,
+			Name:          course.Name,
+			Description:   course.Description,
+			Image:         course.Image,
+			ImageVariants: course.ImageVariants,
+			Price:         course.Price,
+			Tags:          make([]string, len(course.Tags)),
+			UpdatedAt:     course.UpdatedAt,
+		}
+		for i, tag := range course.Tags {
+			entry.Tags[i] = tag.Name
+		}
+		if course.CategoryID == nil {
+			uncategorized = append(uncategorized, entry)
+			continue
+		}
+		byCategory[*course.CategoryID] = append(byCategory[*course.CategoryID], entry)
+	}
+
+	sections := make([]CatalogSection, 0, len(categories)+1)
+	for _, category := range categories {
+		if len(byCategory[category.ID]) == 0 {
+			continue
+		}
+		sections = append(sections, CatalogSection{
+			Category: &CatalogCategory{
+				ID:          category.ID,
+				Name:        category.Name,
+				Description: category.Description,
+				UpdatedAt:   category.UpdatedAt,
+			},
+			Courses: byCategory[category.ID],
+		})
+	}
+	if len(uncategorized) > 0 {
+		sections = append(sections, CatalogSection{Courses: uncategorized})
+	}
+	return sections, nil
+}
+
+// Catalog is the public storefront listing of a subscription, addressed by its
+// identifier name. Inactive and expired subscriptions have no catalog.
+func (h *Handler) Catalog(c *gin.Context) {
+	ctx := c.Request.Context()
+	reader := replica.Reader(h.db.WithContext(ctx))
+
+	sub, err := subscription.GetByIdentifier(reader, c.Param("identifier"))
+	if errors.Is(err, subscription.ErrSubscriptionNotFound) || (err == nil && (!sub.Active || sub.IsExpired(time.Now()))) {
+		h.respondError(c, ErrCatalogNotFound, "catalog not found")
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load subscription", err)
+		return
+	}
+
+	namespace := CacheNamespace(sub.ID)
+	var sections []CatalogSection
+	if !h.queryCache.Get(ctx, namespace, "catalog", &sections) {
+		if sections, err = Catalog(reader, sub.ID); err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load catalog", err)
+			return
+		}
+		h.queryCache.Set(ctx, namespace, "catalog", sections)
+	}
+
+	parts := []interface{}{h.signingEpoch()}
+	for _, section := range sections {
+		if section.Category != nil {
+			parts = append(parts, section.Category.ID, section.Category.UpdatedAt)
+		}
+		for _, course := range section.Courses {
+			parts = append(parts, course.ID, course.UpdatedAt)
+		}
+	}
+	if response.NotModified(c, response.ETag(parts...)) {
+		return
+	}
+
+	for i := range sections {
+		for j := range sections[i].Courses {
+			entry := &sections[i].Courses[j]
+			signed := Course{Image: entry.Image, ImageVariants: entry.ImageVariants}
+			h.signImage(&signed)
+			entry.Image, entry.ImageVariants = signed.Image, signed.ImageVariants
+		}
+	}
+
+	response.Success(c, http.StatusOK, sections, "", nil)
+}
@@ -0,0 +1,458 @@
+package course
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// EnrollmentStatus is where an enrollment request stands.
+type EnrollmentStatus string
+
+const (
+	EnrollmentPending  EnrollmentStatus = "pending"
+	EnrollmentApproved EnrollmentStatus = "approved"
+	EnrollmentRejected EnrollmentStatus = "rejected"
+)
+
+// EnrollmentRequest is a student asking to join a public course.
+type EnrollmentRequest struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID        `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	CourseID       uuid.UUID        `gorm:"type:uuid;not null;column:course_id" json:"courseId"`
+	UserID         uuid.UUID        `gorm:"type:uuid;not null;column:user_id" json:"userId"`
+	Status         EnrollmentStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Message        *string          `gorm:"type:varchar(500)" json:"message,omitempty"`
+	GroupAccessID  *uuid.UUID       `gorm:"type:uuid;column:group_access_id" json:"groupAccessId,omitempty"`
+	ReviewNote     *string          `gorm:"type:varchar(500);column:review_note" json:"reviewNote,omitempty"`
+	ReviewedBy     *uuid.UUID       `gorm:"type:uuid;column:reviewed_by" json:"reviewedBy,omitempty"`
+	ReviewedAt     *time.Time       `gorm:"type:timestamptz;column:reviewed_at" json:"reviewedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (EnrollmentRequest) TableName() string { return "enrollment_requests" }
+
+// EnrollmentRequestView is a request with the student and course it is about.
+type EnrollmentRequestView struct {
+	EnrollmentRequest
+	FullName   string `json:"fullName"`
+	Email      string `json:"email"`
+	CourseName string `json:"courseName"`
+}
+
+// EnrollmentFilters narrows the request listing.
+type EnrollmentFilters struct {
+	SubscriptionID uuid.UUID
+	CourseID       *uuid.UUID
+	UserID         *uuid.UUID
+	Status         EnrollmentStatus
+}
+
+// ListEnrollmentRequests returns requests newest first.
+func ListEnrollmentRequests(db *gorm.DB, filters EnrollmentFilters, params pagination.Params) ([]EnrollmentRequestView, int64, error) {
+	query := db.Table("enrollment_requests").
+		Joins("JOIN users ON users.id = enrollment_requests.user_id").
+		Joins("JOIN courses ON courses.id = enrollment_requests.course_id").
+		Where("enrollment_requests.subscription_id = ?", filters.SubscriptionID)
+	if filters.CourseID != nil {
+		query = query.Where("enrollment_requests.course_id = ?", *filters.CourseID)
+	}
+	if filters.UserID != nil {
+		query = query.Where("enrollment_requests.user_id = ?", *filters.UserID)
+	}
+	if filters.Status != "" {
+		query = query.Where("enrollment_requests.status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	requests := make([]EnrollmentRequestView, 0)
+	err := query.
+		Select("enrollment_requests.*, users.full_name, users.email, courses.name AS course_name").
+		Order("enrollment_requests.created_at DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Scan(&requests).Error
+	return requests, total, err
+}
+
+// RequestEnrollment files a student's request to join a public course. Students
+// who already reach the course, or are waiting on a request, cannot ask again.
+func RequestEnrollment(db *gorm.DB, course Course, userID uuid.UUID, message *string) (EnrollmentRequest, error) {
+	if !course.Public || !course.VisibleToStudents() {
+		return EnrollmentRequest{}, ErrCourseNotFound
+	}
+
+	var enrolled int64
+	if err := db.Table("group_access").
+		Where("subscription_id = ? AND ? = ANY(users) AND ? = ANY(courses)", course.SubscriptionID, userID, course.ID).
+		Count(&enrolled).Error; err != nil {
+		return EnrollmentRequest{}, err
+	}
+	if enrolled > 0 {
+		return EnrollmentRequest{}, ErrAlreadyEnrolled
+	}
+
+	var pending int64
+	if err := db.Model(&EnrollmentRequest{}).
+		Where("course_id = ? AND user_id = ? AND status = ?", course.ID, userID, EnrollmentPending).
+		Count(&pending).Error; err != nil {
+		return EnrollmentRequest{}, err
+	}
+	if pending > 0 {
+		return EnrollmentRequest{}, ErrEnrollmentPending
+	}
+
+	enrollment := EnrollmentRequest{
+		SubscriptionID: course.SubscriptionID,
+		CourseID:       course.ID,
+		UserID:         userID,
+		Status:         EnrollmentPending,
+		Message:        message,
+	}
+	if err := db.Create(&enrollment).Error; err != nil {
+		return EnrollmentRequest{}, err
+	}
+	return enrollment, nil
+}
+
+// ApproveEnrollment adds the student to groupID, or to the course's enrollment
+// group when groupID is nil, and closes the request.
+func ApproveEnrollment(db *gorm.DB, subscriptionID, id uuid.UUID, groupID *uuid.UUID, reviewer uuid.UUID) (EnrollmentRequest, error) {
+	var enrollment EnrollmentRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if enrollment, err = lockPendingEnrollment(tx, subscriptionID, id); err != nil {
+			return err
+		}
+
+		course, err := GetForSubscription(tx, enrollment.CourseID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if groupID == nil {
+			groupID = course.EnrollmentGroupID
+		}
+		if groupID == nil {
+			return ErrEnrollmentGroupRequired
+		}
+		if err := checkEnrollmentGroup(tx, course, *groupID); err != nil {
+			return err
+		}
+
+		sub, err := subscription.Get(tx, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if err := groupaccess.AddUser(tx, sub, *groupID, enrollment.UserID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		enrollment.Status = EnrollmentApproved
+		enrollment.GroupAccessID = groupID
+		enrollment.ReviewedBy = &reviewer
+		enrollment.ReviewedAt = &now
+		return tx.Save(&enrollment).Error
+	})
+	return enrollment, err
+}
+
+// RejectEnrollment closes the request without enrolling the student.
+func RejectEnrollment(db *gorm.DB, subscriptionID, id uuid.UUID, note *string, reviewer uuid.UUID) (EnrollmentRequest, error) {
+	var enrollment EnrollmentRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if enrollment, err = lockPendingEnrollment(tx, subscriptionID, id); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		enrollment.Status = EnrollmentRejected
+		enrollment.ReviewNote = note
+		enrollment.ReviewedBy = &reviewer
+		enrollment.ReviewedAt = &now
+		return tx.Save(&enrollment).Error
+	})
+	return enrollment, err
+}
+
+// SetPublic opts the course in or out of the catalog. The enrollment group must
+// grant the course.
+func SetPublic(db *gorm.DB, course *Course, public bool, price *types.Money, groupID *uuid.UUID) error {
+	if groupID != nil {
+		if err := checkEnrollmentGroup(db, *course, *groupID); err != nil {
+			return err
+		}
+	}
+	return db.Model(course).Updates(map[string]interface{}{
+		"is_public":           public,
+		"price":               price,
+		"enrollment_group_id": groupID,
+	}).Error
+}
+
+func lockPendingEnrollment(tx *gorm.DB, subscriptionID, id uuid.UUID) (EnrollmentRequest, error) {
+	var enrollment EnrollmentRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&enrollment, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return enrollment, ErrEnrollmentNotFound
+		}
+		return enrollment, err
+	}
+	if enrollment.Status != EnrollmentPending {
+		return enrollment, ErrEnrollmentReviewed
+	}
+	return enrollment, nil
+}
+
+// checkEnrollmentGroup makes sure joining the group gives access to the course.
+func checkEnrollmentGroup(db *gorm.DB, course Course, groupID uuid.UUID) error {
+	var count int64
+	if err := db.Table("group_access").
+		Where("id = ? AND subscription_id = ? AND ? = ANY(courses)", groupID, course.SubscriptionID, course.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrEnrollmentGroupInvalid
+	}
+	return nil
+}
+
+type publicRequest struct {
+	Public            *bool                        `json:"isPublic" binding:"required"`
+	Price             validation.Optional[float64] `json:"price" binding:"omitnil,gte=0"`
+	EnrollmentGroupID *uuid.UUID                   `json:"enrollmentGroupId"`
+}
+
+type enrollmentRequest struct {
+	Message *string `json:"message" binding:"omitnil,max=500"`
+}
+
+type approveEnrollmentRequest struct {
+	GroupAccessID *uuid.UUID `json:"groupAccessId"`
+}
+
+type rejectEnrollmentRequest struct {
+	Note *string `json:"note" binding:"omitnil,max=500"`
+}
+
+// SetCoursePublic lists the course in the public catalog, or removes it.
+func (h *Handler) SetCoursePublic(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	var req publicRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid course visibility payload") {
+		return
+	}
+
+	course, err := GetForSubscription(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	var price *types.Money
+	if value := req.Price.Ptr(); value != nil {
+		m := types.NewMoney(*value)
+		price = &m
+	}
+
+	if err := SetPublic(h.db, &course, *req.Public, price, req.EnrollmentGroupID); err != nil {
+		h.respondError(c, err, "failed to update course visibility")
+		return
+	}
+
+	h.respondWithTags(c, id, subscriptionID)
+}
+
+// RequestEnrollment lets a student ask to join a public course.
+func (h *Handler) RequestEnrollment(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+	if usr.UserType != types.UserTypeStudent {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Only students can request enrollment.", nil)
+		return
+	}
+
+	var req enrollmentRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid enrollment payload") {
+		return
+	}
+
+	course, err := GetForSubscription(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	enrollment, err := RequestEnrollment(h.db, course, usr.ID, request.Trimmed(req.Message))
+	if err != nil {
+		h.respondError(c, err, "failed to request enrollment")
+		return
+	}
+
+	response.Created(c, enrollment, "Enrollment requested.")
+}
+
+// ListEnrollmentRequests returns the subscription's enrollment requests for staff.
+func (h *Handler) ListEnrollmentRequests(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	courseID, ok := optionalID(c, "courseId")
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", nil)
+		return
+	}
+
+	status := EnrollmentStatus(c.Query("status"))
+	switch status {
+	case "", EnrollmentPending, EnrollmentApproved, EnrollmentRejected:
+	default:
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "status must be pending, approved or rejected", nil)
+		return
+	}
+
+	h.listEnrollments(c, EnrollmentFilters{SubscriptionID: subscriptionID, CourseID: courseID, Status: status})
+}
+
+// MyEnrollmentRequests returns the current student's enrollment requests.
+func (h *Handler) MyEnrollmentRequests(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	h.listEnrollments(c, EnrollmentFilters{SubscriptionID: subscriptionID, UserID: &usr.ID})
+}
+
+func (h *Handler) listEnrollments(c *gin.Context, filters EnrollmentFilters) {
+	params := pagination.Extract(c)
+	requests, total, err := ListEnrollmentRequests(h.db, filters, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list enrollment requests", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, requests, "", pagination.MetadataFrom(total, params))
+}
+
+// ApproveEnrollment enrolls the student by adding them to an access group.
+func (h *Handler) ApproveEnrollment(c *gin.Context) {
+	subscriptionID, id, usr, ok := h.enrollmentParams(c)
+	if !ok {
+		return
+	}
+
+	var req approveEnrollmentRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid approval payload") {
+		return
+	}
+
+	enrollment, err := ApproveEnrollment(h.db, subscriptionID, id, req.GroupAccessID, usr.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to approve enrollment")
+		return
+	}
+
+	response.Success(c, http.StatusOK, enrollment, "Enrollment approved.", nil)
+}
+
+// RejectEnrollment declines an enrollment request.
+func (h *Handler) RejectEnrollment(c *gin.Context) {
+	subscriptionID, id, usr, ok := h.enrollmentParams(c)
+	if !ok {
+		return
+	}
+
+	var req rejectEnrollmentRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid rejection payload") {
+		return
+	}
+
+	enrollment, err := RejectEnrollment(h.db, subscriptionID, id, request.Trimmed(req.Note), usr.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to reject enrollment")
+		return
+	}
+
+	response.Success(c, http.StatusOK, enrollment, "Enrollment rejected.", nil)
+}
+
+func (h *Handler) enrollmentParams(c *gin.Context) (uuid.UUID, uuid.UUID, *middleware.User, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, uuid.Nil, nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("requestId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid enrollment request id", err)
+		return uuid.Nil, uuid.Nil, nil, false
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return uuid.Nil, uuid.Nil, nil, false
+	}
+
+	return subscriptionID, id, usr, true
+}
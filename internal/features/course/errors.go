@@ -1,9 +1,51 @@
 package course
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
-	ErrCourseNotFound = errors.New("course not found")
-	ErrNameRequired   = errors.New("course name is required")
-	ErrOrderTaken     = errors.New("course order already exists for this subscription")
+	ErrCourseNotFound    = errors.New("course not found")
+	ErrNameRequired      = errors.New("course name is required")
+	ErrOrderTaken        = errors.New("course order already exists for this subscription")
+	ErrCategoryNotFound  = errors.New("course category not found")
+	ErrCategoryNameTaken = errors.New("course category name already exists for this subscription")
+	ErrTagNotFound       = errors.New("course tag not found")
+	ErrTagNameTaken      = errors.New("course tag name already exists for this subscription")
+	ErrCatalogNotFound   = errors.New("catalog not found")
+
+	ErrAlreadyEnrolled         = errors.New("student already has access to this course")
+	ErrEnrollmentPending       = errors.New("an enrollment request for this course is already pending")
+	ErrEnrollmentNotFound      = errors.New("enrollment request not found")
+	ErrEnrollmentReviewed      = errors.New("enrollment request has already been reviewed")
+	ErrEnrollmentGroupRequired = errors.New("an access group is required to approve enrollment")
+	ErrEnrollmentGroupInvalid  = errors.New("access group does not grant this course")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrNameRequired, Status: http.StatusBadRequest, Code: "COURSE_NAME_REQUIRED", Message: "Course name is required."},
+	{Err: ErrOrderTaken, Status: http.StatusConflict, Code: "COURSE_ORDER_TAKEN", Message: "Course order already exists for this subscription."},
+	{Err: publishing.ErrPublishForbidden, Status: http.StatusForbidden, Code: "PUBLISH_FORBIDDEN", Message: "Only instructors and admins can publish courses."},
+	{Err: publishing.ErrInvalidTransition, Status: http.StatusConflict, Code: "INVALID_PUBLISH_TRANSITION", Message: "Course cannot make this transition from its current status."},
+	{Err: publishing.ErrInvalidStatus, Status: http.StatusBadRequest, Code: "INVALID_PUBLISH_STATUS", Message: "Status must be draft, in_review or published."},
+	{Err: ErrCategoryNotFound, Status: http.StatusNotFound, Code: "COURSE_CATEGORY_NOT_FOUND", Message: "Course category not found."},
+	{Err: ErrCategoryNameTaken, Status: http.StatusConflict, Code: "COURSE_CATEGORY_NAME_TAKEN", Message: "A course category with this name already exists."},
+	{Err: ErrTagNotFound, Status: http.StatusNotFound, Code: "COURSE_TAG_NOT_FOUND", Message: "Course tag not found."},
+	{Err: ErrTagNameTaken, Status: http.StatusConflict, Code: "COURSE_TAG_NAME_TAKEN", Message: "A course tag with this name already exists."},
+	{Err: ErrCatalogNotFound, Status: http.StatusNotFound, Code: "CATALOG_NOT_FOUND", Message: "Catalog not found."},
+	{Err: ErrAlreadyEnrolled, Status: http.StatusConflict, Code: "ALREADY_ENROLLED", Message: "You already have access to this course."},
+	{Err: ErrEnrollmentPending, Status: http.StatusConflict, Code: "ENROLLMENT_PENDING", Message: "An enrollment request for this course is already pending."},
+	{Err: ErrEnrollmentNotFound, Status: http.StatusNotFound, Code: "ENROLLMENT_NOT_FOUND", Message: "Enrollment request not found."},
+	{Err: ErrEnrollmentReviewed, Status: http.StatusConflict, Code: "ENROLLMENT_REVIEWED", Message: "Enrollment request has already been reviewed."},
+	{Err: ErrEnrollmentGroupRequired, Status: http.StatusBadRequest, Code: "ENROLLMENT_GROUP_REQUIRED", Message: "Choose an access group, or set the course's enrollment group first."},
+	{Err: ErrEnrollmentGroupInvalid, Status: http.StatusBadRequest, Code: "ENROLLMENT_GROUP_INVALID", Message: "Access group does not grant this course."},
+	{Err: groupaccess.ErrGroupNotFound, Status: http.StatusNotFound, Code: "GROUP_NOT_FOUND", Message: "Group not found."},
+	{Err: groupaccess.ErrPointsLimit, Status: http.StatusForbidden, Code: "GROUP_POINTS_LIMIT", Message: "Subscription points limit exceeded."},
+}
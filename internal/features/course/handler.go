@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"log/slog"
 
@@ -13,13 +16,21 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
 	"github.com/mo-amir99/lms-server-go/pkg/cleanup"
+	"github.com/mo-amir99/lms-server-go/pkg/filetype"
+	"github.com/mo-amir99/lms-server-go/pkg/imaging"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Handler processes course HTTP requests.
@@ -28,6 +39,7 @@ type Handler struct {
 	logger        *slog.Logger
 	streamClient  *bunny.StreamClient
 	storageClient *bunny.StorageClient
+	queryCache    *cache.Store
 }
 
 // NewHandler constructs a course handler instance.
@@ -40,16 +52,35 @@ func NewHandler(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClie
 	}
 }
 
+// UseCache caches course listings; writes invalidate them.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+// CacheNamespace is the cache namespace of a subscription's course listings,
+// which also embed lesson summaries.
+func CacheNamespace(subscriptionID uuid.UUID) string {
+	return "courses:" + subscriptionID.String()
+}
+
+// InvalidateCache drops cached course listings of the subscription and the
+// platform-wide dashboard counts.
+func InvalidateCache(ctx context.Context, store *cache.Store, subscriptionID uuid.UUID) {
+	store.Invalidate(ctx, CacheNamespace(subscriptionID), cache.NamespaceDashboard)
+}
+
 type courseWithLessonSummary struct {
 	Course
 	Lessons []lessonSummary `gorm:"foreignKey:CourseID" json:"lessons"`
 }
 
 type lessonSummary struct {
-	ID       uuid.UUID `json:"id"`
-	CourseID uuid.UUID `json:"courseId"`
-	Name     string    `json:"name"`
-	Order    int       `json:"order"`
+	ID           uuid.UUID `json:"id"`
+	CourseID     uuid.UUID `json:"courseId"`
+	Name         string    `json:"name"`
+	ThumbnailURL *string   `json:"thumbnailUrl,omitempty"`
+	Order        int       `json:"order"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 func (lessonSummary) TableName() string {
@@ -64,21 +95,46 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	ctx := c.Request.Context()
+	namespace := CacheNamespace(subscriptionID)
+
+	usr, _ := middleware.GetUserFromContext(c)
+	subject := authz.SubjectFrom(usr)
+
 	if strings.EqualFold(c.Query("getAllWithLessons"), "true") {
 		courses := make([]courseWithLessonSummary, 0)
-		query := h.db.Model(&Course{}).
-			Where("subscription_id = ?", subscriptionID).
-			Order("\"order\" ASC")
-
-		if err := query.
-			Preload("Lessons", func(db *gorm.DB) *gorm.DB {
-				return db.Select("id", "course_id", "name", "\"order\"").Order("\"order\" ASC")
-			}).
-			Find(&courses).Error; err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load courses", err)
+		if !h.queryCache.Get(ctx, namespace, "withLessons", &courses) {
+			query := authz.ScopeQuery(subject, h.db, "courses.subscription_id").
+				Model(&Course{}).
+				Where("subscription_id = ?", subscriptionID).
+				Order("\"order\" ASC")
+
+			if err := query.
+				Preload("Lessons", func(db *gorm.DB) *gorm.DB {
+					return db.Select("id", "course_id", "name", "thumbnail_url", "\"order\"", "updated_at").Order("\"order\" ASC")
+				}).
+				Find(&courses).Error; err != nil {
+				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load courses", err)
+				return
+			}
+			h.queryCache.Set(ctx, namespace, "withLessons", courses)
+		}
+
+		parts := []interface{}{h.signingEpoch()}
+		for _, course := range courses {
+			parts = append(parts, course.ID, course.UpdatedAt, len(course.Lessons))
+			for _, lesson := range course.Lessons {
+				parts = append(parts, lesson.ID, lesson.UpdatedAt)
+			}
+		}
+		if response.NotModified(c, response.ETag(parts...)) {
 			return
 		}
 
+		for i := range courses {
+			h.signImage(&courses[i].Course)
+		}
+
 		response.Success(c, http.StatusOK, courses, "", nil)
 		return
 	}
@@ -86,21 +142,90 @@ func (h *Handler) List(c *gin.Context) {
 	params := pagination.Extract(c)
 	keyword := c.Query("filterKeyword")
 	activeOnly := c.Query("activeOnly") == "true"
+	status, ok := publishing.ParseFilter(c.Query("status"))
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, publishing.ErrInvalidStatus.Error(), nil)
+		return
+	}
+	categoryID, ok := optionalID(c, "categoryId")
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid category id", nil)
+		return
+	}
+	tagID, ok := optionalID(c, "tagId")
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid tag id", nil)
+		return
+	}
 
-	courses, total, err := List(h.db, ListFilters{
-		SubscriptionID: subscriptionID,
-		Keyword:        keyword,
-		ActiveOnly:     activeOnly,
-	}, params)
+	// Image URLs are stored unsigned and signed per response, so cached pages never hold expiring tokens
+	var page struct {
+		Courses []Course `json:"courses"`
+		Total   int64    `json:"total"`
+	}
+	pageKey := fmt.Sprintf("list:%d:%d:%t:%s:%s:%s:%s", params.Page, params.Limit, activeOnly, status,
+		c.Query("categoryId"), c.Query("tagId"), keyword)
+	if !h.queryCache.Get(ctx, namespace, pageKey, &page) {
+		reader := authz.ScopeQuery(subject, replica.Reader(h.db.WithContext(ctx)), "courses.subscription_id")
+		page.Courses, page.Total, err = List(reader, ListFilters{
+			SubscriptionID: subscriptionID,
+			Keyword:        keyword,
+			ActiveOnly:     activeOnly,
+			Status:         status,
+			CategoryID:     categoryID,
+			TagID:          tagID,
+		}, params)
 
-	if err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list courses", err)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list courses", err)
+			return
+		}
+		h.queryCache.Set(ctx, namespace, pageKey, page)
+	}
+	courses, total := page.Courses, page.Total
+
+	parts := []interface{}{c.Request.URL.RawQuery, total, h.signingEpoch()}
+	for _, course := range courses {
+		parts = append(parts, course.ID, course.UpdatedAt)
+	}
+	if response.NotModified(c, response.ETag(parts...)) {
 		return
 	}
 
+	for i := range courses {
+		h.signImage(&courses[i])
+	}
+
 	response.Success(c, http.StatusOK, courses, "", pagination.MetadataFrom(total, params))
 }
 
+// optionalID parses an optional uuid query parameter; ok is false when it is
+// present but malformed.
+func optionalID(c *gin.Context, key string) (*uuid.UUID, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, false
+	}
+	return &id, true
+}
+
+type createRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	Image            *string  `json:"image"`
+	Description      *string  `json:"description"`
+	StreamStorageGB  *float64 `json:"streamStorageGB"`
+	FileStorageGB    *float64 `json:"fileStorageGB"`
+	StorageUsageInGB *float64 `json:"storageUsageInGB"`
+	Order            *int     `json:"order"`
+	Active           *bool    `json:"isActive"`
+
+	Status *types.ContentStatus `json:"status"`
+}
+
 // Create inserts a new course.
 func (h *Handler) Create(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -120,19 +245,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Name             string   `json:"name" binding:"required"`
-		Image            *string  `json:"image"`
-		Description      *string  `json:"description"`
-		StreamStorageGB  *float64 `json:"streamStorageGB"`
-		FileStorageGB    *float64 `json:"fileStorageGB"`
-		StorageUsageInGB *float64 `json:"storageUsageInGB"`
-		Order            *int     `json:"order"`
-		Active           *bool    `json:"isActive"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid course payload") {
 		return
 	}
 
@@ -141,6 +256,12 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	status, err := publishing.Initial(req.Status, authz.CanPublish(authz.SubjectFrom(usr)))
+	if err != nil {
+		h.respondError(c, err, "invalid course status")
+		return
+	}
+
 	// Get subscription to access identifierName
 	sub, err := subscription.Get(h.db, subscriptionID)
 	if err != nil {
@@ -166,6 +287,8 @@ func (h *Handler) Create(c *gin.Context) {
 		StorageUsageInGB: req.StorageUsageInGB,
 		Order:            req.Order,
 		Active:           req.Active,
+		Status:           status,
+		SubmittedBy:      publishing.SubmittedBy(status, usr.ID),
 	})
 
 	if err != nil {
@@ -197,6 +320,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+
+	h.signImage(&course)
 	response.Created(c, course, "")
 }
 
@@ -220,9 +346,26 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if response.NotModified(c, response.ETag(course.ID, course.UpdatedAt, h.signingEpoch())) {
+		return
+	}
+
+	h.signImage(&course)
 	response.Success(c, http.StatusOK, course, "", nil)
 }
 
+type updateRequest struct {
+	Name             *string                     `json:"name" binding:"omitnil,notblank"`
+	Description      validation.Optional[string] `json:"description" binding:"omitnil,notblank"`
+	Image            validation.Optional[string] `json:"image" binding:"omitnil,notblank"`
+	StreamStorageGB  *float64                    `json:"streamStorageGB" binding:"omitnil,gte=0"`
+	FileStorageGB    *float64                    `json:"fileStorageGB" binding:"omitnil,gte=0"`
+	StorageUsageInGB *float64                    `json:"storageUsageInGB" binding:"omitnil,gte=0"`
+	Order            validation.Optional[int]    `json:"order"`
+	Active           *bool                       `json:"isActive"`
+	CollectionID     validation.Optional[string] `json:"collectionId" binding:"omitnil,notblank"`
+}
+
 // Update modifies an existing course.
 func (h *Handler) Update(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -242,111 +385,25 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid course payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["name"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "name must be a string", err)
-			return
-		}
-		input.Name = &str
-	}
-
-	if value, ok := body["description"]; ok {
-		input.DescProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "description must be a string", err)
-				return
-			}
-			input.Description = &str
-		}
-	}
-
-	if value, ok := body["image"]; ok {
-		input.ImageProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "image must be a string", err)
-				return
-			}
-			input.Image = &str
-		}
-	}
-
-	if value, ok := body["streamStorageGB"]; ok {
-		if value != nil {
-			val, err := request.ReadFloat(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "streamStorageGB must be a number", err)
-				return
-			}
-			input.StreamStorageGB = &val
-		}
-	}
-
-	if value, ok := body["fileStorageGB"]; ok {
-		if value != nil {
-			val, err := request.ReadFloat(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "fileStorageGB must be a number", err)
-				return
-			}
-			input.FileStorageGB = &val
-		}
-	}
-
-	if value, ok := body["storageUsageInGB"]; ok {
-		if value != nil {
-			val, err := request.ReadFloat(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "storageUsageInGB must be a number", err)
-				return
-			}
-			input.StorageUsageInGB = &val
-		}
-	}
-
-	if value, ok := body["order"]; ok {
-		input.OrderProvided = true
-		if value != nil {
-			val, err := request.ReadInt(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "order must be an integer", err)
-				return
-			}
-			input.Order = &val
-		}
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
-	}
-
-	if value, ok := body["collectionId"]; ok {
-		input.CollIDProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "collectionId must be a string", err)
-				return
-			}
-			input.CollectionID = &str
-		}
+	input := UpdateInput{
+		Name:             request.Trimmed(req.Name),
+		DescProvided:     req.Description.Set,
+		Description:      request.Trimmed(req.Description.Ptr()),
+		ImageProvided:    req.Image.Set,
+		Image:            request.Trimmed(req.Image.Ptr()),
+		StreamStorageGB:  req.StreamStorageGB,
+		FileStorageGB:    req.FileStorageGB,
+		StorageUsageInGB: req.StorageUsageInGB,
+		OrderProvided:    req.Order.Set,
+		Order:            req.Order.Ptr(),
+		Active:           req.Active,
+		CollIDProvided:   req.CollectionID.Set,
+		CollectionID:     request.Trimmed(req.CollectionID.Ptr()),
 	}
 
 	// Get original course before update to check if name changed
@@ -390,6 +447,9 @@ func (h *Handler) Update(c *gin.Context) {
 		}
 	}
 
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+
+	h.signImage(&course)
 	response.Success(c, http.StatusOK, course, "", nil)
 }
 
@@ -439,7 +499,9 @@ func (h *Handler) Delete(c *gin.Context) {
 	// clearFiles=true: delete files from Bunny Storage and Stream
 	// storageCleaned=false: storage NOT already cleaned, so DO clean course folder
 	// videoCleaned=false: videos NOT already cleaned, so DO clean collection/videos
-	if err := cleanup.CleanupCourse(c.Request.Context(), h.db, h.streamClient, h.storageClient, h.logger, courseData, true, false, false); err != nil {
+	err = cleanup.CleanupCourse(c.Request.Context(), h.db, h.streamClient, h.storageClient, h.logger, courseData, true, false, false)
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to cleanup course", err)
 		return
 	}
@@ -487,60 +549,139 @@ func (h *Handler) UpdateCourseImage(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// Generate remote path for Bunny Storage
-	ext := ""
-	if idx := strings.LastIndex(fileHeader.Filename, "."); idx != -1 {
-		ext = fileHeader.Filename[idx:]
+	if err := filetype.Image.CheckName(fileHeader.Filename, fileHeader.Size); err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return
+	}
+	head, err := filetype.Head(file)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to read image file.", err)
+		return
+	}
+	if _, err := filetype.Image.CheckContent(head); err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to read image file.", err)
+		return
+	}
+
+	limitGB, usedGB, err := subscription.CheckStorage(h.db, subscriptionID, float64(fileHeader.Size)/(1024*1024*1024))
+	if errors.Is(err, subscription.ErrStorageLimitExceeded) {
+		response.ErrorWithData(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage limit exceeded. Subscription storage limit is %.2fGB, current usage is %.2fGB.", limitGB, usedGB),
+			gin.H{"subscriptionLimitGB": limitGB, "currentUsageGB": usedGB}, nil)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check subscription storage", err)
+		return
 	}
-	remotePath := fmt.Sprintf("%s/%s/covers/%s%s", sub.IdentifierName, courseID.String(), uuid.New().String(), ext)
 
-	// Upload to Bunny Storage
-	imageURL, err := h.storageClient.UploadStream(c.Request.Context(), remotePath, file, fileHeader.Header.Get("Content-Type"))
+	// Covers are served in standard widths without metadata instead of the original upload
+	variants, err := imaging.Resize(file, imaging.Breakpoints)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+			request.RespondInvalid(c, validation.FieldError{Field: "image", Rule: "image", Message: err.Error()})
+			return
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Image could not be read.", err)
+		return
+	}
+
+	basePath := fmt.Sprintf("%s/%s/covers/%s", sub.IdentifierName, courseID.String(), uuid.New().String())
+	imageVariants, err := imaging.Upload(c.Request.Context(), h.storageClient, basePath, variants)
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to upload image to storage.", err)
 		return
 	}
+	imageURL := imaging.Largest(imageVariants)
 
-	// Save old image path before updating
-	oldImage := course.Image
+	// Save old image paths before updating
+	oldImages := imageURLs(course)
 
 	// Update course with new image URL
 	course, err = Update(h.db, courseID, UpdateInput{
 		ImageProvided: true,
 		Image:         &imageURL,
+		ImageVariants: imageVariants,
 	})
 	if err != nil {
 		h.respondError(c, err, "failed to update course image")
 		return
 	}
 
-	// Background deletion of old image
-	go func(oldImagePath *string) {
-		if oldImagePath == nil || *oldImagePath == "" {
-			return
-		}
-
-		// Extract remote path from CDN URL
-		parts := strings.Split(*oldImagePath, "/")
-		for i, part := range parts {
-			if strings.Contains(part, ".b-cdn.net") && i+1 < len(parts) {
-				oldRemotePath := strings.Join(parts[i+1:], "/")
-				if err := h.storageClient.DeleteFile(context.Background(), oldRemotePath); err != nil {
-					h.logger.Error("failed to delete old course image",
-						"courseId", courseID,
-						"oldPath", oldRemotePath,
-						"error", err)
-				} else {
-					h.logger.Info("deleted old course image", "path", oldRemotePath)
-				}
-				break
+	// Background deletion of old images
+	go func(oldImagePaths []string) {
+		for _, oldImagePath := range oldImagePaths {
+			// Images linked from elsewhere are not ours to delete
+			oldRemotePath := h.storageClient.ExtractRelativePath(oldImagePath)
+			if oldRemotePath == "" || strings.Contains(oldRemotePath, "://") {
+				continue
+			}
+			if err := h.storageClient.DeleteFile(context.Background(), oldRemotePath); err != nil {
+				h.logger.Error("failed to delete old course image",
+					"courseId", courseID,
+					"oldPath", oldRemotePath,
+					"error", err)
+			} else {
+				h.logger.Info("deleted old course image", "path", oldRemotePath)
 			}
 		}
-	}(oldImage)
+	}(oldImages)
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
 
+	h.signImage(&course)
 	response.Success(c, http.StatusOK, course, "", nil)
 }
 
+// SignImage replaces the stored cover URLs with token-authenticated ones for the response.
+func SignImage(storageClient *bunny.StorageClient, course *Course) {
+	if storageClient == nil {
+		return
+	}
+	if len(course.ImageVariants) > 0 {
+		signed := make(types.ImageVariants, len(course.ImageVariants))
+		for i, variant := range course.ImageVariants {
+			variant.URL = storageClient.SignedURL(variant.URL)
+			signed[i] = variant
+		}
+		course.ImageVariants = signed
+	}
+	if course.Image == nil || *course.Image == "" {
+		return
+	}
+	signed := storageClient.SignedURL(*course.Image)
+	course.Image = &signed
+}
+
+// imageURLs lists the stored cover and its variants once each.
+func imageURLs(course Course) []string {
+	var urls []string
+	for _, variant := range course.ImageVariants {
+		urls = append(urls, variant.URL)
+	}
+	if course.Image != nil && *course.Image != "" && !slices.Contains(urls, *course.Image) {
+		urls = append(urls, *course.Image)
+	}
+	return urls
+}
+
+func (h *Handler) signImage(course *Course) {
+	SignImage(h.storageClient, course)
+}
+
+// signingEpoch is folded into ETags of responses carrying signed image URLs,
+// so clients revalidating a copy never keep URLs that are about to expire.
+func (h *Handler) signingEpoch() int64 {
+	if h.storageClient == nil {
+		return 0
+	}
+	return h.storageClient.SigningEpoch()
+}
+
 func (h *Handler) initializeCourseStorage(ctx context.Context, subscriptionIdentifier string, courseID uuid.UUID) error {
 	if h.storageClient == nil {
 		return fmt.Errorf("storage client not configured")
@@ -565,20 +706,5 @@ func (h *Handler) initializeCourseStorage(ctx context.Context, subscriptionIdent
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrCourseNotFound):
-		status = http.StatusNotFound
-		message = "Course not found."
-	case errors.Is(err, ErrNameRequired):
-		status = http.StatusBadRequest
-		message = "Course name is required."
-	case errors.Is(err, ErrOrderTaken):
-		status = http.StatusConflict
-		message = "Course order already exists for this subscription."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
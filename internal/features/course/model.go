@@ -24,16 +24,49 @@ type Course struct {
 	StorageUsageInGB float64   `gorm:"type:numeric(10,2);not null;default:0;column:storage_usage_in_gb" json:"storageUsageInGB"`
 	Order            int       `gorm:"type:int;not null;default:0" json:"order"`
 	Active           bool      `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
+
+	// Maintained by storageusage: covers are part of FileStorageGB, and the
+	// alert level is the last quota threshold (percent) instructors were warned about
+	CoverStorageGB    float64 `gorm:"type:numeric(10,2);not null;default:0;column:cover_storage_gb" json:"coverStorageGB"`
+	StorageAlertLevel int     `gorm:"type:int;not null;default:0;column:storage_alert_level" json:"-"`
+
+	// Resized copies of an uploaded cover, smallest first; Image is the largest
+	ImageVariants types.ImageVariants `gorm:"type:jsonb;column:image_variants" json:"imageVariants,omitempty"`
+
+	// Review state; students only see published content. Assistants' changes
+	// go through review, see the publishing service
+	Status      types.ContentStatus `gorm:"type:varchar(20);not null;default:'published'" json:"status"`
+	ReviewNote  *string             `gorm:"type:text;column:review_note" json:"reviewNote,omitempty"`
+	SubmittedBy *uuid.UUID          `gorm:"type:uuid;column:submitted_by" json:"submittedBy,omitempty"`
+	ReviewedBy  *uuid.UUID          `gorm:"type:uuid;column:reviewed_by" json:"reviewedBy,omitempty"`
+
+	// Storefront grouping, see taxonomy.go. Tags are only loaded by List
+	CategoryID *uuid.UUID `gorm:"type:uuid;column:category_id" json:"categoryId,omitempty"`
+	Tags       []Tag      `gorm:"many2many:course_tag_links" json:"tags,omitempty"`
+
+	// Public courses are listed in the catalog and take enrollment requests;
+	// approved students join EnrollmentGroupID unless staff pick another group
+	Public            bool         `gorm:"type:boolean;not null;default:false;column:is_public" json:"isPublic"`
+	Price             *types.Money `gorm:"type:numeric(10,2)" json:"price,omitempty"`
+	EnrollmentGroupID *uuid.UUID   `gorm:"type:uuid;column:enrollment_group_id" json:"enrollmentGroupId,omitempty"`
 }
 
 // TableName overrides the default table name.
 func (Course) TableName() string { return "courses" }
 
+// VisibleToStudents reports whether students may see the course.
+func (c Course) VisibleToStudents() bool {
+	return c.Active && c.Status == types.ContentStatusPublished
+}
+
 // ListFilters defines course query filters.
 type ListFilters struct {
 	SubscriptionID uuid.UUID
 	Keyword        string
 	ActiveOnly     bool
+	Status         types.ContentStatus
+	CategoryID     *uuid.UUID
+	TagID          *uuid.UUID
 }
 
 // CreateInput carries data for creating a new course.
@@ -48,6 +81,8 @@ type CreateInput struct {
 	StorageUsageInGB *float64
 	Order            *int
 	Active           *bool
+	Status           types.ContentStatus // defaults to published
+	SubmittedBy      *uuid.UUID
 }
 
 // UpdateInput captures mutable course fields.
@@ -55,6 +90,7 @@ type UpdateInput struct {
 	Name             *string
 	ImageProvided    bool
 	Image            *string
+	ImageVariants    types.ImageVariants // Replaces the variants whenever ImageProvided
 	DescProvided     bool
 	Description      *string
 	CollIDProvided   bool
@@ -79,6 +115,15 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Course,
 	if filters.ActiveOnly {
 		query = query.Where("is_active = ?", true)
 	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.CategoryID != nil {
+		query = query.Where("category_id = ?", *filters.CategoryID)
+	}
+	if filters.TagID != nil {
+		query = query.Where("id IN (SELECT course_id FROM course_tag_links WHERE tag_id = ?)", *filters.TagID)
+	}
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -87,6 +132,7 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Course,
 
 	var courses []Course
 	err := query.
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
 		Order("\"order\" ASC NULLS LAST, name ASC").
 		Offset(params.Skip).
 		Limit(params.Limit).
@@ -155,6 +201,11 @@ func Create(db *gorm.DB, input CreateInput) (Course, error) {
 		CollectionID:   input.CollectionID,
 		Order:          order,
 		Active:         active,
+		Status:         input.Status,
+		SubmittedBy:    input.SubmittedBy,
+	}
+	if course.Status == "" {
+		course.Status = types.ContentStatusPublished
 	}
 
 	if input.StreamStorageGB != nil {
@@ -215,6 +266,7 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Course, error) {
 
 	if input.ImageProvided {
 		course.Image = input.Image
+		course.ImageVariants = input.ImageVariants
 	}
 
 	if input.CollIDProvided {
@@ -0,0 +1,70 @@
+package course
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Review moves a course through the draft, in_review and published states.
+// Assistants may only submit; approving, rejecting and unpublishing need
+// publish rights.
+func (h *Handler) Review(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req publishing.Request
+	if !request.BindJSON(h.logger, c, &req, "invalid review payload") {
+		return
+	}
+
+	course, err := GetForSubscription(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	next, err := publishing.Next(course.Status, req.Action, authz.CanPublish(authz.SubjectFrom(usr)))
+	if err != nil {
+		h.respondError(c, err, "failed to review course")
+		return
+	}
+
+	if err := publishing.Apply(h.db, course.TableName(), course.ID, course.Status, next, req.Action, usr.ID, request.Trimmed(req.Note)); err != nil {
+		h.respondError(c, err, "failed to review course")
+		return
+	}
+
+	course, err = GetForSubscription(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+
+	h.signImage(&course)
+	response.Success(c, http.StatusOK, course, "", nil)
+}
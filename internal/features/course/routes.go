@@ -2,10 +2,13 @@ package course
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes attaches course endpoints to the router.
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff []gin.HandlerFunc) {
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff []gin.HandlerFunc) {
 	courses := router.Group("/subscriptions/:subscriptionId/courses")
 
 	courses.GET("", append(acStaff, handler.List)...)
@@ -14,4 +17,52 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff []gin.Han
 	courses.PUT("/:courseId", append(acStaff, handler.Update)...)
 	courses.DELETE("/:courseId", append(acStaff, handler.Delete)...)
 	courses.PUT("/:courseId/image", append(acStaff, handler.UpdateCourseImage)...)
+	courses.POST("/:courseId/review", append(acStaff, handler.Review)...)
+	courses.PUT("/:courseId/category", append(acStaff, handler.SetCourseCategory)...)
+	courses.PUT("/:courseId/tags", append(acStaff, handler.SetCourseTags)...)
+	courses.PUT("/:courseId/public", append(acStaff, handler.SetCoursePublic)...)
+	courses.POST("/:courseId/enrollment-requests", append(acAll, handler.RequestEnrollment)...)
+
+	enrollments := router.Group("/subscriptions/:subscriptionId/enrollment-requests")
+	enrollments.GET("", append(acStaff, handler.ListEnrollmentRequests)...)
+	enrollments.GET("/mine", append(acAll, handler.MyEnrollmentRequests)...)
+	enrollments.POST("/:requestId/approve", append(acStaff, handler.ApproveEnrollment)...)
+	enrollments.POST("/:requestId/reject", append(acStaff, handler.RejectEnrollment)...)
+
+	categories := router.Group("/subscriptions/:subscriptionId/course-categories")
+	categories.GET("", append(acStaff, handler.ListCategories)...)
+	categories.POST("", append(acStaff, handler.CreateCategory)...)
+	categories.PUT("/:categoryId", append(acStaff, handler.UpdateCategory)...)
+	categories.DELETE("/:categoryId", append(acStaff, handler.DeleteCategory)...)
+
+	tags := router.Group("/subscriptions/:subscriptionId/course-tags")
+	tags.GET("", append(acStaff, handler.ListTags)...)
+	tags.POST("", append(acStaff, handler.CreateTag)...)
+	tags.PUT("/:tagId", append(acStaff, handler.UpdateTag)...)
+	tags.DELETE("/:tagId", append(acStaff, handler.DeleteTag)...)
+
+	// Public storefront listing, no authentication
+	router.GET("/catalog/:identifier", handler.Catalog)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Course{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Course{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Course{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Course{}})
+	openapi.Describe(handler.UpdateCourseImage, openapi.Spec{RequestType: "multipart/form-data", Response: Course{}})
+	openapi.Describe(handler.Review, openapi.Spec{Request: publishing.Request{}, Response: Course{}})
+	openapi.Describe(handler.SetCourseCategory, openapi.Spec{Request: courseCategoryRequest{}, Response: Course{}})
+	openapi.Describe(handler.SetCourseTags, openapi.Spec{Request: courseTagsRequest{}, Response: Course{}})
+	openapi.Describe(handler.SetCoursePublic, openapi.Spec{Request: publicRequest{}, Response: Course{}})
+	openapi.Describe(handler.RequestEnrollment, openapi.Spec{Request: enrollmentRequest{}, Response: EnrollmentRequest{}})
+	openapi.Describe(handler.ListEnrollmentRequests, openapi.Spec{Response: []EnrollmentRequestView{}})
+	openapi.Describe(handler.MyEnrollmentRequests, openapi.Spec{Response: []EnrollmentRequestView{}})
+	openapi.Describe(handler.ApproveEnrollment, openapi.Spec{Request: approveEnrollmentRequest{}, Response: EnrollmentRequest{}})
+	openapi.Describe(handler.RejectEnrollment, openapi.Spec{Request: rejectEnrollmentRequest{}, Response: EnrollmentRequest{}})
+	openapi.Describe(handler.ListCategories, openapi.Spec{Response: []Category{}})
+	openapi.Describe(handler.CreateCategory, openapi.Spec{Request: createCategoryRequest{}, Response: Category{}})
+	openapi.Describe(handler.UpdateCategory, openapi.Spec{Request: updateCategoryRequest{}, Response: Category{}})
+	openapi.Describe(handler.ListTags, openapi.Spec{Response: []Tag{}})
+	openapi.Describe(handler.CreateTag, openapi.Spec{Request: tagRequest{}, Response: Tag{}})
+	openapi.Describe(handler.UpdateTag, openapi.Spec{Request: tagRequest{}, Response: Tag{}})
+	openapi.Describe(handler.Catalog, openapi.Spec{Response: []CatalogSection{}})
 }
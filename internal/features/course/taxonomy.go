@@ -0,0 +1,557 @@
+package course
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Category groups a subscription's courses on the storefront. A course sits in
+// at most one category.
+type Category struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	Name           string    `gorm:"type:varchar(60);not null" json:"name"`
+	Description    *string   `gorm:"type:varchar(500)" json:"description,omitempty"`
+	Order          int       `gorm:"type:int;not null;default:0" json:"order"`
+}
+
+// TableName overrides the default table name.
+func (Category) TableName() string { return "course_categories" }
+
+// Tag is a free-form label on a subscription's courses.
+type Tag struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	Name           string    `gorm:"type:varchar(40);not null" json:"name"`
+}
+
+// TableName overrides the default table name.
+func (Tag) TableName() string { return "course_tags" }
+
+type tagLink struct {
+	CourseID uuid.UUID `gorm:"type:uuid;primaryKey;column:course_id"`
+	TagID    uuid.UUID `gorm:"type:uuid;primaryKey;column:tag_id"`
+}
+
+func (tagLink) TableName() string { return "course_tag_links" }
+
+// ListCategories returns the subscription's categories in storefront order.
+func ListCategories(db *gorm.DB, subscriptionID uuid.UUID) ([]Category, error) {
+	categories := make([]Category, 0)
+	err := db.Where("subscription_id = ?", subscriptionID).
+		Order("\"order\" ASC, name ASC").
+		Find(&categories).Error
+	return categories, err
+}
+
+// GetCategory retrieves a category of the subscription.
+func GetCategory(db *gorm.DB, subscriptionID, id uuid.UUID) (Category, error) {
+	var category Category
+	if err := db.First(&category, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return category, ErrCategoryNotFound
+		}
+		return category, err
+	}
+	return category, nil
+}
+
+// SaveCategory creates or updates a category; names are unique per
+// subscription regardless of case.
+func SaveCategory(db *gorm.DB, category *Category) error {
+	taken, err := nameTaken(db, category.TableName(), category.SubscriptionID, category.ID, category.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrCategoryNameTaken
+	}
+	if category.ID == uuid.Nil {
+		return db.Create(category).Error
+	}
+	return db.Save(category).Error
+}
+
+// DeleteCategory removes a category. Its courses become uncategorized.
+func DeleteCategory(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Course{}).
+			Where("category_id = ?", id).
+			Update("updated_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&Category{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCategoryNotFound
+		}
+		return nil
+	})
+}
+
+// ListTags returns the subscription's tags by name.
+func ListTags(db *gorm.DB, subscriptionID uuid.UUID) ([]Tag, error) {
+	tags := make([]Tag, 0)
+	err := db.Where("subscription_id = ?", subscriptionID).Order("name ASC").Find(&tags).Error
+	return tags, err
+}
+
+// GetTag retrieves a tag of the subscription.
+func GetTag(db *gorm.DB, subscriptionID, id uuid.UUID) (Tag, error) {
+	var tag Tag
+	if err := db.First(&tag, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tag, ErrTagNotFound
+		}
+		return tag, err
+	}
+	return tag, nil
+}
+
+// SaveTag creates or renames a tag; names are unique per subscription
+// regardless of case. Renaming touches the tagged courses so their listings
+// revalidate.
+func SaveTag(db *gorm.DB, tag *Tag) error {
+	taken, err := nameTaken(db, tag.TableName(), tag.SubscriptionID, tag.ID, tag.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTagNameTaken
+	}
+	if tag.ID == uuid.Nil {
+		return db.Create(tag).Error
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(tag).Error; err != nil {
+			return err
+		}
+		return touchTagged(tx, tag.ID)
+	})
+}
+
+// DeleteTag removes a tag from the subscription and its courses.
+func DeleteTag(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := touchTagged(tx, id); err != nil {
+			return err
+		}
+		result := tx.Delete(&Tag{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTagNotFound
+		}
+		return nil
+	})
+}
+
+// SetCategory moves the course into a category of its subscription, or out of
+// any when categoryID is nil.
+func SetCategory(db *gorm.DB, course *Course, categoryID *uuid.UUID) error {
+	if categoryID != nil {
+		if _, err := GetCategory(db, course.SubscriptionID, *categoryID); err != nil {
+			return err
+		}
+	}
+	return db.Model(course).Update("category_id", categoryID).Error
+}
+
+// SetTags replaces the course's tags. Every tag must belong to the course's
+// subscription.
+func SetTags(db *gorm.DB, course Course, tagIDs []uuid.UUID) error {
+	unique := make([]uuid.UUID, 0, len(tagIDs))
+	seen := make(map[uuid.UUID]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	if len(unique) > 0 {
+		var found int64
+		if err := db.Model(&Tag{}).
+			Where("id IN ? AND subscription_id = ?", unique, course.SubscriptionID).
+			Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(unique) {
+			return ErrTagNotFound
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", course.ID).Delete(&tagLink{}).Error; err != nil {
+			return err
+		}
+		if len(unique) > 0 {
+			links := make([]tagLink, len(unique))
+			for i, id := range unique {
+				links[i] = tagLink{CourseID: course.ID, TagID: id}
+			}
+			if err := tx.Create(&links).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&Course{}).Where("id = ?", course.ID).Update("updated_at", gorm.Expr("NOW()")).Error
+	})
+}
+
+// GetWithTags retrieves a course of the subscription with its tags loaded.
+func GetWithTags(db *gorm.DB, id, subscriptionID uuid.UUID) (Course, error) {
+	var course Course
+	err := db.Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		First(&course, "id = ? AND subscription_id = ?", id, subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return course, ErrCourseNotFound
+	}
+	return course, err
+}
+
+func nameTaken(db *gorm.DB, table string, subscriptionID, ignoreID uuid.UUID, name string) (bool, error) {
+	var count int64
+	err := db.Table(table).
+		Where("subscription_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", subscriptionID, name, ignoreID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// touchTagged bumps updated_at of the courses carrying the tag.
+func touchTagged(db *gorm.DB, tagID uuid.UUID) error {
+	return db.Model(&Course{}).
+		Where("id IN (SELECT course_id FROM course_tag_links WHERE tag_id = ?)", tagID).
+		Update("updated_at", gorm.Expr("NOW()")).Error
+}
+
+type createCategoryRequest struct {
+	Name        string  `json:"name" binding:"required,notblank,max=60"`
+	Description *string `json:"description" binding:"omitnil,max=500"`
+	Order       *int    `json:"order" binding:"omitnil,gte=0"`
+}
+
+type updateCategoryRequest struct {
+	Name        *string                     `json:"name" binding:"omitnil,notblank,max=60"`
+	Description validation.Optional[string] `json:"description" binding:"omitnil,max=500"`
+	Order       *int                        `json:"order" binding:"omitnil,gte=0"`
+}
+
+type tagRequest struct {
+	Name string `json:"name" binding:"required,notblank,max=40"`
+}
+
+type courseCategoryRequest struct {
+	CategoryID *uuid.UUID `json:"categoryId"`
+}
+
+type courseTagsRequest struct {
+	TagIDs []uuid.UUID `json:"tagIds" binding:"max=20"`
+}
+
+// ListCategories returns the subscription's course categories.
+func (h *Handler) ListCategories(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	categories, err := ListCategories(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list course categories", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, categories, "", nil)
+}
+
+// CreateCategory adds a course category.
+func (h *Handler) CreateCategory(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	var req createCategoryRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid category payload") {
+		return
+	}
+
+	category := Category{
+		SubscriptionID: subscriptionID,
+		Name:           strings.TrimSpace(req.Name),
+		Description:    request.Trimmed(req.Description),
+	}
+	if req.Order != nil {
+		category.Order = *req.Order
+	}
+
+	if err := SaveCategory(h.db, &category); err != nil {
+		h.respondError(c, err, "failed to create course category")
+		return
+	}
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+	response.Created(c, category, "")
+}
+
+// UpdateCategory renames, describes or reorders a course category.
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid category id", err)
+		return
+	}
+
+	var req updateCategoryRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid category payload") {
+		return
+	}
+
+	category, err := GetCategory(h.db, subscriptionID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load course category")
+		return
+	}
+
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description.Set {
+		category.Description = request.Trimmed(req.Description.Ptr())
+	}
+	if req.Order != nil {
+		category.Order = *req.Order
+	}
+
+	if err := SaveCategory(h.db, &category); err != nil {
+		h.respondError(c, err, "failed to update course category")
+		return
+	}
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+	response.Success(c, http.StatusOK, category, "", nil)
+}
+
+// DeleteCategory removes a course category; its courses become uncategorized.
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid category id", err)
+		return
+	}
+
+	if err := DeleteCategory(h.db, subscriptionID, id); err != nil {
+		h.respondError(c, err, "failed to delete course category")
+		return
+	}
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// ListTags returns the subscription's course tags.
+func (h *Handler) ListTags(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	tags, err := ListTags(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list course tags", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, tags, "", nil)
+}
+
+// CreateTag adds a course tag.
+func (h *Handler) CreateTag(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	var req tagRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid tag payload") {
+		return
+	}
+
+	tag := Tag{SubscriptionID: subscriptionID, Name: strings.TrimSpace(req.Name)}
+	if err := SaveTag(h.db, &tag); err != nil {
+		h.respondError(c, err, "failed to create course tag")
+		return
+	}
+
+	response.Created(c, tag, "")
+}
+
+// UpdateTag renames a course tag.
+func (h *Handler) UpdateTag(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid tag id", err)
+		return
+	}
+
+	var req tagRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid tag payload") {
+		return
+	}
+
+	tag, err := GetTag(h.db, subscriptionID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load course tag")
+		return
+	}
+
+	tag.Name = strings.TrimSpace(req.Name)
+	if err := SaveTag(h.db, &tag); err != nil {
+		h.respondError(c, err, "failed to update course tag")
+		return
+	}
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+	response.Success(c, http.StatusOK, tag, "", nil)
+}
+
+// DeleteTag removes a course tag from the subscription and its courses.
+func (h *Handler) DeleteTag(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid tag id", err)
+		return
+	}
+
+	if err := DeleteTag(h.db, subscriptionID, id); err != nil {
+		h.respondError(c, err, "failed to delete course tag")
+		return
+	}
+
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// SetCourseCategory assigns the course to a category, or clears it with a null categoryId.
+func (h *Handler) SetCourseCategory(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	var req courseCategoryRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid course category payload") {
+		return
+	}
+
+	course, err := GetForSubscription(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	if err := SetCategory(h.db, &course, req.CategoryID); err != nil {
+		h.respondError(c, err, "failed to set course category")
+		return
+	}
+
+	h.respondWithTags(c, id, subscriptionID)
+}
+
+// SetCourseTags replaces the course's tags.
+func (h *Handler) SetCourseTags(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	var req courseTagsRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid course tags payload") {
+		return
+	}
+
+	course, err := GetForSubscription(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	if err := SetTags(h.db, course, req.TagIDs); err != nil {
+		h.respondError(c, err, "failed to set course tags")
+		return
+	}
+
+	h.respondWithTags(c, id, subscriptionID)
+}
+
+// respondWithTags drops the subscription's cached listings and writes the
+// course with its tags.
+func (h *Handler) respondWithTags(c *gin.Context, id, subscriptionID uuid.UUID) {
+	InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+
+	course, err := GetWithTags(h.db, id, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	h.signImage(&course)
+	response.Success(c, http.StatusOK, course, "", nil)
+}
@@ -0,0 +1,237 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+const (
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	maxAnalyticsRange     = 366 * 24 * time.Hour
+	// lessonCompletionRatio matches the threshold prerequisites are unlocked by.
+	lessonCompletionRatio = lesson.CompletionRatio
+)
+
+// courseAnalytics holds per-course enrollment and completion figures.
+type courseAnalytics struct {
+	CourseID         uuid.UUID `json:"courseId"`
+	Name             string    `json:"name"`
+	LessonsCount     int64     `json:"lessonsCount"`
+	EnrolledCount    int64     `json:"enrolledCount"`
+	ViewersCount     int64     `json:"viewersCount"`
+	CompletedCount   int64     `json:"completedLessons"`
+	CompletionRate   float64   `json:"completionRate"`
+	WatchedSeconds   int64     `json:"watchedSeconds"`
+	AverageQuizScore *float64  `json:"averageQuizScore" gorm:"-"`
+}
+
+// watchTrendPoint is the watch time accumulated in one bucket of the trend series.
+type watchTrendPoint struct {
+	Period         time.Time `json:"period"`
+	WatchedSeconds int64     `json:"watchedSeconds"`
+	Viewers        int64     `json:"viewers"`
+	Sessions       int64     `json:"sessions"`
+}
+
+// GetInstructorAnalytics returns course engagement analytics for a subscription
+// GET /dashboard/instructor/:subscriptionId/analytics?dateFrom=&dateTo=&interval=day|week|month
+func (h *Handler) GetInstructorAnalytics(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	if currentUser.SubscriptionID == nil || *currentUser.SubscriptionID != subscriptionID {
+		response.Error(c, http.StatusForbidden, "Subscription not found or inaccessible", nil)
+		return
+	}
+
+	dateTo := time.Now().UTC()
+	if value := c.Query("dateTo"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateTo format", err)
+			return
+		}
+		dateTo = t.UTC()
+	}
+
+	dateFrom := dateTo.Add(-defaultAnalyticsRange)
+	if value := c.Query("dateFrom"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateFrom format", err)
+			return
+		}
+		dateFrom = t.UTC()
+	}
+
+	if !dateFrom.Before(dateTo) {
+		response.Error(c, http.StatusBadRequest, "dateFrom must be before dateTo", nil)
+		return
+	}
+	if dateTo.Sub(dateFrom) > maxAnalyticsRange {
+		response.Error(c, http.StatusBadRequest, "Date range cannot exceed 366 days", nil)
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	switch interval {
+	case "day", "week", "month":
+	default:
+		response.Error(c, http.StatusBadRequest, "interval must be one of day, week, month", nil)
+		return
+	}
+
+	courses, err := h.loadCourseAnalytics(c.Request.Context(), subscriptionID, dateFrom, dateTo)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load course analytics", err)
+		return
+	}
+
+	loc := currentUser.Location()
+	trend, err := h.loadWatchTrend(c.Request.Context(), subscriptionID, dateFrom, dateTo, interval, loc)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load watch trend", err)
+		return
+	}
+
+	var totalWatched int64
+	for _, item := range trend {
+		totalWatched += item.WatchedSeconds
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"dateFrom":            dateFrom,
+		"dateTo":              dateTo,
+		"interval":            interval,
+		"timezone":            loc.String(),
+		"courses":             courses,
+		"watchTrend":          trend,
+		"totalWatchedSeconds": totalWatched,
+	}, "", nil)
+}
+
+// loadCourseAnalytics computes enrollment, viewers and lesson completion per course.
+// Enrollment counts distinct users granted the course either directly or through one of its lessons.
+// Quiz scores are reported as null until assessments are tracked.
+func (h *Handler) loadCourseAnalytics(ctx context.Context, subscriptionID uuid.UUID, dateFrom, dateTo time.Time) ([]courseAnalytics, error) {
+	rows := make([]courseAnalytics, 0)
+	err := replica.Reader(h.db.WithContext(ctx)).Raw(`
+		WITH course_lessons AS (
+			SELECT l.course_id, COUNT(*) AS lessons_count
+			FROM lessons l
+			JOIN courses c ON c.id = l.course_id
+			WHERE c.subscription_id = @subscription AND l.is_active = TRUE
+			GROUP BY l.course_id
+		),
+		enrollments AS (
+			SELECT c.id AS course_id, COUNT(DISTINCT member) AS enrolled_count
+			FROM courses c
+			JOIN group_access g ON g.subscription_id = c.subscription_id
+				AND (c.id = ANY(g.courses)
+					OR EXISTS (SELECT 1 FROM lessons l WHERE l.course_id = c.id AND l.id = ANY(g.lessons)))
+			CROSS JOIN LATERAL unnest(g.users) AS member
+			WHERE c.subscription_id = @subscription
+			GROUP BY c.id
+		),
+		progress AS (
+			SELECT ws.course_id, ws.user_id, ws.lesson_id,
+				MAX(ws.max_position) AS max_position,
+				SUM(ws.watched_seconds) AS watched_seconds
+			FROM watch_sessions ws
+			JOIN courses c ON c.id = ws.course_id
+			WHERE c.subscription_id = @subscription
+				AND ws.last_heartbeat_at >= @from AND ws.created_at < @to
+			GROUP BY ws.course_id, ws.user_id, ws.lesson_id
+		),
+		engagement AS (
+			SELECT p.course_id,
+				COUNT(DISTINCT p.user_id) AS viewers_count,
+				COUNT(*) FILTER (WHERE l.duration > 0 AND p.max_position >= l.duration * @ratio) AS completed_count,
+				COALESCE(SUM(p.watched_seconds), 0) AS watched_seconds
+			FROM progress p
+			JOIN lessons l ON l.id = p.lesson_id
+			GROUP BY p.course_id
+		)
+		SELECT c.id AS course_id, c.name,
+			COALESCE(cl.lessons_count, 0) AS lessons_count,
+			COALESCE(e.enrolled_count, 0) AS enrolled_count,
+			COALESCE(eg.viewers_count, 0) AS viewers_count,
+			COALESCE(eg.completed_count, 0) AS completed_count,
+			COALESCE(eg.watched_seconds, 0) AS watched_seconds
+		FROM courses c
+		LEFT JOIN course_lessons cl ON cl.course_id = c.id
+		LEFT JOIN enrollments e ON e.course_id = c.id
+		LEFT JOIN engagement eg ON eg.course_id = c.id
+		WHERE c.subscription_id = @subscription
+		ORDER BY c."order" ASC, c.created_at ASC`,
+		map[string]interface{}{
+			"subscription": subscriptionID,
+			"from":         dateFrom,
+			"to":           dateTo,
+			"ratio":        lessonCompletionRatio,
+		}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		// Students who watched without a group grant (e.g. staff previews) still count as audience.
+		audience := rows[i].EnrolledCount
+		if rows[i].ViewersCount > audience {
+			audience = rows[i].ViewersCount
+		}
+		possible := audience * rows[i].LessonsCount
+		if possible > 0 {
+			rate := float64(rows[i].CompletedCount) / float64(possible) * 100
+			if rate > 100 {
+				rate = 100
+			}
+			rows[i].CompletionRate = float64(int(rate*100)) / 100
+		}
+	}
+
+	return rows, nil
+}
+
+// loadWatchTrend buckets watch time by session start into day, week or month periods
+// that begin at midnight in loc, so days match the viewer's calendar.
+func (h *Handler) loadWatchTrend(ctx context.Context, subscriptionID uuid.UUID, dateFrom, dateTo time.Time, interval string, loc *time.Location) ([]watchTrendPoint, error) {
+	points := make([]watchTrendPoint, 0)
+	err := replica.Reader(h.db.WithContext(ctx)).Raw(`
+		SELECT date_trunc(@interval, ws.created_at AT TIME ZONE 'UTC', @timezone) AS period,
+			COALESCE(SUM(ws.watched_seconds), 0) AS watched_seconds,
+			COUNT(DISTINCT ws.user_id) AS viewers,
+			COUNT(*) AS sessions
+		FROM watch_sessions ws
+		JOIN courses c ON c.id = ws.course_id
+		WHERE c.subscription_id = @subscription
+			AND ws.created_at >= @from AND ws.created_at < @to
+		GROUP BY period
+		ORDER BY period ASC`,
+		map[string]interface{}{
+			"interval":     interval,
+			"timezone":     loc.String(),
+			"subscription": subscriptionID,
+			"from":         dateFrom,
+			"to":           dateTo,
+		}).Scan(&points).Error
+	return points, err
+}
@@ -0,0 +1,175 @@
+package dashboard
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const maxCDNRange = 366 * 24 * time.Hour
+
+// cdnPullZone is the traffic of one configured pull zone. Error is set instead
+// of the traffic when Bunny could not be reached for it.
+type cdnPullZone struct {
+	Name string `json:"name"`
+	bunny.TrafficSummary
+	BandwidthGB   float64     `json:"bandwidthGB"`
+	EstimatedCost types.Money `json:"estimatedCost"`
+	Error         string      `json:"error,omitempty"`
+}
+
+// cdnSubscriptionCost attributes a share of the CDN traffic to a subscription.
+// Bunny does not report traffic per subscription, so the share is the
+// subscription's part of all watch time in the range.
+type cdnSubscriptionCost struct {
+	SubscriptionID uuid.UUID   `json:"subscriptionId"`
+	IdentifierName string      `json:"identifierName"`
+	DisplayName    *string     `json:"displayName,omitempty"`
+	WatchedSeconds int64       `json:"watchedSeconds"`
+	Share          float64     `json:"share"`
+	BandwidthGB    float64     `json:"bandwidthGB"`
+	EstimatedCost  types.Money `json:"estimatedCost"`
+}
+
+type cdnUsage struct {
+	DateFrom      time.Time             `json:"dateFrom"`
+	DateTo        time.Time             `json:"dateTo"`
+	CostPerGB     float64               `json:"costPerGB"`
+	BandwidthGB   float64               `json:"bandwidthGB"`
+	Requests      int64                 `json:"requests"`
+	EstimatedCost types.Money           `json:"estimatedCost"`
+	PullZones     []cdnPullZone         `json:"pullZones"`
+	Subscriptions []cdnSubscriptionCost `json:"subscriptions"`
+}
+
+// UseStatistics enables the CDN usage report for the configured pull zones.
+func (h *Handler) UseStatistics(client *bunny.StatisticsClient, stats config.BunnyStatsConfig) {
+	h.statsClient = client
+	h.cdnConfig = stats
+}
+
+// GetAdminCDNUsage reports bandwidth and requests per pull zone from Bunny
+// Statistics with estimated costs, attributed to subscriptions by watch time.
+// Defaults to the current month.
+// GET /dashboard/admin/cdn?dateFrom=&dateTo=
+func (h *Handler) GetAdminCDNUsage(c *gin.Context) {
+	if h.statsClient == nil || len(h.cdnConfig.PullZones) == 0 {
+		response.Error(c, http.StatusServiceUnavailable, "Bunny statistics are not configured.", nil)
+		return
+	}
+
+	// Hour granularity lets repeated requests for "now" share a cache entry
+	dateTo := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	if value := c.Query("dateTo"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateTo format", err)
+			return
+		}
+		dateTo = t.UTC()
+	}
+
+	dateFrom := time.Date(dateTo.Year(), dateTo.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("dateFrom"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateFrom format", err)
+			return
+		}
+		dateFrom = t.UTC()
+	}
+
+	if !dateFrom.Before(dateTo) {
+		response.Error(c, http.StatusBadRequest, "dateFrom must be before dateTo", nil)
+		return
+	}
+	if dateTo.Sub(dateFrom) > maxCDNRange {
+		response.Error(c, http.StatusBadRequest, "Date range cannot exceed one year", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	cacheKey := "cdn:" + dateFrom.Format(time.RFC3339) + ":" + dateTo.Format(time.RFC3339)
+	var usage cdnUsage
+	if h.queryCache.Get(ctx, cache.NamespaceDashboard, cacheKey, &usage) {
+		response.Success(c, http.StatusOK, usage, "", nil)
+		return
+	}
+
+	usage = cdnUsage{
+		DateFrom:      dateFrom,
+		DateTo:        dateTo,
+		CostPerGB:     h.cdnConfig.CostPerGB,
+		PullZones:     make([]cdnPullZone, 0, len(h.cdnConfig.PullZones)),
+		Subscriptions: make([]cdnSubscriptionCost, 0),
+	}
+
+	complete := true
+	for _, zone := range h.cdnConfig.PullZones {
+		entry := cdnPullZone{Name: zone.Name}
+		traffic, err := h.statsClient.PullZoneTraffic(ctx, zone.ID, dateFrom, dateTo)
+		entry.TrafficSummary = traffic
+		if err != nil {
+			h.logger.Warn("failed to fetch pull zone statistics", "pullZone", zone.Name, "error", err)
+			entry.Error = "Statistics are unavailable for this pull zone."
+			complete = false
+		} else {
+			entry.BandwidthGB = bytesToGB(traffic.BandwidthBytes)
+			entry.EstimatedCost = types.NewMoney(entry.BandwidthGB * h.cdnConfig.CostPerGB)
+			usage.BandwidthGB += entry.BandwidthGB
+			usage.Requests += traffic.Requests
+		}
+		usage.PullZones = append(usage.PullZones, entry)
+	}
+	usage.EstimatedCost = types.NewMoney(usage.BandwidthGB * h.cdnConfig.CostPerGB)
+
+	db := replica.Reader(h.db.WithContext(ctx))
+	if err := db.Raw(`
+		SELECT c.subscription_id, s.identifier_name, s.display_name,
+			COALESCE(SUM(ws.watched_seconds), 0) AS watched_seconds
+		FROM watch_sessions ws
+		JOIN courses c ON c.id = ws.course_id
+		JOIN subscriptions s ON s.id = c.subscription_id
+		WHERE ws.created_at >= ? AND ws.created_at < ?
+		GROUP BY c.subscription_id, s.identifier_name, s.display_name
+		HAVING SUM(ws.watched_seconds) > 0
+		ORDER BY watched_seconds DESC`, dateFrom, dateTo).Scan(&usage.Subscriptions).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to attribute CDN usage", err)
+		return
+	}
+
+	var totalSeconds int64
+	for _, sub := range usage.Subscriptions {
+		totalSeconds += sub.WatchedSeconds
+	}
+	for i := range usage.Subscriptions {
+		sub := &usage.Subscriptions[i]
+		sub.Share = math.Round(float64(sub.WatchedSeconds)/float64(totalSeconds)*10000) / 10000
+		sub.BandwidthGB = usage.BandwidthGB * float64(sub.WatchedSeconds) / float64(totalSeconds)
+		sub.EstimatedCost = types.NewMoney(sub.BandwidthGB * h.cdnConfig.CostPerGB)
+	}
+
+	// Partial results are returned but not cached, so a later request retries Bunny
+	if complete {
+		h.queryCache.Set(ctx, cache.NamespaceDashboard, cacheKey, usage)
+	}
+
+	response.Success(c, http.StatusOK, usage, "", nil)
+}
+
+func bytesToGB(value int64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	return float64(value) / (1024 * 1024 * 1024)
+}
@@ -1,14 +1,14 @@
 package dashboard
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
@@ -24,14 +24,26 @@ import (
 	"github.com/mo-amir99/lms-server-go/internal/features/user"
 	"github.com/mo-amir99/lms-server-go/internal/features/userwatch"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	applog "github.com/mo-amir99/lms-server-go/pkg/logger"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 type Handler struct {
 	db           *gorm.DB
 	logger       *slog.Logger
 	meetingCache *meeting.Cache
+	queryCache   *cache.Store
+	logs         applog.Store
+	statsClient  *bunny.StatisticsClient
+	cdnConfig    config.BunnyStatsConfig
 }
 
 func NewHandler(db *gorm.DB, logger *slog.Logger, cache *meeting.Cache) *Handler {
@@ -39,9 +51,20 @@ func NewHandler(db *gorm.DB, logger *slog.Logger, cache *meeting.Cache) *Handler
 		db:           db,
 		logger:       logger,
 		meetingCache: cache,
+		logs:         applog.FileStore{},
 	}
 }
 
+// UseLogStore makes GetSystemLogs query store instead of the local log files.
+func (h *Handler) UseLogStore(store applog.Store) {
+	h.logs = store
+}
+
+// UseCache caches the platform-wide admin statistics.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
 type courseWithLessons struct {
 	course.Course
 	Lessons []lesson.Lesson `gorm:"foreignKey:CourseID" json:"lessons,omitempty"`
@@ -51,76 +74,77 @@ func (courseWithLessons) TableName() string {
 	return course.Course{}.TableName()
 }
 
-// GetSystemLogs returns the last N lines from info.log or error.log
-// GET /dashboard/logs?type=info|error&lines=100
+// GetSystemLogs returns structured log entries, newest first, filtered by level,
+// request ID, user ID and time range and paged by cursor
+// GET /dashboard/logs?type=info|error&level=warn&requestId=&userId=&from=&to=&limit=&cursor=
 func (h *Handler) GetSystemLogs(c *gin.Context) {
-	// Parse query parameters
-	logType := c.DefaultQuery("type", "info")
-	if logType != "info" && logType != "error" {
-		logType = "info"
-	}
-
-	linesStr := c.DefaultQuery("lines", "100")
-	lines, err := strconv.Atoi(linesStr)
-	if err != nil {
-		lines = 100
-	}
-	if lines < 10 {
-		lines = 10
-	}
-	if lines > 1000 {
-		lines = 1000
+	query := applog.Query{
+		Log:       c.DefaultQuery("type", "info"),
+		RequestID: c.Query("requestId"),
+		UserID:    c.Query("userId"),
+		Limit:     pagination.Extract(c).Limit,
+	}
+	if query.Log != "info" && query.Log != "error" {
+		request.RespondInvalid(c, validation.FieldError{Field: "type", Rule: "oneof", Message: "must be one of info, error"})
+		return
 	}
 
-	// Construct log file path
-	logFile := filepath.Join("logs", fmt.Sprintf("%s.log", logType))
-
-	// Check if file exists
-	if _, err := os.Stat(logFile); os.IsNotExist(err) {
-		response.Error(c, http.StatusNotFound, fmt.Sprintf("Log file not found: %s.log", logType), nil)
-		return
+	if raw := c.Query("level"); raw != "" {
+		if err := query.Level.UnmarshalText([]byte(raw)); err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "level", Rule: "oneof", Message: "must be one of debug, info, warn, error"})
+			return
+		}
+	} else {
+		query.Level = slog.LevelDebug
+	}
+
+	bounds := []struct {
+		field string
+		dest  *time.Time
+	}{{"from", &query.From}, {"to", &query.To}}
+	for _, bound := range bounds {
+		if raw := c.Query(bound.field); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				request.RespondInvalid(c, validation.FieldError{Field: bound.field, Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
+				return
+			}
+			*bound.dest = parsed
+		}
 	}
 
-	// Read file
-	file, err := os.Open(logFile)
+	after, err := applog.DecodeCursor(c.Query(pagination.CursorParam))
 	if err != nil {
-		h.logger.Error("Failed to open log file", "error", err, "file", logFile)
-		response.Error(c, http.StatusInternalServerError, "Failed to read log file", nil)
+		request.RespondInvalid(c, request.InvalidCursorField)
 		return
 	}
-	defer file.Close()
+	query.After = after
 
-	// Read all lines
-	var allLines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		allLines = append(allLines, scanner.Text())
+	page, err := h.logs.Query(c.Request.Context(), query)
+	if errors.Is(err, fs.ErrNotExist) {
+		response.Error(c, http.StatusNotFound, fmt.Sprintf("Log file not found: %s.log", query.Log), nil)
+		return
 	}
-
-	if err := scanner.Err(); err != nil {
-		h.logger.Error("Failed to scan log file", "error", err)
-		response.Error(c, http.StatusInternalServerError, "Failed to read log file", nil)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to query logs", err)
 		return
 	}
 
-	// Get last N lines
-	startIdx := len(allLines) - lines
-	if startIdx < 0 {
-		startIdx = 0
+	meta := pagination.CursorMetadata{PageSize: query.Limit, HasNextPage: page.Next != nil}
+	if page.Next != nil {
+		meta.NextCursor = applog.EncodeCursor(*page.Next)
 	}
-	lastLines := allLines[startIdx:]
-
-	response.Success(c, http.StatusOK, gin.H{
-		"type":  logType,
-		"lines": len(lastLines),
-		"log":   lastLines,
-	}, "", nil)
+	entries := page.Entries
+	if entries == nil {
+		entries = []applog.Entry{}
+	}
+	response.Success(c, http.StatusOK, entries, "", meta)
 }
 
 // ClearLogs truncates all log files in the logs directory
 // POST /dashboard/logs/clear
 func (h *Handler) ClearLogs(c *gin.Context) {
-	logsDir := "logs"
+	logsDir := applog.Dir
 
 	// Check if logs directory exists
 	if _, err := os.Stat(logsDir); os.IsNotExist(err) {
@@ -188,82 +212,88 @@ type DiskStats struct {
 // GetAdminDashboard returns admin dashboard statistics
 // GET /dashboard/admin
 func (h *Handler) GetAdminDashboard(c *gin.Context) {
-	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
+	var result adminStats
+	if !h.queryCache.Get(c.Request.Context(), cache.NamespaceDashboard, "admin", &result) {
+		var err error
+		result, err = h.loadAdminStats()
+		if err != nil {
+			h.logger.Error("Failed to count subscriptions", "error", err)
+			response.Error(c, http.StatusInternalServerError, "Failed to retrieve dashboard data", nil)
+			return
+		}
+		h.queryCache.Set(c.Request.Context(), cache.NamespaceDashboard, "admin", result)
+	}
 
-	// Count queries in parallel
-	type countResult struct {
-		totalSubscriptions  int64
-		activeSubscriptions int64
-		instructorsCount    int64
-		recentSignups       int64
-		coursesCount        int64
-		lessonsCount        int64
-		totalStorageUsed    float64
+	// Get active meetings count from cache
+	activeMeetingsCount := 0
+	if h.meetingCache != nil {
+		stats := h.meetingCache.GetStats()
+		if count, ok := stats["totalActiveMeetings"].(int); ok {
+			activeMeetingsCount = count
+		}
 	}
 
-	var result countResult
-	var err error
+	response.Success(c, http.StatusOK, gin.H{
+		"subscriptionsCount":       result.TotalSubscriptions,
+		"activeSubscriptionsCount": result.ActiveSubscriptions,
+		"instructorsCount":         result.InstructorsCount,
+		"coursesCount":             result.CoursesCount,
+		"lessonsCount":             result.LessonsCount,
+		"activeMeetingsCount":      activeMeetingsCount,
+		"totalStorageUsed":         result.TotalStorageUsed,
+		"recentSignups":            result.RecentSignups,
+	}, "", nil)
+}
+
+// adminStats holds the database counts of the admin dashboard; live meeting
+// counts are read from memory and never cached.
+type adminStats struct {
+	TotalSubscriptions  int64   `json:"totalSubscriptions"`
+	ActiveSubscriptions int64   `json:"activeSubscriptions"`
+	InstructorsCount    int64   `json:"instructorsCount"`
+	RecentSignups       int64   `json:"recentSignups"`
+	CoursesCount        int64   `json:"coursesCount"`
+	LessonsCount        int64   `json:"lessonsCount"`
+	TotalStorageUsed    float64 `json:"totalStorageUsed"`
+}
+
+func (h *Handler) loadAdminStats() (adminStats, error) {
+	var result adminStats
+	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
 
 	// Total subscriptions
-	err = h.db.Model(&subscription.Subscription{}).Count(&result.totalSubscriptions).Error
-	if err != nil {
-		h.logger.Error("Failed to count subscriptions", "error", err)
-		response.Error(c, http.StatusInternalServerError, "Failed to retrieve dashboard data", nil)
-		return
+	if err := h.db.Model(&subscription.Subscription{}).Count(&result.TotalSubscriptions).Error; err != nil {
+		return result, err
 	}
 
 	// Active subscriptions
-	err = h.db.Model(&subscription.Subscription{}).Where("is_active = ?", true).Count(&result.activeSubscriptions).Error
+	err := h.db.Model(&subscription.Subscription{}).Where("is_active = ?", true).Count(&result.ActiveSubscriptions).Error
 	if err != nil {
 		h.logger.Error("Failed to count active subscriptions", "error", err)
 	}
 
 	// Instructors count
-	err = h.db.Model(&user.User{}).Where("user_type = ?", string(user.UserTypeInstructor)).Count(&result.instructorsCount).Error
+	err = h.db.Model(&user.User{}).Where("user_type = ?", string(user.UserTypeInstructor)).Count(&result.InstructorsCount).Error
 	if err != nil {
 		h.logger.Error("Failed to count instructors", "error", err)
 	}
 
 	// Recent signups (last 7 days)
-	err = h.db.Model(&user.User{}).Where("created_at >= ?", sevenDaysAgo).Count(&result.recentSignups).Error
+	err = h.db.Model(&user.User{}).Where("created_at >= ?", sevenDaysAgo).Count(&result.RecentSignups).Error
 	if err != nil {
 		h.logger.Error("Failed to count recent signups", "error", err)
 	}
 
-	// Courses count
-	err = h.db.Model(&course.Course{}).Count(&result.coursesCount).Error
+	// Courses, lessons and storage come from the per-subscription summaries
+	platform, err := GetPlatformStats(h.db)
 	if err != nil {
-		h.logger.Error("Failed to count courses", "error", err)
+		h.logger.Error("Failed to load subscription stats", "error", err)
 	}
+	result.CoursesCount = platform.CoursesCount
+	result.LessonsCount = platform.LessonsCount
+	result.TotalStorageUsed = platform.StorageUsageGB
 
-	// Lessons count
-	err = h.db.Model(&lesson.Lesson{}).Count(&result.lessonsCount).Error
-	if err != nil {
-		h.logger.Error("Failed to count lessons", "error", err)
-	}
-
-	// Total storage used (sum of storageUsageInGB)
-	h.db.Model(&course.Course{}).Select("COALESCE(SUM(storage_usage_in_gb), 0)").Scan(&result.totalStorageUsed)
-
-	// Get active meetings count from cache
-	activeMeetingsCount := 0
-	if h.meetingCache != nil {
-		stats := h.meetingCache.GetStats()
-		if count, ok := stats["totalActiveMeetings"].(int); ok {
-			activeMeetingsCount = count
-		}
-	}
-
-	response.Success(c, http.StatusOK, gin.H{
-		"subscriptionsCount":       result.totalSubscriptions,
-		"activeSubscriptionsCount": result.activeSubscriptions,
-		"instructorsCount":         result.instructorsCount,
-		"coursesCount":             result.coursesCount,
-		"lessonsCount":             result.lessonsCount,
-		"activeMeetingsCount":      activeMeetingsCount,
-		"totalStorageUsed":         result.totalStorageUsed,
-		"recentSignups":            result.recentSignups,
-	}, "", nil)
+	return result, nil
 }
 
 // GetInstructorDashboard returns instructor-specific dashboard statistics
@@ -292,22 +322,13 @@ func (h *Handler) GetInstructorDashboard(c *gin.Context) {
 		return
 	}
 
-	// Count courses
-	var coursesCount int64
-	h.db.Model(&course.Course{}).Where("subscription_id = ?", subscriptionID).Count(&coursesCount)
-
-	// Count lessons (through courses)
-	var lessonsCount int64
-	h.db.Model(&lesson.Lesson{}).
-		Joins("JOIN courses ON courses.id = lessons.course_id").
-		Where("courses.subscription_id = ?", subscriptionID).
-		Count(&lessonsCount)
-
-	// Count active students
-	var studentsCount int64
-	h.db.Model(&user.User{}).
-		Where("subscription_id = ? AND user_type = ? AND is_active = ?", subscriptionID, string(user.UserTypeStudent), true).
-		Count(&studentsCount)
+	// Course, lesson and student counts come from the periodically refreshed summary
+	stats, err := GetStats(h.db, sub.ID)
+	if err != nil {
+		h.logger.Error("Failed to load subscription stats", "error", err, "subscriptionId", subscriptionID)
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve dashboard data", nil)
+		return
+	}
 
 	// Calculate subscription days left
 	var subscriptionDaysLeft *int
@@ -324,12 +345,11 @@ func (h *Handler) GetInstructorDashboard(c *gin.Context) {
 	h.db.Where("subscription_id = ?", subscriptionID).Find(&groups)
 
 	subscriptionPointsUsed := 0
-	for i := range groups {
-		points, err := groups[i].CalculatePoints(h.db)
-		if err == nil {
-			groups[i].SubscriptionPointsUsage = points
-			subscriptionPointsUsed += points
-		}
+	if err := groupaccess.CalculateGroupsPoints(h.db, groups); err != nil {
+		h.logger.Error("Failed to calculate group points", "error", err, "subscriptionId", subscriptionID)
+	}
+	for _, group := range groups {
+		subscriptionPointsUsed += group.SubscriptionPointsUsage
 	}
 
 	subscriptionPointsRemaining := 0
@@ -343,9 +363,10 @@ func (h *Handler) GetInstructorDashboard(c *gin.Context) {
 	}
 
 	response.Success(c, http.StatusOK, gin.H{
-		"coursesCount":         coursesCount,
-		"lessonsCount":         lessonsCount,
-		"studentsCount":        studentsCount,
+		"coursesCount":         stats.CoursesCount,
+		"lessonsCount":         stats.LessonsCount,
+		"studentsCount":        stats.StudentsCount,
+		"statsRefreshedAt":     stats.RefreshedAt,
 		"subscriptionDaysLeft": subscriptionDaysLeft,
 		"subscription":         sub,
 		"subscriptionStatus":   subscriptionStatus,
@@ -428,7 +449,6 @@ func (h *Handler) GetStudentDashboard(c *gin.Context) {
 		// Collect accessible course and lesson IDs
 		courseIDMap := make(map[string]bool)
 		lessonIDMap := make(map[string]bool)
-		announcementIDMap := make(map[string]bool)
 
 		for _, group := range groups {
 			// Add direct course access
@@ -440,11 +460,6 @@ func (h *Handler) GetStudentDashboard(c *gin.Context) {
 			for _, lessonID := range group.Lessons {
 				lessonIDMap[lessonID] = true
 			}
-
-			// Add announcement access
-			for _, announcementID := range group.Announcements {
-				announcementIDMap[announcementID] = true
-			}
 		}
 
 		// Get unique course IDs from accessible lessons
@@ -456,7 +471,7 @@ func (h *Handler) GetStudentDashboard(c *gin.Context) {
 
 			var lessonCourses []string
 			h.db.Table("lessons").
-				Where("id IN ? AND is_active = ?", lessonIDs, true).
+				Where("id IN ? AND is_active = ? AND status = ?", lessonIDs, true, types.ContentStatusPublished).
 				Pluck("course_id", &lessonCourses)
 
 			for _, courseID := range lessonCourses {
@@ -472,39 +487,29 @@ func (h *Handler) GetStudentDashboard(c *gin.Context) {
 			}
 
 			if err := h.db.Preload("Lessons", func(db *gorm.DB) *gorm.DB {
-				return db.Where("is_active = ?", true).
+				return db.Where("is_active = ? AND status = ?", true, types.ContentStatusPublished).
 					Order("\"order\" ASC")
 			}).
-				Where("id IN ? AND subscription_id = ? AND is_active = ?", courseIDs, subscriptionID, true).
+				Where("id IN ? AND subscription_id = ? AND is_active = ? AND status = ?", courseIDs, subscriptionID, true, types.ContentStatusPublished).
 				Order("\"order\" ASC").
 				Find(&courses).Error; err != nil {
 				response.Error(c, http.StatusInternalServerError, "Failed to load dashboard data", nil)
 				return
 			}
-		}
 
-		// Get announcements (public + group-specific)
-		announcementIDs := make([]string, 0, len(announcementIDMap))
-		for id := range announcementIDMap {
-			announcementIDs = append(announcementIDs, id)
+			// Scheduled lessons are listed with their release date but stay locked
+			for i := range courses {
+				lesson.ApplyDrip(courses[i].Lessons, currentUser.CreatedAt, time.Now().UTC())
+			}
 		}
 
-		if len(announcementIDs) > 0 {
-			if err := h.db.Where("subscription_id = ? AND is_active = ? AND (is_public = ? OR id IN ?)",
-				subscriptionID, true, true, announcementIDs).
-				Order("created_at DESC").
-				Find(&announcements).Error; err != nil {
-				response.Error(c, http.StatusInternalServerError, "Failed to load dashboard data", nil)
-				return
-			}
-		} else {
-			if err := h.db.Where("subscription_id = ? AND is_active = ? AND is_public = ?",
-				subscriptionID, true, true).
-				Order("created_at DESC").
-				Find(&announcements).Error; err != nil {
-				response.Error(c, http.StatusInternalServerError, "Failed to load dashboard data", nil)
-				return
-			}
+		// Get announcements (public, group-specific and targeted)
+		if err := announcement.VisibleTo(h.db.Where("subscription_id = ? AND is_active = ?", subscriptionID, true),
+			sub.ID, currentUser.ID, currentUser.UserType).
+			Order("created_at DESC").
+			Find(&announcements).Error; err != nil {
+			response.Error(c, http.StatusInternalServerError, "Failed to load dashboard data", nil)
+			return
 		}
 
 		// Get user watches
@@ -528,7 +533,7 @@ func (h *Handler) GetStudentDashboard(c *gin.Context) {
 			for _, courseItem := range courses {
 				for _, lessonItem := range courseItem.Lessons {
 					id := lessonItem.ID.String()
-					if _, ok := lessonIDSet[id]; ok {
+					if _, ok := lessonIDSet[id]; ok && !lessonItem.Locked {
 						activeLessons = append(activeLessons, lessonItem)
 						delete(lessonIDSet, id)
 					}
@@ -0,0 +1,297 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	defaultRevenueMonths     = 12
+	maxRevenueRange          = 5 * 366 * 24 * time.Hour
+	topRevenueSubscriptions  = 20
+	renewalGracePeriodInDays = 30
+)
+
+// revenueMonth is the payment revenue booked in one month and currency.
+type revenueMonth struct {
+	Month        time.Time   `json:"month"`
+	Currency     string      `json:"currency"`
+	Transactions int64       `json:"transactions"`
+	Gross        types.Money `json:"gross"`
+	Refunded     types.Money `json:"refunded"`
+	Discounts    types.Money `json:"discounts"`
+	Net          types.Money `json:"net"`
+}
+
+// revenueGroup is net revenue for one store, package or subscription in one currency.
+type revenueGroup struct {
+	Key          string      `json:"key"`
+	Label        string      `json:"label,omitempty"`
+	Currency     string      `json:"currency"`
+	Transactions int64       `json:"transactions"`
+	Net          types.Money `json:"net"`
+}
+
+// iapRevenueGroup summarises IAP purchases. Stores do not report the charged price, so
+// the amount is estimated from the package list price after its discount.
+type iapRevenueGroup struct {
+	Month           *time.Time  `json:"month,omitempty"`
+	Store           string      `json:"store,omitempty"`
+	PackageID       *uuid.UUID  `json:"packageId,omitempty"`
+	PackageName     string      `json:"packageName,omitempty"`
+	Purchases       int64       `json:"purchases"`
+	Refunded        int64       `json:"refunded"`
+	EstimatedAmount types.Money `json:"estimatedAmount"`
+}
+
+// churnMonth compares billing periods that ended in a month with those that were renewed.
+type churnMonth struct {
+	Month     time.Time `json:"month"`
+	Ended     int64     `json:"ended"`
+	Renewed   int64     `json:"renewed"`
+	Expired   int64     `json:"expired"`
+	ChurnRate float64   `json:"churnRate"`
+}
+
+// mrrEntry is the monthly recurring revenue currently covered by active billing periods.
+type mrrEntry struct {
+	Currency string      `json:"currency"`
+	Amount   types.Money `json:"amount"`
+}
+
+// GetAdminRevenue aggregates payments and IAP purchases for billing analytics
+// GET /dashboard/admin/revenue?dateFrom=&dateTo=
+func (h *Handler) GetAdminRevenue(c *gin.Context) {
+	now := time.Now().UTC()
+
+	dateTo := now
+	if value := c.Query("dateTo"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateTo format", err)
+			return
+		}
+		dateTo = t.UTC()
+	}
+
+	firstOfMonth := time.Date(dateTo.Year(), dateTo.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dateFrom := firstOfMonth.AddDate(0, -(defaultRevenueMonths - 1), 0)
+	if value := c.Query("dateFrom"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateFrom format", err)
+			return
+		}
+		dateFrom = t.UTC()
+	}
+
+	if !dateFrom.Before(dateTo) {
+		response.Error(c, http.StatusBadRequest, "dateFrom must be before dateTo", nil)
+		return
+	}
+	if dateTo.Sub(dateFrom) > maxRevenueRange {
+		response.Error(c, http.StatusBadRequest, "Date range cannot exceed 5 years", nil)
+		return
+	}
+
+	args := map[string]interface{}{
+		"from":  dateFrom,
+		"to":    dateTo,
+		"now":   now,
+		"grace": renewalGracePeriodInDays,
+		"limit": topRevenueSubscriptions,
+	}
+
+	// Revenue reports scan whole tables and tolerate replication lag
+	db := replica.Reader(h.db.WithContext(c.Request.Context()))
+
+	byMonth := make([]revenueMonth, 0)
+	if err := db.Raw(`
+		SELECT date_trunc('month', p.date) AS month, p.currency,
+			COUNT(*) AS transactions,
+			COALESCE(SUM(p.amount), 0) AS gross,
+			COALESCE(SUM(p.refunded_amount), 0) AS refunded,
+			COALESCE(SUM(p.discount), 0) AS discounts,
+			COALESCE(SUM(p.amount - p.refunded_amount), 0) AS net
+		FROM payments p
+		WHERE `+revenuePaymentFilter+`
+		GROUP BY 1, 2
+		ORDER BY 1 ASC, 2 ASC`, args).Scan(&byMonth).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load monthly revenue", err)
+		return
+	}
+
+	byStore := make([]revenueGroup, 0)
+	if err := db.Raw(`
+		SELECT p.payment_method AS key, p.currency,
+			COUNT(*) AS transactions,
+			COALESCE(SUM(p.amount - p.refunded_amount), 0) AS net
+		FROM payments p
+		WHERE `+revenuePaymentFilter+`
+		GROUP BY 1, 2
+		ORDER BY net DESC`, args).Scan(&byStore).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load revenue by store", err)
+		return
+	}
+
+	byPackage := make([]revenueGroup, 0)
+	if err := db.Raw(`
+		SELECT COALESCE(pk.id::text, 'none') AS key, COALESCE(pk.name, 'No package') AS label, p.currency,
+			COUNT(*) AS transactions,
+			COALESCE(SUM(p.amount - p.refunded_amount), 0) AS net
+		FROM payments p
+		JOIN subscriptions s ON s.id = p.subscription_id
+		LEFT JOIN subscription_packages pk ON pk.id = s.package_id
+		WHERE `+revenuePaymentFilter+`
+		GROUP BY pk.id, pk.name, p.currency
+		ORDER BY net DESC`, args).Scan(&byPackage).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load revenue by package", err)
+		return
+	}
+
+	bySubscription := make([]revenueGroup, 0)
+	if err := db.Raw(`
+		SELECT s.id::text AS key, COALESCE(s.display_name, s.identifier_name) AS label, p.currency,
+			COUNT(*) AS transactions,
+			COALESCE(SUM(p.amount - p.refunded_amount), 0) AS net
+		FROM payments p
+		JOIN subscriptions s ON s.id = p.subscription_id
+		WHERE `+revenuePaymentFilter+`
+		GROUP BY s.id, s.display_name, s.identifier_name, p.currency
+		ORDER BY net DESC
+		LIMIT @limit`, args).Scan(&bySubscription).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load revenue by subscription", err)
+		return
+	}
+
+	iapByMonth := make([]iapRevenueGroup, 0)
+	if err := db.Raw(`
+		SELECT date_trunc('month', ip.purchase_date) AS month, ip.store,
+			COUNT(*) AS purchases,
+			COUNT(*) FILTER (WHERE ip.status = 'refunded') AS refunded,
+			COALESCE(SUM(pk.price * (1 - pk.discount_percentage / 100)) FILTER (WHERE ip.status <> 'refunded'), 0) AS estimated_amount
+		FROM iap_purchases ip
+		LEFT JOIN subscription_packages pk ON pk.id = ip.package_id
+		WHERE `+revenueIAPFilter+`
+		GROUP BY 1, 2
+		ORDER BY 1 ASC, 2 ASC`, args).Scan(&iapByMonth).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load IAP revenue", err)
+		return
+	}
+
+	iapByPackage := make([]iapRevenueGroup, 0)
+	if err := db.Raw(`
+		SELECT ip.package_id, COALESCE(pk.name, '') AS package_name,
+			COUNT(*) AS purchases,
+			COUNT(*) FILTER (WHERE ip.status = 'refunded') AS refunded,
+			COALESCE(SUM(pk.price * (1 - pk.discount_percentage / 100)) FILTER (WHERE ip.status <> 'refunded'), 0) AS estimated_amount
+		FROM iap_purchases ip
+		LEFT JOIN subscription_packages pk ON pk.id = ip.package_id
+		WHERE `+revenueIAPFilter+`
+		GROUP BY ip.package_id, pk.name
+		ORDER BY purchases DESC`, args).Scan(&iapByPackage).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load IAP revenue by package", err)
+		return
+	}
+
+	// A billing period is renewed when the same subscription (or IAP original transaction)
+	// is paid again before the period ends plus the grace window.
+	churn := make([]churnMonth, 0)
+	if err := db.Raw(`
+		WITH periods AS (
+			SELECT p.date + make_interval(days => p.period_in_days) AS ends_at,
+				EXISTS (
+					SELECT 1 FROM payments nxt
+					WHERE nxt.subscription_id = p.subscription_id
+						AND nxt.id <> p.id
+						AND nxt.is_addition = FALSE
+						AND nxt.status IN ('completed', 'partially_refunded')
+						AND nxt.date > p.date
+						AND nxt.date <= p.date + make_interval(days => p.period_in_days + @grace)
+				) AS renewed
+			FROM payments p
+			WHERE p.is_addition = FALSE
+				AND p.period_in_days > 0
+				AND p.status IN ('completed', 'partially_refunded')
+			UNION ALL
+			SELECT ip.expiry_date AS ends_at,
+				EXISTS (
+					SELECT 1 FROM iap_purchases nxt
+					WHERE nxt.original_transaction_id = ip.original_transaction_id
+						AND nxt.original_transaction_id <> ''
+						AND nxt.id <> ip.id
+						AND nxt.purchase_date > ip.purchase_date
+				) OR (ip.status = 'validated' AND ip.auto_renewing AND ip.expiry_date > @now) AS renewed
+			FROM iap_purchases ip
+			WHERE ip.expiry_date IS NOT NULL
+				AND ip.status IN ('validated', 'expired', 'canceled')
+		)
+		SELECT date_trunc('month', ends_at) AS month,
+			COUNT(*) AS ended,
+			COUNT(*) FILTER (WHERE renewed) AS renewed,
+			COUNT(*) FILTER (WHERE NOT renewed) AS expired
+		FROM periods
+		WHERE ends_at >= @from AND ends_at < @to AND ends_at <= @now
+		GROUP BY 1
+		ORDER BY 1 ASC`, args).Scan(&churn).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load churn", err)
+		return
+	}
+	for i := range churn {
+		if churn[i].Ended > 0 {
+			churn[i].ChurnRate = float64(churn[i].Expired*10000/churn[i].Ended) / 100
+		}
+	}
+
+	// MRR normalises every billing period that covers today to a 30-day month.
+	mrr := make([]mrrEntry, 0)
+	if err := db.Raw(`
+		SELECT p.currency,
+			COALESCE(SUM((p.amount - p.refunded_amount) * 30 / p.period_in_days), 0) AS amount
+		FROM payments p
+		WHERE p.status IN ('completed', 'partially_refunded')
+			AND p.period_in_days > 0
+			AND p.date <= @now
+			AND p.date + make_interval(days => p.period_in_days) > @now
+		GROUP BY p.currency
+		ORDER BY p.currency ASC`, args).Scan(&mrr).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load MRR", err)
+		return
+	}
+
+	var activeIAPSubscriptions int64
+	if err := db.Table("iap_purchases").
+		Where("status = ? AND expiry_date > ?", "validated", now).
+		Count(&activeIAPSubscriptions).Error; err != nil {
+		h.logger.Error("Failed to count active IAP subscriptions", "error", err)
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"dateFrom":       dateFrom,
+		"dateTo":         dateTo,
+		"byMonth":        byMonth,
+		"byStore":        byStore,
+		"byPackage":      byPackage,
+		"bySubscription": bySubscription,
+		"iap": gin.H{
+			"byMonth":             iapByMonth,
+			"byPackage":           iapByPackage,
+			"activeSubscriptions": activeIAPSubscriptions,
+		},
+		"churn": churn,
+		"mrr":   mrr,
+	}, "", nil)
+}
+
+const revenuePaymentFilter = `p.status IN ('completed', 'partially_refunded', 'refunded')
+			AND p.date >= @from AND p.date < @to`
+
+const revenueIAPFilter = `ip.status IN ('validated', 'expired', 'canceled', 'refunded')
+			AND ip.purchase_date >= @from AND ip.purchase_date < @to`
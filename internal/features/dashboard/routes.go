@@ -2,6 +2,9 @@ package dashboard
 
 import (
 	"github.com/gin-gonic/gin"
+
+	applog "github.com/mo-amir99/lms-server-go/pkg/logger"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdmin, acInstructorStaff, acAllWithInactive, acSuperAdmin []gin.HandlerFunc) {
@@ -14,6 +17,20 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdmin, acInstru
 			)...,
 		)
 
+		dashboard.GET("/admin/revenue",
+			append(
+				acAdmin,
+				handler.GetAdminRevenue,
+			)...,
+		)
+
+		dashboard.GET("/admin/cdn",
+			append(
+				acAdmin,
+				handler.GetAdminCDNUsage,
+			)...,
+		)
+
 		dashboard.GET("/instructor/:subscriptionId",
 			append(
 				acInstructorStaff,
@@ -21,6 +38,13 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdmin, acInstru
 			)...,
 		)
 
+		dashboard.GET("/instructor/:subscriptionId/analytics",
+			append(
+				acInstructorStaff,
+				handler.GetInstructorAnalytics,
+			)...,
+		)
+
 		dashboard.GET("/student/:subscriptionId",
 			append(
 				acAllWithInactive,
@@ -49,4 +73,7 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdmin, acInstru
 			)...,
 		)
 	}
+
+	openapi.Describe(handler.GetAdminCDNUsage, openapi.Spec{Response: cdnUsage{}})
+	openapi.Describe(handler.GetSystemLogs, openapi.Spec{Response: []applog.Entry{}})
 }
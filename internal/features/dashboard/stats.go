@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// SubscriptionStats is a periodically refreshed summary of a subscription's
+// content and members, so dashboards read one row instead of counting tables.
+type SubscriptionStats struct {
+	SubscriptionID uuid.UUID `gorm:"type:uuid;primaryKey;column:subscription_id" json:"subscriptionId"`
+	CoursesCount   int64     `gorm:"type:bigint;not null;default:0;column:courses_count" json:"coursesCount"`
+	LessonsCount   int64     `gorm:"type:bigint;not null;default:0;column:lessons_count" json:"lessonsCount"`
+	StudentsCount  int64     `gorm:"type:bigint;not null;default:0;column:students_count" json:"studentsCount"`
+	StorageUsageGB float64   `gorm:"type:numeric(12,2);not null;default:0;column:storage_usage_gb" json:"storageUsageGB"`
+	RefreshedAt    time.Time `gorm:"not null;column:refreshed_at" json:"refreshedAt"`
+}
+
+// TableName overrides the default table name.
+func (SubscriptionStats) TableName() string { return "subscription_stats" }
+
+// PlatformStats sums the subscription summaries for the admin dashboard.
+type PlatformStats struct {
+	CoursesCount   int64   `json:"coursesCount"`
+	LessonsCount   int64   `json:"lessonsCount"`
+	StorageUsageGB float64 `json:"storageUsageGB"`
+}
+
+const refreshStatsSQL = `
+INSERT INTO subscription_stats (subscription_id, courses_count, lessons_count, students_count, storage_usage_gb, refreshed_at)
+SELECT s.id,
+	(SELECT COUNT(*) FROM courses c WHERE c.subscription_id = s.id),
+	(SELECT COUNT(*) FROM lessons l JOIN courses c ON c.id = l.course_id WHERE c.subscription_id = s.id),
+	(SELECT COUNT(*) FROM users u WHERE u.subscription_id = s.id AND u.user_type = ? AND u.is_active = true),
+	(SELECT COALESCE(SUM(c.storage_usage_in_gb), 0) FROM courses c WHERE c.subscription_id = s.id),
+	NOW()
+FROM subscriptions s
+%s
+ON CONFLICT (subscription_id) DO UPDATE SET
+	courses_count = EXCLUDED.courses_count,
+	lessons_count = EXCLUDED.lessons_count,
+	students_count = EXCLUDED.students_count,
+	storage_usage_gb = EXCLUDED.storage_usage_gb,
+	refreshed_at = EXCLUDED.refreshed_at`
+
+// RefreshStats recomputes the summaries of the given subscriptions, or of every
+// subscription when none are given, and drops summaries of deleted ones.
+func RefreshStats(db *gorm.DB, subscriptionIDs ...uuid.UUID) error {
+	if len(subscriptionIDs) > 0 {
+		return db.Exec(fmt.Sprintf(refreshStatsSQL, "WHERE s.id IN ?"), string(types.UserTypeStudent), subscriptionIDs).Error
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf(refreshStatsSQL, ""), string(types.UserTypeStudent)).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM subscription_stats WHERE subscription_id NOT IN (SELECT id FROM subscriptions)").Error
+	})
+}
+
+// GetStats returns a subscription's summary, computing it on first use.
+func GetStats(db *gorm.DB, subscriptionID uuid.UUID) (SubscriptionStats, error) {
+	var stats SubscriptionStats
+	err := db.First(&stats, "subscription_id = ?", subscriptionID).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return stats, err
+	}
+
+	if err := RefreshStats(db, subscriptionID); err != nil {
+		return stats, err
+	}
+	err = db.First(&stats, "subscription_id = ?", subscriptionID).Error
+	return stats, err
+}
+
+// GetPlatformStats sums every subscription summary.
+func GetPlatformStats(db *gorm.DB) (PlatformStats, error) {
+	var stats PlatformStats
+	err := db.Model(&SubscriptionStats{}).
+		Select("COALESCE(SUM(courses_count), 0) AS courses_count, " +
+			"COALESCE(SUM(lessons_count), 0) AS lessons_count, " +
+			"COALESCE(SUM(storage_usage_gb), 0) AS storage_usage_gb").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// StatsJob periodically refreshes every subscription summary.
+type StatsJob struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewStatsJob constructs the subscription stats refresh job.
+func NewStatsJob(db *gorm.DB, logger *slog.Logger) *StatsJob {
+	return &StatsJob{db: db, logger: logger}
+}
+
+// Name returns the job name.
+func (j *StatsJob) Name() string {
+	return "subscription-stats"
+}
+
+// Execute recomputes all summaries in a single pass.
+func (j *StatsJob) Execute(ctx context.Context) error {
+	started := time.Now()
+	if err := RefreshStats(j.db.WithContext(ctx)); err != nil {
+		return fmt.Errorf("refresh subscription stats: %w", err)
+	}
+
+	j.logger.Debug("subscription stats refreshed", slog.Duration("took", time.Since(started)))
+	return nil
+}
@@ -0,0 +1,33 @@
+package download
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrCourseNotFound       = errors.New("course not found")
+	ErrLessonNotFound       = errors.New("lesson not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrDownloadNotFound     = errors.New("download not found")
+	ErrLessonLimitReached   = errors.New("download limit reached for this lesson")
+	ErrDeviceLimitReached   = errors.New("download device limit reached")
+	ErrDeviceMismatch       = errors.New("downloads are limited to the registered device")
+	ErrPrerequisitesMissing = errors.New("lesson prerequisites are not completed")
+	ErrNotYetAvailable      = errors.New("lesson is not available yet")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found."},
+	{Err: ErrDownloadNotFound, Status: http.StatusNotFound, Code: "DOWNLOAD_NOT_FOUND", Message: "Download not found."},
+	{Err: ErrLessonLimitReached, Status: http.StatusTooManyRequests, Code: "LESSON_LIMIT_REACHED", Message: "Download limit reached for this lesson."},
+	{Err: ErrDeviceLimitReached, Status: http.StatusForbidden, Code: "DEVICE_LIMIT_REACHED", Message: "Downloads are already active on the maximum number of devices. Remove downloads from another device first."},
+	{Err: ErrDeviceMismatch, Status: http.StatusForbidden, Code: "DEVICE_MISMATCH", Message: "Downloads are limited to your registered device."},
+	{Err: ErrNotYetAvailable, Status: http.StatusForbidden, Code: "LESSON_NOT_AVAILABLE_YET", Message: "This lesson is not available yet."},
+	{Err: ErrPrerequisitesMissing, Status: http.StatusForbidden, Code: "PREREQUISITES_MISSING", Message: "Complete the prerequisite lessons first."},
+}
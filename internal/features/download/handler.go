@@ -0,0 +1,290 @@
+package download
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler serves offline download authorizations.
+type Handler struct {
+	db           *gorm.DB
+	logger       *slog.Logger
+	streamClient *bunny.StreamClient
+}
+
+// NewHandler constructs a download handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClient) *Handler {
+	return &Handler{db: db, logger: logger, streamClient: streamClient}
+}
+
+type issueRequest struct {
+	DeviceID string `json:"deviceId" binding:"required,notblank,max=255"`
+}
+
+type issueResponse struct {
+	Download     Download  `json:"download"`
+	DownloadURL  string    `json:"downloadUrl"`
+	URLExpiresAt time.Time `json:"urlExpiresAt"`
+}
+
+// Issue authorizes the device to download a lesson video for offline viewing.
+// The file URL is short-lived; the download itself stays playable until
+// expiresAt unless it is revoked first.
+// POST /subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/downloads
+func (h *Handler) Issue(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return
+	}
+
+	var req issueRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid download payload") {
+		return
+	}
+
+	manager := authz.Can(authz.SubjectFrom(usr), authz.PermContentManage)
+	item, err := h.ensureLesson(subscriptionID, courseID, lessonID, !manager)
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return
+	}
+
+	now := time.Now().UTC()
+	if !manager {
+		if err := h.checkDevice(usr.ID, req.DeviceID); err != nil {
+			h.respondError(c, err, "failed to check device")
+			return
+		}
+		if release := item.ReleaseAt(usr.CreatedAt); release != nil && release.After(now) {
+			errorCatalog.RespondWithData(h.logger, c, ErrNotYetAvailable, gin.H{
+				"availableAt": release,
+			})
+			return
+		}
+		missing, err := lesson.MissingPrerequisites(h.db, usr.ID, item)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check lesson prerequisites", err)
+			return
+		}
+		if len(missing) > 0 {
+			errorCatalog.RespondWithData(h.logger, c, ErrPrerequisitesMissing, gin.H{
+				"missingPrerequisites": missing,
+			})
+			return
+		}
+	}
+
+	download, err := Issue(h.db, IssueInput{
+		UserID:         usr.ID,
+		SubscriptionID: subscriptionID,
+		LessonID:       item.ID,
+		DeviceID:       req.DeviceID,
+	}, now)
+	if err != nil {
+		h.respondError(c, err, "failed to authorize download")
+		return
+	}
+
+	downloadURL, err := h.streamClient.SignedDownloadURL(item.VideoID, RenditionHeight, URLTTL)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to sign download URL", err)
+		return
+	}
+
+	response.Created(c, issueResponse{
+		Download:     download,
+		DownloadURL:  downloadURL,
+		URLExpiresAt: now.Add(URLTTL),
+	}, "")
+}
+
+// ListMine returns the user's playable downloads, optionally for one device.
+// GET /me/downloads?deviceId=
+func (h *Handler) ListMine(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	downloads, err := ListLive(h.db, usr.ID, c.Query("deviceId"), time.Now().UTC())
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list downloads", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, downloads, "", nil)
+}
+
+type checkRequest struct {
+	DeviceID    string      `json:"deviceId" binding:"required,notblank,max=255"`
+	DownloadIDs []uuid.UUID `json:"downloadIds" binding:"max=500"`
+}
+
+type checkResponse struct {
+	Revoked []uuid.UUID `json:"revoked"`
+}
+
+// Check is the revocation list the app consults before offline playback: it
+// returns the downloads among those held by the device that must be deleted.
+// POST /me/downloads/check
+func (h *Handler) Check(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req checkRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid download check payload") {
+		return
+	}
+
+	revoked, err := Revoked(h.db, usr.ID, req.DeviceID, req.DownloadIDs, time.Now().UTC())
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check downloads", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, checkResponse{Revoked: revoked}, "", nil)
+}
+
+// RevokeMine ends one of the user's own downloads, freeing its device slot.
+// DELETE /me/downloads/:downloadId
+func (h *Handler) RevokeMine(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("downloadId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid download id", err)
+		return
+	}
+
+	if err := Revoke(h.db, usr.ID, id); err != nil {
+		h.respondError(c, err, "failed to revoke download")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// RevokeForUser ends every download of a student in the subscription.
+// DELETE /subscriptions/:subscriptionId/users/:userId/downloads
+func (h *Handler) RevokeForUser(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	var count int64
+	if err := h.db.Table("users").Where("id = ? AND subscription_id = ?", userID, subscriptionID).Count(&count).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load user", err)
+		return
+	}
+	if count == 0 {
+		h.respondError(c, ErrUserNotFound, "failed to load user")
+		return
+	}
+
+	revoked, err := RevokeAll(h.db, subscriptionID, userID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to revoke downloads", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"revoked": revoked}, "", nil)
+}
+
+// ensureLesson loads the lesson and checks it belongs to the course and
+// subscription. Students may only download active, published content.
+func (h *Handler) ensureLesson(subscriptionID, courseID, lessonID uuid.UUID, activeOnly bool) (lesson.Lesson, error) {
+	course, err := coursefeature.Get(h.db, courseID)
+	if err != nil {
+		if errors.Is(err, coursefeature.ErrCourseNotFound) {
+			return lesson.Lesson{}, ErrCourseNotFound
+		}
+		return lesson.Lesson{}, err
+	}
+	if course.SubscriptionID != subscriptionID || (activeOnly && !course.VisibleToStudents()) {
+		return lesson.Lesson{}, ErrCourseNotFound
+	}
+
+	item, err := lesson.Get(h.db, lessonID)
+	if err != nil {
+		if errors.Is(err, lesson.ErrLessonNotFound) {
+			return lesson.Lesson{}, ErrLessonNotFound
+		}
+		return lesson.Lesson{}, err
+	}
+	if item.CourseID != courseID || (activeOnly && !item.VisibleToStudents()) {
+		return lesson.Lesson{}, ErrLessonNotFound
+	}
+	return item, nil
+}
+
+// checkDevice applies the subscription's same-device rule: when it is on,
+// downloads may only go to the device the student signs in with.
+func (h *Handler) checkDevice(userID uuid.UUID, deviceID string) error {
+	var row struct {
+		DeviceID      *string
+		RequireDevice bool
+	}
+	err := h.db.Table("users u").
+		Select("u.device_id, COALESCE(s.is_require_same_device_id, FALSE) AS require_device").
+		Joins("LEFT JOIN subscriptions s ON s.id = u.subscription_id").
+		Where("u.id = ?", userID).
+		Scan(&row).Error
+	if err != nil {
+		return err
+	}
+	if row.RequireDevice && row.DeviceID != nil && *row.DeviceID != deviceID {
+		return ErrDeviceMismatch
+	}
+	return nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,166 @@
+package download
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	// LicenseTTL is how long a downloaded lesson may be played offline before
+	// the app must check in again.
+	LicenseTTL = 7 * 24 * time.Hour
+	// URLTTL is how long the signed file URL handed to the app stays valid.
+	URLTTL = 15 * time.Minute
+	// MaxDevices caps the devices holding live downloads for one user.
+	MaxDevices = 2
+	// MaxPerLesson caps the downloads of one lesson a user may start within PerLessonWindow.
+	MaxPerLesson    = 3
+	PerLessonWindow = 30 * 24 * time.Hour
+	// RenditionHeight is the MP4 rendition offered for offline viewing.
+	RenditionHeight = 720
+)
+
+// Download authorizes one device to keep a lesson video for offline viewing.
+type Download struct {
+	types.BaseModel
+
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index:idx_lesson_downloads_user_lesson,priority:1;index:idx_lesson_downloads_user_device,priority:1" json:"userId"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	LessonID       uuid.UUID  `gorm:"type:uuid;not null;column:lesson_id;index:idx_lesson_downloads_user_lesson,priority:2" json:"lessonId"`
+	DeviceID       string     `gorm:"type:varchar(255);not null;column:device_id;index:idx_lesson_downloads_user_device,priority:2" json:"deviceId"`
+	ExpiresAt      time.Time  `gorm:"type:timestamp;not null;column:expires_at" json:"expiresAt"`
+	RevokedAt      *time.Time `gorm:"type:timestamp;column:revoked_at" json:"revokedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Download) TableName() string { return "lesson_downloads" }
+
+// Live reports whether the download may still be played at now.
+func (d Download) Live(now time.Time) bool {
+	return d.RevokedAt == nil && d.ExpiresAt.After(now)
+}
+
+// IssueInput carries the data for authorizing a download.
+type IssueInput struct {
+	UserID         uuid.UUID
+	SubscriptionID uuid.UUID
+	LessonID       uuid.UUID
+	DeviceID       string
+}
+
+// Issue records a download after checking the per-lesson and per-device limits.
+// The checks and insert run under a lock on the user's downloads so parallel
+// requests cannot both pass the limits.
+func Issue(db *gorm.DB, input IssueInput, now time.Time) (Download, error) {
+	download := Download{
+		UserID:         input.UserID,
+		SubscriptionID: input.SubscriptionID,
+		LessonID:       input.LessonID,
+		DeviceID:       input.DeviceID,
+		ExpiresAt:      now.Add(LicenseTTL),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "lesson_downloads:"+input.UserID.String()).Error; err != nil {
+			return err
+		}
+
+		var recent int64
+		if err := tx.Model(&Download{}).
+			Where("user_id = ? AND lesson_id = ? AND created_at > ?", input.UserID, input.LessonID, now.Add(-PerLessonWindow)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent >= MaxPerLesson {
+			return ErrLessonLimitReached
+		}
+
+		var devices []string
+		if err := tx.Model(&Download{}).
+			Distinct("device_id").
+			Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", input.UserID, now).
+			Pluck("device_id", &devices).Error; err != nil {
+			return err
+		}
+		known := false
+		for _, device := range devices {
+			if device == input.DeviceID {
+				known = true
+				break
+			}
+		}
+		if !known && len(devices) >= MaxDevices {
+			return ErrDeviceLimitReached
+		}
+
+		return tx.Create(&download).Error
+	})
+	return download, err
+}
+
+// ListLive returns the user's downloads that can still be played, optionally
+// only those of one device.
+func ListLive(db *gorm.DB, userID uuid.UUID, deviceID string, now time.Time) ([]Download, error) {
+	query := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now)
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+
+	downloads := make([]Download, 0)
+	err := query.Order("created_at DESC").Find(&downloads).Error
+	return downloads, err
+}
+
+// Revoked returns which of the given downloads the device must delete: those
+// revoked, expired, unknown, or issued to another user or device.
+func Revoked(db *gorm.DB, userID uuid.UUID, deviceID string, ids []uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	revoked := make([]uuid.UUID, 0)
+	if len(ids) == 0 {
+		return revoked, nil
+	}
+
+	var live []uuid.UUID
+	if err := db.Model(&Download{}).
+		Where("id IN ? AND user_id = ? AND device_id = ? AND revoked_at IS NULL AND expires_at > ?", ids, userID, deviceID, now).
+		Pluck("id", &live).Error; err != nil {
+		return nil, err
+	}
+
+	valid := make(map[uuid.UUID]bool, len(live))
+	for _, id := range live {
+		valid[id] = true
+	}
+	for _, id := range ids {
+		if !valid[id] {
+			revoked = append(revoked, id)
+		}
+	}
+	return revoked, nil
+}
+
+// Revoke ends one of the user's downloads.
+func Revoke(db *gorm.DB, userID, id uuid.UUID) error {
+	result := db.Model(&Download{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDownloadNotFound
+	}
+	return nil
+}
+
+// RevokeAll ends every live download of the user in the subscription and
+// returns how many were revoked.
+func RevokeAll(db *gorm.DB, subscriptionID, userID uuid.UUID) (int64, error) {
+	result := db.Model(&Download{}).
+		Where("user_id = ? AND subscription_id = ? AND revoked_at IS NULL", userID, subscriptionID).
+		Update("revoked_at", time.Now().UTC())
+	return result.RowsAffected, result.Error
+}
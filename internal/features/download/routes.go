@@ -0,0 +1,22 @@
+package download
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches offline download endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff, allUsers []gin.HandlerFunc) {
+	router.POST("/subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/downloads", append(acAll, handler.Issue)...)
+	router.DELETE("/subscriptions/:subscriptionId/users/:userId/downloads", append(acStaff, handler.RevokeForUser)...)
+
+	me := router.Group("/me/downloads")
+	me.GET("", append(allUsers, handler.ListMine)...)
+	me.POST("/check", append(allUsers, handler.Check)...)
+	me.DELETE("/:downloadId", append(allUsers, handler.RevokeMine)...)
+
+	openapi.Describe(handler.Issue, openapi.Spec{Request: issueRequest{}, Response: issueResponse{}})
+	openapi.Describe(handler.ListMine, openapi.Spec{Response: []Download{}})
+	openapi.Describe(handler.Check, openapi.Spec{Request: checkRequest{}, Response: checkResponse{}})
+}
@@ -0,0 +1,21 @@
+package emailqueue
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrMessageNotFound = errors.New("email message not found")
+	ErrNotResendable   = errors.New("only failed messages can be resent")
+	ErrInvalidStatus   = errors.New("invalid email status")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrMessageNotFound, Status: http.StatusNotFound, Code: "EMAIL_NOT_FOUND", Message: "Email message not found."},
+	{Err: ErrNotResendable, Status: http.StatusConflict, Code: "EMAIL_NOT_RESENDABLE"},
+	{Err: ErrInvalidStatus, Status: http.StatusBadRequest, Code: "INVALID_EMAIL_STATUS", Message: "status must be one of pending, sending, sent or failed"},
+}
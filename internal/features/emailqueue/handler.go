@@ -0,0 +1,132 @@
+package emailqueue
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler exposes the email queue to platform admins.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs an email queue handler.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// List returns queued messages filtered by status, recipient and kind.
+// GET /email-queue
+func (h *Handler) List(c *gin.Context) {
+	filters := ListFilters{
+		Status: Status(strings.TrimSpace(c.Query("status"))),
+		To:     strings.TrimSpace(c.Query("to")),
+		Kind:   strings.TrimSpace(c.Query("kind")),
+	}
+	if filters.Status != "" && !ValidStatus(filters.Status) {
+		h.respondError(c, ErrInvalidStatus, "failed to list email messages")
+		return
+	}
+
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
+
+	messages, total, err := List(h.db, filters, params)
+	if err != nil {
+		h.respondError(c, err, "failed to list email messages")
+		return
+	}
+
+	messages, meta := pagination.Page(messages, total, params)
+	response.Success(c, http.StatusOK, messages, "", meta)
+}
+
+// Stats returns the number of messages per status.
+// GET /email-queue/stats
+func (h *Handler) Stats(c *gin.Context) {
+	counts, err := StatusCounts(h.db)
+	if err != nil {
+		h.respondError(c, err, "failed to load email queue stats")
+		return
+	}
+	response.Success(c, http.StatusOK, counts, "", nil)
+}
+
+// Get returns a message including its rendered body.
+// GET /email-queue/:messageId
+func (h *Handler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("messageId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid message id", err)
+		return
+	}
+
+	message, err := Get(h.db, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load email message")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"message": message,
+		"html":    message.HTML,
+		"text":    message.Text,
+	}, "", nil)
+}
+
+// Resend requeues a failed message.
+// POST /email-queue/:messageId/resend
+func (h *Handler) Resend(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("messageId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid message id", err)
+		return
+	}
+
+	message, err := Get(h.db, id)
+	if err != nil {
+		h.respondError(c, err, "failed to resend email message")
+		return
+	}
+	if message.Status != StatusFailed {
+		h.respondError(c, ErrNotResendable, "failed to resend email message")
+		return
+	}
+
+	if _, err := Requeue(h.db, []uuid.UUID{id}, time.Now().UTC()); err != nil {
+		h.respondError(c, err, "failed to resend email message")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"id": id}, "Email queued for delivery", nil)
+}
+
+// ResendFailed requeues every failed message.
+// POST /email-queue/resend-failed
+func (h *Handler) ResendFailed(c *gin.Context) {
+	count, err := Requeue(h.db, nil, time.Now().UTC())
+	if err != nil {
+		h.respondError(c, err, "failed to resend email messages")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"requeued": count}, "Failed emails queued for delivery", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
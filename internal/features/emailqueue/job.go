@@ -0,0 +1,102 @@
+package emailqueue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/email"
+	"github.com/mo-amir99/lms-server-go/pkg/metrics"
+)
+
+const (
+	batchSize = 50
+	// staleSending is how long a claimed message may stay in sending before another
+	// worker assumes the claimer died and retries it.
+	staleSending = 10 * time.Minute
+)
+
+// Job delivers queued email. It is meant to run every few seconds on the job scheduler.
+type Job struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	client *email.Client
+}
+
+// NewJob constructs the email delivery job.
+func NewJob(db *gorm.DB, logger *slog.Logger, client *email.Client) *Job {
+	return &Job{db: db, logger: logger, client: client}
+}
+
+// Name returns the job name.
+func (j *Job) Name() string {
+	return "email-delivery"
+}
+
+// Execute sends every due message, one batch at a time, then publishes the
+// queue depth.
+func (j *Job) Execute(ctx context.Context) error {
+	defer j.publishDepth(ctx)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		messages, err := ClaimDue(j.db.WithContext(ctx), time.Now().UTC(), staleSending, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			j.deliver(ctx, message)
+		}
+
+		if len(messages) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (j *Job) publishDepth(ctx context.Context) {
+	depth, err := QueueDepth(j.db.WithContext(ctx))
+	if err != nil {
+		j.logger.Warn("failed to count queued email", slog.String("error", err.Error()))
+		return
+	}
+
+	for status, count := range depth {
+		metrics.SetEmailQueueDepth(string(status), count)
+	}
+}
+
+func (j *Job) deliver(ctx context.Context, message Message) {
+	sendErr := j.client.SendEmail(email.EmailOptions{
+		To:       message.To,
+		Subject:  message.Subject,
+		HTML:     message.HTML,
+		Text:     message.Text,
+		Language: message.Language,
+	})
+	now := time.Now().UTC()
+
+	// Persist the outcome even if the job is being cancelled
+	db := j.db.WithContext(context.WithoutCancel(ctx))
+	if sendErr == nil {
+		if err := MarkSent(db, message.ID, now); err != nil {
+			j.logger.Error("failed to mark email as sent", slog.String("messageId", message.ID.String()), slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	j.logger.Warn("email delivery failed",
+		slog.String("messageId", message.ID.String()),
+		slog.String("kind", message.Kind),
+		slog.Int("attempt", message.Attempts+1),
+		slog.String("error", sendErr.Error()))
+	if err := MarkAttemptFailed(db, message, sendErr, now); err != nil {
+		j.logger.Error("failed to record email failure", slog.String("messageId", message.ID.String()), slog.String("error", err.Error()))
+	}
+}
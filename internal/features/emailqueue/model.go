@@ -0,0 +1,216 @@
+package emailqueue
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Status tracks a message through the outbound queue.
+type Status string
+
+const (
+	// StatusPending means the message waits for its next delivery attempt.
+	StatusPending Status = "pending"
+	// StatusSending means a worker claimed the message and is talking to SMTP.
+	StatusSending Status = "sending"
+	// StatusSent means SMTP accepted the message.
+	StatusSent Status = "sent"
+	// StatusFailed means every attempt failed; an admin may resend it.
+	StatusFailed Status = "failed"
+)
+
+// ValidStatus reports whether s is a known status.
+func ValidStatus(s Status) bool {
+	switch s {
+	case StatusPending, StatusSending, StatusSent, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// Message is one outbound email persisted until SMTP accepts it.
+type Message struct {
+	types.BaseModel
+
+	Kind          string     `gorm:"type:varchar(50);not null" json:"kind"`
+	To            string     `gorm:"type:varchar(255);not null;column:to_address;index" json:"to"`
+	Subject       string     `gorm:"type:varchar(255);not null" json:"subject"`
+	HTML          string     `gorm:"type:text;not null;column:html_body" json:"-"`
+	Text          string     `gorm:"type:text;column:text_body" json:"-"`
+	Language      string     `gorm:"type:varchar(10);not null;default:'en'" json:"language"`
+	Status        Status     `gorm:"type:varchar(20);not null;default:'pending';index:idx_email_messages_status_next,priority:1" json:"status"`
+	Attempts      int        `gorm:"type:int;not null;default:0" json:"attempts"`
+	MaxAttempts   int        `gorm:"type:int;not null;default:8;column:max_attempts" json:"maxAttempts"`
+	NextAttemptAt time.Time  `gorm:"type:timestamp;not null;column:next_attempt_at;index:idx_email_messages_status_next,priority:2" json:"nextAttemptAt"`
+	LastError     string     `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	SentAt        *time.Time `gorm:"type:timestamp;column:sent_at" json:"sentAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Message) TableName() string { return "email_messages" }
+
+// ClaimDue moves up to limit due messages to sending and returns them. Messages stuck in
+// sending longer than staleAfter (a worker died mid-send) are reclaimed. SKIP LOCKED
+// lets several instances drain the queue without sending a message twice.
+func ClaimDue(db *gorm.DB, now time.Time, staleAfter time.Duration, limit int) ([]Message, error) {
+	var messages []Message
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at <= ?)",
+				StatusPending, now, StatusSending, now.Add(-staleAfter)).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+			messages[i].Status = StatusSending
+		}
+		return tx.Model(&Message{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": StatusSending, "updated_at": now}).Error
+	})
+	return messages, err
+}
+
+// MarkSent records a successful delivery.
+func MarkSent(db *gorm.DB, id uuid.UUID, now time.Time) error {
+	return db.Model(&Message{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     StatusSent,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"sent_at":    now,
+		"last_error": "",
+	}).Error
+}
+
+// MarkAttemptFailed records a failed attempt and either schedules the retry or gives up.
+func MarkAttemptFailed(db *gorm.DB, message Message, sendErr error, now time.Time) error {
+	attempts := message.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": truncate(sendErr.Error(), 1000),
+	}
+	if attempts >= message.MaxAttempts {
+		updates["status"] = StatusFailed
+	} else {
+		updates["status"] = StatusPending
+		updates["next_attempt_at"] = now.Add(Backoff(attempts))
+	}
+	return db.Model(&Message{}).Where("id = ?", message.ID).Updates(updates).Error
+}
+
+// Get retrieves a message by ID.
+func Get(db *gorm.DB, id uuid.UUID) (Message, error) {
+	var message Message
+	if err := db.Where("id = ?", id).Limit(1).Find(&message).Error; err != nil {
+		return Message{}, err
+	}
+	if message.ID == uuid.Nil {
+		return Message{}, ErrMessageNotFound
+	}
+	return message, nil
+}
+
+// ListFilters narrows the admin message listing.
+type ListFilters struct {
+	Status Status
+	To     string
+	Kind   string
+}
+
+// List returns queued messages, newest first.
+func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Message, int64, error) {
+	query := db.Model(&Message{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.To != "" {
+		query = query.Where("to_address ILIKE ?", "%"+filters.To+"%")
+	}
+	if filters.Kind != "" {
+		query = query.Where("kind = ?", filters.Kind)
+	}
+
+	var total int64
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	messages := make([]Message, 0)
+	if err := pagination.Apply(query, params).Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+// StatusCounts returns the number of messages per status.
+func StatusCounts(db *gorm.DB) (map[Status]int64, error) {
+	var rows []struct {
+		Status Status
+		Count  int64
+	}
+	if err := db.Model(&Message{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := map[Status]int64{StatusPending: 0, StatusSending: 0, StatusSent: 0, StatusFailed: 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// QueueDepth counts the messages still in the queue (pending, sending or failed).
+func QueueDepth(db *gorm.DB) (map[Status]int64, error) {
+	var rows []struct {
+		Status Status
+		Count  int64
+	}
+	if err := db.Model(&Message{}).Select("status, COUNT(*) AS count").
+		Where("status IN ?", []Status{StatusPending, StatusSending, StatusFailed}).
+		Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	depth := map[Status]int64{StatusPending: 0, StatusSending: 0, StatusFailed: 0}
+	for _, row := range rows {
+		depth[row.Status] = row.Count
+	}
+	return depth, nil
+}
+
+// Requeue puts failed messages back in the queue with a fresh attempt budget. With no
+// ids every failed message is requeued. It returns the number of requeued messages.
+func Requeue(db *gorm.DB, ids []uuid.UUID, now time.Time) (int64, error) {
+	query := db.Model(&Message{}).Where("status = ?", StatusFailed)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":          StatusPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	})
+	return result.RowsAffected, result.Error
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
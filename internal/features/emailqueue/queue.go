@@ -0,0 +1,84 @@
+package emailqueue
+
+import (
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/email"
+)
+
+// Message kinds, used for filtering in the admin console.
+const (
+	KindWelcome           = "welcome"
+	KindPasswordReset     = "password_reset"
+	KindEmailVerification = "email_verification"
+	KindNotification      = "notification"
+	KindDunning           = "subscription_dunning"
+)
+
+const (
+	defaultMaxAttempts = 8
+	baseBackoff        = time.Minute
+	maxBackoff         = 6 * time.Hour
+)
+
+// Backoff returns the delay before the retry following the given number of attempts:
+// 1m, 2m, 4m, ... capped at six hours.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return baseBackoff
+	}
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Queue persists outbound email for the delivery job instead of calling SMTP inline.
+type Queue struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewQueue constructs an email queue.
+func NewQueue(db *gorm.DB, logger *slog.Logger) *Queue {
+	return &Queue{db: db, logger: logger}
+}
+
+// Enqueue stores the message for delivery as soon as possible.
+func (q *Queue) Enqueue(kind string, opts email.EmailOptions) error {
+	return q.EnqueueTx(q.db, kind, opts)
+}
+
+// EnqueueTx stores the message using tx so it is only sent if the surrounding
+// transaction commits.
+func (q *Queue) EnqueueTx(tx *gorm.DB, kind string, opts email.EmailOptions) error {
+	return tx.Create(&Message{
+		Kind:          kind,
+		To:            opts.To,
+		Subject:       truncate(opts.Subject, 255),
+		HTML:          opts.HTML,
+		Text:          opts.Text,
+		Language:      opts.Language,
+		Status:        StatusPending,
+		MaxAttempts:   defaultMaxAttempts,
+		NextAttemptAt: time.Now().UTC(),
+	}).Error
+}
+
+// EnqueueOrLog enqueues the message and logs instead of failing; for callers where the
+// email is a side effect of a request that has already succeeded.
+func (q *Queue) EnqueueOrLog(kind string, opts email.EmailOptions) {
+	if err := q.Enqueue(kind, opts); err != nil {
+		q.logger.Error("failed to enqueue email",
+			slog.String("kind", kind),
+			slog.String("to", opts.To),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,19 @@
+package emailqueue
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the admin email queue endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly []gin.HandlerFunc) {
+	queue := router.Group("/email-queue")
+	queue.GET("", append(adminOnly, handler.List)...)
+	queue.GET("/stats", append(adminOnly, handler.Stats)...)
+	queue.POST("/resend-failed", append(adminOnly, handler.ResendFailed)...)
+	queue.GET("/:messageId", append(adminOnly, handler.Get)...)
+	queue.POST("/:messageId/resend", append(adminOnly, handler.Resend)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Message{}})
+}
@@ -0,0 +1,19 @@
+package eventstream
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrTooManyStreams = errors.New("too many open event streams")
+	ErrClosed         = errors.New("event streams are closed")
+)
+
+// errorCatalog maps the errors of the hub to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrTooManyStreams, Status: http.StatusTooManyRequests, Code: "TOO_MANY_STREAMS", Message: "Too many open event streams."},
+	{Err: ErrClosed, Status: http.StatusServiceUnavailable, Code: "SHUTTING_DOWN", Message: "Server is shutting down."},
+}
@@ -0,0 +1,102 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+const (
+	// heartbeatInterval keeps idle streams open through proxies that close
+	// quiet connections.
+	heartbeatInterval = 25 * time.Second
+	// retryDelay is how long clients wait before reconnecting.
+	retryDelay = 5 * time.Second
+)
+
+// Handler serves the server-sent events fallback for clients that cannot hold
+// a Socket.IO connection.
+type Handler struct {
+	hub    *Hub
+	logger *slog.Logger
+}
+
+// NewHandler constructs an event stream handler instance.
+func NewHandler(hub *Hub, logger *slog.Logger) *Handler {
+	return &Handler{hub: hub, logger: logger}
+}
+
+// Stream sends the user's live events, such as notifications and
+// announcements, as server-sent events until the client goes away or the
+// user's sessions are revoked. Events missed while disconnected are not
+// replayed; clients catch up through the REST endpoints on reconnect.
+// GET /events
+func (h *Handler) Stream(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	events, cancel, err := h.hub.open(usr.ID)
+	if err != nil {
+		errorCatalog.Respond(h.logger, c, err, "failed to open event stream")
+		return
+	}
+	defer cancel()
+
+	// The server's timeouts are sized for requests, not streams
+	controller := http.NewResponseController(c.Writer)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warn("failed to clear event stream read deadline", slog.String("error", err.Error()))
+	}
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("failed to clear event stream write deadline", slog.String("error", err.Error()))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", retryDelay.Milliseconds()); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(event.Payload)
+			if err != nil {
+				h.logger.Error("failed to encode live event", slog.String("event", event.Name), slog.String("error", err.Error()))
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Name, data); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,134 @@
+package eventstream
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxStreams caps the open streams of one user.
+	maxStreams = 5
+	// bufferSize is how many events a stream may fall behind before it is
+	// dropped; its client reconnects and catches up over the REST API.
+	bufferSize = 32
+)
+
+// message is one event written to a stream.
+type message struct {
+	Name    string
+	Payload any
+}
+
+// Hub tracks the open streams of each user. It is an eventbus.Transport, so
+// it receives live events like the Socket.IO server does.
+type Hub struct {
+	mu      sync.RWMutex
+	streams map[uuid.UUID]map[chan message]struct{}
+	closed  bool
+}
+
+// NewHub creates an empty hub.
+func NewHub() *Hub {
+	return &Hub{streams: make(map[uuid.UUID]map[chan message]struct{})}
+}
+
+// open starts a stream of the user's events. The channel is closed when the
+// stream is dropped for falling behind, the user is disconnected or the hub
+// closes; cancel releases it early.
+func (h *Hub) open(userID uuid.UUID) (<-chan message, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrClosed
+	}
+	if len(h.streams[userID]) >= maxStreams {
+		return nil, nil, ErrTooManyStreams
+	}
+
+	stream := make(chan message, bufferSize)
+	if h.streams[userID] == nil {
+		h.streams[userID] = make(map[chan message]struct{})
+	}
+	h.streams[userID][stream] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(userID, stream)
+	}
+	return stream, cancel, nil
+}
+
+// EmitToUser sends an event to every stream of the user.
+func (h *Hub) EmitToUser(userID uuid.UUID, event string, payload any) {
+	var behind []chan message
+
+	h.mu.RLock()
+	for stream := range h.streams[userID] {
+		select {
+		case stream <- message{Name: event, Payload: payload}:
+		default:
+			behind = append(behind, stream)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(behind) > 0 {
+		h.mu.Lock()
+		for _, stream := range behind {
+			h.remove(userID, stream)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// DisconnectUser closes the user's streams, as when their sessions are revoked.
+func (h *Hub) DisconnectUser(userID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for stream := range h.streams[userID] {
+		h.remove(userID, stream)
+	}
+}
+
+// Count returns the number of open streams.
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := 0
+	for _, streams := range h.streams {
+		count += len(streams)
+	}
+	return count
+}
+
+// Close ends every stream and refuses new ones. The server's graceful
+// shutdown waits for open requests, so streams must end first.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for userID, streams := range h.streams {
+		for stream := range streams {
+			h.remove(userID, stream)
+		}
+	}
+}
+
+// remove closes a stream once; callers hold the write lock.
+func (h *Hub) remove(userID uuid.UUID, stream chan message) {
+	streams := h.streams[userID]
+	if _, ok := streams[stream]; !ok {
+		return
+	}
+	delete(streams, stream)
+	close(stream)
+	if len(streams) == 0 {
+		delete(h.streams, userID)
+	}
+}
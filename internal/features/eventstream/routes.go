@@ -0,0 +1,9 @@
+package eventstream
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes attaches the event stream endpoint to the router.
+// Middleware is passed as parameters to avoid import cycles
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, allUsers []gin.HandlerFunc) {
+	router.GET("/events", append(allUsers, handler.Stream)...)
+}
@@ -0,0 +1,20 @@
+package featureflag
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrOverrideNotFound = errors.New("feature flag override not found")
+	ErrInvalidTarget    = errors.New("exactly one of subscriptionId and packageId is required")
+	ErrTargetNotFound   = errors.New("subscription or package not found")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrOverrideNotFound, Status: http.StatusNotFound, Code: "FLAG_OVERRIDE_NOT_FOUND", Message: "Feature flag override not found."},
+	{Err: ErrTargetNotFound, Status: http.StatusNotFound, Code: "FLAG_TARGET_NOT_FOUND", Message: "Subscription or package not found."},
+}
@@ -0,0 +1,139 @@
+package featureflag
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Handler serves feature flag endpoints.
+type Handler struct {
+	logger  *slog.Logger
+	service *Service
+}
+
+// NewHandler constructs a feature flag handler.
+func NewHandler(logger *slog.Logger, service *Service) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+// GetMine returns the flags resolved for the current user's subscription.
+// GET /me/features
+func (h *Handler) GetMine(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	flags := Defaults()
+	if usr.SubscriptionID != nil {
+		flags = h.service.Flags(*usr.SubscriptionID)
+	}
+	response.Success(c, http.StatusOK, flags, "", nil)
+}
+
+// List returns stored overrides, optionally of one subscription or package.
+// GET /feature-flags?subscriptionId=&packageId=
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, ok := optionalID(c, "subscriptionId")
+	if !ok {
+		return
+	}
+	packageID, ok := optionalID(c, "packageId")
+	if !ok {
+		return
+	}
+
+	overrides, err := ListOverrides(h.service.db.WithContext(c.Request.Context()), subscriptionID, packageID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list feature flags", err)
+		return
+	}
+	response.Success(c, http.StatusOK, overrides, "", nil)
+}
+
+type saveRequest struct {
+	Flag           string `json:"flag" binding:"required,oneof=liveStreaming meetings forums iap"`
+	SubscriptionID string `json:"subscriptionId" binding:"omitempty,id"`
+	PackageID      string `json:"packageId" binding:"omitempty,id"`
+	Enabled        *bool  `json:"enabled" binding:"required"`
+}
+
+// Save enables or disables a flag for a subscription or package.
+// PUT /feature-flags
+func (h *Handler) Save(c *gin.Context) {
+	var req saveRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid feature flag payload") {
+		return
+	}
+
+	override := Override{Flag: req.Flag, Enabled: *req.Enabled}
+	for _, target := range []struct {
+		field string
+		raw   string
+		dest  **uuid.UUID
+	}{{"subscriptionId", req.SubscriptionID, &override.SubscriptionID}, {"packageId", req.PackageID, &override.PackageID}} {
+		if target.raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(target.raw)
+		if err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: target.field, Rule: "id", Message: "must be a valid id"})
+			return
+		}
+		*target.dest = &id
+	}
+
+	saved, err := h.service.Save(c.Request.Context(), override)
+	if err != nil {
+		h.respondError(c, err, "failed to save feature flag")
+		return
+	}
+	response.Success(c, http.StatusOK, saved, "", nil)
+}
+
+// Delete removes an override, restoring the inherited value.
+// DELETE /feature-flags/:flagId
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("flagId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid feature flag id", err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.respondError(c, err, "failed to delete feature flag")
+		return
+	}
+	response.Success(c, http.StatusOK, nil, "Feature flag override removed.", nil)
+}
+
+func optionalID(c *gin.Context, field string) (*uuid.UUID, bool) {
+	raw := c.Query(field)
+	if raw == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		request.RespondInvalid(c, validation.FieldError{Field: field, Rule: "id", Message: "must be a valid id"})
+		return nil, false
+	}
+	return &id, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, ErrInvalidTarget) {
+		request.RespondInvalid(c, validation.FieldError{Field: "subscriptionId", Rule: "required_without", Message: err.Error()})
+		return
+	}
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,143 @@
+package featureflag
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Capabilities that can be switched off per subscription or plan. Every flag is
+// enabled unless an override disables it.
+const (
+	LiveStreaming = "liveStreaming"
+	Meetings      = "meetings"
+	Forums        = "forums"
+	IAP           = "iap"
+	// OfflineDownloads lets students keep lesson videos on their device
+	OfflineDownloads = "offlineDownloads"
+)
+
+// Flags lists every known flag.
+var Flags = []string{LiveStreaming, Meetings, Forums, IAP, OfflineDownloads}
+
+// Override enables or disables a flag for one subscription or for every
+// subscription on a package. Subscription overrides win over package ones.
+type Override struct {
+	types.BaseModel
+
+	Flag           string     `gorm:"type:varchar(50);not null;column:flag" json:"flag"`
+	SubscriptionID *uuid.UUID `gorm:"type:uuid;column:subscription_id" json:"subscriptionId,omitempty"`
+	PackageID      *uuid.UUID `gorm:"type:uuid;column:package_id" json:"packageId,omitempty"`
+	Enabled        bool       `gorm:"not null;column:enabled" json:"enabled"`
+}
+
+// TableName overrides the default table name.
+func (Override) TableName() string { return "feature_flags" }
+
+// ListOverrides returns the overrides of a subscription and/or package; nil
+// filters match every override.
+func ListOverrides(db *gorm.DB, subscriptionID, packageID *uuid.UUID) ([]Override, error) {
+	query := db.Model(&Override{})
+	if subscriptionID != nil {
+		query = query.Where("subscription_id = ?", *subscriptionID)
+	}
+	if packageID != nil {
+		query = query.Where("package_id = ?", *packageID)
+	}
+
+	var overrides []Override
+	if err := query.Order("flag").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// SaveOverride creates the override of its flag and target or updates the
+// existing one.
+func SaveOverride(db *gorm.DB, override Override) (Override, error) {
+	if (override.SubscriptionID == nil) == (override.PackageID == nil) {
+		return Override{}, ErrInvalidTarget
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		table, targetID, column := "subscriptions", override.SubscriptionID, "subscription_id"
+		if override.PackageID != nil {
+			table, targetID, column = "subscription_packages", override.PackageID, "package_id"
+		}
+
+		var count int64
+		if err := tx.Table(table).Where("id = ?", *targetID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrTargetNotFound
+		}
+
+		var existing Override
+		query := tx.Where("flag = ? AND "+column+" = ?", override.Flag, *targetID)
+		if err := query.Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		if existing.ID == uuid.Nil {
+			return tx.Create(&override).Error
+		}
+		existing.Enabled = override.Enabled
+		if err := tx.Model(&existing).Update("enabled", override.Enabled).Error; err != nil {
+			return err
+		}
+		override = existing
+		return nil
+	})
+	if err != nil {
+		return Override{}, err
+	}
+	return override, nil
+}
+
+// DeleteOverride removes an override, restoring the flag's inherited value.
+func DeleteOverride(db *gorm.DB, id uuid.UUID) error {
+	result := db.Delete(&Override{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOverrideNotFound
+	}
+	return nil
+}
+
+// loadFlags resolves every flag for a subscription: its own overrides, then
+// those of its package, then enabled.
+func loadFlags(db *gorm.DB, subscriptionID uuid.UUID) (map[string]bool, error) {
+	var packageID *uuid.UUID
+	if err := db.Table("subscriptions").Select("package_id").Where("id = ?", subscriptionID).Scan(&packageID).Error; err != nil {
+		return nil, err
+	}
+
+	query := db.Where("subscription_id = ?", subscriptionID)
+	if packageID != nil {
+		query = query.Or("package_id = ?", *packageID)
+	}
+	var overrides []Override
+	if err := query.Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]bool, len(Flags))
+	for _, flag := range Flags {
+		flags[flag] = true
+	}
+	for _, override := range overrides {
+		if override.PackageID != nil {
+			flags[override.Flag] = override.Enabled
+		}
+	}
+	for _, override := range overrides {
+		if override.SubscriptionID != nil {
+			flags[override.Flag] = override.Enabled
+		}
+	}
+	return flags, nil
+}
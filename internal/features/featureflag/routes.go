@@ -0,0 +1,21 @@
+package featureflag
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches feature flag endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, allUsers, superadminOnly []gin.HandlerFunc) {
+	router.GET("/me/features", append(allUsers, handler.GetMine)...)
+
+	flags := router.Group("/feature-flags")
+	flags.GET("", append(superadminOnly, handler.List)...)
+	flags.PUT("", append(superadminOnly, handler.Save)...)
+	flags.DELETE("/:flagId", append(superadminOnly, handler.Delete)...)
+
+	openapi.Describe(handler.GetMine, openapi.Spec{Response: map[string]bool{}})
+	openapi.Describe(handler.List, openapi.Spec{Response: []Override{}})
+	openapi.Describe(handler.Save, openapi.Spec{Request: saveRequest{}, Response: Override{}})
+}
@@ -0,0 +1,135 @@
+package featureflag
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// flagsTTL bounds how long a subscription's flags are served from memory;
+// saves through the service invalidate immediately.
+const flagsTTL = time.Minute
+
+type cachedFlags struct {
+	flags    map[string]bool
+	loadedAt time.Time
+}
+
+// Service resolves feature flags per subscription without a query per request.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedFlags
+}
+
+// NewService constructs a feature flag service.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	return &Service{db: db, logger: logger, cache: make(map[uuid.UUID]cachedFlags)}
+}
+
+// Defaults returns every flag enabled, the flags of users without a subscription.
+func Defaults() map[string]bool {
+	flags := make(map[string]bool, len(Flags))
+	for _, flag := range Flags {
+		flags[flag] = true
+	}
+	return flags
+}
+
+// Flags returns every flag resolved for the subscription. When they cannot be
+// loaded the last known flags, or the defaults, are returned.
+func (s *Service) Flags(subscriptionID uuid.UUID) map[string]bool {
+	s.mu.Lock()
+	cached, ok := s.cache[subscriptionID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < flagsTTL {
+		return maps.Clone(cached.flags)
+	}
+
+	flags, err := loadFlags(s.db, subscriptionID)
+	if err != nil {
+		s.logger.Warn("failed to load feature flags", "subscriptionId", subscriptionID, "error", err)
+		if cached.flags != nil {
+			return maps.Clone(cached.flags)
+		}
+		return Defaults()
+	}
+
+	s.mu.Lock()
+	s.cache[subscriptionID] = cachedFlags{flags: flags, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return maps.Clone(flags)
+}
+
+// Enabled reports whether flag is enabled for the subscription.
+func (s *Service) Enabled(subscriptionID uuid.UUID, flag string) bool {
+	return s.Flags(subscriptionID)[flag]
+}
+
+// Save stores an override and drops every cached subscription, since a package
+// override applies to all of its subscriptions.
+func (s *Service) Save(ctx context.Context, override Override) (Override, error) {
+	saved, err := SaveOverride(s.db.WithContext(ctx), override)
+	if err != nil {
+		return Override{}, err
+	}
+	s.invalidate()
+	return saved, nil
+}
+
+// Delete removes an override and drops every cached subscription.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := DeleteOverride(s.db.WithContext(ctx), id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	clear(s.cache)
+	s.mu.Unlock()
+}
+
+// Require aborts the request when flag is disabled for the subscription in the
+// route, or else the authenticated user's subscription. Requests without a
+// subscription, such as those of platform admins, are not restricted. It must
+// run after the auth middleware has loaded the user.
+func (s *Service) Require(flag string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var subscriptionID *uuid.UUID
+		if id, err := uuid.Parse(c.Param("subscriptionId")); err == nil {
+			subscriptionID = &id
+		} else if usr, ok := middleware.GetUserFromContext(c); ok {
+			subscriptionID = usr.SubscriptionID
+		}
+
+		if subscriptionID != nil && !s.Enabled(*subscriptionID, flag) {
+			response.Error(c, http.StatusForbidden, "This feature is not enabled for your subscription.", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// With returns a copy of the middleware chain extended with Require(flag).
+func (s *Service) With(chain []gin.HandlerFunc, flag string) []gin.HandlerFunc {
+	handlers := make([]gin.HandlerFunc, 0, len(chain)+1)
+	handlers = append(handlers, chain...)
+	return append(handlers, s.Require(flag))
+}
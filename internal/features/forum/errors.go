@@ -1,6 +1,11 @@
 package forum
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
 	ErrForumNotFound  = errors.New("forum not found")
@@ -9,3 +14,11 @@ var (
 	ErrForbidden      = errors.New("access to this forum is forbidden")
 	ErrAssistantsOnly = errors.New("only assistants can post in this forum")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrForumNotFound, Status: http.StatusNotFound, Code: "FORUM_NOT_FOUND", Message: "Forum not found."},
+	{Err: ErrTitleRequired, Status: http.StatusBadRequest, Code: "FORUM_TITLE_REQUIRED", Message: "Title is required"},
+	{Err: ErrTitleExists, Status: http.StatusBadRequest, Code: "FORUM_TITLE_TAKEN", Message: "A forum with this title already exists"},
+	{Err: ErrForbidden, Status: http.StatusForbidden, Message: "Access to this forum is forbidden."},
+}
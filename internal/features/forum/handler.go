@@ -1,7 +1,6 @@
 package forum
 
 import (
-	"errors"
 	"net/http"
 
 	"log/slog"
@@ -10,12 +9,12 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
-	"github.com/mo-amir99/lms-server-go/pkg/cleanup"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Handler processes forum HTTP requests.
@@ -78,6 +77,15 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, forum, "", nil)
 }
 
+type createRequest struct {
+	Title            string  `json:"title" binding:"required"`
+	Description      *string `json:"description"`
+	AssistantsOnly   *bool   `json:"assistantsOnly"`
+	RequiresApproval *bool   `json:"requiresApproval"`
+	Active           *bool   `json:"isActive"`
+	Order            *int    `json:"order"`
+}
+
 // Create inserts a new forum.
 func (h *Handler) Create(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -86,17 +94,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Title            string  `json:"title" binding:"required"`
-		Description      *string `json:"description"`
-		AssistantsOnly   *bool   `json:"assistantsOnly"`
-		RequiresApproval *bool   `json:"requiresApproval"`
-		Active           *bool   `json:"isActive"`
-		Order            *int    `json:"order"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid forum payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid forum payload") {
 		return
 	}
 
@@ -118,6 +118,15 @@ func (h *Handler) Create(c *gin.Context) {
 	response.Created(c, forum, "")
 }
 
+type updateRequest struct {
+	Title            *string                     `json:"title" binding:"omitnil,notblank"`
+	Description      validation.Optional[string] `json:"description" binding:"omitnil,notblank"`
+	AssistantsOnly   *bool                       `json:"assistantsOnly"`
+	RequiresApproval *bool                       `json:"requiresApproval"`
+	Active           *bool                       `json:"isActive"`
+	Order            validation.Optional[int]    `json:"order"`
+}
+
 // Update modifies an existing forum.
 func (h *Handler) Update(c *gin.Context) {
 	forumID, err := uuid.Parse(c.Param("forumId"))
@@ -126,72 +135,20 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid forum payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid forum payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["title"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "title must be a string", err)
-			return
-		}
-		input.Title = &str
-	}
-
-	if value, ok := body["description"]; ok {
-		input.DescriptionProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "description must be a string", err)
-				return
-			}
-			input.Description = &str
-		}
-	}
-
-	if value, ok := body["assistantsOnly"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "assistantsOnly must be boolean", err)
-			return
-		}
-		input.AssistantsOnly = &val
-	}
-
-	if value, ok := body["requiresApproval"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "requiresApproval must be boolean", err)
-			return
-		}
-		input.RequiresApproval = &val
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
-	}
-
-	if value, ok := body["order"]; ok {
-		input.OrderProvided = true
-		if value != nil {
-			val, err := request.ReadInt(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "order must be an integer", err)
-				return
-			}
-			input.Order = &val
-		}
+	input := UpdateInput{
+		Title:               req.Title,
+		DescriptionProvided: req.Description.Set,
+		Description:         req.Description.Ptr(),
+		AssistantsOnly:      req.AssistantsOnly,
+		RequiresApproval:    req.RequiresApproval,
+		Active:              req.Active,
+		OrderProvided:       req.Order.Set,
+		Order:               req.Order.Ptr(),
 	}
 
 	forum, err := Update(h.db, forumID, input)
@@ -217,10 +174,7 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
-	// Delete all threads in this forum
-	cleanup.DeleteForumThreads(h.db, h.logger, forumID)
-
-	// Delete the forum
+	// Delete the forum; its threads go with it through their foreign key
 	if err := Delete(h.db, forumID); err != nil {
 		h.respondError(c, err, "failed to delete forum")
 		return
@@ -230,25 +184,7 @@ func (h *Handler) Delete(c *gin.Context) {
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrForumNotFound):
-		status = http.StatusNotFound
-		message = "Forum not found."
-	case errors.Is(err, ErrTitleRequired):
-		status = http.StatusBadRequest
-		message = "Title is required"
-	case errors.Is(err, ErrTitleExists):
-		status = http.StatusBadRequest
-		message = "A forum with this title already exists"
-	case errors.Is(err, ErrForbidden):
-		status = http.StatusForbidden
-		message = "Access to this forum is forbidden."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
 
 
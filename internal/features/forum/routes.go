@@ -2,6 +2,8 @@ package forum
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes sets up forum endpoints under /subscriptions/:subscriptionId/forums.
@@ -13,4 +15,9 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff []
 	forums.GET("/:forumId", append(acAll, handler.GetByID)...)
 	forums.PUT("/:forumId", append(acStaff, handler.Update)...)
 	forums.DELETE("/:forumId", append(acStaff, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Forum{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Forum{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: ForumWithThreads{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Forum{}})
 }
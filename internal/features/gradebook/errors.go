@@ -0,0 +1,33 @@
+package gradebook
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrCourseNotFound     = errors.New("course not found")
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	ErrQuizNotFound       = errors.New("quiz not found")
+	ErrStudentNotFound    = errors.New("student not found")
+	ErrWeightsInvalid     = errors.New("weights cannot be negative and must not all be zero")
+	ErrMaxPointsInvalid   = errors.New("max points must be greater than zero")
+	ErrPointsOutOfRange   = errors.New("points must be between zero and the assignment's max points")
+	ErrMaxScoreInvalid    = errors.New("max score must be greater than zero")
+	ErrScoreOutOfRange    = errors.New("score must be between zero and max score")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrAssignmentNotFound, Status: http.StatusNotFound, Code: "ASSIGNMENT_NOT_FOUND", Message: "Assignment not found."},
+	{Err: ErrQuizNotFound, Status: http.StatusNotFound, Code: "QUIZ_NOT_FOUND", Message: "Quiz not found."},
+	{Err: ErrStudentNotFound, Status: http.StatusNotFound, Code: "STUDENT_NOT_FOUND", Message: "Student not found."},
+	{Err: ErrWeightsInvalid, Status: http.StatusBadRequest, Code: "WEIGHTS_INVALID", Message: "Weights cannot be negative and at least one must be above zero."},
+	{Err: ErrMaxPointsInvalid, Status: http.StatusBadRequest, Code: "MAX_POINTS_INVALID", Message: "Max points must be greater than zero."},
+	{Err: ErrPointsOutOfRange, Status: http.StatusBadRequest, Code: "POINTS_OUT_OF_RANGE", Message: "Points must be between zero and the assignment's max points."},
+	{Err: ErrMaxScoreInvalid, Status: http.StatusBadRequest, Code: "MAX_SCORE_INVALID", Message: "Max score must be greater than zero."},
+	{Err: ErrScoreOutOfRange, Status: http.StatusBadRequest, Code: "SCORE_OUT_OF_RANGE", Message: "Score must be between zero and max score."},
+}
@@ -0,0 +1,311 @@
+package gradebook
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// courseData is everything a course's grades are computed from.
+type courseData struct {
+	weights     Weights
+	quizIDs     []uuid.UUID
+	bestQuiz    map[uuid.UUID]map[uuid.UUID]float64 // user -> quiz -> best score ratio
+	attempts    map[uuid.UUID]map[uuid.UUID]int     // user -> quiz -> attempts
+	assignments []Assignment
+	marks       map[uuid.UUID]map[uuid.UUID]Mark // user -> assignment -> mark
+	sessions    []heldSession
+	groups      map[uuid.UUID]map[uuid.UUID]bool // group -> members
+	attended    map[uuid.UUID]map[uuid.UUID]bool // user -> session
+}
+
+type heldSession struct {
+	ID            uuid.UUID
+	GroupAccessID *uuid.UUID
+}
+
+// Compute returns the gradebook rows of the course's students, or of one
+// student when userID is set.
+func Compute(db *gorm.DB, subscriptionID, courseID uuid.UUID, userID *uuid.UUID, now time.Time) ([]StudentGrade, Weights, error) {
+	students, err := roster(db, subscriptionID, courseID, userID)
+	if err != nil {
+		return nil, Weights{}, err
+	}
+
+	data, err := loadCourseData(db, courseID, userID)
+	if err != nil {
+		return nil, Weights{}, err
+	}
+
+	for i := range students {
+		data.grade(&students[i], now)
+	}
+	return students, data.weights, nil
+}
+
+// StudentReport returns one student's grade with their quiz and assignment results.
+func StudentReport(db *gorm.DB, subscriptionID, courseID, userID uuid.UUID, now time.Time) (Report, error) {
+	students, err := roster(db, subscriptionID, courseID, &userID)
+	if err != nil {
+		return Report{}, err
+	}
+	if len(students) == 0 {
+		return Report{}, ErrCourseNotFound
+	}
+
+	data, err := loadCourseData(db, courseID, &userID)
+	if err != nil {
+		return Report{}, err
+	}
+	data.grade(&students[0], now)
+
+	report := Report{
+		StudentGrade:    students[0],
+		Weights:         data.weights,
+		QuizResults:     make([]QuizResult, 0),
+		AssignmentMarks: make([]AssignmentResult, 0, len(data.assignments)),
+	}
+
+	if err := quizzes(db, courseID).
+		Select("attachments.id AS attachment_id, attachments.lesson_id, attachments.name").
+		Order("lessons.\"order\" ASC, attachments.\"order\" ASC").
+		Scan(&report.QuizResults).Error; err != nil {
+		return Report{}, err
+	}
+	for i := range report.QuizResults {
+		quizID := report.QuizResults[i].AttachmentID
+		report.QuizResults[i].Attempts = data.attempts[userID][quizID]
+		if best, ok := data.bestQuiz[userID][quizID]; ok {
+			report.QuizResults[i].BestPercent = percent(best)
+		}
+	}
+
+	for _, assignment := range data.assignments {
+		result := AssignmentResult{Assignment: assignment}
+		if mark, ok := data.marks[userID][assignment.ID]; ok {
+			points := mark.Points
+			result.Points = &points
+			result.Feedback = mark.Feedback
+		}
+		report.AssignmentMarks = append(report.AssignmentMarks, result)
+	}
+
+	return report, nil
+}
+
+func loadCourseData(db *gorm.DB, courseID uuid.UUID, userID *uuid.UUID) (*courseData, error) {
+	data := &courseData{
+		bestQuiz: make(map[uuid.UUID]map[uuid.UUID]float64),
+		attempts: make(map[uuid.UUID]map[uuid.UUID]int),
+		marks:    make(map[uuid.UUID]map[uuid.UUID]Mark),
+		groups:   make(map[uuid.UUID]map[uuid.UUID]bool),
+		attended: make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+
+	var err error
+	if data.weights, err = GetWeights(db, courseID); err != nil {
+		return nil, err
+	}
+
+	if err := quizzes(db, courseID).Pluck("attachments.id", &data.quizIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var attemptRows []struct {
+		UserID       uuid.UUID
+		AttachmentID uuid.UUID
+		Best         float64
+		Attempts     int
+	}
+	query := db.Table("quiz_attempts").
+		Select("user_id, attachment_id, MAX(score / max_score) AS best, COUNT(*) AS attempts").
+		Where("course_id = ?", courseID)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Group("user_id, attachment_id").Scan(&attemptRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range attemptRows {
+		if data.bestQuiz[row.UserID] == nil {
+			data.bestQuiz[row.UserID] = make(map[uuid.UUID]float64)
+			data.attempts[row.UserID] = make(map[uuid.UUID]int)
+		}
+		data.bestQuiz[row.UserID][row.AttachmentID] = row.Best
+		data.attempts[row.UserID][row.AttachmentID] = row.Attempts
+	}
+
+	if data.assignments, err = ListAssignments(db, courseID); err != nil {
+		return nil, err
+	}
+	var marks []Mark
+	query = db.Model(&Mark{}).
+		Where("assignment_id IN (SELECT id FROM gradebook_assignments WHERE course_id = ?)", courseID)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Find(&marks).Error; err != nil {
+		return nil, err
+	}
+	for _, mark := range marks {
+		if data.marks[mark.UserID] == nil {
+			data.marks[mark.UserID] = make(map[uuid.UUID]Mark)
+		}
+		data.marks[mark.UserID][mark.AssignmentID] = mark
+	}
+
+	if err := loadAttendance(db, courseID, userID, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// loadAttendance reads the course's scheduled sessions that went live and who
+// joined them. A session is matched to the meeting or stream run under its live
+// id that began within its window.
+func loadAttendance(db *gorm.DB, courseID uuid.UUID, userID *uuid.UUID, data *courseData) error {
+	if err := db.Table("scheduled_sessions").
+		Select("id, group_access_id").
+		Where("course_id = ? AND status IN ? AND live_id <> ''", courseID, []string{"live", "completed"}).
+		Scan(&data.sessions).Error; err != nil {
+		return err
+	}
+	if len(data.sessions) == 0 {
+		return nil
+	}
+
+	groupIDs := make([]uuid.UUID, 0)
+	for _, session := range data.sessions {
+		if session.GroupAccessID != nil {
+			groupIDs = append(groupIDs, *session.GroupAccessID)
+		}
+	}
+	if len(groupIDs) > 0 {
+		var groups []struct {
+			ID    uuid.UUID
+			Users pq.StringArray `gorm:"type:uuid[]"`
+		}
+		if err := db.Table("group_access").Select("id, users").Where("id IN ?", groupIDs).Scan(&groups).Error; err != nil {
+			return err
+		}
+		for _, group := range groups {
+			members := make(map[uuid.UUID]bool, len(group.Users))
+			for _, raw := range group.Users {
+				if id, err := uuid.Parse(raw); err == nil {
+					members[id] = true
+				}
+			}
+			data.groups[group.ID] = members
+		}
+	}
+
+	window := `runs.started_at >= s.starts_at - ? * INTERVAL '1 second'
+		AND runs.started_at < s.starts_at + s.duration_minutes * INTERVAL '1 minute'`
+	userFilter := ""
+	args := []interface{}{sessionMatchWindow.Seconds(), courseID, sessionMatchWindow.Seconds(), courseID}
+	if userID != nil {
+		userFilter = " AND attendee_id = ?"
+		args = append(args, *userID)
+	}
+
+	var rows []struct {
+		SessionID  uuid.UUID
+		AttendeeID uuid.UUID
+	}
+	if err := db.Raw(`
+		SELECT DISTINCT session_id, attendee_id FROM (
+			SELECT s.id AS session_id, mp.user_id AS attendee_id
+			FROM scheduled_sessions s
+			JOIN meetings runs ON runs.room_id = s.live_id AND runs.subscription_id = s.subscription_id AND `+window+`
+			JOIN meeting_participants mp ON mp.meeting_id = runs.id
+			WHERE s.type = 'meeting' AND s.course_id = ?
+			UNION ALL
+			SELECT s.id, v.viewer_id
+			FROM scheduled_sessions s
+			JOIN live_streams runs ON runs.stream_id = s.live_id AND `+window+`
+			JOIN live_stream_viewers v ON v.live_stream_id = runs.id
+			WHERE s.type = 'stream' AND s.course_id = ?
+		) AS attendance WHERE TRUE`+userFilter, args...).
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if data.attended[row.AttendeeID] == nil {
+			data.attended[row.AttendeeID] = make(map[uuid.UUID]bool)
+		}
+		data.attended[row.AttendeeID][row.SessionID] = true
+	}
+	return nil
+}
+
+// grade fills in the student's components and weighted grade. Quizzes not
+// attempted and assignments past due without a mark count as zero; sessions of
+// groups the student is not in are not counted.
+func (d *courseData) grade(student *StudentGrade, now time.Time) {
+	userID := student.UserID
+
+	student.Quizzes = Component{Weight: d.weights.QuizWeight, Items: len(d.quizIDs)}
+	if len(d.quizIDs) > 0 {
+		total := 0.0
+		for _, quizID := range d.quizIDs {
+			if best, ok := d.bestQuiz[userID][quizID]; ok {
+				total += best
+				student.Quizzes.Completed++
+			}
+		}
+		student.Quizzes.Percent = percent(total / float64(len(d.quizIDs)))
+	}
+
+	student.Assignments = Component{Weight: d.weights.AssignmentWeight, Items: len(d.assignments)}
+	earned, possible := 0.0, 0.0
+	for _, assignment := range d.assignments {
+		mark, marked := d.marks[userID][assignment.ID]
+		switch {
+		case marked:
+			earned += mark.Points
+			possible += assignment.MaxPoints
+			student.Assignments.Completed++
+		case assignment.DueAt != nil && assignment.DueAt.Before(now):
+			possible += assignment.MaxPoints
+		}
+	}
+	if possible > 0 {
+		student.Assignments.Percent = percent(earned / possible)
+	}
+
+	student.Attendance = Component{Weight: d.weights.AttendanceWeight}
+	for _, session := range d.sessions {
+		if session.GroupAccessID != nil && !d.groups[*session.GroupAccessID][userID] {
+			continue
+		}
+		student.Attendance.Items++
+		if d.attended[userID][session.ID] {
+			student.Attendance.Completed++
+		}
+	}
+	if student.Attendance.Items > 0 {
+		student.Attendance.Percent = percent(float64(student.Attendance.Completed) / float64(student.Attendance.Items))
+	}
+
+	weighted, weights := 0.0, 0.0
+	for _, component := range []Component{student.Quizzes, student.Assignments, student.Attendance} {
+		if component.Percent == nil || component.Weight <= 0 {
+			continue
+		}
+		weighted += *component.Percent * component.Weight
+		weights += component.Weight
+	}
+	if weights > 0 {
+		grade := math.Round(weighted/weights*100) / 100
+		student.Grade = &grade
+	}
+}
+
+// percent turns a 0-1 ratio into a percentage rounded to two decimals.
+func percent(ratio float64) *float64 {
+	value := math.Round(ratio*10000) / 100
+	return &value
+}
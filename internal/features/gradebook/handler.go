@@ -0,0 +1,418 @@
+package gradebook
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes gradebook HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a gradebook handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// CourseGradebook returns every student's grade in the course.
+func (h *Handler) CourseGradebook(c *gin.Context) {
+	subscriptionID, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	students, weights, err := Compute(h.db, subscriptionID, courseID, nil, time.Now().UTC())
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to compute gradebook", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, Gradebook{Weights: weights, Students: students}, "", nil)
+}
+
+// StudentGradebook returns one student's grade and results in the course.
+func (h *Handler) StudentGradebook(c *gin.Context) {
+	subscriptionID, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	h.respondReport(c, subscriptionID, courseID, userID, ErrStudentNotFound)
+}
+
+// MyGradebook returns the requesting student's grade and results in the course.
+func (h *Handler) MyGradebook(c *gin.Context) {
+	subscriptionID, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	h.respondReport(c, subscriptionID, courseID, usr.ID, ErrCourseNotFound)
+}
+
+func (h *Handler) respondReport(c *gin.Context, subscriptionID, courseID, userID uuid.UUID, notFound error) {
+	report, err := StudentReport(h.db, subscriptionID, courseID, userID, time.Now().UTC())
+	if errors.Is(err, ErrCourseNotFound) {
+		err = notFound
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to compute gradebook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, report, "", nil)
+}
+
+// GetWeights returns the course's grade weights.
+func (h *Handler) GetWeights(c *gin.Context) {
+	_, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	weights, err := GetWeights(h.db, courseID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load grade weights", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, weights, "", nil)
+}
+
+type weightsRequest struct {
+	QuizWeight       float64 `json:"quizWeight" binding:"gte=0,lte=100"`
+	AssignmentWeight float64 `json:"assignmentWeight" binding:"gte=0,lte=100"`
+	AttendanceWeight float64 `json:"attendanceWeight" binding:"gte=0,lte=100"`
+}
+
+// UpdateWeights sets the course's grade weights.
+func (h *Handler) UpdateWeights(c *gin.Context) {
+	_, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req weightsRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid weights payload") {
+		return
+	}
+
+	weights := Weights{
+		CourseID:         courseID,
+		QuizWeight:       req.QuizWeight,
+		AssignmentWeight: req.AssignmentWeight,
+		AttendanceWeight: req.AttendanceWeight,
+		UpdatedBy:        &usr.ID,
+	}
+	if err := SaveWeights(h.db, &weights); err != nil {
+		h.respondError(c, err, "failed to save grade weights")
+		return
+	}
+
+	response.Success(c, http.StatusOK, weights, "", nil)
+}
+
+// ListAssignments returns the course's assignments.
+func (h *Handler) ListAssignments(c *gin.Context) {
+	_, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	assignments, err := ListAssignments(h.db, courseID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load assignments", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, assignments, "", nil)
+}
+
+type assignmentRequest struct {
+	Title       string     `json:"title" binding:"required,notblank,max=150"`
+	Description *string    `json:"description" binding:"omitnil,max=2000"`
+	MaxPoints   float64    `json:"maxPoints" binding:"required,gt=0,lte=10000"`
+	DueAt       *time.Time `json:"dueAt"`
+}
+
+func (r assignmentRequest) input() AssignmentInput {
+	return AssignmentInput{
+		Title:       strings.TrimSpace(r.Title),
+		Description: request.Trimmed(r.Description),
+		MaxPoints:   r.MaxPoints,
+		DueAt:       r.DueAt,
+	}
+}
+
+// CreateAssignment adds an assignment to the course.
+func (h *Handler) CreateAssignment(c *gin.Context) {
+	_, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	var req assignmentRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid assignment payload") {
+		return
+	}
+
+	assignment, err := CreateAssignment(h.db, courseID, req.input())
+	if err != nil {
+		h.respondError(c, err, "failed to create assignment")
+		return
+	}
+
+	response.Created(c, assignment, "")
+}
+
+// UpdateAssignment replaces an assignment's details.
+func (h *Handler) UpdateAssignment(c *gin.Context) {
+	_, assignment, ok := h.resolveAssignment(c)
+	if !ok {
+		return
+	}
+
+	var req assignmentRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid assignment payload") {
+		return
+	}
+
+	if err := UpdateAssignment(h.db, &assignment, req.input()); err != nil {
+		h.respondError(c, err, "failed to update assignment")
+		return
+	}
+
+	response.Success(c, http.StatusOK, assignment, "", nil)
+}
+
+// DeleteAssignment removes an assignment and its marks.
+func (h *Handler) DeleteAssignment(c *gin.Context) {
+	_, assignment, ok := h.resolveAssignment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(&assignment).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to delete assignment", err)
+		return
+	}
+
+	response.NoContent(c, "Assignment deleted")
+}
+
+type markRequest struct {
+	Points   float64 `json:"points" binding:"gte=0"`
+	Feedback *string `json:"feedback" binding:"omitnil,max=2000"`
+}
+
+// SaveMark records a student's mark on an assignment.
+func (h *Handler) SaveMark(c *gin.Context) {
+	subscriptionID, assignment, ok := h.resolveAssignment(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req markRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid mark payload") {
+		return
+	}
+
+	if err := h.ensureEnrolled(subscriptionID, assignment.CourseID, userID, ErrStudentNotFound); err != nil {
+		h.respondError(c, err, "failed to load student")
+		return
+	}
+
+	mark := Mark{
+		UserID:   userID,
+		Points:   req.Points,
+		Feedback: request.Trimmed(req.Feedback),
+		GradedBy: &usr.ID,
+	}
+	if err := SaveMark(h.db, assignment, &mark); err != nil {
+		h.respondError(c, err, "failed to save mark")
+		return
+	}
+
+	response.Success(c, http.StatusOK, mark, "", nil)
+}
+
+// DeleteMark clears a student's mark on an assignment.
+func (h *Handler) DeleteMark(c *gin.Context) {
+	_, assignment, ok := h.resolveAssignment(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	if err := h.db.Where("assignment_id = ? AND user_id = ?", assignment.ID, userID).Delete(&Mark{}).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to delete mark", err)
+		return
+	}
+
+	response.NoContent(c, "Mark deleted")
+}
+
+type attemptRequest struct {
+	AttachmentID uuid.UUID `json:"attachmentId" binding:"required"`
+	Score        float64   `json:"score" binding:"gte=0"`
+	MaxScore     float64   `json:"maxScore" binding:"required,gt=0"`
+}
+
+// RecordAttempt stores the requesting student's score on a course quiz. MCQ
+// questions are graded by the app, which reports the score here.
+func (h *Handler) RecordAttempt(c *gin.Context) {
+	subscriptionID, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req attemptRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid quiz attempt payload") {
+		return
+	}
+
+	if err := h.ensureEnrolled(subscriptionID, courseID, usr.ID, ErrCourseNotFound); err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	if err := FindQuiz(h.db, courseID, req.AttachmentID); err != nil {
+		h.respondError(c, err, "failed to load quiz")
+		return
+	}
+
+	attempt := QuizAttempt{
+		CourseID:     courseID,
+		AttachmentID: req.AttachmentID,
+		UserID:       usr.ID,
+		Score:        req.Score,
+		MaxScore:     req.MaxScore,
+	}
+	if err := RecordAttempt(h.db, &attempt); err != nil {
+		h.respondError(c, err, "failed to record quiz attempt")
+		return
+	}
+
+	response.Created(c, attempt, "")
+}
+
+// resolveCourse validates route ids and ensures the course belongs to the subscription.
+func (h *Handler) resolveCourse(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	var count int64
+	if err := h.db.Table("courses").
+		Where("id = ? AND subscription_id = ?", courseID, subscriptionID).
+		Count(&count).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load course", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if count == 0 {
+		h.respondError(c, ErrCourseNotFound, "failed to load course")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return subscriptionID, courseID, true
+}
+
+// resolveAssignment loads the assignment named by the route within its course.
+func (h *Handler) resolveAssignment(c *gin.Context) (uuid.UUID, Assignment, bool) {
+	subscriptionID, courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return uuid.Nil, Assignment{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("assignmentId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid assignment id", err)
+		return uuid.Nil, Assignment{}, false
+	}
+
+	assignment, err := GetAssignment(h.db, courseID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load assignment")
+		return uuid.Nil, Assignment{}, false
+	}
+	return subscriptionID, assignment, true
+}
+
+// ensureEnrolled returns notFound unless the user is a student of the course.
+func (h *Handler) ensureEnrolled(subscriptionID, courseID, userID uuid.UUID, notFound error) error {
+	students, err := roster(h.db, subscriptionID, courseID, &userID)
+	if err != nil {
+		return err
+	}
+	if len(students) == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,295 @@
+package gradebook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Default component weights used until staff configure a course.
+const (
+	defaultQuizWeight       = 40
+	defaultAssignmentWeight = 40
+	defaultAttendanceWeight = 20
+)
+
+// sessionMatchWindow is how long before a scheduled session's start its meeting or
+// stream may begin and still count as that session.
+const sessionMatchWindow = time.Hour
+
+// Weights sets how much each component contributes to a course grade. They are
+// relative, so 2/2/1 grades the same as 40/40/20.
+type Weights struct {
+	types.TimestampModel
+
+	CourseID         uuid.UUID  `gorm:"type:uuid;primaryKey;column:course_id" json:"courseId"`
+	QuizWeight       float64    `gorm:"type:numeric(5,2);not null;default:40;column:quiz_weight" json:"quizWeight"`
+	AssignmentWeight float64    `gorm:"type:numeric(5,2);not null;default:40;column:assignment_weight" json:"assignmentWeight"`
+	AttendanceWeight float64    `gorm:"type:numeric(5,2);not null;default:20;column:attendance_weight" json:"attendanceWeight"`
+	UpdatedBy        *uuid.UUID `gorm:"type:uuid;column:updated_by" json:"updatedBy,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Weights) TableName() string { return "gradebook_weights" }
+
+// Assignment is graded work marked by staff outside the app.
+type Assignment struct {
+	types.BaseModel
+
+	CourseID    uuid.UUID  `gorm:"type:uuid;not null;column:course_id;index" json:"courseId"`
+	Title       string     `gorm:"type:varchar(150);not null" json:"title"`
+	Description *string    `gorm:"type:text" json:"description,omitempty"`
+	MaxPoints   float64    `gorm:"type:numeric(7,2);not null;column:max_points" json:"maxPoints"`
+	DueAt       *time.Time `gorm:"type:timestamptz;column:due_at" json:"dueAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Assignment) TableName() string { return "gradebook_assignments" }
+
+// Mark is a student's result on an assignment.
+type Mark struct {
+	types.BaseModel
+
+	AssignmentID uuid.UUID  `gorm:"type:uuid;not null;column:assignment_id;uniqueIndex:idx_assignment_marks_user,priority:1" json:"assignmentId"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;column:user_id;uniqueIndex:idx_assignment_marks_user,priority:2" json:"userId"`
+	Points       float64    `gorm:"type:numeric(7,2);not null" json:"points"`
+	Feedback     *string    `gorm:"type:text" json:"feedback,omitempty"`
+	GradedBy     *uuid.UUID `gorm:"type:uuid;column:graded_by" json:"gradedBy,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Mark) TableName() string { return "assignment_marks" }
+
+// QuizAttempt is one scored try at an MCQ attachment. The best attempt counts.
+type QuizAttempt struct {
+	types.BaseModel
+
+	CourseID     uuid.UUID `gorm:"type:uuid;not null;column:course_id;index" json:"courseId"`
+	AttachmentID uuid.UUID `gorm:"type:uuid;not null;column:attachment_id" json:"attachmentId"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;column:user_id" json:"userId"`
+	Score        float64   `gorm:"type:numeric(7,2);not null" json:"score"`
+	MaxScore     float64   `gorm:"type:numeric(7,2);not null;column:max_score" json:"maxScore"`
+}
+
+// TableName overrides the default table name.
+func (QuizAttempt) TableName() string { return "quiz_attempts" }
+
+// Component is one weighted part of a grade. Percent is nil while the course has
+// nothing of that kind to grade yet; such components are left out of the grade.
+type Component struct {
+	Weight    float64  `json:"weight"`
+	Items     int      `json:"items"`
+	Completed int      `json:"completed"`
+	Percent   *float64 `json:"percent"`
+}
+
+// StudentGrade is one student's row of the gradebook.
+type StudentGrade struct {
+	UserID      uuid.UUID `json:"userId"`
+	FullName    string    `json:"fullName"`
+	Email       string    `json:"email"`
+	Quizzes     Component `json:"quizzes"`
+	Assignments Component `json:"assignments"`
+	Attendance  Component `json:"attendance"`
+	Grade       *float64  `json:"grade"`
+}
+
+// Gradebook is the course-wide view for staff.
+type Gradebook struct {
+	Weights  Weights        `json:"weights"`
+	Students []StudentGrade `json:"students"`
+}
+
+// QuizResult is a student's best attempt at one quiz.
+type QuizResult struct {
+	AttachmentID uuid.UUID `json:"attachmentId"`
+	LessonID     uuid.UUID `json:"lessonId"`
+	Name         string    `json:"name"`
+	Attempts     int       `json:"attempts"`
+	BestPercent  *float64  `json:"bestPercent"`
+}
+
+// AssignmentResult is a student's mark on one assignment.
+type AssignmentResult struct {
+	Assignment
+	Points   *float64 `json:"points"`
+	Feedback *string  `json:"feedback,omitempty"`
+}
+
+// Report is a student's own gradebook entry with the items behind it.
+type Report struct {
+	StudentGrade
+	Weights         Weights            `json:"weights"`
+	QuizResults     []QuizResult       `json:"quizResults"`
+	AssignmentMarks []AssignmentResult `json:"assignmentMarks"`
+}
+
+// AssignmentInput carries data for creating or updating an assignment.
+type AssignmentInput struct {
+	Title       string
+	Description *string
+	MaxPoints   float64
+	DueAt       *time.Time
+}
+
+// GetWeights returns the course's weights, or the defaults when none are saved.
+func GetWeights(db *gorm.DB, courseID uuid.UUID) (Weights, error) {
+	weights := Weights{
+		CourseID:         courseID,
+		QuizWeight:       defaultQuizWeight,
+		AssignmentWeight: defaultAssignmentWeight,
+		AttendanceWeight: defaultAttendanceWeight,
+	}
+	var saved []Weights
+	if err := db.Where("course_id = ?", courseID).Limit(1).Find(&saved).Error; err != nil {
+		return weights, err
+	}
+	if len(saved) > 0 {
+		weights = saved[0]
+	}
+	return weights, nil
+}
+
+// SaveWeights stores the course's weights.
+func SaveWeights(db *gorm.DB, weights *Weights) error {
+	if weights.QuizWeight < 0 || weights.AssignmentWeight < 0 || weights.AttendanceWeight < 0 ||
+		weights.QuizWeight+weights.AssignmentWeight+weights.AttendanceWeight <= 0 {
+		return ErrWeightsInvalid
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "course_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quiz_weight", "assignment_weight", "attendance_weight", "updated_by", "updated_at"}),
+	}).Create(weights).Error
+}
+
+// ListAssignments returns the course's assignments, soonest due first.
+func ListAssignments(db *gorm.DB, courseID uuid.UUID) ([]Assignment, error) {
+	assignments := make([]Assignment, 0)
+	err := db.Where("course_id = ?", courseID).
+		Order("due_at ASC NULLS LAST, created_at ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// GetAssignment loads an assignment of the course.
+func GetAssignment(db *gorm.DB, courseID, id uuid.UUID) (Assignment, error) {
+	var assignment Assignment
+	if err := db.First(&assignment, "id = ? AND course_id = ?", id, courseID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return assignment, ErrAssignmentNotFound
+		}
+		return assignment, err
+	}
+	return assignment, nil
+}
+
+// CreateAssignment adds an assignment to the course.
+func CreateAssignment(db *gorm.DB, courseID uuid.UUID, input AssignmentInput) (Assignment, error) {
+	if input.MaxPoints <= 0 {
+		return Assignment{}, ErrMaxPointsInvalid
+	}
+	assignment := Assignment{
+		CourseID:    courseID,
+		Title:       input.Title,
+		Description: input.Description,
+		MaxPoints:   input.MaxPoints,
+		DueAt:       input.DueAt,
+	}
+	if err := db.Create(&assignment).Error; err != nil {
+		return Assignment{}, err
+	}
+	return assignment, nil
+}
+
+// UpdateAssignment replaces an assignment's fields. Lowering MaxPoints below a
+// mark already given is refused.
+func UpdateAssignment(db *gorm.DB, assignment *Assignment, input AssignmentInput) error {
+	if input.MaxPoints <= 0 {
+		return ErrMaxPointsInvalid
+	}
+
+	var above int64
+	if err := db.Model(&Mark{}).
+		Where("assignment_id = ? AND points > ?", assignment.ID, input.MaxPoints).
+		Count(&above).Error; err != nil {
+		return err
+	}
+	if above > 0 {
+		return ErrPointsOutOfRange
+	}
+
+	assignment.Title = input.Title
+	assignment.Description = input.Description
+	assignment.MaxPoints = input.MaxPoints
+	assignment.DueAt = input.DueAt
+	return db.Save(assignment).Error
+}
+
+// SaveMark records or replaces a student's mark on an assignment.
+func SaveMark(db *gorm.DB, assignment Assignment, mark *Mark) error {
+	if mark.Points < 0 || mark.Points > assignment.MaxPoints {
+		return ErrPointsOutOfRange
+	}
+	mark.AssignmentID = assignment.ID
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "assignment_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"points", "feedback", "graded_by", "updated_at"}),
+	}).Create(mark).Error
+}
+
+// RecordAttempt stores a quiz attempt.
+func RecordAttempt(db *gorm.DB, attempt *QuizAttempt) error {
+	if attempt.MaxScore <= 0 {
+		return ErrMaxScoreInvalid
+	}
+	if attempt.Score < 0 || attempt.Score > attempt.MaxScore {
+		return ErrScoreOutOfRange
+	}
+	return db.Create(attempt).Error
+}
+
+// FindQuiz checks that the attachment is a quiz students can see in the course.
+func FindQuiz(db *gorm.DB, courseID, attachmentID uuid.UUID) error {
+	var count int64
+	if err := quizzes(db, courseID).
+		Where("attachments.id = ?", attachmentID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrQuizNotFound
+	}
+	return nil
+}
+
+// quizzes selects the course's MCQ attachments that students can see.
+func quizzes(db *gorm.DB, courseID uuid.UUID) *gorm.DB {
+	return db.Table("attachments").
+		Joins("JOIN lessons ON lessons.id = attachments.lesson_id").
+		Joins("JOIN courses ON courses.id = lessons.course_id").
+		Where("attachments.type = ? AND attachments.is_active AND lessons.course_id = ?", types.AttachmentTypeMCQ, courseID).
+		Where("lessons.is_active AND lessons.status = ? AND courses.is_active AND courses.status = ?",
+			types.ContentStatusPublished, types.ContentStatusPublished)
+}
+
+// roster returns the active students who reach the course through an access
+// group, directly or through one of its lessons.
+func roster(db *gorm.DB, subscriptionID, courseID uuid.UUID, userID *uuid.UUID) ([]StudentGrade, error) {
+	students := make([]StudentGrade, 0)
+	query := db.Table("users").
+		Select("id AS user_id, full_name, email").
+		Where("subscription_id = ? AND user_type = ? AND is_active = ?", subscriptionID, types.UserTypeStudent, true).
+		Where(`id IN (
+			SELECT UNNEST(users) FROM group_access
+			WHERE subscription_id = ? AND (? = ANY(courses) OR lessons && ARRAY(SELECT id FROM lessons WHERE course_id = ?))
+		)`, subscriptionID, courseID, courseID)
+	if userID != nil {
+		query = query.Where("id = ?", *userID)
+	}
+	err := query.Order("full_name ASC").Scan(&students).Error
+	return students, err
+}
@@ -0,0 +1,38 @@
+package gradebook
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches gradebook endpoints to the router. Staff read grades
+// with acReports and manage weights, assignments and marks with acContent;
+// students read their own grade and report quiz attempts.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acReports, acContent []gin.HandlerFunc) {
+	gradebook := router.Group("/subscriptions/:subscriptionId/courses/:courseId/gradebook")
+
+	gradebook.GET("", append(acReports, handler.CourseGradebook)...)
+	gradebook.GET("/me", append(acAll, handler.MyGradebook)...)
+	gradebook.GET("/students/:userId", append(acReports, handler.StudentGradebook)...)
+	gradebook.GET("/weights", append(acReports, handler.GetWeights)...)
+	gradebook.PUT("/weights", append(acContent, handler.UpdateWeights)...)
+	gradebook.POST("/quiz-attempts", append(acAll, handler.RecordAttempt)...)
+	gradebook.GET("/assignments", append(acAll, handler.ListAssignments)...)
+	gradebook.POST("/assignments", append(acContent, handler.CreateAssignment)...)
+	gradebook.PUT("/assignments/:assignmentId", append(acContent, handler.UpdateAssignment)...)
+	gradebook.DELETE("/assignments/:assignmentId", append(acContent, handler.DeleteAssignment)...)
+	gradebook.PUT("/assignments/:assignmentId/marks/:userId", append(acContent, handler.SaveMark)...)
+	gradebook.DELETE("/assignments/:assignmentId/marks/:userId", append(acContent, handler.DeleteMark)...)
+
+	openapi.Describe(handler.CourseGradebook, openapi.Spec{Response: Gradebook{}})
+	openapi.Describe(handler.MyGradebook, openapi.Spec{Response: Report{}})
+	openapi.Describe(handler.StudentGradebook, openapi.Spec{Response: Report{}})
+	openapi.Describe(handler.GetWeights, openapi.Spec{Response: Weights{}})
+	openapi.Describe(handler.UpdateWeights, openapi.Spec{Request: weightsRequest{}, Response: Weights{}})
+	openapi.Describe(handler.RecordAttempt, openapi.Spec{Request: attemptRequest{}, Response: QuizAttempt{}})
+	openapi.Describe(handler.ListAssignments, openapi.Spec{Response: []Assignment{}})
+	openapi.Describe(handler.CreateAssignment, openapi.Spec{Request: assignmentRequest{}, Response: Assignment{}})
+	openapi.Describe(handler.UpdateAssignment, openapi.Spec{Request: assignmentRequest{}, Response: Assignment{}})
+	openapi.Describe(handler.SaveMark, openapi.Spec{Request: markRequest{}, Response: Mark{}})
+}
@@ -0,0 +1,8 @@
+package groupaccess
+
+import "errors"
+
+var (
+	ErrGroupNotFound = errors.New("group not found")
+	ErrPointsLimit   = errors.New("subscription points limit exceeded")
+)
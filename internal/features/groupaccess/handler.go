@@ -11,6 +11,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
@@ -25,20 +26,21 @@ func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
 	return &Handler{db: db, logger: logger}
 }
 
+type createRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Users         []string `json:"users" binding:"max=10000"`
+	Courses       []string `json:"courses" binding:"max=1000"`
+	Lessons       []string `json:"lessons" binding:"max=1000"`
+	Announcements []string `json:"announcements" binding:"max=1000"`
+}
+
 // Create creates a new group access with points validation.
 func (h *Handler) Create(c *gin.Context) {
 	subscriptionID := c.Param("subscriptionId")
 
-	var req struct {
-		Name          string   `json:"name" binding:"required"`
-		Users         []string `json:"users"`
-		Courses       []string `json:"courses"`
-		Lessons       []string `json:"lessons"`
-		Announcements []string `json:"announcements"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid group access payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid group access payload") {
 		return
 	}
 
@@ -153,21 +155,22 @@ func (h *Handler) Get(c *gin.Context) {
 	response.Success(c, http.StatusOK, group, "", nil)
 }
 
+type updateRequest struct {
+	Name          *string   `json:"name"`
+	Users         *[]string `json:"users" binding:"omitnil,max=10000"`
+	Courses       *[]string `json:"courses" binding:"omitnil,max=1000"`
+	Lessons       *[]string `json:"lessons" binding:"omitnil,max=1000"`
+	Announcements *[]string `json:"announcements" binding:"omitnil,max=1000"`
+}
+
 // Update updates a group access with points recalculation.
 func (h *Handler) Update(c *gin.Context) {
 	groupID := c.Param("groupId")
 	subscriptionID := c.Param("subscriptionId")
 
-	var req struct {
-		Name          *string   `json:"name"`
-		Users         *[]string `json:"users"`
-		Courses       *[]string `json:"courses"`
-		Lessons       *[]string `json:"lessons"`
-		Announcements *[]string `json:"announcements"`
-	}
+	var req updateRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid update payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid update payload") {
 		return
 	}
 
@@ -1,10 +1,14 @@
 package groupaccess
 
 import (
+	"slices"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
@@ -62,3 +66,119 @@ func (g *GroupAccess) CalculatePoints(db *gorm.DB) (int, error) {
 	points := userCount * len(uniqueCourses)
 	return points, nil
 }
+
+// CalculateGroupsPoints fills SubscriptionPointsUsage for several groups,
+// resolving every lesson's course in a single query.
+func CalculateGroupsPoints(db *gorm.DB, groups []GroupAccess) error {
+	var lessonIDs []string
+	for _, group := range groups {
+		if len(group.Users) > 0 {
+			lessonIDs = append(lessonIDs, group.Lessons...)
+		}
+	}
+
+	lessonCourses := make(map[string]string, len(lessonIDs))
+	if len(lessonIDs) > 0 {
+		var rows []struct {
+			ID       string
+			CourseID string
+		}
+		if err := db.Table("lessons").Select("id, course_id").Where("id IN ?", lessonIDs).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			lessonCourses[row.ID] = row.CourseID
+		}
+	}
+
+	for i := range groups {
+		if len(groups[i].Users) == 0 {
+			groups[i].SubscriptionPointsUsage = 0
+			continue
+		}
+
+		uniqueCourses := make(map[string]bool)
+		for _, courseID := range groups[i].Courses {
+			uniqueCourses[courseID] = true
+		}
+		for _, lessonID := range groups[i].Lessons {
+			if courseID, ok := lessonCourses[lessonID]; ok {
+				uniqueCourses[courseID] = true
+			}
+		}
+
+		groups[i].SubscriptionPointsUsage = len(groups[i].Users) * len(uniqueCourses)
+	}
+
+	return nil
+}
+
+// AddUser appends the user to the group and keeps the subscription within its
+// points budget. Members already in the group are left as they are.
+func AddUser(tx *gorm.DB, sub subscription.Subscription, groupID, userID uuid.UUID) error {
+	var group GroupAccess
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&group, "id = ? AND subscription_id = ?", groupID, sub.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+
+	if slices.Contains(group.Users, userID.String()) {
+		return nil
+	}
+	group.Users = append(group.Users, userID.String())
+
+	points, err := group.CalculatePoints(tx)
+	if err != nil {
+		return err
+	}
+
+	if sub.SubscriptionPoints > 0 {
+		var otherUsage int64
+		if err := tx.Model(&GroupAccess{}).
+			Where("subscription_id = ? AND id != ?", sub.ID, group.ID).
+			Select("COALESCE(SUM(subscription_points_usage), 0)").
+			Scan(&otherUsage).Error; err != nil {
+			return err
+		}
+		if int(otherUsage)+points > sub.SubscriptionPoints {
+			return ErrPointsLimit
+		}
+	}
+
+	return tx.Model(&group).Updates(map[string]interface{}{
+		"users":                     group.Users,
+		"subscription_points_usage": points,
+	}).Error
+}
+
+// RemoveUser drops the user from the group and releases the points they used.
+// Users who are not members are ignored.
+func RemoveUser(tx *gorm.DB, subscriptionID, groupID, userID uuid.UUID) error {
+	var group GroupAccess
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&group, "id = ? AND subscription_id = ?", groupID, subscriptionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+
+	index := slices.Index(group.Users, userID.String())
+	if index < 0 {
+		return nil
+	}
+	group.Users = slices.Delete(group.Users, index, index+1)
+
+	points, err := group.CalculatePoints(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&group).Updates(map[string]interface{}{
+		"users":                     group.Users,
+		"subscription_points_usage": points,
+	}).Error
+}
@@ -2,16 +2,26 @@ package groupaccess
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 )
 
 // RegisterRoutes registers group access routes.
 // Middleware is passed as parameters to avoid import cycles
 func RegisterRoutes(r *gin.RouterGroup, handler *Handler, acStaff []gin.HandlerFunc) {
 	groups := r.Group("/subscriptions/:subscriptionId/groups")
+	// Groups list every member, which can be more ids than the default body allows
+	members := request.Limit(request.Limits{MaxBytes: 4 << 20, MaxItems: 10000})
 
 	groups.GET("", append(acStaff, handler.List)...)
-	groups.POST("", append(acStaff, handler.Create)...)
+	groups.POST("", append(acStaff, members, handler.Create)...)
 	groups.GET("/:groupId", append(acStaff, handler.Get)...)
-	groups.PUT("/:groupId", append(acStaff, handler.Update)...)
+	groups.PUT("/:groupId", append(acStaff, members, handler.Update)...)
 	groups.DELETE("/:groupId", append(acStaff, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []GroupAccess{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}})
+	openapi.Describe(handler.Get, openapi.Spec{Response: GroupAccess{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}})
 }
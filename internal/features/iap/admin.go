@@ -0,0 +1,303 @@
+package iap
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+const (
+	eventTypeManualRevalidation = "manual_revalidation"
+	eventTypeManualRefund       = "manual_refund"
+)
+
+// PurchaseFilters narrows the admin purchase listing.
+type PurchaseFilters struct {
+	Store  Store
+	Status PurchaseStatus
+	UserID *uuid.UUID
+	Search string // Matches transaction, original transaction or order IDs
+}
+
+// ListPurchases returns purchases matching filters, newest first.
+func ListPurchases(db *gorm.DB, filters PurchaseFilters, params pagination.Params) ([]Purchase, int64, error) {
+	query := db.Model(&Purchase{})
+
+	if filters.Store != "" {
+		query = query.Where("store = ?", filters.Store)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.UserID != nil {
+		query = query.Where("user_id = ?", *filters.UserID)
+	}
+	if filters.Search != "" {
+		query = query.Where("transaction_id = ? OR original_transaction_id = ? OR order_id = ?",
+			filters.Search, filters.Search, filters.Search)
+	}
+
+	var total int64
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var purchases []Purchase
+	err := pagination.Apply(query, params).Find(&purchases).Error
+
+	return purchases, total, err
+}
+
+// ListWebhookEvents returns the webhook and manual events recorded for a purchase.
+func ListWebhookEvents(db *gorm.DB, purchaseID uuid.UUID, params pagination.Params) ([]WebhookEvent, int64, error) {
+	query := db.Model(&WebhookEvent{}).Where("purchase_id = ?", purchaseID)
+
+	var total int64
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var events []WebhookEvent
+	err := pagination.Apply(query, params).Find(&events).Error
+
+	return events, total, err
+}
+
+// AdminListPurchases lists purchases for support staff.
+// GET /api/iap/admin/purchases
+func (h *Handler) AdminListPurchases(c *gin.Context) {
+	filters := PurchaseFilters{
+		Store:  Store(c.Query("store")),
+		Status: PurchaseStatus(c.Query("status")),
+		Search: c.Query("filterKeyword"),
+	}
+
+	if filters.Store != "" && filters.Store != StoreGooglePlay && filters.Store != StoreAppStore {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "store must be google_play or app_store", nil)
+		return
+	}
+
+	if userParam := c.Query("userId"); userParam != "" {
+		userID, err := uuid.Parse(userParam)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+			return
+		}
+		filters.UserID = &userID
+	}
+
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
+
+	purchases, total, err := ListPurchases(replica.Reader(h.db.WithContext(c.Request.Context())), filters, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to list purchases", err)
+		return
+	}
+
+	purchases, meta := pagination.Page(purchases, total, params)
+	response.Success(c, http.StatusOK, purchases, "", meta)
+}
+
+// AdminGetPurchase returns a single purchase.
+// GET /api/iap/admin/purchases/:purchaseId
+func (h *Handler) AdminGetPurchase(c *gin.Context) {
+	purchase, ok := h.loadPurchase(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, http.StatusOK, purchase, "", nil)
+}
+
+// AdminPurchaseEvents lists the webhook event history of a purchase.
+// GET /api/iap/admin/purchases/:purchaseId/events
+func (h *Handler) AdminPurchaseEvents(c *gin.Context) {
+	purchase, ok := h.loadPurchase(c)
+	if !ok {
+		return
+	}
+
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
+
+	events, total, err := ListWebhookEvents(replica.Reader(h.db.WithContext(c.Request.Context())), purchase.ID, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to list webhook events", err)
+		return
+	}
+
+	events, meta := pagination.Page(events, total, params)
+	response.Success(c, http.StatusOK, events, "", meta)
+}
+
+// AdminRevalidatePurchase re-runs store validation with the stored receipt and
+// refreshes the purchase and its subscription.
+// POST /api/iap/admin/purchases/:purchaseId/revalidate
+func (h *Handler) AdminRevalidatePurchase(c *gin.Context) {
+	purchase, ok := h.loadPurchase(c)
+	if !ok {
+		return
+	}
+
+	verified, err := h.verifyWithStore(c.Request.Context(), purchase.Store, purchase.ProductID, purchase.OriginalReceipt, purchase.TransactionID)
+	if err != nil {
+		h.recordManualEvent(c, &purchase, eventTypeManualRevalidation, nil, err)
+		h.respondStoreError(c, err)
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		purchase.Status = PurchaseStatusValidated
+		purchase.ExpiryDate = verified.ExpiryDate
+		purchase.AutoRenewing = verified.AutoRenewing
+		purchase.ValidationData = verified.ValidationData
+		if verified.TransactionID != "" {
+			purchase.TransactionID = verified.TransactionID
+		}
+		if err := tx.Save(&purchase).Error; err != nil {
+			return err
+		}
+
+		if purchase.SubscriptionID == nil || verified.ExpiryDate == nil {
+			return nil
+		}
+
+		_, err := subscription.Renew(tx, *purchase.SubscriptionID, *verified.ExpiryDate, subscription.SourceIAP)
+		return err
+	})
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to update purchase", err)
+		return
+	}
+
+	h.recordManualEvent(c, &purchase, eventTypeManualRevalidation, nil, nil)
+
+	response.Success(c, http.StatusOK, purchase, "Purchase revalidated", nil)
+}
+
+type adminRefundPurchaseRequest struct {
+	Reason                 string `json:"reason"`
+	DeactivateSubscription *bool  `json:"deactivateSubscription"`
+}
+
+// AdminRefundPurchase marks a purchase refunded after a store refund or
+// billing dispute and, unless told otherwise, deactivates its subscription.
+// POST /api/iap/admin/purchases/:purchaseId/refund
+func (h *Handler) AdminRefundPurchase(c *gin.Context) {
+	purchase, ok := h.loadPurchase(c)
+	if !ok {
+		return
+	}
+
+	var req adminRefundPurchaseRequest
+	if c.Request.ContentLength > 0 {
+		if !request.BindJSON(h.logger, c, &req, "Invalid request") {
+			return
+		}
+	}
+
+	if purchase.Status == PurchaseStatusRefunded {
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "Purchase is already refunded", nil)
+		return
+	}
+
+	deactivate := req.DeactivateSubscription == nil || *req.DeactivateSubscription
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		purchase.Status = PurchaseStatusRefunded
+		purchase.AutoRenewing = false
+		if err := tx.Save(&purchase).Error; err != nil {
+			return err
+		}
+
+		if !deactivate || purchase.SubscriptionID == nil {
+			return nil
+		}
+
+		_, err := subscription.Expire(tx, *purchase.SubscriptionID, subscription.SourceAdmin)
+		return err
+	})
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to refund purchase", err)
+		return
+	}
+
+	h.recordManualEvent(c, &purchase, eventTypeManualRefund, map[string]interface{}{
+		"reason":                 req.Reason,
+		"deactivateSubscription": deactivate,
+	}, nil)
+
+	response.Success(c, http.StatusOK, purchase, "Purchase marked as refunded", nil)
+}
+
+func (h *Handler) loadPurchase(c *gin.Context) (Purchase, bool) {
+	var purchase Purchase
+
+	id, err := uuid.Parse(c.Param("purchaseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Invalid purchase ID", err)
+		return purchase, false
+	}
+
+	if err := h.db.First(&purchase, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.ErrorWithLog(h.logger, c, http.StatusNotFound, "Purchase not found", err)
+			return purchase, false
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to load purchase", err)
+		return purchase, false
+	}
+
+	return purchase, true
+}
+
+// recordManualEvent adds a support action to the purchase's event history.
+func (h *Handler) recordManualEvent(c *gin.Context, purchase *Purchase, eventType string, details map[string]interface{}, actionErr error) {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	if user, ok := middleware.GetUserFromContext(c); ok && user != nil {
+		details["adminId"] = user.ID
+	}
+	payload, _ := json.Marshal(details)
+
+	now := time.Now()
+	event := WebhookEvent{
+		Store:       purchase.Store,
+		EventType:   eventType,
+		PurchaseID:  &purchase.ID,
+		Payload:     string(payload),
+		Success:     actionErr == nil,
+		ProcessedAt: &now,
+	}
+	if actionErr != nil {
+		event.ErrorMessage = actionErr.Error()
+	}
+
+	if err := h.db.Create(&event).Error; err != nil {
+		h.logger.Error("Failed to record IAP support action", "error", err, "purchaseId", purchase.ID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,24 +23,93 @@ const (
 	AppleStatusProductionReceipt = 21008 // Receipt is from production but sent to sandbox
 )
 
+// ErrAppleVerificationUnavailable is returned when signed payloads cannot be
+// verified because no Apple root certificate is configured.
+var ErrAppleVerificationUnavailable = errors.New("apple signature verification not configured")
+
 // AppStoreValidator handles Apple App Store purchase validation
 type AppStoreValidator struct {
 	password   string // Shared secret from App Store Connect
+	bundleID   string
 	httpClient *http.Client
 	useSandbox bool
 	autoRetry  bool // Automatically retry with sandbox if production fails with 21007
+
+	verifier *JWSVerifier          // Verifies signed notifications and transactions
+	server   *AppStoreServerClient // Optional App Store Server API client
 }
 
 // NewAppStoreValidator creates a new App Store validator
-func NewAppStoreValidator(password string, useSandbox bool) *AppStoreValidator {
+func NewAppStoreValidator(password, bundleID string, useSandbox bool) *AppStoreValidator {
 	return &AppStoreValidator{
 		password:   password,
+		bundleID:   bundleID,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 		useSandbox: useSandbox,
 		autoRetry:  true,
 	}
 }
 
+// UseSignedPayloads enables JWS verification of App Store notifications and,
+// when server is non-nil, transaction lookups through the App Store Server API.
+func (v *AppStoreValidator) UseSignedPayloads(verifier *JWSVerifier, server *AppStoreServerClient) {
+	v.verifier = verifier
+	v.server = server
+}
+
+// HasServerAPI reports whether transactions can be looked up through the App Store Server API.
+func (v *AppStoreValidator) HasServerAPI() bool {
+	return v.server != nil
+}
+
+// LookupTransaction fetches the verified transaction from the App Store Server API.
+func (v *AppStoreValidator) LookupTransaction(ctx context.Context, transactionID string) (*AppleTransactionInfo, error) {
+	if v.server == nil {
+		return nil, fmt.Errorf("app store server api not configured")
+	}
+	return v.server.GetTransactionInfo(ctx, transactionID)
+}
+
+// VerifyNotification verifies a signed V2 notification along with the
+// transaction and renewal info nested in it. The renewal info may be nil.
+func (v *AppStoreValidator) VerifyNotification(signedPayload string) (*AppleServerNotification, *AppleTransactionInfo, *AppleRenewalInfo, error) {
+	if v.verifier == nil {
+		return nil, nil, nil, ErrAppleVerificationUnavailable
+	}
+
+	var notification AppleServerNotification
+	if err := v.verifier.Verify(signedPayload, &notification); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if v.bundleID != "" && notification.Data.BundleID != v.bundleID {
+		return nil, nil, nil, fmt.Errorf("%w: notification for bundle %q", ErrInvalidAppleSignature, notification.Data.BundleID)
+	}
+
+	if notification.Data.SignedTransactionInfo == "" {
+		return nil, nil, nil, fmt.Errorf("missing signedTransactionInfo in notification")
+	}
+
+	var transaction AppleTransactionInfo
+	if err := v.verifier.Verify(notification.Data.SignedTransactionInfo, &transaction); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if transaction.BundleID != notification.Data.BundleID {
+		return nil, nil, nil, fmt.Errorf("%w: transaction bundle does not match notification", ErrInvalidAppleSignature)
+	}
+
+	var renewal *AppleRenewalInfo
+	if notification.Data.SignedRenewalInfo != "" {
+		renewal = &AppleRenewalInfo{}
+		if err := v.verifier.Verify(notification.Data.SignedRenewalInfo, renewal); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return &notification, &transaction, renewal, nil
+}
+
 // ValidateReceipt validates an App Store receipt
 func (v *AppStoreValidator) ValidateReceipt(ctx context.Context, receiptData string) (*AppleReceiptResponse, error) {
 	url := AppleProductionURL
@@ -0,0 +1,148 @@
+package iap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// App Store Server API base URLs
+	AppleServerAPIProductionURL = "https://api.storekit.itunes.apple.com"
+	AppleServerAPISandboxURL    = "https://api.storekit-sandbox.itunes.apple.com"
+
+	appleServerAPIAudience = "appstoreconnect-v1"
+	appleServerAPITokenTTL = 5 * time.Minute
+)
+
+// ErrAppleTransactionNotFound is returned when the App Store has no record of a transaction.
+var ErrAppleTransactionNotFound = errors.New("apple transaction not found")
+
+// AppStoreServerClient calls the App Store Server API using an App Store Connect API key.
+type AppStoreServerClient struct {
+	issuerID   string
+	keyID      string
+	bundleID   string
+	privateKey *ecdsa.PrivateKey
+	verifier   *JWSVerifier
+	httpClient *http.Client
+	useSandbox bool
+}
+
+// NewAppStoreServerClient creates a client from the in-app purchase key (.p8 PEM content).
+func NewAppStoreServerClient(issuerID, keyID, bundleID string, privateKeyPEM []byte, useSandbox bool, verifier *JWSVerifier) (*AppStoreServerClient, error) {
+	if issuerID == "" || keyID == "" || bundleID == "" {
+		return nil, fmt.Errorf("issuer id, key id and bundle id are required")
+	}
+	if verifier == nil {
+		return nil, fmt.Errorf("a JWS verifier is required")
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse App Store private key: %w", err)
+	}
+
+	return &AppStoreServerClient{
+		issuerID:   issuerID,
+		keyID:      keyID,
+		bundleID:   bundleID,
+		privateKey: key,
+		verifier:   verifier,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		useSandbox: useSandbox,
+	}, nil
+}
+
+// GetTransactionInfo looks up a transaction and returns its verified payload.
+// Like receipt validation, it falls back to the other environment when the
+// transaction is unknown in the configured one.
+func (c *AppStoreServerClient) GetTransactionInfo(ctx context.Context, transactionID string) (*AppleTransactionInfo, error) {
+	primary, fallback := AppleServerAPIProductionURL, AppleServerAPISandboxURL
+	if c.useSandbox {
+		primary, fallback = fallback, primary
+	}
+
+	info, err := c.getTransactionInfo(ctx, primary, transactionID)
+	if errors.Is(err, ErrAppleTransactionNotFound) {
+		info, err = c.getTransactionInfo(ctx, fallback, transactionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.BundleID != c.bundleID {
+		return nil, fmt.Errorf("transaction belongs to bundle %q", info.BundleID)
+	}
+
+	return info, nil
+}
+
+func (c *AppStoreServerClient) getTransactionInfo(ctx context.Context, baseURL, transactionID string) (*AppleTransactionInfo, error) {
+	token, err := c.authToken()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := baseURL + "/inApps/v1/transactions/" + url.PathEscape(transactionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrAppleTransactionNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("app store server api returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var payload struct {
+		SignedTransactionInfo string `json:"signedTransactionInfo"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var info AppleTransactionInfo
+	if err := c.verifier.Verify(payload.SignedTransactionInfo, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// authToken signs the short-lived bearer token the Server API expects.
+func (c *AppStoreServerClient) authToken() (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": c.issuerID,
+		"iat": now.Unix(),
+		"exp": now.Add(appleServerAPITokenTTL).Unix(),
+		"aud": appleServerAPIAudience,
+		"bid": c.bundleID,
+	})
+	token.Header["kid"] = c.keyID
+
+	signed, err := token.SignedString(c.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign App Store API token: %w", err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,184 @@
+package iap
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidAppleSignature is returned when a signed App Store payload fails verification.
+var ErrInvalidAppleSignature = errors.New("invalid App Store signature")
+
+var (
+	// Marker extensions Apple places on the App Store signing certificates.
+	oidAppleLeafMarker         = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 11, 1}
+	oidAppleIntermediateMarker = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 2, 1}
+)
+
+// JWSVerifier verifies App Store signed payloads (JWS) against the x5c chain
+// embedded in their header, anchored at Apple's root certificate.
+type JWSVerifier struct {
+	roots *x509.CertPool
+	now   func() time.Time
+}
+
+// NewJWSVerifier loads the Apple root certificate (PEM or DER) from rootCertPath.
+func NewJWSVerifier(rootCertPath string) (*JWSVerifier, error) {
+	if rootCertPath == "" {
+		return nil, fmt.Errorf("apple root certificate path is required")
+	}
+
+	data, err := os.ReadFile(rootCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read apple root certificate: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	loaded := 0
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse apple root certificate: %w", err)
+		}
+		roots.AddCert(cert)
+		loaded++
+	}
+
+	// Apple distributes the root as a DER .cer file
+	if loaded == 0 {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse apple root certificate: %w", err)
+		}
+		roots.AddCert(cert)
+	}
+
+	return &JWSVerifier{roots: roots, now: time.Now}, nil
+}
+
+type jwsHeader struct {
+	Alg string   `json:"alg"`
+	X5C []string `json:"x5c"`
+}
+
+// Verify checks the signature and certificate chain of token and decodes its
+// payload into dest. Any failure wraps ErrInvalidAppleSignature.
+func (v *JWSVerifier) Verify(token string, dest interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: expected 3 segments, got %d", ErrInvalidAppleSignature, len(parts))
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: malformed header: %v", ErrInvalidAppleSignature, err)
+	}
+
+	var header jwsHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return fmt.Errorf("%w: malformed header: %v", ErrInvalidAppleSignature, err)
+	}
+
+	if header.Alg != jwt.SigningMethodES256.Alg() {
+		return fmt.Errorf("%w: unexpected alg %q", ErrInvalidAppleSignature, header.Alg)
+	}
+
+	key, err := v.verifyChain(header.X5C)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAppleSignature, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature: %v", ErrInvalidAppleSignature, err)
+	}
+
+	if err := jwt.SigningMethodES256.Verify(parts[0]+"."+parts[1], signature, key); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAppleSignature, err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: malformed payload: %v", ErrInvalidAppleSignature, err)
+	}
+
+	if err := json.Unmarshal(payload, dest); err != nil {
+		return fmt.Errorf("failed to parse signed payload: %w", err)
+	}
+
+	return nil
+}
+
+// verifyChain validates the x5c chain (leaf, intermediate, root) and returns
+// the leaf's public key.
+func (v *JWSVerifier) verifyChain(x5c []string) (*ecdsa.PublicKey, error) {
+	if len(x5c) < 2 {
+		return nil, fmt.Errorf("x5c chain too short")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(x5c))
+	for _, encoded := range x5c {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("malformed x5c certificate: %w", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("malformed x5c certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	leaf, intermediate := certs[0], certs[1]
+	if !hasExtension(leaf, oidAppleLeafMarker) {
+		return nil, fmt.Errorf("leaf certificate is not an App Store signing certificate")
+	}
+	if !hasExtension(intermediate, oidAppleIntermediateMarker) {
+		return nil, fmt.Errorf("intermediate certificate is not an Apple WWDR certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("certificate chain not trusted: %w", err)
+	}
+
+	key, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("leaf certificate key is not ECDSA")
+	}
+
+	return key, nil
+}
+
+func hasExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package iap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// testCert is a certificate of a test chain and its key.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate signed by parent, or self-signed when parent is
+// nil, carrying the marker extension when one is given.
+func issue(t *testing.T, name string, parent *testCert, isCA bool, marker []int) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             testNow.AddDate(-1, 0, 0),
+		NotAfter:              testNow.AddDate(1, 0, 0),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	if marker != nil {
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: marker, Value: []byte{0x05, 0x00}})
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate %s: %v", name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate %s: %v", name, err)
+	}
+	return testCert{cert: cert, key: key}
+}
+
+// testChain is a root, an Apple-like intermediate and leaf, and a verifier
+// that trusts the root.
+type testChain struct {
+	root, intermediate, leaf testCert
+	verifier                 *JWSVerifier
+}
+
+func newTestChain(t *testing.T) testChain {
+	t.Helper()
+
+	root := issue(t, "Test Root CA", nil, true, nil)
+	intermediate := issue(t, "Test WWDR CA", &root, true, oidAppleIntermediateMarker)
+	leaf := issue(t, "Test App Store Signing", &intermediate, false, oidAppleLeafMarker)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	return testChain{
+		root:         root,
+		intermediate: intermediate,
+		leaf:         leaf,
+		verifier:     &JWSVerifier{roots: roots, now: func() time.Time { return testNow }},
+	}
+}
+
+func (c testChain) x5c() []string {
+	return []string{
+		base64.StdEncoding.EncodeToString(c.leaf.cert.Raw),
+		base64.StdEncoding.EncodeToString(c.intermediate.cert.Raw),
+		base64.StdEncoding.EncodeToString(c.root.cert.Raw),
+	}
+}
+
+// signJWS builds a compact JWS of payload with the header, signed ES256 by key.
+func signJWS(t *testing.T, header jwsHeader, payload interface{}, key *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("marshal header: %v", err)
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	signingString := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	signature, err := jwt.SigningMethodES256.Sign(signingString, key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWSVerifierVerify(t *testing.T) {
+	chain := newTestChain(t)
+	otherChain := newTestChain(t)
+	unmarkedLeaf := issue(t, "Test Unmarked Leaf", &chain.intermediate, false, nil)
+
+	transaction := AppleTransactionInfo{TransactionID: "2000000123", BundleID: "com.example.lms", ProductID: "com.example.lms.monthly"}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr bool
+	}{
+		{
+			name: "valid chain",
+			token: func() string {
+				return signJWS(t, jwsHeader{Alg: "ES256", X5C: chain.x5c()}, transaction, chain.leaf.key)
+			},
+		},
+		{
+			name: "chain to another root",
+			token: func() string {
+				return signJWS(t, jwsHeader{Alg: "ES256", X5C: otherChain.x5c()}, transaction, otherChain.leaf.key)
+			},
+			wantErr: true,
+		},
+		{
+			name: "leaf without the marker extension",
+			token: func() string {
+				x5c := chain.x5c()
+				x5c[0] = base64.StdEncoding.EncodeToString(unmarkedLeaf.cert.Raw)
+				return signJWS(t, jwsHeader{Alg: "ES256", X5C: x5c}, transaction, unmarkedLeaf.key)
+			},
+			wantErr: true,
+		},
+		{
+			name: "signed by a key other than the leaf's",
+			token: func() string {
+				return signJWS(t, jwsHeader{Alg: "ES256", X5C: chain.x5c()}, transaction, otherChain.leaf.key)
+			},
+			wantErr: true,
+		},
+		{
+			name: "chain without an intermediate",
+			token: func() string {
+				return signJWS(t, jwsHeader{Alg: "ES256", X5C: chain.x5c()[:1]}, transaction, chain.leaf.key)
+			},
+			wantErr: true,
+		},
+		{
+			name: "alg none",
+			token: func() string {
+				header, _ := json.Marshal(jwsHeader{Alg: "none", X5C: chain.x5c()})
+				payload, _ := json.Marshal(transaction)
+				return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+			},
+			wantErr: true,
+		},
+		{
+			// HS256 keyed with the leaf certificate, which an attacker has.
+			name: "alg HS256",
+			token: func() string {
+				header, _ := json.Marshal(jwsHeader{Alg: "HS256", X5C: chain.x5c()})
+				payload, _ := json.Marshal(transaction)
+				signingString := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+				signature, err := jwt.SigningMethodHS256.Sign(signingString, chain.leaf.cert.Raw)
+				if err != nil {
+					t.Fatalf("sign: %v", err)
+				}
+				return signingString + "." + base64.RawURLEncoding.EncodeToString(signature)
+			},
+			wantErr: true,
+		},
+		{
+			name: "payload changed after signing",
+			token: func() string {
+				token := signJWS(t, jwsHeader{Alg: "ES256", X5C: chain.x5c()}, transaction, chain.leaf.key)
+				forged := transaction
+				forged.ProductID = "com.example.lms.lifetime"
+				payload, _ := json.Marshal(forged)
+				parts := strings.Split(token, ".")
+				return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got AppleTransactionInfo
+			err := chain.verifier.Verify(tt.token(), &got)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAppleSignature) {
+					t.Fatalf("Verify() error = %v, want %v", err, ErrInvalidAppleSignature)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got != transaction {
+				t.Errorf("Verify() payload = %+v, want %+v", got, transaction)
+			}
+		})
+	}
+}
+
+func TestAppStoreValidatorVerifyNotificationBundle(t *testing.T) {
+	chain := newTestChain(t)
+	x5c := chain.x5c()
+
+	notification := func(notificationBundle, transactionBundle string) string {
+		transaction := signJWS(t, jwsHeader{Alg: "ES256", X5C: x5c}, AppleTransactionInfo{TransactionID: "2000000123", BundleID: transactionBundle}, chain.leaf.key)
+		return signJWS(t, jwsHeader{Alg: "ES256", X5C: x5c}, AppleServerNotification{
+			NotificationType: "DID_RENEW",
+			Data:             AppleNotificationData{BundleID: notificationBundle, SignedTransactionInfo: transaction},
+		}, chain.leaf.key)
+	}
+
+	tests := []struct {
+		name               string
+		notificationBundle string
+		transactionBundle  string
+		wantErr            bool
+	}{
+		{name: "matching bundle", notificationBundle: "com.example.lms", transactionBundle: "com.example.lms"},
+		{name: "notification for another bundle", notificationBundle: "com.other.app", transactionBundle: "com.other.app", wantErr: true},
+		{name: "transaction for another bundle", notificationBundle: "com.example.lms", transactionBundle: "com.other.app", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewAppStoreValidator("", "com.example.lms", true)
+			validator.UseSignedPayloads(chain.verifier, nil)
+
+			_, transaction, _, err := validator.VerifyNotification(notification(tt.notificationBundle, tt.transactionBundle))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAppleSignature) {
+					t.Fatalf("VerifyNotification() error = %v, want %v", err, ErrInvalidAppleSignature)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyNotification() error = %v", err)
+			}
+			if transaction.BundleID != tt.transactionBundle {
+				t.Errorf("transaction bundle = %q, want %q", transaction.BundleID, tt.transactionBundle)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package iap
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/idtoken"
+)
+
+// ErrGooglePushUnauthorized is returned when a Pub/Sub push request cannot be authenticated.
+var ErrGooglePushUnauthorized = errors.New("unauthorized pub/sub push")
+
+var googleTokenIssuers = map[string]bool{
+	"accounts.google.com":         true,
+	"https://accounts.google.com": true,
+}
+
+// GooglePushAuthenticator authenticates Pub/Sub push deliveries of Real-time
+// Developer Notifications. Both checks must pass: the shared path token, and the
+// OIDC token Pub/Sub attaches when push authentication is enabled.
+type GooglePushAuthenticator struct {
+	pathToken      string
+	audience       string
+	serviceAccount string
+	validate       func(ctx context.Context, token, audience string) (*idtoken.Payload, error)
+}
+
+// NewGooglePushAuthenticator creates an authenticator. The path token and push
+// audience are both required; serviceAccount, when set, pins the token's email claim.
+func NewGooglePushAuthenticator(pathToken, audience, serviceAccount string) (*GooglePushAuthenticator, error) {
+	if pathToken == "" {
+		return nil, fmt.Errorf("a webhook token is required")
+	}
+	if audience == "" {
+		return nil, fmt.Errorf("a push audience is required")
+	}
+
+	return &GooglePushAuthenticator{
+		pathToken:      pathToken,
+		audience:       audience,
+		serviceAccount: serviceAccount,
+		validate:       idtoken.Validate,
+	}, nil
+}
+
+// Authenticate checks the path token and the Authorization header of a push request.
+func (a *GooglePushAuthenticator) Authenticate(ctx context.Context, pathToken, authorization string) error {
+	if subtle.ConstantTimeCompare([]byte(pathToken), []byte(a.pathToken)) != 1 {
+		return fmt.Errorf("%w: invalid webhook token", ErrGooglePushUnauthorized)
+	}
+
+	bearer, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || bearer == "" {
+		return fmt.Errorf("%w: missing bearer token", ErrGooglePushUnauthorized)
+	}
+
+	payload, err := a.validate(ctx, bearer, a.audience)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrGooglePushUnauthorized, err)
+	}
+
+	if !googleTokenIssuers[payload.Issuer] {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrGooglePushUnauthorized, payload.Issuer)
+	}
+
+	if a.serviceAccount != "" {
+		email, _ := payload.Claims["email"].(string)
+		verified, _ := payload.Claims["email_verified"].(bool)
+		if !verified || email != a.serviceAccount {
+			return fmt.Errorf("%w: unexpected service account %q", ErrGooglePushUnauthorized, email)
+		}
+	}
+
+	return nil
+}
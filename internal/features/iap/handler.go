@@ -1,7 +1,7 @@
 package iap
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,9 +11,11 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/features/coupon"
 	packageModel "github.com/mo-amir99/lms-server-go/internal/features/package"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
@@ -23,6 +25,9 @@ type Handler struct {
 	logger          *slog.Logger
 	googleValidator *GooglePlayValidator
 	appleValidator  *AppStoreValidator
+	googlePush      *GooglePushAuthenticator
+
+	activationHooks []func(subscription.Subscription)
 }
 
 // NewHandler creates a new IAP handler
@@ -35,6 +40,18 @@ func NewHandler(db *gorm.DB, logger *slog.Logger, googleValidator *GooglePlayVal
 	}
 }
 
+// UseGooglePushAuthenticator sets how Google Play webhook deliveries are
+// authenticated. Without one, Google webhooks are rejected.
+func (h *Handler) UseGooglePushAuthenticator(auth *GooglePushAuthenticator) {
+	h.googlePush = auth
+}
+
+// OnSubscriptionActivated registers a callback invoked after a purchase creates
+// a new subscription.
+func (h *Handler) OnSubscriptionActivated(fn func(sub subscription.Subscription)) {
+	h.activationHooks = append(h.activationHooks, fn)
+}
+
 // ValidatePurchase validates a purchase from Google Play or App Store and creates/extends subscription
 // POST /api/iap/validate
 func (h *Handler) ValidatePurchase(c *gin.Context) {
@@ -45,8 +62,7 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 	}
 
 	var req ValidatePurchaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Invalid request", err)
+	if !request.BindJSON(h.logger, c, &req, "Invalid request") {
 		return
 	}
 
@@ -86,91 +102,12 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 	}
 
 	// Validate purchase based on store
-	var purchaseDate time.Time
-	var expiryDate *time.Time
-	var autoRenewing bool
-	var orderID string
-	var transactionID string
-	var originalTransactionID string
-	var validationData string
-
-	switch req.Store {
-	case StoreGooglePlay:
-		if h.googleValidator == nil {
-			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Google Play validation not configured", nil)
-			return
-		}
-
-		googleSub, err := h.googleValidator.ValidateSubscription(c.Request.Context(), req.ProductID, req.PurchaseToken)
-		if err != nil {
-			h.logger.Error("Google Play validation failed", "error", err, "userId", user.ID)
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Invalid purchase token", err)
-			return
-		}
-
-		// Check if subscription is active
-		if !IsSubscriptionActive(googleSub) {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Subscription is not active", nil)
-			return
-		}
-
-		purchaseDate, _ = ParsePurchaseTime(googleSub.StartTimeMillis)
-		expiry, _ := ParsePurchaseTime(googleSub.ExpiryTimeMillis)
-		expiryDate = &expiry
-		autoRenewing = googleSub.AutoRenewing
-		orderID = googleSub.OrderID
-		originalTransactionID = req.PurchaseToken // For Google, purchase token stays constant
-
-		// Acknowledge the subscription if not already acknowledged
-		if googleSub.AcknowledgementState == 0 {
-			if err := h.googleValidator.AcknowledgeSubscription(c.Request.Context(), req.ProductID, req.PurchaseToken); err != nil {
-				h.logger.Warn("Failed to acknowledge Google subscription", "error", err)
-			}
-		}
-
-		validationBytes, _ := json.Marshal(googleSub)
-		validationData = string(validationBytes)
-
-	case StoreAppStore:
-		if h.appleValidator == nil {
-			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "App Store validation not configured", nil)
-			return
-		}
-
-		appleResponse, err := h.appleValidator.ValidateReceipt(c.Request.Context(), req.PurchaseToken)
-		if err != nil {
-			h.logger.Error("App Store validation failed", "error", err, "userId", user.ID)
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Invalid receipt data", err)
-			return
-		}
-
-		// Get latest subscription info
-		latestInfo, err := h.appleValidator.GetLatestSubscriptionInfo(appleResponse, req.ProductID)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Product not found in receipt", err)
-			return
-		}
-
-		// Check if subscription is active
-		if !IsAppleSubscriptionActive(latestInfo) {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Subscription is not active", nil)
-			return
-		}
-
-		purchaseDate, _ = ParseAppleTime(latestInfo.PurchaseDateMS)
-		expiry, _ := ParseAppleTime(latestInfo.ExpiresDateMS)
-		expiryDate = &expiry
-		autoRenewing = IsAutoRenewing(appleResponse, latestInfo.OriginalTransactionID)
-		transactionID = latestInfo.TransactionID
-		originalTransactionID = latestInfo.OriginalTransactionID // This stays constant across renewals
-
-		validationBytes, _ := json.Marshal(appleResponse)
-		validationData = string(validationBytes)
-
-	default:
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Invalid store type", nil)
+	verified, err := h.verifyWithStore(c.Request.Context(), req.Store, req.ProductID, req.PurchaseToken, req.TransactionID)
+	if err != nil {
+		h.respondStoreError(c, err)
 		return
 	}
+	expiryDate := verified.ExpiryDate
 
 	// Create or update subscription
 	var sub subscription.Subscription
@@ -183,8 +120,7 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 
 		// Update expiry if needed
 		if expiryDate != nil && expiryDate.After(sub.SubscriptionEnd) {
-			sub.SubscriptionEnd = *expiryDate
-			if err := h.db.Save(&sub).Error; err != nil {
+			if _, err := subscription.Renew(h.db, sub.ID, *expiryDate, subscription.SourceIAP); err != nil {
 				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to update subscription", err)
 				return
 			}
@@ -203,8 +139,11 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 			AssistantsLimit:        pkg.AssistantsLimit,
 			WatchLimit:             pkg.WatchLimit,
 			WatchInterval:          pkg.WatchInterval,
+			RateLimitPerMinute:     pkg.RateLimitPerMinute,
 			SubscriptionEnd:        expiryDate,
 			Active:                 &activeTrue,
+			PackageID:              &pkg.ID,
+			Source:                 subscription.SourceIAP,
 		}
 
 		newSub, err := subscription.Create(h.db, createInput)
@@ -218,6 +157,10 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 		if err := h.db.Model(&middleware.User{}).Where("id = ?", user.ID).Update("subscription_id", sub.ID).Error; err != nil {
 			h.logger.Error("Failed to update user subscription", "error", err, "userId", user.ID)
 		}
+
+		for _, hook := range h.activationHooks {
+			hook(sub)
+		}
 	}
 
 	// Store purchase record
@@ -228,15 +171,15 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 		Store:                 req.Store,
 		ProductID:             req.ProductID,
 		PurchaseToken:         req.PurchaseToken,
-		TransactionID:         transactionID,
-		OriginalTransactionID: originalTransactionID,
-		OrderID:               orderID,
+		TransactionID:         verified.TransactionID,
+		OriginalTransactionID: verified.OriginalTransactionID,
+		OrderID:               verified.OrderID,
 		Status:                PurchaseStatusValidated,
-		PurchaseDate:          purchaseDate,
+		PurchaseDate:          verified.PurchaseDate,
 		ExpiryDate:            expiryDate,
-		AutoRenewing:          autoRenewing,
+		AutoRenewing:          verified.AutoRenewing,
 		OriginalReceipt:       req.PurchaseToken,
-		ValidationData:        validationData,
+		ValidationData:        verified.ValidationData,
 		WebhookProcessed:      false,
 	}
 
@@ -251,13 +194,59 @@ func (h *Handler) ValidatePurchase(c *gin.Context) {
 		PurchaseID:     purchase.ID,
 		SubscriptionID: sub.ID,
 		ExpiryDate:     expiryDate,
-		AutoRenewing:   autoRenewing,
+		AutoRenewing:   verified.AutoRenewing,
 		Message:        "Purchase validated successfully",
 	}
 
+	if req.CouponCode != "" {
+		resp.Coupon = h.redeemCoupon(req.CouponCode, user.ID, pkg.ID, purchase.ID)
+	}
+
 	response.Success(c, http.StatusOK, resp, "", nil)
 }
 
+// redeemCoupon records a coupon used with a store purchase. The store has
+// already charged the user, so a rejected coupon is logged instead of failing
+// the validation.
+func (h *Handler) redeemCoupon(code string, userID, packageID, purchaseID uuid.UUID) *coupon.Quote {
+	amount, err := coupon.PackagePrice(h.db, packageID)
+	if err != nil {
+		h.logger.Warn("Failed to price package for coupon", "error", err, "packageId", packageID)
+		return nil
+	}
+
+	var quote coupon.Quote
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		redeemed, err := coupon.Redeem(tx, coupon.RedeemInput{
+			ApplyInput: coupon.ApplyInput{
+				Code:      code,
+				UserID:    userID,
+				PackageID: &packageID,
+				Amount:    amount,
+			},
+			PurchaseID: &purchaseID,
+		}, time.Now().UTC())
+		quote = redeemed
+		return err
+	})
+	if err != nil {
+		h.logger.Warn("Coupon not applied to purchase", "error", err, "code", code, "purchaseId", purchaseID)
+		return nil
+	}
+
+	return &quote
+}
+
+// respondStoreError maps a failed store verification to its HTTP response.
+func (h *Handler) respondStoreError(c *gin.Context, err error) {
+	var storeErr *storeError
+	if errors.As(err, &storeErr) {
+		response.ErrorWithLog(h.logger, c, storeErr.status, storeErr.message, storeErr.err)
+		return
+	}
+	response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to validate purchase", err)
+}
+
 // handleError is a helper to log and respond with errors
 func (h *Handler) handleError(c *gin.Context, status int, message string, err error) {
 	if err != nil {
@@ -4,6 +4,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/coupon"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 )
 
 // Store represents the purchase platform
@@ -53,6 +56,11 @@ func (Purchase) TableName() string {
 	return "iap_purchases"
 }
 
+// CursorKey returns the keyset position of the row for cursor pagination.
+func (p Purchase) CursorKey() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
 // ValidatePurchaseRequest is the request to validate a purchase
 type ValidatePurchaseRequest struct {
 	Store         Store  `json:"store" binding:"required"`
@@ -60,15 +68,37 @@ type ValidatePurchaseRequest struct {
 	ProductID     string `json:"productId" binding:"required"`
 	PurchaseToken string `json:"purchaseToken" binding:"required"` // Android: purchase token, iOS: receipt data
 	TransactionID string `json:"transactionId"`                    // iOS transaction ID
+	CouponCode    string `json:"couponCode"`                       // Optional promotion redeemed with the purchase
 }
 
 // ValidatePurchaseResponse is returned after successful validation
 type ValidatePurchaseResponse struct {
+	Success        bool          `json:"success"`
+	PurchaseID     uuid.UUID     `json:"purchaseId"`
+	SubscriptionID uuid.UUID     `json:"subscriptionId"`
+	ExpiryDate     *time.Time    `json:"expiryDate,omitempty"`
+	AutoRenewing   bool          `json:"autoRenewing"`
+	Coupon         *coupon.Quote `json:"coupon,omitempty"`
+	Message        string        `json:"message"`
+}
+
+// RestorePurchaseRequest re-validates an existing purchase for the current user
+type RestorePurchaseRequest struct {
+	Store         Store  `json:"store" binding:"required"`
+	ProductID     string `json:"productId" binding:"required"`
+	PurchaseToken string `json:"purchaseToken" binding:"required"` // Android: purchase token, iOS: receipt data
+	TransactionID string `json:"transactionId"`                    // iOS transaction ID
+}
+
+// RestorePurchaseResponse is returned after a purchase is restored
+type RestorePurchaseResponse struct {
 	Success        bool       `json:"success"`
 	PurchaseID     uuid.UUID  `json:"purchaseId"`
 	SubscriptionID uuid.UUID  `json:"subscriptionId"`
 	ExpiryDate     *time.Time `json:"expiryDate,omitempty"`
 	AutoRenewing   bool       `json:"autoRenewing"`
+	Transferred    bool       `json:"transferred"` // Subscription moved from another account
+	RestoredAt     time.Time  `json:"restoredAt"`
 	Message        string     `json:"message"`
 }
 
@@ -179,6 +209,20 @@ type ApplePendingRenewal struct {
 	OfferCodeRefName          string `json:"offer_code_ref_name,omitempty"`
 }
 
+// PubSubPushRequest is the envelope Cloud Pub/Sub posts to push endpoints
+type PubSubPushRequest struct {
+	Message      PubSubMessage `json:"message"`
+	Subscription string        `json:"subscription"`
+}
+
+// PubSubMessage carries a base64-encoded Real-time Developer Notification
+type PubSubMessage struct {
+	Data        string            `json:"data"`
+	MessageID   string            `json:"messageId"`
+	PublishTime string            `json:"publishTime"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+}
+
 // GooglePlayWebhookNotification represents a Google Play Real-time Developer Notification
 type GooglePlayWebhookNotification struct {
 	Version                    string                            `json:"version"`
@@ -210,6 +254,11 @@ type GoogleTestNotification struct {
 	Version string `json:"version"`
 }
 
+// AppleSignedNotification is the body Apple posts for App Store Server Notifications V2
+type AppleSignedNotification struct {
+	SignedPayload string `json:"signedPayload"`
+}
+
 // AppleServerNotification represents App Store Server Notification V2
 type AppleServerNotification struct {
 	NotificationType string                `json:"notificationType"`
@@ -230,12 +279,39 @@ type AppleNotificationData struct {
 	SignedTransactionInfo string `json:"signedTransactionInfo,omitempty"`
 }
 
+// AppleTransactionInfo is the decoded payload of a signed App Store transaction
+type AppleTransactionInfo struct {
+	TransactionID         string `json:"transactionId"`
+	OriginalTransactionID string `json:"originalTransactionId"`
+	BundleID              string `json:"bundleId"`
+	ProductID             string `json:"productId"`
+	PurchaseDate          int64  `json:"purchaseDate"` // milliseconds
+	ExpiresDate           int64  `json:"expiresDate,omitempty"`
+	RevocationDate        int64  `json:"revocationDate,omitempty"`
+	RevocationReason      *int   `json:"revocationReason,omitempty"`
+	Type                  string `json:"type"`
+	Environment           string `json:"environment"`
+	SignedDate            int64  `json:"signedDate"`
+}
+
+// AppleRenewalInfo is the decoded payload of signed App Store renewal info
+type AppleRenewalInfo struct {
+	OriginalTransactionID  string `json:"originalTransactionId"`
+	AutoRenewProductID     string `json:"autoRenewProductId"`
+	AutoRenewStatus        int    `json:"autoRenewStatus"` // 0=Off, 1=On
+	IsInBillingRetryPeriod bool   `json:"isInBillingRetryPeriod,omitempty"`
+	GracePeriodExpiresDate int64  `json:"gracePeriodExpiresDate,omitempty"`
+	Environment            string `json:"environment"`
+	SignedDate             int64  `json:"signedDate"`
+}
+
 // WebhookEvent represents a processed webhook event
 type WebhookEvent struct {
 	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	Store        Store      `gorm:"type:varchar(20);not null;index" json:"store"`
 	EventType    string     `gorm:"type:varchar(100);not null" json:"eventType"`
 	PurchaseID   *uuid.UUID `gorm:"type:uuid;index" json:"purchaseId,omitempty"`
+	MessageID    *string    `gorm:"type:varchar(255);uniqueIndex:idx_iap_webhook_events_message,where:message_id IS NOT NULL" json:"messageId,omitempty"` // Pub/Sub message ID, used to drop redeliveries
 	Payload      string     `gorm:"type:jsonb;not null" json:"-"`
 	ProcessedAt  *time.Time `json:"processedAt,omitempty"`
 	Success      bool       `gorm:"default:false" json:"success"`
@@ -247,3 +323,8 @@ type WebhookEvent struct {
 func (WebhookEvent) TableName() string {
 	return "iap_webhook_events"
 }
+
+// CursorKey returns the keyset position of the row for cursor pagination.
+func (w WebhookEvent) CursorKey() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: w.CreatedAt, ID: w.ID}
+}
@@ -0,0 +1,148 @@
+package iap
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	errRestoreNotFound        = errors.New("no purchase to restore")
+	errRestoreNoSubscription  = errors.New("purchase has no subscription")
+	errRestoreHasSubscription = errors.New("account already has a different subscription")
+)
+
+var restoreErrors = response.Catalog{
+	{Err: errRestoreNotFound, Status: http.StatusNotFound, Code: "PURCHASE_NOT_FOUND", Message: "No purchase found to restore"},
+	{Err: errRestoreNoSubscription, Status: http.StatusConflict, Code: "PURCHASE_NOT_LINKED", Message: "Purchase is not linked to a subscription"},
+	{Err: errRestoreHasSubscription, Status: http.StatusConflict, Code: "USER_HAS_SUBSCRIPTION", Message: "Account already has a different subscription"},
+}
+
+// RestorePurchase re-validates a store purchase and re-links its subscription
+// to the requesting user, e.g. after reinstalling or switching devices.
+// POST /api/iap/restore
+func (h *Handler) RestorePurchase(c *gin.Context) {
+	user, ok := middleware.GetUserFromContext(c)
+	if !ok || user == nil {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req RestorePurchaseRequest
+	if !request.BindJSON(h.logger, c, &req, "Invalid request") {
+		return
+	}
+
+	verified, err := h.verifyWithStore(c.Request.Context(), req.Store, req.ProductID, req.PurchaseToken, req.TransactionID)
+	if err != nil {
+		h.respondStoreError(c, err)
+		return
+	}
+
+	var (
+		purchase    Purchase
+		sub         subscription.Subscription
+		transferred bool
+	)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("store = ? AND original_transaction_id = ?", req.Store, verified.OriginalTransactionID).
+			Order("created_at DESC").
+			First(&purchase).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errRestoreNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if purchase.SubscriptionID == nil {
+			return errRestoreNoSubscription
+		}
+
+		if err := tx.First(&sub, "id = ?", purchase.SubscriptionID).Error; err != nil {
+			return err
+		}
+
+		if user.SubscriptionID != nil && *user.SubscriptionID != sub.ID {
+			return errRestoreHasSubscription
+		}
+
+		// Reconcile every row of this purchase (renewals share the original transaction)
+		if err := tx.Model(&Purchase{}).
+			Where("store = ? AND original_transaction_id = ?", req.Store, verified.OriginalTransactionID).
+			Update("user_id", user.ID).Error; err != nil {
+			return err
+		}
+
+		purchase.UserID = user.ID
+		purchase.Status = PurchaseStatusValidated
+		purchase.ExpiryDate = verified.ExpiryDate
+		purchase.AutoRenewing = verified.AutoRenewing
+		purchase.ValidationData = verified.ValidationData
+		if verified.TransactionID != "" {
+			purchase.TransactionID = verified.TransactionID
+		}
+		if err := tx.Save(&purchase).Error; err != nil {
+			return err
+		}
+
+		if sub.UserID != user.ID {
+			// Detach the previous owner before handing the subscription over
+			if err := tx.Model(&middleware.User{}).
+				Where("id = ? AND subscription_id = ?", sub.UserID, sub.ID).
+				Update("subscription_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&sub).Update("user_id", user.ID).Error; err != nil {
+				return err
+			}
+			transferred = true
+		}
+
+		if err := tx.Model(&middleware.User{}).Where("id = ?", user.ID).Update("subscription_id", sub.ID).Error; err != nil {
+			return err
+		}
+
+		renewed := false
+		if verified.ExpiryDate != nil {
+			if renewed, err = subscription.Renew(tx, sub.ID, *verified.ExpiryDate, subscription.SourceIAP); err != nil {
+				return err
+			}
+		}
+		if !renewed {
+			_, err = subscription.Activate(tx, sub.ID, subscription.SourceIAP)
+		}
+		return err
+	})
+
+	if err != nil {
+		restoreErrors.Respond(h.logger, c, err, "Failed to restore purchase")
+		return
+	}
+
+	if transferred {
+		h.logger.Info("IAP subscription moved to new account", "purchaseId", purchase.ID, "subscriptionId", sub.ID, "userId", user.ID)
+	}
+
+	resp := RestorePurchaseResponse{
+		Success:        true,
+		PurchaseID:     purchase.ID,
+		SubscriptionID: sub.ID,
+		ExpiryDate:     verified.ExpiryDate,
+		AutoRenewing:   verified.AutoRenewing,
+		Transferred:    transferred,
+		RestoredAt:     time.Now().UTC(),
+		Message:        "Purchase restored successfully",
+	}
+
+	response.Success(c, http.StatusOK, resp, "", nil)
+}
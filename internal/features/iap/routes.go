@@ -2,19 +2,41 @@ package iap
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
-// RegisterRoutes attaches IAP endpoints to the router
-func RegisterRoutes(api *gin.RouterGroup, handler *Handler, authenticated []gin.HandlerFunc) {
+// RegisterRoutes attaches IAP endpoints to the router. Purchases accept an
+// Idempotency-Key through idempotent, so retries never validate twice.
+func RegisterRoutes(api *gin.RouterGroup, handler *Handler, authenticated, superadminOnly []gin.HandlerFunc, idempotent gin.HandlerFunc) {
 	iap := api.Group("/iap")
 
 	// Purchase validation (requires authentication)
-	iap.POST("/validate", append(authenticated, handler.ValidatePurchase)...)
+	iap.POST("/validate", append(authenticated, idempotent, handler.ValidatePurchase)...)
+	iap.POST("/restore", append(authenticated, idempotent, handler.RestorePurchase)...)
 
-	// Webhook endpoints (no authentication - verified by store signatures in production)
+	// Support console for billing disputes
+	admin := iap.Group("/admin/purchases")
+	{
+		admin.GET("", append(superadminOnly, handler.AdminListPurchases)...)
+		admin.GET("/:purchaseId", append(superadminOnly, handler.AdminGetPurchase)...)
+		admin.GET("/:purchaseId/events", append(superadminOnly, handler.AdminPurchaseEvents)...)
+		admin.POST("/:purchaseId/revalidate", append(superadminOnly, handler.AdminRevalidatePurchase)...)
+		admin.POST("/:purchaseId/refund", append(superadminOnly, handler.AdminRefundPurchase)...)
+	}
+
+	// Webhook endpoints (no authentication - verified by store signatures)
 	webhooks := iap.Group("/webhooks")
 	{
-		webhooks.POST("/google", handler.GoogleWebhook)
+		webhooks.POST("/google/:token", handler.GoogleWebhook)
 		webhooks.POST("/apple", handler.AppleWebhook)
 	}
+
+	openapi.Describe(handler.ValidatePurchase, openapi.Spec{Request: ValidatePurchaseRequest{}, Response: ValidatePurchaseResponse{}})
+	openapi.Describe(handler.RestorePurchase, openapi.Spec{Request: RestorePurchaseRequest{}, Response: RestorePurchaseResponse{}})
+	openapi.Describe(handler.AdminListPurchases, openapi.Spec{Response: []Purchase{}})
+	openapi.Describe(handler.AdminGetPurchase, openapi.Spec{Response: Purchase{}})
+	openapi.Describe(handler.AdminPurchaseEvents, openapi.Spec{Response: []WebhookEvent{}})
+	openapi.Describe(handler.AdminRevalidatePurchase, openapi.Spec{Response: Purchase{}})
+	openapi.Describe(handler.AdminRefundPurchase, openapi.Spec{Request: adminRefundPurchaseRequest{}, Response: Purchase{}})
 }
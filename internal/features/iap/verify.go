@@ -0,0 +1,150 @@
+package iap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// storeVerification is the store's authoritative view of a purchase.
+type storeVerification struct {
+	PurchaseDate          time.Time
+	ExpiryDate            *time.Time
+	AutoRenewing          bool
+	OrderID               string
+	TransactionID         string
+	OriginalTransactionID string // Apple: stays same across renewals, Google: the purchase token
+	ValidationData        string
+}
+
+// storeError carries the HTTP status and client message for a failed store check.
+type storeError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *storeError) Error() string {
+	if e.err != nil {
+		return e.message + ": " + e.err.Error()
+	}
+	return e.message
+}
+
+func (e *storeError) Unwrap() error {
+	return e.err
+}
+
+// verifyWithStore re-validates a purchase token/receipt against Google Play or
+// the App Store and requires the subscription to be active.
+func (h *Handler) verifyWithStore(ctx context.Context, store Store, productID, purchaseToken, transactionID string) (*storeVerification, error) {
+	switch store {
+	case StoreGooglePlay:
+		return h.verifyWithGoogle(ctx, productID, purchaseToken)
+	case StoreAppStore:
+		return h.verifyWithApple(ctx, productID, purchaseToken, transactionID)
+	default:
+		return nil, &storeError{status: http.StatusBadRequest, message: "Invalid store type"}
+	}
+}
+
+func (h *Handler) verifyWithGoogle(ctx context.Context, productID, purchaseToken string) (*storeVerification, error) {
+	if h.googleValidator == nil {
+		return nil, &storeError{status: http.StatusInternalServerError, message: "Google Play validation not configured"}
+	}
+
+	googleSub, err := h.googleValidator.ValidateSubscription(ctx, productID, purchaseToken)
+	if err != nil {
+		return nil, &storeError{status: http.StatusBadRequest, message: "Invalid purchase token", err: err}
+	}
+
+	// Check if subscription is active
+	if !IsSubscriptionActive(googleSub) {
+		return nil, &storeError{status: http.StatusBadRequest, message: "Subscription is not active"}
+	}
+
+	result := &storeVerification{
+		AutoRenewing:          googleSub.AutoRenewing,
+		OrderID:               googleSub.OrderID,
+		OriginalTransactionID: purchaseToken, // For Google, purchase token stays constant
+	}
+	result.PurchaseDate, _ = ParsePurchaseTime(googleSub.StartTimeMillis)
+	expiry, _ := ParsePurchaseTime(googleSub.ExpiryTimeMillis)
+	result.ExpiryDate = &expiry
+
+	// Acknowledge the subscription if not already acknowledged
+	if googleSub.AcknowledgementState == 0 {
+		if err := h.googleValidator.AcknowledgeSubscription(ctx, productID, purchaseToken); err != nil {
+			h.logger.Warn("Failed to acknowledge Google subscription", "error", err)
+		}
+	}
+
+	validationBytes, _ := json.Marshal(googleSub)
+	result.ValidationData = string(validationBytes)
+
+	return result, nil
+}
+
+func (h *Handler) verifyWithApple(ctx context.Context, productID, receiptData, transactionID string) (*storeVerification, error) {
+	if h.appleValidator == nil {
+		return nil, &storeError{status: http.StatusInternalServerError, message: "App Store validation not configured"}
+	}
+
+	// StoreKit 2 clients send the transaction ID; look it up through the App Store Server API
+	if transactionID != "" && h.appleValidator.HasServerAPI() {
+		info, err := h.appleValidator.LookupTransaction(ctx, transactionID)
+		if err != nil {
+			return nil, &storeError{status: http.StatusBadRequest, message: "Invalid transaction", err: err}
+		}
+
+		if info.ProductID != productID {
+			return nil, &storeError{status: http.StatusBadRequest, message: "Transaction is for a different product"}
+		}
+
+		if info.RevocationDate != 0 || info.ExpiresDate == 0 || !time.Now().Before(time.UnixMilli(info.ExpiresDate)) {
+			return nil, &storeError{status: http.StatusBadRequest, message: "Subscription is not active"}
+		}
+
+		expiry := time.UnixMilli(info.ExpiresDate)
+		validationBytes, _ := json.Marshal(info)
+		return &storeVerification{
+			PurchaseDate:          time.UnixMilli(info.PurchaseDate),
+			ExpiryDate:            &expiry,
+			AutoRenewing:          true, // Renewal state is kept current by server notifications
+			TransactionID:         info.TransactionID,
+			OriginalTransactionID: info.OriginalTransactionID,
+			ValidationData:        string(validationBytes),
+		}, nil
+	}
+
+	appleResponse, err := h.appleValidator.ValidateReceipt(ctx, receiptData)
+	if err != nil {
+		return nil, &storeError{status: http.StatusBadRequest, message: "Invalid receipt data", err: err}
+	}
+
+	// Get latest subscription info
+	latestInfo, err := h.appleValidator.GetLatestSubscriptionInfo(appleResponse, productID)
+	if err != nil {
+		return nil, &storeError{status: http.StatusBadRequest, message: "Product not found in receipt", err: err}
+	}
+
+	// Check if subscription is active
+	if !IsAppleSubscriptionActive(latestInfo) {
+		return nil, &storeError{status: http.StatusBadRequest, message: "Subscription is not active"}
+	}
+
+	result := &storeVerification{
+		AutoRenewing:          IsAutoRenewing(appleResponse, latestInfo.OriginalTransactionID),
+		TransactionID:         latestInfo.TransactionID,
+		OriginalTransactionID: latestInfo.OriginalTransactionID, // This stays constant across renewals
+	}
+	result.PurchaseDate, _ = ParseAppleTime(latestInfo.PurchaseDateMS)
+	expiry, _ := ParseAppleTime(latestInfo.ExpiresDateMS)
+	result.ExpiryDate = &expiry
+
+	validationBytes, _ := json.Marshal(appleResponse)
+	result.ValidationData = string(validationBytes)
+
+	return result, nil
+}
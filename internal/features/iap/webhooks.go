@@ -4,20 +4,35 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 )
 
 // GoogleWebhook handles Google Play Real-time Developer Notifications
-// POST /api/iap/webhooks/google
+// POST /api/iap/webhooks/google/:token
 func (h *Handler) GoogleWebhook(c *gin.Context) {
+	if h.googlePush == nil {
+		h.logger.Error("Rejected Google webhook: push authentication not configured")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Push authentication not configured"})
+		return
+	}
+
+	if err := h.googlePush.Authenticate(c.Request.Context(), c.Param("token"), c.GetHeader("Authorization")); err != nil {
+		h.logger.Warn("Rejected unauthenticated Google webhook", "error", err, "ip", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		h.logger.Error("Failed to read Google webhook body", "error", err)
@@ -25,23 +40,49 @@ func (h *Handler) GoogleWebhook(c *gin.Context) {
 		return
 	}
 
-	var notification GooglePlayWebhookNotification
-	if err := json.Unmarshal(body, &notification); err != nil {
+	var push PubSubPushRequest
+	if err := json.Unmarshal(body, &push); err != nil || push.Message.MessageID == "" {
 		h.logger.Error("Failed to parse Google webhook", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
 		return
 	}
 
-	// Log webhook event
+	data, err := base64.StdEncoding.DecodeString(push.Message.Data)
+	if err != nil {
+		h.logger.Error("Failed to decode Google webhook data", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message data"})
+		return
+	}
+
+	var notification GooglePlayWebhookNotification
+	if err := json.Unmarshal(data, &notification); err != nil {
+		h.logger.Error("Failed to parse Google notification", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if h.googleValidator != nil && notification.PackageName != h.googleValidator.packageName {
+		h.logger.Warn("Rejected Google webhook for another package", "package", notification.PackageName)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown package"})
+		return
+	}
+
+	// Log webhook event; the unique message ID turns Pub/Sub redeliveries into no-ops
 	webhookEvent := WebhookEvent{
 		Store:     StoreGooglePlay,
 		EventType: fmt.Sprintf("notification_type_%d", getGoogleNotificationType(notification)),
-		Payload:   string(body),
+		MessageID: &push.Message.MessageID,
+		Payload:   string(data),
 		Success:   false,
 	}
 
-	if err := h.db.Create(&webhookEvent).Error; err != nil {
-		h.logger.Error("Failed to store webhook event", "error", err)
+	result := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&webhookEvent)
+	if result.Error != nil {
+		h.logger.Error("Failed to store webhook event", "error", result.Error)
+	} else if result.RowsAffected == 0 {
+		h.logger.Info("Ignoring redelivered Google webhook", "messageId", push.Message.MessageID)
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
 	}
 
 	// Handle test notification
@@ -125,9 +166,7 @@ func (h *Handler) handleGoogleSubscriptionNotification(notif *GoogleSubscription
 
 				// Update subscription end date
 				if purchase.SubscriptionID != nil {
-					h.db.Model(&subscription.Subscription{}).
-						Where("id = ?", purchase.SubscriptionID).
-						Update("subscription_end", expiryTime)
+					h.renewSubscription(*purchase.SubscriptionID, expiryTime)
 				}
 
 				h.db.Save(&purchase)
@@ -150,9 +189,7 @@ func (h *Handler) handleGoogleSubscriptionNotification(notif *GoogleSubscription
 
 		// Deactivate subscription if expired
 		if purchase.SubscriptionID != nil {
-			h.db.Model(&subscription.Subscription{}).
-				Where("id = ?", purchase.SubscriptionID).
-				Update("is_active", false)
+			h.expireSubscription(*purchase.SubscriptionID)
 		}
 		// Subscription expired	case 12: // SUBSCRIPTION_REVOKED (refunded)
 		purchase.Status = PurchaseStatusRefunded
@@ -162,9 +199,7 @@ func (h *Handler) handleGoogleSubscriptionNotification(notif *GoogleSubscription
 
 		// Deactivate subscription immediately
 		if purchase.SubscriptionID != nil {
-			h.db.Model(&subscription.Subscription{}).
-				Where("id = ?", purchase.SubscriptionID).
-				Update("is_active", false)
+			h.expireSubscription(*purchase.SubscriptionID)
 		}
 		h.logger.Warn("Subscription refunded", "purchaseId", purchase.ID)
 	}
@@ -211,13 +246,30 @@ func (h *Handler) AppleWebhook(c *gin.Context) {
 		return
 	}
 
-	var notification AppleServerNotification
-	if err := json.Unmarshal(body, &notification); err != nil {
+	var envelope AppleSignedNotification
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.SignedPayload == "" {
 		h.logger.Error("Failed to parse Apple webhook", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
 		return
 	}
 
+	if h.appleValidator == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "App Store validation not configured"})
+		return
+	}
+
+	notification, transaction, renewal, err := h.appleValidator.VerifyNotification(envelope.SignedPayload)
+	if err != nil {
+		if errors.Is(err, ErrAppleVerificationUnavailable) {
+			h.logger.Error("Rejected Apple webhook: signature verification not configured")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Signature verification not configured"})
+			return
+		}
+		h.logger.Warn("Rejected Apple webhook with invalid signature", "error", err, "ip", c.ClientIP())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
+		return
+	}
+
 	// Log webhook event
 	webhookEvent := WebhookEvent{
 		Store:     StoreAppStore,
@@ -230,8 +282,18 @@ func (h *Handler) AppleWebhook(c *gin.Context) {
 		h.logger.Error("Failed to store webhook event", "error", err)
 	}
 
+	// Prefer the App Store's current view of the transaction when the Server API is configured
+	if h.appleValidator.HasServerAPI() {
+		latest, err := h.appleValidator.LookupTransaction(c.Request.Context(), transaction.TransactionID)
+		if err != nil {
+			h.logger.Warn("Failed to look up Apple transaction", "error", err, "transactionId", transaction.TransactionID)
+		} else {
+			transaction = latest
+		}
+	}
+
 	// Handle different notification types
-	if err := h.handleAppleNotification(&notification, &webhookEvent); err != nil {
+	if err := h.handleAppleNotification(notification, transaction, renewal, &webhookEvent); err != nil {
 		h.logger.Error("Failed to process Apple notification", "error", err, "type", notification.NotificationType)
 		webhookEvent.ErrorMessage = err.Error()
 		h.db.Save(&webhookEvent)
@@ -246,35 +308,22 @@ func (h *Handler) AppleWebhook(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
-func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event *WebhookEvent) error {
+func (h *Handler) handleAppleNotification(notif *AppleServerNotification, transaction *AppleTransactionInfo, renewal *AppleRenewalInfo, event *WebhookEvent) error {
 	// Apple notification types (v2):
 	// SUBSCRIBED, DID_RENEW, DID_CHANGE_RENEWAL_STATUS, DID_FAIL_TO_RENEW,
 	// EXPIRED, DID_CHANGE_RENEWAL_PREF, PRICE_INCREASE, REFUND, REVOKE, etc.
 
-	// Decode the JWT to get transaction details
-	if notif.Data.SignedTransactionInfo == "" {
-		return fmt.Errorf("missing signedTransactionInfo in notification")
-	}
-
-	transactionInfo, err := decodeAppleJWT(notif.Data.SignedTransactionInfo)
-	if err != nil {
-		h.logger.Error("Failed to decode Apple JWT", "error", err)
-		return fmt.Errorf("failed to decode transaction JWT: %w", err)
-	}
-
-	// Extract critical fields from JWT
-	originalTransactionID, _ := transactionInfo["originalTransactionId"].(string)
-	transactionID, _ := transactionInfo["transactionId"].(string)
-	_, _ = transactionInfo["productId"].(string) // Reserved for future use
-	expiresDateMs, _ := transactionInfo["expiresDate"].(float64)
+	originalTransactionID := transaction.OriginalTransactionID
+	transactionID := transaction.TransactionID
+	expiresDateMs := transaction.ExpiresDate
 
 	if originalTransactionID == "" {
-		return fmt.Errorf("missing originalTransactionId in JWT")
+		return fmt.Errorf("missing originalTransactionId in transaction")
 	}
 
 	// Find purchase by original_transaction_id
 	var purchase Purchase
-	err = h.db.Where("original_transaction_id = ? AND store = ?", originalTransactionID, StoreAppStore).
+	err := h.db.Where("original_transaction_id = ? AND store = ?", originalTransactionID, StoreAppStore).
 		First(&purchase).Error
 
 	if err != nil {
@@ -303,15 +352,13 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 
 		// Update expiry date from JWT
 		if expiresDateMs > 0 {
-			expiryTime := time.Unix(int64(expiresDateMs)/1000, 0)
+			expiryTime := time.UnixMilli(expiresDateMs)
 			purchase.ExpiryDate = &expiryTime
 			purchase.TransactionID = transactionID // Update to new transaction ID
 
 			// Update subscription end date
 			if purchase.SubscriptionID != nil {
-				h.db.Model(&subscription.Subscription{}).
-					Where("id = ?", purchase.SubscriptionID).
-					Update("subscription_end", expiryTime)
+				h.renewSubscription(*purchase.SubscriptionID, expiryTime)
 
 				h.logger.Info("Extended subscription",
 					"subscriptionId", purchase.SubscriptionID,
@@ -332,7 +379,9 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 
 	case "DID_CHANGE_RENEWAL_STATUS":
 		// User enabled/disabled auto-renewal
-		if notif.Subtype == "AUTO_RENEW_DISABLED" {
+		if renewal != nil {
+			purchase.AutoRenewing = renewal.AutoRenewStatus == 1
+		} else if notif.Subtype == "AUTO_RENEW_DISABLED" {
 			purchase.AutoRenewing = false
 		} else if notif.Subtype == "AUTO_RENEW_ENABLED" {
 			purchase.AutoRenewing = true
@@ -347,9 +396,7 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 
 		// Deactivate subscription
 		if purchase.SubscriptionID != nil {
-			h.db.Model(&subscription.Subscription{}).
-				Where("id = ?", purchase.SubscriptionID).
-				Update("is_active", false)
+			h.expireSubscription(*purchase.SubscriptionID)
 		}
 
 	case "REFUND":
@@ -361,9 +408,7 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 
 		// Deactivate subscription immediately
 		if purchase.SubscriptionID != nil {
-			h.db.Model(&subscription.Subscription{}).
-				Where("id = ?", purchase.SubscriptionID).
-				Update("is_active", false)
+			h.expireSubscription(*purchase.SubscriptionID)
 		}
 
 	case "REVOKE":
@@ -374,9 +419,7 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 		purchase.WebhookProcessed = true
 
 		if purchase.SubscriptionID != nil {
-			h.db.Model(&subscription.Subscription{}).
-				Where("id = ?", purchase.SubscriptionID).
-				Update("is_active", false)
+			h.expireSubscription(*purchase.SubscriptionID)
 		}
 
 	case "GRACE_PERIOD_EXPIRED":
@@ -387,9 +430,7 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 		purchase.WebhookProcessed = true
 
 		if purchase.SubscriptionID != nil {
-			h.db.Model(&subscription.Subscription{}).
-				Where("id = ?", purchase.SubscriptionID).
-				Update("is_active", false)
+			h.expireSubscription(*purchase.SubscriptionID)
 		}
 
 	default:
@@ -407,6 +448,20 @@ func (h *Handler) handleAppleNotification(notif *AppleServerNotification, event
 	return nil
 }
 
+// renewSubscription extends a store-backed subscription and publishes the renewal.
+func (h *Handler) renewSubscription(subscriptionID uuid.UUID, end time.Time) {
+	if _, err := subscription.Renew(h.db, subscriptionID, end, subscription.SourceIAP); err != nil {
+		h.logger.Error("Failed to extend subscription", "error", err, "subscriptionId", subscriptionID)
+	}
+}
+
+// expireSubscription deactivates a store-backed subscription and publishes the expiry.
+func (h *Handler) expireSubscription(subscriptionID uuid.UUID) {
+	if _, err := subscription.Expire(h.db, subscriptionID, subscription.SourceIAP); err != nil {
+		h.logger.Error("Failed to deactivate subscription", "error", err, "subscriptionId", subscriptionID)
+	}
+}
+
 func getGoogleNotificationType(notif GooglePlayWebhookNotification) int {
 	if notif.SubscriptionNotification != nil {
 		return notif.SubscriptionNotification.NotificationType
@@ -420,56 +475,3 @@ func getGoogleNotificationType(notif GooglePlayWebhookNotification) int {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
-
-// decodeAppleJWT decodes an Apple JWT without verification
-// Note: In production, you should verify the JWT signature using Apple's public keys
-// Apple provides their public keys at: https://api.storekit.itunes.apple.com/v1/verifyReceipt
-func decodeAppleJWT(tokenString string) (map[string]interface{}, error) {
-	// Split the JWT to get the payload (base64 encoded)
-	parts := splitJWT(tokenString)
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid JWT format: expected 3 parts, got %d", len(parts))
-	}
-
-	// Decode the payload (second part)
-	payload, err := base64DecodeSegment(parts[1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode JWT payload: %w", err)
-	}
-
-	// Parse JSON
-	var claims map[string]interface{}
-	if err := json.Unmarshal(payload, &claims); err != nil {
-		return nil, fmt.Errorf("failed to parse JWT claims: %w", err)
-	}
-
-	return claims, nil
-}
-
-// splitJWT splits a JWT into its three parts
-func splitJWT(token string) []string {
-	parts := make([]string, 0, 3)
-	start := 0
-	for i := 0; i < len(token); i++ {
-		if token[i] == '.' {
-			parts = append(parts, token[start:i])
-			start = i + 1
-		}
-	}
-	parts = append(parts, token[start:])
-	return parts
-}
-
-// base64DecodeSegment decodes a base64 URL-encoded segment
-func base64DecodeSegment(seg string) ([]byte, error) {
-	// Add padding if necessary
-	switch len(seg) % 4 {
-	case 2:
-		seg = seg + "=="
-	case 3:
-		seg = seg + "="
-	}
-
-	// Use URL encoding (which handles - and _ characters)
-	return base64.URLEncoding.DecodeString(seg)
-}
@@ -0,0 +1,22 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrKeyNotFound = errors.New("idempotency key not found")
+	ErrKeyTooLong  = errors.New("idempotency key is too long")
+	ErrKeyReused   = errors.New("idempotency key was used for a different request")
+	ErrInProgress  = errors.New("a request with this idempotency key is in progress")
+)
+
+// errorCatalog maps the errors of the middleware to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrKeyTooLong, Status: http.StatusBadRequest, Code: "IDEMPOTENCY_KEY_INVALID", Message: "Idempotency-Key must be at most 255 characters."},
+	{Err: ErrKeyReused, Status: http.StatusUnprocessableEntity, Code: "IDEMPOTENCY_KEY_REUSED", Message: "Idempotency-Key was already used for a different request."},
+	{Err: ErrInProgress, Status: http.StatusConflict, Code: "IDEMPOTENCY_KEY_IN_PROGRESS", Message: "A request with this Idempotency-Key is still being processed."},
+}
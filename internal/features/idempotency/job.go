@@ -0,0 +1,39 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PruneJob deletes idempotency keys whose responses are no longer replayed.
+type PruneJob struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewPruneJob constructs the idempotency key pruning job.
+func NewPruneJob(db *gorm.DB, logger *slog.Logger) *PruneJob {
+	return &PruneJob{db: db, logger: logger}
+}
+
+// Name returns the job name.
+func (j *PruneJob) Name() string {
+	return "idempotency-key-prune"
+}
+
+// Execute removes expired idempotency keys.
+func (j *PruneJob) Execute(ctx context.Context) error {
+	pruned, err := Prune(j.db.WithContext(ctx), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("prune idempotency keys: %w", err)
+	}
+
+	if pruned > 0 {
+		j.logger.Info("idempotency keys pruned", slog.Int64("count", pruned))
+	}
+	return nil
+}
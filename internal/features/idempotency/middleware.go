@@ -0,0 +1,176 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Header carries the key clients choose for a request they may retry.
+const Header = "Idempotency-Key"
+
+const (
+	maxKeyLength = 255
+	// keyTTL is how long a response is replayed to retries.
+	keyTTL = 24 * time.Hour
+	// staleAfter frees a key whose request never finished, such as when the
+	// process stopped while handling it.
+	staleAfter = 5 * time.Minute
+)
+
+// Service makes endpoints safe to retry: a request carrying an Idempotency-Key
+// the user already sent is answered with the stored response instead of
+// being handled again.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewService constructs the idempotency service.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	return &Service{db: db, logger: logger}
+}
+
+// Middleware handles a request with an Idempotency-Key once per user and key,
+// replaying its response to retries for 24 hours. Server errors are not
+// stored, so the request can be retried with the same key. A key reused with
+// a different request is rejected with 422, and one whose request is still
+// being handled with 409. Requests without the header pass through. Add it
+// after authentication.
+func (s *Service) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(Header))
+		usr, ok := middleware.GetUserFromContext(c)
+		if key == "" || !ok {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			errorCatalog.Respond(s.logger, c, ErrKeyTooLong, "")
+			c.Abort()
+			return
+		}
+
+		hash, err := requestHash(c)
+		if err != nil {
+			response.ErrorWithLog(s.logger, c, http.StatusBadRequest, "failed to read request body", err)
+			c.Abort()
+			return
+		}
+
+		now := time.Now().UTC()
+		db := s.db.WithContext(c.Request.Context())
+		claimed, err := Claim(db, Record{
+			UserID:      usr.ID,
+			Key:         key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(keyTTL),
+		}, now.Add(-staleAfter))
+		if err != nil {
+			response.ErrorWithLog(s.logger, c, http.StatusInternalServerError, "failed to claim idempotency key", err)
+			c.Abort()
+			return
+		}
+		if !claimed {
+			s.replay(c, db, Record{UserID: usr.ID, Key: key, RequestHash: hash})
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stored := false
+		defer func() {
+			// Panics and server errors leave the key free for a retry
+			if !stored {
+				if err := Release(s.db, usr.ID, key); err != nil {
+					s.logger.Error("failed to release idempotency key", "error", err, "userId", usr.ID)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		// A key left claimed answers retries with 409 until it goes stale,
+		// which is safer than handling the request twice
+		stored = true
+		if err := Complete(s.db, usr.ID, key, status, c.Writer.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			s.logger.Error("failed to store idempotent response", "error", err, "userId", usr.ID)
+		}
+	}
+}
+
+// replay answers a retry with the stored response.
+func (s *Service) replay(c *gin.Context, db *gorm.DB, request Record) {
+	record, err := Get(db, request.UserID, request.Key)
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		// Released by a failed request in the meantime; the client retries
+		errorCatalog.Respond(s.logger, c, ErrInProgress, "")
+		return
+	case err != nil:
+		response.ErrorWithLog(s.logger, c, http.StatusInternalServerError, "failed to load idempotency key", err)
+		return
+	case record.RequestHash != request.RequestHash:
+		errorCatalog.Respond(s.logger, c, ErrKeyReused, "")
+		return
+	case !record.Completed():
+		c.Header("Retry-After", "1")
+		errorCatalog.Respond(s.logger, c, ErrInProgress, "")
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+}
+
+// requestHash fingerprints the method, path and body of the request, leaving
+// the body in place for the handler.
+func requestHash(c *gin.Context) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// responseRecorder keeps a copy of the response body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
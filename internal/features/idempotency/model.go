@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Record is a request made with an Idempotency-Key and, once handled, the
+// response replayed to retries of it.
+type Record struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id" json:"userId"`
+	Key    string    `gorm:"type:varchar(255);primaryKey;column:key" json:"key"`
+	// RequestHash tells a retry from another request reusing the key.
+	RequestHash string `gorm:"type:char(64);not null;column:request_hash" json:"-"`
+	// Status is 0 while the request is being handled.
+	Status      int       `gorm:"not null;column:status" json:"status"`
+	ContentType string    `gorm:"type:varchar(255);not null;column:content_type" json:"-"`
+	Body        []byte    `gorm:"type:bytea;column:body" json:"-"`
+	CreatedAt   time.Time `gorm:"not null;column:created_at" json:"createdAt"`
+	ExpiresAt   time.Time `gorm:"not null;column:expires_at;index" json:"expiresAt"`
+}
+
+// TableName overrides the default table name.
+func (Record) TableName() string { return "idempotency_keys" }
+
+// Completed reports whether the response has been stored.
+func (r Record) Completed() bool {
+	return r.Status != 0
+}
+
+// Claim stores record unless its key is already in use, replacing an expired
+// record and one whose request was abandoned before staleAfter. It reports
+// whether the caller now handles the request.
+func Claim(db *gorm.DB, record Record, staleAfter time.Time) (bool, error) {
+	claimed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND key = ?", record.UserID, record.Key).
+			Where("expires_at < ? OR (status = 0 AND created_at < ?)", record.CreatedAt, staleAfter).
+			Delete(&Record{}).Error; err != nil {
+			return err
+		}
+		result := tx.Exec(`INSERT INTO idempotency_keys (user_id, key, request_hash, status, content_type, created_at, expires_at)
+			VALUES (?, ?, ?, 0, '', ?, ?) ON CONFLICT DO NOTHING`,
+			record.UserID, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected > 0
+		return nil
+	})
+	return claimed, err
+}
+
+// Get returns the record of a key.
+func Get(db *gorm.DB, userID uuid.UUID, key string) (Record, error) {
+	var record Record
+	err := db.Where("user_id = ? AND key = ?", userID, key).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Record{}, ErrKeyNotFound
+	}
+	return record, err
+}
+
+// Complete stores the response of a claimed key.
+func Complete(db *gorm.DB, userID uuid.UUID, key string, status int, contentType string, body []byte) error {
+	return db.Model(&Record{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Updates(map[string]interface{}{"status": status, "content_type": contentType, "body": body}).Error
+}
+
+// Release forgets a claimed key whose request failed, so it can be retried.
+func Release(db *gorm.DB, userID uuid.UUID, key string) error {
+	return db.Where("user_id = ? AND key = ? AND status = 0", userID, key).Delete(&Record{}).Error
+}
+
+// Prune deletes the records that expired before cutoff.
+func Prune(db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.Where("expires_at < ?", cutoff).Delete(&Record{})
+	return result.RowsAffected, result.Error
+}
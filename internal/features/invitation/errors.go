@@ -0,0 +1,41 @@
+package invitation
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationRevoked  = errors.New("invitation has been revoked")
+	ErrInvitationExpired  = errors.New("invitation has expired")
+	ErrInvitationFull     = errors.New("invitation has no seats left")
+	ErrGroupNotFound      = groupaccess.ErrGroupNotFound
+	ErrMaxUsesInvalid     = errors.New("max uses must be between 1 and 1000")
+	ErrExpiryInvalid      = errors.New("expiry must be in the future")
+	ErrStudentLimit       = errors.New("student limit reached for this subscription")
+	ErrGroupPointsLimit   = groupaccess.ErrPointsLimit
+	ErrEmailDomain        = errors.New("email must end with the subscription identifier")
+	ErrSubscriptionClosed = errors.New("subscription is inactive")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrInvitationNotFound, Status: http.StatusNotFound, Code: "INVITATION_NOT_FOUND", Message: "Invitation not found."},
+	{Err: ErrInvitationRevoked, Status: http.StatusGone, Code: "INVITATION_REVOKED", Message: "This invitation has been revoked."},
+	{Err: ErrInvitationExpired, Status: http.StatusGone, Code: "INVITATION_EXPIRED", Message: "This invitation has expired."},
+	{Err: ErrInvitationFull, Status: http.StatusGone, Code: "INVITATION_FULL", Message: "This invitation has no seats left."},
+	{Err: ErrGroupNotFound, Status: http.StatusNotFound, Code: "GROUP_NOT_FOUND", Message: "Group not found."},
+	{Err: ErrMaxUsesInvalid, Status: http.StatusBadRequest, Code: "INVITATION_MAX_USES_INVALID", Message: "Max uses must be between 1 and 1000."},
+	{Err: ErrExpiryInvalid, Status: http.StatusBadRequest, Code: "INVITATION_EXPIRY_INVALID", Message: "Expiry must be in the future."},
+	{Err: ErrStudentLimit, Status: http.StatusForbidden, Code: "STUDENT_LIMIT_REACHED", Message: "Student limit reached for this subscription."},
+	{Err: ErrGroupPointsLimit, Status: http.StatusForbidden, Code: "GROUP_POINTS_LIMIT", Message: "Subscription points limit exceeded."},
+	{Err: ErrEmailDomain, Status: http.StatusBadRequest, Code: "EMAIL_DOMAIN_NOT_ALLOWED", Message: "Email must end with the subscription identifier domain."},
+	{Err: ErrSubscriptionClosed, Status: http.StatusForbidden, Code: "SUBSCRIPTION_CLOSED", Message: "This subscription is not accepting new students."},
+	{Err: user.ErrEmailTaken, Status: http.StatusConflict, Code: "EMAIL_TAKEN", Message: "Email already exists."},
+	{Err: user.ErrInvalidPassword, Status: http.StatusBadRequest, Code: "INVALID_PASSWORD", Message: "Password must be at least 8 characters."},
+}
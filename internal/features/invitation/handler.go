@@ -0,0 +1,215 @@
+package invitation
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/auth"
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes invitation HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	cfg    *config.Config
+}
+
+// NewHandler constructs an invitation handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger, cfg *config.Config) *Handler {
+	return &Handler{db: db, logger: logger, cfg: cfg}
+}
+
+// List returns all invitations of a subscription.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	invitations, err := List(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list invitations", err)
+		return
+	}
+
+	for i := range invitations {
+		invitations[i].URL = h.inviteURL(invitations[i].Token)
+	}
+
+	response.Success(c, http.StatusOK, invitations, "", nil)
+}
+
+type createRequest struct {
+	GroupID   *string `json:"groupId"`
+	MaxUses   int     `json:"maxUses" binding:"required"`
+	ExpiresAt *string `json:"expiresAt"`
+}
+
+// Create generates a new invitation link.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	var req createRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid invitation payload") {
+		return
+	}
+
+	input := CreateInput{
+		SubscriptionID: subscriptionID,
+		CreatedBy:      requester.ID,
+		MaxUses:        req.MaxUses,
+	}
+
+	if req.GroupID != nil && *req.GroupID != "" {
+		groupID, err := uuid.Parse(*req.GroupID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid group id", err)
+			return
+		}
+		input.GroupID = &groupID
+	}
+
+	if req.ExpiresAt != nil && *req.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid expiresAt format", err)
+			return
+		}
+		input.ExpiresAt = &parsed
+	}
+
+	invitation, err := Create(h.db, input)
+	if err != nil {
+		h.respondError(c, err, "failed to create invitation")
+		return
+	}
+
+	invitation.URL = h.inviteURL(invitation.Token)
+	response.Created(c, invitation, "")
+}
+
+// Revoke disables an invitation link.
+func (h *Handler) Revoke(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid invitation id", err)
+		return
+	}
+
+	invitation, err := Revoke(h.db, subscriptionID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to revoke invitation")
+		return
+	}
+
+	response.Success(c, http.StatusOK, invitation, "Invitation revoked", nil)
+}
+
+// Preview returns public details of an invitation so the sign-up page can render it.
+func (h *Handler) Preview(c *gin.Context) {
+	invitation, err := GetByToken(h.db, c.Param("token"))
+	if err != nil {
+		h.respondError(c, err, "failed to load invitation")
+		return
+	}
+	if err := invitation.Usable(time.Now()); err != nil {
+		h.respondError(c, err, "invitation is not usable")
+		return
+	}
+
+	sub, err := subscription.Get(h.db, invitation.SubscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load subscription", err)
+		return
+	}
+
+	var groupName *string
+	if invitation.GroupID != nil {
+		var group groupaccess.GroupAccess
+		if err := h.db.Select("name").First(&group, "id = ?", *invitation.GroupID).Error; err == nil {
+			groupName = &group.Name
+		}
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"subscriptionName": sub.DisplayName,
+		"identifierName":   sub.IdentifierName,
+		"emailDomain":      "@" + sub.IdentifierName,
+		"groupName":        groupName,
+		"seatsRemaining":   invitation.MaxUses - invitation.UsedCount,
+		"expiresAt":        invitation.ExpiresAt,
+	}, "", nil)
+}
+
+type registerRequest struct {
+	FullName string  `json:"fullName" binding:"required"`
+	Email    string  `json:"email" binding:"required"`
+	Password string  `json:"password" binding:"required"`
+	Phone    *string `json:"phone"`
+}
+
+// Register creates a student account through an invitation and signs them in.
+func (h *Handler) Register(c *gin.Context) {
+	var req registerRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid registration payload") {
+		return
+	}
+
+	newUser, err := Redeem(h.db, c.Param("token"), RedeemInput{
+		FullName: req.FullName,
+		Email:    req.Email,
+		Password: req.Password,
+		Phone:    req.Phone,
+	})
+	if err != nil {
+		h.respondError(c, err, "registration failed")
+		return
+	}
+
+	authResp, err := auth.IssueTokens(h.db, newUser, auth.TokenConfigFrom(h.cfg))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to issue tokens", err)
+		return
+	}
+
+	response.Created(c, authResp, "Registration successful")
+}
+
+func (h *Handler) inviteURL(token string) string {
+	base := strings.TrimRight(h.cfg.Email.FrontendURL, "/")
+	return base + "/invite/" + token
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,239 @@
+package invitation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const maxInvitationUses = 1000
+
+// Invitation is a shareable self-registration link for students of a subscription.
+type Invitation struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	GroupID        *uuid.UUID `gorm:"type:uuid;column:group_id;index" json:"groupId,omitempty"`
+	Token          string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"token"`
+	CreatedBy      uuid.UUID  `gorm:"type:uuid;not null;column:created_by" json:"createdBy"`
+	MaxUses        int        `gorm:"type:int;not null;column:max_uses" json:"maxUses"`
+	UsedCount      int        `gorm:"type:int;not null;default:0;column:used_count" json:"usedCount"`
+	ExpiresAt      *time.Time `gorm:"type:timestamp;column:expires_at" json:"expiresAt,omitempty"`
+	RevokedAt      *time.Time `gorm:"type:timestamp;column:revoked_at" json:"revokedAt,omitempty"`
+
+	URL string `gorm:"-" json:"url,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Invitation) TableName() string { return "invitations" }
+
+// Usable reports why the invitation can no longer be redeemed, or nil when it can.
+func (i Invitation) Usable(now time.Time) error {
+	switch {
+	case i.RevokedAt != nil:
+		return ErrInvitationRevoked
+	case i.ExpiresAt != nil && !now.Before(*i.ExpiresAt):
+		return ErrInvitationExpired
+	case i.UsedCount >= i.MaxUses:
+		return ErrInvitationFull
+	}
+	return nil
+}
+
+// CreateInput carries data for a new invitation.
+type CreateInput struct {
+	SubscriptionID uuid.UUID
+	GroupID        *uuid.UUID
+	CreatedBy      uuid.UUID
+	MaxUses        int
+	ExpiresAt      *time.Time
+}
+
+// RedeemInput carries the student's registration details.
+type RedeemInput struct {
+	FullName string
+	Email    string
+	Password string
+	Phone    *string
+}
+
+// List returns a subscription's invitations, newest first.
+func List(db *gorm.DB, subscriptionID uuid.UUID) ([]Invitation, error) {
+	invitations := make([]Invitation, 0)
+	err := db.Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// GetByToken retrieves an invitation by its public token.
+func GetByToken(db *gorm.DB, token string) (Invitation, error) {
+	var invitation Invitation
+	if err := db.First(&invitation, "token = ?", token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return invitation, ErrInvitationNotFound
+		}
+		return invitation, err
+	}
+	return invitation, nil
+}
+
+// Create generates a new invitation token.
+func Create(db *gorm.DB, input CreateInput) (Invitation, error) {
+	if input.MaxUses < 1 || input.MaxUses > maxInvitationUses {
+		return Invitation{}, ErrMaxUsesInvalid
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return Invitation{}, ErrExpiryInvalid
+	}
+
+	if input.GroupID != nil {
+		var count int64
+		if err := db.Model(&groupaccess.GroupAccess{}).
+			Where("id = ? AND subscription_id = ?", *input.GroupID, input.SubscriptionID).
+			Count(&count).Error; err != nil {
+			return Invitation{}, err
+		}
+		if count == 0 {
+			return Invitation{}, ErrGroupNotFound
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	invitation := Invitation{
+		SubscriptionID: input.SubscriptionID,
+		GroupID:        input.GroupID,
+		Token:          token,
+		CreatedBy:      input.CreatedBy,
+		MaxUses:        input.MaxUses,
+		ExpiresAt:      input.ExpiresAt,
+	}
+
+	if err := db.Create(&invitation).Error; err != nil {
+		return Invitation{}, err
+	}
+
+	return invitation, nil
+}
+
+// Revoke disables an invitation so it can no longer be redeemed.
+func Revoke(db *gorm.DB, subscriptionID, id uuid.UUID) (Invitation, error) {
+	var invitation Invitation
+	if err := db.First(&invitation, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return invitation, ErrInvitationNotFound
+		}
+		return invitation, err
+	}
+
+	if invitation.RevokedAt == nil {
+		now := time.Now().UTC()
+		if err := db.Model(&invitation).Update("revoked_at", now).Error; err != nil {
+			return invitation, err
+		}
+		invitation.RevokedAt = &now
+	}
+
+	return invitation, nil
+}
+
+// Redeem registers a student through an invitation. Seat usage, the subscription's
+// student limit and the group's points budget are checked under row locks so
+// concurrent sign-ups cannot oversubscribe the invite.
+func Redeem(db *gorm.DB, token string, input RedeemInput) (user.User, error) {
+	var created user.User
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var invitation Invitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&invitation, "token = ?", token).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+		if err := invitation.Usable(time.Now()); err != nil {
+			return err
+		}
+
+		var sub subscription.Subscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&sub, "id = ?", invitation.SubscriptionID).Error; err != nil {
+			return err
+		}
+		if !sub.Active || sub.IsExpired(time.Now()) {
+			return ErrSubscriptionClosed
+		}
+
+		email := strings.ToLower(strings.TrimSpace(input.Email))
+		domain := "@" + strings.ToLower(sub.IdentifierName)
+		if email != "" && !strings.Contains(email, "@") {
+			email += domain
+		}
+		if !strings.HasSuffix(email, domain) || email == domain {
+			return ErrEmailDomain
+		}
+
+		if sub.SubscriptionPoints > 0 {
+			var students int64
+			if err := tx.Model(&user.User{}).
+				Where("subscription_id = ? AND user_type = ?", sub.ID, types.UserTypeStudent).
+				Count(&students).Error; err != nil {
+				return err
+			}
+			if students >= int64(sub.SubscriptionPoints) {
+				return ErrStudentLimit
+			}
+		}
+
+		newUser, err := user.Create(tx, user.CreateInput{
+			SubscriptionID: &sub.ID,
+			FullName:       input.FullName,
+			Email:          email,
+			Phone:          input.Phone,
+			Password:       input.Password,
+			UserType:       types.UserTypeStudent,
+		})
+		if err != nil {
+			return err
+		}
+
+		if invitation.GroupID != nil {
+			if err := groupaccess.AddUser(tx, sub, *invitation.GroupID, newUser.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&invitation).
+			Update("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+			return err
+		}
+
+		created = newUser
+		return nil
+	})
+
+	return created, err
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,26 @@
+package invitation
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/auth"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches invitation management and public sign-up endpoints to the router.
+// The public endpoints sit behind limited since they can be used to guess tokens.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdminInstructor, limited []gin.HandlerFunc) {
+	manage := router.Group("/subscriptions/:subscriptionId/invitations")
+	manage.GET("", append(acAdminInstructor, handler.List)...)
+	manage.POST("", append(acAdminInstructor, handler.Create)...)
+	manage.POST("/:invitationId/revoke", append(acAdminInstructor, handler.Revoke)...)
+
+	public := router.Group("/invitations", limited...)
+	public.GET("/:token", handler.Preview)
+	public.POST("/:token/register", handler.Register)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Invitation{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Invitation{}})
+	openapi.Describe(handler.Revoke, openapi.Spec{Response: Invitation{}})
+	openapi.Describe(handler.Register, openapi.Spec{Request: registerRequest{}, Response: auth.AuthResponse{}})
+}
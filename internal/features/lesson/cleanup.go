@@ -0,0 +1,45 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+)
+
+// CleanupDeleted returns the outbox subscriber that removes a deleted lesson's
+// videos and files from Bunny. Anything already gone counts as removed, so a
+// retried event only repeats the deletes that failed.
+func CleanupDeleted(streamClient *bunny.StreamClient, storageClient *bunny.StorageClient, logger *slog.Logger) outbox.Handler {
+	return func(ctx context.Context, event outbox.Event) error {
+		var deleted DeletedEvent
+		if err := event.Decode(&deleted); err != nil {
+			return err
+		}
+
+		var errs []error
+		for _, videoID := range deleted.VideoIDs {
+			if err := streamClient.DeleteVideo(ctx, videoID); err != nil && !errors.Is(err, bunny.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("delete video %s: %w", videoID, err))
+			}
+		}
+		for _, file := range deleted.Files {
+			path := storageClient.ExtractRelativePath(file)
+			if err := storageClient.DeleteFile(ctx, path); err != nil && !errors.Is(err, bunny.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("delete file %s: %w", path, err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+
+		logger.Info("cleaned up deleted lesson",
+			"lessonId", deleted.LessonID,
+			"videos", len(deleted.VideoIDs),
+			"files", len(deleted.Files))
+		return nil
+	}
+}
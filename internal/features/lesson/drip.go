@@ -0,0 +1,40 @@
+package lesson
+
+import "time"
+
+// ReleaseAt returns when the lesson opens for a student who enrolled at
+// enrolledAt, or nil when it has no schedule. With both a date and a day
+// offset set, the later of the two applies.
+func (l Lesson) ReleaseAt(enrolledAt time.Time) *time.Time {
+	var release *time.Time
+	if l.AvailableFrom != nil {
+		at := l.AvailableFrom.UTC()
+		release = &at
+	}
+	if l.AvailableAfterDaysEnrolled != nil && *l.AvailableAfterDaysEnrolled > 0 && !enrolledAt.IsZero() {
+		at := enrolledAt.UTC().AddDate(0, 0, *l.AvailableAfterDaysEnrolled)
+		if release == nil || at.After(*release) {
+			release = &at
+		}
+	}
+	return release
+}
+
+// ApplyDrip marks lessons that have not opened yet for the student as locked
+// and drops their attachments, so schedules cannot be bypassed through the
+// lesson payload. Lessons that are already open are left untouched.
+func ApplyDrip(lessons []Lesson, enrolledAt, now time.Time) {
+	for i := range lessons {
+		lessons[i].applyDrip(enrolledAt, now)
+	}
+}
+
+func (l *Lesson) applyDrip(enrolledAt, now time.Time) {
+	release := l.ReleaseAt(enrolledAt)
+	if release == nil || !release.After(now) {
+		return
+	}
+	l.Locked = true
+	l.AvailableAt = release
+	l.Attachments = nil
+}
@@ -1,17 +1,54 @@
 package lesson
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
-	ErrLessonNotFound     = errors.New("lesson not found")
-	ErrNameRequired       = errors.New("lesson name is required")
-	ErrNameLength         = errors.New("lesson name must be between 3 and 80 characters")
-	ErrVideoIDRequired    = errors.New("video ID is required")
-	ErrCourseNotFound     = errors.New("course not found")
-	ErrDescriptionTooLong = errors.New("lesson description cannot exceed 1000 characters")
-	ErrOrderInvalid       = errors.New("lesson order cannot be negative")
-	ErrDurationInvalid    = errors.New("lesson duration cannot be negative")
-	ErrVideoMismatch      = errors.New("video not found for this lesson")
-	ErrWatchLimitReached  = errors.New("watch limit reached for this lesson")
-	ErrJobIDRequired      = errors.New("job id is required")
+	ErrLessonNotFound        = errors.New("lesson not found")
+	ErrNameRequired          = errors.New("lesson name is required")
+	ErrNameLength            = errors.New("lesson name must be between 3 and 80 characters")
+	ErrVideoIDRequired       = errors.New("video ID is required")
+	ErrCourseNotFound        = errors.New("course not found")
+	ErrDescriptionTooLong    = errors.New("lesson description cannot exceed 1000 characters")
+	ErrOrderInvalid          = errors.New("lesson order cannot be negative")
+	ErrDurationInvalid       = errors.New("lesson duration cannot be negative")
+	ErrVideoMismatch         = errors.New("video not found for this lesson")
+	ErrWatchLimitReached     = errors.New("watch limit reached for this lesson")
+	ErrJobIDRequired         = errors.New("job id is required")
+	ErrAvailableAfterInvalid = errors.New("lesson release delay cannot be negative")
+	ErrNotYetAvailable       = errors.New("lesson is not available yet")
+	ErrPrerequisiteInvalid   = errors.New("prerequisites must be other lessons of the same course")
+	ErrPrerequisiteCycle     = errors.New("prerequisites cannot form a cycle")
+	ErrPrerequisitesMissing  = errors.New("lesson prerequisites are not completed")
+	ErrWatchLimitInvalid     = errors.New("lesson watch limit must be at least 1")
+	ErrWatchIntervalInvalid  = errors.New("lesson watch interval must be at least 1 minute")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrNameRequired, Status: http.StatusBadRequest, Code: "LESSON_NAME_REQUIRED", Message: "Lesson name is required."},
+	{Err: ErrNameLength, Status: http.StatusBadRequest, Code: "LESSON_NAME_LENGTH", Message: "Lesson name must be between 3 and 80 characters."},
+	{Err: ErrVideoIDRequired, Status: http.StatusBadRequest, Code: "VIDEO_ID_REQUIRED", Message: "Video ID is required."},
+	{Err: ErrDescriptionTooLong, Status: http.StatusBadRequest, Code: "LESSON_DESCRIPTION_TOO_LONG", Message: "Lesson description cannot exceed 1000 characters."},
+	{Err: ErrOrderInvalid, Status: http.StatusBadRequest, Code: "LESSON_ORDER_INVALID", Message: "Lesson order cannot be negative."},
+	{Err: ErrDurationInvalid, Status: http.StatusBadRequest, Code: "LESSON_DURATION_INVALID", Message: "Lesson duration cannot be negative."},
+	{Err: ErrAvailableAfterInvalid, Status: http.StatusBadRequest, Code: "LESSON_AVAILABLE_AFTER_INVALID", Message: "Lesson release delay cannot be negative."},
+	{Err: ErrPrerequisiteInvalid, Status: http.StatusBadRequest, Code: "PREREQUISITE_INVALID", Message: "Prerequisites must be other lessons of the same course."},
+	{Err: ErrPrerequisiteCycle, Status: http.StatusBadRequest, Code: "PREREQUISITE_CYCLE", Message: "Prerequisites cannot form a cycle."},
+	{Err: ErrWatchLimitInvalid, Status: http.StatusBadRequest, Code: "WATCH_LIMIT_INVALID", Message: "Lesson watch limit must be at least 1."},
+	{Err: ErrWatchIntervalInvalid, Status: http.StatusBadRequest, Code: "WATCH_INTERVAL_INVALID", Message: "Lesson watch interval must be at least 1 minute."},
+	{Err: publishing.ErrPublishForbidden, Status: http.StatusForbidden, Code: "PUBLISH_FORBIDDEN", Message: "Only instructors and admins can publish lessons."},
+	{Err: publishing.ErrInvalidTransition, Status: http.StatusConflict, Code: "INVALID_PUBLISH_TRANSITION", Message: "Lesson cannot make this transition from its current status."},
+	{Err: publishing.ErrInvalidStatus, Status: http.StatusBadRequest, Code: "INVALID_PUBLISH_STATUS", Message: "Status must be draft, in_review or published."},
+	{Err: ErrVideoMismatch, Status: http.StatusNotFound, Code: "VIDEO_NOT_FOUND", Message: "Video not found for this lesson."},
+	{Err: ErrNotYetAvailable, Status: http.StatusForbidden, Code: "LESSON_NOT_AVAILABLE_YET", Message: "This lesson is not available yet."},
+	{Err: ErrPrerequisitesMissing, Status: http.StatusForbidden, Code: "PREREQUISITES_MISSING", Message: "Complete the prerequisite lessons first."},
+	{Err: ErrWatchLimitReached, Status: http.StatusForbidden, Code: "WATCH_LIMIT_REACHED", Message: "Watch limit reached for this lesson."},
+}
@@ -0,0 +1,76 @@
+package lesson
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+)
+
+// TopicPublished is the outbox topic published when a lesson is approved.
+const TopicPublished = "lesson.published"
+
+// TopicDeleted is the outbox topic published when a lesson is deleted.
+const TopicDeleted = "lesson.deleted"
+
+// Event is the outbox payload for published lessons.
+type Event struct {
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	CourseID       uuid.UUID `json:"courseId"`
+	LessonID       uuid.UUID `json:"lessonId"`
+	Name           string    `json:"name"`
+	Duration       int       `json:"duration"`
+}
+
+// DeletedEvent is the outbox payload for deleted lessons. It lists what the
+// lesson kept on Bunny, since the rows naming it are gone once it commits.
+type DeletedEvent struct {
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	CourseID       uuid.UUID `json:"courseId"`
+	LessonID       uuid.UUID `json:"lessonId"`
+	// VideoIDs are the lesson's video and the new videos of its pending replacements
+	VideoIDs []string `json:"videoIds,omitempty"`
+	// Files are the storage paths or CDN URLs of attachment files and image variants
+	Files []string `json:"files,omitempty"`
+}
+
+// fileAttachmentTypes are the attachment types stored on Bunny Storage.
+var fileAttachmentTypes = map[string]bool{"pdf": true, "audio": true, "image": true}
+
+func publishPublished(tx *gorm.DB, subscriptionID uuid.UUID, lesson Lesson) error {
+	return outbox.Publish(tx, TopicPublished, lesson.ID, Event{
+		SubscriptionID: subscriptionID,
+		CourseID:       lesson.CourseID,
+		LessonID:       lesson.ID,
+		Name:           lesson.Name,
+		Duration:       lesson.Duration,
+	})
+}
+
+func publishDeleted(tx *gorm.DB, subscriptionID uuid.UUID, lesson Lesson, attachments []attachment.Attachment, replacementVideoIDs []string) error {
+	event := DeletedEvent{
+		SubscriptionID: subscriptionID,
+		CourseID:       lesson.CourseID,
+		LessonID:       lesson.ID,
+	}
+	if lesson.VideoID != "" {
+		event.VideoIDs = append(event.VideoIDs, lesson.VideoID)
+	}
+	event.VideoIDs = append(event.VideoIDs, replacementVideoIDs...)
+
+	for _, att := range attachments {
+		if !fileAttachmentTypes[att.Type] {
+			continue
+		}
+		if att.Path != nil && *att.Path != "" {
+			event.Files = append(event.Files, *att.Path)
+		}
+		for _, variant := range att.Variants {
+			if att.Path == nil || variant.URL != *att.Path {
+				event.Files = append(event.Files, variant.URL)
+			}
+		}
+	}
+	return outbox.Publish(tx, TopicDeleted, lesson.ID, event)
+}
@@ -14,17 +14,23 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
+	"github.com/mo-amir99/lms-server-go/internal/features/chapter"
 	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/playback"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/internal/features/userwatch"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
 	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
-	"github.com/mo-amir99/lms-server-go/pkg/cleanup"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Handler processes lesson HTTP requests.
@@ -34,6 +40,10 @@ type Handler struct {
 	streamClient  *bunny.StreamClient
 	storageClient *bunny.StorageClient
 	storageUsage  *storageusage.Service
+	queryCache    *cache.Store
+	uploadProxy   bool
+	uploadEvents  UploadBroadcaster
+	publishHooks  []func(lesson Lesson)
 }
 
 // NewHandler constructs a lesson handler instance.
@@ -47,6 +57,23 @@ func NewHandler(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClie
 	}
 }
 
+// UseCache sets the query cache that lesson writes invalidate.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+// OnPublished registers a callback invoked after a lesson is approved and
+// becomes published.
+func (h *Handler) OnPublished(fn func(lesson Lesson)) {
+	h.publishHooks = append(h.publishHooks, fn)
+}
+
+func (h *Handler) notifyPublished(lesson Lesson) {
+	for _, hook := range h.publishHooks {
+		hook(lesson)
+	}
+}
+
 // List returns paginated lessons for a course.
 func (h *Handler) List(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -69,11 +96,27 @@ func (h *Handler) List(c *gin.Context) {
 	params := pagination.Extract(c)
 	keyword := c.Query("filterKeyword")
 	activeOnly := c.Query("activeOnly") == "true"
+	status, ok := publishing.ParseFilter(c.Query("status"))
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, publishing.ErrInvalidStatus.Error(), nil)
+		return
+	}
+
+	enrolledAt, drip, ok := studentView(c)
+	if !ok {
+		return
+	}
+	if drip {
+		// Students never see drafts, whatever the query asks for
+		activeOnly, status = true, types.ContentStatusPublished
+	}
 
-	lessons, total, err := List(h.db, ListFilters{
+	usr, _ := middleware.GetUserFromContext(c)
+	lessons, total, err := List(authz.ScopeQuery(authz.SubjectFrom(usr), h.db, subscriptionColumn), ListFilters{
 		CourseID:   courseID,
 		Keyword:    keyword,
 		ActiveOnly: activeOnly,
+		Status:     status,
 	}, params)
 
 	if err != nil {
@@ -81,9 +124,40 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	if drip {
+		ApplyDrip(lessons, enrolledAt, time.Now().UTC())
+	}
+
+	// Lessons unlock as time passes, so the lock state is part of the version
+	parts := []interface{}{c.Request.URL.RawQuery, total}
+	for _, lesson := range lessons {
+		parts = append(parts, lesson.ID, lesson.UpdatedAt, lesson.Locked)
+	}
+	if response.NotModified(c, response.ETag(parts...)) {
+		return
+	}
+
 	response.Success(c, http.StatusOK, lessons, "", pagination.MetadataFrom(total, params))
 }
 
+type createRequest struct {
+	VideoID                    string      `json:"videoId" binding:"required"`
+	ProcessingJobID            *string     `json:"processingJobId"`
+	Name                       string      `json:"name" binding:"required"`
+	Description                *string     `json:"description"`
+	Duration                   *int        `json:"duration"`
+	Order                      *int        `json:"order"`
+	Active                     *bool       `json:"isActive"`
+	AvailableFrom              *time.Time  `json:"availableFrom"`
+	AvailableAfterDaysEnrolled *int        `json:"availableAfterDaysEnrolled" binding:"omitnil,gte=0,lte=3650"`
+	Prerequisites              []uuid.UUID `json:"prerequisites" binding:"max=50"`
+	WatchLimit                 *int        `json:"watchLimit" binding:"omitnil,gte=1"`
+	WatchInterval              *int        `json:"watchInterval" binding:"omitnil,gte=1"`
+	UnlimitedWatches           *bool       `json:"unlimitedWatches"`
+
+	Status *types.ContentStatus `json:"status"`
+}
+
 // Create inserts a new lesson.
 func (h *Handler) Create(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -103,18 +177,21 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		VideoID         string  `json:"videoId" binding:"required"`
-		ProcessingJobID *string `json:"processingJobId"`
-		Name            string  `json:"name" binding:"required"`
-		Description     *string `json:"description"`
-		Duration        *int    `json:"duration"`
-		Order           *int    `json:"order"`
-		Active          *bool   `json:"isActive"`
+	var req createRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid lesson payload") {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson payload", err)
+	status, err := publishing.Initial(req.Status, authz.CanPublish(authz.SubjectFrom(usr)))
+	if err != nil {
+		h.respondError(c, err, "invalid lesson status")
 		return
 	}
 
@@ -127,6 +204,14 @@ func (h *Handler) Create(c *gin.Context) {
 		Duration:        req.Duration,
 		Order:           req.Order,
 		Active:          req.Active,
+		AvailableFrom:   req.AvailableFrom,
+		AvailableAfter:  req.AvailableAfterDaysEnrolled,
+		Prerequisites:   req.Prerequisites,
+		WatchLimit:      req.WatchLimit,
+		WatchInterval:   req.WatchInterval,
+		Unlimited:       req.UnlimitedWatches,
+		Status:          status,
+		SubmittedBy:     publishing.SubmittedBy(status, usr.ID),
 	})
 
 	if err != nil {
@@ -135,6 +220,7 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	h.refreshCourseStorage(c.Request.Context(), courseID)
+	coursefeature.InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
 
 	response.Created(c, lesson, "")
 }
@@ -159,7 +245,8 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
-	if _, err := h.ensureCourse(subscriptionID, courseID); err != nil {
+	course, err := h.ensureCourse(subscriptionID, courseID)
+	if err != nil {
 		h.respondError(c, err, "failed to load course")
 		return
 	}
@@ -170,9 +257,50 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
+	enrolledAt, drip, ok := studentView(c)
+	if !ok {
+		return
+	}
+	if drip {
+		if err := hiddenFromStudents(course, lesson); err != nil {
+			h.respondError(c, err, "failed to load lesson")
+			return
+		}
+		lesson.applyDrip(enrolledAt, time.Now().UTC())
+	}
+
+	// Attachments are edited on their own, so their versions count too
+	parts := []interface{}{lesson.ID, lesson.UpdatedAt, lesson.Locked, len(lesson.Attachments)}
+	for _, att := range lesson.Attachments {
+		parts = append(parts, att.ID, att.UpdatedAt)
+	}
+	if response.NotModified(c, response.ETag(parts...)) {
+		return
+	}
+
 	response.Success(c, http.StatusOK, lesson, "", nil)
 }
 
+type updateRequest struct {
+	Name            *string                     `json:"name" binding:"omitnil,notblank"`
+	Description     validation.Optional[string] `json:"description" binding:"omitnil,notblank"`
+	Order           validation.Optional[int]    `json:"order"`
+	Active          *bool                       `json:"isActive"`
+	VideoID         validation.Optional[string] `json:"videoId" binding:"omitnil,notblank"`
+	ProcessingJobID validation.Optional[string] `json:"processingJobId" binding:"omitnil,notblank"`
+	Duration        *int                        `json:"duration" binding:"omitnil,gte=0"`
+	Attachments     interface{}                 `json:"attachments"`
+
+	AvailableFrom              validation.Optional[time.Time] `json:"availableFrom"`
+	AvailableAfterDaysEnrolled validation.Optional[int]       `json:"availableAfterDaysEnrolled" binding:"omitnil,gte=0,lte=3650"`
+	// Prerequisites replaces the list; null or [] clears it
+	Prerequisites validation.Optional[[]uuid.UUID] `json:"prerequisites"`
+	// Watch policy overrides; null reverts to the subscription's value
+	WatchLimit       validation.Optional[int] `json:"watchLimit" binding:"omitnil,gte=1"`
+	WatchInterval    validation.Optional[int] `json:"watchInterval" binding:"omitnil,gte=1"`
+	UnlimitedWatches *bool                    `json:"unlimitedWatches"`
+}
+
 // Update modifies an existing lesson.
 func (h *Handler) Update(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -203,95 +331,40 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid lesson payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["name"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "name must be a string", err)
-			return
-		}
-		input.Name = &str
-	}
-
-	if value, ok := body["description"]; ok {
-		input.DescProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "description must be a string", err)
-				return
-			}
-			input.Description = &str
-		}
-	}
-
-	if value, ok := body["order"]; ok {
-		input.OrderProvided = true
-		if value != nil {
-			val, err := request.ReadInt(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "order must be an integer", err)
-				return
-			}
-			input.Order = &val
-		}
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
-	}
-
-	if value, ok := body["videoId"]; ok {
-		input.VideoIDProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "videoId must be a string", err)
-				return
-			}
-			input.VideoID = &str
-		}
-	}
-
-	if value, ok := body["processingJobId"]; ok {
-		input.ProcessingJobIDProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "processingJobId must be a string", err)
-				return
-			}
-			input.ProcessingJobID = &str
-		}
-	}
-
-	if value, ok := body["duration"]; ok {
-		if value != nil {
-			val, err := request.ReadInt(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "duration must be an integer", err)
-				return
-			}
-			input.Duration = &val
-		}
+	input := UpdateInput{
+		Name:                    request.Trimmed(req.Name),
+		DescProvided:            req.Description.Set,
+		Description:             request.Trimmed(req.Description.Ptr()),
+		OrderProvided:           req.Order.Set,
+		Order:                   req.Order.Ptr(),
+		Active:                  req.Active,
+		VideoIDProvided:         req.VideoID.Set,
+		VideoID:                 request.Trimmed(req.VideoID.Ptr()),
+		ProcessingJobIDProvided: req.ProcessingJobID.Set,
+		ProcessingJobID:         request.Trimmed(req.ProcessingJobID.Ptr()),
+		Duration:                req.Duration,
+		AvailableFromProvided:   req.AvailableFrom.Set,
+		AvailableFrom:           req.AvailableFrom.Ptr(),
+		AvailableAfterProvided:  req.AvailableAfterDaysEnrolled.Set,
+		AvailableAfter:          req.AvailableAfterDaysEnrolled.Ptr(),
+		PrerequisitesProvided:   req.Prerequisites.Set,
+		Prerequisites:           req.Prerequisites.Value,
+		WatchLimitProvided:      req.WatchLimit.Set,
+		WatchLimit:              req.WatchLimit.Ptr(),
+		WatchIntervalProvided:   req.WatchInterval.Set,
+		WatchInterval:           req.WatchInterval.Ptr(),
+		Unlimited:               req.UnlimitedWatches,
 	}
 
-	if value, ok := body["attachments"]; ok {
-		attachments, provided, err := normalizeAttachmentIDs(value)
+	if req.Attachments != nil {
+		attachments, provided, err := normalizeAttachmentIDs(req.Attachments)
 		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "attachments must be an array of UUIDs", err)
+			request.RespondInvalid(c, validation.FieldError{Field: "attachments", Rule: "ids", Message: "must be an array of UUIDs"})
 			return
 		}
 		if provided {
@@ -304,6 +377,7 @@ func (h *Handler) Update(c *gin.Context) {
 		h.respondError(c, err, "failed to update lesson")
 		return
 	}
+	coursefeature.InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
 
 	updatedLesson, err := h.ensureLesson(courseID, id, true)
 	if err != nil {
@@ -343,41 +417,39 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
-	// Get lesson with attachments to access videoID and attachments before deleting
-	lesson, err := h.ensureLesson(courseID, id, true)
+	lesson, err := h.ensureLesson(courseID, id, false)
 	if err != nil {
 		h.respondError(c, err, "failed to load lesson")
 		return
 	}
 
-	// Collect attachment IDs for bulk deletion
-	var attachmentIDs []uuid.UUID
-	for _, att := range lesson.Attachments {
-		attachmentIDs = append(attachmentIDs, att.ID)
-		// Delete attachment files from Bunny Storage (standalone lesson deletion, so storageCleaned=false)
-		if err := cleanup.DeleteAttachmentFile(c.Request.Context(), h.storageClient, h.logger, att.ID, att.Type, att.Path, false); err != nil {
-			h.logger.Warn("failed to delete attachment file", "attachmentId", att.ID, "error", err)
+	// The rows go in one transaction; the Bunny videos and files they name are
+	// removed after it commits by the subscribers of the deleted event, which
+	// retry until Bunny accepts.
+	err = h.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var attachments []attachment.Attachment
+		if err := tx.Select("id", "type", "path", "variants").Where("lesson_id = ?", id).Find(&attachments).Error; err != nil {
+			return fmt.Errorf("load attachments: %w", err)
+		}
+		var replacementVideoIDs []string
+		if err := tx.Model(&Replacement{}).Where("lesson_id = ? AND status = ?", id, ReplacementPending).
+			Pluck("new_video_id", &replacementVideoIDs).Error; err != nil {
+			return fmt.Errorf("load pending replacements: %w", err)
 		}
-	}
-
-	// Delete comments for this lesson
-	cleanup.BulkDeleteComments(h.db, h.logger, []uuid.UUID{id}, fmt.Sprintf("lesson_%s", id))
-
-	// Delete all attachments for this lesson
-	cleanup.BulkDeleteAttachments(h.db, h.logger, attachmentIDs, fmt.Sprintf("lesson_%s", id))
 
-	// Delete lesson from database
-	if err := Delete(h.db, id); err != nil {
+		// Comments, attachments and replacements go with the lesson through their foreign keys
+		if err := Delete(tx, id); err != nil {
+			return err
+		}
+		return publishDeleted(tx, subscriptionID, lesson, attachments, replacementVideoIDs)
+	})
+	if err != nil {
 		h.respondError(c, err, "failed to delete lesson")
 		return
 	}
 
-	// Cleanup Bunny Stream video (standalone lesson deletion, so videoCleaned=false)
-	if err := cleanup.DeleteLessonVideo(c.Request.Context(), h.streamClient, h.logger, id, lesson.VideoID, false); err != nil {
-		h.logger.Warn("failed to delete lesson video", "lessonId", id, "error", err)
-	}
-
 	h.refreshCourseStorage(c.Request.Context(), courseID)
+	coursefeature.InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
 
 	response.Success(c, http.StatusOK, true, "", nil)
 }
@@ -408,7 +480,8 @@ func (h *Handler) GetVideoURL(c *gin.Context) {
 		return
 	}
 
-	if _, err := h.ensureCourse(subscriptionID, courseID); err != nil {
+	course, err := h.ensureCourse(subscriptionID, courseID)
+	if err != nil {
 		h.respondError(c, err, "failed to load course")
 		return
 	}
@@ -420,24 +493,75 @@ func (h *Handler) GetVideoURL(c *gin.Context) {
 	}
 
 	if lesson.VideoID != videoID {
-		response.ErrorWithLog(h.logger, c, http.StatusNotFound, "video not found for this lesson", ErrVideoMismatch)
+		errorCatalog.Respond(h.logger, c, ErrVideoMismatch, "failed to load video")
 		return
 	}
 
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	enrolledAt, gated, ok := studentView(c)
+	if !ok {
+		return
+	}
+	if gated {
+		if err := hiddenFromStudents(course, lesson); err != nil {
+			h.respondError(c, err, "failed to load lesson")
+			return
+		}
+		lesson.applyDrip(enrolledAt, time.Now().UTC())
+		if lesson.Locked {
+			errorCatalog.RespondWithData(h.logger, c, ErrNotYetAvailable, gin.H{
+				"availableAt": lesson.AvailableAt,
+			})
+			return
+		}
+
+		missing, err := MissingPrerequisites(h.db, usr.ID, lesson)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check lesson prerequisites", err)
+			return
+		}
+		if len(missing) > 0 {
+			errorCatalog.RespondWithData(h.logger, c, ErrPrerequisitesMissing, gin.H{
+				"missingPrerequisites": missing,
+			})
+			return
+		}
+	}
+
 	signedURL, err := h.streamClient.SignedVideoURL(videoID)
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to sign video URL", err)
 		return
 	}
 
-	usr, ok := middleware.GetUserFromContext(c)
-	if !ok {
-		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+	chapters, err := chapter.GetByLesson(h.db, lesson.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load chapters", err)
+		return
+	}
+
+	// Each URL handed out gets its own watermark code so a leak can be traced
+	watermark, err := playback.Start(h.db, playback.StartInput{
+		UserID:         usr.ID,
+		SubscriptionID: subscriptionID,
+		LessonID:       lesson.ID,
+		FullName:       usr.FullName,
+		Email:          usr.Email,
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	})
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start playback session", err)
 		return
 	}
 
-	if usr.UserType != types.UserTypeStudent {
-		response.Success(c, http.StatusOK, gin.H{"videoUrl": signedURL}, "", nil)
+	if authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		response.Success(c, http.StatusOK, gin.H{"videoUrl": signedURL, "chapters": chapters, "watermark": watermark}, "", nil)
 		return
 	}
 
@@ -465,8 +589,15 @@ func (h *Handler) GetVideoURL(c *gin.Context) {
 		}
 	}
 
-	watchLimit := sub.WatchLimit
-	intervalMinutes := sub.WatchInterval
+	watchLimit, intervalMinutes := lesson.WatchPolicy(sub.WatchLimit, sub.WatchInterval)
+	if watchLimit > 0 {
+		extra, err := userwatch.ExtraWatches(h.db, usr.ID, lessonID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load watch grants", err)
+			return
+		}
+		watchLimit += extra
+	}
 	if intervalMinutes <= 0 {
 		intervalMinutes = 240
 	}
@@ -497,11 +628,11 @@ func (h *Handler) GetVideoURL(c *gin.Context) {
 
 	if activeWatch == nil {
 		if watchLimit > 0 && expiredCount >= watchLimit {
-			response.ErrorWithData(h.logger, c, http.StatusForbidden, "Watch limit reached for this lesson.", gin.H{
+			errorCatalog.RespondWithData(h.logger, c, ErrWatchLimitReached, gin.H{
 				"watchLimit":  watchLimit,
 				"watchesUsed": expiredCount,
 				"timeLimit":   int(interval.Seconds()),
-			}, ErrWatchLimitReached)
+			})
 			return
 		}
 
@@ -540,6 +671,8 @@ func (h *Handler) GetVideoURL(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, gin.H{
 		"videoUrl":        signedURL,
+		"chapters":        chapters,
+		"watermark":       watermark,
 		"watchesUsed":     watchesUsed,
 		"watchLimit":      watchLimit,
 		"timeLimit":       int(interval.Seconds()),
@@ -551,8 +684,10 @@ func (h *Handler) GetVideoURL(c *gin.Context) {
 	}, "", nil)
 }
 
-// GetUploadURL generates a signed Bunny Stream upload URL for direct client upload
-func (h *Handler) GetUploadURL(c *gin.Context) {
+// UnlockStatus reports, for every lesson of the course, whether the requester
+// completed it and whether its schedule and prerequisites let them watch it,
+// so apps can render locked lessons without probing video URLs.
+func (h *Handler) UnlockStatus(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
@@ -565,12 +700,74 @@ func (h *Handler) GetUploadURL(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		LessonName string `json:"lessonName" binding:"required"`
+	course, err := h.ensureCourse(subscriptionID, courseID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid request payload", err)
+	enrolledAt, gated, ok := studentView(c)
+	if !ok {
+		return
+	}
+	if gated && !course.VisibleToStudents() {
+		h.respondError(c, ErrCourseNotFound, "failed to load course")
+		return
+	}
+
+	lessons, err := GetByCourse(h.db, courseID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load lessons", err)
+		return
+	}
+	if gated {
+		visible := lessons[:0]
+		for _, item := range lessons {
+			if item.VisibleToStudents() {
+				visible = append(visible, item)
+			}
+		}
+		lessons = visible
+	}
+
+	statuses, err := UnlockStatuses(h.db, usr.ID, lessons, gated, enrolledAt, time.Now().UTC())
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load unlock status", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, statuses, "", nil)
+}
+
+type getUploadURLRequest struct {
+	LessonName string `json:"lessonName" binding:"required"`
+	FileSize   int64  `json:"fileSize" binding:"omitempty,gte=0"` // bytes, checked against the subscription storage limit
+}
+
+// GetUploadURL generates a signed Bunny Stream upload URL for direct client upload.
+// Uploads are refused once the subscription's courses use up its combined storage.
+func (h *Handler) GetUploadURL(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	var req getUploadURLRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid request payload") {
 		return
 	}
 
@@ -586,6 +783,18 @@ func (h *Handler) GetUploadURL(c *gin.Context) {
 		return
 	}
 
+	limitGB, usedGB, err := subscription.CheckStorage(h.db, subscriptionID, float64(req.FileSize)/(1024*1024*1024))
+	if errors.Is(err, subscription.ErrStorageLimitExceeded) {
+		response.ErrorWithData(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage limit exceeded. Subscription storage limit is %.2fGB, current usage is %.2fGB.", limitGB, usedGB),
+			gin.H{"subscriptionLimitGB": limitGB, "currentUsageGB": usedGB}, nil)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check subscription storage", err)
+		return
+	}
+
 	// Generate TUS upload info for resumable uploads (6 hour expiration)
 	// TUS protocol allows uploads to resume if connection is interrupted
 	// Large videos (1-2GB) can take 2-4 hours on slow internet
@@ -599,37 +808,50 @@ func (h *Handler) GetUploadURL(c *gin.Context) {
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrCourseNotFound):
-		status = http.StatusNotFound
-		message = "Course not found."
-	case errors.Is(err, ErrLessonNotFound):
-		status = http.StatusNotFound
-		message = "Lesson not found."
-	case errors.Is(err, ErrNameRequired):
-		status = http.StatusBadRequest
-		message = "Lesson name is required."
-	case errors.Is(err, ErrNameLength):
-		status = http.StatusBadRequest
-		message = "Lesson name must be between 3 and 80 characters."
-	case errors.Is(err, ErrVideoIDRequired):
-		status = http.StatusBadRequest
-		message = "Video ID is required."
-	case errors.Is(err, ErrDescriptionTooLong):
-		status = http.StatusBadRequest
-		message = "Lesson description cannot exceed 1000 characters."
-	case errors.Is(err, ErrOrderInvalid):
-		status = http.StatusBadRequest
-		message = "Lesson order cannot be negative."
-	case errors.Is(err, ErrDurationInvalid):
-		status = http.StatusBadRequest
-		message = "Lesson duration cannot be negative."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
+
+// studentView reports whether drip schedules and prerequisites apply to the
+// request and the enrollment date schedules are measured from. Students are measured from when
+// their account joined the subscription. Content managers see every lesson
+// unless they pass ?preview=student, optionally with enrolledAt to preview
+// what a student who enrolled then sees today. ok is false once an invalid
+// query has been answered.
+func studentView(c *gin.Context) (enrolledAt time.Time, applies bool, ok bool) {
+	usr, found := middleware.GetUserFromContext(c)
+	if !found {
+		return time.Time{}, false, true
+	}
+	if !authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		return usr.CreatedAt, true, true
+	}
+	if c.Query("preview") != "student" {
+		return time.Time{}, false, true
+	}
+
+	enrolledAt = time.Now().UTC()
+	if raw := c.Query("enrolledAt"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "enrolledAt", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
+			return time.Time{}, false, false
+		}
+		enrolledAt = parsed
+	}
+	return enrolledAt, true, true
+}
+
+// hiddenFromStudents returns a not-found error when students may not see the
+// lesson: it or its course is inactive or not published. Status is checked on
+// its own so reactivating a draft never leaks it.
+func hiddenFromStudents(course coursefeature.Course, lesson Lesson) error {
+	if !course.VisibleToStudents() {
+		return ErrCourseNotFound
+	}
+	if !lesson.VisibleToStudents() {
+		return ErrLessonNotFound
+	}
+	return nil
 }
 
 func (h *Handler) refreshCourseStorage(ctx context.Context, courseID uuid.UUID) {
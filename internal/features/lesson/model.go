@@ -3,6 +3,7 @@ package lesson
 import (
 	"sort"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/google/uuid"
@@ -23,22 +24,50 @@ type Lesson struct {
 	ProcessingJobID *string        `gorm:"type:varchar(255);column:processing_job_id;index" json:"processingJobId,omitempty"`
 	Name            string         `gorm:"type:varchar(80);not null" json:"name"`
 	Description     *string        `gorm:"type:varchar(1000)" json:"description,omitempty"`
+	ThumbnailURL    *string        `gorm:"type:text;column:thumbnail_url" json:"thumbnailUrl,omitempty"`
 	Duration        int            `gorm:"type:int;not null;default:0" json:"duration"` // seconds
 	Order           int            `gorm:"type:int;not null;default:0" json:"order"`
 	Active          bool           `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
 	AttachmentIDs   pq.StringArray `gorm:"type:uuid[];column:attachments" json:"attachmentOrder,omitempty"`
+	PrerequisiteIDs pq.StringArray `gorm:"type:uuid[];not null;default:'{}';column:prerequisites" json:"prerequisites"`
 
-	Attachments []attachment.Attachment `gorm:"foreignKey:LessonID" json:"attachments,omitempty"`
+	// Drip schedule: students see the lesson from AvailableFrom or this many days after enrolling
+	AvailableFrom              *time.Time `gorm:"type:timestamp;column:available_from" json:"availableFrom,omitempty"`
+	AvailableAfterDaysEnrolled *int       `gorm:"type:int;column:available_after_days_enrolled" json:"availableAfterDaysEnrolled,omitempty"`
+
+	// Watch policy overrides; nil falls back to the subscription's limit and interval (minutes)
+	WatchLimit       *int `gorm:"type:int;column:watch_limit" json:"watchLimit,omitempty"`
+	WatchInterval    *int `gorm:"type:int;column:watch_interval" json:"watchInterval,omitempty"`
+	UnlimitedWatches bool `gorm:"type:boolean;not null;default:false;column:unlimited_watches" json:"unlimitedWatches"`
+
+	// Review state; students see published lessons of published courses only
+	Status      types.ContentStatus `gorm:"type:varchar(20);not null;default:'published'" json:"status"`
+	ReviewNote  *string             `gorm:"type:text;column:review_note" json:"reviewNote,omitempty"`
+	SubmittedBy *uuid.UUID          `gorm:"type:uuid;column:submitted_by" json:"submittedBy,omitempty"`
+	ReviewedBy  *uuid.UUID          `gorm:"type:uuid;column:reviewed_by" json:"reviewedBy,omitempty"`
+
+	// Set per student by ApplyDrip while the lesson is still scheduled
+	Locked      bool       `gorm:"-" json:"locked,omitempty"`
+	AvailableAt *time.Time `gorm:"-" json:"availableAt,omitempty"`
+
+	Attachments []attachment.Attachment `gorm:"foreignKey:LessonID;constraint:OnDelete:CASCADE" json:"attachments,omitempty"`
 }
 
 // TableName overrides the default table name.
 func (Lesson) TableName() string { return "lessons" }
 
+// VisibleToStudents reports whether students may see the lesson itself; its
+// course must be visible too.
+func (l Lesson) VisibleToStudents() bool {
+	return l.Active && l.Status == types.ContentStatusPublished
+}
+
 // ListFilters defines lesson query filters.
 type ListFilters struct {
 	CourseID   uuid.UUID
 	Keyword    string
 	ActiveOnly bool
+	Status     types.ContentStatus
 }
 
 // CreateInput carries data for creating a new lesson.
@@ -51,6 +80,14 @@ type CreateInput struct {
 	Duration        *int
 	Order           *int
 	Active          *bool
+	AvailableFrom   *time.Time
+	AvailableAfter  *int
+	Prerequisites   []uuid.UUID
+	WatchLimit      *int
+	WatchInterval   *int
+	Unlimited       *bool
+	Status          types.ContentStatus // defaults to published
+	SubmittedBy     *uuid.UUID
 }
 
 // UpdateInput captures mutable lesson fields.
@@ -68,8 +105,24 @@ type UpdateInput struct {
 	Active                  *bool
 	AttachmentsProvided     bool
 	Attachments             []string
+	ThumbnailProvided       bool
+	ThumbnailURL            *string
+	AvailableFromProvided   bool
+	AvailableFrom           *time.Time
+	AvailableAfterProvided  bool
+	AvailableAfter          *int
+	PrerequisitesProvided   bool
+	Prerequisites           []uuid.UUID
+	WatchLimitProvided      bool
+	WatchLimit              *int
+	WatchIntervalProvided   bool
+	WatchInterval           *int
+	Unlimited               *bool
 }
 
+// subscriptionColumn resolves a lesson's subscription through its course, for authz.ScopeQuery.
+const subscriptionColumn = "(SELECT subscription_id FROM courses WHERE courses.id = lessons.course_id)"
+
 // List retrieves paginated lessons with filters.
 func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Lesson, int64, error) {
 	query := db.Model(&Lesson{}).Where("course_id = ?", filters.CourseID)
@@ -82,6 +135,9 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Lesson,
 	if filters.ActiveOnly {
 		query = query.Where("is_active = ?", true)
 	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
 
 	var total int64
 	countQuery := db.Model(&Lesson{}).Where("course_id = ?", filters.CourseID)
@@ -92,6 +148,9 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Lesson,
 	if filters.ActiveOnly {
 		countQuery = countQuery.Where("is_active = ?", true)
 	}
+	if filters.Status != "" {
+		countQuery = countQuery.Where("status = ?", filters.Status)
+	}
 	if err := countQuery.Count(&total).Error; err != nil {
 		return nil, total, err
 	}
@@ -190,6 +249,19 @@ func Create(db *gorm.DB, input CreateInput) (Lesson, error) {
 		return Lesson{}, ErrDurationInvalid
 	}
 
+	if input.AvailableAfter != nil && *input.AvailableAfter < 0 {
+		return Lesson{}, ErrAvailableAfterInvalid
+	}
+
+	if err := validateWatchPolicy(input.WatchLimit, input.WatchInterval); err != nil {
+		return Lesson{}, err
+	}
+
+	// A new lesson cannot be anyone's prerequisite yet, so only membership is checked
+	if err := validatePrerequisites(db, input.CourseID, uuid.Nil, input.Prerequisites); err != nil {
+		return Lesson{}, err
+	}
+
 	active := true
 	if input.Active != nil {
 		active = *input.Active
@@ -215,7 +287,21 @@ func Create(db *gorm.DB, input CreateInput) (Lesson, error) {
 		Order:           order,
 		Active:          active,
 		AttachmentIDs:   pq.StringArray{},
+		PrerequisiteIDs: pq.StringArray(uniqueStrings(input.Prerequisites)),
+		WatchLimit:      input.WatchLimit,
+		WatchInterval:   input.WatchInterval,
+
+		AvailableFrom:              utcPtr(input.AvailableFrom),
+		AvailableAfterDaysEnrolled: input.AvailableAfter,
 	}
+	if input.Unlimited != nil {
+		lesson.UnlimitedWatches = *input.Unlimited
+	}
+	lesson.Status = input.Status
+	if lesson.Status == "" {
+		lesson.Status = types.ContentStatusPublished
+	}
+	lesson.SubmittedBy = input.SubmittedBy
 
 	if err := db.Create(&lesson).Error; err != nil {
 		return Lesson{}, err
@@ -304,6 +390,41 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Lesson, error) {
 		lesson.AttachmentIDs = pq.StringArray(input.Attachments)
 	}
 
+	if input.ThumbnailProvided {
+		lesson.ThumbnailURL = input.ThumbnailURL
+	}
+
+	if input.AvailableFromProvided {
+		lesson.AvailableFrom = utcPtr(input.AvailableFrom)
+	}
+
+	if input.AvailableAfterProvided {
+		if input.AvailableAfter != nil && *input.AvailableAfter < 0 {
+			return lesson, ErrAvailableAfterInvalid
+		}
+		lesson.AvailableAfterDaysEnrolled = input.AvailableAfter
+	}
+
+	if input.WatchLimitProvided {
+		lesson.WatchLimit = input.WatchLimit
+	}
+	if input.WatchIntervalProvided {
+		lesson.WatchInterval = input.WatchInterval
+	}
+	if err := validateWatchPolicy(lesson.WatchLimit, lesson.WatchInterval); err != nil {
+		return lesson, err
+	}
+	if input.Unlimited != nil {
+		lesson.UnlimitedWatches = *input.Unlimited
+	}
+
+	if input.PrerequisitesProvided {
+		if err := validatePrerequisites(db, lesson.CourseID, lesson.ID, input.Prerequisites); err != nil {
+			return lesson, err
+		}
+		lesson.PrerequisiteIDs = pq.StringArray(uniqueStrings(input.Prerequisites))
+	}
+
 	if err := db.Save(&lesson).Error; err != nil {
 		return lesson, err
 	}
@@ -311,16 +432,18 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Lesson, error) {
 	return lesson, nil
 }
 
-// Delete removes a lesson.
+// Delete removes a lesson and drops it from the prerequisites of other lessons.
 func Delete(db *gorm.DB, id uuid.UUID) error {
-	result := db.Delete(&Lesson{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrLessonNotFound
-	}
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Lesson{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLessonNotFound
+		}
+		return tx.Exec(`UPDATE lessons SET prerequisites = array_remove(prerequisites, ?), updated_at = NOW() WHERE ? = ANY(prerequisites)`, id, id).Error
+	})
 }
 
 // GetByCourse retrieves all lessons for a course.
@@ -334,12 +457,12 @@ func GetByCourse(db *gorm.DB, courseID uuid.UUID) ([]Lesson, error) {
 
 // AppendAttachmentID appends an attachment ID to the lesson-level attachment order array.
 func AppendAttachmentID(db *gorm.DB, lessonID, attachmentID uuid.UUID) error {
-	return db.Exec(`UPDATE lessons SET attachments = array_append(COALESCE(attachments, '{}'::uuid[]), ?) WHERE id = ?`, attachmentID, lessonID).Error
+	return db.Exec(`UPDATE lessons SET attachments = array_append(COALESCE(attachments, '{}'::uuid[]), ?), updated_at = NOW() WHERE id = ?`, attachmentID, lessonID).Error
 }
 
 // RemoveAttachmentID removes an attachment ID from the lesson-level attachment order array.
 func RemoveAttachmentID(db *gorm.DB, lessonID, attachmentID uuid.UUID) error {
-	return db.Exec(`UPDATE lessons SET attachments = array_remove(COALESCE(attachments, '{}'::uuid[]), ?) WHERE id = ?`, attachmentID, lessonID).Error
+	return db.Exec(`UPDATE lessons SET attachments = array_remove(COALESCE(attachments, '{}'::uuid[]), ?), updated_at = NOW() WHERE id = ?`, attachmentID, lessonID).Error
 }
 
 func stringPtr(value string) *string {
@@ -347,6 +470,41 @@ func stringPtr(value string) *string {
 	return &v
 }
 
+// WatchPolicy returns the watch limit and interval in minutes that apply to
+// the lesson, given the subscription's. A limit of zero means unlimited.
+func (l Lesson) WatchPolicy(subscriptionLimit, subscriptionInterval int) (int, int) {
+	limit, interval := subscriptionLimit, subscriptionInterval
+	if l.WatchLimit != nil {
+		limit = *l.WatchLimit
+	}
+	if l.WatchInterval != nil {
+		interval = *l.WatchInterval
+	}
+	if l.UnlimitedWatches {
+		limit = 0
+	}
+	return limit, interval
+}
+
+func validateWatchPolicy(limit, interval *int) error {
+	if limit != nil && *limit < 1 {
+		return ErrWatchLimitInvalid
+	}
+	if interval != nil && *interval < 1 {
+		return ErrWatchIntervalInvalid
+	}
+	return nil
+}
+
+// utcPtr normalizes schedule times, which are stored without a zone.
+func utcPtr(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	v := value.UTC()
+	return &v
+}
+
 func applyAttachmentOrder(lesson *Lesson) {
 	if lesson == nil {
 		return
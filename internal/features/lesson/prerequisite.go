@@ -0,0 +1,175 @@
+package lesson
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompletionRatio is the share of a lesson's duration a student must reach to
+// count it as completed. Lessons without a duration complete on first view.
+const CompletionRatio = 0.9
+
+// UnlockStatus describes whether a student may watch a lesson and, if not, why.
+type UnlockStatus struct {
+	LessonID             uuid.UUID   `json:"lessonId"`
+	Completed            bool        `json:"completed"`
+	Unlocked             bool        `json:"unlocked"`
+	AvailableAt          *time.Time  `json:"availableAt,omitempty"`
+	MissingPrerequisites []uuid.UUID `json:"missingPrerequisites"`
+}
+
+// CompletedLessons returns which of the lessons the user has completed.
+func CompletedLessons(db *gorm.DB, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	completed := make(map[uuid.UUID]bool, len(lessonIDs))
+	if len(lessonIDs) == 0 {
+		return completed, nil
+	}
+
+	var ids []uuid.UUID
+	err := db.Raw(`
+		SELECT l.id
+		FROM lessons l
+		JOIN (
+			SELECT lesson_id, MAX(max_position) AS max_position
+			FROM watch_sessions
+			WHERE user_id = @user AND lesson_id IN @lessons
+			GROUP BY lesson_id
+		) w ON w.lesson_id = l.id
+		WHERE l.duration <= 0 OR w.max_position >= l.duration * @ratio`,
+		map[string]interface{}{"user": userID, "lessons": lessonIDs, "ratio": CompletionRatio}).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		completed[id] = true
+	}
+	return completed, nil
+}
+
+// MissingPrerequisites returns the prerequisites of the lesson the user has not completed yet.
+func MissingPrerequisites(db *gorm.DB, userID uuid.UUID, lesson Lesson) ([]uuid.UUID, error) {
+	prerequisites := lesson.prerequisiteIDs()
+	completed, err := CompletedLessons(db, userID, prerequisites)
+	if err != nil {
+		return nil, err
+	}
+	return missing(prerequisites, completed), nil
+}
+
+// UnlockStatuses reports, for each lesson, whether the user completed it and
+// whether its drip schedule and prerequisites let them watch it. When gated is
+// false, as for content managers, every lesson is unlocked.
+func UnlockStatuses(db *gorm.DB, userID uuid.UUID, lessons []Lesson, gated bool, enrolledAt, now time.Time) ([]UnlockStatus, error) {
+	ids := make([]uuid.UUID, 0, len(lessons))
+	for _, item := range lessons {
+		ids = append(ids, item.ID)
+		ids = append(ids, item.prerequisiteIDs()...)
+	}
+
+	completed, err := CompletedLessons(db, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]UnlockStatus, 0, len(lessons))
+	for _, item := range lessons {
+		status := UnlockStatus{
+			LessonID:             item.ID,
+			Completed:            completed[item.ID],
+			Unlocked:             true,
+			MissingPrerequisites: []uuid.UUID{},
+		}
+		if gated {
+			if release := item.ReleaseAt(enrolledAt); release != nil && release.After(now) {
+				status.AvailableAt = release
+			}
+			status.MissingPrerequisites = missing(item.prerequisiteIDs(), completed)
+			status.Unlocked = status.AvailableAt == nil && len(status.MissingPrerequisites) == 0
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// validatePrerequisites checks that every prerequisite is another lesson of the
+// same course and that the resulting graph has no cycle through the lesson.
+func validatePrerequisites(db *gorm.DB, courseID, lessonID uuid.UUID, prerequisites []uuid.UUID) error {
+	if len(prerequisites) == 0 {
+		return nil
+	}
+
+	var siblings []Lesson
+	if err := db.Select("id", "prerequisites").Where("course_id = ?", courseID).Find(&siblings).Error; err != nil {
+		return err
+	}
+
+	graph := make(map[uuid.UUID][]uuid.UUID, len(siblings))
+	for _, sibling := range siblings {
+		graph[sibling.ID] = sibling.prerequisiteIDs()
+	}
+	for _, id := range prerequisites {
+		if id == lessonID {
+			return ErrPrerequisiteInvalid
+		}
+		if _, ok := graph[id]; !ok {
+			return ErrPrerequisiteInvalid
+		}
+	}
+	graph[lessonID] = prerequisites
+
+	// The lesson is in a cycle when walking its prerequisites leads back to it
+	visited := make(map[uuid.UUID]bool, len(graph))
+	stack := append([]uuid.UUID(nil), prerequisites...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == lessonID {
+			return ErrPrerequisiteCycle
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, graph[id]...)
+	}
+	return nil
+}
+
+// prerequisiteIDs parses the stored prerequisite array, skipping malformed entries.
+func (l Lesson) prerequisiteIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(l.PrerequisiteIDs))
+	for _, raw := range l.PrerequisiteIDs {
+		if id, err := uuid.Parse(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func missing(required []uuid.UUID, completed map[uuid.UUID]bool) []uuid.UUID {
+	result := make([]uuid.UUID, 0)
+	for _, id := range required {
+		if !completed[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// uniqueStrings converts prerequisite IDs for storage, dropping duplicates.
+func uniqueStrings(ids []uuid.UUID) []string {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		values = append(values, id.String())
+	}
+	return values
+}
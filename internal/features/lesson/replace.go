@@ -0,0 +1,373 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Replacement states.
+const (
+	ReplacementPending   = "pending"
+	ReplacementCompleted = "completed"
+	ReplacementFailed    = "failed"
+	ReplacementCancelled = "cancelled"
+)
+
+const (
+	// replacementUploadWindowSeconds matches the TUS window of new lesson uploads.
+	replacementUploadWindowSeconds = 21600
+	// replacementTimeout abandons replacements whose video never finished processing.
+	replacementTimeout = 48 * time.Hour
+)
+
+// Bunny Stream video states that end a replacement; see bunny.VideoStatus.
+const (
+	videoStatusFinished           = 3
+	videoStatusResolutionFinished = 4
+	videoStatusFailed             = 5
+	videoStatusUploadFailed       = 6
+)
+
+// errLessonVideoChanged aborts a swap when the lesson no longer has the old video.
+var errLessonVideoChanged = errors.New("lesson video changed during replacement")
+
+// Replacement swaps a lesson's video for a newly uploaded one. The lesson keeps
+// serving the old video until the new one has finished processing, then the
+// IDs are swapped and the old video is deleted. Watch records reference the
+// lesson, not the video, so they carry over; the row keeps the old video ID
+// for looking up its Bunny analytics.
+type Replacement struct {
+	types.BaseModel
+
+	LessonID       uuid.UUID  `gorm:"type:uuid;not null;column:lesson_id" json:"lessonId"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	CourseID       uuid.UUID  `gorm:"type:uuid;not null;column:course_id" json:"courseId"`
+	OldVideoID     string     `gorm:"type:varchar(255);not null;column:old_video_id" json:"oldVideoId"`
+	NewVideoID     string     `gorm:"type:varchar(255);not null;column:new_video_id" json:"newVideoId"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	RequestedBy    *uuid.UUID `gorm:"type:uuid;column:requested_by" json:"requestedBy,omitempty"`
+	Error          *string    `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt    *time.Time `gorm:"type:timestamptz;column:completed_at" json:"completedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Replacement) TableName() string { return "lesson_video_replacements" }
+
+// ReplacementStarted is returned when a replacement begins: the replacement and
+// the TUS details for uploading the new video.
+type ReplacementStarted struct {
+	Replacement Replacement          `json:"replacement"`
+	Upload      *bunny.TusUploadInfo `json:"upload"`
+}
+
+type replaceVideoRequest struct {
+	FileSize int64 `json:"fileSize" binding:"omitempty,gte=0"` // bytes, checked against the subscription storage limit
+}
+
+// ReplaceVideo creates a new Bunny video for the lesson and returns TUS upload
+// details for it. The lesson switches to the new video once processing
+// completes; a pending replacement of the same lesson is cancelled.
+// POST /subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/replace-video
+func (h *Handler) ReplaceVideo(c *gin.Context) {
+	subscriptionID, courseID, lessonID, ok := h.lessonParams(c)
+	if !ok {
+		return
+	}
+
+	var req replaceVideoRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid request payload") {
+		return
+	}
+
+	course, err := h.ensureCourse(subscriptionID, courseID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+	if course.CollectionID == nil || *course.CollectionID == "" {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "course missing Bunny collection", nil)
+		return
+	}
+
+	lesson, err := h.ensureLesson(courseID, lessonID, false)
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return
+	}
+
+	limitGB, usedGB, err := subscription.CheckStorage(h.db, subscriptionID, float64(req.FileSize)/(1024*1024*1024))
+	if errors.Is(err, subscription.ErrStorageLimitExceeded) {
+		response.ErrorWithData(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage limit exceeded. Subscription storage limit is %.2fGB, current usage is %.2fGB.", limitGB, usedGB),
+			gin.H{"subscriptionLimitGB": limitGB, "currentUsageGB": usedGB}, nil)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check subscription storage", err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tusInfo, err := h.streamClient.GenerateTusUploadInfo(ctx, lesson.Name, *course.CollectionID, replacementUploadWindowSeconds)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to generate TUS upload info", err)
+		return
+	}
+
+	replacement := Replacement{
+		LessonID:       lessonID,
+		SubscriptionID: subscriptionID,
+		CourseID:       courseID,
+		OldVideoID:     lesson.VideoID,
+		NewVideoID:     tusInfo.VideoID,
+		Status:         ReplacementPending,
+	}
+	if usr, ok := middleware.GetUserFromContext(c); ok {
+		replacement.RequestedBy = &usr.ID
+	}
+
+	var superseded []Replacement
+	err = h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("lesson_id = ? AND status = ?", lessonID, ReplacementPending).Find(&superseded).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Replacement{}).Where("lesson_id = ? AND status = ?", lessonID, ReplacementPending).
+			Update("status", ReplacementCancelled).Error; err != nil {
+			return err
+		}
+		return tx.Create(&replacement).Error
+	})
+	if err != nil {
+		h.deleteVideo(tusInfo.VideoID)
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start video replacement", err)
+		return
+	}
+	for _, previous := range superseded {
+		h.deleteVideo(previous.NewVideoID)
+	}
+
+	response.Created(c, ReplacementStarted{Replacement: replacement, Upload: tusInfo}, "Upload the new video to complete the replacement")
+}
+
+// GetVideoReplacement returns the lesson's latest video replacement.
+// GET /subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/replace-video
+func (h *Handler) GetVideoReplacement(c *gin.Context) {
+	_, courseID, lessonID, ok := h.lessonParams(c)
+	if !ok {
+		return
+	}
+
+	var replacement Replacement
+	err := h.db.WithContext(c.Request.Context()).
+		Where("lesson_id = ? AND course_id = ?", lessonID, courseID).
+		Order("created_at DESC").First(&replacement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.ErrorWithLog(h.logger, c, http.StatusNotFound, "No video replacement found.", err)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load video replacement", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, replacement, "", nil)
+}
+
+// CancelVideoReplacement stops a pending replacement and deletes its new video.
+// DELETE /subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/replace-video
+func (h *Handler) CancelVideoReplacement(c *gin.Context) {
+	_, courseID, lessonID, ok := h.lessonParams(c)
+	if !ok {
+		return
+	}
+
+	var replacement Replacement
+	err := h.db.WithContext(c.Request.Context()).
+		Where("lesson_id = ? AND course_id = ? AND status = ?", lessonID, courseID, ReplacementPending).
+		First(&replacement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.ErrorWithLog(h.logger, c, http.StatusNotFound, "No pending video replacement.", err)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load video replacement", err)
+		return
+	}
+
+	// The job may complete the swap concurrently; only a still-pending row is cancelled
+	result := h.db.WithContext(c.Request.Context()).Model(&Replacement{}).
+		Where("id = ? AND status = ?", replacement.ID, ReplacementPending).
+		Update("status", ReplacementCancelled)
+	if result.Error != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to cancel video replacement", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "Video replacement already finished.", nil)
+		return
+	}
+	h.deleteVideo(replacement.NewVideoID)
+
+	response.NoContent(c, "Video replacement cancelled")
+}
+
+func (h *Handler) lessonParams(c *gin.Context) (subscriptionID, courseID, lessonID uuid.UUID, ok bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	courseID, err = uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	lessonID, err = uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return
+	}
+
+	if _, err := h.ensureCourse(subscriptionID, courseID); err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+	return subscriptionID, courseID, lessonID, true
+}
+
+// ReplacementJob completes pending video replacements once Bunny has finished
+// processing the new video. It is meant to run every minute on the job scheduler.
+type ReplacementJob struct {
+	db           *gorm.DB
+	logger       *slog.Logger
+	streamClient *bunny.StreamClient
+	queryCache   *cache.Store
+}
+
+// NewReplacementJob constructs the video replacement job. queryCache may be nil.
+func NewReplacementJob(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClient, queryCache *cache.Store) *ReplacementJob {
+	return &ReplacementJob{db: db, logger: logger, streamClient: streamClient, queryCache: queryCache}
+}
+
+// Name returns the job name.
+func (j *ReplacementJob) Name() string {
+	return "lesson-video-replacements"
+}
+
+// Execute checks every pending replacement once.
+func (j *ReplacementJob) Execute(ctx context.Context) error {
+	var pending []Replacement
+	if err := j.db.WithContext(ctx).Where("status = ?", ReplacementPending).Order("created_at").Find(&pending).Error; err != nil {
+		return fmt.Errorf("load pending replacements: %w", err)
+	}
+
+	for _, replacement := range pending {
+		if err := j.check(ctx, replacement); err != nil {
+			j.logger.Error("failed to process video replacement",
+				"replacementId", replacement.ID, "lessonId", replacement.LessonID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (j *ReplacementJob) check(ctx context.Context, replacement Replacement) error {
+	status, err := j.streamClient.GetVideoStatus(ctx, replacement.NewVideoID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Status == videoStatusFinished || status.Status == videoStatusResolutionFinished:
+		return j.complete(ctx, replacement, status.Length)
+	case status.Status == videoStatusFailed || status.Status == videoStatusUploadFailed:
+		return j.fail(ctx, replacement, "new video failed to process")
+	case time.Since(replacement.CreatedAt) > replacementTimeout:
+		return j.fail(ctx, replacement, "new video was not processed in time")
+	}
+	return nil
+}
+
+// complete swaps the lesson to the new video in one transaction. The swap only
+// applies while the lesson still has the old video, so a manual change made in
+// the meantime wins and the replacement fails instead.
+func (j *ReplacementJob) complete(ctx context.Context, replacement Replacement, duration int) error {
+	swapped := false
+	err := j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claim := tx.Model(&Replacement{}).Where("id = ? AND status = ?", replacement.ID, ReplacementPending).
+			Updates(map[string]interface{}{"status": ReplacementCompleted, "completed_at": time.Now().UTC()})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			return claim.Error
+		}
+
+		updates := map[string]interface{}{"video_id": replacement.NewVideoID, "updated_at": time.Now().UTC()}
+		if duration > 0 {
+			updates["duration"] = duration
+		}
+		swap := tx.Model(&Lesson{}).Where("id = ? AND video_id = ?", replacement.LessonID, replacement.OldVideoID).Updates(updates)
+		if swap.Error != nil {
+			return swap.Error
+		}
+		if swap.RowsAffected == 0 {
+			return errLessonVideoChanged
+		}
+		swapped = true
+		return nil
+	})
+	if errors.Is(err, errLessonVideoChanged) {
+		return j.fail(ctx, replacement, "lesson video was changed before the replacement finished")
+	}
+	if err != nil || !swapped {
+		return err
+	}
+
+	coursefeature.InvalidateCache(ctx, j.queryCache, replacement.SubscriptionID)
+
+	// Another lesson may still point at the old video, e.g. after a course copy
+	var users int64
+	if err := j.db.WithContext(ctx).Model(&Lesson{}).Where("video_id = ?", replacement.OldVideoID).Count(&users).Error; err != nil {
+		return fmt.Errorf("check old video usage: %w", err)
+	}
+	if users == 0 && replacement.OldVideoID != "" {
+		if err := j.streamClient.DeleteVideo(ctx, replacement.OldVideoID); err != nil {
+			return fmt.Errorf("delete old video: %w", err)
+		}
+	}
+
+	j.logger.Info("lesson video replaced",
+		"lessonId", replacement.LessonID, "oldVideoId", replacement.OldVideoID, "newVideoId", replacement.NewVideoID)
+	return nil
+}
+
+func (j *ReplacementJob) fail(ctx context.Context, replacement Replacement, reason string) error {
+	result := j.db.WithContext(ctx).Model(&Replacement{}).
+		Where("id = ? AND status = ?", replacement.ID, ReplacementPending).
+		Updates(map[string]interface{}{"status": ReplacementFailed, "error": reason})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	j.logger.Warn("lesson video replacement failed", "lessonId", replacement.LessonID, "reason", reason)
+	if err := j.streamClient.DeleteVideo(ctx, replacement.NewVideoID); err != nil {
+		return fmt.Errorf("delete new video: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package lesson
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Review moves a lesson through the draft, in_review and published states.
+// Assistants may only submit; approving, rejecting and unpublishing need
+// publish rights. Students see a published lesson only while its course is
+// published too.
+func (h *Handler) Review(c *gin.Context) {
+	subscriptionID, courseID, lessonID, ok := h.lessonParams(c)
+	if !ok {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req publishing.Request
+	if !request.BindJSON(h.logger, c, &req, "invalid review payload") {
+		return
+	}
+
+	lesson, err := h.ensureLesson(courseID, lessonID, false)
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return
+	}
+
+	next, err := publishing.Next(lesson.Status, req.Action, authz.CanPublish(authz.SubjectFrom(usr)))
+	if err != nil {
+		h.respondError(c, err, "failed to review lesson")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := publishing.Apply(tx, lesson.TableName(), lesson.ID, lesson.Status, next, req.Action, usr.ID, request.Trimmed(req.Note)); err != nil {
+			return err
+		}
+		if lesson.Status == types.ContentStatusPublished || next != types.ContentStatusPublished {
+			return nil
+		}
+		return publishPublished(tx, subscriptionID, lesson)
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to review lesson")
+		return
+	}
+
+	previous := lesson.Status
+	lesson, err = h.ensureLesson(courseID, lessonID, true)
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return
+	}
+
+	coursefeature.InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+
+	if previous != types.ContentStatusPublished && lesson.Status == types.ContentStatusPublished {
+		h.notifyPublished(lesson)
+	}
+
+	response.Success(c, http.StatusOK, lesson, "", nil)
+}
@@ -2,6 +2,10 @@ package lesson
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes attaches lesson endpoints to the router.
@@ -10,9 +14,35 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff []
 
 	lessons.GET("/:lessonId/video/:videoId", append(acAll, handler.GetVideoURL)...)
 	lessons.GET("", append(acStaff, handler.List)...)
+	lessons.GET("/unlock-status", append(acAll, handler.UnlockStatus)...)
 	lessons.GET("/:lessonId", append(acAll, handler.GetByID)...)
 	lessons.POST("/upload-url", append(acStaff, handler.GetUploadURL)...)
+	lessons.POST("/uploads", append(acStaff, handler.StartUpload)...)
+	lessons.GET("/uploads/:uploadId", append(acStaff, handler.GetUploadProgress)...)
+	lessons.DELETE("/uploads/:uploadId", append(acStaff, handler.AbortUpload)...)
+	lessons.HEAD("/uploads/:uploadId/tus", append(acStaff, handler.HeadUpload)...)
+	lessons.PATCH("/uploads/:uploadId/tus", append(acStaff, handler.PatchUpload)...)
 	lessons.POST("", append(acStaff, handler.Create)...)
 	lessons.PUT("/:lessonId", append(acStaff, handler.Update)...)
 	lessons.DELETE("/:lessonId", append(acStaff, handler.Delete)...)
+	lessons.GET("/:lessonId/thumbnails", append(acStaff, handler.ListThumbnails)...)
+	lessons.PUT("/:lessonId/thumbnail", append(acStaff, handler.SelectThumbnail)...)
+	lessons.POST("/:lessonId/replace-video", append(acStaff, handler.ReplaceVideo)...)
+	lessons.GET("/:lessonId/replace-video", append(acStaff, handler.GetVideoReplacement)...)
+	lessons.DELETE("/:lessonId/replace-video", append(acStaff, handler.CancelVideoReplacement)...)
+	lessons.POST("/:lessonId/review", append(acStaff, handler.Review)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Lesson{}})
+	openapi.Describe(handler.UnlockStatus, openapi.Spec{Response: []UnlockStatus{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Lesson{}})
+	openapi.Describe(handler.GetUploadURL, openapi.Spec{Request: getUploadURLRequest{}, Response: bunny.TusUploadInfo{}})
+	openapi.Describe(handler.StartUpload, openapi.Spec{Request: startUploadRequest{}, Response: UploadProgress{}})
+	openapi.Describe(handler.GetUploadProgress, openapi.Spec{Response: UploadProgress{}})
+	openapi.Describe(handler.PatchUpload, openapi.Spec{RequestType: "application/offset+octet-stream"})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Lesson{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Lesson{}})
+	openapi.Describe(handler.ReplaceVideo, openapi.Spec{Request: replaceVideoRequest{}, Response: ReplacementStarted{}})
+	openapi.Describe(handler.GetVideoReplacement, openapi.Spec{Response: Replacement{}})
+	openapi.Describe(handler.SelectThumbnail, openapi.Spec{Request: selectThumbnailRequest{}, Response: Lesson{}})
+	openapi.Describe(handler.Review, openapi.Spec{Request: publishing.Request{}, Response: Lesson{}})
 }
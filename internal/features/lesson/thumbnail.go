@@ -0,0 +1,143 @@
+package lesson
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// ListThumbnails returns the Bunny Stream thumbnails available for a lesson video.
+func (h *Handler) ListThumbnails(c *gin.Context) {
+	lesson, ok := h.loadLessonForThumbnail(c)
+	if !ok {
+		return
+	}
+
+	thumbnails, err := h.streamClient.ListThumbnails(c.Request.Context(), lesson.VideoID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadGateway, "failed to load video thumbnails", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"selected":   lesson.ThumbnailURL,
+		"thumbnails": thumbnails,
+	}, "", nil)
+}
+
+type selectThumbnailRequest struct {
+	Index        *int    `json:"index"`
+	ThumbnailURL *string `json:"thumbnailUrl"`
+}
+
+// SelectThumbnail stores one of the video's thumbnails on the lesson and makes it the Bunny default.
+// Accepts either {"index": n} from ListThumbnails or {"thumbnailUrl": "..."} matching one of them.
+func (h *Handler) SelectThumbnail(c *gin.Context) {
+	lesson, ok := h.loadLessonForThumbnail(c)
+	if !ok {
+		return
+	}
+
+	var req selectThumbnailRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid thumbnail payload") {
+		return
+	}
+
+	if req.Index == nil && (req.ThumbnailURL == nil || strings.TrimSpace(*req.ThumbnailURL) == "") {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "index or thumbnailUrl is required", nil)
+		return
+	}
+
+	thumbnails, err := h.streamClient.ListThumbnails(c.Request.Context(), lesson.VideoID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadGateway, "failed to load video thumbnails", err)
+		return
+	}
+
+	var selected *bunny.VideoThumbnail
+	for i := range thumbnails {
+		if req.Index != nil && thumbnails[i].Index == *req.Index {
+			selected = &thumbnails[i]
+			break
+		}
+		if req.ThumbnailURL != nil && thumbnails[i].URL == strings.TrimSpace(*req.ThumbnailURL) {
+			selected = &thumbnails[i]
+			break
+		}
+	}
+
+	if selected == nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "thumbnail does not belong to this lesson video", nil)
+		return
+	}
+
+	// Preview frames are copied over the default thumbnail so players pick them up too.
+	if !selected.IsDefault {
+		if err := h.streamClient.SetThumbnail(c.Request.Context(), lesson.VideoID, selected.URL); err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadGateway, "failed to set video thumbnail", err)
+			return
+		}
+	}
+
+	thumbnailURL := selected.URL
+	updated, err := Update(h.db, lesson.ID, UpdateInput{
+		ThumbnailProvided: true,
+		ThumbnailURL:      &thumbnailURL,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to update lesson thumbnail")
+		return
+	}
+
+	// The subscription ID was validated by loadLessonForThumbnail
+	subscriptionID, _ := uuid.Parse(c.Param("subscriptionId"))
+	coursefeature.InvalidateCache(c.Request.Context(), h.queryCache, subscriptionID)
+
+	response.Success(c, http.StatusOK, updated, "", nil)
+}
+
+func (h *Handler) loadLessonForThumbnail(c *gin.Context) (Lesson, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return Lesson{}, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return Lesson{}, false
+	}
+
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return Lesson{}, false
+	}
+
+	if _, err := h.ensureCourse(subscriptionID, courseID); err != nil {
+		h.respondError(c, err, "failed to load course")
+		return Lesson{}, false
+	}
+
+	lesson, err := h.ensureLesson(courseID, lessonID, false)
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return Lesson{}, false
+	}
+
+	if strings.TrimSpace(lesson.VideoID) == "" {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "lesson has no video", ErrVideoIDRequired)
+		return Lesson{}, false
+	}
+
+	return lesson, true
+}
@@ -0,0 +1,489 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/internal/services/publishing"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Upload states.
+const (
+	UploadStatusUploading = "uploading"
+	UploadStatusCompleted = "completed"
+	UploadStatusFailed    = "failed"
+)
+
+// Socket events sent to the uploader while a proxied upload runs.
+const (
+	EventUploadProgress  = "lessonUploadProgress"
+	EventUploadCompleted = "lessonUploadCompleted"
+	EventUploadFailed    = "lessonUploadFailed"
+)
+
+const (
+	// tusResumable is the TUS protocol version the proxy speaks.
+	tusResumable = "1.0.0"
+	// proxyChunkSize is the PATCH size clients should use; it stays under the request size limit.
+	proxyChunkSize int64 = 8 << 20
+	// proxyUploadWindowSeconds is how long the Bunny signature of a proxied upload stays valid.
+	proxyUploadWindowSeconds = 24 * 60 * 60
+)
+
+// UploadBroadcaster pushes upload progress to the uploader's sockets.
+type UploadBroadcaster interface {
+	EmitToUser(userID uuid.UUID, event string, payload any)
+}
+
+// Upload is a lesson video relayed to Bunny Stream through the API. The lesson
+// is created from the stored fields once every byte has been received.
+type Upload struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	CourseID       uuid.UUID  `gorm:"type:uuid;not null;column:course_id" json:"courseId"`
+	UserID         *uuid.UUID `gorm:"type:uuid;column:user_id" json:"userId,omitempty"`
+	VideoID        string     `gorm:"type:varchar(255);not null;column:video_id" json:"videoId"`
+	UploadURL      string     `gorm:"type:text;not null;column:upload_url" json:"-"`
+	AuthSignature  string     `gorm:"type:varchar(255);not null;column:auth_signature" json:"-"`
+	AuthExpire     int64      `gorm:"type:bigint;not null;column:auth_expire" json:"-"`
+	LessonName     string     `gorm:"type:varchar(80);not null;column:lesson_name" json:"lessonName"`
+	Description    *string    `gorm:"type:varchar(1000)" json:"description,omitempty"`
+	LessonOrder    *int       `gorm:"type:int;column:lesson_order" json:"order,omitempty"`
+	Active         *bool      `gorm:"type:boolean;column:is_active" json:"isActive,omitempty"`
+	Size           int64      `gorm:"type:bigint;not null" json:"size"`
+	UploadedBytes  int64      `gorm:"type:bigint;not null;default:0;column:uploaded_bytes" json:"uploadedBytes"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'uploading'" json:"status"`
+	LessonID       *uuid.UUID `gorm:"type:uuid;column:lesson_id" json:"lessonId,omitempty"`
+	Error          *string    `gorm:"type:text" json:"error,omitempty"`
+
+	// LessonStatus is the review status resolved for the uploader when the upload started
+	LessonStatus types.ContentStatus `gorm:"type:varchar(20);not null;default:'published';column:lesson_status" json:"lessonStatus"`
+}
+
+// TableName overrides the default table name.
+func (Upload) TableName() string { return "lesson_uploads" }
+
+// UploadProgress is the client-facing view of a proxied upload.
+type UploadProgress struct {
+	Upload
+	Percent   float64   `json:"percent"`
+	ChunkSize int64     `json:"chunkSize"`
+	UploadURL string    `json:"uploadUrl"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (u Upload) progress(uploadURL string) UploadProgress {
+	percent := 0.0
+	if u.Size > 0 {
+		percent = float64(int(float64(u.UploadedBytes)/float64(u.Size)*10000)) / 100
+	}
+	return UploadProgress{
+		Upload:    u,
+		Percent:   percent,
+		ChunkSize: proxyChunkSize,
+		UploadURL: uploadURL,
+		ExpiresAt: time.Unix(u.AuthExpire, 0).UTC(),
+	}
+}
+
+func (u Upload) tusInfo() *bunny.TusUploadInfo {
+	return &bunny.TusUploadInfo{
+		VideoID:                u.VideoID,
+		LessonName:             u.LessonName,
+		AuthorizationSignature: u.AuthSignature,
+		AuthorizationExpire:    u.AuthExpire,
+	}
+}
+
+// UseUploadProxy enables relaying lesson videos to Bunny through the API, so
+// clients never receive Bunny credentials. events may be nil.
+func (h *Handler) UseUploadProxy(events UploadBroadcaster) {
+	h.uploadProxy = true
+	h.uploadEvents = events
+}
+
+type startUploadRequest struct {
+	LessonName  string  `json:"lessonName" binding:"required,notblank,min=3,max=80"`
+	FileSize    int64   `json:"fileSize" binding:"required,gt=0"`
+	Description *string `json:"description" binding:"omitnil,max=1000"`
+	Order       *int    `json:"order" binding:"omitnil,gte=0"`
+	Active      *bool   `json:"isActive"`
+
+	Status *types.ContentStatus `json:"status"`
+}
+
+// StartUpload creates the Bunny video and a TUS upload for it on the server
+// and returns the proxy URL clients send the file to with TUS HEAD and PATCH
+// requests (tus-js-client: pass it as uploadUrl).
+// POST /subscriptions/:subscriptionId/courses/:courseId/lessons/uploads
+func (h *Handler) StartUpload(c *gin.Context) {
+	if !h.requireUploadProxy(c) {
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	var req startUploadRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid upload payload") {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	status, err := publishing.Initial(req.Status, authz.CanPublish(authz.SubjectFrom(usr)))
+	if err != nil {
+		h.respondError(c, err, "invalid lesson status")
+		return
+	}
+
+	course, err := h.ensureCourse(subscriptionID, courseID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+	if course.CollectionID == nil || *course.CollectionID == "" {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "course missing Bunny collection", nil)
+		return
+	}
+
+	limitGB, usedGB, err := subscription.CheckStorage(h.db, subscriptionID, float64(req.FileSize)/(1024*1024*1024))
+	if errors.Is(err, subscription.ErrStorageLimitExceeded) {
+		response.ErrorWithData(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage limit exceeded. Subscription storage limit is %.2fGB, current usage is %.2fGB.", limitGB, usedGB),
+			gin.H{"subscriptionLimitGB": limitGB, "currentUsageGB": usedGB}, nil)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check subscription storage", err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tusInfo, err := h.streamClient.GenerateTusUploadInfo(ctx, req.LessonName, *course.CollectionID, proxyUploadWindowSeconds)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadGateway, "failed to create video", err)
+		return
+	}
+
+	uploadURL, err := h.streamClient.CreateTusUpload(ctx, tusInfo, *course.CollectionID, req.FileSize)
+	if err != nil {
+		h.deleteVideo(tusInfo.VideoID)
+		response.ErrorWithLog(h.logger, c, http.StatusBadGateway, "failed to start upload", err)
+		return
+	}
+
+	upload := Upload{
+		SubscriptionID: subscriptionID,
+		CourseID:       courseID,
+		VideoID:        tusInfo.VideoID,
+		UploadURL:      uploadURL,
+		AuthSignature:  tusInfo.AuthorizationSignature,
+		AuthExpire:     tusInfo.AuthorizationExpire,
+		LessonName:     req.LessonName,
+		Description:    req.Description,
+		LessonOrder:    req.Order,
+		Active:         req.Active,
+		LessonStatus:   status,
+		Size:           req.FileSize,
+		Status:         UploadStatusUploading,
+		UserID:         &usr.ID,
+	}
+	if err := h.db.WithContext(ctx).Create(&upload).Error; err != nil {
+		h.deleteVideo(tusInfo.VideoID)
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to save upload", err)
+		return
+	}
+
+	response.Created(c, upload.progress(fmt.Sprintf("%s/%s/tus", c.Request.URL.Path, upload.ID)), "Upload started")
+}
+
+// GetUploadProgress reports how much of a proxied upload has arrived and, once
+// complete, the lesson that was created.
+// GET /subscriptions/:subscriptionId/courses/:courseId/lessons/uploads/:uploadId
+func (h *Handler) GetUploadProgress(c *gin.Context) {
+	upload, ok := h.loadUpload(c)
+	if !ok {
+		return
+	}
+	response.Success(c, http.StatusOK, upload.progress(c.Request.URL.Path+"/tus"), "", nil)
+}
+
+// HeadUpload answers a TUS HEAD with the number of bytes Bunny has received,
+// letting clients resume after an interruption.
+// HEAD /subscriptions/:subscriptionId/courses/:courseId/lessons/uploads/:uploadId/tus
+func (h *Handler) HeadUpload(c *gin.Context) {
+	c.Header("Tus-Resumable", tusResumable)
+	c.Header("Cache-Control", "no-store")
+
+	upload, ok := h.loadUpload(c)
+	if !ok {
+		return
+	}
+
+	if upload.Status == UploadStatusUploading {
+		// A chunk interrupted mid-way may still have reached Bunny
+		offset, err := h.streamClient.TusOffset(c.Request.Context(), upload.tusInfo(), upload.UploadURL)
+		if err != nil {
+			h.logger.Warn("failed to read upstream upload offset", "uploadId", upload.ID, "error", err)
+		} else if offset != upload.UploadedBytes {
+			h.recordOffset(c.Request.Context(), &upload, offset)
+		}
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.UploadedBytes, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload relays one TUS chunk to Bunny. The lesson is created when the
+// last byte arrives.
+// PATCH /subscriptions/:subscriptionId/courses/:courseId/lessons/uploads/:uploadId/tus
+func (h *Handler) PatchUpload(c *gin.Context) {
+	c.Header("Tus-Resumable", tusResumable)
+
+	upload, ok := h.loadUpload(c)
+	if !ok {
+		return
+	}
+	if upload.Status != UploadStatusUploading {
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, fmt.Sprintf("upload is already %s", upload.Status), nil)
+		return
+	}
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		response.ErrorWithLog(h.logger, c, http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream", nil)
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Upload-Offset header is required", err)
+		return
+	}
+	if offset != upload.UploadedBytes {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.UploadedBytes, 10))
+		response.ErrorWithLog(h.logger, c, http.StatusConflict,
+			fmt.Sprintf("Upload-Offset must be %d", upload.UploadedBytes), nil)
+		return
+	}
+
+	size := c.Request.ContentLength
+	if size < 0 {
+		response.ErrorWithLog(h.logger, c, http.StatusLengthRequired, "Content-Length header is required", nil)
+		return
+	}
+	if offset+size > upload.Size {
+		response.ErrorWithLog(h.logger, c, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("chunk exceeds the declared upload size of %d bytes", upload.Size), nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	newOffset, err := h.streamClient.TusPatch(ctx, upload.tusInfo(), upload.UploadURL, offset, io.LimitReader(c.Request.Body, size), size)
+	if errors.Is(err, bunny.ErrTusOffsetMismatch) {
+		if current, headErr := h.streamClient.TusOffset(ctx, upload.tusInfo(), upload.UploadURL); headErr == nil {
+			h.recordOffset(ctx, &upload, current)
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(upload.UploadedBytes, 10))
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "upload offset changed, resume from Upload-Offset", err)
+		return
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadGateway, "failed to relay upload chunk", err)
+		return
+	}
+
+	h.recordOffset(ctx, &upload, newOffset)
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.UploadedBytes, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// AbortUpload cancels an unfinished proxied upload and deletes its video.
+// DELETE /subscriptions/:subscriptionId/courses/:courseId/lessons/uploads/:uploadId
+func (h *Handler) AbortUpload(c *gin.Context) {
+	upload, ok := h.loadUpload(c)
+	if !ok {
+		return
+	}
+	if upload.Status == UploadStatusCompleted {
+		response.ErrorWithLog(h.logger, c, http.StatusConflict, "upload is already completed", nil)
+		return
+	}
+
+	if err := h.db.WithContext(c.Request.Context()).Delete(&Upload{}, "id = ?", upload.ID).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to abort upload", err)
+		return
+	}
+	h.deleteVideo(upload.VideoID)
+
+	response.NoContent(c, "Upload aborted")
+}
+
+func (h *Handler) requireUploadProxy(c *gin.Context) bool {
+	if !h.uploadProxy || h.streamClient == nil {
+		response.ErrorWithLog(h.logger, c, http.StatusServiceUnavailable, "Upload proxy is not enabled.", nil)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) loadUpload(c *gin.Context) (Upload, bool) {
+	if !h.requireUploadProxy(c) {
+		return Upload{}, false
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return Upload{}, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return Upload{}, false
+	}
+
+	uploadID, err := uuid.Parse(c.Param("uploadId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid upload id", err)
+		return Upload{}, false
+	}
+
+	var upload Upload
+	err = h.db.WithContext(c.Request.Context()).
+		Where("id = ? AND subscription_id = ? AND course_id = ?", uploadID, subscriptionID, courseID).
+		First(&upload).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.ErrorWithLog(h.logger, c, http.StatusNotFound, "Upload not found.", err)
+		return Upload{}, false
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load upload", err)
+		return Upload{}, false
+	}
+	return upload, true
+}
+
+// recordOffset stores the received byte count, tells the uploader, and creates
+// the lesson once the upload is complete.
+func (h *Handler) recordOffset(ctx context.Context, upload *Upload, offset int64) {
+	if err := h.db.WithContext(ctx).Model(&Upload{}).Where("id = ?", upload.ID).
+		Updates(map[string]interface{}{"uploaded_bytes": offset, "updated_at": time.Now().UTC()}).Error; err != nil {
+		h.logger.Error("failed to record upload progress", "uploadId", upload.ID, "error", err)
+	}
+	upload.UploadedBytes = offset
+
+	progress := upload.progress("")
+	h.emitUpload(upload, EventUploadProgress, gin.H{
+		"uploadId":      upload.ID,
+		"courseId":      upload.CourseID,
+		"videoId":       upload.VideoID,
+		"uploadedBytes": upload.UploadedBytes,
+		"size":          upload.Size,
+		"percent":       progress.Percent,
+	})
+
+	if offset >= upload.Size {
+		h.finalizeUpload(ctx, upload)
+	}
+}
+
+// finalizeUpload creates the lesson of a completed upload. The status update
+// is conditional so concurrent requests finishing the same upload create it once.
+func (h *Handler) finalizeUpload(ctx context.Context, upload *Upload) {
+	db := h.db.WithContext(ctx)
+	claim := db.Model(&Upload{}).Where("id = ? AND status = ?", upload.ID, UploadStatusUploading).
+		Updates(map[string]interface{}{"status": UploadStatusCompleted, "updated_at": time.Now().UTC()})
+	if claim.Error != nil {
+		h.logger.Error("failed to complete upload", "uploadId", upload.ID, "error", claim.Error)
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return
+	}
+
+	input := CreateInput{
+		CourseID:    upload.CourseID,
+		VideoID:     upload.VideoID,
+		Name:        upload.LessonName,
+		Description: upload.Description,
+		Order:       upload.LessonOrder,
+		Active:      upload.Active,
+		Status:      upload.LessonStatus,
+	}
+	if upload.UserID != nil {
+		input.SubmittedBy = publishing.SubmittedBy(upload.LessonStatus, *upload.UserID)
+	}
+	lesson, err := Create(db, input)
+	if err != nil {
+		h.logger.Error("failed to create lesson for upload", "uploadId", upload.ID, "videoId", upload.VideoID, "error", err)
+		message := err.Error()
+		if updateErr := db.Model(&Upload{}).Where("id = ?", upload.ID).
+			Updates(map[string]interface{}{"status": UploadStatusFailed, "error": message}).Error; updateErr != nil {
+			h.logger.Error("failed to mark upload as failed", "uploadId", upload.ID, "error", updateErr)
+		}
+		upload.Status = UploadStatusFailed
+		upload.Error = &message
+		h.emitUpload(upload, EventUploadFailed, gin.H{"uploadId": upload.ID, "error": message})
+		return
+	}
+
+	if err := db.Model(&Upload{}).Where("id = ?", upload.ID).Update("lesson_id", lesson.ID).Error; err != nil {
+		h.logger.Error("failed to link upload to lesson", "uploadId", upload.ID, "lessonId", lesson.ID, "error", err)
+	}
+	upload.Status = UploadStatusCompleted
+	upload.LessonID = &lesson.ID
+
+	h.refreshCourseStorage(ctx, upload.CourseID)
+	coursefeature.InvalidateCache(ctx, h.queryCache, upload.SubscriptionID)
+
+	h.emitUpload(upload, EventUploadCompleted, gin.H{"uploadId": upload.ID, "lesson": lesson})
+}
+
+func (h *Handler) emitUpload(upload *Upload, event string, payload any) {
+	if h.uploadEvents == nil || upload.UserID == nil {
+		return
+	}
+	h.uploadEvents.EmitToUser(*upload.UserID, event, payload)
+}
+
+// deleteVideo removes the Bunny video of an upload that will not become a lesson.
+func (h *Handler) deleteVideo(videoID string) {
+	if err := h.streamClient.DeleteVideo(context.Background(), videoID); err != nil {
+		h.logger.Error("failed to delete video of abandoned upload", "videoId", videoID, "error", err)
+	}
+}
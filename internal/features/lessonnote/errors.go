@@ -0,0 +1,25 @@
+package lessonnote
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrNoteNotFound    = errors.New("note not found")
+	ErrCourseNotFound  = errors.New("course not found")
+	ErrLessonNotFound  = errors.New("lesson not found")
+	ErrPositionInvalid = errors.New("position is past the end of the lesson")
+	ErrContentRequired = errors.New("note content is required")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrNoteNotFound, Status: http.StatusNotFound, Code: "NOTE_NOT_FOUND", Message: "Note not found."},
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrPositionInvalid, Status: http.StatusBadRequest, Code: "NOTE_POSITION_INVALID", Message: "Position is past the end of the lesson."},
+	{Err: ErrContentRequired, Status: http.StatusBadRequest, Code: "NOTE_CONTENT_REQUIRED", Message: "Note content is required."},
+}
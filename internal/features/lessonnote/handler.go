@@ -0,0 +1,303 @@
+package lessonnote
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/contentsync"
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/export"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Export formats of a course's notes.
+const (
+	formatMarkdown = "markdown"
+	formatPDF      = "pdf"
+)
+
+// Handler processes lesson note HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a lesson note handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+type createRequest struct {
+	PositionSeconds int    `json:"positionSeconds" binding:"gte=0"`
+	Content         string `json:"content" binding:"required,notblank,max=5000"`
+}
+
+type updateRequest struct {
+	PositionSeconds *int    `json:"positionSeconds" binding:"omitnil,gte=0"`
+	Content         *string `json:"content" binding:"omitnil,notblank,max=5000"`
+}
+
+// List returns the current user's notes in a course, optionally for one lesson
+// or matching ?q=.
+func (h *Handler) List(c *gin.Context) {
+	_, parent, usr, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	lessonID, err := optionalID(c.Query("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return
+	}
+
+	params := pagination.Extract(c)
+	entries, total, err := List(h.db, ListFilters{
+		UserID:   usr.ID,
+		CourseID: parent.ID,
+		LessonID: lessonID,
+		Keyword:  strings.TrimSpace(c.Query("q")),
+	}, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list notes", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, entries, "", pagination.MetadataFrom(total, params))
+}
+
+// Create pins a note to a point in a lesson.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, parent, usr, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return
+	}
+
+	var req createRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid note payload") {
+		return
+	}
+
+	if !authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		target, err := lesson.Get(h.db, lessonID)
+		if err != nil || target.CourseID != parent.ID || !target.VisibleToStudents() {
+			h.respondError(c, ErrLessonNotFound, "failed to load lesson")
+			return
+		}
+	}
+
+	note := Note{
+		UserID:          usr.ID,
+		SubscriptionID:  subscriptionID,
+		CourseID:        parent.ID,
+		LessonID:        lessonID,
+		PositionSeconds: req.PositionSeconds,
+		Content:         req.Content,
+	}
+	if err := Save(h.db, &note); err != nil {
+		h.respondError(c, err, "failed to create note")
+		return
+	}
+
+	response.Created(c, note, "")
+}
+
+// Update edits one of the current user's notes.
+func (h *Handler) Update(c *gin.Context) {
+	_, parent, usr, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid note id", err)
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid note payload") {
+		return
+	}
+
+	note, err := Get(h.db, usr.ID, parent.ID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load note")
+		return
+	}
+
+	if req.PositionSeconds != nil {
+		note.PositionSeconds = *req.PositionSeconds
+	}
+	if req.Content != nil {
+		note.Content = *req.Content
+	}
+
+	if err := Save(h.db, &note); err != nil {
+		h.respondError(c, err, "failed to update note")
+		return
+	}
+
+	response.Success(c, http.StatusOK, note, "", nil)
+}
+
+// Delete removes one of the current user's notes.
+func (h *Handler) Delete(c *gin.Context) {
+	_, parent, usr, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid note id", err)
+		return
+	}
+
+	if err := Delete(h.db, usr.ID, parent.ID, id); err != nil {
+		h.respondError(c, err, "failed to delete note")
+		return
+	}
+
+	response.NoContent(c, "Note deleted.")
+}
+
+// Export downloads the current user's notes in a course as Markdown
+// (?format=markdown, the default) or PDF.
+func (h *Handler) Export(c *gin.Context) {
+	_, parent, usr, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(c.Query("format")))
+	if format == "" {
+		format = formatMarkdown
+	}
+	if format != formatMarkdown && format != formatPDF {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "format must be markdown or pdf", nil)
+		return
+	}
+
+	entries, _, err := List(h.db, ListFilters{UserID: usr.ID, CourseID: parent.ID}, pagination.Params{})
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load notes", err)
+		return
+	}
+
+	title := "Notes: " + parent.Name
+	filename := fmt.Sprintf("notes-%s", time.Now().UTC().Format("20060102"))
+	c.Header("Cache-Control", "no-store")
+
+	if format == formatPDF {
+		doc := export.NewPDF()
+		doc.Heading(title)
+		for i, entry := range entries {
+			if i == 0 || entry.LessonID != entries[i-1].LessonID {
+				doc.Heading(entry.LessonName)
+			}
+			doc.Caption(Timestamp(entry.PositionSeconds))
+			doc.Paragraph(entry.Content)
+		}
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".pdf"))
+		c.Status(http.StatusOK)
+		if _, err := doc.WriteTo(c.Writer); err != nil {
+			h.logger.Error("failed to write notes export", "courseId", parent.ID, "error", err)
+		}
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	for i, entry := range entries {
+		if i == 0 || entry.LessonID != entries[i-1].LessonID {
+			fmt.Fprintf(&b, "\n## %s\n\n", entry.LessonName)
+		}
+		content := strings.ReplaceAll(strings.TrimSpace(entry.Content), "\n", "\n  ")
+		fmt.Fprintf(&b, "- **[%s]** %s\n", Timestamp(entry.PositionSeconds), content)
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".md"))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(b.String()))
+}
+
+// resolveCourse loads the course from the path. Students only reach published
+// courses granted by their access groups.
+func (h *Handler) resolveCourse(c *gin.Context) (uuid.UUID, course.Course, *middleware.User, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, course.Course{}, nil, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return uuid.Nil, course.Course{}, nil, false
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return uuid.Nil, course.Course{}, nil, false
+	}
+
+	parent, err := course.GetForSubscription(h.db, courseID, subscriptionID)
+	if errors.Is(err, course.ErrCourseNotFound) {
+		err = ErrCourseNotFound
+	}
+	if err == nil && !authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		if !parent.VisibleToStudents() {
+			err = ErrCourseNotFound
+		} else {
+			var accessible []string
+			if accessible, err = contentsync.AccessibleCourseIDs(h.db, subscriptionID, usr.ID); err == nil &&
+				!slices.Contains(accessible, courseID.String()) {
+				err = ErrCourseNotFound
+			}
+		}
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return uuid.Nil, course.Course{}, nil, false
+	}
+
+	return subscriptionID, parent, usr, true
+}
+
+func optionalID(raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
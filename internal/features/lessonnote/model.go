@@ -0,0 +1,140 @@
+package lessonnote
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Note is a student's private note pinned to a point in a lesson's video.
+type Note struct {
+	types.BaseModel
+
+	UserID          uuid.UUID `gorm:"type:uuid;not null;column:user_id;index:idx_lesson_notes_user_course,priority:1" json:"userId"`
+	SubscriptionID  uuid.UUID `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	CourseID        uuid.UUID `gorm:"type:uuid;not null;column:course_id;index:idx_lesson_notes_user_course,priority:2" json:"courseId"`
+	LessonID        uuid.UUID `gorm:"type:uuid;not null;column:lesson_id" json:"lessonId"`
+	PositionSeconds int       `gorm:"type:int;not null;default:0;column:position_seconds" json:"positionSeconds"`
+	Content         string    `gorm:"type:text;not null" json:"content"`
+}
+
+// TableName overrides the default table name.
+func (Note) TableName() string { return "lesson_notes" }
+
+// Entry is a note with the lesson it belongs to.
+type Entry struct {
+	Note
+	LessonName string `json:"lessonName"`
+}
+
+// ListFilters narrows a user's notes in a course.
+type ListFilters struct {
+	UserID   uuid.UUID
+	CourseID uuid.UUID
+	LessonID *uuid.UUID
+	Keyword  string
+}
+
+// lessonRow is the part of a lesson notes are checked against.
+type lessonRow struct {
+	ID       uuid.UUID
+	CourseID uuid.UUID
+	Duration int
+}
+
+// List returns the user's notes in lesson order, then by position. A zero
+// params.Limit returns every note, as used by exports.
+func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Entry, int64, error) {
+	query := db.Table("lesson_notes").
+		Joins("JOIN lessons ON lessons.id = lesson_notes.lesson_id").
+		Where("lesson_notes.user_id = ? AND lesson_notes.course_id = ?", filters.UserID, filters.CourseID)
+	if filters.LessonID != nil {
+		query = query.Where("lesson_notes.lesson_id = ?", *filters.LessonID)
+	}
+	if filters.Keyword != "" {
+		query = query.Where("LOWER(lesson_notes.content) LIKE ?", "%"+strings.ToLower(filters.Keyword)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.
+		Select("lesson_notes.*, lessons.name AS lesson_name").
+		Order("lessons.\"order\" ASC, lesson_notes.lesson_id, lesson_notes.position_seconds ASC, lesson_notes.created_at ASC")
+	if params.Limit > 0 {
+		query = query.Offset(params.Skip).Limit(params.Limit)
+	}
+
+	entries := make([]Entry, 0)
+	err := query.Scan(&entries).Error
+	return entries, total, err
+}
+
+// Get loads one of the user's notes.
+func Get(db *gorm.DB, userID, courseID, id uuid.UUID) (Note, error) {
+	var note Note
+	if err := db.First(&note, "id = ? AND user_id = ? AND course_id = ?", id, userID, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return note, ErrNoteNotFound
+		}
+		return note, err
+	}
+	return note, nil
+}
+
+// Save validates and stores a new or edited note.
+func Save(db *gorm.DB, note *Note) error {
+	note.Content = strings.TrimSpace(note.Content)
+	if note.Content == "" {
+		return ErrContentRequired
+	}
+
+	var lesson lessonRow
+	if err := db.Table("lessons").Select("id, course_id, duration").
+		Where("id = ? AND course_id = ?", note.LessonID, note.CourseID).
+		Take(&lesson).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLessonNotFound
+		}
+		return err
+	}
+	if note.PositionSeconds < 0 || (lesson.Duration > 0 && note.PositionSeconds > lesson.Duration) {
+		return ErrPositionInvalid
+	}
+
+	if note.ID == uuid.Nil {
+		return db.Create(note).Error
+	}
+	return db.Save(note).Error
+}
+
+// Delete removes one of the user's notes.
+func Delete(db *gorm.DB, userID, courseID, id uuid.UUID) error {
+	result := db.Delete(&Note{}, "id = ? AND user_id = ? AND course_id = ?", id, userID, courseID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNoteNotFound
+	}
+	return nil
+}
+
+// Timestamp renders a position as m:ss, or h:mm:ss for long videos.
+func Timestamp(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+	hours, minutes, secs := int(d.Hours()), int(d.Minutes())%60, seconds%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
@@ -0,0 +1,22 @@
+package lessonnote
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches lesson note endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll []gin.HandlerFunc) {
+	course := router.Group("/subscriptions/:subscriptionId/courses/:courseId")
+
+	course.GET("/notes", append(acAll, handler.List)...)
+	course.GET("/notes/export", append(acAll, handler.Export)...)
+	course.PUT("/notes/:noteId", append(acAll, handler.Update)...)
+	course.DELETE("/notes/:noteId", append(acAll, handler.Delete)...)
+	course.POST("/lessons/:lessonId/notes", append(acAll, handler.Create)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Entry{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Note{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Note{}})
+}
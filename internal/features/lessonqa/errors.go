@@ -0,0 +1,31 @@
+package lessonqa
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrAnswerNotFound   = errors.New("answer not found")
+	ErrCourseNotFound   = errors.New("course not found")
+	ErrLessonNotFound   = errors.New("lesson not found")
+	ErrTitleRequired    = errors.New("question title is required")
+	ErrBodyRequired     = errors.New("answer body is required")
+	ErrOwnPost          = errors.New("cannot upvote your own post")
+	ErrUnauthorized     = errors.New("not authorized to perform this action")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrQuestionNotFound, Status: http.StatusNotFound, Code: "QUESTION_NOT_FOUND", Message: "Question not found."},
+	{Err: ErrAnswerNotFound, Status: http.StatusNotFound, Code: "ANSWER_NOT_FOUND", Message: "Answer not found."},
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrTitleRequired, Status: http.StatusBadRequest, Code: "QUESTION_TITLE_REQUIRED", Message: "Question title is required."},
+	{Err: ErrBodyRequired, Status: http.StatusBadRequest, Code: "POST_BODY_REQUIRED", Message: "Answer body is required."},
+	{Err: ErrOwnPost, Status: http.StatusBadRequest, Code: "OWN_POST", Message: "You cannot upvote your own post."},
+	{Err: ErrUnauthorized, Status: http.StatusForbidden, Message: "Not authorized."},
+}
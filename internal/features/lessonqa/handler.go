@@ -0,0 +1,473 @@
+package lessonqa
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/contentsync"
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes lesson Q&A HTTP requests.
+type Handler struct {
+	db       *gorm.DB
+	logger   *slog.Logger
+	notifier *notification.Service
+}
+
+// NewHandler constructs a lesson Q&A handler instance. notifier may be nil to
+// disable answer notifications.
+func NewHandler(db *gorm.DB, logger *slog.Logger, notifier *notification.Service) *Handler {
+	return &Handler{db: db, logger: logger, notifier: notifier}
+}
+
+type askRequest struct {
+	Title string  `json:"title" binding:"required,notblank,max=200"`
+	Body  *string `json:"body" binding:"omitnil,max=5000"`
+}
+
+type answerRequest struct {
+	Body string `json:"body" binding:"required,notblank,max=5000"`
+}
+
+type acceptRequest struct {
+	AnswerID *string `json:"answerId"`
+}
+
+// scope is the course and lesson a request works in.
+type scope struct {
+	subscriptionID uuid.UUID
+	course         course.Course
+	lesson         lesson.Lesson
+	user           *middleware.User
+}
+
+// List returns a lesson's questions, filtered by ?status= (unanswered,
+// unresolved or resolved) and ?q=, newest first or by upvotes with ?sort=top.
+func (h *Handler) List(c *gin.Context) {
+	s, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+	h.list(c, s, &s.lesson.ID, "")
+}
+
+// ListCourse is the instructors' queue across a course's lessons. It takes the
+// same filters as List but shows unanswered questions unless ?status= is given.
+func (h *Handler) ListCourse(c *gin.Context) {
+	s, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+	h.list(c, s, nil, StatusUnanswered)
+}
+
+func (h *Handler) list(c *gin.Context, s scope, lessonID *uuid.UUID, defaultStatus string) {
+	status := c.DefaultQuery("status", defaultStatus)
+	if status != "" && status != StatusUnanswered && status != StatusUnresolved && status != StatusResolved {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "status must be unanswered, unresolved or resolved", nil)
+		return
+	}
+	sortBy := c.DefaultQuery("sort", SortNewest)
+	if sortBy != SortNewest && sortBy != SortTop {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "sort must be newest or top", nil)
+		return
+	}
+
+	params := pagination.Extract(c)
+	questions, total, err := List(h.db, ListFilters{
+		CourseID: s.course.ID,
+		LessonID: lessonID,
+		Status:   status,
+		Sort:     sortBy,
+		Keyword:  strings.TrimSpace(c.Query("q")),
+	}, params)
+	if err == nil {
+		err = MarkUpvoted(h.db, s.user.ID, questions)
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list questions", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, questions, "", pagination.MetadataFrom(total, params))
+}
+
+// Get returns a question with its answers.
+func (h *Handler) Get(c *gin.Context) {
+	s, question, ok := h.resolveQuestion(c)
+	if !ok {
+		return
+	}
+
+	answers, err := Answers(h.db, question)
+	if err == nil {
+		err = MarkAnswersUpvoted(h.db, s.user.ID, answers)
+	}
+	if err == nil {
+		questions := []Question{question}
+		err = MarkUpvoted(h.db, s.user.ID, questions)
+		question = questions[0]
+	}
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load answers", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, Detail{Question: question, Answers: answers}, "", nil)
+}
+
+// Ask posts a question on the lesson.
+func (h *Handler) Ask(c *gin.Context) {
+	s, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	var req askRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid question payload") {
+		return
+	}
+
+	question := Question{
+		SubscriptionID: s.subscriptionID,
+		CourseID:       s.course.ID,
+		LessonID:       s.lesson.ID,
+		UserID:         s.user.ID,
+		UserName:       s.user.FullName,
+		Title:          req.Title,
+		Body:           request.Trimmed(req.Body),
+	}
+	if err := CreateQuestion(h.db, &question); err != nil {
+		h.respondError(c, err, "failed to create question")
+		return
+	}
+
+	response.Created(c, question, "")
+}
+
+// Delete removes a question. Authors and moderators may delete it.
+func (h *Handler) Delete(c *gin.Context) {
+	s, question, ok := h.resolveQuestion(c)
+	if !ok {
+		return
+	}
+
+	if question.UserID != s.user.ID && !authz.CanModerate(authz.SubjectFrom(s.user)) {
+		h.respondError(c, ErrUnauthorized, "not authorized")
+		return
+	}
+
+	if err := DeleteQuestion(h.db, question); err != nil {
+		h.respondError(c, err, "failed to delete question")
+		return
+	}
+
+	response.NoContent(c, "Question deleted.")
+}
+
+// Answer replies to a question and notifies its author.
+func (h *Handler) Answer(c *gin.Context) {
+	s, question, ok := h.resolveQuestion(c)
+	if !ok {
+		return
+	}
+
+	var req answerRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid answer payload") {
+		return
+	}
+
+	answer := Answer{
+		QuestionID: question.ID,
+		UserID:     s.user.ID,
+		UserName:   s.user.FullName,
+		UserType:   string(s.user.UserType),
+		Body:       req.Body,
+	}
+	if err := CreateAnswer(h.db, &answer); err != nil {
+		h.respondError(c, err, "failed to create answer")
+		return
+	}
+
+	if h.notifier != nil && question.UserID != s.user.ID {
+		h.notifier.Notify([]uuid.UUID{question.UserID}, notification.Notification{
+			SubscriptionID: &s.subscriptionID,
+			CourseID:       &s.course.ID,
+			LessonID:       &s.lesson.ID,
+			Type:           notification.TypeQuestionAnswered,
+			Title:          fmt.Sprintf("%s answered your question", s.user.FullName),
+			Message:        question.Title,
+			ActorName:      s.user.FullName,
+		})
+	}
+
+	response.Created(c, answer, "")
+}
+
+// DeleteAnswer removes an answer. Authors and moderators may delete it.
+func (h *Handler) DeleteAnswer(c *gin.Context) {
+	s, question, answer, ok := h.resolveAnswer(c)
+	if !ok {
+		return
+	}
+
+	if answer.UserID != s.user.ID && !authz.CanModerate(authz.SubjectFrom(s.user)) {
+		h.respondError(c, ErrUnauthorized, "not authorized")
+		return
+	}
+
+	if err := DeleteAnswer(h.db, question, answer.ID); err != nil {
+		h.respondError(c, err, "failed to delete answer")
+		return
+	}
+
+	response.NoContent(c, "Answer deleted.")
+}
+
+// Accept sets or clears (answerId: null) the question's accepted answer. Only
+// the question's author and moderators may choose it.
+func (h *Handler) Accept(c *gin.Context) {
+	s, question, ok := h.resolveQuestion(c)
+	if !ok {
+		return
+	}
+
+	if question.UserID != s.user.ID && !authz.CanModerate(authz.SubjectFrom(s.user)) {
+		h.respondError(c, ErrUnauthorized, "not authorized")
+		return
+	}
+
+	var req acceptRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid accept payload") {
+		return
+	}
+
+	var answerID *uuid.UUID
+	if req.AnswerID != nil {
+		parsed, err := uuid.Parse(*req.AnswerID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid answer id", err)
+			return
+		}
+		answerID = &parsed
+	}
+
+	if err := Accept(h.db, &question, answerID); err != nil {
+		h.respondError(c, err, "failed to accept answer")
+		return
+	}
+
+	response.Success(c, http.StatusOK, question, "", nil)
+}
+
+// Endorse marks an answer as vouched for by staff.
+func (h *Handler) Endorse(c *gin.Context) {
+	h.endorse(c, true)
+}
+
+// Unendorse withdraws a staff endorsement.
+func (h *Handler) Unendorse(c *gin.Context) {
+	h.endorse(c, false)
+}
+
+func (h *Handler) endorse(c *gin.Context, endorsed bool) {
+	s, _, answer, ok := h.resolveAnswer(c)
+	if !ok {
+		return
+	}
+
+	var by *uuid.UUID
+	if endorsed {
+		by = &s.user.ID
+	}
+	if err := Endorse(h.db, &answer, by); err != nil {
+		h.respondError(c, err, "failed to endorse answer")
+		return
+	}
+
+	response.Success(c, http.StatusOK, answer, "", nil)
+}
+
+// UpvoteQuestion adds the current user's upvote to a question.
+func (h *Handler) UpvoteQuestion(c *gin.Context) {
+	h.voteQuestion(c, true)
+}
+
+// RemoveQuestionUpvote withdraws the current user's upvote of a question.
+func (h *Handler) RemoveQuestionUpvote(c *gin.Context) {
+	h.voteQuestion(c, false)
+}
+
+func (h *Handler) voteQuestion(c *gin.Context, up bool) {
+	s, question, ok := h.resolveQuestion(c)
+	if !ok {
+		return
+	}
+
+	upvotes, err := VoteQuestion(h.db, question, s.user.ID, up)
+	if err != nil {
+		h.respondError(c, err, "failed to vote")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"upvotes": upvotes, "upvoted": up}, "", nil)
+}
+
+// UpvoteAnswer adds the current user's upvote to an answer.
+func (h *Handler) UpvoteAnswer(c *gin.Context) {
+	h.voteAnswer(c, true)
+}
+
+// RemoveAnswerUpvote withdraws the current user's upvote of an answer.
+func (h *Handler) RemoveAnswerUpvote(c *gin.Context) {
+	h.voteAnswer(c, false)
+}
+
+func (h *Handler) voteAnswer(c *gin.Context, up bool) {
+	s, _, answer, ok := h.resolveAnswer(c)
+	if !ok {
+		return
+	}
+
+	upvotes, err := VoteAnswer(h.db, answer, s.user.ID, up)
+	if err != nil {
+		h.respondError(c, err, "failed to vote")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"upvotes": upvotes, "upvoted": up}, "", nil)
+}
+
+// resolveCourse loads the course from the path. Students only reach published
+// courses granted by their access groups.
+func (h *Handler) resolveCourse(c *gin.Context) (scope, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return scope{}, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return scope{}, false
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return scope{}, false
+	}
+
+	parent, err := course.GetForSubscription(h.db, courseID, subscriptionID)
+	if errors.Is(err, course.ErrCourseNotFound) {
+		err = ErrCourseNotFound
+	}
+	if err == nil && !authz.Can(authz.SubjectFrom(usr), authz.PermContentManage) {
+		if !parent.VisibleToStudents() {
+			err = ErrCourseNotFound
+		} else {
+			var accessible []string
+			if accessible, err = contentsync.AccessibleCourseIDs(h.db, subscriptionID, usr.ID); err == nil &&
+				!slices.Contains(accessible, courseID.String()) {
+				err = ErrCourseNotFound
+			}
+		}
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return scope{}, false
+	}
+
+	return scope{subscriptionID: subscriptionID, course: parent, user: usr}, true
+}
+
+// resolveLesson loads the course and lesson from the path. Students only reach
+// published lessons.
+func (h *Handler) resolveLesson(c *gin.Context) (scope, bool) {
+	s, ok := h.resolveCourse(c)
+	if !ok {
+		return scope{}, false
+	}
+
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return scope{}, false
+	}
+
+	s.lesson, err = lesson.Get(h.db, lessonID)
+	if errors.Is(err, lesson.ErrLessonNotFound) || (err == nil && (s.lesson.CourseID != s.course.ID ||
+		(!s.lesson.VisibleToStudents() && !authz.Can(authz.SubjectFrom(s.user), authz.PermContentManage)))) {
+		err = ErrLessonNotFound
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return scope{}, false
+	}
+
+	return s, true
+}
+
+func (h *Handler) resolveQuestion(c *gin.Context) (scope, Question, bool) {
+	s, ok := h.resolveLesson(c)
+	if !ok {
+		return scope{}, Question{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("questionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid question id", err)
+		return scope{}, Question{}, false
+	}
+
+	question, err := GetQuestion(h.db, s.lesson.ID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load question")
+		return scope{}, Question{}, false
+	}
+
+	return s, question, true
+}
+
+func (h *Handler) resolveAnswer(c *gin.Context) (scope, Question, Answer, bool) {
+	s, question, ok := h.resolveQuestion(c)
+	if !ok {
+		return scope{}, Question{}, Answer{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("answerId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid answer id", err)
+		return scope{}, Question{}, Answer{}, false
+	}
+
+	answer, err := GetAnswer(h.db, question.ID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load answer")
+		return scope{}, Question{}, Answer{}, false
+	}
+
+	return s, question, answer, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,376 @@
+package lessonqa
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Question filters by answer state.
+const (
+	StatusUnanswered = "unanswered" // no answers yet
+	StatusUnresolved = "unresolved" // no accepted answer
+	StatusResolved   = "resolved"   // has an accepted answer
+)
+
+// Question orderings.
+const (
+	SortNewest = "newest"
+	SortTop    = "top"
+)
+
+// Question is a student's question on a lesson.
+type Question struct {
+	types.BaseModel
+
+	SubscriptionID   uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	CourseID         uuid.UUID  `gorm:"type:uuid;not null;column:course_id;index" json:"courseId"`
+	LessonID         uuid.UUID  `gorm:"type:uuid;not null;column:lesson_id;index" json:"lessonId"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;column:user_id" json:"userId"`
+	UserName         string     `gorm:"type:varchar(255);not null;column:user_name" json:"userName"`
+	Title            string     `gorm:"type:varchar(200);not null" json:"title"`
+	Body             *string    `gorm:"type:text" json:"body,omitempty"`
+	AcceptedAnswerID *uuid.UUID `gorm:"type:uuid;column:accepted_answer_id" json:"acceptedAnswerId,omitempty"`
+	AnswerCount      int        `gorm:"type:int;not null;default:0;column:answer_count" json:"answerCount"`
+	Upvotes          int        `gorm:"type:int;not null;default:0" json:"upvotes"`
+
+	// Set per user when listing
+	Upvoted bool `gorm:"-" json:"upvoted"`
+}
+
+// TableName overrides the default table name.
+func (Question) TableName() string { return "lesson_questions" }
+
+// Answer is a reply to a question. Staff may endorse answers they vouch for.
+type Answer struct {
+	types.BaseModel
+
+	QuestionID uuid.UUID  `gorm:"type:uuid;not null;column:question_id;index" json:"questionId"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;column:user_id" json:"userId"`
+	UserName   string     `gorm:"type:varchar(255);not null;column:user_name" json:"userName"`
+	UserType   string     `gorm:"type:varchar(20);not null;column:user_type" json:"userType"`
+	Body       string     `gorm:"type:text;not null" json:"body"`
+	Endorsed   bool       `gorm:"type:boolean;not null;default:false;column:is_endorsed" json:"endorsed"`
+	EndorsedBy *uuid.UUID `gorm:"type:uuid;column:endorsed_by" json:"endorsedBy,omitempty"`
+	Upvotes    int        `gorm:"type:int;not null;default:0" json:"upvotes"`
+
+	// Set when loading a question's answers
+	Accepted bool `gorm:"-" json:"accepted"`
+	Upvoted  bool `gorm:"-" json:"upvoted"`
+}
+
+// TableName overrides the default table name.
+func (Answer) TableName() string { return "lesson_answers" }
+
+// QuestionVote records a user's upvote of a question.
+type QuestionVote struct {
+	QuestionID uuid.UUID `gorm:"type:uuid;primaryKey;column:question_id"`
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// TableName overrides the default table name.
+func (QuestionVote) TableName() string { return "lesson_question_votes" }
+
+// AnswerVote records a user's upvote of an answer.
+type AnswerVote struct {
+	AnswerID  uuid.UUID `gorm:"type:uuid;primaryKey;column:answer_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName overrides the default table name.
+func (AnswerVote) TableName() string { return "lesson_answer_votes" }
+
+// Detail is a question with its answers.
+type Detail struct {
+	Question
+	Answers []Answer `json:"answers"`
+}
+
+// ListFilters narrows the questions of a course or one of its lessons.
+type ListFilters struct {
+	CourseID uuid.UUID
+	LessonID *uuid.UUID
+	Status   string
+	Sort     string
+	Keyword  string
+}
+
+// List returns questions matching the filters, newest first or by upvotes.
+func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Question, int64, error) {
+	query := db.Model(&Question{}).Where("course_id = ?", filters.CourseID)
+	if filters.LessonID != nil {
+		query = query.Where("lesson_id = ?", *filters.LessonID)
+	}
+	switch filters.Status {
+	case StatusUnanswered:
+		query = query.Where("answer_count = 0")
+	case StatusUnresolved:
+		query = query.Where("accepted_answer_id IS NULL")
+	case StatusResolved:
+		query = query.Where("accepted_answer_id IS NOT NULL")
+	}
+	if filters.Keyword != "" {
+		keyword := "%" + strings.ToLower(filters.Keyword) + "%"
+		query = query.Where("LOWER(title) LIKE ? OR LOWER(COALESCE(body, '')) LIKE ?", keyword, keyword)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filters.Sort == SortTop {
+		query = query.Order("upvotes DESC")
+	}
+
+	questions := make([]Question, 0)
+	err := query.Order("created_at DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Find(&questions).Error
+	return questions, total, err
+}
+
+// GetQuestion loads a question of the lesson.
+func GetQuestion(db *gorm.DB, lessonID, id uuid.UUID) (Question, error) {
+	var question Question
+	if err := db.First(&question, "id = ? AND lesson_id = ?", id, lessonID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return question, ErrQuestionNotFound
+		}
+		return question, err
+	}
+	return question, nil
+}
+
+// GetAnswer loads an answer to the question.
+func GetAnswer(db *gorm.DB, questionID, id uuid.UUID) (Answer, error) {
+	var answer Answer
+	if err := db.First(&answer, "id = ? AND question_id = ?", id, questionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return answer, ErrAnswerNotFound
+		}
+		return answer, err
+	}
+	return answer, nil
+}
+
+// Answers returns the question's answers: the accepted one first, then endorsed
+// answers, then by upvotes and age.
+func Answers(db *gorm.DB, question Question) ([]Answer, error) {
+	answers := make([]Answer, 0)
+	if err := db.Where("question_id = ?", question.ID).Order("created_at ASC").Find(&answers).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range answers {
+		answers[i].Accepted = question.AcceptedAnswerID != nil && *question.AcceptedAnswerID == answers[i].ID
+	}
+	sort.SliceStable(answers, func(i, j int) bool {
+		a, b := answers[i], answers[j]
+		if a.Accepted != b.Accepted {
+			return a.Accepted
+		}
+		if a.Endorsed != b.Endorsed {
+			return a.Endorsed
+		}
+		return a.Upvotes > b.Upvotes
+	})
+	return answers, nil
+}
+
+// MarkUpvoted flags the questions the user upvoted.
+func MarkUpvoted(db *gorm.DB, userID uuid.UUID, questions []Question) error {
+	if len(questions) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(questions))
+	for i, question := range questions {
+		ids[i] = question.ID
+	}
+
+	var voted []uuid.UUID
+	if err := db.Model(&QuestionVote{}).
+		Where("user_id = ? AND question_id IN ?", userID, ids).
+		Pluck("question_id", &voted).Error; err != nil {
+		return err
+	}
+	for i := range questions {
+		questions[i].Upvoted = slices.Contains(voted, questions[i].ID)
+	}
+	return nil
+}
+
+// MarkAnswersUpvoted flags the answers the user upvoted.
+func MarkAnswersUpvoted(db *gorm.DB, userID uuid.UUID, answers []Answer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(answers))
+	for i, answer := range answers {
+		ids[i] = answer.ID
+	}
+
+	var voted []uuid.UUID
+	if err := db.Model(&AnswerVote{}).
+		Where("user_id = ? AND answer_id IN ?", userID, ids).
+		Pluck("answer_id", &voted).Error; err != nil {
+		return err
+	}
+	for i := range answers {
+		answers[i].Upvoted = slices.Contains(voted, answers[i].ID)
+	}
+	return nil
+}
+
+// CreateQuestion stores a new question.
+func CreateQuestion(db *gorm.DB, question *Question) error {
+	question.Title = strings.TrimSpace(question.Title)
+	if question.Title == "" {
+		return ErrTitleRequired
+	}
+	return db.Create(question).Error
+}
+
+// DeleteQuestion removes a question with its answers and votes.
+func DeleteQuestion(db *gorm.DB, question Question) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		answerIDs := tx.Model(&Answer{}).Select("id").Where("question_id = ?", question.ID)
+		if err := tx.Where("answer_id IN (?)", answerIDs).Delete(&AnswerVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", question.ID).Delete(&Answer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", question.ID).Delete(&QuestionVote{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&Question{}, "id = ?", question.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrQuestionNotFound
+		}
+		return nil
+	})
+}
+
+// CreateAnswer stores an answer and counts it on its question.
+func CreateAnswer(db *gorm.DB, answer *Answer) error {
+	answer.Body = strings.TrimSpace(answer.Body)
+	if answer.Body == "" {
+		return ErrBodyRequired
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(answer).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Question{}).Where("id = ?", answer.QuestionID).
+			UpdateColumn("answer_count", gorm.Expr("answer_count + 1")).Error
+	})
+}
+
+// DeleteAnswer removes an answer, un-accepting it if needed.
+func DeleteAnswer(db *gorm.DB, question Question, id uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("answer_id = ?", id).Delete(&AnswerVote{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&Answer{}, "id = ? AND question_id = ?", id, question.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAnswerNotFound
+		}
+
+		updates := map[string]interface{}{"answer_count": gorm.Expr("GREATEST(answer_count - 1, 0)")}
+		if question.AcceptedAnswerID != nil && *question.AcceptedAnswerID == id {
+			updates["accepted_answer_id"] = nil
+		}
+		return tx.Model(&Question{}).Where("id = ?", question.ID).UpdateColumns(updates).Error
+	})
+}
+
+// Accept marks one of the question's answers as the accepted one, or clears
+// the accepted answer when answerID is nil.
+func Accept(db *gorm.DB, question *Question, answerID *uuid.UUID) error {
+	if answerID != nil {
+		if _, err := GetAnswer(db, question.ID, *answerID); err != nil {
+			return err
+		}
+	}
+	if err := db.Model(question).Update("accepted_answer_id", answerID).Error; err != nil {
+		return err
+	}
+	question.AcceptedAnswerID = answerID
+	return nil
+}
+
+// Endorse sets whether staff vouch for the answer.
+func Endorse(db *gorm.DB, answer *Answer, endorsedBy *uuid.UUID) error {
+	answer.Endorsed = endorsedBy != nil
+	answer.EndorsedBy = endorsedBy
+	return db.Model(answer).Updates(map[string]interface{}{
+		"is_endorsed": answer.Endorsed,
+		"endorsed_by": answer.EndorsedBy,
+	}).Error
+}
+
+// VoteQuestion adds or removes the user's upvote of a question and returns the new count.
+func VoteQuestion(db *gorm.DB, question Question, userID uuid.UUID, up bool) (int, error) {
+	if question.UserID == userID {
+		return question.Upvotes, ErrOwnPost
+	}
+	return vote(db, &Question{}, question.ID, &QuestionVote{QuestionID: question.ID, UserID: userID}, up)
+}
+
+// VoteAnswer adds or removes the user's upvote of an answer and returns the new count.
+func VoteAnswer(db *gorm.DB, answer Answer, userID uuid.UUID, up bool) (int, error) {
+	if answer.UserID == userID {
+		return answer.Upvotes, ErrOwnPost
+	}
+	return vote(db, &Answer{}, answer.ID, &AnswerVote{AnswerID: answer.ID, UserID: userID}, up)
+}
+
+// vote records or removes a vote row and keeps the target's upvotes in step.
+// Repeated votes and removals of missing votes leave the count unchanged.
+func vote(db *gorm.DB, target interface{}, targetID uuid.UUID, row interface{}, up bool) (int, error) {
+	var upvotes int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		delta := 1
+		if up {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+		} else {
+			result = tx.Where(row).Delete(row)
+			delta = -1
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if err := tx.Model(target).Where("id = ?", targetID).
+				UpdateColumn("upvotes", gorm.Expr("GREATEST(upvotes + ?, 0)", delta)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(target).Where("id = ?", targetID).Select("upvotes").Scan(&upvotes).Error
+	})
+	return upvotes, err
+}
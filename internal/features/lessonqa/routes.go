@@ -0,0 +1,37 @@
+package lessonqa
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches lesson Q&A endpoints to the router. Endorsing answers
+// and the course-wide queue require acContent.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acContent []gin.HandlerFunc) {
+	router.GET("/subscriptions/:subscriptionId/courses/:courseId/questions", append(acContent, handler.ListCourse)...)
+
+	questions := router.Group("/subscriptions/:subscriptionId/courses/:courseId/lessons/:lessonId/questions")
+
+	questions.GET("", append(acAll, handler.List)...)
+	questions.POST("", append(acAll, handler.Ask)...)
+	questions.GET("/:questionId", append(acAll, handler.Get)...)
+	questions.DELETE("/:questionId", append(acAll, handler.Delete)...)
+	questions.PUT("/:questionId/accepted-answer", append(acAll, handler.Accept)...)
+	questions.POST("/:questionId/upvote", append(acAll, handler.UpvoteQuestion)...)
+	questions.DELETE("/:questionId/upvote", append(acAll, handler.RemoveQuestionUpvote)...)
+
+	questions.POST("/:questionId/answers", append(acAll, handler.Answer)...)
+	questions.DELETE("/:questionId/answers/:answerId", append(acAll, handler.DeleteAnswer)...)
+	questions.POST("/:questionId/answers/:answerId/upvote", append(acAll, handler.UpvoteAnswer)...)
+	questions.DELETE("/:questionId/answers/:answerId/upvote", append(acAll, handler.RemoveAnswerUpvote)...)
+	questions.POST("/:questionId/answers/:answerId/endorse", append(acContent, handler.Endorse)...)
+	questions.DELETE("/:questionId/answers/:answerId/endorse", append(acContent, handler.Unendorse)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Question{}})
+	openapi.Describe(handler.ListCourse, openapi.Spec{Response: []Question{}})
+	openapi.Describe(handler.Get, openapi.Spec{Response: Detail{}})
+	openapi.Describe(handler.Ask, openapi.Spec{Request: askRequest{}, Response: Question{}})
+	openapi.Describe(handler.Answer, openapi.Spec{Request: answerRequest{}, Response: Answer{}})
+	openapi.Describe(handler.Accept, openapi.Spec{Request: acceptRequest{}, Response: Question{}})
+}
@@ -0,0 +1,5 @@
+package maintenance
+
+import "errors"
+
+var ErrEndsInPast = errors.New("maintenance end time is in the past")
@@ -0,0 +1,82 @@
+package maintenance
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Handler serves the maintenance mode endpoints.
+type Handler struct {
+	logger  *slog.Logger
+	service *Service
+}
+
+// NewHandler constructs a maintenance handler.
+func NewHandler(logger *slog.Logger, service *Service) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+// Status returns whether maintenance mode is on, for clients to poll while
+// it is; it is served during maintenance.
+// GET /maintenance/status
+func (h *Handler) Status(c *gin.Context) {
+	response.Success(c, http.StatusOK, h.service.Mode().Status(), "", nil)
+}
+
+// Get returns the maintenance mode with who switched it last.
+// GET /maintenance
+func (h *Handler) Get(c *gin.Context) {
+	response.Success(c, http.StatusOK, h.service.Mode(), "", nil)
+}
+
+type updateRequest struct {
+	Enabled *bool      `json:"enabled" binding:"required"`
+	Message string     `json:"message" binding:"max=500"`
+	EndsAt  *time.Time `json:"endsAt"`
+}
+
+// Update switches maintenance mode on or off. Connected clients are told over
+// Socket.IO; other instances apply it within 10 seconds.
+// PUT /maintenance
+func (h *Handler) Update(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid maintenance payload") {
+		return
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(time.Now()) {
+		h.respondError(c, ErrEndsInPast)
+		return
+	}
+
+	mode, err := h.service.Set(c.Request.Context(), *req.Enabled, strings.TrimSpace(req.Message), req.EndsAt, usr.ID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	response.Success(c, http.StatusOK, mode, "", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEndsInPast):
+		request.RespondInvalid(c, validation.FieldError{Field: "endsAt", Rule: "future", Message: "must be in the future"})
+	default:
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to update maintenance mode", err)
+	}
+}
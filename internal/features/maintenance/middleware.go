@@ -0,0 +1,76 @@
+package maintenance
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/utils/jwt"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const defaultMessage = "The platform is undergoing maintenance. Please try again later."
+
+// Middleware answers requests with 503 while maintenance mode is on, except
+// those of superadmins and those under an allowed path prefix, such as health
+// checks. The body carries the status so clients can show why.
+func (s *Service) Middleware(jwtSecret string, allowed ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode := s.Mode()
+		if !mode.Enabled || allowedPath(c.Request.URL.Path, allowed) || s.isSuperAdmin(c, jwtSecret) {
+			c.Next()
+			return
+		}
+
+		if mode.EndsAt != nil {
+			if wait := time.Until(*mode.EndsAt); wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			}
+		}
+		message := mode.Message
+		if message == "" {
+			message = defaultMessage
+		}
+		response.ErrorWithData(nil, c, http.StatusServiceUnavailable, message, mode.Status(), nil)
+		c.Abort()
+	}
+}
+
+// isSuperAdmin reports whether the request carries a valid access token of a
+// superadmin. Only checked while maintenance mode is on.
+func (s *Service) isSuperAdmin(c *gin.Context, jwtSecret string) bool {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return false
+	}
+	claims, err := jwt.VerifyToken(strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer ")), jwtSecret)
+	if err != nil || claims.Purpose != "" {
+		return false
+	}
+
+	var usr struct {
+		UserType     types.UserType
+		TokenVersion int
+	}
+	if err := s.db.WithContext(c.Request.Context()).
+		Table("users").
+		Select("user_type", "token_version").
+		Where("id = ?", claims.UserID).
+		Take(&usr).Error; err != nil {
+		return false
+	}
+	return usr.UserType == types.UserTypeSuperAdmin && usr.TokenVersion == claims.TokenVersion
+}
+
+func allowedPath(path string, allowed []string) bool {
+	for _, prefix := range allowed {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package maintenance
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventChanged is the Socket.IO event sent to every connected client when
+// maintenance mode is switched on or off, so clients can show a banner.
+const EventChanged = "maintenanceMode"
+
+// modeID is the primary key of the single maintenance mode row.
+const modeID = 1
+
+// Mode is the platform's maintenance mode. While it is enabled, requests of
+// everyone but superadmins are answered with 503.
+type Mode struct {
+	ID      int    `gorm:"primaryKey;autoIncrement:false;column:id" json:"-"`
+	Enabled bool   `gorm:"not null;column:enabled" json:"enabled"`
+	Message string `gorm:"type:text;not null;column:message" json:"message"`
+	// EndsAt is when maintenance is expected to end; clients are told to retry then.
+	EndsAt    *time.Time `gorm:"column:ends_at" json:"endsAt,omitempty"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid;column:updated_by" json:"updatedBy,omitempty"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+// TableName overrides the default table name.
+func (Mode) TableName() string { return "maintenance_mode" }
+
+// Status is the part of the mode shown to every client.
+type Status struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message"`
+	EndsAt  *time.Time `json:"endsAt,omitempty"`
+}
+
+// Status returns the mode as shown to clients.
+func (m Mode) Status() Status {
+	return Status{Enabled: m.Enabled, Message: m.Message, EndsAt: m.EndsAt}
+}
+
+// Equal reports whether clients would see the same status.
+func (s Status) Equal(other Status) bool {
+	if s.Enabled != other.Enabled || s.Message != other.Message || (s.EndsAt == nil) != (other.EndsAt == nil) {
+		return false
+	}
+	return s.EndsAt == nil || s.EndsAt.Equal(*other.EndsAt)
+}
+
+// Get returns the stored mode, or the disabled mode when none was saved.
+func Get(db *gorm.DB) (Mode, error) {
+	var mode Mode
+	err := db.Where("id = ?", modeID).Take(&mode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Mode{ID: modeID}, nil
+	}
+	if err != nil {
+		return Mode{}, err
+	}
+	return mode, nil
+}
+
+// Save creates or replaces the stored mode.
+func Save(db *gorm.DB, mode Mode) (Mode, error) {
+	mode.ID = modeID
+	mode.UpdatedAt = time.Now().UTC()
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "message", "ends_at", "updated_by", "updated_at"}),
+	}).Create(&mode).Error
+	if err != nil {
+		return Mode{}, err
+	}
+	return mode, nil
+}
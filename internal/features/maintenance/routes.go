@@ -0,0 +1,19 @@
+package maintenance
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the maintenance mode endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, superadminOnly []gin.HandlerFunc) {
+	maintenance := router.Group("/maintenance")
+	maintenance.GET("/status", handler.Status)
+	maintenance.GET("", append(superadminOnly, handler.Get)...)
+	maintenance.PUT("", append(superadminOnly, handler.Update)...)
+
+	openapi.Describe(handler.Status, openapi.Spec{Response: Status{}})
+	openapi.Describe(handler.Get, openapi.Spec{Response: Mode{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Mode{}})
+}
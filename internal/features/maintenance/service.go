@@ -0,0 +1,100 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// modeTTL bounds how long the mode is served from memory, so switching it
+// through another instance applies within it; saves through the service
+// apply immediately.
+const modeTTL = 10 * time.Second
+
+// Service serves the maintenance mode without a query per request and tells
+// its hooks when the mode changes.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	mode     Mode
+	loadedAt time.Time
+	hooks    []func(Status)
+}
+
+// NewService constructs the maintenance service.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	return &Service{db: db, logger: logger, mode: Mode{ID: modeID}}
+}
+
+// OnChange registers hook to run whenever the status shown to clients
+// changes, whether switched through this instance or noticed on reload.
+func (s *Service) OnChange(hook func(Status)) {
+	s.mu.Lock()
+	s.hooks = append(s.hooks, hook)
+	s.mu.Unlock()
+}
+
+// Mode returns the maintenance mode, reloading it once it is older than
+// modeTTL. While the database is unreachable the last loaded mode stays in
+// effect.
+func (s *Service) Mode() Mode {
+	s.mu.Lock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < modeTTL {
+		defer s.mu.Unlock()
+		return s.mode
+	}
+
+	previous, first := s.mode, s.loadedAt.IsZero()
+	mode, err := Get(s.db)
+	s.loadedAt = time.Now()
+	if err != nil {
+		s.logger.Warn("failed to load maintenance mode", "error", err)
+		s.mu.Unlock()
+		return previous
+	}
+	s.mode = mode
+	hooks := s.hooks
+	s.mu.Unlock()
+
+	// Clients connected before this process started learn the mode on connect
+	if !first && !previous.Status().Equal(mode.Status()) {
+		s.notify(hooks, mode)
+	}
+	return mode
+}
+
+// Set switches maintenance mode on or off and tells the hooks.
+func (s *Service) Set(ctx context.Context, enabled bool, message string, endsAt *time.Time, updatedBy uuid.UUID) (Mode, error) {
+	mode, err := Save(s.db.WithContext(ctx), Mode{
+		Enabled:   enabled,
+		Message:   message,
+		EndsAt:    endsAt,
+		UpdatedBy: &updatedBy,
+	})
+	if err != nil {
+		return Mode{}, err
+	}
+
+	s.mu.Lock()
+	s.mode = mode
+	s.loadedAt = time.Now()
+	hooks := s.hooks
+	s.mu.Unlock()
+
+	s.logger.Info("maintenance mode changed", "enabled", mode.Enabled, "message", mode.Message, "userId", updatedBy)
+	s.notify(hooks, mode)
+	return mode, nil
+}
+
+func (s *Service) notify(hooks []func(Status), mode Mode) {
+	status := mode.Status()
+	for _, hook := range hooks {
+		hook(status)
+	}
+}
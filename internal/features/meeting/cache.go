@@ -8,8 +8,16 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrMeetingNotFound matches the message the handlers already compare against.
+	ErrMeetingNotFound     = errors.New("Meeting not found")
+	ErrParticipantNotFound = errors.New("Participant not found")
+	ErrRemovedFromMeeting  = errors.New("You were removed from this meeting")
+)
+
 // Meeting represents an active meeting
 type Meeting struct {
+	RecordID           uuid.UUID               `json:"meetingId"` // ID of the persisted meeting row
 	RoomID             string                  `json:"roomId"`
 	SubscriptionID     string                  `json:"subscriptionId"`
 	Title              string                  `json:"title"`
@@ -21,6 +29,7 @@ type Meeting struct {
 	StartedAt          time.Time               `json:"startedAt"`
 	Status             string                  `json:"status"` // "active" or "ended"
 	StudentPermissions StudentPermissions      `json:"studentPermissions"`
+	Removed            map[string]bool         `json:"-"` // users kicked by the host; they cannot rejoin
 }
 
 // Participant represents a meeting participant
@@ -32,6 +41,23 @@ type Participant struct {
 	Mic         bool   `json:"mic"`
 	Camera      bool   `json:"camera"`
 	ScreenShare bool   `json:"screenShare"`
+
+	HandRaised   bool                `json:"handRaised"`
+	HandRaisedAt *time.Time          `json:"handRaisedAt,omitempty"`
+	Permissions  *PermissionOverride `json:"permissions,omitempty"` // overrides StudentPermissions for this participant
+}
+
+// PermissionOverride grants or revokes individual student permissions for one
+// participant; nil fields fall back to the meeting-wide StudentPermissions.
+type PermissionOverride struct {
+	CanUseMic      *bool `json:"canUseMic,omitempty"`
+	CanUseCamera   *bool `json:"canUseCamera,omitempty"`
+	CanScreenShare *bool `json:"canScreenShare,omitempty"`
+}
+
+// IsEmpty reports whether the override changes nothing.
+func (o PermissionOverride) IsEmpty() bool {
+	return o.CanUseMic == nil && o.CanUseCamera == nil && o.CanScreenShare == nil
 }
 
 // StudentPermissions represents what students can do in the meeting
@@ -74,7 +100,13 @@ func (c *Cache) CreateMeeting(input CreateMeetingInput) (*Meeting, error) {
 	}
 
 	// Create meeting
+	recordID := input.RecordID
+	if recordID == uuid.Nil {
+		recordID = uuid.New()
+	}
+
 	meeting := &Meeting{
+		RecordID:       recordID,
 		RoomID:         input.RoomID,
 		SubscriptionID: input.SubscriptionID,
 		Title:          input.Title,
@@ -83,6 +115,7 @@ func (c *Cache) CreateMeeting(input CreateMeetingInput) (*Meeting, error) {
 		AccessType:     input.AccessType,
 		GroupAccess:    input.GroupAccess,
 		Participants:   make(map[string]*Participant),
+		Removed:        make(map[string]bool),
 		StartedAt:      time.Now(),
 		Status:         "active",
 		StudentPermissions: StudentPermissions{
@@ -112,6 +145,7 @@ func (c *Cache) CreateMeeting(input CreateMeetingInput) (*Meeting, error) {
 
 // CreateMeetingInput represents the input for creating a meeting
 type CreateMeetingInput struct {
+	RecordID       uuid.UUID // optional; generated when empty
 	RoomID         string
 	SubscriptionID string
 	Title          string
@@ -185,6 +219,10 @@ func (c *Cache) JoinMeeting(roomID, userID string, details *Participant) (*Meeti
 		return nil, errors.New("Meeting is not active")
 	}
 
+	if meeting.Removed[userID] {
+		return nil, ErrRemovedFromMeeting
+	}
+
 	// Add participant
 	if details == nil {
 		details = &Participant{
@@ -329,6 +367,165 @@ func (c *Cache) UpdateParticipantMedia(roomID, userID string, mic, camera, scree
 	}
 }
 
+// SetHandRaised raises or lowers a participant's hand.
+func (c *Cache) SetHandRaised(roomID, userID string, raised bool) (Participant, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	participant, err := c.participantLocked(roomID, userID)
+	if err != nil {
+		return Participant{}, err
+	}
+
+	participant.HandRaised = raised
+	participant.HandRaisedAt = nil
+	if raised {
+		now := time.Now().UTC()
+		participant.HandRaisedAt = &now
+	}
+
+	return *participant, nil
+}
+
+// MuteAll turns off the microphone of every participant except the host and lowers
+// all raised hands. When lock is set students also lose the mic permission, including
+// per-participant grants. It returns the IDs of the participants that were muted.
+func (c *Cache) MuteAll(roomID string, lock bool) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	meeting, exists := c.meetings[roomID]
+	if !exists {
+		return nil, ErrMeetingNotFound
+	}
+
+	muted := make([]string, 0, len(meeting.Participants))
+	for userID, participant := range meeting.Participants {
+		if userID == meeting.HostID {
+			continue
+		}
+		participant.Mic = false
+		participant.HandRaised = false
+		participant.HandRaisedAt = nil
+		if lock && participant.Permissions != nil {
+			participant.Permissions.CanUseMic = nil
+			if participant.Permissions.IsEmpty() {
+				participant.Permissions = nil
+			}
+		}
+		muted = append(muted, userID)
+	}
+
+	if lock {
+		meeting.StudentPermissions.CanUseMic = false
+	}
+
+	return muted, nil
+}
+
+// KickParticipant removes a participant and prevents them from rejoining this meeting.
+func (c *Cache) KickParticipant(roomID, userID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	meeting, exists := c.meetings[roomID]
+	if !exists {
+		return ErrMeetingNotFound
+	}
+	if _, ok := meeting.Participants[userID]; !ok {
+		return ErrParticipantNotFound
+	}
+
+	delete(meeting.Participants, userID)
+	meeting.Removed[userID] = true
+
+	if userRooms, ok := c.userMeetings[userID]; ok {
+		delete(userRooms, roomID)
+		if len(userRooms) == 0 {
+			delete(c.userMeetings, userID)
+		}
+	}
+
+	return nil
+}
+
+// SetParticipantPermissions replaces a participant's permission override; an empty
+// override clears it. Revoked capabilities are switched off immediately.
+func (c *Cache) SetParticipantPermissions(roomID, userID string, override PermissionOverride) (Participant, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	participant, err := c.participantLocked(roomID, userID)
+	if err != nil {
+		return Participant{}, err
+	}
+
+	if override.IsEmpty() {
+		participant.Permissions = nil
+	} else {
+		participant.Permissions = &override
+	}
+
+	if override.CanUseMic != nil && !*override.CanUseMic {
+		participant.Mic = false
+	}
+	if override.CanUseCamera != nil && !*override.CanUseCamera {
+		participant.Camera = false
+	}
+	if override.CanScreenShare != nil && !*override.CanScreenShare {
+		participant.ScreenShare = false
+	}
+
+	return *participant, nil
+}
+
+// EffectivePermissions resolves what a participant may do, applying their override on
+// top of the meeting-wide student permissions. The host may do everything.
+func EffectivePermissions(meeting *Meeting, participant Participant) StudentPermissions {
+	if participant.ID == meeting.HostID {
+		return StudentPermissions{CanUseMic: true, CanUseCamera: true, CanScreenShare: true}
+	}
+
+	effective := meeting.StudentPermissions
+	if o := participant.Permissions; o != nil {
+		if o.CanUseMic != nil {
+			effective.CanUseMic = *o.CanUseMic
+		}
+		if o.CanUseCamera != nil {
+			effective.CanUseCamera = *o.CanUseCamera
+		}
+		if o.CanScreenShare != nil {
+			effective.CanScreenShare = *o.CanScreenShare
+		}
+	}
+	return effective
+}
+
+// IsParticipant reports whether the user is currently in the meeting.
+func (c *Cache) IsParticipant(roomID, userID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	meeting, exists := c.meetings[roomID]
+	if !exists {
+		return false
+	}
+	_, ok := meeting.Participants[userID]
+	return ok
+}
+
+func (c *Cache) participantLocked(roomID, userID string) (*Participant, error) {
+	meeting, exists := c.meetings[roomID]
+	if !exists {
+		return nil, ErrMeetingNotFound
+	}
+	participant, ok := meeting.Participants[userID]
+	if !ok {
+		return nil, ErrParticipantNotFound
+	}
+	return participant, nil
+}
+
 // GetStats returns cache statistics
 func (c *Cache) GetStats() map[string]interface{} {
 	c.mu.RLock()
@@ -341,6 +538,17 @@ func (c *Cache) GetStats() map[string]interface{} {
 	}
 }
 
+// Counts returns the number of active meetings and their participants
+func (c *Cache) Counts() (meetings, participants int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, meeting := range c.meetings {
+		participants += len(meeting.Participants)
+	}
+	return len(c.meetings), participants
+}
+
 // GenerateRoomID generates a unique room ID
 func GenerateRoomID() string {
 	return uuid.New().String()
@@ -1,48 +1,73 @@
 package meeting
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
 	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
-	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/webrtc"
 )
 
+// Live events pushed to sockets that joined a meeting room.
+const (
+	EventHandRaised         = "meetingHandRaised"
+	EventMutedAll           = "meetingMutedAll"
+	EventParticipantKicked  = "meetingParticipantKicked"
+	EventPermissionsUpdated = "meetingPermissionsUpdated"
+)
+
+// Broadcaster pushes meeting events to connected clients.
+type Broadcaster interface {
+	EmitToMeeting(roomID, event string, payload any)
+	RemoveFromMeeting(roomID, userID string)
+}
+
 type Handler struct {
 	db     *gorm.DB
 	logger *slog.Logger
 	cache  *Cache
+	events Broadcaster
+	ice    *webrtc.Provider
 }
 
-func NewHandler(db *gorm.DB, logger *slog.Logger, cache *Cache) *Handler {
+// NewHandler constructs a meeting handler. events may be nil to disable live updates.
+func NewHandler(db *gorm.DB, logger *slog.Logger, cache *Cache, events Broadcaster, ice *webrtc.Provider) *Handler {
 	return &Handler{
 		db:     db,
 		logger: logger,
 		cache:  cache,
+		events: events,
+		ice:    ice,
 	}
 }
 
+type createMeetingRequest struct {
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description"`
+	AccessType  string   `json:"accessType"` // "public" or "group"
+	GroupAccess []string `json:"groupAccess" binding:"max=100"`
+}
+
 // CreateMeeting creates and starts a new meeting
 // POST /subscriptions/:subscriptionId/meetings
 func (h *Handler) CreateMeeting(c *gin.Context) {
 	subscriptionID := c.Param("subscriptionId")
 
 	// Parse request body
-	var req struct {
-		Title       string   `json:"title" binding:"required"`
-		Description string   `json:"description"`
-		AccessType  string   `json:"accessType"` // "public" or "group"
-		GroupAccess []string `json:"groupAccess"`
-	}
+	var req createMeetingRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !request.BindJSON(h.logger, c, &req, "Invalid request body") {
 		return
 	}
 
@@ -111,6 +136,9 @@ func (h *Handler) CreateMeeting(c *gin.Context) {
 		ScreenShare: true,
 	})
 
+	h.persist("start", roomID, RecordStart(h.db, meeting))
+	h.persist("join", roomID, RecordJoin(h.db, meeting.RecordID, currentUser.ID.String(), currentUser.FullName))
+
 	// Convert participants map to array for response
 	participants := make([]*Participant, 0, len(meeting.Participants))
 	for _, p := range meeting.Participants {
@@ -118,6 +146,7 @@ func (h *Handler) CreateMeeting(c *gin.Context) {
 	}
 
 	responseData := gin.H{
+		"meetingId":          meeting.RecordID,
 		"roomId":             meeting.RoomID,
 		"subscriptionId":     meeting.SubscriptionID,
 		"title":              meeting.Title,
@@ -136,6 +165,9 @@ func (h *Handler) CreateMeeting(c *gin.Context) {
 			"email": currentUser.Email,
 		},
 	}
+	if h.ice != nil {
+		responseData["ice"] = h.ice.Credentials(currentUser.ID.String(), time.Now())
+	}
 
 	response.Created(c, responseData, "Meeting created and started successfully")
 }
@@ -156,6 +188,7 @@ func (h *Handler) GetActiveMeetings(c *gin.Context) {
 		}
 
 		responseData = append(responseData, gin.H{
+			"meetingId":          meeting.RecordID,
 			"roomId":             meeting.RoomID,
 			"subscriptionId":     meeting.SubscriptionID,
 			"title":              meeting.Title,
@@ -191,6 +224,7 @@ func (h *Handler) GetMeetingByRoomID(c *gin.Context) {
 	}
 
 	responseData := gin.H{
+		"meetingId":          meeting.RecordID,
 		"roomId":             meeting.RoomID,
 		"subscriptionId":     meeting.SubscriptionID,
 		"title":              meeting.Title,
@@ -235,12 +269,16 @@ func (h *Handler) JoinMeeting(c *gin.Context) {
 			response.Error(c, http.StatusNotFound, "Meeting not found", nil)
 		} else if err.Error() == "Meeting is not active" {
 			response.Error(c, http.StatusBadRequest, "Meeting is not active", nil)
+		} else if errors.Is(err, ErrRemovedFromMeeting) {
+			response.Error(c, http.StatusForbidden, err.Error(), nil)
 		} else {
 			response.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		}
 		return
 	}
 
+	h.persist("join", roomID, RecordJoin(h.db, meeting.RecordID, currentUser.ID.String(), currentUser.FullName))
+
 	// Convert participants map to array
 	participants := make([]*Participant, 0, len(meeting.Participants))
 	for _, p := range meeting.Participants {
@@ -248,6 +286,7 @@ func (h *Handler) JoinMeeting(c *gin.Context) {
 	}
 
 	responseData := gin.H{
+		"meetingId":          meeting.RecordID,
 		"roomId":             meeting.RoomID,
 		"subscriptionId":     meeting.SubscriptionID,
 		"title":              meeting.Title,
@@ -260,6 +299,9 @@ func (h *Handler) JoinMeeting(c *gin.Context) {
 		"status":             meeting.Status,
 		"studentPermissions": meeting.StudentPermissions,
 	}
+	if h.ice != nil {
+		responseData["ice"] = h.ice.Credentials(currentUser.ID.String(), time.Now())
+	}
 
 	response.Success(c, http.StatusOK, responseData, "Successfully joined the meeting", nil)
 }
@@ -276,13 +318,19 @@ func (h *Handler) LeaveMeeting(c *gin.Context) {
 		return
 	}
 
-	found, autoClosedMeeting, _ := h.cache.LeaveMeeting(roomID, currentUser.ID.String())
+	found, autoClosedMeeting, meeting := h.cache.LeaveMeeting(roomID, currentUser.ID.String())
 
 	if !found {
 		response.Error(c, http.StatusNotFound, "Meeting not found", nil)
 		return
 	}
 
+	if autoClosedMeeting {
+		h.persist("end", roomID, RecordEnd(h.db, meeting.RecordID))
+	} else {
+		h.persist("leave", roomID, RecordLeave(h.db, meeting.RecordID, currentUser.ID.String()))
+	}
+
 	message := "Successfully left the meeting"
 	responseData := gin.H{
 		"meetingEnded": autoClosedMeeting,
@@ -302,8 +350,7 @@ func (h *Handler) UpdateStudentPermissions(c *gin.Context) {
 
 	// Parse request body
 	var req StudentPermissions
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !request.BindJSON(h.logger, c, &req, "Invalid request body") {
 		return
 	}
 
@@ -322,10 +369,7 @@ func (h *Handler) UpdateStudentPermissions(c *gin.Context) {
 	}
 
 	// Check if user is the host (or admin/superadmin)
-	isHost := meeting.HostID == currentUser.ID.String()
-	isAdmin := currentUser.UserType == types.UserTypeAdmin || currentUser.UserType == types.UserTypeSuperAdmin
-
-	if !isHost && !isAdmin {
+	if !authz.CanControlSession(authz.SubjectFrom(currentUser), meeting.HostID) {
 		response.Error(c, http.StatusForbidden, "Only the meeting host can update student permissions", nil)
 		return
 	}
@@ -337,6 +381,12 @@ func (h *Handler) UpdateStudentPermissions(c *gin.Context) {
 		return
 	}
 
+	h.emit(roomID, EventPermissionsUpdated, gin.H{
+		"roomId":             roomID,
+		"studentPermissions": updatedMeeting.StudentPermissions,
+		"timestamp":          time.Now().UTC().Format(time.RFC3339),
+	})
+
 	response.Success(c, http.StatusOK, updatedMeeting.StudentPermissions, "Student permissions updated successfully", nil)
 }
 
@@ -360,10 +410,7 @@ func (h *Handler) EndMeeting(c *gin.Context) {
 	}
 
 	// Check if user is the host (or admin/superadmin)
-	isHost := meeting.HostID == currentUser.ID.String()
-	isAdmin := currentUser.UserType == types.UserTypeAdmin || currentUser.UserType == types.UserTypeSuperAdmin
-
-	if !isHost && !isAdmin {
+	if !authz.CanControlSession(authz.SubjectFrom(currentUser), meeting.HostID) {
 		response.Error(c, http.StatusForbidden, "Only the meeting host can end the meeting", nil)
 		return
 	}
@@ -375,6 +422,8 @@ func (h *Handler) EndMeeting(c *gin.Context) {
 		return
 	}
 
+	h.persist("end", roomID, RecordEnd(h.db, endedMeeting.RecordID))
+
 	// Convert participants map to array
 	participants := make([]*Participant, 0, len(endedMeeting.Participants))
 	for _, p := range endedMeeting.Participants {
@@ -382,6 +431,7 @@ func (h *Handler) EndMeeting(c *gin.Context) {
 	}
 
 	responseData := gin.H{
+		"meetingId":          endedMeeting.RecordID,
 		"roomId":             endedMeeting.RoomID,
 		"subscriptionId":     endedMeeting.SubscriptionID,
 		"title":              endedMeeting.Title,
@@ -397,3 +447,260 @@ func (h *Handler) EndMeeting(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, responseData, "Meeting ended successfully", nil)
 }
+
+// GetAttendance reports meetings and per-user attendance for a date range
+// GET /subscriptions/:subscriptionId/meetings/attendance?from=&to=
+func (h *Handler) GetAttendance(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID", nil)
+		return
+	}
+
+	// Default to the last 30 days
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := parseReportDate(raw)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "from must be YYYY-MM-DD or RFC 3339", nil)
+			return
+		}
+		from = parsed
+	}
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := parseReportDate(raw)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "to must be YYYY-MM-DD or RFC 3339", nil)
+			return
+		}
+		// A bare date includes the whole day
+		if len(raw) == len("2006-01-02") {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+
+	if !from.Before(to) {
+		response.Error(c, http.StatusBadRequest, "from must be before to", nil)
+		return
+	}
+
+	currentUser, _ := middleware.GetUserFromContext(c)
+	db := authz.ScopeQuery(authz.SubjectFrom(currentUser), h.db, "m.subscription_id")
+	meetings, attendees, err := Attendance(db, subscriptionID, from, to)
+	if err != nil {
+		h.logger.Error("Failed to load meeting attendance", "error", err)
+		response.Error(c, http.StatusInternalServerError, "Failed to load meeting attendance", nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"from":      from,
+		"to":        to,
+		"meetings":  meetings,
+		"attendees": attendees,
+	}, "", nil)
+}
+
+// persist writes meeting history; failures are logged so the live meeting keeps working.
+func (h *Handler) persist(action, roomID string, err error) {
+	if err != nil {
+		h.logger.Warn("Failed to persist meeting "+action, "roomId", roomID, "error", err)
+	}
+}
+
+func parseReportDate(raw string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t.UTC(), err
+}
+
+type setHandRaisedRequest struct {
+	Raised *bool `json:"raised" binding:"required"`
+}
+
+// SetHandRaised raises or lowers the current user's hand
+// PUT /meetings/:roomId/hand
+func (h *Handler) SetHandRaised(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var req setHandRaisedRequest
+	if !request.BindJSON(h.logger, c, &req, "Invalid request body") {
+		return
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	participant, err := h.cache.SetHandRaised(roomID, currentUser.ID.String(), *req.Raised)
+	if err != nil {
+		h.respondModerationError(c, err)
+		return
+	}
+
+	h.emit(roomID, EventHandRaised, HandRaisedPayload(roomID, participant))
+
+	response.Success(c, http.StatusOK, participant, "", nil)
+}
+
+type muteAllRequest struct {
+	Lock bool `json:"lock"` // also revoke the students' mic permission
+}
+
+// MuteAll mutes every participant except the host (host only)
+// POST /meetings/:roomId/mute-all
+func (h *Handler) MuteAll(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var req muteAllRequest
+	if c.Request.ContentLength > 0 {
+		if !request.BindJSON(h.logger, c, &req, "Invalid request body") {
+			return
+		}
+	}
+
+	if _, ok := h.requireHost(c, roomID, "Only the meeting host can mute participants"); !ok {
+		return
+	}
+
+	muted, err := h.cache.MuteAll(roomID, req.Lock)
+	if err != nil {
+		h.respondModerationError(c, err)
+		return
+	}
+
+	payload := gin.H{
+		"roomId":    roomID,
+		"muted":     muted,
+		"locked":    req.Lock,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	h.emit(roomID, EventMutedAll, payload)
+
+	response.Success(c, http.StatusOK, payload, "All participants muted", nil)
+}
+
+// KickParticipant removes a participant from the meeting (host only)
+// DELETE /meetings/:roomId/participants/:userId
+func (h *Handler) KickParticipant(c *gin.Context) {
+	roomID := c.Param("roomId")
+	userID := c.Param("userId")
+
+	meeting, ok := h.requireHost(c, roomID, "Only the meeting host can remove participants")
+	if !ok {
+		return
+	}
+
+	if userID == meeting.HostID {
+		response.Error(c, http.StatusBadRequest, "The host cannot be removed from the meeting", nil)
+		return
+	}
+
+	if err := h.cache.KickParticipant(roomID, userID); err != nil {
+		h.respondModerationError(c, err)
+		return
+	}
+
+	h.persist("leave", roomID, RecordLeave(h.db, meeting.RecordID, userID))
+
+	h.emit(roomID, EventParticipantKicked, gin.H{
+		"roomId":    roomID,
+		"userId":    userID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	if h.events != nil {
+		h.events.RemoveFromMeeting(roomID, userID)
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"userId": userID}, "Participant removed from the meeting", nil)
+}
+
+// UpdateParticipantPermissions overrides the student permissions of one participant (host only)
+// PUT /meetings/:roomId/participants/:userId/permissions
+func (h *Handler) UpdateParticipantPermissions(c *gin.Context) {
+	roomID := c.Param("roomId")
+	userID := c.Param("userId")
+
+	var req PermissionOverride
+	if !request.BindJSON(h.logger, c, &req, "Invalid request body") {
+		return
+	}
+
+	meeting, ok := h.requireHost(c, roomID, "Only the meeting host can update participant permissions")
+	if !ok {
+		return
+	}
+
+	participant, err := h.cache.SetParticipantPermissions(roomID, userID, req)
+	if err != nil {
+		h.respondModerationError(c, err)
+		return
+	}
+
+	payload := gin.H{
+		"roomId":      roomID,
+		"userId":      userID,
+		"permissions": participant.Permissions,
+		"effective":   EffectivePermissions(meeting, participant),
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+	}
+	h.emit(roomID, EventPermissionsUpdated, payload)
+
+	response.Success(c, http.StatusOK, payload, "Participant permissions updated successfully", nil)
+}
+
+// HandRaisedPayload builds the EventHandRaised payload shared by REST and socket updates.
+func HandRaisedPayload(roomID string, participant Participant) gin.H {
+	return gin.H{
+		"roomId":       roomID,
+		"userId":       participant.ID,
+		"name":         participant.Name,
+		"handRaised":   participant.HandRaised,
+		"handRaisedAt": participant.HandRaisedAt,
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// requireHost loads the meeting and checks the current user may control it.
+func (h *Handler) requireHost(c *gin.Context, roomID, forbidden string) (*Meeting, bool) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Authentication required.", nil)
+		return nil, false
+	}
+
+	meeting := h.cache.GetMeeting(roomID)
+	if meeting == nil {
+		response.Error(c, http.StatusNotFound, "Meeting not found", nil)
+		return nil, false
+	}
+
+	if !authz.CanControlSession(authz.SubjectFrom(currentUser), meeting.HostID) {
+		response.Error(c, http.StatusForbidden, forbidden, nil)
+		return nil, false
+	}
+
+	return meeting, true
+}
+
+var moderationErrors = response.Catalog{
+	{Err: ErrMeetingNotFound, Status: http.StatusNotFound, Code: "MEETING_NOT_FOUND", Message: "Meeting not found"},
+	{Err: ErrParticipantNotFound, Status: http.StatusNotFound, Code: "PARTICIPANT_NOT_FOUND", Message: "Participant not found"},
+}
+
+func (h *Handler) respondModerationError(c *gin.Context, err error) {
+	moderationErrors.Respond(h.logger, c, err, "failed to moderate meeting")
+}
+
+func (h *Handler) emit(roomID, event string, payload any) {
+	if h.events != nil {
+		h.events.EmitToMeeting(roomID, event, payload)
+	}
+}
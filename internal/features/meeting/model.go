@@ -0,0 +1,161 @@
+package meeting
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Record is the persisted history of a meeting. The cache only holds live meetings;
+// rows here survive restarts and feed the attendance report.
+type Record struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;index:idx_meetings_subscription_started,priority:1" json:"subscriptionId"`
+	RoomID         string     `gorm:"type:varchar(100);not null;column:room_id" json:"roomId"`
+	Title          string     `gorm:"type:varchar(255);not null" json:"title"`
+	Description    string     `gorm:"type:text" json:"description,omitempty"`
+	HostID         uuid.UUID  `gorm:"type:uuid;not null;column:host_id" json:"hostId"`
+	AccessType     string     `gorm:"type:varchar(20);column:access_type" json:"accessType"`
+	StartedAt      time.Time  `gorm:"type:timestamp;not null;column:started_at;index:idx_meetings_subscription_started,priority:2" json:"startedAt"`
+	EndedAt        *time.Time `gorm:"type:timestamp;column:ended_at" json:"endedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Record) TableName() string { return "meetings" }
+
+// ParticipantRecord is one continuous stay of a user in a meeting; rejoining opens a new row.
+type ParticipantRecord struct {
+	types.BaseModel
+
+	MeetingID uuid.UUID  `gorm:"type:uuid;not null;column:meeting_id;index" json:"meetingId"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index" json:"userId"`
+	Name      string     `gorm:"type:varchar(100)" json:"name"`
+	JoinedAt  time.Time  `gorm:"type:timestamp;not null;column:joined_at" json:"joinedAt"`
+	LeftAt    *time.Time `gorm:"type:timestamp;column:left_at" json:"leftAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (ParticipantRecord) TableName() string { return "meeting_participants" }
+
+// RecordStart persists a newly created meeting.
+func RecordStart(db *gorm.DB, meeting *Meeting) error {
+	subscriptionID, err := uuid.Parse(meeting.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	hostID, err := uuid.Parse(meeting.HostID)
+	if err != nil {
+		return err
+	}
+
+	record := Record{
+		SubscriptionID: subscriptionID,
+		RoomID:         meeting.RoomID,
+		Title:          meeting.Title,
+		Description:    meeting.Description,
+		HostID:         hostID,
+		AccessType:     meeting.AccessType,
+		StartedAt:      meeting.StartedAt.UTC(),
+	}
+	record.ID = meeting.RecordID
+	return db.Create(&record).Error
+}
+
+// RecordJoin opens an attendance row unless the user is already marked present.
+func RecordJoin(db *gorm.DB, meetingID uuid.UUID, userID, name string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	var open int64
+	if err := db.Model(&ParticipantRecord{}).
+		Where("meeting_id = ? AND user_id = ? AND left_at IS NULL", meetingID, uid).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return nil
+	}
+
+	return db.Create(&ParticipantRecord{
+		MeetingID: meetingID,
+		UserID:    uid,
+		Name:      name,
+		JoinedAt:  time.Now().UTC(),
+	}).Error
+}
+
+// RecordLeave closes the user's open attendance row.
+func RecordLeave(db *gorm.DB, meetingID uuid.UUID, userID string) error {
+	return db.Model(&ParticipantRecord{}).
+		Where("meeting_id = ? AND user_id = ? AND left_at IS NULL", meetingID, userID).
+		Update("left_at", time.Now().UTC()).Error
+}
+
+// RecordEnd stamps the meeting end and closes every open attendance row.
+func RecordEnd(db *gorm.DB, meetingID uuid.UUID) error {
+	now := time.Now().UTC()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ParticipantRecord{}).
+			Where("meeting_id = ? AND left_at IS NULL", meetingID).
+			Update("left_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Record{}).
+			Where("id = ? AND ended_at IS NULL", meetingID).
+			Update("ended_at", now).Error
+	})
+}
+
+// MeetingSummary is a meeting row of the attendance report.
+type MeetingSummary struct {
+	ID               uuid.UUID  `json:"id"`
+	RoomID           string     `json:"roomId"`
+	Title            string     `json:"title"`
+	HostID           uuid.UUID  `json:"hostId"`
+	StartedAt        time.Time  `json:"startedAt"`
+	EndedAt          *time.Time `json:"endedAt,omitempty"`
+	ParticipantCount int64      `json:"participantCount"`
+}
+
+// AttendeeSummary aggregates one user's attendance across the report range.
+type AttendeeSummary struct {
+	UserID           uuid.UUID `json:"userId"`
+	Name             string    `json:"name"`
+	MeetingsAttended int64     `json:"meetingsAttended"`
+	TotalSeconds     int64     `json:"totalSeconds"`
+}
+
+// Attendance returns the subscription's meetings started within [from, to) together
+// with per-user attendance totals. Stays still in progress count up to now.
+func Attendance(db *gorm.DB, subscriptionID uuid.UUID, from, to time.Time) ([]MeetingSummary, []AttendeeSummary, error) {
+	meetings := make([]MeetingSummary, 0)
+	if err := db.Table("meetings AS m").
+		Select("m.id, m.room_id, m.title, m.host_id, m.started_at, m.ended_at, COUNT(DISTINCT mp.user_id) AS participant_count").
+		Joins("LEFT JOIN meeting_participants AS mp ON mp.meeting_id = m.id").
+		Where("m.subscription_id = ? AND m.started_at >= ? AND m.started_at < ?", subscriptionID, from, to).
+		Group("m.id").
+		Order("m.started_at DESC").
+		Scan(&meetings).Error; err != nil {
+		return nil, nil, err
+	}
+
+	attendees := make([]AttendeeSummary, 0)
+	if err := db.Table("meeting_participants AS mp").
+		Select(`mp.user_id, MAX(mp.name) AS name, COUNT(DISTINCT mp.meeting_id) AS meetings_attended,
+			COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(mp.left_at, NOW() AT TIME ZONE 'UTC') - mp.joined_at))), 0)::bigint AS total_seconds`).
+		Joins("JOIN meetings AS m ON m.id = mp.meeting_id").
+		Where("m.subscription_id = ? AND m.started_at >= ? AND m.started_at < ?", subscriptionID, from, to).
+		Group("mp.user_id").
+		Order("total_seconds DESC").
+		Scan(&attendees).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return meetings, attendees, nil
+}
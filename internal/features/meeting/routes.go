@@ -2,9 +2,11 @@ package meeting
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff, acAll []gin.HandlerFunc) {
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff, acAll, acReports []gin.HandlerFunc) {
 	meetings := router.Group("/subscriptions/:subscriptionId")
 	{
 		meetings.POST("/meetings",
@@ -21,6 +23,13 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff, acAll []
 			)...,
 		)
 
+		meetings.GET("/meetings/attendance",
+			append(
+				acReports,
+				handler.GetAttendance,
+			)...,
+		)
+
 		meetings.GET("/room/:roomId",
 			append(
 				acAll,
@@ -55,5 +64,39 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff, acAll []
 				handler.EndMeeting,
 			)...,
 		)
+
+		meetings.PUT("/meetings/:roomId/hand",
+			append(
+				acAll,
+				handler.SetHandRaised,
+			)...,
+		)
+
+		meetings.POST("/meetings/:roomId/mute-all",
+			append(
+				acStaff,
+				handler.MuteAll,
+			)...,
+		)
+
+		meetings.DELETE("/meetings/:roomId/participants/:userId",
+			append(
+				acStaff,
+				handler.KickParticipant,
+			)...,
+		)
+
+		meetings.PUT("/meetings/:roomId/participants/:userId/permissions",
+			append(
+				acStaff,
+				handler.UpdateParticipantPermissions,
+			)...,
+		)
 	}
+
+	openapi.Describe(handler.CreateMeeting, openapi.Spec{Request: createMeetingRequest{}})
+	openapi.Describe(handler.UpdateStudentPermissions, openapi.Spec{Request: StudentPermissions{}, Response: StudentPermissions{}})
+	openapi.Describe(handler.SetHandRaised, openapi.Spec{Request: setHandRaisedRequest{}, Response: Participant{}})
+	openapi.Describe(handler.MuteAll, openapi.Spec{Request: muteAllRequest{}})
+	openapi.Describe(handler.UpdateParticipantPermissions, openapi.Spec{Request: PermissionOverride{}})
 }
@@ -0,0 +1,25 @@
+package message
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrRecipientNotFound    = errors.New("recipient not found")
+	ErrSelfConversation     = errors.New("cannot message yourself")
+	ErrNotAllowed           = errors.New("not allowed to message this user")
+	ErrBodyRequired         = errors.New("message body or attachment is required")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrConversationNotFound, Status: http.StatusNotFound, Code: "CONVERSATION_NOT_FOUND", Message: "Conversation not found."},
+	{Err: ErrRecipientNotFound, Status: http.StatusNotFound, Code: "RECIPIENT_NOT_FOUND", Message: "Recipient not found."},
+	{Err: ErrSelfConversation, Status: http.StatusBadRequest, Code: "SELF_CONVERSATION", Message: "You cannot message yourself."},
+	{Err: ErrNotAllowed, Status: http.StatusForbidden, Code: "MESSAGING_NOT_ALLOWED", Message: "You are not allowed to message this user."},
+	{Err: ErrBodyRequired, Status: http.StatusBadRequest, Code: "MESSAGE_BODY_REQUIRED", Message: "Message body or attachment is required."},
+}
@@ -0,0 +1,414 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/filetype"
+	"github.com/mo-amir99/lms-server-go/pkg/imaging"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+const (
+	// maxBodyLength caps the text of a message, in characters.
+	maxBodyLength = 5000
+	// maxAttachmentBytes caps a message's file below the per-kind upload policies.
+	maxAttachmentBytes = 25 << 20
+	// attachmentImageWidth is the width images are re-encoded at.
+	attachmentImageWidth = 1920
+)
+
+// attachmentPolicies are the kinds of file a message may carry.
+var attachmentPolicies = []filetype.Policy{filetype.Image, filetype.PDF, filetype.Audio}
+
+// Broadcaster delivers message events to a user's sockets.
+type Broadcaster interface {
+	EmitToUser(userID uuid.UUID, event string, payload any)
+}
+
+// Handler processes direct message HTTP requests.
+type Handler struct {
+	db            *gorm.DB
+	logger        *slog.Logger
+	storageClient *bunny.StorageClient
+	events        Broadcaster
+}
+
+// NewHandler constructs a direct message handler instance. Attachments are
+// refused without a storage client; events may be nil.
+func NewHandler(db *gorm.DB, logger *slog.Logger, storageClient *bunny.StorageClient, events Broadcaster) *Handler {
+	return &Handler{db: db, logger: logger, storageClient: storageClient, events: events}
+}
+
+type openRequest struct {
+	UserID uuid.UUID `json:"userId" binding:"required"`
+}
+
+type sendRequest struct {
+	Body string `json:"body" binding:"required,notblank,max=5000"`
+}
+
+// unreadResponse is the user's unread total in a subscription.
+type unreadResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// readEvent tells both members how far one of them has read.
+type readEvent struct {
+	ConversationID uuid.UUID `json:"conversationId"`
+	UserID         uuid.UUID `json:"userId"`
+	ReadAt         time.Time `json:"readAt"`
+}
+
+// List returns the current user's conversations with unread counts.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, usr, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	params := pagination.Extract(c)
+	summaries, total, err := List(h.db, subscriptionID, usr.ID, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list conversations", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, summaries, "", pagination.MetadataFrom(total, params))
+}
+
+// Unread returns how many messages the current user has not read.
+func (h *Handler) Unread(c *gin.Context) {
+	subscriptionID, usr, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	count, err := UnreadCount(h.db, subscriptionID, usr.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to count unread messages", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, unreadResponse{Unread: count}, "", nil)
+}
+
+// Open returns the conversation with another member, starting it if needed.
+func (h *Handler) Open(c *gin.Context) {
+	subscriptionID, usr, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	var req openRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid conversation payload") {
+		return
+	}
+
+	if req.UserID == usr.ID {
+		h.respondError(c, ErrSelfConversation, "failed to open conversation")
+		return
+	}
+	if err := h.checkRecipient(subscriptionID, usr, req.UserID); err != nil {
+		h.respondError(c, err, "failed to load recipient")
+		return
+	}
+
+	conversation, err := Open(h.db, subscriptionID, usr.ID, req.UserID)
+	if err != nil {
+		h.respondError(c, err, "failed to open conversation")
+		return
+	}
+
+	response.Success(c, http.StatusOK, conversation, "", nil)
+}
+
+// Messages returns a page of a conversation's messages, newest first.
+func (h *Handler) Messages(c *gin.Context) {
+	_, _, conversation, ok := h.resolveConversation(c)
+	if !ok {
+		return
+	}
+
+	params := pagination.Extract(c)
+	messages, total, err := Messages(h.db, conversation.ID, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list messages", err)
+		return
+	}
+
+	for i := range messages {
+		h.signAttachment(&messages[i])
+	}
+	response.Success(c, http.StatusOK, messages, "", pagination.MetadataFrom(total, params))
+}
+
+// Send posts a message as JSON ({"body"}) or as a multipart form with an
+// optional "file" next to the "body" field, and pushes it to both members.
+func (h *Handler) Send(c *gin.Context) {
+	subscriptionID, usr, conversation, ok := h.resolveConversation(c)
+	if !ok {
+		return
+	}
+
+	recipientID, err := Recipient(h.db, conversation.ID, usr.ID)
+	if err == nil {
+		err = h.checkRecipient(subscriptionID, usr, recipientID)
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to load recipient")
+		return
+	}
+
+	message := Message{ConversationID: conversation.ID, SenderID: usr.ID}
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentBytes+1<<20)
+		message.Body = strings.TrimSpace(c.PostForm("body"))
+		if utf8.RuneCountInString(message.Body) > maxBodyLength {
+			request.RespondInvalid(c, validation.FieldError{Field: "body", Rule: "max", Message: fmt.Sprintf("must be at most %d characters", maxBodyLength)})
+			return
+		}
+
+		file, header, err := c.Request.FormFile("file")
+		switch {
+		case errors.Is(err, http.ErrMissingFile):
+		case err != nil:
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "failed to read uploaded file", err)
+			return
+		default:
+			defer file.Close()
+			if !h.uploadAttachment(c, subscriptionID, &message, file, header) {
+				return
+			}
+		}
+	} else {
+		var req sendRequest
+		if !request.BindJSON(h.logger, c, &req, "invalid message payload") {
+			return
+		}
+		message.Body = strings.TrimSpace(req.Body)
+	}
+
+	if err := Send(h.db, &message); err != nil {
+		if message.AttachmentPath != nil {
+			go h.deleteAttachment(*message.AttachmentPath)
+		}
+		h.respondError(c, err, "failed to send message")
+		return
+	}
+
+	h.signAttachment(&message)
+	h.emit([]uuid.UUID{recipientID, usr.ID}, EventMessage, message)
+	response.Created(c, message, "")
+}
+
+// MarkRead marks the conversation read for the current user and tells both
+// members, so the sender sees the receipt and the reader's other devices clear
+// their badge.
+func (h *Handler) MarkRead(c *gin.Context) {
+	_, usr, conversation, ok := h.resolveConversation(c)
+	if !ok {
+		return
+	}
+
+	readAt, err := MarkRead(h.db, conversation.ID, usr.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to mark conversation read", err)
+		return
+	}
+
+	event := readEvent{ConversationID: conversation.ID, UserID: usr.ID, ReadAt: readAt}
+	recipients := []uuid.UUID{usr.ID}
+	if recipientID, err := Recipient(h.db, conversation.ID, usr.ID); err == nil {
+		recipients = append(recipients, recipientID)
+	}
+	h.emit(recipients, EventRead, event)
+
+	response.Success(c, http.StatusOK, event, "", nil)
+}
+
+// checkRecipient applies the messaging rules between the current user and
+// another member of the subscription.
+func (h *Handler) checkRecipient(subscriptionID uuid.UUID, usr *middleware.User, recipientID uuid.UUID) error {
+	recipient, err := GetParticipant(h.db, subscriptionID, recipientID)
+	if err != nil {
+		return err
+	}
+	if !CanMessage(usr.UserType, recipient.UserType) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// uploadAttachment checks the file against the attachment policies and stores
+// it under the subscription's folder. Images are re-encoded without metadata.
+// ok is false once an error has been answered.
+func (h *Handler) uploadAttachment(c *gin.Context, subscriptionID uuid.UUID, message *Message, file multipart.File, header *multipart.FileHeader) bool {
+	if h.storageClient == nil {
+		response.ErrorWithLog(h.logger, c, http.StatusServiceUnavailable, "Attachment storage is not configured.", nil)
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	policy := attachmentPolicies[0]
+	for _, candidate := range attachmentPolicies {
+		if err := candidate.CheckName(header.Filename, 0); err == nil {
+			policy = candidate
+			break
+		}
+	}
+	policy.MaxSize = min(policy.MaxSize, maxAttachmentBytes)
+	if err := policy.CheckName(header.Filename, header.Size); err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return false
+	}
+
+	head, err := filetype.Head(file)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read uploaded file", err)
+		return false
+	}
+	contentType, err := policy.CheckContent(head)
+	if err != nil {
+		request.RespondRejectedFile(h.logger, c, err)
+		return false
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to read uploaded file", err)
+		return false
+	}
+
+	sub, err := subscription.Get(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load subscription", err)
+		return false
+	}
+	basePath := fmt.Sprintf("%s/messages/%s/%s", sub.IdentifierName, message.ConversationID, uuid.New())
+
+	var remotePath string
+	size := header.Size
+	if policy.Kind == filetype.Image.Kind {
+		variants, err := imaging.Resize(file, []int{attachmentImageWidth})
+		if err != nil {
+			if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+				request.RespondInvalid(c, validation.FieldError{Field: "file", Rule: "image", Message: err.Error()})
+				return false
+			}
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "image could not be read", err)
+			return false
+		}
+		image := variants[len(variants)-1]
+		remotePath, contentType, size = basePath+".jpg", imaging.ContentTypeJPEG, int64(len(image.Data))
+		err = h.storageClient.UploadBuffer(c.Request.Context(), image.Data, remotePath, contentType)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to upload attachment to storage", err)
+			return false
+		}
+	} else {
+		remotePath = basePath + ext
+		if _, err := h.storageClient.UploadSizedStream(c.Request.Context(), remotePath, file, header.Size, contentType); err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to upload attachment to storage", err)
+			return false
+		}
+	}
+
+	url := h.storageClient.GetPublicURL(remotePath)
+	name := filepath.Base(header.Filename)
+	message.AttachmentPath = &url
+	message.AttachmentName = &name
+	message.AttachmentType = &contentType
+	message.AttachmentSize = &size
+	return true
+}
+
+// signAttachment swaps the stored attachment URL for a token-authenticated one
+// on the response copy.
+func (h *Handler) signAttachment(message *Message) {
+	if h.storageClient == nil || message.AttachmentPath == nil {
+		return
+	}
+	signed := h.storageClient.SignedURL(*message.AttachmentPath)
+	message.AttachmentPath = &signed
+}
+
+// deleteAttachment removes the file of a message that could not be stored;
+// failures only leave an orphaned file behind, so they are logged.
+func (h *Handler) deleteAttachment(attachmentURL string) {
+	remotePath := h.storageClient.ExtractRelativePath(attachmentURL)
+	if err := h.storageClient.DeleteFile(context.Background(), remotePath); err != nil {
+		h.logger.Error("failed to delete message attachment", "path", remotePath, "error", err)
+	}
+}
+
+func (h *Handler) emit(userIDs []uuid.UUID, event string, payload any) {
+	if h.events == nil {
+		return
+	}
+	for _, userID := range userIDs {
+		h.events.EmitToUser(userID, event, payload)
+	}
+}
+
+// resolve reads the subscription from the path and the current user.
+func (h *Handler) resolve(c *gin.Context) (uuid.UUID, *middleware.User, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, nil, false
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return uuid.Nil, nil, false
+	}
+
+	return subscriptionID, usr, true
+}
+
+// resolveConversation loads a conversation from the path that the current user
+// is a member of.
+func (h *Handler) resolveConversation(c *gin.Context) (uuid.UUID, *middleware.User, Conversation, bool) {
+	subscriptionID, usr, ok := h.resolve(c)
+	if !ok {
+		return uuid.Nil, nil, Conversation{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("conversationId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid conversation id", err)
+		return uuid.Nil, nil, Conversation{}, false
+	}
+
+	conversation, err := Get(h.db, subscriptionID, usr.ID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load conversation")
+		return uuid.Nil, nil, Conversation{}, false
+	}
+
+	return subscriptionID, usr, conversation, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,265 @@
+package message
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Socket events pushed to both members of a conversation.
+const (
+	EventMessage = "directMessage"
+	EventRead    = "conversationRead"
+)
+
+// Conversation is a private exchange between two members of a subscription.
+type Conversation struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;uniqueIndex:idx_conversations_participants,priority:1" json:"subscriptionId"`
+	ParticipantKey string     `gorm:"type:varchar(73);not null;column:participant_key;uniqueIndex:idx_conversations_participants,priority:2" json:"-"`
+	LastMessageAt  *time.Time `gorm:"column:last_message_at" json:"lastMessageAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Conversation) TableName() string { return "conversations" }
+
+// Member links a user to a conversation. Messages from the other member sent
+// after LastReadAt are unread.
+type Member struct {
+	ConversationID uuid.UUID  `gorm:"type:uuid;primaryKey;column:conversation_id" json:"conversationId"`
+	UserID         uuid.UUID  `gorm:"type:uuid;primaryKey;column:user_id;index" json:"userId"`
+	LastReadAt     *time.Time `gorm:"column:last_read_at" json:"lastReadAt,omitempty"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"createdAt"`
+}
+
+// TableName overrides the default table name.
+func (Member) TableName() string { return "conversation_members" }
+
+// Message is one direct message, optionally carrying a file.
+type Message struct {
+	types.BaseModel
+
+	ConversationID uuid.UUID `gorm:"type:uuid;not null;column:conversation_id;index" json:"conversationId"`
+	SenderID       uuid.UUID `gorm:"type:uuid;not null;column:sender_id" json:"senderId"`
+	Body           string    `gorm:"type:text;not null;default:''" json:"body"`
+	AttachmentPath *string   `gorm:"type:text;column:attachment_path" json:"attachmentUrl,omitempty"`
+	AttachmentName *string   `gorm:"type:varchar(255);column:attachment_name" json:"attachmentName,omitempty"`
+	AttachmentType *string   `gorm:"type:varchar(100);column:attachment_type" json:"attachmentType,omitempty"`
+	AttachmentSize *int64    `gorm:"column:attachment_size" json:"attachmentSize,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Message) TableName() string { return "messages" }
+
+// Summary is a conversation as listed for one of its members.
+type Summary struct {
+	ID              uuid.UUID      `json:"id"`
+	SubscriptionID  uuid.UUID      `json:"subscriptionId"`
+	ParticipantID   uuid.UUID      `json:"participantId"`
+	ParticipantName string         `json:"participantName"`
+	ParticipantType types.UserType `json:"participantType"`
+	LastMessage     *string        `json:"lastMessage,omitempty"`
+	LastMessageAt   *time.Time     `json:"lastMessageAt,omitempty"`
+	UnreadCount     int64          `json:"unreadCount"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+// Participant is the part of a user messaging rules are checked against.
+type Participant struct {
+	ID             uuid.UUID
+	FullName       string
+	UserType       types.UserType
+	SubscriptionID *uuid.UUID
+	Active         bool `gorm:"column:is_active"`
+}
+
+// CanMessage reports whether a sender may start or continue a conversation with
+// a recipient. Students only reach the subscription's instructors and
+// assistants; staff reach students and each other.
+func CanMessage(sender, recipient types.UserType) bool {
+	switch {
+	case sender == types.UserTypeStudent:
+		return authz.IsSubscriptionStaff(recipient)
+	case authz.IsStaff(sender):
+		return recipient == types.UserTypeStudent || authz.IsSubscriptionStaff(recipient)
+	}
+	return false
+}
+
+// GetParticipant loads a user who can take part in the subscription's
+// conversations: an active member of it.
+func GetParticipant(db *gorm.DB, subscriptionID, userID uuid.UUID) (Participant, error) {
+	var found []Participant
+	if err := db.Table("users").
+		Select("id, full_name, user_type, subscription_id, is_active").
+		Where("id = ? AND subscription_id = ? AND is_active", userID, subscriptionID).
+		Limit(1).Scan(&found).Error; err != nil {
+		return Participant{}, err
+	}
+	if len(found) == 0 {
+		return Participant{}, ErrRecipientNotFound
+	}
+	return found[0], nil
+}
+
+// participantKey orders the pair so either member opens the same conversation.
+func participantKey(a, b uuid.UUID) string {
+	if a.String() > b.String() {
+		a, b = b, a
+	}
+	return a.String() + ":" + b.String()
+}
+
+// Open returns the pair's conversation in the subscription, creating it on first contact.
+func Open(db *gorm.DB, subscriptionID, userID, recipientID uuid.UUID) (Conversation, error) {
+	if userID == recipientID {
+		return Conversation{}, ErrSelfConversation
+	}
+
+	key := participantKey(userID, recipientID)
+	var conversation Conversation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		candidate := Conversation{SubscriptionID: subscriptionID, ParticipantKey: key}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "participant_key"}},
+			DoNothing: true,
+		}).Create(&candidate).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ? AND participant_key = ?", subscriptionID, key).
+			First(&conversation).Error; err != nil {
+			return err
+		}
+
+		members := []Member{
+			{ConversationID: conversation.ID, UserID: userID},
+			{ConversationID: conversation.ID, UserID: recipientID},
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+	})
+	return conversation, err
+}
+
+// Get loads a conversation of the subscription the user is a member of.
+func Get(db *gorm.DB, subscriptionID, userID, id uuid.UUID) (Conversation, error) {
+	var conversation Conversation
+	err := db.Joins("JOIN conversation_members ON conversation_members.conversation_id = conversations.id").
+		Where("conversations.id = ? AND conversations.subscription_id = ? AND conversation_members.user_id = ?", id, subscriptionID, userID).
+		First(&conversation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return conversation, ErrConversationNotFound
+	}
+	return conversation, err
+}
+
+// Recipient returns the other member of the conversation.
+func Recipient(db *gorm.DB, conversationID, userID uuid.UUID) (uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := db.Model(&Member{}).
+		Where("conversation_id = ? AND user_id <> ?", conversationID, userID).
+		Limit(1).Pluck("user_id", &ids).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if len(ids) == 0 {
+		return uuid.Nil, ErrRecipientNotFound
+	}
+	return ids[0], nil
+}
+
+// List returns the user's conversations in the subscription, most recently
+// active first, with the other member and the unread count.
+func List(db *gorm.DB, subscriptionID, userID uuid.UUID, params pagination.Params) ([]Summary, int64, error) {
+	query := db.Table("conversations").
+		Joins("JOIN conversation_members me ON me.conversation_id = conversations.id AND me.user_id = ?", userID).
+		Joins("JOIN conversation_members other ON other.conversation_id = conversations.id AND other.user_id <> ?", userID).
+		Joins("JOIN users ON users.id = other.user_id").
+		Where("conversations.subscription_id = ?", subscriptionID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	summaries := make([]Summary, 0)
+	err := query.
+		Select(`conversations.id, conversations.subscription_id, conversations.last_message_at, conversations.created_at,
+			users.id AS participant_id, users.full_name AS participant_name, users.user_type AS participant_type,
+			(SELECT body FROM messages WHERE messages.conversation_id = conversations.id
+				ORDER BY messages.created_at DESC LIMIT 1) AS last_message,
+			(SELECT COUNT(*) FROM messages WHERE messages.conversation_id = conversations.id
+				AND messages.sender_id <> me.user_id
+				AND (me.last_read_at IS NULL OR messages.created_at > me.last_read_at)) AS unread_count`).
+		Order("COALESCE(conversations.last_message_at, conversations.created_at) DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Scan(&summaries).Error
+	return summaries, total, err
+}
+
+// UnreadCount returns how many messages the user has not read across the
+// subscription's conversations.
+func UnreadCount(db *gorm.DB, subscriptionID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Table("messages").
+		Joins("JOIN conversation_members me ON me.conversation_id = messages.conversation_id AND me.user_id = ?", userID).
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id").
+		Where("conversations.subscription_id = ? AND messages.sender_id <> ?", subscriptionID, userID).
+		Where("me.last_read_at IS NULL OR messages.created_at > me.last_read_at").
+		Count(&count).Error
+	return count, err
+}
+
+// Messages returns a page of the conversation's messages, newest first.
+func Messages(db *gorm.DB, conversationID uuid.UUID, params pagination.Params) ([]Message, int64, error) {
+	query := db.Model(&Message{}).Where("conversation_id = ?", conversationID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	messages := make([]Message, 0)
+	err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(&messages).Error
+	return messages, total, err
+}
+
+// Send stores a message, moves the conversation up the members' lists and
+// marks it read for the sender.
+func Send(db *gorm.DB, message *Message) error {
+	if message.Body == "" && message.AttachmentPath == nil {
+		return ErrBodyRequired
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Conversation{}).Where("id = ?", message.ConversationID).
+			Updates(map[string]interface{}{"last_message_at": message.CreatedAt, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return markRead(tx, message.ConversationID, message.SenderID, message.CreatedAt)
+	})
+}
+
+// MarkRead records that the user has read the conversation up to now.
+func MarkRead(db *gorm.DB, conversationID, userID uuid.UUID) (time.Time, error) {
+	readAt := time.Now().UTC()
+	return readAt, markRead(db, conversationID, userID, readAt)
+}
+
+// markRead only moves a member's read marker forward.
+func markRead(db *gorm.DB, conversationID, userID uuid.UUID, readAt time.Time) error {
+	return db.Model(&Member{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Where("last_read_at IS NULL OR last_read_at < ?", readAt).
+		Update("last_read_at", readAt).Error
+}
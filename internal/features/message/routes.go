@@ -0,0 +1,26 @@
+package message
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes sets up direct message endpoints under /subscriptions/:subscriptionId/conversations.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll []gin.HandlerFunc) {
+	conversations := router.Group("/subscriptions/:subscriptionId/conversations")
+
+	conversations.GET("", append(acAll, handler.List)...)
+	conversations.POST("", append(acAll, handler.Open)...)
+	conversations.GET("/unread", append(acAll, handler.Unread)...)
+	conversations.GET("/:conversationId/messages", append(acAll, handler.Messages)...)
+	conversations.POST("/:conversationId/messages", append(acAll, handler.Send)...)
+	conversations.PUT("/:conversationId/read", append(acAll, handler.MarkRead)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Summary{}})
+	openapi.Describe(handler.Open, openapi.Spec{Request: openRequest{}, Response: Conversation{}})
+	openapi.Describe(handler.Unread, openapi.Spec{Response: unreadResponse{}})
+	openapi.Describe(handler.Messages, openapi.Spec{Response: []Message{}})
+	openapi.Describe(handler.Send, openapi.Spec{Request: sendRequest{}, Response: Message{}})
+	openapi.Describe(handler.MarkRead, openapi.Spec{Response: readEvent{}})
+}
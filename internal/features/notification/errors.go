@@ -0,0 +1,17 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrNotificationNotFound, Status: http.StatusNotFound, Code: "NOTIFICATION_NOT_FOUND", Message: "Notification not found."},
+}
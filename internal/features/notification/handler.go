@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes notification HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a notification handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// List returns the current user's notifications.
+func (h *Handler) List(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
+	unreadOnly := c.Query("unreadOnly") == "true"
+
+	notifications, total, err := List(h.db, currentUser.ID, unreadOnly, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list notifications", err)
+		return
+	}
+
+	notifications, meta := pagination.Page(notifications, total, params)
+	response.Success(c, http.StatusOK, notifications, "", meta)
+}
+
+// UnreadCount returns the number of unread notifications of the current user.
+func (h *Handler) UnreadCount(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	count, err := UnreadCount(h.db, currentUser.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to count notifications", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"unread": count}, "", nil)
+}
+
+// MarkRead marks a single notification as read.
+func (h *Handler) MarkRead(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("notificationId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid notification id", err)
+		return
+	}
+
+	if err := MarkRead(h.db, currentUser.ID, id); err != nil {
+		h.respondError(c, err, "failed to mark notification as read")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// MarkAllRead marks every notification of the current user as read.
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	updated, err := MarkAllRead(h.db, currentUser.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to mark notifications as read", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"updated": updated}, "", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// maxMentions caps how many distinct handles one message may notify.
+const maxMentions = 20
+
+// A mention is "@handle" where handle is the local part of the member's email
+// (accounts are created as handle@subscription-identifier), not preceded by a word
+// character so email addresses in the text are not treated as mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([A-Za-z0-9][A-Za-z0-9._-]{0,63})`)
+
+// ParseMentions returns the distinct, lower-cased handles mentioned in the text.
+func ParseMentions(text string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(text, -1)
+	seen := make(map[string]bool, len(matches))
+	handles := make([]string, 0, len(matches))
+
+	for _, m := range matches {
+		handle := strings.ToLower(strings.TrimRight(m[1], "._-"))
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+		if len(handles) == maxMentions {
+			break
+		}
+	}
+
+	return handles
+}
+
+// ResolveMentions maps handles to active members of the subscription, skipping excludeID.
+func ResolveMentions(db *gorm.DB, subscriptionID uuid.UUID, handles []string, excludeID uuid.UUID) ([]uuid.UUID, error) {
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	err := db.Table("users").
+		Where("subscription_id = ? AND is_active = ? AND id <> ?", subscriptionID, true, excludeID).
+		Where("user_type <> ?", types.UserTypeReferrer).
+		Where("LOWER(split_part(email, '@', 1)) IN ?", handles).
+		Pluck("id", &ids).Error
+	return ids, err
+}
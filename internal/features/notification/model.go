@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Type identifies why a notification was created.
+type Type string
+
+const (
+	TypeThreadReply     Type = "thread_reply"
+	TypeMention         Type = "mention"
+	TypeSessionReminder Type = "session_reminder"
+	TypeSessionStarted  Type = "session_started"
+
+	TypeSubscriptionActivated Type = "subscription_activated"
+	TypeSubscriptionRenewed   Type = "subscription_renewed"
+	TypeSubscriptionExpired   Type = "subscription_expired"
+
+	TypeStorageQuota    Type = "storage_quota"
+	TypeMalwareDetected Type = "malware_detected"
+
+	TypeBookmarkPublished Type = "bookmark_published"
+	TypeQuestionAnswered  Type = "question_answered"
+
+	TypeAnnouncement Type = "announcement"
+)
+
+// Notification is an in-app message addressed to a single user.
+type Notification struct {
+	types.BaseModel
+
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index:idx_notifications_user_read,priority:1" json:"userId"`
+	SubscriptionID *uuid.UUID `gorm:"type:uuid;column:subscription_id" json:"subscriptionId,omitempty"`
+	Type           Type       `gorm:"type:varchar(30);not null" json:"type"`
+	Title          string     `gorm:"type:varchar(150);not null" json:"title"`
+	Message        string     `gorm:"type:varchar(500);not null" json:"message"`
+	ActorName      string     `gorm:"type:varchar(30);column:actor_name" json:"actorName"`
+	ForumID        *uuid.UUID `gorm:"type:uuid;column:forum_id" json:"forumId,omitempty"`
+	ThreadID       *uuid.UUID `gorm:"type:uuid;column:thread_id" json:"threadId,omitempty"`
+	CourseID       *uuid.UUID `gorm:"type:uuid;column:course_id" json:"courseId,omitempty"`
+	LessonID       *uuid.UUID `gorm:"type:uuid;column:lesson_id" json:"lessonId,omitempty"`
+	CommentID      *uuid.UUID `gorm:"type:uuid;column:comment_id" json:"commentId,omitempty"`
+	SessionID      *uuid.UUID `gorm:"type:uuid;column:session_id" json:"sessionId,omitempty"`
+	ReadAt         *time.Time `gorm:"type:timestamp;column:read_at;index:idx_notifications_user_read,priority:2" json:"readAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Notification) TableName() string { return "notifications" }
+
+// ThreadSubscription records that a user follows a thread. Muted subscriptions keep
+// the user attached (so they are not re-subscribed by replying) without notifying them.
+type ThreadSubscription struct {
+	ThreadID  uuid.UUID `gorm:"type:uuid;primaryKey;column:thread_id" json:"threadId"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id;index" json:"userId"`
+	Muted     bool      `gorm:"type:boolean;not null;default:false" json:"muted"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+// TableName overrides the default table name.
+func (ThreadSubscription) TableName() string { return "thread_subscriptions" }
+
+// List returns a user's notifications, newest first.
+func List(db *gorm.DB, userID uuid.UUID, unreadOnly bool, params pagination.Params) ([]Notification, int64, error) {
+	query := db.Model(&Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	notifications := make([]Notification, 0)
+	if err := pagination.Apply(query, params).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// UnreadCount returns how many notifications the user has not read yet.
+func UnreadCount(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one of the user's notifications as read.
+func MarkRead(db *gorm.DB, userID, id uuid.UUID) error {
+	result := db.Model(&Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read.
+func MarkAllRead(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	result := db.Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// GetThreadSubscription returns the user's subscription to a thread, or nil if none exists.
+func GetThreadSubscription(db *gorm.DB, threadID, userID uuid.UUID) (*ThreadSubscription, error) {
+	var sub ThreadSubscription
+	err := db.Where("thread_id = ? AND user_id = ?", threadID, userID).Limit(1).Find(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	if sub.ThreadID == uuid.Nil {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+// Follow subscribes the user to a thread, keeping an existing mute setting untouched.
+func Follow(db *gorm.DB, threadID, userID uuid.UUID) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&ThreadSubscription{ThreadID: threadID, UserID: userID}).Error
+}
+
+// SetThreadSubscription subscribes the user to a thread with the given mute setting.
+func SetThreadSubscription(db *gorm.DB, threadID, userID uuid.UUID, muted bool) (ThreadSubscription, error) {
+	sub := ThreadSubscription{ThreadID: threadID, UserID: userID, Muted: muted}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "thread_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"muted", "updated_at"}),
+	}).Create(&sub).Error
+	return sub, err
+}
+
+// Unfollow removes the user's subscription to a thread.
+func Unfollow(db *gorm.DB, threadID, userID uuid.UUID) error {
+	return db.Where("thread_id = ? AND user_id = ?", threadID, userID).Delete(&ThreadSubscription{}).Error
+}
+
+// DeleteThreadSubscriptions removes every subscription to a thread.
+func DeleteThreadSubscriptions(db *gorm.DB, threadID uuid.UUID) error {
+	return db.Where("thread_id = ?", threadID).Delete(&ThreadSubscription{}).Error
+}
+
+// ThreadFollowers returns the users following a thread who have not muted it.
+func ThreadFollowers(db *gorm.DB, threadID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Model(&ThreadSubscription{}).
+		Where("thread_id = ? AND muted = ?", threadID, false).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,18 @@
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the current user's notification inbox endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, allUsers []gin.HandlerFunc) {
+	notifications := router.Group("/notifications")
+	notifications.GET("", append(allUsers, handler.List)...)
+	notifications.GET("/unread-count", append(allUsers, handler.UnreadCount)...)
+	notifications.POST("/read-all", append(allUsers, handler.MarkAllRead)...)
+	notifications.POST("/:notificationId/read", append(allUsers, handler.MarkRead)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Notification{}})
+}
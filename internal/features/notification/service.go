@@ -0,0 +1,298 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
+	"github.com/mo-amir99/lms-server-go/internal/features/emailqueue"
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/email"
+	"github.com/mo-amir99/lms-server-go/pkg/eventbus"
+)
+
+// TopicCreated is published on the event bus for each stored notification.
+const TopicCreated = "notification.created"
+
+// Service creates notifications for forum and comment activity and optionally
+// mirrors them by email. Failures are logged and never fail the triggering request.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	mail   *emailqueue.Queue
+	events *eventbus.Bus
+}
+
+// NewService constructs a notification service. mail may be nil to disable emails.
+func NewService(db *gorm.DB, logger *slog.Logger, mail *emailqueue.Queue) *Service {
+	return &Service{db: db, logger: logger, mail: mail}
+}
+
+// UseEvents sets the bus stored notifications are published to. It must be
+// set before the service is used.
+func (s *Service) UseEvents(events *eventbus.Bus) {
+	s.events = events
+}
+
+// ThreadEvent describes a new thread or reply.
+type ThreadEvent struct {
+	SubscriptionID uuid.UUID
+	ForumID        uuid.UUID
+	ThreadID       uuid.UUID
+	ThreadTitle    string
+	ActorID        uuid.UUID
+	ActorName      string
+	Text           string
+	// Visible is false while a thread awaits approval; mentions are held back until then.
+	Visible bool
+}
+
+// CommentEvent describes a new lesson comment.
+type CommentEvent struct {
+	SubscriptionID uuid.UUID
+	CourseID       uuid.UUID
+	LessonID       uuid.UUID
+	CommentID      uuid.UUID
+	ActorID        uuid.UUID
+	ActorName      string
+	Text           string
+}
+
+// ThreadCreated subscribes the author to the thread and notifies mentioned members.
+func (s *Service) ThreadCreated(ev ThreadEvent) {
+	if err := Follow(s.db, ev.ThreadID, ev.ActorID); err != nil {
+		s.logger.Error("failed to subscribe author to thread", slog.String("threadId", ev.ThreadID.String()), slog.String("error", err.Error()))
+	}
+	if !ev.Visible {
+		return
+	}
+
+	mentioned := s.mentioned(ev.SubscriptionID, ev.Text, ev.ActorID)
+	s.notify(mentioned, s.threadNotification(ev, TypeMention,
+		fmt.Sprintf("%s mentioned you in \"%s\"", ev.ActorName, ev.ThreadTitle)))
+}
+
+// ThreadReplied subscribes the replier, notifies mentioned members and then every
+// other unmuted follower of the thread.
+func (s *Service) ThreadReplied(ev ThreadEvent) {
+	if err := Follow(s.db, ev.ThreadID, ev.ActorID); err != nil {
+		s.logger.Error("failed to subscribe replier to thread", slog.String("threadId", ev.ThreadID.String()), slog.String("error", err.Error()))
+	}
+
+	mentioned := s.mentioned(ev.SubscriptionID, ev.Text, ev.ActorID)
+	s.notify(mentioned, s.threadNotification(ev, TypeMention,
+		fmt.Sprintf("%s mentioned you in \"%s\"", ev.ActorName, ev.ThreadTitle)))
+
+	followers, err := ThreadFollowers(s.db, ev.ThreadID)
+	if err != nil {
+		s.logger.Error("failed to load thread followers", slog.String("threadId", ev.ThreadID.String()), slog.String("error", err.Error()))
+		return
+	}
+
+	skip := make(map[uuid.UUID]bool, len(mentioned)+1)
+	skip[ev.ActorID] = true
+	for _, id := range mentioned {
+		skip[id] = true
+	}
+
+	recipients := make([]uuid.UUID, 0, len(followers))
+	for _, id := range followers {
+		if !skip[id] {
+			recipients = append(recipients, id)
+		}
+	}
+
+	s.notify(recipients, s.threadNotification(ev, TypeThreadReply,
+		fmt.Sprintf("%s replied to \"%s\"", ev.ActorName, ev.ThreadTitle)))
+}
+
+// CommentCreated notifies members mentioned in a lesson comment.
+func (s *Service) CommentCreated(ev CommentEvent) {
+	mentioned := s.mentioned(ev.SubscriptionID, ev.Text, ev.ActorID)
+	s.notify(mentioned, Notification{
+		SubscriptionID: &ev.SubscriptionID,
+		Type:           TypeMention,
+		Title:          fmt.Sprintf("%s mentioned you in a lesson comment", ev.ActorName),
+		Message:        excerpt(ev.Text),
+		ActorName:      ev.ActorName,
+		CourseID:       &ev.CourseID,
+		LessonID:       &ev.LessonID,
+		CommentID:      &ev.CommentID,
+	})
+}
+
+// Notify stores a copy of the notification for each recipient. Used by features that
+// compute their own audience, such as scheduled session reminders.
+func (s *Service) Notify(recipients []uuid.UUID, n Notification) {
+	n.Title = truncate(n.Title, 150)
+	n.Message = truncate(n.Message, 500)
+	s.notify(recipients, n)
+}
+
+// SubscriptionChanged is the outbox subscriber that tells the subscription owner
+// about activation, renewal and expiry.
+func (s *Service) SubscriptionChanged(_ context.Context, event outbox.Event) error {
+	ev, err := subscription.DecodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	var (
+		kind  Type
+		title string
+	)
+	switch event.Topic {
+	case subscription.TopicActivated:
+		kind, title = TypeSubscriptionActivated, "Your subscription is active"
+	case subscription.TopicRenewed:
+		kind, title = TypeSubscriptionRenewed, "Your subscription was renewed"
+	case subscription.TopicExpired:
+		kind, title = TypeSubscriptionExpired, "Your subscription has expired"
+	default:
+		return nil
+	}
+
+	message := "Renew it to restore full access for your members."
+	if kind != TypeSubscriptionExpired {
+		message = fmt.Sprintf("Access continues until %s.", ev.SubscriptionEnd.In(s.localeOf(ev.UserID).Location).Format("January 2, 2006"))
+	}
+
+	s.notify([]uuid.UUID{ev.UserID}, Notification{
+		SubscriptionID: &ev.SubscriptionID,
+		Type:           kind,
+		Title:          title,
+		Message:        message,
+	})
+	return nil
+}
+
+// AnnouncementPublished is the event bus subscriber that notifies the audience
+// of a newly published announcement.
+func (s *Service) AnnouncementPublished(_ context.Context, event eventbus.Event) error {
+	published, ok := event.Payload.(announcement.Announcement)
+	if !ok {
+		return fmt.Errorf("unexpected %s payload %T", event.Topic, event.Payload)
+	}
+
+	message := ""
+	if published.Content != nil {
+		message = excerpt(*published.Content)
+	}
+
+	s.notify(event.Users, Notification{
+		SubscriptionID: &published.SubscriptionID,
+		Type:           TypeAnnouncement,
+		Title:          truncate(published.Title, 150),
+		Message:        message,
+	})
+	return nil
+}
+
+func (s *Service) threadNotification(ev ThreadEvent, kind Type, title string) Notification {
+	return Notification{
+		SubscriptionID: &ev.SubscriptionID,
+		Type:           kind,
+		Title:          truncate(title, 150),
+		Message:        excerpt(ev.Text),
+		ActorName:      ev.ActorName,
+		ForumID:        &ev.ForumID,
+		ThreadID:       &ev.ThreadID,
+	}
+}
+
+func (s *Service) mentioned(subscriptionID uuid.UUID, text string, actorID uuid.UUID) []uuid.UUID {
+	ids, err := ResolveMentions(s.db, subscriptionID, ParseMentions(text), actorID)
+	if err != nil {
+		s.logger.Error("failed to resolve mentions", slog.String("error", err.Error()))
+		return nil
+	}
+	return ids
+}
+
+// notify stores one copy of the notification per recipient, publishes it to the
+// event bus and emails them in the background.
+func (s *Service) notify(recipients []uuid.UUID, template Notification) {
+	if len(recipients) == 0 {
+		return
+	}
+
+	rows := make([]Notification, len(recipients))
+	for i, id := range recipients {
+		rows[i] = template
+		rows[i].UserID = id
+	}
+
+	if err := s.db.CreateInBatches(&rows, 500).Error; err != nil {
+		s.logger.Error("failed to store notifications", slog.String("type", string(template.Type)), slog.String("error", err.Error()))
+		return
+	}
+
+	for _, row := range rows {
+		s.events.Publish(context.Background(), eventbus.Event{Topic: TopicCreated, Users: []uuid.UUID{row.UserID}, Payload: row})
+	}
+
+	if s.mail != nil {
+		go s.sendEmails(recipients, template)
+	}
+}
+
+// recipient is the address and language a notification email is sent with.
+type recipient struct {
+	Email             string
+	PreferredLanguage string
+}
+
+func (s *Service) sendEmails(recipients []uuid.UUID, n Notification) {
+	var rows []recipient
+	if err := s.db.Table("users").
+		Select("email, preferred_language").
+		Where("id IN ? AND is_active = ?", recipients, true).
+		Scan(&rows).Error; err != nil {
+		s.logger.Error("failed to load notification recipients", slog.String("error", err.Error()))
+		return
+	}
+
+	title := html.EscapeString(n.Title)
+	message := html.EscapeString(n.Message)
+	for _, row := range rows {
+		opts := email.NotificationMessage(row.Email, title, message)
+		opts.Language = email.LocaleFor(row.PreferredLanguage, "").Language
+		if err := s.mail.Enqueue(emailqueue.KindNotification, opts); err != nil {
+			s.logger.Warn("failed to queue notification email", slog.String("to", row.Email), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// localeOf returns the user's language and timezone, or the defaults when they
+// cannot be loaded.
+func (s *Service) localeOf(userID uuid.UUID) email.Locale {
+	var prefs struct {
+		PreferredLanguage string
+		Timezone          string
+	}
+	if err := s.db.Table("users").
+		Select("preferred_language, timezone").
+		Where("id = ?", userID).
+		Take(&prefs).Error; err != nil {
+		s.logger.Warn("failed to load user locale", slog.String("userId", userID.String()), slog.String("error", err.Error()))
+	}
+	return email.LocaleFor(prefs.PreferredLanguage, prefs.Timezone)
+}
+
+func excerpt(text string) string {
+	return truncate(text, 200)
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	batchSize = 100
+	// staleDispatching is how long a claimed event may stay in dispatching before
+	// another worker assumes the claimer died and retries it.
+	staleDispatching = 10 * time.Minute
+)
+
+// Handler consumes one event. Delivery is at least once: a failing subscriber
+// causes the whole event to be retried, so handlers must be idempotent.
+type Handler func(ctx context.Context, event Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Dispatcher routes committed events to the subscribers of their topic.
+type Dispatcher struct {
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+}
+
+// NewDispatcher constructs an empty dispatcher.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{subscribers: make(map[string][]subscriber)}
+}
+
+// Subscribe registers handler for topic under a name used in logs and errors.
+func (d *Dispatcher) Subscribe(topic, name string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.subscribers[topic] = append(d.subscribers[topic], subscriber{name: name, handler: handler})
+}
+
+// Dispatch runs every subscriber of the event's topic and joins their errors.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	d.mu.RLock()
+	subs := d.subscribers[event.Topic]
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if err := sub.handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AuditLog returns a subscriber that writes every event it receives to the
+// audit log.
+func AuditLog(logger *slog.Logger) Handler {
+	return func(_ context.Context, event Event) error {
+		logger.Info("domain event",
+			slog.Bool("audit", true),
+			slog.String("eventId", event.ID.String()),
+			slog.String("topic", event.Topic),
+			slog.String("aggregateId", event.AggregateID.String()),
+			slog.Time("occurredAt", event.CreatedAt),
+			slog.String("payload", event.Payload))
+		return nil
+	}
+}
+
+// Job dispatches pending outbox events. It is meant to run every few seconds on
+// the job scheduler.
+type Job struct {
+	db         *gorm.DB
+	logger     *slog.Logger
+	dispatcher *Dispatcher
+}
+
+// NewJob constructs the outbox dispatch job.
+func NewJob(db *gorm.DB, logger *slog.Logger, dispatcher *Dispatcher) *Job {
+	return &Job{db: db, logger: logger, dispatcher: dispatcher}
+}
+
+// Name returns the job name.
+func (j *Job) Name() string {
+	return "outbox-dispatch"
+}
+
+// Execute drains due events one batch at a time.
+func (j *Job) Execute(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		events, err := ClaimDue(j.db.WithContext(ctx), time.Now().UTC(), staleDispatching, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			j.dispatch(ctx, event)
+		}
+
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (j *Job) dispatch(ctx context.Context, event Event) {
+	dispatchErr := j.dispatcher.Dispatch(ctx, event)
+	now := time.Now().UTC()
+
+	// Persist the outcome even if the job is being cancelled
+	db := j.db.WithContext(context.WithoutCancel(ctx))
+	if dispatchErr == nil {
+		if err := MarkDone(db, event.ID, now); err != nil {
+			j.logger.Error("failed to mark outbox event as done", slog.String("eventId", event.ID.String()), slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	j.logger.Warn("outbox event dispatch failed",
+		slog.String("eventId", event.ID.String()),
+		slog.String("topic", event.Topic),
+		slog.Int("attempt", event.Attempts+1),
+		slog.String("error", dispatchErr.Error()))
+	if err := MarkAttemptFailed(db, event, dispatchErr, now); err != nil {
+		j.logger.Error("failed to record outbox failure", slog.String("eventId", event.ID.String()), slog.String("error", err.Error()))
+	}
+}
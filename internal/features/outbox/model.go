@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Status tracks an event through dispatch.
+type Status string
+
+const (
+	// StatusPending means the event waits for its next dispatch attempt.
+	StatusPending Status = "pending"
+	// StatusDispatching means a worker claimed the event and is running subscribers.
+	StatusDispatching Status = "dispatching"
+	// StatusDone means every subscriber handled the event.
+	StatusDone Status = "done"
+	// StatusFailed means subscribers kept failing and the event was given up on.
+	StatusFailed Status = "failed"
+)
+
+const (
+	defaultMaxAttempts = 10
+	baseBackoff        = 30 * time.Second
+	maxBackoff         = time.Hour
+)
+
+// Event is a domain event written in the same transaction as the state change it
+// describes, so subscribers see exactly the changes that committed.
+type Event struct {
+	types.BaseModel
+
+	Topic         string     `gorm:"type:varchar(100);not null;index" json:"topic"`
+	AggregateID   uuid.UUID  `gorm:"type:uuid;not null;column:aggregate_id;index" json:"aggregateId"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status        Status     `gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_events_status_next,priority:1" json:"status"`
+	Attempts      int        `gorm:"type:int;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"type:timestamp;not null;column:next_attempt_at;index:idx_outbox_events_status_next,priority:2" json:"nextAttemptAt"`
+	LastError     string     `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	ProcessedAt   *time.Time `gorm:"type:timestamp;column:processed_at" json:"processedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Event) TableName() string { return "outbox_events" }
+
+// Decode unmarshals the event payload into dest.
+func (e Event) Decode(dest interface{}) error {
+	if err := json.Unmarshal([]byte(e.Payload), dest); err != nil {
+		return fmt.Errorf("decode %s payload: %w", e.Topic, err)
+	}
+	return nil
+}
+
+// Publish records an event using tx. Call it inside the transaction that makes the
+// change so the event exists if and only if the change commits.
+func Publish(tx *gorm.DB, topic string, aggregateID uuid.UUID, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", topic, err)
+	}
+
+	return tx.Create(&Event{
+		Topic:         topic,
+		AggregateID:   aggregateID,
+		Payload:       string(body),
+		Status:        StatusPending,
+		NextAttemptAt: time.Now().UTC(),
+	}).Error
+}
+
+// ClaimDue moves up to limit due events to dispatching and returns them, oldest
+// first. Events stuck in dispatching longer than staleAfter are reclaimed.
+func ClaimDue(db *gorm.DB, now time.Time, staleAfter time.Duration, limit int) ([]Event, error) {
+	var events []Event
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at <= ?)",
+				StatusPending, now, StatusDispatching, now.Add(-staleAfter)).
+			Order("created_at").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+			events[i].Status = StatusDispatching
+		}
+		return tx.Model(&Event{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": StatusDispatching, "updated_at": now}).Error
+	})
+	return events, err
+}
+
+// MarkDone records that every subscriber handled the event.
+func MarkDone(db *gorm.DB, id uuid.UUID, now time.Time) error {
+	return db.Model(&Event{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       StatusDone,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"processed_at": now,
+		"last_error":   "",
+	}).Error
+}
+
+// MarkAttemptFailed schedules a retry with exponential backoff, or gives up after
+// the maximum number of attempts.
+func MarkAttemptFailed(db *gorm.DB, event Event, dispatchErr error, now time.Time) error {
+	attempts := event.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": truncate(dispatchErr.Error(), 1000),
+	}
+	if attempts >= defaultMaxAttempts {
+		updates["status"] = StatusFailed
+	} else {
+		updates["status"] = StatusPending
+		updates["next_attempt_at"] = now.Add(backoff(attempts))
+	}
+	return db.Model(&Event{}).Where("id = ?", event.ID).Updates(updates).Error
+}
+
+// backoff returns 30s, 1m, 2m, ... capped at one hour.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
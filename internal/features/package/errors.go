@@ -2,10 +2,22 @@ package pkg
 
 import (
 	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
 var (
 	ErrPackageNotFound   = errors.New("subscription package not found")
 	ErrPackageNameTaken  = errors.New("package name already exists")
 	ErrPackageOrderTaken = errors.New("package order already exists")
+	ErrPackageInUse      = errors.New("package is in use")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrPackageNotFound, Status: http.StatusNotFound, Code: "PACKAGE_NOT_FOUND", Message: "Package not found."},
+	{Err: ErrPackageNameTaken, Status: http.StatusConflict, Code: "PACKAGE_NAME_TAKEN", Message: "Package name already exists."},
+	{Err: ErrPackageOrderTaken, Status: http.StatusConflict, Code: "PACKAGE_ORDER_TAKEN", Message: "Package order already exists."},
+	{Err: ErrPackageInUse, Status: http.StatusConflict, Code: "PACKAGE_IN_USE", Message: "Package is used by subscriptions or purchases."},
+}
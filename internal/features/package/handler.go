@@ -1,7 +1,6 @@
 package pkg
 
 import (
-	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -15,6 +14,7 @@ import (
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Handler processes subscription package HTTP requests.
@@ -43,9 +43,9 @@ func (h *Handler) List(c *gin.Context) {
 }
 
 type createRequest struct {
-	Name                   string   `json:"name" binding:"required"`
+	Name                   string   `json:"name" binding:"required,notblank"`
 	Description            *string  `json:"description"`
-	DiscountPercentage     *float64 `json:"discountPercentage"`
+	DiscountPercentage     *float64 `json:"discountPercentage" binding:"omitnil,gte=0,lte=100"`
 	Order                  float64  `json:"order" binding:"required"`
 	SubscriptionPointPrice *float64 `json:"subscriptionPointPrice"`
 	SubscriptionPoints     *float64 `json:"subscriptionPoints"`
@@ -54,6 +54,7 @@ type createRequest struct {
 	AssistantsLimit        *float64 `json:"assistantsLimit"`
 	WatchLimit             *float64 `json:"watchLimit"`
 	WatchInterval          *float64 `json:"watchInterval"`
+	RateLimitPerMinute     *int     `json:"rateLimitPerMinute" binding:"omitnil,gte=0"`
 	GooglePlayProductID    *string  `json:"googlePlayProductId"`
 	AppStoreProductID      *string  `json:"appStoreProductId"`
 	Active                 *bool    `json:"isActive"`
@@ -83,8 +84,7 @@ func normalizeOptionalWholeNumber(field string, value *float64) (*int, error) {
 // Create inserts a new package.
 func (h *Handler) Create(c *gin.Context) {
 	var req createRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid package payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid package payload") {
 		return
 	}
 
@@ -145,6 +145,7 @@ func (h *Handler) Create(c *gin.Context) {
 		AssistantsLimit:        assistantsLimit,
 		WatchLimit:             watchLimit,
 		WatchInterval:          watchInterval,
+		RateLimitPerMinute:     req.RateLimitPerMinute,
 		GooglePlayProductID:    req.GooglePlayProductID,
 		AppStoreProductID:      req.AppStoreProductID,
 		Active:                 req.Active,
@@ -176,6 +177,24 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, pkg, "", nil)
 }
 
+type updateRequest struct {
+	Name                   *string                     `json:"name" binding:"omitnil,notblank"`
+	Description            validation.Optional[string] `json:"description" binding:"omitnil,notblank"`
+	DiscountPercentage     *float64                    `json:"discountPercentage" binding:"omitnil,gte=0,lte=100"`
+	Order                  *int                        `json:"order"`
+	SubscriptionPointPrice *float64                    `json:"subscriptionPointPrice" binding:"omitnil,gte=0"`
+	SubscriptionPoints     *int                        `json:"subscriptionPoints" binding:"omitnil,gte=0"`
+	GooglePlayProductID    validation.Optional[string] `json:"googlePlayProductId" binding:"omitnil,notblank"`
+	AppStoreProductID      validation.Optional[string] `json:"appStoreProductId" binding:"omitnil,notblank"`
+	CoursesLimit           *int                        `json:"coursesLimit" binding:"omitnil,gte=0"`
+	CourseLimitInGB        *float64                    `json:"courseLimitInGB" binding:"omitnil,gte=0"`
+	AssistantsLimit        *int                        `json:"assistantsLimit" binding:"omitnil,gte=0"`
+	WatchLimit             *int                        `json:"watchLimit" binding:"omitnil,gte=0"`
+	WatchInterval          *int                        `json:"watchInterval" binding:"omitnil,gte=0"`
+	RateLimitPerMinute     *int                        `json:"rateLimitPerMinute" binding:"omitnil,gte=0"`
+	Active                 *bool                       `json:"isActive"`
+}
+
 // Update modifies an existing package.
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("packageId"))
@@ -184,156 +203,36 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid package payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid package payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["name"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "name must be a string", err)
-			return
-		}
-		input.Name = &str
-	}
-
-	if value, ok := body["description"]; ok {
-		input.DescriptionProvided = true
-		if value == nil {
-			input.Description = nil
-		} else {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "description must be a string", err)
-				return
-			}
-			input.Description = &str
-		}
-	}
-
-	if value, ok := body["discountPercentage"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "discountPercentage must be a number", err)
-			return
-		}
-		input.DiscountPercentage = &val
-	}
-
-	if value, ok := body["order"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "order must be an integer", err)
-			return
-		}
-		input.Order = &val
+	input := UpdateInput{
+		Name:                        request.Trimmed(req.Name),
+		DescriptionProvided:         req.Description.Set,
+		Description:                 request.Trimmed(req.Description.Ptr()),
+		DiscountPercentage:          req.DiscountPercentage,
+		Order:                       req.Order,
+		SubscriptionPoints:          req.SubscriptionPoints,
+		CoursesLimit:                req.CoursesLimit,
+		CourseLimitInGB:             req.CourseLimitInGB,
+		AssistantsLimit:             req.AssistantsLimit,
+		WatchLimit:                  req.WatchLimit,
+		WatchInterval:               req.WatchInterval,
+		RateLimitPerMinute:          req.RateLimitPerMinute,
+		GooglePlayProductIDProvided: req.GooglePlayProductID.Set,
+		GooglePlayProductID:         request.Trimmed(req.GooglePlayProductID.Ptr()),
+		AppStoreProductIDProvided:   req.AppStoreProductID.Set,
+		AppStoreProductID:           request.Trimmed(req.AppStoreProductID.Ptr()),
+		Active:                      req.Active,
 	}
 
-	if value, ok := body["subscriptionPointPrice"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionPointPrice must be a number", err)
-			return
-		}
-		m := types.NewMoney(val)
+	if req.SubscriptionPointPrice != nil {
+		m := types.NewMoney(*req.SubscriptionPointPrice)
 		input.SubscriptionPointPrice = &m
 	}
 
-	if value, ok := body["subscriptionPoints"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionPoints must be an integer", err)
-			return
-		}
-		input.SubscriptionPoints = &val
-	}
-
-	if value, ok := body["googlePlayProductId"]; ok {
-		input.GooglePlayProductIDProvided = true
-		if value == nil {
-			input.GooglePlayProductID = nil
-		} else {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "googlePlayProductId must be a string", err)
-				return
-			}
-			input.GooglePlayProductID = &str
-		}
-	}
-
-	if value, ok := body["appStoreProductId"]; ok {
-		input.AppStoreProductIDProvided = true
-		if value == nil {
-			input.AppStoreProductID = nil
-		} else {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "appStoreProductId must be a string", err)
-				return
-			}
-			input.AppStoreProductID = &str
-		}
-	}
-
-	if value, ok := body["coursesLimit"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "coursesLimit must be an integer", err)
-			return
-		}
-		input.CoursesLimit = &val
-	}
-
-	if value, ok := body["courseLimitInGB"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "courseLimitInGB must be a number", err)
-			return
-		}
-		input.CourseLimitInGB = &val
-	}
-
-	if value, ok := body["assistantsLimit"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "assistantsLimit must be an integer", err)
-			return
-		}
-		input.AssistantsLimit = &val
-	}
-
-	if value, ok := body["watchLimit"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "watchLimit must be an integer", err)
-			return
-		}
-		input.WatchLimit = &val
-	}
-
-	if value, ok := body["watchInterval"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "watchInterval must be an integer", err)
-			return
-		}
-		input.WatchInterval = &val
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
-	}
-
 	pkg, err := Update(h.db, id, input)
 	if err != nil {
 		h.respondError(c, err, "failed to update package")
@@ -360,25 +259,10 @@ func (h *Handler) Delete(c *gin.Context) {
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrPackageNotFound):
-		status = http.StatusNotFound
-		message = "Package not found."
-	case errors.Is(err, ErrPackageNameTaken):
-		status = http.StatusConflict
-		message = "Package name already exists."
-	case errors.Is(err, ErrPackageOrderTaken):
-		status = http.StatusConflict
-		message = "Package order already exists."
-	default:
-		if err.Error() == "name cannot be empty" {
-			status = http.StatusBadRequest
-			message = err.Error()
-		}
+	// Reported by the service without a sentinel error
+	if message := err.Error(); message == "name cannot be empty" {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, message, err)
+		return
 	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/pkg/types"
@@ -26,6 +27,7 @@ type Package struct {
 	AssistantsLimit        *int         `gorm:"type:int;column:assistants_limit" json:"assistantsLimit,omitempty"`
 	WatchLimit             *int         `gorm:"type:int;column:watch_limit" json:"watchLimit,omitempty"`
 	WatchInterval          *int         `gorm:"type:int;column:watch_interval" json:"watchInterval,omitempty"`
+	RateLimitPerMinute     *int         `gorm:"type:int;column:rate_limit_per_minute" json:"rateLimitPerMinute,omitempty"`
 	GooglePlayProductID    *string      `gorm:"type:varchar(255);column:google_play_product_id" json:"googlePlayProductId,omitempty"`
 	AppStoreProductID      *string      `gorm:"type:varchar(255);column:app_store_product_id" json:"appStoreProductId,omitempty"`
 	Active                 bool         `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
@@ -47,6 +49,7 @@ type CreateInput struct {
 	AssistantsLimit        *int
 	WatchLimit             *int
 	WatchInterval          *int
+	RateLimitPerMinute     *int
 	GooglePlayProductID    *string
 	AppStoreProductID      *string
 	Active                 *bool
@@ -66,6 +69,7 @@ type UpdateInput struct {
 	AssistantsLimit             *int
 	WatchLimit                  *int
 	WatchInterval               *int
+	RateLimitPerMinute          *int
 	GooglePlayProductID         *string
 	GooglePlayProductIDProvided bool
 	AppStoreProductID           *string
@@ -115,6 +119,7 @@ func Create(db *gorm.DB, input CreateInput) (Package, error) {
 		AssistantsLimit:        input.AssistantsLimit,
 		WatchLimit:             input.WatchLimit,
 		WatchInterval:          input.WatchInterval,
+		RateLimitPerMinute:     input.RateLimitPerMinute,
 		GooglePlayProductID:    input.GooglePlayProductID,
 		AppStoreProductID:      input.AppStoreProductID,
 		Active:                 true,
@@ -207,6 +212,9 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Package, error) {
 	if input.WatchInterval != nil {
 		updates["watch_interval"] = *input.WatchInterval
 	}
+	if input.RateLimitPerMinute != nil {
+		updates["rate_limit_per_minute"] = *input.RateLimitPerMinute
+	}
 	if input.Active != nil {
 		updates["is_active"] = *input.Active
 	}
@@ -226,10 +234,24 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Package, error) {
 	return Get(db, id)
 }
 
+// foreignKeyViolation is the Postgres error code of a foreign key violation.
+const foreignKeyViolation = "23503"
+
+// packageInUseConstraints are the restricting foreign keys through which
+// subscriptions and purchases keep a package.
+var packageInUseConstraints = map[string]bool{
+	"fk_subscriptions_package":      true,
+	"iap_purchases_package_id_fkey": true,
+}
+
 // Delete removes a package.
 func Delete(db *gorm.DB, id uuid.UUID) error {
 	result := db.Delete(&Package{}, "id = ?", id)
 	if result.Error != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(result.Error, &pgErr) && pgErr.Code == foreignKeyViolation && packageInUseConstraints[pgErr.ConstraintName] {
+			return ErrPackageInUse
+		}
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
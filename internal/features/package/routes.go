@@ -5,6 +5,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes wires package endpoints into the API group.
@@ -21,4 +23,9 @@ func RegisterRoutes(api *gin.RouterGroup, db *gorm.DB, logger *slog.Logger, supe
 	packages.POST("", append(superadminOnly, handler.Create)...)
 	packages.PUT("/:packageId", append(superadminOnly, handler.Update)...)
 	packages.DELETE("/:packageId", append(superadminOnly, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Package{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Package{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Package{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Package{}})
 }
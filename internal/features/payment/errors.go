@@ -2,7 +2,9 @@ package payment
 
 import (
 	"errors"
+	"net/http"
 
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
@@ -10,6 +12,7 @@ var (
 	ErrPaymentNotFound      = errors.New("payment not found")
 	ErrInvalidStatus        = errors.New("invalid payment status")
 	ErrInvalidPaymentMethod = errors.New("invalid payment method")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
 
 // Re-export PaymentStatus constants from types for backward compatibility
@@ -45,3 +48,11 @@ func ValidStatuses() []types.PaymentStatus {
 func ValidPaymentMethods() []types.PaymentMethod {
 	return []types.PaymentMethod{MethodCash, MethodBankTransfer, MethodCreditCard, MethodPayPal, MethodOther}
 }
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrPaymentNotFound, Status: http.StatusNotFound, Code: "PAYMENT_NOT_FOUND", Message: "Payment not found."},
+	{Err: ErrInvalidStatus, Status: http.StatusBadRequest, Code: "INVALID_PAYMENT_STATUS", Message: "Invalid payment status."},
+	{Err: ErrInvalidPaymentMethod, Status: http.StatusBadRequest, Code: "INVALID_PAYMENT_METHOD", Message: "Invalid payment method."},
+	{Err: ErrSubscriptionNotFound, Status: http.StatusNotFound, Code: "SUBSCRIPTION_NOT_FOUND", Message: "Subscription not found."},
+}
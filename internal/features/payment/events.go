@@ -0,0 +1,43 @@
+package payment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// TopicCompleted is the outbox topic published when a payment becomes completed.
+const TopicCompleted = "payment.completed"
+
+// Event is the outbox payload for completed payments.
+type Event struct {
+	SubscriptionID uuid.UUID           `json:"subscriptionId"`
+	PaymentID      uuid.UUID           `json:"paymentId"`
+	Amount         types.Money         `json:"amount"`
+	Discount       types.Money         `json:"discount"`
+	Currency       types.Currency      `json:"currency"`
+	PaymentMethod  types.PaymentMethod `json:"paymentMethod"`
+	PeriodInDays   int                 `json:"periodInDays"`
+	Date           time.Time           `json:"date"`
+}
+
+// publishCompleted announces a payment that just reached completed.
+func publishCompleted(tx *gorm.DB, before types.PaymentStatus, payment Payment) error {
+	if before == types.PaymentStatusCompleted || payment.Status != types.PaymentStatusCompleted {
+		return nil
+	}
+	return outbox.Publish(tx, TopicCompleted, payment.ID, Event{
+		SubscriptionID: payment.SubscriptionID,
+		PaymentID:      payment.ID,
+		Amount:         payment.Amount,
+		Discount:       payment.Discount,
+		Currency:       payment.Currency,
+		PaymentMethod:  payment.PaymentMethod,
+		PeriodInDays:   payment.PeriodInDays,
+		Date:           payment.Date,
+	})
+}
@@ -1,8 +1,8 @@
 package payment
 
 import (
-	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"log/slog"
@@ -11,10 +11,12 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/features/coupon"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Handler processes payment HTTP requests.
@@ -32,6 +34,22 @@ func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
 func (h *Handler) List(c *gin.Context) {
 	params := pagination.Extract(c)
 
+	filters, ok := h.parseListFilters(c)
+	if !ok {
+		return
+	}
+
+	payments, total, err := List(h.db, filters, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list payments", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, payments, "", pagination.MetadataFrom(total, params))
+}
+
+// parseListFilters reads the query filters shared by List and Export.
+func (h *Handler) parseListFilters(c *gin.Context) (ListFilters, bool) {
 	filters := ListFilters{
 		Keyword:       c.Query("filterKeyword"),
 		PaymentMethod: c.Query("paymentMethod"),
@@ -44,7 +62,7 @@ func (h *Handler) List(c *gin.Context) {
 		parsed, err := uuid.Parse(subID)
 		if err != nil {
 			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
-			return
+			return filters, false
 		}
 		filters.SubscriptionID = &parsed
 	}
@@ -53,7 +71,7 @@ func (h *Handler) List(c *gin.Context) {
 		t, err := time.Parse(time.RFC3339, dateFrom)
 		if err != nil {
 			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateFrom format", err)
-			return
+			return filters, false
 		}
 		filters.DateFrom = &t
 	}
@@ -62,41 +80,38 @@ func (h *Handler) List(c *gin.Context) {
 		t, err := time.Parse(time.RFC3339, dateTo)
 		if err != nil {
 			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid dateTo format", err)
-			return
+			return filters, false
 		}
 		filters.DateTo = &t
 	}
 
-	payments, total, err := List(h.db, filters, params)
-	if err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list payments", err)
-		return
-	}
+	return filters, true
+}
 
-	response.Success(c, http.StatusOK, payments, "", pagination.MetadataFrom(total, params))
+type createRequest struct {
+	SubscriptionID       string   `json:"subscriptionId" binding:"required,id"`
+	Date                 *string  `json:"date" binding:"omitnil,rfc3339"`
+	Amount               float64  `json:"amount" binding:"required"`
+	PaymentMethod        *string  `json:"paymentMethod"`
+	ScreenshotURL        *string  `json:"screenshotUrl"`
+	Details              *string  `json:"details"`
+	TransactionReference *string  `json:"transactionReference"`
+	Status               *string  `json:"status"`
+	SubscriptionPoints   int      `json:"subscriptionPoints" binding:"required"`
+	RefundedAmount       *float64 `json:"refundedAmount"`
+	Discount             *float64 `json:"discount"`
+	PeriodInDays         int      `json:"periodInDays" binding:"required"`
+	IsAddition           *bool    `json:"isAddition"`
+	Currency             *string  `json:"currency"`
+	PackageID            *string  `json:"packageId"`
+	CouponCode           *string  `json:"couponCode"`
 }
 
 // Create inserts a new payment.
 func (h *Handler) Create(c *gin.Context) {
-	var req struct {
-		SubscriptionID       string   `json:"subscriptionId" binding:"required"`
-		Date                 *string  `json:"date"`
-		Amount               float64  `json:"amount" binding:"required"`
-		PaymentMethod        *string  `json:"paymentMethod"`
-		ScreenshotURL        *string  `json:"screenshotUrl"`
-		Details              *string  `json:"details"`
-		TransactionReference *string  `json:"transactionReference"`
-		Status               *string  `json:"status"`
-		SubscriptionPoints   int      `json:"subscriptionPoints" binding:"required"`
-		RefundedAmount       *float64 `json:"refundedAmount"`
-		Discount             *float64 `json:"discount"`
-		PeriodInDays         int      `json:"periodInDays" binding:"required"`
-		IsAddition           *bool    `json:"isAddition"`
-		Currency             *string  `json:"currency"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid payment payload", err)
+	var req createRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid payment payload") {
 		return
 	}
 
@@ -150,7 +165,17 @@ func (h *Handler) Create(c *gin.Context) {
 		currency = &cur
 	}
 
-	payment, err := Create(h.db, CreateInput{
+	var packageID *uuid.UUID
+	if req.PackageID != nil && *req.PackageID != "" {
+		id, err := uuid.Parse(*req.PackageID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid package id", err)
+			return
+		}
+		packageID = &id
+	}
+
+	input := CreateInput{
 		SubscriptionID:       subscriptionID,
 		Date:                 date,
 		Amount:               amount,
@@ -165,8 +190,24 @@ func (h *Handler) Create(c *gin.Context) {
 		PeriodInDays:         req.PeriodInDays,
 		IsAddition:           req.IsAddition,
 		Currency:             currency,
+	}
+
+	var payment Payment
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.CouponCode == nil || *req.CouponCode == "" {
+			created, err := Create(tx, input)
+			payment = created
+			return err
+		}
+
+		return h.createWithCoupon(tx, input, *req.CouponCode, packageID, &payment)
 	})
 
+	if coupon.IsRejection(err) {
+		response.ErrorWithLog(h.logger, c, http.StatusUnprocessableEntity, err.Error(), err)
+		return
+	}
+
 	if err != nil {
 		h.respondError(c, err, "failed to create payment")
 		return
@@ -175,6 +216,46 @@ func (h *Handler) Create(c *gin.Context) {
 	response.Created(c, payment, "")
 }
 
+// createWithCoupon discounts the payment by the coupon and records the
+// redemption against the subscription owner.
+func (h *Handler) createWithCoupon(tx *gorm.DB, input CreateInput, code string, packageID *uuid.UUID, payment *Payment) error {
+	var ownerIDs []uuid.UUID
+	if err := tx.Table("subscriptions").Where("id = ?", input.SubscriptionID).Pluck("user_id", &ownerIDs).Error; err != nil {
+		return err
+	}
+	if len(ownerIDs) == 0 {
+		return ErrSubscriptionNotFound
+	}
+
+	apply := coupon.ApplyInput{
+		Code:      code,
+		UserID:    ownerIDs[0],
+		PackageID: packageID,
+		Amount:    input.Amount,
+	}
+
+	quote, err := coupon.Apply(tx, apply, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	input.Amount = quote.FinalAmount
+	totalDiscount := quote.Discount
+	if input.Discount != nil {
+		totalDiscount = totalDiscount.Add(*input.Discount)
+	}
+	input.Discount = &totalDiscount
+
+	created, err := Create(tx, input)
+	if err != nil {
+		return err
+	}
+	*payment = created
+
+	_, err = coupon.Redeem(tx, coupon.RedeemInput{ApplyInput: apply, PaymentID: &created.ID}, time.Now().UTC())
+	return err
+}
+
 // GetByID fetches a single payment.
 func (h *Handler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("paymentId"))
@@ -192,6 +273,22 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, payment, "", nil)
 }
 
+type updateRequest struct {
+	Date                 *string                     `json:"date" binding:"omitnil,rfc3339"`
+	Amount               *float64                    `json:"amount" binding:"omitnil,gte=0"`
+	PaymentMethod        *string                     `json:"paymentMethod" binding:"omitnil,notblank"`
+	Details              validation.Optional[string] `json:"details" binding:"omitnil,notblank"`
+	TransactionReference validation.Optional[string] `json:"transactionReference" binding:"omitnil,notblank"`
+	Status               *string                     `json:"status" binding:"omitnil,notblank"`
+	SubscriptionPoints   *int                        `json:"subscriptionPoints" binding:"omitnil,gte=0"`
+	ScreenshotURL        validation.Optional[string] `json:"screenshotUrl" binding:"omitnil,notblank"`
+	RefundedAmount       *float64                    `json:"refundedAmount" binding:"omitnil,gte=0"`
+	Discount             *float64                    `json:"discount" binding:"omitnil,gte=0"`
+	PeriodInDays         *int                        `json:"periodInDays" binding:"omitnil,gte=0"`
+	IsAddition           *bool                       `json:"isAddition"`
+	Currency             *string                     `json:"currency" binding:"omitnil,notblank"`
+}
+
 // Update modifies an existing payment.
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("paymentId"))
@@ -200,148 +297,53 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid payment payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid payment payload") {
 		return
 	}
 
-	input := UpdateInput{}
+	input := UpdateInput{
+		DetailsProvided:       req.Details.Set,
+		Details:               request.Trimmed(req.Details.Ptr()),
+		TransactionProvided:   req.TransactionReference.Set,
+		TransactionReference:  request.Trimmed(req.TransactionReference.Ptr()),
+		SubscriptionPoints:    req.SubscriptionPoints,
+		ScreenshotURLProvided: req.ScreenshotURL.Set,
+		ScreenshotURL:         request.Trimmed(req.ScreenshotURL.Ptr()),
+		PeriodInDays:          req.PeriodInDays,
+		IsAddition:            req.IsAddition,
+	}
 
-	if value, ok := body["date"]; ok && value != nil {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "date must be a string", err)
-			return
-		}
-		t, err := time.Parse(time.RFC3339, str)
+	if req.Date != nil {
+		t, err := time.Parse(time.RFC3339, *req.Date)
 		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid date format", err)
+			request.RespondInvalid(c, validation.FieldError{Field: "date", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
 			return
 		}
 		input.Date = &t
 	}
-
-	if value, ok := body["amount"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "amount must be a number", err)
-			return
-		}
-		m := types.NewMoney(val)
+	if req.Amount != nil {
+		m := types.NewMoney(*req.Amount)
 		input.Amount = &m
 	}
-
-	if value, ok := body["paymentMethod"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "paymentMethod must be a string", err)
-			return
-		}
-		pm := types.PaymentMethod(str)
-		input.PaymentMethod = &pm
-	}
-
-	if value, ok := body["details"]; ok {
-		input.DetailsProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "details must be a string", err)
-				return
-			}
-			input.Details = &str
-		}
-	}
-
-	if value, ok := body["transactionReference"]; ok {
-		input.TransactionProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "transactionReference must be a string", err)
-				return
-			}
-			input.TransactionReference = &str
-		}
-	}
-
-	if value, ok := body["status"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "status must be a string", err)
-			return
-		}
-		s := types.PaymentStatus(str)
-		input.Status = &s
-	}
-
-	if value, ok := body["subscriptionPoints"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionPoints must be an integer", err)
-			return
-		}
-		input.SubscriptionPoints = &val
-	}
-
-	if value, ok := body["screenshotUrl"]; ok {
-		input.ScreenshotURLProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "screenshotUrl must be a string", err)
-				return
-			}
-			input.ScreenshotURL = &str
-		}
-	}
-
-	if value, ok := body["refundedAmount"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "refundedAmount must be a number", err)
-			return
-		}
-		m := types.NewMoney(val)
+	if req.RefundedAmount != nil {
+		m := types.NewMoney(*req.RefundedAmount)
 		input.RefundedAmount = &m
 	}
-
-	if value, ok := body["discount"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "discount must be a number", err)
-			return
-		}
-		m := types.NewMoney(val)
+	if req.Discount != nil {
+		m := types.NewMoney(*req.Discount)
 		input.Discount = &m
 	}
-
-	if value, ok := body["periodInDays"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "periodInDays must be an integer", err)
-			return
-		}
-		input.PeriodInDays = &val
+	if req.PaymentMethod != nil {
+		pm := types.PaymentMethod(strings.TrimSpace(*req.PaymentMethod))
+		input.PaymentMethod = &pm
 	}
-
-	if value, ok := body["isAddition"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isAddition must be a boolean", err)
-			return
-		}
-		input.IsAddition = &val
+	if req.Status != nil {
+		status := types.PaymentStatus(strings.TrimSpace(*req.Status))
+		input.Status = &status
 	}
-
-	if value, ok := body["currency"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "currency must be a string", err)
-			return
-		}
-		cur := types.Currency(str)
+	if req.Currency != nil {
+		cur := types.Currency(strings.TrimSpace(*req.Currency))
 		input.Currency = &cur
 	}
 
@@ -371,22 +373,7 @@ func (h *Handler) Delete(c *gin.Context) {
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrPaymentNotFound):
-		status = http.StatusNotFound
-		message = "Payment not found."
-	case errors.Is(err, ErrInvalidStatus):
-		status = http.StatusBadRequest
-		message = "Invalid payment status."
-	case errors.Is(err, ErrInvalidPaymentMethod):
-		status = http.StatusBadRequest
-		message = "Invalid payment method."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
 
 
@@ -86,6 +86,48 @@ type UpdateInput struct {
 
 // List retrieves paginated payments with filters.
 func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Payment, int64, error) {
+	query := filteredQuery(db, filters)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var payments []Payment
+	err := query.
+		Order(sortClause(filters)).
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Find(&payments).Error
+
+	return payments, total, err
+}
+
+// Each streams every payment matching the filters in list sort order, one row at a time
+// over a single cursor so exports never load the full result set.
+func Each(db *gorm.DB, filters ListFilters, fn func(Payment) error) error {
+	query := filteredQuery(db, filters).Order(sortClause(filters))
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item Payment
+		if err := query.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func filteredQuery(db *gorm.DB, filters ListFilters) *gorm.DB {
 	query := db.Model(&Payment{})
 
 	if filters.SubscriptionID != nil {
@@ -113,11 +155,10 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Payment
 		query = query.Where("LOWER(details) LIKE ? OR LOWER(transaction_reference) LIKE ?", keyword, keyword)
 	}
 
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+	return query
+}
 
+func sortClause(filters ListFilters) string {
 	// Sorting
 	sortColumn := "date"
 	sortOrder := "DESC"
@@ -138,14 +179,7 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]Payment
 		sortOrder = "ASC"
 	}
 
-	var payments []Payment
-	err := query.
-		Order(sortColumn + " " + sortOrder).
-		Offset(params.Skip).
-		Limit(params.Limit).
-		Find(&payments).Error
-
-	return payments, total, err
+	return sortColumn + " " + sortOrder
 }
 
 // Get retrieves a payment by ID.
@@ -241,7 +275,13 @@ func Create(db *gorm.DB, input CreateInput) (Payment, error) {
 		Currency:             currency,
 	}
 
-	if err := db.Create(&payment).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return publishCompleted(tx, "", payment)
+	})
+	if err != nil {
 		return Payment{}, err
 	}
 
@@ -254,6 +294,7 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Payment, error) {
 	if err != nil {
 		return payment, err
 	}
+	previousStatus := payment.Status
 
 	if input.Date != nil {
 		payment.Date = *input.Date
@@ -329,7 +370,13 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Payment, error) {
 		payment.Currency = *input.Currency
 	}
 
-	if err := db.Save(&payment).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&payment).Error; err != nil {
+			return err
+		}
+		return publishCompleted(tx, previousStatus, payment)
+	})
+	if err != nil {
 		return payment, err
 	}
 
@@ -2,15 +2,24 @@ package payment
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
-// RegisterRoutes attaches payment endpoints to the router.
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly []gin.HandlerFunc) {
+// RegisterRoutes attaches payment endpoints to the router. Creating a payment
+// accepts an Idempotency-Key through idempotent.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly []gin.HandlerFunc, idempotent gin.HandlerFunc) {
 	payments := router.Group("/payments")
 
 	payments.GET("", append(adminOnly, handler.List)...)
-	payments.POST("", append(adminOnly, handler.Create)...)
+	payments.POST("", append(adminOnly, idempotent, handler.Create)...)
+	payments.GET("/export", append(adminOnly, handler.Export)...)
 	payments.GET("/:paymentId", append(adminOnly, handler.GetByID)...)
 	payments.PUT("/:paymentId", append(adminOnly, handler.Update)...)
 	payments.DELETE("/:paymentId", append(adminOnly, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Payment{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Payment{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Payment{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Payment{}})
 }
@@ -0,0 +1,5 @@
+package playback
+
+import "errors"
+
+var ErrSessionNotFound = errors.New("playback session not found")
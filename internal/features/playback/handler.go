@@ -0,0 +1,52 @@
+package playback
+
+import (
+	"errors"
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler serves watermark lookups for subscription admins.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a playback handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// Lookup identifies the student behind the watermark code seen in a leaked video.
+// GET /subscriptions/:subscriptionId/playback-sessions/:code
+func (h *Handler) Lookup(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	trace, err := Lookup(h.db, subscriptionID, c.Param("code"))
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			response.ErrorWithLog(h.logger, c, http.StatusNotFound, "Playback session not found.", err)
+			return
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to look up playback session", err)
+		return
+	}
+
+	if requester, ok := middleware.GetUserFromContext(c); ok {
+		h.logger.Info("playback session traced", "code", trace.Session.Code, "userId", trace.Session.UserID, "requestedBy", requester.ID)
+	}
+
+	response.Success(c, http.StatusOK, trace, "", nil)
+}
@@ -0,0 +1,153 @@
+package playback
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	codeLength = 10
+	// codeAlphabet leaves out characters easily misread from a video frame.
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Session records one signed playback URL and the watermark code shown over it.
+type Session struct {
+	types.BaseModel
+
+	Code           string    `gorm:"type:varchar(12);not null;uniqueIndex:idx_playback_sessions_code" json:"code"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;column:user_id;index:idx_playback_sessions_user" json:"userId"`
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	LessonID       uuid.UUID `gorm:"type:uuid;not null;column:lesson_id" json:"lessonId"`
+	IPAddress      *string   `gorm:"type:varchar(64);column:ip_address" json:"ipAddress,omitempty"`
+	UserAgent      *string   `gorm:"type:varchar(500);column:user_agent" json:"userAgent,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Session) TableName() string { return "playback_sessions" }
+
+// Watermark is what the player overlays on the video, moving it around the frame.
+type Watermark struct {
+	SessionID uuid.UUID `json:"sessionId"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Text      string    `json:"text"`
+}
+
+// StartInput identifies who is about to play which lesson.
+type StartInput struct {
+	UserID         uuid.UUID
+	SubscriptionID uuid.UUID
+	LessonID       uuid.UUID
+	FullName       string
+	Email          string
+	IPAddress      string
+	UserAgent      string
+}
+
+// Trace is the result of looking up a watermark code.
+type Trace struct {
+	Session    Session   `json:"session"`
+	FullName   string    `json:"fullName"`
+	Email      string    `json:"email"`
+	LessonName string    `json:"lessonName"`
+	CourseID   uuid.UUID `json:"courseId"`
+	CourseName string    `json:"courseName"`
+}
+
+// Start records a playback session and returns its watermark.
+func Start(db *gorm.DB, input StartInput) (Watermark, error) {
+	code, err := generateCode()
+	if err != nil {
+		return Watermark{}, err
+	}
+
+	session := Session{
+		Code:           code,
+		UserID:         input.UserID,
+		SubscriptionID: input.SubscriptionID,
+		LessonID:       input.LessonID,
+		IPAddress:      truncated(input.IPAddress, 64),
+		UserAgent:      truncated(input.UserAgent, 500),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return Watermark{}, err
+	}
+
+	return Watermark{
+		SessionID: session.ID,
+		Code:      code,
+		Name:      input.FullName,
+		Email:     input.Email,
+		Text:      fmt.Sprintf("%s · %s · %s", input.FullName, input.Email, code),
+	}, nil
+}
+
+// Lookup finds the session a watermark code belongs to within the subscription.
+func Lookup(db *gorm.DB, subscriptionID uuid.UUID, code string) (Trace, error) {
+	var session Session
+	err := db.Where("code = ? AND subscription_id = ?", strings.ToUpper(strings.TrimSpace(code)), subscriptionID).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Trace{}, ErrSessionNotFound
+		}
+		return Trace{}, err
+	}
+
+	var owner struct {
+		FullName   string
+		Email      string
+		LessonName string
+		CourseID   uuid.UUID
+		CourseName string
+	}
+	err = db.Raw(`
+		SELECT u.full_name, u.email, l.name AS lesson_name, c.id AS course_id, c.name AS course_name
+		FROM lessons l
+		JOIN courses c ON c.id = l.course_id
+		JOIN users u ON u.id = ?
+		WHERE l.id = ?`, session.UserID, session.LessonID).
+		Scan(&owner).Error
+	if err != nil {
+		return Trace{}, err
+	}
+
+	return Trace{
+		Session:    session,
+		FullName:   owner.FullName,
+		Email:      owner.Email,
+		LessonName: owner.LessonName,
+		CourseID:   owner.CourseID,
+		CourseName: owner.CourseName,
+	}, nil
+}
+
+func generateCode() (string, error) {
+	buf := make([]byte, codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func truncated(value string, max int) *string {
+	if value == "" {
+		return nil
+	}
+	if len(value) > max {
+		value = value[:max]
+	}
+	return &value
+}
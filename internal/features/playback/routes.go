@@ -0,0 +1,14 @@
+package playback
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches playback session endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdmin []gin.HandlerFunc) {
+	router.GET("/subscriptions/:subscriptionId/playback-sessions/:code", append(acAdmin, handler.Lookup)...)
+
+	openapi.Describe(handler.Lookup, openapi.Spec{Response: Trace{}})
+}
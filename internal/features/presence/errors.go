@@ -0,0 +1,19 @@
+package presence
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrCourseNotFound = errors.New("course not found")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrGroupNotFound, Status: http.StatusNotFound, Code: "GROUP_NOT_FOUND", Message: "Group not found."},
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+}
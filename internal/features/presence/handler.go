@@ -0,0 +1,122 @@
+package presence
+
+import (
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Handler serves presence snapshots for clients without a socket, such as
+// dashboards polling in the background.
+type Handler struct {
+	db      *gorm.DB
+	logger  *slog.Logger
+	tracker *Tracker
+}
+
+// NewHandler constructs a presence handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger, tracker *Tracker) *Handler {
+	return &Handler{db: db, logger: logger, tracker: tracker}
+}
+
+// Subscription lists the subscription's online users, optionally only one
+// ?role= such as student.
+func (h *Handler) Subscription(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	entries := h.tracker.Online(subscriptionID)
+	if role := types.UserType(c.Query("role")); role != "" {
+		kept := make([]Entry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.UserType == role {
+				kept = append(kept, entry)
+			}
+		}
+		entries = kept
+	}
+
+	response.Success(c, http.StatusOK, entries, "", nil)
+}
+
+// Group lists the online members of an access group.
+func (h *Handler) Group(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	groupID, err := uuid.Parse(c.Param("groupId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid group id", err)
+		return
+	}
+
+	members, err := GroupMembers(h.db, subscriptionID, groupID)
+	if err != nil {
+		h.respondError(c, err, "failed to load group")
+		return
+	}
+
+	response.Success(c, http.StatusOK, filter(h.tracker.Online(subscriptionID), members, nil), "", nil)
+}
+
+// Course lists the online students of a course: those its access groups grant
+// it to and anyone currently studying one of its lessons.
+func (h *Handler) Course(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return
+	}
+
+	members, err := CourseMembers(h.db, subscriptionID, courseID)
+	if err != nil {
+		h.respondError(c, err, "failed to load course")
+		return
+	}
+
+	entries := filter(h.tracker.Online(subscriptionID), members, func(entry Entry) bool {
+		return entry.Studying != nil && entry.Studying.CourseID == courseID
+	})
+	students := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.UserType != types.UserTypeStudent {
+			continue
+		}
+		// Only a lesson of this course counts as studying it
+		if entry.Studying != nil && entry.Studying.CourseID != courseID {
+			entry.Studying = nil
+		}
+		students = append(students, entry)
+	}
+
+	response.Success(c, http.StatusOK, students, "", nil)
+}
+
+func (h *Handler) subscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+	return subscriptionID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,60 @@
+package presence
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GroupMembers returns the users of an access group of the subscription.
+func GroupMembers(db *gorm.DB, subscriptionID, groupID uuid.UUID) ([]string, error) {
+	var groups []struct{ ID uuid.UUID }
+	if err := db.Table("group_access").Select("id").
+		Where("id = ? AND subscription_id = ?", groupID, subscriptionID).
+		Limit(1).Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	members := make([]string, 0)
+	err := db.Raw(`SELECT UNNEST(users)::text FROM group_access WHERE id = ?`, groupID).Scan(&members).Error
+	return members, err
+}
+
+// CourseMembers returns the users whose access groups grant the course, directly
+// or through one of its lessons.
+func CourseMembers(db *gorm.DB, subscriptionID, courseID uuid.UUID) ([]string, error) {
+	var found int64
+	if err := db.Table("courses").Where("id = ? AND subscription_id = ?", courseID, subscriptionID).Count(&found).Error; err != nil {
+		return nil, err
+	}
+	if found == 0 {
+		return nil, ErrCourseNotFound
+	}
+
+	members := make([]string, 0)
+	err := db.Raw(`
+		SELECT DISTINCT UNNEST(users)::text FROM group_access
+		WHERE subscription_id = ?
+		AND (? = ANY(courses) OR lessons && ARRAY(SELECT id FROM lessons WHERE course_id = ?))`,
+		subscriptionID, courseID.String(), courseID,
+	).Scan(&members).Error
+	return members, err
+}
+
+// filter keeps the entries of the given users and those keep accepts.
+func filter(entries []Entry, userIDs []string, keep func(Entry) bool) []Entry {
+	allowed := make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		allowed[id] = struct{}{}
+	}
+
+	kept := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := allowed[entry.UserID.String()]; ok || (keep != nil && keep(entry)) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,18 @@
+package presence
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches presence endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acReports []gin.HandlerFunc) {
+	router.GET("/subscriptions/:subscriptionId/presence", append(acReports, handler.Subscription)...)
+	router.GET("/subscriptions/:subscriptionId/groups/:groupId/presence", append(acReports, handler.Group)...)
+	router.GET("/subscriptions/:subscriptionId/courses/:courseId/presence", append(acReports, handler.Course)...)
+
+	openapi.Describe(handler.Subscription, openapi.Spec{Response: []Entry{}})
+	openapi.Describe(handler.Group, openapi.Spec{Response: []Entry{}})
+	openapi.Describe(handler.Course, openapi.Spec{Response: []Entry{}})
+}
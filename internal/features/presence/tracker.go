@@ -0,0 +1,182 @@
+package presence
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Studying is the lesson a user has open.
+type Studying struct {
+	CourseID uuid.UUID `json:"courseId"`
+	LessonID uuid.UUID `json:"lessonId"`
+	Since    time.Time `json:"since"`
+}
+
+// Entry is one online user. A user with several sockets is online from the
+// first connection and studies what they opened last.
+type Entry struct {
+	UserID      uuid.UUID      `json:"userId"`
+	FullName    string         `json:"fullName"`
+	UserType    types.UserType `json:"userType"`
+	OnlineSince time.Time      `json:"onlineSince"`
+	Connections int            `json:"connections"`
+	Studying    *Studying      `json:"studying,omitempty"`
+}
+
+// Change is a presence update to broadcast: a user came online, went offline
+// or opened or left a lesson.
+type Change struct {
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	Online         bool      `json:"online"`
+	Entry
+}
+
+type connection struct {
+	subscriptionID uuid.UUID
+	userID         uuid.UUID
+	fullName       string
+	userType       types.UserType
+	connectedAt    time.Time
+	studying       *Studying
+}
+
+// Tracker is an in-memory registry of the socket connections of subscription
+// members. Like the stream and meeting caches it only knows the connections
+// of this instance.
+type Tracker struct {
+	mu          sync.RWMutex
+	connections map[string]*connection
+}
+
+// NewTracker constructs an empty presence tracker.
+func NewTracker() *Tracker {
+	return &Tracker{connections: make(map[string]*connection)}
+}
+
+// Connect records a new socket of the user.
+func (t *Tracker) Connect(connID string, subscriptionID, userID uuid.UUID, fullName string, userType types.UserType) Change {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.connections[connID] = &connection{
+		subscriptionID: subscriptionID,
+		userID:         userID,
+		fullName:       fullName,
+		userType:       userType,
+		connectedAt:    time.Now().UTC(),
+	}
+	return t.changeLocked(subscriptionID, userID)
+}
+
+// Disconnect forgets a socket. ok is false for sockets that were never tracked.
+func (t *Tracker) Disconnect(connID string) (Change, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conn, exists := t.connections[connID]
+	if !exists {
+		return Change{}, false
+	}
+	delete(t.connections, connID)
+
+	change := t.changeLocked(conn.subscriptionID, conn.userID)
+	if !change.Online {
+		change.UserID, change.FullName, change.UserType = conn.userID, conn.fullName, conn.userType
+	}
+	return change, true
+}
+
+// Study records that the socket opened a lesson.
+func (t *Tracker) Study(connID string, courseID, lessonID uuid.UUID) (Change, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conn, exists := t.connections[connID]
+	if !exists {
+		return Change{}, false
+	}
+	conn.studying = &Studying{CourseID: courseID, LessonID: lessonID, Since: time.Now().UTC()}
+	return t.changeLocked(conn.subscriptionID, conn.userID), true
+}
+
+// StopStudying records that the socket left the lesson.
+func (t *Tracker) StopStudying(connID string, lessonID uuid.UUID) (Change, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conn, exists := t.connections[connID]
+	if !exists || conn.studying == nil || conn.studying.LessonID != lessonID {
+		return Change{}, false
+	}
+	conn.studying = nil
+	return t.changeLocked(conn.subscriptionID, conn.userID), true
+}
+
+// Online returns the subscription's online users, longest online first.
+func (t *Tracker) Online(subscriptionID uuid.UUID) []Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	byUser := make(map[uuid.UUID]*Entry)
+	for _, conn := range t.connections {
+		if conn.subscriptionID == subscriptionID {
+			merge(byUser, conn)
+		}
+	}
+
+	entries := make([]Entry, 0, len(byUser))
+	for _, entry := range byUser {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].OnlineSince.Equal(entries[j].OnlineSince) {
+			return entries[i].OnlineSince.Before(entries[j].OnlineSince)
+		}
+		return entries[i].FullName < entries[j].FullName
+	})
+	return entries
+}
+
+// changeLocked summarises the user's current presence. t.mu must be held.
+func (t *Tracker) changeLocked(subscriptionID, userID uuid.UUID) Change {
+	byUser := make(map[uuid.UUID]*Entry, 1)
+	for _, conn := range t.connections {
+		if conn.userID == userID {
+			merge(byUser, conn)
+		}
+	}
+
+	change := Change{SubscriptionID: subscriptionID}
+	if entry, online := byUser[userID]; online {
+		change.Online = true
+		change.Entry = *entry
+	}
+	return change
+}
+
+func merge(byUser map[uuid.UUID]*Entry, conn *connection) {
+	entry, exists := byUser[conn.userID]
+	if !exists {
+		entry = &Entry{
+			UserID:      conn.userID,
+			FullName:    conn.fullName,
+			UserType:    conn.userType,
+			OnlineSince: conn.connectedAt,
+		}
+		byUser[conn.userID] = entry
+	}
+
+	entry.Connections++
+	if conn.connectedAt.Before(entry.OnlineSince) {
+		entry.OnlineSince = conn.connectedAt
+	}
+	if conn.studying != nil && (entry.Studying == nil || conn.studying.Since.After(entry.Studying.Since)) {
+		studying := *conn.studying
+		entry.Studying = &studying
+	}
+}
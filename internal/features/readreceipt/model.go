@@ -0,0 +1,86 @@
+package readreceipt
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Chats read receipts are kept for.
+const (
+	ChannelThread = "thread"
+	ChannelStream = "stream"
+)
+
+// EventRead is emitted to a chat's room when a member marks it read, with the
+// Reader as payload.
+const EventRead = "messageRead"
+
+// Receipt is how far a user has read a chat: the last message seen and when
+// that message was posted.
+type Receipt struct {
+	Channel     string    `gorm:"type:varchar(20);primaryKey" json:"channel"`
+	ChannelID   string    `gorm:"type:varchar(100);primaryKey;column:channel_id" json:"channelId"`
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id" json:"userId"`
+	MessageID   string    `gorm:"type:varchar(100);not null;column:message_id" json:"messageId"`
+	ReadThrough time.Time `gorm:"not null;column:read_through" json:"readThrough"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+// TableName overrides the default table name.
+func (Receipt) TableName() string { return "read_receipts" }
+
+// Reader is a receipt with the reader's name, for "seen by" lists.
+type Reader struct {
+	Receipt
+	UserName string `json:"userName"`
+}
+
+// Mark records that the user read up to messageID, posted at readThrough. An
+// older message than the one already recorded leaves the receipt unchanged.
+func Mark(db *gorm.DB, userID uuid.UUID, channel, channelID, messageID string, readThrough time.Time) (Receipt, error) {
+	receipt := Receipt{
+		Channel:     channel,
+		ChannelID:   channelID,
+		UserID:      userID,
+		MessageID:   messageID,
+		ReadThrough: readThrough.UTC(),
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel"}, {Name: "channel_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"message_id", "read_through", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "read_receipts.read_through <= excluded.read_through"}}},
+	}).Create(&receipt).Error
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	return Get(db, userID, channel, channelID)
+}
+
+// Get returns the user's receipt for the chat, zero when they read nothing yet.
+func Get(db *gorm.DB, userID uuid.UUID, channel, channelID string) (Receipt, error) {
+	var receipt Receipt
+	err := db.Where("channel = ? AND channel_id = ? AND user_id = ?", channel, channelID, userID).
+		Limit(1).Find(&receipt).Error
+	return receipt, err
+}
+
+// Readers returns everyone's receipt for the chat, furthest read first.
+func Readers(db *gorm.DB, channel, channelID string) ([]Reader, error) {
+	readers := make([]Reader, 0)
+	err := db.Table("read_receipts").
+		Select("read_receipts.*, users.full_name AS user_name").
+		Joins("JOIN users ON users.id = read_receipts.user_id").
+		Where("read_receipts.channel = ? AND read_receipts.channel_id = ?", channel, channelID).
+		Order("read_receipts.read_through DESC").
+		Scan(&readers).Error
+	return readers, err
+}
+
+// DeleteChannel removes the receipts of a deleted chat.
+func DeleteChannel(db *gorm.DB, channel, channelID string) error {
+	return db.Where("channel = ? AND channel_id = ?", channel, channelID).Delete(&Receipt{}).Error
+}
@@ -1,6 +1,11 @@
 package referral
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
 	ErrReferralNotFound     = errors.New("referral not found")
@@ -10,4 +15,28 @@ var (
 	ErrInvalidReferrerType  = errors.New("selected user is not a referrer")
 	ErrReferredUserNotFound = errors.New("referred user not found")
 	ErrUnauthorized         = errors.New("unauthorized to create referral for another referrer")
+	ErrCodeNotFound         = errors.New("referral code not found")
+	ErrCodeGeneration       = errors.New("failed to generate a unique referral code")
+	ErrSelfReferral         = errors.New("users cannot redeem their own referral code")
+	ErrAlreadyReferred      = errors.New("user has already been referred")
+	ErrRuleNotFound         = errors.New("reward rule not found")
+	ErrPackageNotFound      = errors.New("package not found")
+	ErrInvalidReward        = errors.New("reward percentage must be between 0 and 100 and amount cannot be negative")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrReferralNotFound, Status: http.StatusNotFound, Code: "REFERRAL_NOT_FOUND", Message: "Referral not found."},
+	{Err: ErrReferralExists, Status: http.StatusConflict, Code: "REFERRAL_EXISTS", Message: "Referral already exists for this user."},
+	{Err: ErrReferrerRequired, Status: http.StatusBadRequest, Code: "REFERRER_REQUIRED", Message: "Referrer is required."},
+	{Err: ErrReferrerNotFound, Status: http.StatusNotFound, Code: "REFERRER_NOT_FOUND", Message: "Referrer user not found."},
+	{Err: ErrInvalidReferrerType, Status: http.StatusBadRequest, Code: "INVALID_REFERRER_TYPE", Message: "Selected user is not a referrer."},
+	{Err: ErrReferredUserNotFound, Status: http.StatusNotFound, Code: "REFERRED_USER_NOT_FOUND", Message: "Referred user not found."},
+	{Err: ErrUnauthorized, Status: http.StatusForbidden, Message: "Unauthorized to create referral for another referrer."},
+	{Err: ErrCodeNotFound, Status: http.StatusNotFound, Code: "REFERRAL_CODE_NOT_FOUND", Message: "Referral code not found."},
+	{Err: ErrSelfReferral, Status: http.StatusBadRequest, Code: "SELF_REFERRAL", Message: "You cannot redeem your own referral code."},
+	{Err: ErrAlreadyReferred, Status: http.StatusConflict, Code: "ALREADY_REFERRED", Message: "You have already been referred."},
+	{Err: ErrRuleNotFound, Status: http.StatusNotFound, Code: "REFERRAL_RULE_NOT_FOUND", Message: "Reward rule not found."},
+	{Err: ErrPackageNotFound, Status: http.StatusNotFound, Code: "PACKAGE_NOT_FOUND", Message: "Package not found."},
+	{Err: ErrInvalidReward, Status: http.StatusBadRequest, Code: "INVALID_REFERRAL_REWARD", Message: "Reward percentage must be between 0 and 100 and amount cannot be negative."},
+}
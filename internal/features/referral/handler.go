@@ -2,6 +2,7 @@ package referral
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"time"
 
@@ -11,11 +12,13 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
 	"github.com/mo-amir99/lms-server-go/internal/features/user"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Handler processes referral HTTP requests.
@@ -42,7 +45,7 @@ func (h *Handler) List(c *gin.Context) {
 	// REFERRER users can only see their own referrals
 	if currentUser.UserType == types.UserTypeReferrer {
 		referrerID = &currentUser.ID
-	} else if user.CanManageUserType(currentUser.UserType, types.UserTypeReferrer) {
+	} else if authz.Outranks(currentUser.UserType, types.UserTypeReferrer) {
 		// Admins/Superadmins can filter by referrer
 		if referrerParam := c.Query("referrer"); referrerParam != "" {
 			id, err := uuid.Parse(referrerParam)
@@ -80,6 +83,12 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, referral, "", nil)
 }
 
+type createRequest struct {
+	ReferrerID     *string `json:"referrer"`
+	ReferredUserID *string `json:"referredUser"`
+	ExpiresAt      *string `json:"expiresAt"`
+}
+
 // Create inserts a new referral.
 func (h *Handler) Create(c *gin.Context) {
 	currentUser, ok := middleware.GetUserFromContext(c)
@@ -88,14 +97,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		ReferrerID     *string `json:"referrer"`
-		ReferredUserID *string `json:"referredUser"`
-		ExpiresAt      *string `json:"expiresAt"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid referral payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid referral payload") {
 		return
 	}
 
@@ -180,6 +184,11 @@ func (h *Handler) Create(c *gin.Context) {
 	response.Created(c, referral, "")
 }
 
+type updateRequest struct {
+	ReferredUser validation.Optional[string] `json:"referredUser" binding:"omitnil,id"`
+	ExpiresAt    *string                     `json:"expiresAt" binding:"omitnil,rfc3339"`
+}
+
 // Update modifies an existing referral.
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("referralId"))
@@ -188,47 +197,28 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid referral payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid referral payload") {
 		return
 	}
 
-	input := UpdateInput{}
+	input := UpdateInput{ReferredUserIDProvided: req.ReferredUser.Set}
 
-	if value, ok := body["referredUser"]; ok {
-		input.ReferredUserIDProvided = true
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "referredUser must be a string", err)
-				return
-			}
-			if str != "" {
-				id, err := uuid.Parse(str)
-				if err != nil {
-					response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid referred user id", err)
-					return
-				}
-				input.ReferredUserID = &id
-			}
+	if value := req.ReferredUser.Ptr(); value != nil {
+		referredUserID, err := uuid.Parse(*value)
+		if err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "referredUser", Rule: "id", Message: "must be a valid id"})
+			return
 		}
+		input.ReferredUserID = &referredUserID
 	}
-
-	if value, ok := body["expiresAt"]; ok {
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "expiresAt must be a string", err)
-				return
-			}
-			parsed, err := time.Parse(time.RFC3339, str)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid expiresAt format", err)
-				return
-			}
-			input.ExpiresAt = &parsed
+	if req.ExpiresAt != nil {
+		parsed, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			request.RespondInvalid(c, validation.FieldError{Field: "expiresAt", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
+			return
 		}
+		input.ExpiresAt = &parsed
 	}
 
 	referral, err := Update(h.db, id, input)
@@ -256,33 +246,217 @@ func (h *Handler) Delete(c *gin.Context) {
 	response.Success(c, http.StatusOK, true, "", nil)
 }
 
+// GetCode returns the current user's referral code, generating it on first use.
+func (h *Handler) GetCode(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	code, err := GetOrCreateCode(h.db, currentUser.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to load referral code")
+		return
+	}
+
+	response.Success(c, http.StatusOK, code, "", nil)
+}
+
+type redeemRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Redeem links the current user to the referrer owning the submitted code.
+func (h *Handler) Redeem(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req redeemRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid referral code payload") {
+		return
+	}
+
+	referral, err := Redeem(h.db, currentUser.ID, req.Code)
+	if err != nil {
+		h.respondError(c, err, "failed to redeem referral code")
+		return
+	}
+
+	response.Created(c, referral, "")
+}
+
+// ListCredits returns referral credits. Referrers only see their own.
+func (h *Handler) ListCredits(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	filters, ok := h.creditFilters(c)
+	if !ok {
+		return
+	}
+
+	if currentUser.UserType == types.UserTypeReferrer {
+		filters.ReferrerID = &currentUser.ID
+	}
+
+	credits, err := ListCredits(h.db, filters)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load referral credits", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, credits, "", nil)
+}
+
+// ListRules returns the reward rules configured per package.
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := ListRules(h.db)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load reward rules", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, rules, "", nil)
+}
+
+type upsertRuleRequest struct {
+	Percentage  float64        `json:"percentage"`
+	FixedAmount float64        `json:"fixedAmount"`
+	Currency    types.Currency `json:"currency"`
+	Active      *bool          `json:"isActive"`
+}
+
+// UpsertRule creates or replaces the reward rule for a package.
+func (h *Handler) UpsertRule(c *gin.Context) {
+	packageID, err := uuid.Parse(c.Param("packageId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid package id", err)
+		return
+	}
+
+	var req upsertRuleRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid reward rule payload") {
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	rule, err := UpsertRule(h.db, packageID, RuleInput{
+		Percentage:  req.Percentage,
+		FixedAmount: types.NewMoney(req.FixedAmount),
+		Currency:    req.Currency,
+		Active:      active,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to save reward rule")
+		return
+	}
+
+	response.Success(c, http.StatusOK, rule, "", nil)
+}
+
+// DeleteRule removes the reward rule for a package.
+func (h *Handler) DeleteRule(c *gin.Context) {
+	packageID, err := uuid.Parse(c.Param("packageId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid package id", err)
+		return
+	}
+
+	if err := DeleteRule(h.db, packageID); err != nil {
+		h.respondError(c, err, "failed to delete reward rule")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "", nil)
+}
+
+// PayoutReport aggregates pending and paid credits per referrer.
+func (h *Handler) PayoutReport(c *gin.Context) {
+	filters, ok := h.creditFilters(c)
+	if !ok {
+		return
+	}
+
+	rows, err := PayoutReport(h.db, filters)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to build payout report", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, rows, "", nil)
+}
+
+type markPaidRequest struct {
+	Reference *string `json:"reference"`
+}
+
+// MarkPaid settles all pending credits of a referrer.
+func (h *Handler) MarkPaid(c *gin.Context) {
+	referrerID, err := uuid.Parse(c.Param("referrerId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid referrer id", err)
+		return
+	}
+
+	var req markPaidRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid payout payload", err)
+		return
+	}
+
+	updated, err := MarkPaid(h.db, referrerID, req.Reference, time.Now().UTC())
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to mark credits as paid", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"updated": updated}, "", nil)
+}
+
+func (h *Handler) creditFilters(c *gin.Context) (CreditFilters, bool) {
+	filters := CreditFilters{Status: c.Query("status")}
+
+	if filters.Status != "" && filters.Status != CreditStatusPending && filters.Status != CreditStatusPaid {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "status must be pending or paid", nil)
+		return filters, false
+	}
+
+	if referrerParam := c.Query("referrer"); referrerParam != "" {
+		id, err := uuid.Parse(referrerParam)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid referrer id", err)
+			return filters, false
+		}
+		filters.ReferrerID = &id
+	}
+
+	for key, target := range map[string]**time.Time{"from": &filters.From, "to": &filters.To} {
+		value := c.Query(key)
+		parsed, err := request.ParseRFC3339Ptr(&value)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, key+" must be RFC3339", err)
+			return filters, false
+		}
+		*target = parsed
+	}
+
+	return filters, true
+}
+
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrReferralNotFound):
-		status = http.StatusNotFound
-		message = "Referral not found."
-	case errors.Is(err, ErrReferralExists):
-		status = http.StatusConflict
-		message = "Referral already exists for this user."
-	case errors.Is(err, ErrReferrerRequired):
-		status = http.StatusBadRequest
-		message = "Referrer is required."
-	case errors.Is(err, ErrReferrerNotFound):
-		status = http.StatusNotFound
-		message = "Referrer user not found."
-	case errors.Is(err, ErrInvalidReferrerType):
-		status = http.StatusBadRequest
-		message = "Selected user is not a referrer."
-	case errors.Is(err, ErrReferredUserNotFound):
-		status = http.StatusNotFound
-		message = "Referred user not found."
-	case errors.Is(err, ErrUnauthorized):
-		status = http.StatusForbidden
-		message = "Unauthorized to create referral for another referrer."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
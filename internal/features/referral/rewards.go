@@ -0,0 +1,351 @@
+package referral
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	packageModel "github.com/mo-amir99/lms-server-go/internal/features/package"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Credit statuses.
+const (
+	CreditStatusPending = "pending"
+	CreditStatusPaid    = "paid"
+)
+
+const (
+	codeLength   = 8
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Code is the shareable referral code owned by a user.
+type Code struct {
+	types.BaseModel
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex;column:user_id" json:"userId"`
+	Code   string    `gorm:"type:varchar(16);not null;uniqueIndex" json:"code"`
+}
+
+// TableName overrides the default table name.
+func (Code) TableName() string { return "referral_codes" }
+
+// RewardRule defines the reward a referrer earns when a referred user
+// activates a subscription on a package.
+type RewardRule struct {
+	types.BaseModel
+
+	PackageID   uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex;column:package_id" json:"packageId"`
+	Percentage  float64        `gorm:"type:numeric(5,2);not null;default:0" json:"percentage"`
+	FixedAmount types.Money    `gorm:"type:numeric(10,2);not null;default:0;column:fixed_amount" json:"fixedAmount"`
+	Currency    types.Currency `gorm:"type:varchar(3);not null;default:'EGP'" json:"currency"`
+	Active      bool           `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
+}
+
+// TableName overrides the default table name.
+func (RewardRule) TableName() string { return "referral_reward_rules" }
+
+// Credit is a reward earned by a referrer for one activated subscription.
+type Credit struct {
+	types.BaseModel
+
+	ReferralID      uuid.UUID      `gorm:"type:uuid;not null;column:referral_id;index" json:"referralId"`
+	ReferrerID      uuid.UUID      `gorm:"type:uuid;not null;column:referrer_id;index:idx_referral_credits_referrer_status,priority:1" json:"referrerId"`
+	ReferredUserID  uuid.UUID      `gorm:"type:uuid;not null;column:referred_user_id" json:"referredUserId"`
+	SubscriptionID  uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex;column:subscription_id" json:"subscriptionId"`
+	PackageID       uuid.UUID      `gorm:"type:uuid;not null;column:package_id" json:"packageId"`
+	Amount          types.Money    `gorm:"type:numeric(10,2);not null" json:"amount"`
+	Currency        types.Currency `gorm:"type:varchar(3);not null;default:'EGP'" json:"currency"`
+	Status          string         `gorm:"type:varchar(20);not null;default:'pending';index:idx_referral_credits_referrer_status,priority:2" json:"status"`
+	PaidAt          *time.Time     `gorm:"type:timestamp;column:paid_at" json:"paidAt,omitempty"`
+	PayoutReference *string        `gorm:"type:varchar(255);column:payout_reference" json:"payoutReference,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Credit) TableName() string { return "referral_credits" }
+
+// RuleInput carries the fields of a reward rule.
+type RuleInput struct {
+	Percentage  float64
+	FixedAmount types.Money
+	Currency    types.Currency
+	Active      bool
+}
+
+// CreditFilters defines credit query filters.
+type CreditFilters struct {
+	ReferrerID *uuid.UUID
+	Status     string
+	From       *time.Time
+	To         *time.Time
+}
+
+// PayoutRow aggregates a referrer's credits for the payout report.
+type PayoutRow struct {
+	ReferrerID    uuid.UUID      `json:"referrerId"`
+	FullName      string         `json:"fullName"`
+	Email         string         `json:"email"`
+	Currency      types.Currency `json:"currency"`
+	Credits       int64          `json:"credits"`
+	PendingAmount types.Money    `json:"pendingAmount"`
+	PaidAmount    types.Money    `json:"paidAmount"`
+}
+
+// GetOrCreateCode returns the user's referral code, generating one on first use.
+func GetOrCreateCode(db *gorm.DB, userID uuid.UUID) (Code, error) {
+	var code Code
+	err := db.Where("user_id = ?", userID).First(&code).Error
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return code, err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		value, err := generateCode()
+		if err != nil {
+			return Code{}, err
+		}
+
+		code = Code{UserID: userID, Code: value}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&code)
+		if result.Error != nil {
+			return Code{}, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return code, nil
+		}
+
+		// Either the code collided or a concurrent request created the user's code.
+		if err := db.Where("user_id = ?", userID).First(&code).Error; err == nil {
+			return code, nil
+		}
+	}
+
+	return Code{}, ErrCodeGeneration
+}
+
+// Redeem links the user to the owner of the referral code.
+func Redeem(db *gorm.DB, userID uuid.UUID, value string) (*Referral, error) {
+	var code Code
+	if err := db.Where("code = ?", strings.ToUpper(strings.TrimSpace(value))).First(&code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCodeNotFound
+		}
+		return nil, err
+	}
+
+	if code.UserID == userID {
+		return nil, ErrSelfReferral
+	}
+
+	var count int64
+	if err := db.Model(&Referral{}).Where("referred_user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyReferred
+	}
+
+	return Create(db, CreateInput{ReferrerID: code.UserID, ReferredUserID: &userID})
+}
+
+// ListRules returns all reward rules.
+func ListRules(db *gorm.DB) ([]RewardRule, error) {
+	var rules []RewardRule
+	err := db.Order("created_at ASC").Find(&rules).Error
+	return rules, err
+}
+
+// UpsertRule creates or replaces the reward rule for a package.
+func UpsertRule(db *gorm.DB, packageID uuid.UUID, input RuleInput) (RewardRule, error) {
+	if input.Percentage < 0 || input.Percentage > 100 || input.FixedAmount.LessThan(types.NewMoney(0)) {
+		return RewardRule{}, ErrInvalidReward
+	}
+
+	var count int64
+	if err := db.Model(&packageModel.Package{}).Where("id = ?", packageID).Count(&count).Error; err != nil {
+		return RewardRule{}, err
+	}
+	if count == 0 {
+		return RewardRule{}, ErrPackageNotFound
+	}
+
+	currency := input.Currency
+	if currency == "" {
+		currency = types.CurrencyEGP
+	}
+
+	rule := RewardRule{
+		PackageID:   packageID,
+		Percentage:  input.Percentage,
+		FixedAmount: input.FixedAmount,
+		Currency:    currency,
+		Active:      input.Active,
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "package_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"percentage", "fixed_amount", "currency", "is_active", "updated_at"}),
+	}).Create(&rule).Error
+	if err != nil {
+		return RewardRule{}, err
+	}
+
+	// GORM omits false for columns with a default, so persist the flag explicitly.
+	if err := db.Model(&RewardRule{}).Where("package_id = ?", packageID).Update("is_active", input.Active).Error; err != nil {
+		return RewardRule{}, err
+	}
+
+	if err := db.Where("package_id = ?", packageID).First(&rule).Error; err != nil {
+		return RewardRule{}, err
+	}
+	return rule, nil
+}
+
+// DeleteRule removes the reward rule for a package.
+func DeleteRule(db *gorm.DB, packageID uuid.UUID) error {
+	result := db.Delete(&RewardRule{}, "package_id = ?", packageID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// ApplyCredit records the referrer's reward for an activated subscription.
+// It returns nil when the subscription's owner was not referred, the referral
+// has expired, or no active rule exists for the package. Applying the credit
+// twice for the same subscription is a no-op.
+func ApplyCredit(db *gorm.DB, sub subscription.Subscription, now time.Time) (*Credit, error) {
+	if !sub.Active || sub.PackageID == nil {
+		return nil, nil
+	}
+
+	var referral Referral
+	err := db.Where("referred_user_id = ? AND expires_at > ?", sub.UserID, now).
+		Order("created_at ASC").
+		First(&referral).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rule RewardRule
+	err = db.Where("package_id = ? AND is_active = ?", *sub.PackageID, true).First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg packageModel.Package
+	if err := db.First(&pkg, "id = ?", *sub.PackageID).Error; err != nil {
+		return nil, err
+	}
+
+	price := pkg.Price.Mul(1 - pkg.DiscountPercentage/100)
+	amount := rule.FixedAmount.Add(price.Mul(rule.Percentage / 100))
+	if amount.IsZero() {
+		return nil, nil
+	}
+
+	credit := Credit{
+		ReferralID:     referral.ID,
+		ReferrerID:     referral.ReferrerID,
+		ReferredUserID: sub.UserID,
+		SubscriptionID: sub.ID,
+		PackageID:      *sub.PackageID,
+		Amount:         amount,
+		Currency:       rule.Currency,
+		Status:         CreditStatusPending,
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&credit)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &credit, nil
+}
+
+// ListCredits returns credits matching the filters, newest first.
+func ListCredits(db *gorm.DB, filters CreditFilters) ([]Credit, error) {
+	var credits []Credit
+	err := applyCreditFilters(db.Model(&Credit{}), filters).
+		Order("created_at DESC").
+		Find(&credits).Error
+	return credits, err
+}
+
+// PayoutReport aggregates credits per referrer and currency.
+func PayoutReport(db *gorm.DB, filters CreditFilters) ([]PayoutRow, error) {
+	rows := make([]PayoutRow, 0)
+	credits := applyCreditFilters(db.Model(&Credit{}), filters)
+	err := db.Table("(?) AS rc", credits).
+		Select(`rc.referrer_id, u.full_name, u.email, rc.currency,
+			COUNT(*) AS credits,
+			COALESCE(SUM(rc.amount) FILTER (WHERE rc.status = ?), 0) AS pending_amount,
+			COALESCE(SUM(rc.amount) FILTER (WHERE rc.status = ?), 0) AS paid_amount`,
+			CreditStatusPending, CreditStatusPaid).
+		Joins("JOIN users u ON u.id = rc.referrer_id").
+		Group("rc.referrer_id, u.full_name, u.email, rc.currency").
+		Order("pending_amount DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// MarkPaid settles all pending credits of a referrer and returns how many
+// were updated.
+func MarkPaid(db *gorm.DB, referrerID uuid.UUID, reference *string, now time.Time) (int64, error) {
+	result := db.Model(&Credit{}).
+		Where("referrer_id = ? AND status = ?", referrerID, CreditStatusPending).
+		Updates(map[string]interface{}{
+			"status":           CreditStatusPaid,
+			"paid_at":          now,
+			"payout_reference": reference,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func applyCreditFilters(query *gorm.DB, filters CreditFilters) *gorm.DB {
+	if filters.ReferrerID != nil {
+		query = query.Where("referrer_id = ?", *filters.ReferrerID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at <= ?", *filters.To)
+	}
+	return query
+}
+
+func generateCode() (string, error) {
+	buf := make([]byte, codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(buf), nil
+}
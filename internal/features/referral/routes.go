@@ -2,15 +2,41 @@ package referral
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes sets up referral endpoints under /referrals.
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler, referralAccess, adminOnly []gin.HandlerFunc) {
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, referralAccess, adminOnly, allUsers []gin.HandlerFunc) {
 	referrals := router.Group("/referrals")
 
 	referrals.GET("", append(referralAccess, handler.List)...)
 	referrals.POST("", append(referralAccess, handler.Create)...)
+
+	referrals.GET("/code", append(referralAccess, handler.GetCode)...)
+	referrals.POST("/redeem", append(allUsers, handler.Redeem)...)
+	referrals.GET("/credits", append(referralAccess, handler.ListCredits)...)
+
+	referrals.GET("/rules", append(adminOnly, handler.ListRules)...)
+	referrals.PUT("/rules/:packageId", append(adminOnly, handler.UpsertRule)...)
+	referrals.DELETE("/rules/:packageId", append(adminOnly, handler.DeleteRule)...)
+
+	referrals.GET("/payouts", append(adminOnly, handler.PayoutReport)...)
+	referrals.POST("/payouts/:referrerId/mark-paid", append(adminOnly, handler.MarkPaid)...)
+
 	referrals.GET("/:referralId", append(referralAccess, handler.GetByID)...)
 	referrals.PUT("/:referralId", append(referralAccess, handler.Update)...)
 	referrals.DELETE("/:referralId", append(adminOnly, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Referral{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Referral{}})
+	openapi.Describe(handler.GetCode, openapi.Spec{Response: Code{}})
+	openapi.Describe(handler.Redeem, openapi.Spec{Request: redeemRequest{}, Response: Referral{}})
+	openapi.Describe(handler.ListCredits, openapi.Spec{Response: []Credit{}})
+	openapi.Describe(handler.ListRules, openapi.Spec{Response: []RewardRule{}})
+	openapi.Describe(handler.UpsertRule, openapi.Spec{Request: upsertRuleRequest{}, Response: RewardRule{}})
+	openapi.Describe(handler.PayoutReport, openapi.Spec{Response: []PayoutRow{}})
+	openapi.Describe(handler.MarkPaid, openapi.Spec{Request: markPaidRequest{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Referral{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Referral{}})
 }
@@ -0,0 +1,39 @@
+package referral
+
+import (
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+)
+
+// Service credits referrers when referred users activate subscriptions.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewService constructs a referral reward service.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	return &Service{db: db, logger: logger}
+}
+
+// SubscriptionActivated applies the referral credit for the subscription, if any.
+// It is registered as a subscription activation hook.
+func (s *Service) SubscriptionActivated(sub subscription.Subscription) {
+	credit, err := ApplyCredit(s.db, sub, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("failed to apply referral credit", "subscriptionId", sub.ID, "error", err)
+		return
+	}
+	if credit != nil {
+		s.logger.Info("referral credit applied",
+			"subscriptionId", sub.ID,
+			"referrerId", credit.ReferrerID,
+			"amount", credit.Amount.String(),
+			"currency", credit.Currency,
+		)
+	}
+}
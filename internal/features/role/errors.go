@@ -0,0 +1,29 @@
+package role
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleNameInvalid   = errors.New("role name must be between 1 and 50 characters")
+	ErrRoleNameTaken     = errors.New("role name already exists")
+	ErrInvalidPermission = errors.New("unknown permission")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserNotAssignable = errors.New("custom roles can only be assigned to students and assistants")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrRoleNotFound, Status: http.StatusNotFound, Code: "ROLE_NOT_FOUND", Message: "Role not found."},
+	{Err: ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found."},
+	{Err: user.ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found."},
+	{Err: ErrRoleNameInvalid, Status: http.StatusBadRequest, Code: "ROLE_NAME_INVALID", Message: "Role name must be between 1 and 50 characters."},
+	{Err: ErrRoleNameTaken, Status: http.StatusConflict, Code: "ROLE_NAME_TAKEN", Message: "A role with this name already exists."},
+	{Err: ErrInvalidPermission, Status: http.StatusBadRequest, Code: "INVALID_PERMISSION", Message: "Unknown permission."},
+	{Err: ErrUserNotAssignable, Status: http.StatusBadRequest, Code: "USER_NOT_ASSIGNABLE", Message: "Custom roles can only be assigned to students and assistants."},
+}
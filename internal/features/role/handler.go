@@ -0,0 +1,210 @@
+package role
+
+import (
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes custom role HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a role handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+type roleRequest struct {
+	Name        string             `json:"name" binding:"required"`
+	Description *string            `json:"description"`
+	Permissions []authz.Permission `json:"permissions"`
+}
+
+// Permissions returns the catalog of permissions a custom role may grant.
+func (h *Handler) Permissions(c *gin.Context) {
+	response.Success(c, http.StatusOK, authz.Permissions, "", nil)
+}
+
+// List returns the custom roles of a subscription.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	roles, err := List(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list roles", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, roles, "", nil)
+}
+
+// GetByID returns a single custom role.
+func (h *Handler) GetByID(c *gin.Context) {
+	subscriptionID, id, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	role, err := Get(h.db, subscriptionID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load role")
+		return
+	}
+
+	response.Success(c, http.StatusOK, role, "", nil)
+}
+
+// Create defines a new custom role.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	var req roleRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid role payload") {
+		return
+	}
+
+	role, err := Create(h.db, subscriptionID, Input{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to create role")
+		return
+	}
+
+	response.Created(c, role, "")
+}
+
+// Update replaces a custom role's definition.
+func (h *Handler) Update(c *gin.Context) {
+	subscriptionID, id, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req roleRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid role payload") {
+		return
+	}
+
+	role, err := Update(h.db, subscriptionID, id, Input{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to update role")
+		return
+	}
+
+	response.Success(c, http.StatusOK, role, "Role updated", nil)
+}
+
+// Delete removes a custom role.
+func (h *Handler) Delete(c *gin.Context) {
+	subscriptionID, id, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := Delete(h.db, subscriptionID, id); err != nil {
+		h.respondError(c, err, "failed to delete role")
+		return
+	}
+
+	response.Success(c, http.StatusOK, nil, "Role deleted", nil)
+}
+
+type assignToUserRequest struct {
+	RoleID *string `json:"roleId"`
+}
+
+// AssignToUser sets or clears a subscription member's custom role.
+func (h *Handler) AssignToUser(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	var req assignToUserRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid role assignment payload") {
+		return
+	}
+
+	var roleID *uuid.UUID
+	if req.RoleID != nil && *req.RoleID != "" {
+		parsed, err := uuid.Parse(*req.RoleID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid role id", err)
+			return
+		}
+		roleID = &parsed
+	}
+
+	if requester.ID == userID {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You cannot change your own role.", nil)
+		return
+	}
+
+	updated, err := Assign(h.db, subscriptionID, userID, roleID)
+	if err != nil {
+		h.respondError(c, err, "failed to assign role")
+		return
+	}
+
+	response.Success(c, http.StatusOK, updated, "Role assigned", nil)
+}
+
+func (h *Handler) parseIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("roleId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid role id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return subscriptionID, id, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,242 @@
+package role
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Role is a custom, subscription-scoped role granting a set of permissions on top of
+// the user's built-in role.
+type Role struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;column:subscription_id;uniqueIndex:idx_roles_subscription_name,priority:1" json:"subscriptionId"`
+	Name           string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_roles_subscription_name,priority:2" json:"name"`
+	Description    *string   `gorm:"type:varchar(255)" json:"description,omitempty"`
+
+	Permissions []RolePermission `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE" json:"-"`
+
+	PermissionNames []authz.Permission `gorm:"-" json:"permissions"`
+}
+
+// TableName overrides the default table name.
+func (Role) TableName() string { return "roles" }
+
+// RolePermission grants a single permission to a role.
+type RolePermission struct {
+	RoleID     uuid.UUID        `gorm:"type:uuid;primaryKey;column:role_id"`
+	Permission authz.Permission `gorm:"type:varchar(50);primaryKey"`
+}
+
+// TableName overrides the default table name.
+func (RolePermission) TableName() string { return "role_permissions" }
+
+// Input carries the editable fields of a role.
+type Input struct {
+	Name        string
+	Description *string
+	Permissions []authz.Permission
+}
+
+// List returns a subscription's custom roles ordered by name.
+func List(db *gorm.DB, subscriptionID uuid.UUID) ([]Role, error) {
+	roles := make([]Role, 0)
+	if err := db.Preload("Permissions").
+		Where("subscription_id = ?", subscriptionID).
+		Order("name ASC").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		roles[i].fillPermissionNames()
+	}
+	return roles, nil
+}
+
+// Get retrieves a role of the given subscription.
+func Get(db *gorm.DB, subscriptionID, id uuid.UUID) (Role, error) {
+	var role Role
+	if err := db.Preload("Permissions").
+		First(&role, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return role, ErrRoleNotFound
+		}
+		return role, err
+	}
+	role.fillPermissionNames()
+	return role, nil
+}
+
+// Create inserts a role together with its permissions.
+func Create(db *gorm.DB, subscriptionID uuid.UUID, input Input) (Role, error) {
+	name, permissions, err := normalize(input)
+	if err != nil {
+		return Role{}, err
+	}
+
+	role := Role{
+		SubscriptionID: subscriptionID,
+		Name:           name,
+		Description:    trimStringPtr(input.Description),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Create(&role).Error; err != nil {
+			return mapUniqueErr(err)
+		}
+		return replacePermissions(tx, role.ID, permissions)
+	})
+	if err != nil {
+		return Role{}, err
+	}
+
+	return Get(db, subscriptionID, role.ID)
+}
+
+// Update replaces a role's name, description and permissions.
+func Update(db *gorm.DB, subscriptionID, id uuid.UUID, input Input) (Role, error) {
+	name, permissions, err := normalize(input)
+	if err != nil {
+		return Role{}, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Role{}).
+			Where("id = ? AND subscription_id = ?", id, subscriptionID).
+			Updates(map[string]interface{}{
+				"name":        name,
+				"description": trimStringPtr(input.Description),
+			})
+		if result.Error != nil {
+			return mapUniqueErr(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+		return replacePermissions(tx, id, permissions)
+	})
+	if err != nil {
+		return Role{}, err
+	}
+
+	return Get(db, subscriptionID, id)
+}
+
+// Delete removes a role and unassigns it from every user holding it.
+func Delete(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.User{}).
+			Where("role_id = ?", id).
+			Update("role_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND subscription_id = ?", id, subscriptionID).Delete(&Role{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+		return nil
+	})
+}
+
+// Assign sets or clears (roleID == nil) the custom role of a subscription member.
+func Assign(db *gorm.DB, subscriptionID, userID uuid.UUID, roleID *uuid.UUID) (user.User, error) {
+	target, err := user.Get(db, userID)
+	if err != nil {
+		if err == user.ErrUserNotFound {
+			return target, ErrUserNotFound
+		}
+		return target, err
+	}
+	if target.SubscriptionID == nil || *target.SubscriptionID != subscriptionID {
+		return target, ErrUserNotFound
+	}
+	if target.UserType != types.UserTypeStudent && target.UserType != types.UserTypeAssistant {
+		return target, ErrUserNotAssignable
+	}
+
+	if roleID != nil {
+		if _, err := Get(db, subscriptionID, *roleID); err != nil {
+			return target, err
+		}
+	}
+
+	if err := db.Model(&user.User{}).Where("id = ?", userID).Update("role_id", roleID).Error; err != nil {
+		return target, err
+	}
+
+	return user.Get(db, userID)
+}
+
+func (r *Role) fillPermissionNames() {
+	r.PermissionNames = make([]authz.Permission, len(r.Permissions))
+	for i, p := range r.Permissions {
+		r.PermissionNames[i] = p.Permission
+	}
+}
+
+func replacePermissions(tx *gorm.DB, roleID uuid.UUID, permissions []authz.Permission) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&RolePermission{}).Error; err != nil {
+		return err
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	rows := make([]RolePermission, len(permissions))
+	for i, p := range permissions {
+		rows[i] = RolePermission{RoleID: roleID, Permission: p}
+	}
+	return tx.Create(&rows).Error
+}
+
+func normalize(input Input) (string, []authz.Permission, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 50 {
+		return "", nil, ErrRoleNameInvalid
+	}
+
+	seen := make(map[authz.Permission]bool, len(input.Permissions))
+	permissions := make([]authz.Permission, 0, len(input.Permissions))
+	for _, p := range input.Permissions {
+		if !authz.ValidPermission(p) {
+			return "", nil, ErrInvalidPermission
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		permissions = append(permissions, p)
+	}
+
+	return name, permissions, nil
+}
+
+func mapUniqueErr(err error) error {
+	if strings.Contains(err.Error(), "idx_roles_subscription_name") {
+		return ErrRoleNameTaken
+	}
+	return err
+}
+
+func trimStringPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,27 @@
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches custom role management endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdminInstructor []gin.HandlerFunc) {
+	roles := router.Group("/subscriptions/:subscriptionId/roles")
+	roles.GET("/permissions", append(acAdminInstructor, handler.Permissions)...)
+	roles.GET("", append(acAdminInstructor, handler.List)...)
+	roles.POST("", append(acAdminInstructor, handler.Create)...)
+	roles.GET("/:roleId", append(acAdminInstructor, handler.GetByID)...)
+	roles.PUT("/:roleId", append(acAdminInstructor, handler.Update)...)
+	roles.DELETE("/:roleId", append(acAdminInstructor, handler.Delete)...)
+
+	router.PUT("/subscriptions/:subscriptionId/users/:userId/role", append(acAdminInstructor, handler.AssignToUser)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Role{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: roleRequest{}, Response: Role{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Role{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: roleRequest{}, Response: Role{}})
+	openapi.Describe(handler.AssignToUser, openapi.Spec{Request: assignToUserRequest{}, Response: user.User{}})
+}
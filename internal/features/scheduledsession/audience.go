@@ -0,0 +1,43 @@
+package scheduledsession
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+var staffTypes = []types.UserType{types.UserTypeInstructor, types.UserTypeAssistant}
+
+// MemberGroups returns the access groups of the subscription the user belongs to.
+func MemberGroups(db *gorm.DB, subscriptionID, userID uuid.UUID) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0)
+	err := db.Table("group_access").
+		Where("subscription_id = ? AND ? = ANY(users)", subscriptionID, userID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Audience returns the active members who should hear about the session: everyone in
+// the subscription, or only the group's members plus staff for group-scoped sessions.
+func Audience(db *gorm.DB, session Session) ([]uuid.UUID, error) {
+	query := db.Table("users").
+		Where("subscription_id = ? AND is_active = ?", session.SubscriptionID, true).
+		Where("user_type <> ?", types.UserTypeReferrer)
+
+	if session.GroupAccessID != nil {
+		query = query.Where("user_type IN ? OR id IN (SELECT unnest(users) FROM group_access WHERE id = ?)",
+			staffTypes, *session.GroupAccessID)
+	}
+
+	var ids []uuid.UUID
+	err := query.Pluck("id", &ids).Error
+	return ids, err
+}
+
+// belongsToSubscription reports whether the row of table exists in the subscription.
+func belongsToSubscription(db *gorm.DB, table string, id, subscriptionID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Table(table).Where("id = ? AND subscription_id = ?", id, subscriptionID).Count(&count).Error
+	return count > 0, err
+}
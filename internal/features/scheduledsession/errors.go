@@ -0,0 +1,35 @@
+package scheduledsession
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("scheduled session not found")
+	ErrTitleRequired      = errors.New("title is required")
+	ErrTitleLength        = errors.New("title must be between 3 and 100 characters")
+	ErrDescriptionTooLong = errors.New("description must be at most 1000 characters")
+	ErrInvalidType        = errors.New("type must be stream or meeting")
+	ErrInvalidDuration    = errors.New("duration must be between 5 and 480 minutes")
+	ErrStartInPast        = errors.New("start time must be in the future")
+	ErrInvalidScope       = errors.New("course or group does not belong to this subscription")
+	ErrNotEditable        = errors.New("only scheduled sessions can be changed")
+	ErrInvalidFeedToken   = errors.New("invalid calendar token")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrSessionNotFound, Status: http.StatusNotFound, Code: "SESSION_NOT_FOUND", Message: "Session not found."},
+	{Err: ErrInvalidFeedToken, Status: http.StatusNotFound, Code: "INVALID_FEED_TOKEN", Message: "Calendar feed not found."},
+	{Err: ErrNotEditable, Status: http.StatusConflict, Code: "SESSION_NOT_EDITABLE"},
+	{Err: ErrTitleRequired, Status: http.StatusBadRequest, Code: "SESSION_TITLE_REQUIRED"},
+	{Err: ErrTitleLength, Status: http.StatusBadRequest, Code: "SESSION_TITLE_LENGTH"},
+	{Err: ErrDescriptionTooLong, Status: http.StatusBadRequest, Code: "SESSION_DESCRIPTION_TOO_LONG"},
+	{Err: ErrInvalidType, Status: http.StatusBadRequest, Code: "INVALID_SESSION_TYPE"},
+	{Err: ErrInvalidDuration, Status: http.StatusBadRequest, Code: "INVALID_SESSION_DURATION"},
+	{Err: ErrStartInPast, Status: http.StatusBadRequest, Code: "SESSION_START_IN_PAST"},
+	{Err: ErrInvalidScope, Status: http.StatusBadRequest, Code: "INVALID_SESSION_SCOPE"},
+}
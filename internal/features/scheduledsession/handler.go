@@ -0,0 +1,379 @@
+package scheduledsession
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// feedHistory is how far back the calendar feed includes past sessions.
+const feedHistory = 30 * 24 * time.Hour
+
+// Handler processes scheduled session HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a scheduled session handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// List returns sessions that have not ended, optionally bounded by ?from= and ?to= (RFC 3339).
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	filters := ListFilters{SubscriptionID: subscriptionID, From: time.Now().UTC()}
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "from must be an RFC 3339 timestamp", err)
+			return
+		}
+		filters.From = from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "to must be an RFC 3339 timestamp", err)
+			return
+		}
+		filters.To = &to
+	}
+
+	filters.GroupIDs, err = h.visibleGroups(currentUser.UserType, subscriptionID, currentUser.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load group membership", err)
+		return
+	}
+
+	sessions, err := List(h.db, filters)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list sessions", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, sessions, "", nil)
+}
+
+// GetByID returns a single session.
+func (h *Handler) GetByID(c *gin.Context) {
+	session, ok := h.loadSession(c)
+	if !ok {
+		return
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	if session.GroupAccessID != nil {
+		groups, err := h.visibleGroups(currentUser.UserType, session.SubscriptionID, currentUser.ID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load group membership", err)
+			return
+		}
+		if groups != nil && !containsID(groups, *session.GroupAccessID) {
+			h.respondError(c, ErrSessionNotFound, "failed to load session")
+			return
+		}
+	}
+
+	response.Success(c, http.StatusOK, session, "", nil)
+}
+
+type createRequest struct {
+	Type            Type       `json:"type" binding:"required"`
+	Title           string     `json:"title" binding:"required"`
+	Description     string     `json:"description"`
+	StartsAt        time.Time  `json:"startsAt" binding:"required"`
+	DurationMinutes int        `json:"durationMinutes" binding:"required"`
+	CourseID        *uuid.UUID `json:"courseId"`
+	GroupAccessID   *uuid.UUID `json:"groupAccessId"`
+}
+
+// Create schedules a stream or meeting hosted by the current user.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req createRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid session payload") {
+		return
+	}
+
+	if err := h.validateScope(subscriptionID, req.CourseID, req.GroupAccessID); err != nil {
+		h.respondError(c, err, "failed to validate session scope")
+		return
+	}
+
+	session, err := Create(h.db, CreateInput{
+		SubscriptionID:  subscriptionID,
+		CourseID:        req.CourseID,
+		GroupAccessID:   req.GroupAccessID,
+		HostID:          currentUser.ID,
+		HostName:        currentUser.FullName,
+		Type:            req.Type,
+		Title:           req.Title,
+		Description:     req.Description,
+		StartsAt:        req.StartsAt,
+		DurationMinutes: req.DurationMinutes,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to create session")
+		return
+	}
+
+	response.Created(c, session, "")
+}
+
+type updateRequest struct {
+	Title           *string    `json:"title"`
+	Description     *string    `json:"description"`
+	StartsAt        *time.Time `json:"startsAt"`
+	DurationMinutes *int       `json:"durationMinutes"`
+}
+
+// Update reschedules or renames a session that has not started.
+func (h *Handler) Update(c *gin.Context) {
+	session, ok := h.loadSession(c)
+	if !ok {
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid session payload") {
+		return
+	}
+
+	updated, err := Update(h.db, session, UpdateInput{
+		Title:           req.Title,
+		Description:     req.Description,
+		StartsAt:        req.StartsAt,
+		DurationMinutes: req.DurationMinutes,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to update session")
+		return
+	}
+
+	response.Success(c, http.StatusOK, updated, "", nil)
+}
+
+// Delete removes a session from the schedule.
+func (h *Handler) Delete(c *gin.Context) {
+	session, ok := h.loadSession(c)
+	if !ok {
+		return
+	}
+
+	if err := Delete(h.db, session.ID); err != nil {
+		h.respondError(c, err, "failed to delete session")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "Session deleted.", nil)
+}
+
+// CalendarURL returns the current user's private iCal feed URL.
+func (h *Handler) CalendarURL(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	token, err := FeedToken(h.db, currentUser.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load calendar feed", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"url": feedURL(c, token)}, "", nil)
+}
+
+// ResetCalendarURL issues a new feed URL; the previous one stops working.
+func (h *Handler) ResetCalendarURL(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	token, err := RotateFeedToken(h.db, currentUser.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to reset calendar feed", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"url": feedURL(c, token)}, "", nil)
+}
+
+// Feed serves the iCal feed. It is public because calendar apps cannot send bearer
+// tokens; the secret token in the URL authenticates the user instead.
+func (h *Handler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	userID, err := FeedOwner(h.db, token)
+	if err != nil {
+		h.respondError(c, err, "failed to load calendar feed")
+		return
+	}
+
+	var owner struct {
+		SubscriptionID *uuid.UUID
+		UserType       types.UserType
+		IsActive       bool
+	}
+	if err := h.db.Table("users").Select("subscription_id, user_type, is_active").
+		Where("id = ?", userID).Scan(&owner).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load calendar owner", err)
+		return
+	}
+	if !owner.IsActive {
+		h.respondError(c, ErrInvalidFeedToken, "failed to load calendar feed")
+		return
+	}
+
+	sessions := make([]Session, 0)
+	if owner.SubscriptionID != nil && owner.UserType != types.UserTypeReferrer {
+		groups, err := h.visibleGroups(owner.UserType, *owner.SubscriptionID, userID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load group membership", err)
+			return
+		}
+
+		sessions, err = List(h.db, ListFilters{
+			SubscriptionID: *owner.SubscriptionID,
+			From:           time.Now().UTC().Add(-feedHistory),
+			GroupIDs:       groups,
+		})
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list sessions", err)
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", `inline; filename="sessions.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", renderICal(sessions, time.Now()))
+}
+
+func (h *Handler) loadSession(c *gin.Context) (Session, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return Session{}, false
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid session id", err)
+		return Session{}, false
+	}
+
+	session, err := Get(h.db, subscriptionID, sessionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load session")
+		return Session{}, false
+	}
+
+	return session, true
+}
+
+// visibleGroups returns nil when the user sees every session, otherwise the groups
+// whose sessions they may see.
+func (h *Handler) visibleGroups(role types.UserType, subscriptionID, userID uuid.UUID) ([]uuid.UUID, error) {
+	if authz.IsStaff(role) {
+		return nil, nil
+	}
+	return MemberGroups(h.db, subscriptionID, userID)
+}
+
+func (h *Handler) validateScope(subscriptionID uuid.UUID, courseID, groupAccessID *uuid.UUID) error {
+	if courseID != nil {
+		ok, err := belongsToSubscription(h.db, "courses", *courseID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidScope
+		}
+	}
+
+	if groupAccessID != nil {
+		ok, err := belongsToSubscription(h.db, "group_access", *groupAccessID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidScope
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
+
+// feedURL builds the absolute feed URL from the current request, keeping the API prefix.
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+
+	prefix := c.FullPath()
+	if idx := strings.Index(prefix, "/sessions/calendar"); idx >= 0 {
+		prefix = prefix[:idx]
+	}
+
+	return scheme + "://" + c.Request.Host + prefix + "/calendar/" + token + ".ics"
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package scheduledsession
+
+import (
+	"strings"
+	"time"
+)
+
+const icalTimeFormat = "20060102T150405Z"
+
+// renderICal renders sessions as an RFC 5545 calendar.
+func renderICal(sessions []Session, now time.Time) []byte {
+	var b strings.Builder
+
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//LMS Server//Scheduled Sessions//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:Live sessions")
+
+	stamp := now.UTC().Format(icalTimeFormat)
+	for _, session := range sessions {
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+session.ID.String()+"@lms-server")
+		writeICalLine(&b, "DTSTAMP:"+stamp)
+		writeICalLine(&b, "DTSTART:"+session.StartsAt.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTEND:"+session.EndsAt().UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "LAST-MODIFIED:"+session.UpdatedAt.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(session.Title))
+		if session.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(session.Description))
+		}
+		writeICalLine(&b, "CATEGORIES:"+strings.ToUpper(string(session.Type)))
+		writeICalLine(&b, "STATUS:CONFIRMED")
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// writeICalLine folds content lines longer than 75 octets as required by RFC 5545,
+// without splitting UTF-8 sequences.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+var icalEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeICalText(text string) string {
+	return icalEscaper.Replace(text)
+}
@@ -0,0 +1,241 @@
+package scheduledsession
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/meeting"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// reminderLead is how long before the start time members are reminded.
+const reminderLead = 15 * time.Minute
+
+// Job sends reminders for upcoming sessions, takes due sessions live and closes
+// finished ones. It is meant to run every minute on the job scheduler.
+type Job struct {
+	db       *gorm.DB
+	logger   *slog.Logger
+	notifier *notification.Service
+	meetings *meeting.Cache
+	streams  *streamcache.Cache
+}
+
+// NewJob constructs the scheduled session job. notifier may be nil to skip notifications.
+func NewJob(db *gorm.DB, logger *slog.Logger, notifier *notification.Service, meetings *meeting.Cache, streams *streamcache.Cache) *Job {
+	return &Job{db: db, logger: logger, notifier: notifier, meetings: meetings, streams: streams}
+}
+
+// Name returns the job name.
+func (j *Job) Name() string {
+	return "scheduled-sessions"
+}
+
+// Execute runs one pass over the schedule.
+func (j *Job) Execute(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	if err := j.sendReminders(ctx, now); err != nil {
+		return fmt.Errorf("send reminders: %w", err)
+	}
+	if err := j.startDue(ctx, now); err != nil {
+		return fmt.Errorf("start sessions: %w", err)
+	}
+	if err := j.completeFinished(ctx, now); err != nil {
+		return fmt.Errorf("complete sessions: %w", err)
+	}
+	return nil
+}
+
+func (j *Job) sendReminders(ctx context.Context, now time.Time) error {
+	var sessions []Session
+	if err := j.db.WithContext(ctx).
+		Where("status = ? AND reminder_sent_at IS NULL", StatusScheduled).
+		Where("starts_at > ? AND starts_at <= ?", now, now.Add(reminderLead)).
+		Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		// Claim the reminder first so concurrent instances do not send it twice
+		result := j.db.WithContext(ctx).Model(&Session{}).
+			Where("id = ? AND reminder_sent_at IS NULL", session.ID).
+			Update("reminder_sent_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		minutes := int(session.StartsAt.Sub(now).Round(time.Minute).Minutes())
+		j.notify(session, notification.TypeSessionReminder,
+			fmt.Sprintf("\"%s\" starts in %d minutes", session.Title, minutes))
+	}
+
+	return nil
+}
+
+func (j *Job) startDue(ctx context.Context, now time.Time) error {
+	var sessions []Session
+	if err := j.db.WithContext(ctx).
+		Where("status = ? AND starts_at <= ?", StatusScheduled, now).
+		Where("starts_at + (duration_minutes * INTERVAL '1 minute') > ?", now).
+		Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		liveID, err := j.goLive(ctx, session)
+		if err != nil {
+			j.logger.Warn("failed to start scheduled session",
+				slog.String("sessionId", session.ID.String()),
+				slog.String("type", string(session.Type)),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		if err := j.db.WithContext(ctx).Model(&Session{}).
+			Where("id = ?", session.ID).
+			Updates(map[string]interface{}{"status": StatusLive, "live_id": liveID}).Error; err != nil {
+			return err
+		}
+
+		j.notify(session, notification.TypeSessionStarted,
+			fmt.Sprintf("\"%s\" is live now", session.Title))
+	}
+
+	return nil
+}
+
+// goLive registers the stream or meeting in its cache so members can join as soon as
+// the host connects.
+func (j *Job) goLive(ctx context.Context, session Session) (string, error) {
+	var host struct {
+		FullName string
+		Email    string
+	}
+	if err := j.db.WithContext(ctx).Table("users").Select("full_name, email").
+		Where("id = ?", session.HostID).Scan(&host).Error; err != nil {
+		return "", err
+	}
+
+	switch session.Type {
+	case TypeStream:
+		streamID := session.ID.String()
+		if existing, ok := j.streams.GetStream(streamID); ok && existing.IsLive {
+			return streamID, nil
+		}
+		var groupAccess []string
+		if session.GroupAccessID != nil {
+			groupAccess = []string{session.GroupAccessID.String()}
+		}
+		j.streams.StartStream(streamID, session.HostID.String(), streamcache.StreamOptions{
+			Title:          session.Title,
+			Description:    session.Description,
+			HostName:       host.FullName,
+			IsPublic:       session.GroupAccessID == nil,
+			SubscriptionID: session.SubscriptionID.String(),
+			GroupAccess:    groupAccess,
+		})
+		return streamID, nil
+
+	case TypeMeeting:
+		var identifiers []string
+		if err := j.db.WithContext(ctx).Table("subscriptions").
+			Where("id = ?", session.SubscriptionID).
+			Pluck("identifier_name", &identifiers).Error; err != nil {
+			return "", err
+		}
+
+		roomID := meeting.GenerateRoomID()
+		if len(identifiers) > 0 && identifiers[0] != "" {
+			roomID = identifiers[0]
+		}
+
+		var groupAccess []string
+		accessType := "public"
+		if session.GroupAccessID != nil {
+			accessType = "group"
+			groupAccess = []string{session.GroupAccessID.String()}
+		}
+
+		created, err := j.meetings.CreateMeeting(meeting.CreateMeetingInput{
+			RoomID:         roomID,
+			SubscriptionID: session.SubscriptionID.String(),
+			Title:          session.Title,
+			Description:    session.Description,
+			HostID:         session.HostID.String(),
+			AccessType:     accessType,
+			GroupAccess:    groupAccess,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		j.meetings.AddParticipant(roomID, session.HostID.String(), &meeting.Participant{
+			Name:        host.FullName,
+			Email:       host.Email,
+			Mic:         true,
+			Camera:      true,
+			ScreenShare: true,
+		})
+
+		// Attendance is recorded once the host actually joins through the REST endpoint
+		if err := meeting.RecordStart(j.db, created); err != nil {
+			j.logger.Warn("failed to persist scheduled meeting", slog.String("roomId", roomID), slog.String("error", err.Error()))
+		}
+		return roomID, nil
+	}
+
+	return "", ErrInvalidType
+}
+
+// completeFinished closes sessions whose planned window has passed. Live streams and
+// meetings themselves keep running until the host ends them.
+func (j *Job) completeFinished(ctx context.Context, now time.Time) error {
+	return j.db.WithContext(ctx).Model(&Session{}).
+		Where("status IN ?", []Status{StatusScheduled, StatusLive}).
+		Where("starts_at + (duration_minutes * INTERVAL '1 minute') <= ?", now).
+		Update("status", StatusCompleted).Error
+}
+
+func (j *Job) notify(session Session, kind notification.Type, title string) {
+	if j.notifier == nil {
+		return
+	}
+
+	recipients, err := Audience(j.db, session)
+	if err != nil {
+		j.logger.Error("failed to resolve session audience", slog.String("sessionId", session.ID.String()), slog.String("error", err.Error()))
+		return
+	}
+
+	subscriptionID := session.SubscriptionID
+	sessionID := session.ID
+	// The host is always told, including platform admins hosting outside their subscription
+	j.notifier.Notify(withUser(recipients, session.HostID), notification.Notification{
+		SubscriptionID: &subscriptionID,
+		Type:           kind,
+		Title:          title,
+		Message:        fmt.Sprintf("%s, %s UTC, hosted by %s", session.Title, session.StartsAt.Format("Jan 2 15:04"), session.HostName),
+		ActorName:      session.HostName,
+		CourseID:       session.CourseID,
+		SessionID:      &sessionID,
+	})
+}
+
+func withUser(ids []uuid.UUID, userID uuid.UUID) []uuid.UUID {
+	for _, id := range ids {
+		if id == userID {
+			return ids
+		}
+	}
+	return append(ids, userID)
+}
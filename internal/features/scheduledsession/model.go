@@ -0,0 +1,320 @@
+package scheduledsession
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Type selects what the session turns into at its start time.
+type Type string
+
+const (
+	TypeStream  Type = "stream"
+	TypeMeeting Type = "meeting"
+)
+
+// Status tracks a session through its lifecycle.
+type Status string
+
+const (
+	StatusScheduled Status = "scheduled"
+	StatusLive      Status = "live"
+	StatusCompleted Status = "completed"
+)
+
+const (
+	minDurationMinutes = 5
+	maxDurationMinutes = 480
+)
+
+// Session is a live stream or meeting planned ahead of time. A session scoped to a
+// group is only visible to (and announced to) that group's members and the staff.
+type Session struct {
+	types.BaseModel
+
+	SubscriptionID  uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;index:idx_scheduled_sessions_subscription_start,priority:1" json:"subscriptionId"`
+	CourseID        *uuid.UUID `gorm:"type:uuid;column:course_id" json:"courseId,omitempty"`
+	GroupAccessID   *uuid.UUID `gorm:"type:uuid;column:group_access_id" json:"groupAccessId,omitempty"`
+	HostID          uuid.UUID  `gorm:"type:uuid;not null;column:host_id" json:"hostId"`
+	HostName        string     `gorm:"type:varchar(30);column:host_name" json:"hostName"`
+	Type            Type       `gorm:"type:varchar(20);not null" json:"type"`
+	Title           string     `gorm:"type:varchar(100);not null" json:"title"`
+	Description     string     `gorm:"type:varchar(1000)" json:"description,omitempty"`
+	StartsAt        time.Time  `gorm:"type:timestamp;not null;column:starts_at;index:idx_scheduled_sessions_subscription_start,priority:2" json:"startsAt"`
+	DurationMinutes int        `gorm:"type:int;not null;column:duration_minutes" json:"durationMinutes"`
+	Status          Status     `gorm:"type:varchar(20);not null;default:'scheduled';index" json:"status"`
+	LiveID          string     `gorm:"type:varchar(100);column:live_id" json:"liveId,omitempty"` // stream ID or meeting room ID once live
+	ReminderSentAt  *time.Time `gorm:"type:timestamp;column:reminder_sent_at" json:"-"`
+}
+
+// TableName overrides the default table name.
+func (Session) TableName() string { return "scheduled_sessions" }
+
+// EndsAt returns the planned end of the session.
+func (s Session) EndsAt() time.Time {
+	return s.StartsAt.Add(time.Duration(s.DurationMinutes) * time.Minute)
+}
+
+// CalendarFeed holds the secret token that authenticates a user's iCal subscription URL.
+type CalendarFeed struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id" json:"userId"`
+	Token     string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+// TableName overrides the default table name.
+func (CalendarFeed) TableName() string { return "calendar_feeds" }
+
+// CreateInput carries data for scheduling a session.
+type CreateInput struct {
+	SubscriptionID  uuid.UUID
+	CourseID        *uuid.UUID
+	GroupAccessID   *uuid.UUID
+	HostID          uuid.UUID
+	HostName        string
+	Type            Type
+	Title           string
+	Description     string
+	StartsAt        time.Time
+	DurationMinutes int
+}
+
+// UpdateInput captures mutable session fields.
+type UpdateInput struct {
+	Title           *string
+	Description     *string
+	StartsAt        *time.Time
+	DurationMinutes *int
+}
+
+// ListFilters narrows the sessions returned by List.
+type ListFilters struct {
+	SubscriptionID uuid.UUID
+	From           time.Time
+	To             *time.Time
+	// GroupIDs limits group-scoped sessions to these groups; nil means every group.
+	GroupIDs []uuid.UUID
+}
+
+// List returns sessions that have not ended before From, ordered by start time.
+func List(db *gorm.DB, filters ListFilters) ([]Session, error) {
+	query := db.Model(&Session{}).
+		Where("subscription_id = ?", filters.SubscriptionID).
+		Where("starts_at + (duration_minutes * INTERVAL '1 minute') >= ?", filters.From)
+
+	if filters.To != nil {
+		query = query.Where("starts_at <= ?", *filters.To)
+	}
+
+	if filters.GroupIDs != nil {
+		if len(filters.GroupIDs) == 0 {
+			query = query.Where("group_access_id IS NULL")
+		} else {
+			query = query.Where("group_access_id IS NULL OR group_access_id IN ?", filters.GroupIDs)
+		}
+	}
+
+	sessions := make([]Session, 0)
+	err := query.Order("starts_at ASC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Get retrieves a session of the subscription.
+func Get(db *gorm.DB, subscriptionID, id uuid.UUID) (Session, error) {
+	var session Session
+	if err := db.Where("id = ? AND subscription_id = ?", id, subscriptionID).Limit(1).Find(&session).Error; err != nil {
+		return Session{}, err
+	}
+	if session.ID == uuid.Nil {
+		return Session{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// Create validates and inserts a new session.
+func Create(db *gorm.DB, input CreateInput) (Session, error) {
+	if input.Type != TypeStream && input.Type != TypeMeeting {
+		return Session{}, ErrInvalidType
+	}
+
+	title, err := validateTitle(input.Title)
+	if err != nil {
+		return Session{}, err
+	}
+	description, err := validateDescription(input.Description)
+	if err != nil {
+		return Session{}, err
+	}
+	if err := validateSchedule(input.StartsAt, input.DurationMinutes); err != nil {
+		return Session{}, err
+	}
+
+	session := Session{
+		SubscriptionID:  input.SubscriptionID,
+		CourseID:        input.CourseID,
+		GroupAccessID:   input.GroupAccessID,
+		HostID:          input.HostID,
+		HostName:        input.HostName,
+		Type:            input.Type,
+		Title:           title,
+		Description:     description,
+		StartsAt:        input.StartsAt.UTC(),
+		DurationMinutes: input.DurationMinutes,
+		Status:          StatusScheduled,
+	}
+
+	if err := db.Create(&session).Error; err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// Update changes a session that has not started yet. Moving the start time re-arms
+// the reminder.
+func Update(db *gorm.DB, session Session, input UpdateInput) (Session, error) {
+	if session.Status != StatusScheduled {
+		return Session{}, ErrNotEditable
+	}
+
+	updates := map[string]interface{}{}
+
+	if input.Title != nil {
+		title, err := validateTitle(*input.Title)
+		if err != nil {
+			return Session{}, err
+		}
+		updates["title"] = title
+	}
+
+	if input.Description != nil {
+		description, err := validateDescription(*input.Description)
+		if err != nil {
+			return Session{}, err
+		}
+		updates["description"] = description
+	}
+
+	startsAt := session.StartsAt
+	duration := session.DurationMinutes
+	if input.StartsAt != nil {
+		startsAt = input.StartsAt.UTC()
+	}
+	if input.DurationMinutes != nil {
+		duration = *input.DurationMinutes
+	}
+	if input.StartsAt != nil || input.DurationMinutes != nil {
+		if err := validateSchedule(startsAt, duration); err != nil {
+			return Session{}, err
+		}
+		updates["starts_at"] = startsAt
+		updates["duration_minutes"] = duration
+	}
+	if input.StartsAt != nil && !startsAt.Equal(session.StartsAt) {
+		updates["reminder_sent_at"] = nil
+	}
+
+	if len(updates) > 0 {
+		if err := db.Model(&Session{}).Where("id = ?", session.ID).Updates(updates).Error; err != nil {
+			return Session{}, err
+		}
+	}
+
+	return Get(db, session.SubscriptionID, session.ID)
+}
+
+// Delete removes a session.
+func Delete(db *gorm.DB, id uuid.UUID) error {
+	return db.Delete(&Session{}, "id = ?", id).Error
+}
+
+// FeedToken returns the user's calendar token, creating one on first use.
+func FeedToken(db *gorm.DB, userID uuid.UUID) (string, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&CalendarFeed{UserID: userID, Token: token}).Error; err != nil {
+		return "", err
+	}
+
+	var feed CalendarFeed
+	if err := db.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		return "", err
+	}
+	return feed.Token, nil
+}
+
+// RotateFeedToken replaces the user's calendar token, invalidating the old feed URL.
+func RotateFeedToken(db *gorm.DB, userID uuid.UUID) (string, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "updated_at"}),
+	}).Create(&CalendarFeed{UserID: userID, Token: token}).Error
+	return token, err
+}
+
+// FeedOwner resolves a calendar token to its user.
+func FeedOwner(db *gorm.DB, token string) (uuid.UUID, error) {
+	var feed CalendarFeed
+	if err := db.Where("token = ?", token).Limit(1).Find(&feed).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if feed.UserID == uuid.Nil {
+		return uuid.Nil, ErrInvalidFeedToken
+	}
+	return feed.UserID, nil
+}
+
+func newFeedToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func validateTitle(value string) (string, error) {
+	title := strings.TrimSpace(value)
+	if title == "" {
+		return "", ErrTitleRequired
+	}
+	if n := utf8.RuneCountInString(title); n < 3 || n > 100 {
+		return "", ErrTitleLength
+	}
+	return title, nil
+}
+
+func validateDescription(value string) (string, error) {
+	description := strings.TrimSpace(value)
+	if utf8.RuneCountInString(description) > 1000 {
+		return "", ErrDescriptionTooLong
+	}
+	return description, nil
+}
+
+func validateSchedule(startsAt time.Time, durationMinutes int) error {
+	if durationMinutes < minDurationMinutes || durationMinutes > maxDurationMinutes {
+		return ErrInvalidDuration
+	}
+	if !startsAt.After(time.Now()) {
+		return ErrStartInPast
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package scheduledsession
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches scheduled session and calendar feed endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff, allUsers []gin.HandlerFunc) {
+	sessions := router.Group("/subscriptions/:subscriptionId/sessions")
+	sessions.GET("", append(acAll, handler.List)...)
+	sessions.GET("/:sessionId", append(acAll, handler.GetByID)...)
+	sessions.POST("", append(acStaff, handler.Create)...)
+	sessions.PUT("/:sessionId", append(acStaff, handler.Update)...)
+	sessions.DELETE("/:sessionId", append(acStaff, handler.Delete)...)
+
+	router.GET("/sessions/calendar", append(allUsers, handler.CalendarURL)...)
+	router.POST("/sessions/calendar/reset", append(allUsers, handler.ResetCalendarURL)...)
+
+	// Public: authenticated by the secret token in the URL
+	router.GET("/calendar/:token", handler.Feed)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Session{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Session{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Session{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Session{}})
+}
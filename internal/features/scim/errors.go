@@ -0,0 +1,32 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrInvalidToken         = errors.New("invalid scim token")
+	ErrTokenLimit           = errors.New("scim token limit reached")
+	ErrTokenNotFound        = errors.New("scim token not found")
+	ErrSubscriptionInactive = errors.New("subscription is inactive")
+	ErrUserNotFound         = errors.New("scim user not found")
+	ErrGroupNotFound        = groupaccess.ErrGroupNotFound
+	ErrUserExists           = errors.New("a user with this userName already exists")
+	ErrExternalIDTaken      = errors.New("externalId belongs to another user")
+	ErrStudentLimit         = errors.New("student limit reached for this subscription")
+	ErrAssistantLimit       = errors.New("assistant limit reached for this subscription")
+	ErrPointsLimit          = groupaccess.ErrPointsLimit
+	ErrInvalidFilter        = errors.New("invalid filter")
+	ErrInvalidValue         = errors.New("invalid value")
+	ErrInvalidSyntax        = errors.New("invalid request")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrTokenLimit, Status: http.StatusConflict, Code: "SCIM_TOKEN_LIMIT", Message: "This subscription already has the maximum number of SCIM tokens."},
+	{Err: ErrTokenNotFound, Status: http.StatusNotFound, Code: "SCIM_TOKEN_NOT_FOUND", Message: "SCIM token not found."},
+}
@@ -0,0 +1,83 @@
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCount = 100
+	maxCount     = 200
+)
+
+// filter is an `attribute eq "value"` expression, the only form identity
+// providers use to look up resources before creating them.
+type filter struct {
+	Attribute string
+	Value     string
+}
+
+// parseFilter reads a filter, normalising the attribute to lower case. An
+// empty expression yields nil.
+func parseFilter(expression string, attributes ...string) (*filter, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, nil
+	}
+
+	attribute, rest, ok := strings.Cut(expression, " ")
+	if !ok {
+		return nil, fmt.Errorf("%w: expected attribute eq \"value\"", ErrInvalidFilter)
+	}
+	operator, value, ok := strings.Cut(strings.TrimSpace(rest), " ")
+	if !ok || !strings.EqualFold(operator, "eq") {
+		return nil, fmt.Errorf("%w: only the eq operator is supported", ErrInvalidFilter)
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("%w: values must be quoted strings", ErrInvalidFilter)
+	}
+
+	attribute = strings.ToLower(attribute)
+	for _, supported := range attributes {
+		if attribute == strings.ToLower(supported) {
+			return &filter{Attribute: attribute, Value: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: filtering by %s is not supported", ErrInvalidFilter, attribute)
+}
+
+// page reads the 1-based startIndex and count of a list request.
+func page(startIndex, count string) (int, int) {
+	start, err := strconv.Atoi(startIndex)
+	if err != nil || start < 1 {
+		start = 1
+	}
+	size, err := strconv.Atoi(count)
+	if err != nil || size < 0 {
+		size = defaultCount
+	}
+	if size > maxCount {
+		size = maxCount
+	}
+	return start, size
+}
+
+// memberFilter reads the member ID out of a `members[value eq "id"]` path.
+func memberFilter(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.ToLower(path), "members[")
+	if !ok {
+		return "", false
+	}
+	expression, ok := strings.CutSuffix(rest, "]")
+	if !ok {
+		return "", false
+	}
+	parsed, err := parseFilter(expression, "value")
+	if err != nil || parsed == nil {
+		return "", false
+	}
+	return parsed.Value, true
+}
@@ -0,0 +1,647 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+const (
+	contentType     = "application/scim+json"
+	subscriptionKey = "scimSubscription"
+	// maxBodySize bounds SCIM request bodies; groups of a few thousand
+	// members fit comfortably.
+	maxBodySize = 1 << 20
+)
+
+// Handler processes SCIM token management and provisioning requests.
+type Handler struct {
+	db         *gorm.DB
+	logger     *slog.Logger
+	queryCache *cache.Store
+	sessions   user.SessionCloser
+}
+
+// NewHandler constructs a SCIM handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// UseCache sets the query cache whose dashboard counts provisioning invalidates.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+// UseSessionCloser sets where live connections of deactivated users are closed.
+func (h *Handler) UseSessionCloser(sessions user.SessionCloser) {
+	h.sessions = sessions
+}
+
+type createTokenRequest struct {
+	Name string `json:"name" binding:"required,notblank,max=100"`
+}
+
+type createdToken struct {
+	Token
+	Secret string `json:"token"`
+}
+
+// ListTokens returns the subscription's SCIM tokens.
+func (h *Handler) ListTokens(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := ListTokens(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list scim tokens", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, tokens, "", nil)
+}
+
+// CreateToken issues a SCIM token. The token is only ever shown in this
+// response.
+func (h *Handler) CreateToken(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req createTokenRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid scim token payload") {
+		return
+	}
+
+	token := Token{
+		SubscriptionID: subscriptionID,
+		Name:           strings.TrimSpace(req.Name),
+		CreatedBy:      &usr.ID,
+	}
+	secret, err := CreateToken(h.db, &token)
+	if err != nil {
+		h.respondError(c, err, "failed to create scim token")
+		return
+	}
+
+	response.Created(c, createdToken{Token: token, Secret: secret}, "Copy the token now; it will not be shown again.")
+}
+
+// DeleteToken revokes a SCIM token.
+func (h *Handler) DeleteToken(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("tokenId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid token id", err)
+		return
+	}
+
+	if err := DeleteToken(h.db, subscriptionID, id); err != nil {
+		h.respondError(c, err, "failed to delete scim token")
+		return
+	}
+
+	response.NoContent(c, "SCIM token revoked.")
+}
+
+// Authenticate resolves the bearer token of a SCIM request to its
+// subscription. Inactive subscriptions cannot provision.
+func (h *Handler) Authenticate(c *gin.Context) {
+	secret, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(secret, tokenPrefix) {
+		h.fail(c, ErrInvalidToken)
+		return
+	}
+
+	token, err := Authenticate(h.db, strings.TrimSpace(secret), time.Now())
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	sub, err := subscription.Get(h.db, token.SubscriptionID)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	if !sub.Active {
+		h.fail(c, ErrSubscriptionInactive)
+		return
+	}
+
+	c.Set(subscriptionKey, sub)
+	c.Next()
+}
+
+// ServiceProviderConfig describes the SCIM features the service supports.
+func (h *Handler) ServiceProviderConfig(c *gin.Context) {
+	h.respond(c, http.StatusOK, gin.H{
+		"schemas":        []string{configSchema},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": maxCount},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "A SCIM token issued in the subscription's settings.",
+			"primary":     true,
+		}},
+	})
+}
+
+// ResourceTypes lists the resources the service provisions.
+func (h *Handler) ResourceTypes(c *gin.Context) {
+	resources := []interface{}{
+		gin.H{"schemas": []string{typeSchema}, "id": "User", "name": "User", "endpoint": "/Users", "schema": userSchema},
+		gin.H{"schemas": []string{typeSchema}, "id": "Group", "name": "Group", "endpoint": "/Groups", "schema": groupSchema},
+	}
+	h.respond(c, http.StatusOK, listResponse{
+		Schemas:      []string{listSchema},
+		TotalResults: int64(len(resources)),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// ListUsers returns the students and assistants matching the filter.
+func (h *Handler) ListUsers(c *gin.Context) {
+	sub := h.subscription(c)
+
+	f, err := parseFilter(c.Query("filter"), "userName", "externalId", "emails.value", "id")
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	start, count := page(c.Query("startIndex"), c.Query("count"))
+
+	users, total, err := ListUsers(h.db, sub.ID, f, start, count)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, usr := range users {
+		ids = append(ids, usr.ID)
+	}
+	externalIDs, err := ExternalIDs(h.db, ids)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	resources := make([]interface{}, 0, len(users))
+	for _, usr := range users {
+		resources = append(resources, newUserResource(usr, externalIDs[usr.ID]))
+	}
+	h.respond(c, http.StatusOK, listResponse{
+		Schemas:      []string{listSchema},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetUser returns one user.
+func (h *Handler) GetUser(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	usr, err := GetUser(h.db, sub.ID, id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respondUser(c, http.StatusOK, usr)
+}
+
+// CreateUser provisions a student or assistant.
+func (h *Handler) CreateUser(c *gin.Context) {
+	sub := h.subscription(c)
+
+	var resource userResource
+	if !h.bind(c, &resource) {
+		return
+	}
+	fields, err := resource.fields()
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	usr, err := CreateUser(h.db, sub, fields)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+	h.respondUser(c, http.StatusCreated, usr)
+}
+
+// ReplaceUser overwrites a user with the posted resource.
+func (h *Handler) ReplaceUser(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	var resource userResource
+	if !h.bind(c, &resource) {
+		return
+	}
+	fields, err := resource.fields()
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	h.replaceUser(c, sub, id, fields)
+}
+
+// PatchUser applies patch operations to a user. Identity providers
+// deactivate users this way when they are unassigned.
+func (h *Handler) PatchUser(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	var patch patchRequest
+	if !h.bind(c, &patch) {
+		return
+	}
+
+	usr, err := GetUser(h.db, sub.ID, id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	externalIDs, err := ExternalIDs(h.db, []uuid.UUID{id})
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	resource := newUserResource(usr, externalIDs[id])
+	if err := applyUserPatch(&resource, patch.Operations); err != nil {
+		h.fail(c, err)
+		return
+	}
+	fields, err := resource.fields()
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	h.replaceUser(c, sub, id, fields)
+}
+
+func (h *Handler) replaceUser(c *gin.Context, sub subscription.Subscription, id uuid.UUID, fields userFields) {
+	usr, err := ReplaceUser(h.db, sub, id, fields)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	if !usr.Active {
+		h.closeSessions(id)
+	}
+
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+	h.respondUser(c, http.StatusOK, usr)
+}
+
+// DeleteUser deprovisions a user. The account is deactivated and leaves its
+// groups rather than being deleted, so its history stays with the school.
+func (h *Handler) DeleteUser(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	if err := DeactivateUser(h.db, sub.ID, id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.closeSessions(id)
+
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups returns the groups matching the filter.
+func (h *Handler) ListGroups(c *gin.Context) {
+	sub := h.subscription(c)
+
+	f, err := parseFilter(c.Query("filter"), "displayName", "id")
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	start, count := page(c.Query("startIndex"), c.Query("count"))
+
+	groups, total, err := ListGroups(h.db, sub.ID, f, start, count)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	withMembers := !excludesMembers(c)
+	names := map[uuid.UUID]string{}
+	if withMembers {
+		if names, err = MemberNames(h.db, groups); err != nil {
+			h.fail(c, err)
+			return
+		}
+	}
+
+	resources := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		resources = append(resources, newGroupResource(group, names, withMembers))
+	}
+	h.respond(c, http.StatusOK, listResponse{
+		Schemas:      []string{listSchema},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetGroup returns one group.
+func (h *Handler) GetGroup(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	group, err := GetGroup(h.db, sub.ID, id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respondGroup(c, http.StatusOK, group)
+}
+
+// CreateGroup creates a group access with the posted members.
+func (h *Handler) CreateGroup(c *gin.Context) {
+	sub := h.subscription(c)
+
+	var resource groupResource
+	if !h.bind(c, &resource) {
+		return
+	}
+
+	group, err := CreateGroup(h.db, sub, resource.DisplayName, memberIDs(resource.Members))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respondGroup(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup renames a group and sets its members.
+func (h *Handler) ReplaceGroup(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	var resource groupResource
+	if !h.bind(c, &resource) {
+		return
+	}
+
+	group, err := ReplaceGroup(h.db, sub, id, resource.DisplayName, memberIDs(resource.Members))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respondGroup(c, http.StatusOK, group)
+}
+
+// PatchGroup renames a group or adds and removes members.
+func (h *Handler) PatchGroup(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	var patch patchRequest
+	if !h.bind(c, &patch) {
+		return
+	}
+
+	group, err := PatchGroup(h.db, sub, id, patch.Operations)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respondGroup(c, http.StatusOK, group)
+}
+
+// DeleteGroup removes a group access. Its members keep their accounts.
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	sub := h.subscription(c)
+	id, ok := h.resourceID(c, ErrGroupNotFound)
+	if !ok {
+		return
+	}
+
+	if err := DeleteGroup(h.db, sub.ID, id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) respondUser(c *gin.Context, status int, usr user.User) {
+	externalIDs, err := ExternalIDs(h.db, []uuid.UUID{usr.ID})
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, status, newUserResource(usr, externalIDs[usr.ID]))
+}
+
+func (h *Handler) respondGroup(c *gin.Context, status int, group groupaccess.GroupAccess) {
+	withMembers := !excludesMembers(c)
+	names := map[uuid.UUID]string{}
+	if withMembers {
+		var err error
+		if names, err = MemberNames(h.db, []groupaccess.GroupAccess{group}); err != nil {
+			h.fail(c, err)
+			return
+		}
+	}
+	h.respond(c, status, newGroupResource(group, names, withMembers))
+}
+
+// excludesMembers reports whether the request asked to leave members out, as
+// identity providers do when they only check that a group exists.
+func excludesMembers(c *gin.Context) bool {
+	for _, attribute := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			return true
+		}
+	}
+	return false
+}
+
+func memberIDs(members []multiValue) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Value)
+	}
+	return ids
+}
+
+func (h *Handler) closeSessions(userID uuid.UUID) {
+	if h.sessions != nil {
+		h.sessions.DisconnectUser(userID)
+	}
+}
+
+func (h *Handler) subscription(c *gin.Context) subscription.Subscription {
+	return c.MustGet(subscriptionKey).(subscription.Subscription)
+}
+
+func (h *Handler) subscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+	return subscriptionID, true
+}
+
+// resourceID reads the path's resource ID. IDs that are not UUIDs cannot
+// name a resource, so they are reported with notFound.
+func (h *Handler) resourceID(c *gin.Context, notFound error) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.fail(c, notFound)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// bind decodes a SCIM request body, which identity providers send as
+// application/scim+json.
+func (h *Handler) bind(c *gin.Context, dest interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+	if err := decoder.Decode(dest); err != nil {
+		if errors.Is(err, ErrInvalidValue) {
+			h.fail(c, err)
+			return false
+		}
+		h.fail(c, ErrInvalidSyntax)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", contentType)
+	c.JSON(status, body)
+}
+
+// fail responds with a SCIM error and stops the chain.
+func (h *Handler) fail(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	scimType := ""
+	detail := "Internal server error."
+
+	switch {
+	case errors.Is(err, ErrInvalidToken):
+		status = http.StatusUnauthorized
+		detail = "SCIM token is missing or invalid."
+	case errors.Is(err, ErrSubscriptionInactive):
+		status = http.StatusForbidden
+		detail = "The subscription is inactive."
+	case errors.Is(err, subscription.ErrSubscriptionNotFound):
+		status = http.StatusUnauthorized
+		detail = "SCIM token is missing or invalid."
+	case errors.Is(err, ErrUserNotFound):
+		status = http.StatusNotFound
+		detail = "User not found."
+	case errors.Is(err, ErrGroupNotFound):
+		status = http.StatusNotFound
+		detail = "Group not found."
+	case errors.Is(err, ErrUserExists), errors.Is(err, ErrExternalIDTaken):
+		status = http.StatusConflict
+		scimType = "uniqueness"
+		detail = err.Error()
+	case errors.Is(err, ErrStudentLimit), errors.Is(err, ErrAssistantLimit), errors.Is(err, ErrPointsLimit):
+		status = http.StatusForbidden
+		detail = err.Error()
+	case errors.Is(err, ErrInvalidFilter):
+		status = http.StatusBadRequest
+		scimType = "invalidFilter"
+		detail = err.Error()
+	case errors.Is(err, ErrInvalidValue):
+		status = http.StatusBadRequest
+		scimType = "invalidValue"
+		detail = err.Error()
+	case errors.Is(err, ErrInvalidSyntax):
+		status = http.StatusBadRequest
+		scimType = "invalidSyntax"
+		detail = err.Error()
+	default:
+		h.logger.Error("scim request failed", "path", c.FullPath(), "error", err)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.AbortWithStatusJSON(status, errorResponse{
+		Schemas:  []string{errorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
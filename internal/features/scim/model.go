@@ -0,0 +1,158 @@
+package scim
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	// tokenPrefix marks SCIM tokens so leaked ones are easy to recognise.
+	tokenPrefix = "scim_"
+	// maxTokens caps the tokens of one subscription.
+	maxTokens = 5
+	// touchInterval bounds how often a token's last use is written.
+	touchInterval = 5 * time.Minute
+)
+
+// Token authenticates an identity provider provisioning a subscription's
+// users. Only its hash is stored; the token itself is shown once.
+type Token struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	Name           string     `gorm:"type:varchar(100);not null" json:"name"`
+	Hash           string     `gorm:"type:varchar(64);not null;uniqueIndex;column:token_hash" json:"-"`
+	Prefix         string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	CreatedBy      *uuid.UUID `gorm:"type:uuid;column:created_by" json:"createdBy,omitempty"`
+	LastUsedAt     *time.Time `gorm:"type:timestamp;column:last_used_at" json:"lastUsedAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Token) TableName() string { return "scim_tokens" }
+
+// Link records the external ID an identity provider gave a user it manages.
+type Link struct {
+	types.TimestampModel
+
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey;column:user_id" json:"userId"`
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;column:subscription_id;uniqueIndex:idx_scim_users_external,priority:1" json:"subscriptionId"`
+	ExternalID     string    `gorm:"type:varchar(255);not null;column:external_id;uniqueIndex:idx_scim_users_external,priority:2" json:"externalId"`
+}
+
+// TableName overrides the default table name.
+func (Link) TableName() string { return "scim_users" }
+
+// ListTokens returns the subscription's tokens, newest first.
+func ListTokens(db *gorm.DB, subscriptionID uuid.UUID) ([]Token, error) {
+	tokens := make([]Token, 0)
+	err := db.Where("subscription_id = ?", subscriptionID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// CreateToken issues a token for the subscription within its limit and
+// returns the secret to show once.
+func CreateToken(db *gorm.DB, token *Token) (string, error) {
+	var count int64
+	if err := db.Model(&Token{}).Where("subscription_id = ?", token.SubscriptionID).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count >= maxTokens {
+		return "", ErrTokenLimit
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := tokenPrefix + hex.EncodeToString(buf)
+
+	token.Hash = hashToken(secret)
+	token.Prefix = secret[:len(tokenPrefix)+6]
+	if err := db.Create(token).Error; err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// DeleteToken revokes one of the subscription's tokens.
+func DeleteToken(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	result := db.Delete(&Token{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a bearer token and records its use.
+func Authenticate(db *gorm.DB, secret string, now time.Time) (Token, error) {
+	var token Token
+	if err := db.First(&token, "token_hash = ?", hashToken(secret)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return token, ErrInvalidToken
+		}
+		return token, err
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > touchInterval {
+		if err := db.Model(&token).UpdateColumn("last_used_at", now).Error; err != nil {
+			return token, err
+		}
+	}
+	return token, nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ExternalIDs returns the external IDs of the users that have one.
+func ExternalIDs(db *gorm.DB, userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	ids := make(map[uuid.UUID]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return ids, nil
+	}
+	var links []Link
+	if err := db.Where("user_id IN ?", userIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		ids[link.UserID] = link.ExternalID
+	}
+	return ids, nil
+}
+
+// SetExternalID stores the user's external ID; an empty ID removes it.
+func SetExternalID(db *gorm.DB, subscriptionID, userID uuid.UUID, externalID string) error {
+	if externalID == "" {
+		return db.Delete(&Link{}, "user_id = ?", userID).Error
+	}
+
+	var taken int64
+	if err := db.Model(&Link{}).
+		Where("subscription_id = ? AND external_id = ? AND user_id != ?", subscriptionID, externalID, userID).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrExternalIDTaken
+	}
+
+	link := Link{UserID: userID, SubscriptionID: subscriptionID, ExternalID: externalID}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"external_id", "updated_at"}),
+	}).Create(&link).Error
+}
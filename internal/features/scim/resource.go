@@ -0,0 +1,248 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	userSchema   = "urn:ietf:params:scim:schemas:core:2.0:User"
+	groupSchema  = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	listSchema   = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	patchSchema  = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	errorSchema  = "urn:ietf:params:scim:api:messages:2.0:Error"
+	configSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	typeSchema   = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+const (
+	// maxFullNameLength matches users.full_name.
+	maxFullNameLength = 30
+	// maxPhoneLength matches users.phone.
+	maxPhoneLength = 20
+	// maxExternalIDLength matches scim_users.external_id.
+	maxExternalIDLength = 255
+)
+
+// boolean accepts true/false as JSON booleans or strings; Microsoft Entra ID
+// sends "True" and "False" in patch operations.
+type boolean bool
+
+func (b *boolean) UnmarshalJSON(data []byte) error {
+	switch strings.ToLower(strings.Trim(string(data), `"`)) {
+	case "true":
+		*b = true
+	case "false":
+		*b = false
+	default:
+		return fmt.Errorf("%w: %s is not a boolean", ErrInvalidValue, data)
+	}
+	return nil
+}
+
+type meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// multiValue is an entry of a multi-valued attribute such as emails or members.
+type multiValue struct {
+	Value   string  `json:"value"`
+	Type    string  `json:"type,omitempty"`
+	Primary boolean `json:"primary,omitempty"`
+	Display string  `json:"display,omitempty"`
+}
+
+// userResource is a SCIM User. Students and assistants map to users;
+// userType selects which, and userName is their email.
+type userResource struct {
+	Schemas      []string     `json:"schemas"`
+	ID           string       `json:"id,omitempty"`
+	ExternalID   string       `json:"externalId,omitempty"`
+	UserName     string       `json:"userName"`
+	Name         *name        `json:"name,omitempty"`
+	DisplayName  string       `json:"displayName,omitempty"`
+	Emails       []multiValue `json:"emails,omitempty"`
+	PhoneNumbers []multiValue `json:"phoneNumbers,omitempty"`
+	UserType     string       `json:"userType,omitempty"`
+	Active       *boolean     `json:"active,omitempty"`
+	Meta         *meta        `json:"meta,omitempty"`
+}
+
+// groupResource is a SCIM Group, backed by a group access.
+type groupResource struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []multiValue `json:"members,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// userFields is what a User resource sets on an account.
+type userFields struct {
+	Email      string
+	FullName   string
+	Phone      *string
+	UserType   types.UserType
+	Active     bool
+	ExternalID string
+}
+
+// fields validates the resource and maps it to account fields. The primary
+// email wins over userName, which Microsoft Entra ID fills with the UPN.
+func (r userResource) fields() (userFields, error) {
+	f := userFields{
+		Email:      r.UserName,
+		Active:     r.Active == nil || bool(*r.Active),
+		ExternalID: strings.TrimSpace(r.ExternalID),
+		UserType:   types.UserTypeStudent,
+	}
+
+	if email := primary(r.Emails); email != "" {
+		f.Email = email
+	}
+	f.Email = strings.ToLower(strings.TrimSpace(f.Email))
+	if address, err := mail.ParseAddress(f.Email); err != nil || address.Address != f.Email || len(f.Email) > 255 {
+		return f, fmt.Errorf("%w: userName or the primary email must be an email address", ErrInvalidValue)
+	}
+
+	switch types.UserType(strings.ToLower(strings.TrimSpace(r.UserType))) {
+	case "", types.UserTypeStudent:
+	case types.UserTypeAssistant:
+		f.UserType = types.UserTypeAssistant
+	default:
+		return f, fmt.Errorf("%w: userType must be student or assistant", ErrInvalidValue)
+	}
+
+	f.FullName = strings.TrimSpace(r.DisplayName)
+	if f.FullName == "" && r.Name != nil {
+		f.FullName = strings.TrimSpace(r.Name.Formatted)
+		if f.FullName == "" {
+			f.FullName = strings.TrimSpace(r.Name.GivenName + " " + r.Name.FamilyName)
+		}
+	}
+	if f.FullName == "" {
+		f.FullName, _, _ = strings.Cut(f.Email, "@")
+	}
+	if utf8.RuneCountInString(f.FullName) > maxFullNameLength {
+		f.FullName = strings.TrimSpace(string([]rune(f.FullName)[:maxFullNameLength]))
+	}
+
+	if phone := strings.TrimSpace(primary(r.PhoneNumbers)); phone != "" {
+		if len(phone) > maxPhoneLength {
+			return f, fmt.Errorf("%w: phone numbers are limited to %d characters", ErrInvalidValue, maxPhoneLength)
+		}
+		f.Phone = &phone
+	}
+
+	if len(f.ExternalID) > maxExternalIDLength {
+		return f, fmt.Errorf("%w: externalId is limited to %d characters", ErrInvalidValue, maxExternalIDLength)
+	}
+	return f, nil
+}
+
+// primary returns the primary entry's value, or the first one's.
+func primary(values []multiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+func newUserResource(usr user.User, externalID string) userResource {
+	active := boolean(usr.Active)
+	resource := userResource{
+		Schemas:     []string{userSchema},
+		ID:          usr.ID.String(),
+		ExternalID:  externalID,
+		UserName:    usr.Email,
+		Name:        &name{Formatted: usr.FullName},
+		DisplayName: usr.FullName,
+		Emails:      []multiValue{{Value: usr.Email, Type: "work", Primary: true}},
+		UserType:    string(usr.UserType),
+		Active:      &active,
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      usr.CreatedAt,
+			LastModified: usr.UpdatedAt,
+		},
+	}
+	if usr.Phone != nil {
+		resource.PhoneNumbers = []multiValue{{Value: *usr.Phone, Type: "mobile", Primary: true}}
+	}
+	return resource
+}
+
+// newGroupResource describes a group, naming its members from names. Members
+// are left out when the identity provider excluded them.
+func newGroupResource(group groupaccess.GroupAccess, names map[uuid.UUID]string, withMembers bool) groupResource {
+	resource := groupResource{
+		Schemas:     []string{groupSchema},
+		ID:          group.ID.String(),
+		DisplayName: group.Name,
+		Meta: &meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+		},
+	}
+	if withMembers {
+		resource.Members = make([]multiValue, 0, len(group.Users))
+		for _, member := range group.Users {
+			id, err := uuid.Parse(member)
+			if err != nil {
+				continue
+			}
+			resource.Members = append(resource.Members, multiValue{Value: member, Display: names[id]})
+		}
+	}
+	return resource
+}
@@ -0,0 +1,40 @@
+package scim
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches token management and the SCIM 2.0 endpoints to the
+// router. The SCIM endpoints authenticate with the subscription's SCIM tokens
+// instead of user sessions and answer in SCIM's own format, so they are left
+// out of the OpenAPI document.
+// Middleware is passed as parameters to avoid import cycles
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdminInstructor []gin.HandlerFunc) {
+	tokens := router.Group("/subscriptions/:subscriptionId/scim/tokens")
+	tokens.GET("", append(acAdminInstructor, handler.ListTokens)...)
+	tokens.POST("", append(acAdminInstructor, handler.CreateToken)...)
+	tokens.DELETE("/:tokenId", append(acAdminInstructor, handler.DeleteToken)...)
+
+	scim := router.Group("/scim/v2", handler.Authenticate)
+	scim.GET("/ServiceProviderConfig", handler.ServiceProviderConfig)
+	scim.GET("/ResourceTypes", handler.ResourceTypes)
+
+	scim.GET("/Users", handler.ListUsers)
+	scim.POST("/Users", handler.CreateUser)
+	scim.GET("/Users/:id", handler.GetUser)
+	scim.PUT("/Users/:id", handler.ReplaceUser)
+	scim.PATCH("/Users/:id", handler.PatchUser)
+	scim.DELETE("/Users/:id", handler.DeleteUser)
+
+	scim.GET("/Groups", handler.ListGroups)
+	scim.POST("/Groups", handler.CreateGroup)
+	scim.GET("/Groups/:id", handler.GetGroup)
+	scim.PUT("/Groups/:id", handler.ReplaceGroup)
+	scim.PATCH("/Groups/:id", handler.PatchGroup)
+	scim.DELETE("/Groups/:id", handler.DeleteGroup)
+
+	openapi.Describe(handler.ListTokens, openapi.Spec{Response: []Token{}})
+	openapi.Describe(handler.CreateToken, openapi.Spec{Request: createTokenRequest{}, Response: createdToken{}})
+}
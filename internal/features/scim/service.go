@@ -0,0 +1,672 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// managedTypes are the accounts identity providers may provision. Staff above
+// assistants stay under the school's own control.
+var managedTypes = []types.UserType{types.UserTypeStudent, types.UserTypeAssistant}
+
+// maxGroupNameLength matches group_access.name.
+const maxGroupNameLength = 100
+
+func usersOf(db *gorm.DB, subscriptionID uuid.UUID) *gorm.DB {
+	return db.Model(&user.User{}).
+		Where("subscription_id = ? AND user_type IN ? AND anonymized_at IS NULL", subscriptionID, managedTypes)
+}
+
+// ListUsers returns a page of the subscription's managed users.
+func ListUsers(db *gorm.DB, subscriptionID uuid.UUID, f *filter, start, count int) ([]user.User, int64, error) {
+	query := usersOf(db, subscriptionID)
+	if f != nil {
+		switch f.Attribute {
+		case "username", "emails.value":
+			query = query.Where("LOWER(email) = ?", strings.ToLower(f.Value))
+		case "externalid":
+			query = query.Where("id IN (?)", db.Model(&Link{}).Select("user_id").
+				Where("subscription_id = ? AND external_id = ?", subscriptionID, f.Value))
+		case "id":
+			id, err := uuid.Parse(f.Value)
+			if err != nil {
+				return []user.User{}, 0, nil
+			}
+			query = query.Where("id = ?", id)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]user.User, 0)
+	if count == 0 {
+		return users, total, nil
+	}
+	err := query.Order("created_at ASC, id ASC").Offset(start - 1).Limit(count).Find(&users).Error
+	return users, total, err
+}
+
+// GetUser returns one of the subscription's managed users.
+func GetUser(db *gorm.DB, subscriptionID, id uuid.UUID) (user.User, error) {
+	var usr user.User
+	if err := usersOf(db, subscriptionID).First(&usr, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return usr, ErrUserNotFound
+		}
+		return usr, err
+	}
+	return usr, nil
+}
+
+// CreateUser provisions an account within the subscription's limits. The
+// identity provider vouched for the address, and the account gets a random
+// password its owner can reset or skip by signing in with SSO.
+func CreateUser(db *gorm.DB, sub subscription.Subscription, fields userFields) (user.User, error) {
+	var created user.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLimit(tx, sub, fields.UserType, nil); err != nil {
+			return err
+		}
+
+		password, err := user.GeneratePassword()
+		if err != nil {
+			return err
+		}
+		created, err = user.Create(tx, user.CreateInput{
+			SubscriptionID: &sub.ID,
+			FullName:       fields.FullName,
+			Email:          fields.Email,
+			Phone:          fields.Phone,
+			Password:       password,
+			UserType:       fields.UserType,
+			Active:         &fields.Active,
+		})
+		if err != nil {
+			if errors.Is(err, user.ErrEmailTaken) {
+				return ErrUserExists
+			}
+			return err
+		}
+
+		if err := tx.Model(&created).Update("email_verified", true).Error; err != nil {
+			return err
+		}
+		created.EmailVerified = true
+
+		return SetExternalID(tx, sub.ID, created.ID, fields.ExternalID)
+	})
+	return created, err
+}
+
+// ReplaceUser sets every field of a managed user. Deactivation revokes their
+// sessions; moving between students and assistants respects both limits.
+func ReplaceUser(db *gorm.DB, sub subscription.Subscription, id uuid.UUID, fields userFields) (user.User, error) {
+	var updated user.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := GetUser(tx, sub.ID, id)
+		if err != nil {
+			return err
+		}
+		if current.UserType != fields.UserType {
+			if err := checkLimit(tx, sub, fields.UserType, &id); err != nil {
+				return err
+			}
+		}
+
+		updated, err = user.Update(tx, id, user.UpdateInput{
+			FullName:      &fields.FullName,
+			Email:         &fields.Email,
+			Phone:         fields.Phone,
+			PhoneProvided: true,
+			UserType:      &fields.UserType,
+			Active:        &fields.Active,
+		})
+		if err != nil {
+			if errors.Is(err, user.ErrEmailTaken) {
+				return ErrUserExists
+			}
+			return err
+		}
+
+		return SetExternalID(tx, sub.ID, id, fields.ExternalID)
+	})
+	return updated, err
+}
+
+// DeactivateUser handles a deprovisioned user: the account is kept for its
+// history but deactivated and removed from every group.
+func DeactivateUser(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := GetUser(tx, subscriptionID, id); err != nil {
+			return err
+		}
+
+		active := false
+		if _, err := user.Update(tx, id, user.UpdateInput{Active: &active}); err != nil {
+			return err
+		}
+
+		var groupIDs []uuid.UUID
+		if err := tx.Model(&groupaccess.GroupAccess{}).
+			Where("subscription_id = ? AND ? = ANY(users)", subscriptionID, id).
+			Pluck("id", &groupIDs).Error; err != nil {
+			return err
+		}
+		for _, groupID := range groupIDs {
+			if err := groupaccess.RemoveUser(tx, subscriptionID, groupID, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkLimit keeps the subscription within its student or assistant limit,
+// not counting the user being changed.
+func checkLimit(tx *gorm.DB, sub subscription.Subscription, userType types.UserType, excludeID *uuid.UUID) error {
+	limit, limitErr := sub.SubscriptionPoints, ErrStudentLimit
+	if userType == types.UserTypeAssistant {
+		limit, limitErr = sub.AssistantsLimit, ErrAssistantLimit
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	query := tx.Model(&user.User{}).Where("subscription_id = ? AND user_type = ?", sub.ID, userType)
+	if excludeID != nil {
+		query = query.Where("id != ?", *excludeID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return limitErr
+	}
+	return nil
+}
+
+// applyUserPatch applies patch operations to the user's current resource.
+// Attributes the service does not store, such as the enterprise extension
+// identity providers send by default, are ignored rather than refused.
+func applyUserPatch(resource *userResource, operations []patchOperation) error {
+	var named, renamed bool
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return fmt.Errorf("%w: unknown operation %q", ErrInvalidSyntax, operation.Op)
+		}
+
+		if operation.Path == "" {
+			if op == "remove" {
+				return fmt.Errorf("%w: remove needs a path", ErrInvalidSyntax)
+			}
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return fmt.Errorf("%w: operations without a path need an object value", ErrInvalidValue)
+			}
+			for path, value := range values {
+				if err := setUserAttribute(resource, path, value, false, &named, &renamed); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := setUserAttribute(resource, operation.Path, operation.Value, op == "remove", &named, &renamed); err != nil {
+			return err
+		}
+	}
+
+	// A new given or family name replaces the display name unless the patch
+	// also set one
+	if renamed && !named {
+		resource.DisplayName = ""
+		resource.Name.Formatted = ""
+	}
+	return nil
+}
+
+func setUserAttribute(resource *userResource, path string, value json.RawMessage, remove bool, named, renamed *bool) error {
+	attribute := strings.ToLower(path)
+	if strings.HasPrefix(attribute, "name") && resource.Name == nil {
+		resource.Name = &name{}
+	}
+
+	switch {
+	case attribute == "active":
+		if remove {
+			return nil
+		}
+		var active boolean
+		if err := json.Unmarshal(value, &active); err != nil {
+			return fmt.Errorf("%w: active must be a boolean", ErrInvalidValue)
+		}
+		resource.Active = &active
+	case attribute == "username":
+		return setString(&resource.UserName, value, remove, false)
+	case attribute == "externalid":
+		return setString(&resource.ExternalID, value, remove, true)
+	case attribute == "usertype":
+		return setString(&resource.UserType, value, remove, true)
+	case attribute == "displayname":
+		*named = true
+		return setString(&resource.DisplayName, value, remove, true)
+	case attribute == "name.formatted":
+		*named = true
+		return setString(&resource.Name.Formatted, value, remove, true)
+	case attribute == "name.givenname":
+		*renamed = true
+		return setString(&resource.Name.GivenName, value, remove, true)
+	case attribute == "name.familyname":
+		*renamed = true
+		return setString(&resource.Name.FamilyName, value, remove, true)
+	case attribute == "name":
+		if remove {
+			return nil
+		}
+		var values name
+		if err := json.Unmarshal(value, &values); err != nil {
+			return fmt.Errorf("%w: name must be an object", ErrInvalidValue)
+		}
+		*resource.Name = values
+		if values.Formatted != "" {
+			*named = true
+		} else {
+			*renamed = true
+		}
+	case strings.HasPrefix(attribute, "emails"):
+		// Accounts always keep their email
+		if remove {
+			return nil
+		}
+		return setMultiValue(&resource.Emails, attribute != "emails", value)
+	case strings.HasPrefix(attribute, "phonenumbers"):
+		if remove {
+			resource.PhoneNumbers = nil
+			return nil
+		}
+		return setMultiValue(&resource.PhoneNumbers, attribute != "phonenumbers", value)
+	}
+	return nil
+}
+
+// setString sets a string attribute; only optional ones may be removed.
+func setString(target *string, value json.RawMessage, remove, optional bool) error {
+	if remove {
+		if optional {
+			*target = ""
+		}
+		return nil
+	}
+	if err := json.Unmarshal(value, target); err != nil {
+		return fmt.Errorf("%w: expected a string", ErrInvalidValue)
+	}
+	return nil
+}
+
+// setMultiValue replaces a multi-valued attribute, either with a list of
+// entries or, for sub-attribute paths such as emails[type eq "work"].value,
+// with a single primary value.
+func setMultiValue(target *[]multiValue, single bool, value json.RawMessage) error {
+	if single {
+		var entry string
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("%w: expected a string", ErrInvalidValue)
+		}
+		*target = []multiValue{{Value: entry, Primary: true}}
+		return nil
+	}
+
+	var entries []multiValue
+	if err := json.Unmarshal(value, &entries); err != nil {
+		return fmt.Errorf("%w: expected a list of values", ErrInvalidValue)
+	}
+	*target = entries
+	return nil
+}
+
+// ListGroups returns a page of the subscription's groups.
+func ListGroups(db *gorm.DB, subscriptionID uuid.UUID, f *filter, start, count int) ([]groupaccess.GroupAccess, int64, error) {
+	query := db.Model(&groupaccess.GroupAccess{}).Where("subscription_id = ?", subscriptionID)
+	if f != nil {
+		switch f.Attribute {
+		case "displayname":
+			query = query.Where("LOWER(name) = ?", strings.ToLower(f.Value))
+		case "id":
+			id, err := uuid.Parse(f.Value)
+			if err != nil {
+				return []groupaccess.GroupAccess{}, 0, nil
+			}
+			query = query.Where("id = ?", id)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	groups := make([]groupaccess.GroupAccess, 0)
+	if count == 0 {
+		return groups, total, nil
+	}
+	err := query.Order("created_at ASC, id ASC").Offset(start - 1).Limit(count).Find(&groups).Error
+	return groups, total, err
+}
+
+// GetGroup returns one of the subscription's groups.
+func GetGroup(db *gorm.DB, subscriptionID, id uuid.UUID) (groupaccess.GroupAccess, error) {
+	var group groupaccess.GroupAccess
+	if err := db.First(&group, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return group, ErrGroupNotFound
+		}
+		return group, err
+	}
+	return group, nil
+}
+
+// MemberNames returns the names of the groups' members.
+func MemberNames(db *gorm.DB, groups []groupaccess.GroupAccess) (map[uuid.UUID]string, error) {
+	ids := make([]uuid.UUID, 0)
+	for _, group := range groups {
+		for _, member := range group.Users {
+			if id, err := uuid.Parse(member); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	names := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var users []user.User
+	if err := db.Select("id", "full_name").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, usr := range users {
+		names[usr.ID] = usr.FullName
+	}
+	return names, nil
+}
+
+// CreateGroup creates a group with the given members. Members count against
+// the subscription's points once the school assigns courses to the group.
+func CreateGroup(db *gorm.DB, sub subscription.Subscription, displayName string, members []string) (groupaccess.GroupAccess, error) {
+	displayName, err := groupName(displayName)
+	if err != nil {
+		return groupaccess.GroupAccess{}, err
+	}
+
+	group := groupaccess.GroupAccess{SubscriptionID: sub.ID, Name: displayName}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		return syncMembers(tx, sub, group, members)
+	})
+	if err != nil {
+		return group, err
+	}
+	return GetGroup(db, sub.ID, group.ID)
+}
+
+// ReplaceGroup renames the group and sets its members. Members the identity
+// provider cannot see, such as instructors, are kept.
+func ReplaceGroup(db *gorm.DB, sub subscription.Subscription, id uuid.UUID, displayName string, members []string) (groupaccess.GroupAccess, error) {
+	displayName, err := groupName(displayName)
+	if err != nil {
+		return groupaccess.GroupAccess{}, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroup(tx, sub.ID, id)
+		if err != nil {
+			return err
+		}
+		if group.Name != displayName {
+			if err := tx.Model(&group).Update("name", displayName).Error; err != nil {
+				return err
+			}
+		}
+		return syncMembers(tx, sub, group, members)
+	})
+	if err != nil {
+		return groupaccess.GroupAccess{}, err
+	}
+	return GetGroup(db, sub.ID, id)
+}
+
+// PatchGroup applies patch operations to the group's name and members.
+func PatchGroup(db *gorm.DB, sub subscription.Subscription, id uuid.UUID, operations []patchOperation) (groupaccess.GroupAccess, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroup(tx, sub.ID, id)
+		if err != nil {
+			return err
+		}
+		members, err := visibleMembers(tx, sub.ID, group.Users)
+		if err != nil {
+			return err
+		}
+
+		displayName := group.Name
+		for _, operation := range operations {
+			if err := applyGroupOperation(operation, &displayName, &members); err != nil {
+				return err
+			}
+		}
+
+		displayName, err = groupName(displayName)
+		if err != nil {
+			return err
+		}
+		if group.Name != displayName {
+			if err := tx.Model(&group).Update("name", displayName).Error; err != nil {
+				return err
+			}
+		}
+		return syncMembers(tx, sub, group, members)
+	})
+	if err != nil {
+		return groupaccess.GroupAccess{}, err
+	}
+	return GetGroup(db, sub.ID, id)
+}
+
+func applyGroupOperation(operation patchOperation, displayName *string, members *[]string) error {
+	op := strings.ToLower(operation.Op)
+	path := strings.ToLower(operation.Path)
+
+	if id, ok := memberFilter(path); ok {
+		if op != "remove" {
+			return fmt.Errorf("%w: member filters only support remove", ErrInvalidSyntax)
+		}
+		*members = slices.DeleteFunc(*members, func(member string) bool { return member == id })
+		return nil
+	}
+
+	switch op {
+	case "add", "replace":
+		if path == "" {
+			var values struct {
+				DisplayName *string      `json:"displayName"`
+				Members     []multiValue `json:"members"`
+			}
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return fmt.Errorf("%w: operations without a path need an object value", ErrInvalidValue)
+			}
+			if values.DisplayName != nil {
+				*displayName = *values.DisplayName
+			}
+			if values.Members != nil {
+				*members = mergeMembers(*members, values.Members, op == "replace")
+			}
+			return nil
+		}
+
+		switch path {
+		case "displayname":
+			if err := json.Unmarshal(operation.Value, displayName); err != nil {
+				return fmt.Errorf("%w: displayName must be a string", ErrInvalidValue)
+			}
+		case "members":
+			var entries []multiValue
+			if err := json.Unmarshal(operation.Value, &entries); err != nil {
+				return fmt.Errorf("%w: members must be a list", ErrInvalidValue)
+			}
+			*members = mergeMembers(*members, entries, op == "replace")
+		}
+	case "remove":
+		if path != "members" {
+			return nil
+		}
+		if len(operation.Value) == 0 || string(operation.Value) == "null" {
+			*members = nil
+			return nil
+		}
+		var entries []multiValue
+		if err := json.Unmarshal(operation.Value, &entries); err != nil {
+			return fmt.Errorf("%w: members must be a list", ErrInvalidValue)
+		}
+		*members = slices.DeleteFunc(*members, func(member string) bool {
+			return slices.ContainsFunc(entries, func(entry multiValue) bool { return strings.EqualFold(entry.Value, member) })
+		})
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidSyntax, operation.Op)
+	}
+	return nil
+}
+
+func mergeMembers(members []string, entries []multiValue, replace bool) []string {
+	if replace {
+		members = nil
+	}
+	for _, entry := range entries {
+		value := strings.ToLower(entry.Value)
+		if !slices.Contains(members, value) {
+			members = append(members, value)
+		}
+	}
+	return members
+}
+
+// DeleteGroup removes one of the subscription's groups.
+func DeleteGroup(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	result := db.Delete(&groupaccess.GroupAccess{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+func groupName(displayName string) (string, error) {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" || utf8.RuneCountInString(displayName) > maxGroupNameLength {
+		return "", fmt.Errorf("%w: displayName is required and limited to %d characters", ErrInvalidValue, maxGroupNameLength)
+	}
+	return displayName, nil
+}
+
+func lockGroup(tx *gorm.DB, subscriptionID, id uuid.UUID) (groupaccess.GroupAccess, error) {
+	var group groupaccess.GroupAccess
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&group, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return group, ErrGroupNotFound
+		}
+		return group, err
+	}
+	return group, nil
+}
+
+// visibleMembers returns the group's members the identity provider manages.
+func visibleMembers(tx *gorm.DB, subscriptionID uuid.UUID, members []string) ([]string, error) {
+	ids, err := visibleIDs(tx, subscriptionID, members)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]string, 0, len(ids))
+	for _, id := range ids {
+		visible = append(visible, id.String())
+	}
+	return visible, nil
+}
+
+func visibleIDs(tx *gorm.DB, subscriptionID uuid.UUID, members []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(members))
+	if len(members) == 0 {
+		return ids, nil
+	}
+	err := usersOf(tx, subscriptionID).Where("id IN ?", members).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// syncMembers brings the group's managed members in line with the wanted
+// ones, adding through groupaccess so the points limit holds.
+func syncMembers(tx *gorm.DB, sub subscription.Subscription, group groupaccess.GroupAccess, wanted []string) error {
+	ids := make([]uuid.UUID, 0, len(wanted))
+	for _, member := range wanted {
+		id, err := uuid.Parse(member)
+		if err != nil {
+			return fmt.Errorf("%w: member %q is not a user id", ErrInvalidValue, member)
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	wantedIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		wantedIDs = append(wantedIDs, id.String())
+	}
+	known, err := visibleIDs(tx, sub.ID, wantedIDs)
+	if err != nil {
+		return err
+	}
+	if len(known) != len(ids) {
+		return fmt.Errorf("%w: members must be users of this subscription", ErrInvalidValue)
+	}
+
+	current, err := visibleIDs(tx, sub.ID, group.Users)
+	if err != nil {
+		return err
+	}
+	for _, id := range current {
+		if slices.Contains(ids, id) {
+			continue
+		}
+		if err := groupaccess.RemoveUser(tx, sub.ID, group.ID, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if slices.Contains(current, id) {
+			continue
+		}
+		if err := groupaccess.AddUser(tx, sub, group.ID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
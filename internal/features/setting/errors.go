@@ -0,0 +1,18 @@
+package setting
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrUnknownSetting = errors.New("unknown setting")
+	ErrOutOfRange     = errors.New("setting value out of range")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrUnknownSetting, Status: http.StatusNotFound, Code: "SETTING_NOT_FOUND", Message: "Setting not found."},
+}
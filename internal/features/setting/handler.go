@@ -0,0 +1,112 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Handler serves the runtime settings admin endpoints.
+type Handler struct {
+	logger  *slog.Logger
+	service *Service
+	reload  func() ([]config.Change, error)
+}
+
+// NewHandler constructs a settings handler.
+func NewHandler(logger *slog.Logger, service *Service) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+// UseReloader enables reloading the configuration through the API, for
+// deployments that cannot signal the process.
+func (h *Handler) UseReloader(reload func() ([]config.Change, error)) {
+	h.reload = reload
+}
+
+// List returns every setting with its default, range and effective value.
+// GET /settings
+func (h *Handler) List(c *gin.Context) {
+	response.Success(c, http.StatusOK, h.service.Values(), "", nil)
+}
+
+type updateRequest struct {
+	Value *int `json:"value" binding:"required"`
+}
+
+// Update overrides a setting; other instances pick it up within 30 seconds.
+// PUT /settings/:key
+func (h *Handler) Update(c *gin.Context) {
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid setting payload") {
+		return
+	}
+
+	value, err := h.service.Set(c.Request.Context(), c.Param("key"), *req.Value, usr.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to update setting")
+		return
+	}
+
+	h.logger.Info("setting updated", "key", value.Key, "value", value.Value, "userId", usr.ID)
+	response.Success(c, http.StatusOK, value, "", nil)
+}
+
+// Reset removes a setting's override, restoring its default.
+// DELETE /settings/:key
+func (h *Handler) Reset(c *gin.Context) {
+	value, err := h.service.Reset(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		h.respondError(c, err, "failed to reset setting")
+		return
+	}
+
+	response.Success(c, http.StatusOK, value, "", nil)
+}
+
+// Reload reloads the configuration like SIGHUP does and returns what changed.
+// Changes that need a restart are listed with applied false.
+// POST /settings/reload
+func (h *Handler) Reload(c *gin.Context) {
+	if h.reload == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Configuration reload is not available.", nil)
+		return
+	}
+
+	changes, err := h.reload()
+	if err != nil {
+		// The watcher has logged the problems already
+		response.Error(c, http.StatusUnprocessableEntity, "Configuration is invalid; the current configuration stays in effect.", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, changes, "", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, ErrOutOfRange) {
+		def, _ := h.service.Definition(c.Param("key"))
+		request.RespondInvalid(c, validation.FieldError{
+			Field:   "value",
+			Rule:    "range",
+			Message: fmt.Sprintf("must be between %d and %d", def.Min, def.Max),
+		})
+		return
+	}
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,91 @@
+package setting
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Keys of the runtime-tunable settings.
+const (
+	KeyMaxConcurrentStreamsPerUser = "streaming.maxConcurrentStreamsPerUser"
+	KeyMaxViewersPerStream         = "streaming.maxViewersPerStream"
+	KeyMaxTotalConcurrentStreams   = "streaming.maxTotalConcurrentStreams"
+	KeyStreamStartCooldownSeconds  = "streaming.streamStartCooldownSeconds"
+
+	KeyDefaultWatchLimit           = "watch.defaultWatchLimit"
+	KeyDefaultWatchIntervalMinutes = "watch.defaultWatchIntervalMinutes"
+
+	KeyRateLimitIPPerMinute           = "rateLimit.ipPerMinute"
+	KeyRateLimitUserPerMinute         = "rateLimit.userPerMinute"
+	KeyRateLimitSubscriptionPerMinute = "rateLimit.subscriptionPerMinute"
+	KeyRateLimitAuthPerMinute         = "rateLimit.authPerMinute"
+)
+
+// Setting is a runtime override of a tunable value. Keys without a row use the
+// default of their Definition.
+type Setting struct {
+	Key       string     `gorm:"type:varchar(100);primaryKey;column:key" json:"key"`
+	Value     int        `gorm:"type:int;not null;column:value" json:"value"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid;column:updated_by" json:"updatedBy,omitempty"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+// TableName overrides the default table name.
+func (Setting) TableName() string { return "settings" }
+
+// Definition describes a tunable setting and the values it accepts.
+type Definition struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Default     int    `json:"default"`
+	Min         int    `json:"min"`
+	Max         int    `json:"max"`
+}
+
+// Validate reports whether value is accepted for the setting.
+func (d Definition) Validate(value int) error {
+	if value < d.Min || value > d.Max {
+		return ErrOutOfRange
+	}
+	return nil
+}
+
+// Value is the effective value of a setting. UpdatedBy and UpdatedAt are set
+// when it is overridden.
+type Value struct {
+	Definition
+	Value      int        `json:"value"`
+	Overridden bool       `json:"overridden"`
+	UpdatedBy  *uuid.UUID `json:"updatedBy,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+// List returns every stored override.
+func List(db *gorm.DB) ([]Setting, error) {
+	var settings []Setting
+	if err := db.Order("key").Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Save creates or replaces the override of key.
+func Save(db *gorm.DB, key string, value int, updatedBy *uuid.UUID) (Setting, error) {
+	setting := Setting{Key: key, Value: value, UpdatedBy: updatedBy, UpdatedAt: time.Now().UTC()}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+	}).Create(&setting).Error
+	if err != nil {
+		return Setting{}, err
+	}
+	return setting, nil
+}
+
+// Delete removes the override of key, restoring its default.
+func Delete(db *gorm.DB, key string) error {
+	return db.Where("key = ?", key).Delete(&Setting{}).Error
+}
@@ -0,0 +1,22 @@
+package setting
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the runtime settings endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, superadminOnly []gin.HandlerFunc) {
+	settings := router.Group("/settings")
+	settings.GET("", append(superadminOnly, handler.List)...)
+	settings.POST("/reload", append(superadminOnly, handler.Reload)...)
+	settings.PUT("/:key", append(superadminOnly, handler.Update)...)
+	settings.DELETE("/:key", append(superadminOnly, handler.Reset)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Value{}})
+	openapi.Describe(handler.Reload, openapi.Spec{Response: []config.Change{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Value{}})
+	openapi.Describe(handler.Reset, openapi.Spec{Response: Value{}})
+}
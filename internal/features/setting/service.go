@@ -0,0 +1,196 @@
+package setting
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+)
+
+// overridesTTL bounds how long overrides are served from memory, so changes
+// made through another instance apply within it; saves through the service
+// invalidate immediately.
+const overridesTTL = 30 * time.Second
+
+// Service serves the effective settings to the code paths they tune without a
+// query per read.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+
+	// defaultsMu guards the defaults, which follow configuration reloads
+	defaultsMu  sync.RWMutex
+	definitions []Definition
+	rateLimits  config.RateLimitConfig
+
+	mu        sync.Mutex
+	overrides map[string]Setting
+	loadedAt  time.Time
+}
+
+// NewService constructs the settings service. Rate limit and streaming
+// settings default to the configured quotas and limits.
+func NewService(db *gorm.DB, logger *slog.Logger, rateLimits config.RateLimitConfig, streaming config.StreamingConfig) *Service {
+	s := &Service{db: db, logger: logger}
+	s.Configure(rateLimits, streaming)
+	return s
+}
+
+// Configure replaces the configured defaults, such as after a configuration
+// reload. Overrides keep precedence over them.
+func (s *Service) Configure(rateLimits config.RateLimitConfig, streaming config.StreamingConfig) {
+	s.defaultsMu.Lock()
+	s.definitions = definitions(rateLimits, streaming)
+	s.rateLimits = rateLimits
+	s.defaultsMu.Unlock()
+}
+
+func definitions(rateLimits config.RateLimitConfig, streaming config.StreamingConfig) []Definition {
+	return []Definition{
+		{Key: KeyMaxConcurrentStreamsPerUser, Description: "Live streams one user may host at once", Default: streaming.MaxStreamsPerUser, Min: 1, Max: 10},
+		{Key: KeyMaxViewersPerStream, Description: "Viewers admitted to one live stream", Default: streaming.MaxViewersPerStream, Min: 1, Max: 10000},
+		{Key: KeyMaxTotalConcurrentStreams, Description: "Live streams running on the platform at once", Default: streaming.MaxTotalStreams, Min: 1, Max: 1000},
+		{Key: KeyStreamStartCooldownSeconds, Description: "Seconds a user waits between starting streams", Default: streaming.StartCooldownSeconds, Min: 0, Max: 3600},
+		{Key: KeyDefaultWatchLimit, Description: "Watch limit of new subscriptions that do not set one", Default: 2, Min: 0, Max: 100},
+		{Key: KeyDefaultWatchIntervalMinutes, Description: "Watch interval in minutes of new subscriptions that do not set one", Default: 240, Min: 1, Max: 525600},
+		{Key: KeyRateLimitIPPerMinute, Description: "Requests per minute per client IP without an access token", Default: rateLimits.IPPerMinute, Min: 1, Max: 100000},
+		{Key: KeyRateLimitUserPerMinute, Description: "Requests per minute per authenticated user", Default: rateLimits.UserPerMinute, Min: 1, Max: 100000},
+		{Key: KeyRateLimitSubscriptionPerMinute, Description: "Requests per minute per subscription without a plan quota", Default: rateLimits.SubscriptionPerMinute, Min: 1, Max: 100000},
+		{Key: KeyRateLimitAuthPerMinute, Description: "Requests per minute per client IP to login and sign-up endpoints", Default: rateLimits.AuthPerMinute, Min: 1, Max: 10000},
+	}
+}
+
+// Definition returns the definition of key.
+func (s *Service) Definition(key string) (Definition, bool) {
+	for _, def := range s.currentDefinitions() {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Int returns the effective value of key, or 0 for unknown keys.
+func (s *Service) Int(key string) int {
+	def, ok := s.Definition(key)
+	if !ok {
+		return 0
+	}
+	if override, ok := s.load()[key]; ok {
+		return override.Value
+	}
+	return def.Default
+}
+
+// Values returns every setting with its effective value.
+func (s *Service) Values() []Value {
+	overrides := s.load()
+	defs := s.currentDefinitions()
+	values := make([]Value, 0, len(defs))
+	for _, def := range defs {
+		values = append(values, s.value(def, overrides))
+	}
+	return values
+}
+
+func (s *Service) value(def Definition, overrides map[string]Setting) Value {
+	value := Value{Definition: def, Value: def.Default}
+	if override, ok := overrides[def.Key]; ok {
+		updatedAt := override.UpdatedAt
+		value.Value = override.Value
+		value.Overridden = true
+		value.UpdatedBy = override.UpdatedBy
+		value.UpdatedAt = &updatedAt
+	}
+	return value
+}
+
+// Set validates and stores an override of key.
+func (s *Service) Set(ctx context.Context, key string, value int, updatedBy uuid.UUID) (Value, error) {
+	def, ok := s.Definition(key)
+	if !ok {
+		return Value{}, ErrUnknownSetting
+	}
+	if err := def.Validate(value); err != nil {
+		return Value{}, err
+	}
+
+	if _, err := Save(s.db.WithContext(ctx), key, value, &updatedBy); err != nil {
+		return Value{}, err
+	}
+	s.invalidate()
+	return s.value(def, s.load()), nil
+}
+
+// Reset removes the override of key, restoring its default.
+func (s *Service) Reset(ctx context.Context, key string) (Value, error) {
+	def, ok := s.Definition(key)
+	if !ok {
+		return Value{}, ErrUnknownSetting
+	}
+
+	if err := Delete(s.db.WithContext(ctx), key); err != nil {
+		return Value{}, err
+	}
+	s.invalidate()
+	return s.value(def, s.load()), nil
+}
+
+// RateLimits returns the configured quotas with their overrides applied.
+func (s *Service) RateLimits() config.RateLimitConfig {
+	s.defaultsMu.RLock()
+	limits := s.rateLimits
+	s.defaultsMu.RUnlock()
+	limits.IPPerMinute = s.Int(KeyRateLimitIPPerMinute)
+	limits.UserPerMinute = s.Int(KeyRateLimitUserPerMinute)
+	limits.SubscriptionPerMinute = s.Int(KeyRateLimitSubscriptionPerMinute)
+	limits.AuthPerMinute = s.Int(KeyRateLimitAuthPerMinute)
+	return limits
+}
+
+func (s *Service) currentDefinitions() []Definition {
+	s.defaultsMu.RLock()
+	defer s.defaultsMu.RUnlock()
+	return s.definitions
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// load returns the stored overrides, reloading them once they are older than
+// overridesTTL. While the database is unreachable the last loaded overrides
+// stay in effect.
+func (s *Service) load() map[string]Setting {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.overrides != nil && time.Since(s.loadedAt) < overridesTTL {
+		return s.overrides
+	}
+
+	settings, err := List(s.db)
+	if err != nil {
+		s.logger.Warn("failed to load settings", "error", err)
+		s.loadedAt = time.Now()
+		if s.overrides == nil {
+			return map[string]Setting{}
+		}
+		return s.overrides
+	}
+
+	overrides := make(map[string]Setting, len(settings))
+	for _, setting := range settings {
+		overrides[setting.Key] = setting
+	}
+	s.overrides = overrides
+	s.loadedAt = time.Now()
+	return overrides
+}
@@ -0,0 +1,60 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/auth"
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrNotConfigured      = errors.New("single sign-on is not configured")
+	ErrProviderNotFound   = errors.New("sso provider not found")
+	ErrProviderLimit      = errors.New("sso provider limit reached")
+	ErrUnknownKind        = errors.New("unknown sso provider kind")
+	ErrInvalidIssuer      = errors.New("invalid sso issuer")
+	ErrInvalidTenant      = errors.New("invalid microsoft tenant")
+	ErrInvalidDomain      = errors.New("invalid allowed domain")
+	ErrInvalidMetadata    = errors.New("invalid saml metadata")
+	ErrGroupNotFound      = groupaccess.ErrGroupNotFound
+	ErrDiscovery          = errors.New("sso provider discovery failed")
+	ErrInvalidState       = errors.New("invalid or expired sso state")
+	ErrInvalidCode        = errors.New("invalid or expired sso code")
+	ErrEmailMissing       = errors.New("provider did not return a verified email")
+	ErrDomainNotAllowed   = errors.New("email domain is not allowed")
+	ErrNoAccount          = errors.New("no account for this email")
+	ErrAccountConflict    = errors.New("email belongs to an account outside the subscription")
+	ErrIdentityConflict   = errors.New("account is already linked to another identity")
+	ErrIdentityNotFound   = errors.New("sso identity not found")
+	ErrStudentLimit       = errors.New("student limit reached for this subscription")
+	ErrInvalidPassword    = errors.New("invalid password")
+	ErrSubscriptionClosed = errors.New("subscription is inactive")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrNotConfigured, Status: http.StatusServiceUnavailable, Code: "SSO_NOT_CONFIGURED", Message: "Single sign-on is not configured."},
+	{Err: ErrProviderNotFound, Status: http.StatusNotFound, Code: "SSO_PROVIDER_NOT_FOUND", Message: "SSO provider not found."},
+	{Err: ErrProviderLimit, Status: http.StatusConflict, Code: "SSO_PROVIDER_LIMIT", Message: "This subscription already has the maximum number of SSO providers."},
+	{Err: ErrUnknownKind, Status: http.StatusBadRequest, Code: "UNKNOWN_SSO_KIND", Message: "Provider kind must be google, microsoft, oidc or saml."},
+	{Err: ErrInvalidIssuer, Status: http.StatusBadRequest, Code: "INVALID_SSO_ISSUER", Message: "Issuer must be a public https URL."},
+	{Err: ErrInvalidTenant, Status: http.StatusBadRequest, Code: "INVALID_SSO_TENANT", Message: "Microsoft providers need a tenant ID or domain; shared tenants are not supported."},
+	{Err: ErrInvalidDomain, Status: http.StatusBadRequest, Code: "INVALID_SSO_DOMAIN", Message: "Allowed domains must be domain names such as school.edu."},
+	{Err: ErrInvalidMetadata, Status: http.StatusBadRequest, Code: "INVALID_SSO_METADATA", Message: "SAML metadata is invalid. It must describe one identity provider with an https HTTP-Redirect sign-on URL and an RSA signing certificate."},
+	{Err: ErrGroupNotFound, Status: http.StatusBadRequest, Code: "GROUP_NOT_FOUND", Message: "Default group not found."},
+	{Err: ErrDiscovery, Status: http.StatusBadGateway, Code: "SSO_DISCOVERY_FAILED", Message: "Could not reach the SSO provider."},
+	{Err: ErrInvalidCode, Status: http.StatusUnauthorized, Code: "INVALID_SSO_CODE", Message: "Sign-in code is invalid or expired."},
+	{Err: ErrInvalidPassword, Status: http.StatusUnauthorized, Code: "INVALID_PASSWORD", Message: "Invalid password."},
+	{Err: ErrIdentityConflict, Status: http.StatusConflict, Code: "SSO_IDENTITY_CONFLICT", Message: "This account is already linked to another identity of the provider."},
+	{Err: ErrIdentityNotFound, Status: http.StatusNotFound, Code: "SSO_IDENTITY_NOT_FOUND", Message: "Identity not found."},
+	{Err: subscription.ErrSubscriptionNotFound, Status: http.StatusNotFound, Code: "SUBSCRIPTION_NOT_FOUND", Message: "Subscription not found."},
+	{Err: user.ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found."},
+	{Err: auth.ErrDeviceRequired, Status: http.StatusBadRequest, Code: "DEVICE_REQUIRED", Message: "Device ID is required for this subscription"},
+	{Err: auth.ErrDeviceMismatch, Status: http.StatusForbidden, Code: "DEVICE_MISMATCH", Message: "Device mismatch detected. Please contact support for device reset"},
+	{Err: auth.ErrInactiveAccount, Status: http.StatusForbidden, Code: "ACCOUNT_INACTIVE", Message: "Your account is inactive. Please contact support"},
+	{Err: auth.ErrInactiveSubscription, Status: http.StatusForbidden, Code: "SUBSCRIPTION_INACTIVE", Message: "Your subscription is inactive. Please contact support"},
+}
@@ -0,0 +1,227 @@
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	// maxFullNameLength matches users.full_name.
+	maxFullNameLength = 30
+	// maxPhoneLength matches users.phone.
+	maxPhoneLength = 20
+)
+
+// Outcome is what a provider's callback resolved to.
+type Outcome struct {
+	Purpose Purpose
+	UserID  uuid.UUID
+	// Provisioned is set when the sign-in created the account.
+	Provisioned bool
+}
+
+// ResolveClaims decides what a sign-in with the provider does:
+//   - a linked identity signs its user in;
+//   - a verified email of an allowed domain matching an account of the
+//     subscription asks its owner to confirm the link;
+//   - an unknown email creates a student when the provider auto-provisions,
+//     links the identity and signs them in.
+func ResolveClaims(db *gorm.DB, provider Provider, claims Claims, now time.Time) (Outcome, string, error) {
+	email := claims.VerifiedEmail(provider.Kind)
+
+	identity, err := FindIdentity(db, provider.ID, claims.Subject)
+	if err != nil {
+		return Outcome{}, email, err
+	}
+	if identity != nil {
+		if err := Touch(db, identity, email, now); err != nil {
+			return Outcome{}, email, err
+		}
+		return Outcome{Purpose: PurposeLogin, UserID: identity.UserID}, email, nil
+	}
+
+	if email == "" {
+		return Outcome{}, email, ErrEmailMissing
+	}
+	if !provider.AllowsEmail(email) {
+		return Outcome{}, email, ErrDomainNotAllowed
+	}
+
+	existing, err := user.GetByEmail(db, email)
+	switch {
+	case err == nil:
+		if existing.SubscriptionID == nil || *existing.SubscriptionID != provider.SubscriptionID {
+			return Outcome{}, email, ErrAccountConflict
+		}
+		var linked int64
+		if err := db.Model(&Identity{}).
+			Where("provider_id = ? AND user_id = ?", provider.ID, existing.ID).
+			Count(&linked).Error; err != nil {
+			return Outcome{}, email, err
+		}
+		if linked > 0 {
+			return Outcome{}, email, ErrIdentityConflict
+		}
+		return Outcome{Purpose: PurposeLink, UserID: existing.ID}, email, nil
+	case !errors.Is(err, user.ErrUserNotFound):
+		return Outcome{}, email, err
+	}
+
+	if !provider.AutoProvision {
+		return Outcome{}, email, ErrNoAccount
+	}
+
+	created, err := provision(db, provider, claims, email, now)
+	if err != nil {
+		return Outcome{}, email, err
+	}
+	return Outcome{Purpose: PurposeLogin, UserID: created.ID, Provisioned: true}, email, nil
+}
+
+// provision creates a student for the identity within the subscription's
+// student limit, adds them to the provider's default group and links the
+// identity. The account gets a random password they can reset later.
+func provision(db *gorm.DB, provider Provider, claims Claims, email string, now time.Time) (user.User, error) {
+	var created user.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		sub, err := subscription.Get(tx, provider.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if !sub.Active {
+			return ErrSubscriptionClosed
+		}
+
+		if sub.SubscriptionPoints > 0 {
+			var students int64
+			if err := tx.Model(&user.User{}).
+				Where("subscription_id = ? AND user_type = ?", sub.ID, types.UserTypeStudent).
+				Count(&students).Error; err != nil {
+				return err
+			}
+			if students >= int64(sub.SubscriptionPoints) {
+				return ErrStudentLimit
+			}
+		}
+
+		password, err := user.GeneratePassword()
+		if err != nil {
+			return err
+		}
+		created, err = user.Create(tx, user.CreateInput{
+			SubscriptionID: &sub.ID,
+			FullName:       displayName(claims, email),
+			Email:          email,
+			Phone:          phoneNumber(claims),
+			Password:       password,
+			UserType:       types.UserTypeStudent,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The provider vouched for the address
+		if err := tx.Model(&created).Update("email_verified", true).Error; err != nil {
+			return err
+		}
+
+		if provider.DefaultGroupID != nil {
+			if err := groupaccess.AddUser(tx, sub, *provider.DefaultGroupID, created.ID); err != nil {
+				return err
+			}
+		}
+
+		return Link(tx, &Identity{
+			ProviderID:  provider.ID,
+			UserID:      created.ID,
+			Subject:     claims.Subject,
+			Email:       email,
+			LastLoginAt: &now,
+		})
+	})
+	return created, err
+}
+
+// ConfirmLink links the identity of a link sign-in to its account once the
+// owner proved it with their password.
+func ConfirmLink(db *gorm.DB, login Login, password string, now time.Time) (user.User, error) {
+	if login.UserID == nil || login.Subject == nil || login.Email == nil {
+		return user.User{}, ErrInvalidCode
+	}
+
+	owner, err := user.Get(db, *login.UserID)
+	if err != nil {
+		return user.User{}, err
+	}
+	if !owner.ComparePassword(password) {
+		return user.User{}, ErrInvalidPassword
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Redeem(tx, *login.Code, PurposeLink, now); err != nil {
+			return err
+		}
+		return Link(tx, &Identity{
+			ProviderID:  login.ProviderID,
+			UserID:      owner.ID,
+			Subject:     *login.Subject,
+			Email:       *login.Email,
+			LastLoginAt: &now,
+		})
+	})
+	return owner, err
+}
+
+// LoadUser loads a user with their subscription, as auth.SignIn expects.
+func LoadUser(db *gorm.DB, id uuid.UUID) (user.User, error) {
+	var usr user.User
+	if err := db.Preload("Subscription").First(&usr, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return usr, user.ErrUserNotFound
+		}
+		return usr, err
+	}
+	return usr, nil
+}
+
+// displayName prefers the provider's name, then the email's local part.
+func displayName(claims Claims, email string) string {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	if utf8.RuneCountInString(name) > maxFullNameLength {
+		name = strings.TrimSpace(string([]rune(name)[:maxFullNameLength]))
+	}
+	return name
+}
+
+// phoneNumber returns the provider's phone number if it fits users.phone.
+func phoneNumber(claims Claims) *string {
+	phone := strings.TrimSpace(claims.Phone)
+	if phone == "" || len(phone) > maxPhoneLength {
+		return nil
+	}
+	return &phone
+}
+
+// randomToken returns a random hex string of 2*size characters.
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,767 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/auth"
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/saml"
+)
+
+// callbackPath is the frontend page the provider's callback sends users to
+// with ?code=&action=login|link, or ?error= when sign-in failed.
+const callbackPath = "/sso/callback"
+
+// Handler processes single sign-on HTTP requests.
+type Handler struct {
+	db          *gorm.DB
+	logger      *slog.Logger
+	cfg         *config.Config
+	client      *Client
+	samlURL     string
+	frontendURL string
+	queryCache  *cache.Store
+}
+
+// NewHandler constructs a single sign-on handler instance. Without a callback
+// URL in the configuration providers can be managed but nobody can sign in
+// with OAuth providers; without a SAML URL the same holds for SAML providers.
+func NewHandler(db *gorm.DB, logger *slog.Logger, cfg *config.Config) *Handler {
+	h := &Handler{
+		db:          db,
+		logger:      logger,
+		cfg:         cfg,
+		samlURL:     strings.TrimRight(cfg.SSO.SAMLURL, "/"),
+		frontendURL: strings.TrimRight(cfg.Email.FrontendURL, "/"),
+	}
+	if cfg.SSO.RedirectURL != "" {
+		h.client = NewClient(cfg.SSO.RedirectURL)
+	}
+	return h
+}
+
+// UseCache sets the query cache whose dashboard counts provisioned students invalidate.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+type createRequest struct {
+	Kind           Kind          `json:"kind" binding:"required,oneof=google microsoft oidc saml"`
+	Name           string        `json:"name" binding:"required,notblank,max=100"`
+	Issuer         string        `json:"issuer" binding:"max=500"` // Microsoft tenant or OIDC issuer URL
+	ClientID       string        `json:"clientId" binding:"required_unless=Kind saml,max=255"`
+	ClientSecret   string        `json:"clientSecret" binding:"required_unless=Kind saml,max=1000"`
+	Metadata       string        `json:"metadata" binding:"required_if=Kind saml,max=524288"` // SAML IdP metadata XML
+	AttributeMap   *AttributeMap `json:"attributeMap"`
+	AllowedDomains []string      `json:"allowedDomains" binding:"max=50"`
+	AutoProvision  bool          `json:"autoProvision"`
+	DefaultGroupID *uuid.UUID    `json:"defaultGroupId"`
+}
+
+type updateRequest struct {
+	Name           *string       `json:"name" binding:"omitnil,notblank,max=100"`
+	Issuer         *string       `json:"issuer" binding:"omitnil,max=500"`
+	ClientID       *string       `json:"clientId" binding:"omitnil,notblank,max=255"`
+	ClientSecret   *string       `json:"clientSecret" binding:"omitnil,notblank,max=1000"`
+	Metadata       *string       `json:"metadata" binding:"omitnil,notblank,max=524288"`
+	AttributeMap   *AttributeMap `json:"attributeMap"`
+	AllowedDomains *[]string     `json:"allowedDomains" binding:"omitnil,max=50"`
+	AutoProvision  *bool         `json:"autoProvision"`
+	DefaultGroupID *uuid.UUID    `json:"defaultGroupId"`
+	ClearGroup     bool          `json:"clearDefaultGroup"`
+	IsActive       *bool         `json:"isActive"`
+}
+
+type exchangeRequest struct {
+	Code     string  `json:"code" binding:"required"`
+	DeviceID *string `json:"deviceId"`
+}
+
+type linkRequest struct {
+	Code     string  `json:"code" binding:"required"`
+	Password string  `json:"password" binding:"required"`
+	DeviceID *string `json:"deviceId"`
+}
+
+type linkPreview struct {
+	Email        string `json:"email"`
+	ProviderName string `json:"providerName"`
+	FullName     string `json:"fullName"`
+}
+
+// List returns the subscription's providers.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	providers, err := ListProviders(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list sso providers", err)
+		return
+	}
+	for i := range providers {
+		h.describe(&providers[i])
+	}
+
+	response.Success(c, http.StatusOK, providers, "", nil)
+}
+
+// Get returns one provider.
+func (h *Handler) Get(c *gin.Context) {
+	provider, ok := h.resolveProvider(c)
+	if !ok {
+		return
+	}
+	h.describe(&provider)
+
+	response.Success(c, http.StatusOK, provider, "", nil)
+}
+
+// Create configures a provider for the subscription.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req createRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid sso provider payload") {
+		return
+	}
+
+	domains, err := normalizeDomains(req.AllowedDomains)
+	if err != nil {
+		h.respondError(c, err, "invalid sso provider")
+		return
+	}
+	if err := h.checkGroup(subscriptionID, req.DefaultGroupID); err != nil {
+		h.respondError(c, err, "invalid sso provider")
+		return
+	}
+
+	provider := Provider{
+		SubscriptionID: subscriptionID,
+		Kind:           req.Kind,
+		Name:           strings.TrimSpace(req.Name),
+		AllowedDomains: domains,
+		AutoProvision:  req.AutoProvision,
+		DefaultGroupID: req.DefaultGroupID,
+		Active:         true,
+	}
+	if req.Kind == KindSAML {
+		if err := ApplyMetadata(&provider, req.Metadata); err != nil {
+			h.respondError(c, err, "invalid sso provider")
+			return
+		}
+		provider.AttributeMap = normalizeAttributes(req.AttributeMap)
+	} else {
+		issuer, err := IssuerFor(req.Kind, req.Issuer)
+		if err != nil {
+			h.respondError(c, err, "invalid sso provider")
+			return
+		}
+		provider.Issuer = issuer
+		provider.ClientID = strings.TrimSpace(req.ClientID)
+		provider.ClientSecret = strings.TrimSpace(req.ClientSecret)
+	}
+
+	if err := CreateProvider(h.db, &provider); err != nil {
+		h.respondError(c, err, "failed to create sso provider")
+		return
+	}
+	h.describe(&provider)
+
+	response.Created(c, provider, "")
+}
+
+// Update changes a provider. The kind is fixed once created. SAML providers
+// take fresh metadata when their IdP rolls its signing certificate.
+func (h *Handler) Update(c *gin.Context) {
+	provider, ok := h.resolveProvider(c)
+	if !ok {
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid sso provider payload") {
+		return
+	}
+
+	if req.Name != nil {
+		provider.Name = strings.TrimSpace(*req.Name)
+	}
+	if provider.Kind == KindSAML {
+		if req.Metadata != nil {
+			if err := ApplyMetadata(&provider, *req.Metadata); err != nil {
+				h.respondError(c, err, "invalid sso provider")
+				return
+			}
+		}
+		if req.AttributeMap != nil {
+			provider.AttributeMap = normalizeAttributes(req.AttributeMap)
+		}
+	} else {
+		if req.Issuer != nil && provider.Kind != KindGoogle {
+			issuer, err := IssuerFor(provider.Kind, *req.Issuer)
+			if err != nil {
+				h.respondError(c, err, "invalid sso provider")
+				return
+			}
+			provider.Issuer = issuer
+		}
+		if req.ClientID != nil {
+			provider.ClientID = strings.TrimSpace(*req.ClientID)
+		}
+		if req.ClientSecret != nil {
+			provider.ClientSecret = strings.TrimSpace(*req.ClientSecret)
+		}
+	}
+	if req.AllowedDomains != nil {
+		domains, err := normalizeDomains(*req.AllowedDomains)
+		if err != nil {
+			h.respondError(c, err, "invalid sso provider")
+			return
+		}
+		provider.AllowedDomains = domains
+	}
+	if req.AutoProvision != nil {
+		provider.AutoProvision = *req.AutoProvision
+	}
+	switch {
+	case req.ClearGroup:
+		provider.DefaultGroupID = nil
+	case req.DefaultGroupID != nil:
+		if err := h.checkGroup(provider.SubscriptionID, req.DefaultGroupID); err != nil {
+			h.respondError(c, err, "invalid sso provider")
+			return
+		}
+		provider.DefaultGroupID = req.DefaultGroupID
+	}
+	if req.IsActive != nil {
+		provider.Active = *req.IsActive
+	}
+
+	if err := SaveProvider(h.db, &provider); err != nil {
+		h.respondError(c, err, "failed to update sso provider")
+		return
+	}
+	h.describe(&provider)
+
+	response.Success(c, http.StatusOK, provider, "", nil)
+}
+
+// Delete removes a provider. Users keep their accounts and passwords.
+func (h *Handler) Delete(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("providerId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid provider id", err)
+		return
+	}
+
+	if err := DeleteProvider(h.db, subscriptionID, id); err != nil {
+		h.respondError(c, err, "failed to delete sso provider")
+		return
+	}
+
+	response.NoContent(c, "SSO provider deleted.")
+}
+
+// Identities returns the identities linked to the current user.
+func (h *Handler) Identities(c *gin.Context) {
+	subscriptionID, usr, ok := h.member(c)
+	if !ok {
+		return
+	}
+
+	identities, err := ListIdentities(h.db, subscriptionID, usr.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list sso identities", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, identities, "", nil)
+}
+
+// Unlink removes one of the current user's identities.
+func (h *Handler) Unlink(c *gin.Context) {
+	subscriptionID, usr, ok := h.member(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("identityId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid identity id", err)
+		return
+	}
+
+	if err := Unlink(h.db, subscriptionID, usr.ID, id); err != nil {
+		h.respondError(c, err, "failed to unlink sso identity")
+		return
+	}
+
+	response.NoContent(c, "Identity unlinked.")
+}
+
+// Providers lists the providers of a subscription's sign-in page.
+// GET /auth/sso/providers?subscription=<identifier>
+func (h *Handler) Providers(c *gin.Context) {
+	identifier := strings.TrimSpace(c.Query("subscription"))
+	if identifier == "" {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscription is required", nil)
+		return
+	}
+
+	sub, err := subscription.GetByIdentifier(h.db, identifier)
+	if err != nil {
+		h.respondError(c, err, "failed to load subscription")
+		return
+	}
+
+	providers, err := ActiveProviders(h.db, sub.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list sso providers", err)
+		return
+	}
+	providers = slices.DeleteFunc(providers, func(provider PublicProvider) bool { return !h.enabled(provider.Kind) })
+
+	response.Success(c, http.StatusOK, providers, "", nil)
+}
+
+// Start sends the browser to the provider's sign-in page.
+// GET /auth/sso/providers/:providerId/start
+func (h *Handler) Start(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("providerId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid provider id", err)
+		return
+	}
+	provider, err := GetActiveProvider(h.db, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load sso provider")
+		return
+	}
+	if !h.enabled(provider.Kind) {
+		h.respondError(c, ErrNotConfigured, "")
+		return
+	}
+
+	now := time.Now().UTC()
+	state, err := randomToken(32)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start sign-in", err)
+		return
+	}
+	login := Login{ProviderID: provider.ID, State: state}
+
+	var authURL string
+	if provider.Kind == KindSAML {
+		requestID, err := randomToken(20)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start sign-in", err)
+			return
+		}
+		// Request IDs must not start with a digit
+		login.Verifier = "id-" + requestID
+		authURL, err = h.serviceProvider(provider).AuthnRequestURL(provider.identityProvider(), login.Verifier, login.State, now)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start sign-in", err)
+			return
+		}
+	} else {
+		login.Verifier = oauth2.GenerateVerifier()
+		authURL, err = h.client.AuthURL(c.Request.Context(), provider, login.State, login.Verifier)
+		if err != nil {
+			h.respondError(c, err, "failed to start sign-in")
+			return
+		}
+	}
+	if err := StartLogin(h.db, &login, now); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start sign-in", err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback is where providers send the browser back. It resolves the sign-in
+// and forwards the browser to the frontend with a one-time code, which either
+// signs the user in or asks them to confirm linking their account.
+// GET /auth/sso/callback
+func (h *Handler) Callback(c *gin.Context) {
+	if h.client == nil {
+		h.redirect(c, url.Values{"error": {"not_configured"}})
+		return
+	}
+
+	now := time.Now().UTC()
+	login, err := PendingLogin(h.db, c.Query("state"), now)
+	if err != nil {
+		h.logger.Warn("sso callback rejected", slog.String("error", err.Error()))
+		h.redirect(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		// The user cancelled at the provider
+		h.redirect(c, url.Values{"error": {"cancelled"}})
+		return
+	}
+
+	provider, err := GetActiveProvider(h.db, login.ProviderID)
+	if err != nil || provider.Kind == KindSAML {
+		h.redirect(c, url.Values{"error": {"provider_unavailable"}})
+		return
+	}
+
+	claims, err := h.client.Claims(c.Request.Context(), provider, code, login.Verifier)
+	if err != nil {
+		h.logger.Error("sso code exchange failed", slog.String("providerId", provider.ID.String()), slog.String("error", err.Error()))
+		h.redirect(c, url.Values{"error": {"provider_error"}})
+		return
+	}
+
+	h.complete(c, login, provider, claims, now)
+}
+
+// SAMLMetadata serves the service provider metadata a SAML provider's IdP is
+// configured with.
+// GET /auth/sso/saml/:providerId/metadata
+func (h *Handler) SAMLMetadata(c *gin.Context) {
+	provider, ok := h.samlProvider(c)
+	if !ok {
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", h.serviceProvider(provider).Metadata())
+}
+
+// SAMLAssertion is the assertion consumer service SAML IdPs post responses
+// to. Only answers to a sign-in started here are accepted; the browser is
+// forwarded to the frontend like after an OAuth callback.
+// POST /auth/sso/saml/:providerId/acs
+func (h *Handler) SAMLAssertion(c *gin.Context) {
+	if h.samlURL == "" {
+		h.redirect(c, url.Values{"error": {"not_configured"}})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxResponseSize)
+
+	// The relay state finds the sign-in; the response must then be addressed to
+	// the ACS URL of the sign-in's provider
+	now := time.Now().UTC()
+	login, err := PendingLogin(h.db, c.PostForm("RelayState"), now)
+	if err != nil {
+		h.logger.Warn("saml response rejected", slog.String("error", err.Error()))
+		h.redirect(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	provider, err := GetActiveProvider(h.db, login.ProviderID)
+	if err != nil || provider.Kind != KindSAML {
+		h.redirect(c, url.Values{"error": {"provider_unavailable"}})
+		return
+	}
+
+	assertion, err := h.serviceProvider(provider).ParseResponse(c.PostForm("SAMLResponse"), provider.identityProvider(), login.Verifier, now)
+	if err != nil {
+		h.logger.Error("saml response rejected", slog.String("providerId", provider.ID.String()), slog.String("error", err.Error()))
+		h.redirect(c, url.Values{"error": {"provider_error"}})
+		return
+	}
+
+	h.complete(c, login, provider, samlClaims(provider, assertion), now)
+}
+
+// complete resolves a sign-in the provider vouched for and forwards the
+// browser to the frontend with its one-time code.
+func (h *Handler) complete(c *gin.Context, login Login, provider Provider, claims Claims, now time.Time) {
+	outcome, email, err := ResolveClaims(h.db, provider, claims, now)
+	if err != nil {
+		h.logger.Info("sso sign-in refused", slog.String("providerId", provider.ID.String()), slog.String("error", err.Error()))
+		h.redirect(c, url.Values{"error": {callbackError(err)}})
+		return
+	}
+
+	if outcome.Provisioned {
+		h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+	}
+
+	redeem, err := randomToken(32)
+	if err == nil {
+		err = Resolve(h.db, &login, outcome.Purpose, redeem, outcome.UserID, claims.Subject, email, now)
+	}
+	if err != nil {
+		h.logger.Error("failed to resolve sso sign-in", slog.String("providerId", provider.ID.String()), slog.String("error", err.Error()))
+		h.redirect(c, url.Values{"error": {"server_error"}})
+		return
+	}
+
+	h.redirect(c, url.Values{"code": {redeem}, "action": {string(outcome.Purpose)}})
+}
+
+// Exchange trades the code of a completed sign-in for tokens.
+// POST /auth/sso/exchange
+func (h *Handler) Exchange(c *gin.Context) {
+	var req exchangeRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid sso exchange payload") {
+		return
+	}
+
+	login, err := Redeem(h.db, req.Code, PurposeLogin, time.Now().UTC())
+	if err != nil {
+		h.respondError(c, err, "sign-in failed")
+		return
+	}
+
+	h.signIn(c, *login.UserID, req.DeviceID)
+}
+
+// LinkPreview describes the account a link code would connect, so the
+// frontend can ask for the right password.
+// GET /auth/sso/link/:code
+func (h *Handler) LinkPreview(c *gin.Context) {
+	login, err := PeekLink(h.db, c.Param("code"), time.Now().UTC())
+	if err != nil {
+		h.respondError(c, err, "failed to load account link")
+		return
+	}
+
+	owner, err := user.Get(h.db, *login.UserID)
+	if err != nil {
+		h.respondError(c, err, "failed to load account link")
+		return
+	}
+	var provider Provider
+	if err := h.db.Select("name").First(&provider, "id = ?", login.ProviderID).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load account link", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, linkPreview{Email: owner.Email, ProviderName: provider.Name, FullName: owner.FullName}, "", nil)
+}
+
+// Link confirms linking an identity to the account its email matched with the
+// account's password, then signs the user in.
+// POST /auth/sso/link
+func (h *Handler) Link(c *gin.Context) {
+	var req linkRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid sso link payload") {
+		return
+	}
+
+	now := time.Now().UTC()
+	login, err := PeekLink(h.db, req.Code, now)
+	if err != nil {
+		h.respondError(c, err, "failed to link account")
+		return
+	}
+
+	owner, err := ConfirmLink(h.db, login, req.Password, now)
+	if err != nil {
+		h.respondError(c, err, "failed to link account")
+		return
+	}
+
+	h.signIn(c, owner.ID, req.DeviceID)
+}
+
+func (h *Handler) signIn(c *gin.Context, userID uuid.UUID, deviceID *string) {
+	usr, err := LoadUser(h.db, userID)
+	if err != nil {
+		h.respondError(c, err, "sign-in failed")
+		return
+	}
+
+	authResp, err := auth.SignIn(h.db, usr, deviceID, auth.TokenConfigFrom(h.cfg))
+	if err != nil {
+		h.respondError(c, err, "sign-in failed")
+		return
+	}
+
+	response.Success(c, http.StatusOK, authResp, "Login successful", nil)
+}
+
+// enabled reports whether users can sign in with providers of the kind.
+func (h *Handler) enabled(kind Kind) bool {
+	if kind == KindSAML {
+		return h.samlURL != ""
+	}
+	return h.client != nil
+}
+
+// serviceProvider identifies this server to a SAML provider's IdP.
+func (h *Handler) serviceProvider(provider Provider) saml.ServiceProvider {
+	base := h.samlURL + "/" + provider.ID.String()
+	return saml.ServiceProvider{EntityID: base + "/metadata", ACSURL: base + "/acs"}
+}
+
+// describe adds what the IdP of a SAML provider is configured with.
+func (h *Handler) describe(provider *Provider) {
+	if provider.Kind != KindSAML || h.samlURL == "" {
+		return
+	}
+	sp := h.serviceProvider(*provider)
+	info := &ServiceProvider{EntityID: sp.EntityID, ACSURL: sp.ACSURL, MetadataURL: sp.EntityID}
+	if expiresAt := saml.CertificatesExpireAt(provider.Certificates); !expiresAt.IsZero() {
+		info.CertificatesExpireAt = &expiresAt
+	}
+	provider.ServiceProvider = info
+}
+
+// normalizeDomains lowercases and deduplicates the allowed domains.
+func normalizeDomains(domains []string) ([]string, error) {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" {
+			continue
+		}
+		if len(domain) > 253 || !strings.Contains(domain, ".") || strings.ContainsAny(domain, " @/:") {
+			return nil, ErrInvalidDomain
+		}
+		if !slices.Contains(normalized, domain) {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized, nil
+}
+
+func (h *Handler) checkGroup(subscriptionID uuid.UUID, groupID *uuid.UUID) error {
+	if groupID == nil {
+		return nil
+	}
+	var count int64
+	if err := h.db.Model(&groupaccess.GroupAccess{}).
+		Where("id = ? AND subscription_id = ?", *groupID, subscriptionID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// callbackError is the reason the frontend shows for a refused sign-in.
+func callbackError(err error) string {
+	switch {
+	case errors.Is(err, ErrEmailMissing):
+		return "email_unverified"
+	case errors.Is(err, ErrDomainNotAllowed):
+		return "domain_not_allowed"
+	case errors.Is(err, ErrNoAccount):
+		return "no_account"
+	case errors.Is(err, ErrAccountConflict):
+		return "account_conflict"
+	case errors.Is(err, ErrIdentityConflict):
+		return "already_linked"
+	case errors.Is(err, ErrStudentLimit), errors.Is(err, groupaccess.ErrPointsLimit):
+		return "limit_reached"
+	case errors.Is(err, ErrSubscriptionClosed):
+		return "subscription_inactive"
+	}
+	return "server_error"
+}
+
+func (h *Handler) redirect(c *gin.Context, query url.Values) {
+	c.Redirect(http.StatusFound, h.frontendURL+callbackPath+"?"+query.Encode())
+}
+
+func (h *Handler) subscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+	return subscriptionID, true
+}
+
+func (h *Handler) member(c *gin.Context) (uuid.UUID, *middleware.User, bool) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return uuid.Nil, nil, false
+	}
+
+	usr, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return uuid.Nil, nil, false
+	}
+	return subscriptionID, usr, true
+}
+
+// samlProvider loads the active SAML provider of the path.
+func (h *Handler) samlProvider(c *gin.Context) (Provider, bool) {
+	if h.samlURL == "" {
+		h.respondError(c, ErrNotConfigured, "")
+		return Provider{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("providerId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid provider id", err)
+		return Provider{}, false
+	}
+
+	provider, err := GetActiveProvider(h.db, id)
+	if err == nil && provider.Kind != KindSAML {
+		err = ErrProviderNotFound
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to load sso provider")
+		return Provider{}, false
+	}
+	return provider, true
+}
+
+func (h *Handler) resolveProvider(c *gin.Context) (Provider, bool) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return Provider{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("providerId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid provider id", err)
+		return Provider{}, false
+	}
+
+	provider, err := GetProvider(h.db, subscriptionID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load sso provider")
+		return Provider{}, false
+	}
+	return provider, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
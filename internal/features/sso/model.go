@@ -0,0 +1,396 @@
+package sso
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Kind selects how a provider's issuer is derived and how far its email
+// claims are trusted.
+type Kind string
+
+const (
+	// KindGoogle signs users in with Google accounts.
+	KindGoogle Kind = "google"
+	// KindMicrosoft signs users in with accounts of one Microsoft Entra tenant.
+	KindMicrosoft Kind = "microsoft"
+	// KindOIDC signs users in with any OpenID Connect issuer.
+	KindOIDC Kind = "oidc"
+	// KindSAML signs users in with a SAML 2.0 identity provider such as
+	// Microsoft Entra ID or AD FS.
+	KindSAML Kind = "saml"
+)
+
+// Purpose tracks what a sign-in resolved to once the provider redirected back.
+type Purpose string
+
+const (
+	// PurposePending means the user has not come back from the provider yet.
+	PurposePending Purpose = "pending"
+	// PurposeLogin means the identity is known and the code signs the user in.
+	PurposeLogin Purpose = "login"
+	// PurposeLink means the email matched an account whose owner must confirm
+	// the link with their password.
+	PurposeLink Purpose = "link"
+)
+
+const (
+	// maxProviders caps the providers of one subscription.
+	maxProviders = 5
+	// startTTL bounds the time spent on the provider's consent screen.
+	startTTL = 10 * time.Minute
+	// codeTTL bounds the time the frontend has to use a callback code.
+	codeTTL = 5 * time.Minute
+)
+
+// Provider is an identity provider a subscription's users can sign in with.
+// Users whose email matches no account are created as students when
+// AutoProvision is on, and added to DefaultGroupID if set.
+//
+// SAML providers keep the IdP's entity ID in Issuer and leave the client
+// credentials empty; their sign-on URL and certificates come from the
+// uploaded metadata.
+type Provider struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID      `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	Kind           Kind           `gorm:"type:varchar(20);not null" json:"kind"`
+	Name           string         `gorm:"type:varchar(100);not null" json:"name"`
+	Issuer         string         `gorm:"type:varchar(500);not null" json:"issuer"`
+	ClientID       string         `gorm:"type:varchar(255);not null;column:client_id" json:"clientId"`
+	ClientSecret   string         `gorm:"type:text;not null;column:client_secret" json:"-"`
+	AllowedDomains pq.StringArray `gorm:"type:text[];not null;default:'{}';column:allowed_domains" json:"allowedDomains"`
+	AutoProvision  bool           `gorm:"type:boolean;not null;default:false;column:auto_provision" json:"autoProvision"`
+	DefaultGroupID *uuid.UUID     `gorm:"type:uuid;column:default_group_id" json:"defaultGroupId,omitempty"`
+	Active         bool           `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
+
+	SSOURL       string         `gorm:"type:varchar(500);not null;default:'';column:saml_sso_url" json:"samlSsoUrl,omitempty"`
+	Certificates pq.StringArray `gorm:"type:text[];not null;default:'{}';column:saml_certificates" json:"-"`
+	AttributeMap *AttributeMap  `gorm:"type:jsonb;column:attribute_map" json:"attributeMap,omitempty"`
+
+	// ServiceProvider describes this server to a SAML provider's IdP.
+	ServiceProvider *ServiceProvider `gorm:"-" json:"serviceProvider,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Provider) TableName() string { return "sso_providers" }
+
+// AllowsEmail reports whether the email's domain may sign in. An empty list
+// allows every domain.
+func (p Provider) AllowsEmail(email string) bool {
+	if len(p.AllowedDomains) == 0 {
+		return true
+	}
+	_, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok {
+		return false
+	}
+	for _, allowed := range p.AllowedDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceProvider is what an administrator configures the IdP of a SAML
+// provider with.
+type ServiceProvider struct {
+	EntityID    string `json:"entityId"`
+	ACSURL      string `json:"acsUrl"`
+	MetadataURL string `json:"metadataUrl"`
+	// CertificatesExpireAt is when the last of the IdP's signing certificates
+	// expires; upload fresh metadata before then.
+	CertificatesExpireAt *time.Time `json:"certificatesExpireAt,omitempty"`
+}
+
+// AttributeMap names the SAML attributes user fields are read from. Empty
+// entries fall back to the claim names Microsoft Entra ID and AD FS send.
+type AttributeMap struct {
+	Email     string `json:"email,omitempty"`
+	FullName  string `json:"fullName,omitempty"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+// Value implements driver.Valuer for JSONB serialization.
+func (m AttributeMap) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// Scan implements sql.Scanner for JSONB deserialization.
+func (m *AttributeMap) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*m = AttributeMap{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, m)
+	case string:
+		return json.Unmarshal([]byte(data), m)
+	default:
+		return fmt.Errorf("sso.AttributeMap: unsupported scan type %T", value)
+	}
+}
+
+// PublicProvider is what the sign-in page needs to render a provider button.
+type PublicProvider struct {
+	ID   uuid.UUID `json:"id"`
+	Kind Kind      `json:"kind"`
+	Name string    `json:"name"`
+}
+
+// Identity links an account at a provider to a user. Subject is the
+// provider's stable identifier; Email is the address it reported last.
+type Identity struct {
+	types.BaseModel
+
+	ProviderID  uuid.UUID  `gorm:"type:uuid;not null;column:provider_id;uniqueIndex:idx_sso_identities_subject,priority:1;uniqueIndex:idx_sso_identities_user,priority:1" json:"providerId"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;column:user_id;uniqueIndex:idx_sso_identities_user,priority:2;index" json:"userId"`
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_sso_identities_subject,priority:2" json:"-"`
+	Email       string     `gorm:"type:varchar(255);not null" json:"email"`
+	LastLoginAt *time.Time `gorm:"type:timestamp;column:last_login_at" json:"lastLoginAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Identity) TableName() string { return "sso_identities" }
+
+// Login is a sign-in in progress. It carries the OAuth state and PKCE
+// verifier to the callback, then the one-time code the frontend redeems. A
+// SAML sign-in keeps its RelayState in State and its AuthnRequest ID in
+// Verifier.
+type Login struct {
+	types.BaseModel
+
+	ProviderID uuid.UUID  `gorm:"type:uuid;not null;column:provider_id" json:"providerId"`
+	State      string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Verifier   string     `gorm:"type:varchar(128);not null" json:"-"`
+	Purpose    Purpose    `gorm:"type:varchar(10);not null;default:'pending'" json:"purpose"`
+	Code       *string    `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	UserID     *uuid.UUID `gorm:"type:uuid;column:user_id" json:"userId,omitempty"`
+	Subject    *string    `gorm:"type:varchar(255)" json:"-"`
+	Email      *string    `gorm:"type:varchar(255)" json:"email,omitempty"`
+	ExpiresAt  time.Time  `gorm:"type:timestamp;not null;column:expires_at;index" json:"expiresAt"`
+}
+
+// TableName overrides the default table name.
+func (Login) TableName() string { return "sso_logins" }
+
+// ListProviders returns the subscription's providers, oldest first.
+func ListProviders(db *gorm.DB, subscriptionID uuid.UUID) ([]Provider, error) {
+	providers := make([]Provider, 0)
+	err := db.Where("subscription_id = ?", subscriptionID).Order("created_at ASC").Find(&providers).Error
+	return providers, err
+}
+
+// ActiveProviders returns the providers the subscription's sign-in page offers.
+func ActiveProviders(db *gorm.DB, subscriptionID uuid.UUID) ([]PublicProvider, error) {
+	providers := make([]PublicProvider, 0)
+	err := db.Model(&Provider{}).
+		Select("id, kind, name").
+		Where("subscription_id = ? AND is_active", subscriptionID).
+		Order("created_at ASC").
+		Scan(&providers).Error
+	return providers, err
+}
+
+// GetProvider loads one of the subscription's providers.
+func GetProvider(db *gorm.DB, subscriptionID, id uuid.UUID) (Provider, error) {
+	var provider Provider
+	err := db.First(&provider, "id = ? AND subscription_id = ?", id, subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return provider, ErrProviderNotFound
+	}
+	return provider, err
+}
+
+// GetActiveProvider loads a provider users can sign in with.
+func GetActiveProvider(db *gorm.DB, id uuid.UUID) (Provider, error) {
+	var provider Provider
+	err := db.First(&provider, "id = ? AND is_active", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return provider, ErrProviderNotFound
+	}
+	return provider, err
+}
+
+// CreateProvider stores a new provider within the subscription's limit.
+func CreateProvider(db *gorm.DB, provider *Provider) error {
+	var count int64
+	if err := db.Model(&Provider{}).Where("subscription_id = ?", provider.SubscriptionID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= maxProviders {
+		return ErrProviderLimit
+	}
+	return db.Create(provider).Error
+}
+
+// SaveProvider stores changes to a provider.
+func SaveProvider(db *gorm.DB, provider *Provider) error {
+	return db.Save(provider).Error
+}
+
+// DeleteProvider removes a provider with its identities and pending sign-ins.
+func DeleteProvider(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	result := db.Delete(&Provider{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProviderNotFound
+	}
+	return nil
+}
+
+// IdentitySummary is a linked identity as listed for its user.
+type IdentitySummary struct {
+	ID           uuid.UUID  `json:"id"`
+	ProviderID   uuid.UUID  `json:"providerId"`
+	ProviderKind Kind       `json:"providerKind"`
+	ProviderName string     `json:"providerName"`
+	Email        string     `json:"email"`
+	LastLoginAt  *time.Time `json:"lastLoginAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// ListIdentities returns the identities linked to the user in the subscription.
+func ListIdentities(db *gorm.DB, subscriptionID, userID uuid.UUID) ([]IdentitySummary, error) {
+	identities := make([]IdentitySummary, 0)
+	err := db.Table("sso_identities").
+		Select(`sso_identities.id, sso_identities.provider_id, sso_identities.email, sso_identities.last_login_at,
+			sso_identities.created_at, sso_providers.kind AS provider_kind, sso_providers.name AS provider_name`).
+		Joins("JOIN sso_providers ON sso_providers.id = sso_identities.provider_id").
+		Where("sso_providers.subscription_id = ? AND sso_identities.user_id = ?", subscriptionID, userID).
+		Order("sso_identities.created_at ASC").
+		Scan(&identities).Error
+	return identities, err
+}
+
+// Unlink removes one of the user's identities in the subscription.
+func Unlink(db *gorm.DB, subscriptionID, userID, id uuid.UUID) error {
+	result := db.Where("id = ? AND user_id = ?", id, userID).
+		Where("provider_id IN (?)", db.Model(&Provider{}).Select("id").Where("subscription_id = ?", subscriptionID)).
+		Delete(&Identity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+// FindIdentity loads the identity of the provider's subject, if linked.
+func FindIdentity(db *gorm.DB, providerID uuid.UUID, subject string) (*Identity, error) {
+	var identity Identity
+	err := db.First(&identity, "provider_id = ? AND subject = ?", providerID, subject).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Link stores an identity for the user. A user holds at most one identity per
+// provider and a subject belongs to one user.
+func Link(db *gorm.DB, identity *Identity) error {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(identity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdentityConflict
+	}
+	return nil
+}
+
+// Touch records a sign-in with the identity and the email it reported.
+func Touch(db *gorm.DB, identity *Identity, email string, now time.Time) error {
+	updates := map[string]interface{}{"last_login_at": now, "updated_at": now}
+	if email != "" {
+		updates["email"] = email
+	}
+	return db.Model(identity).Updates(updates).Error
+}
+
+// StartLogin stores a sign-in waiting for the provider's callback and drops
+// sign-ins nobody finished.
+func StartLogin(db *gorm.DB, login *Login, now time.Time) error {
+	if err := db.Where("expires_at < ?", now).Delete(&Login{}).Error; err != nil {
+		return err
+	}
+	login.Purpose = PurposePending
+	login.ExpiresAt = now.Add(startTTL)
+	return db.Create(login).Error
+}
+
+// PendingLogin loads the sign-in the provider's callback carries the state of.
+func PendingLogin(db *gorm.DB, state string, now time.Time) (Login, error) {
+	var login Login
+	err := db.First(&login, "state = ? AND purpose = ? AND expires_at > ?", state, PurposePending, now).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return login, ErrInvalidState
+	}
+	return login, err
+}
+
+// Resolve records what the callback resolved a sign-in to and the code the
+// frontend redeems it with.
+func Resolve(db *gorm.DB, login *Login, purpose Purpose, code string, userID uuid.UUID, subject, email string, now time.Time) error {
+	login.Purpose = purpose
+	login.Code = &code
+	login.UserID = &userID
+	login.Subject = &subject
+	login.Email = &email
+	login.ExpiresAt = now.Add(codeTTL)
+	return db.Model(login).Updates(map[string]interface{}{
+		"purpose":    purpose,
+		"code":       code,
+		"user_id":    userID,
+		"subject":    subject,
+		"email":      email,
+		"expires_at": login.ExpiresAt,
+		"updated_at": now,
+	}).Error
+}
+
+// Redeem consumes a resolved sign-in by its code. A code works once.
+func Redeem(db *gorm.DB, code string, purpose Purpose, now time.Time) (Login, error) {
+	var login Login
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&login, "code = ? AND purpose = ? AND expires_at > ?", code, purpose, now).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCode
+			}
+			return err
+		}
+		return tx.Delete(&login).Error
+	})
+	return login, err
+}
+
+// PeekLink loads an unconfirmed account link without consuming it.
+func PeekLink(db *gorm.DB, code string, now time.Time) (Login, error) {
+	var login Login
+	err := db.First(&login, "code = ? AND purpose = ? AND expires_at > ?", code, PurposeLink, now).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return login, ErrInvalidCode
+	}
+	return login, err
+}
@@ -0,0 +1,45 @@
+package sso
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/auth"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches provider management, linked identity and public
+// sign-in endpoints to the router. The sign-in endpoints sit behind limited
+// like the other credential endpoints.
+// Middleware is passed as parameters to avoid import cycles
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdminInstructor, acAll, limited []gin.HandlerFunc) {
+	providers := router.Group("/subscriptions/:subscriptionId/sso/providers")
+	providers.GET("", append(acAdminInstructor, handler.List)...)
+	providers.POST("", append(acAdminInstructor, handler.Create)...)
+	providers.GET("/:providerId", append(acAdminInstructor, handler.Get)...)
+	providers.PUT("/:providerId", append(acAdminInstructor, handler.Update)...)
+	providers.DELETE("/:providerId", append(acAdminInstructor, handler.Delete)...)
+
+	identities := router.Group("/subscriptions/:subscriptionId/sso/identities")
+	identities.GET("", append(acAll, handler.Identities)...)
+	identities.DELETE("/:identityId", append(acAll, handler.Unlink)...)
+
+	public := router.Group("/auth/sso", limited...)
+	public.GET("/providers", handler.Providers)
+	public.GET("/providers/:providerId/start", handler.Start)
+	public.GET("/callback", handler.Callback)
+	public.POST("/exchange", handler.Exchange)
+	public.GET("/link/:code", handler.LinkPreview)
+	public.POST("/link", handler.Link)
+	public.GET("/saml/:providerId/metadata", handler.SAMLMetadata)
+	public.POST("/saml/:providerId/acs", handler.SAMLAssertion)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Provider{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Provider{}})
+	openapi.Describe(handler.Get, openapi.Spec{Response: Provider{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Provider{}})
+	openapi.Describe(handler.Identities, openapi.Spec{Response: []IdentitySummary{}})
+	openapi.Describe(handler.Providers, openapi.Spec{Response: []PublicProvider{}})
+	openapi.Describe(handler.Exchange, openapi.Spec{Request: exchangeRequest{}, Response: auth.AuthResponse{}})
+	openapi.Describe(handler.LinkPreview, openapi.Spec{Response: linkPreview{}})
+	openapi.Describe(handler.Link, openapi.Spec{Request: linkRequest{}, Response: auth.AuthResponse{}})
+}
@@ -0,0 +1,108 @@
+package sso
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mo-amir99/lms-server-go/pkg/saml"
+)
+
+// maxResponseSize bounds the SAML responses IdPs post back.
+const maxResponseSize = 1 << 20
+
+// The claim names Microsoft Entra ID and AD FS send by default.
+var defaultAttributes = AttributeMap{
+	Email:     "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+	FullName:  "http://schemas.microsoft.com/identity/claims/displayname",
+	FirstName: "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname",
+	LastName:  "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname",
+	Phone:     "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/mobilephone",
+}
+
+const emailNameID = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+
+// ApplyMetadata configures a SAML provider from its IdP's metadata document.
+func ApplyMetadata(provider *Provider, metadata string) error {
+	idp, err := saml.ParseMetadata([]byte(metadata))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	if len(idp.EntityID) > 500 || len(idp.SSOURL) > 500 {
+		return ErrInvalidMetadata
+	}
+	provider.Issuer = idp.EntityID
+	provider.SSOURL = idp.SSOURL
+	provider.Certificates = idp.Certificates
+	return nil
+}
+
+// normalizeAttributes trims the mapping and drops it when it only repeats the
+// defaults.
+func normalizeAttributes(mapping *AttributeMap) *AttributeMap {
+	if mapping == nil {
+		return nil
+	}
+	normalized := AttributeMap{
+		Email:     strings.TrimSpace(mapping.Email),
+		FullName:  strings.TrimSpace(mapping.FullName),
+		FirstName: strings.TrimSpace(mapping.FirstName),
+		LastName:  strings.TrimSpace(mapping.LastName),
+		Phone:     strings.TrimSpace(mapping.Phone),
+	}
+	if normalized == (AttributeMap{}) {
+		return nil
+	}
+	return &normalized
+}
+
+func (p Provider) identityProvider() saml.IdentityProvider {
+	return saml.IdentityProvider{EntityID: p.Issuer, SSOURL: p.SSOURL, Certificates: p.Certificates}
+}
+
+// attributes returns the provider's mapping with defaults for empty entries.
+func (p Provider) attributes() AttributeMap {
+	mapping := defaultAttributes
+	if p.AttributeMap == nil {
+		return mapping
+	}
+	if p.AttributeMap.Email != "" {
+		mapping.Email = p.AttributeMap.Email
+	}
+	if p.AttributeMap.FullName != "" {
+		mapping.FullName = p.AttributeMap.FullName
+	}
+	if p.AttributeMap.FirstName != "" {
+		mapping.FirstName = p.AttributeMap.FirstName
+	}
+	if p.AttributeMap.LastName != "" {
+		mapping.LastName = p.AttributeMap.LastName
+	}
+	if p.AttributeMap.Phone != "" {
+		mapping.Phone = p.AttributeMap.Phone
+	}
+	return mapping
+}
+
+// samlClaims reads the user fields of a verified assertion. The NameID is the
+// subject; it doubles as the email when the IdP sends it in email format and
+// no email attribute. The IdP is the school's directory, so its addresses are
+// trusted as verified.
+func samlClaims(provider Provider, assertion *saml.Assertion) Claims {
+	mapping := provider.attributes()
+	verified := flexBool(true)
+
+	claims := Claims{
+		Subject:       assertion.NameID,
+		Email:         assertion.Attribute(mapping.Email),
+		EmailVerified: &verified,
+		Name:          assertion.Attribute(mapping.FullName),
+		Phone:         assertion.Attribute(mapping.Phone),
+	}
+	if claims.Email == "" && assertion.NameIDFormat == emailNameID {
+		claims.Email = assertion.NameID
+	}
+	if claims.Name == "" {
+		claims.Name = strings.TrimSpace(assertion.Attribute(mapping.FirstName) + " " + assertion.Attribute(mapping.LastName))
+	}
+	return claims
+}
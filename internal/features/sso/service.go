@@ -0,0 +1,239 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/webhook"
+)
+
+const (
+	googleIssuer = "https://accounts.google.com"
+	// discoveryTTL is how long an issuer's endpoints are reused before they are
+	// fetched again.
+	discoveryTTL = time.Hour
+	// maxDocumentSize bounds discovery and userinfo responses.
+	maxDocumentSize = 1 << 20
+)
+
+// scopes ask for the subject, a verified email and a display name.
+var scopes = []string{"openid", "email", "profile"}
+
+// tenantPattern accepts Entra tenant IDs and domains. The shared "common",
+// "organizations" and "consumers" tenants are refused because any account
+// could then claim any email.
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]{0,99}$`)
+
+// IssuerFor returns the issuer URL of a provider kind. Microsoft takes a
+// tenant, OIDC an issuer URL; Google ignores the value.
+func IssuerFor(kind Kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case KindGoogle:
+		return googleIssuer, nil
+	case KindMicrosoft:
+		switch strings.ToLower(value) {
+		case "", "common", "organizations", "consumers":
+			return "", ErrInvalidTenant
+		}
+		if !tenantPattern.MatchString(value) {
+			return "", ErrInvalidTenant
+		}
+		return "https://login.microsoftonline.com/" + value + "/v2.0", nil
+	case KindOIDC:
+		issuer, err := webhook.ValidateURL(value)
+		if err != nil {
+			return "", ErrInvalidIssuer
+		}
+		return strings.TrimRight(issuer, "/"), nil
+	}
+	return "", ErrUnknownKind
+}
+
+// Claims is what a provider's userinfo endpoint says about the user.
+type Claims struct {
+	Subject           string    `json:"sub"`
+	Email             string    `json:"email"`
+	EmailVerified     *flexBool `json:"email_verified"`
+	Name              string    `json:"name"`
+	PreferredUsername string    `json:"preferred_username"`
+	Phone             string    `json:"phone_number"`
+}
+
+// VerifiedEmail returns the user's email if the provider vouches for it.
+// Microsoft does not send email_verified; tenant accounts are trusted since
+// the tenant's administrators assign their addresses.
+func (c Claims) VerifiedEmail(kind Kind) string {
+	email := strings.ToLower(strings.TrimSpace(c.Email))
+	if email == "" && kind == KindMicrosoft && strings.Contains(c.PreferredUsername, "@") {
+		email = strings.ToLower(strings.TrimSpace(c.PreferredUsername))
+	}
+	if email == "" {
+		return ""
+	}
+	if c.EmailVerified != nil {
+		if !bool(*c.EmailVerified) {
+			return ""
+		}
+		return email
+	}
+	if kind == KindMicrosoft {
+		return email
+	}
+	return ""
+}
+
+// flexBool accepts true/false as JSON booleans or strings; some issuers send
+// email_verified as "true".
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	*b = flexBool(strings.EqualFold(text, "true"))
+	return nil
+}
+
+// metadata holds the endpoints of an issuer's discovery document.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+
+	fetchedAt time.Time
+}
+
+// Client talks to identity providers. Discovery documents are cached per issuer.
+type Client struct {
+	http        *http.Client
+	redirectURL string
+
+	mu        sync.Mutex
+	discovery map[string]metadata
+}
+
+// NewClient constructs a provider client that sends users back to redirectURL.
+func NewClient(redirectURL string) *Client {
+	return &Client{
+		http:        &http.Client{Timeout: 10 * time.Second},
+		redirectURL: redirectURL,
+		discovery:   map[string]metadata{},
+	}
+}
+
+// AuthURL returns where to send the user to sign in at the provider.
+func (c *Client) AuthURL(ctx context.Context, provider Provider, state, verifier string) (string, error) {
+	config, _, err := c.oauthConfig(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	options := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(verifier), oauth2.SetAuthURLParam("prompt", "select_account")}
+	if len(provider.AllowedDomains) == 1 && provider.Kind == KindGoogle {
+		// Narrows Google's account chooser to the school's workspace
+		options = append(options, oauth2.SetAuthURLParam("hd", provider.AllowedDomains[0]))
+	}
+	return config.AuthCodeURL(state, options...), nil
+}
+
+// Claims exchanges the callback's code and returns what the provider says
+// about the user.
+func (c *Client) Claims(ctx context.Context, provider Provider, code, verifier string) (Claims, error) {
+	config, meta, err := c.oauthConfig(ctx, provider)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.http)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Claims{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.UserinfoEndpoint, nil)
+	if err != nil {
+		return Claims{}, err
+	}
+	token.SetAuthHeader(req)
+
+	var claims Claims
+	if err := c.getJSON(req, &claims); err != nil {
+		return Claims{}, err
+	}
+	if claims.Subject == "" {
+		return Claims{}, fmt.Errorf("userinfo response without subject")
+	}
+	return claims, nil
+}
+
+func (c *Client) oauthConfig(ctx context.Context, provider Provider) (*oauth2.Config, metadata, error) {
+	meta, err := c.metadata(ctx, provider.Issuer)
+	if err != nil {
+		return nil, meta, err
+	}
+	return &oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  c.redirectURL,
+		Scopes:       scopes,
+		Endpoint:     oauth2.Endpoint{AuthURL: meta.AuthorizationEndpoint, TokenURL: meta.TokenEndpoint},
+	}, meta, nil
+}
+
+// metadata returns the issuer's endpoints from its discovery document.
+func (c *Client) metadata(ctx context.Context, issuer string) (metadata, error) {
+	c.mu.Lock()
+	cached, ok := c.discovery[issuer]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < discoveryTTL {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return metadata{}, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	var meta metadata
+	if err := c.getJSON(req, &meta); err != nil {
+		return metadata{}, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.UserinfoEndpoint == "" {
+		return metadata{}, fmt.Errorf("%w: incomplete discovery document", ErrDiscovery)
+	}
+	if !strings.HasPrefix(meta.TokenEndpoint, "https://") || !strings.HasPrefix(meta.UserinfoEndpoint, "https://") {
+		return metadata{}, fmt.Errorf("%w: endpoints must use https", ErrDiscovery)
+	}
+
+	meta.fetchedAt = time.Now()
+	c.mu.Lock()
+	c.discovery[issuer] = meta
+	c.mu.Unlock()
+	return meta, nil
+}
+
+func (c *Client) getJSON(req *http.Request, target interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", req.URL.Host, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
@@ -0,0 +1,130 @@
+package streamanalytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// retentionPoints is the number of samples in the retention curve.
+const retentionPoints = 20
+
+// Interval is one stay of a viewer between joining and leaving.
+type Interval struct {
+	ViewerID string
+	From     time.Time
+	To       time.Time
+}
+
+// RetentionPoint is the share of the stream's unique viewers watching at an offset.
+type RetentionPoint struct {
+	OffsetSeconds int64   `json:"offsetSeconds"`
+	Viewers       int     `json:"viewers"`
+	Percent       float64 `json:"percent"`
+}
+
+// Report summarises a stream's audience.
+type Report struct {
+	StreamID              string           `json:"streamId"`
+	Title                 string           `json:"title"`
+	IsLive                bool             `json:"isLive"`
+	StartedAt             time.Time        `json:"startedAt"`
+	EndedAt               *time.Time       `json:"endedAt,omitempty"`
+	DurationSeconds       int64            `json:"durationSeconds"`
+	UniqueViewers         int              `json:"uniqueViewers"`
+	PeakConcurrentViewers int              `json:"peakConcurrentViewers"`
+	PeakAt                *time.Time       `json:"peakAt,omitempty"`
+	AverageWatchSeconds   int64            `json:"averageWatchSeconds"`
+	TotalWatchSeconds     int64            `json:"totalWatchSeconds"`
+	Retention             []RetentionPoint `json:"retention"`
+}
+
+// Compute builds the report for a stream running from start to end. Intervals are
+// clamped to that window.
+func Compute(start, end time.Time, intervals []Interval) Report {
+	report := Report{
+		StartedAt: start,
+		Retention: make([]RetentionPoint, 0, retentionPoints+1),
+	}
+	if end.Before(start) {
+		end = start
+	}
+	duration := end.Sub(start)
+	report.DurationSeconds = int64(duration.Seconds())
+
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	events := make([]event, 0, len(intervals)*2)
+	perViewer := make(map[string]time.Duration)
+
+	clamped := intervals[:0:0]
+	for _, iv := range intervals {
+		if iv.From.Before(start) {
+			iv.From = start
+		}
+		if iv.To.After(end) {
+			iv.To = end
+		}
+		if iv.To.Before(iv.From) {
+			iv.To = iv.From
+		}
+		clamped = append(clamped, iv)
+		perViewer[iv.ViewerID] += iv.To.Sub(iv.From)
+		events = append(events, event{iv.From, 1}, event{iv.To, -1})
+	}
+
+	// Leaves sort before joins at the same instant so back-to-back rejoins do not
+	// count twice towards the peak
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+	current := 0
+	for _, e := range events {
+		current += e.delta
+		if current > report.PeakConcurrentViewers {
+			report.PeakConcurrentViewers = current
+			at := e.at
+			report.PeakAt = &at
+		}
+	}
+
+	report.UniqueViewers = len(perViewer)
+	var total time.Duration
+	for _, watched := range perViewer {
+		total += watched
+	}
+	report.TotalWatchSeconds = int64(total.Seconds())
+	if report.UniqueViewers > 0 {
+		report.AverageWatchSeconds = report.TotalWatchSeconds / int64(report.UniqueViewers)
+	}
+
+	if duration <= 0 {
+		return report
+	}
+	for i := 0; i <= retentionPoints; i++ {
+		at := start.Add(duration * time.Duration(i) / retentionPoints)
+		watching := make(map[string]struct{})
+		for _, iv := range clamped {
+			// The final sample is taken just before the end so viewers present at the
+			// close are still counted
+			if !iv.From.After(at) && (iv.To.After(at) || (i == retentionPoints && iv.To.Equal(end))) {
+				watching[iv.ViewerID] = struct{}{}
+			}
+		}
+		point := RetentionPoint{
+			OffsetSeconds: int64(at.Sub(start).Seconds()),
+			Viewers:       len(watching),
+		}
+		if report.UniqueViewers > 0 {
+			point.Percent = math.Round(float64(point.Viewers)*1000/float64(report.UniqueViewers)) / 10
+		}
+		report.Retention = append(report.Retention, point)
+	}
+
+	return report
+}
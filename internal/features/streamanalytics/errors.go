@@ -0,0 +1,19 @@
+package streamanalytics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrStreamNotFound = errors.New("stream not found")
+	ErrNotStreamHost  = errors.New("only the stream host can view its analytics")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrStreamNotFound, Status: http.StatusNotFound, Code: "STREAM_NOT_FOUND", Message: "Stream not found."},
+	{Err: ErrNotStreamHost, Status: http.StatusForbidden, Code: "NOT_STREAM_HOST", Message: "Only the stream host can view its analytics."},
+}
@@ -0,0 +1,91 @@
+package streamanalytics
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// Handler serves stream analytics.
+type Handler struct {
+	db      *gorm.DB
+	logger  *slog.Logger
+	streams *streamcache.Cache
+}
+
+// NewHandler constructs a stream analytics handler.
+func NewHandler(db *gorm.DB, logger *slog.Logger, streams *streamcache.Cache) *Handler {
+	return &Handler{db: db, logger: logger, streams: streams}
+}
+
+// Get returns audience analytics for a stream: live figures while it is running,
+// otherwise those of its most recent finished run. Only the host may view them.
+// GET /streams/:streamId/analytics
+func (h *Handler) Get(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+	subject := authz.SubjectFrom(currentUser)
+	streamID := c.Param("streamId")
+
+	if stream, found := h.streams.GetStream(streamID); found && stream.IsLive {
+		if !authz.CanControlSession(subject, stream.HostID) {
+			h.respondError(c, ErrNotStreamHost)
+			return
+		}
+		sessions, _ := h.streams.ViewerSessions(streamID)
+		now := time.Now().UTC()
+
+		intervals := make([]Interval, 0, len(sessions))
+		for _, session := range sessions {
+			to := now
+			if session.LeftAt != nil {
+				to = *session.LeftAt
+			}
+			intervals = append(intervals, Interval{ViewerID: session.ViewerID, From: session.JoinedAt, To: to})
+		}
+
+		report := Compute(stream.StartTime, now, intervals)
+		report.StreamID = stream.ID
+		report.Title = stream.Title
+		report.IsLive = true
+		response.Success(c, http.StatusOK, report, "", nil)
+		return
+	}
+
+	record, viewers, err := Latest(h.db, streamID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	if !authz.CanControlSession(subject, record.HostID.String()) {
+		h.respondError(c, ErrNotStreamHost)
+		return
+	}
+
+	intervals := make([]Interval, 0, len(viewers))
+	for _, viewer := range viewers {
+		intervals = append(intervals, Interval{ViewerID: viewer.ViewerID.String(), From: viewer.JoinedAt, To: viewer.LeftAt})
+	}
+
+	report := Compute(record.StartedAt, record.EndedAt, intervals)
+	report.StreamID = record.StreamID
+	report.Title = record.Title
+	endedAt := record.EndedAt
+	report.EndedAt = &endedAt
+	response.Success(c, http.StatusOK, report, "", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error) {
+	errorCatalog.Respond(h.logger, c, err, "failed to load stream analytics")
+}
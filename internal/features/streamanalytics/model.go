@@ -0,0 +1,72 @@
+package streamanalytics
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// StreamRecord is the persisted history of a live stream. Stream IDs are chosen by the
+// host and may be reused, so each run of a stream gets its own row.
+type StreamRecord struct {
+	types.BaseModel
+
+	StreamID       string     `gorm:"type:varchar(100);not null;column:stream_id;index:idx_live_streams_stream_started,priority:1" json:"streamId"`
+	HostID         uuid.UUID  `gorm:"type:uuid;not null;column:host_id;index" json:"hostId"`
+	SubscriptionID *uuid.UUID `gorm:"type:uuid;column:subscription_id" json:"subscriptionId,omitempty"`
+	Title          string     `gorm:"type:varchar(255);not null" json:"title"`
+	StartedAt      time.Time  `gorm:"type:timestamp;not null;column:started_at;index:idx_live_streams_stream_started,priority:2" json:"startedAt"`
+	EndedAt        time.Time  `gorm:"type:timestamp;not null;column:ended_at" json:"endedAt"`
+}
+
+// TableName overrides the default table name.
+func (StreamRecord) TableName() string { return "live_streams" }
+
+// ViewerRecord is one continuous stay of a viewer in a live stream.
+type ViewerRecord struct {
+	types.BaseModel
+
+	LiveStreamID uuid.UUID `gorm:"type:uuid;not null;column:live_stream_id;index" json:"liveStreamId"`
+	ViewerID     uuid.UUID `gorm:"type:uuid;not null;column:viewer_id" json:"viewerId"`
+	JoinedAt     time.Time `gorm:"type:timestamp;not null;column:joined_at" json:"joinedAt"`
+	LeftAt       time.Time `gorm:"type:timestamp;not null;column:left_at" json:"leftAt"`
+}
+
+// TableName overrides the default table name.
+func (ViewerRecord) TableName() string { return "live_stream_viewers" }
+
+// Save persists a finished stream together with its viewer sessions.
+func Save(db *gorm.DB, record *StreamRecord, viewers []ViewerRecord) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		if len(viewers) == 0 {
+			return nil
+		}
+		for i := range viewers {
+			viewers[i].LiveStreamID = record.ID
+		}
+		return tx.CreateInBatches(viewers, 500).Error
+	})
+}
+
+// Latest returns the most recent finished run of the stream and its viewer sessions.
+func Latest(db *gorm.DB, streamID string) (StreamRecord, []ViewerRecord, error) {
+	var record StreamRecord
+	if err := db.Where("stream_id = ?", streamID).Order("started_at DESC").Limit(1).Find(&record).Error; err != nil {
+		return StreamRecord{}, nil, err
+	}
+	if record.ID == uuid.Nil {
+		return StreamRecord{}, nil, ErrStreamNotFound
+	}
+
+	var viewers []ViewerRecord
+	if err := db.Where("live_stream_id = ?", record.ID).Order("joined_at").Find(&viewers).Error; err != nil {
+		return StreamRecord{}, nil, err
+	}
+	return record, viewers, nil
+}
@@ -0,0 +1,14 @@
+package streamanalytics
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches stream analytics endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, allUsers []gin.HandlerFunc) {
+	router.GET("/streams/:streamId/analytics", append(allUsers, handler.Get)...)
+
+	openapi.Describe(handler.Get, openapi.Spec{Response: Report{}})
+}
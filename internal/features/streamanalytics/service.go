@@ -0,0 +1,71 @@
+package streamanalytics
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// Service persists viewer history when live streams end.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewService constructs a stream analytics service.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	return &Service{db: db, logger: logger}
+}
+
+// StreamEnded stores the finished stream and its viewer sessions. It is registered as
+// a socket server stream-ended hook.
+func (s *Service) StreamEnded(stream streamcache.Stream) {
+	hostID, err := uuid.Parse(stream.HostID)
+	if err != nil {
+		s.logger.Warn("skipping analytics for stream with invalid host", "streamId", stream.ID, "hostId", stream.HostID)
+		return
+	}
+
+	endedAt := time.Now().UTC()
+	if stream.EndTime != nil {
+		endedAt = *stream.EndTime
+	}
+
+	record := StreamRecord{
+		StreamID:  stream.ID,
+		HostID:    hostID,
+		Title:     stream.Title,
+		StartedAt: stream.StartTime.UTC(),
+		EndedAt:   endedAt,
+	}
+
+	var subscriptionIDs []uuid.UUID
+	if err := s.db.Table("users").Where("id = ? AND subscription_id IS NOT NULL", hostID).
+		Pluck("subscription_id", &subscriptionIDs).Error; err != nil {
+		s.logger.Warn("failed to resolve stream host subscription", "streamId", stream.ID, "error", err)
+	}
+	if len(subscriptionIDs) > 0 {
+		record.SubscriptionID = &subscriptionIDs[0]
+	}
+
+	viewers := make([]ViewerRecord, 0, len(stream.ViewerSessions))
+	for _, session := range stream.ViewerSessions {
+		viewerID, err := uuid.Parse(session.ViewerID)
+		if err != nil {
+			continue
+		}
+		leftAt := endedAt
+		if session.LeftAt != nil {
+			leftAt = *session.LeftAt
+		}
+		viewers = append(viewers, ViewerRecord{ViewerID: viewerID, JoinedAt: session.JoinedAt, LeftAt: leftAt})
+	}
+
+	if err := Save(s.db, &record, viewers); err != nil {
+		s.logger.Error("failed to persist stream analytics", "streamId", stream.ID, "error", err)
+	}
+}
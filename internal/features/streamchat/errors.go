@@ -0,0 +1,8 @@
+package streamchat
+
+import "errors"
+
+var (
+	ErrInvalidBannedWord = errors.New("banned words must be single words of at most 50 characters")
+	ErrTooManyWords      = errors.New("too many banned words")
+)
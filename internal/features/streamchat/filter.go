@@ -0,0 +1,81 @@
+package streamchat
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxBannedWords      = 500
+	maxBannedWordLength = 50
+)
+
+// NormalizeWords lowercases, trims and de-duplicates a banned word list.
+func NormalizeWords(words []string) ([]string, error) {
+	if len(words) > maxBannedWords {
+		return nil, ErrTooManyWords
+	}
+
+	seen := make(map[string]struct{}, len(words))
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		if utf8.RuneCountInString(word) > maxBannedWordLength || strings.IndexFunc(word, isSeparator) >= 0 {
+			return nil, ErrInvalidBannedWord
+		}
+		if _, dup := seen[word]; dup {
+			continue
+		}
+		seen[word] = struct{}{}
+		normalized = append(normalized, word)
+	}
+	return normalized, nil
+}
+
+// Censor masks every whole word of message found in banned with asterisks and reports
+// whether anything was masked. Matching is case-insensitive and works for any script.
+func Censor(message string, banned map[string]struct{}) (string, bool) {
+	if len(banned) == 0 {
+		return message, false
+	}
+
+	var b strings.Builder
+	censored := false
+	start := -1
+	flush := func(end int) {
+		word := message[start:end]
+		if _, hit := banned[strings.ToLower(word)]; hit {
+			b.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)))
+			censored = true
+		} else {
+			b.WriteString(word)
+		}
+		start = -1
+	}
+
+	for i, r := range message {
+		if isSeparator(r) {
+			if start >= 0 {
+				flush(i)
+			}
+			b.WriteRune(r)
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		flush(len(message))
+	}
+
+	return b.String(), censored
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+}
@@ -0,0 +1,75 @@
+package streamchat
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler serves stream chat settings.
+type Handler struct {
+	db      *gorm.DB
+	logger  *slog.Logger
+	service *Service
+}
+
+// NewHandler constructs a stream chat settings handler.
+func NewHandler(db *gorm.DB, logger *slog.Logger, service *Service) *Handler {
+	return &Handler{db: db, logger: logger, service: service}
+}
+
+// GetSettings returns the subscription's stream chat settings.
+// GET /subscriptions/:subscriptionId/stream-chat/settings
+func (h *Handler) GetSettings(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	settings, err := Get(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load stream chat settings", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, settings, "", nil)
+}
+
+type updateSettingsRequest struct {
+	BannedWords []string `json:"bannedWords" binding:"required,max=500"`
+}
+
+// UpdateSettings replaces the subscription's banned word list.
+// PUT /subscriptions/:subscriptionId/stream-chat/settings
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	var req updateSettingsRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid stream chat settings payload") {
+		return
+	}
+
+	settings, err := h.service.Save(subscriptionID, req.BannedWords)
+	if err != nil {
+		if errors.Is(err, ErrInvalidBannedWord) || errors.Is(err, ErrTooManyWords) {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to save stream chat settings", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, settings, "Stream chat settings updated", nil)
+}
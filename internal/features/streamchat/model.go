@@ -0,0 +1,46 @@
+package streamchat
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Settings holds a subscription's live stream chat moderation settings.
+type Settings struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex;column:subscription_id" json:"subscriptionId"`
+	BannedWords    pq.StringArray `gorm:"type:text[];not null;default:'{}';column:banned_words" json:"bannedWords"`
+}
+
+// TableName overrides the default table name.
+func (Settings) TableName() string { return "stream_chat_settings" }
+
+// Get returns the subscription's settings, or empty settings if none were saved.
+func Get(db *gorm.DB, subscriptionID uuid.UUID) (Settings, error) {
+	var settings Settings
+	if err := db.Where("subscription_id = ?", subscriptionID).Limit(1).Find(&settings).Error; err != nil {
+		return Settings{}, err
+	}
+	if settings.ID == uuid.Nil {
+		return Settings{SubscriptionID: subscriptionID, BannedWords: pq.StringArray{}}, nil
+	}
+	return settings, nil
+}
+
+// Save creates or replaces the subscription's banned word list.
+func Save(db *gorm.DB, subscriptionID uuid.UUID, bannedWords []string) (Settings, error) {
+	settings := Settings{SubscriptionID: subscriptionID, BannedWords: pq.StringArray(bannedWords)}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subscription_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"banned_words", "updated_at"}),
+	}).Create(&settings).Error
+	if err != nil {
+		return Settings{}, err
+	}
+	return Get(db, subscriptionID)
+}
@@ -0,0 +1,17 @@
+package streamchat
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches stream chat settings endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff []gin.HandlerFunc) {
+	settings := router.Group("/subscriptions/:subscriptionId/stream-chat/settings")
+	settings.GET("", append(acStaff, handler.GetSettings)...)
+	settings.PUT("", append(acStaff, handler.UpdateSettings)...)
+
+	openapi.Describe(handler.GetSettings, openapi.Spec{Response: Settings{}})
+	openapi.Describe(handler.UpdateSettings, openapi.Spec{Request: updateSettingsRequest{}, Response: Settings{}})
+}
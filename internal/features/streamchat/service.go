@@ -0,0 +1,86 @@
+package streamchat
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// settingsTTL bounds how long a subscription's banned words are served from memory;
+// saves through the service invalidate immediately.
+const settingsTTL = time.Minute
+
+type cachedWords struct {
+	words    map[string]struct{}
+	loadedAt time.Time
+}
+
+// Service serves banned word lists to the socket server without a query per message.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedWords
+}
+
+// NewService constructs a stream chat service.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	return &Service{db: db, logger: logger, cache: make(map[uuid.UUID]cachedWords)}
+}
+
+// Save stores the subscription's banned words and drops the cached copy.
+func (s *Service) Save(subscriptionID uuid.UUID, words []string) (Settings, error) {
+	normalized, err := NormalizeWords(words)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings, err := Save(s.db, subscriptionID, normalized)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, subscriptionID)
+	s.mu.Unlock()
+	return settings, nil
+}
+
+// Filter masks the subscription's banned words in message. Messages of streams without
+// a subscription, or whose settings cannot be loaded, pass through unchanged.
+func (s *Service) Filter(subscriptionID, message string) (string, bool) {
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return message, false
+	}
+	return Censor(message, s.bannedWords(id))
+}
+
+func (s *Service) bannedWords(subscriptionID uuid.UUID) map[string]struct{} {
+	s.mu.Lock()
+	cached, ok := s.cache[subscriptionID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < settingsTTL {
+		return cached.words
+	}
+
+	settings, err := Get(s.db, subscriptionID)
+	if err != nil {
+		s.logger.Warn("failed to load stream chat settings", "subscriptionId", subscriptionID, "error", err)
+		return cached.words
+	}
+
+	words := make(map[string]struct{}, len(settings.BannedWords))
+	for _, word := range settings.BannedWords {
+		words[word] = struct{}{}
+	}
+
+	s.mu.Lock()
+	s.cache[subscriptionID] = cachedWords{words: words, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return words
+}
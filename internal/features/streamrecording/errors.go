@@ -0,0 +1,31 @@
+package streamrecording
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrRecordingNotFound    = errors.New("recording not found")
+	ErrCourseNotFound       = errors.New("course not found")
+	ErrCourseMissingVideos  = errors.New("course has no Bunny Stream collection")
+	ErrStreamNotLive        = errors.New("stream is not live")
+	ErrNotStreamHost        = errors.New("only the stream host can record it")
+	ErrAlreadyRecording     = errors.New("stream is already being recorded")
+	ErrRecordingFinalized   = errors.New("recording has already been converted")
+	ErrRecordingUnavailable = errors.New("recording is not configured")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrRecordingNotFound, Status: http.StatusNotFound, Code: "RECORDING_NOT_FOUND", Message: "Recording not found."},
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrStreamNotLive, Status: http.StatusNotFound, Code: "STREAM_NOT_LIVE", Message: "Stream is not live."},
+	{Err: ErrNotStreamHost, Status: http.StatusForbidden, Code: "NOT_STREAM_HOST", Message: "Only the stream host can manage its recording."},
+	{Err: ErrCourseMissingVideos, Status: http.StatusBadRequest, Code: "COURSE_MISSING_VIDEOS"},
+	{Err: ErrAlreadyRecording, Status: http.StatusConflict, Code: "ALREADY_RECORDING"},
+	{Err: ErrRecordingFinalized, Status: http.StatusConflict, Code: "RECORDING_FINALIZED"},
+	{Err: ErrRecordingUnavailable, Status: http.StatusServiceUnavailable, Code: "RECORDING_UNAVAILABLE", Message: "Recording is not available."},
+}
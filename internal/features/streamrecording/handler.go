@@ -0,0 +1,188 @@
+package streamrecording
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// Handler processes stream recording HTTP requests.
+type Handler struct {
+	db      *gorm.DB
+	logger  *slog.Logger
+	service *Service
+	streams *streamcache.Cache
+}
+
+// NewHandler constructs a stream recording handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger, service *Service, streams *streamcache.Cache) *Handler {
+	return &Handler{db: db, logger: logger, service: service, streams: streams}
+}
+
+type startRequest struct {
+	StreamID string `json:"streamId" binding:"required"`
+}
+
+// Start begins recording a live stream hosted by the current user into the course.
+func (h *Handler) Start(c *gin.Context) {
+	subscriptionID, courseID, ok := h.parseScope(c)
+	if !ok {
+		return
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	var req startRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid recording payload") {
+		return
+	}
+
+	stream, found := h.streams.GetStream(req.StreamID)
+	if !found || !stream.IsLive {
+		h.respondError(c, ErrStreamNotLive, "failed to start recording")
+		return
+	}
+	if stream.HostID != currentUser.ID.String() {
+		h.respondError(c, ErrNotStreamHost, "failed to start recording")
+		return
+	}
+
+	recording, upload, err := h.service.Start(c.Request.Context(), StartInput{
+		SubscriptionID: subscriptionID,
+		CourseID:       courseID,
+		HostID:         currentUser.ID,
+		Stream:         *stream,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to start recording")
+		return
+	}
+
+	response.Created(c, gin.H{
+		"recording": recording,
+		"upload":    upload,
+	}, "Recording started. Upload the recording using the TUS details.")
+}
+
+// List returns the course's stream recordings.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, courseID, ok := h.parseScope(c)
+	if !ok {
+		return
+	}
+
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
+	recordings, total, err := ListByCourse(h.db, subscriptionID, courseID, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list recordings", err)
+		return
+	}
+
+	recordings, meta := pagination.Page(recordings, total, params)
+	response.Success(c, http.StatusOK, recordings, "", meta)
+}
+
+// Complete marks the upload as finished and converts the recording once the stream has ended.
+func (h *Handler) Complete(c *gin.Context) {
+	recording, ok := h.loadControlled(c)
+	if !ok {
+		return
+	}
+
+	stream, found := h.streams.GetStream(recording.StreamID)
+	streamLive := found && stream.IsLive
+
+	updated, err := h.service.Complete(c.Request.Context(), recording, streamLive)
+	if err != nil {
+		h.respondError(c, err, "failed to complete recording")
+		return
+	}
+
+	response.Success(c, http.StatusOK, updated, "", nil)
+}
+
+// Discard deletes an unconverted recording and its uploaded video.
+func (h *Handler) Discard(c *gin.Context) {
+	recording, ok := h.loadControlled(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Discard(c.Request.Context(), recording); err != nil {
+		h.respondError(c, err, "failed to discard recording")
+		return
+	}
+
+	response.Success(c, http.StatusOK, true, "Recording discarded.", nil)
+}
+
+// loadControlled loads the recording and requires the stream host or a platform admin.
+func (h *Handler) loadControlled(c *gin.Context) (Recording, bool) {
+	subscriptionID, courseID, ok := h.parseScope(c)
+	if !ok {
+		return Recording{}, false
+	}
+
+	recordingID, err := uuid.Parse(c.Param("recordingId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid recording id", err)
+		return Recording{}, false
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return Recording{}, false
+	}
+
+	recording, err := Get(h.db, subscriptionID, courseID, recordingID)
+	if err != nil {
+		h.respondError(c, err, "failed to load recording")
+		return Recording{}, false
+	}
+
+	if !authz.CanControlSession(authz.SubjectFrom(currentUser), recording.HostID.String()) {
+		h.respondError(c, ErrNotStreamHost, "failed to update recording")
+		return Recording{}, false
+	}
+
+	return recording, true
+}
+
+func (h *Handler) parseScope(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return subscriptionID, courseID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,101 @@
+package streamrecording
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Status tracks a recording from upload to lesson draft.
+type Status string
+
+const (
+	// StatusPending means the Bunny video exists and the host is still uploading.
+	StatusPending Status = "pending"
+	// StatusUploaded means the host finished uploading; conversion waits for the stream to end.
+	StatusUploaded Status = "uploaded"
+	// StatusConverted means a lesson draft was created from the recording.
+	StatusConverted Status = "converted"
+)
+
+// Recording links a live stream to the Bunny Stream video it was recorded into.
+type Recording struct {
+	types.BaseModel
+
+	SubscriptionID  uuid.UUID  `gorm:"type:uuid;not null;column:subscription_id" json:"subscriptionId"`
+	CourseID        uuid.UUID  `gorm:"type:uuid;not null;column:course_id;index" json:"courseId"`
+	HostID          uuid.UUID  `gorm:"type:uuid;not null;column:host_id" json:"hostId"`
+	StreamID        string     `gorm:"type:varchar(100);not null;column:stream_id;index" json:"streamId"`
+	Title           string     `gorm:"type:varchar(80);not null" json:"title"`
+	Description     string     `gorm:"type:varchar(1000)" json:"description,omitempty"`
+	VideoID         string     `gorm:"type:varchar(255);not null;column:video_id" json:"videoId"`
+	Status          Status     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	StreamStartedAt time.Time  `gorm:"type:timestamp;not null;column:stream_started_at" json:"streamStartedAt"`
+	StreamEndedAt   *time.Time `gorm:"type:timestamp;column:stream_ended_at" json:"streamEndedAt,omitempty"`
+	LessonID        *uuid.UUID `gorm:"type:uuid;column:lesson_id" json:"lessonId,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Recording) TableName() string { return "stream_recordings" }
+
+// Get retrieves a recording of the given subscription course.
+func Get(db *gorm.DB, subscriptionID, courseID, id uuid.UUID) (Recording, error) {
+	var recording Recording
+	err := db.Where("id = ? AND course_id = ? AND subscription_id = ?", id, courseID, subscriptionID).Limit(1).Find(&recording).Error
+	if err != nil {
+		return Recording{}, err
+	}
+	if recording.ID == uuid.Nil {
+		return Recording{}, ErrRecordingNotFound
+	}
+	return recording, nil
+}
+
+// ListByCourse returns the course's recordings, newest first.
+func ListByCourse(db *gorm.DB, subscriptionID, courseID uuid.UUID, params pagination.Params) ([]Recording, int64, error) {
+	query := db.Model(&Recording{}).Where("course_id = ? AND subscription_id = ?", courseID, subscriptionID)
+
+	var total int64
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	recordings := make([]Recording, 0)
+	if err := pagination.Apply(query, params).Find(&recordings).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return recordings, total, nil
+}
+
+// ActiveForStream returns the recording of a stream that has not been converted yet, if any.
+func ActiveForStream(db *gorm.DB, streamID string) (*Recording, error) {
+	var recording Recording
+	err := db.Where("stream_id = ? AND status <> ?", streamID, StatusConverted).
+		Order("created_at DESC").Limit(1).Find(&recording).Error
+	if err != nil {
+		return nil, err
+	}
+	if recording.ID == uuid.Nil {
+		return nil, nil
+	}
+	return &recording, nil
+}
+
+// MarkStreamEnded stamps the end time on every unfinished recording of the stream.
+func MarkStreamEnded(db *gorm.DB, streamID string, endedAt time.Time) error {
+	return db.Model(&Recording{}).
+		Where("stream_id = ? AND status <> ? AND stream_ended_at IS NULL", streamID, StatusConverted).
+		Update("stream_ended_at", endedAt).Error
+}
+
+// Delete removes a recording row.
+func Delete(db *gorm.DB, id uuid.UUID) error {
+	return db.Delete(&Recording{}, "id = ?", id).Error
+}
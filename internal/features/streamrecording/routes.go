@@ -0,0 +1,21 @@
+package streamrecording
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches stream recording endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acContent []gin.HandlerFunc) {
+	recordings := router.Group("/subscriptions/:subscriptionId/courses/:courseId/recordings")
+
+	recordings.GET("", append(acContent, handler.List)...)
+	recordings.POST("", append(acContent, handler.Start)...)
+	recordings.POST("/:recordingId/complete", append(acContent, handler.Complete)...)
+	recordings.DELETE("/:recordingId", append(acContent, handler.Discard)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Recording{}})
+	openapi.Describe(handler.Start, openapi.Spec{Request: startRequest{}})
+	openapi.Describe(handler.Complete, openapi.Spec{Response: Recording{}})
+}
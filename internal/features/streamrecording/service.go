@@ -0,0 +1,268 @@
+package streamrecording
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	coursefeature "github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// uploadWindowSeconds is how long the TUS upload signature stays valid; long streams
+// are uploaded while they run, so it covers the maximum stream duration plus slack.
+const uploadWindowSeconds = 6 * 60 * 60
+
+// Service records live streams into Bunny Stream and turns finished recordings into
+// lesson drafts.
+type Service struct {
+	db           *gorm.DB
+	logger       *slog.Logger
+	streamClient *bunny.StreamClient
+	storageUsage *storageusage.Service
+}
+
+// NewService constructs a recording service. streamClient may be nil, in which case
+// recording is unavailable.
+func NewService(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClient, storageUsage *storageusage.Service) *Service {
+	return &Service{db: db, logger: logger, streamClient: streamClient, storageUsage: storageUsage}
+}
+
+// StartInput carries the stream being recorded and the course that will own the lesson.
+type StartInput struct {
+	SubscriptionID uuid.UUID
+	CourseID       uuid.UUID
+	HostID         uuid.UUID
+	Stream         streamcache.Stream
+}
+
+// Start creates the Bunny video in the course collection and returns the TUS upload
+// details the host (or an SFU-side recorder) uses to upload the recording in chunks.
+func (s *Service) Start(ctx context.Context, input StartInput) (Recording, *bunny.TusUploadInfo, error) {
+	if s.streamClient == nil {
+		return Recording{}, nil, ErrRecordingUnavailable
+	}
+
+	course, err := coursefeature.Get(s.db, input.CourseID)
+	if err != nil {
+		if errors.Is(err, coursefeature.ErrCourseNotFound) {
+			return Recording{}, nil, ErrCourseNotFound
+		}
+		return Recording{}, nil, err
+	}
+	if course.SubscriptionID != input.SubscriptionID {
+		return Recording{}, nil, ErrCourseNotFound
+	}
+	if course.CollectionID == nil || *course.CollectionID == "" {
+		return Recording{}, nil, ErrCourseMissingVideos
+	}
+
+	existing, err := ActiveForStream(s.db, input.Stream.ID)
+	if err != nil {
+		return Recording{}, nil, err
+	}
+	if existing != nil {
+		return Recording{}, nil, ErrAlreadyRecording
+	}
+
+	title := lessonTitle(input.Stream.Title)
+	tusInfo, err := s.streamClient.GenerateTusUploadInfo(ctx, title, *course.CollectionID, uploadWindowSeconds)
+	if err != nil {
+		return Recording{}, nil, err
+	}
+
+	recording := Recording{
+		SubscriptionID:  input.SubscriptionID,
+		CourseID:        input.CourseID,
+		HostID:          input.HostID,
+		StreamID:        input.Stream.ID,
+		Title:           title,
+		Description:     truncate(input.Stream.Description, 1000),
+		VideoID:         tusInfo.VideoID,
+		Status:          StatusPending,
+		StreamStartedAt: input.Stream.StartTime,
+	}
+
+	if err := s.db.Create(&recording).Error; err != nil {
+		if delErr := s.streamClient.DeleteVideo(ctx, tusInfo.VideoID); delErr != nil {
+			s.logger.Warn("failed to delete orphaned recording video", "videoId", tusInfo.VideoID, "error", delErr)
+		}
+		return Recording{}, nil, err
+	}
+
+	return recording, tusInfo, nil
+}
+
+// Complete marks the upload as finished. If the stream has already ended the
+// recording is converted into a lesson draft right away.
+func (s *Service) Complete(ctx context.Context, recording Recording, streamLive bool) (Recording, error) {
+	if recording.Status == StatusConverted {
+		return recording, ErrRecordingFinalized
+	}
+
+	updates := map[string]interface{}{"status": StatusUploaded}
+	if !streamLive && recording.StreamEndedAt == nil {
+		// The end hook never fired (e.g. the server restarted mid-stream)
+		now := time.Now().UTC()
+		updates["stream_ended_at"] = now
+		recording.StreamEndedAt = &now
+	}
+
+	if err := s.db.Model(&Recording{}).Where("id = ?", recording.ID).Updates(updates).Error; err != nil {
+		return recording, err
+	}
+	recording.Status = StatusUploaded
+
+	if recording.StreamEndedAt == nil {
+		return recording, nil
+	}
+	return s.convert(ctx, recording)
+}
+
+// Discard deletes a recording that has not been converted, along with its Bunny video.
+func (s *Service) Discard(ctx context.Context, recording Recording) error {
+	if recording.Status == StatusConverted {
+		return ErrRecordingFinalized
+	}
+
+	if s.streamClient != nil {
+		if err := s.streamClient.DeleteVideo(ctx, recording.VideoID); err != nil {
+			s.logger.Warn("failed to delete recording video", "videoId", recording.VideoID, "error", err)
+		}
+	}
+
+	return Delete(s.db, recording.ID)
+}
+
+// StreamEnded is registered as the socket server's stream-ended hook. Recordings whose
+// upload already finished are converted immediately; the rest convert on Complete.
+func (s *Service) StreamEnded(stream streamcache.Stream) {
+	endedAt := time.Now().UTC()
+	if stream.EndTime != nil {
+		endedAt = *stream.EndTime
+	}
+
+	if err := MarkStreamEnded(s.db, stream.ID, endedAt); err != nil {
+		s.logger.Error("failed to mark recording stream as ended", "streamId", stream.ID, "error", err)
+		return
+	}
+
+	recording, err := ActiveForStream(s.db, stream.ID)
+	if err != nil {
+		s.logger.Error("failed to load stream recording", "streamId", stream.ID, "error", err)
+		return
+	}
+	if recording == nil || recording.Status != StatusUploaded {
+		return
+	}
+
+	if _, err := s.convert(context.Background(), *recording); err != nil {
+		s.logger.Error("failed to convert stream recording", "recordingId", recording.ID, "error", err)
+	}
+}
+
+// convert creates an inactive lesson for the recording. The status flip is guarded so
+// the end hook and Complete racing each other only produce one lesson.
+func (s *Service) convert(ctx context.Context, recording Recording) (Recording, error) {
+	duration := s.recordingDuration(ctx, recording)
+	inactive := false
+	var description *string
+	if recording.Description != "" {
+		description = &recording.Description
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Recording{}).
+			Where("id = ? AND status = ?", recording.ID, StatusUploaded).
+			Update("status", StatusConverted)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordingFinalized
+		}
+
+		var nextOrder int
+		if err := tx.Model(&lesson.Lesson{}).
+			Where("course_id = ?", recording.CourseID).
+			Select("COALESCE(MAX(\"order\") + 1, 0)").
+			Scan(&nextOrder).Error; err != nil {
+			return err
+		}
+
+		draft, err := lesson.Create(tx, lesson.CreateInput{
+			CourseID:    recording.CourseID,
+			VideoID:     recording.VideoID,
+			Name:        recording.Title,
+			Description: description,
+			Duration:    &duration,
+			Order:       &nextOrder,
+			Active:      &inactive,
+		})
+		if err != nil {
+			return err
+		}
+
+		recording.LessonID = &draft.ID
+		recording.Status = StatusConverted
+		return tx.Model(&Recording{}).Where("id = ?", recording.ID).Update("lesson_id", draft.ID).Error
+	})
+	if err != nil {
+		return recording, err
+	}
+
+	if s.storageUsage != nil {
+		if _, err := s.storageUsage.UpdateCourseStorage(ctx, recording.CourseID); err != nil {
+			s.logger.Warn("failed to refresh course storage usage", "courseId", recording.CourseID, "error", err)
+		}
+	}
+
+	s.logger.Info("stream recording converted to lesson draft",
+		"recordingId", recording.ID, "streamId", recording.StreamID, "lessonId", recording.LessonID)
+
+	return recording, nil
+}
+
+// recordingDuration prefers the length Bunny reports and falls back to the stream's
+// wall-clock duration while the video is still encoding.
+func (s *Service) recordingDuration(ctx context.Context, recording Recording) int {
+	if s.streamClient != nil {
+		if status, err := s.streamClient.GetVideoStatus(ctx, recording.VideoID); err == nil && status.Length > 0 {
+			return status.Length
+		}
+	}
+	if recording.StreamEndedAt == nil {
+		return 0
+	}
+	seconds := int(recording.StreamEndedAt.Sub(recording.StreamStartedAt).Seconds())
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// lessonTitle fits the stream title into the lesson name limits (3-80 characters).
+func lessonTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) < 3 {
+		title = "Live Stream Recording"
+	}
+	return truncate(title, 80)
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
@@ -2,7 +2,9 @@ package subscription
 
 import (
 	"errors"
+	"net/http"
 
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
@@ -12,6 +14,7 @@ var (
 	ErrSubscriptionTaken    = errors.New("user already has a subscription or identifier is taken")
 	ErrPackageNotFound      = errors.New("subscription package not found")
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrStorageLimitExceeded = errors.New("subscription storage limit exceeded")
 )
 
 var (
@@ -23,3 +26,12 @@ var (
 	defaultWatchLimit             = 2
 	defaultWatchInterval          = 240
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: ErrUserNotFound.Error()},
+	{Err: ErrSubscriptionNotFound, Status: http.StatusNotFound, Code: "SUBSCRIPTION_NOT_FOUND", Message: ErrSubscriptionNotFound.Error()},
+	{Err: ErrPackageNotFound, Status: http.StatusNotFound, Code: "PACKAGE_NOT_FOUND", Message: ErrPackageNotFound.Error()},
+	{Err: ErrUserHasSubscription, Status: http.StatusBadRequest, Code: "USER_HAS_SUBSCRIPTION", Message: ErrUserHasSubscription.Error()},
+	{Err: ErrSubscriptionTaken, Status: http.StatusConflict, Code: "SUBSCRIPTION_TAKEN", Message: ErrSubscriptionTaken.Error()},
+}
@@ -0,0 +1,140 @@
+package subscription
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+)
+
+// Outbox topics published when a subscription changes state.
+const (
+	TopicActivated = "subscription.activated"
+	TopicRenewed   = "subscription.renewed"
+	TopicExpired   = "subscription.expired"
+)
+
+// Event sources, recorded so subscribers can tell who changed the subscription.
+const (
+	SourceAdmin      = "admin"
+	SourceIAP        = "iap"
+	SourceExpiration = "expiration"
+)
+
+// Event is the outbox payload for subscription state changes.
+type Event struct {
+	SubscriptionID  uuid.UUID `json:"subscriptionId"`
+	UserID          uuid.UUID `json:"userId"`
+	SubscriptionEnd time.Time `json:"subscriptionEnd"`
+	Source          string    `json:"source"`
+}
+
+// DecodeEvent reads the subscription payload of an outbox event.
+func DecodeEvent(e outbox.Event) (Event, error) {
+	var ev Event
+	err := e.Decode(&ev)
+	return ev, err
+}
+
+func publish(tx *gorm.DB, topic string, sub Subscription, source string) error {
+	return outbox.Publish(tx, topic, sub.ID, Event{
+		SubscriptionID:  sub.ID,
+		UserID:          sub.UserID,
+		SubscriptionEnd: sub.SubscriptionEnd,
+		Source:          source,
+	})
+}
+
+// publishCreated announces a subscription created active.
+func publishCreated(tx *gorm.DB, sub Subscription, source string) error {
+	if !sub.Active {
+		return nil
+	}
+	if source == "" {
+		source = SourceAdmin
+	}
+	return publish(tx, TopicActivated, sub, source)
+}
+
+// publishUpdated derives the state change of an admin update from the rows
+// before and after it.
+func publishUpdated(tx *gorm.DB, before, after Subscription) error {
+	switch {
+	case !before.Active && after.Active:
+		return publish(tx, TopicActivated, after, SourceAdmin)
+	case before.Active && !after.Active:
+		return publish(tx, TopicExpired, after, SourceAdmin)
+	case after.Active && after.SubscriptionEnd.After(before.SubscriptionEnd):
+		return publish(tx, TopicRenewed, after, SourceAdmin)
+	}
+	return nil
+}
+
+// Renew moves the end date forward (never back), reactivates the subscription,
+// clears any grace period and publishes subscription.renewed. It reports whether
+// the subscription changed.
+func Renew(db *gorm.DB, id uuid.UUID, end time.Time, source string) (bool, error) {
+	changed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var sub Subscription
+		result := tx.Model(&sub).
+			Clauses(clause.Returning{}).
+			Where("id = ? AND subscription_end < ?", id, end).
+			Updates(map[string]interface{}{
+				"subscription_end": end.UTC(),
+				"is_active":        true,
+				"grace_until":      nil,
+				"dunning_stage":    0,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		changed = true
+		return publish(tx, TopicRenewed, sub, source)
+	})
+	return changed, err
+}
+
+// Activate reactivates the subscription and publishes subscription.activated.
+// It reports whether the subscription was inactive.
+func Activate(db *gorm.DB, id uuid.UUID, source string) (bool, error) {
+	changed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var sub Subscription
+		result := tx.Model(&sub).
+			Clauses(clause.Returning{}).
+			Where("id = ? AND is_active = ?", id, false).
+			Update("is_active", true)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		changed = true
+		return publish(tx, TopicActivated, sub, source)
+	})
+	return changed, err
+}
+
+// Expire deactivates the subscription and publishes subscription.expired. It
+// reports whether the subscription was active.
+func Expire(db *gorm.DB, id uuid.UUID, source string) (bool, error) {
+	changed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var sub Subscription
+		result := tx.Model(&sub).
+			Clauses(clause.Returning{}).
+			Where("id = ? AND is_active = ?", id, true).
+			Update("is_active", false)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		changed = true
+		return publish(tx, TopicExpired, sub, source)
+	})
+	return changed, err
+}
@@ -0,0 +1,178 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/emailqueue"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/email"
+)
+
+const dunningBatchSize = 200
+
+// ExpirationJob moves expired subscriptions through the grace period: it opens the
+// grace window, emails the owner on each dunning day, and deactivates the
+// subscription once the window closes. Renewed subscriptions leave the flow.
+type ExpirationJob struct {
+	db          *gorm.DB
+	logger      *slog.Logger
+	mail        *emailqueue.Queue
+	graceDays   int
+	dunningDays []int
+}
+
+// NewExpirationJob constructs the subscription expiration job.
+func NewExpirationJob(db *gorm.DB, logger *slog.Logger, mail *emailqueue.Queue, cfg config.SubscriptionConfig) *ExpirationJob {
+	return &ExpirationJob{
+		db:          db,
+		logger:      logger,
+		mail:        mail,
+		graceDays:   cfg.GraceDays,
+		dunningDays: cfg.DunningDays,
+	}
+}
+
+// Name returns the job name.
+func (j *ExpirationJob) Name() string {
+	return "subscription-expiration"
+}
+
+// Execute runs one pass of the grace and dunning flow.
+func (j *ExpirationJob) Execute(ctx context.Context) error {
+	db := j.db.WithContext(ctx)
+	now := time.Now().UTC()
+
+	renewed := db.Model(&Subscription{}).
+		Where("grace_until IS NOT NULL AND subscription_end > ?", now).
+		Updates(map[string]interface{}{"grace_until": nil, "dunning_stage": 0})
+	if renewed.Error != nil {
+		return fmt.Errorf("clear grace for renewed subscriptions: %w", renewed.Error)
+	}
+
+	// A zero-day grace period still records grace_until so the dunning emails go out.
+	started := db.Exec(`UPDATE subscriptions
+		SET grace_until = subscription_end + make_interval(days => ?), updated_at = NOW()
+		WHERE is_active = true AND grace_until IS NULL AND subscription_end <= ?`, j.graceDays, now)
+	if started.Error != nil {
+		return fmt.Errorf("start grace periods: %w", started.Error)
+	}
+
+	sent, err := j.sendDunning(db, now)
+	if err != nil {
+		return err
+	}
+
+	var expired []Subscription
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&expired).
+			Clauses(clause.Returning{}).
+			Where("is_active = ? AND grace_until <= ? AND subscription_end <= ?", true, now, now).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		for _, sub := range expired {
+			if err := publish(tx, TopicExpired, sub, SourceExpiration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("deactivate subscriptions past grace: %w", err)
+	}
+
+	if renewed.RowsAffected > 0 || started.RowsAffected > 0 || sent > 0 || len(expired) > 0 {
+		j.logger.Info("subscription expiration pass completed",
+			"renewed", renewed.RowsAffected,
+			"enteredGrace", started.RowsAffected,
+			"dunningEmails", sent,
+			"deactivated", len(expired),
+		)
+	}
+
+	return nil
+}
+
+type dunningRow struct {
+	ID                uuid.UUID
+	IdentifierName    string
+	DisplayName       *string
+	SubscriptionEnd   time.Time
+	GraceUntil        time.Time
+	DunningStage      int
+	Email             string
+	FullName          string
+	PreferredLanguage string
+	Timezone          string
+}
+
+// sendDunning queues the reminder for every subscription that reached a new
+// dunning day. Only the latest due reminder is sent when several are overdue.
+func (j *ExpirationJob) sendDunning(db *gorm.DB, now time.Time) (int, error) {
+	if len(j.dunningDays) == 0 {
+		return 0, nil
+	}
+
+	var rows []dunningRow
+	err := db.Table("subscriptions AS s").
+		Select("s.id, s.identifier_name, s.display_name, s.subscription_end, s.grace_until, s.dunning_stage, u.email, u.full_name, u.preferred_language, u.timezone").
+		Joins("JOIN users u ON u.id = s.user_id").
+		Where("s.grace_until IS NOT NULL AND s.subscription_end <= ? AND s.dunning_stage < ?", now, len(j.dunningDays)).
+		Order("s.subscription_end ASC").
+		Limit(dunningBatchSize).
+		Scan(&rows).Error
+	if err != nil {
+		return 0, fmt.Errorf("load subscriptions for dunning: %w", err)
+	}
+
+	sent := 0
+	for _, row := range rows {
+		stage := j.dueStage(now.Sub(row.SubscriptionEnd))
+		if stage <= row.DunningStage {
+			continue
+		}
+
+		name := row.IdentifierName
+		if row.DisplayName != nil && *row.DisplayName != "" {
+			name = *row.DisplayName
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&Subscription{}).
+				Where("id = ? AND dunning_stage = ?", row.ID, row.DunningStage).
+				Update("dunning_stage", stage)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			return j.mail.EnqueueTx(tx, emailqueue.KindDunning,
+				email.SubscriptionDunningMessage(row.Email, row.FullName, name, row.SubscriptionEnd, row.GraceUntil,
+					email.LocaleFor(row.PreferredLanguage, row.Timezone)))
+		})
+		if err != nil {
+			j.logger.Error("failed to queue dunning email", "subscriptionId", row.ID, "stage", stage, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// dueStage returns how many dunning days have passed since expiry.
+func (j *ExpirationJob) dueStage(sinceExpiry time.Duration) int {
+	days := int(sinceExpiry.Hours() / 24)
+	stage := 0
+	for _, day := range j.dunningDays {
+		if days >= day {
+			stage++
+		}
+	}
+	return stage
+}
@@ -1,7 +1,6 @@
 package subscription
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/features/setting"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
 	"github.com/mo-amir99/lms-server-go/pkg/cleanup"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
@@ -28,6 +28,9 @@ type Handler struct {
 	logger        *slog.Logger
 	streamClient  *bunny.StreamClient
 	storageClient *bunny.StorageClient
+	settings      *setting.Service
+
+	activationHooks []func(Subscription)
 }
 
 // NewHandler constructs a subscription handler instance.
@@ -40,9 +43,49 @@ func NewHandler(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClie
 	}
 }
 
+// UseSettings makes new subscriptions take their default watch limit and
+// interval from the runtime settings.
+func (h *Handler) UseSettings(service *setting.Service) {
+	h.settings = service
+}
+
+// applyWatchDefaults fills the watch limit and interval the request left unset.
+func (h *Handler) applyWatchDefaults(input *CreateInput) {
+	if h.settings == nil {
+		return
+	}
+	if input.WatchLimit == nil {
+		limit := h.settings.Int(setting.KeyDefaultWatchLimit)
+		input.WatchLimit = &limit
+	}
+	if input.WatchInterval == nil {
+		interval := h.settings.Int(setting.KeyDefaultWatchIntervalMinutes)
+		input.WatchInterval = &interval
+	}
+}
+
+// OnActivated registers a callback invoked after a subscription is created or
+// switched to active.
+func (h *Handler) OnActivated(fn func(sub Subscription)) {
+	h.activationHooks = append(h.activationHooks, fn)
+}
+
+func (h *Handler) notifyActivated(sub Subscription) {
+	if !sub.Active {
+		return
+	}
+	for _, hook := range h.activationHooks {
+		hook(sub)
+	}
+}
+
 // List returns paginated subscriptions.
 func (h *Handler) List(c *gin.Context) {
-	params := pagination.Extract(c)
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
 	keyword := c.Query("filterKeyword")
 
 	items, total, err := List(h.db, params, keyword)
@@ -51,21 +94,24 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, http.StatusOK, items, "", pagination.MetadataFrom(total, params))
+	items, meta := pagination.Page(items, total, params)
+	response.Success(c, http.StatusOK, items, "", meta)
 }
 
 type createRequest struct {
-	User                   string   `json:"user" binding:"required"`
+	User                   string   `json:"user" binding:"required,id"`
 	DisplayName            *string  `json:"displayName"`
-	IdentifierName         string   `json:"identifierName" binding:"required"`
-	SubscriptionPoints     *int     `json:"SubscriptionPoints"`
-	SubscriptionPointPrice *float64 `json:"SubscriptionPointPrice"`
-	CourseLimitInGB        *float64 `json:"CourseLimitInGB"`
-	CoursesLimit           *int     `json:"CoursesLimit"`
-	AssistantsLimit        *int     `json:"assistantsLimit"`
-	WatchLimit             *int     `json:"watchLimit"`
-	WatchInterval          *int     `json:"watchInterval"`
-	SubscriptionEnd        *string  `json:"subscriptionEnd"`
+	IdentifierName         string   `json:"identifierName" binding:"required,identifier"`
+	SubscriptionPoints     *int     `json:"SubscriptionPoints" binding:"omitnil,gte=0"`
+	SubscriptionPointPrice *float64 `json:"SubscriptionPointPrice" binding:"omitnil,gte=0"`
+	CourseLimitInGB        *float64 `json:"CourseLimitInGB" binding:"omitnil,gte=0"`
+	CoursesLimit           *int     `json:"CoursesLimit" binding:"omitnil,gte=0"`
+	StorageLimitInGB       *float64 `json:"storageLimitInGB" binding:"omitnil,gte=0"`
+	AssistantsLimit        *int     `json:"assistantsLimit" binding:"omitnil,gte=0"`
+	WatchLimit             *int     `json:"watchLimit" binding:"omitnil,gte=0"`
+	WatchInterval          *int     `json:"watchInterval" binding:"omitnil,gte=0"`
+	RateLimitPerMinute     *int     `json:"rateLimitPerMinute" binding:"omitnil,gte=0"`
+	SubscriptionEnd        *string  `json:"subscriptionEnd" binding:"omitempty,rfc3339"`
 	RequireSameDeviceID    *bool    `json:"isRequireSameDeviceId"`
 	Active                 *bool    `json:"isActive"`
 }
@@ -73,8 +119,7 @@ type createRequest struct {
 // Create inserts a new subscription.
 func (h *Handler) Create(c *gin.Context) {
 	var req createRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid subscription payload") {
 		return
 	}
 
@@ -111,13 +156,16 @@ func (h *Handler) Create(c *gin.Context) {
 		SubscriptionPointPrice: subscriptionPointPrice,
 		CourseLimitInGB:        req.CourseLimitInGB,
 		CoursesLimit:           req.CoursesLimit,
+		StorageLimitInGB:       req.StorageLimitInGB,
 		AssistantsLimit:        req.AssistantsLimit,
 		WatchLimit:             req.WatchLimit,
 		WatchInterval:          req.WatchInterval,
+		RateLimitPerMinute:     req.RateLimitPerMinute,
 		SubscriptionEnd:        subscriptionEnd,
 		RequireSameDeviceID:    req.RequireSameDeviceID,
 		Active:                 req.Active,
 	}
+	h.applyWatchDefaults(&input)
 
 	sub, err := Create(h.db, input)
 	if err != nil {
@@ -125,19 +173,20 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	h.notifyActivated(sub)
+
 	response.Created(c, sub, "")
 }
 
 type createFromPackageRequest struct {
 	createRequest
-	PackageID string `json:"packageId" binding:"required"`
+	PackageID string `json:"packageId" binding:"required,id"`
 }
 
 // CreateFromPackage seeds a subscription using package defaults.
 func (h *Handler) CreateFromPackage(c *gin.Context) {
 	var req createFromPackageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid subscription payload") {
 		return
 	}
 
@@ -186,15 +235,18 @@ func (h *Handler) CreateFromPackage(c *gin.Context) {
 			SubscriptionPointPrice: subscriptionPointPrice,
 			CourseLimitInGB:        req.CourseLimitInGB,
 			CoursesLimit:           req.CoursesLimit,
+			StorageLimitInGB:       req.StorageLimitInGB,
 			AssistantsLimit:        req.AssistantsLimit,
 			WatchLimit:             req.WatchLimit,
 			WatchInterval:          req.WatchInterval,
+			RateLimitPerMinute:     req.RateLimitPerMinute,
 			SubscriptionEnd:        subscriptionEnd,
 			RequireSameDeviceID:    req.RequireSameDeviceID,
 			Active:                 req.Active,
 		},
 		PackageID: packageID,
 	}
+	h.applyWatchDefaults(&input.CreateInput)
 
 	sub, err := CreateFromPackage(h.db, input)
 	if err != nil {
@@ -202,6 +254,8 @@ func (h *Handler) CreateFromPackage(c *gin.Context) {
 		return
 	}
 
+	h.notifyActivated(sub)
+
 	response.Created(c, sub, "")
 }
 
@@ -222,161 +276,116 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, sub, "", nil)
 }
 
-// Update mutates an existing subscription.
-func (h *Handler) Update(c *gin.Context) {
+// GetStorage returns the subscription's combined storage usage against its
+// limit with a breakdown per course.
+// GET /subscriptions/:subscriptionId/storage
+func (h *Handler) GetStorage(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("subscriptionId"))
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription payload", err)
+	storage, err := LoadStorage(h.db, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load subscription storage")
 		return
 	}
 
-	input := UpdateInput{}
+	response.Success(c, http.StatusOK, storage, "", nil)
+}
 
-	if value, ok := body["user"]; ok {
-		input.UserProvided = true
-		if value == nil {
-			h.respondError(c, ErrUserNotFound, ErrUserNotFound.Error())
-			return
-		}
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "user must be a string", err)
-			return
-		}
-		parsed, err := uuid.Parse(str)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
-			return
-		}
-		input.UserID = &parsed
-	}
+// updateRequest is a partial update; null clears displayName, is rejected for
+// user and subscriptionEnd, and leaves the other fields unchanged.
+type updateRequest struct {
+	User                   validation.Optional[string] `json:"user" binding:"omitnil,id"`
+	DisplayName            validation.Optional[string] `json:"displayName" binding:"omitnil,notblank"`
+	SubscriptionPoints     *int                        `json:"SubscriptionPoints" binding:"omitnil,gte=0"`
+	SubscriptionPointPrice *float64                    `json:"SubscriptionPointPrice" binding:"omitnil,gte=0"`
+	CourseLimitInGB        *float64                    `json:"CourseLimitInGB" binding:"omitnil,gte=0"`
+	CoursesLimit           *int                        `json:"CoursesLimit" binding:"omitnil,gte=0"`
+	StorageLimitInGB       *float64                    `json:"storageLimitInGB" binding:"omitnil,gte=0"`
+	AssistantsLimit        *int                        `json:"assistantsLimit" binding:"omitnil,gte=0"`
+	WatchLimit             *int                        `json:"watchLimit" binding:"omitnil,gte=0"`
+	WatchInterval          *int                        `json:"watchInterval" binding:"omitnil,gte=0"`
+	RateLimitPerMinute     *int                        `json:"rateLimitPerMinute" binding:"omitnil,gte=0"`
+	SubscriptionEnd        validation.Optional[string] `json:"subscriptionEnd" binding:"omitnil,rfc3339"`
+	RequireSameDeviceID    *bool                       `json:"isRequireSameDeviceId"`
+	Active                 *bool                       `json:"isActive"`
+}
 
-	if value, ok := body["displayName"]; ok {
-		input.DisplayNameProvided = true
-		if value == nil {
-			input.DisplayName = nil
-		} else {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "displayName must be a string", err)
-				return
-			}
-			input.DisplayName = &str
-		}
+// Update mutates an existing subscription.
+func (h *Handler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
 	}
 
-	if value, ok := body["SubscriptionPoints"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "SubscriptionPoints must be an integer", err)
-			return
-		}
-		input.SubscriptionPoints = &val
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid subscription payload") {
+		return
 	}
 
-	if value, ok := body["SubscriptionPointPrice"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "SubscriptionPointPrice must be a number", err)
-			return
-		}
-		m := types.NewMoney(val)
-		input.SubscriptionPointPrice = &m
+	if req.User.Null {
+		h.respondError(c, ErrUserNotFound, ErrUserNotFound.Error())
+		return
 	}
-
-	if value, ok := body["CourseLimitInGB"]; ok {
-		val, err := request.ReadFloat(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "CourseLimitInGB must be a number", err)
-			return
-		}
-		input.CourseLimitInGB = &val
+	if req.SubscriptionEnd.Null {
+		request.RespondInvalid(c, validation.FieldError{Field: "subscriptionEnd", Rule: "required", Message: "cannot be null"})
+		return
 	}
 
-	if value, ok := body["CoursesLimit"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "CoursesLimit must be an integer", err)
-			return
-		}
-		input.CoursesLimit = &val
+	input := UpdateInput{
+		UserProvided:        req.User.Set,
+		DisplayNameProvided: req.DisplayName.Set,
+		SubscriptionPoints:  req.SubscriptionPoints,
+		CourseLimitInGB:     req.CourseLimitInGB,
+		CoursesLimit:        req.CoursesLimit,
+		StorageLimitInGB:    req.StorageLimitInGB,
+		AssistantsLimit:     req.AssistantsLimit,
+		WatchLimit:          req.WatchLimit,
+		WatchInterval:       req.WatchInterval,
+		RateLimitPerMinute:  req.RateLimitPerMinute,
+		RequireSameDeviceID: req.RequireSameDeviceID,
+		Active:              req.Active,
 	}
 
-	if value, ok := body["assistantsLimit"]; ok {
-		val, err := request.ReadInt(value)
+	if value := req.User.Ptr(); value != nil {
+		parsed, err := uuid.Parse(*value)
 		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "assistantsLimit must be an integer", err)
+			request.RespondInvalid(c, validation.FieldError{Field: "user", Rule: "id", Message: "must be a valid id"})
 			return
 		}
-		input.AssistantsLimit = &val
+		input.UserID = &parsed
 	}
-
-	if value, ok := body["watchLimit"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "watchLimit must be an integer", err)
-			return
-		}
-		input.WatchLimit = &val
+	if value := req.DisplayName.Ptr(); value != nil {
+		trimmed := strings.TrimSpace(*value)
+		input.DisplayName = &trimmed
 	}
-
-	if value, ok := body["watchInterval"]; ok {
-		val, err := request.ReadInt(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "watchInterval must be an integer", err)
-			return
-		}
-		input.WatchInterval = &val
+	if req.SubscriptionPointPrice != nil {
+		m := types.NewMoney(*req.SubscriptionPointPrice)
+		input.SubscriptionPointPrice = &m
 	}
-
-	if value, ok := body["subscriptionEnd"]; ok {
-		if value == nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionEnd cannot be null", fmt.Errorf("subscriptionEnd is null"))
-			return
-		}
-		str, err := request.ReadString(value)
+	if value := req.SubscriptionEnd.Ptr(); value != nil {
+		parsed, err := time.Parse(time.RFC3339, *value)
 		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionEnd must be a string", err)
-			return
-		}
-		parsed, err := time.Parse(time.RFC3339, str)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionEnd must be RFC3339", err)
+			request.RespondInvalid(c, validation.FieldError{Field: "subscriptionEnd", Rule: "rfc3339", Message: "must be an RFC3339 timestamp"})
 			return
 		}
 		input.SubscriptionEnd = &parsed
 	}
 
-	if value, ok := body["isRequireSameDeviceId"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isRequireSameDeviceId must be boolean", err)
-			return
-		}
-		input.RequireSameDeviceID = &val
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
-	}
-
 	sub, err := Update(h.db, id, input)
 	if err != nil {
 		h.respondError(c, err, "failed to update subscription")
 		return
 	}
 
+	if input.Active != nil && *input.Active {
+		h.notifyActivated(sub)
+	}
+
 	response.Success(c, http.StatusOK, sub, "", nil)
 }
 
@@ -405,26 +414,5 @@ func (h *Handler) Delete(c *gin.Context) {
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrUserNotFound):
-		status = http.StatusNotFound
-		message = ErrUserNotFound.Error()
-	case errors.Is(err, ErrSubscriptionNotFound):
-		status = http.StatusNotFound
-		message = ErrSubscriptionNotFound.Error()
-	case errors.Is(err, ErrPackageNotFound):
-		status = http.StatusNotFound
-		message = ErrPackageNotFound.Error()
-	case errors.Is(err, ErrUserHasSubscription):
-		status = http.StatusBadRequest
-		message = ErrUserHasSubscription.Error()
-	case errors.Is(err, ErrSubscriptionTaken):
-		status = http.StatusConflict
-		message = ErrSubscriptionTaken.Error()
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
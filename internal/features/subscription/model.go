@@ -23,13 +23,17 @@ type Subscription struct {
 	SubscriptionPointPrice types.Money `gorm:"type:numeric(10,2);not null;default:0;column:subscription_point_price" json:"SubscriptionPointPrice"`
 	CourseLimitInGB        float64     `gorm:"type:numeric(10,2);not null;default:25;column:course_limit_in_gb" json:"CourseLimitInGB"`
 	CoursesLimit           int         `gorm:"type:int;not null;default:5;column:courses_limit" json:"CoursesLimit"`
+	StorageLimitInGB       float64     `gorm:"type:numeric(10,2);not null;default:0;column:storage_limit_in_gb" json:"storageLimitInGB"` // 0 derives it from the course limits
 	PackageID              *uuid.UUID  `gorm:"type:uuid;column:package_id" json:"packageId,omitempty"`
 	AssistantsLimit        int         `gorm:"type:int;not null;default:5;column:assistants_limit" json:"assistantsLimit"`
 	WatchLimit             int         `gorm:"type:int;not null;default:2;column:watch_limit" json:"watchLimit"`
 	WatchInterval          int         `gorm:"type:int;not null;default:240;column:watch_interval" json:"watchInterval"`
+	RateLimitPerMinute     int         `gorm:"type:int;not null;default:0;column:rate_limit_per_minute" json:"rateLimitPerMinute"` // 0 uses the platform default
 	SubscriptionEnd        time.Time   `gorm:"type:timestamp;not null;default:now();column:subscription_end;index;index:idx_active_end,priority:2" json:"subscriptionEnd"`
 	RequireSameDeviceID    bool        `gorm:"type:boolean;not null;default:false;column:is_require_same_device_id" json:"isRequireSameDeviceId"`
 	Active                 bool        `gorm:"type:boolean;not null;default:true;column:is_active;index:idx_active_end,priority:1" json:"isActive"`
+	GraceUntil             *time.Time  `gorm:"type:timestamp;column:grace_until" json:"graceUntil,omitempty"`
+	DunningStage           int         `gorm:"type:int;not null;default:0;column:dunning_stage" json:"dunningStage"`
 }
 
 // TableName overrides the default table name.
@@ -38,6 +42,20 @@ func (Subscription) TableName() string { return "subscriptions" }
 // IsExpired reports whether the subscription has passed its end time.
 func (s Subscription) IsExpired(now time.Time) bool { return now.After(s.SubscriptionEnd) }
 
+// InGrace reports whether the subscription has expired but is still inside its grace period.
+func (s Subscription) InGrace(now time.Time) bool {
+	return s.Active && s.GraceUntil != nil && s.IsExpired(now)
+}
+
+// TotalStorageLimitGB is the combined storage all courses may use. Without an
+// explicit StorageLimitInGB every allowed course gets its full course limit.
+func (s Subscription) TotalStorageLimitGB() float64 {
+	if s.StorageLimitInGB > 0 {
+		return s.StorageLimitInGB
+	}
+	return float64(s.CoursesLimit) * s.CourseLimitInGB
+}
+
 // CreateInput carries the data needed for a new subscription.
 type CreateInput struct {
 	UserID                 uuid.UUID
@@ -47,12 +65,16 @@ type CreateInput struct {
 	SubscriptionPointPrice *types.Money
 	CourseLimitInGB        *float64
 	CoursesLimit           *int
+	StorageLimitInGB       *float64
 	AssistantsLimit        *int
 	WatchLimit             *int
 	WatchInterval          *int
+	RateLimitPerMinute     *int
 	SubscriptionEnd        *time.Time
 	RequireSameDeviceID    *bool
 	Active                 *bool
+	PackageID              *uuid.UUID
+	Source                 string // Recorded on the activation event; defaults to admin
 }
 
 // CreateFromPackageInput extends CreateInput with a package reference.
@@ -73,9 +95,11 @@ type UpdateInput struct {
 	SubscriptionPointPrice *types.Money
 	CourseLimitInGB        *float64
 	CoursesLimit           *int
+	StorageLimitInGB       *float64
 	AssistantsLimit        *int
 	WatchLimit             *int
 	WatchInterval          *int
+	RateLimitPerMinute     *int
 	SubscriptionEnd        *time.Time
 	RequireSameDeviceID    *bool
 	Active                 *bool
@@ -90,12 +114,14 @@ func List(db *gorm.DB, params pagination.Params, keyword string) ([]Subscription
 	}
 
 	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
 	}
 
 	var items []Subscription
-	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(&items).Error; err != nil {
+	if err := pagination.Apply(query, params).Find(&items).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -107,6 +133,18 @@ func Get(db *gorm.DB, id uuid.UUID) (Subscription, error) {
 	return fetchSubscription(db, id)
 }
 
+// GetByIdentifier retrieves a subscription by its identifier name.
+func GetByIdentifier(db *gorm.DB, identifier string) (Subscription, error) {
+	var sub Subscription
+	if err := db.First(&sub, "identifier_name = ?", identifier).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return sub, ErrSubscriptionNotFound
+		}
+		return sub, err
+	}
+	return sub, nil
+}
+
 // Create inserts a new subscription and links it to a user.
 func Create(db *gorm.DB, input CreateInput) (Subscription, error) {
 	sub := newSubscriptionFromInput(input)
@@ -132,7 +170,11 @@ func Create(db *gorm.DB, input CreateInput) (Subscription, error) {
 			return err
 		}
 
-		return setUserSubscription(tx, input.UserID, &sub.ID)
+		if err := setUserSubscription(tx, input.UserID, &sub.ID); err != nil {
+			return err
+		}
+
+		return publishCreated(tx, sub, input.Source)
 	})
 
 	return sub, err
@@ -171,7 +213,11 @@ func CreateFromPackage(db *gorm.DB, input CreateFromPackageInput) (Subscription,
 			return err
 		}
 
-		return setUserSubscription(tx, input.UserID, &sub.ID)
+		if err := setUserSubscription(tx, input.UserID, &sub.ID); err != nil {
+			return err
+		}
+
+		return publishCreated(tx, sub, input.Source)
 	})
 
 	return sub, err
@@ -238,6 +284,9 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Subscription, error)
 		if input.CoursesLimit != nil {
 			updates["courses_limit"] = *input.CoursesLimit
 		}
+		if input.StorageLimitInGB != nil {
+			updates["storage_limit_in_gb"] = *input.StorageLimitInGB
+		}
 		if input.AssistantsLimit != nil {
 			updates["assistants_limit"] = *input.AssistantsLimit
 		}
@@ -247,8 +296,16 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Subscription, error)
 		if input.WatchInterval != nil {
 			updates["watch_interval"] = *input.WatchInterval
 		}
+		if input.RateLimitPerMinute != nil {
+			updates["rate_limit_per_minute"] = *input.RateLimitPerMinute
+		}
 		if input.SubscriptionEnd != nil {
 			updates["subscription_end"] = input.SubscriptionEnd.UTC()
+			// A renewal ends any grace period and restarts the dunning sequence.
+			if input.SubscriptionEnd.After(time.Now()) {
+				updates["grace_until"] = nil
+				updates["dunning_stage"] = 0
+			}
 		}
 		if input.RequireSameDeviceID != nil {
 			updates["is_require_same_device_id"] = *input.RequireSameDeviceID
@@ -277,7 +334,8 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (Subscription, error)
 			return err
 		}
 		updated = refreshed
-		return nil
+
+		return publishUpdated(tx, current, refreshed)
 	})
 
 	return updated, err
@@ -322,6 +380,7 @@ func newSubscriptionFromInput(input CreateInput) Subscription {
 		SubscriptionEnd:        now,
 		RequireSameDeviceID:    false,
 		Active:                 true,
+		PackageID:              input.PackageID,
 	}
 
 	if input.SubscriptionPoints != nil {
@@ -336,6 +395,9 @@ func newSubscriptionFromInput(input CreateInput) Subscription {
 	if input.CoursesLimit != nil {
 		sub.CoursesLimit = *input.CoursesLimit
 	}
+	if input.StorageLimitInGB != nil {
+		sub.StorageLimitInGB = *input.StorageLimitInGB
+	}
 	if input.AssistantsLimit != nil {
 		sub.AssistantsLimit = *input.AssistantsLimit
 	}
@@ -345,6 +407,9 @@ func newSubscriptionFromInput(input CreateInput) Subscription {
 	if input.WatchInterval != nil {
 		sub.WatchInterval = *input.WatchInterval
 	}
+	if input.RateLimitPerMinute != nil {
+		sub.RateLimitPerMinute = *input.RateLimitPerMinute
+	}
 	if input.SubscriptionEnd != nil {
 		sub.SubscriptionEnd = input.SubscriptionEnd.UTC()
 	}
@@ -377,6 +442,9 @@ func applyPackage(sub *Subscription, pkg subscriptionPackageRow) {
 	if pkg.WatchInterval != nil {
 		sub.WatchInterval = *pkg.WatchInterval
 	}
+	if pkg.RateLimitPerMinute != nil {
+		sub.RateLimitPerMinute = *pkg.RateLimitPerMinute
+	}
 }
 
 func fetchSubscription(db *gorm.DB, id uuid.UUID) (Subscription, error) {
@@ -454,6 +522,7 @@ type subscriptionPackageRow struct {
 	AssistantsLimit        *int         `gorm:"column:assistants_limit"`
 	WatchLimit             *int         `gorm:"column:watch_limit"`
 	WatchInterval          *int         `gorm:"column:watch_interval"`
+	RateLimitPerMinute     *int         `gorm:"column:rate_limit_per_minute"`
 }
 
 func (subscriptionPackageRow) TableName() string { return "subscription_packages" }
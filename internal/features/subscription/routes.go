@@ -1,25 +1,29 @@
 package subscription
 
 import (
-	"log/slog"
-
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
-	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes attaches subscription routes under /subscriptions.
-// Middleware is passed as parameters to avoid import cycles
-func RegisterRoutes(api *gin.RouterGroup, db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClient, storageClient *bunny.StorageClient, adminOnly, adminStaff []gin.HandlerFunc) {
-	handler := NewHandler(db, logger, streamClient, storageClient)
-
+// Middleware is passed as parameters to avoid import cycles; idempotent lets
+// the endpoints creating subscriptions take an Idempotency-Key.
+func RegisterRoutes(api *gin.RouterGroup, handler *Handler, adminOnly, adminStaff, acStaffWithInactive []gin.HandlerFunc, idempotent gin.HandlerFunc) {
 	group := api.Group("/subscriptions")
 
 	group.GET("", append(adminOnly, handler.List)...)
-	group.POST("", append(adminOnly, handler.Create)...)
-	group.POST("/from-package", append(adminOnly, handler.CreateFromPackage)...)
+	group.POST("", append(adminOnly, idempotent, handler.Create)...)
+	group.POST("/from-package", append(adminOnly, idempotent, handler.CreateFromPackage)...)
 	group.GET("/:subscriptionId", append(adminStaff, handler.GetByID)...)
+	group.GET("/:subscriptionId/storage", append(acStaffWithInactive, handler.GetStorage)...)
 	group.PUT("/:subscriptionId", append(adminOnly, handler.Update)...)
 	group.DELETE("/:subscriptionId", append(adminOnly, handler.Delete)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Subscription{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Subscription{}})
+	openapi.Describe(handler.CreateFromPackage, openapi.Spec{Request: createFromPackageRequest{}, Response: Subscription{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Subscription{}})
+	openapi.Describe(handler.GetStorage, openapi.Spec{Response: Storage{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Subscription{}})
 }
@@ -0,0 +1,99 @@
+package subscription
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CourseStorage is one course's share of the subscription's storage, as last
+// recalculated by the storage usage service.
+type CourseStorage struct {
+	CourseID        uuid.UUID `json:"courseId"`
+	Name            string    `json:"name"`
+	StreamStorageGB float64   `json:"streamStorageGB"`
+	FileStorageGB   float64   `json:"fileStorageGB"`
+	CoverStorageGB  float64   `json:"coverStorageGB"`
+	TotalStorageGB  float64   `json:"totalStorageGB"`
+	UsedPercent     float64   `json:"usedPercent"` // of the course limit
+}
+
+// Storage sums the usage of every course against the subscription-wide limit.
+type Storage struct {
+	SubscriptionID  uuid.UUID       `json:"subscriptionId"`
+	LimitGB         float64         `json:"limitGB"`
+	CourseLimitGB   float64         `json:"courseLimitGB"`
+	StreamStorageGB float64         `json:"streamStorageGB"`
+	FileStorageGB   float64         `json:"fileStorageGB"`
+	UsedGB          float64         `json:"usedGB"`
+	RemainingGB     float64         `json:"remainingGB"`
+	UsedPercent     float64         `json:"usedPercent"`
+	Courses         []CourseStorage `json:"courses"`
+}
+
+// LoadStorage returns the subscription's storage totals with a breakdown per
+// course, largest first.
+func LoadStorage(db *gorm.DB, id uuid.UUID) (Storage, error) {
+	sub, err := fetchSubscription(db, id)
+	if err != nil {
+		return Storage{}, err
+	}
+
+	courses := make([]CourseStorage, 0)
+	if err := db.Table("courses").
+		Select("id AS course_id, name, stream_storage_gb, file_storage_gb - cover_storage_gb AS file_storage_gb, "+
+			"cover_storage_gb, storage_usage_in_gb AS total_storage_gb").
+		Where("subscription_id = ?", id).
+		Order("storage_usage_in_gb DESC, name ASC").
+		Scan(&courses).Error; err != nil {
+		return Storage{}, err
+	}
+
+	storage := Storage{
+		SubscriptionID: id,
+		LimitGB:        sub.TotalStorageLimitGB(),
+		CourseLimitGB:  sub.CourseLimitInGB,
+		Courses:        courses,
+	}
+	for i := range courses {
+		courses[i].UsedPercent = percentOf(courses[i].TotalStorageGB, sub.CourseLimitInGB)
+		storage.StreamStorageGB += courses[i].StreamStorageGB
+		storage.FileStorageGB += courses[i].FileStorageGB + courses[i].CoverStorageGB
+		storage.UsedGB += courses[i].TotalStorageGB
+	}
+	storage.RemainingGB = math.Max(storage.LimitGB-storage.UsedGB, 0)
+	storage.UsedPercent = percentOf(storage.UsedGB, storage.LimitGB)
+
+	return storage, nil
+}
+
+// CheckStorage returns ErrStorageLimitExceeded when adding incomingGB would take
+// the combined usage of the subscription's courses past its limit. The returned
+// limit and usage describe the rejection to the uploader.
+func CheckStorage(db *gorm.DB, id uuid.UUID, incomingGB float64) (limitGB, usedGB float64, err error) {
+	sub, err := fetchSubscription(db, id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := db.Table("courses").
+		Select("COALESCE(SUM(storage_usage_in_gb), 0)").
+		Where("subscription_id = ?", id).
+		Scan(&usedGB).Error; err != nil {
+		return 0, 0, err
+	}
+
+	limitGB = sub.TotalStorageLimitGB()
+	if limitGB > 0 && (usedGB >= limitGB || usedGB+incomingGB > limitGB) {
+		return limitGB, usedGB, ErrStorageLimitExceeded
+	}
+	return limitGB, usedGB, nil
+}
+
+func percentOf(used, limit float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return math.Round(used/limit*10000) / 100
+}
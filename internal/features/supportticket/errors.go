@@ -1,6 +1,11 @@
 package supportticket
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
 	ErrTicketNotFound    = errors.New("ticket not found")
@@ -8,3 +13,11 @@ var (
 	ErrMessageRequired   = errors.New("message is required")
 	ErrReplyInfoRequired = errors.New("reply information is required")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrTicketNotFound, Status: http.StatusNotFound, Code: "TICKET_NOT_FOUND", Message: "Ticket not found."},
+	{Err: ErrSubjectRequired, Status: http.StatusBadRequest, Code: "TICKET_SUBJECT_REQUIRED", Message: "Subject is required."},
+	{Err: ErrMessageRequired, Status: http.StatusBadRequest, Code: "TICKET_MESSAGE_REQUIRED", Message: "Message is required."},
+	{Err: ErrReplyInfoRequired, Status: http.StatusBadRequest, Code: "TICKET_REPLY_INFO_REQUIRED", Message: "Reply information is required."},
+}
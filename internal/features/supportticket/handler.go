@@ -1,7 +1,6 @@
 package supportticket
 
 import (
-	"errors"
 	"net/http"
 
 	"log/slog"
@@ -10,11 +9,10 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
-	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/internal/authz"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
-	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
 // Handler processes support ticket HTTP requests.
@@ -85,6 +83,12 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, ticket, "", nil)
 }
 
+type createRequest struct {
+	Subject   string  `json:"subject" binding:"required"`
+	Message   string  `json:"message" binding:"required"`
+	ReplyInfo *string `json:"replyInfo"`
+}
+
 // Create inserts a new ticket (students submitting tickets).
 func (h *Handler) Create(c *gin.Context) {
 	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
@@ -93,14 +97,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Subject   string  `json:"subject" binding:"required"`
-		Message   string  `json:"message" binding:"required"`
-		ReplyInfo *string `json:"replyInfo"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid ticket payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid ticket payload") {
 		return
 	}
 
@@ -126,6 +125,10 @@ func (h *Handler) Create(c *gin.Context) {
 	response.Created(c, ticket, "")
 }
 
+type replyRequest struct {
+	ReplyInfo string `json:"replyInfo" binding:"required,notblank"`
+}
+
 // Reply adds a reply to a ticket (instructors+).
 func (h *Handler) Reply(c *gin.Context) {
 	ticketID, err := uuid.Parse(c.Param("ticketId"))
@@ -134,32 +137,14 @@ func (h *Handler) Reply(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid ticket payload", err)
-		return
-	}
-
-	var replyInfo *string
-	if value, ok := body["replyInfo"]; ok {
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "replyInfo must be a string", err)
-				return
-			}
-			replyInfo = &str
-		}
-	}
-
-	if replyInfo == nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Reply information is required.", ErrReplyInfoRequired)
+	var req replyRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid ticket payload") {
 		return
 	}
 
 	ticket, err := Update(h.db, ticketID, UpdateInput{
 		ReplyInfoProvided: true,
-		ReplyInfo:         replyInfo,
+		ReplyInfo:         request.Trimmed(&req.ReplyInfo),
 	})
 
 	if err != nil {
@@ -185,7 +170,7 @@ func (h *Handler) Delete(c *gin.Context) {
 	}
 
 	// Only admins and superadmins can delete tickets
-	if !user.CanManageUserType(currentUser.UserType, types.UserTypeAdmin) {
+	if !authz.IsPlatformAdmin(currentUser.UserType) {
 		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "unauthorized to delete tickets", nil)
 		return
 	}
@@ -199,23 +184,5 @@ func (h *Handler) Delete(c *gin.Context) {
 }
 
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrTicketNotFound):
-		status = http.StatusNotFound
-		message = "Ticket not found."
-	case errors.Is(err, ErrSubjectRequired):
-		status = http.StatusBadRequest
-		message = "Subject is required."
-	case errors.Is(err, ErrMessageRequired):
-		status = http.StatusBadRequest
-		message = "Message is required."
-	case errors.Is(err, ErrReplyInfoRequired):
-		status = http.StatusBadRequest
-		message = "Reply information is required."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
@@ -2,6 +2,8 @@ package supportticket
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes sets up support ticket endpoints under /subscriptions/:subscriptionId/support-tickets.
@@ -14,4 +16,10 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff, acAll []
 	tickets.GET("/:ticketId", append(acAll, handler.GetByID)...)
 	tickets.PUT("/:ticketId/reply", append(acStaff, handler.Reply)...)
 	tickets.DELETE("/:ticketId", append(acStaff, handler.Delete)...)
+
+	openapi.Describe(handler.ListForSubscription, openapi.Spec{Response: []SupportTicket{}})
+	openapi.Describe(handler.ListMyTickets, openapi.Spec{Response: []SupportTicket{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: SupportTicket{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: SupportTicket{}})
+	openapi.Describe(handler.Reply, openapi.Spec{Request: replyRequest{}, Response: SupportTicket{}})
 }
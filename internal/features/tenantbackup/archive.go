@@ -0,0 +1,379 @@
+package tenantbackup
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+)
+
+// FormatVersion is the archive layout written by Export. Restore rejects
+// archives of any other version.
+const FormatVersion = 1
+
+const (
+	manifestFile = "manifest.json"
+	assetsFile   = "assets.json"
+	tablesDir    = "tables/"
+	// restoreBatch is how many rows one INSERT restores.
+	restoreBatch = 500
+	// maxRowSize bounds one archived row; lesson content and thread replies
+	// are the largest.
+	maxRowSize = 16 << 20
+)
+
+// Manifest describes an archive. It is written last, so an archive with a
+// manifest is complete.
+type Manifest struct {
+	Version        int       `json:"version"`
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	Identifier     string    `json:"identifier"`
+	ExportedAt     time.Time `json:"exportedAt"`
+	Assets         bool      `json:"assets"`
+	Tables         []Table   `json:"tables"`
+}
+
+// Table is one archived table, stored as JSON lines in tables/<name>.jsonl.
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int      `json:"rows"`
+}
+
+// ExportOptions selects what Export adds besides the database rows.
+type ExportOptions struct {
+	// Assets adds assets.json, the Bunny videos and files the rows refer to.
+	Assets        bool
+	LibraryID     string
+	StorageZone   string
+	StorageCDNURL string
+}
+
+// Export writes every row of the subscription, across all tables that belong
+// to it, to w as a zip archive. The rows are read in one snapshot, so the
+// archive is consistent even while the tenant is in use.
+func Export(db *gorm.DB, subscriptionID uuid.UUID, w io.Writer, opts ExportOptions) (Manifest, error) {
+	manifest := Manifest{Version: FormatVersion, SubscriptionID: subscriptionID, Assets: opts.Assets}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		sub, err := subscription.Get(tx, subscriptionID)
+		if err != nil {
+			return err
+		}
+		manifest.Identifier = sub.IdentifierName
+		manifest.ExportedAt = time.Now().UTC()
+
+		s, err := loadSchema(tx)
+		if err != nil {
+			return err
+		}
+		plans := s.plan()
+
+		archive := zip.NewWriter(w)
+		for _, plan := range plans {
+			table, err := exportTable(tx, s, plan, subscriptionID, archive)
+			if err != nil {
+				return fmt.Errorf("export %s: %w", plan.Name, err)
+			}
+			manifest.Tables = append(manifest.Tables, table)
+		}
+
+		if opts.Assets {
+			assets, err := collectAssets(tx, plans, subscriptionID, opts)
+			if err != nil {
+				return fmt.Errorf("collect assets: %w", err)
+			}
+			if err := writeJSON(archive, assetsFile, assets); err != nil {
+				return err
+			}
+		}
+
+		if err := writeJSON(archive, manifestFile, manifest); err != nil {
+			return err
+		}
+		return archive.Close()
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	return manifest, err
+}
+
+func exportTable(tx *gorm.DB, s schema, plan tablePlan, subscriptionID uuid.UUID, archive *zip.Writer) (Table, error) {
+	table := Table{Name: plan.Name}
+	for _, c := range s.columns[plan.Name] {
+		if !c.Generated && !slices.Contains(plan.Omit, c.Name) {
+			table.Columns = append(table.Columns, c.Name)
+		}
+	}
+
+	// Generated and omitted columns are left out of the rows as well
+	drop := slices.Clone(plan.Omit)
+	for _, c := range s.columns[plan.Name] {
+		if c.Generated {
+			drop = append(drop, c.Name)
+		}
+	}
+	row := "to_jsonb(t)"
+	if len(drop) > 0 {
+		quoted := make([]string, len(drop))
+		for i, name := range drop {
+			quoted[i] = "'" + strings.ReplaceAll(name, "'", "''") + "'"
+		}
+		row = fmt.Sprintf("to_jsonb(t) - ARRAY[%s]::text[]", strings.Join(quoted, ", "))
+	}
+
+	rows, err := tx.Raw(fmt.Sprintf("SELECT (%s)::text FROM %s t WHERE %s", row, quote(plan.Name), plan.Where),
+		map[string]interface{}{"subscription": subscriptionID}).Rows()
+	if err != nil {
+		return table, err
+	}
+	defer rows.Close()
+
+	file, err := archive.Create(tablesDir + plan.Name + ".jsonl")
+	if err != nil {
+		return table, err
+	}
+	for rows.Next() {
+		var line []byte
+		if err := rows.Scan(&line); err != nil {
+			return table, err
+		}
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			return table, err
+		}
+		table.Rows++
+	}
+	return table, rows.Err()
+}
+
+func writeJSON(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// ReadManifest returns the manifest of an archive without restoring it.
+func ReadManifest(r io.ReaderAt, size int64) (Manifest, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return readManifest(archive)
+}
+
+func readManifest(archive *zip.Reader) (Manifest, error) {
+	var manifest Manifest
+	file, err := archive.Open(manifestFile)
+	if err != nil {
+		return manifest, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, manifestFile)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, manifestFile, err)
+	}
+	if manifest.Version != FormatVersion {
+		return manifest, fmt.Errorf("%w: %d", ErrUnsupportedVersion, manifest.Version)
+	}
+	if manifest.SubscriptionID == uuid.Nil || len(manifest.Tables) == 0 || manifest.Tables[0].Name != "subscriptions" {
+		return manifest, fmt.Errorf("%w: %s does not describe a subscription", ErrInvalidArchive, manifestFile)
+	}
+	return manifest, nil
+}
+
+// Restore inserts the rows of an archive written by Export, keeping their
+// IDs. The subscription must not exist yet and the database must have every
+// archived table and column, so an archive restores into the same or a newer
+// release. Everything is restored in one transaction, so a failed restore
+// leaves nothing behind. Bunny assets are not copied; see assets.json.
+func Restore(db *gorm.DB, r io.ReaderAt, size int64) (Manifest, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return manifest, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := subscription.Get(tx, manifest.SubscriptionID); err == nil {
+			return ErrSubscriptionExists
+		} else if !errors.Is(err, subscription.ErrSubscriptionNotFound) {
+			return err
+		}
+
+		s, err := loadSchema(tx)
+		if err != nil {
+			return err
+		}
+		if err := s.check(manifest.Tables); err != nil {
+			return err
+		}
+
+		names := make([]string, len(manifest.Tables))
+		columns := make(map[string][]string, len(manifest.Tables))
+		for i, table := range manifest.Tables {
+			names[i] = table.Name
+			columns[table.Name] = table.Columns
+		}
+		order, err := s.restoreOrder(names)
+		if err != nil {
+			return err
+		}
+
+		restored := make(map[string]bool, len(order))
+		deferred := make(map[string][]foreignKey, len(order))
+		for _, table := range order {
+			deferred[table] = s.deferred(table, restored)
+			if err := insertRows(tx, archive, table, columns[table], deferred[table]); err != nil {
+				return fmt.Errorf("restore %s: %w", table, err)
+			}
+			restored[table] = true
+		}
+		for _, table := range order {
+			if err := linkRows(tx, archive, s, table, columns[table], deferred[table]); err != nil {
+				return fmt.Errorf("restore references of %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+	return manifest, err
+}
+
+// check reports archived tables or columns the database does not have. Only
+// tables an export of this database would include can be restored.
+func (s schema) check(tables []Table) error {
+	planned := make(map[string]bool)
+	for _, plan := range s.plan() {
+		planned[plan.Name] = true
+	}
+
+	var missing []string
+	for _, table := range tables {
+		if !planned[table.Name] {
+			missing = append(missing, table.Name)
+			continue
+		}
+		for _, name := range table.Columns {
+			if c, ok := s.column(table.Name, name); !ok || c.Generated {
+				missing = append(missing, table.Name+"."+name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrSchemaMismatch, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// insertRows inserts the table's rows without their deferred references.
+func insertRows(tx *gorm.DB, archive *zip.Reader, table string, columns []string, deferred []foreignKey) error {
+	var insert []string
+	for _, name := range columns {
+		if !slices.ContainsFunc(deferred, func(key foreignKey) bool { return key.Column == name }) {
+			insert = append(insert, quote(name))
+		}
+	}
+	list := strings.Join(insert, ", ")
+	query := fmt.Sprintf("INSERT INTO %[1]s (%[2]s) SELECT %[2]s FROM json_populate_recordset(NULL::%[1]s, CAST(@rows AS json))",
+		quote(table), list)
+
+	return eachBatch(archive, table, func(rows string) error {
+		return tx.Exec(query, map[string]interface{}{"rows": rows}).Error
+	})
+}
+
+// linkRows sets the table's deferred references whose rows exist.
+func linkRows(tx *gorm.DB, archive *zip.Reader, s schema, table string, columns []string, deferred []foreignKey) error {
+	var match []string
+	for _, name := range s.primary[table] {
+		match = append(match, fmt.Sprintf("t.%[1]s = d.%[1]s", quote(name)))
+	}
+
+	for _, key := range deferred {
+		if !slices.Contains(columns, key.Column) {
+			continue
+		}
+		query := fmt.Sprintf(`UPDATE %[1]s t SET %[2]s = d.%[2]s
+			FROM json_populate_recordset(NULL::%[1]s, CAST(@rows AS json)) d
+			WHERE %[3]s AND d.%[2]s IS NOT NULL
+				AND EXISTS (SELECT 1 FROM %[4]s p WHERE p.%[5]s = d.%[2]s)`,
+			quote(table), quote(key.Column), strings.Join(match, " AND "), quote(key.Parent), quote(key.ParentColumn))
+
+		if err := eachBatch(archive, table, func(rows string) error {
+			return tx.Exec(query, map[string]interface{}{"rows": rows}).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachBatch calls fn with the table's rows as JSON arrays of up to
+// restoreBatch rows.
+func eachBatch(archive *zip.Reader, table string, fn func(rows string) error) error {
+	file, err := archive.Open(tablesDir + table + ".jsonl")
+	if err != nil {
+		return fmt.Errorf("%w: rows of %s are missing", ErrInvalidArchive, table)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRowSize)
+
+	var batch bytes.Buffer
+	count := 0
+	flush := func() error {
+		if count == 0 {
+			return nil
+		}
+		batch.WriteByte(']')
+		err := fn(batch.String())
+		batch.Reset()
+		count = 0
+		return err
+	}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return fmt.Errorf("%w: malformed row in %s", ErrInvalidArchive, table)
+		}
+		if count == 0 {
+			batch.WriteByte('[')
+		} else {
+			batch.WriteByte(',')
+		}
+		batch.Write(line)
+		count++
+
+		if count == restoreBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, table, err)
+	}
+	return flush()
+}
@@ -0,0 +1,86 @@
+package tenantbackup
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Assets lists the Bunny objects an archive's rows refer to. They stay in the
+// source library and storage zone; copying them to the target environment is
+// left to Bunny's own tooling.
+type Assets struct {
+	LibraryID   string  `json:"libraryId"`
+	StorageZone string  `json:"storageZone"`
+	CDNURL      string  `json:"cdnUrl,omitempty"`
+	Items       []Asset `json:"items"`
+}
+
+// Asset is one Bunny object and the row referring to it.
+type Asset struct {
+	Kind   string `json:"kind"`
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	RowID  string `json:"rowId"`
+	Ref    string `json:"ref"`
+}
+
+const (
+	AssetCollection = "collection"
+	AssetVideo      = "video"
+	AssetFile       = "file"
+)
+
+// assetColumn is a column holding a Bunny reference.
+type assetColumn struct {
+	kind   string
+	table  string
+	column string
+}
+
+var assetColumns = []assetColumn{
+	{kind: AssetCollection, table: "courses", column: "collection_id"},
+	{kind: AssetFile, table: "courses", column: "image"},
+	{kind: AssetVideo, table: "lessons", column: "video_id"},
+	{kind: AssetFile, table: "attachments", column: "path"},
+	{kind: AssetFile, table: "users", column: "avatar_url"},
+}
+
+// collectAssets reads the Bunny references of the exported rows.
+func collectAssets(tx *gorm.DB, plans []tablePlan, subscriptionID uuid.UUID, opts ExportOptions) (Assets, error) {
+	assets := Assets{LibraryID: opts.LibraryID, StorageZone: opts.StorageZone, CDNURL: opts.StorageCDNURL, Items: []Asset{}}
+
+	where := make(map[string]string, len(plans))
+	for _, plan := range plans {
+		where[plan.Name] = plan.Where
+	}
+
+	for _, ac := range assetColumns {
+		condition, ok := where[ac.table]
+		if !ok {
+			continue
+		}
+
+		var refs []struct {
+			RowID string
+			Ref   string
+		}
+		query := fmt.Sprintf("SELECT id::text AS row_id, %[1]s AS ref FROM %[2]s WHERE (%[3]s) AND %[1]s IS NOT NULL AND %[1]s <> '' ORDER BY id",
+			quote(ac.column), quote(ac.table), condition)
+		if err := tx.Raw(query, map[string]interface{}{"subscription": subscriptionID}).Scan(&refs).Error; err != nil {
+			return assets, fmt.Errorf("%s.%s: %w", ac.table, ac.column, err)
+		}
+
+		for _, ref := range refs {
+			assets.Items = append(assets.Items, Asset{
+				Kind:   ac.kind,
+				Table:  ac.table,
+				Column: ac.column,
+				RowID:  ref.RowID,
+				Ref:    ref.Ref,
+			})
+		}
+	}
+	return assets, nil
+}
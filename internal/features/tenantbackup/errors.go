@@ -0,0 +1,26 @@
+package tenantbackup
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrSubscriptionNotFound = subscription.ErrSubscriptionNotFound
+	ErrSubscriptionExists   = errors.New("subscription already exists in this environment")
+	ErrInvalidArchive       = errors.New("invalid backup archive")
+	ErrUnsupportedVersion   = errors.New("unsupported backup archive version")
+	ErrSchemaMismatch       = errors.New("backup archive does not match the database schema")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrSubscriptionNotFound, Status: http.StatusNotFound, Code: "SUBSCRIPTION_NOT_FOUND", Message: "Subscription not found."},
+	{Err: ErrSubscriptionExists, Status: http.StatusConflict, Code: "SUBSCRIPTION_EXISTS", Message: "Subscription already exists in this environment."},
+	{Err: ErrInvalidArchive, Status: http.StatusBadRequest, Code: "INVALID_BACKUP_ARCHIVE"},
+	{Err: ErrUnsupportedVersion, Status: http.StatusBadRequest, Code: "UNSUPPORTED_BACKUP_VERSION"},
+	{Err: ErrSchemaMismatch, Status: http.StatusBadRequest, Code: "BACKUP_SCHEMA_MISMATCH"},
+}
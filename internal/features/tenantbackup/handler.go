@@ -0,0 +1,115 @@
+package tenantbackup
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// maxArchiveSize bounds uploaded archives; larger tenants are restored with
+// lmsctl, which reads the archive from disk.
+const maxArchiveSize = 1 << 30
+
+// Handler serves the tenant backup endpoints.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	assets ExportOptions
+}
+
+// NewHandler constructs a tenant backup handler.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// UseBunny sets the Bunny library and storage zone named in asset manifests.
+func (h *Handler) UseBunny(libraryID, storageZone, cdnURL string) {
+	h.assets = ExportOptions{LibraryID: libraryID, StorageZone: storageZone, StorageCDNURL: cdnURL}
+}
+
+// Export downloads a zip archive of every row of the subscription and, with
+// assets=true, a manifest of the Bunny objects they refer to. The archive
+// holds password hashes and personal data.
+// GET /subscriptions/:subscriptionId/backup?assets=true
+func (h *Handler) Export(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	db := h.db.WithContext(c.Request.Context())
+	sub, err := subscription.Get(db, subscriptionID)
+	if err != nil {
+		h.respondError(c, err, "failed to load subscription")
+		return
+	}
+
+	opts := h.assets
+	opts.Assets = c.Query("assets") == "true"
+
+	filename := fmt.Sprintf("backup-%s-%s.zip", sub.IdentifierName, subscriptionID)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	manifest, err := Export(db, subscriptionID, c.Writer, opts)
+	if err != nil {
+		// Headers are already sent; the truncated download is the only signal left for the client.
+		h.logger.Error("tenant backup failed", "subscriptionId", subscriptionID, "error", err)
+		return
+	}
+
+	h.logger.Info("tenant backup exported", "subscriptionId", subscriptionID, "tables", len(manifest.Tables), "assets", opts.Assets, "requestedBy", requester.ID)
+}
+
+// Restore recreates a subscription from an archive uploaded as the "archive"
+// form file. The subscription must not exist in this environment.
+// POST /subscriptions/restore
+func (h *Handler) Restore(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxArchiveSize+(64<<10))
+	file, header, err := c.Request.FormFile("archive")
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Backup archive is required.", err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxArchiveSize {
+		response.ErrorWithLog(h.logger, c, http.StatusRequestEntityTooLarge, "Backup archive cannot exceed 1GB; restore it with lmsctl instead.", nil)
+		return
+	}
+
+	manifest, err := Restore(h.db.WithContext(c.Request.Context()), file, header.Size)
+	if err != nil {
+		h.respondError(c, err, "failed to restore backup")
+		return
+	}
+
+	h.logger.Info("tenant backup restored", "subscriptionId", manifest.SubscriptionID, "tables", len(manifest.Tables), "requestedBy", requester.ID)
+	response.Success(c, http.StatusCreated, manifest, "", nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
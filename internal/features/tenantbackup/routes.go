@@ -0,0 +1,18 @@
+package tenantbackup
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the tenant backup endpoints to the router. Archives
+// hold every user's credentials, so only super admins may take or restore them.
+// Middleware is passed as parameters to avoid import cycles
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, superadminOnly []gin.HandlerFunc) {
+	group := router.Group("/subscriptions")
+	group.GET("/:subscriptionId/backup", append(superadminOnly, handler.Export)...)
+	group.POST("/restore", append(superadminOnly, handler.Restore)...)
+
+	openapi.Describe(handler.Restore, openapi.Spec{RequestType: "multipart/form-data", Response: Manifest{}})
+}
@@ -0,0 +1,276 @@
+package tenantbackup
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// excluded tables are never exported: queues, in-flight sessions, derived
+// summaries and credentials that only the issuing environment can honour.
+// Tables that cannot exist without one of them are skipped too.
+var excluded = map[string]bool{
+	"outbox_events":         true,
+	"email_messages":        true,
+	"webhook_deliveries":    true,
+	"playback_sessions":     true,
+	"lesson_uploads":        true,
+	"sso_logins":            true,
+	"scim_tokens":           true,
+	"classroom_connections": true,
+	"subscription_stats":    true,
+	"sync_tombstones":       true,
+}
+
+type column struct {
+	Name      string
+	Nullable  bool
+	Generated bool
+}
+
+// foreignKey is a single-column foreign key.
+type foreignKey struct {
+	Table        string
+	Column       string
+	Parent       string
+	ParentColumn string
+}
+
+// schema is the part of the database catalogue backups depend on.
+type schema struct {
+	columns map[string][]column
+	primary map[string][]string
+	keys    []foreignKey
+}
+
+// loadSchema reads the tables, primary keys and foreign keys of the current schema.
+func loadSchema(db *gorm.DB) (schema, error) {
+	s := schema{columns: make(map[string][]column), primary: make(map[string][]string)}
+
+	var columns []struct {
+		TableName  string
+		ColumnName string
+		IsNullable string
+		Generated  string
+	}
+	if err := db.Raw(`
+		SELECT c.table_name, c.column_name, c.is_nullable, c.is_generated AS generated
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position`).Scan(&columns).Error; err != nil {
+		return s, fmt.Errorf("load columns: %w", err)
+	}
+	for _, c := range columns {
+		s.columns[c.TableName] = append(s.columns[c.TableName], column{
+			Name:      c.ColumnName,
+			Nullable:  c.IsNullable == "YES",
+			Generated: c.Generated == "ALWAYS",
+		})
+	}
+
+	var primary []struct {
+		TableName  string
+		ColumnName string
+	}
+	if err := db.Raw(`
+		SELECT tc.table_name, kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = current_schema() AND tc.constraint_type = 'PRIMARY KEY'
+		ORDER BY tc.table_name, kcu.ordinal_position`).Scan(&primary).Error; err != nil {
+		return s, fmt.Errorf("load primary keys: %w", err)
+	}
+	for _, p := range primary {
+		s.primary[p.TableName] = append(s.primary[p.TableName], p.ColumnName)
+	}
+
+	// Composite foreign keys cannot be followed by a single column and are left out
+	if err := db.Raw(`
+		SELECT kcu.table_name AS "table", kcu.column_name AS "column",
+			ccu.table_name AS parent, ccu.column_name AS parent_column
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = current_schema() AND tc.constraint_type = 'FOREIGN KEY'
+			AND (SELECT count(*) FROM information_schema.key_column_usage k
+				WHERE k.constraint_schema = tc.constraint_schema AND k.constraint_name = tc.constraint_name) = 1
+		ORDER BY kcu.table_name, kcu.column_name`).Scan(&s.keys).Error; err != nil {
+		return s, fmt.Errorf("load foreign keys: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s schema) column(table, name string) (column, bool) {
+	for _, c := range s.columns[table] {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// tablePlan is how one table is exported.
+type tablePlan struct {
+	Name string
+	// Where selects the subscription's rows; it uses the @subscription parameter.
+	Where string
+	// Omit are nullable references to excluded tables, which are restored empty.
+	Omit []string
+}
+
+// plan finds every table holding data of a subscription: the subscription
+// itself, tables with a subscription_id column and, transitively, tables
+// referencing rows already included. Parents come before the tables that
+// reach the subscription through them.
+func (s schema) plan() []tablePlan {
+	tables := make([]string, 0, len(s.columns))
+	for table := range s.columns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	skipped := s.skipped()
+	plans := []tablePlan{{Name: "subscriptions", Where: "id = @subscription"}}
+	included := map[string]int{"subscriptions": 0}
+
+	for changed := true; changed; {
+		changed = false
+		for _, table := range tables {
+			if _, ok := included[table]; ok || skipped[table] {
+				continue
+			}
+			if plan, ok := s.planTable(table, plans, included, skipped); ok {
+				included[table] = len(plans)
+				plans = append(plans, plan)
+				changed = true
+			}
+		}
+	}
+	return plans
+}
+
+// skipped returns the excluded tables and every table that requires a row of
+// one of them.
+func (s schema) skipped() map[string]bool {
+	skipped := make(map[string]bool, len(excluded))
+	for table := range excluded {
+		skipped[table] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, key := range s.keys {
+			if skipped[key.Table] || !skipped[key.Parent] {
+				continue
+			}
+			if col, _ := s.column(key.Table, key.Column); !col.Nullable {
+				skipped[key.Table] = true
+				changed = true
+			}
+		}
+	}
+	return skipped
+}
+
+// planTable plans one table against the tables included so far.
+func (s schema) planTable(table string, plans []tablePlan, included map[string]int, skipped map[string]bool) (tablePlan, bool) {
+	plan := tablePlan{Name: table}
+	var conditions []string
+
+	for _, key := range s.keys {
+		if key.Table != table || key.Parent == table {
+			continue
+		}
+		if skipped[key.Parent] {
+			plan.Omit = append(plan.Omit, key.Column)
+			continue
+		}
+		if i, ok := included[key.Parent]; ok {
+			conditions = append(conditions, fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+				quote(key.Column), quote(key.ParentColumn), quote(key.Parent), plans[i].Where))
+		}
+	}
+
+	if _, ok := s.column(table, "subscription_id"); ok {
+		plan.Where = "subscription_id = @subscription"
+		return plan, true
+	}
+	if len(conditions) == 0 {
+		return plan, false
+	}
+	plan.Where = strings.Join(conditions, " OR ")
+	return plan, true
+}
+
+// restoreOrder sorts the archived tables so every required reference points
+// to a table restored earlier. Optional references are filled in afterwards,
+// see deferred, so they may form cycles.
+func (s schema) restoreOrder(tables []string) ([]string, error) {
+	pending := slices.Clone(tables)
+	order := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+
+	for len(pending) > 0 {
+		next := pending[:0]
+		progressed := false
+		for _, table := range pending {
+			if s.requiresPending(table, tables, done) {
+				next = append(next, table)
+				continue
+			}
+			order = append(order, table)
+			done[table] = true
+			progressed = true
+		}
+		if !progressed {
+			return nil, fmt.Errorf("%w: required references form a cycle between %s", ErrInvalidArchive, strings.Join(next, ", "))
+		}
+		pending = next
+	}
+	return order, nil
+}
+
+func (s schema) requiresPending(table string, tables []string, done map[string]bool) bool {
+	for _, key := range s.keys {
+		if key.Table != table || key.Parent == table || done[key.Parent] || !slices.Contains(tables, key.Parent) {
+			continue
+		}
+		if col, _ := s.column(table, key.Column); !col.Nullable || len(s.primary[table]) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// deferred returns the table's nullable references to tables not restored
+// before it, including itself and tables outside the archive. They are left
+// empty when rows are inserted and set once every table is restored, and only
+// where the referenced row exists. Rows without a primary key cannot be
+// updated, so their references are inserted with the rows instead.
+func (s schema) deferred(table string, restored map[string]bool) []foreignKey {
+	if len(s.primary[table]) == 0 {
+		return nil
+	}
+	var keys []foreignKey
+	for _, key := range s.keys {
+		if key.Table != table || (restored[key.Parent] && key.Parent != table) {
+			continue
+		}
+		if col, _ := s.column(table, key.Column); col.Nullable {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -1,6 +1,11 @@
 package thread
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
 
 var (
 	ErrThreadNotFound   = errors.New("thread not found")
@@ -10,3 +15,13 @@ var (
 	ErrUnauthorized     = errors.New("unauthorized to modify this thread")
 	ErrReplyNotFound    = errors.New("reply not found")
 )
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrThreadNotFound, Status: http.StatusNotFound, Code: "THREAD_NOT_FOUND", Message: "Thread not found."},
+	{Err: ErrTitleRequired, Status: http.StatusBadRequest, Code: "THREAD_TITLE_REQUIRED", Message: "Thread title is required."},
+	{Err: ErrContentRequired, Status: http.StatusBadRequest, Code: "THREAD_CONTENT_REQUIRED", Message: "Thread content is required."},
+	{Err: ErrUserNameRequired, Status: http.StatusBadRequest, Code: "USER_NAME_REQUIRED", Message: "Author name is required."},
+	{Err: ErrUnauthorized, Status: http.StatusForbidden, Message: "Unauthorized to modify this thread."},
+	{Err: ErrReplyNotFound, Status: http.StatusNotFound, Code: "REPLY_NOT_FOUND", Message: "Reply not found."},
+}
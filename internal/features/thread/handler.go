@@ -1,8 +1,9 @@
 package thread
 
 import (
-	"errors"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"log/slog"
 
@@ -10,23 +11,38 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
-	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/features/readreceipt"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
-	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
+// Live events emitted to sockets that joined a thread.
+const (
+	EventReplyAdded   = "threadReplyAdded"
+	EventReplyDeleted = "threadReplyDeleted"
+)
+
+// ThreadBroadcaster pushes live updates to clients viewing a thread.
+type ThreadBroadcaster interface {
+	EmitToThread(threadID uuid.UUID, event string, payload any)
+}
+
 // Handler processes thread HTTP requests.
 type Handler struct {
-	db     *gorm.DB
-	logger *slog.Logger
+	db       *gorm.DB
+	logger   *slog.Logger
+	events   ThreadBroadcaster
+	notifier *notification.Service
 }
 
-// NewHandler constructs a thread handler instance.
-func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
-	return &Handler{db: db, logger: logger}
+// NewHandler constructs a thread handler instance. events and notifier may be nil
+// to disable live updates and reply notifications.
+func NewHandler(db *gorm.DB, logger *slog.Logger, events ThreadBroadcaster, notifier *notification.Service) *Handler {
+	return &Handler{db: db, logger: logger, events: events, notifier: notifier}
 }
 
 // List returns all threads for a forum with pagination.
@@ -66,6 +82,12 @@ func (h *Handler) GetByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, thread, "", nil)
 }
 
+type createRequest struct {
+	Title    string `json:"title" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+	Approved *bool  `json:"isApproved"`
+}
+
 // Create inserts a new thread.
 func (h *Handler) Create(c *gin.Context) {
 	forumID, err := uuid.Parse(c.Param("forumId"))
@@ -74,14 +96,9 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Title    string `json:"title" binding:"required"`
-		Content  string `json:"content" binding:"required"`
-		Approved *bool  `json:"isApproved"`
-	}
+	var req createRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid thread payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid thread payload") {
 		return
 	}
 
@@ -93,10 +110,11 @@ func (h *Handler) Create(c *gin.Context) {
 
 	// Check if forum allows this user type to post
 	var forum struct {
+		SubscriptionID uuid.UUID
 		AssistantsOnly bool
 		Active         bool
 	}
-	err = h.db.Table("forums").Select("assistants_only, active").Where("id = ?", forumID).Scan(&forum).Error
+	err = h.db.Table("forums").Select("subscription_id, assistants_only, active").Where("id = ?", forumID).Scan(&forum).Error
 	if err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load forum", err)
 		return
@@ -108,10 +126,7 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	// Check assistantsOnly permission
-	isStaff := currentUser.UserType == types.UserTypeInstructor ||
-		currentUser.UserType == types.UserTypeAssistant ||
-		currentUser.UserType == types.UserTypeAdmin ||
-		currentUser.UserType == types.UserTypeSuperAdmin
+	isStaff := authz.CanModerate(authz.SubjectFrom(currentUser))
 
 	if forum.AssistantsOnly && !isStaff {
 		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Only instructors and assistants can post in this forum.", ErrUnauthorized)
@@ -122,6 +137,7 @@ func (h *Handler) Create(c *gin.Context) {
 		ForumID:  forumID,
 		Title:    req.Title,
 		Content:  req.Content,
+		UserID:   currentUser.ID,
 		UserName: currentUser.FullName,
 		UserType: currentUser.UserType,
 		Approved: req.Approved,
@@ -132,9 +148,28 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	if h.notifier != nil {
+		h.notifier.ThreadCreated(notification.ThreadEvent{
+			SubscriptionID: forum.SubscriptionID,
+			ForumID:        forumID,
+			ThreadID:       thread.ID,
+			ThreadTitle:    thread.Title,
+			ActorID:        currentUser.ID,
+			ActorName:      currentUser.FullName,
+			Text:           thread.Title + "\n" + thread.Content,
+			Visible:        thread.Approved,
+		})
+	}
+
 	response.Created(c, thread, "")
 }
 
+type updateRequest struct {
+	Title    *string `json:"title" binding:"omitnil,notblank"`
+	Content  *string `json:"content" binding:"omitnil,notblank"`
+	Approved *bool   `json:"isApproved"`
+}
+
 // Update modifies an existing thread.
 func (h *Handler) Update(c *gin.Context) {
 	threadID, err := uuid.Parse(c.Param("threadId"))
@@ -143,39 +178,15 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid thread payload", err)
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid thread payload") {
 		return
 	}
 
-	input := UpdateInput{}
-
-	if value, ok := body["title"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "title must be a string", err)
-			return
-		}
-		input.Title = &str
-	}
-
-	if value, ok := body["content"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "content must be a string", err)
-			return
-		}
-		input.Content = &str
-	}
-
-	if value, ok := body["isApproved"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isApproved must be boolean", err)
-			return
-		}
-		input.Approved = &val
+	input := UpdateInput{
+		Title:    request.Trimmed(req.Title),
+		Content:  request.Trimmed(req.Content),
+		Approved: req.Approved,
 	}
 
 	thread, err := Update(h.db, threadID, input)
@@ -203,6 +214,10 @@ func (h *Handler) Delete(c *gin.Context) {
 	response.Success(c, http.StatusOK, nil, "Thread deleted successfully", nil)
 }
 
+type approveRequest struct {
+	Approved bool `json:"isApproved"`
+}
+
 // Approve toggles the approval status of a thread.
 func (h *Handler) Approve(c *gin.Context) {
 	threadID, err := uuid.Parse(c.Param("threadId"))
@@ -211,12 +226,15 @@ func (h *Handler) Approve(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Approved bool `json:"isApproved"`
+	var req approveRequest
+
+	if !request.BindJSON(h.logger, c, &req, "invalid approval payload") {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid approval payload", err)
+	existing, err := Get(h.db, threadID)
+	if err != nil {
+		h.respondError(c, err, "failed to load thread")
 		return
 	}
 
@@ -229,9 +247,31 @@ func (h *Handler) Approve(c *gin.Context) {
 		return
 	}
 
+	// Mentions in threads held for approval are delivered once the thread goes public
+	if h.notifier != nil && !existing.Approved && req.Approved && existing.UserID != nil {
+		if subscriptionID, err := h.forumSubscription(existing.ForumID); err != nil {
+			h.logger.Error("failed to load forum subscription", "forumId", existing.ForumID, "error", err)
+		} else {
+			h.notifier.ThreadCreated(notification.ThreadEvent{
+				SubscriptionID: subscriptionID,
+				ForumID:        existing.ForumID,
+				ThreadID:       existing.ID,
+				ThreadTitle:    existing.Title,
+				ActorID:        *existing.UserID,
+				ActorName:      existing.UserName,
+				Text:           existing.Title + "\n" + existing.Content,
+				Visible:        true,
+			})
+		}
+	}
+
 	response.Success(c, http.StatusOK, thread, "", nil)
 }
 
+type addReplyRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
 // AddReply adds a reply to a thread.
 func (h *Handler) AddReply(c *gin.Context) {
 	threadID, err := uuid.Parse(c.Param("threadId"))
@@ -240,12 +280,9 @@ func (h *Handler) AddReply(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Content string `json:"content" binding:"required"`
-	}
+	var req addReplyRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid reply payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid reply payload") {
 		return
 	}
 
@@ -266,10 +303,11 @@ func (h *Handler) AddReply(c *gin.Context) {
 
 	// Check if forum is assistantsOnly
 	var forum struct {
+		SubscriptionID uuid.UUID
 		AssistantsOnly bool
 		Active         bool
 	}
-	if err := h.db.Table("forums").Select("assistants_only, active").Where("id = ?", threadData.ForumID).Scan(&forum).Error; err != nil {
+	if err := h.db.Table("forums").Select("subscription_id, assistants_only, active").Where("id = ?", threadData.ForumID).Scan(&forum).Error; err != nil {
 		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load forum", err)
 		return
 	}
@@ -280,22 +318,42 @@ func (h *Handler) AddReply(c *gin.Context) {
 	}
 
 	// Check assistantsOnly permission for replies
-	isStaff := currentUser.UserType == types.UserTypeInstructor ||
-		currentUser.UserType == types.UserTypeAssistant ||
-		currentUser.UserType == types.UserTypeAdmin ||
-		currentUser.UserType == types.UserTypeSuperAdmin
+	isStaff := authz.CanModerate(authz.SubjectFrom(currentUser))
 
 	if forum.AssistantsOnly && !isStaff {
 		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Only instructors and assistants can reply in this forum.", ErrUnauthorized)
 		return
 	}
 
-	thread, err := AddReply(h.db, threadID, currentUser.FullName, currentUser.UserType, req.Content)
+	thread, err := AddReply(h.db, threadID, currentUser.ID, currentUser.FullName, currentUser.UserType, req.Content)
 	if err != nil {
 		h.respondError(c, err, "failed to add reply")
 		return
 	}
 
+	if h.notifier != nil {
+		h.notifier.ThreadReplied(notification.ThreadEvent{
+			SubscriptionID: forum.SubscriptionID,
+			ForumID:        thread.ForumID,
+			ThreadID:       thread.ID,
+			ThreadTitle:    thread.Title,
+			ActorID:        currentUser.ID,
+			ActorName:      currentUser.FullName,
+			Text:           req.Content,
+			Visible:        true,
+		})
+	}
+
+	if h.events != nil {
+		var replies []Reply
+		if err := json.Unmarshal(thread.Replies, &replies); err == nil && len(replies) > 0 {
+			h.events.EmitToThread(thread.ID, EventReplyAdded, gin.H{
+				"threadId": thread.ID,
+				"reply":    replies[len(replies)-1],
+			})
+		}
+	}
+
 	response.Success(c, http.StatusOK, thread, "", nil)
 }
 
@@ -320,7 +378,7 @@ func (h *Handler) DeleteReply(c *gin.Context) {
 	}
 
 	// Check authorization: only instructors, assistants, admins, superadmins can delete replies
-	if !user.CanManageUserType(currentUser.UserType, types.UserTypeStudent) {
+	if !authz.CanModerate(authz.SubjectFrom(currentUser)) {
 		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "unauthorized to delete replies", ErrUnauthorized)
 		return
 	}
@@ -331,33 +389,164 @@ func (h *Handler) DeleteReply(c *gin.Context) {
 		return
 	}
 
+	if h.events != nil {
+		h.events.EmitToThread(threadID, EventReplyDeleted, gin.H{
+			"threadId": threadID,
+			"replyId":  replyID,
+		})
+	}
+
 	response.Success(c, http.StatusOK, thread, "", nil)
 }
 
+// GetSubscription reports whether the current user follows the thread and whether it is muted.
+func (h *Handler) GetSubscription(c *gin.Context) {
+	threadID, currentUser, ok := h.threadAndUser(c)
+	if !ok {
+		return
+	}
+
+	sub, err := notification.GetThreadSubscription(h.db, threadID, currentUser.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load thread subscription", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"subscribed": sub != nil,
+		"muted":      sub != nil && sub.Muted,
+	}, "", nil)
+}
+
+type subscribeRequest struct {
+	Muted bool `json:"muted"`
+}
+
+// Subscribe follows the thread; pass {"muted": true} to keep following silently.
+func (h *Handler) Subscribe(c *gin.Context) {
+	threadID, currentUser, ok := h.threadAndUser(c)
+	if !ok {
+		return
+	}
+
+	var req subscribeRequest
+	if c.Request.ContentLength > 0 {
+		if !request.BindJSON(h.logger, c, &req, "invalid subscription payload") {
+			return
+		}
+	}
+
+	if _, err := Get(h.db, threadID); err != nil {
+		h.respondError(c, err, "failed to load thread")
+		return
+	}
+
+	sub, err := notification.SetThreadSubscription(h.db, threadID, currentUser.ID, req.Muted)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to update thread subscription", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"subscribed": true, "muted": sub.Muted}, "", nil)
+}
+
+// Unsubscribe stops following the thread.
+func (h *Handler) Unsubscribe(c *gin.Context) {
+	threadID, currentUser, ok := h.threadAndUser(c)
+	if !ok {
+		return
+	}
+
+	if err := notification.Unfollow(h.db, threadID, currentUser.ID); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to update thread subscription", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"subscribed": false, "muted": false}, "", nil)
+}
+
+type markReadRequest struct {
+	ReplyID string `json:"replyId"`
+}
+
+// MarkRead records that the current user read the thread up to a reply, or
+// all of it without {"replyId"}, and tells the thread's viewers.
+func (h *Handler) MarkRead(c *gin.Context) {
+	threadID, currentUser, ok := h.threadAndUser(c)
+	if !ok {
+		return
+	}
+
+	var req markReadRequest
+	if c.Request.ContentLength > 0 {
+		if !request.BindJSON(h.logger, c, &req, "invalid read payload") {
+			return
+		}
+	}
+
+	thread, err := Get(h.db, threadID)
+	if err != nil {
+		h.respondError(c, err, "failed to load thread")
+		return
+	}
+
+	messageID, readThrough, err := ReadPoint(thread, strings.TrimSpace(req.ReplyID))
+	if err != nil {
+		h.respondError(c, err, "failed to mark thread read")
+		return
+	}
+
+	receipt, err := readreceipt.Mark(h.db, currentUser.ID, readreceipt.ChannelThread, threadID.String(), messageID, readThrough)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to mark thread read", err)
+		return
+	}
+
+	if h.events != nil {
+		h.events.EmitToThread(threadID, readreceipt.EventRead, readreceipt.Reader{Receipt: receipt, UserName: currentUser.FullName})
+	}
+
+	response.Success(c, http.StatusOK, receipt, "", nil)
+}
+
+// Readers lists how far each member has read the thread.
+func (h *Handler) Readers(c *gin.Context) {
+	threadID, _, ok := h.threadAndUser(c)
+	if !ok {
+		return
+	}
+
+	readers, err := readreceipt.Readers(h.db, readreceipt.ChannelThread, threadID.String())
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load read receipts", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, readers, "", nil)
+}
+
+func (h *Handler) threadAndUser(c *gin.Context) (uuid.UUID, *middleware.User, bool) {
+	threadID, err := uuid.Parse(c.Param("threadId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid thread id", err)
+		return uuid.Nil, nil, false
+	}
+
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return uuid.Nil, nil, false
+	}
+
+	return threadID, currentUser, true
+}
+
+func (h *Handler) forumSubscription(forumID uuid.UUID) (uuid.UUID, error) {
+	var subscriptionID uuid.UUID
+	err := h.db.Table("forums").Select("subscription_id").Where("id = ?", forumID).Scan(&subscriptionID).Error
+	return subscriptionID, err
+}
+
 func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
-
-	switch {
-	case errors.Is(err, ErrThreadNotFound):
-		status = http.StatusNotFound
-		message = "Thread not found."
-	case errors.Is(err, ErrTitleRequired):
-		status = http.StatusBadRequest
-		message = "Thread title is required."
-	case errors.Is(err, ErrContentRequired):
-		status = http.StatusBadRequest
-		message = "Thread content is required."
-	case errors.Is(err, ErrUserNameRequired):
-		status = http.StatusBadRequest
-		message = "Author name is required."
-	case errors.Is(err, ErrUnauthorized):
-		status = http.StatusForbidden
-		message = "Unauthorized to modify this thread."
-	case errors.Is(err, ErrReplyNotFound):
-		status = http.StatusNotFound
-		message = "Reply not found."
-	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
@@ -7,12 +7,15 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/features/readreceipt"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
 // Reply represents a nested reply in a thread.
 type Reply struct {
 	ID        string    `json:"id"`
+	UserID    string    `json:"userId,omitempty"`
 	UserName  string    `json:"userName"`
 	UserType  string    `json:"userType"`
 	Content   string    `json:"content"`
@@ -26,6 +29,7 @@ type Thread struct {
 	ForumID   uuid.UUID       `gorm:"type:uuid;not null;index:idx_forum_approved_created,priority:1;index:idx_forum_created,priority:1" json:"forumId"`
 	Title     string          `gorm:"size:100;not null" json:"title"`
 	Content   string          `gorm:"size:2000;not null" json:"content"`
+	UserID    *uuid.UUID      `gorm:"type:uuid;column:user_id" json:"userId,omitempty"`
 	UserName  string          `gorm:"size:30;not null;index:idx_username_created,priority:1" json:"userName"`
 	UserType  string          `gorm:"size:20;not null" json:"userType"`
 	Replies   json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"replies"`
@@ -49,7 +53,7 @@ func GetRecentForForum(db *gorm.DB, forumID uuid.UUID, limit int) ([]Thread, err
 	err := db.Where("forum_id = ? AND approved = ?", forumID, true).
 		Order("created_at DESC").
 		Limit(limit).
-		Select("id, forum_id, title, content, user_name, user_type, approved, created_at, updated_at").
+		Select("id, forum_id, title, content, user_id, user_name, user_type, approved, created_at, updated_at").
 		Find(&threads).Error
 
 	return threads, err
@@ -69,7 +73,7 @@ func GetByForum(db *gorm.DB, forumID uuid.UUID, limit, offset int) ([]Thread, in
 	if err := query.Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
-		Select("id, forum_id, title, content, user_name, user_type, approved, created_at, updated_at").
+		Select("id, forum_id, title, content, user_id, user_name, user_type, approved, created_at, updated_at").
 		Find(&threads).Error; err != nil {
 		return nil, 0, err
 	}
@@ -94,6 +98,7 @@ type CreateInput struct {
 	ForumID  uuid.UUID
 	Title    string
 	Content  string
+	UserID   uuid.UUID
 	UserName string
 	UserType types.UserType
 	Approved *bool
@@ -147,6 +152,7 @@ func Create(db *gorm.DB, input CreateInput) (*Thread, error) {
 		ForumID:  input.ForumID,
 		Title:    input.Title,
 		Content:  input.Content,
+		UserID:   &input.UserID,
 		UserName: input.UserName,
 		UserType: string(input.UserType),
 		Replies:  json.RawMessage("[]"),
@@ -206,22 +212,27 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (*Thread, error) {
 	return &thread, nil
 }
 
-// Delete removes a thread.
+// Delete removes a thread together with its followers and read receipts.
 func Delete(db *gorm.DB, id uuid.UUID) error {
-	result := db.Delete(&Thread{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Thread{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.RowsAffected == 0 {
-		return ErrThreadNotFound
-	}
+		if result.RowsAffected == 0 {
+			return ErrThreadNotFound
+		}
 
-	return nil
+		if err := readreceipt.DeleteChannel(tx, readreceipt.ChannelThread, id.String()); err != nil {
+			return err
+		}
+		return notification.DeleteThreadSubscriptions(tx, id)
+	})
 }
 
 // AddReply adds a reply to a thread.
-func AddReply(db *gorm.DB, threadID uuid.UUID, userName string, userType types.UserType, content string) (*Thread, error) {
+func AddReply(db *gorm.DB, threadID, userID uuid.UUID, userName string, userType types.UserType, content string) (*Thread, error) {
 	var thread Thread
 	if err := db.First(&thread, "id = ?", threadID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -239,6 +250,7 @@ func AddReply(db *gorm.DB, threadID uuid.UUID, userName string, userType types.U
 	// Replies are always approved (only threads need approval)
 	newReply := Reply{
 		ID:        uuid.New().String(),
+		UserID:    userID.String(),
 		UserName:  userName,
 		UserType:  string(userType),
 		Content:   content,
@@ -302,3 +314,28 @@ func DeleteReply(db *gorm.DB, threadID uuid.UUID, replyID string) (*Thread, erro
 	thread.Replies = repliesJSON
 	return &thread, nil
 }
+
+// ReadPoint returns the message a reader reached: the given reply, or the
+// newest one when replyID is empty. A thread without replies is read through
+// its opening post.
+func ReadPoint(thread *Thread, replyID string) (string, time.Time, error) {
+	var replies []Reply
+	if err := json.Unmarshal(thread.Replies, &replies); err != nil {
+		return "", time.Time{}, err
+	}
+
+	if replyID == "" {
+		if len(replies) == 0 {
+			return thread.ID.String(), thread.CreatedAt, nil
+		}
+		last := replies[len(replies)-1]
+		return last.ID, last.CreatedAt, nil
+	}
+
+	for _, reply := range replies {
+		if reply.ID == replyID {
+			return reply.ID, reply.CreatedAt, nil
+		}
+	}
+	return "", time.Time{}, ErrReplyNotFound
+}
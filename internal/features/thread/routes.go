@@ -2,6 +2,9 @@ package thread
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/readreceipt"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes sets up thread endpoints under /subscriptions/:subscriptionId/forums/:forumId/threads.
@@ -16,4 +19,20 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll []gin.Handl
 	threads.PUT("/:threadId/approve", append(acStaff, handler.Approve)...)
 	threads.POST("/:threadId/replies", append(acAll, handler.AddReply)...)
 	threads.DELETE("/:threadId/replies/:replyId", append(acAll, handler.DeleteReply)...)
+	threads.GET("/:threadId/subscription", append(acAll, handler.GetSubscription)...)
+	threads.PUT("/:threadId/subscription", append(acAll, handler.Subscribe)...)
+	threads.DELETE("/:threadId/subscription", append(acAll, handler.Unsubscribe)...)
+	threads.PUT("/:threadId/read", append(acAll, handler.MarkRead)...)
+	threads.GET("/:threadId/readers", append(acAll, handler.Readers)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Thread{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: Thread{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: Thread{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Thread{}})
+	openapi.Describe(handler.Approve, openapi.Spec{Request: approveRequest{}, Response: Thread{}})
+	openapi.Describe(handler.AddReply, openapi.Spec{Request: addReplyRequest{}, Response: Thread{}})
+	openapi.Describe(handler.DeleteReply, openapi.Spec{Response: Thread{}})
+	openapi.Describe(handler.Subscribe, openapi.Spec{Request: subscribeRequest{}})
+	openapi.Describe(handler.MarkRead, openapi.Spec{Request: markReadRequest{}, Response: readreceipt.Receipt{}})
+	openapi.Describe(handler.Readers, openapi.Spec{Response: []readreceipt.Reader{}})
 }
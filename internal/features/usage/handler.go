@@ -3,6 +3,7 @@ package usage
 import (
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,6 +15,11 @@ import (
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
+const (
+	defaultHistoryDays = 30
+	maxHistoryDays     = 365
+)
+
 type Handler struct {
 	db           *gorm.DB
 	logger       *slog.Logger
@@ -191,6 +197,60 @@ func (h *Handler) GetCourseStats(c *gin.Context) {
 	response.Success(c, http.StatusOK, usageStats, "", nil)
 }
 
+// GetCourseBreakdown splits a course's stored usage into streams, files and
+// covers against its limit, with daily snapshots of the last days.
+// GET /usage/subscription/:subscriptionId/course/:courseId/breakdown?days=30
+func (h *Handler) GetCourseBreakdown(c *gin.Context) {
+	if h.storageUsage == nil {
+		response.ErrorWithLog(h.logger, c, http.StatusNotImplemented, "storage usage service is not configured", nil)
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID format", nil)
+		return
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid course ID format", nil)
+		return
+	}
+
+	days := defaultHistoryDays
+	if raw := c.Query("days"); raw != "" {
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 1 || days > maxHistoryDays {
+			response.Error(c, http.StatusBadRequest, "days must be between 1 and 365", nil)
+			return
+		}
+	}
+
+	courseRecord, err := course.Get(h.db, courseID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(c, http.StatusNotFound, "Course not found", nil)
+		} else {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to retrieve course", err)
+		}
+		return
+	}
+
+	if courseRecord.SubscriptionID != subscriptionID {
+		response.Error(c, http.StatusNotFound, "Course not found for subscription", nil)
+		return
+	}
+
+	breakdown, err := h.storageUsage.CourseBreakdown(courseID, days)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to load course storage breakdown", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, breakdown, "", nil)
+}
+
 // RecalculateSubscription forces a Bunny usage refresh for all courses in a subscription.
 func (h *Handler) RecalculateSubscription(c *gin.Context) {
 	if h.storageUsage == nil {
@@ -2,6 +2,9 @@ package usage
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly, acAdmin, acStaffWithInactive []gin.HandlerFunc) {
@@ -35,6 +38,13 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly, acAdmi
 			)...,
 		)
 
+		usage.GET("/subscription/:subscriptionId/course/:courseId/breakdown",
+			append(
+				acStaffWithInactive,
+				handler.GetCourseBreakdown,
+			)...,
+		)
+
 		usage.POST("/subscription/:subscriptionId/course/:courseId/recalculate",
 			append(
 				acStaffWithInactive,
@@ -42,4 +52,7 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminOnly, acAdmi
 			)...,
 		)
 	}
+
+	openapi.Describe(handler.GetCourseBreakdown, openapi.Spec{Response: storageusage.Breakdown{}})
+	openapi.Describe(handler.RecalculateCourse, openapi.Spec{Response: storageusage.CourseStats{}})
 }
@@ -2,7 +2,9 @@ package user
 
 import (
 	"errors"
+	"net/http"
 
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
@@ -12,6 +14,7 @@ var (
 	ErrInvalidPassword    = errors.New("password must be at least 8 characters")
 	ErrUnauthorized       = errors.New("unauthorized to perform this action")
 	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrIncorrectPassword  = errors.New("current password is incorrect")
 )
 
 // Re-export types from pkg/types for backward compatibility
@@ -27,11 +30,10 @@ const (
 	UserTypeAll        = types.UserTypeAll
 )
 
-var UserTypeOrder = []types.UserType{
-	types.UserTypeReferrer,
-	types.UserTypeStudent,
-	types.UserTypeAssistant,
-	types.UserTypeInstructor,
-	types.UserTypeAdmin,
-	types.UserTypeSuperAdmin,
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found."},
+	{Err: ErrEmailTaken, Status: http.StatusConflict, Code: "EMAIL_TAKEN", Message: "Email already exists."},
+	{Err: ErrInvalidPassword, Status: http.StatusBadRequest, Code: "INVALID_PASSWORD"},
+	{Err: ErrUnauthorized, Status: http.StatusForbidden},
 }
@@ -0,0 +1,31 @@
+package user
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// TopicCreated is the outbox topic published when an account joins a subscription.
+const TopicCreated = "user.created"
+
+// Event is the outbox payload for new subscription accounts.
+type Event struct {
+	SubscriptionID uuid.UUID      `json:"subscriptionId"`
+	UserID         uuid.UUID      `json:"userId"`
+	FullName       string         `json:"fullName"`
+	Email          string         `json:"email"`
+	UserType       types.UserType `json:"userType"`
+}
+
+func publishCreated(tx *gorm.DB, user User) error {
+	return outbox.Publish(tx, TopicCreated, user.ID, Event{
+		SubscriptionID: *user.SubscriptionID,
+		UserID:         user.ID,
+		FullName:       user.FullName,
+		Email:          user.Email,
+		UserType:       user.UserType,
+	})
+}
@@ -0,0 +1,135 @@
+package user
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/export"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Export streams the users visible to the requester as CSV or XLSX.
+// GET /users/export?format=csv|xlsx&filterKeyword=&subscription=
+func (h *Handler) Export(c *gin.Context) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "format must be csv or xlsx", err)
+		return
+	}
+
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	filters, ok := listFiltersFor(requester, c.Query("filterKeyword"), c.Query("subscription"))
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Subscription not found or inaccessible", nil)
+		return
+	}
+
+	writer, err := export.Stream(c, format, "users", []string{
+		"ID", "Full Name", "Email", "Phone", "User Type", "Subscription ID", "Active", "Email Verified", "Created At",
+	})
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to start export", err)
+		return
+	}
+
+	db := authz.ScopeQuery(authz.SubjectFrom(requester), replica.Reader(h.db.WithContext(c.Request.Context())), "users.subscription_id")
+	err = Each(db, filters, func(u User) error {
+		subscriptionID := ""
+		if u.SubscriptionID != nil {
+			subscriptionID = u.SubscriptionID.String()
+		}
+		return writer.Write([]string{
+			u.ID.String(),
+			u.FullName,
+			u.Email,
+			export.FormatStringPtr(u.Phone),
+			string(u.UserType),
+			subscriptionID,
+			strconv.FormatBool(u.Active),
+			strconv.FormatBool(u.EmailVerified),
+			export.FormatTime(u.CreatedAt),
+		})
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// Headers are already sent; the truncated download is the only signal left for the client.
+		h.logger.Error("user export failed", "error", err)
+	}
+}
+
+// ExportPersonalData downloads everything stored about one user: profile,
+// comments, forum posts, lesson access, watch history, purchases, support
+// tickets and notifications. Users may export their own data; staff may export
+// the accounts they manage.
+// POST /users/:userId/export?format=json|zip
+func (h *Handler) ExportPersonalData(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		request.RespondInvalid(c, validation.FieldError{Field: "format", Rule: "oneof", Message: "must be one of: json, zip"})
+		return
+	}
+
+	target, err := Get(h.db, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load user")
+		return
+	}
+
+	if !authz.CanManage(authz.SubjectFrom(requester), targetOf(target)) {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to export this user's data", nil)
+		return
+	}
+
+	data, err := CollectPersonalData(h.db.WithContext(c.Request.Context()), id)
+	if err != nil {
+		h.respondError(c, err, "failed to collect personal data")
+		return
+	}
+	h.signAvatar(&data.Profile)
+
+	h.logger.Info("personal data exported", "userId", id, "requestedBy", requester.ID, "format", format)
+
+	filename := fmt.Sprintf("user-%s.%s", id, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := data.WriteZip(zip.NewWriter(c.Writer)); err != nil {
+		// Headers are already sent; the truncated download is the only signal left for the client.
+		h.logger.Error("personal data export failed", "userId", id, "error", err)
+	}
+}
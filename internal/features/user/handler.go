@@ -13,12 +13,17 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
 	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
 	"github.com/mo-amir99/lms-server-go/pkg/request"
 	"github.com/mo-amir99/lms-server-go/pkg/response"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
 )
 
 // Email validation regex - allows standard emails and subscription domain format (@identifier)
@@ -26,8 +31,16 @@ var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+$`)
 
 // Handler processes user HTTP requests.
 type Handler struct {
-	db     *gorm.DB
-	logger *slog.Logger
+	db            *gorm.DB
+	logger        *slog.Logger
+	queryCache    *cache.Store
+	storageClient *bunny.StorageClient
+	sessions      SessionCloser
+}
+
+// SessionCloser closes a user's live connections once their tokens are revoked.
+type SessionCloser interface {
+	DisconnectUser(userID uuid.UUID)
 }
 
 // NewHandler constructs a user handler instance.
@@ -35,11 +48,36 @@ func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
 	return &Handler{db: db, logger: logger}
 }
 
+// UseCache sets the query cache whose dashboard counts user writes invalidate.
+func (h *Handler) UseCache(store *cache.Store) {
+	h.queryCache = store
+}
+
+// UseStorage sets the Bunny Storage client that holds avatars and signs their URLs.
+func (h *Handler) UseStorage(client *bunny.StorageClient) {
+	h.storageClient = client
+}
+
+// UseSessionCloser sets where live connections are closed when a user is
+// deactivated, deleted or changes password.
+func (h *Handler) UseSessionCloser(sessions SessionCloser) {
+	h.sessions = sessions
+}
+
+// closeSessions disconnects the user's sockets when a session closer is set.
+func (h *Handler) closeSessions(userID uuid.UUID) {
+	if h.sessions != nil {
+		h.sessions.DisconnectUser(userID)
+	}
+}
+
 // List returns paginated users with filters.
 func (h *Handler) List(c *gin.Context) {
-	params := pagination.Extract(c)
-	keyword := c.Query("filterKeyword")
-	subscriptionFilter := c.Query("subscription")
+	params, err := pagination.ExtractCursor(c)
+	if err != nil {
+		request.RespondInvalid(c, request.InvalidCursorField)
+		return
+	}
 
 	// Get current user from context (set by middleware)
 	user, ok := middleware.GetUserFromContext(c)
@@ -48,23 +86,45 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
+	filters, ok := listFiltersFor(user, c.Query("filterKeyword"), c.Query("subscription"))
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Subscription not found or inaccessible", nil)
+		return
+	}
+
+	db := authz.ScopeQuery(authz.SubjectFrom(user), replica.Reader(h.db.WithContext(c.Request.Context())), "users.subscription_id")
+	users, total, err := List(db, filters, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list users", err)
+		return
+	}
+
+	users, meta := pagination.Page(users, total, params)
+	for i := range users {
+		h.signAvatar(&users[i])
+	}
+	response.Success(c, http.StatusOK, users, "", meta)
+}
+
+// listFiltersFor builds the role-scoped filters shared by List and Export. The
+// subscription boundary itself is applied to the query with authz.ScopeQuery.
+// It reports false when the requester has no subscription to scope the listing to.
+func listFiltersFor(user *middleware.User, keyword, subscriptionFilter string) (ListFilters, bool) {
+	subject := authz.SubjectFrom(user)
 	filters := ListFilters{
 		Keyword: keyword,
 	}
 
-	// Role-based filtering logic
-	if user.UserType != types.UserTypeSuperAdmin {
-		// Non-superadmin users can only see users with lower user types
-		requesterIndex := UserTypeIndex(user.UserType)
-		if requesterIndex >= 0 {
-			allowedTypes := UserTypeOrder[:requesterIndex]
-			filters.UserTypes = allowedTypes
+	// Non-superadmin users can only see users with lower user types
+	if subject.Role != types.UserTypeSuperAdmin {
+		filters.UserTypes = authz.RolesBelow(subject.Role)
+		if len(filters.UserTypes) == 0 {
+			return filters, false
 		}
 	}
 
-	// Subscription filtering
-	switch user.UserType {
-	case types.UserTypeAdmin, types.UserTypeSuperAdmin:
+	switch {
+	case authz.IsPlatformAdmin(subject.Role):
 		// Admin/SuperAdmin can filter by subscription and exclude students by default
 		if subscriptionFilter != "" {
 			subID, err := uuid.Parse(subscriptionFilter)
@@ -74,41 +134,42 @@ func (h *Handler) List(c *gin.Context) {
 		}
 		// Exclude students and assistants for admin/superadmin views
 		filters.ExcludeUserTypes = []types.UserType{types.UserTypeStudent, types.UserTypeAssistant}
-	case types.UserTypeInstructor, types.UserTypeAssistant:
+	case authz.IsSubscriptionStaff(subject.Role):
 		// Instructor/Assistant can only see users from their subscription
-		filters.SubscriptionID = user.SubscriptionID
-	}
-
-	users, total, err := List(h.db, filters, params)
-	if err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list users", err)
-		return
+		if subject.SubscriptionID == nil {
+			return filters, false
+		}
+	default:
+		return filters, false
 	}
 
-	response.Success(c, http.StatusOK, users, "", pagination.MetadataFrom(total, params))
+	return filters, true
 }
 
 type createRequest struct {
-	SubscriptionID *string `json:"subscriptionId"`
-	FullName       string  `json:"fullName" binding:"required"`
+	SubscriptionID *string `json:"subscriptionId" binding:"omitnil,id"`
+	FullName       string  `json:"fullName" binding:"required,notblank"`
 	Email          string  `json:"email" binding:"required"`
 	Phone          *string `json:"phone"`
 	Password       string  `json:"password" binding:"required"`
-	UserType       string  `json:"userType" binding:"required"`
+	UserType       string  `json:"userType" binding:"required,usertype"`
 	Active         *bool   `json:"isActive"`
 }
 
+// invalidEmail is reported when an email fails emailRegex, which also accepts
+// subscription-domain addresses that the stock email rule rejects.
+var invalidEmail = validation.FieldError{Field: "email", Rule: "email", Message: "must be a valid email address"}
+
 // Create inserts a new user.
 func (h *Handler) Create(c *gin.Context) {
 	var req createRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user payload", err)
+	if !request.BindJSON(h.logger, c, &req, "invalid user payload") {
 		return
 	}
 
 	// Validate email format (allows both standard emails and subscription domain format)
 	if !emailRegex.MatchString(req.Email) {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid email format", fmt.Errorf("email must be in valid format"))
+		request.RespondInvalid(c, invalidEmail)
 		return
 	}
 
@@ -120,13 +181,12 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	targetUserType := types.UserType(req.UserType)
+	subject := authz.SubjectFrom(requester)
 
 	// Authorization: Prevent creating users with higher or equal user type
-	if requester.UserType != types.UserTypeSuperAdmin {
-		if !CanManageUserType(requester.UserType, targetUserType) {
-			response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to create a user with this user type", nil)
-			return
-		}
+	if !authz.CanCreate(subject, targetUserType) {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to create a user with this user type", nil)
+		return
 	}
 
 	var subscriptionID *uuid.UUID
@@ -140,7 +200,7 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	// Set subscription for instructor/assistant
-	if requester.UserType == types.UserTypeInstructor || requester.UserType == types.UserTypeAssistant {
+	if authz.IsSubscriptionStaff(subject.Role) {
 		subscriptionID = requester.SubscriptionID
 
 		// Email domain check - MUST end with @{identifierName}
@@ -185,6 +245,8 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+
 	response.Created(c, user, "")
 }
 
@@ -209,25 +271,27 @@ func (h *Handler) GetByID(c *gin.Context) {
 	}
 
 	// Authorization: user can only see their own profile, users in their subscription, or any user if admin/superadmin
-	isAuthorized := requesterUser.UserType == types.UserTypeAdmin ||
-		requesterUser.UserType == types.UserTypeSuperAdmin ||
-		requesterUser.ID == user.ID
-
-	if !isAuthorized && (requesterUser.UserType == types.UserTypeInstructor || requesterUser.UserType == types.UserTypeAssistant) {
-		// Instructor/Assistant can see users in their subscription
-		if requesterUser.SubscriptionID != nil && user.SubscriptionID != nil {
-			isAuthorized = *requesterUser.SubscriptionID == *user.SubscriptionID
-		}
-	}
-
-	if !isAuthorized {
+	if !authz.CanView(authz.SubjectFrom(requesterUser), targetOf(user)) {
 		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to get this user", nil)
 		return
 	}
 
+	h.signAvatar(&user)
 	response.Success(c, http.StatusOK, user, "", nil)
 }
 
+// updateRequest is a partial update; null clears phone and subscriptionId and
+// leaves the other fields unchanged.
+type updateRequest struct {
+	UserType       *string                     `json:"userType" binding:"omitnil,usertype"`
+	SubscriptionID validation.Optional[string] `json:"subscriptionId" binding:"omitnil,id"`
+	FullName       *string                     `json:"fullName" binding:"omitnil,notblank"`
+	Email          *string                     `json:"email" binding:"omitnil,notblank"`
+	Phone          validation.Optional[string] `json:"phone" binding:"omitnil,notblank"`
+	Password       *string                     `json:"password" binding:"omitnil,notblank"`
+	Active         *bool                       `json:"isActive"`
+}
+
 // Update modifies an existing user.
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("userId"))
@@ -250,43 +314,41 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	body := map[string]interface{}{}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user payload", err)
+	subject := authz.SubjectFrom(requester)
+	target := targetOf(userToUpdate)
+
+	if !authz.CanManage(subject, target) {
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to update this user", nil)
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid user payload") {
 		return
 	}
 
-	input := UpdateInput{}
+	input := UpdateInput{
+		FullName:      req.FullName,
+		PhoneProvided: req.Phone.Set,
+		Phone:         req.Phone.Ptr(),
+		Password:      req.Password,
+		Active:        req.Active,
+	}
 
 	// Check if userType is being changed
-	if value, ok := body["userType"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "userType must be a string", err)
-			return
-		}
-		targetUserType := types.UserType(str)
+	if req.UserType != nil {
+		targetUserType := types.UserType(*req.UserType)
 
 		// Authorization check: prevent updating users with higher userType or updating to higher userType
-		isSameUser := requester.ID == id
-		if requester.UserType != types.UserTypeSuperAdmin && !isSameUser {
-			// Check if requester can manage the current user type
-			if !CanManageUserType(requester.UserType, userToUpdate.UserType) {
-				response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to update this user", nil)
-				return
-			}
-			// Check if requester can manage the target user type
-			if !CanManageUserType(requester.UserType, targetUserType) {
-				response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to update this user", nil)
-				return
-			}
+		if !authz.CanAssignRole(subject, target, targetUserType) {
+			response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to update this user", nil)
+			return
 		}
 
 		input.UserType = &targetUserType
 
 		// Check subscription limits if instructor/assistant is changing user type
-		if (requester.UserType == types.UserTypeInstructor || requester.UserType == types.UserTypeAssistant) &&
-			targetUserType != userToUpdate.UserType {
+		if authz.IsSubscriptionStaff(subject.Role) && targetUserType != userToUpdate.UserType {
 			if err := h.checkSubscriptionLimits(userToUpdate.SubscriptionID, targetUserType, &id); err != nil {
 				response.ErrorWithLog(h.logger, c, http.StatusForbidden, err.Error(), err)
 				return
@@ -294,65 +356,36 @@ func (h *Handler) Update(c *gin.Context) {
 		}
 	}
 
-	// Instructors/assistants can only update users in their subscription
-	if requester.UserType == types.UserTypeInstructor || requester.UserType == types.UserTypeAssistant {
-		if requester.SubscriptionID == nil || userToUpdate.SubscriptionID == nil ||
-			*requester.SubscriptionID != *userToUpdate.SubscriptionID {
-			response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to update this user", nil)
-			return
-		}
-	}
-
-	if value, ok := body["subscriptionId"]; ok {
+	if req.SubscriptionID.Set {
 		input.SubscriptionIDProvided = true
 
 		// Only admin/superadmin can change subscription
-		if requester.UserType != types.UserTypeAdmin && requester.UserType != types.UserTypeSuperAdmin {
+		if !authz.CanChangeSubscription(subject) {
 			response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Only admins can change subscription", nil)
 			return
 		}
 
-		if value == nil {
-			input.SubscriptionID = nil
-		} else {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "subscriptionId must be a string", err)
-				return
-			}
-			parsed, err := uuid.Parse(str)
+		if value := req.SubscriptionID.Ptr(); value != nil {
+			parsed, err := uuid.Parse(*value)
 			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+				request.RespondInvalid(c, validation.FieldError{Field: "subscriptionId", Rule: "id", Message: "must be a valid id"})
 				return
 			}
 			input.SubscriptionID = &parsed
 		}
 	}
 
-	if value, ok := body["fullName"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "fullName must be a string", err)
-			return
-		}
-		input.FullName = &str
-	}
-
-	if value, ok := body["email"]; ok {
-		str, err := request.ReadString(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "email must be a string", err)
-			return
-		}
+	if req.Email != nil {
+		str := strings.TrimSpace(*req.Email)
 
 		// Validate email format
 		if !emailRegex.MatchString(str) {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid email format", fmt.Errorf("email must be in valid format"))
+			request.RespondInvalid(c, invalidEmail)
 			return
 		}
 
 		// Email domain check for instructor/assistant - MUST end with @{identifierName}
-		if requester.UserType == types.UserTypeInstructor || requester.UserType == types.UserTypeAssistant {
+		if authz.IsSubscriptionStaff(subject.Role) {
 			if requester.Subscription == nil || requester.Subscription.IdentifierName == "" {
 				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Subscription identifier not found", fmt.Errorf("subscription identifier missing"))
 				return
@@ -375,51 +408,25 @@ func (h *Handler) Update(c *gin.Context) {
 		input.Email = &str
 	}
 
-	if value, ok := body["phone"]; ok {
-		input.PhoneProvided = true
-		if value == nil {
-			input.Phone = nil
-		} else {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "phone must be a string", err)
-				return
-			}
-			input.Phone = &str
-		}
-	}
-
-	if value, ok := body["password"]; ok {
-		// Allow null password (means don't update it)
-		if value != nil {
-			str, err := request.ReadString(value)
-			if err != nil {
-				response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "password must be a string", err)
-				return
-			}
-			input.Password = &str
-		}
-	}
-
-	if value, ok := body["isActive"]; ok {
-		val, err := request.ReadBool(value)
-		if err != nil {
-			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "isActive must be boolean", err)
-			return
-		}
-		input.Active = &val
-	}
-
 	user, err := Update(h.db, id, input)
 	if err != nil {
 		h.respondError(c, err, "failed to update user")
 		return
 	}
 
+	if input.Password != nil || (userToUpdate.Active && !user.Active) {
+		h.closeSessions(id)
+	}
+
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+
+	h.signAvatar(&user)
 	response.Success(c, http.StatusOK, user, "", nil)
 }
 
-// Delete removes a user.
+// Delete removes a user. With mode=anonymize the account is kept for analytics
+// but its personal data is scrubbed; see Anonymize.
+// DELETE /users/:userId?mode=delete|anonymize
 func (h *Handler) Delete(c *gin.Context) {
 	requesterUser, ok := middleware.GetUserFromContext(c)
 	if !ok || requesterUser == nil {
@@ -433,100 +440,61 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
+	mode := c.DefaultQuery("mode", "delete")
+	if mode != "delete" && mode != "anonymize" {
+		request.RespondInvalid(c, validation.FieldError{Field: "mode", Rule: "oneof", Message: "must be one of: delete, anonymize"})
+		return
+	}
+
 	userToDelete, err := Get(h.db, id)
 	if err != nil {
 		h.respondError(c, err, "failed to load user")
 		return
 	}
 
-	// Authorization rules (matching Node.js implementation):
-	// 1. Only superadmin can delete admins or superadmins
-	if (userToDelete.UserType == types.UserTypeAdmin || userToDelete.UserType == types.UserTypeSuperAdmin) &&
-		requesterUser.UserType != types.UserTypeSuperAdmin {
-		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Only superadmins can delete admins", nil)
-		return
-	}
+	subject := authz.SubjectFrom(requesterUser)
+	target := targetOf(userToDelete)
 
-	// 2. Superadmin can delete anyone
-	if requesterUser.UserType == types.UserTypeSuperAdmin {
-		if err := Delete(h.db, id); err != nil {
-			h.respondError(c, err, "failed to delete user")
+	if !authz.CanDelete(subject, target) {
+		// Only superadmin can delete admins or superadmins
+		if authz.IsPlatformAdmin(target.Role) && subject.ID != target.ID {
+			response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Only superadmins can delete admins", nil)
 			return
 		}
-		response.Success(c, http.StatusOK, true, "", nil)
+		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to delete this user", nil)
 		return
 	}
 
-	// 3. Admin can delete anyone except superadmin
-	if requesterUser.UserType == types.UserTypeAdmin {
-		if userToDelete.UserType != types.UserTypeSuperAdmin {
-			if err := Delete(h.db, id); err != nil {
-				h.respondError(c, err, "failed to delete user")
-				return
-			}
-			response.Success(c, http.StatusOK, true, "", nil)
+	if mode == "anonymize" {
+		if err := Anonymize(h.db.WithContext(c.Request.Context()), id); err != nil {
+			h.respondError(c, err, "failed to anonymize user")
 			return
 		}
-	}
-
-	// 4. Assistants cannot delete instructors or other assistants
-	if requesterUser.UserType == types.UserTypeAssistant &&
-		(userToDelete.UserType == types.UserTypeInstructor || userToDelete.UserType == types.UserTypeAssistant) {
-		response.ErrorWithLog(h.logger, c, http.StatusForbidden, "Assistants can not delete instructors or other assistants", nil)
+	} else if err := Delete(h.db, id); err != nil {
+		h.respondError(c, err, "failed to delete user")
 		return
 	}
 
-	// 5. Instructor/Assistant can delete users in their subscription
-	if (requesterUser.UserType == types.UserTypeInstructor || requesterUser.UserType == types.UserTypeAssistant) &&
-		requesterUser.SubscriptionID != nil && userToDelete.SubscriptionID != nil &&
-		*requesterUser.SubscriptionID == *userToDelete.SubscriptionID {
-		if err := Delete(h.db, id); err != nil {
-			h.respondError(c, err, "failed to delete user")
-			return
-		}
-		response.Success(c, http.StatusOK, true, "", nil)
-		return
-	}
+	h.closeSessions(id)
+	go h.deleteAvatar(userToDelete.AvatarURL)
 
-	// 6. Student can delete themselves
-	if requesterUser.UserType == types.UserTypeStudent && requesterUser.ID == userToDelete.ID {
-		if err := Delete(h.db, id); err != nil {
-			h.respondError(c, err, "failed to delete user")
-			return
-		}
-		response.Success(c, http.StatusOK, true, "", nil)
-		return
-	}
+	h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
 
-	// If none of the above conditions met, deny access
-	response.ErrorWithLog(h.logger, c, http.StatusForbidden, "You are not authorized to delete this user", nil)
+	response.Success(c, http.StatusOK, true, "", nil)
 }
 
-func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
-	status := http.StatusInternalServerError
-	message := fallback
+// targetOf describes a user account for authorization policies.
+func targetOf(u User) authz.Target {
+	return authz.Target{ID: u.ID, Role: u.UserType, SubscriptionID: u.SubscriptionID}
+}
 
-	switch {
-	case errors.Is(err, ErrUserNotFound):
-		status = http.StatusNotFound
-		message = "User not found."
-	case errors.Is(err, ErrEmailTaken):
-		status = http.StatusConflict
-		message = "Email already exists."
-	case errors.Is(err, ErrInvalidPassword):
-		status = http.StatusBadRequest
-		message = err.Error()
-	case errors.Is(err, ErrUnauthorized):
-		status = http.StatusForbidden
-		message = err.Error()
-	default:
-		if err.Error() == "fullName cannot be empty" || err.Error() == "email cannot be empty" {
-			status = http.StatusBadRequest
-			message = err.Error()
-		}
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	// Reported by the service without a sentinel error
+	if message := err.Error(); message == "fullName cannot be empty" || message == "email cannot be empty" {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, message, err)
+		return
 	}
-
-	response.ErrorWithLog(h.logger, c, status, message, err)
+	errorCatalog.Respond(h.logger, c, err, fallback)
 }
 
 // checkSubscriptionLimits verifies if the subscription can accommodate a new user of the given type
@@ -0,0 +1,344 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	maxImportFileSize     = 2 << 20 // 2MB
+	maxImportRows         = 1000
+	maxFullNameLength     = 30
+	generatedPasswordLen  = 12
+	generatedPasswordSet  = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+	importStatusCreated   = "created"
+	importStatusValid     = "valid"
+	importStatusFailed    = "failed"
+	importStatusDuplicate = "duplicate"
+)
+
+// importRow is the per-row outcome of a bulk student import.
+type importRow struct {
+	Row      int        `json:"row"`
+	FullName string     `json:"fullName"`
+	Email    string     `json:"email"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	UserID   *uuid.UUID `json:"userId,omitempty"`
+	Password string     `json:"password,omitempty"` // only returned when generated by the server
+
+	phone    *string
+	password string
+}
+
+// Import creates students in bulk from an uploaded CSV file.
+// POST /subscriptions/:subscriptionId/users/import?dryRun=true
+//
+// The CSV needs a header row with "fullName" and "email" columns; "phone" and "password"
+// are optional. Emails without a domain get the subscription identifier appended, and
+// missing passwords are generated and returned once in the report.
+func (h *Handler) Import(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return
+	}
+
+	sub, err := subscription.Get(h.db, subscriptionID)
+	if err != nil {
+		if errors.Is(err, subscription.ErrSubscriptionNotFound) {
+			response.ErrorWithLog(h.logger, c, http.StatusNotFound, "Subscription not found.", err)
+			return
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load subscription", err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+(64<<10))
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "CSV file is required.", err)
+		return
+	}
+	defer file.Close()
+
+	if fileHeader.Size > maxImportFileSize {
+		response.ErrorWithLog(h.logger, c, http.StatusRequestEntityTooLarge, "CSV file cannot exceed 2MB.", nil)
+		return
+	}
+
+	rows, err := parseImportCSV(file, "@"+strings.ToLower(sub.IdentifierName))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err := h.markExistingEmails(rows); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to check existing emails", err)
+		return
+	}
+
+	remaining := -1 // unlimited
+	if sub.SubscriptionPoints > 0 {
+		var currentStudents int64
+		if err := h.db.Model(&User{}).
+			Where("subscription_id = ? AND user_type = ?", subscriptionID, types.UserTypeStudent).
+			Count(&currentStudents).Error; err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to count students", err)
+			return
+		}
+		remaining = sub.SubscriptionPoints - int(currentStudents)
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+	created, failed := 0, 0
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != "" {
+			failed++
+			continue
+		}
+
+		if remaining == 0 {
+			row.Status = importStatusFailed
+			row.Error = "Student limit reached for this subscription."
+			failed++
+			continue
+		}
+
+		generated := false
+		if row.password == "" {
+			password, err := GeneratePassword()
+			if err != nil {
+				response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to generate password", err)
+				return
+			}
+			row.password = password
+			generated = true
+		}
+
+		if dryRun {
+			row.Status = importStatusValid
+		} else {
+			user, err := Create(h.db, CreateInput{
+				SubscriptionID: &subscriptionID,
+				FullName:       row.FullName,
+				Email:          row.Email,
+				Phone:          row.phone,
+				Password:       row.password,
+				UserType:       types.UserTypeStudent,
+			})
+			if err != nil {
+				row.Status = importStatusFailed
+				switch {
+				case errors.Is(err, ErrEmailTaken):
+					row.Status = importStatusDuplicate
+					row.Error = "Email already exists."
+				case errors.Is(err, ErrInvalidPassword):
+					row.Error = "Password must be at least 8 characters."
+				default:
+					h.logger.Error("failed to import student", "row", row.Row, "error", err)
+					row.Error = "Failed to create user."
+				}
+				failed++
+				continue
+			}
+			row.Status = importStatusCreated
+			row.UserID = &user.ID
+			if generated {
+				row.Password = row.password
+			}
+		}
+
+		created++
+		if remaining > 0 {
+			remaining--
+		}
+	}
+
+	if created > 0 {
+		h.queryCache.Invalidate(c.Request.Context(), cache.NamespaceDashboard)
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"dryRun":  dryRun,
+		"total":   len(rows),
+		"created": created,
+		"failed":  failed,
+		"results": rows,
+	}, "", nil)
+}
+
+// parseImportCSV reads and validates the CSV rows. Rows that fail validation are
+// returned with a failed status so the report covers every line of the file.
+func parseImportCSV(r io.Reader, requiredDomain string) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("CSV file is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		key = strings.NewReplacer("_", "", " ", "", "-", "").Replace(key)
+		if key == "name" {
+			key = "fullname"
+		}
+		columns[key] = i
+	}
+	if _, ok := columns["fullname"]; !ok {
+		return nil, fmt.Errorf("CSV header must include a fullName column")
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, fmt.Errorf("CSV header must include an email column")
+	}
+
+	field := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	rows := make([]importRow, 0)
+	seen := map[string]int{}
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV at line %d: %w", line, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(rows) >= maxImportRows {
+			return nil, fmt.Errorf("CSV file cannot contain more than %d students", maxImportRows)
+		}
+
+		row := importRow{
+			Row:      line,
+			FullName: field(record, "fullname"),
+			Email:    strings.ToLower(field(record, "email")),
+			password: field(record, "password"),
+		}
+		if phone := field(record, "phone"); phone != "" {
+			row.phone = &phone
+		}
+		if row.Email != "" && !strings.Contains(row.Email, "@") {
+			row.Email += requiredDomain
+		}
+
+		switch {
+		case row.FullName == "":
+			row.Error = "Full name is required."
+		case utf8.RuneCountInString(row.FullName) > maxFullNameLength:
+			row.Error = fmt.Sprintf("Full name cannot exceed %d characters.", maxFullNameLength)
+		case row.Email == "":
+			row.Error = "Email is required."
+		case !emailRegex.MatchString(row.Email) || strings.HasPrefix(row.Email, "@"):
+			row.Error = "Invalid email format."
+		case !strings.HasSuffix(row.Email, requiredDomain):
+			row.Error = "Email must end with " + requiredDomain
+		case row.password != "" && len(row.password) < 8:
+			row.Error = "Password must be at least 8 characters."
+		}
+
+		if row.Error == "" {
+			if first, ok := seen[row.Email]; ok {
+				row.Status = importStatusDuplicate
+				row.Error = fmt.Sprintf("Email repeated from row %d.", first)
+			} else {
+				seen[row.Email] = line
+			}
+		} else {
+			row.Status = importStatusFailed
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("CSV file has no student rows")
+	}
+
+	return rows, nil
+}
+
+// markExistingEmails flags rows whose email is already registered.
+func (h *Handler) markExistingEmails(rows []importRow) error {
+	emails := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Status == "" {
+			emails = append(emails, row.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	var existing []string
+	if err := h.db.Model(&User{}).Where("LOWER(email) IN ?", emails).Pluck("LOWER(email)", &existing).Error; err != nil {
+		return err
+	}
+
+	taken := make(map[string]bool, len(existing))
+	for _, email := range existing {
+		taken[email] = true
+	}
+
+	for i := range rows {
+		if rows[i].Status == "" && taken[rows[i].Email] {
+			rows[i].Status = importStatusDuplicate
+			rows[i].Error = "Email already exists."
+		}
+	}
+
+	return nil
+}
+
+// GeneratePassword returns a random password without easily confused characters
+// for accounts created on a user's behalf.
+func GeneratePassword() (string, error) {
+	max := big.NewInt(int64(len(generatedPasswordSet)))
+	buf := make([]byte, generatedPasswordLen)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = generatedPasswordSet[n.Int64()]
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,230 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/imaging"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+const (
+	// avatarSize is the side in pixels of the square avatar stored for each user.
+	avatarSize = 256
+	// maxAvatarBytes caps the uploaded file before it is decoded.
+	maxAvatarBytes = 5 << 20
+)
+
+// GetMe returns the authenticated user's own profile.
+// GET /me
+func (h *Handler) GetMe(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	user, err := Get(h.db.WithContext(c.Request.Context()), requester.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to load profile")
+		return
+	}
+
+	h.signAvatar(&user)
+	response.Success(c, http.StatusOK, user, "", nil)
+}
+
+// updateMeRequest is a partial update of the fields users may change about
+// themselves; null clears phone.
+type updateMeRequest struct {
+	FullName          *string                     `json:"fullName" binding:"omitnil,notblank,max=30"`
+	Phone             validation.Optional[string] `json:"phone" binding:"omitnil,notblank,max=20"`
+	PreferredLanguage *string                     `json:"preferredLanguage" binding:"omitnil,oneof=en ar"`
+	Timezone          *string                     `json:"timezone" binding:"omitnil,notblank,timezone"`
+}
+
+// UpdateMe changes the authenticated user's name, phone and preferences.
+// PUT /me
+func (h *Handler) UpdateMe(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	var req updateMeRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid profile payload") {
+		return
+	}
+
+	user, err := Update(h.db.WithContext(c.Request.Context()), requester.ID, UpdateInput{
+		FullName:      req.FullName,
+		PhoneProvided: req.Phone.Set,
+		Phone:         req.Phone.Ptr(),
+		Language:      req.PreferredLanguage,
+		Timezone:      req.Timezone,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to update profile")
+		return
+	}
+
+	h.signAvatar(&user)
+	response.Success(c, http.StatusOK, user, "", nil)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+// ChangePassword sets a new password once the current one is confirmed.
+// PUT /me/password
+func (h *Handler) ChangePassword(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	var req changePasswordRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid password payload") {
+		return
+	}
+
+	err := ChangePassword(h.db.WithContext(c.Request.Context()), requester.ID, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, ErrIncorrectPassword) {
+		request.RespondInvalid(c, validation.FieldError{Field: "currentPassword", Rule: "match", Message: "is incorrect"})
+		return
+	}
+	if err != nil {
+		h.respondError(c, err, "failed to change password")
+		return
+	}
+
+	h.closeSessions(requester.ID)
+
+	response.Success(c, http.StatusOK, true, "Password changed. Please sign in again.", nil)
+}
+
+// UploadAvatar stores a square, resized copy of the uploaded image as the
+// user's avatar and removes the previous one.
+// PUT /me/avatar (multipart form field "avatar")
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	if h.storageClient == nil {
+		response.ErrorWithLog(h.logger, c, http.StatusServiceUnavailable, "Avatar storage is not configured.", nil)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarBytes+1<<20)
+	file, header, err := c.Request.FormFile("avatar")
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Avatar file is required.", err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxAvatarBytes {
+		request.RespondInvalid(c, validation.FieldError{Field: "avatar", Rule: "max", Message: "must be at most 5 MB"})
+		return
+	}
+
+	avatar, err := imaging.Square(file, avatarSize)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+			request.RespondInvalid(c, validation.FieldError{Field: "avatar", Rule: "image", Message: err.Error()})
+			return
+		}
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "Avatar could not be read as an image.", err)
+		return
+	}
+
+	current, err := Get(h.db.WithContext(c.Request.Context()), requester.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to load profile")
+		return
+	}
+
+	remotePath := fmt.Sprintf("avatars/%s/%s.jpg", requester.ID, uuid.New())
+	if err := h.storageClient.UploadBuffer(c.Request.Context(), avatar, remotePath, imaging.ContentTypeJPEG); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "Failed to upload avatar to storage.", err)
+		return
+	}
+
+	avatarURL := h.storageClient.GetPublicURL(remotePath)
+	user, err := Update(h.db.WithContext(c.Request.Context()), requester.ID, UpdateInput{
+		AvatarProvided: true,
+		AvatarURL:      &avatarURL,
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to update avatar")
+		return
+	}
+
+	go h.deleteAvatar(current.AvatarURL)
+
+	h.signAvatar(&user)
+	response.Success(c, http.StatusOK, user, "", nil)
+}
+
+// DeleteMyAvatar clears the user's avatar.
+// DELETE /me/avatar
+func (h *Handler) DeleteMyAvatar(c *gin.Context) {
+	requester, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	current, err := Get(h.db.WithContext(c.Request.Context()), requester.ID)
+	if err != nil {
+		h.respondError(c, err, "failed to load profile")
+		return
+	}
+
+	user, err := Update(h.db.WithContext(c.Request.Context()), requester.ID, UpdateInput{AvatarProvided: true})
+	if err != nil {
+		h.respondError(c, err, "failed to remove avatar")
+		return
+	}
+
+	go h.deleteAvatar(current.AvatarURL)
+
+	response.Success(c, http.StatusOK, user, "", nil)
+}
+
+// deleteAvatar removes a replaced avatar from storage; failures only leave an
+// orphaned file behind, so they are logged rather than reported.
+func (h *Handler) deleteAvatar(avatarURL *string) {
+	if avatarURL == nil || *avatarURL == "" || h.storageClient == nil {
+		return
+	}
+
+	remotePath := h.storageClient.ExtractRelativePath(*avatarURL)
+	if err := h.storageClient.DeleteFile(context.Background(), remotePath); err != nil {
+		h.logger.Error("failed to delete old avatar", "path", remotePath, "error", err)
+	}
+}
+
+// signAvatar replaces the stored avatar URL with a token-authenticated one for the response.
+func (h *Handler) signAvatar(user *User) {
+	if h.storageClient == nil || user.AvatarURL == nil || *user.AvatarURL == "" {
+		return
+	}
+	signed := h.storageClient.SignedURL(*user.AvatarURL)
+	user.AvatarURL = &signed
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -23,13 +24,19 @@ type User struct {
 	Phone          *string        `gorm:"type:varchar(20)" json:"phone,omitempty"`
 	Password       string         `gorm:"type:varchar(255);not null" json:"-"`
 	UserType       types.UserType `gorm:"type:varchar(20);not null;default:'student';column:user_type;index;index:idx_usertype_subscription,priority:1;index:idx_usertype_active,priority:1" json:"userType"`
+	RoleID         *uuid.UUID     `gorm:"type:uuid;column:role_id;index" json:"roleId,omitempty"`
 	RefreshToken   *string        `gorm:"type:text;column:refresh_token" json:"-"`
 	DeviceID       *string        `gorm:"type:varchar(255);column:device_id" json:"-"`
+	TokenVersion   int            `gorm:"type:int;not null;default:0;column:token_version;<-:create" json:"-"`
 	Active         bool           `gorm:"type:boolean;not null;default:true;column:is_active;index;index:idx_usertype_active,priority:2;index:idx_subscription_active,priority:2" json:"isActive"`
 	EmailVerified  bool           `gorm:"type:boolean;not null;default:false;column:email_verified" json:"emailVerified"`
+	AvatarURL      *string        `gorm:"type:text;column:avatar_url" json:"avatarUrl,omitempty"`
+	Language       string         `gorm:"type:varchar(10);not null;default:'en';column:preferred_language" json:"preferredLanguage"`
+	Timezone       string         `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"`
+	AnonymizedAt   *time.Time     `gorm:"type:timestamp;column:anonymized_at" json:"anonymizedAt,omitempty"`
 
 	// Relations
-	Subscription *subscription.Subscription `gorm:"foreignKey:SubscriptionID" json:"subscription,omitempty"`
+	Subscription *subscription.Subscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"subscription,omitempty"`
 }
 
 // TableName overrides the default table name.
@@ -67,11 +74,57 @@ type UpdateInput struct {
 	Password               *string
 	UserType               *types.UserType
 	Active                 *bool
+	Language               *string
+	Timezone               *string
+	AvatarURL              *string
+	AvatarProvided         bool
 }
 
 // List queries users with filters and pagination.
 func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]User, int64, error) {
-	query := db.Model(&User{})
+	query := filteredQuery(db, filters)
+
+	var total int64
+	if !params.Cursor {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var users []User
+	if err := pagination.Apply(query, params).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// Each streams every user matching the filters, newest first, one row at a time
+// over a single cursor so exports never load the full result set.
+func Each(db *gorm.DB, filters ListFilters, fn func(User) error) error {
+	query := filteredQuery(db, filters).Order("created_at DESC")
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item User
+		if err := query.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func filteredQuery(db *gorm.DB, filters ListFilters) *gorm.DB {
+	query := db.Model(&User{}).Where("anonymized_at IS NULL")
 
 	if filters.Keyword != "" {
 		keyword := "%" + strings.ToLower(filters.Keyword) + "%"
@@ -99,17 +152,7 @@ func List(db *gorm.DB, filters ListFilters, params pagination.Params) ([]User, i
 		query = query.Where("user_type NOT IN ?", filters.ExcludeUserTypes)
 	}
 
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	var users []User
-	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(&users).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return users, total, nil
+	return query
 }
 
 // Get retrieves a user by ID.
@@ -161,7 +204,17 @@ func Create(db *gorm.DB, input CreateInput) (User, error) {
 		user.Active = *input.Active
 	}
 
-	if err := db.Create(&user).Error; err != nil {
+	// Accounts of a subscription are announced to its webhooks
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if user.SubscriptionID == nil {
+			return nil
+		}
+		return publishCreated(tx, user)
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "users_email_key") {
 			return user, ErrEmailTaken
 		}
@@ -228,6 +281,18 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (User, error) {
 		updates["is_active"] = *input.Active
 	}
 
+	if input.Language != nil {
+		updates["preferred_language"] = *input.Language
+	}
+
+	if input.Timezone != nil {
+		updates["timezone"] = strings.TrimSpace(*input.Timezone)
+	}
+
+	if input.AvatarProvided {
+		updates["avatar_url"] = input.AvatarURL
+	}
+
 	if len(updates) > 0 {
 		if err := db.Model(&User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 			if strings.Contains(err.Error(), "users_email_key") {
@@ -237,6 +302,12 @@ func Update(db *gorm.DB, id uuid.UUID, input UpdateInput) (User, error) {
 		}
 	}
 
+	if input.Password != nil || (input.Active != nil && !*input.Active && user.Active) {
+		if err := RevokeSessions(db, id); err != nil {
+			return user, err
+		}
+	}
+
 	return Get(db, id)
 }
 
@@ -252,6 +323,45 @@ func Delete(db *gorm.DB, id uuid.UUID) error {
 	return nil
 }
 
+// ChangePassword replaces the user's password after checking the current one and
+// revokes every existing session, so all devices must sign in again.
+func ChangePassword(db *gorm.DB, id uuid.UUID, currentPassword, newPassword string) error {
+	user, err := Get(db, id)
+	if err != nil {
+		return err
+	}
+
+	if !user.ComparePassword(currentPassword) {
+		return ErrIncorrectPassword
+	}
+
+	if len(newPassword) < 8 {
+		return ErrInvalidPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 10)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{}).Where("id = ?", id).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return RevokeSessions(tx, id)
+	})
+}
+
+// RevokeSessions invalidates every access and refresh token issued to the user
+// by bumping their token version, which auth middleware compares on each request.
+// Offline download licenses go with them.
+func RevokeSessions(db *gorm.DB, id uuid.UUID) error {
+	if err := db.Exec("UPDATE users SET token_version = token_version + 1, refresh_token = NULL WHERE id = ?", id).Error; err != nil {
+		return err
+	}
+	return db.Exec("UPDATE lesson_downloads SET revoked_at = NOW(), updated_at = NOW() WHERE user_id = ? AND revoked_at IS NULL", id).Error
+}
+
 // ComparePassword checks if the provided password matches the user's hashed password.
 func (u *User) ComparePassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
@@ -270,26 +380,3 @@ func trimStringPtr(s *string) *string {
 	}
 	return &trimmed
 }
-
-// UserTypeIndex returns the position of a userType in the hierarchy.
-func UserTypeIndex(userType types.UserType) int {
-	for i, t := range UserTypeOrder {
-		if t == userType {
-			return i
-		}
-	}
-	return -1
-}
-
-// CanManageUserType checks if requester can manage a target user type.
-func CanManageUserType(requesterType, targetType types.UserType) bool {
-	requesterIdx := UserTypeIndex(requesterType)
-	targetIdx := UserTypeIndex(targetType)
-
-	if requesterIdx == -1 || targetIdx == -1 {
-		return false
-	}
-
-	// Can only manage users with lower user type
-	return targetIdx < requesterIdx
-}
@@ -0,0 +1,174 @@
+package user
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AnonymizedName replaces the name of anonymized users wherever it was copied.
+const AnonymizedName = "Deleted user"
+
+// personalDataSection is one group of records in a personal data export. The
+// query selects only columns the user supplied or that describe their activity,
+// never credentials or raw store receipts.
+type personalDataSection struct {
+	name  string
+	query string
+}
+
+var personalDataSections = []personalDataSection{
+	{name: "comments", query: `
+		SELECT id, lesson_id, parent_id, content, created_at, updated_at
+		FROM comments WHERE user_id = @user ORDER BY created_at`},
+	{name: "threads", query: `
+		SELECT id, forum_id, title, content, approved, created_at, updated_at
+		FROM threads WHERE user_id = @user ORDER BY created_at`},
+	{name: "threadReplies", query: `
+		SELECT t.id AS thread_id, r->>'id' AS id, r->>'content' AS content, r->>'createdAt' AS created_at
+		FROM threads t, jsonb_array_elements(t.replies) AS r
+		WHERE r->>'userId' = @user::text ORDER BY r->>'createdAt'`},
+	{name: "lessonAccess", query: `
+		SELECT lesson_id, end_date, created_at
+		FROM user_watches WHERE user_id = @user ORDER BY created_at`},
+	{name: "watchSessions", query: `
+		SELECT lesson_id, course_id, max_position, watched_seconds, playback_rate, created_at, last_heartbeat_at
+		FROM watch_sessions WHERE user_id = @user ORDER BY created_at`},
+	{name: "playbackSessions", query: `
+		SELECT code, lesson_id, ip_address, user_agent, created_at
+		FROM playback_sessions WHERE user_id = @user ORDER BY created_at`},
+	{name: "purchases", query: `
+		SELECT id, subscription_id, package_id, store, product_id, transaction_id, order_id, status,
+			purchase_date, expiry_date, auto_renewing, created_at
+		FROM iap_purchases WHERE user_id = @user ORDER BY purchase_date`},
+	{name: "supportTickets", query: `
+		SELECT id, subscription_id, subject, message, reply_info, created_at, updated_at
+		FROM support_tickets WHERE user_id = @user ORDER BY created_at`},
+	{name: "notifications", query: `
+		SELECT id, type, title, message, read_at, created_at
+		FROM notifications WHERE user_id = @user ORDER BY created_at`},
+}
+
+// PersonalData is everything stored about one user, grouped by section.
+type PersonalData struct {
+	ExportedAt time.Time                           `json:"exportedAt"`
+	Profile    User                                `json:"profile"`
+	Sections   map[string][]map[string]interface{} `json:"sections"`
+}
+
+// CollectPersonalData loads the user's profile and every personal data section.
+func CollectPersonalData(db *gorm.DB, id uuid.UUID) (PersonalData, error) {
+	profile, err := Get(db, id)
+	if err != nil {
+		return PersonalData{}, err
+	}
+
+	data := PersonalData{
+		ExportedAt: time.Now().UTC(),
+		Profile:    profile,
+		Sections:   make(map[string][]map[string]interface{}, len(personalDataSections)),
+	}
+	for _, section := range personalDataSections {
+		rows := make([]map[string]interface{}, 0)
+		if err := db.Raw(section.query, map[string]interface{}{"user": id}).Scan(&rows).Error; err != nil {
+			return PersonalData{}, fmt.Errorf("load %s: %w", section.name, err)
+		}
+		data.Sections[section.name] = rows
+	}
+
+	return data, nil
+}
+
+// WriteZip writes the profile and each section as separate JSON files.
+func (d PersonalData) WriteZip(archive *zip.Writer) error {
+	profile := map[string]interface{}{"exportedAt": d.ExportedAt, "profile": d.Profile}
+	if err := writeZipJSON(archive, "profile.json", profile); err != nil {
+		return err
+	}
+	for _, section := range personalDataSections {
+		if err := writeZipJSON(archive, section.name+".json", d.Sections[section.name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeZipJSON(archive *zip.Writer, name string, v interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Anonymize scrubs the user's personal data while keeping the account row and
+// its activity, so watch time, enrollment and revenue aggregates stay intact.
+// The account is deactivated and can no longer sign in. Copied names on
+// comments and forum posts are replaced, private correspondence is removed.
+func Anonymize(db *gorm.DB, id uuid.UUID) error {
+	if _, err := Get(db, id); err != nil {
+		return err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), 10)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"full_name":      AnonymizedName,
+			"email":          fmt.Sprintf("deleted-%s@anonymized.invalid", id),
+			"phone":          nil,
+			"password":       string(password),
+			"device_id":      nil,
+			"avatar_url":     nil,
+			"is_active":      false,
+			"email_verified": false,
+			"anonymized_at":  time.Now().UTC(),
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := RevokeSessions(tx, id); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("UPDATE comments SET user_name = ? WHERE user_id = ?", AnonymizedName, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE threads SET user_name = ? WHERE user_id = ?", AnonymizedName, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			UPDATE threads SET replies = (
+				SELECT jsonb_agg(CASE WHEN r->>'userId' = @user::text
+					THEN jsonb_set(r, '{userName}', to_jsonb(@name::text)) ELSE r END ORDER BY ord)
+				FROM jsonb_array_elements(replies) WITH ORDINALITY AS e(r, ord))
+			WHERE replies @> jsonb_build_array(jsonb_build_object('userId', @user::text))`,
+			map[string]interface{}{"user": id, "name": AnonymizedName}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("UPDATE playback_sessions SET ip_address = NULL, user_agent = NULL WHERE user_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM support_tickets WHERE user_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM notifications WHERE user_id = ?", id).Error
+	})
+}
@@ -2,16 +2,39 @@ package user
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
 )
 
 // RegisterRoutes attaches user endpoints to the router.
 // Middleware is passed as parameters to avoid import cycles
-func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminStaff, allUsers []gin.HandlerFunc) {
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, adminStaff, allUsers, acStaff []gin.HandlerFunc) {
 	users := router.Group("/users")
 
 	users.GET("", append(adminStaff, handler.List)...)
 	users.POST("", append(adminStaff, handler.Create)...)
+	users.GET("/export", append(adminStaff, handler.Export)...)
 	users.GET("/:userId", append(allUsers, handler.GetByID)...)
 	users.PUT("/:userId", append(allUsers, handler.Update)...)
 	users.DELETE("/:userId", append(allUsers, handler.Delete)...)
+	users.POST("/:userId/export", append(allUsers, handler.ExportPersonalData)...)
+
+	router.POST("/subscriptions/:subscriptionId/users/import", append(acStaff, handler.Import)...)
+
+	me := router.Group("/me")
+	me.GET("", append(allUsers, handler.GetMe)...)
+	me.PUT("", append(allUsers, handler.UpdateMe)...)
+	me.PUT("/password", append(allUsers, handler.ChangePassword)...)
+	me.PUT("/avatar", append(allUsers, handler.UploadAvatar)...)
+	me.DELETE("/avatar", append(allUsers, handler.DeleteMyAvatar)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []User{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: User{}})
+	openapi.Describe(handler.GetByID, openapi.Spec{Response: User{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: User{}})
+	openapi.Describe(handler.GetMe, openapi.Spec{Response: User{}})
+	openapi.Describe(handler.UpdateMe, openapi.Spec{Request: updateMeRequest{}, Response: User{}})
+	openapi.Describe(handler.ChangePassword, openapi.Spec{Request: changePasswordRequest{}})
+	openapi.Describe(handler.UploadAvatar, openapi.Spec{RequestType: "multipart/form-data", Response: User{}})
+	openapi.Describe(handler.DeleteMyAvatar, openapi.Spec{Response: User{}})
 }
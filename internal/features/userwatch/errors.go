@@ -0,0 +1,21 @@
+package userwatch
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrLessonNotFound      = errors.New("lesson not found")
+	ErrExtraWatchesInvalid = errors.New("extra watches must be positive")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrUserNotFound, Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found."},
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrExtraWatchesInvalid, Status: http.StatusBadRequest, Code: "EXTRA_WATCHES_INVALID", Message: "Extra watches must be positive."},
+}
@@ -0,0 +1,29 @@
+package userwatch
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+)
+
+// Outbox topics published when staff change a student's watch allowance.
+const (
+	TopicReset   = "watches.reset"
+	TopicGranted = "watches.granted"
+)
+
+// Event is the outbox payload for watch allowance changes. Watches is the
+// number of watches removed by a reset or added by a grant.
+type Event struct {
+	SubscriptionID uuid.UUID  `json:"subscriptionId"`
+	UserID         uuid.UUID  `json:"userId"`
+	LessonID       *uuid.UUID `json:"lessonId,omitempty"`
+	Watches        int        `json:"watches"`
+	ActorID        uuid.UUID  `json:"actorId"`
+	Reason         *string    `json:"reason,omitempty"`
+}
+
+func publish(tx *gorm.DB, topic string, event Event) error {
+	return outbox.Publish(tx, topic, event.UserID, event)
+}
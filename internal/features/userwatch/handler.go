@@ -0,0 +1,192 @@
+package userwatch
+
+import (
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// Handler serves the staff endpoints that inspect and adjust a student's watches.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a user watch handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+// List reports the student's used and granted watches per lesson.
+// GET /subscriptions/:subscriptionId/users/:userId/watches?lessonId=
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, userID, ok := h.resolveStudent(c)
+	if !ok {
+		return
+	}
+	lessonID, ok := h.lessonFilter(c, subscriptionID)
+	if !ok {
+		return
+	}
+
+	var watchLimit int
+	if err := h.db.Table("subscriptions").Select("watch_limit").Where("id = ?", subscriptionID).Scan(&watchLimit).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load subscription", err)
+		return
+	}
+
+	lessons, err := Summarize(h.db, subscriptionID, userID, lessonID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load watches", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, Report{WatchLimit: watchLimit, Lessons: lessons}, "", nil)
+}
+
+// Reset deletes the student's watches so the watch limit starts over.
+// DELETE /subscriptions/:subscriptionId/users/:userId/watches?lessonId=
+func (h *Handler) Reset(c *gin.Context) {
+	actor, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+	subscriptionID, userID, ok := h.resolveStudent(c)
+	if !ok {
+		return
+	}
+	lessonID, ok := h.lessonFilter(c, subscriptionID)
+	if !ok {
+		return
+	}
+
+	removed, err := Reset(h.db, subscriptionID, userID, lessonID, actor.ID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to reset watches", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"removed": removed}, "", nil)
+}
+
+type grantRequest struct {
+	LessonID     uuid.UUID `json:"lessonId" binding:"required"`
+	ExtraWatches int       `json:"extraWatches" binding:"required,gte=1,lte=100"`
+	Reason       *string   `json:"reason" binding:"omitnil,notblank,max=500"`
+}
+
+// Grant gives the student extra watches of one lesson beyond the watch limit.
+// POST /subscriptions/:subscriptionId/users/:userId/watches/grants
+func (h *Handler) Grant(c *gin.Context) {
+	actor, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+	subscriptionID, userID, ok := h.resolveStudent(c)
+	if !ok {
+		return
+	}
+
+	var req grantRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid watch grant payload") {
+		return
+	}
+
+	if err := h.ensureLesson(subscriptionID, req.LessonID); err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return
+	}
+
+	grant, err := CreateGrant(h.db, GrantInput{
+		SubscriptionID: subscriptionID,
+		UserID:         userID,
+		LessonID:       req.LessonID,
+		ExtraWatches:   req.ExtraWatches,
+		GrantedBy:      actor.ID,
+		Reason:         request.Trimmed(req.Reason),
+	})
+	if err != nil {
+		h.respondError(c, err, "failed to grant watches")
+		return
+	}
+
+	response.Created(c, grant, "")
+}
+
+// resolveStudent parses the path and checks the user belongs to the subscription.
+func (h *Handler) resolveStudent(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	var count int64
+	if err := h.db.Table("users").
+		Where("id = ? AND subscription_id = ?", userID, subscriptionID).
+		Count(&count).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load user", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if count == 0 {
+		h.respondError(c, ErrUserNotFound, "failed to load user")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return subscriptionID, userID, true
+}
+
+// lessonFilter reads the optional lessonId query parameter.
+func (h *Handler) lessonFilter(c *gin.Context, subscriptionID uuid.UUID) (*uuid.UUID, bool) {
+	raw := c.Query("lessonId")
+	if raw == "" {
+		return nil, true
+	}
+
+	lessonID, err := uuid.Parse(raw)
+	if err != nil {
+		request.RespondInvalid(c, validation.FieldError{Field: "lessonId", Rule: "uuid", Message: "must be a valid UUID"})
+		return nil, false
+	}
+	if err := h.ensureLesson(subscriptionID, lessonID); err != nil {
+		h.respondError(c, err, "failed to load lesson")
+		return nil, false
+	}
+	return &lessonID, true
+}
+
+func (h *Handler) ensureLesson(subscriptionID, lessonID uuid.UUID) error {
+	var count int64
+	err := h.db.Table("lessons").
+		Joins("JOIN courses ON courses.id = lessons.course_id").
+		Where("lessons.id = ? AND courses.subscription_id = ?", lessonID, subscriptionID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLessonNotFound
+	}
+	return nil
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
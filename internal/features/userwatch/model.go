@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
@@ -19,3 +20,158 @@ type UserWatch struct {
 
 // TableName overrides the default table name.
 func (UserWatch) TableName() string { return "user_watches" }
+
+// Grant adds watches for one student and lesson on top of the subscription's watch limit.
+type Grant struct {
+	types.BaseModel
+
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index:idx_watch_grants_user_lesson,priority:1" json:"userId"`
+	LessonID     uuid.UUID  `gorm:"type:uuid;not null;column:lesson_id;index:idx_watch_grants_user_lesson,priority:2" json:"lessonId"`
+	ExtraWatches int        `gorm:"type:int;not null;column:extra_watches" json:"extraWatches"`
+	GrantedBy    *uuid.UUID `gorm:"type:uuid;column:granted_by" json:"grantedBy,omitempty"`
+	Reason       *string    `gorm:"type:varchar(500)" json:"reason,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Grant) TableName() string { return "watch_grants" }
+
+// LessonWatches summarizes a student's use of one lesson's watch limit.
+type LessonWatches struct {
+	LessonID      uuid.UUID  `json:"lessonId"`
+	LessonName    string     `json:"lessonName"`
+	CourseID      uuid.UUID  `json:"courseId"`
+	WatchesUsed   int        `json:"watchesUsed"`
+	WatchLimit    int        `json:"watchLimit"` // after lesson overrides; 0 is unlimited
+	ExtraWatches  int        `json:"extraWatches"`
+	ActiveUntil   *time.Time `json:"activeUntil"`
+	LastWatchedAt *time.Time `json:"lastWatchedAt"`
+}
+
+// Report is a student's watch usage across the lessons of a subscription.
+// WatchLimit is the subscription default; lessons may override it.
+type Report struct {
+	WatchLimit int             `json:"watchLimit"`
+	Lessons    []LessonWatches `json:"lessons"`
+}
+
+// GrantInput carries the data for granting extra watches.
+type GrantInput struct {
+	SubscriptionID uuid.UUID
+	UserID         uuid.UUID
+	LessonID       uuid.UUID
+	ExtraWatches   int
+	GrantedBy      uuid.UUID
+	Reason         *string
+}
+
+// ExtraWatches returns the watches granted to the user for the lesson.
+func ExtraWatches(db *gorm.DB, userID, lessonID uuid.UUID) (int, error) {
+	var extra int
+	err := db.Model(&Grant{}).
+		Select("COALESCE(SUM(extra_watches), 0)").
+		Where("user_id = ? AND lesson_id = ?", userID, lessonID).
+		Scan(&extra).Error
+	return extra, err
+}
+
+// Summarize reports the user's watches per lesson of the subscription, limited
+// to lessonID when it is set. Lessons the user never watched and was never
+// granted watches for are left out.
+func Summarize(db *gorm.DB, subscriptionID, userID uuid.UUID, lessonID *uuid.UUID) ([]LessonWatches, error) {
+	query := `
+		SELECT l.id AS lesson_id, l.name AS lesson_name, l.course_id,
+			COUNT(w.id) AS watches_used,
+			CASE WHEN l.unlimited_watches THEN 0 ELSE COALESCE(l.watch_limit, c.watch_limit) END AS watch_limit,
+			COALESCE(g.extra_watches, 0) AS extra_watches,
+			MAX(w.end_date) FILTER (WHERE w.end_date > @now) AS active_until,
+			MAX(w.created_at) AS last_watched_at
+		FROM lessons l
+		JOIN (
+			SELECT courses.id, subscriptions.watch_limit
+			FROM courses JOIN subscriptions ON subscriptions.id = courses.subscription_id
+			WHERE courses.subscription_id = @subscription
+		) c ON c.id = l.course_id
+		LEFT JOIN user_watches w ON w.lesson_id = l.id AND w.user_id = @user
+		LEFT JOIN (
+			SELECT lesson_id, SUM(extra_watches) AS extra_watches
+			FROM watch_grants WHERE user_id = @user
+			GROUP BY lesson_id
+		) g ON g.lesson_id = l.id
+		WHERE (w.id IS NOT NULL OR g.lesson_id IS NOT NULL)`
+	args := map[string]interface{}{"subscription": subscriptionID, "user": userID, "now": time.Now().UTC()}
+	if lessonID != nil {
+		query += ` AND l.id = @lesson`
+		args["lesson"] = *lessonID
+	}
+	query += `
+		GROUP BY l.id, l.name, l.course_id, l.unlimited_watches, l.watch_limit, c.watch_limit, g.extra_watches
+		ORDER BY last_watched_at DESC NULLS LAST, l.name`
+
+	rows := make([]LessonWatches, 0)
+	err := db.Raw(query, args).Scan(&rows).Error
+	return rows, err
+}
+
+// Reset deletes the user's watches for the subscription's lessons, or only for
+// lessonID when it is set, and records the reset for the audit log. It returns
+// the number of watches removed.
+func Reset(db *gorm.DB, subscriptionID, userID uuid.UUID, lessonID *uuid.UUID, actorID uuid.UUID) (int64, error) {
+	var removed int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("user_id = ?", userID).
+			Where("lesson_id IN (?)", tx.Table("lessons").
+				Select("lessons.id").
+				Joins("JOIN courses ON courses.id = lessons.course_id").
+				Where("courses.subscription_id = ?", subscriptionID))
+		if lessonID != nil {
+			query = query.Where("lesson_id = ?", *lessonID)
+		}
+
+		result := query.Delete(&UserWatch{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+		if removed == 0 {
+			return nil
+		}
+
+		return publish(tx, TopicReset, Event{
+			SubscriptionID: subscriptionID,
+			UserID:         userID,
+			LessonID:       lessonID,
+			Watches:        int(removed),
+			ActorID:        actorID,
+		})
+	})
+	return removed, err
+}
+
+// CreateGrant stores extra watches and records the grant for the audit log.
+func CreateGrant(db *gorm.DB, input GrantInput) (Grant, error) {
+	if input.ExtraWatches <= 0 {
+		return Grant{}, ErrExtraWatchesInvalid
+	}
+
+	grant := Grant{
+		UserID:       input.UserID,
+		LessonID:     input.LessonID,
+		ExtraWatches: input.ExtraWatches,
+		GrantedBy:    &input.GrantedBy,
+		Reason:       input.Reason,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&grant).Error; err != nil {
+			return err
+		}
+		return publish(tx, TopicGranted, Event{
+			SubscriptionID: input.SubscriptionID,
+			UserID:         input.UserID,
+			LessonID:       &input.LessonID,
+			Watches:        input.ExtraWatches,
+			ActorID:        input.GrantedBy,
+			Reason:         input.Reason,
+		})
+	})
+	return grant, err
+}
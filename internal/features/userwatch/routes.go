@@ -0,0 +1,19 @@
+package userwatch
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches the staff watch management endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acStaff []gin.HandlerFunc) {
+	watches := router.Group("/subscriptions/:subscriptionId/users/:userId/watches")
+
+	watches.GET("", append(acStaff, handler.List)...)
+	watches.DELETE("", append(acStaff, handler.Reset)...)
+	watches.POST("/grants", append(acStaff, handler.Grant)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: Report{}})
+	openapi.Describe(handler.Grant, openapi.Spec{Request: grantRequest{}, Response: Grant{}})
+}
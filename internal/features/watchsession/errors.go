@@ -0,0 +1,25 @@
+package watchsession
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrLessonNotFound      = errors.New("lesson not found")
+	ErrCourseNotFound      = errors.New("course not found")
+	ErrPositionInvalid     = errors.New("position cannot be negative")
+	ErrWatchedInvalid      = errors.New("watched seconds cannot be negative")
+	ErrPlaybackRateInvalid = errors.New("playback rate must be between 0.25 and 4")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrLessonNotFound, Status: http.StatusNotFound, Code: "LESSON_NOT_FOUND", Message: "Lesson not found."},
+	{Err: ErrCourseNotFound, Status: http.StatusNotFound, Code: "COURSE_NOT_FOUND", Message: "Course not found."},
+	{Err: ErrPositionInvalid, Status: http.StatusBadRequest, Code: "WATCH_POSITION_INVALID", Message: "Position cannot be negative."},
+	{Err: ErrWatchedInvalid, Status: http.StatusBadRequest, Code: "WATCHED_SECONDS_INVALID", Message: "Watched seconds cannot be negative."},
+	{Err: ErrPlaybackRateInvalid, Status: http.StatusBadRequest, Code: "PLAYBACK_RATE_INVALID", Message: "Playback rate must be between 0.25 and 4."},
+}
@@ -0,0 +1,203 @@
+package watchsession
+
+import (
+	"net/http"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes watch analytics HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a watch session handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+type heartbeatRequest struct {
+	SessionID      string  `json:"sessionId"`
+	Position       int     `json:"position"`
+	WatchedSeconds int     `json:"watchedSeconds"`
+	PlaybackRate   float64 `json:"playbackRate"`
+}
+
+// Heartbeat records a playback heartbeat for the current user.
+func (h *Handler) Heartbeat(c *gin.Context) {
+	user, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.ErrorWithLog(h.logger, c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	target, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	var req heartbeatRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid heartbeat payload") {
+		return
+	}
+
+	input := HeartbeatInput{
+		Position:       req.Position,
+		WatchedSeconds: req.WatchedSeconds,
+		PlaybackRate:   req.PlaybackRate,
+	}
+	if req.SessionID != "" {
+		id, err := uuid.Parse(req.SessionID)
+		if err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid session id", err)
+			return
+		}
+		input.SessionID = &id
+	}
+
+	session, err := RecordHeartbeat(h.db, user.ID, target, input)
+	if err != nil {
+		h.respondError(c, err, "failed to record heartbeat")
+		return
+	}
+
+	response.Success(c, http.StatusOK, session, "", nil)
+}
+
+// LessonReport returns per-student watch totals for a lesson.
+func (h *Handler) LessonReport(c *gin.Context) {
+	target, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	params := pagination.Extract(c)
+
+	viewers, total, err := LessonViewers(h.db, target, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load watch report", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, viewers, "", pagination.MetadataFrom(total, params))
+}
+
+// UserReport returns one student's watch sessions for a lesson.
+func (h *Handler) UserReport(c *gin.Context) {
+	target, ok := h.resolveLesson(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	sessions, err := UserSessions(h.db, target.LessonID, userID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load watch sessions", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, sessions, "", nil)
+}
+
+// CourseReport returns engagement totals for each lesson of a course.
+func (h *Handler) CourseReport(c *gin.Context) {
+	courseID, ok := h.resolveCourse(c)
+	if !ok {
+		return
+	}
+
+	lessons, err := CourseLessonSummaries(h.db, courseID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load watch report", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, lessons, "", nil)
+}
+
+// resolveCourse validates route ids and ensures the course belongs to the subscription.
+func (h *Handler) resolveCourse(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return uuid.Nil, false
+	}
+
+	var count int64
+	if err := h.db.Table("courses").
+		Where("id = ? AND subscription_id = ?", courseID, subscriptionID).
+		Count(&count).Error; err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load course", err)
+		return uuid.Nil, false
+	}
+	if count == 0 {
+		h.respondError(c, ErrCourseNotFound, "failed to load course")
+		return uuid.Nil, false
+	}
+
+	return courseID, true
+}
+
+// resolveLesson validates route ids and loads the lesson scoped to its course and subscription.
+func (h *Handler) resolveLesson(c *gin.Context) (LessonTarget, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return LessonTarget{}, false
+	}
+
+	courseID, err := uuid.Parse(c.Param("courseId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid course id", err)
+		return LessonTarget{}, false
+	}
+
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid lesson id", err)
+		return LessonTarget{}, false
+	}
+
+	var rows []LessonTarget
+	err = h.db.Table("lessons").
+		Select("lessons.id AS lesson_id, lessons.course_id AS course_id, lessons.duration AS duration").
+		Joins("JOIN courses ON courses.id = lessons.course_id").
+		Where("lessons.id = ? AND courses.id = ? AND courses.subscription_id = ?", lessonID, courseID, subscriptionID).
+		Limit(1).
+		Scan(&rows).Error
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to load lesson", err)
+		return LessonTarget{}, false
+	}
+	if len(rows) == 0 {
+		h.respondError(c, ErrLessonNotFound, "failed to load lesson")
+		return LessonTarget{}, false
+	}
+
+	return rows[0], true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,319 @@
+package watchsession
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+const (
+	// maxHeartbeatSeconds caps the watched time credited by a single heartbeat so a
+	// tampered or replayed client cannot inflate analytics.
+	maxHeartbeatSeconds = 120
+	// sessionIdleTimeout is how long a session may go without heartbeats before the
+	// next heartbeat starts a new session.
+	sessionIdleTimeout = 30 * time.Minute
+
+	minPlaybackRate = 0.25
+	maxPlaybackRate = 4.0
+)
+
+// WatchSession aggregates the playback heartbeats of one continuous viewing of a lesson.
+type WatchSession struct {
+	types.BaseModel
+
+	UserID          uuid.UUID `gorm:"type:uuid;not null;column:user_id;index:idx_watch_sessions_user_lesson,priority:1" json:"userId"`
+	LessonID        uuid.UUID `gorm:"type:uuid;not null;column:lesson_id;index:idx_watch_sessions_user_lesson,priority:2;index" json:"lessonId"`
+	CourseID        uuid.UUID `gorm:"type:uuid;not null;column:course_id;index" json:"courseId"`
+	LastPosition    int       `gorm:"type:int;not null;default:0;column:last_position" json:"lastPosition"`     // seconds
+	MaxPosition     int       `gorm:"type:int;not null;default:0;column:max_position" json:"maxPosition"`       // seconds
+	WatchedSeconds  int       `gorm:"type:int;not null;default:0;column:watched_seconds" json:"watchedSeconds"` // seconds
+	PlaybackRate    float64   `gorm:"type:numeric(4,2);not null;default:1;column:playback_rate" json:"playbackRate"`
+	HeartbeatCount  int       `gorm:"type:int;not null;default:0;column:heartbeat_count" json:"heartbeatCount"`
+	LastHeartbeatAt time.Time `gorm:"type:timestamp;not null;column:last_heartbeat_at;index" json:"lastHeartbeatAt"`
+}
+
+// TableName overrides the default table name.
+func (WatchSession) TableName() string { return "watch_sessions" }
+
+// HeartbeatInput carries a single playback report from the player.
+type HeartbeatInput struct {
+	SessionID      *uuid.UUID
+	Position       int     // current playhead in seconds
+	WatchedSeconds int     // seconds actually played since the previous heartbeat
+	PlaybackRate   float64 // 0 keeps the previous rate
+}
+
+// LessonTarget identifies the lesson a heartbeat or report is scoped to.
+type LessonTarget struct {
+	LessonID uuid.UUID
+	CourseID uuid.UUID
+	Duration int
+}
+
+// ViewerSummary is one row of the per-lesson "who watched what" report.
+type ViewerSummary struct {
+	UserID            uuid.UUID `json:"userId"`
+	FullName          string    `json:"fullName"`
+	Email             string    `json:"email"`
+	Sessions          int64     `json:"sessions"`
+	WatchedSeconds    int64     `json:"watchedSeconds"`
+	MaxPosition       int       `json:"maxPosition"`
+	CompletionPercent float64   `json:"completionPercent"`
+	FirstWatchedAt    time.Time `json:"firstWatchedAt"`
+	LastWatchedAt     time.Time `json:"lastWatchedAt"`
+}
+
+// LessonSummary is one row of the per-course lesson engagement report.
+type LessonSummary struct {
+	LessonID       uuid.UUID  `json:"lessonId"`
+	Name           string     `json:"name"`
+	Order          int        `json:"order"`
+	Duration       int        `json:"duration"`
+	Viewers        int64      `json:"viewers"`
+	Sessions       int64      `json:"sessions"`
+	WatchedSeconds int64      `json:"watchedSeconds"`
+	LastWatchedAt  *time.Time `json:"lastWatchedAt,omitempty"`
+}
+
+// RecordHeartbeat folds a playback heartbeat into the caller's active session,
+// starting a new session when none is given or the previous one went idle.
+func RecordHeartbeat(db *gorm.DB, userID uuid.UUID, target LessonTarget, input HeartbeatInput) (WatchSession, error) {
+	if input.Position < 0 {
+		return WatchSession{}, ErrPositionInvalid
+	}
+	if input.WatchedSeconds < 0 {
+		return WatchSession{}, ErrWatchedInvalid
+	}
+	if input.PlaybackRate != 0 && (input.PlaybackRate < minPlaybackRate || input.PlaybackRate > maxPlaybackRate) {
+		return WatchSession{}, ErrPlaybackRateInvalid
+	}
+
+	position := input.Position
+	if target.Duration > 0 && position > target.Duration {
+		position = target.Duration
+	}
+
+	now := time.Now().UTC()
+	var session WatchSession
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		found := false
+		if input.SessionID != nil {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND user_id = ? AND lesson_id = ? AND last_heartbeat_at > ?",
+					*input.SessionID, userID, target.LessonID, now.Add(-sessionIdleTimeout)).
+				First(&session).Error
+			switch {
+			case err == nil:
+				found = true
+			case err != gorm.ErrRecordNotFound:
+				return err
+			}
+		}
+
+		if !found {
+			rate := input.PlaybackRate
+			if rate == 0 {
+				rate = 1
+			}
+			session = WatchSession{
+				UserID:          userID,
+				LessonID:        target.LessonID,
+				CourseID:        target.CourseID,
+				LastPosition:    position,
+				MaxPosition:     position,
+				PlaybackRate:    rate,
+				HeartbeatCount:  1,
+				LastHeartbeatAt: now,
+			}
+			// The first heartbeat only opens the session; there is no previous report
+			// to bound the claimed watch time against.
+			return tx.Create(&session).Error
+		}
+
+		session.WatchedSeconds += creditedSeconds(session, input.WatchedSeconds, now)
+		session.LastPosition = position
+		if position > session.MaxPosition {
+			session.MaxPosition = position
+		}
+		if input.PlaybackRate != 0 {
+			session.PlaybackRate = input.PlaybackRate
+		}
+		session.HeartbeatCount++
+		session.LastHeartbeatAt = now
+
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"watched_seconds":   session.WatchedSeconds,
+			"last_position":     session.LastPosition,
+			"max_position":      session.MaxPosition,
+			"playback_rate":     session.PlaybackRate,
+			"heartbeat_count":   session.HeartbeatCount,
+			"last_heartbeat_at": session.LastHeartbeatAt,
+		}).Error
+	})
+
+	return session, err
+}
+
+// creditedSeconds bounds the reported watch time by the wall-clock time elapsed since the
+// previous heartbeat (scaled by playback rate) and by maxHeartbeatSeconds.
+func creditedSeconds(session WatchSession, reported int, now time.Time) int {
+	rate := session.PlaybackRate
+	if rate < 1 {
+		rate = 1
+	}
+
+	elapsed := now.Sub(session.LastHeartbeatAt).Seconds() * rate
+	limit := int(elapsed) + 1
+	if limit > maxHeartbeatSeconds {
+		limit = maxHeartbeatSeconds
+	}
+
+	if reported > limit {
+		return limit
+	}
+	return reported
+}
+
+// LessonViewers returns per-student watch totals for a lesson, most recent viewers first.
+func LessonViewers(db *gorm.DB, target LessonTarget, params pagination.Params) ([]ViewerSummary, int64, error) {
+	var total int64
+	if err := db.Model(&WatchSession{}).
+		Where("lesson_id = ?", target.LessonID).
+		Distinct("user_id").
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rows := make([]ViewerSummary, 0)
+	err := db.Table("watch_sessions AS ws").
+		Select(`ws.user_id AS user_id,
+			users.full_name AS full_name,
+			users.email AS email,
+			COUNT(*) AS sessions,
+			COALESCE(SUM(ws.watched_seconds), 0) AS watched_seconds,
+			COALESCE(MAX(ws.max_position), 0) AS max_position,
+			MIN(ws.created_at) AS first_watched_at,
+			MAX(ws.last_heartbeat_at) AS last_watched_at`).
+		Joins("JOIN users ON users.id = ws.user_id").
+		Where("ws.lesson_id = ?", target.LessonID).
+		Group("ws.user_id, users.full_name, users.email").
+		Order("last_watched_at DESC").
+		Offset(params.Skip).
+		Limit(params.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range rows {
+		rows[i].CompletionPercent = completionPercent(rows[i].MaxPosition, target.Duration)
+	}
+
+	return rows, total, nil
+}
+
+// CourseLessonSummaries returns engagement totals for every lesson of a course in lesson order.
+func CourseLessonSummaries(db *gorm.DB, courseID uuid.UUID) ([]LessonSummary, error) {
+	rows := make([]LessonSummary, 0)
+	err := db.Table("lessons").
+		Select(`lessons.id AS lesson_id,
+			lessons.name AS name,
+			lessons."order" AS "order",
+			lessons.duration AS duration,
+			COUNT(DISTINCT ws.user_id) AS viewers,
+			COUNT(ws.id) AS sessions,
+			COALESCE(SUM(ws.watched_seconds), 0) AS watched_seconds,
+			MAX(ws.last_heartbeat_at) AS last_watched_at`).
+		Joins("LEFT JOIN watch_sessions AS ws ON ws.lesson_id = lessons.id").
+		Where("lessons.course_id = ?", courseID).
+		Group("lessons.id, lessons.name, lessons.\"order\", lessons.duration").
+		Order("lessons.\"order\" ASC, lessons.created_at ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// UserSessions lists a single student's sessions for a lesson, newest first.
+func UserSessions(db *gorm.DB, lessonID, userID uuid.UUID) ([]WatchSession, error) {
+	sessions := make([]WatchSession, 0)
+	err := db.Where("lesson_id = ? AND user_id = ?", lessonID, userID).
+		Order("last_heartbeat_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func completionPercent(maxPosition, duration int) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	if maxPosition >= duration {
+		return 100
+	}
+	return float64(maxPosition*10000/duration) / 100
+}
+
+// ProgressRow is one student's aggregated progress on one lesson of a course.
+type ProgressRow struct {
+	UserID         uuid.UUID
+	FullName       string
+	Email          string
+	LessonID       uuid.UUID
+	LessonName     string
+	LessonOrder    int
+	Duration       int
+	Sessions       int64
+	WatchedSeconds int64
+	MaxPosition    int
+	LastWatchedAt  time.Time
+}
+
+// EachCourseProgress streams per-student, per-lesson progress for a course ordered by
+// student then lesson, reading from a single cursor.
+func EachCourseProgress(db *gorm.DB, courseID uuid.UUID, fn func(ProgressRow) error) error {
+	query := db.Table("watch_sessions AS ws").
+		Select(`ws.user_id AS user_id,
+			users.full_name AS full_name,
+			users.email AS email,
+			ws.lesson_id AS lesson_id,
+			lessons.name AS lesson_name,
+			lessons."order" AS lesson_order,
+			lessons.duration AS duration,
+			COUNT(*) AS sessions,
+			COALESCE(SUM(ws.watched_seconds), 0) AS watched_seconds,
+			COALESCE(MAX(ws.max_position), 0) AS max_position,
+			MAX(ws.last_heartbeat_at) AS last_watched_at`).
+		Joins("JOIN users ON users.id = ws.user_id").
+		Joins("JOIN lessons ON lessons.id = ws.lesson_id").
+		Where("ws.course_id = ?", courseID).
+		Group(`ws.user_id, users.full_name, users.email, ws.lesson_id, lessons.name, lessons."order", lessons.duration`).
+		Order(`users.full_name ASC, ws.user_id ASC, lessons."order" ASC`)
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ProgressRow
+		if err := query.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CompletionPercent reports how far into the lesson the student reached.
+func (r ProgressRow) CompletionPercent() float64 {
+	return completionPercent(r.MaxPosition, r.Duration)
+}
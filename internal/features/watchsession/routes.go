@@ -0,0 +1,24 @@
+package watchsession
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes attaches watch analytics endpoints to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAll, acStaff []gin.HandlerFunc) {
+	course := router.Group("/subscriptions/:subscriptionId/courses/:courseId")
+	course.GET("/watch-report", append(acStaff, handler.CourseReport)...)
+	course.GET("/watch-report/export", append(acStaff, handler.ExportCourseReport)...)
+
+	lesson := course.Group("/lessons/:lessonId")
+	lesson.POST("/heartbeat", append(acAll, handler.Heartbeat)...)
+	lesson.GET("/watch-report", append(acStaff, handler.LessonReport)...)
+	lesson.GET("/watch-report/users/:userId", append(acStaff, handler.UserReport)...)
+
+	openapi.Describe(handler.CourseReport, openapi.Spec{Response: []LessonSummary{}})
+	openapi.Describe(handler.Heartbeat, openapi.Spec{Request: heartbeatRequest{}, Response: WatchSession{}})
+	openapi.Describe(handler.LessonReport, openapi.Spec{Response: []ViewerSummary{}})
+	openapi.Describe(handler.UserReport, openapi.Spec{Response: []WatchSession{}})
+}
@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidURL       = errors.New("webhook url must be a public https address")
+	ErrUnknownEvent     = errors.New("unknown webhook event")
+	ErrEventsRequired   = errors.New("at least one event is required")
+	ErrEndpointLimit    = errors.New("webhook endpoint limit reached")
+	ErrPrivateAddress   = errors.New("webhook url resolves to a private address")
+	ErrEndpointDisabled = errors.New("webhook endpoint is disabled")
+)
+
+// errorCatalog maps the errors of the service to their responses.
+var errorCatalog = response.Catalog{
+	{Err: ErrEndpointNotFound, Status: http.StatusNotFound, Code: "WEBHOOK_NOT_FOUND", Message: "Webhook not found."},
+	{Err: ErrDeliveryNotFound, Status: http.StatusNotFound, Code: "WEBHOOK_DELIVERY_NOT_FOUND", Message: "Delivery not found."},
+	{Err: ErrInvalidURL, Status: http.StatusBadRequest, Code: "INVALID_WEBHOOK_URL", Message: "Webhook URL must be a public https address."},
+	{Err: ErrEventsRequired, Status: http.StatusBadRequest, Code: "WEBHOOK_EVENTS_REQUIRED", Message: "At least one event is required."},
+	{Err: ErrUnknownEvent, Status: http.StatusBadRequest, Code: "UNKNOWN_WEBHOOK_EVENT", Message: "Unknown event. Supported events: " + strings.Join(Topics, ", ") + "."},
+	{Err: ErrEndpointLimit, Status: http.StatusConflict, Code: "WEBHOOK_LIMIT", Message: "This subscription already has the maximum number of webhooks."},
+}
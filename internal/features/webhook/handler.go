@@ -0,0 +1,342 @@
+package webhook
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/request"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// Handler processes webhook endpoint HTTP requests.
+type Handler struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewHandler constructs a webhook handler instance.
+func NewHandler(db *gorm.DB, logger *slog.Logger) *Handler {
+	return &Handler{db: db, logger: logger}
+}
+
+type createRequest struct {
+	URL         string   `json:"url" binding:"required,notblank,max=2048"`
+	Description *string  `json:"description" binding:"omitnil,max=255"`
+	Events      []string `json:"events" binding:"required,max=50"`
+	Secret      *string  `json:"secret" binding:"omitnil,min=16,max=100"`
+}
+
+type updateRequest struct {
+	URL         *string   `json:"url" binding:"omitnil,notblank,max=2048"`
+	Description *string   `json:"description" binding:"omitnil,max=255"`
+	Events      *[]string `json:"events" binding:"omitnil,max=50"`
+	IsActive    *bool     `json:"isActive"`
+}
+
+// endpointWithSecret is returned when a secret is set, the only time it is shown.
+type endpointWithSecret struct {
+	Endpoint
+	Secret string `json:"secret"`
+}
+
+// Events lists the events endpoints can subscribe to.
+func (h *Handler) Events(c *gin.Context) {
+	response.Success(c, http.StatusOK, Topics, "", nil)
+}
+
+// List returns the subscription's endpoints.
+func (h *Handler) List(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	endpoints, err := ListEndpoints(h.db, subscriptionID)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list webhooks", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, endpoints, "", nil)
+}
+
+// Get returns one endpoint.
+func (h *Handler) Get(c *gin.Context) {
+	endpoint, ok := h.resolveEndpoint(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, http.StatusOK, endpoint, "", nil)
+}
+
+// Create registers an endpoint. Without a secret in the request one is
+// generated; either way it is returned once.
+func (h *Handler) Create(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req createRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid webhook payload") {
+		return
+	}
+
+	target, err := ValidateURL(req.URL)
+	if err == nil {
+		err = validateEvents(req.Events)
+	}
+	if err != nil {
+		h.respondError(c, err, "invalid webhook")
+		return
+	}
+
+	secret := ""
+	if req.Secret != nil {
+		secret = strings.TrimSpace(*req.Secret)
+	}
+	if secret == "" {
+		if secret, err = NewSecret(); err != nil {
+			response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to generate webhook secret", err)
+			return
+		}
+	}
+
+	endpoint := Endpoint{
+		SubscriptionID: subscriptionID,
+		URL:            target,
+		Description:    request.Trimmed(req.Description),
+		Secret:         secret,
+		Events:         req.Events,
+		Active:         true,
+	}
+	if err := CreateEndpoint(h.db, &endpoint); err != nil {
+		h.respondError(c, err, "failed to create webhook")
+		return
+	}
+
+	response.Created(c, endpointWithSecret{Endpoint: endpoint, Secret: secret}, "")
+}
+
+// Update changes an endpoint's URL, description, events or active flag.
+func (h *Handler) Update(c *gin.Context) {
+	endpoint, ok := h.resolveEndpoint(c)
+	if !ok {
+		return
+	}
+
+	var req updateRequest
+	if !request.BindJSON(h.logger, c, &req, "invalid webhook payload") {
+		return
+	}
+
+	if req.URL != nil {
+		target, err := ValidateURL(*req.URL)
+		if err != nil {
+			h.respondError(c, err, "invalid webhook")
+			return
+		}
+		endpoint.URL = target
+	}
+	if req.Description != nil {
+		endpoint.Description = request.Trimmed(req.Description)
+	}
+	if req.Events != nil {
+		if err := validateEvents(*req.Events); err != nil {
+			h.respondError(c, err, "invalid webhook")
+			return
+		}
+		endpoint.Events = *req.Events
+	}
+	if req.IsActive != nil {
+		endpoint.Active = *req.IsActive
+	}
+
+	if err := SaveEndpoint(h.db, &endpoint); err != nil {
+		h.respondError(c, err, "failed to update webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, endpoint, "", nil)
+}
+
+// RotateSecret replaces an endpoint's signing secret and returns the new one.
+func (h *Handler) RotateSecret(c *gin.Context) {
+	endpoint, ok := h.resolveEndpoint(c)
+	if !ok {
+		return
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to generate webhook secret", err)
+		return
+	}
+	endpoint.Secret = secret
+	if err := SaveEndpoint(h.db, &endpoint); err != nil {
+		h.respondError(c, err, "failed to rotate webhook secret")
+		return
+	}
+
+	response.Success(c, http.StatusOK, endpointWithSecret{Endpoint: endpoint, Secret: secret}, "", nil)
+}
+
+// Delete removes an endpoint and its delivery log.
+func (h *Handler) Delete(c *gin.Context) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid webhook id", err)
+		return
+	}
+
+	if err := DeleteEndpoint(h.db, subscriptionID, id); err != nil {
+		h.respondError(c, err, "failed to delete webhook")
+		return
+	}
+
+	response.NoContent(c, "Webhook deleted.")
+}
+
+// Test queues a webhook.ping delivery to the endpoint.
+func (h *Handler) Test(c *gin.Context) {
+	endpoint, ok := h.resolveEndpoint(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := Ping(h.db, endpoint)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to queue test delivery", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, delivery, "Test delivery queued.", nil)
+}
+
+// Deliveries returns the endpoint's delivery log, optionally filtered by ?status=.
+func (h *Handler) Deliveries(c *gin.Context) {
+	endpoint, ok := h.resolveEndpoint(c)
+	if !ok {
+		return
+	}
+
+	status := Status(strings.TrimSpace(c.Query("status")))
+	if status != "" && !ValidStatus(status) {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid delivery status", nil)
+		return
+	}
+
+	params := pagination.Extract(c)
+	deliveries, total, err := ListDeliveries(h.db, endpoint.ID, status, params)
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to list deliveries", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, deliveries, "", pagination.MetadataFrom(total, params))
+}
+
+// Delivery returns one delivery with its payload and the endpoint's answer.
+func (h *Handler) Delivery(c *gin.Context) {
+	delivery, ok := h.resolveDelivery(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, http.StatusOK, delivery, "", nil)
+}
+
+// Redeliver queues a delivery again with a fresh attempt budget.
+func (h *Handler) Redeliver(c *gin.Context) {
+	delivery, ok := h.resolveDelivery(c)
+	if !ok {
+		return
+	}
+
+	if err := Redeliver(h.db, &delivery, time.Now().UTC()); err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusInternalServerError, "failed to redeliver webhook", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, delivery, "Delivery queued.", nil)
+}
+
+func validateEvents(events []string) error {
+	if len(events) == 0 {
+		return ErrEventsRequired
+	}
+	for _, event := range events {
+		if !slices.Contains(Topics, event) {
+			return ErrUnknownEvent
+		}
+	}
+	return nil
+}
+
+func (h *Handler) subscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid subscription id", err)
+		return uuid.Nil, false
+	}
+	return subscriptionID, true
+}
+
+func (h *Handler) resolveEndpoint(c *gin.Context) (Endpoint, bool) {
+	subscriptionID, ok := h.subscriptionID(c)
+	if !ok {
+		return Endpoint{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid webhook id", err)
+		return Endpoint{}, false
+	}
+
+	endpoint, err := GetEndpoint(h.db, subscriptionID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load webhook")
+		return Endpoint{}, false
+	}
+	return endpoint, true
+}
+
+func (h *Handler) resolveDelivery(c *gin.Context) (Delivery, bool) {
+	endpoint, ok := h.resolveEndpoint(c)
+	if !ok {
+		return Delivery{}, false
+	}
+
+	id, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		response.ErrorWithLog(h.logger, c, http.StatusBadRequest, "invalid delivery id", err)
+		return Delivery{}, false
+	}
+
+	delivery, err := GetDelivery(h.db, endpoint.ID, id)
+	if err != nil {
+		h.respondError(c, err, "failed to load delivery")
+		return Delivery{}, false
+	}
+	return delivery, true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, fallback string) {
+	errorCatalog.Respond(h.logger, c, err, fallback)
+}
@@ -0,0 +1,278 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Status tracks a delivery through the outbound queue.
+type Status string
+
+const (
+	// StatusPending means the delivery waits for its next attempt.
+	StatusPending Status = "pending"
+	// StatusSending means a worker claimed the delivery and is calling the endpoint.
+	StatusSending Status = "sending"
+	// StatusSucceeded means the endpoint answered with a 2xx status.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means every attempt failed; the tenant may redeliver it.
+	StatusFailed Status = "failed"
+)
+
+// ValidStatus reports whether s is a known status.
+func ValidStatus(s Status) bool {
+	switch s {
+	case StatusPending, StatusSending, StatusSucceeded, StatusFailed:
+		return true
+	}
+	return false
+}
+
+const (
+	maxAttempts = 8
+	baseBackoff = time.Minute
+	maxBackoff  = 6 * time.Hour
+	// maxEndpoints caps the endpoints of one subscription.
+	maxEndpoints = 10
+	// maxResponseBody is how much of an endpoint's answer the log keeps.
+	maxResponseBody = 2048
+)
+
+// Endpoint is a URL a subscription registered to receive events. The secret
+// signs every delivery and is only shown when it is set.
+type Endpoint struct {
+	types.BaseModel
+
+	SubscriptionID uuid.UUID      `gorm:"type:uuid;not null;column:subscription_id;index" json:"subscriptionId"`
+	URL            string         `gorm:"type:varchar(2048);not null" json:"url"`
+	Description    *string        `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Secret         string         `gorm:"type:varchar(100);not null" json:"-"`
+	Events         pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"events"`
+	Active         bool           `gorm:"type:boolean;not null;default:true;column:is_active" json:"isActive"`
+}
+
+// TableName overrides the default table name.
+func (Endpoint) TableName() string { return "webhook_endpoints" }
+
+// Delivery is one event sent to one endpoint, kept as the endpoint's log.
+// Payload is the exact body that is signed and sent on every attempt.
+type Delivery struct {
+	types.BaseModel
+
+	EndpointID     uuid.UUID  `gorm:"type:uuid;not null;column:endpoint_id;uniqueIndex:idx_webhook_deliveries_endpoint_event,priority:1" json:"endpointId"`
+	EventID        uuid.UUID  `gorm:"type:uuid;not null;column:event_id;uniqueIndex:idx_webhook_deliveries_endpoint_event,priority:2" json:"eventId"`
+	Event          string     `gorm:"type:varchar(100);not null" json:"event"`
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status         Status     `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_deliveries_status_next,priority:1" json:"status"`
+	Attempts       int        `gorm:"type:int;not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"type:timestamp;not null;column:next_attempt_at;index:idx_webhook_deliveries_status_next,priority:2" json:"nextAttemptAt"`
+	ResponseStatus *int       `gorm:"type:int;column:response_status" json:"responseStatus,omitempty"`
+	ResponseBody   *string    `gorm:"type:text;column:response_body" json:"responseBody,omitempty"`
+	LastError      *string    `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	DurationMs     *int       `gorm:"type:int;column:duration_ms" json:"durationMs,omitempty"`
+	DeliveredAt    *time.Time `gorm:"type:timestamp;column:delivered_at" json:"deliveredAt,omitempty"`
+}
+
+// TableName overrides the default table name.
+func (Delivery) TableName() string { return "webhook_deliveries" }
+
+// Attempt is the outcome of one call to an endpoint.
+type Attempt struct {
+	ResponseStatus *int
+	ResponseBody   *string
+	Duration       time.Duration
+	Err            error
+}
+
+// ListEndpoints returns the subscription's endpoints, oldest first.
+func ListEndpoints(db *gorm.DB, subscriptionID uuid.UUID) ([]Endpoint, error) {
+	endpoints := make([]Endpoint, 0)
+	err := db.Where("subscription_id = ?", subscriptionID).Order("created_at ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// GetEndpoint loads one of the subscription's endpoints.
+func GetEndpoint(db *gorm.DB, subscriptionID, id uuid.UUID) (Endpoint, error) {
+	var endpoint Endpoint
+	err := db.First(&endpoint, "id = ? AND subscription_id = ?", id, subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return endpoint, ErrEndpointNotFound
+	}
+	return endpoint, err
+}
+
+// CreateEndpoint stores a new endpoint within the subscription's limit.
+func CreateEndpoint(db *gorm.DB, endpoint *Endpoint) error {
+	var count int64
+	if err := db.Model(&Endpoint{}).Where("subscription_id = ?", endpoint.SubscriptionID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= maxEndpoints {
+		return ErrEndpointLimit
+	}
+	return db.Create(endpoint).Error
+}
+
+// SaveEndpoint stores changes to an endpoint.
+func SaveEndpoint(db *gorm.DB, endpoint *Endpoint) error {
+	return db.Save(endpoint).Error
+}
+
+// DeleteEndpoint removes an endpoint and, through the foreign key, its log.
+func DeleteEndpoint(db *gorm.DB, subscriptionID, id uuid.UUID) error {
+	result := db.Delete(&Endpoint{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+// Subscribers returns the subscription's active endpoints listening to event.
+func Subscribers(db *gorm.DB, subscriptionID uuid.UUID, event string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := db.Where("subscription_id = ? AND is_active AND ? = ANY(events)", subscriptionID, event).Find(&endpoints).Error
+	return endpoints, err
+}
+
+// Enqueue queues a delivery, once per endpoint and event however often the
+// event is dispatched.
+func Enqueue(db *gorm.DB, delivery *Delivery) error {
+	delivery.Status = StatusPending
+	delivery.NextAttemptAt = time.Now().UTC()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint_id"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(delivery).Error
+}
+
+// ListDeliveries returns an endpoint's deliveries, newest first, without
+// their payloads.
+func ListDeliveries(db *gorm.DB, endpointID uuid.UUID, status Status, params pagination.Params) ([]Delivery, int64, error) {
+	query := db.Model(&Delivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := make([]Delivery, 0)
+	err := query.Omit("payload", "response_body").
+		Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// GetDelivery loads one of an endpoint's deliveries.
+func GetDelivery(db *gorm.DB, endpointID, id uuid.UUID) (Delivery, error) {
+	var delivery Delivery
+	err := db.First(&delivery, "id = ? AND endpoint_id = ?", id, endpointID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return delivery, ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// Redeliver puts a delivery back in the queue with a fresh attempt budget.
+func Redeliver(db *gorm.DB, delivery *Delivery, now time.Time) error {
+	delivery.Status = StatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	return db.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          StatusPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	}).Error
+}
+
+// ClaimDue moves up to limit due deliveries to sending and returns them.
+// Deliveries stuck in sending longer than staleAfter are reclaimed. SKIP LOCKED
+// lets several instances drain the queue without calling an endpoint twice.
+func ClaimDue(db *gorm.DB, now time.Time, staleAfter time.Duration, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at <= ?)",
+				StatusPending, now, StatusSending, now.Add(-staleAfter)).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+			deliveries[i].Status = StatusSending
+		}
+		return tx.Model(&Delivery{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": StatusSending, "updated_at": now}).Error
+	})
+	return deliveries, err
+}
+
+// Record stores the outcome of an attempt and either finishes the delivery,
+// schedules the retry or gives up.
+func Record(db *gorm.DB, delivery Delivery, attempt Attempt, now time.Time) error {
+	attempts := delivery.Attempts + 1
+	durationMs := int(attempt.Duration.Milliseconds())
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"response_status": attempt.ResponseStatus,
+		"response_body":   attempt.ResponseBody,
+		"duration_ms":     durationMs,
+		"updated_at":      now,
+	}
+
+	switch {
+	case attempt.Err == nil:
+		updates["status"] = StatusSucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+	case attempts >= maxAttempts:
+		updates["status"] = StatusFailed
+		updates["last_error"] = truncate(attempt.Err.Error(), 1000)
+	default:
+		updates["status"] = StatusPending
+		updates["next_attempt_at"] = now.Add(backoff(attempts))
+		updates["last_error"] = truncate(attempt.Err.Error(), 1000)
+	}
+	return db.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+}
+
+// backoff returns 1m, 2m, 4m, ... capped at six hours.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+)
+
+// RegisterRoutes sets up webhook endpoints under /subscriptions/:subscriptionId/webhooks.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, acAdminInstructor []gin.HandlerFunc) {
+	webhooks := router.Group("/subscriptions/:subscriptionId/webhooks")
+
+	webhooks.GET("", append(acAdminInstructor, handler.List)...)
+	webhooks.POST("", append(acAdminInstructor, handler.Create)...)
+	webhooks.GET("/events", append(acAdminInstructor, handler.Events)...)
+	webhooks.GET("/:webhookId", append(acAdminInstructor, handler.Get)...)
+	webhooks.PUT("/:webhookId", append(acAdminInstructor, handler.Update)...)
+	webhooks.DELETE("/:webhookId", append(acAdminInstructor, handler.Delete)...)
+	webhooks.POST("/:webhookId/rotate-secret", append(acAdminInstructor, handler.RotateSecret)...)
+	webhooks.POST("/:webhookId/test", append(acAdminInstructor, handler.Test)...)
+	webhooks.GET("/:webhookId/deliveries", append(acAdminInstructor, handler.Deliveries)...)
+	webhooks.GET("/:webhookId/deliveries/:deliveryId", append(acAdminInstructor, handler.Delivery)...)
+	webhooks.POST("/:webhookId/deliveries/:deliveryId/redeliver", append(acAdminInstructor, handler.Redeliver)...)
+
+	openapi.Describe(handler.List, openapi.Spec{Response: []Endpoint{}})
+	openapi.Describe(handler.Create, openapi.Spec{Request: createRequest{}, Response: endpointWithSecret{}})
+	openapi.Describe(handler.Events, openapi.Spec{Response: []string{}})
+	openapi.Describe(handler.Get, openapi.Spec{Response: Endpoint{}})
+	openapi.Describe(handler.Update, openapi.Spec{Request: updateRequest{}, Response: Endpoint{}})
+	openapi.Describe(handler.RotateSecret, openapi.Spec{Response: endpointWithSecret{}})
+	openapi.Describe(handler.Test, openapi.Spec{Response: Delivery{}})
+	openapi.Describe(handler.Deliveries, openapi.Spec{Response: []Delivery{}})
+	openapi.Describe(handler.Delivery, openapi.Spec{Response: Delivery{}})
+	openapi.Describe(handler.Redeliver, openapi.Spec{Response: Delivery{}})
+}
@@ -0,0 +1,366 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	"github.com/mo-amir99/lms-server-go/internal/features/payment"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/eventbus"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// TopicStreamStarted is the outbox topic published when a host goes live.
+const TopicStreamStarted = "stream.started"
+
+// EventPing is sent by the test endpoint; it is never published to the outbox.
+const EventPing = "webhook.ping"
+
+// The topics tenants can subscribe their endpoints to. OutboxTopics are
+// delivered by the outbox, LiveTopics are relayed from the event bus.
+var (
+	OutboxTopics = []string{user.TopicCreated, lesson.TopicPublished, payment.TopicCompleted, TopicStreamStarted}
+	LiveTopics   = []string{announcement.TopicPublished}
+	Topics       = slices.Concat(OutboxTopics, LiveTopics)
+)
+
+// Request headers of every delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the endpoint's secret.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	batchSize = 50
+	// staleSending is how long a claimed delivery may stay in sending before
+	// another worker assumes the claimer died and retries it.
+	staleSending = 10 * time.Minute
+	// requestTimeout bounds one call to an endpoint.
+	requestTimeout = 10 * time.Second
+)
+
+// envelope is the body of every delivery.
+type envelope struct {
+	ID             uuid.UUID       `json:"id"`
+	Event          string          `json:"event"`
+	SubscriptionID uuid.UUID       `json:"subscriptionId"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// StreamEvent is the outbox payload for live streams going live.
+type StreamEvent struct {
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	StreamID       string    `json:"streamId"`
+	Title          string    `json:"title"`
+	HostID         string    `json:"hostId"`
+	HostName       string    `json:"hostName"`
+	IsPublic       bool      `json:"isPublic"`
+	StartedAt      time.Time `json:"startedAt"`
+}
+
+// Service turns outbox events into deliveries and sends them.
+type Service struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	client *http.Client
+}
+
+// NewService constructs the webhook service. Its HTTP client refuses to
+// connect to private addresses and does not follow redirects.
+func NewService(db *gorm.DB, logger *slog.Logger) *Service {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refusePrivate}
+	return &Service{
+		db:     db,
+		logger: logger,
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Enqueue is the outbox subscriber: it queues one delivery per endpoint of the
+// event's subscription that listens to the topic. Redispatching an event does
+// not queue it twice.
+func (s *Service) Enqueue(ctx context.Context, event outbox.Event) error {
+	var scope struct {
+		SubscriptionID uuid.UUID `json:"subscriptionId"`
+	}
+	if err := event.Decode(&scope); err != nil {
+		return err
+	}
+	if scope.SubscriptionID == uuid.Nil {
+		return nil
+	}
+
+	return s.enqueue(ctx, envelope{
+		ID:             event.ID,
+		Event:          event.Topic,
+		SubscriptionID: scope.SubscriptionID,
+		CreatedAt:      event.CreatedAt,
+		Data:           json.RawMessage(event.Payload),
+	})
+}
+
+// Relay is the event bus subscriber for LiveTopics. The bus is not durable, but
+// once queued its deliveries are retried like those of outbox events.
+func (s *Service) Relay(ctx context.Context, event eventbus.Event) error {
+	if event.SubscriptionID == uuid.Nil {
+		return nil
+	}
+
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	return s.enqueue(ctx, envelope{
+		ID:             uuid.New(),
+		Event:          event.Topic,
+		SubscriptionID: event.SubscriptionID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           data,
+	})
+}
+
+// enqueue queues one delivery of the event per endpoint of its subscription
+// that listens to it.
+func (s *Service) enqueue(ctx context.Context, event envelope) error {
+	db := s.db.WithContext(ctx)
+	endpoints, err := Subscribers(db, event.SubscriptionID, event.Event)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if err := Enqueue(db, &Delivery{
+			EndpointID: endpoint.ID,
+			EventID:    event.ID,
+			Event:      event.Event,
+			Payload:    string(body),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping queues a test delivery to the endpoint, whatever events it listens to.
+func Ping(db *gorm.DB, endpoint Endpoint) (Delivery, error) {
+	eventID := uuid.New()
+	body, err := json.Marshal(envelope{
+		ID:             eventID,
+		Event:          EventPing,
+		SubscriptionID: endpoint.SubscriptionID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           json.RawMessage(`{}`),
+	})
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	delivery := Delivery{EndpointID: endpoint.ID, EventID: eventID, Event: EventPing, Payload: string(body)}
+	return delivery, Enqueue(db, &delivery)
+}
+
+// StreamStarted publishes stream.started for streams of a subscription. It is
+// registered as a socket server hook, so failures are only logged.
+func (s *Service) StreamStarted(stream streamcache.Stream) {
+	subscriptionID, err := uuid.Parse(stream.SubscriptionID)
+	if err != nil {
+		return
+	}
+
+	if err := outbox.Publish(s.db, TopicStreamStarted, subscriptionID, StreamEvent{
+		SubscriptionID: subscriptionID,
+		StreamID:       stream.ID,
+		Title:          stream.Title,
+		HostID:         stream.HostID,
+		HostName:       stream.HostName,
+		IsPublic:       stream.IsPublic,
+		StartedAt:      stream.StartTime,
+	}); err != nil {
+		s.logger.Error("failed to publish stream started event", slog.String("streamId", stream.ID), slog.String("error", err.Error()))
+	}
+}
+
+// deliver sends one delivery to its endpoint. Deliveries of a disabled
+// endpoint fail without being sent.
+func (s *Service) deliver(ctx context.Context, delivery Delivery) Attempt {
+	var endpoint Endpoint
+	if err := s.db.WithContext(ctx).First(&endpoint, "id = ?", delivery.EndpointID).Error; err != nil {
+		return Attempt{Err: err}
+	}
+	if !endpoint.Active {
+		return Attempt{Err: ErrEndpointDisabled}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return Attempt{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LMS-Server-Go-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(endpoint.Secret, timestamp, []byte(delivery.Payload)))
+
+	started := time.Now()
+	resp, err := s.client.Do(req)
+	attempt := Attempt{Duration: time.Since(started)}
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	status := resp.StatusCode
+	text := string(body)
+	attempt.ResponseStatus = &status
+	attempt.ResponseBody = &text
+	if status < 200 || status >= 300 {
+		attempt.Err = fmt.Errorf("endpoint answered %d", status)
+	}
+	return attempt
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// ValidateURL accepts absolute https URLs whose host is a name or a public IP.
+// Names are checked again when connecting, see refusePrivate.
+func ValidateURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" || parsed.User != nil {
+		return "", ErrInvalidURL
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return "", ErrInvalidURL
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return "", ErrInvalidURL
+	}
+	return parsed.String(), nil
+}
+
+// refusePrivate stops the dialer from reaching internal services through a
+// tenant's URL, including names that resolve to private addresses.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// Job delivers queued webhooks. It is meant to run every few seconds on the job
+// scheduler.
+type Job struct {
+	service *Service
+}
+
+// NewJob constructs the webhook delivery job.
+func NewJob(service *Service) *Job {
+	return &Job{service: service}
+}
+
+// Name returns the job name.
+func (j *Job) Name() string {
+	return "webhook-delivery"
+}
+
+// Execute sends every due delivery, one batch at a time.
+func (j *Job) Execute(ctx context.Context) error {
+	s := j.service
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		deliveries, err := ClaimDue(s.db.WithContext(ctx), time.Now().UTC(), staleSending, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			attempt := s.deliver(ctx, delivery)
+			if attempt.Err != nil {
+				s.logger.Warn("webhook delivery failed",
+					slog.String("deliveryId", delivery.ID.String()),
+					slog.String("event", delivery.Event),
+					slog.Int("attempt", delivery.Attempts+1),
+					slog.String("error", attempt.Err.Error()))
+			}
+
+			// Persist the outcome even if the job is being cancelled
+			db := s.db.WithContext(context.WithoutCancel(ctx))
+			if err := Record(db, delivery, attempt, time.Now().UTC()); err != nil {
+				s.logger.Error("failed to record webhook delivery", slog.String("deliveryId", delivery.ID.String()), slog.String("error", err.Error()))
+			}
+		}
+
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package webrtc
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/webrtc"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	provider *webrtc.Provider
+}
+
+func NewHandler(logger *slog.Logger, provider *webrtc.Provider) *Handler {
+	return &Handler{logger: logger, provider: provider}
+}
+
+// GetICEServers returns STUN servers and time-limited TURN credentials for the current user
+// GET /webrtc/ice-servers
+func (h *Handler) GetICEServers(c *gin.Context) {
+	currentUser, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Authentication required.", nil)
+		return
+	}
+
+	// Credentials are per user and short-lived; never let intermediaries cache them
+	c.Header("Cache-Control", "no-store")
+	response.Success(c, http.StatusOK, h.provider.Credentials(currentUser.ID.String(), time.Now()), "", nil)
+}
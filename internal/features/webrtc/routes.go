@@ -0,0 +1,16 @@
+package webrtc
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+	"github.com/mo-amir99/lms-server-go/pkg/webrtc"
+)
+
+// RegisterRoutes attaches the WebRTC ICE configuration endpoint to the router.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, allUsers []gin.HandlerFunc) {
+	rtc := router.Group("/webrtc")
+	rtc.GET("/ice-servers", append(allUsers, handler.GetICEServers)...)
+
+	openapi.Describe(handler.GetICEServers, openapi.Spec{Response: webrtc.Credentials{}})
+}
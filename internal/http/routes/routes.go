@@ -1,42 +1,119 @@
 package routes
 
 import (
+	"fmt"
 	"log/slog"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
 	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
 	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
 	"github.com/mo-amir99/lms-server-go/internal/features/auth"
+	"github.com/mo-amir99/lms-server-go/internal/features/bookmark"
+	"github.com/mo-amir99/lms-server-go/internal/features/chapter"
+	"github.com/mo-amir99/lms-server-go/internal/features/classroom"
 	"github.com/mo-amir99/lms-server-go/internal/features/comment"
+	"github.com/mo-amir99/lms-server-go/internal/features/contentsync"
+	"github.com/mo-amir99/lms-server-go/internal/features/coupon"
 	"github.com/mo-amir99/lms-server-go/internal/features/course"
 	"github.com/mo-amir99/lms-server-go/internal/features/dashboard"
+	"github.com/mo-amir99/lms-server-go/internal/features/download"
+	"github.com/mo-amir99/lms-server-go/internal/features/emailqueue"
+	"github.com/mo-amir99/lms-server-go/internal/features/eventstream"
+	"github.com/mo-amir99/lms-server-go/internal/features/featureflag"
 	"github.com/mo-amir99/lms-server-go/internal/features/forum"
+	"github.com/mo-amir99/lms-server-go/internal/features/gradebook"
 	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
 	"github.com/mo-amir99/lms-server-go/internal/features/iap"
+	"github.com/mo-amir99/lms-server-go/internal/features/idempotency"
+	"github.com/mo-amir99/lms-server-go/internal/features/invitation"
 	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/features/lessonnote"
+	"github.com/mo-amir99/lms-server-go/internal/features/lessonqa"
+	"github.com/mo-amir99/lms-server-go/internal/features/maintenance"
 	"github.com/mo-amir99/lms-server-go/internal/features/meeting"
+	"github.com/mo-amir99/lms-server-go/internal/features/message"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
 	pkg "github.com/mo-amir99/lms-server-go/internal/features/package"
 	"github.com/mo-amir99/lms-server-go/internal/features/payment"
+	"github.com/mo-amir99/lms-server-go/internal/features/playback"
+	"github.com/mo-amir99/lms-server-go/internal/features/presence"
 	"github.com/mo-amir99/lms-server-go/internal/features/referral"
+	"github.com/mo-amir99/lms-server-go/internal/features/role"
+	"github.com/mo-amir99/lms-server-go/internal/features/scheduledsession"
+	"github.com/mo-amir99/lms-server-go/internal/features/scim"
+	"github.com/mo-amir99/lms-server-go/internal/features/setting"
+	"github.com/mo-amir99/lms-server-go/internal/features/sso"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamanalytics"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamchat"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamrecording"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
 	"github.com/mo-amir99/lms-server-go/internal/features/supportticket"
+	"github.com/mo-amir99/lms-server-go/internal/features/tenantbackup"
 	"github.com/mo-amir99/lms-server-go/internal/features/thread"
 	"github.com/mo-amir99/lms-server-go/internal/features/usage"
 	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/internal/features/userwatch"
+	"github.com/mo-amir99/lms-server-go/internal/features/watchsession"
+	"github.com/mo-amir99/lms-server-go/internal/features/webhook"
+	"github.com/mo-amir99/lms-server-go/internal/features/webrtc"
 	"github.com/mo-amir99/lms-server-go/internal/middleware"
 	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/cache"
+	"github.com/mo-amir99/lms-server-go/pkg/clamav"
 	"github.com/mo-amir99/lms-server-go/pkg/config"
-	"github.com/mo-amir99/lms-server-go/pkg/email"
+	"github.com/mo-amir99/lms-server-go/pkg/eventbus"
 	"github.com/mo-amir99/lms-server-go/pkg/health"
+	applog "github.com/mo-amir99/lms-server-go/pkg/logger"
+	httpmiddleware "github.com/mo-amir99/lms-server-go/pkg/middleware"
+	"github.com/mo-amir99/lms-server-go/pkg/openapi"
+	socketioserver "github.com/mo-amir99/lms-server-go/pkg/socketio"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
 	"github.com/mo-amir99/lms-server-go/pkg/types"
+	webrtcice "github.com/mo-amir99/lms-server-go/pkg/webrtc"
+)
+
+// API path prefixes. LegacyAPIPrefix is kept as a deprecated alias of APIPrefix.
+const (
+	APIVersion      = "v1"
+	APIPrefix       = "/api/" + APIVersion
+	LegacyAPIPrefix = "/api"
 )
 
 // Register wires all feature routes onto the engine.
-func Register(engine *gin.Engine, cfg *config.Config, db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClient, storageClient *bunny.StorageClient, statsClient *bunny.StatisticsClient, emailClient *email.Client, meetingCache *meeting.Cache) {
+func Register(engine *gin.Engine, cfg *config.Config, configWatcher *config.Watcher, db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClient, storageClient *bunny.StorageClient, statsClient *bunny.StatisticsClient, emailQueue *emailqueue.Queue, meetingCache *meeting.Cache, socketServer *socketioserver.Server, liveEvents *eventbus.Bus, eventStreams *eventstream.Hub, notificationService *notification.Service, queryCache *cache.Store, rateLimitStore httpmiddleware.Limiter) {
+	// Rate limiting: anonymous requests per client IP, authenticated ones per user
+	// and subscription, so schools sharing one NAT address are not throttled together
+	rateLimiter := middleware.NewTenantLimiter(cfg.JWTSecret, cfg.RateLimit, rateLimitStore)
+
+	// Streaming limits, watch defaults and quotas can be tuned at runtime by superadmins;
+	// the configured defaults follow configuration reloads
+	settingService := setting.NewService(db, logger, cfg.RateLimit, cfg.Streaming)
+	configWatcher.OnReload(func(next *config.Config) {
+		settingService.Configure(next.RateLimit, next.Streaming)
+	})
+	rateLimiter.UseLimits(settingService.RateLimits)
+	engine.Use(rateLimiter.Middleware())
+
+	// Maintenance mode answers 503 to everyone but superadmins; health checks, the
+	// status endpoint and login (so superadmins can sign in) stay reachable
+	maintenanceService := maintenance.NewService(db, logger)
+	engine.Use(maintenanceService.Middleware(cfg.JWTSecret,
+		"/health", "/ready", "/version", "/metrics", "/public/",
+		APIPrefix+"/maintenance/status", APIPrefix+"/auth/login", APIPrefix+"/auth/refresh-token", APIPrefix+"/auth/refreshToken"))
+	if socketServer != nil {
+		maintenanceService.OnChange(func(status maintenance.Status) {
+			socketServer.Broadcast(maintenance.EventChanged, status)
+		})
+		socketServer.SetMaintenance(maintenanceService)
+	}
+
 	// Health check endpoints (no /api prefix for Kubernetes probes)
 	healthHandler := health.NewHandler(db, logger)
 	engine.GET("/health", healthHandler.Health)
@@ -54,10 +131,13 @@ func Register(engine *gin.Engine, cfg *config.Config, db *gorm.DB, logger *slog.
 		engine.GET("/debug/db-stats", healthHandler.DBStats)
 	}
 
-	api := engine.Group("/api")
+	// All feature routes live under the current API version; cmd/app wraps the engine
+	// so legacy unversioned /api paths are served from here with deprecation headers
+	api := engine.Group(APIPrefix)
 
 	// Initialize global middleware instance (like Node.js)
 	middleware.Initialize(db, cfg.JWTSecret, logger)
+	middleware.UseTenantLimiter(rateLimiter)
 
 	// Create middleware configurations
 	// Note: SuperAdmin automatically has access to everything (handled in AuthorizeRoles)
@@ -76,57 +156,254 @@ func Register(engine *gin.Engine, cfg *config.Config, db *gorm.DB, logger *slog.
 	acAllWithInactive := middleware.AccessControl([]types.UserType{types.UserTypeAll}, middleware.AccessControlOptions{AllowInactiveSubscription: true})
 	acStaffWithInactive := middleware.AccessControl([]types.UserType{types.UserTypeAdmin, types.UserTypeInstructor, types.UserTypeAssistant}, middleware.AccessControlOptions{AllowInactiveSubscription: true})
 
+	// Students are read-only while their subscription is in its grace period, except for
+	// playback heartbeats and support requests
+	acAllDuringGrace := middleware.AccessControl([]types.UserType{types.UserTypeAll}, middleware.AccessControlOptions{AllowDuringGrace: true})
+
+	// Permission-based access: staff hold every permission, other members only those
+	// granted by their custom subscription role
+	acContent := authz.WithPermission(acAll, authz.PermContentManage)
+	acReports := authz.WithPermission(acAll, authz.PermReportsView)
+
+	// Credential and token endpoints share a tighter per-IP quota
+	authLimited := []gin.HandlerFunc{rateLimiter.Strict()}
+
+	// Live streaming, meetings, forums and IAP can be switched off per subscription or plan
+	featureFlags := featureflag.NewService(db, logger)
+	featureFlagHandler := featureflag.NewHandler(logger, featureFlags)
+	featureflag.RegisterRoutes(api, featureFlagHandler, allUsers, superadminOnly)
+	if socketServer != nil {
+		socketServer.SetFeatureFlags(featureFlags)
+	}
+
 	pkg.RegisterRoutes(api, db, logger, superadminOnly)
-	subscription.RegisterRoutes(api, db, logger, streamClient, storageClient, adminOnly, adminStaff)
+	referralService := referral.NewService(db, logger)
+
+	// Purchases and subscriptions accept an Idempotency-Key, so retries from
+	// flaky mobile networks replay the first response instead of buying twice
+	idempotent := idempotency.NewService(db, logger).Middleware()
+
+	subscriptionHandler := subscription.NewHandler(db, logger, streamClient, storageClient)
+	subscriptionHandler.OnActivated(referralService.SubscriptionActivated)
+	subscriptionHandler.UseSettings(settingService)
+	subscription.RegisterRoutes(api, subscriptionHandler, adminOnly, adminStaff, acStaffWithInactive, idempotent)
+
+	tenantBackupHandler := tenantbackup.NewHandler(db, logger)
+	tenantBackupHandler.UseBunny(cfg.Bunny.Stream.LibraryID, cfg.Bunny.Storage.StorageZone, cfg.Bunny.Storage.CDNURL)
+	tenantbackup.RegisterRoutes(api, tenantBackupHandler, superadminOnly)
 
 	userHandler := user.NewHandler(db, logger)
-	user.RegisterRoutes(api, userHandler, adminStaff, allUsers)
+	userHandler.UseCache(queryCache)
+	userHandler.UseStorage(storageClient)
+	userHandler.UseSessionCloser(liveEvents)
+	user.RegisterRoutes(api, userHandler, adminStaff, allUsers, acStaff)
 
 	groupAccessHandler := groupaccess.NewHandler(db, logger)
 	groupaccess.RegisterRoutes(api, groupAccessHandler, acStaff)
 
-	authHandler := auth.NewHandler(db, logger, cfg, emailClient)
-	auth.RegisterRoutes(api, authHandler)
+	authHandler := auth.NewHandler(db, logger, cfg, emailQueue)
+	authHandler.UseCache(queryCache)
+	authHandler.UseSessionCloser(liveEvents)
+	auth.RegisterRoutes(api, authHandler, authLimited)
+
+	invitationHandler := invitation.NewHandler(db, logger, cfg)
+	invitation.RegisterRoutes(api, invitationHandler, acAdminInstructor, authLimited)
+
+	ssoHandler := sso.NewHandler(db, logger, cfg)
+	ssoHandler.UseCache(queryCache)
+	sso.RegisterRoutes(api, ssoHandler, acAdminInstructor, acAll, authLimited)
+
+	scimHandler := scim.NewHandler(db, logger)
+	scimHandler.UseCache(queryCache)
+	scimHandler.UseSessionCloser(liveEvents)
+	scim.RegisterRoutes(api, scimHandler, acAdminInstructor)
+
+	roleHandler := role.NewHandler(db, logger)
+	role.RegisterRoutes(api, roleHandler, acAdminInstructor)
 
 	courseHandler := course.NewHandler(db, logger, streamClient, storageClient)
-	course.RegisterRoutes(api, courseHandler, acStaff)
+	courseHandler.UseCache(queryCache)
+	course.RegisterRoutes(api, courseHandler, acAll, acContent)
 
 	storageUsageService := storageusage.NewService(db, logger, streamClient, storageClient, statsClient)
+	storageUsageService.UseNotifier(notificationService)
 
 	lessonHandler := lesson.NewHandler(db, logger, streamClient, storageClient, storageUsageService)
-	lesson.RegisterRoutes(api, lessonHandler, acAll, acStaff)
+	lessonHandler.UseCache(queryCache)
+	if cfg.Bunny.Stream.TusProxy {
+		lessonHandler.UseUploadProxy(liveEvents)
+	}
+	lesson.RegisterRoutes(api, lessonHandler, acAll, acContent)
+
+	bookmarkService := bookmark.NewService(db, logger, notificationService)
+	lessonHandler.OnPublished(bookmarkService.LessonPublished)
+	bookmarkHandler := bookmark.NewHandler(db, logger, storageClient)
+	bookmark.RegisterRoutes(api, bookmarkHandler, acAll)
+
+	lessonNoteHandler := lessonnote.NewHandler(db, logger)
+	lessonnote.RegisterRoutes(api, lessonNoteHandler, acAll)
+
+	lessonQAHandler := lessonqa.NewHandler(db, logger, notificationService)
+	lessonqa.RegisterRoutes(api, lessonQAHandler, acAll, acContent)
+
+	// Online members and the lessons they study are tracked from socket connections
+	presenceTracker := presence.NewTracker()
+	if socketServer != nil {
+		socketServer.SetPresence(presenceTracker)
+	}
+	presenceHandler := presence.NewHandler(db, logger, presenceTracker)
+	presence.RegisterRoutes(api, presenceHandler, acReports)
+
+	chapterHandler := chapter.NewHandler(db, logger)
+	chapter.RegisterRoutes(api, chapterHandler, acAll, acContent)
+
+	watchSessionHandler := watchsession.NewHandler(db, logger)
+	watchsession.RegisterRoutes(api, watchSessionHandler, acAllDuringGrace, acReports)
+
+	userWatchHandler := userwatch.NewHandler(db, logger)
+	userwatch.RegisterRoutes(api, userWatchHandler, acContent)
+
+	playbackHandler := playback.NewHandler(db, logger)
+	playback.RegisterRoutes(api, playbackHandler, acAdminInstructor)
+
+	downloadHandler := download.NewHandler(db, logger, streamClient)
+	download.RegisterRoutes(api, downloadHandler,
+		featureFlags.With(acAll, featureflag.OfflineDownloads), acContent, allUsers)
 
 	announcementHandler := announcement.NewHandler(db, logger)
+	announcementHandler.UseEvents(liveEvents)
 	announcement.RegisterRoutes(api, announcementHandler, acAll, acStaff, acAdminInstructor)
 
+	// Offline clients pull content changes since their last checkpoint
+	syncHandler := contentsync.NewHandler(db, logger, storageClient, cfg.Sync.TombstoneRetentionDays)
+	contentsync.RegisterRoutes(api, syncHandler, acAll)
+
 	paymentHandler := payment.NewHandler(db, logger)
-	payment.RegisterRoutes(api, paymentHandler, adminOnly)
+	payment.RegisterRoutes(api, paymentHandler, adminOnly, idempotent)
+
+	couponHandler := coupon.NewHandler(db, logger)
+	coupon.RegisterRoutes(api, couponHandler, adminOnly, allUsers)
+
+	// Recordings become lesson drafts once both the upload and the stream have finished
+	recordingService := streamrecording.NewService(db, logger, streamClient, storageUsageService)
+	if socketServer != nil {
+		socketServer.OnStreamEnded(recordingService.StreamEnded)
+	}
+	recordingHandler := streamrecording.NewHandler(db, logger, recordingService, streamcache.Global())
+	streamrecording.RegisterRoutes(api, recordingHandler, featureFlags.With(acContent, featureflag.LiveStreaming))
+
+	// Viewer history is persisted when a stream ends and reported back to its host
+	analyticsService := streamanalytics.NewService(db, logger)
+	if socketServer != nil {
+		socketServer.OnStreamEnded(analyticsService.StreamEnded)
+	}
+	analyticsHandler := streamanalytics.NewHandler(db, logger, streamcache.Global())
+	streamanalytics.RegisterRoutes(api, analyticsHandler, allUsers)
+
+	// Hosts moderate chat over the socket; banned words are configured per subscription
+	streamChatService := streamchat.NewService(db, logger)
+	if socketServer != nil {
+		socketServer.SetStreamChat(streamChatService)
+		socketServer.SetSettings(settingService)
+	}
+	streamChatHandler := streamchat.NewHandler(db, logger, streamChatService)
+	streamchat.RegisterRoutes(api, streamChatHandler, featureFlags.With(acStaff, featureflag.LiveStreaming))
+
+	notificationHandler := notification.NewHandler(db, logger)
+	notification.RegisterRoutes(api, notificationHandler, allUsers)
 
-	commentHandler := comment.NewHandler(db, logger)
+	// Clients without Socket.IO receive the same live events as server-sent events
+	eventStreamHandler := eventstream.NewHandler(eventStreams, logger)
+	eventstream.RegisterRoutes(api, eventStreamHandler, allUsers)
+
+	// Live comment updates are pushed to sockets that joined the lesson room
+	var lessonEvents comment.LessonBroadcaster
+	if socketServer != nil {
+		lessonEvents = socketServer
+	}
+	commentHandler := comment.NewHandler(db, logger, lessonEvents, notificationService)
 	comment.RegisterRoutes(api, commentHandler, acAll)
 
 	attachmentHandler := attachment.NewHandler(db, logger, storageClient, storageUsageService)
-	attachment.RegisterRoutes(api, attachmentHandler, acAll, acStaff)
+	attachmentHandler.UseNotifier(notificationService)
+	if cfg.ClamAV.Address != "" {
+		attachmentHandler.UseScanner(clamav.NewClient(cfg.ClamAV.Address, cfg.ClamAV.Timeout), cfg.ClamAV.DetectOnly, cfg.ClamAV.QuarantineDir)
+	}
+	attachment.RegisterRoutes(api, attachmentHandler, acAll, acContent)
 
 	forumHandler := forum.NewHandler(db, logger)
-	forum.RegisterRoutes(api, forumHandler, acAll, acStaff)
+	forum.RegisterRoutes(api, forumHandler, featureFlags.With(acAll, featureflag.Forums), featureFlags.With(acStaff, featureflag.Forums))
+
+	// Thread replies and read receipts are pushed to sockets that joined the thread
+	var threadEvents thread.ThreadBroadcaster
+	if socketServer != nil {
+		threadEvents = socketServer
+	}
+	threadHandler := thread.NewHandler(db, logger, threadEvents, notificationService)
+	thread.RegisterRoutes(api, threadHandler, featureFlags.With(acAll, featureflag.Forums), featureFlags.With(acStaff, featureflag.Forums))
 
-	threadHandler := thread.NewHandler(db, logger)
-	thread.RegisterRoutes(api, threadHandler, acAll, acStaff)
+	// Direct messages are delivered live to both members
+	messageHandler := message.NewHandler(db, logger, storageClient, liveEvents)
+	message.RegisterRoutes(api, messageHandler, acAll)
+
+	sessionHandler := scheduledsession.NewHandler(db, logger)
+	scheduledsession.RegisterRoutes(api, sessionHandler, acAll, acStaff, allUsers)
+
+	// Grades combine quiz attempts, assignment marks and scheduled session attendance
+	gradebookHandler := gradebook.NewHandler(db, logger)
+	gradebook.RegisterRoutes(api, gradebookHandler, acAll, acReports, acContent)
 
 	referralHandler := referral.NewHandler(db, logger)
-	referral.RegisterRoutes(api, referralHandler, referralAccess, adminOnly)
+	referral.RegisterRoutes(api, referralHandler, referralAccess, adminOnly, allUsers)
+
+	webhookHandler := webhook.NewHandler(db, logger)
+	webhook.RegisterRoutes(api, webhookHandler, acAdminInstructor)
+
+	classroomHandler := classroom.NewHandler(db, logger, cfg)
+	classroomHandler.UseCache(queryCache)
+	classroom.RegisterRoutes(api, classroomHandler, acAdminInstructor)
 
 	supportTicketHandler := supportticket.NewHandler(db, logger)
-	supportticket.RegisterRoutes(api, supportTicketHandler, acStaff, acAll)
+	supportticket.RegisterRoutes(api, supportTicketHandler, acStaff, acAllDuringGrace)
 
 	// Dashboard routes (admin/instructor/student dashboards)
 	dashboardHandler := dashboard.NewHandler(db, logger, meetingCache)
+	dashboardHandler.UseCache(queryCache)
+	dashboardHandler.UseStatistics(statsClient, cfg.Bunny.Stats)
+	if cfg.Log.LokiURL != "" {
+		dashboardHandler.UseLogStore(applog.NewLokiStore(cfg.Log.LokiURL, cfg.Log.LokiSelector))
+	}
 	dashboard.RegisterRoutes(api, dashboardHandler, acAdmin, acInstructorStaff, acAllWithInactive, superadminOnly)
 
+	maintenanceHandler := maintenance.NewHandler(logger, maintenanceService)
+	maintenance.RegisterRoutes(api, maintenanceHandler, superadminOnly)
+
+	settingHandler := setting.NewHandler(logger, settingService)
+	settingHandler.UseReloader(configWatcher.Reload)
+	setting.RegisterRoutes(api, settingHandler, superadminOnly)
+
+	// ICE servers with short-lived TURN credentials for meeting and stream peers
+	iceProvider := webrtcice.NewProvider(cfg.WebRTC.STUNURLs, cfg.WebRTC.TURNURLs, cfg.WebRTC.TURNSecret, time.Duration(cfg.WebRTC.TURNTTL)*time.Second)
+	webrtcHandler := webrtc.NewHandler(logger, iceProvider)
+	webrtc.RegisterRoutes(api, webrtcHandler, allUsers)
+
 	// Meeting routes (WebRTC meetings with cache)
-	meetingHandler := meeting.NewHandler(db, logger, meetingCache)
-	meeting.RegisterRoutes(api, meetingHandler, acStaff, acAll)
+	var meetingEvents meeting.Broadcaster
+	if socketServer != nil {
+		socketServer.SetMeetingCache(meetingCache)
+		socketServer.SetICEProvider(iceProvider)
+		meetingEvents = socketServer
+	}
+	meetingHandler := meeting.NewHandler(db, logger, meetingCache, meetingEvents, iceProvider)
+	meeting.RegisterRoutes(api, meetingHandler,
+		featureFlags.With(acStaff, featureflag.Meetings),
+		featureFlags.With(acAll, featureflag.Meetings),
+		featureFlags.With(acReports, featureflag.Meetings),
+	)
+
+	// Outbound email queue inspection and resend for platform admins
+	emailQueueHandler := emailqueue.NewHandler(db, logger)
+	emailqueue.RegisterRoutes(api, emailQueueHandler, adminOnly)
 
 	// Usage routes (Bunny CDN statistics)
 	usageHandler := usage.NewHandler(db, logger, storageUsageService)
@@ -150,12 +427,76 @@ func Register(engine *gin.Engine, cfg *config.Config, db *gorm.DB, logger *slog.
 			}
 		}
 
+		var googlePush *iap.GooglePushAuthenticator
+		if cfg.IAP.GooglePlay.Enabled {
+			// Google webhooks are rejected until push authentication is configured
+			auth, err := iap.NewGooglePushAuthenticator(cfg.IAP.GooglePlay.WebhookToken, cfg.IAP.GooglePlay.PushAudience, cfg.IAP.GooglePlay.PushServiceAccount)
+			if err != nil {
+				logger.Error("Google Play webhook authentication disabled", "error", err)
+			} else {
+				googlePush = auth
+			}
+		}
+
 		if cfg.IAP.AppStore.Enabled {
-			appleValidator = iap.NewAppStoreValidator(cfg.IAP.AppStore.SharedSecret, cfg.IAP.AppStore.UseSandbox)
-			logger.Info("App Store IAP enabled", "sandbox", cfg.IAP.AppStore.UseSandbox)
+			appStore := cfg.IAP.AppStore
+			appleValidator = iap.NewAppStoreValidator(appStore.SharedSecret, appStore.BundleID, appStore.UseSandbox)
+			logger.Info("App Store IAP enabled", "sandbox", appStore.UseSandbox)
+
+			// Signed notifications are rejected until the Apple root certificate is configured
+			verifier, err := iap.NewJWSVerifier(appStore.RootCertPath)
+			if err != nil {
+				logger.Error("App Store signature verification disabled", "error", err)
+			} else {
+				var serverClient *iap.AppStoreServerClient
+				if appStore.PrivateKeyPath != "" {
+					serverClient, err = newAppStoreServerClient(appStore, verifier)
+					if err != nil {
+						logger.Error("Failed to initialize App Store Server API client", "error", err)
+					}
+				}
+				appleValidator.UseSignedPayloads(verifier, serverClient)
+			}
 		}
 
 		iapHandler := iap.NewHandler(db, logger, googleValidator, appleValidator)
-		iap.RegisterRoutes(api, iapHandler, allUsers)
+		iapHandler.UseGooglePushAuthenticator(googlePush)
+		iapHandler.OnSubscriptionActivated(referralService.SubscriptionActivated)
+		iap.RegisterRoutes(api, iapHandler, featureFlags.With(allUsers, featureflag.IAP), superadminOnly, idempotent)
+	}
+
+	// OpenAPI spec generated from the route table above; must stay last so every
+	// feature route is included
+	registerOpenAPI(engine, cfg, logger)
+}
+
+func newAppStoreServerClient(cfg config.AppStoreConfig, verifier *iap.JWSVerifier) (*iap.AppStoreServerClient, error) {
+	privateKey, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read App Store private key: %w", err)
+	}
+	return iap.NewAppStoreServerClient(cfg.IssuerID, cfg.KeyID, cfg.BundleID, privateKey, cfg.UseSandbox, verifier)
+}
+
+func registerOpenAPI(engine *gin.Engine, cfg *config.Config, logger *slog.Logger) {
+	doc := openapi.Build(engine.Routes(), openapi.Options{
+		Info: openapi.Info{
+			Title:       "LMS Server API",
+			Version:     health.Version,
+			Description: "Responses use the standard envelope: success, message, data, pagination and error.",
+		},
+		Public:  []string{"/health", "/ready", "/version", "/metrics", APIPrefix + "/auth/", APIPrefix + "/maintenance/status", APIPrefix + "/invitations/", APIPrefix + "/iap/webhooks/", APIPrefix + "/calendar/", APIPrefix + "/integrations/google-classroom/callback"},
+		Exclude: []string{"/public/", "/socket.io/", "/debug/", APIPrefix + "/scim/v2/"},
+	})
+
+	openAPIHandler, err := openapi.NewHandler(doc)
+	if err != nil {
+		logger.Error("Failed to render OpenAPI spec", "error", err)
+		return
+	}
+
+	engine.GET("/openapi.json", openAPIHandler.Spec)
+	if !cfg.IsProduction() {
+		engine.GET("/docs/*file", openAPIHandler.UI("/openapi.json"))
 	}
 }
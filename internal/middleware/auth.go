@@ -24,9 +24,17 @@ type User struct {
 	FullName       string         `gorm:"column:full_name"`
 	UserType       types.UserType `gorm:"column:user_type"`
 	SubscriptionID *uuid.UUID     `gorm:"column:subscription_id"`
+	RoleID         *uuid.UUID     `gorm:"column:role_id"`
+	Active         bool           `gorm:"column:is_active"`
+	TokenVersion   int            `gorm:"column:token_version"`
+	Language       string         `gorm:"column:preferred_language"`
+	Timezone       string         `gorm:"column:timezone"`
 	Subscription   *Subscription  `gorm:"foreignKey:SubscriptionID"`
 	CreatedAt      time.Time      `gorm:"column:created_at"`
 	UpdatedAt      time.Time      `gorm:"column:updated_at"`
+
+	// Permissions granted by the user's custom subscription role, if any.
+	Permissions []string `gorm:"-"`
 }
 
 // TableName specifies the table name for the User model
@@ -34,11 +42,26 @@ func (User) TableName() string {
 	return "users"
 }
 
+// Location returns the user's preferred timezone, or UTC when it is unset or unknown.
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
 // Subscription represents a subscription in middleware context
 type Subscription struct {
-	ID             uuid.UUID `gorm:"column:id"`
-	Active         bool      `gorm:"column:is_active"`
-	IdentifierName string    `gorm:"column:identifier_name"`
+	ID                 uuid.UUID  `gorm:"column:id"`
+	Active             bool       `gorm:"column:is_active"`
+	IdentifierName     string     `gorm:"column:identifier_name"`
+	SubscriptionEnd    time.Time  `gorm:"column:subscription_end"`
+	GraceUntil         *time.Time `gorm:"column:grace_until"`
+	RateLimitPerMinute int        `gorm:"column:rate_limit_per_minute"`
 }
 
 // TableName specifies the table name for the Subscription model
@@ -46,14 +69,20 @@ func (Subscription) TableName() string {
 	return "subscriptions"
 }
 
+// InGrace reports whether the subscription has expired but is still inside its grace period.
+func (s Subscription) InGrace(now time.Time) bool {
+	return s.Active && s.GraceUntil != nil && now.After(s.SubscriptionEnd)
+}
+
 // Global instance to be initialized once at startup
 var global *AuthMiddleware
 
 // AuthMiddleware holds dependencies for authentication middleware
 type AuthMiddleware struct {
-	db        *gorm.DB
-	jwtSecret string
-	logger    *slog.Logger
+	db            *gorm.DB
+	jwtSecret     string
+	logger        *slog.Logger
+	tenantLimiter *TenantLimiter
 }
 
 // Initialize sets up the global middleware instance (call once at startup)
@@ -76,6 +105,9 @@ func NewAuthMiddleware(db *gorm.DB, jwtSecret string, logger *slog.Logger) *Auth
 
 type AccessControlOptions struct {
 	AllowInactiveSubscription bool
+	// AllowDuringGrace lets students use mutating endpoints while the subscription
+	// is in its post-expiry grace period, which is otherwise read-only for them.
+	AllowDuringGrace bool
 }
 
 // AuthenticateToken validates JWT tokens and loads user data into context.
@@ -110,7 +142,7 @@ func (m *AuthMiddleware) AuthorizeRoles(roles ...types.UserType) gin.HandlerFunc
 			}
 		}
 
-		response.ErrorWithLog(m.logger, c, http.StatusForbidden, "Access denied: Insufficient permissions.", nil)
+		response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeInsufficientPermissions, "Access denied: Insufficient permissions.", nil)
 		c.Abort()
 	}
 }
@@ -137,13 +169,13 @@ func (m *AuthMiddleware) AuthorizeSubscription(options ...AccessControlOptions)
 
 		subscriptionID := strings.TrimSpace(c.Param("subscriptionId"))
 		if subscriptionID == "" {
-			response.ErrorWithLog(m.logger, c, http.StatusForbidden, "Access denied: Invalid or inactive subscription.", nil)
+			response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeSubscriptionInactive, "Access denied: Invalid or inactive subscription.", nil)
 			c.Abort()
 			return
 		}
 
 		if usr.SubscriptionID == nil || !strings.EqualFold(usr.SubscriptionID.String(), subscriptionID) {
-			response.ErrorWithLog(m.logger, c, http.StatusForbidden, "Access denied: Invalid or inactive subscription.", nil)
+			response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeSubscriptionInactive, "Access denied: Invalid or inactive subscription.", nil)
 			c.Abort()
 			return
 		}
@@ -153,7 +185,7 @@ func (m *AuthMiddleware) AuthorizeSubscription(options ...AccessControlOptions)
 				m.logger.Error("Subscription is nil",
 					"user_id", usr.ID,
 					"subscription_id", usr.SubscriptionID)
-				response.ErrorWithLog(m.logger, c, http.StatusForbidden, "Access denied: Invalid or inactive subscription.", nil)
+				response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeSubscriptionInactive, "Access denied: Invalid or inactive subscription.", nil)
 				c.Abort()
 				return
 			}
@@ -163,12 +195,19 @@ func (m *AuthMiddleware) AuthorizeSubscription(options ...AccessControlOptions)
 					"subscription_id", usr.SubscriptionID,
 					"subscription_active", usr.Subscription.Active,
 					"subscription_identifier_name", usr.Subscription.IdentifierName)
-				response.ErrorWithLog(m.logger, c, http.StatusForbidden, "Access denied: Invalid or inactive subscription.", nil)
+				response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeSubscriptionInactive, "Access denied: Invalid or inactive subscription.", nil)
 				c.Abort()
 				return
 			}
 		}
 
+		if !opts.AllowDuringGrace && usr.UserType == types.UserTypeStudent && !isReadOnlyMethod(c.Request.Method) &&
+			usr.Subscription != nil && usr.Subscription.InGrace(time.Now()) {
+			response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeSubscriptionReadOnly, "Subscription expired: access is read-only until it is renewed.", nil)
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -194,7 +233,7 @@ func (m *AuthMiddleware) AccessControl(allowedRoles []types.UserType, options ..
 			}
 
 			if usr.UserType == types.UserTypeReferrer {
-				response.ErrorWithLog(m.logger, c, http.StatusForbidden, "Access denied: Referrer not allowed.", nil)
+				response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeInsufficientPermissions, "Access denied: Referrer not allowed.", nil)
 				c.Abort()
 				return
 			}
@@ -268,14 +307,14 @@ func (m *AuthMiddleware) ensureAuthenticated(c *gin.Context) (*User, bool) {
 
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		response.ErrorWithLog(m.logger, c, http.StatusUnauthorized, "No token provided", nil)
+		response.ErrorCodeWithLog(m.logger, c, http.StatusUnauthorized, CodeTokenMissing, "No token provided", nil)
 		c.Abort()
 		return nil, false
 	}
 
 	token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
 	if token == "" {
-		response.ErrorWithLog(m.logger, c, http.StatusUnauthorized, "No token provided", nil)
+		response.ErrorCodeWithLog(m.logger, c, http.StatusUnauthorized, CodeTokenMissing, "No token provided", nil)
 		c.Abort()
 		return nil, false
 	}
@@ -284,16 +323,16 @@ func (m *AuthMiddleware) ensureAuthenticated(c *gin.Context) (*User, bool) {
 	if err != nil {
 		switch {
 		case errors.Is(err, jwt.ErrExpiredToken):
-			response.ErrorWithLog(m.logger, c, http.StatusUnauthorized, "Token expired", err)
+			response.ErrorCodeWithLog(m.logger, c, http.StatusUnauthorized, CodeTokenExpired, "Token expired", err)
 		default:
-			response.ErrorWithLog(m.logger, c, http.StatusUnauthorized, "Invalid token", err)
+			response.ErrorCodeWithLog(m.logger, c, http.StatusUnauthorized, CodeTokenInvalid, "Invalid token", err)
 		}
 		c.Abort()
 		return nil, false
 	}
 
 	if claims.UserID == uuid.Nil {
-		response.ErrorWithLog(m.logger, c, http.StatusUnauthorized, "Invalid token payload", nil)
+		response.ErrorCodeWithLog(m.logger, c, http.StatusUnauthorized, CodeTokenInvalid, "Invalid token payload", nil)
 		c.Abort()
 		return nil, false
 	}
@@ -301,13 +340,13 @@ func (m *AuthMiddleware) ensureAuthenticated(c *gin.Context) (*User, bool) {
 	var usr User
 	if err := m.db.WithContext(c.Request.Context()).
 		Preload("Subscription", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "is_active", "identifier_name")
+			return db.Select("id", "is_active", "identifier_name", "subscription_end", "grace_until", "rate_limit_per_minute")
 		}).
 		Table("users").
 		First(&usr, "id = ?", claims.UserID).Error; err != nil {
 		switch {
 		case errors.Is(err, gorm.ErrRecordNotFound):
-			response.ErrorWithLog(m.logger, c, http.StatusNotFound, "User not found", err)
+			response.ErrorCodeWithLog(m.logger, c, http.StatusNotFound, CodeUserNotFound, "User not found", err)
 		default:
 			response.ErrorWithLog(m.logger, c, http.StatusInternalServerError, "Internal Server Error", err)
 		}
@@ -315,20 +354,59 @@ func (m *AuthMiddleware) ensureAuthenticated(c *gin.Context) (*User, bool) {
 		return nil, false
 	}
 
+	// Password changes and deactivation bump the version, revoking older tokens
+	if claims.TokenVersion != usr.TokenVersion {
+		response.ErrorCodeWithLog(m.logger, c, http.StatusUnauthorized, CodeTokenRevoked, "Token revoked", nil)
+		c.Abort()
+		return nil, false
+	}
+
+	// Admins are exempt, matching login
+	if !usr.Active && usr.UserType != types.UserTypeAdmin && usr.UserType != types.UserTypeSuperAdmin {
+		response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeAccountInactive, "Account is inactive", nil)
+		c.Abort()
+		return nil, false
+	}
+
 	if usr.UserType == types.UserTypeStudent {
 		if usr.Subscription == nil || !usr.Subscription.Active {
-			response.ErrorWithLog(m.logger, c, http.StatusForbidden, "User subscription not found or inactive", nil)
+			response.ErrorCodeWithLog(m.logger, c, http.StatusForbidden, CodeSubscriptionInactive, "User subscription not found or inactive", nil)
+			c.Abort()
+			return nil, false
+		}
+	}
+
+	if usr.RoleID != nil && usr.SubscriptionID != nil {
+		if err := m.db.WithContext(c.Request.Context()).
+			Table("role_permissions").
+			Joins("JOIN roles ON roles.id = role_permissions.role_id").
+			Where("roles.id = ? AND roles.subscription_id = ?", *usr.RoleID, *usr.SubscriptionID).
+			Pluck("role_permissions.permission", &usr.Permissions).Error; err != nil {
+			response.ErrorWithLog(m.logger, c, http.StatusInternalServerError, "Internal Server Error", err)
 			c.Abort()
 			return nil, false
 		}
 	}
 
+	if m.tenantLimiter != nil && !m.tenantLimiter.allowSubscription(c, &usr) {
+		return nil, false
+	}
+
 	usrCopy := usr
 	c.Set("user", &usrCopy)
 	c.Set("userId", usr.ID)
 	return &usrCopy, true
 }
 
+func isReadOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
 func containsRole(roles []types.UserType, target types.UserType) bool {
 	for _, role := range roles {
 		if role == target {
@@ -0,0 +1,16 @@
+package middleware
+
+import "github.com/mo-amir99/lms-server-go/pkg/response"
+
+// Codes of the errors the access middlewares answer with.
+const (
+	CodeTokenMissing            response.Code = "TOKEN_MISSING"
+	CodeTokenExpired            response.Code = "TOKEN_EXPIRED"
+	CodeTokenInvalid            response.Code = "TOKEN_INVALID"
+	CodeTokenRevoked            response.Code = "TOKEN_REVOKED"
+	CodeUserNotFound            response.Code = "USER_NOT_FOUND"
+	CodeAccountInactive         response.Code = "ACCOUNT_INACTIVE"
+	CodeInsufficientPermissions response.Code = "INSUFFICIENT_PERMISSIONS"
+	CodeSubscriptionInactive    response.Code = "SUBSCRIPTION_INACTIVE"
+	CodeSubscriptionReadOnly    response.Code = "SUBSCRIPTION_READ_ONLY"
+)
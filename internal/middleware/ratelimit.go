@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/internal/utils/jwt"
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	httpmiddleware "github.com/mo-amir99/lms-server-go/pkg/middleware"
+)
+
+// TenantLimiter enforces request quotas by who is calling rather than only by
+// address: anonymous requests per client IP, requests carrying an access token
+// per user, and authenticated subscription members per subscription.
+type TenantLimiter struct {
+	limiter   httpmiddleware.Limiter
+	jwtSecret string
+	limits    config.RateLimitConfig
+	// current, when set, supplies quotas that may change at runtime
+	current func() config.RateLimitConfig
+}
+
+// NewTenantLimiter constructs a limiter whose buckets live in store, which
+// should refill each bucket over one minute.
+func NewTenantLimiter(jwtSecret string, limits config.RateLimitConfig, store httpmiddleware.Limiter) *TenantLimiter {
+	return &TenantLimiter{
+		limiter:   store,
+		jwtSecret: jwtSecret,
+		limits:    limits,
+	}
+}
+
+// UseLimits makes the limiter read its quotas from limits on every request
+// instead of the ones it was constructed with.
+func (t *TenantLimiter) UseLimits(limits func() config.RateLimitConfig) {
+	t.current = limits
+}
+
+func (t *TenantLimiter) quotas() config.RateLimitConfig {
+	if t.current != nil {
+		return t.current()
+	}
+	return t.limits
+}
+
+// Middleware limits every request per user when it carries a valid access
+// token and per client IP otherwise. Subscription quotas are applied once the
+// user is authenticated, see UseTenantLimiter.
+func (t *TenantLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limits := t.quotas()
+		key, limit := "ip:"+c.ClientIP(), limits.IPPerMinute
+		if token := bearerToken(c); token != "" {
+			if claims, err := jwt.VerifyToken(token, t.jwtSecret); err == nil && claims.Purpose == "" {
+				key, limit = "user:"+claims.UserID.String(), limits.UserPerMinute
+			}
+		}
+
+		if !httpmiddleware.ApplyQuota(c, t.limiter.Take(key, limit)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Strict limits public endpoints that accept credentials or tokens per client
+// IP on their own, tighter quota, so guessing passwords or invitation tokens is
+// throttled well before the general anonymous quota.
+func (t *TenantLimiter) Strict() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !httpmiddleware.ApplyQuota(c, t.limiter.Take("auth:ip:"+c.ClientIP(), t.quotas().AuthPerMinute)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowSubscription counts an authenticated request against the quota its
+// subscription's plan sets, falling back to the platform default.
+func (t *TenantLimiter) allowSubscription(c *gin.Context, usr *User) bool {
+	if usr.SubscriptionID == nil || usr.Subscription == nil {
+		return true
+	}
+
+	limit := t.quotas().SubscriptionPerMinute
+	if usr.Subscription.RateLimitPerMinute > 0 {
+		limit = usr.Subscription.RateLimitPerMinute
+	}
+	return httpmiddleware.ApplyQuota(c, t.limiter.Take("subscription:"+usr.SubscriptionID.String(), limit))
+}
+
+// UseTenantLimiter makes authentication also enforce subscription quotas.
+// Call after Initialize.
+func UseTenantLimiter(limiter *TenantLimiter) {
+	if global == nil {
+		panic("middleware not initialized - call middleware.Initialize() first")
+	}
+	global.tenantLimiter = limiter
+}
+
+func bearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+}
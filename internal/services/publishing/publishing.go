@@ -0,0 +1,140 @@
+// Package publishing moves courses and lessons through review. Assistants
+// write drafts and submit them; instructors and admins approve submissions,
+// which publishes them to students, or send them back with a note.
+package publishing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Action is a review transition requested by staff.
+type Action string
+
+const (
+	// ActionSubmit sends a draft to review.
+	ActionSubmit Action = "submit"
+	// ActionApprove publishes a draft or submitted item.
+	ActionApprove Action = "approve"
+	// ActionReject returns a submitted item to draft with a note.
+	ActionReject Action = "reject"
+	// ActionUnpublish takes published content back to draft.
+	ActionUnpublish Action = "unpublish"
+)
+
+var (
+	ErrInvalidTransition = errors.New("content cannot make this transition from its current status")
+	ErrPublishForbidden  = errors.New("only instructors and admins can publish content")
+	ErrInvalidStatus     = errors.New("status must be draft, in_review or published")
+)
+
+// Request is the body of the review endpoints.
+type Request struct {
+	Action Action  `json:"action" binding:"required,oneof=submit approve reject unpublish"`
+	Note   *string `json:"note" binding:"omitnil,max=1000"`
+}
+
+// Next returns the status content in current moves to after action.
+// canPublish is whether the actor may approve and unpublish.
+func Next(current types.ContentStatus, action Action, canPublish bool) (types.ContentStatus, error) {
+	switch action {
+	case ActionSubmit:
+		if current == types.ContentStatusDraft {
+			return types.ContentStatusInReview, nil
+		}
+	case ActionApprove:
+		if !canPublish {
+			return "", ErrPublishForbidden
+		}
+		if current == types.ContentStatusDraft || current == types.ContentStatusInReview {
+			return types.ContentStatusPublished, nil
+		}
+	case ActionReject:
+		if !canPublish {
+			return "", ErrPublishForbidden
+		}
+		if current == types.ContentStatusInReview {
+			return types.ContentStatusDraft, nil
+		}
+	case ActionUnpublish:
+		if !canPublish {
+			return "", ErrPublishForbidden
+		}
+		if current == types.ContentStatusPublished {
+			return types.ContentStatusDraft, nil
+		}
+	}
+	return "", ErrInvalidTransition
+}
+
+// Initial resolves the status of new content. Without a requested status it is
+// published for those who may publish and a draft for everyone else.
+func Initial(requested *types.ContentStatus, canPublish bool) (types.ContentStatus, error) {
+	if requested == nil {
+		if canPublish {
+			return types.ContentStatusPublished, nil
+		}
+		return types.ContentStatusDraft, nil
+	}
+
+	switch *requested {
+	case types.ContentStatusDraft, types.ContentStatusInReview:
+		return *requested, nil
+	case types.ContentStatusPublished:
+		if !canPublish {
+			return "", ErrPublishForbidden
+		}
+		return *requested, nil
+	default:
+		return "", ErrInvalidStatus
+	}
+}
+
+// SubmittedBy returns actor when new content starts in review, which records
+// who submitted it, and nil otherwise.
+func SubmittedBy(status types.ContentStatus, actor uuid.UUID) *uuid.UUID {
+	if status != types.ContentStatusInReview {
+		return nil
+	}
+	return &actor
+}
+
+// ParseFilter validates a ?status= query value. An empty value means no filter.
+func ParseFilter(value string) (types.ContentStatus, bool) {
+	switch status := types.ContentStatus(value); status {
+	case "", types.ContentStatusDraft, types.ContentStatusInReview, types.ContentStatusPublished:
+		return status, true
+	}
+	return "", false
+}
+
+// Apply stores a transition on the row id of table. The update only matches
+// while the row is still in current, so two reviewers acting at once cannot
+// both apply; the loser gets ErrInvalidTransition.
+func Apply(db *gorm.DB, table string, id uuid.UUID, current, next types.ContentStatus, action Action, actor uuid.UUID, note *string) error {
+	updates := map[string]interface{}{
+		"status":     next,
+		"updated_at": time.Now().UTC(),
+	}
+	if action == ActionSubmit {
+		updates["submitted_by"] = actor
+		updates["review_note"] = nil
+	} else {
+		updates["reviewed_by"] = actor
+		updates["review_note"] = note
+	}
+
+	result := db.Table(table).Where("id = ? AND status = ?", id, current).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTransition
+	}
+	return nil
+}
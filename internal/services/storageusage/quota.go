@@ -0,0 +1,177 @@
+package storageusage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// quotaThresholds are the shares of CourseLimitInGB, in percent, at which
+// instructors are warned. Each is sent once until usage drops below it again.
+var quotaThresholds = []int{80, 95}
+
+// courseLookup is what a recalculation needs to know about the course.
+type courseLookup struct {
+	CourseID          uuid.UUID
+	CourseName        string
+	SubscriptionID    uuid.UUID
+	SubscriptionSlug  string
+	CollectionID      *string
+	StorageAlertLevel int
+	LimitGB           float64
+}
+
+// Snapshot is a course's storage usage as of the last recalculation on a day.
+type Snapshot struct {
+	CourseID        uuid.UUID `gorm:"type:uuid;primaryKey;column:course_id" json:"-"`
+	TakenOn         time.Time `gorm:"type:date;primaryKey;column:taken_on" json:"takenOn"`
+	StreamStorageGB float64   `gorm:"type:numeric(10,2);not null;default:0;column:stream_storage_gb" json:"streamStorageGB"`
+	FileStorageGB   float64   `gorm:"type:numeric(10,2);not null;default:0;column:file_storage_gb" json:"storageStorageGB"`
+	CoverStorageGB  float64   `gorm:"type:numeric(10,2);not null;default:0;column:cover_storage_gb" json:"coverStorageGB"`
+	TotalStorageGB  float64   `gorm:"type:numeric(10,2);not null;default:0;column:total_storage_gb" json:"totalStorageGB"`
+	LimitGB         float64   `gorm:"type:numeric(10,2);not null;default:0;column:limit_gb" json:"limitGB"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"-"`
+	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+// TableName overrides the default table name.
+func (Snapshot) TableName() string { return "course_storage_snapshots" }
+
+// Breakdown splits a course's stored usage by kind against its limit, with
+// the daily history of the last days.
+type Breakdown struct {
+	CourseID         uuid.UUID  `json:"courseId"`
+	StreamStorageGB  float64    `json:"streamStorageGB"`
+	FileStorageGB    float64    `json:"fileStorageGB"` // attachments and other files, covers excluded
+	CoverStorageGB   float64    `json:"coverStorageGB"`
+	TotalStorageGB   float64    `json:"totalStorageGB"`
+	LimitGB          float64    `json:"limitGB"`
+	UsedPercent      float64    `json:"usedPercent"`
+	Thresholds       []int      `json:"thresholds"`
+	History          []Snapshot `json:"history"`
+	LastCalculatedAt *time.Time `json:"lastCalculatedAt"`
+}
+
+// CourseBreakdown returns the stored usage of a course and its snapshots of
+// the last days, without querying Bunny.
+func (s *Service) CourseBreakdown(courseID uuid.UUID, days int) (Breakdown, error) {
+	var row struct {
+		course.Course
+		LimitGB float64
+	}
+	if err := s.db.Table("courses").
+		Select("courses.*, subscriptions.course_limit_in_gb AS limit_gb").
+		Joins("JOIN subscriptions ON subscriptions.id = courses.subscription_id").
+		Where("courses.id = ?", courseID).
+		Take(&row).Error; err != nil {
+		return Breakdown{}, err
+	}
+
+	breakdown := Breakdown{
+		CourseID:        courseID,
+		StreamStorageGB: row.StreamStorageGB,
+		FileStorageGB:   max(row.FileStorageGB-row.CoverStorageGB, 0),
+		CoverStorageGB:  row.CoverStorageGB,
+		TotalStorageGB:  row.StorageUsageInGB,
+		LimitGB:         row.LimitGB,
+		UsedPercent:     usedPercent(row.StorageUsageInGB, row.LimitGB),
+		Thresholds:      quotaThresholds,
+		History:         make([]Snapshot, 0),
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	if err := s.db.Where("course_id = ? AND taken_on >= ?", courseID, since.Format(time.DateOnly)).
+		Order("taken_on ASC").
+		Find(&breakdown.History).Error; err != nil {
+		return Breakdown{}, err
+	}
+	if n := len(breakdown.History); n > 0 {
+		breakdown.LastCalculatedAt = &breakdown.History[n-1].UpdatedAt
+	}
+
+	return breakdown, nil
+}
+
+// recordSnapshot keeps the latest recalculation of the day for the history.
+func (s *Service) recordSnapshot(lookup courseLookup, stats CourseStats) error {
+	snapshot := Snapshot{
+		CourseID:        lookup.CourseID,
+		TakenOn:         time.Now().UTC().Truncate(24 * time.Hour),
+		StreamStorageGB: stats.StreamStorageGB,
+		FileStorageGB:   stats.FileStorageGB,
+		CoverStorageGB:  stats.CoverStorageGB,
+		TotalStorageGB:  stats.TotalStorageGB,
+		LimitGB:         lookup.LimitGB,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "course_id"}, {Name: "taken_on"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"stream_storage_gb", "file_storage_gb", "cover_storage_gb", "total_storage_gb", "limit_gb", "updated_at",
+		}),
+	}).Create(&snapshot).Error
+}
+
+// checkQuota warns the subscription's admins and instructors the first time
+// usage crosses a threshold, and rearms lower thresholds once usage falls back.
+func (s *Service) checkQuota(lookup courseLookup, stats CourseStats) {
+	percent := usedPercent(stats.TotalStorageGB, lookup.LimitGB)
+	level := 0
+	for _, threshold := range quotaThresholds {
+		if percent >= float64(threshold) {
+			level = threshold
+		}
+	}
+	if level == lookup.StorageAlertLevel {
+		return
+	}
+
+	// Claim the level so concurrent recalculations alert once
+	result := s.db.Model(&course.Course{}).
+		Where("id = ? AND storage_alert_level = ?", lookup.CourseID, lookup.StorageAlertLevel).
+		Update("storage_alert_level", level)
+	if result.Error != nil {
+		s.logger.Warn("failed to update storage alert level", "courseId", lookup.CourseID, "error", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 || level < lookup.StorageAlertLevel || s.notifier == nil {
+		return
+	}
+
+	recipients, err := quotaRecipients(s.db, lookup.SubscriptionID)
+	if err != nil {
+		s.logger.Warn("failed to load storage alert recipients", "courseId", lookup.CourseID, "error", err)
+		return
+	}
+
+	s.notifier.Notify(recipients, notification.Notification{
+		SubscriptionID: &lookup.SubscriptionID,
+		CourseID:       &lookup.CourseID,
+		Type:           notification.TypeStorageQuota,
+		Title:          fmt.Sprintf("\"%s\" has used %d%% of its storage", lookup.CourseName, level),
+		Message: fmt.Sprintf("The course uses %.2f GB of its %.2f GB limit. Remove unused videos or files before uploads are blocked.",
+			stats.TotalStorageGB, lookup.LimitGB),
+	})
+}
+
+func quotaRecipients(db *gorm.DB, subscriptionID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Table("users").
+		Where("subscription_id = ? AND user_type IN ? AND is_active = ?", subscriptionID,
+			[]types.UserType{types.UserTypeAdmin, types.UserTypeInstructor}, true).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func usedPercent(used, limit float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return used / limit * 100
+}
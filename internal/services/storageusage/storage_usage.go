@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
 )
 
@@ -20,6 +21,7 @@ type Service struct {
 	streamClient  *bunny.StreamClient
 	storageClient *bunny.StorageClient
 	statsClient   *bunny.StatisticsClient
+	notifier      *notification.Service
 }
 
 // NewService builds a storage usage service instance.
@@ -27,11 +29,18 @@ func NewService(db *gorm.DB, logger *slog.Logger, streamClient *bunny.StreamClie
 	return &Service{db: db, logger: logger, streamClient: streamClient, storageClient: storageClient, statsClient: statsClient}
 }
 
+// UseNotifier sets the service that warns instructors when a course nears its storage limit.
+func (s *Service) UseNotifier(notifier *notification.Service) {
+	s.notifier = notifier
+}
+
 // CourseStats represents recalculated storage metrics for a course.
+// CoverStorageGB is the part of FileStorageGB taken by cover images.
 type CourseStats struct {
 	CourseID        uuid.UUID `json:"courseId"`
 	StreamStorageGB float64   `json:"streamStorageGB"`
 	FileStorageGB   float64   `json:"storageStorageGB"`
+	CoverStorageGB  float64   `json:"coverStorageGB"`
 	TotalStorageGB  float64   `json:"totalStorageGB"`
 }
 
@@ -47,15 +56,11 @@ type SystemStats struct {
 func (s *Service) UpdateCourseStorage(ctx context.Context, courseID uuid.UUID) (CourseStats, error) {
 	stats := CourseStats{CourseID: courseID}
 
-	var lookup struct {
-		CourseID         uuid.UUID
-		SubscriptionID   uuid.UUID
-		SubscriptionSlug string
-		CollectionID     *string
-	}
+	var lookup courseLookup
 
 	if err := s.db.Table("courses").
-		Select("courses.id as course_id, courses.subscription_id, courses.collection_id, subscriptions.identifier_name as subscription_slug").
+		Select("courses.id as course_id, courses.name as course_name, courses.subscription_id, courses.collection_id, "+
+			"courses.storage_alert_level, subscriptions.identifier_name as subscription_slug, subscriptions.course_limit_in_gb as limit_gb").
 		Joins("JOIN subscriptions ON subscriptions.id = courses.subscription_id").
 		Where("courses.id = ?", courseID).
 		Take(&lookup).Error; err != nil {
@@ -83,6 +88,13 @@ func (s *Service) UpdateCourseStorage(ctx context.Context, courseID uuid.UUID) (
 		} else {
 			stats.FileStorageGB = bytesToGB(bytes)
 		}
+
+		coversPath := storagePath + "/covers"
+		if bytes, err := s.storageClient.CalculateFolderSize(ctx, coversPath); err != nil {
+			s.logger.Warn("failed to fetch cover storage usage", "courseId", courseID, "path", coversPath, "error", err)
+		} else {
+			stats.CoverStorageGB = bytesToGB(bytes)
+		}
 	}
 
 	stats.TotalStorageGB = stats.StreamStorageGB + stats.FileStorageGB
@@ -92,11 +104,17 @@ func (s *Service) UpdateCourseStorage(ctx context.Context, courseID uuid.UUID) (
 		Updates(map[string]interface{}{
 			"stream_storage_gb":   stats.StreamStorageGB,
 			"file_storage_gb":     stats.FileStorageGB,
+			"cover_storage_gb":    stats.CoverStorageGB,
 			"storage_usage_in_gb": stats.TotalStorageGB,
 		}).Error; err != nil {
 		return stats, err
 	}
 
+	if err := s.recordSnapshot(lookup, stats); err != nil {
+		s.logger.Warn("failed to record course storage snapshot", "courseId", courseID, "error", err)
+	}
+	s.checkQuota(lookup, stats)
+
 	s.logger.Info("updated course storage", "courseId", courseID, "streamStorageGB", stats.StreamStorageGB, "fileStorageGB", stats.FileStorageGB, "totalStorageGB", stats.TotalStorageGB)
 
 	return stats, nil
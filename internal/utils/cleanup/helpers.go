@@ -9,7 +9,6 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
-	"github.com/mo-amir99/lms-server-go/internal/features/comment"
 	"github.com/mo-amir99/lms-server-go/internal/features/course"
 	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
 	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
@@ -66,14 +65,6 @@ func CleanupLesson(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 		return fmt.Errorf("failed to load lesson: %w", err)
 	}
 
-	// Delete all comments for this lesson
-	if err := db.Where("lesson_id = ?", lessonID).Delete(&comment.Comment{}).Error; err != nil {
-		logger.Error("failed to delete comments for lesson", "lessonId", lessonID, "error", err)
-	} else {
-		logger.Info("deleted comments for lesson", "lessonId", lessonID)
-	}
-
-	// Delete all attachments for this lesson
 	if len(les.Attachments) > 0 {
 		for _, att := range les.Attachments {
 			// Delete attachment files from Bunny Storage (background)
@@ -98,20 +89,9 @@ func CleanupLesson(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 				}(att.ID, path)
 			}
 		}
-
-		// Bulk delete attachments from database
-		attachmentIDs := make([]uuid.UUID, len(les.Attachments))
-		for i, att := range les.Attachments {
-			attachmentIDs[i] = att.ID
-		}
-		if err := db.Where("id IN ?", attachmentIDs).Delete(&attachment.Attachment{}).Error; err != nil {
-			logger.Error("failed to delete attachments for lesson", "lessonId", lessonID, "error", err)
-		} else {
-			logger.Info("deleted attachments for lesson", "lessonId", lessonID, "count", len(attachmentIDs))
-		}
 	}
 
-	// Delete lesson from database
+	// Delete lesson from database; its attachments and comments go with it
 	if err := lesson.Delete(db, lessonID); err != nil {
 		return fmt.Errorf("failed to delete lesson from database: %w", err)
 	}
@@ -158,7 +138,6 @@ func CleanupCourse(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 
 	// Collect all video IDs and attachment info
 	var videoIDs []string
-	var attachmentIDs []uuid.UUID
 
 	for _, les := range lessons {
 		if les.VideoID != "" {
@@ -173,8 +152,6 @@ func CleanupCourse(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 		}
 
 		for _, att := range attachments {
-			attachmentIDs = append(attachmentIDs, att.ID)
-
 			// Delete attachment files from Bunny Storage (background)
 			fileTypes := []string{"pdf", "audio", "image"}
 			isFileType := false
@@ -199,42 +176,7 @@ func CleanupCourse(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 		}
 	}
 
-	// Delete all comments for lessons in this course
-	if len(lessons) > 0 {
-		lessonIDs := make([]uuid.UUID, len(lessons))
-		for i, les := range lessons {
-			lessonIDs[i] = les.ID
-		}
-		if err := db.Where("lesson_id IN ?", lessonIDs).Delete(&comment.Comment{}).Error; err != nil {
-			logger.Error("failed to delete comments for course lessons", "courseId", courseID, "error", err)
-		} else {
-			logger.Info("deleted comments for course lessons", "courseId", courseID)
-		}
-	}
-
-	// Bulk delete all attachments
-	if len(attachmentIDs) > 0 {
-		if err := db.Where("id IN ?", attachmentIDs).Delete(&attachment.Attachment{}).Error; err != nil {
-			logger.Error("failed to delete attachments for course", "courseId", courseID, "error", err)
-		} else {
-			logger.Info("deleted attachments for course", "courseId", courseID, "count", len(attachmentIDs))
-		}
-	}
-
-	// Bulk delete all lessons
-	if len(lessons) > 0 {
-		lessonIDs := make([]uuid.UUID, len(lessons))
-		for i, les := range lessons {
-			lessonIDs[i] = les.ID
-		}
-		if err := db.Where("id IN ?", lessonIDs).Delete(&lesson.Lesson{}).Error; err != nil {
-			logger.Error("failed to delete lessons for course", "courseId", courseID, "error", err)
-		} else {
-			logger.Info("deleted lessons for course", "courseId", courseID, "count", len(lessonIDs))
-		}
-	}
-
-	// Delete course from database
+	// Delete course from database; its lessons, attachments and comments go with it
 	if err := course.Delete(db, courseID); err != nil {
 		return fmt.Errorf("failed to delete course from database: %w", err)
 	}
@@ -16,6 +16,9 @@ var (
 type Claims struct {
 	UserID  uuid.UUID `json:"id"`
 	Purpose string    `json:"purpose,omitempty"`
+	// TokenVersion is the user's token version at issue time; bumping the
+	// stored version revokes every token issued before.
+	TokenVersion int `json:"ver,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -25,9 +28,10 @@ type TokenPair struct {
 }
 
 // GenerateAccessToken creates a short-lived JWT for API access.
-func GenerateAccessToken(userID uuid.UUID, secret string, expiry time.Duration) (string, error) {
+func GenerateAccessToken(userID uuid.UUID, tokenVersion int, secret string, expiry time.Duration) (string, error) {
 	claims := Claims{
-		UserID: userID,
+		UserID:       userID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -39,9 +43,10 @@ func GenerateAccessToken(userID uuid.UUID, secret string, expiry time.Duration)
 }
 
 // GenerateRefreshToken creates a long-lived JWT for token refresh.
-func GenerateRefreshToken(userID uuid.UUID, secret string, expiry time.Duration) (string, error) {
+func GenerateRefreshToken(userID uuid.UUID, tokenVersion int, secret string, expiry time.Duration) (string, error) {
 	claims := Claims{
-		UserID: userID,
+		UserID:       userID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,337 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/mo-amir99/lms-server-go/pkg/metrics"
+)
+
+// ErrCircuitOpen is returned when the Bunny API circuit breaker is rejecting calls.
+var ErrCircuitOpen = errors.New("bunny API circuit breaker is open")
+
+// ErrNotFound is wrapped by deletes of files and videos that no longer exist,
+// so callers retrying a cleanup can treat them as done.
+var ErrNotFound = errors.New("bunny resource not found")
+
+// RetryPolicy controls how transient Bunny API failures are retried.
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts including the first one
+	BaseDelay      time.Duration // initial backoff, doubled per attempt
+	MaxDelay       time.Duration // upper bound for a single backoff
+	AttemptTimeout time.Duration // per-attempt timeout for body-less calls (0 disables)
+
+	FailureThreshold int           // consecutive failures before the breaker opens
+	OpenDuration     time.Duration // how long the breaker stays open before probing
+}
+
+// DefaultRetryPolicy returns the policy used by the Bunny clients.
+func DefaultRetryPolicy(attemptTimeout time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:      3,
+		BaseDelay:        200 * time.Millisecond,
+		MaxDelay:         3 * time.Second,
+		AttemptTimeout:   attemptTimeout,
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// resilientTransport wraps an http.RoundTripper with retries, backoff and a circuit breaker.
+// Only idempotent methods with a replayable body are retried; POST calls (collection/video
+// creation) are attempted once so a slow-but-successful request never creates duplicates.
+type resilientTransport struct {
+	name    string
+	base    http.RoundTripper
+	policy  RetryPolicy
+	breaker *circuitBreaker
+}
+
+func newResilientTransport(name string, policy RetryPolicy) *resilientTransport {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	// Each attempt is traced as its own client span so retries are visible
+	base := otelhttp.NewTransport(http.DefaultTransport,
+		otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+			return "bunny " + name + " " + req.Method
+		}),
+	)
+
+	return &resilientTransport{
+		name:    name,
+		base:    base,
+		policy:  policy,
+		breaker: newCircuitBreaker(name, policy.FailureThreshold, policy.OpenDuration),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		metrics.RecordBunnyRequest(t.name, req.Method, "rejected")
+		return nil, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	attempts := 1
+	if isRetryable(req) {
+		attempts = t.policy.MaxAttempts
+	}
+
+	var resp *http.Response
+	var err error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			metrics.RecordBunnyRetry(t.name, req.Method)
+			waitErr := sleepContext(req.Context(), t.backoff(attempt-1, resp))
+			if resp != nil {
+				drainAndClose(resp.Body)
+				resp = nil
+			}
+			if waitErr != nil {
+				err = waitErr
+				break
+			}
+		}
+
+		resp, err = t.attempt(req)
+		if !shouldRetry(resp, err) || req.Context().Err() != nil {
+			break
+		}
+	}
+
+	var outcome string
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// Caller gave up; this says nothing about Bunny's health.
+		t.breaker.release()
+		outcome = "canceled"
+	case err != nil || isServerFailure(resp):
+		t.breaker.recordFailure()
+		outcome = "failure"
+	default:
+		t.breaker.recordSuccess()
+		outcome = "success"
+	}
+	metrics.RecordBunnyRequest(t.name, req.Method, outcome)
+	metrics.RecordBunnyLatency(t.name, req.Method, outcome, time.Since(start))
+
+	return resp, err
+}
+
+func (t *resilientTransport) attempt(req *http.Request) (*http.Response, error) {
+	outgoing := req
+	var cancel context.CancelFunc
+
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		outgoing = req.Clone(req.Context())
+		outgoing.Body = body
+	}
+
+	if t.policy.AttemptTimeout > 0 && (req.Body == nil || req.Body == http.NoBody) {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.policy.AttemptTimeout)
+		outgoing = outgoing.WithContext(ctx)
+	}
+
+	resp, err := t.base.RoundTrip(outgoing)
+	if cancel != nil {
+		if err != nil {
+			cancel()
+		} else {
+			// Keep the attempt context alive until the caller finishes reading the body.
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		}
+	}
+
+	return resp, err
+}
+
+// backoff computes a full-jitter exponential delay, honouring Retry-After on 429/503.
+func (t *resilientTransport) backoff(retry int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			delay := time.Duration(seconds) * time.Second
+			if delay > t.policy.MaxDelay {
+				delay = t.policy.MaxDelay
+			}
+			return delay
+		}
+	}
+
+	ceiling := t.policy.BaseDelay << uint(retry-1)
+	if ceiling <= 0 || ceiling > t.policy.MaxDelay {
+		ceiling = t.policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+	// Streamed bodies (file uploads) cannot be replayed.
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || isServerFailure(resp)
+}
+
+func isServerFailure(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode >= http.StatusInternalServerError
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func drainAndClose(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	_ = body.Close()
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// circuitBreaker is a consecutive-failure breaker with a single half-open probe.
+type circuitBreaker struct {
+	name         string
+	threshold    int
+	openDuration time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(name string, threshold int, openDuration time.Duration) *circuitBreaker {
+	cb := &circuitBreaker{
+		name:         name,
+		threshold:    threshold,
+		openDuration: openDuration,
+	}
+	metrics.SetBunnyCircuitState(name, int(breakerClosed))
+	return cb
+}
+
+func (cb *circuitBreaker) allow() bool {
+	if cb.threshold <= 0 {
+		return true
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < cb.openDuration {
+			return false
+		}
+		cb.setState(breakerHalfOpen)
+		cb.probing = true
+		return true
+	case breakerHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.probing = false
+	if cb.state != breakerClosed {
+		cb.setState(breakerClosed)
+	}
+}
+
+// release frees a half-open probe slot without changing the breaker state.
+func (cb *circuitBreaker) release() {
+	cb.mu.Lock()
+	cb.probing = false
+	cb.mu.Unlock()
+}
+
+func (cb *circuitBreaker) recordFailure() {
+	if cb.threshold <= 0 {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.probing = false
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = time.Now()
+		cb.setState(breakerOpen)
+	}
+}
+
+func (cb *circuitBreaker) setState(state breakerState) {
+	cb.state = state
+	metrics.SetBunnyCircuitState(cb.name, int(state))
+}
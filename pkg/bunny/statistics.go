@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
@@ -29,7 +30,8 @@ func NewStatisticsClient(baseURL, apiKey string) *StatisticsClient {
 		baseURL: trimmedBaseURL,
 		apiKey:  strings.TrimSpace(apiKey),
 		httpClient: &http.Client{
-			Timeout: 15 * time.Second,
+			Timeout:   15 * time.Second,
+			Transport: newResilientTransport("statistics", DefaultRetryPolicy(10*time.Second)),
 		},
 	}
 }
@@ -101,3 +103,98 @@ func (c *StatisticsClient) BandwidthUsage(ctx context.Context, from, to time.Tim
 	summary.TotalBandwidthBytes = int64(totalBytes)
 	return summary, nil
 }
+
+// TrafficSummary is the CDN traffic of one pull zone for a time range.
+type TrafficSummary struct {
+	PullZoneID     int64          `json:"pullZoneId"`
+	BandwidthBytes int64          `json:"bandwidthBytes"`
+	Requests       int64          `json:"requests"`
+	CacheHitRate   float64        `json:"cacheHitRate"`
+	Daily          []TrafficPoint `json:"daily"`
+	RangeStart     time.Time      `json:"rangeStart"`
+	RangeEnd       time.Time      `json:"rangeEnd"`
+}
+
+// TrafficPoint is the traffic served on one day.
+type TrafficPoint struct {
+	Date           time.Time `json:"date"`
+	BandwidthBytes int64     `json:"bandwidthBytes"`
+	Requests       int64     `json:"requests"`
+}
+
+// PullZoneTraffic fetches bandwidth and request counts of a pull zone between
+// two timestamps, with a daily breakdown.
+func (c *StatisticsClient) PullZoneTraffic(ctx context.Context, pullZoneID int64, from, to time.Time) (TrafficSummary, error) {
+	if from.After(to) {
+		from, to = to, from
+	}
+	summary := TrafficSummary{PullZoneID: pullZoneID, RangeStart: from, RangeEnd: to, Daily: make([]TrafficPoint, 0)}
+
+	if c == nil {
+		return summary, fmt.Errorf("statistics client is not configured")
+	}
+	if strings.TrimSpace(c.apiKey) == "" {
+		return summary, fmt.Errorf("bunny statistics API key is missing")
+	}
+
+	params := url.Values{}
+	params.Set("dateFrom", from.UTC().Format(time.RFC3339))
+	params.Set("dateTo", to.UTC().Format(time.RFC3339))
+	params.Set("pullZone", fmt.Sprint(pullZoneID))
+
+	endpoint := fmt.Sprintf("%s/statistics?%s", c.baseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return summary, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("AccessKey", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "LMS-Server-Go/1.0.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return summary, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return summary, fmt.Errorf("bunny statistics error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var payload struct {
+		TotalBandwidthUsed  float64            `json:"TotalBandwidthUsed"`
+		TotalRequestsServed float64            `json:"TotalRequestsServed"`
+		CacheHitRate        float64            `json:"CacheHitRate"`
+		BandwidthUsedChart  map[string]float64 `json:"BandwidthUsedChart"`
+		RequestsServedChart map[string]float64 `json:"RequestsServedChart"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return summary, fmt.Errorf("failed to decode statistics response: %w", err)
+	}
+
+	summary.BandwidthBytes = int64(payload.TotalBandwidthUsed)
+	summary.Requests = int64(payload.TotalRequestsServed)
+	summary.CacheHitRate = payload.CacheHitRate
+
+	// Both charts are keyed by the same timestamps
+	for key, bandwidth := range payload.BandwidthUsedChart {
+		date, err := time.Parse(time.RFC3339, key)
+		if err != nil {
+			date, err = time.Parse("2006-01-02T15:04:05", key)
+			if err != nil {
+				continue
+			}
+		}
+		summary.Daily = append(summary.Daily, TrafficPoint{
+			Date:           date.UTC(),
+			BandwidthBytes: int64(bandwidth),
+			Requests:       int64(payload.RequestsServedChart[key]),
+		})
+	}
+	sort.Slice(summary.Daily, func(i, j int) bool { return summary.Daily[i].Date.Before(summary.Daily[j].Date) })
+
+	return summary, nil
+}
@@ -4,34 +4,43 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 // StorageClient handles Bunny Storage (CDN) operations.
 type StorageClient struct {
-	zoneName   string
-	password   string
-	baseURL    string
-	hostname   string
-	httpClient *http.Client
+	zoneName       string
+	password       string
+	baseURL        string
+	hostname       string
+	tokenKey       string
+	tokenExpiresIn int
+	httpClient     *http.Client
 }
 
 // NewStorageClient creates a new Bunny Storage client.
-func NewStorageClient(zoneName, password, baseURL, hostname string) *StorageClient {
+// tokenKey is the pull zone URL token authentication key; when empty, SignedURL returns plain public URLs.
+func NewStorageClient(zoneName, password, baseURL, hostname, tokenKey string, tokenExpiresIn int) *StorageClient {
 	return &StorageClient{
-		zoneName: zoneName,
-		password: password,
-		baseURL:  baseURL,
-		hostname: hostname,
+		zoneName:       zoneName,
+		password:       password,
+		baseURL:        baseURL,
+		hostname:       hostname,
+		tokenKey:       tokenKey,
+		tokenExpiresIn: tokenExpiresIn,
 		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // Increased for large audio/video files
+			Timeout:   5 * time.Minute, // Increased for large audio/video files
+			Transport: newResilientTransport("storage", DefaultRetryPolicy(30*time.Second)),
 		},
 	}
 }
@@ -153,6 +162,38 @@ func (c *StorageClient) UploadStream(ctx context.Context, remotePath string, rea
 	return publicURL, nil
 }
 
+// UploadSizedStream uploads a stream of known length to Bunny Storage.
+// Setting the content length avoids chunked transfer encoding for large assembled uploads.
+func (c *StorageClient) UploadSizedStream(ctx context.Context, remotePath string, reader io.Reader, size int64, contentType string) (string, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	url := fmt.Sprintf("%s/%s/%s", c.baseURL, c.zoneName, remotePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+
+	req.Header.Set("AccessKey", c.password)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "LMS-Server-Go/1.0.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bunny storage error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return c.GetPublicURL(remotePath), nil
+}
+
 // DeleteFile deletes a file from Bunny Storage.
 func (c *StorageClient) DeleteFile(ctx context.Context, remotePath string) error {
 	url := fmt.Sprintf("%s/%s/%s", c.baseURL, c.zoneName, remotePath)
@@ -170,6 +211,9 @@ func (c *StorageClient) DeleteFile(ctx context.Context, remotePath string) error
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, remotePath)
+	}
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("bunny storage error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
@@ -178,11 +222,135 @@ func (c *StorageClient) DeleteFile(ctx context.Context, remotePath string) error
 	return nil
 }
 
-// DeleteFolder deletes a folder and all its contents from Bunny Storage.
+// maxConcurrentDeletes bounds parallel DELETE calls issued by DeleteFolder.
+const maxConcurrentDeletes = 8
+
+// FolderDeleteError reports the paths that could not be removed by DeleteFolder.
+type FolderDeleteError struct {
+	FolderPath string
+	Deleted    int
+	Failed     map[string]error
+}
+
+func (e *FolderDeleteError) Error() string {
+	paths := make([]string, 0, len(e.Failed))
+	for path := range e.Failed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	const maxListed = 5
+	listed := paths
+	if len(listed) > maxListed {
+		listed = listed[:maxListed]
+	}
+
+	return fmt.Sprintf("failed to delete %d item(s) under %q (deleted %d): %s",
+		len(e.Failed), e.FolderPath, e.Deleted, strings.Join(listed, ", "))
+}
+
+// DeleteFolder recursively deletes a folder and all its contents from Bunny Storage.
+// Files are deleted in parallel (bounded by maxConcurrentDeletes), then the emptied
+// directories are removed deepest-first. When some items fail, the remaining items are
+// still processed and a *FolderDeleteError describing the failures is returned.
 func (c *StorageClient) DeleteFolder(ctx context.Context, folderPath string) error {
-	// List all files in folder first, then delete each
-	// For now, just delete the folder path directly
-	return c.DeleteFile(ctx, folderPath)
+	root := strings.Trim(folderPath, "/")
+	if root == "" {
+		return fmt.Errorf("refusing to delete storage zone root")
+	}
+
+	files, dirs, err := c.collectFolderTree(ctx, root)
+	if err != nil {
+		return fmt.Errorf("failed to list folder %q: %w", root, err)
+	}
+
+	result := &FolderDeleteError{FolderPath: root, Failed: make(map[string]error)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentDeletes)
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			mu.Lock()
+			result.Failed[file] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := c.DeleteFile(ctx, path)
+			mu.Lock()
+			if err != nil {
+				result.Failed[path] = err
+			} else {
+				result.Deleted++
+			}
+			mu.Unlock()
+		}(file)
+	}
+	wg.Wait()
+
+	// Directories were collected parent-first; remove children before their parents.
+	// A directory whose contents failed to delete is skipped to keep errors meaningful.
+	dirs = append([]string{root}, dirs...)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		if hasFailedDescendant(result.Failed, dir) {
+			continue
+		}
+		if err := c.DeleteFile(ctx, dir+"/"); err != nil {
+			result.Failed[dir+"/"] = err
+			continue
+		}
+		result.Deleted++
+	}
+
+	if len(result.Failed) > 0 {
+		return result
+	}
+	return nil
+}
+
+// collectFolderTree walks folderPath and returns every file path and sub-directory path (parent-first).
+func (c *StorageClient) collectFolderTree(ctx context.Context, folderPath string) ([]string, []string, error) {
+	items, err := c.ListFiles(ctx, folderPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files, dirs []string
+	for _, item := range items {
+		itemPath := joinStoragePaths(folderPath, item.ObjectName)
+		if !item.IsDirectory {
+			files = append(files, itemPath)
+			continue
+		}
+
+		dirs = append(dirs, itemPath)
+		subFiles, subDirs, err := c.collectFolderTree(ctx, itemPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, subFiles...)
+		dirs = append(dirs, subDirs...)
+	}
+
+	return files, dirs, nil
+}
+
+func hasFailedDescendant(failed map[string]error, dir string) bool {
+	prefix := dir + "/"
+	for path := range failed {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetPublicURL constructs the public CDN URL for a file.
@@ -190,6 +358,69 @@ func (c *StorageClient) GetPublicURL(remotePath string) string {
 	return fmt.Sprintf("https://%s/%s", c.hostname, remotePath)
 }
 
+// TokenAuthEnabled reports whether CDN token authentication is configured.
+func (c *StorageClient) TokenAuthEnabled() bool {
+	return strings.TrimSpace(c.tokenKey) != ""
+}
+
+// SignedURL returns a Bunny CDN token-authenticated URL for a stored file.
+// Accepts either a relative storage path or a full CDN URL. When token authentication
+// is not configured the plain public URL is returned, and foreign URLs are returned unchanged.
+func (c *StorageClient) SignedURL(pathOrURL string) string {
+	trimmed := strings.TrimSpace(pathOrURL)
+	if trimmed == "" {
+		return trimmed
+	}
+
+	relativePath := c.ExtractRelativePath(trimmed)
+	if strings.HasPrefix(relativePath, "http://") || strings.HasPrefix(relativePath, "https://") {
+		// Not served from our pull zone (e.g. external links)
+		return trimmed
+	}
+
+	if !c.TokenAuthEnabled() {
+		return c.GetPublicURL(relativePath)
+	}
+
+	expiration := time.Now().Unix() + c.tokenLifetime()
+
+	return c.signPath(relativePath, expiration)
+}
+
+// SigningEpoch identifies the current half-lifetime window of signed URLs, or 0
+// when URLs are not signed. Responses built in the same window carry URLs with at
+// least half their lifetime left, so it can be folded into ETags of such responses.
+func (c *StorageClient) SigningEpoch() int64 {
+	if !c.TokenAuthEnabled() {
+		return 0
+	}
+	window := c.tokenLifetime() / 2
+	if window <= 0 {
+		window = 1
+	}
+	return time.Now().Unix() / window
+}
+
+func (c *StorageClient) tokenLifetime() int64 {
+	if c.tokenExpiresIn <= 0 {
+		return 3600
+	}
+	return int64(c.tokenExpiresIn)
+}
+
+// signPath builds the token URL using Bunny's SHA256 scheme:
+// Base64URL(SHA256(tokenKey + "/" + path + expires)) with padding stripped.
+func (c *StorageClient) signPath(relativePath string, expiration int64) string {
+	urlPath := "/" + strings.TrimLeft(relativePath, "/")
+
+	stringToSign := fmt.Sprintf("%s%s%d", c.tokenKey, urlPath, expiration)
+	hash := sha256.Sum256([]byte(stringToSign))
+	token := base64.StdEncoding.EncodeToString(hash[:])
+	token = strings.NewReplacer("+", "-", "/", "_", "=", "").Replace(token)
+
+	return fmt.Sprintf("https://%s%s?token=%s&expires=%d", c.hostname, urlPath, token, expiration)
+}
+
 // ExtractRelativePath extracts the relative storage path from a full CDN URL.
 // For example, converts "https://elites-academy.b-cdn.net/test-sub/course-id/file.pdf"
 // to "test-sub/course-id/file.pdf"
@@ -37,7 +37,8 @@ func NewStreamClient(libraryID, apiKey, baseURL, securityKey, deliveryURL string
 		deliveryURL: deliveryURL,
 		expiresIn:   expiresIn,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newResilientTransport("stream", DefaultRetryPolicy(15*time.Second)),
 		},
 	}
 }
@@ -289,6 +290,9 @@ func (c *StreamClient) DeleteVideo(ctx context.Context, videoID string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: video %s", ErrNotFound, videoID)
+	}
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("bunny API error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
@@ -299,12 +303,15 @@ func (c *StreamClient) DeleteVideo(ctx context.Context, videoID string) error {
 
 // GetVideoStatus retrieves the processing status of a video.
 type VideoStatus struct {
-	GUID           string  `json:"guid"`
-	Title          string  `json:"title"`
-	Status         int     `json:"status"` // 0=queued, 1=processing, 2=encoding, 3=finished, 4=resolution_finished, 5=failed
-	AvgWatchTime   float64 `json:"averageWatchTime"`
-	TotalWatchTime float64 `json:"totalWatchTime"`
-	Views          int     `json:"views"`
+	GUID              string  `json:"guid"`
+	Title             string  `json:"title"`
+	Status            int     `json:"status"` // 0=queued, 1=processing, 2=encoding, 3=finished, 4=resolution_finished, 5=failed
+	AvgWatchTime      float64 `json:"averageWatchTime"`
+	TotalWatchTime    float64 `json:"totalWatchTime"`
+	Views             int     `json:"views"`
+	Length            int     `json:"length"` // seconds
+	ThumbnailCount    int     `json:"thumbnailCount"`
+	ThumbnailFileName string  `json:"thumbnailFileName"`
 }
 
 func (c *StreamClient) GetVideoStatus(ctx context.Context, videoID string) (*VideoStatus, error) {
@@ -336,39 +343,121 @@ func (c *StreamClient) GetVideoStatus(ctx context.Context, videoID string) (*Vid
 	return &status, nil
 }
 
-// SignedVideoURL generates a signed Bunny Stream playlist URL matching the legacy Node implementation.
-func (c *StreamClient) SignedVideoURL(videoID string) (string, error) {
-	if strings.TrimSpace(videoID) == "" {
-		return "", fmt.Errorf("videoID is required")
+// VideoThumbnail describes a thumbnail image available for a Bunny Stream video.
+type VideoThumbnail struct {
+	Index     int    `json:"index"` // 0 is the current default thumbnail, 1..N are generated preview frames
+	URL       string `json:"url"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// ListThumbnails returns the current thumbnail plus every preview frame Bunny generated for the video.
+func (c *StreamClient) ListThumbnails(ctx context.Context, videoID string) ([]VideoThumbnail, error) {
+	status, err := c.GetVideoStatus(ctx, videoID)
+	if err != nil {
+		return nil, err
 	}
-	if strings.TrimSpace(c.securityKey) == "" || strings.TrimSpace(c.deliveryURL) == "" {
-		return "", fmt.Errorf("bunny stream signing configuration is missing")
+
+	base := c.deliveryBaseURL()
+	if base == "" {
+		return nil, fmt.Errorf("bunny stream delivery URL is not configured")
+	}
+
+	defaultFile := status.ThumbnailFileName
+	if defaultFile == "" {
+		defaultFile = "thumbnail.jpg"
+	}
+
+	thumbnails := []VideoThumbnail{{
+		Index:     0,
+		URL:       fmt.Sprintf("%s/%s/%s", base, videoID, defaultFile),
+		IsDefault: true,
+	}}
+
+	for i := 1; i <= status.ThumbnailCount; i++ {
+		thumbnails = append(thumbnails, VideoThumbnail{
+			Index: i,
+			URL:   fmt.Sprintf("%s/%s/thumbnail_%d.jpg", base, videoID, i),
+		})
+	}
+
+	return thumbnails, nil
+}
+
+// SetThumbnail makes the given image URL the video's default thumbnail in Bunny Stream.
+func (c *StreamClient) SetThumbnail(ctx context.Context, videoID, thumbnailURL string) error {
+	params := url.Values{}
+	params.Set("thumbnailUrl", thumbnailURL)
+
+	endpoint := fmt.Sprintf("%s/library/%s/videos/%s/thumbnail?%s", c.baseURL, c.libraryID, videoID, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("AccessKey", c.apiKey)
+	req.Header.Set("User-Agent", "LMS-Server-Go/1.0.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
 	}
+	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bunny API error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+func (c *StreamClient) deliveryBaseURL() string {
 	delivery := strings.TrimSpace(c.deliveryURL)
+	if delivery == "" {
+		return ""
+	}
 	if !strings.HasPrefix(delivery, "http://") && !strings.HasPrefix(delivery, "https://") {
 		delivery = "https://" + delivery
 	}
+	return strings.TrimRight(delivery, "/")
+}
 
-	if !strings.HasSuffix(delivery, "/") {
-		delivery += "/"
-	}
-
+// SignedVideoURL generates a signed Bunny Stream playlist URL matching the legacy Node implementation.
+func (c *StreamClient) SignedVideoURL(videoID string) (string, error) {
 	expiresIn := c.expiresIn
 	if expiresIn <= 0 {
 		expiresIn = 3600
 	}
+	return c.signedURL(videoID, "playlist.m3u8", time.Duration(expiresIn)*time.Second)
+}
+
+// SignedDownloadURL generates a signed URL of the MP4 rendition of the video at
+// the given height, valid for ttl. MP4 fallback must be enabled on the library.
+func (c *StreamClient) SignedDownloadURL(videoID string, height int, ttl time.Duration) (string, error) {
+	if height <= 0 {
+		return "", fmt.Errorf("rendition height must be positive")
+	}
+	return c.signedURL(videoID, fmt.Sprintf("play_%dp.mp4", height), ttl)
+}
+
+// signedURL signs the path of a file of the video with the token authentication key.
+func (c *StreamClient) signedURL(videoID, file string, ttl time.Duration) (string, error) {
+	if strings.TrimSpace(videoID) == "" {
+		return "", fmt.Errorf("videoID is required")
+	}
+	if strings.TrimSpace(c.securityKey) == "" || strings.TrimSpace(c.deliveryURL) == "" {
+		return "", fmt.Errorf("bunny stream signing configuration is missing")
+	}
 
-	expiration := time.Now().Unix() + int64(expiresIn)
-	path := fmt.Sprintf("%s/playlist.m3u8", strings.Trim(strings.TrimPrefix(videoID, "/"), "/"))
-	urlPath := "/" + path
+	expiration := time.Now().Add(ttl).Unix()
+	urlPath := fmt.Sprintf("/%s/%s", strings.Trim(strings.TrimPrefix(videoID, "/"), "/"), file)
 
 	stringToSign := fmt.Sprintf("%s%s%d", c.securityKey, urlPath, expiration)
 	hash := sha256.Sum256([]byte(stringToSign))
 	token := base64.StdEncoding.EncodeToString(hash[:])
 	token = strings.NewReplacer("+", "-", "/", "_", "=", "").Replace(token)
 
-	return fmt.Sprintf("%s%s?token=%s&expires=%d", strings.TrimRight(delivery, "/"), urlPath, token, expiration), nil
+	return fmt.Sprintf("%s%s?token=%s&expires=%d", c.deliveryBaseURL(), urlPath, token, expiration), nil
 }
 
 // CreateVideoUploadURL creates a video entry and returns a signed upload URL for direct client upload
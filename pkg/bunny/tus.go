@@ -0,0 +1,121 @@
+package bunny
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// tusVersion is the TUS protocol version spoken with Bunny Stream.
+const tusVersion = "1.0.0"
+
+// ErrTusOffsetMismatch means the upstream upload is at a different offset than
+// the chunk being sent; the caller should re-read the offset and resume.
+var ErrTusOffsetMismatch = errors.New("tus upload offset mismatch")
+
+// tusHTTPClient carries upload chunks. Bodies are streamed and cannot be
+// replayed, so it skips the retrying transport, and its timeout allows for
+// slow client connections.
+var tusHTTPClient = &http.Client{Timeout: 30 * time.Minute}
+
+// CreateTusUpload starts a TUS upload of length bytes on Bunny's endpoint using
+// the credentials in info and returns the upload's URL. It is used when the
+// server relays uploads instead of handing the credentials to clients.
+func (c *StreamClient) CreateTusUpload(ctx context.Context, info *TusUploadInfo, collectionID string, length int64) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, info.TusEndpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setTusHeaders(req, info)
+	req.Header.Set("Upload-Length", strconv.FormatInt(length, 10))
+	req.Header.Set("Upload-Metadata", fmt.Sprintf("filetype %s,title %s,collection %s",
+		base64.StdEncoding.EncodeToString([]byte("video/mp4")),
+		base64.StdEncoding.EncodeToString([]byte(info.LessonName)),
+		base64.StdEncoding.EncodeToString([]byte(collectionID))))
+
+	resp, err := tusHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bunny tus error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return "", fmt.Errorf("bunny tus response has no upload location: %w", err)
+	}
+	return location.String(), nil
+}
+
+// TusOffset returns how many bytes of the upload Bunny has received.
+func (c *StreamClient) TusOffset(ctx context.Context, info *TusUploadInfo, uploadURL string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, uploadURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setTusHeaders(req, info)
+
+	resp, err := tusHTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return 0, fmt.Errorf("bunny tus error: status=%d", resp.StatusCode)
+	}
+	return parseUploadOffset(resp)
+}
+
+// TusPatch sends size bytes from body at offset and returns the new offset.
+func (c *StreamClient) TusPatch(ctx context.Context, info *TusUploadInfo, uploadURL string, offset int64, body io.Reader, size int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, uploadURL, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	c.setTusHeaders(req, info)
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+
+	resp, err := tusHTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return 0, ErrTusOffsetMismatch
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("bunny tus error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
+	}
+	return parseUploadOffset(resp)
+}
+
+func (c *StreamClient) setTusHeaders(req *http.Request, info *TusUploadInfo) {
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("AuthorizationSignature", info.AuthorizationSignature)
+	req.Header.Set("AuthorizationExpire", strconv.FormatInt(info.AuthorizationExpire, 10))
+	req.Header.Set("VideoId", info.VideoID)
+	req.Header.Set("LibraryId", c.libraryID)
+	req.Header.Set("User-Agent", "LMS-Server-Go/1.0.0")
+}
+
+func parseUploadOffset(resp *http.Response) (int64, error) {
+	offset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bunny tus response has no valid Upload-Offset: %w", err)
+	}
+	return offset, nil
+}
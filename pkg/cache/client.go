@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -139,9 +141,13 @@ func (r *RedisClient) GetJSON(ctx context.Context, key string, dest interface{})
 	return nil
 }
 
-// MemoryCache is an in-memory cache implementation for development/testing.
+// MemoryCache is an in-memory cache used when Redis is not configured.
+// It is safe for concurrent use; expired entries are dropped on access and
+// swept periodically as the store grows.
 type MemoryCache struct {
-	store map[string]cacheItem
+	mu        sync.Mutex
+	store     map[string]cacheItem
+	nextSweep int
 }
 
 type cacheItem struct {
@@ -149,15 +155,22 @@ type cacheItem struct {
 	expiration time.Time
 }
 
-// NewMemoryCache creates a new in-memory cache (for development/testing).
+// memorySweepThreshold is the store size that triggers the first sweep of expired entries.
+const memorySweepThreshold = 1024
+
+// NewMemoryCache creates a new in-memory cache.
 func NewMemoryCache() *MemoryCache {
 	return &MemoryCache{
-		store: make(map[string]cacheItem),
+		store:     make(map[string]cacheItem),
+		nextSweep: memorySweepThreshold,
 	}
 }
 
 // Get retrieves a value from memory cache.
 func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	item, exists := m.store[key]
 	if !exists {
 		return "", fmt.Errorf("key not found")
@@ -187,21 +200,45 @@ func (m *MemoryCache) Set(ctx context.Context, key string, value interface{}, ex
 		strValue = string(data)
 	}
 
-	exp := time.Now().Add(expiration)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, strValue, expiration)
+	return nil
+}
+
+// set stores a value; callers must hold m.mu.
+func (m *MemoryCache) set(key, value string, expiration time.Duration) {
 	if expiration == 0 {
-		exp = time.Now().Add(24 * time.Hour) // Default 24 hour expiration
+		expiration = 24 * time.Hour // Default 24 hour expiration
 	}
 
 	m.store[key] = cacheItem{
-		value:      strValue,
-		expiration: exp,
+		value:      value,
+		expiration: time.Now().Add(expiration),
 	}
 
-	return nil
+	if len(m.store) >= m.nextSweep {
+		m.sweep()
+	}
+}
+
+// sweep drops expired entries and sets the size of the next sweep; callers must hold m.mu.
+func (m *MemoryCache) sweep() {
+	now := time.Now()
+	for key, item := range m.store {
+		if now.After(item.expiration) {
+			delete(m.store, key)
+		}
+	}
+	m.nextSweep = max(memorySweepThreshold, 2*len(m.store))
 }
 
 // Delete removes keys from memory cache.
 func (m *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for _, key := range keys {
 		delete(m.store, key)
 	}
@@ -210,9 +247,13 @@ func (m *MemoryCache) Delete(ctx context.Context, keys ...string) error {
 
 // Exists checks if keys exist in memory cache.
 func (m *MemoryCache) Exists(ctx context.Context, keys ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
 	count := int64(0)
 	for _, key := range keys {
-		if _, exists := m.store[key]; exists {
+		if item, exists := m.store[key]; exists && !now.After(item.expiration) {
 			count++
 		}
 	}
@@ -221,23 +262,24 @@ func (m *MemoryCache) Exists(ctx context.Context, keys ...string) (int64, error)
 
 // Increment increments a counter in memory cache.
 func (m *MemoryCache) Increment(ctx context.Context, key string) (int64, error) {
-	item, exists := m.store[key]
-	if !exists {
-		m.Set(ctx, key, "1", 24*time.Hour)
-		return 1, nil
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	// Try to parse as int64
 	var current int64
-	fmt.Sscanf(item.value, "%d", &current)
+	if item, exists := m.store[key]; exists && !time.Now().After(item.expiration) {
+		fmt.Sscanf(item.value, "%d", &current)
+	}
 	current++
 
-	m.Set(ctx, key, fmt.Sprintf("%d", current), 24*time.Hour)
+	m.set(key, strconv.FormatInt(current, 10), 24*time.Hour)
 	return current, nil
 }
 
 // Expire sets an expiration on a key in memory cache.
 func (m *MemoryCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	item, exists := m.store[key]
 	if !exists {
 		return fmt.Errorf("key not found")
@@ -250,6 +292,9 @@ func (m *MemoryCache) Expire(ctx context.Context, key string, expiration time.Du
 
 // Close is a no-op for memory cache.
 func (m *MemoryCache) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.store = make(map[string]cacheItem)
 	return nil
 }
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// NamespaceDashboard groups the platform-wide statistics of the admin dashboard.
+const NamespaceDashboard = "dashboard"
+
+// Store caches query results as JSON. Keys are grouped into namespaces that
+// each carry a version counter: invalidating a namespace bumps its version, so
+// entries written under an older version are never read again and simply expire.
+//
+// A nil *Store is valid and caches nothing, so callers need no nil checks.
+type Store struct {
+	client Client
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+// NewStore creates a query cache on top of client with the given entry lifetime.
+func NewStore(client Client, ttl time.Duration, logger *slog.Logger) *Store {
+	return &Store{
+		client: client,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Get loads a cached value into dest and reports whether it was found.
+// Cache failures are treated as misses.
+func (s *Store) Get(ctx context.Context, namespace, key string, dest interface{}) bool {
+	if s == nil {
+		return false
+	}
+
+	data, err := s.client.Get(ctx, s.key(ctx, namespace, key))
+	if err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal([]byte(data), dest); err != nil {
+		s.logger.Warn("discarding unreadable cache entry", "namespace", namespace, "key", key, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// Set caches value under the current version of namespace.
+func (s *Store) Set(ctx context.Context, namespace, key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Warn("failed to encode cache entry", "namespace", namespace, "key", key, "error", err)
+		return
+	}
+
+	if err := s.client.Set(ctx, s.key(ctx, namespace, key), string(data), s.ttl); err != nil {
+		s.logger.Warn("failed to write cache entry", "namespace", namespace, "key", key, "error", err)
+	}
+}
+
+// Invalidate discards every entry cached under the given namespaces.
+func (s *Store) Invalidate(ctx context.Context, namespaces ...string) {
+	if s == nil {
+		return
+	}
+
+	for _, namespace := range namespaces {
+		if _, err := s.client.Increment(ctx, versionKey(namespace)); err != nil {
+			s.logger.Warn("failed to invalidate cache namespace", "namespace", namespace, "error", err)
+		}
+	}
+}
+
+func (s *Store) key(ctx context.Context, namespace, key string) string {
+	version, err := s.client.Get(ctx, versionKey(namespace))
+	if err != nil || version == "" {
+		version = "0"
+	}
+	return "cache:" + namespace + ":v" + version + ":" + key
+}
+
+func versionKey(namespace string) string {
+	return "cache:version:" + namespace
+}
@@ -0,0 +1,144 @@
+// Package clamav scans streams with a ClamAV daemon (clamd) over its INSTREAM
+// protocol, so files never need to be written where clamd can read them.
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of each INSTREAM chunk sent to clamd.
+const chunkSize = 64 << 10
+
+var (
+	// ErrStreamTooLarge means the file exceeds clamd's StreamMaxLength and was not scanned.
+	ErrStreamTooLarge = errors.New("file exceeds the scanner's size limit")
+)
+
+// Result is the verdict on one stream. Signature names the detected malware.
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Client talks to clamd over TCP ("host:port") or a Unix socket ("unix:/path").
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClient creates a client for the clamd at address. Each scan opens its own
+// connection and must finish within timeout.
+func NewClient(address string, timeout time.Duration) *Client {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	}
+	address = strings.TrimPrefix(address, "tcp://")
+
+	return &Client{network: network, address: address, timeout: timeout}
+}
+
+// Ping checks that clamd is reachable and answering.
+func (c *Client) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd and returns its verdict.
+func (c *Client) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	reply, err := c.command(ctx, "zINSTREAM\x00", r)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Replies look like "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case strings.Contains(verdict, "size limit exceeded"):
+		return Result{}, ErrStreamTooLarge
+	default:
+		return Result{}, fmt.Errorf("clamd scan failed: %s", verdict)
+	}
+}
+
+// command sends a null-terminated command, then body as INSTREAM chunks when
+// given, and reads the null-terminated reply.
+func (c *Client) command(ctx context.Context, cmd string, body io.Reader) (string, error) {
+	if c == nil {
+		return "", errors.New("clamav client is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := io.WriteString(conn, cmd); err != nil {
+		return "", fmt.Errorf("send clamd command: %w", err)
+	}
+
+	if body != nil {
+		if err := writeChunks(conn, body); err != nil {
+			return "", err
+		}
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", fmt.Errorf("read clamd reply: %w", err)
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// writeChunks frames body as length-prefixed chunks ended by a zero length.
+// clamd closes the connection early when the stream passes its size limit,
+// so a failed write is reported only if no reply explains it.
+func writeChunks(conn net.Conn, body io.Reader) error {
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := io.ReadFull(body, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return nil
+			}
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read file for scanning: %w", readErr)
+		}
+	}
+
+	_, _ = conn.Write(make([]byte, 4))
+	return nil
+}
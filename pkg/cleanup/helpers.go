@@ -9,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mo-amir99/lms-server-go/pkg/bunny"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
 )
 
 // AttachmentData represents attachment info needed for cleanup
@@ -71,6 +72,23 @@ func DeleteAttachmentFile(ctx context.Context, storageClient *bunny.StorageClien
 	return nil
 }
 
+// DeleteImageVariants deletes the resized copies of an image attachment from
+// Bunny Storage, skipping the one stored as its path, which DeleteAttachmentFile removes.
+func DeleteImageVariants(ctx context.Context, storageClient *bunny.StorageClient, logger *slog.Logger, attachmentID uuid.UUID, variants types.ImageVariants, path *string) {
+	for _, variant := range variants {
+		if path != nil && variant.URL == *path {
+			continue
+		}
+		relativePath := storageClient.ExtractRelativePath(variant.URL)
+		if err := storageClient.DeleteFile(ctx, relativePath); err != nil {
+			logger.Error("failed to delete image variant",
+				"attachmentId", attachmentID,
+				"path", relativePath,
+				"error", err)
+		}
+	}
+}
+
 // DeleteLessonVideo deletes a lesson video from Bunny Stream
 // If videoCleaned is true, skips deletion as parent collection was already deleted
 func DeleteLessonVideo(ctx context.Context, streamClient *bunny.StreamClient, logger *slog.Logger, lessonID uuid.UUID, videoID string, videoCleaned bool) error {
@@ -138,60 +156,6 @@ func DeleteCourseFolder(ctx context.Context, storageClient *bunny.StorageClient,
 	return nil
 }
 
-// BulkDeleteComments deletes all comments for given lesson IDs
-func BulkDeleteComments(db *gorm.DB, logger *slog.Logger, lessonIDs []uuid.UUID, contextMsg string) {
-	if len(lessonIDs) == 0 {
-		return
-	}
-
-	result := db.Table("comments").Where("lesson_id IN ?", lessonIDs).Delete(nil)
-	if result.Error != nil {
-		logger.Error("failed to delete comments",
-			"context", contextMsg,
-			"error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted comments",
-			"context", contextMsg,
-			"count", result.RowsAffected)
-	}
-}
-
-// BulkDeleteAttachments deletes all attachments for given IDs
-func BulkDeleteAttachments(db *gorm.DB, logger *slog.Logger, attachmentIDs []uuid.UUID, contextMsg string) {
-	if len(attachmentIDs) == 0 {
-		return
-	}
-
-	result := db.Table("attachments").Where("id IN ?", attachmentIDs).Delete(nil)
-	if result.Error != nil {
-		logger.Error("failed to delete attachments",
-			"context", contextMsg,
-			"error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted attachments",
-			"context", contextMsg,
-			"count", result.RowsAffected)
-	}
-}
-
-// BulkDeleteLessons deletes all lessons for given IDs
-func BulkDeleteLessons(db *gorm.DB, logger *slog.Logger, lessonIDs []uuid.UUID, contextMsg string) {
-	if len(lessonIDs) == 0 {
-		return
-	}
-
-	result := db.Table("lessons").Where("id IN ?", lessonIDs).Delete(nil)
-	if result.Error != nil {
-		logger.Error("failed to delete lessons",
-			"context", contextMsg,
-			"error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted lessons",
-			"context", contextMsg,
-			"count", result.RowsAffected)
-	}
-}
-
 // BulkDeleteVideos deletes multiple videos from Bunny Stream
 func BulkDeleteVideos(ctx context.Context, streamClient *bunny.StreamClient, logger *slog.Logger, videoIDs []string, contextMsg string) {
 	if len(videoIDs) == 0 {
@@ -216,20 +180,6 @@ func BulkDeleteVideos(ctx context.Context, streamClient *bunny.StreamClient, log
 	}
 }
 
-// DeleteForumThreads deletes all threads for a given forum ID
-func DeleteForumThreads(db *gorm.DB, logger *slog.Logger, forumID uuid.UUID) {
-	result := db.Table("threads").Where("forum_id = ?", forumID).Delete(nil)
-	if result.Error != nil {
-		logger.Error("failed to delete forum threads",
-			"forumId", forumID,
-			"error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted forum threads",
-			"forumId", forumID,
-			"count", result.RowsAffected)
-	}
-}
-
 // DeleteSubscriptionFolder deletes entire subscription folder from Bunny Storage
 func DeleteSubscriptionFolder(ctx context.Context, storageClient *bunny.StorageClient, logger *slog.Logger, subscriptionIdentifier string) error {
 	if subscriptionIdentifier == "" {
@@ -311,7 +261,6 @@ func CleanupCourse(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 
 	// Step 2: Get all attachments for these lessons (only if storage not cleaned)
 	var attachments []AttachmentData
-	var attachmentIDs []uuid.UUID
 	if len(lessonIDs) > 0 {
 		err = db.Table("attachments").
 			Select("id, type, path").
@@ -320,11 +269,6 @@ func CleanupCourse(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 		if err != nil {
 			logger.Error("failed to load attachments for course cleanup", "courseId", courseID, "error", err)
 		}
-
-		// Collect attachment IDs
-		for _, att := range attachments {
-			attachmentIDs = append(attachmentIDs, att.ID)
-		}
 	}
 
 	// Step 3: Handle video cleanup
@@ -376,16 +320,8 @@ func CleanupCourse(ctx context.Context, db *gorm.DB, streamClient *bunny.StreamC
 		}
 	}
 
-	// Step 5: Delete comments for all lessons
-	BulkDeleteComments(db, logger, lessonIDs, fmt.Sprintf("course_%s", courseID))
-
-	// Step 6: Delete all attachments from database
-	BulkDeleteAttachments(db, logger, attachmentIDs, fmt.Sprintf("course_%s", courseID))
-
-	// Step 7: Delete all lessons from database
-	BulkDeleteLessons(db, logger, lessonIDs, fmt.Sprintf("course_%s", courseID))
-
-	// Step 8: Delete course from database
+	// Step 5: Delete course from database; its lessons and their attachments
+	// and comments go with it through their foreign keys
 	if err := db.Table("courses").Where("id = ?", courseID).Delete(nil).Error; err != nil {
 		logger.Error("failed to delete course from database", "courseId", courseID, "error", err)
 		return err
@@ -448,52 +384,8 @@ func CleanupSubscription(ctx context.Context, db *gorm.DB, streamClient *bunny.S
 		}
 	}
 
-	// Step 5: Delete all forums and their threads
-	var forumIDs []uuid.UUID
-	err = db.Table("forums").Select("id").Where("subscription_id = ?", subscriptionID).Find(&forumIDs).Error
-	if err != nil {
-		logger.Error("failed to load forums", "subscriptionId", subscriptionID, "error", err)
-	} else {
-		for _, forumID := range forumIDs {
-			DeleteForumThreads(db, logger, forumID)
-		}
-		// Delete forums
-		if result := db.Table("forums").Where("subscription_id = ?", subscriptionID).Delete(nil); result.Error != nil {
-			logger.Error("failed to delete forums", "subscriptionId", subscriptionID, "error", result.Error)
-		} else if result.RowsAffected > 0 {
-			logger.Info("deleted forums", "subscriptionId", subscriptionID, "count", result.RowsAffected)
-		}
-	}
-
-	// Step 6: Delete all users for this subscription
-	if result := db.Table("users").Where("subscription_id = ?", subscriptionID).Delete(nil); result.Error != nil {
-		logger.Error("failed to delete users", "subscriptionId", subscriptionID, "error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted users", "subscriptionId", subscriptionID, "count", result.RowsAffected)
-	}
-
-	// Step 7: Delete all announcements for this subscription
-	if result := db.Table("announcements").Where("subscription_id = ?", subscriptionID).Delete(nil); result.Error != nil {
-		logger.Error("failed to delete announcements", "subscriptionId", subscriptionID, "error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted announcements", "subscriptionId", subscriptionID, "count", result.RowsAffected)
-	}
-
-	// Step 8: Delete all payments for this subscription
-	if result := db.Table("payments").Where("subscription_id = ?", subscriptionID).Delete(nil); result.Error != nil {
-		logger.Error("failed to delete payments", "subscriptionId", subscriptionID, "error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted payments", "subscriptionId", subscriptionID, "count", result.RowsAffected)
-	}
-
-	// Step 9: Delete all group access for this subscription
-	if result := db.Table("group_access").Where("subscription_id = ?", subscriptionID).Delete(nil); result.Error != nil {
-		logger.Error("failed to delete group access", "subscriptionId", subscriptionID, "error", result.Error)
-	} else if result.RowsAffected > 0 {
-		logger.Info("deleted group access", "subscriptionId", subscriptionID, "count", result.RowsAffected)
-	}
-
-	// Step 10: Delete subscription from database
+	// Step 5: Delete subscription from database; its users, forums and threads,
+	// announcements, payments and group access go with it through their foreign keys
 	if err := db.Table("subscriptions").Where("id = ?", subscriptionID).Delete(nil).Error; err != nil {
 		logger.Error("failed to delete subscription from database", "subscriptionId", subscriptionID, "error", err)
 		return err
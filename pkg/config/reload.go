@@ -0,0 +1,194 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/joho/godotenv"
+)
+
+// envFile is read by Load when present. The process environment takes
+// precedence over it.
+const envFile = ".env"
+
+// Values Load set from envFile and from secret files, guarded by loadMu. A
+// reload replaces them while leaving the process environment alone.
+var (
+	envFileValues    = map[string]string{}
+	secretFileValues = map[string]string{}
+)
+
+// reloadable lists the settings Reload applies without a restart.
+var reloadable = []string{
+	"LMS_LOG_LEVEL",
+	"RATE_LIMIT_IP_PER_MINUTE",
+	"RATE_LIMIT_USER_PER_MINUTE",
+	"RATE_LIMIT_SUBSCRIPTION_PER_MINUTE",
+	"RATE_LIMIT_AUTH_PER_MINUTE",
+	"STREAMING_MAX_STREAMS_PER_USER",
+	"STREAMING_MAX_VIEWERS_PER_STREAM",
+	"STREAMING_MAX_TOTAL_STREAMS",
+	"STREAMING_START_COOLDOWN_SECONDS",
+}
+
+// loadEnvFile sets the variables of envFile that the process environment does
+// not, and unsets those an earlier load set but the file no longer has.
+func loadEnvFile() error {
+	values, err := godotenv.Read(envFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		values = map[string]string{}
+	} else if err != nil {
+		return fmt.Errorf("read %s: %w", envFile, err)
+	}
+
+	for name, previous := range envFileValues {
+		if _, ok := values[name]; !ok && os.Getenv(name) == previous {
+			_ = os.Unsetenv(name)
+		}
+	}
+	loaded := make(map[string]string, len(values))
+	for name, value := range values {
+		current, set := os.LookupEnv(name)
+		if previous, ok := envFileValues[name]; set && !(ok && current == previous) {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		loaded[name] = value
+	}
+	envFileValues = loaded
+	return nil
+}
+
+// Change is a setting whose value differs after a reload. Applied is false
+// for settings that only take effect on restart.
+type Change struct {
+	Name    string `json:"name"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Applied bool   `json:"applied"`
+}
+
+// Reload reads the configuration again and returns a copy of c with the
+// reloadable settings replaced: the log level, rate limits and streaming
+// limits. The changes include the settings that need a restart.
+func (c *Config) Reload() (*Config, []Change, error) {
+	fresh, err := Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	// The process mode may come from a command line flag instead
+	fresh.Mode = c.Mode
+
+	next := *c
+	next.LogLevel = fresh.LogLevel
+	next.RateLimit = fresh.RateLimit
+	// Moving buckets to or from Redis needs a restart
+	next.RateLimit.Backend = c.RateLimit.Backend
+	next.Streaming = fresh.Streaming
+
+	previous := make(map[string]string)
+	for _, setting := range c.Summary() {
+		previous[setting.Name] = setting.Value
+	}
+	changes := []Change{}
+	for _, setting := range fresh.Summary() {
+		if from := previous[setting.Name]; from != setting.Value {
+			changes = append(changes, Change{
+				Name:    setting.Name,
+				From:    from,
+				To:      setting.Value,
+				Applied: slices.Contains(reloadable, setting.Name),
+			})
+		}
+	}
+	return &next, changes, nil
+}
+
+// Watcher reloads the configuration on SIGHUP and hands it to the components
+// that apply it at runtime.
+type Watcher struct {
+	logger *slog.Logger
+	// reloadMu serializes reloads, so callbacks see them in order
+	reloadMu sync.Mutex
+
+	mu        sync.Mutex
+	current   *Config
+	callbacks []func(*Config)
+}
+
+// NewWatcher constructs a watcher starting from cfg.
+func NewWatcher(cfg *Config, logger *slog.Logger) *Watcher {
+	return &Watcher{logger: logger, current: cfg}
+}
+
+// OnReload registers fn to be called with the new configuration after every
+// successful reload.
+func (w *Watcher) OnReload(fn func(*Config)) {
+	w.mu.Lock()
+	w.callbacks = append(w.callbacks, fn)
+	w.mu.Unlock()
+}
+
+// Current returns the configuration in effect.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Reload reloads the configuration now and logs what changed. An invalid
+// configuration is rejected and the current one stays in effect.
+func (w *Watcher) Reload() ([]Change, error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	next, changes, err := w.Current().Reload()
+	if err != nil {
+		w.logger.Error("configuration reload failed, keeping the current configuration", "error", err)
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Applied {
+			w.logger.Info("configuration setting reloaded", "setting", change.Name, "from", change.From, "to", change.To)
+		} else {
+			w.logger.Warn("configuration setting changed, restart to apply it", "setting", change.Name, "from", change.From, "to", change.To)
+		}
+	}
+
+	w.mu.Lock()
+	w.current = next
+	callbacks := slices.Clone(w.callbacks)
+	w.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(next)
+	}
+	w.logger.Info("configuration reloaded", "changes", len(changes))
+	return changes, nil
+}
+
+// Run reloads the configuration on every SIGHUP until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			_, _ = w.Reload()
+		}
+	}
+}
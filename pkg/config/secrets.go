@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// secretFileSuffix marks variables that name a file holding the value, as
+// Docker and Kubernetes secrets are mounted: JWT_SECRET_FILE=/run/secrets/jwt
+// sets JWT_SECRET to the file's content. Any variable can be set this way.
+const secretFileSuffix = "_FILE"
+
+// redacted replaces secrets in the configuration summary.
+const redacted = "[redacted]"
+
+// loadSecretFiles sets every NAME for which NAME_FILE is set to the content
+// of that file, without the trailing newline. Called again on reload, it
+// picks up rotated secrets.
+func loadSecretFiles() error {
+	for _, entry := range os.Environ() {
+		key, path, _ := strings.Cut(entry, "=")
+		name, ok := strings.CutSuffix(key, secretFileSuffix)
+		if !ok || name == "" || path == "" {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: read secret file: %w", key, err)
+		}
+		value := strings.TrimRight(string(content), "\r\n")
+		if value == "" {
+			return fmt.Errorf("%s: secret file %s is empty", key, path)
+		}
+		// An earlier Load may have set the value from this file
+		current := os.Getenv(name)
+		if previous, ok := secretFileValues[name]; current != "" && current != value && !(ok && current == previous) {
+			return fmt.Errorf("%s and %s are both set; set only one of them", name, key)
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		secretFileValues[name] = value
+	}
+	return nil
+}
+
+// redact hides a secret, keeping whether it is set.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL hides the password of a URL such as a database URL.
+func redactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return redacted
+	}
+	return parsed.Redacted()
+}
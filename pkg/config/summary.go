@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Setting is one effective configuration value, named by the environment
+// variable it is read from.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Summary lists the effective settings with secrets redacted, for checking
+// what a deployment actually runs with. Empty values are unset.
+func (c *Config) Summary() []Setting {
+	itoa := strconv.Itoa
+	db, stream, storage := c.Database, c.Bunny.Stream, c.Bunny.Storage
+
+	zones := make([]string, len(c.Bunny.Stats.PullZones))
+	for i, zone := range c.Bunny.Stats.PullZones {
+		zones[i] = fmt.Sprintf("%s:%d", zone.Name, zone.ID)
+	}
+	headers := make([]string, 0, len(c.Tracing.Headers))
+	for key := range c.Tracing.Headers {
+		headers = append(headers, key+"="+redacted)
+	}
+
+	return []Setting{
+		{"LMS_SERVER_ENV", c.Env},
+		{"LMS_SERVER_MODE", c.Mode},
+		{"LMS_SERVER_HOST", c.Host},
+		{"LMS_SERVER_PORT", c.Port},
+		{"LMS_ALLOWED_ORIGINS", strings.Join(c.AllowedOrigins, ",")},
+		{"LMS_LOG_LEVEL", c.LogLevel},
+		{"LMS_LOG_LOKI_URL", c.Log.LokiURL},
+		{"LMS_API_STRICT_JSON", strconv.FormatBool(c.API.StrictJSON)},
+		{"LMS_API_MAX_JSON_BODY_KB", itoa(c.API.MaxJSONBodyKB)},
+
+		{"JWT_SECRET", redact(c.JWTSecret)},
+		{"JWT_REFRESH_SECRET", redact(c.JWTRefreshSecret)},
+		{"JWT_ACCESS_TOKEN_EXPIRY", itoa(c.AccessTokenExpiry)},
+		{"JWT_REFRESH_TOKEN_EXPIRY", itoa(c.RefreshTokenExpiry)},
+
+		{"LMS_DB_HOST", db.Host},
+		{"LMS_DB_PORT", db.Port},
+		{"LMS_DB_NAME", db.Name},
+		{"LMS_DB_USER", db.User},
+		{"LMS_DB_PASSWORD", redact(db.Password)},
+		{"LMS_DB_SSLMODE", db.SSLMode},
+		{"LMS_DB_MAX_OPEN_CONNS", itoa(db.MaxOpenConns)},
+		{"LMS_DB_RUN_MIGRATIONS", strconv.FormatBool(db.RunMigrations)},
+		{"LMS_DB_MIGRATIONS_DIR", db.MigrationsDir},
+		{"LMS_DB_REPLICA_URL", redactURL(db.ReplicaURL)},
+
+		{"REDIS_ADDR", c.Cache.RedisAddr},
+		{"REDIS_PASSWORD", redact(c.Cache.RedisPassword)},
+		{"CACHE_TTL_SECONDS", itoa(c.Cache.TTL)},
+		{"RATE_LIMIT_BACKEND", c.RateLimit.Backend},
+		{"RATE_LIMIT_IP_PER_MINUTE", itoa(c.RateLimit.IPPerMinute)},
+		{"RATE_LIMIT_USER_PER_MINUTE", itoa(c.RateLimit.UserPerMinute)},
+		{"RATE_LIMIT_SUBSCRIPTION_PER_MINUTE", itoa(c.RateLimit.SubscriptionPerMinute)},
+		{"RATE_LIMIT_AUTH_PER_MINUTE", itoa(c.RateLimit.AuthPerMinute)},
+		{"STREAMING_MAX_STREAMS_PER_USER", itoa(c.Streaming.MaxStreamsPerUser)},
+		{"STREAMING_MAX_VIEWERS_PER_STREAM", itoa(c.Streaming.MaxViewersPerStream)},
+		{"STREAMING_MAX_TOTAL_STREAMS", itoa(c.Streaming.MaxTotalStreams)},
+		{"STREAMING_START_COOLDOWN_SECONDS", itoa(c.Streaming.StartCooldownSeconds)},
+
+		{"BUNNY_STREAM_LIBRARY_ID", stream.LibraryID},
+		{"BUNNY_STREAM_API_KEY", redact(stream.APIKey)},
+		{"BUNNY_STREAM_SECURITY_KEY", redact(stream.SecurityKey)},
+		{"BUNNY_STREAM_BASE_URL", stream.BaseURL},
+		{"BUNNY_STREAM_DELIVERY_URL", stream.DeliveryURL},
+		{"BUNNY_STREAM_EXPIRES_IN", itoa(stream.ExpiresIn)},
+		{"BUNNY_STORAGE_ZONE", storage.StorageZone},
+		{"BUNNY_STORAGE_API_KEY", redact(storage.APIKey)},
+		{"BUNNY_STORAGE_BASE_URL", storage.BaseURL},
+		{"BUNNY_STORAGE_CDN_URL", storage.CDNURL},
+		{"BUNNY_STORAGE_TOKEN_KEY", redact(storage.TokenKey)},
+		{"BUNNY_STORAGE_TOKEN_EXPIRES_IN", itoa(storage.TokenExpiresIn)},
+		{"BUNNY_STATS_API_KEY", redact(c.Bunny.Stats.APIKey)},
+		{"BUNNY_STATS_PULL_ZONES", strings.Join(zones, ",")},
+
+		{"SMTP_HOST", c.Email.Host},
+		{"SMTP_PORT", c.Email.Port},
+		{"SMTP_USER", c.Email.Username},
+		{"SMTP_PASS", redact(c.Email.Password)},
+		{"SMTP_FROM", c.Email.From},
+		{"FRONTEND_URL", c.Email.FrontendURL},
+		{"LMS_EMAIL_TEMPLATES_DIR", c.Email.TemplatesDir},
+
+		{"IAP_GOOGLE_PLAY_ENABLED", strconv.FormatBool(c.IAP.GooglePlay.Enabled)},
+		{"IAP_GOOGLE_PLAY_WEBHOOK_TOKEN", redact(c.IAP.GooglePlay.WebhookToken)},
+		{"IAP_APP_STORE_ENABLED", strconv.FormatBool(c.IAP.AppStore.Enabled)},
+		{"IAP_APP_STORE_SHARED_SECRET", redact(c.IAP.AppStore.SharedSecret)},
+
+		{"WEBRTC_TURN_URLS", strings.Join(c.WebRTC.TURNURLs, ",")},
+		{"WEBRTC_TURN_SECRET", redact(c.WebRTC.TURNSecret)},
+		{"CLAMAV_ADDRESS", c.ClamAV.Address},
+		{"GOOGLE_CLASSROOM_CLIENT_ID", c.Classroom.ClientID},
+		{"GOOGLE_CLASSROOM_CLIENT_SECRET", redact(c.Classroom.ClientSecret)},
+		{"SSO_REDIRECT_URL", c.SSO.RedirectURL},
+		{"SSO_SAML_URL", c.SSO.SAMLURL},
+
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", c.Tracing.Endpoint},
+		{"OTEL_EXPORTER_OTLP_HEADERS", strings.Join(headers, ",")},
+	}
+}
+
+// LogValue logs the configuration as its redacted summary, so logging the
+// Config never leaks a secret.
+func (c *Config) LogValue() slog.Value {
+	settings := c.Summary()
+	attrs := make([]slog.Attr, 0, len(settings))
+	for _, setting := range settings {
+		if setting.Value != "" {
+			attrs = append(attrs, slog.String(setting.Name, setting.Value))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Defaults of the JWT secrets, which must be replaced in production.
+const (
+	defaultJWTSecret        = "your-secret-key-change-me"
+	defaultJWTRefreshSecret = "your-refresh-secret-change-me"
+)
+
+// minSecretLength is the shortest JWT secret accepted in production.
+const minSecretLength = 32
+
+var (
+	sslModes  = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	logLevels = []string{"debug", "info", "warn", "warning", "error"}
+)
+
+// ValidationError lists every problem found in the configuration, so all of
+// them can be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+var (
+	// loadMu serializes Load, which collects malformed values in malformed.
+	loadMu    sync.Mutex
+	malformed []string
+)
+
+// reportMalformed records a value the getEnvAs helpers could not parse.
+func reportMalformed(key, value, kind string) {
+	malformed = append(malformed, fmt.Sprintf("%s must be %s, got %q", key, kind, value))
+}
+
+// validate reports malformed values and missing or unusable settings.
+// Production additionally requires real secrets and the Bunny credentials.
+func (c *Config) validate() error {
+	problems := slices.Clone(malformed)
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	production := c.IsProduction()
+
+	check(validPort(c.Port), "LMS_SERVER_PORT must be a port between 1 and 65535, got %q", c.Port)
+	check(slices.Contains(logLevels, strings.ToLower(c.LogLevel)), "LMS_LOG_LEVEL must be one of %s, got %q", strings.Join(logLevels, ", "), c.LogLevel)
+
+	check(c.JWTSecret != "", "JWT_SECRET is required")
+	check(c.JWTRefreshSecret != "", "JWT_REFRESH_SECRET is required")
+	if production {
+		check(c.JWTSecret != defaultJWTSecret && len(c.JWTSecret) >= minSecretLength,
+			"JWT_SECRET must be a random value of at least %d characters in production", minSecretLength)
+		check(c.JWTRefreshSecret != defaultJWTRefreshSecret && len(c.JWTRefreshSecret) >= minSecretLength,
+			"JWT_REFRESH_SECRET must be a random value of at least %d characters in production", minSecretLength)
+		check(c.JWTSecret != c.JWTRefreshSecret, "JWT_REFRESH_SECRET must differ from JWT_SECRET")
+	}
+	check(c.AccessTokenExpiry > 0, "JWT_ACCESS_TOKEN_EXPIRY must be a positive number of minutes, got %d", c.AccessTokenExpiry)
+	check(c.RefreshTokenExpiry > 0, "JWT_REFRESH_TOKEN_EXPIRY must be a positive number of hours, got %d", c.RefreshTokenExpiry)
+	check(c.PasswordResetExpiry > 0, "JWT_PASSWORD_RESET_EXPIRY must be a positive number of hours, got %d", c.PasswordResetExpiry)
+	check(c.EmailVerificationExpiry > 0, "JWT_EMAIL_VERIFICATION_EXPIRY must be a positive number of hours, got %d", c.EmailVerificationExpiry)
+
+	db := c.Database
+	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
+		check(isPostgresURL(dbURL), "DATABASE_URL must be a postgres:// or postgresql:// URL")
+	}
+	check(db.Host != "", "LMS_DB_HOST (or the host in DATABASE_URL) is required")
+	check(validPort(db.Port), "LMS_DB_PORT must be a port between 1 and 65535, got %q", db.Port)
+	check(db.Name != "", "LMS_DB_NAME (or the database in DATABASE_URL) is required")
+	check(db.User != "", "LMS_DB_USER (or the user in DATABASE_URL) is required")
+	check(slices.Contains(sslModes, db.SSLMode), "LMS_DB_SSLMODE must be one of %s, got %q", strings.Join(sslModes, ", "), db.SSLMode)
+	check(db.MaxIdleConns >= 0 && db.MaxOpenConns >= 0, "LMS_DB_MAX_IDLE_CONNS and LMS_DB_MAX_OPEN_CONNS must not be negative")
+	if production {
+		check(db.Password != "", "LMS_DB_PASSWORD (or the password in DATABASE_URL) is required in production")
+	}
+	if db.ReplicaURL != "" {
+		check(isPostgresURL(db.ReplicaURL), "LMS_DB_REPLICA_URL must be a postgres:// or postgresql:// URL")
+	}
+
+	stream, storage := c.Bunny.Stream, c.Bunny.Storage
+	check(stream.LibraryID == "" || isDigits(stream.LibraryID), "BUNNY_STREAM_LIBRARY_ID must be the numeric library ID, got %q", stream.LibraryID)
+	check(stream.ExpiresIn > 0, "BUNNY_STREAM_EXPIRES_IN must be a positive number of seconds, got %d", stream.ExpiresIn)
+	check(storage.TokenExpiresIn > 0, "BUNNY_STORAGE_TOKEN_EXPIRES_IN must be a positive number of seconds, got %d", storage.TokenExpiresIn)
+	for name, value := range map[string]string{
+		"BUNNY_STREAM_BASE_URL":     stream.BaseURL,
+		"BUNNY_STREAM_DELIVERY_URL": stream.DeliveryURL,
+		"BUNNY_STORAGE_BASE_URL":    storage.BaseURL,
+		"BUNNY_STORAGE_CDN_URL":     storage.CDNURL,
+		"BUNNY_STATS_BASE_URL":      c.Bunny.Stats.BaseURL,
+		"FRONTEND_URL":              c.Email.FrontendURL,
+	} {
+		check(value == "" || isHTTPURL(value), "%s must be an http(s) URL, got %q", name, value)
+	}
+	if production {
+		for name, value := range map[string]string{
+			"BUNNY_STREAM_LIBRARY_ID": stream.LibraryID,
+			"BUNNY_STREAM_API_KEY":    stream.APIKey,
+			"BUNNY_STORAGE_ZONE":      storage.StorageZone,
+			"BUNNY_STORAGE_API_KEY":   storage.APIKey,
+			"BUNNY_STORAGE_CDN_URL":   storage.CDNURL,
+		} {
+			check(value != "", "%s is required in production", name)
+		}
+	}
+
+	for name, value := range map[string]int{
+		"RATE_LIMIT_IP_PER_MINUTE":           c.RateLimit.IPPerMinute,
+		"RATE_LIMIT_USER_PER_MINUTE":         c.RateLimit.UserPerMinute,
+		"RATE_LIMIT_SUBSCRIPTION_PER_MINUTE": c.RateLimit.SubscriptionPerMinute,
+		"RATE_LIMIT_AUTH_PER_MINUTE":         c.RateLimit.AuthPerMinute,
+		"STREAMING_MAX_STREAMS_PER_USER":     c.Streaming.MaxStreamsPerUser,
+		"STREAMING_MAX_VIEWERS_PER_STREAM":   c.Streaming.MaxViewersPerStream,
+		"STREAMING_MAX_TOTAL_STREAMS":        c.Streaming.MaxTotalStreams,
+	} {
+		check(value > 0, "%s must be positive, got %d", name, value)
+	}
+	check(c.Streaming.StartCooldownSeconds >= 0, "STREAMING_START_COOLDOWN_SECONDS must not be negative, got %d", c.Streaming.StartCooldownSeconds)
+
+	check(c.API.MaxJSONBodyKB > 0, "LMS_API_MAX_JSON_BODY_KB must be positive, got %d", c.API.MaxJSONBodyKB)
+	check(validPort(c.Email.Port), "SMTP_PORT must be a port between 1 and 65535, got %q", c.Email.Port)
+	check(c.Cache.RedisDB >= 0, "REDIS_DB must not be negative, got %d", c.Cache.RedisDB)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	// Maps are checked in random order; sorting keeps the message stable
+	slices.Sort(problems)
+	return &ValidationError{Problems: problems}
+}
+
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port > 0 && port <= 65535
+}
+
+func isDigits(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 64)
+	return err == nil
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func isPostgresURL(value string) bool {
+	return strings.HasPrefix(value, "postgres://") || strings.HasPrefix(value, "postgresql://")
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
 	"math"
@@ -10,22 +11,8 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
-	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
-	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
-	"github.com/mo-amir99/lms-server-go/internal/features/comment"
-	"github.com/mo-amir99/lms-server-go/internal/features/course"
-	"github.com/mo-amir99/lms-server-go/internal/features/forum"
-	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
-	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
-	packagefeature "github.com/mo-amir99/lms-server-go/internal/features/package"
-	"github.com/mo-amir99/lms-server-go/internal/features/payment"
-	"github.com/mo-amir99/lms-server-go/internal/features/referral"
-	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
-	"github.com/mo-amir99/lms-server-go/internal/features/supportticket"
-	"github.com/mo-amir99/lms-server-go/internal/features/thread"
-	"github.com/mo-amir99/lms-server-go/internal/features/user"
-	"github.com/mo-amir99/lms-server-go/internal/features/userwatch"
 	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/metrics"
 )
 
 // Connect establishes a GORM connection using the provided configuration with retry logic.
@@ -82,7 +69,7 @@ func ConnectWithRetry(ctx context.Context, cfg config.DatabaseConfig, log *slog.
 // connectOnce attempts a single database connection without retry logic.
 func connectOnce(ctx context.Context, cfg config.DatabaseConfig, log *slog.Logger) (*gorm.DB, error) {
 	// Use custom logger with metrics integration
-	gormLogger := NewCustomLogger(log, 200*time.Millisecond)
+	gormLogger := NewCustomLogger(log, time.Duration(cfg.SlowQueryThreshold)*time.Millisecond)
 
 	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
 		Logger: gormLogger,
@@ -101,18 +88,8 @@ func connectOnce(ctx context.Context, cfg config.DatabaseConfig, log *slog.Logge
 	}
 
 	// Configure connection pool
-	if cfg.MaxIdleConns > 0 {
-		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	}
-	if cfg.MaxOpenConns > 0 {
-		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	}
-	if cfg.ConnMaxLifetime > 0 {
-		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
-	}
-	if cfg.ConnMaxIdleTime > 0 {
-		sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime) * time.Second)
-	}
+	configurePool(sqlDB, cfg)
+	metrics.RegisterDBStats(sqlDB, "primary")
 
 	// Ping database to verify connection
 	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -128,6 +105,19 @@ func connectOnce(ctx context.Context, cfg config.DatabaseConfig, log *slog.Logge
 		return nil, fmt.Errorf("register reconnect plugin: %w", err)
 	}
 
+	// Statements run with a traced context become child spans of the request
+	if err := db.Use(NewTracingPlugin()); err != nil {
+		return nil, fmt.Errorf("register tracing plugin: %w", err)
+	}
+
+	// Heavy reads opt in to the replica through Reader
+	if replicaCfg, ok := cfg.Replica(); ok {
+		if err := attachReplica(ctx, db, replicaCfg, log); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
+
 	// Enable UUID extension for PostgreSQL
 	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
 		return nil, fmt.Errorf("create uuid extension: %w", err)
@@ -138,23 +128,7 @@ func connectOnce(ctx context.Context, cfg config.DatabaseConfig, log *slog.Logge
 	// Auto-migrate all models only if explicitly enabled
 	if cfg.RunMigrations {
 		log.Info("running database migrations")
-		if err := db.AutoMigrate(
-			&user.User{},
-			&subscription.Subscription{},
-			&course.Course{},
-			&lesson.Lesson{},
-			&attachment.Attachment{},
-			&comment.Comment{},
-			&forum.Forum{},
-			&thread.Thread{},
-			&announcement.Announcement{},
-			&payment.Payment{},
-			&referral.Referral{},
-			&supportticket.SupportTicket{},
-			&groupaccess.GroupAccess{},
-			&packagefeature.Package{},
-			&userwatch.UserWatch{},
-		); err != nil {
+		if err := db.AutoMigrate(Models()...); err != nil {
 			return nil, fmt.Errorf("auto migrate: %w", err)
 		}
 		log.Info("database schema migrated successfully")
@@ -165,12 +139,32 @@ func connectOnce(ctx context.Context, cfg config.DatabaseConfig, log *slog.Logge
 	return db, nil
 }
 
-// Close gracefully closes the underlying sql.DB connection pool.
+// configurePool applies the connection pool settings to sqlDB.
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime) * time.Second)
+	}
+}
+
+// Close gracefully closes the underlying sql.DB connection pools.
 func Close(db *gorm.DB, log *slog.Logger) error {
 	if db == nil {
 		return nil
 	}
 
+	if err := closeReplica(db); err != nil {
+		log.Warn("read replica close failed", slog.String("error", err.Error()))
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return fmt.Errorf("get sql db: %w", err)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/mo-amir99/lms-server-go/pkg/metrics"
+	"github.com/mo-amir99/lms-server-go/pkg/middleware"
 	"gorm.io/gorm/logger"
 )
 
@@ -74,8 +75,10 @@ func (l *CustomLogger) Trace(ctx context.Context, begin time.Time, fc func() (sq
 			slog.Duration("elapsed", elapsed),
 			slog.String("sql", sql),
 			slog.Int64("rows", rows),
+			slog.String("request_id", middleware.RequestIDFromContext(ctx)),
 		)
 	case elapsed > l.slowThreshold && l.slowThreshold != 0 && l.logLevel >= logger.Warn:
+		metrics.RecordSlowDBQuery(operation, table)
 		l.logger.WarnContext(ctx, "slow query detected",
 			slog.Duration("elapsed", elapsed),
 			slog.Duration("threshold", l.slowThreshold),
@@ -83,6 +86,7 @@ func (l *CustomLogger) Trace(ctx context.Context, begin time.Time, fc func() (sq
 			slog.String("table", table),
 			slog.Int64("rows", rows),
 			slog.String("sql", sql),
+			slog.String("request_id", middleware.RequestIDFromContext(ctx)),
 		)
 	case l.logLevel >= logger.Info:
 		l.logger.DebugContext(ctx, "database query",
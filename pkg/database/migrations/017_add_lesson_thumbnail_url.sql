@@ -0,0 +1,4 @@
+-- Add thumbnail_url to lessons
+-- Stores the Bunny Stream thumbnail selected by the instructor for course pages
+
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;
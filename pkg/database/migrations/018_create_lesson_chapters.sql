@@ -0,0 +1,12 @@
+-- Lesson chapters (video chapter markers)
+
+CREATE TABLE IF NOT EXISTS lesson_chapters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    start_time INT NOT NULL DEFAULT 0 CHECK (start_time >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lesson_chapter_start ON lesson_chapters(lesson_id, start_time);
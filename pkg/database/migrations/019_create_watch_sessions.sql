@@ -0,0 +1,21 @@
+-- Per-student playback sessions aggregated from player heartbeats
+
+CREATE TABLE IF NOT EXISTS watch_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    last_position INT NOT NULL DEFAULT 0 CHECK (last_position >= 0),
+    max_position INT NOT NULL DEFAULT 0 CHECK (max_position >= 0),
+    watched_seconds INT NOT NULL DEFAULT 0 CHECK (watched_seconds >= 0),
+    playback_rate NUMERIC(4,2) NOT NULL DEFAULT 1,
+    heartbeat_count INT NOT NULL DEFAULT 0,
+    last_heartbeat_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_user_lesson ON watch_sessions(user_id, lesson_id);
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_lesson_id ON watch_sessions(lesson_id);
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_course_id ON watch_sessions(course_id);
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_last_heartbeat_at ON watch_sessions(last_heartbeat_at);
@@ -0,0 +1,18 @@
+-- Student self-registration invitation links
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    group_id UUID REFERENCES group_access(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    max_uses INT NOT NULL CHECK (max_uses > 0),
+    used_count INT NOT NULL DEFAULT 0 CHECK (used_count >= 0),
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_subscription_id ON invitations(subscription_id);
+CREATE INDEX IF NOT EXISTS idx_invitations_group_id ON invitations(group_id);
@@ -0,0 +1,21 @@
+-- Live stream recordings uploaded to Bunny Stream and converted into lesson drafts
+
+CREATE TABLE IF NOT EXISTS stream_recordings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    host_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stream_id VARCHAR(100) NOT NULL,
+    title VARCHAR(80) NOT NULL,
+    description VARCHAR(1000),
+    video_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    stream_started_at TIMESTAMP NOT NULL,
+    stream_ended_at TIMESTAMP,
+    lesson_id UUID REFERENCES lessons(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_recordings_course_id ON stream_recordings(course_id);
+CREATE INDEX IF NOT EXISTS idx_stream_recordings_stream_id ON stream_recordings(stream_id);
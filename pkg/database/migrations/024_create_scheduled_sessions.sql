@@ -0,0 +1,32 @@
+-- Scheduled live streams and meetings with per-user calendar feeds
+
+CREATE TABLE IF NOT EXISTS scheduled_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID REFERENCES courses(id) ON DELETE SET NULL,
+    group_access_id UUID REFERENCES group_access(id) ON DELETE CASCADE,
+    host_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    host_name VARCHAR(30),
+    type VARCHAR(20) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description VARCHAR(1000),
+    starts_at TIMESTAMP NOT NULL,
+    duration_minutes INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    live_id VARCHAR(100),
+    reminder_sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_sessions_subscription_start ON scheduled_sessions(subscription_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_sessions_status ON scheduled_sessions(status);
+
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS session_id UUID;
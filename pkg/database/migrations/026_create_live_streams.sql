@@ -0,0 +1,28 @@
+-- Live stream history and per-viewer join/leave sessions for host analytics
+
+CREATE TABLE IF NOT EXISTS live_streams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stream_id VARCHAR(100) NOT NULL,
+    host_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id UUID REFERENCES subscriptions(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_live_streams_stream_started ON live_streams(stream_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_live_streams_host_id ON live_streams(host_id);
+
+CREATE TABLE IF NOT EXISTS live_stream_viewers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    live_stream_id UUID NOT NULL REFERENCES live_streams(id) ON DELETE CASCADE,
+    viewer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    left_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_live_stream_viewers_live_stream_id ON live_stream_viewers(live_stream_id);
@@ -0,0 +1,8 @@
+-- Announcement targeting by course, user type and explicit user list
+
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_course_ids UUID[] NOT NULL DEFAULT '{}';
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_user_types TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_user_ids UUID[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_announcements_target_course_ids ON announcements USING GIN (target_course_ids);
+CREATE INDEX IF NOT EXISTS idx_announcements_target_user_ids ON announcements USING GIN (target_user_ids);
@@ -0,0 +1,35 @@
+-- Discount coupons for packages and their redemption history
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL UNIQUE,
+    description VARCHAR(500),
+    discount_type VARCHAR(20) NOT NULL,
+    discount_value NUMERIC(10,2) NOT NULL,
+    max_redemptions INT,
+    per_user_limit INT,
+    redemption_count INT NOT NULL DEFAULT 0,
+    package_ids UUID[] NOT NULL DEFAULT '{}',
+    starts_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupons_expires_at ON coupons(expires_at);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    package_id UUID,
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    purchase_id UUID,
+    original_amount NUMERIC(10,2) NOT NULL,
+    discount NUMERIC(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
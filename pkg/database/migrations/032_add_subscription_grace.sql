@@ -0,0 +1,6 @@
+-- Grace period and dunning progress for expired subscriptions
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS grace_until TIMESTAMP;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS dunning_stage INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_grace_until ON subscriptions(grace_until) WHERE grace_until IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Pub/Sub message IDs for deduplicating Google Play webhook redeliveries
+
+ALTER TABLE iap_webhook_events ADD COLUMN IF NOT EXISTS message_id VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_iap_webhook_events_message ON iap_webhook_events(message_id) WHERE message_id IS NOT NULL;
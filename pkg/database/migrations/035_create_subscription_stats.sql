@@ -0,0 +1,11 @@
+-- Per-subscription dashboard summary, refreshed by the subscription-stats job
+
+CREATE TABLE IF NOT EXISTS subscription_stats (
+    subscription_id UUID PRIMARY KEY REFERENCES subscriptions(id) ON DELETE CASCADE,
+    courses_count BIGINT NOT NULL DEFAULT 0,
+    lessons_count BIGINT NOT NULL DEFAULT 0,
+    students_count BIGINT NOT NULL DEFAULT 0,
+    storage_usage_gb NUMERIC(12,2) NOT NULL DEFAULT 0,
+    refreshed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
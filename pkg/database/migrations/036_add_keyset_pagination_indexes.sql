@@ -0,0 +1,4 @@
+-- Keyset indexes backing cursor pagination (created_at DESC, id DESC)
+
+CREATE INDEX IF NOT EXISTS idx_users_created_id ON users(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_lesson_created_id ON comments(lesson_id, created_at DESC, id DESC);
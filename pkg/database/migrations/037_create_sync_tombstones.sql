@@ -0,0 +1,55 @@
+-- Deletions remembered for the delta sync feed of offline clients. Rows are written
+-- by triggers so every delete path is covered, including cleanup helpers.
+--
+-- Lessons and attachments removed together with their course (or lesson) cascade may
+-- find no parent left to resolve the subscription from; clients drop children of a
+-- deleted course or lesson themselves, so no tombstone is needed for them.
+
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id UUID NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_subscription_deleted ON sync_tombstones(subscription_id, deleted_at);
+
+CREATE OR REPLACE FUNCTION record_sync_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'lessons' THEN
+        INSERT INTO sync_tombstones (subscription_id, entity_type, entity_id)
+        SELECT c.subscription_id, TG_ARGV[0], OLD.id FROM courses c WHERE c.id = OLD.course_id;
+    ELSIF TG_TABLE_NAME = 'attachments' THEN
+        INSERT INTO sync_tombstones (subscription_id, entity_type, entity_id)
+        SELECT c.subscription_id, TG_ARGV[0], OLD.id
+        FROM lessons l JOIN courses c ON c.id = l.course_id
+        WHERE l.id = OLD.lesson_id;
+    ELSE
+        INSERT INTO sync_tombstones (subscription_id, entity_type, entity_id)
+        VALUES (OLD.subscription_id, TG_ARGV[0], OLD.id);
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_courses_sync_tombstone ON courses;
+CREATE TRIGGER trg_courses_sync_tombstone AFTER DELETE ON courses
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('course');
+
+DROP TRIGGER IF EXISTS trg_lessons_sync_tombstone ON lessons;
+CREATE TRIGGER trg_lessons_sync_tombstone AFTER DELETE ON lessons
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('lesson');
+
+DROP TRIGGER IF EXISTS trg_attachments_sync_tombstone ON attachments;
+CREATE TRIGGER trg_attachments_sync_tombstone AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('attachment');
+
+DROP TRIGGER IF EXISTS trg_announcements_sync_tombstone ON announcements;
+CREATE TRIGGER trg_announcements_sync_tombstone AFTER DELETE ON announcements
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('announcement');
+
+-- Group changes alter what students can see, so they force a full sync
+DROP TRIGGER IF EXISTS trg_group_access_sync_tombstone ON group_access;
+CREATE TRIGGER trg_group_access_sync_tombstone AFTER DELETE ON group_access
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('group_access');
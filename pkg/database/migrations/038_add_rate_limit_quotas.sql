@@ -0,0 +1,4 @@
+-- Per-plan API request quotas; 0 on a subscription uses the platform default
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS rate_limit_per_minute INT NOT NULL DEFAULT 0;
+ALTER TABLE subscription_packages ADD COLUMN IF NOT EXISTS rate_limit_per_minute INT;
@@ -0,0 +1,15 @@
+-- Per-subscription and per-plan capability switches; flags without an override are enabled
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    flag VARCHAR(50) NOT NULL,
+    subscription_id UUID REFERENCES subscriptions(id) ON DELETE CASCADE,
+    package_id UUID REFERENCES subscription_packages(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_feature_flags_target CHECK ((subscription_id IS NULL) <> (package_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feature_flags_subscription ON feature_flags(subscription_id, flag) WHERE subscription_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feature_flags_package ON feature_flags(package_id, flag) WHERE package_id IS NOT NULL;
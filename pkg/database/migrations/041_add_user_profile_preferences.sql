@@ -0,0 +1,9 @@
+-- Add self-service profile fields to users
+-- The avatar is a Bunny Storage URL; language and timezone localise emails and dashboards
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferred_language VARCHAR(10) NOT NULL DEFAULT 'en';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- Queued emails keep the recipient's language for the HTML wrapper
+ALTER TABLE email_messages ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en';
@@ -0,0 +1,4 @@
+-- Mark users whose personal data was scrubbed on delete
+-- Their rows stay so watch sessions, enrollments and purchases keep counting in analytics
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Add a per-user token version embedded in issued JWTs
+-- Bumping it on password change or deactivation revokes every outstanding token
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Add drip scheduling to lessons
+-- A lesson opens for students at available_from, or a number of days after they enrolled, whichever is later
+
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS available_from TIMESTAMP;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS available_after_days_enrolled INT;
@@ -0,0 +1,4 @@
+-- Add prerequisite lessons that must be completed before a lesson unlocks for students
+
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS prerequisites UUID[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_lessons_prerequisites ON lessons USING GIN (prerequisites);
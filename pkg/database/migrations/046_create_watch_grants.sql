@@ -0,0 +1,14 @@
+-- Extra watches granted to a student for one lesson on top of the subscription watch limit
+
+CREATE TABLE IF NOT EXISTS watch_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    extra_watches INT NOT NULL CHECK (extra_watches > 0),
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason VARCHAR(500),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_watch_grants_user_lesson ON watch_grants(user_id, lesson_id);
@@ -0,0 +1,6 @@
+-- Allow lessons to override the subscription watch limit and interval
+-- NULL keeps the subscription value; unlimited_watches lifts the limit entirely
+
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS watch_limit INT;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS watch_interval INT;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS unlimited_watches BOOLEAN NOT NULL DEFAULT FALSE;
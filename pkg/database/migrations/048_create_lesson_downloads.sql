@@ -0,0 +1,16 @@
+-- Offline download authorizations: each row licenses one lesson video on one device until it expires or is revoked
+
+CREATE TABLE IF NOT EXISTS lesson_downloads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    device_id VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_downloads_user_lesson ON lesson_downloads(user_id, lesson_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_downloads_user_device ON lesson_downloads(user_id, device_id);
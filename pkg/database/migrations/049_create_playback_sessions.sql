@@ -0,0 +1,17 @@
+-- Every signed video URL handed out is recorded with the code shown in its watermark,
+-- so a leaked recording can be traced back to the student who played it
+
+CREATE TABLE IF NOT EXISTS playback_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(12) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    ip_address VARCHAR(64),
+    user_agent VARCHAR(500),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_playback_sessions_code ON playback_sessions(code);
+CREATE INDEX IF NOT EXISTS idx_playback_sessions_user ON playback_sessions(user_id);
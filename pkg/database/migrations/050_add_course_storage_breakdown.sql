@@ -0,0 +1,18 @@
+-- Track cover image storage separately, remember the last quota alert sent per course
+-- and keep one storage snapshot per course and day for usage history
+
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS cover_storage_gb NUMERIC(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS storage_alert_level INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS course_storage_snapshots (
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    taken_on DATE NOT NULL,
+    stream_storage_gb NUMERIC(10,2) NOT NULL DEFAULT 0,
+    file_storage_gb NUMERIC(10,2) NOT NULL DEFAULT 0,
+    cover_storage_gb NUMERIC(10,2) NOT NULL DEFAULT 0,
+    total_storage_gb NUMERIC(10,2) NOT NULL DEFAULT 0,
+    limit_gb NUMERIC(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (course_id, taken_on)
+);
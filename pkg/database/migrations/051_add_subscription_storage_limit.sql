@@ -0,0 +1,4 @@
+-- Cap the combined storage of all courses in a subscription; 0 derives the cap
+-- from courses_limit x course_limit_in_gb
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS storage_limit_in_gb NUMERIC(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Resized copies of uploaded course covers and image attachments
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS image_variants JSONB;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS variants JSONB;
@@ -0,0 +1,19 @@
+-- Uploads flagged by the virus scanner; blocked files are kept on the
+-- receiving server under quarantine_path and never reach Bunny Storage
+CREATE TABLE IF NOT EXISTS attachment_quarantine (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL,
+    lesson_id UUID NOT NULL,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    file_name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    signature VARCHAR(255) NOT NULL,
+    blocked BOOLEAN NOT NULL DEFAULT TRUE,
+    quarantine_path TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachment_quarantine_subscription ON attachment_quarantine(subscription_id);
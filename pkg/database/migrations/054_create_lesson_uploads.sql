@@ -0,0 +1,26 @@
+-- Video uploads relayed to Bunny Stream through the API. The Bunny upload URL
+-- and its signature never leave the server; the lesson is created once
+-- uploaded_bytes reaches size
+CREATE TABLE IF NOT EXISTS lesson_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    video_id VARCHAR(255) NOT NULL,
+    upload_url TEXT NOT NULL,
+    auth_signature VARCHAR(255) NOT NULL,
+    auth_expire BIGINT NOT NULL,
+    lesson_name VARCHAR(80) NOT NULL,
+    description VARCHAR(1000),
+    lesson_order INT,
+    is_active BOOLEAN,
+    size BIGINT NOT NULL,
+    uploaded_bytes BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'uploading',
+    lesson_id UUID REFERENCES lessons(id) ON DELETE SET NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_uploads_course ON lesson_uploads(course_id, status);
@@ -0,0 +1,20 @@
+-- Pending and past swaps of a lesson's Bunny video. The lesson keeps its old
+-- video until the new one finishes processing; old_video_id stays here so the
+-- old video's analytics can still be found
+CREATE TABLE IF NOT EXISTS lesson_video_replacements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL,
+    old_video_id VARCHAR(255) NOT NULL,
+    new_video_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    error TEXT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_video_replacements_lesson ON lesson_video_replacements(lesson_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_lesson_video_replacements_pending ON lesson_video_replacements(status) WHERE status = 'pending';
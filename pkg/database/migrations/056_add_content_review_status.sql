@@ -0,0 +1,17 @@
+-- Review workflow for courses and lessons. Existing content stays published;
+-- students only ever see rows with status 'published'
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published';
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS review_note TEXT;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS submitted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published';
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS review_note TEXT;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS submitted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_courses_status ON courses(subscription_id, status);
+CREATE INDEX IF NOT EXISTS idx_lessons_status ON lessons(course_id, status);
+
+-- Status a proxied upload's lesson is created with, resolved for the uploader
+ALTER TABLE lesson_uploads ADD COLUMN IF NOT EXISTS lesson_status VARCHAR(20) NOT NULL DEFAULT 'published';
@@ -0,0 +1,34 @@
+-- Course categories and tags, both scoped to a subscription. A course sits in at
+-- most one category and carries any number of tags
+CREATE TABLE IF NOT EXISTS course_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    name VARCHAR(60) NOT NULL,
+    description VARCHAR(500),
+    "order" INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_course_categories_name ON course_categories(subscription_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS course_tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    name VARCHAR(40) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_course_tags_name ON course_tags(subscription_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS course_tag_links (
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES course_tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (course_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_tag_links_tag ON course_tag_links(tag_id);
+
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES course_categories(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_courses_category ON courses(category_id);
@@ -0,0 +1,26 @@
+-- Courses opted into the public catalog, with an optional price and the group
+-- approved students join
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS is_public BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS price NUMERIC(10,2);
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS enrollment_group_id UUID REFERENCES group_access(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_courses_public ON courses(subscription_id) WHERE is_public;
+
+-- Students asking to join a public course; staff approve or reject them
+CREATE TABLE IF NOT EXISTS enrollment_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message VARCHAR(500),
+    group_access_id UUID REFERENCES group_access(id) ON DELETE SET NULL,
+    review_note VARCHAR(500),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrollment_requests_subscription ON enrollment_requests(subscription_id, status, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_enrollment_requests_pending ON enrollment_requests(course_id, user_id) WHERE status = 'pending';
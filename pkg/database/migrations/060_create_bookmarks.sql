@@ -0,0 +1,15 @@
+-- Courses and lessons students saved for later. Lesson bookmarks also carry
+-- their course so listings and cleanup need no join
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    lesson_id UUID REFERENCES lessons(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user ON bookmarks(user_id, subscription_id, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmarks_course ON bookmarks(user_id, course_id) WHERE lesson_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmarks_lesson ON bookmarks(user_id, lesson_id) WHERE lesson_id IS NOT NULL;
@@ -0,0 +1,50 @@
+-- Structured Q&A per lesson, kept apart from the free-form comment stream.
+-- Answer counts and upvotes are denormalised for sorting and the unanswered filter
+CREATE TABLE IF NOT EXISTS lesson_questions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_name VARCHAR(255) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT,
+    accepted_answer_id UUID, -- cleared by the app when the answer is deleted
+    answer_count INT NOT NULL DEFAULT 0,
+    upvotes INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_questions_lesson ON lesson_questions(lesson_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_lesson_questions_course ON lesson_questions(course_id, answer_count, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS lesson_answers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    question_id UUID NOT NULL REFERENCES lesson_questions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_name VARCHAR(255) NOT NULL,
+    user_type VARCHAR(20) NOT NULL,
+    body TEXT NOT NULL,
+    is_endorsed BOOLEAN NOT NULL DEFAULT FALSE,
+    endorsed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    upvotes INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_answers_question ON lesson_answers(question_id);
+
+CREATE TABLE IF NOT EXISTS lesson_question_votes (
+    question_id UUID NOT NULL REFERENCES lesson_questions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (question_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS lesson_answer_votes (
+    answer_id UUID NOT NULL REFERENCES lesson_answers(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (answer_id, user_id)
+);
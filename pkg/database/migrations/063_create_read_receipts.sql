@@ -0,0 +1,11 @@
+-- How far each user has read a chat: a forum thread's replies or a live
+-- stream's chat. Only ever moves forward
+CREATE TABLE IF NOT EXISTS read_receipts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    channel_id VARCHAR(100) NOT NULL,
+    message_id VARCHAR(100) NOT NULL,
+    read_through TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (channel, channel_id, user_id)
+);
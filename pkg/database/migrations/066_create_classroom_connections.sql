@@ -0,0 +1,31 @@
+-- Google Classroom accounts connected by a subscription to import rosters from.
+-- A connection holds the OAuth state while the consent screen is open and the
+-- tokens once Google redirects back. Imported classes are linked to the group
+-- access entry they fill, so importing a class again updates the same group
+CREATE TABLE IF NOT EXISTS classroom_connections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    connected_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    google_email VARCHAR(255),
+    access_token TEXT,
+    refresh_token TEXT,
+    token_type VARCHAR(20),
+    token_expiry TIMESTAMP,
+    state VARCHAR(64),
+    state_expires_at TIMESTAMP,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    connected_at TIMESTAMP,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_classroom_connections_subscription ON classroom_connections(subscription_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_classroom_connections_state ON classroom_connections(state) WHERE state IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS classroom_course_links (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    course_id VARCHAR(64) NOT NULL,
+    group_id UUID NOT NULL REFERENCES group_access(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subscription_id, course_id)
+);
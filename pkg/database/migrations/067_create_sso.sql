@@ -0,0 +1,55 @@
+-- Single sign-on providers configured by a subscription, the external
+-- identities linked to its users and the sign-ins in progress. A sign-in holds
+-- the OAuth state until the provider redirects back, then the one-time code the
+-- frontend exchanges for tokens or confirms an account link with
+CREATE TABLE IF NOT EXISTS sso_providers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    issuer VARCHAR(500) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL,
+    allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+    auto_provision BOOLEAN NOT NULL DEFAULT FALSE,
+    default_group_id UUID REFERENCES group_access(id) ON DELETE SET NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_providers_subscription ON sso_providers(subscription_id);
+
+CREATE TABLE IF NOT EXISTS sso_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider_id UUID NOT NULL REFERENCES sso_providers(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    last_login_at TIMESTAMP,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sso_identities_subject ON sso_identities(provider_id, subject);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sso_identities_user ON sso_identities(provider_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_sso_identities_user_id ON sso_identities(user_id);
+
+CREATE TABLE IF NOT EXISTS sso_logins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider_id UUID NOT NULL REFERENCES sso_providers(id) ON DELETE CASCADE,
+    state VARCHAR(64) NOT NULL,
+    verifier VARCHAR(128) NOT NULL,
+    purpose VARCHAR(10) NOT NULL DEFAULT 'pending',
+    code VARCHAR(64),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    subject VARCHAR(255),
+    email VARCHAR(255),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sso_logins_state ON sso_logins(state);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sso_logins_code ON sso_logins(code) WHERE code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sso_logins_expires ON sso_logins(expires_at);
@@ -0,0 +1,5 @@
+-- SAML 2.0 providers keep the IdP's entity ID in issuer, and its sign-on URL,
+-- signing certificates and the attributes user fields are read from here
+ALTER TABLE sso_providers ADD COLUMN IF NOT EXISTS saml_sso_url VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE sso_providers ADD COLUMN IF NOT EXISTS saml_certificates TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE sso_providers ADD COLUMN IF NOT EXISTS attribute_map JSONB;
@@ -0,0 +1,10 @@
+-- Platform maintenance mode; a single row, absent until first switched on
+
+CREATE TABLE IF NOT EXISTS maintenance_mode (
+    id INT PRIMARY KEY CHECK (id = 1),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    message TEXT NOT NULL DEFAULT '',
+    ends_at TIMESTAMPTZ,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
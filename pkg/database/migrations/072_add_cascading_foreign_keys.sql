@@ -0,0 +1,58 @@
+-- Foreign keys with explicit ON DELETE actions for the tables AutoMigrate
+-- creates, which had none or NO ACTION ones, so deleting a subscription,
+-- course, lesson, forum or comment removes what belongs to it. A key already
+-- in place with its action is left alone; otherwise rows left behind by
+-- earlier partial deletes are removed, or their reference cleared, and the key
+-- is (re)created. Parents come before children so orphans are removed down
+-- the tree. Orphaned payments are financial records and are never removed:
+-- the migration stops and lists them so they can be resolved by hand.
+
+DO $$
+DECLARE
+    fk RECORD;
+    orphan_ids TEXT;
+BEGIN
+    FOR fk IN
+        SELECT * FROM (VALUES
+            (1,  'courses',       'fk_courses_subscription',       'subscription_id', 'subscriptions',         'CASCADE',  'c', 'delete'),
+            (2,  'lessons',       'fk_lessons_course',             'course_id',       'courses',               'CASCADE',  'c', 'delete'),
+            (3,  'attachments',   'fk_lessons_attachments',        'lesson_id',       'lessons',               'CASCADE',  'c', 'delete'),
+            (4,  'comments',      'fk_comments_lesson',            'lesson_id',       'lessons',               'CASCADE',  'c', 'delete'),
+            (5,  'comments',      'fk_comments_parent',            'parent_id',       'comments',              'CASCADE',  'c', 'delete'),
+            (6,  'forums',        'fk_forums_subscription',        'subscription_id', 'subscriptions',         'CASCADE',  'c', 'delete'),
+            (7,  'threads',       'fk_threads_forum',              'forum_id',        'forums',                'CASCADE',  'c', 'delete'),
+            (8,  'users',         'fk_users_subscription',         'subscription_id', 'subscriptions',         'CASCADE',  'c', 'null'),
+            (9,  'announcements', 'fk_announcements_subscription', 'subscription_id', 'subscriptions',         'CASCADE',  'c', 'delete'),
+            (10, 'payments',      'fk_payments_subscription',      'subscription_id', 'subscriptions',         'CASCADE',  'c', 'raise'),
+            (11, 'group_access',  'fk_group_access_subscription',  'subscription_id', 'subscriptions',         'CASCADE',  'c', 'delete'),
+            -- A package cannot be deleted while subscriptions are on it
+            (12, 'subscriptions', 'fk_subscriptions_package',      'package_id',      'subscription_packages', 'RESTRICT', 'r', 'null')
+        ) AS keys(ord, child, name, col, parent, action, action_code, orphans)
+        ORDER BY ord
+    LOOP
+        IF EXISTS (
+            SELECT 1 FROM pg_constraint
+            WHERE conname = fk.name AND conrelid = fk.child::regclass AND confdeltype::text = fk.action_code
+        ) THEN
+            CONTINUE;
+        END IF;
+
+        IF fk.orphans = 'delete' THEN
+            EXECUTE format('DELETE FROM %I c WHERE c.%I IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %I p WHERE p.id = c.%I)',
+                fk.child, fk.col, fk.parent, fk.col);
+        ELSIF fk.orphans = 'raise' THEN
+            EXECUTE format('SELECT string_agg(c.id::text, '', '') FROM %I c WHERE c.%I IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %I p WHERE p.id = c.%I)',
+                fk.child, fk.col, fk.parent, fk.col) INTO orphan_ids;
+            IF orphan_ids IS NOT NULL THEN
+                RAISE EXCEPTION '% rows reference a missing % through %: %', fk.child, fk.parent, fk.col, orphan_ids;
+            END IF;
+        ELSE
+            EXECUTE format('UPDATE %I c SET %I = NULL WHERE c.%I IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %I p WHERE p.id = c.%I)',
+                fk.child, fk.col, fk.col, fk.parent, fk.col);
+        END IF;
+
+        EXECUTE format('ALTER TABLE %I DROP CONSTRAINT IF EXISTS %I', fk.child, fk.name);
+        EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY (%I) REFERENCES %I(id) ON DELETE %s',
+            fk.child, fk.name, fk.col, fk.parent, fk.action);
+    END LOOP;
+END $$;
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// The SQL migrations are compiled into the binary, so they apply the same
+// whatever directory the process runs from.
+//
+//go:embed *.sql
+var embeddedSQL embed.FS
+
+// SQLFile is one SQL migration.
+type SQLFile struct {
+	Name string
+	SQL  string
+}
+
+// SQLFiles returns the SQL migrations in file name order. They come from dir
+// when it is set, for trying out migrations without rebuilding, and from the
+// binary otherwise.
+func SQLFiles(dir string) ([]SQLFile, error) {
+	var fsys fs.FS = embeddedSQL
+	if dir != "" {
+		fsys = os.DirFS(dir)
+	}
+
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		if dir == "" {
+			dir = "the binary"
+		}
+		return nil, fmt.Errorf("no SQL migrations found in %s", dir)
+	}
+	sort.Strings(names)
+
+	files := make([]SQLFile, 0, len(names))
+	for _, name := range names {
+		sql, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		files = append(files, SQLFile{Name: name, SQL: string(sql)})
+	}
+	return files, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"github.com/mo-amir99/lms-server-go/internal/features/announcement"
+	"github.com/mo-amir99/lms-server-go/internal/features/attachment"
+	"github.com/mo-amir99/lms-server-go/internal/features/bookmark"
+	"github.com/mo-amir99/lms-server-go/internal/features/chapter"
+	"github.com/mo-amir99/lms-server-go/internal/features/classroom"
+	"github.com/mo-amir99/lms-server-go/internal/features/comment"
+	"github.com/mo-amir99/lms-server-go/internal/features/contentsync"
+	"github.com/mo-amir99/lms-server-go/internal/features/coupon"
+	"github.com/mo-amir99/lms-server-go/internal/features/course"
+	"github.com/mo-amir99/lms-server-go/internal/features/dashboard"
+	"github.com/mo-amir99/lms-server-go/internal/features/download"
+	"github.com/mo-amir99/lms-server-go/internal/features/emailqueue"
+	"github.com/mo-amir99/lms-server-go/internal/features/featureflag"
+	"github.com/mo-amir99/lms-server-go/internal/features/forum"
+	"github.com/mo-amir99/lms-server-go/internal/features/gradebook"
+	"github.com/mo-amir99/lms-server-go/internal/features/groupaccess"
+	"github.com/mo-amir99/lms-server-go/internal/features/idempotency"
+	"github.com/mo-amir99/lms-server-go/internal/features/invitation"
+	"github.com/mo-amir99/lms-server-go/internal/features/lesson"
+	"github.com/mo-amir99/lms-server-go/internal/features/lessonnote"
+	"github.com/mo-amir99/lms-server-go/internal/features/lessonqa"
+	"github.com/mo-amir99/lms-server-go/internal/features/maintenance"
+	"github.com/mo-amir99/lms-server-go/internal/features/meeting"
+	"github.com/mo-amir99/lms-server-go/internal/features/message"
+	"github.com/mo-amir99/lms-server-go/internal/features/notification"
+	"github.com/mo-amir99/lms-server-go/internal/features/outbox"
+	packagefeature "github.com/mo-amir99/lms-server-go/internal/features/package"
+	"github.com/mo-amir99/lms-server-go/internal/features/payment"
+	"github.com/mo-amir99/lms-server-go/internal/features/playback"
+	"github.com/mo-amir99/lms-server-go/internal/features/readreceipt"
+	"github.com/mo-amir99/lms-server-go/internal/features/referral"
+	"github.com/mo-amir99/lms-server-go/internal/features/role"
+	"github.com/mo-amir99/lms-server-go/internal/features/scheduledsession"
+	"github.com/mo-amir99/lms-server-go/internal/features/scim"
+	"github.com/mo-amir99/lms-server-go/internal/features/setting"
+	"github.com/mo-amir99/lms-server-go/internal/features/sso"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamanalytics"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamchat"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamrecording"
+	"github.com/mo-amir99/lms-server-go/internal/features/subscription"
+	"github.com/mo-amir99/lms-server-go/internal/features/supportticket"
+	"github.com/mo-amir99/lms-server-go/internal/features/thread"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/internal/features/userwatch"
+	"github.com/mo-amir99/lms-server-go/internal/features/watchsession"
+	"github.com/mo-amir99/lms-server-go/internal/features/webhook"
+	"github.com/mo-amir99/lms-server-go/internal/services/storageusage"
+)
+
+// Models lists every GORM model in migration order. Both the app's opt-in
+// auto-migration and scripts/migrate use it so the two cannot drift apart.
+func Models() []any {
+	return []any{
+		&user.User{},
+		&subscription.Subscription{},
+		&course.Category{},
+		&course.Tag{},
+		&course.Course{},
+		&course.EnrollmentRequest{},
+		&storageusage.Snapshot{},
+		&lesson.Lesson{},
+		&lesson.Upload{},
+		&lesson.Replacement{},
+		&bookmark.Bookmark{},
+		&lessonnote.Note{},
+		&lessonqa.Question{},
+		&lessonqa.Answer{},
+		&lessonqa.QuestionVote{},
+		&lessonqa.AnswerVote{},
+		&streamrecording.Recording{},
+		&streamanalytics.StreamRecord{},
+		&streamanalytics.ViewerRecord{},
+		&streamchat.Settings{},
+		&attachment.Attachment{},
+		&attachment.Quarantine{},
+		&chapter.Chapter{},
+		&comment.Comment{},
+		&forum.Forum{},
+		&thread.Thread{},
+		&notification.ThreadSubscription{},
+		&readreceipt.Receipt{},
+		&message.Conversation{},
+		&message.Member{},
+		&message.Message{},
+		&notification.Notification{},
+		&announcement.Announcement{},
+		&payment.Payment{},
+		&coupon.Coupon{},
+		&coupon.Redemption{},
+		&referral.Referral{},
+		&referral.Code{},
+		&referral.RewardRule{},
+		&referral.Credit{},
+		&supportticket.SupportTicket{},
+		&groupaccess.GroupAccess{},
+		&scheduledsession.Session{},
+		&meeting.Record{},
+		&meeting.ParticipantRecord{},
+		&gradebook.Weights{},
+		&gradebook.Assignment{},
+		&gradebook.Mark{},
+		&gradebook.QuizAttempt{},
+		&scheduledsession.CalendarFeed{},
+		&invitation.Invitation{},
+		&emailqueue.Message{},
+		&outbox.Event{},
+		&webhook.Endpoint{},
+		&webhook.Delivery{},
+		&classroom.Connection{},
+		&classroom.CourseLink{},
+		&sso.Provider{},
+		&sso.Identity{},
+		&sso.Login{},
+		&scim.Token{},
+		&scim.Link{},
+		&dashboard.SubscriptionStats{},
+		&contentsync.Tombstone{},
+		&role.Role{},
+		&role.RolePermission{},
+		&packagefeature.Package{},
+		&userwatch.UserWatch{},
+		&userwatch.Grant{},
+		&download.Download{},
+		&playback.Session{},
+		&watchsession.WatchSession{},
+		&setting.Setting{},
+		&featureflag.Override{},
+		&maintenance.Mode{},
+		&idempotency.Record{},
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+	"github.com/mo-amir99/lms-server-go/pkg/metrics"
+	"github.com/mo-amir99/lms-server-go/pkg/replica"
+)
+
+// attachReplica connects to the read replica and registers it on db.
+func attachReplica(ctx context.Context, db *gorm.DB, cfg config.DatabaseConfig, log *slog.Logger) error {
+	replicaDB, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger:                 db.Config.Logger,
+		PrepareStmt:            true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open replica: %w", err)
+	}
+
+	sqlDB, err := replicaDB.DB()
+	if err != nil {
+		return fmt.Errorf("get replica sql db: %w", err)
+	}
+	configurePool(sqlDB, cfg)
+	metrics.RegisterDBStats(sqlDB, "replica")
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := sqlDB.PingContext(pingCtx); err != nil {
+		sqlDB.Close()
+		return fmt.Errorf("ping replica: %w", err)
+	}
+
+	if err := db.Use(replica.NewPlugin(replicaDB.ConnPool, sqlDB)); err != nil {
+		sqlDB.Close()
+		return fmt.Errorf("register replica plugin: %w", err)
+	}
+
+	log.Info("read replica connected", slog.String("host", cfg.Host))
+	return nil
+}
+
+// closeReplica closes the replica pool registered on db, if any.
+func closeReplica(db *gorm.DB) error {
+	plugin, ok := replica.Lookup(db)
+	if !ok {
+		return nil
+	}
+	return plugin.Close()
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	tracerName   = "github.com/mo-amir99/lms-server-go/pkg/database"
+	spanSetting  = "tracing:span"
+	maxQueryText = 2048
+)
+
+// TracingPlugin is a GORM plugin that records a client span for every
+// statement, as a child of the span in the statement's context.
+type TracingPlugin struct {
+	tracer trace.Tracer
+}
+
+// NewTracingPlugin creates a new tracing plugin.
+func NewTracingPlugin() *TracingPlugin {
+	return &TracingPlugin{tracer: otel.Tracer(tracerName)}
+}
+
+// Name returns the plugin name.
+func (p *TracingPlugin) Name() string {
+	return "tracing_plugin"
+}
+
+// Initialize registers span callbacks around each statement type.
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("tracing:before_create", p.before("create")); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("tracing:after_create", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register("tracing:before_query", p.before("query")); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("tracing:after_query", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tracing:before_update", p.before("update")); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("tracing:after_update", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register("tracing:after_delete", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tracing:before_row", p.before("row")); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("tracing:after_row", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().After("gorm:raw").Register("tracing:after_raw", p.after); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *TracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// Statements outside a traced request or job would each start a new trace
+			return
+		}
+
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+
+		_, span := p.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		)
+		if db.Statement.Table != "" {
+			span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+		}
+		db.InstanceSet(spanSetting, span)
+	}
+}
+
+func (p *TracingPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanSetting)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	// Placeholders only; bound values may hold personal data
+	query := db.Statement.SQL.String()
+	if len(query) > maxQueryText {
+		query = query[:maxQueryText]
+	}
+	if operation, _, _ := strings.Cut(strings.TrimSpace(query), " "); operation != "" {
+		span.SetAttributes(semconv.DBOperationName(strings.ToUpper(operation)))
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(query),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -1,7 +1,6 @@
 package email
 
 import (
-	"bytes"
 	"fmt"
 	"html/template"
 	"net/smtp"
@@ -36,12 +35,14 @@ type EmailOptions struct {
 	Subject string
 	HTML    string
 	Text    string
+	// Language sets the lang and text direction of the HTML wrapper; empty means DefaultLanguage.
+	Language string
 }
 
 // SendEmail sends an email with HTML content.
 func (c *Client) SendEmail(opts EmailOptions) error {
 	// Wrap HTML in template
-	wrappedHTML := c.wrapHTMLTemplate(opts.HTML)
+	wrappedHTML := c.wrapHTMLTemplate(opts.HTML, opts.Language)
 
 	// Build message
 	message := c.buildMessage(opts.To, opts.Subject, wrappedHTML, opts.Text)
@@ -58,46 +59,25 @@ func (c *Client) SendEmail(opts EmailOptions) error {
 	return nil
 }
 
-// wrapHTMLTemplate wraps the HTML content in a nice template.
-func (c *Client) wrapHTMLTemplate(content string) string {
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-</head>
-<body style="margin: 0; padding: 0; font-family: Arial, sans-serif; background: #f9f9f9;">
-    <div style="padding: 32px;">
-        <div style="max-width: 600px; margin: auto; background: #fff; border-radius: 8px; box-shadow: 0 2px 8px #eee; padding: 32px;">
-            <div style="text-align: center; margin-bottom: 24px;">
-                <h2 style="color: #2a7ae2; margin: 0;">Elites Academy Notification</h2>
-            </div>
-            <div style="font-size: 16px; color: #333;">
-                {{.Content}}
-            </div>
-            <div style="margin-top: 32px; text-align: center; color: #aaa; font-size: 12px;">
-                &copy; {{.Year}} Elites Academy. All rights reserved.
-            </div>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("email").Parse(tmpl))
-	var buf bytes.Buffer
+// wrapHTMLTemplate wraps the HTML content in the layout template.
+func (c *Client) wrapHTMLTemplate(content, language string) string {
+	if language == "" {
+		language = DefaultLanguage
+	}
 	data := map[string]interface{}{
-		"Content": template.HTML(content),
-		"Year":    time.Now().Year(),
+		"Content":   template.HTML(content),
+		"Year":      time.Now().Year(),
+		"Language":  language,
+		"Direction": direction(language),
 	}
 
-	if err := t.Execute(&buf, data); err != nil {
+	html, err := render(layoutTemplate, data)
+	if err != nil {
 		// Fallback to plain content if template fails
 		return content
 	}
 
-	return buf.String()
+	return html
 }
 
 // buildMessage constructs the email message with headers.
@@ -134,76 +114,106 @@ func (c *Client) buildMessage(to, subject, html, text string) string {
 
 // SendPasswordReset sends a password reset email with a token.
 func (c *Client) SendPasswordReset(to, resetToken, resetURL string) error {
-	html := fmt.Sprintf(`
-		<p>Hello,</p>
-		<p>You requested to reset your password. Click the link below to reset your password:</p>
-		<p style="text-align: center; margin: 24px 0;">
-			<a href="%s?token=%s" style="background: #2a7ae2; color: #fff; padding: 12px 24px; text-decoration: none; border-radius: 4px; display: inline-block;">
-				Reset Password
-			</a>
-		</p>
-		<p>If you did not request this, please ignore this email.</p>
-		<p>This link will expire in 1 hour.</p>
-	`, resetURL, resetToken)
-
-	return c.SendEmail(EmailOptions{
+	return c.SendEmail(PasswordResetMessage(to, resetToken, resetURL))
+}
+
+// SendEmailVerification sends an email verification link.
+func (c *Client) SendEmailVerification(to, verificationToken, verificationURL string) error {
+	return c.SendEmail(EmailVerificationMessage(to, verificationToken, verificationURL))
+}
+
+// SendWelcome sends a welcome email to a new user.
+func (c *Client) SendWelcome(to, userName string) error {
+	return c.SendEmail(WelcomeMessage(to, userName))
+}
+
+// SendNotification sends a general notification email.
+func (c *Client) SendNotification(to, title, message string) error {
+	return c.SendEmail(NotificationMessage(to, title, message))
+}
+
+// PasswordResetMessage builds the password reset email.
+func PasswordResetMessage(to, resetToken, resetURL string) EmailOptions {
+	text := fmt.Sprintf("Reset your password: %s?token=%s", resetURL, resetToken)
+	html := renderBody("password_reset.html", map[string]interface{}{
+		"URL":   resetURL,
+		"Token": resetToken,
+	}, text)
+
+	return EmailOptions{
 		To:      to,
 		Subject: "Password Reset Request",
 		HTML:    html,
-		Text:    fmt.Sprintf("Reset your password: %s?token=%s", resetURL, resetToken),
-	})
+		Text:    text,
+	}
 }
 
-// SendEmailVerification sends an email verification link.
-func (c *Client) SendEmailVerification(to, verificationToken, verificationURL string) error {
-	html := fmt.Sprintf(`
-		<p>Hello,</p>
-		<p>Welcome! Please verify your email address by clicking the link below:</p>
-		<p style="text-align: center; margin: 24px 0;">
-			<a href="%s?token=%s" style="background: #2a7ae2; color: #fff; padding: 12px 24px; text-decoration: none; border-radius: 4px; display: inline-block;">
-				Verify Email
-			</a>
-		</p>
-		<p>If you did not create this account, please ignore this email.</p>
-	`, verificationURL, verificationToken)
-
-	return c.SendEmail(EmailOptions{
+// EmailVerificationMessage builds the email verification email.
+func EmailVerificationMessage(to, verificationToken, verificationURL string) EmailOptions {
+	text := fmt.Sprintf("Verify your email: %s?token=%s", verificationURL, verificationToken)
+	html := renderBody("email_verification.html", map[string]interface{}{
+		"URL":   verificationURL,
+		"Token": verificationToken,
+	}, text)
+
+	return EmailOptions{
 		To:      to,
 		Subject: "Verify Your Email Address",
 		HTML:    html,
-		Text:    fmt.Sprintf("Verify your email: %s?token=%s", verificationURL, verificationToken),
-	})
+		Text:    text,
+	}
 }
 
-// SendWelcome sends a welcome email to a new user.
-func (c *Client) SendWelcome(to, userName string) error {
-	html := fmt.Sprintf(`
-		<p>Hello %s,</p>
-		<p>Welcome to Elites Academy! We're excited to have you on board.</p>
-		<p>Get started by adding your first course.</p>
-		<p>If you have any questions, feel free to reach out to our support team.</p>
-		<p>Happy teaching!</p>
-	`, userName)
-
-	return c.SendEmail(EmailOptions{
+// WelcomeMessage builds the welcome email for a new user.
+func WelcomeMessage(to, userName string) EmailOptions {
+	text := fmt.Sprintf("Hello %s, Welcome to Elites Academy!", userName)
+	html := renderBody("welcome.html", map[string]interface{}{"Name": userName}, text)
+
+	return EmailOptions{
 		To:      to,
 		Subject: "Welcome to Elites Academy!",
 		HTML:    html,
-		Text:    fmt.Sprintf("Hello %s, Welcome to Elites Academy!", userName),
-	})
+		Text:    text,
+	}
 }
 
-// SendNotification sends a general notification email.
-func (c *Client) SendNotification(to, title, message string) error {
-	html := fmt.Sprintf(`
-		<h3 style="color: #2a7ae2;">%s</h3>
-		<p>%s</p>
-	`, title, message)
+// NotificationMessage builds a general notification email.
+func NotificationMessage(to, title, message string) EmailOptions {
+	html := renderBody("notification.html", map[string]interface{}{
+		"Title":   title,
+		"Message": message,
+	}, message)
 
-	return c.SendEmail(EmailOptions{
+	return EmailOptions{
 		To:      to,
 		Subject: title,
 		HTML:    html,
 		Text:    message,
-	})
+	}
+}
+
+// SubscriptionDunningMessage builds the renewal reminder sent after a subscription expires.
+// accessUntil is the end of the grace period; when it is not after expiredOn the
+// subscription has already been deactivated. Dates are shown in the recipient's timezone.
+func SubscriptionDunningMessage(to, userName, subscriptionName string, expiredOn, accessUntil time.Time, locale Locale) EmailOptions {
+	status := "The subscription has been deactivated."
+	if accessUntil.After(expiredOn) {
+		status = fmt.Sprintf("Your students keep read-only access until %s. After that the subscription will be deactivated.", locale.Date(accessUntil))
+	}
+
+	text := fmt.Sprintf("Hello %s, your subscription %s expired on %s. %s", userName, subscriptionName, locale.Date(expiredOn), status)
+	html := renderBody("subscription_dunning.html", map[string]interface{}{
+		"Name":         userName,
+		"Subscription": subscriptionName,
+		"ExpiredOn":    locale.Date(expiredOn),
+		"Status":       status,
+	}, text)
+
+	return EmailOptions{
+		To:       to,
+		Subject:  fmt.Sprintf("Your subscription %s has expired", subscriptionName),
+		HTML:     html,
+		Text:     text,
+		Language: locale.Language,
+	}
 }
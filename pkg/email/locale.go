@@ -0,0 +1,62 @@
+package email
+
+import (
+	"slices"
+	"time"
+)
+
+// Languages users can choose for emails and dashboards.
+const (
+	LanguageEnglish = "en"
+	LanguageArabic  = "ar"
+)
+
+// DefaultLanguage and DefaultTimezone apply to users who never set a preference.
+const (
+	DefaultLanguage = LanguageEnglish
+	DefaultTimezone = "UTC"
+)
+
+// Languages lists every supported language code.
+var Languages = []string{LanguageEnglish, LanguageArabic}
+
+// Locale carries a recipient's language and timezone into message builders.
+type Locale struct {
+	Language string
+	Location *time.Location
+}
+
+// LocaleFor resolves stored preferences, falling back to the defaults when a
+// value is empty or no longer recognised.
+func LocaleFor(language, timezone string) Locale {
+	locale := Locale{Language: DefaultLanguage, Location: time.UTC}
+	if slices.Contains(Languages, language) {
+		locale.Language = language
+	}
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			locale.Location = loc
+		}
+	}
+	return locale
+}
+
+// Date formats t as a calendar date in the recipient's timezone.
+func (l Locale) Date(t time.Time) string {
+	return t.In(l.location()).Format("2006-01-02")
+}
+
+func (l Locale) location() *time.Location {
+	if l.Location == nil {
+		return time.UTC
+	}
+	return l.Location
+}
+
+// direction is the text direction of the language for the HTML wrapper.
+func direction(language string) string {
+	if language == LanguageArabic {
+		return "rtl"
+	}
+	return "ltr"
+}
@@ -0,0 +1,73 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// The built-in templates are compiled into the binary, so emails render the
+// same whatever directory the process runs from.
+//
+//go:embed templates/*.html
+var embeddedTemplates embed.FS
+
+const layoutTemplate = "layout.html"
+
+var (
+	templatesMu sync.RWMutex
+	templates   = template.Must(template.ParseFS(embeddedTemplates, "templates/*.html"))
+)
+
+// LoadTemplates overrides the built-in templates with the files of the same
+// name in dir, such as layout.html, for editing templates without rebuilding.
+// Templates missing from dir keep their built-in version.
+func LoadTemplates(dir string) error {
+	files, err := fs.Glob(os.DirFS(dir), "*.html")
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no email templates (*.html) found in %s", dir)
+	}
+
+	set, err := template.ParseFS(embeddedTemplates, "templates/*.html")
+	if err != nil {
+		return err
+	}
+	if _, err := set.ParseFS(os.DirFS(dir), files...); err != nil {
+		return fmt.Errorf("parse email templates in %s: %w", dir, err)
+	}
+
+	templatesMu.Lock()
+	templates = set
+	templatesMu.Unlock()
+	return nil
+}
+
+// render executes the named template.
+func render(name string, data interface{}) (string, error) {
+	templatesMu.RLock()
+	set := templates
+	templatesMu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := set.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderBody executes a message template. A broken override falls back to
+// the message's plain text so the email still goes out.
+func renderBody(name string, data interface{}, text string) string {
+	html, err := render(name, data)
+	if err != nil {
+		return "<p>" + template.HTMLEscapeString(text) + "</p>"
+	}
+	return html
+}
@@ -0,0 +1,130 @@
+// Package eventbus is the in-process publish/subscribe bus for live events,
+// such as new notifications and announcements. Features publish to it without
+// knowing who listens; the Socket.IO server, server-sent event streams,
+// webhooks and notification workers subscribe to the topics they handle.
+//
+// Unlike the outbox, the bus is not durable: events published while the
+// process stops are lost, so it carries what clients can catch up on over the
+// REST API.
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// AllTopics subscribes a handler to every topic.
+const AllTopics = "*"
+
+// TopicSessionsRevoked asks transports to close the connections of its users.
+const TopicSessionsRevoked = "sessions.revoked"
+
+// Event is one published event.
+type Event struct {
+	Topic string
+	// SubscriptionID is the tenant the event belongs to, when it has one.
+	SubscriptionID uuid.UUID
+	// Users are the accounts the event is delivered to live.
+	Users   []uuid.UUID
+	Payload any
+}
+
+// Handler consumes one event. Handlers run on the publisher's goroutine, so
+// they must return quickly; failures are logged and never reach the publisher.
+type Handler func(ctx context.Context, event Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus routes published events to the subscribers of their topic.
+type Bus struct {
+	logger      *slog.Logger
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+}
+
+// New constructs an empty bus.
+func New(logger *slog.Logger) *Bus {
+	return &Bus{logger: logger, subscribers: make(map[string][]subscriber)}
+}
+
+// Subscribe registers handler for topic, or every topic with AllTopics, under a
+// name used in logs.
+func (b *Bus) Subscribe(topic, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[topic] = append(b.subscribers[topic], subscriber{name: name, handler: handler})
+}
+
+// Publish runs every subscriber of the event's topic. Publishing on a nil bus
+// does nothing, so features work without live delivery.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subs := append(append([]subscriber(nil), b.subscribers[event.Topic]...), b.subscribers[AllTopics]...)
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := b.run(ctx, sub, event); err != nil {
+			b.logger.Error("event subscriber failed",
+				slog.String("topic", event.Topic),
+				slog.String("subscriber", sub.name),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// EmitToUser publishes a payload to one user's connections. It lets the bus
+// stand in wherever a feature only needs to reach a user.
+func (b *Bus) EmitToUser(userID uuid.UUID, event string, payload any) {
+	b.Publish(context.Background(), Event{Topic: event, Users: []uuid.UUID{userID}, Payload: payload})
+}
+
+// DisconnectUser publishes TopicSessionsRevoked for the user, as when their
+// sessions are revoked.
+func (b *Bus) DisconnectUser(userID uuid.UUID) {
+	b.Publish(context.Background(), Event{Topic: TopicSessionsRevoked, Users: []uuid.UUID{userID}})
+}
+
+// run calls one subscriber, turning a panic into an error so one broken
+// subscriber cannot fail the publishing request.
+func (b *Bus) run(ctx context.Context, sub subscriber, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handler(ctx, event)
+}
+
+// Transport is a per-user connection registry, such as the Socket.IO server.
+type Transport interface {
+	EmitToUser(userID uuid.UUID, event string, payload any)
+	DisconnectUser(userID uuid.UUID)
+}
+
+// Deliver returns a subscriber that sends each event to its users over the
+// transport, named by topic, and closes their connections on
+// TopicSessionsRevoked. Subscribe it to AllTopics.
+func Deliver(transport Transport) Handler {
+	return func(_ context.Context, event Event) error {
+		for _, userID := range event.Users {
+			if event.Topic == TopicSessionsRevoked {
+				transport.DisconnectUser(userID)
+			} else {
+				transport.EmitToUser(userID, event.Topic, event.Payload)
+			}
+		}
+		return nil
+	}
+}
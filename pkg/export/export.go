@@ -0,0 +1,143 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Format identifies a tabular export file format.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for unknown export formats.
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// ParseFormat normalises a format query value, defaulting to CSV.
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the MIME type for the format.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer streams rows of a single-sheet table.
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// NewWriter creates a row writer for the given format.
+func NewWriter(w io.Writer, format Format, sheetName string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheetName)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Stream sets download headers on the response and returns a writer bound to the body.
+// Rows are flushed to the client as they are written so large exports never sit in memory.
+func Stream(c *gin.Context, format Format, baseName string, header []string) (Writer, error) {
+	filename := fmt.Sprintf("%s-%s.%s", baseName, time.Now().UTC().Format("20060102-150405"), format)
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(200)
+
+	writer, err := NewWriter(c.Writer, format, baseName)
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (c *csvWriter) Write(row []string) error {
+	escaped := make([]string, len(row))
+	for i, value := range row {
+		escaped[i] = neutralizeFormula(value)
+	}
+	if err := c.w.Write(escaped); err != nil {
+		return err
+	}
+	c.rows++
+	if c.rows%500 == 0 {
+		c.w.Flush()
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// neutralizeFormula prevents spreadsheet applications from evaluating user-supplied
+// values (names, notes) as formulas when a CSV is opened.
+func neutralizeFormula(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
+
+// FormatTime renders a timestamp for export cells.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FormatTimePtr renders an optional timestamp for export cells.
+func FormatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return FormatTime(*t)
+}
+
+// FormatStringPtr renders an optional string for export cells.
+func FormatStringPtr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Page geometry of PDF documents, in points (A4).
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 56
+)
+
+type pdfStyle struct {
+	font    string
+	size    float64
+	leading float64
+	// approximate glyph width as a fraction of the font size, used to wrap lines
+	charWidth float64
+}
+
+var (
+	pdfBody    = pdfStyle{font: "F1", size: 11, leading: 15, charWidth: 0.52}
+	pdfHeading = pdfStyle{font: "F2", size: 14, leading: 20, charWidth: 0.6}
+	pdfMuted   = pdfStyle{font: "F1", size: 9, leading: 13, charWidth: 0.52}
+)
+
+type pdfLine struct {
+	style pdfStyle
+	text  string
+	space float64 // extra gap above the line
+}
+
+// PDF builds a plain text document with the standard Helvetica fonts. They
+// only cover Western European (Windows-1252) text; other characters are drawn
+// as '?', so exports should offer a UTF-8 format alongside.
+type PDF struct {
+	lines []pdfLine
+}
+
+// NewPDF starts a document.
+func NewPDF() *PDF {
+	return &PDF{}
+}
+
+// Heading adds a bold line.
+func (p *PDF) Heading(text string) {
+	p.add(pdfHeading, text, 10)
+}
+
+// Paragraph adds body text, wrapped to the page width.
+func (p *PDF) Paragraph(text string) {
+	p.add(pdfBody, text, 4)
+}
+
+// Caption adds small text, such as a date or timestamp under a heading.
+func (p *PDF) Caption(text string) {
+	p.add(pdfMuted, text, 2)
+}
+
+func (p *PDF) add(style pdfStyle, text string, space float64) {
+	perLine := int((pdfPageWidth - 2*pdfMargin) / (style.size * style.charWidth))
+	first := true
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		for _, line := range wrap(paragraph, perLine) {
+			gap := 0.0
+			if first {
+				gap = space
+				first = false
+			}
+			p.lines = append(p.lines, pdfLine{style: style, text: line, space: gap})
+		}
+	}
+}
+
+// WriteTo renders the document.
+func (p *PDF) WriteTo(w io.Writer) (int64, error) {
+	var pages []*bytes.Buffer
+	var page *bytes.Buffer
+	y := 0.0
+	for _, line := range p.lines {
+		if page == nil || y-line.space-line.style.leading < pdfMargin {
+			page = &bytes.Buffer{}
+			pages = append(pages, page)
+			y = pdfPageHeight - pdfMargin
+		} else {
+			y -= line.space
+		}
+		y -= line.style.leading
+		fmt.Fprintf(page, "BT /%s %.0f Tf %d %.2f Td (%s) Tj ET\n", line.style.font, line.style.size, pdfMargin, y, pdfEscape(line.text))
+	}
+	if len(pages) == 0 {
+		pages = append(pages, &bytes.Buffer{})
+	}
+
+	// Objects: 1 catalog, 2 page tree, 3-4 fonts, then a page and its content per page
+	var out bytes.Buffer
+	offsets := make([]int, 0, 4+2*len(pages))
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", pages[i].Len(), pages[i].String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// wrap breaks text into lines of at most width characters, at spaces where possible.
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		if word == "" {
+			continue
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// pdfEscape encodes text as WinAnsi for a literal string.
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		encoded, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			encoded = '?'
+		}
+		switch encoded {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(encoded)
+		default:
+			if encoded < 0x20 || encoded > 0x7e {
+				fmt.Fprintf(&b, "\\%03o", encoded)
+			} else {
+				b.WriteByte(encoded)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+// Package filetype checks uploads against per-kind allow-lists. The content
+// type is sniffed from the first bytes of the file, so neither the extension
+// nor the Content-Type sent by the client is trusted on its own.
+package filetype
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SniffLen is the number of leading bytes Detect looks at.
+const SniffLen = 512
+
+// Policy lists what one kind of upload accepts.
+type Policy struct {
+	Kind         string
+	Extensions   []string
+	ContentTypes []string
+	MaxSize      int64
+}
+
+var (
+	PDF = Policy{
+		Kind:         "pdf",
+		Extensions:   []string{".pdf"},
+		ContentTypes: []string{"application/pdf"},
+		MaxSize:      200 << 20,
+	}
+	Audio = Policy{
+		Kind:         "audio",
+		Extensions:   []string{".mp3", ".m4a", ".aac", ".wav", ".ogg", ".oga", ".flac"},
+		ContentTypes: []string{"audio/mpeg", "audio/mp4", "audio/aac", "audio/wave", "application/ogg", "audio/flac"},
+		MaxSize:      1 << 30,
+	}
+	// Image matches the decoders in pkg/imaging, since every image upload is re-encoded.
+	Image = Policy{
+		Kind:         "image",
+		Extensions:   []string{".jpg", ".jpeg", ".png", ".gif"},
+		ContentTypes: []string{"image/jpeg", "image/png", "image/gif"},
+		MaxSize:      50 << 20,
+	}
+)
+
+// policies maps attachment types to their policy.
+var policies = map[string]Policy{
+	PDF.Kind:   PDF,
+	Audio.Kind: Audio,
+	Image.Kind: Image,
+}
+
+// For returns the policy of an attachment type.
+func For(kind string) (Policy, bool) {
+	policy, ok := policies[kind]
+	return policy, ok
+}
+
+var (
+	ErrUnsupported = errors.New("file type is not allowed")
+	ErrTooLarge    = errors.New("file is too large")
+)
+
+// RejectedError explains why an upload was refused. It wraps ErrUnsupported or
+// ErrTooLarge.
+type RejectedError struct {
+	Err      error
+	Policy   Policy
+	Detected string
+}
+
+func (e *RejectedError) Error() string {
+	if errors.Is(e.Err, ErrTooLarge) {
+		return fmt.Sprintf("%s files cannot be larger than %d MB", e.Policy.Kind, e.Policy.MaxSize>>20)
+	}
+	if e.Detected != "" {
+		return fmt.Sprintf("%s is not an allowed %s file; accepted extensions are %s",
+			e.Detected, e.Policy.Kind, strings.Join(e.Policy.Extensions, ", "))
+	}
+	return fmt.Sprintf("%s uploads must use one of the extensions %s",
+		e.Policy.Kind, strings.Join(e.Policy.Extensions, ", "))
+}
+
+func (e *RejectedError) Unwrap() error { return e.Err }
+
+// CheckName validates the declared file name and size before any content is read.
+func (p Policy) CheckName(fileName string, size int64) error {
+	if !slices.Contains(p.Extensions, strings.ToLower(filepath.Ext(fileName))) {
+		return &RejectedError{Err: ErrUnsupported, Policy: p}
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return &RejectedError{Err: ErrTooLarge, Policy: p}
+	}
+	return nil
+}
+
+// CheckContent sniffs head, the first bytes of the file, and returns the
+// detected content type when the policy allows it.
+func (p Policy) CheckContent(head []byte) (string, error) {
+	contentType := Detect(head)
+	if !slices.Contains(p.ContentTypes, contentType) {
+		return "", &RejectedError{Err: ErrUnsupported, Policy: p, Detected: contentType}
+	}
+	return contentType, nil
+}
+
+// Head reads up to SniffLen bytes from r.
+func Head(r io.Reader) ([]byte, error) {
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// Detect returns the content type of data without parameters. It extends
+// http.DetectContentType with the audio containers that it does not know or
+// reports under a video type.
+func Detect(data []byte) string {
+	switch {
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) && isAudioBrand(data[8:12]):
+		return "audio/mp4"
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return "audio/flac"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xF6 == 0xF0:
+		// ADTS frame header: sync word with layer bits 00.
+		return "audio/aac"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 && data[1]&0x06 != 0:
+		// MPEG audio frame without an ID3 tag.
+		return "audio/mpeg"
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return contentType
+}
+
+func isAudioBrand(brand []byte) bool {
+	switch string(brand) {
+	case "M4A ", "M4B ", "M4P ", "F4A ":
+		return true
+	}
+	return false
+}
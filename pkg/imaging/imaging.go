@@ -0,0 +1,195 @@
+// Package imaging decodes uploaded images and re-encodes them at bounded sizes
+// using only the standard library decoders (JPEG, PNG and GIF). Output is
+// always a baseline JPEG without metadata: EXIF orientation is applied to the
+// pixels and everything else, including location tags, is dropped.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+
+	// Register the decoders accepted for uploads.
+	_ "image/gif"
+	_ "image/png"
+)
+
+// ContentTypeJPEG is the content type of every image produced by this package.
+const ContentTypeJPEG = "image/jpeg"
+
+// maxPixels caps the decoded size so a small compressed upload cannot expand
+// into an arbitrarily large bitmap.
+const maxPixels = 40_000_000
+
+// Breakpoints are the widths in pixels of the variants produced for covers and
+// image attachments.
+var Breakpoints = []int{320, 640, 1280, 1920}
+
+var (
+	ErrUnsupportedFormat = errors.New("image must be a JPEG, PNG or GIF")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Square center-crops the image read from r to a square and scales it down so
+// each side is at most size pixels. Transparent areas are flattened onto white
+// and the result is encoded as a JPEG.
+func Square(r io.Reader, size int) ([]byte, error) {
+	src, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	out := min(side, size)
+	return encode(scale(flatten(src, crop), out, out))
+}
+
+// Variant is one re-encoded size of an image.
+type Variant struct {
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Resize produces a JPEG variant of the image read from r at each of widths,
+// smallest first, keeping the aspect ratio. Widths at or beyond the source
+// width collapse into a single variant at the source size, so images are never
+// upscaled and at least one variant is returned for any widths.
+func Resize(r io.Reader, widths []int) ([]Variant, error) {
+	src, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	full := flatten(src, src.Bounds())
+	sw, sh := full.Bounds().Dx(), full.Bounds().Dy()
+
+	variants := make([]Variant, 0, len(widths))
+	for _, width := range widths {
+		if width >= sw {
+			break
+		}
+		height := max(1, (sh*width+sw/2)/sw)
+		data, err := encode(scale(full, width, height))
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Width: width, Height: height, Data: data})
+	}
+
+	if len(variants) < len(widths) {
+		data, err := encode(full)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Width: sw, Height: sh, Data: data})
+	}
+
+	return variants, nil
+}
+
+// decode reads an image and turns it upright according to its EXIF orientation.
+func decode(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupportedFormat
+		}
+		return nil, fmt.Errorf("decode image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	if format == "jpeg" {
+		if orientation := jpegOrientation(data); orientation > 1 {
+			return orient(flatten(img, img.Bounds()), orientation), nil
+		}
+	}
+	return img, nil
+}
+
+// flatten copies the rect of src onto an opaque white canvas.
+func flatten(src image.Image, rect image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, rect.Min, draw.Over)
+	return dst
+}
+
+// scale resizes src to width x height by averaging the source pixels that
+// fall inside each destination pixel. It is meant for downscaling; callers
+// never ask for more pixels than the source has.
+func scale(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, sh)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, sw)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// span returns the source range covered by destination index i, always at
+// least one pixel wide.
+func span(i, dstLen, srcLen int) (int, int) {
+	start := i * srcLen / dstLen
+	end := (i + 1) * srcLen / dstLen
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+package imaging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// Storage is the part of the Bunny storage client used to publish variants.
+type Storage interface {
+	UploadBuffer(ctx context.Context, buffer []byte, remotePath, contentType string) error
+	DeleteFile(ctx context.Context, remotePath string) error
+	GetPublicURL(remotePath string) string
+}
+
+// Upload stores each variant as <basePath>_<width>.jpg and returns their public
+// URLs. If any upload fails the variants already stored are removed again.
+func Upload(ctx context.Context, storage Storage, basePath string, variants []Variant) (types.ImageVariants, error) {
+	uploaded := make(types.ImageVariants, 0, len(variants))
+	paths := make([]string, 0, len(variants))
+
+	for _, variant := range variants {
+		remotePath := fmt.Sprintf("%s_%d.jpg", basePath, variant.Width)
+		if err := storage.UploadBuffer(ctx, variant.Data, remotePath, ContentTypeJPEG); err != nil {
+			for _, path := range paths {
+				_ = storage.DeleteFile(context.Background(), path)
+			}
+			return nil, fmt.Errorf("upload %dpx variant: %w", variant.Width, err)
+		}
+		paths = append(paths, remotePath)
+		uploaded = append(uploaded, types.ImageVariant{
+			Width:  variant.Width,
+			Height: variant.Height,
+			URL:    storage.GetPublicURL(remotePath),
+		})
+	}
+
+	return uploaded, nil
+}
+
+// Largest returns the URL of the widest variant, which stands in for the
+// original image.
+func Largest(variants types.ImageVariants) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	return variants[len(variants)-1].URL
+}
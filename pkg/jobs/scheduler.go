@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/mo-amir99/lms-server-go/pkg/metrics"
 )
 
 // Job represents a background job.
@@ -101,9 +103,11 @@ func (s *Scheduler) runJob(scheduled *ScheduledJob) {
 
 // executeJob executes a single job with error handling.
 func (s *Scheduler) executeJob(job Job) {
+	start := time.Now()
 	defer func() {
 		if r := recover(); r != nil {
 			s.logger.Error("job panic", "name", job.Name(), "panic", r)
+			metrics.RecordJobRun(job.Name(), "panic", time.Since(start))
 		}
 	}()
 
@@ -112,11 +116,12 @@ func (s *Scheduler) executeJob(job Job) {
 
 	s.logger.Debug("executing job", "name", job.Name())
 
-	start := time.Now()
 	if err := job.Execute(ctx); err != nil {
 		s.logger.Error("job execution failed", "name", job.Name(), "error", err, "duration", time.Since(start))
+		metrics.RecordJobRun(job.Name(), "failure", time.Since(start))
 	} else {
 		s.logger.Debug("job completed", "name", job.Name(), "duration", time.Since(start))
+		metrics.RecordJobRun(job.Name(), "success", time.Since(start))
 	}
 }
 
@@ -298,118 +303,3 @@ func (j *StorageCleanupJob) Execute(ctx context.Context) error {
 
 	return nil
 }
-
-// SubscriptionExpirationJob checks subscription expirations.
-type SubscriptionExpirationJob struct {
-	db          *gorm.DB
-	emailClient EmailClient
-	logger      *slog.Logger
-}
-
-// EmailClient interface for sending emails
-type EmailClient interface {
-	SendNotification(to, subject, body string) error
-}
-
-// NewSubscriptionExpirationJob creates a new subscription expiration job.
-func NewSubscriptionExpirationJob(db *gorm.DB, emailClient EmailClient, logger *slog.Logger) *SubscriptionExpirationJob {
-	return &SubscriptionExpirationJob{
-		db:          db,
-		emailClient: emailClient,
-		logger:      logger,
-	}
-}
-
-// Name returns the job name.
-func (j *SubscriptionExpirationJob) Name() string {
-	return "subscription_expiration"
-}
-
-// Execute checks for expired subscriptions and sends notifications.
-func (j *SubscriptionExpirationJob) Execute(ctx context.Context) error {
-	j.logger.Debug("checking subscription expirations")
-
-	now := time.Now()
-	sevenDaysFromNow := now.AddDate(0, 0, 7)
-
-	// Query subscriptions expiring within 7 days
-	rows, err := j.db.WithContext(ctx).
-		Raw(`SELECT s.id, s.identifier_name, s.subscription_end, u.email, u.full_name
-			 FROM subscriptions s
-			 JOIN users u ON u.subscription_id = s.id
-			 WHERE s.subscription_end <= ?
-			 AND s.subscription_end > ?
-			 AND s.is_active = true
-			 AND u.user_type = 'admin'
-			 LIMIT 100`, sevenDaysFromNow, now).
-		Rows()
-
-	if err != nil {
-		return fmt.Errorf("failed to query expiring subscriptions: %w", err)
-	}
-	defer rows.Close()
-
-	notificationCount := 0
-	errorCount := 0
-
-	for rows.Next() {
-		var subscriptionID, identifierName, email, fullName string
-		var subscriptionEnd time.Time
-
-		if err := rows.Scan(&subscriptionID, &identifierName, &subscriptionEnd, &email, &fullName); err != nil {
-			j.logger.Error("failed to scan subscription row", "error", err)
-			continue
-		}
-
-		daysRemaining := int(time.Until(subscriptionEnd).Hours() / 24)
-
-		subject := fmt.Sprintf("Subscription Expiring Soon - %s", identifierName)
-		body := fmt.Sprintf(`
-Hello %s,
-
-Your subscription "%s" will expire in %d days on %s.
-
-Please renew your subscription to continue accessing the platform.
-
-Best regards,
-LMS Team
-		`, fullName, identifierName, daysRemaining, subscriptionEnd.Format("2006-01-02"))
-
-		// Send notification email
-		if j.emailClient != nil {
-			if err := j.emailClient.SendNotification(email, subject, body); err != nil {
-				j.logger.Error("failed to send expiration notification",
-					"subscriptionId", subscriptionID,
-					"email", email,
-					"error", err)
-				errorCount++
-			} else {
-				j.logger.Debug("sent expiration notification",
-					"subscriptionId", subscriptionID,
-					"email", email,
-					"daysRemaining", daysRemaining)
-				notificationCount++
-			}
-		}
-	}
-
-	// Mark subscriptions as inactive if past expiration date
-	result := j.db.WithContext(ctx).
-		Exec(`UPDATE subscriptions 
-			  SET is_active = false, updated_at = NOW()
-			  WHERE subscription_end <= ? AND is_active = true`, now)
-
-	if result.Error != nil {
-		j.logger.Error("failed to deactivate expired subscriptions", "error", result.Error)
-	} else if result.RowsAffected > 0 {
-		j.logger.Info("deactivated expired subscriptions", "count", result.RowsAffected)
-	}
-
-	if notificationCount > 0 || errorCount > 0 {
-		j.logger.Info("subscription expiration check completed",
-			"notifications", notificationCount,
-			"errors", errorCount)
-	}
-
-	return nil
-}
@@ -5,30 +5,49 @@ import (
 	"errors"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
 )
 
-// New creates a structured slog.Logger based on the provided level string.
-// Logs to files in logs/ directory and only shows important messages to console
-func New(level string) (*slog.Logger, error) {
-	handlerLevel, err := parseLevel(level)
+// Dir is the directory log files are written to.
+const Dir = "logs"
+
+// level is the minimum level of the loggers made by New, shared so SetLevel
+// changes it at runtime.
+var level = new(slog.LevelVar)
+
+// SetLevel changes the minimum level of the loggers made by New, such as on
+// a configuration reload.
+func SetLevel(name string) error {
+	parsed, err := parseLevel(name)
 	if err != nil {
+		return err
+	}
+	level.Set(parsed.Level())
+	return nil
+}
+
+// New creates a structured slog.Logger based on the provided level string.
+// Logs to files in logs/ directory and only shows important messages to console.
+// Files are rotated and pruned as configured by rotation.
+func New(levelName string, rotation config.LogConfig) (*slog.Logger, error) {
+	if err := SetLevel(levelName); err != nil {
 		return nil, err
 	}
 
 	// Create logs directory if it doesn't exist
-	if err := os.MkdirAll("logs", 0755); err != nil {
+	if err := os.MkdirAll(Dir, 0755); err != nil {
 		return nil, err
 	}
 
 	// Open log files
-	errorFile, err := os.OpenFile(filepath.Join("logs", "error.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	errorFile, err := openRotatingFile("error", rotation)
 	if err != nil {
 		return nil, err
 	}
 
-	infoFile, err := os.OpenFile(filepath.Join("logs", "info.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	infoFile, err := openRotatingFile("info", rotation)
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +55,8 @@ func New(level string) (*slog.Logger, error) {
 	// Create handlers:
 	// - Console: text format for readability
 	// - Files: JSON format for parsing
-	consoleHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: handlerLevel})
-	infoFileHandler := slog.NewJSONHandler(infoFile, &slog.HandlerOptions{Level: handlerLevel})
+	consoleHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	infoFileHandler := slog.NewJSONHandler(infoFile, &slog.HandlerOptions{Level: level})
 	errorFileHandler := slog.NewJSONHandler(errorFile, &slog.HandlerOptions{Level: slog.LevelError})
 
 	// Create a custom handler that routes logs to console and files
@@ -58,7 +77,7 @@ func NewMultiLevelHandler(consoleHandler, infoFileHandler, errorFileHandler slog
 		consoleHandler:   consoleHandler,
 		infoFileHandler:  infoFileHandler,
 		errorFileHandler: errorFileHandler,
-		level:            slog.LevelInfo,
+		level:            level,
 	}
 }
 
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lokiLookback bounds queries without a start time, since Loki otherwise
+// searches only the last hour.
+const lokiLookback = 30 * 24 * time.Hour
+
+// LokiStore queries log entries shipped to Grafana Loki. Lines must be the JSON
+// written by New; selector picks their streams, e.g. {app="lms-server-go"}.
+type LokiStore struct {
+	baseURL    string
+	selector   string
+	httpClient *http.Client
+}
+
+// NewLokiStore creates a store reading from the Loki server at baseURL.
+func NewLokiStore(baseURL, selector string) *LokiStore {
+	return &LokiStore{
+		baseURL:    strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		selector:   strings.TrimSpace(selector),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result []struct {
+			Values [][2]string `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (s *LokiStore) Query(ctx context.Context, q Query) (Page, error) {
+	if q.Limit <= 0 {
+		return Page{}, nil
+	}
+
+	end := time.Now()
+	if !q.To.IsZero() && q.To.Before(end) {
+		end = q.To
+	}
+	if q.After != nil && q.After.Time.Before(end) {
+		end = q.After.Time
+	}
+	start := q.From
+	if start.IsZero() {
+		start = end.Add(-lokiLookback)
+	}
+
+	// Entries at the cursor time that were already returned are fetched again
+	// and skipped by the collector.
+	limit := q.Limit + 1
+	if q.After != nil {
+		limit += q.After.Skip
+	}
+
+	params := url.Values{}
+	params.Set("query", s.logQL(q))
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano()+1, 10))
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("direction", "backward")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Page{}, fmt.Errorf("loki query failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload lokiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return Page{}, fmt.Errorf("failed to decode loki response: %w", err)
+	}
+
+	// Streams are returned separately; merge them newest first.
+	var entries []Entry
+	for _, stream := range payload.Data.Result {
+		for _, value := range stream.Values {
+			entry, ok := parseEntry([]byte(value[1]))
+			if !ok {
+				continue
+			}
+			if entry.Time.IsZero() {
+				if nanos, err := strconv.ParseInt(value[0], 10, 64); err == nil {
+					entry.Time = time.Unix(0, nanos)
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+
+	c := &collector{query: q}
+	for _, entry := range entries {
+		if !c.add(entry) {
+			break
+		}
+	}
+	return c.page, nil
+}
+
+// logQL builds the query for q's filters; the time range and cursor are
+// applied through the request parameters.
+func (s *LokiStore) logQL(q Query) string {
+	var b strings.Builder
+	b.WriteString(s.selector)
+	b.WriteString(" | json")
+
+	minimum := q.Level
+	if q.Log == "error" {
+		minimum = max(minimum, slog.LevelError)
+	}
+	var levels []string
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		if level >= minimum {
+			levels = append(levels, level.String())
+		}
+	}
+	if len(levels) < 4 {
+		fmt.Fprintf(&b, " | level=~%s", strconv.Quote(strings.Join(levels, "|")))
+	}
+
+	if q.RequestID != "" {
+		fmt.Fprintf(&b, " | request_id=%s", strconv.Quote(q.RequestID))
+	}
+	if q.UserID != "" {
+		fmt.Fprintf(&b, " | user_id=%s", strconv.Quote(q.UserID))
+	}
+	return b.String()
+}
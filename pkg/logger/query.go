@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one structured log record.
+type Entry struct {
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	RequestID string         `json:"requestId,omitempty"`
+	UserID    string         `json:"userId,omitempty"`
+	Attrs     map[string]any `json:"attrs,omitempty"`
+}
+
+// Query selects log entries, newest first. Zero fields do not filter.
+type Query struct {
+	// Log is "info" for every entry or "error" for errors only.
+	Log string
+	// Level is the minimum level returned.
+	Level     slog.Level
+	RequestID string
+	UserID    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	// After continues from the end of a previous page.
+	After *Cursor
+}
+
+// Cursor is the end of a page: entries newer than Time, and the first Skip
+// matching entries at Time, were already returned.
+type Cursor struct {
+	Time time.Time
+	Skip int
+}
+
+// Page is one page of query results. Next is nil on the last page.
+type Page struct {
+	Entries []Entry
+	Next    *Cursor
+}
+
+// Store answers log queries.
+type Store interface {
+	Query(ctx context.Context, q Query) (Page, error)
+}
+
+// ErrInvalidCursor is returned by DecodeCursor for tokens it did not produce.
+var ErrInvalidCursor = errors.New("invalid log cursor")
+
+// EncodeCursor returns the opaque token for a page boundary.
+func EncodeCursor(cursor Cursor) string {
+	raw := strconv.FormatInt(cursor.Time.UnixNano(), 10) + ":" + strconv.Itoa(cursor.Skip)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. An empty token is the
+// first page and decodes to nil.
+func DecodeCursor(token string) (*Cursor, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, skip, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	ts, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(skip)
+	if err != nil || n < 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Time: time.Unix(0, ts).UTC(), Skip: n}, nil
+}
+
+// parseEntry decodes a line written by the JSON file handlers.
+func parseEntry(line []byte) (Entry, bool) {
+	var attrs map[string]any
+	if err := json.Unmarshal(line, &attrs); err != nil {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if raw, ok := attrs[slog.TimeKey].(string); ok {
+		entry.Time, _ = time.Parse(time.RFC3339Nano, raw)
+	}
+	entry.Level, _ = attrs[slog.LevelKey].(string)
+	entry.Message, _ = attrs[slog.MessageKey].(string)
+	entry.RequestID, _ = attrs["request_id"].(string)
+	entry.UserID, _ = attrs["user_id"].(string)
+	for _, key := range []string{slog.TimeKey, slog.LevelKey, slog.MessageKey, "request_id", "user_id"} {
+		delete(attrs, key)
+	}
+	if len(attrs) > 0 {
+		entry.Attrs = attrs
+	}
+	return entry, true
+}
+
+// collector pages through entries visited newest first.
+type collector struct {
+	query   Query
+	page    Page
+	skipped int
+}
+
+func (c *collector) matches(entry Entry) bool {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(entry.Level)); err != nil || level < c.query.Level {
+		return false
+	}
+	if c.query.Log == "error" && level < slog.LevelError {
+		return false
+	}
+	if c.query.RequestID != "" && entry.RequestID != c.query.RequestID {
+		return false
+	}
+	return c.query.UserID == "" || entry.UserID == c.query.UserID
+}
+
+// add offers the next older entry and reports whether more are wanted.
+func (c *collector) add(entry Entry) bool {
+	if !c.query.From.IsZero() && entry.Time.Before(c.query.From) {
+		return false
+	}
+	if !c.query.To.IsZero() && entry.Time.After(c.query.To) {
+		return true
+	}
+	if !c.matches(entry) {
+		return true
+	}
+
+	if after := c.query.After; after != nil {
+		if entry.Time.After(after.Time) {
+			return true
+		}
+		if entry.Time.Equal(after.Time) && c.skipped < after.Skip {
+			c.skipped++
+			return true
+		}
+	}
+
+	if len(c.page.Entries) == c.query.Limit {
+		c.page.Next = c.cursor()
+		return false
+	}
+	c.page.Entries = append(c.page.Entries, entry)
+	return true
+}
+
+// cursor points after the last collected entry.
+func (c *collector) cursor() *Cursor {
+	last := c.page.Entries[len(c.page.Entries)-1].Time
+	next := &Cursor{Time: last}
+	for _, entry := range slices.Backward(c.page.Entries) {
+		if !entry.Time.Equal(last) {
+			break
+		}
+		next.Skip++
+	}
+	if c.query.After != nil && c.query.After.Time.Equal(last) {
+		next.Skip += c.query.After.Skip
+	}
+	return next
+}
+
+// FileStore queries the JSON log files written by New. Files are read
+// backwards from the newest, and rotated files outside the time range are
+// skipped by the rotation time in their names.
+type FileStore struct{}
+
+func (FileStore) Query(ctx context.Context, q Query) (Page, error) {
+	if q.Limit <= 0 {
+		return Page{}, nil
+	}
+
+	rotated, err := Rotated(q.Log)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Walk newest first; each file holds entries up to its rotation time and
+	// after the rotation time of the next older one.
+	slices.Reverse(rotated)
+	files := append([]string{Path(q.Log)}, rotated...)
+
+	newest := q.To
+	if q.After != nil && (newest.IsZero() || q.After.Time.Before(newest)) {
+		newest = q.After.Time
+	}
+
+	c := &collector{query: q}
+	found := false
+	for i, path := range files {
+		if err := ctx.Err(); err != nil {
+			return Page{}, err
+		}
+		if i > 0 && !q.From.IsZero() && rotatedAt(path, q.Log).Before(q.From) {
+			break
+		}
+		if i+1 < len(files) && !newest.IsZero() && !rotatedAt(files[i+1], q.Log).Before(newest) {
+			continue
+		}
+
+		more := true
+		err := scanBackward(path, func(line []byte) bool {
+			entry, ok := parseEntry(line)
+			if !ok {
+				return true
+			}
+			more = c.add(entry)
+			return more
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Page{}, err
+		}
+		found = true
+		if !more {
+			break
+		}
+	}
+
+	if !found && len(files) == 1 {
+		return Page{}, fs.ErrNotExist
+	}
+	return c.page, nil
+}
+
+// rotatedAt returns the rotation time encoded in a rotated file name.
+func rotatedAt(path, name string) time.Time {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), name+"-"), ".log")
+	t, err := time.Parse(rotatedTimeFormat, stamp)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+)
+
+// rotatedTimeFormat sorts lexically in time order, so rotated files can be
+// ordered by name.
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// rotatingFile is an append-only log file that is renamed aside once it grows
+// too large or its rotation period ends. slog handlers write one record per
+// Write call, so rotation never splits a line.
+type rotatingFile struct {
+	name   string
+	config config.LogConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+}
+
+func openRotatingFile(name string, cfg config.LogConfig) (*rotatingFile, error) {
+	f := &rotatingFile{name: name, config: cfg}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the path of the log file currently being written for name.
+func Path(name string) string {
+	return filepath.Join(Dir, name+".log")
+}
+
+// Rotated returns the rotated files of log name, oldest first.
+func Rotated(name string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(Dir, name+"-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.due(len(p), time.Now()) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether the current file must be rotated before writing n bytes.
+func (f *rotatingFile) due(n int, now time.Time) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.config.MaxSizeMB > 0 && f.size+int64(n) > int64(f.config.MaxSizeMB)<<20 {
+		return true
+	}
+	return f.config.RotateHours > 0 && !f.periodOf(now).Equal(f.period)
+}
+
+func (f *rotatingFile) periodOf(t time.Time) time.Time {
+	if f.config.RotateHours <= 0 {
+		return time.Time{}
+	}
+	return t.Truncate(time.Duration(f.config.RotateHours) * time.Hour)
+}
+
+// open opens the current file. An existing file keeps the period of its last
+// write, so a restart after the period ended still rotates it.
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(Path(f.name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.period = f.periodOf(info.ModTime())
+	if f.size == 0 {
+		f.period = f.periodOf(time.Now())
+	}
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	rotated := filepath.Join(Dir, f.name+"-"+time.Now().UTC().Format(rotatedTimeFormat)+".log")
+	if err := os.Rename(Path(f.name), rotated); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.prune()
+	return nil
+}
+
+// prune deletes rotated files beyond the configured count or age. Failures are
+// ignored; the files are retried on the next rotation.
+func (f *rotatingFile) prune() {
+	files, err := Rotated(f.name)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -f.config.MaxAgeDays)
+	for i, path := range files {
+		expired := f.config.MaxBackups > 0 && i < len(files)-f.config.MaxBackups
+		if !expired && f.config.MaxAgeDays > 0 {
+			expired = rotatedAt(path, f.name).Before(cutoff)
+		}
+		if expired {
+			_ = os.Remove(path)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+)
+
+const tailChunkSize = 64 << 10
+
+// scanBackward calls fn with each non-empty line of path, newest first, until
+// fn returns false. The file is read in chunks from its end, so only the lines
+// visited are loaded.
+func scanBackward(path string, fn func(line []byte) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// partial holds the start of a line that began in an earlier chunk.
+	var partial []byte
+	chunk := make([]byte, tailChunkSize)
+	offset := info.Size()
+	for offset > 0 {
+		size := min(int64(len(chunk)), offset)
+		offset -= size
+		if _, err := file.ReadAt(chunk[:size], offset); err != nil {
+			return err
+		}
+
+		data := append(chunk[:size:size], partial...)
+		for {
+			i := bytes.LastIndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if line := data[i+1:]; len(line) > 0 && !fn(line) {
+				return nil
+			}
+			data = data[:i]
+		}
+		partial = bytes.Clone(data)
+	}
+	if len(partial) > 0 {
+		fn(partial)
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package metrics
 
 import (
+	"database/sql"
+	"errors"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
@@ -63,6 +66,83 @@ var (
 		},
 		[]string{"operation", "table"},
 	)
+
+	dbSlowQueriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_slow_queries_total",
+			Help: "Total number of database queries exceeding the slow query threshold",
+		},
+		[]string{"operation", "table"},
+	)
+
+	// Bunny API metrics
+	bunnyRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bunny_requests_total",
+			Help: "Total number of Bunny API calls by outcome (success, failure, canceled, rejected)",
+		},
+		[]string{"client", "method", "outcome"},
+	)
+
+	bunnyRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bunny_retries_total",
+			Help: "Total number of retried Bunny API attempts",
+		},
+		[]string{"client", "method"},
+	)
+
+	bunnyCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bunny_circuit_breaker_state",
+			Help: "Bunny API circuit breaker state (0=closed, 1=half-open, 2=open)",
+		},
+		[]string{"client"},
+	)
+
+	bunnyRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bunny_request_duration_seconds",
+			Help:    "Duration of Bunny API calls in seconds, including retries",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"client", "method", "outcome"},
+	)
+
+	// Background job metrics
+	jobRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "job_runs_total",
+			Help: "Total number of background job runs by outcome (success, failure, panic)",
+		},
+		[]string{"job", "outcome"},
+	)
+
+	jobDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "job_duration_seconds",
+			Help:    "Duration of background job runs in seconds",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"job"},
+	)
+
+	jobLastSuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "job_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful run of a background job",
+		},
+		[]string{"job"},
+	)
+
+	// Email queue metrics
+	emailQueueMessages = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "email_queue_messages",
+			Help: "Number of outbound email messages waiting in the queue by status (pending, sending, failed)",
+		},
+		[]string{"status"},
+	)
 )
 
 // Middleware collects HTTP metrics for Prometheus.
@@ -98,3 +178,102 @@ func RecordDBQuery(operation, table string, duration time.Duration) {
 	dbQueriesTotal.WithLabelValues(operation, table).Inc()
 	dbQueryDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
 }
+
+// RecordSlowDBQuery counts a query that exceeded the slow query threshold.
+func RecordSlowDBQuery(operation, table string) {
+	dbSlowQueriesTotal.WithLabelValues(operation, table).Inc()
+}
+
+// RegisterDBStats exports the connection pool statistics of db (open, in use,
+// idle and wait counts) labelled with name. Registering a name twice is a no-op.
+func RegisterDBStats(db *sql.DB, name string) {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, name))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &alreadyRegistered) {
+		panic(err)
+	}
+}
+
+// RecordBunnyRequest records the final outcome of a Bunny API call.
+func RecordBunnyRequest(client, method, outcome string) {
+	bunnyRequestsTotal.WithLabelValues(client, method, outcome).Inc()
+}
+
+// RecordBunnyRetry records a retried Bunny API attempt.
+func RecordBunnyRetry(client, method string) {
+	bunnyRetriesTotal.WithLabelValues(client, method).Inc()
+}
+
+// SetBunnyCircuitState publishes the current circuit breaker state for a Bunny client.
+func SetBunnyCircuitState(client string, state int) {
+	bunnyCircuitState.WithLabelValues(client).Set(float64(state))
+}
+
+// RecordBunnyLatency records how long a Bunny API call took, retries included.
+func RecordBunnyLatency(client, method, outcome string, duration time.Duration) {
+	bunnyRequestDuration.WithLabelValues(client, method, outcome).Observe(duration.Seconds())
+}
+
+// RecordJobRun records the outcome and duration of a background job run.
+func RecordJobRun(job, outcome string, duration time.Duration) {
+	jobRunsTotal.WithLabelValues(job, outcome).Inc()
+	jobDuration.WithLabelValues(job).Observe(duration.Seconds())
+	if outcome == "success" {
+		jobLastSuccess.WithLabelValues(job).SetToCurrentTime()
+	}
+}
+
+// SetEmailQueueDepth publishes the number of queued email messages with status.
+func SetEmailQueueDepth(status string, count int64) {
+	emailQueueMessages.WithLabelValues(status).Set(float64(count))
+}
+
+// RegisterSocketConnections exports the number of open Socket.IO connections,
+// read from count on every scrape.
+func RegisterSocketConnections(count func() int) {
+	registerGaugeFunc("socketio_connections", "Number of open Socket.IO connections", count)
+}
+
+// RegisterEventStreams exports the number of open server-sent event streams,
+// read from count on every scrape.
+func RegisterEventStreams(count func() int) {
+	registerGaugeFunc("event_streams", "Number of open server-sent event streams", count)
+}
+
+// RegisterLiveStreams exports the number of live streams and their viewers,
+// read from stats on every scrape.
+func RegisterLiveStreams(stats func() (streams, viewers int)) {
+	registerGaugeFunc("live_streams", "Number of live streams", func() int {
+		streams, _ := stats()
+		return streams
+	})
+	registerGaugeFunc("live_stream_viewers", "Number of viewers across live streams", func() int {
+		_, viewers := stats()
+		return viewers
+	})
+}
+
+// RegisterMeetings exports the number of active meetings and their
+// participants, read from stats on every scrape.
+func RegisterMeetings(stats func() (meetings, participants int)) {
+	registerGaugeFunc("meetings_active", "Number of active meetings", func() int {
+		meetings, _ := stats()
+		return meetings
+	})
+	registerGaugeFunc("meeting_participants", "Number of participants across active meetings", func() int {
+		_, participants := stats()
+		return participants
+	})
+}
+
+// registerGaugeFunc registers a gauge sampled from value. Registering a name
+// twice is a no-op.
+func registerGaugeFunc(name, help string, value func() int) {
+	err := prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 {
+		return float64(value())
+	}))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &alreadyRegistered) {
+		panic(err)
+	}
+}
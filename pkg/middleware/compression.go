@@ -87,6 +87,11 @@ func shouldCompress(req *http.Request) bool {
 		return false
 	}
 
+	// Event streams must reach the client as each event is flushed
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+
 	// Don't compress if already compressed (e.g., images, videos)
 	contentType := req.Header.Get("Content-Type")
 	compressibleTypes := []string{
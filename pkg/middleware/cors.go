@@ -24,8 +24,9 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 			c.Header("Vary", "Origin")
 		}
 		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Authorization,Content-Type,X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Authorization,Content-Type,X-Requested-With,Idempotency-Key")
 		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Expose-Headers", "API-Version,Deprecation,Sunset,Link,X-Request-ID,Idempotent-Replayed")
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
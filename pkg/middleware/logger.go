@@ -18,6 +18,12 @@ func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
 		status := c.Writer.Status()
 		latency := time.Since(start)
 
+		// The user ID is set by authentication; it lets log queries filter by user
+		var userID slog.Attr
+		if id, ok := c.Get("userId"); ok {
+			userID = slog.Any("user_id", id)
+		}
+
 		// Only log errors and warnings to console
 		if status >= 500 {
 			logger.Error(
@@ -27,6 +33,7 @@ func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
 				slog.String("path", c.Request.URL.Path),
 				slog.Int("status", status),
 				slog.Duration("latency", latency),
+				userID,
 			)
 		} else if status >= 400 {
 			logger.Warn(
@@ -35,6 +42,7 @@ func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
 				slog.String("method", c.Request.Method),
 				slog.String("path", c.Request.URL.Path),
 				slog.Int("status", status),
+				userID,
 			)
 		}
 	}
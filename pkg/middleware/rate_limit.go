@@ -1,14 +1,26 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
-// RateLimiter implements a simple token bucket rate limiter.
+const quotaContextKey = "rateLimitQuota"
+
+// Limiter counts requests against per-key quotas.
+type Limiter interface {
+	Take(key string, limit int) Quota
+}
+
+// RateLimiter implements a token bucket rate limiter in process memory. Each
+// bucket holds up to rate tokens and refills continuously at rate per duration.
 type RateLimiter struct {
 	requests map[string]*bucket
 	mu       sync.RWMutex
@@ -17,14 +29,24 @@ type RateLimiter struct {
 }
 
 type bucket struct {
-	tokens    int
-	lastReset time.Time
-	mu        sync.Mutex
+	tokens  float64
+	updated time.Time
+	mu      sync.Mutex
+}
+
+// Quota is the state of a key's bucket after a request was counted against it.
+// Reset is when the next request will be allowed if this one was denied, and
+// otherwise when the bucket will be full again.
+type Quota struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Allowed   bool
 }
 
 // NewRateLimiter creates a new rate limiter with the given rate.
 // rate: maximum number of requests per duration
-// duration: time window for rate limiting
+// duration: time in which an empty bucket refills completely
 func NewRateLimiter(rate int, duration time.Duration) *RateLimiter {
 	rl := &RateLimiter{
 		requests: make(map[string]*bucket),
@@ -42,14 +64,7 @@ func NewRateLimiter(rate int, duration time.Duration) *RateLimiter {
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Use client IP as the key
-		key := c.ClientIP()
-
-		if !rl.allow(key) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Rate limit exceeded",
-				"message": "Too many requests. Please try again later.",
-			})
-			c.Abort()
+		if !ApplyQuota(c, rl.Take(c.ClientIP(), rl.rate)) {
 			return
 		}
 
@@ -57,13 +72,18 @@ func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	}
 }
 
-func (rl *RateLimiter) allow(key string) bool {
+// Take counts one request against key, whose bucket holds limit tokens and
+// refills at limit per duration. Keys may use different limits; a changed limit
+// applies to the existing bucket.
+func (rl *RateLimiter) Take(key string, limit int) Quota {
+	now := time.Now()
+
 	rl.mu.Lock()
 	b, exists := rl.requests[key]
 	if !exists {
 		b = &bucket{
-			tokens:    rl.rate,
-			lastReset: time.Now(),
+			tokens:  float64(limit),
+			updated: now,
 		}
 		rl.requests[key] = b
 	}
@@ -72,17 +92,49 @@ func (rl *RateLimiter) allow(key string) bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	// Reset bucket if duration has passed
-	if time.Since(b.lastReset) > rl.duration {
-		b.tokens = rl.rate
-		b.lastReset = time.Now()
+	quota := Quota{Limit: limit, Reset: now.Add(rl.duration)}
+	if limit <= 0 {
+		return quota
 	}
 
-	if b.tokens > 0 {
+	// Refill for the time elapsed since the last request
+	perToken := rl.duration / time.Duration(limit)
+	b.tokens = min(b.tokens+float64(now.Sub(b.updated))/float64(perToken), float64(limit))
+	b.updated = now
+
+	if b.tokens >= 1 {
 		b.tokens--
+		quota.Allowed = true
+		quota.Reset = now.Add(time.Duration((float64(limit) - b.tokens) * float64(perToken)))
+	} else {
+		quota.Reset = now.Add(time.Duration((1 - b.tokens) * float64(perToken)))
+	}
+	quota.Remaining = int(b.tokens)
+
+	return quota
+}
+
+// ApplyQuota reports quota in the X-RateLimit headers, keeping those of a tighter
+// quota already applied to the request, and answers 429 with Retry-After when
+// the request is over it. It reports whether the request may continue.
+func ApplyQuota(c *gin.Context, quota Quota) bool {
+	if previous, ok := c.Get(quotaContextKey); ok && quota.Allowed && previous.(Quota).Remaining <= quota.Remaining {
+		return true
+	}
+	c.Set(quotaContextKey, quota)
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(quota.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(quota.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(quota.Reset.Unix(), 10))
+
+	if quota.Allowed {
 		return true
 	}
 
+	retryAfter := max(int(math.Ceil(time.Until(quota.Reset).Seconds())), 1)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	response.ErrorCode(c, http.StatusTooManyRequests, response.CodeRateLimited, "Too many requests. Please try again later.", nil)
+	c.Abort()
 	return false
 }
 
@@ -94,7 +146,7 @@ func (rl *RateLimiter) cleanup() {
 		rl.mu.Lock()
 		for key, b := range rl.requests {
 			b.mu.Lock()
-			if time.Since(b.lastReset) > 24*time.Hour {
+			if time.Since(b.updated) > 24*time.Hour {
 				delete(rl.requests, key)
 			}
 			b.mu.Unlock()
@@ -7,6 +7,8 @@ import (
 	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
 // Recovery recovers from panics and logs them with stack traces.
@@ -31,12 +33,7 @@ func Recovery(logger *slog.Logger) gin.HandlerFunc {
 				)
 
 				// Return error response
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":      "Internal server error",
-					"request_id": requestID,
-					"message":    fmt.Sprintf("An unexpected error occurred: %v", err),
-				})
-
+				response.ErrorCode(c, http.StatusInternalServerError, response.CodeInternal, "Internal server error", fmt.Sprintf("An unexpected error occurred: %v", err))
 				c.Abort()
 			}
 		}()
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisRateLimitPrefix = "ratelimit:"
+	// redisRateLimitTimeout bounds each Redis round trip so an unreachable
+	// server delays a request by at most this long.
+	redisRateLimitTimeout = 250 * time.Millisecond
+	// redisRateLimitCooldown is how long limits stay in memory after a Redis
+	// error before Redis is tried again.
+	redisRateLimitCooldown = 10 * time.Second
+)
+
+// takeScript counts one request against a token bucket with the same refill
+// rules as RateLimiter, using the Redis clock so all instances agree on how much
+// has refilled. ARGV is {limit, duration in milliseconds}. It returns
+// {allowed, remaining, reset in unix milliseconds}.
+var takeScript = redis.NewScript(`
+local now = redis.call('TIME')
+local nowMs = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local limit = tonumber(ARGV[1])
+local duration = tonumber(ARGV[2])
+if limit <= 0 then
+	return {0, 0, nowMs + duration}
+end
+
+local perToken = duration / limit
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or limit
+local updated = tonumber(state[2]) or nowMs
+tokens = math.min(tokens + math.max(nowMs - updated, 0) / perToken, limit)
+
+local allowed = 0
+local reset
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+	reset = nowMs + math.ceil((limit - tokens) * perToken)
+else
+	reset = nowMs + math.ceil((1 - tokens) * perToken)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', nowMs)
+-- A bucket left alone until it is full is the same as a missing one
+redis.call('PEXPIRE', KEYS[1], math.ceil((limit - tokens) * perToken) + 1)
+return {allowed, math.floor(tokens), reset}
+`)
+
+// RedisRateLimiter keeps buckets in Redis so every instance shares one quota
+// per key. While Redis is unreachable it falls back to per-instance buckets.
+type RedisRateLimiter struct {
+	client    *redis.Client
+	duration  time.Duration
+	fallback  *RateLimiter
+	logger    *slog.Logger
+	downUntil atomic.Int64
+}
+
+// NewRedisRateLimiter creates a Redis-backed rate limiter. rate and duration
+// configure the in-memory fallback as for NewRateLimiter.
+func NewRedisRateLimiter(client *redis.Client, rate int, duration time.Duration, logger *slog.Logger) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client:   client,
+		duration: duration,
+		fallback: NewRateLimiter(rate, duration),
+		logger:   logger,
+	}
+}
+
+// Take counts one request against key, whose bucket holds limit tokens and
+// refills at limit per duration.
+func (rl *RedisRateLimiter) Take(key string, limit int) Quota {
+	downUntil := rl.downUntil.Load()
+	if downUntil != 0 && time.Now().UnixNano() < downUntil {
+		return rl.fallback.Take(key, limit)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisRateLimitTimeout)
+	defer cancel()
+
+	result, err := takeScript.Run(ctx, rl.client, []string{redisRateLimitPrefix + key}, limit, rl.duration.Milliseconds()).Int64Slice()
+	if err != nil || len(result) != 3 {
+		if rl.downUntil.Swap(time.Now().Add(redisRateLimitCooldown).UnixNano()) == 0 {
+			rl.logger.Warn("redis rate limiter unavailable, limiting per instance", slog.Any("error", err))
+		}
+		return rl.fallback.Take(key, limit)
+	}
+
+	if rl.downUntil.Swap(0) != 0 {
+		rl.logger.Info("redis rate limiter recovered")
+	}
+
+	return Quota{
+		Limit:     limit,
+		Remaining: int(result[1]),
+		Reset:     time.UnixMilli(result[2]),
+		Allowed:   result[0] == 1,
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/response"
 )
 
 // SecurityHeaders adds common security headers to responses.
@@ -40,10 +42,7 @@ func SecurityHeaders() gin.HandlerFunc {
 func RequestSizeLimit(maxBytes int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.ContentLength > maxBytes {
-			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
-				"error":   "Request too large",
-				"message": "The request body exceeds the maximum allowed size",
-			})
+			response.ErrorCode(c, http.StatusRequestEntityTooLarge, response.CodePayloadTooLarge, "The request body exceeds the maximum allowed size", nil)
 			c.Abort()
 			return
 		}
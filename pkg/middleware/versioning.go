@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader names the response header carrying the API version that served the request.
+const APIVersionHeader = "API-Version"
+
+// VersionedAPI serves the unversioned legacy prefix (e.g. /api) from the current
+// versioned prefix (e.g. /api/v1) so existing clients keep working while they migrate.
+// Legacy requests are rewritten before routing and answered with Deprecation (RFC 9745),
+// Link rel="successor-version" and, once announced, Sunset (RFC 8594) headers.
+//
+// It wraps the whole router because Gin matches routes before any middleware runs.
+func VersionedAPI(next http.Handler, legacyPrefix, currentPrefix, version string, sunset *time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+
+		switch {
+		case hasPathPrefix(path, currentPrefix):
+			w.Header().Set(APIVersionHeader, version)
+		case hasPathPrefix(path, legacyPrefix):
+			successor := currentPrefix + strings.TrimPrefix(path, legacyPrefix)
+
+			w.Header().Set(APIVersionHeader, version)
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+			if sunset != nil {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			r.URL.Path = successor
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = currentPrefix + strings.TrimPrefix(r.URL.RawPath, legacyPrefix)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
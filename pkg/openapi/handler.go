@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const swaggerUIVersion = "5.17.14"
+
+// Handler serves a pre-rendered document and the Swagger UI page.
+type Handler struct {
+	spec []byte
+}
+
+// NewHandler renders the document once so requests only copy bytes.
+func NewHandler(doc *Document) (*Handler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{spec: spec}, nil
+}
+
+// Spec writes the OpenAPI document.
+func (h *Handler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// UI renders Swagger UI pointed at specURL.
+func (h *Handler) UI(specURL string) gin.HandlerFunc {
+	page := `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"></script>
+  <script src="./swagger-init.js"></script>
+</body>
+</html>`
+	initScript := `window.ui = SwaggerUIBundle({ url: ` + jsString(specURL) + `, dom_id: "#swagger-ui" });`
+
+	return func(c *gin.Context) {
+		// The default CSP only allows same-origin scripts; Swagger UI is loaded from unpkg.
+		c.Header("Content-Security-Policy", "default-src 'self'; script-src 'self' https://unpkg.com; style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data: https:; connect-src 'self'")
+
+		if c.Param("file") == "/swagger-init.js" {
+			c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(initScript))
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	}
+}
+
+func jsString(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
@@ -0,0 +1,349 @@
+// Package openapi builds an OpenAPI 3 document from the routes registered on a Gin
+// engine so the published spec can never drift from the actual route table.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI specification version the generated documents conform to.
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the API is reachable at.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations of one feature.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Operation describes a single method on a path.
+type Operation struct {
+	Tags        []string               `json:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	OperationID string                 `json:"operationId"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]Response    `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   Schema `json:"schema"`
+}
+
+// RequestBody describes the payload accepted by an operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response returned by an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType binds a schema to a content type.
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used by the generated document.
+type Schema struct {
+	Ref                  string            `json:"$ref,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Format               string            `json:"format,omitempty"`
+	Minimum              *int              `json:"minimum,omitempty"`
+	Nullable             bool              `json:"nullable,omitempty"`
+	Items                *Schema           `json:"items,omitempty"`
+	Properties           map[string]Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema           `json:"additionalProperties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	AllOf                []Schema          `json:"allOf,omitempty"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]Schema         `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how clients authenticate.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Options controls document generation.
+type Options struct {
+	Info Info
+	// ServerURL is advertised as the API base URL when set.
+	ServerURL string
+	// Public lists path prefixes that do not require a bearer token.
+	Public []string
+	// Exclude lists path prefixes left out of the document.
+	Exclude []string
+}
+
+const bearerScheme = "bearerAuth"
+
+var (
+	pathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+	// Handler names look like "github.com/org/repo/internal/features/course.(*Handler).List-fm".
+	handlerName = regexp.MustCompile(`([A-Za-z0-9_]+)\.\(\*?[A-Za-z0-9_]+\)\.([A-Za-z0-9_]+)-fm$`)
+)
+
+// Build generates a document describing every route registered on the engine.
+// Payloads of handlers registered with Describe are documented from their Go types;
+// other bodies are left as free-form objects.
+func Build(routes gin.RoutesInfo, opts Options) *Document {
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     opts.Info,
+		Security: []map[string][]string{{bearerScheme: {}}},
+		Paths:    make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: map[string]Schema{
+				"Envelope": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"success":    {Type: "boolean"},
+						"code":       {Type: "string"},
+						"message":    {Type: "string"},
+						"data":       {Nullable: true},
+						"pagination": {Type: "object", Nullable: true},
+						"details":    {Nullable: true},
+						"error":      {Nullable: true},
+						"requestId":  {Type: "string"},
+					},
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	if opts.ServerURL != "" {
+		doc.Servers = []Server{{URL: opts.ServerURL}}
+	}
+
+	sorted := make(gin.RoutesInfo, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	tags := make(map[string]bool)
+	operationIDs := make(map[string]int)
+	schemas := newSchemaRegistry(doc.Components.Schemas)
+
+	for _, route := range sorted {
+		if route.Method == http.MethodHead || hasPrefix(route.Path, opts.Exclude) {
+			continue
+		}
+
+		tag, action := describeHandler(route)
+		tags[tag] = true
+		spec, described := specFor(route.Handler)
+
+		op := &Operation{
+			Tags:        []string{tag},
+			Summary:     humanize(action),
+			OperationID: uniqueOperationID(operationIDs, tag+upperFirst(action)),
+			Parameters:  pathParameters(route.Path),
+			Responses:   responses(schemas, spec.Response),
+		}
+
+		if strings.HasPrefix(action, "List") && route.Method == http.MethodGet {
+			op.Parameters = append(op.Parameters, paginationParameters()...)
+		}
+
+		switch {
+		case spec.Request != nil:
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: schemas.schemaOf(reflect.TypeOf(spec.Request))},
+				},
+			}
+		case spec.RequestType != "":
+			schema := Schema{Type: "string", Format: "binary"}
+			if strings.HasPrefix(spec.RequestType, "multipart/") {
+				schema = Schema{Type: "object"}
+			}
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{spec.RequestType: {Schema: schema}},
+			}
+		case described:
+			// The handler reads no body
+		case route.Method == http.MethodPost, route.Method == http.MethodPut, route.Method == http.MethodPatch:
+			op.RequestBody = &RequestBody{
+				Content: map[string]MediaType{"application/json": {Schema: Schema{Type: "object"}}},
+			}
+		}
+
+		if hasPrefix(route.Path, opts.Public) {
+			op.Security = &[]map[string][]string{}
+		} else {
+			op.Responses["401"] = Response{Description: "Missing or invalid token", Content: envelopeContent()}
+			op.Responses["403"] = Response{Description: "Insufficient permissions", Content: envelopeContent()}
+		}
+
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	return doc
+}
+
+// describeHandler derives a tag (the feature package) and action (the handler method)
+// from the route's handler name, falling back to the first path segment.
+func describeHandler(route gin.RouteInfo) (string, string) {
+	if m := handlerName.FindStringSubmatch(route.Handler); m != nil {
+		return m[1], m[2]
+	}
+
+	segments := strings.Split(strings.Trim(route.Path, "/"), "/")
+	tag := segments[0]
+	if tag == "api" && len(segments) > 1 {
+		tag = segments[1]
+	}
+	return tag, strings.ToLower(route.Method) + upperFirst(tag)
+}
+
+func pathParameters(path string) []Parameter {
+	matches := pathParam.FindAllStringSubmatch(path, -1)
+	params := make([]Parameter, 0, len(matches))
+	for _, m := range matches {
+		schema := Schema{Type: "string"}
+		if m[1] == "partNumber" {
+			minimum := 1
+			schema = Schema{Type: "integer", Minimum: &minimum}
+		}
+		params = append(params, Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+func paginationParameters() []Parameter {
+	minimum := 1
+	return []Parameter{
+		{Name: "page", In: "query", Schema: Schema{Type: "integer", Minimum: &minimum}},
+		{Name: "limit", In: "query", Schema: Schema{Type: "integer", Minimum: &minimum}},
+	}
+}
+
+// responses documents the envelope, narrowing its data field to data's type when known.
+func responses(schemas *schemaRegistry, data any) map[string]Response {
+	success := envelopeContent()
+	if data != nil {
+		success = map[string]MediaType{"application/json": {Schema: Schema{AllOf: []Schema{
+			{Ref: "#/components/schemas/Envelope"},
+			{Type: "object", Properties: map[string]Schema{"data": schemas.schemaOf(reflect.TypeOf(data))}},
+		}}}}
+	}
+
+	return map[string]Response{
+		"2XX":     {Description: "Successful response", Content: success},
+		"default": {Description: "Error response", Content: envelopeContent()},
+	}
+}
+
+func envelopeContent() map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: Schema{Ref: "#/components/schemas/Envelope"}}}
+}
+
+func uniqueOperationID(seen map[string]int, id string) string {
+	seen[id]++
+	if n := seen[id]; n > 1 {
+		return fmt.Sprintf("%s%d", id, n)
+	}
+	return id
+}
+
+// humanize turns a Go method name such as "GetVideoURL" into "Get video URL".
+func humanize(name string) string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prevLower := unicode.IsLower(runes[i-1])
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(runes[i]) && (prevLower || nextLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+
+	for i, word := range words {
+		if i > 0 && strings.ToUpper(word) != word {
+			words[i] = strings.ToLower(word)
+		}
+	}
+	return upperFirst(strings.Join(words, " "))
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Spec describes the payloads of one handler. Request is a zero value of the type
+// the handler binds its JSON body into; Response is a zero value of what it returns
+// in the envelope's data field. Either may be nil.
+type Spec struct {
+	Request any
+	// RequestType documents a non-JSON body, such as a multipart form or raw
+	// upload, for handlers that have no Request type.
+	RequestType string
+	Response    any
+}
+
+var (
+	specsMu sync.RWMutex
+	specs   = make(map[string]Spec)
+)
+
+// Describe records the payload types of handler so Build can document them. Routes
+// are matched by handler name, so every route served by the same method shares it.
+func Describe(handler gin.HandlerFunc, spec Spec) {
+	specsMu.Lock()
+	defer specsMu.Unlock()
+	specs[funcName(handler)] = spec
+}
+
+func specFor(handlerName string) (Spec, bool) {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+	spec, ok := specs[handlerName]
+	return spec, ok
+}
+
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	uuidType      = reflect.TypeOf(uuid.UUID{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaRegistry turns Go types into schemas, registering named structs as
+// components so recursive and shared models are emitted once.
+type schemaRegistry struct {
+	components map[string]Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaRegistry(components map[string]Schema) *schemaRegistry {
+	return &schemaRegistry{components: components, names: make(map[reflect.Type]string)}
+}
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) Schema {
+	if t == nil {
+		return Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return Schema{Type: "string", Format: "uuid"}
+	case isOptional(t):
+		value, _ := t.FieldByName("Value")
+		schema := r.schemaOf(value.Type)
+		schema.Nullable = true
+		return schema
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return marshalerSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{Type: "number"}
+	case reflect.String:
+		return Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{Type: "string", Format: "byte"}
+		}
+		items := r.schemaOf(t.Elem())
+		return Schema{Type: "array", Items: &items}
+	case reflect.Map:
+		values := r.schemaOf(t.Elem())
+		return Schema{Type: "object", AdditionalProperties: &values}
+	case reflect.Struct:
+		return r.structSchema(t)
+	default:
+		return Schema{}
+	}
+}
+
+// structSchema inlines anonymous structs and references named ones.
+func (r *schemaRegistry) structSchema(t reflect.Type) Schema {
+	if t.Name() == "" {
+		return r.objectSchema(t)
+	}
+
+	if name, ok := r.names[t]; ok {
+		return Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	r.names[t] = name
+	r.components[name] = r.objectSchema(t)
+	return Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (r *schemaRegistry) objectSchema(t reflect.Type) Schema {
+	schema := Schema{Type: "object", Properties: make(map[string]Schema)}
+	r.addFields(&schema, t)
+	return schema
+}
+
+// addFields mirrors encoding/json: embedded structs without a JSON name are
+// flattened and fields tagged "-" are skipped. Fields whose binding tag starts
+// with required are listed as required.
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = r.schemaOf(field.Type)
+		if rule, _, _ := strings.Cut(field.Tag.Get("binding"), ","); rule == "required" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// isOptional matches validation.Optional, whose JSON form is that of its Value.
+func isOptional(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || !strings.HasPrefix(t.Name(), "Optional[") {
+		return false
+	}
+	_, hasSet := t.FieldByName("Set")
+	_, hasValue := t.FieldByName("Value")
+	return hasSet && hasValue
+}
+
+// marshalerSchema describes types with custom JSON encodings. Decimals encode as
+// strings; anything else is left unconstrained.
+func marshalerSchema(t reflect.Type) Schema {
+	switch {
+	case t.Name() == "Decimal" || t.Name() == "Money":
+		return Schema{Type: "string", Format: "decimal"}
+	case t.Name() == "DeletedAt":
+		return Schema{Type: "string", Format: "date-time", Nullable: true}
+	default:
+		return Schema{}
+	}
+}
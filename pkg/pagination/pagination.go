@@ -1,11 +1,16 @@
 package pagination
 
 import (
+	"encoding/base64"
+	"errors"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 const (
@@ -14,11 +19,39 @@ const (
 	MaxLimit     = 100
 )
 
+// CursorParam is the query parameter that switches a list to keyset pagination.
+// An empty value requests the first page.
+const CursorParam = "cursor"
+
 // Params represents pagination query parameters.
 type Params struct {
 	Page  int
 	Limit int
 	Skip  int
+
+	// Cursor is set when the client asked for keyset pagination; After holds
+	// the position to continue from and is nil on the first page.
+	Cursor bool
+	After  *Cursor
+}
+
+// Cursor is a keyset position over rows ordered by created_at DESC, id DESC.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Keyed is implemented by rows that can be paged by cursor.
+type Keyed interface {
+	CursorKey() Cursor
+}
+
+// CursorMetadata is returned instead of Metadata for keyset pages. Totals are
+// omitted because counting the full result set is what cursors avoid.
+type CursorMetadata struct {
+	PageSize    int    `json:"pageSize"`
+	HasNextPage bool   `json:"hasNextPage"`
+	NextCursor  string `json:"nextCursor,omitempty"`
 }
 
 // Metadata holds pagination metadata mirrored from the Node implementation.
@@ -31,7 +64,8 @@ type Metadata struct {
 	HasPrevPage bool  `json:"hasPrevPage"`
 }
 
-// Extract reads pagination parameters from the request query string.
+// Extract reads offset pagination parameters from the request query string.
+// The cursor parameter is ignored; endpoints that page by keyset use ExtractCursor.
 func Extract(c *gin.Context) Params {
 	page := parsePositiveInt(c.Query("page"), DefaultPage)
 	limit := parsePositiveInt(c.Query("limit"), DefaultLimit)
@@ -51,6 +85,98 @@ func Extract(c *gin.Context) Params {
 	return Params{Page: page, Limit: limit, Skip: skip}
 }
 
+// ExtractCursor is Extract for endpoints that also support keyset pagination:
+// a cursor parameter switches to cursor mode. It returns ErrInvalidCursor when
+// the token cannot be decoded so the caller can reject it with request.InvalidCursorField
+// instead of serving the first page again.
+func ExtractCursor(c *gin.Context) (Params, error) {
+	params := Extract(c)
+
+	raw, ok := c.GetQuery(CursorParam)
+	if !ok {
+		return params, nil
+	}
+
+	after, err := DecodeCursor(raw)
+	if err != nil {
+		return params, err
+	}
+
+	params.Cursor = true
+	params.Page = DefaultPage
+	params.Skip = 0
+	params.After = after
+	return params, nil
+}
+
+// Apply orders query newest first and restricts it to the requested page.
+// In cursor mode one extra row is fetched so Page can tell whether more follow.
+func Apply(query *gorm.DB, params Params) *gorm.DB {
+	if !params.Cursor {
+		return query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit)
+	}
+
+	if params.After != nil {
+		query = query.Where("(created_at, id) < (?, ?)", params.After.CreatedAt, params.After.ID)
+	}
+	return query.Order("created_at DESC").Order("id DESC").Limit(params.Limit + 1)
+}
+
+// Page trims the look-ahead row fetched by Apply and builds the matching
+// response metadata. total is ignored in cursor mode.
+func Page[T Keyed](items []T, total int64, params Params) ([]T, interface{}) {
+	if !params.Cursor {
+		return items, MetadataFrom(total, params)
+	}
+
+	meta := CursorMetadata{PageSize: params.Limit}
+	if len(items) > params.Limit {
+		items = items[:params.Limit]
+		meta.HasNextPage = true
+		meta.NextCursor = EncodeCursor(items[len(items)-1].CursorKey())
+	}
+	return items, meta
+}
+
+// EncodeCursor returns the opaque token for a keyset position.
+func EncodeCursor(cursor Cursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. An empty token is the
+// first page and decodes to nil.
+func DecodeCursor(token string) (*Cursor, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: parsedTime, ID: parsedID}, nil
+}
+
+// ErrInvalidCursor is returned for tokens that were not produced by EncodeCursor.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
 // MetadataFrom builds response metadata given totals.
 func MetadataFrom(total int64, params Params) Metadata {
 	totalPages := 0
@@ -0,0 +1,73 @@
+// Package replica routes read-heavy queries to an optional read replica.
+package replica
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+const pluginName = "read_replica"
+
+// Plugin is a GORM plugin that carries the read replica's connection pool on
+// the primary connection, so any *gorm.DB can be routed to it with Reader.
+type Plugin struct {
+	pool  gorm.ConnPool
+	sqlDB *sql.DB
+}
+
+// NewPlugin wraps the connection pool of an opened replica.
+func NewPlugin(pool gorm.ConnPool, sqlDB *sql.DB) *Plugin {
+	return &Plugin{pool: pool, sqlDB: sqlDB}
+}
+
+// Name returns the plugin name.
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+// Initialize initializes the plugin.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return nil
+}
+
+// Close closes the replica connection pool.
+func (p *Plugin) Close() error {
+	return p.sqlDB.Close()
+}
+
+// Lookup returns the replica registered on db, if any.
+func Lookup(db *gorm.DB) (*Plugin, bool) {
+	plugin, ok := db.Config.Plugins[pluginName].(*Plugin)
+	return plugin, ok
+}
+
+// Reader returns a session of db whose queries run on the read replica. Use it
+// for heavy lists and analytics that tolerate replication lag, never for writes.
+// Without a replica, or inside a transaction, db is returned unchanged.
+func Reader(db *gorm.DB) *gorm.DB {
+	if db == nil {
+		return db
+	}
+
+	plugin, ok := Lookup(db)
+	if !ok {
+		return db
+	}
+
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return db
+	}
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// A context session clones the statement and config, so the primary is left untouched
+	tx := db.Session(&gorm.Session{Context: ctx})
+	tx.Statement.ConnPool = plugin.pool
+	tx.Config.ConnPool = plugin.pool
+	return tx
+}
@@ -0,0 +1,116 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+	"github.com/mo-amir99/lms-server-go/pkg/validation"
+)
+
+// ValidationFailedMessage is the message of every 422 response.
+const ValidationFailedMessage = "Validation failed"
+
+// InvalidCursorField is reported when pagination.ExtractCursor rejects the cursor.
+var InvalidCursorField = validation.FieldError{
+	Field:   pagination.CursorParam,
+	Rule:    "cursor",
+	Message: "must be a cursor returned by a previous page",
+}
+
+// BindJSON decodes and validates the request body into dest. Invalid fields,
+// oversized arrays and, in strict mode, unknown fields produce a 422 listing
+// each of them; a body over the route's Limits a 413; a malformed body a 400
+// with message. It reports whether the handler may continue.
+func BindJSON(logger *slog.Logger, c *gin.Context, dest interface{}, message string) bool {
+	limits := limitsOf(c)
+	err := readBody(c, limits)
+	if err == nil {
+		err = c.ShouldBindJSON(dest)
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, errBodyTooLarge) || errors.As(err, &maxBytesErr) {
+		response.Error(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", limits.MaxBytes), nil)
+		return false
+	}
+
+	var shapeErr *shapeError
+	if errors.As(err, &shapeErr) {
+		RespondInvalid(c, validation.FieldError{
+			Field:   shapeErr.field,
+			Rule:    "max",
+			Message: fmt.Sprintf("must have at most %d items", shapeErr.max),
+		})
+		return false
+	}
+
+	// Strict mode, see Configure
+	if field, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		RespondInvalid(c, validation.FieldError{
+			Field:   strings.TrimSuffix(field, `"`),
+			Rule:    "unknown",
+			Message: "is not a known field",
+		})
+		return false
+	}
+
+	if fields, ok := validation.Fields(err); ok {
+		RespondInvalid(c, fields...)
+		return false
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		RespondInvalid(c, validation.FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be " + jsonKind(typeErr.Type),
+		})
+		return false
+	}
+
+	response.ErrorWithLog(logger, c, http.StatusBadRequest, message, err)
+	return false
+}
+
+// RespondInvalid writes the 422 body for fields rejected outside of binding,
+// such as checks that need the database.
+func RespondInvalid(c *gin.Context, fields ...validation.FieldError) {
+	response.Error(c, http.StatusUnprocessableEntity, ValidationFailedMessage, fields)
+}
+
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a valid value"
+	}
+}
@@ -29,7 +29,13 @@ func Handler(logger *slog.Logger) gin.HandlerFunc {
 
 		var appErr *apperrors.AppError
 		if errors.As(err, &appErr) {
-			response.ErrorWithLog(logger, c, appErr.StatusCode(), appErr.Message(), err)
+			logger.ErrorContext(c.Request.Context(), appErr.Message(), slog.Int("status", appErr.StatusCode()), slog.String("error", err.Error()))
+			code := response.Code(strings.ToUpper(string(appErr.Code())))
+			if appErr.Fields() != nil {
+				response.ErrorCode(c, appErr.StatusCode(), code, appErr.Message(), appErr.Fields())
+			} else {
+				response.ErrorCode(c, appErr.StatusCode(), code, appErr.Message(), err.Error())
+			}
 			return
 		}
 
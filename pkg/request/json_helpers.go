@@ -22,6 +22,26 @@ func ParseRFC3339Ptr(value *string) (*time.Time, error) {
 	return &parsed, nil
 }
 
+// Trimmed returns a whitespace-trimmed copy of an optional string.
+func Trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	return &trimmed
+}
+
+// TrimStrings trims each entry and drops the empty ones.
+func TrimStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
 // ReadString trims the input if it is a string and returns an error otherwise.
 func ReadString(value interface{}) (string, error) {
 	switch v := value.(type) {
@@ -35,37 +55,3 @@ func ReadString(value interface{}) (string, error) {
 		return "", fmt.Errorf("value is not a string")
 	}
 }
-
-// ReadInt converts JSON numbers (float64) to int when possible.
-func ReadInt(value interface{}) (int, error) {
-	switch v := value.(type) {
-	case float64:
-		return int(v), nil
-	case int:
-		return v, nil
-	default:
-		return 0, fmt.Errorf("value is not a number")
-	}
-}
-
-// ReadFloat converts JSON numbers to float64.
-func ReadFloat(value interface{}) (float64, error) {
-	switch v := value.(type) {
-	case float64:
-		return v, nil
-	case int:
-		return float64(v), nil
-	default:
-		return 0, fmt.Errorf("value is not a number")
-	}
-}
-
-// ReadBool asserts that the value is a boolean.
-func ReadBool(value interface{}) (bool, error) {
-	switch v := value.(type) {
-	case bool:
-		return v, nil
-	default:
-		return false, fmt.Errorf("value is not a boolean")
-	}
-}
@@ -0,0 +1,184 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Limits bound the JSON bodies BindJSON decodes, so a handler never binds
+// more than it expects, including into map[string]interface{}.
+type Limits struct {
+	// MaxBytes is the largest body accepted.
+	MaxBytes int64
+	// MaxDepth is how deeply objects and arrays may nest.
+	MaxDepth int
+	// MaxItems is the most elements of one array or fields of one object.
+	MaxItems int
+}
+
+// DefaultLimits apply to every endpoint that does not set its own with Limit.
+var DefaultLimits = Limits{MaxBytes: 1 << 20, MaxDepth: 32, MaxItems: 1000}
+
+const limitsKey = "request.limits"
+
+// Configure sets the default body size and strict mode, in which JSON bodies
+// with fields the destination does not declare are rejected. Call it once at
+// startup.
+func Configure(strict bool, maxBytes int64) {
+	binding.EnableDecoderDisallowUnknownFields = strict
+	if maxBytes > 0 {
+		DefaultLimits.MaxBytes = maxBytes
+	}
+}
+
+// Limit replaces DefaultLimits on the routes it is added to, such as bulk
+// endpoints that accept more. Zero fields keep their default.
+func Limit(limits Limits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(limitsKey, limits)
+		c.Next()
+	}
+}
+
+func limitsOf(c *gin.Context) Limits {
+	limits := DefaultLimits
+	if value, ok := c.Get(limitsKey); ok {
+		override := value.(Limits)
+		if override.MaxBytes > 0 {
+			limits.MaxBytes = override.MaxBytes
+		}
+		if override.MaxDepth > 0 {
+			limits.MaxDepth = override.MaxDepth
+		}
+		if override.MaxItems > 0 {
+			limits.MaxItems = override.MaxItems
+		}
+	}
+	return limits
+}
+
+var (
+	errBodyTooLarge = errors.New("request body too large")
+	errTooDeep      = errors.New("request body nests too deeply")
+)
+
+// shapeError reports a container with more than Limits.MaxItems entries.
+type shapeError struct {
+	field string
+	max   int
+}
+
+func (e *shapeError) Error() string {
+	return fmt.Sprintf("%s has more than %d items", e.field, e.max)
+}
+
+// readBody reads the request body within limits and puts it back for binding.
+func readBody(c *gin.Context, limits Limits) error {
+	if c.Request.Body == nil {
+		return nil
+	}
+	if c.Request.ContentLength > limits.MaxBytes {
+		return errBodyTooLarge
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, limits.MaxBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > limits.MaxBytes {
+		return errBodyTooLarge
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return checkShape(body, limits)
+}
+
+// frame is an open object or array while walking a body.
+type frame struct {
+	array bool
+	// key is the current field of an object; count its fields or elements so far
+	key   string
+	count int
+	// wantKey is set while the next token of an object is a field name
+	wantKey bool
+}
+
+// checkShape walks the body's tokens, rejecting nesting deeper than
+// MaxDepth and containers with more than MaxItems entries. Malformed JSON
+// is left for the binder to report.
+func checkShape(body []byte, limits Limits) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var stack []frame
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+
+		var parent *frame
+		if len(stack) > 0 {
+			parent = &stack[len(stack)-1]
+		}
+		delim, isDelim := token.(json.Delim)
+
+		if parent != nil && !parent.array && parent.wantKey && delim != '}' {
+			parent.key, _ = token.(string)
+			parent.wantKey = false
+			if parent.count++; parent.count > limits.MaxItems {
+				return &shapeError{field: fieldPath(stack[:len(stack)-1]), max: limits.MaxItems}
+			}
+			continue
+		}
+
+		if isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		// Any other token is a value of its parent
+		if parent != nil {
+			if parent.array {
+				if parent.count++; parent.count > limits.MaxItems {
+					return &shapeError{field: fieldPath(stack[:len(stack)-1]), max: limits.MaxItems}
+				}
+			} else {
+				parent.wantKey = true
+			}
+		}
+		if isDelim {
+			stack = append(stack, frame{array: delim == '[', wantKey: delim == '{'})
+			if len(stack) > limits.MaxDepth {
+				return errTooDeep
+			}
+		}
+	}
+}
+
+// fieldPath names the container below frames, such as items[2].tags, in
+// the notation of the validation errors.
+func fieldPath(frames []frame) string {
+	var path strings.Builder
+	for _, f := range frames {
+		if f.array {
+			path.WriteString("[" + strconv.Itoa(f.count-1) + "]")
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteByte('.')
+		}
+		path.WriteString(f.key)
+	}
+	if path.Len() == 0 {
+		return "body"
+	}
+	return path.String()
+}
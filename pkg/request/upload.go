@@ -0,0 +1,39 @@
+package request
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mo-amir99/lms-server-go/pkg/filetype"
+	"github.com/mo-amir99/lms-server-go/pkg/response"
+)
+
+// RespondRejectedFile writes a 415 or 413 for uploads refused by a filetype
+// policy, listing what the policy accepts. It reports false for other errors
+// so the caller can handle them.
+func RespondRejectedFile(logger *slog.Logger, c *gin.Context, err error) bool {
+	var rejected *filetype.RejectedError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+
+	status := http.StatusUnsupportedMediaType
+	if errors.Is(err, filetype.ErrTooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+
+	data := gin.H{
+		"type":                rejected.Policy.Kind,
+		"allowedExtensions":   rejected.Policy.Extensions,
+		"allowedContentTypes": rejected.Policy.ContentTypes,
+		"maxSizeBytes":        rejected.Policy.MaxSize,
+	}
+	if rejected.Detected != "" {
+		data["detectedContentType"] = rejected.Detected
+	}
+	response.ErrorWithData(logger, c, status, rejected.Error(), data, nil)
+	return true
+}
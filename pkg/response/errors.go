@@ -0,0 +1,122 @@
+package response
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code identifies an error for clients, which branch on it instead of the
+// message. Codes are stable; messages may be reworded.
+type Code string
+
+// Codes for errors without a more specific one, derived from the status.
+const (
+	CodeBadRequest         Code = "BAD_REQUEST"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeConflict           Code = "CONFLICT"
+	CodeGone               Code = "GONE"
+	CodePreconditionFailed Code = "PRECONDITION_FAILED"
+	CodePayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeBadGateway         Code = "BAD_GATEWAY"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+)
+
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusGone:                  CodeGone,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusBadGateway:            CodeBadGateway,
+	http.StatusServiceUnavailable:    CodeServiceUnavailable,
+}
+
+// StatusCode returns the generic code of an HTTP status.
+func StatusCode(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// ErrorDef maps a feature error to the response clients receive for it.
+type ErrorDef struct {
+	Err     error
+	Status  int
+	Code    Code
+	Message string
+}
+
+// Catalog lists the errors a feature answers with something other than 500,
+// in the order they are matched.
+type Catalog []ErrorDef
+
+// Lookup returns the first definition whose error err wraps.
+func (cat Catalog) Lookup(err error) (ErrorDef, bool) {
+	for _, def := range cat {
+		if errors.Is(err, def.Err) {
+			return def, true
+		}
+	}
+	return ErrorDef{}, false
+}
+
+// Respond writes the response defined for err, or logs it and answers 500
+// with fallback as the message when the catalog does not list it.
+func (cat Catalog) Respond(logger *slog.Logger, c *gin.Context, err error, fallback string) {
+	def, ok := cat.Lookup(err)
+	if !ok {
+		ErrorWithLog(logger, c, http.StatusInternalServerError, fallback, err)
+		return
+	}
+	code, message := def.describe(err)
+	ErrorCode(c, def.Status, code, message, nil)
+}
+
+// RespondWithData writes the response defined for err along with data that
+// lets clients act on it, such as when a locked lesson becomes available.
+func (cat Catalog) RespondWithData(logger *slog.Logger, c *gin.Context, err error, data interface{}) {
+	def, ok := cat.Lookup(err)
+	if !ok {
+		ErrorWithData(logger, c, http.StatusInternalServerError, "Internal server error", data, err)
+		return
+	}
+	code, message := def.describe(err)
+	c.JSON(def.Status, Envelope{
+		Success:   false,
+		Code:      code,
+		Message:   message,
+		Data:      data,
+		RequestID: c.GetString(requestIDKey),
+	})
+}
+
+// describe fills in the code of the status and the message of err where the
+// definition leaves them out.
+func (def ErrorDef) describe(err error) (Code, string) {
+	code, message := def.Code, def.Message
+	if code == "" {
+		code = StatusCode(def.Status)
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return code, message
+}
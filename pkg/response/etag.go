@@ -0,0 +1,56 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag builds a weak entity tag from the parts a response is derived from,
+// typically record ids with their updated_at timestamps.
+func ETag(parts ...interface{}) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		if ts, ok := part.(time.Time); ok {
+			part = ts.UnixNano()
+		}
+		fmt.Fprintf(hash, "%v|", part)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// NotModified sets etag on the response and makes clients revalidate instead of
+// dropping their copy. When the request's If-None-Match matches it writes a 304
+// and reports true, in which case the handler must not write a body.
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	c.Writer.Header().Del("Pragma")
+	c.Writer.Header().Del("Expires")
+
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison If-None-Match requires.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -8,14 +8,24 @@ import (
 )
 
 // Envelope represents the standard API response shape shared with the legacy Node implementation.
+// Error responses also carry a Code and the RequestID to quote in support requests.
 type Envelope struct {
-	Success    bool        `json:"success"`
-	Message    string      `json:"message,omitempty"`
-	Data       interface{} `json:"data,omitempty"`
+	Success bool        `json:"success"`
+	Code    Code        `json:"code,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	// Details elaborates an error, such as the rejected fields of a 422.
+	Details interface{} `json:"details,omitempty"`
+	// Error repeats Details for clients written against the legacy Node API.
 	Error      interface{} `json:"error,omitempty"`
+	RequestID  string      `json:"requestId,omitempty"`
 	Pagination interface{} `json:"pagination,omitempty"`
 }
 
+// requestIDKey is where middleware.RequestID stores the request ID. That
+// package writes its own errors through this one, so it cannot be imported.
+const requestIDKey = "request_id"
+
 // Success writes a success response with optional message and data.
 func Success(c *gin.Context, status int, data interface{}, message string, pagination interface{}) {
 	c.JSON(status, Envelope{
@@ -36,26 +46,40 @@ func NoContent(c *gin.Context, message string) {
 	Success(c, http.StatusNoContent, nil, message, nil)
 }
 
-// Error writes an error response capturing the message and optional error payload.
+// Error writes an error response capturing the message and optional error
+// payload, coded by status; see ErrorCode for a specific code.
 func Error(c *gin.Context, status int, message string, err interface{}) {
+	ErrorCode(c, status, StatusCode(status), message, err)
+}
+
+// ErrorCode writes an error response with a specific code and optional details.
+func ErrorCode(c *gin.Context, status int, code Code, message string, details interface{}) {
 	c.JSON(status, Envelope{
-		Success: false,
-		Message: message,
-		Error:   err,
+		Success:   false,
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		Error:     details,
+		RequestID: c.GetString(requestIDKey),
 	})
 }
 
 // ErrorWithLog writes an error response and logs the error via slog.
 func ErrorWithLog(logger *slog.Logger, c *gin.Context, status int, message string, err error) {
+	ErrorCodeWithLog(logger, c, status, StatusCode(status), message, err)
+}
+
+// ErrorCodeWithLog writes an error response with a specific code and logs the error via slog.
+func ErrorCodeWithLog(logger *slog.Logger, c *gin.Context, status int, code Code, message string, err error) {
 	if logger != nil && err != nil {
 		logger.ErrorContext(c.Request.Context(), message, slog.Int("status", status), slog.String("error", err.Error()))
 	}
 
 	// Return a serialized error value so clients receive a useful message
 	if err != nil {
-		Error(c, status, message, err.Error())
+		ErrorCode(c, status, code, message, err.Error())
 	} else {
-		Error(c, status, message, nil)
+		ErrorCode(c, status, code, message, nil)
 	}
 }
 
@@ -66,9 +90,11 @@ func ErrorWithData(logger *slog.Logger, c *gin.Context, status int, message stri
 	}
 
 	c.JSON(status, Envelope{
-		Success: false,
-		Message: message,
-		Data:    data,
-		Error:   err,
+		Success:   false,
+		Code:      StatusCode(status),
+		Message:   message,
+		Data:      data,
+		Error:     err,
+		RequestID: c.GetString(requestIDKey),
 	})
 }
@@ -0,0 +1,190 @@
+package saml
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// canonicalizer renders an element with Exclusive XML Canonicalization 1.0
+// without comments (https://www.w3.org/TR/xml-exc-c14n/), the form SAML
+// signatures are computed over.
+type canonicalizer struct {
+	buf bytes.Buffer
+	// exclude is left out of the output; it is the enveloped signature.
+	exclude *element
+	// inclusive lists the prefixes rendered like inclusive canonicalization
+	// would, from the transform's InclusiveNamespaces PrefixList.
+	inclusive []string
+}
+
+// canonicalize returns the canonical form of el without exclude.
+func canonicalize(el, exclude *element, inclusive []string) ([]byte, error) {
+	c := &canonicalizer{exclude: exclude, inclusive: inclusive}
+	if err := c.element(el, map[string]string{}); err != nil {
+		return nil, err
+	}
+	return c.buf.Bytes(), nil
+}
+
+type declaration struct {
+	prefix string
+	uri    string
+}
+
+type canonicalAttr struct {
+	namespace string
+	name      string
+	value     string
+}
+
+// element renders el. rendered holds the namespace declarations already
+// output by its ancestors.
+func (c *canonicalizer) element(el *element, rendered map[string]string) error {
+	// Only the prefixes the element and its attributes use are declared
+	used := []string{el.prefix}
+	for _, attr := range el.attrs {
+		if attr.Name.Space != "" && attr.Name.Space != "xml" {
+			used = append(used, attr.Name.Space)
+		}
+	}
+	for _, prefix := range c.inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		if _, ok := el.lookup(prefix); ok {
+			used = append(used, prefix)
+		}
+	}
+
+	var declarations []declaration
+	scope := rendered
+	for _, prefix := range used {
+		uri, ok := el.lookup(prefix)
+		if !ok {
+			return fmt.Errorf("%w: undeclared prefix %q", ErrMalformed, prefix)
+		}
+		previous, declared := scope[prefix]
+		if declared && previous == uri {
+			continue
+		}
+		if !declared && prefix == "" && uri == "" {
+			continue
+		}
+		if len(declarations) == 0 {
+			scope = make(map[string]string, len(rendered)+1)
+			for k, v := range rendered {
+				scope[k] = v
+			}
+		}
+		scope[prefix] = uri
+		declarations = append(declarations, declaration{prefix: prefix, uri: uri})
+	}
+	sort.Slice(declarations, func(i, j int) bool { return declarations[i].prefix < declarations[j].prefix })
+
+	attrs := make([]canonicalAttr, 0, len(el.attrs))
+	for _, attr := range el.attrs {
+		name := attr.Name.Local
+		namespace := ""
+		if attr.Name.Space != "" {
+			name = attr.Name.Space + ":" + attr.Name.Local
+			namespace, _ = el.lookup(attr.Name.Space)
+		}
+		attrs = append(attrs, canonicalAttr{namespace: namespace, name: name, value: attr.Value})
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].namespace != attrs[j].namespace {
+			return attrs[i].namespace < attrs[j].namespace
+		}
+		return localName(attrs[i].name) < localName(attrs[j].name)
+	})
+
+	name := qualified(el.prefix, el.local)
+	c.buf.WriteByte('<')
+	c.buf.WriteString(name)
+	for _, d := range declarations {
+		if d.prefix == "" {
+			c.buf.WriteString(` xmlns="`)
+		} else {
+			c.buf.WriteString(` xmlns:` + d.prefix + `="`)
+		}
+		escapeAttr(&c.buf, d.uri)
+		c.buf.WriteByte('"')
+	}
+	for _, attr := range attrs {
+		c.buf.WriteString(" " + attr.name + `="`)
+		escapeAttr(&c.buf, attr.value)
+		c.buf.WriteByte('"')
+	}
+	c.buf.WriteByte('>')
+
+	for _, n := range el.children {
+		switch child := n.(type) {
+		case *element:
+			if child == c.exclude {
+				continue
+			}
+			if err := c.element(child, scope); err != nil {
+				return err
+			}
+		case text:
+			escapeText(&c.buf, string(child))
+		}
+	}
+
+	c.buf.WriteString("</" + name + ">")
+	return nil
+}
+
+func qualified(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+func localName(name string) string {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func escapeText(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+func escapeAttr(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
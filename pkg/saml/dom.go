@@ -0,0 +1,170 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// element is a node of a parsed document. Names keep the prefixes they were
+// written with so the element can be canonicalized exactly as it was signed.
+type element struct {
+	prefix string
+	local  string
+	// attrs excludes namespace declarations, which live in ns.
+	attrs []xml.Attr
+	// ns maps the prefixes declared on the element to their URIs; "" is the
+	// default namespace.
+	ns       map[string]string
+	parent   *element
+	children []node
+}
+
+// node is either an *element or text.
+type node interface{}
+
+type text string
+
+// parse reads a document into a tree. Doctypes, and with them entity
+// declarations, are refused.
+func parse(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root, current *element
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if current == nil && root != nil {
+				return nil, fmt.Errorf("%w: multiple root elements", ErrMalformed)
+			}
+			el := &element{prefix: t.Name.Space, local: t.Name.Local, ns: map[string]string{}, parent: current}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					el.ns[attr.Name.Local] = attr.Value
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					el.ns[""] = attr.Value
+				default:
+					el.attrs = append(el.attrs, attr)
+				}
+			}
+			if current == nil {
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, fmt.Errorf("%w: unexpected end element %s", ErrMalformed, t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, text(t))
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("%w: doctypes are not allowed", ErrMalformed)
+		case xml.ProcInst:
+			if current != nil {
+				return nil, fmt.Errorf("%w: processing instructions are not allowed", ErrMalformed)
+			}
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, fmt.Errorf("%w: incomplete document", ErrMalformed)
+	}
+	return root, nil
+}
+
+// lookup resolves a prefix to the URI in scope at the element.
+func (e *element) lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for el := e; el != nil; el = el.parent {
+		if uri, ok := el.ns[prefix]; ok {
+			return uri, true
+		}
+	}
+	// Without a declaration the default namespace is empty
+	return "", prefix == ""
+}
+
+func (e *element) namespace() string {
+	uri, _ := e.lookup(e.prefix)
+	return uri
+}
+
+func (e *element) is(namespace, local string) bool {
+	return e.local == local && e.namespace() == namespace
+}
+
+// attr returns the value of an unqualified attribute.
+func (e *element) attr(local string) string {
+	for _, attr := range e.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// child returns the first child element with the name.
+func (e *element) child(namespace, local string) *element {
+	for _, n := range e.children {
+		if el, ok := n.(*element); ok && el.is(namespace, local) {
+			return el
+		}
+	}
+	return nil
+}
+
+// elements returns the child elements with the name.
+func (e *element) elements(namespace, local string) []*element {
+	var found []*element
+	for _, n := range e.children {
+		if el, ok := n.(*element); ok && el.is(namespace, local) {
+			found = append(found, el)
+		}
+	}
+	return found
+}
+
+// text returns the element's character data, ignoring child elements.
+func (e *element) text() string {
+	var buf bytes.Buffer
+	for _, n := range e.children {
+		if t, ok := n.(text); ok {
+			buf.WriteString(string(t))
+		}
+	}
+	return buf.String()
+}
+
+// countIDs counts the elements of the tree whose ID attribute is id.
+func (e *element) countIDs(id string) int {
+	count := 0
+	if e.attr("ID") == id {
+		count++
+	}
+	for _, n := range e.children {
+		if el, ok := n.(*element); ok {
+			count += el.countIDs(id)
+		}
+	}
+	return count
+}
@@ -0,0 +1,17 @@
+package saml
+
+import "errors"
+
+var (
+	ErrMalformed            = errors.New("saml: malformed document")
+	ErrInvalidMetadata      = errors.New("saml: invalid identity provider metadata")
+	ErrInvalidSignature     = errors.New("saml: invalid signature")
+	ErrUnsupportedAlgorithm = errors.New("saml: unsupported algorithm")
+	ErrUnsigned             = errors.New("saml: response is not signed")
+	ErrEncryptedAssertion   = errors.New("saml: encrypted assertions are not supported")
+	ErrInvalidResponse      = errors.New("saml: invalid response")
+	ErrStatus               = errors.New("saml: identity provider refused the sign-in")
+	ErrExpired              = errors.New("saml: assertion is not valid at this time")
+	ErrAudience             = errors.New("saml: assertion is meant for another service provider")
+	ErrTransientNameID      = errors.New("saml: transient name identifiers cannot be linked")
+)
@@ -0,0 +1,185 @@
+package saml
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	metadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	assertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	protocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	redirectBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	postBinding     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// IdentityProvider is what a service provider needs to know about an IdP.
+type IdentityProvider struct {
+	EntityID string
+	// SSOURL receives authentication requests with the HTTP-Redirect binding.
+	SSOURL string
+	// Certificates are the base64 DER signing certificates.
+	Certificates []string
+}
+
+// ServiceProvider identifies this server to an IdP.
+type ServiceProvider struct {
+	EntityID string
+	// ACSURL is the assertion consumer service IdPs post responses to.
+	ACSURL string
+}
+
+// ParseMetadata reads an IdP's metadata document as exported by Microsoft
+// Entra ID, AD FS and most other IdPs. An EntitiesDescriptor must hold exactly
+// one IdP. The metadata's own signature is not checked; it is trusted as
+// uploaded by an administrator.
+func ParseMetadata(data []byte) (IdentityProvider, error) {
+	root, err := parse(data)
+	if err != nil {
+		return IdentityProvider{}, err
+	}
+
+	var entities []*element
+	switch {
+	case root.is(metadataNamespace, "EntityDescriptor"):
+		entities = []*element{root}
+	case root.is(metadataNamespace, "EntitiesDescriptor"):
+		entities = root.elements(metadataNamespace, "EntityDescriptor")
+	default:
+		return IdentityProvider{}, fmt.Errorf("%w: not a metadata document", ErrInvalidMetadata)
+	}
+
+	var found []IdentityProvider
+	for _, entity := range entities {
+		descriptor := entity.child(metadataNamespace, "IDPSSODescriptor")
+		if descriptor == nil {
+			continue
+		}
+		idp, err := identityProvider(entity, descriptor)
+		if err != nil {
+			return IdentityProvider{}, err
+		}
+		found = append(found, idp)
+	}
+
+	switch len(found) {
+	case 0:
+		return IdentityProvider{}, fmt.Errorf("%w: no identity provider in the document", ErrInvalidMetadata)
+	case 1:
+		return found[0], nil
+	}
+	return IdentityProvider{}, fmt.Errorf("%w: the document describes several identity providers", ErrInvalidMetadata)
+}
+
+func identityProvider(entity, descriptor *element) (IdentityProvider, error) {
+	idp := IdentityProvider{EntityID: strings.TrimSpace(entity.attr("entityID"))}
+	if idp.EntityID == "" {
+		return idp, fmt.Errorf("%w: missing entityID", ErrInvalidMetadata)
+	}
+
+	for _, service := range descriptor.elements(metadataNamespace, "SingleSignOnService") {
+		if service.attr("Binding") == redirectBinding {
+			idp.SSOURL = strings.TrimSpace(service.attr("Location"))
+			break
+		}
+	}
+	if idp.SSOURL == "" {
+		return idp, fmt.Errorf("%w: the identity provider must accept the HTTP-Redirect binding", ErrInvalidMetadata)
+	}
+	if parsed, err := url.Parse(idp.SSOURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return idp, fmt.Errorf("%w: the sign-on URL must use https", ErrInvalidMetadata)
+	}
+
+	for _, key := range descriptor.elements(metadataNamespace, "KeyDescriptor") {
+		if use := key.attr("use"); use != "" && use != "signing" {
+			continue
+		}
+		keyInfo := key.child(dsigNamespace, "KeyInfo")
+		if keyInfo == nil {
+			continue
+		}
+		for _, data := range keyInfo.elements(dsigNamespace, "X509Data") {
+			for _, cert := range data.elements(dsigNamespace, "X509Certificate") {
+				der, err := decodeBase64(cert.text())
+				if err != nil {
+					return idp, fmt.Errorf("%w: certificate is not base64", ErrInvalidMetadata)
+				}
+				if _, err := parseCertificate(der); err != nil {
+					return idp, err
+				}
+				idp.Certificates = append(idp.Certificates, base64.StdEncoding.EncodeToString(der))
+			}
+		}
+	}
+	if len(idp.Certificates) == 0 {
+		return idp, fmt.Errorf("%w: no signing certificate", ErrInvalidMetadata)
+	}
+	return idp, nil
+}
+
+// CertificatesExpireAt returns when the last of the certificates expires.
+func CertificatesExpireAt(certificates []string) time.Time {
+	var latest time.Time
+	for _, encoded := range certificates {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(der); err == nil && cert.NotAfter.After(latest) {
+			latest = cert.NotAfter
+		}
+	}
+	return latest
+}
+
+// parseCertificate parses a signing certificate. Only RSA keys are supported.
+// Expiry is not enforced: IdPs commonly keep signing with self-signed
+// certificates past their dates, and the key is trusted by configuration.
+func parseCertificate(der []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("%w: only RSA signing keys are supported", ErrInvalidMetadata)
+	}
+	return cert, nil
+}
+
+func (idp IdentityProvider) certificates() ([]*x509.Certificate, error) {
+	certificates := make([]*x509.Certificate, 0, len(idp.Certificates))
+	for _, encoded := range idp.Certificates {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: certificate is not base64", ErrInvalidMetadata)
+		}
+		cert, err := parseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, cert)
+	}
+	return certificates, nil
+}
+
+// Metadata returns the service provider's metadata document IdPs import.
+func (sp ServiceProvider) Metadata() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<md:EntityDescriptor xmlns:md="` + metadataNamespace + `" entityID="`)
+	xml.EscapeText(&buf, []byte(sp.EntityID))
+	buf.WriteString(`"><md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + protocolNamespace + `">`)
+	buf.WriteString(`<md:NameIDFormat>urn:oasis:names:tc:SAML:2.0:nameid-format:persistent</md:NameIDFormat>`)
+	buf.WriteString(`<md:AssertionConsumerService Binding="` + postBinding + `" Location="`)
+	xml.EscapeText(&buf, []byte(sp.ACSURL))
+	buf.WriteString(`" index="0" isDefault="true"/></md:SPSSODescriptor></md:EntityDescriptor>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,50 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AuthnRequestURL returns the IdP URL that starts a sign-in with the
+// HTTP-Redirect binding. id must be an XML NCName, such as a random hex string
+// with a letter prefix; the response quotes it in InResponseTo. relayState
+// comes back with the response unchanged.
+func (sp ServiceProvider) AuthnRequestURL(idp IdentityProvider, id, relayState string, now time.Time) (string, error) {
+	var doc bytes.Buffer
+	doc.WriteString(`<samlp:AuthnRequest xmlns:samlp="` + protocolNamespace + `" xmlns:saml="` + assertionNamespace + `" ID="`)
+	xml.EscapeText(&doc, []byte(id))
+	doc.WriteString(`" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `" Destination="`)
+	xml.EscapeText(&doc, []byte(idp.SSOURL))
+	doc.WriteString(`" AssertionConsumerServiceURL="`)
+	xml.EscapeText(&doc, []byte(sp.ACSURL))
+	doc.WriteString(`" ProtocolBinding="` + postBinding + `"><saml:Issuer>`)
+	xml.EscapeText(&doc, []byte(sp.EntityID))
+	doc.WriteString(`</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"/></samlp:AuthnRequest>`)
+
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(doc.Bytes()); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"SAMLRequest": {base64.StdEncoding.EncodeToString(compressed.Bytes())},
+		"RelayState":  {relayState},
+	}
+	separator := "?"
+	if strings.Contains(idp.SSOURL, "?") {
+		separator = "&"
+	}
+	return idp.SSOURL + separator + query.Encode(), nil
+}
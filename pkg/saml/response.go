@@ -0,0 +1,245 @@
+package saml
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	statusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	bearerMethod    = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	transientNameID = "urn:oasis:names:tc:SAML:2.0:nameid-format:transient"
+
+	// clockSkew tolerates clocks of the IdP and this server drifting apart.
+	clockSkew = 3 * time.Minute
+)
+
+// Assertion is what a verified response says about the user.
+type Assertion struct {
+	NameID       string
+	NameIDFormat string
+	// Attributes maps attribute names to their values.
+	Attributes map[string][]string
+}
+
+// Attribute returns the first non-empty value of the named attribute.
+func (a Assertion) Attribute(name string) string {
+	for _, value := range a.Attributes[name] {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ParseResponse verifies a response posted to the assertion consumer service
+// and returns its assertion. encoded is the SAMLResponse form value and
+// requestID the ID of the AuthnRequest the response must answer, so
+// unsolicited and replayed responses are refused.
+//
+// Either the response or its assertion must be signed by one of the IdP's
+// certificates. Only values of the verified elements are read.
+func (sp ServiceProvider) ParseResponse(encoded string, idp IdentityProvider, requestID string, now time.Time) (*Assertion, error) {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: response is not base64", ErrMalformed)
+	}
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if !root.is(protocolNamespace, "Response") {
+		return nil, fmt.Errorf("%w: not a SAML response", ErrInvalidResponse)
+	}
+
+	certificates, err := idp.certificates()
+	if err != nil {
+		return nil, err
+	}
+
+	if destination := root.attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("%w: response is addressed to %s", ErrInvalidResponse, destination)
+	}
+	if root.attr("InResponseTo") != requestID {
+		return nil, fmt.Errorf("%w: response does not answer the sign-in request", ErrInvalidResponse)
+	}
+	if err := checkIssuer(root, idp); err != nil {
+		return nil, err
+	}
+	if err := checkStatus(root); err != nil {
+		return nil, err
+	}
+
+	if root.child(assertionNamespace, "EncryptedAssertion") != nil {
+		return nil, ErrEncryptedAssertion
+	}
+	assertions := root.elements(assertionNamespace, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: expected one assertion, found %d", ErrInvalidResponse, len(assertions))
+	}
+	assertion := assertions[0]
+
+	responseSigned, assertionSigned := signed(root), signed(assertion)
+	if !responseSigned && !assertionSigned {
+		return nil, ErrUnsigned
+	}
+	if responseSigned {
+		if err := verify(root, root, certificates); err != nil {
+			return nil, err
+		}
+	}
+	if assertionSigned {
+		if err := verify(assertion, root, certificates); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkIssuer(assertion, idp); err != nil {
+		return nil, err
+	}
+	if err := sp.checkConditions(assertion, now); err != nil {
+		return nil, err
+	}
+
+	subject := assertion.child(assertionNamespace, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("%w: assertion without subject", ErrInvalidResponse)
+	}
+	if err := sp.checkConfirmation(subject, requestID, now); err != nil {
+		return nil, err
+	}
+	nameID := subject.child(assertionNamespace, "NameID")
+	if nameID == nil || strings.TrimSpace(nameID.text()) == "" {
+		return nil, fmt.Errorf("%w: assertion without NameID", ErrInvalidResponse)
+	}
+
+	result := &Assertion{
+		NameID:       strings.TrimSpace(nameID.text()),
+		NameIDFormat: nameID.attr("Format"),
+		Attributes:   map[string][]string{},
+	}
+	if result.NameIDFormat == transientNameID {
+		return nil, ErrTransientNameID
+	}
+	for _, statement := range assertion.elements(assertionNamespace, "AttributeStatement") {
+		for _, attribute := range statement.elements(assertionNamespace, "Attribute") {
+			name := attribute.attr("Name")
+			for _, value := range attribute.elements(assertionNamespace, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], value.text())
+			}
+		}
+	}
+	return result, nil
+}
+
+// checkIssuer requires an element's Issuer, if any, to be the IdP.
+func checkIssuer(el *element, idp IdentityProvider) error {
+	issuer := el.child(assertionNamespace, "Issuer")
+	if issuer == nil {
+		if el.local == "Assertion" {
+			return fmt.Errorf("%w: assertion without issuer", ErrInvalidResponse)
+		}
+		return nil
+	}
+	if strings.TrimSpace(issuer.text()) != idp.EntityID {
+		return fmt.Errorf("%w: issued by %s", ErrInvalidResponse, strings.TrimSpace(issuer.text()))
+	}
+	return nil
+}
+
+func checkStatus(root *element) error {
+	status := root.child(protocolNamespace, "Status")
+	if status == nil {
+		return fmt.Errorf("%w: response without status", ErrInvalidResponse)
+	}
+	code := status.child(protocolNamespace, "StatusCode")
+	if code == nil {
+		return fmt.Errorf("%w: response without status code", ErrInvalidResponse)
+	}
+	if value := code.attr("Value"); value != statusSuccess {
+		if nested := code.child(protocolNamespace, "StatusCode"); nested != nil {
+			value += " " + nested.attr("Value")
+		}
+		return fmt.Errorf("%w: %s", ErrStatus, value)
+	}
+	return nil
+}
+
+// checkConditions enforces the assertion's validity window and audiences. An
+// assertion must be restricted to this service provider, so one issued for
+// another SP cannot be replayed here.
+func (sp ServiceProvider) checkConditions(assertion *element, now time.Time) error {
+	conditions := assertion.child(assertionNamespace, "Conditions")
+	if conditions == nil {
+		return fmt.Errorf("%w: assertion without conditions", ErrInvalidResponse)
+	}
+	if err := checkWindow(conditions, now); err != nil {
+		return err
+	}
+	restrictions := conditions.elements(assertionNamespace, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return ErrAudience
+	}
+	for _, restriction := range restrictions {
+		allowed := false
+		for _, audience := range restriction.elements(assertionNamespace, "Audience") {
+			if strings.TrimSpace(audience.text()) == sp.EntityID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrAudience
+		}
+	}
+	return nil
+}
+
+// checkConfirmation requires a bearer confirmation of the subject for this
+// service provider and sign-in request that has not expired. Unsolicited
+// responses are not supported, so the confirmation must name the request.
+func (sp ServiceProvider) checkConfirmation(subject *element, requestID string, now time.Time) error {
+	for _, confirmation := range subject.elements(assertionNamespace, "SubjectConfirmation") {
+		if confirmation.attr("Method") != bearerMethod {
+			continue
+		}
+		data := confirmation.child(assertionNamespace, "SubjectConfirmationData")
+		if data == nil || data.attr("NotOnOrAfter") == "" {
+			continue
+		}
+		if data.attr("Recipient") != sp.ACSURL {
+			continue
+		}
+		if data.attr("InResponseTo") != requestID {
+			continue
+		}
+		if checkWindow(data, now) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no valid bearer confirmation", ErrInvalidResponse)
+}
+
+// checkWindow enforces an element's NotBefore and NotOnOrAfter attributes.
+func checkWindow(el *element, now time.Time) error {
+	if value := el.attr("NotBefore"); value != "" {
+		notBefore, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid NotBefore", ErrInvalidResponse)
+		}
+		if now.Add(clockSkew).Before(notBefore) {
+			return ErrExpired
+		}
+	}
+	if value := el.attr("NotOnOrAfter"); value != "" {
+		notOnOrAfter, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid NotOnOrAfter", ErrInvalidResponse)
+		}
+		if !now.Add(-clockSkew).Before(notOnOrAfter) {
+			return ErrExpired
+		}
+	}
+	return nil
+}
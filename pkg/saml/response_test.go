@@ -0,0 +1,376 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testRequestID = "id4f1c2a9e"
+	testIssuer    = "https://sts.windows.net/8d2e0c6b/"
+	testNameID    = "jane@example.com"
+)
+
+var (
+	testNow = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	testSP  = ServiceProvider{EntityID: "https://lms.example.com/saml", ACSURL: "https://lms.example.com/saml/acs"}
+)
+
+// azureResponse is shaped like a Microsoft Entra ID response: default
+// namespaces on the assertion and its signature.
+const azureResponse = `<samlp:Response ID="_r1" Version="2.0" IssueInstant="2026-03-01T09:59:58Z" Destination="{acs}" InResponseTo="{request}" xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol">` +
+	`<Issuer xmlns="urn:oasis:names:tc:SAML:2.0:assertion">{issuer}</Issuer>{responseSignature}` +
+	`<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>` +
+	`<Assertion ID="_a1" IssueInstant="2026-03-01T09:59:58Z" Version="2.0" xmlns="urn:oasis:names:tc:SAML:2.0:assertion">` +
+	`<Issuer>{issuer}</Issuer>{assertionSignature}` +
+	`<Subject><NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">{nameID}</NameID>` +
+	`<SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">` +
+	`<SubjectConfirmationData InResponseTo="{request}" NotOnOrAfter="2026-03-01T10:04:58Z" Recipient="{acs}"/>` +
+	`</SubjectConfirmation></Subject>` +
+	`<Conditions NotBefore="2026-03-01T09:54:58Z" NotOnOrAfter="{expiry}"><AudienceRestriction><Audience>{audience}</Audience></AudienceRestriction></Conditions>` +
+	`<AttributeStatement><Attribute Name="http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname"><AttributeValue>Jane</AttributeValue></Attribute></AttributeStatement>` +
+	`<AuthnStatement AuthnInstant="2026-03-01T09:59:50Z" SessionIndex="_a1"><AuthnContext><AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:Password</AuthnContextClassRef></AuthnContext></AuthnStatement>` +
+	`</Assertion></samlp:Response>`
+
+// adfsResponse is shaped like an AD FS response: prefixed namespaces, with
+// the ds prefix on the signatures.
+const adfsResponse = `<samlp:Response ID="_r1" Version="2.0" IssueInstant="2026-03-01T09:59:58.123Z" Destination="{acs}" Consent="urn:oasis:names:tc:SAML:2.0:consent:unspecified" InResponseTo="{request}" xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol">` +
+	"\n  " + `<Issuer xmlns="urn:oasis:names:tc:SAML:2.0:assertion">{issuer}</Issuer>{responseSignature}` +
+	"\n  " + `<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success" /></samlp:Status>` +
+	"\n  " + `<saml:Assertion ID="_a1" IssueInstant="2026-03-01T09:59:58.123Z" Version="2.0" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">` +
+	"\n    " + `<saml:Issuer>{issuer}</saml:Issuer>{assertionSignature}` +
+	"\n    " + `<saml:Subject><saml:NameID Format="urn:oasis:names:tc:SAML:2.0:nameid-format:persistent">{nameID}</saml:NameID>` +
+	`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">` +
+	`<saml:SubjectConfirmationData InResponseTo="{request}" NotOnOrAfter="2026-03-01T10:04:58.123Z" Recipient="{acs}" />` +
+	`</saml:SubjectConfirmation></saml:Subject>` +
+	"\n    " + `<saml:Conditions NotBefore="2026-03-01T09:59:58.123Z" NotOnOrAfter="{expiry}"><saml:AudienceRestriction><saml:Audience>{audience}</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+	"\n    " + `<saml:AttributeStatement><saml:Attribute Name="http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname"><saml:AttributeValue>Jane</saml:AttributeValue></saml:Attribute></saml:AttributeStatement>` +
+	"\n  " + `</saml:Assertion>` +
+	"\n" + `</samlp:Response>`
+
+// signatureTemplate returns an enveloped signature of the element with the ID
+// whose digest and value still have to be filled in by sign.
+func signatureTemplate(prefix, id, tag string) string {
+	name := func(local string) string {
+		if prefix == "" {
+			return local
+		}
+		return prefix + ":" + local
+	}
+	declaration := `xmlns="` + dsigNamespace + `"`
+	if prefix != "" {
+		declaration = `xmlns:` + prefix + `="` + dsigNamespace + `"`
+	}
+	return `<` + name("Signature") + ` ` + declaration + `>` +
+		`<` + name("SignedInfo") + `>` +
+		`<` + name("CanonicalizationMethod") + ` Algorithm="` + excC14N + `"/>` +
+		`<` + name("SignatureMethod") + ` Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"/>` +
+		`<` + name("Reference") + ` URI="#` + id + `"><` + name("Transforms") + `>` +
+		`<` + name("Transform") + ` Algorithm="` + envelopedSignature + `"/>` +
+		`<` + name("Transform") + ` Algorithm="` + excC14N + `"/>` +
+		`</` + name("Transforms") + `>` +
+		`<` + name("DigestMethod") + ` Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/>` +
+		`<` + name("DigestValue") + `>digest` + tag + `</` + name("DigestValue") + `>` +
+		`</` + name("Reference") + `></` + name("SignedInfo") + `>` +
+		`<` + name("SignatureValue") + `>value` + tag + `</` + name("SignatureValue") + `>` +
+		`</` + name("Signature") + `>`
+}
+
+// sign fills in the signature of the element find returns.
+func sign(t *testing.T, doc, tag string, key *rsa.PrivateKey, find func(root *element) *element) string {
+	t.Helper()
+
+	root, err := parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("parse unsigned document: %v", err)
+	}
+	el := find(root)
+	content, err := canonicalize(el, el.child(dsigNamespace, "Signature"), nil)
+	if err != nil {
+		t.Fatalf("canonicalize %s: %v", el.local, err)
+	}
+	digest := sha256.Sum256(content)
+	doc = strings.Replace(doc, "digest"+tag, base64.StdEncoding.EncodeToString(digest[:]), 1)
+
+	root, err = parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("parse digested document: %v", err)
+	}
+	signedInfo := find(root).child(dsigNamespace, "Signature").child(dsigNamespace, "SignedInfo")
+	info, err := canonicalize(signedInfo, nil, nil)
+	if err != nil {
+		t.Fatalf("canonicalize SignedInfo: %v", err)
+	}
+	sum := sha256.Sum256(info)
+	value, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return strings.Replace(doc, "value"+tag, base64.StdEncoding.EncodeToString(value), 1)
+}
+
+// newSigner returns a key and its self-signed certificate, base64 DER encoded
+// as in IdP metadata.
+func newSigner(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test IdP Signing"},
+		NotBefore:    testNow.AddDate(-1, 0, 0),
+		NotAfter:     testNow.AddDate(1, 0, 0),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+func TestParseResponse(t *testing.T) {
+	key, certificate := newSigner(t)
+	otherKey, _ := newSigner(t)
+	idp := IdentityProvider{EntityID: testIssuer, SSOURL: "https://login.example.com/saml2", Certificates: []string{certificate}}
+
+	tests := []struct {
+		name            string
+		template        string
+		signaturePrefix string
+		signResponse    bool
+		signAssertion   bool
+		key             *rsa.PrivateKey
+		// before edits the document before it is signed, after once it is.
+		before   func(doc string) string
+		after    func(doc string) string
+		wantErr  error
+		wantName string
+	}{
+		{name: "azure ad, assertion signed", template: azureResponse, signAssertion: true},
+		{name: "azure ad, response signed", template: azureResponse, signResponse: true},
+		{name: "adfs, assertion signed", template: adfsResponse, signaturePrefix: "ds", signAssertion: true},
+		{name: "adfs, response and assertion signed", template: adfsResponse, signaturePrefix: "ds", signResponse: true, signAssertion: true},
+		{name: "unsigned", template: azureResponse, wantErr: ErrUnsigned},
+		{
+			name:          "signed by another key",
+			template:      azureResponse,
+			signAssertion: true,
+			key:           otherKey,
+			wantErr:       ErrInvalidSignature,
+		},
+		{
+			name:          "assertion changed after signing",
+			template:      azureResponse,
+			signAssertion: true,
+			after: func(doc string) string {
+				return strings.Replace(doc, testNameID, "admin@example.com", 1)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:         "assertion changed under a response signature",
+			template:     adfsResponse,
+			signResponse: true,
+			after: func(doc string) string {
+				return strings.Replace(doc, testNameID, "admin@example.com", 1)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			// The signed assertion moves into Extensions and a forged one
+			// with the same ID and signature takes its place.
+			name:          "signature wrapping with a duplicate ID",
+			template:      azureResponse,
+			signAssertion: true,
+			after: func(doc string) string {
+				signed := doc[strings.Index(doc, "<Assertion "):strings.Index(doc, "</samlp:Response>")]
+				forged := strings.Replace(signed, testNameID, "admin@example.com", 1)
+				doc = strings.Replace(doc, signed, forged, 1)
+				return strings.Replace(doc, "<samlp:Status>", "<samlp:Extensions>"+signed+"</samlp:Extensions><samlp:Status>", 1)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			// A forged assertion carries the original signature, with the
+			// signed assertion hidden inside it.
+			name:          "signature wrapping inside the signature",
+			template:      azureResponse,
+			signAssertion: true,
+			after: func(doc string) string {
+				signed := doc[strings.Index(doc, "<Assertion "):strings.Index(doc, "</samlp:Response>")]
+				forged := strings.Replace(signed, `ID="_a1"`, `ID="_forged"`, 1)
+				forged = strings.Replace(forged, testNameID, "admin@example.com", 1)
+				forged = strings.Replace(forged, "</Signature>", "<Object>"+signed+"</Object></Signature>", 1)
+				return strings.Replace(doc, signed, forged, 1)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			// Canonicalization drops comments, so the signature still holds;
+			// the NameID must be read whole, not up to the comment.
+			name:          "comment injected into the NameID",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				return strings.Replace(doc, "{nameID}", "jane@example.com.evil.test", 1)
+			},
+			after: func(doc string) string {
+				return strings.Replace(doc, ">jane@example.com.evil.test<", ">jane@example.com<!---->.evil.test<", 1)
+			},
+			wantName: "jane@example.com.evil.test",
+		},
+		{
+			name:          "doctype",
+			template:      azureResponse,
+			signAssertion: true,
+			after: func(doc string) string {
+				return `<!DOCTYPE samlp:Response [<!ENTITY name "admin@example.com">]>` + doc
+			},
+			wantErr: ErrMalformed,
+		},
+		{
+			name:          "expired assertion",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				return strings.Replace(doc, "{expiry}", "2026-03-01T09:50:00Z", 1)
+			},
+			wantErr: ErrExpired,
+		},
+		{
+			name:            "wrong audience",
+			template:        adfsResponse,
+			signaturePrefix: "ds",
+			signAssertion:   true,
+			before: func(doc string) string {
+				return strings.Replace(doc, "{audience}", "https://other.example.com/saml", 1)
+			},
+			wantErr: ErrAudience,
+		},
+		{
+			name:          "no audience restriction",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				return strings.Replace(doc, "<AudienceRestriction><Audience>{audience}</Audience></AudienceRestriction>", "", 1)
+			},
+			wantErr: ErrAudience,
+		},
+		{
+			name:          "no conditions",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				start, end := strings.Index(doc, "<Conditions "), strings.Index(doc, "</Conditions>")
+				return doc[:start] + doc[end+len("</Conditions>"):]
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name:          "wrong recipient",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				return strings.Replace(doc, `Recipient="{acs}"`, `Recipient="https://other.example.com/saml/acs"`, 1)
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name:          "confirmation without InResponseTo",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				return strings.Replace(doc, `<SubjectConfirmationData InResponseTo="{request}" `, `<SubjectConfirmationData `, 1)
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name:          "confirmation for another request",
+			template:      azureResponse,
+			signAssertion: true,
+			before: func(doc string) string {
+				return strings.Replace(doc, `<SubjectConfirmationData InResponseTo="{request}" `, `<SubjectConfirmationData InResponseTo="id0ther" `, 1)
+			},
+			wantErr: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := tt.template
+			if tt.before != nil {
+				doc = tt.before(doc)
+			}
+			responseSignature, assertionSignature := "", ""
+			if tt.signResponse {
+				responseSignature = signatureTemplate(tt.signaturePrefix, "_r1", "R")
+			}
+			if tt.signAssertion {
+				assertionSignature = signatureTemplate(tt.signaturePrefix, "_a1", "A")
+			}
+			doc = strings.NewReplacer(
+				"{acs}", testSP.ACSURL,
+				"{request}", testRequestID,
+				"{issuer}", testIssuer,
+				"{nameID}", testNameID,
+				"{expiry}", "2026-03-01T11:00:00Z",
+				"{audience}", testSP.EntityID,
+				"{responseSignature}", responseSignature,
+				"{assertionSignature}", assertionSignature,
+			).Replace(doc)
+
+			signer := key
+			if tt.key != nil {
+				signer = tt.key
+			}
+			// The assertion is signed first; a response signature covers it.
+			if tt.signAssertion {
+				doc = sign(t, doc, "A", signer, func(root *element) *element {
+					return root.child(assertionNamespace, "Assertion")
+				})
+			}
+			if tt.signResponse {
+				doc = sign(t, doc, "R", signer, func(root *element) *element { return root })
+			}
+			if tt.after != nil {
+				doc = tt.after(doc)
+			}
+
+			encoded := base64.StdEncoding.EncodeToString([]byte(doc))
+			assertion, err := testSP.ParseResponse(encoded, idp, testRequestID, testNow)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseResponse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseResponse() error = %v", err)
+			}
+
+			wantName := tt.wantName
+			if wantName == "" {
+				wantName = testNameID
+			}
+			if assertion.NameID != wantName {
+				t.Errorf("NameID = %q, want %q", assertion.NameID, wantName)
+			}
+			if got := assertion.Attribute("http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname"); got != "Jane" {
+				t.Errorf("givenname = %q, want %q", got, "Jane")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	// Registers the hashes of the supported algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	dsigNamespace      = "http://www.w3.org/2000/09/xmldsig#"
+	excC14N            = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	envelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+// Only SHA-2 algorithms are accepted; SHA-1 signatures can be forged.
+var (
+	signatureHashes = map[string]crypto.Hash{
+		"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": crypto.SHA256,
+		"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": crypto.SHA512,
+	}
+	digestHashes = map[string]crypto.Hash{
+		"http://www.w3.org/2001/04/xmlenc#sha256": crypto.SHA256,
+		"http://www.w3.org/2001/04/xmlenc#sha512": crypto.SHA512,
+	}
+)
+
+// signed reports whether el carries an enveloped signature.
+func signed(el *element) bool {
+	return el.child(dsigNamespace, "Signature") != nil
+}
+
+// verify checks the enveloped signature of el against the certificates. The
+// signature must reference el itself, by an ID no other element of the
+// document shares, so a signed element cannot be swapped for another.
+func verify(el *element, root *element, certificates []*x509.Certificate) error {
+	signatures := el.elements(dsigNamespace, "Signature")
+	if len(signatures) != 1 {
+		return fmt.Errorf("%w: expected one signature, found %d", ErrInvalidSignature, len(signatures))
+	}
+	signature := signatures[0]
+
+	id := el.attr("ID")
+	if id == "" || root.countIDs(id) != 1 {
+		return fmt.Errorf("%w: signed element needs a unique ID", ErrInvalidSignature)
+	}
+
+	signedInfo := signature.child(dsigNamespace, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("%w: missing SignedInfo", ErrInvalidSignature)
+	}
+
+	method := signedInfo.child(dsigNamespace, "CanonicalizationMethod")
+	if method == nil || method.attr("Algorithm") != excC14N {
+		return fmt.Errorf("%w: unsupported canonicalization", ErrUnsupportedAlgorithm)
+	}
+
+	signatureMethod := signedInfo.child(dsigNamespace, "SignatureMethod")
+	if signatureMethod == nil {
+		return fmt.Errorf("%w: missing SignatureMethod", ErrInvalidSignature)
+	}
+	signatureHash, ok := signatureHashes[signatureMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: signature method %s", ErrUnsupportedAlgorithm, signatureMethod.attr("Algorithm"))
+	}
+
+	references := signedInfo.elements(dsigNamespace, "Reference")
+	if len(references) != 1 {
+		return fmt.Errorf("%w: expected one reference, found %d", ErrInvalidSignature, len(references))
+	}
+	reference := references[0]
+	if reference.attr("URI") != "#"+id {
+		return fmt.Errorf("%w: reference does not point at the signed element", ErrInvalidSignature)
+	}
+
+	var inclusive []string
+	if transforms := reference.child(dsigNamespace, "Transforms"); transforms != nil {
+		for _, transform := range transforms.elements(dsigNamespace, "Transform") {
+			switch transform.attr("Algorithm") {
+			case envelopedSignature:
+			case excC14N:
+				inclusive = prefixList(transform)
+			default:
+				return fmt.Errorf("%w: transform %s", ErrUnsupportedAlgorithm, transform.attr("Algorithm"))
+			}
+		}
+	}
+
+	digestMethod := reference.child(dsigNamespace, "DigestMethod")
+	if digestMethod == nil {
+		return fmt.Errorf("%w: missing DigestMethod", ErrInvalidSignature)
+	}
+	digestHash, ok := digestHashes[digestMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: digest method %s", ErrUnsupportedAlgorithm, digestMethod.attr("Algorithm"))
+	}
+	digestValue := reference.child(dsigNamespace, "DigestValue")
+	if digestValue == nil {
+		return fmt.Errorf("%w: missing DigestValue", ErrInvalidSignature)
+	}
+	expected, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return fmt.Errorf("%w: digest is not base64", ErrInvalidSignature)
+	}
+
+	content, err := canonicalize(el, signature, inclusive)
+	if err != nil {
+		return err
+	}
+	digest := digestHash.New()
+	digest.Write(content)
+	if !hmac.Equal(digest.Sum(nil), expected) {
+		return fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	signatureValue := signature.child(dsigNamespace, "SignatureValue")
+	if signatureValue == nil {
+		return fmt.Errorf("%w: missing SignatureValue", ErrInvalidSignature)
+	}
+	value, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+
+	canonicalInfo, err := canonicalize(signedInfo, nil, prefixList(method))
+	if err != nil {
+		return err
+	}
+	hash := signatureHash.New()
+	hash.Write(canonicalInfo)
+	sum := hash.Sum(nil)
+
+	for _, certificate := range certificates {
+		key, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(key, signatureHash, sum, value) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no certificate of the identity provider matches", ErrInvalidSignature)
+}
+
+// prefixList returns the InclusiveNamespaces PrefixList of a canonicalization
+// method or transform.
+func prefixList(el *element) []string {
+	inclusive := el.child(excC14N, "InclusiveNamespaces")
+	if inclusive == nil {
+		return nil
+	}
+	return strings.Fields(inclusive.attr("PrefixList"))
+}
+
+// decodeBase64 decodes base64 that may be wrapped over several lines.
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
@@ -0,0 +1,207 @@
+package socketio
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	socket "github.com/zishang520/socket.io/socket"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/featureflag"
+	"github.com/mo-amir99/lms-server-go/internal/features/readreceipt"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+)
+
+// EmitToThread broadcasts an event to every socket viewing a forum thread.
+func (s *Server) EmitToThread(threadID uuid.UUID, event string, payload any) {
+	if err := s.io.To(threadRoom(threadID.String())).Emit(event, payload); err != nil {
+		s.logger.Warn("failed to emit thread event",
+			slog.String("event", event),
+			slog.String("threadId", threadID.String()),
+			slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) handleJoinThread(sock *socket.Socket, rawThreadID string) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
+	threadID, err := uuid.Parse(strings.TrimSpace(rawThreadID))
+	if err != nil {
+		s.emitError(sock, "INVALID_INPUT", "invalid thread ID")
+		return
+	}
+
+	allowed, err := s.canAccessThread(userData, threadID)
+	if err != nil {
+		s.logger.Error("failed to check thread access", slog.String("threadId", threadID.String()), slog.String("error", err.Error()))
+		s.emitError(sock, "INTERNAL_ERROR", "failed to join thread")
+		return
+	}
+	if !allowed {
+		s.emitError(sock, "THREAD_NOT_FOUND", "thread not found")
+		return
+	}
+
+	sock.Join(threadRoom(threadID.String()))
+
+	if err := sock.Emit("threadJoined", map[string]any{
+		"threadId":  threadID.String(),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Warn("failed to emit threadJoined", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) handleLeaveThread(sock *socket.Socket, rawThreadID string) {
+	threadID, err := uuid.Parse(strings.TrimSpace(rawThreadID))
+	if err != nil {
+		s.emitError(sock, "INVALID_INPUT", "invalid thread ID")
+		return
+	}
+	sock.Leave(threadRoom(threadID.String()))
+}
+
+// handleTyping relays that the user started or stopped typing to the other
+// sockets in the chat. Clients should treat an indicator as stale after a few
+// seconds without a refresh.
+func (s *Server) handleTyping(sock *socket.Socket, e typingEvent) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
+	room := chatRoom(e.Channel, e.ID)
+	if !sock.Rooms().Has(room) {
+		s.emitError(sock, "NOT_IN_CHAT", "join the chat first")
+		return
+	}
+
+	if err := sock.To(room).Emit("typing", map[string]any{
+		"channel":   e.Channel,
+		"id":        e.ID,
+		"userId":    userData.ID.String(),
+		"userName":  userData.FullName,
+		"typing":    *e.Typing,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Warn("failed to broadcast typing", slog.String("error", err.Error()))
+	}
+}
+
+// handleMarkRead stores how far the user read the chat and tells its members.
+func (s *Server) handleMarkRead(sock *socket.Socket, e markReadEvent) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
+	room := chatRoom(e.Channel, e.ID)
+	if !sock.Rooms().Has(room) {
+		s.emitError(sock, "NOT_IN_CHAT", "join the chat first")
+		return
+	}
+
+	readThrough, ok := s.messageTime(e)
+	if !ok {
+		s.emitError(sock, "MESSAGE_NOT_FOUND", "message not found")
+		return
+	}
+
+	receipt, err := readreceipt.Mark(s.db, userData.ID, e.Channel, e.ID, e.MessageID, readThrough)
+	if err != nil {
+		s.logger.Error("failed to store read receipt", slog.String("channel", e.Channel), slog.String("id", e.ID), slog.String("error", err.Error()))
+		s.emitError(sock, "INTERNAL_ERROR", "failed to mark read")
+		return
+	}
+
+	if err := s.io.To(room).Emit(readreceipt.EventRead, readreceipt.Reader{Receipt: receipt, UserName: userData.FullName}); err != nil {
+		s.logger.Warn("failed to broadcast read receipt", slog.String("error", err.Error()))
+	}
+}
+
+// messageTime returns when a chat message was posted. Stream chat messages are
+// not stored and carry their send time in nanoseconds as ID; thread messages
+// are replies, or the thread itself for its opening post.
+func (s *Server) messageTime(e markReadEvent) (time.Time, bool) {
+	if e.Channel == readreceipt.ChannelStream {
+		nanos, err := strconv.ParseInt(e.MessageID, 10, 64)
+		if err != nil || nanos <= 0 {
+			return time.Time{}, false
+		}
+		return time.Unix(0, nanos), true
+	}
+
+	var postedAt []time.Time
+	query := s.db.Table("threads").Where("id = ?", e.ID)
+	if e.MessageID == e.ID {
+		query = query.Select("created_at")
+	} else {
+		query = query.Select("(reply->>'createdAt')::timestamptz").
+			Joins("CROSS JOIN LATERAL jsonb_array_elements(threads.replies) AS reply").
+			Where("reply->>'id' = ?", e.MessageID)
+	}
+	if err := query.Limit(1).Scan(&postedAt).Error; err != nil {
+		s.logger.Warn("failed to load thread message", slog.String("threadId", e.ID), slog.String("error", err.Error()))
+		return time.Time{}, false
+	}
+	if len(postedAt) == 0 {
+		return time.Time{}, false
+	}
+	return postedAt[0], true
+}
+
+// canAccessThread mirrors the forum rules: members of the forum's subscription
+// with forums enabled, and platform admins. Unapproved threads are staff only.
+func (s *Server) canAccessThread(userData *user.User, threadID uuid.UUID) (bool, error) {
+	var found []struct {
+		SubscriptionID uuid.UUID
+		Active         bool
+		Approved       bool
+	}
+	if err := s.db.Table("threads").
+		Select("forums.subscription_id, forums.active, threads.approved").
+		Joins("JOIN forums ON forums.id = threads.forum_id").
+		Where("threads.id = ?", threadID).
+		Limit(1).
+		Scan(&found).Error; err != nil {
+		return false, err
+	}
+	if len(found) == 0 {
+		return false, nil
+	}
+	thread := found[0]
+
+	if authz.IsPlatformAdmin(userData.UserType) {
+		return true, nil
+	}
+	if userData.SubscriptionID == nil || *userData.SubscriptionID != thread.SubscriptionID {
+		return false, nil
+	}
+	if s.featureFlags != nil && !s.featureFlags.Enabled(thread.SubscriptionID, featureflag.Forums) {
+		return false, nil
+	}
+	if !authz.IsSubscriptionStaff(userData.UserType) && (!thread.Active || !thread.Approved) {
+		return false, nil
+	}
+	return true, nil
+}
+
+func chatRoom(channel, id string) socket.Room {
+	if channel == readreceipt.ChannelThread {
+		return threadRoom(id)
+	}
+	return streamRoom(id)
+}
+
+func threadRoom(threadID string) socket.Room {
+	return socket.Room("thread_" + threadID)
+}
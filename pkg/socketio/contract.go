@@ -0,0 +1,188 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	socket "github.com/zishang520/socket.io/socket"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Error codes returned when a client event does not match its contract.
+const (
+	codeInvalidPayload = "INVALID_PAYLOAD"
+	codeInvalidInput   = "INVALID_INPUT"
+	codeInternalError  = "INTERNAL_ERROR"
+)
+
+// FieldError describes why one field of an event payload was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// contract is implemented by every typed client event payload. validate normalises
+// the payload in place and records rule violations.
+type contract interface {
+	validate(v *validator)
+}
+
+// bareIDContract is implemented by payloads that may also be sent as a bare ID string.
+type bareIDContract interface {
+	contract
+	setID(id string)
+}
+
+// on registers handle for event. The first argument is decoded into P and validated
+// before handle runs; malformed payloads are answered with a structured error event
+// and never reach the handler.
+func on[T any, P interface {
+	*T
+	contract
+}](s *Server, sock *socket.Socket, event string, handle func(*socket.Socket, T)) {
+	sock.On(event, func(args ...any) {
+		span := s.startEventSpan(sock, event)
+		defer span.End()
+		defer s.recoverEvent(sock, event, span)
+
+		var payload T
+		if fields, err := decodeEvent(args, P(&payload)); err != nil {
+			span.SetStatus(codes.Error, codeInvalidPayload)
+			s.emitEventError(sock, event, codeInvalidPayload, err.Error(), fields)
+			return
+		}
+
+		var v validator
+		P(&payload).validate(&v)
+		if len(v.errs) > 0 {
+			span.SetStatus(codes.Error, codeInvalidInput)
+			s.emitEventError(sock, event, codeInvalidInput, v.errs[0].Field+" "+v.errs[0].Message, v.errs)
+			return
+		}
+
+		handle(sock, payload)
+	})
+}
+
+func decodeEvent(args []any, dst contract) ([]FieldError, error) {
+	if len(args) == 0 || args[0] == nil {
+		return nil, errors.New("payload is required")
+	}
+
+	if id, ok := args[0].(string); ok {
+		bare, accepts := dst.(bareIDContract)
+		if !accepts {
+			return nil, errors.New("payload must be an object")
+		}
+		bare.setID(id)
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, errors.New("payload is not valid JSON")
+	}
+	if len(raw) == 0 || raw[0] != '{' {
+		return nil, errors.New("payload must be an object")
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			message := "must be " + jsonTypeName(typeErr.Type)
+			return []FieldError{{Field: typeErr.Field, Message: message}}, fmt.Errorf("%s %s", typeErr.Field, message)
+		}
+		return nil, errors.New("payload is malformed")
+	}
+	return nil, nil
+}
+
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+func (s *Server) emitEventError(sock *socket.Socket, event, code, message string, fields []FieldError) {
+	payload := map[string]any{
+		"code":    code,
+		"message": message,
+		"event":   event,
+	}
+	if len(fields) > 0 {
+		payload["fields"] = fields
+	}
+	if err := sock.Emit("error", payload); err != nil {
+		s.logger.Debug("failed to emit error", slog.String("error", err.Error()))
+	}
+}
+
+// recoverEvent keeps a panicking handler from taking the connection down.
+func (s *Server) recoverEvent(sock *socket.Socket, event string, span trace.Span) {
+	if r := recover(); r != nil {
+		span.SetStatus(codes.Error, fmt.Sprint(r))
+		s.logger.Error("socket event handler panicked",
+			slog.String("event", event),
+			slog.Any("panic", r))
+		s.emitEventError(sock, event, codeInternalError, "failed to handle event", nil)
+	}
+}
+
+// validator collects rule violations of one payload.
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) add(field, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: message})
+}
+
+func (v *validator) required(field string, value *string) bool {
+	*value = strings.TrimSpace(*value)
+	if *value == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) maxLen(field string, value *string, max int) {
+	*value = strings.TrimSpace(*value)
+	if utf8.RuneCountInString(*value) > max {
+		v.add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+func (v *validator) uuid(field, value string) {
+	if _, err := uuid.Parse(value); err != nil {
+		v.add(field, "must be a valid ID")
+	}
+}
+
+func (v *validator) between(field string, value, min, max int) {
+	if value < min || value > max {
+		v.add(field, fmt.Sprintf("must be between %d and %d", min, max))
+	}
+}
@@ -0,0 +1,85 @@
+package socketio
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+const (
+	// reconnectAfter and reconnectJitter tell clients to come back after a random
+	// delay in [after, after+jitter) so a restart is not met by every client at once.
+	reconnectAfter  = 2 * time.Second
+	reconnectJitter = 8 * time.Second
+	// drainFlush gives the shutdown notice time to reach clients before their
+	// connections are closed.
+	drainFlush = time.Second
+
+	codeServerShutdown = "SERVER_SHUTTING_DOWN"
+)
+
+// Drain prepares the server for shutdown: new connections are refused, every
+// client is sent serverShutdown with a reconnect hint, live streams are ended
+// and their stream-ended hooks awaited so recordings and viewer history are
+// persisted, and the remaining connections are closed. It returns early, with
+// hooks still running, once ctx is done.
+func (s *Server) Drain(ctx context.Context) {
+	if !s.draining.CompareAndSwap(false, true) {
+		return
+	}
+
+	s.logger.Info("draining socket connections", slog.Int("connections", s.ConnectionCount()))
+
+	payload := map[string]any{
+		"reason":            "shutdown",
+		"reconnectAfterMs":  reconnectAfter.Milliseconds(),
+		"reconnectJitterMs": reconnectJitter.Milliseconds(),
+		"timestamp":         time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.io.Local().Emit("serverShutdown", payload); err != nil {
+		s.logger.Warn("failed to broadcast serverShutdown", slog.String("error", err.Error()))
+	}
+
+	var hooks sync.WaitGroup
+	for _, stream := range s.streamCache.GetAllStreams() {
+		ended, err := s.streamCache.EndStream(stream.ID)
+		if err != nil {
+			continue
+		}
+		s.decrementStreamActivity(ended.HostID)
+		s.broadcastStreamEnded(ended.ID, "server-shutdown")
+
+		for _, hook := range s.streamEndedHooks {
+			hooks.Add(1)
+			go func(hook func(streamcache.Stream), stream streamcache.Stream) {
+				defer hooks.Done()
+				hook(stream)
+			}(hook, *ended)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		hooks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.logger.Warn("socket drain timed out waiting for stream-ended hooks")
+		return
+	}
+
+	// Let the notices flush before closing the transports
+	select {
+	case <-time.After(drainFlush):
+	case <-ctx.Done():
+	}
+
+	s.io.Local().DisconnectSockets(true)
+	s.logger.Info("socket connections drained")
+}
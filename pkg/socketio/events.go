@@ -0,0 +1,274 @@
+package socketio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/readreceipt"
+)
+
+// Typed payloads of the client events. Field names are the JSON keys clients send.
+
+const (
+	maxStreamIDLength      = 100
+	maxStreamTitleLength   = 255
+	maxStreamDescLength    = 1000
+	maxStreamGroups        = 50
+	maxStreamMessageSize   = 500
+	maxChatMuteMinutes     = 24 * 60
+	maxSlowModeSeconds     = 3600
+	defaultMuteMinutes     = 5
+	maxMeetingRoomIDLength = 100
+)
+
+func validateStreamID(v *validator, streamID *string) {
+	if v.required("streamId", streamID) {
+		v.maxLen("streamId", streamID, maxStreamIDLength)
+	}
+}
+
+// streamRef identifies a stream; sent as {"streamId"} or a bare stream ID.
+type streamRef struct {
+	StreamID string `json:"streamId"`
+}
+
+func (e *streamRef) setID(id string)       { e.StreamID = id }
+func (e *streamRef) validate(v *validator) { validateStreamID(v, &e.StreamID) }
+
+type startStreamEvent struct {
+	StreamID    string   `json:"streamId"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	IsPublic    *bool    `json:"isPublic"`
+	ChatEnabled *bool    `json:"chatEnabled"`
+	GroupAccess []string `json:"groupAccess"`
+}
+
+func (e *startStreamEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	if v.required("title", &e.Title) {
+		v.maxLen("title", &e.Title, maxStreamTitleLength)
+	}
+	v.maxLen("description", &e.Description, maxStreamDescLength)
+
+	if len(e.GroupAccess) > maxStreamGroups {
+		v.add("groupAccess", fmt.Sprintf("must contain at most %d groups", maxStreamGroups))
+		return
+	}
+	seen := make(map[string]struct{}, len(e.GroupAccess))
+	groups := make([]string, 0, len(e.GroupAccess))
+	for i, id := range e.GroupAccess {
+		id = strings.TrimSpace(id)
+		v.uuid(fmt.Sprintf("groupAccess[%d]", i), id)
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		groups = append(groups, id)
+	}
+	e.GroupAccess = groups
+}
+
+type updateStreamMediaEvent struct {
+	StreamID       string `json:"streamId"`
+	HasVideo       *bool  `json:"hasVideo"`
+	HasAudio       *bool  `json:"hasAudio"`
+	HasScreenShare *bool  `json:"hasScreenShare"`
+}
+
+func (e *updateStreamMediaEvent) validate(v *validator) { validateStreamID(v, &e.StreamID) }
+
+type streamMessageEvent struct {
+	StreamID string `json:"streamId"`
+	Message  string `json:"message"`
+}
+
+func (e *streamMessageEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	if v.required("message", &e.Message) {
+		v.maxLen("message", &e.Message, maxStreamMessageSize)
+	}
+}
+
+type streamSignalEvent struct {
+	StreamID     string `json:"streamId"`
+	Signal       any    `json:"signal"`
+	TargetUserID string `json:"targetUserId"`
+}
+
+func (e *streamSignalEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	if e.Signal == nil {
+		v.add("signal", "is required")
+	}
+	if e.TargetUserID = strings.TrimSpace(e.TargetUserID); e.TargetUserID != "" {
+		v.uuid("targetUserId", e.TargetUserID)
+	}
+}
+
+type deleteStreamMessageEvent struct {
+	StreamID  string `json:"streamId"`
+	MessageID string `json:"messageId"`
+}
+
+func (e *deleteStreamMessageEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	if v.required("messageId", &e.MessageID) {
+		v.maxLen("messageId", &e.MessageID, 64)
+	}
+}
+
+type muteStreamUserEvent struct {
+	StreamID string `json:"streamId"`
+	UserID   string `json:"userId"`
+	Minutes  *int   `json:"minutes"`
+}
+
+func (e *muteStreamUserEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	if v.required("userId", &e.UserID) {
+		v.uuid("userId", e.UserID)
+	}
+	if e.Minutes == nil {
+		minutes := defaultMuteMinutes
+		e.Minutes = &minutes
+	}
+	v.between("minutes", *e.Minutes, 1, maxChatMuteMinutes)
+}
+
+type unmuteStreamUserEvent struct {
+	StreamID string `json:"streamId"`
+	UserID   string `json:"userId"`
+}
+
+func (e *unmuteStreamUserEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	if v.required("userId", &e.UserID) {
+		v.uuid("userId", e.UserID)
+	}
+}
+
+type slowModeEvent struct {
+	StreamID string `json:"streamId"`
+	Seconds  int    `json:"seconds"`
+}
+
+func (e *slowModeEvent) validate(v *validator) {
+	validateStreamID(v, &e.StreamID)
+	v.between("seconds", e.Seconds, 0, maxSlowModeSeconds)
+}
+
+// lessonRef identifies a lesson; sent as {"lessonId"} or a bare lesson ID.
+type lessonRef struct {
+	LessonID string `json:"lessonId"`
+}
+
+func (e *lessonRef) setID(id string) { e.LessonID = id }
+
+func (e *lessonRef) validate(v *validator) {
+	if v.required("lessonId", &e.LessonID) {
+		v.uuid("lessonId", e.LessonID)
+	}
+}
+
+// threadRef identifies a forum thread; sent as {"threadId"} or a bare thread ID.
+type threadRef struct {
+	ThreadID string `json:"threadId"`
+}
+
+func (e *threadRef) setID(id string) { e.ThreadID = id }
+
+func (e *threadRef) validate(v *validator) {
+	if v.required("threadId", &e.ThreadID) {
+		v.uuid("threadId", e.ThreadID)
+	}
+}
+
+// validateChat checks a chat reference: a stream ID for stream chats, a
+// thread ID for forum threads.
+func validateChat(v *validator, channel, id *string) {
+	if !v.required("channel", channel) {
+		return
+	}
+	switch *channel {
+	case readreceipt.ChannelStream:
+		if v.required("id", id) {
+			v.maxLen("id", id, maxStreamIDLength)
+		}
+	case readreceipt.ChannelThread:
+		if v.required("id", id) {
+			v.uuid("id", *id)
+		}
+	default:
+		v.add("channel", "must be stream or thread")
+	}
+}
+
+type typingEvent struct {
+	Channel string `json:"channel"`
+	ID      string `json:"id"`
+	Typing  *bool  `json:"typing"`
+}
+
+func (e *typingEvent) validate(v *validator) {
+	validateChat(v, &e.Channel, &e.ID)
+	if e.Typing == nil {
+		typing := true
+		e.Typing = &typing
+	}
+}
+
+type markReadEvent struct {
+	Channel   string `json:"channel"`
+	ID        string `json:"id"`
+	MessageID string `json:"messageId"`
+}
+
+func (e *markReadEvent) validate(v *validator) {
+	validateChat(v, &e.Channel, &e.ID)
+	if v.required("messageId", &e.MessageID) {
+		v.maxLen("messageId", &e.MessageID, 100)
+	}
+}
+
+// presenceRef identifies the subscription whose presence is watched; sent as
+// {"subscriptionId"} or a bare subscription ID.
+type presenceRef struct {
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+func (e *presenceRef) setID(id string) { e.SubscriptionID = id }
+
+func (e *presenceRef) validate(v *validator) {
+	if v.required("subscriptionId", &e.SubscriptionID) {
+		v.uuid("subscriptionId", e.SubscriptionID)
+	}
+}
+
+// meetingRef identifies a meeting room; sent as {"roomId"} or a bare room ID.
+type meetingRef struct {
+	RoomID string `json:"roomId"`
+}
+
+func (e *meetingRef) setID(id string) { e.RoomID = id }
+
+func (e *meetingRef) validate(v *validator) {
+	if v.required("roomId", &e.RoomID) {
+		v.maxLen("roomId", &e.RoomID, maxMeetingRoomIDLength)
+	}
+}
+
+type raiseHandEvent struct {
+	RoomID string `json:"roomId"`
+	Raised *bool  `json:"raised"`
+}
+
+func (e *raiseHandEvent) validate(v *validator) {
+	if v.required("roomId", &e.RoomID) {
+		v.maxLen("roomId", &e.RoomID, maxMeetingRoomIDLength)
+	}
+	if e.Raised == nil {
+		raised := true
+		e.Raised = &raised
+	}
+}
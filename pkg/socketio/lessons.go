@@ -0,0 +1,100 @@
+package socketio
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	socket "github.com/zishang520/socket.io/socket"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// EmitToLesson broadcasts an event to every socket watching a lesson's discussion.
+func (s *Server) EmitToLesson(lessonID uuid.UUID, event string, payload any) {
+	if err := s.io.To(lessonRoom(lessonID.String())).Emit(event, payload); err != nil {
+		s.logger.Warn("failed to emit lesson event",
+			slog.String("event", event),
+			slog.String("lessonId", lessonID.String()),
+			slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) handleJoinLesson(sock *socket.Socket, rawLessonID string) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
+	lessonID, err := uuid.Parse(strings.TrimSpace(rawLessonID))
+	if err != nil {
+		s.emitError(sock, "INVALID_INPUT", "invalid lesson ID")
+		return
+	}
+
+	allowed, err := s.canAccessLesson(userData, lessonID)
+	if err != nil {
+		s.logger.Error("failed to check lesson access", slog.String("lessonId", lessonID.String()), slog.String("error", err.Error()))
+		s.emitError(sock, "INTERNAL_ERROR", "failed to join lesson")
+		return
+	}
+	if !allowed {
+		s.emitError(sock, "LESSON_NOT_FOUND", "lesson not found")
+		return
+	}
+
+	sock.Join(lessonRoom(lessonID.String()))
+	s.trackStudying(sock, lessonID)
+
+	if err := sock.Emit("lessonJoined", map[string]any{
+		"lessonId":  lessonID.String(),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Warn("failed to emit lessonJoined", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) handleLeaveLesson(sock *socket.Socket, rawLessonID string) {
+	lessonID, err := uuid.Parse(strings.TrimSpace(rawLessonID))
+	if err != nil {
+		s.emitError(sock, "INVALID_INPUT", "invalid lesson ID")
+		return
+	}
+	sock.Leave(lessonRoom(lessonID.String()))
+	s.trackStoppedStudying(sock, lessonID)
+}
+
+// canAccessLesson mirrors the REST access rules for comment routes: platform admins see
+// every lesson, other members only lessons of their own active subscription.
+func (s *Server) canAccessLesson(userData *user.User, lessonID uuid.UUID) (bool, error) {
+	var subscriptionIDs []uuid.UUID
+	if err := s.db.Table("lessons").
+		Joins("JOIN courses ON courses.id = lessons.course_id").
+		Where("lessons.id = ?", lessonID).
+		Limit(1).
+		Pluck("courses.subscription_id", &subscriptionIDs).Error; err != nil {
+		return false, err
+	}
+	if len(subscriptionIDs) == 0 {
+		return false, nil
+	}
+
+	switch userData.UserType {
+	case types.UserTypeAdmin, types.UserTypeSuperAdmin:
+		return true, nil
+	case types.UserTypeReferrer:
+		return false, nil
+	}
+
+	if userData.SubscriptionID == nil || *userData.SubscriptionID != subscriptionIDs[0] {
+		return false, nil
+	}
+	return userData.Subscription != nil && userData.Subscription.Active, nil
+}
+
+func lessonRoom(lessonID string) socket.Room {
+	return socket.Room("lesson_" + lessonID)
+}
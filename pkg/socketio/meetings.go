@@ -0,0 +1,85 @@
+package socketio
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	socket "github.com/zishang520/socket.io/socket"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/meeting"
+)
+
+// SetMeetingCache enables the meeting room events; call it before serving traffic.
+func (s *Server) SetMeetingCache(cache *meeting.Cache) {
+	s.meetings = cache
+}
+
+// EmitToMeeting broadcasts an event to every socket that joined the meeting room.
+func (s *Server) EmitToMeeting(roomID, event string, payload any) {
+	if err := s.io.To(meetingRoom(roomID)).Emit(event, payload); err != nil {
+		s.logger.Warn("failed to emit meeting event",
+			slog.String("event", event),
+			slog.String("roomId", roomID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// RemoveFromMeeting detaches all of a user's sockets from the meeting room.
+func (s *Server) RemoveFromMeeting(roomID, userID string) {
+	s.io.In(userRoom(userID)).SocketsLeave(meetingRoom(roomID))
+}
+
+func (s *Server) handleJoinMeeting(sock *socket.Socket, rawRoomID string) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
+	roomID := strings.TrimSpace(rawRoomID)
+	// Joining over REST registers the participant; the socket only subscribes to events
+	if s.meetings == nil || !s.meetings.IsParticipant(roomID, userData.ID.String()) {
+		s.emitError(sock, "MEETING_NOT_FOUND", "join the meeting before subscribing to its events")
+		return
+	}
+
+	sock.Join(meetingRoom(roomID))
+
+	if err := sock.Emit("meetingJoined", map[string]any{
+		"roomId":    roomID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Warn("failed to emit meetingJoined", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) handleLeaveMeeting(sock *socket.Socket, rawRoomID string) {
+	sock.Leave(meetingRoom(strings.TrimSpace(rawRoomID)))
+}
+
+func (s *Server) handleRaiseHand(sock *socket.Socket, e raiseHandEvent) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
+	roomID := e.RoomID
+	if s.meetings == nil {
+		s.emitError(sock, "MEETING_NOT_FOUND", "meetings are not available")
+		return
+	}
+
+	participant, err := s.meetings.SetHandRaised(roomID, userData.ID.String(), *e.Raised)
+	if err != nil {
+		s.emitError(sock, "MEETING_NOT_FOUND", err.Error())
+		return
+	}
+
+	s.EmitToMeeting(roomID, meeting.EventHandRaised, meeting.HandRaisedPayload(roomID, participant))
+}
+
+func meetingRoom(roomID string) socket.Room {
+	return socket.Room("meeting_" + roomID)
+}
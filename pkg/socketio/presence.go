@@ -0,0 +1,121 @@
+package socketio
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	socket "github.com/zishang520/socket.io/socket"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/presence"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+)
+
+// SetPresence makes the server record which members are online and which
+// lesson they study; call it before serving traffic.
+func (s *Server) SetPresence(tracker *presence.Tracker) {
+	s.presence = tracker
+}
+
+// trackConnect records a new socket of a subscription member.
+func (s *Server) trackConnect(sock *socket.Socket, userData *user.User) {
+	if s.presence == nil || userData.SubscriptionID == nil {
+		return
+	}
+	s.broadcastPresence(s.presence.Connect(s.socketID(sock), *userData.SubscriptionID, userData.ID, userData.FullName, userData.UserType))
+}
+
+func (s *Server) trackDisconnect(sock *socket.Socket) {
+	if s.presence == nil {
+		return
+	}
+	if change, ok := s.presence.Disconnect(s.socketID(sock)); ok {
+		s.broadcastPresence(change)
+	}
+}
+
+// trackStudying records the lesson a socket joined.
+func (s *Server) trackStudying(sock *socket.Socket, lessonID uuid.UUID) {
+	if s.presence == nil {
+		return
+	}
+
+	var courseIDs []uuid.UUID
+	if err := s.db.Table("lessons").Where("id = ?", lessonID).Limit(1).Pluck("course_id", &courseIDs).Error; err != nil || len(courseIDs) == 0 {
+		if err != nil {
+			s.logger.Warn("failed to load lesson course for presence", slog.String("lessonId", lessonID.String()), slog.String("error", err.Error()))
+		}
+		return
+	}
+	if change, ok := s.presence.Study(s.socketID(sock), courseIDs[0], lessonID); ok {
+		s.broadcastPresence(change)
+	}
+}
+
+func (s *Server) trackStoppedStudying(sock *socket.Socket, lessonID uuid.UUID) {
+	if s.presence == nil {
+		return
+	}
+	if change, ok := s.presence.StopStudying(s.socketID(sock), lessonID); ok {
+		s.broadcastPresence(change)
+	}
+}
+
+// broadcastPresence tells the sockets watching the subscription's presence.
+func (s *Server) broadcastPresence(change presence.Change) {
+	if err := s.io.To(presenceRoom(change.SubscriptionID.String())).Emit("presenceChanged", change); err != nil {
+		s.logger.Warn("failed to broadcast presence", slog.String("error", err.Error()))
+	}
+}
+
+// handleWatchPresence subscribes staff to a subscription's presence updates and
+// answers with who is online now.
+func (s *Server) handleWatchPresence(sock *socket.Socket, rawSubscriptionID string) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+	if s.presence == nil {
+		s.emitError(sock, "FEATURE_DISABLED", "presence is not available")
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(strings.TrimSpace(rawSubscriptionID))
+	if err != nil {
+		s.emitError(sock, "INVALID_INPUT", "invalid subscription ID")
+		return
+	}
+
+	allowed := authz.IsPlatformAdmin(userData.UserType) ||
+		(authz.IsSubscriptionStaff(userData.UserType) && userData.SubscriptionID != nil && *userData.SubscriptionID == subscriptionID)
+	if !allowed {
+		s.emitError(sock, "FORBIDDEN", "only staff can watch presence")
+		return
+	}
+
+	sock.Join(presenceRoom(subscriptionID.String()))
+
+	if err := sock.Emit("presenceSnapshot", map[string]any{
+		"subscriptionId": subscriptionID.String(),
+		"online":         s.presence.Online(subscriptionID),
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Warn("failed to emit presenceSnapshot", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) handleUnwatchPresence(sock *socket.Socket, rawSubscriptionID string) {
+	subscriptionID, err := uuid.Parse(strings.TrimSpace(rawSubscriptionID))
+	if err != nil {
+		s.emitError(sock, "INVALID_INPUT", "invalid subscription ID")
+		return
+	}
+	sock.Leave(presenceRoom(subscriptionID.String()))
+}
+
+func presenceRoom(subscriptionID string) socket.Room {
+	return socket.Room("presence_" + subscriptionID)
+}
@@ -6,14 +6,24 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	socket "github.com/zishang520/socket.io/socket"
 	"gorm.io/gorm"
 
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/featureflag"
+	"github.com/mo-amir99/lms-server-go/internal/features/maintenance"
+	"github.com/mo-amir99/lms-server-go/internal/features/meeting"
+	"github.com/mo-amir99/lms-server-go/internal/features/presence"
+	"github.com/mo-amir99/lms-server-go/internal/features/setting"
+	"github.com/mo-amir99/lms-server-go/internal/features/streamchat"
 	"github.com/mo-amir99/lms-server-go/internal/features/user"
 	jwtutil "github.com/mo-amir99/lms-server-go/internal/utils/jwt"
 	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+	"github.com/mo-amir99/lms-server-go/pkg/webrtc"
 )
 
 // StreamingLimits defines production-ready streaming constraints.
@@ -47,6 +57,53 @@ type Server struct {
 
 	activityMu   sync.Mutex
 	userActivity map[string]*userStreamActivity
+
+	streamStartedHooks []func(streamcache.Stream)
+	streamEndedHooks   []func(streamcache.Stream)
+	meetings           *meeting.Cache
+	iceProvider        *webrtc.Provider
+	streamChat         *streamchat.Service
+	settings           *setting.Service
+	featureFlags       *featureflag.Service
+	maintenance        *maintenance.Service
+	presence           *presence.Tracker
+
+	// draining refuses new connections once shutdown has begun, see Drain
+	draining atomic.Bool
+}
+
+// SetFeatureFlags refuses to start streams for subscriptions with live
+// streaming switched off.
+func (s *Server) SetFeatureFlags(service *featureflag.Service) {
+	s.featureFlags = service
+}
+
+// SetMaintenance tells clients the maintenance mode when they connect and
+// makes the heartbeat notice switches made through other instances.
+func (s *Server) SetMaintenance(service *maintenance.Service) {
+	s.maintenance = service
+}
+
+// SetSettings makes the streaming limits follow the runtime settings.
+func (s *Server) SetSettings(service *setting.Service) {
+	s.settings = service
+}
+
+// streamingLimits returns the built-in limits with runtime overrides applied.
+func (s *Server) streamingLimits() StreamingLimits {
+	limits := s.limits
+	if s.settings != nil {
+		limits.MaxConcurrentStreamsPerUser = s.settings.Int(setting.KeyMaxConcurrentStreamsPerUser)
+		limits.MaxViewersPerStream = s.settings.Int(setting.KeyMaxViewersPerStream)
+		limits.MaxTotalConcurrentStreams = s.settings.Int(setting.KeyMaxTotalConcurrentStreams)
+		limits.StreamStartCooldown = time.Duration(s.settings.Int(setting.KeyStreamStartCooldownSeconds)) * time.Second
+	}
+	return limits
+}
+
+// SetICEProvider makes the server hand ICE servers to clients on connect.
+func (s *Server) SetICEProvider(provider *webrtc.Provider) {
+	s.iceProvider = provider
 }
 
 // NewServer creates a new Socket.IO server with streaming support.
@@ -104,6 +161,19 @@ func (s *Server) Close() error {
 	return nil
 }
 
+// OnStreamStarted registers a callback invoked in the background whenever a host
+// goes live, including hosts claiming a scheduled session's stream. Register
+// hooks before serving traffic.
+func (s *Server) OnStreamStarted(fn func(stream streamcache.Stream)) {
+	s.streamStartedHooks = append(s.streamStartedHooks, fn)
+}
+
+// OnStreamEnded registers a callback invoked in the background whenever a stream ends,
+// whether the host ended it, left or disconnected. Register hooks before serving traffic.
+func (s *Server) OnStreamEnded(fn func(stream streamcache.Stream)) {
+	s.streamEndedHooks = append(s.streamEndedHooks, fn)
+}
+
 // setupEventHandlers configures all Socket.IO event handlers.
 func (s *Server) setupEventHandlers() {
 	s.io.Use(s.connectionMiddleware)
@@ -118,6 +188,15 @@ func (s *Server) setupEventHandlers() {
 }
 
 func (s *Server) connectionMiddleware(sock *socket.Socket, next func(*socket.ExtendedError)) {
+	if s.draining.Load() {
+		next(socket.NewExtendedError("server is shutting down", map[string]any{
+			"code":              codeServerShutdown,
+			"reconnectAfterMs":  reconnectAfter.Milliseconds(),
+			"reconnectJitterMs": reconnectJitter.Milliseconds(),
+		}))
+		return
+	}
+
 	token := s.extractToken(sock)
 	if token == "" {
 		s.logger.Warn("socket connection rejected: missing token")
@@ -139,10 +218,52 @@ func (s *Server) connectionMiddleware(sock *socket.Socket, next func(*socket.Ext
 		return
 	}
 
+	if claims.TokenVersion != userData.TokenVersion {
+		s.logger.Warn("socket connection rejected: token revoked", slog.Any("userId", claims.UserID))
+		next(socket.NewExtendedError("token revoked", map[string]any{"code": "TOKEN_REVOKED"}))
+		return
+	}
+
+	if !userData.Active && !authz.IsPlatformAdmin(userData.UserType) {
+		s.logger.Warn("socket connection rejected: account inactive", slog.Any("userId", claims.UserID))
+		next(socket.NewExtendedError("account inactive", map[string]any{"code": "ACCOUNT_INACTIVE"}))
+		return
+	}
+
 	sock.SetData(&userData)
 	next(nil)
 }
 
+// EmitToUser sends an event to every socket of the user.
+func (s *Server) EmitToUser(userID uuid.UUID, event string, payload any) {
+	if err := s.io.To(userRoom(userID.String())).Emit(event, payload); err != nil {
+		s.logger.Warn("failed to emit user event",
+			slog.String("event", event),
+			slog.String("userId", userID.String()),
+			slog.String("error", err.Error()))
+	}
+}
+
+// Broadcast sends an event to every socket connected to this instance.
+func (s *Server) Broadcast(event string, payload any) {
+	if err := s.io.Local().Emit(event, payload); err != nil {
+		s.logger.Warn("failed to broadcast event", slog.String("event", event), slog.String("error", err.Error()))
+	}
+}
+
+// DisconnectUser tells every socket of the user that its session was revoked
+// and closes them, so clients must reconnect with a fresh token.
+func (s *Server) DisconnectUser(userID uuid.UUID) {
+	room := userRoom(userID.String())
+	if err := s.io.To(room).Emit("sessionRevoked", map[string]any{
+		"code":      "SESSION_REVOKED",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Warn("failed to notify revoked sockets", slog.String("userId", userID.String()), slog.String("error", err.Error()))
+	}
+	s.io.In(room).DisconnectSockets(true)
+}
+
 func (s *Server) handleConnection(sock *socket.Socket) {
 	userData := s.getUserFromSocket(sock)
 	if userData == nil {
@@ -184,81 +305,63 @@ func (s *Server) handleConnection(sock *socket.Socket) {
 		}
 	}
 
+	// Stream and meeting peers need ICE servers before they can negotiate
+	if s.iceProvider != nil {
+		confirmData["ice"] = s.iceProvider.Credentials(userData.ID.String(), time.Now())
+	}
+
 	if err := sock.Emit("connectionConfirmed", confirmData); err != nil {
 		s.logger.Warn("failed to emit connection confirmation", slog.String("error", err.Error()))
 	}
 
+	// Clients connecting during maintenance show the banner straight away
+	if s.maintenance != nil {
+		if status := s.maintenance.Mode().Status(); status.Enabled {
+			if err := sock.Emit(maintenance.EventChanged, status); err != nil {
+				s.logger.Warn("failed to emit maintenance mode", slog.String("error", err.Error()))
+			}
+		}
+	}
+
 	sock.Join(userRoom(userData.ID.String()))
 	s.registerEventHandlers(sock)
+	s.trackConnect(sock, userData)
 }
 
 func (s *Server) registerEventHandlers(sock *socket.Socket) {
 	sock.On("getActiveStreams", func(args ...any) {
+		span := s.startEventSpan(sock, "getActiveStreams")
+		defer span.End()
+		defer s.recoverEvent(sock, "getActiveStreams", span)
 		s.handleGetActiveStreams(sock)
 	})
 
-	sock.On("startStream", func(args ...any) {
-		payload := mapArg(args)
-		if payload == nil {
-			s.emitError(sock, "INVALID_INPUT", "stream payload is required")
-			return
-		}
-		s.handleStartStream(sock, payload)
-	})
-
-	sock.On("joinStream", func(args ...any) {
-		streamID := stringArg(args)
-		if streamID == "" {
-			s.emitError(sock, "INVALID_INPUT", "stream ID is required")
-			return
-		}
-		s.handleJoinStream(sock, streamID)
-	})
-
-	sock.On("leaveStream", func(args ...any) {
-		streamID := stringArg(args)
-		if streamID == "" {
-			s.emitError(sock, "INVALID_INPUT", "stream ID is required")
-			return
-		}
-		s.handleLeaveStream(sock, streamID, "client-request")
-	})
-
-	sock.On("endStream", func(args ...any) {
-		streamID := stringArg(args)
-		if streamID == "" {
-			s.emitError(sock, "INVALID_INPUT", "stream ID is required")
-			return
-		}
-		s.handleEndStream(sock, streamID)
-	})
-
-	sock.On("updateStreamMedia", func(args ...any) {
-		payload := mapArg(args)
-		if payload == nil {
-			s.emitError(sock, "INVALID_INPUT", "media payload is required")
-			return
-		}
-		s.handleUpdateStreamMedia(sock, payload)
-	})
-
-	sock.On("streamMessage", func(args ...any) {
-		payload := mapArg(args)
-		if payload == nil {
-			s.emitError(sock, "INVALID_INPUT", "message payload is required")
-			return
-		}
-		s.handleStreamMessage(sock, payload)
-	})
-
-	sock.On("streamSignal", func(args ...any) {
-		payload := mapArg(args)
-		if payload == nil {
-			s.emitError(sock, "INVALID_INPUT", "signal payload is required")
-			return
-		}
-		s.handleStreamSignal(sock, payload)
-	})
+	on(s, sock, "startStream", s.handleStartStream)
+	on(s, sock, "joinStream", func(sock *socket.Socket, e streamRef) { s.handleJoinStream(sock, e.StreamID) })
+	on(s, sock, "leaveStream", func(sock *socket.Socket, e streamRef) { s.handleLeaveStream(sock, e.StreamID, "client-request") })
+	on(s, sock, "endStream", func(sock *socket.Socket, e streamRef) { s.handleEndStream(sock, e.StreamID) })
+	on(s, sock, "updateStreamMedia", s.handleUpdateStreamMedia)
+	on(s, sock, "streamMessage", s.handleStreamMessage)
+	on(s, sock, "deleteStreamMessage", s.handleDeleteStreamMessage)
+	on(s, sock, "muteStreamUser", s.handleMuteStreamUser)
+	on(s, sock, "unmuteStreamUser", s.handleUnmuteStreamUser)
+	on(s, sock, "setStreamSlowMode", s.handleSetSlowMode)
+	on(s, sock, "streamSignal", s.handleStreamSignal)
+
+	on(s, sock, "joinLesson", func(sock *socket.Socket, e lessonRef) { s.handleJoinLesson(sock, e.LessonID) })
+	on(s, sock, "leaveLesson", func(sock *socket.Socket, e lessonRef) { s.handleLeaveLesson(sock, e.LessonID) })
+
+	on(s, sock, "joinThread", func(sock *socket.Socket, e threadRef) { s.handleJoinThread(sock, e.ThreadID) })
+	on(s, sock, "leaveThread", func(sock *socket.Socket, e threadRef) { s.handleLeaveThread(sock, e.ThreadID) })
+	on(s, sock, "typing", s.handleTyping)
+	on(s, sock, "markRead", s.handleMarkRead)
+
+	on(s, sock, "watchPresence", func(sock *socket.Socket, e presenceRef) { s.handleWatchPresence(sock, e.SubscriptionID) })
+	on(s, sock, "unwatchPresence", func(sock *socket.Socket, e presenceRef) { s.handleUnwatchPresence(sock, e.SubscriptionID) })
+
+	on(s, sock, "joinMeeting", func(sock *socket.Socket, e meetingRef) { s.handleJoinMeeting(sock, e.RoomID) })
+	on(s, sock, "leaveMeeting", func(sock *socket.Socket, e meetingRef) { s.handleLeaveMeeting(sock, e.RoomID) })
+	on(s, sock, "raiseHand", s.handleRaiseHand)
 
 	sock.On("pong", func(args ...any) {
 		// optional: log latency when needed
@@ -279,10 +382,16 @@ func (s *Server) registerEventHandlers(sock *socket.Socket) {
 }
 
 func (s *Server) handleGetActiveStreams(sock *socket.Socket) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return
+	}
+
 	streams := s.streamCache.GetAllStreams()
 	payload := make([]map[string]any, 0, len(streams))
 	for _, stream := range streams {
-		if !stream.IsLive {
+		if !stream.IsLive || !s.canViewStream(userData, stream) {
 			continue
 		}
 		payload = append(payload, serializeStream(stream))
@@ -293,51 +402,74 @@ func (s *Server) handleGetActiveStreams(sock *socket.Socket) {
 	}
 }
 
-func (s *Server) handleStartStream(sock *socket.Socket, payload map[string]any) {
+func (s *Server) handleStartStream(sock *socket.Socket, e startStreamEvent) {
 	userData := s.getUserFromSocket(sock)
 	if userData == nil {
 		s.emitError(sock, "UNAUTHORIZED", "user context missing")
 		return
 	}
 
-	streamID := strings.TrimSpace(stringValue(payload, "streamId"))
-	title := strings.TrimSpace(stringValue(payload, "title"))
-	description := strings.TrimSpace(stringValue(payload, "description"))
-	chatEnabled := boolPointer(payload, "chatEnabled")
-	isPublic := boolValue(payload, "isPublic", true)
-
-	if streamID == "" || title == "" {
-		s.emitError(sock, "INVALID_INPUT", "streamId and title are required")
+	if s.featureFlags != nil && userData.SubscriptionID != nil && !s.featureFlags.Enabled(*userData.SubscriptionID, featureflag.LiveStreaming) {
+		s.emitError(sock, "FEATURE_DISABLED", "live streaming is not enabled for this subscription")
 		return
 	}
 
-	if existing, ok := s.streamCache.GetStream(streamID); ok && existing != nil && existing.IsLive {
+	streamID := e.StreamID
+	isPublic := e.IsPublic == nil || *e.IsPublic
+	groupAccess := e.GroupAccess
+
+	// Scheduled sessions are opened ahead of time by the scheduler; their host claims
+	// the already-registered stream instead of creating a new one.
+	existing, exists := s.streamCache.GetStream(streamID)
+	claimed := exists && existing != nil && existing.IsLive && existing.HostID == userData.ID.String()
+	if exists && existing != nil && existing.IsLive && !claimed {
 		s.emitError(sock, "STREAM_EXISTS", "stream already exists")
 		return
 	}
 
-	if err := s.validateStreamStart(userData.ID.String()); err != nil {
-		s.emitError(sock, err.code, err.message)
-		return
-	}
+	var stream *streamcache.Stream
+	if claimed {
+		stream = existing
+	} else {
+		// Group-scoped streams are never listed publicly
+		if len(groupAccess) > 0 {
+			if !s.validateStreamGroups(userData, groupAccess) {
+				s.emitError(sock, "INVALID_INPUT", "one or more access groups are invalid")
+				return
+			}
+			isPublic = false
+		}
 
-	if total := len(s.streamCache.GetAllStreams()); total >= s.limits.MaxTotalConcurrentStreams {
-		s.emitError(sock, "SERVER_BUSY", "too many active streams, try again later")
-		return
-	}
+		if err := s.validateStreamStart(userData.ID.String()); err != nil {
+			s.emitError(sock, err.code, err.message)
+			return
+		}
 
-	sock.Join(streamRoom(streamID))
+		if total := len(s.streamCache.GetAllStreams()); total >= s.streamingLimits().MaxTotalConcurrentStreams {
+			s.emitError(sock, "SERVER_BUSY", "too many active streams, try again later")
+			return
+		}
+
+		opts := streamcache.StreamOptions{
+			Title:       e.Title,
+			Description: e.Description,
+			HostName:    userData.FullName,
+			IsPublic:    isPublic,
+			ChatEnabled: e.ChatEnabled,
+			GroupAccess: groupAccess,
+		}
+		if userData.Subscription != nil {
+			opts.SubscriptionID = userData.Subscription.ID.String()
+		}
 
-	opts := streamcache.StreamOptions{
-		Title:       title,
-		Description: description,
-		HostName:    userData.FullName,
-		IsPublic:    isPublic,
-		ChatEnabled: chatEnabled,
+		stream = s.streamCache.StartStream(streamID, userData.ID.String(), opts)
 	}
 
-	stream := s.streamCache.StartStream(streamID, userData.ID.String(), opts)
+	sock.Join(streamRoom(streamID))
 	s.incrementStreamActivity(userData.ID.String())
+	for _, hook := range s.streamStartedHooks {
+		go hook(*stream)
+	}
 
 	response := map[string]any{
 		"streamId":  stream.ID,
@@ -349,14 +481,27 @@ func (s *Server) handleStartStream(sock *socket.Socket, payload map[string]any)
 		s.logger.Warn("failed to emit streamStarted", slog.String("error", err.Error()))
 	}
 
-	if stream.IsPublic {
-		if err := sock.Broadcast().Emit("newStreamAvailable", map[string]any{
-			"streamId":    stream.ID,
-			"title":       stream.Title,
-			"hostName":    stream.HostName,
-			"viewerCount": stream.ViewerCount,
-			"timestamp":   time.Now().UTC().Format(time.RFC3339),
-		}); err != nil {
+	announcement := map[string]any{
+		"streamId":    stream.ID,
+		"title":       stream.Title,
+		"hostName":    stream.HostName,
+		"viewerCount": stream.ViewerCount,
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	switch {
+	case len(stream.GroupAccess) > 0:
+		// Only members of the stream's groups hear about it
+		for _, memberID := range s.streamGroupMembers(*stream) {
+			if memberID == userData.ID.String() {
+				continue
+			}
+			if err := s.io.To(userRoom(memberID)).Emit("newStreamAvailable", announcement); err != nil {
+				s.logger.Warn("failed to notify group member of new stream", slog.String("error", err.Error()))
+			}
+		}
+	case stream.IsPublic:
+		if err := sock.Broadcast().Emit("newStreamAvailable", announcement); err != nil {
 			s.logger.Warn("failed to broadcast new stream", slog.String("error", err.Error()))
 		}
 	}
@@ -380,7 +525,12 @@ func (s *Server) handleJoinStream(sock *socket.Socket, streamID string) {
 		return
 	}
 
-	if stream.ViewerCount >= s.limits.MaxViewersPerStream {
+	if !s.canViewStream(userData, *stream) {
+		s.emitError(sock, "FORBIDDEN", "this stream is limited to specific groups")
+		return
+	}
+
+	if stream.ViewerCount >= s.streamingLimits().MaxViewersPerStream {
 		s.emitError(sock, "STREAM_FULL", "stream is at maximum capacity")
 		return
 	}
@@ -433,6 +583,7 @@ func (s *Server) handleLeaveStream(sock *socket.Socket, streamID, reason string)
 	if stream != nil && !stream.IsLive {
 		s.decrementStreamActivity(userData.ID.String())
 		s.broadcastStreamEnded(streamID, "host-ended")
+		s.runStreamEndedHooks(*stream)
 		return
 	}
 
@@ -468,27 +619,25 @@ func (s *Server) handleEndStream(sock *socket.Socket, streamID string) {
 		return
 	}
 
-	if _, err := s.streamCache.EndStream(streamID); err != nil {
+	ended, err := s.streamCache.EndStream(streamID)
+	if err != nil {
 		s.emitError(sock, "END_FAILED", err.Error())
 		return
 	}
 
 	s.decrementStreamActivity(userData.ID.String())
 	s.broadcastStreamEnded(streamID, "host-ended")
+	s.runStreamEndedHooks(*ended)
 }
 
-func (s *Server) handleUpdateStreamMedia(sock *socket.Socket, payload map[string]any) {
+func (s *Server) handleUpdateStreamMedia(sock *socket.Socket, e updateStreamMediaEvent) {
 	userData := s.getUserFromSocket(sock)
 	if userData == nil {
 		s.emitError(sock, "UNAUTHORIZED", "user context missing")
 		return
 	}
 
-	streamID := strings.TrimSpace(stringValue(payload, "streamId"))
-	if streamID == "" {
-		s.emitError(sock, "INVALID_INPUT", "stream ID is required")
-		return
-	}
+	streamID := e.StreamID
 
 	stream, ok := s.streamCache.GetStream(streamID)
 	if !ok || stream == nil {
@@ -502,9 +651,9 @@ func (s *Server) handleUpdateStreamMedia(sock *socket.Socket, payload map[string
 	}
 
 	updated, err := s.streamCache.UpdateStreamMedia(streamID, streamcache.MediaState{
-		HasVideo:       boolPointer(payload, "hasVideo"),
-		HasAudio:       boolPointer(payload, "hasAudio"),
-		HasScreenShare: boolPointer(payload, "hasScreenShare"),
+		HasVideo:       e.HasVideo,
+		HasAudio:       e.HasAudio,
+		HasScreenShare: e.HasScreenShare,
 	})
 	if err != nil {
 		s.emitError(sock, "UPDATE_FAILED", err.Error())
@@ -522,18 +671,13 @@ func (s *Server) handleUpdateStreamMedia(sock *socket.Socket, payload map[string
 	}
 }
 
-func (s *Server) handleStreamMessage(sock *socket.Socket, payload map[string]any) {
+func (s *Server) handleStreamMessage(sock *socket.Socket, e streamMessageEvent) {
 	userData := s.getUserFromSocket(sock)
 	if userData == nil {
 		return
 	}
 
-	streamID := strings.TrimSpace(stringValue(payload, "streamId"))
-	message := strings.TrimSpace(stringValue(payload, "message"))
-	if streamID == "" || message == "" {
-		s.emitError(sock, "INVALID_INPUT", "streamId and message are required")
-		return
-	}
+	streamID, message := e.StreamID, e.Message
 
 	stream, ok := s.streamCache.GetStream(streamID)
 	if !ok || stream == nil {
@@ -541,6 +685,15 @@ func (s *Server) handleStreamMessage(sock *socket.Socket, payload map[string]any
 		return
 	}
 
+	if !s.checkChatMessage(sock, streamID, userData.ID.String()) {
+		return
+	}
+
+	censored := false
+	if s.streamChat != nil && stream.SubscriptionID != "" {
+		message, censored = s.streamChat.Filter(stream.SubscriptionID, message)
+	}
+
 	chatMessage := map[string]any{
 		"id":        fmt.Sprintf("%d", time.Now().UnixNano()),
 		"streamId":  streamID,
@@ -549,6 +702,7 @@ func (s *Server) handleStreamMessage(sock *socket.Socket, payload map[string]any
 		"message":   message,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"isHost":    stream.HostID == userData.ID.String(),
+		"censored":  censored,
 	}
 
 	// Broadcast to everyone in the stream room including the sender
@@ -558,25 +712,13 @@ func (s *Server) handleStreamMessage(sock *socket.Socket, payload map[string]any
 	}
 }
 
-func (s *Server) handleStreamSignal(sock *socket.Socket, payload map[string]any) {
+func (s *Server) handleStreamSignal(sock *socket.Socket, e streamSignalEvent) {
 	userData := s.getUserFromSocket(sock)
 	if userData == nil {
 		return
 	}
 
-	streamID := strings.TrimSpace(stringValue(payload, "streamId"))
-	if streamID == "" {
-		s.emitError(sock, "INVALID_INPUT", "stream ID is required")
-		return
-	}
-
-	signal, ok := payload["signal"]
-	if !ok {
-		s.emitError(sock, "INVALID_INPUT", "signal payload is required")
-		return
-	}
-
-	targetUserID := strings.TrimSpace(stringValue(payload, "targetUserId"))
+	streamID, signal, targetUserID := e.StreamID, e.Signal, e.TargetUserID
 	signalPayload := map[string]any{
 		"streamId": streamID,
 		"signal":   signal,
@@ -602,6 +744,8 @@ func (s *Server) handleDisconnect(sock *socket.Socket, reason string) {
 	delete(s.connections, s.socketID(sock))
 	s.connMutex.Unlock()
 
+	s.trackDisconnect(sock)
+
 	if userData == nil {
 		return
 	}
@@ -617,8 +761,9 @@ func (s *Server) handleDisconnect(sock *socket.Socket, reason string) {
 		switch {
 		case stream.HostID == userData.ID.String():
 			s.decrementStreamActivity(userData.ID.String())
-			if _, err := s.streamCache.EndStream(stream.ID); err == nil {
+			if ended, err := s.streamCache.EndStream(stream.ID); err == nil {
 				s.broadcastStreamEnded(stream.ID, "host-disconnected")
+				s.runStreamEndedHooks(*ended)
 			}
 		default:
 			s.handleLeaveStream(sock, stream.ID, "disconnect")
@@ -642,6 +787,12 @@ func (s *Server) broadcastStreamEnded(streamID, reason string) {
 	}
 }
 
+func (s *Server) runStreamEndedHooks(stream streamcache.Stream) {
+	for _, hook := range s.streamEndedHooks {
+		go hook(stream)
+	}
+}
+
 func (s *Server) startHeartbeat() {
 	s.heartbeatStop = make(chan struct{})
 	s.heartbeatWG.Add(1)
@@ -655,6 +806,10 @@ func (s *Server) startHeartbeat() {
 			select {
 			case <-ticker.C:
 				s.sendHeartbeat()
+				// Reloading the mode broadcasts switches made through other instances
+				if s.maintenance != nil {
+					s.maintenance.Mode()
+				}
 			case <-s.heartbeatStop:
 				return
 			}
@@ -675,6 +830,14 @@ func (s *Server) sendHeartbeat() {
 	}
 }
 
+// ConnectionCount returns the number of open socket connections.
+func (s *Server) ConnectionCount() int {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	return len(s.connections)
+}
+
 func (s *Server) getUserFromSocket(sock *socket.Socket) *user.User {
 	if sock == nil {
 		return nil
@@ -714,13 +877,14 @@ func (s *Server) validateStreamStart(userID string) *streamStartError {
 		s.userActivity[userID] = activity
 	}
 
-	if !activity.lastStreamStart.IsZero() && now.Sub(activity.lastStreamStart) < s.limits.StreamStartCooldown {
-		remaining := s.limits.StreamStartCooldown - now.Sub(activity.lastStreamStart)
+	limits := s.streamingLimits()
+	if !activity.lastStreamStart.IsZero() && now.Sub(activity.lastStreamStart) < limits.StreamStartCooldown {
+		remaining := limits.StreamStartCooldown - now.Sub(activity.lastStreamStart)
 		return &streamStartError{code: "COOLDOWN", message: fmt.Sprintf("please wait %d seconds before starting another stream", int(remaining.Seconds()))}
 	}
 
 	hostStreams := s.countStreamsByHost(userID)
-	if hostStreams >= s.limits.MaxConcurrentStreamsPerUser {
+	if hostStreams >= limits.MaxConcurrentStreamsPerUser {
 		return &streamStartError{code: "STREAM_LIMIT", message: "maximum concurrent streams reached"}
 	}
 
@@ -809,101 +973,28 @@ func (s *Server) socketID(sock *socket.Socket) string {
 
 func serializeStream(stream streamcache.Stream) map[string]any {
 	payload := map[string]any{
-		"id":             stream.ID,
-		"hostId":         stream.HostID,
-		"hostName":       stream.HostName,
-		"title":          stream.Title,
-		"description":    stream.Description,
-		"viewerCount":    stream.ViewerCount,
-		"isLive":         stream.IsLive,
-		"isPublic":       stream.IsPublic,
-		"startTime":      stream.StartTime,
-		"hasVideo":       stream.HasVideo,
-		"hasAudio":       stream.HasAudio,
-		"hasScreenShare": stream.HasScreenShare,
-		"chatEnabled":    stream.ChatEnabled,
+		"id":              stream.ID,
+		"hostId":          stream.HostID,
+		"hostName":        stream.HostName,
+		"title":           stream.Title,
+		"description":     stream.Description,
+		"viewerCount":     stream.ViewerCount,
+		"isLive":          stream.IsLive,
+		"isPublic":        stream.IsPublic,
+		"startTime":       stream.StartTime,
+		"hasVideo":        stream.HasVideo,
+		"hasAudio":        stream.HasAudio,
+		"hasScreenShare":  stream.HasScreenShare,
+		"chatEnabled":     stream.ChatEnabled,
+		"slowModeSeconds": stream.SlowModeSeconds,
 	}
 	if stream.EndTime != nil {
 		payload["endTime"] = stream.EndTime
 	}
-	return payload
-}
-
-func stringValue(payload map[string]any, key string) string {
-	if val, ok := payload[key]; ok {
-		switch v := val.(type) {
-		case string:
-			return v
-		case fmt.Stringer:
-			return v.String()
-		case []byte:
-			return string(v)
-		}
-	}
-	return ""
-}
-
-func boolValue(payload map[string]any, key string, fallback bool) bool {
-	if val, ok := payload[key]; ok {
-		switch v := val.(type) {
-		case bool:
-			return v
-		case string:
-			lower := strings.ToLower(strings.TrimSpace(v))
-			if lower == "true" || lower == "1" {
-				return true
-			}
-			if lower == "false" || lower == "0" {
-				return false
-			}
-		}
+	if len(stream.GroupAccess) > 0 {
+		payload["groupAccess"] = stream.GroupAccess
 	}
-	return fallback
-}
-
-func boolPointer(payload map[string]any, key string) *bool {
-	if val, ok := payload[key]; ok {
-		switch v := val.(type) {
-		case bool:
-			return &v
-		case string:
-			lower := strings.ToLower(strings.TrimSpace(v))
-			if lower == "true" || lower == "1" {
-				b := true
-				return &b
-			}
-			if lower == "false" || lower == "0" {
-				b := false
-				return &b
-			}
-		}
-	}
-	return nil
-}
-
-func stringArg(args []any) string {
-	if len(args) == 0 {
-		return ""
-	}
-	switch v := args[0].(type) {
-	case string:
-		return v
-	case fmt.Stringer:
-		return v.String()
-	case []byte:
-		return string(v)
-	}
-	return ""
-}
-
-func mapArg(args []any) map[string]any {
-	if len(args) == 0 {
-		return nil
-	}
-	if payload, ok := args[0].(map[string]any); ok {
-		return payload
-	}
-	return nil
+	return payload
 }
 
 func streamRoom(streamID string) socket.Room {
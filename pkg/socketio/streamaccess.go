@@ -0,0 +1,88 @@
+package socketio
+
+import (
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mo-amir99/lms-server-go/internal/authz"
+	"github.com/mo-amir99/lms-server-go/internal/features/user"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// canViewStream reports whether the user may watch the stream. Group-scoped streams
+// are limited to the host, platform admins, the subscription's staff and members of
+// one of the stream's access groups.
+func (s *Server) canViewStream(viewer *user.User, stream streamcache.Stream) bool {
+	if len(stream.GroupAccess) == 0 {
+		return true
+	}
+	if viewer.ID.String() == stream.HostID || authz.IsPlatformAdmin(viewer.UserType) {
+		return true
+	}
+	if viewer.SubscriptionID == nil || viewer.SubscriptionID.String() != stream.SubscriptionID {
+		return false
+	}
+	if authz.IsSubscriptionStaff(viewer.UserType) {
+		return true
+	}
+
+	var count int64
+	if err := s.db.Table("group_access").
+		Where("id IN ? AND subscription_id = ? AND ? = ANY(users)", stream.GroupAccess, stream.SubscriptionID, viewer.ID).
+		Count(&count).Error; err != nil {
+		s.logger.Warn("failed to check stream group access",
+			slog.String("streamId", stream.ID),
+			slog.String("error", err.Error()))
+		return false
+	}
+	return count > 0
+}
+
+// validateStreamGroups checks that every group ID belongs to the host's subscription.
+func (s *Server) validateStreamGroups(host *user.User, groupIDs []string) bool {
+	if host.SubscriptionID == nil {
+		return false
+	}
+	for _, id := range groupIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return false
+		}
+	}
+
+	var count int64
+	if err := s.db.Table("group_access").
+		Where("id IN ? AND subscription_id = ?", groupIDs, *host.SubscriptionID).
+		Count(&count).Error; err != nil {
+		s.logger.Warn("failed to validate stream groups", slog.String("error", err.Error()))
+		return false
+	}
+	return int(count) == len(groupIDs)
+}
+
+// streamGroupMembers returns the users of the stream's access groups.
+func (s *Server) streamGroupMembers(stream streamcache.Stream) []string {
+	var groups []pq.StringArray
+	if err := s.db.Table("group_access").
+		Where("id IN ? AND subscription_id = ?", stream.GroupAccess, stream.SubscriptionID).
+		Pluck("users", &groups).Error; err != nil {
+		s.logger.Warn("failed to load stream group members",
+			slog.String("streamId", stream.ID),
+			slog.String("error", err.Error()))
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	members := make([]string, 0)
+	for _, users := range groups {
+		for _, id := range users {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			members = append(members, id)
+		}
+	}
+	return members
+}
@@ -0,0 +1,154 @@
+package socketio
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	socket "github.com/zishang520/socket.io/socket"
+
+	"github.com/mo-amir99/lms-server-go/internal/features/streamchat"
+	"github.com/mo-amir99/lms-server-go/pkg/streamcache"
+)
+
+// SetStreamChat enables banned word filtering of stream chat messages.
+func (s *Server) SetStreamChat(service *streamchat.Service) {
+	s.streamChat = service
+}
+
+// moderatedStream returns the live stream if the socket's user hosts it, emitting an
+// error otherwise.
+func (s *Server) moderatedStream(sock *socket.Socket, streamID string) (*streamcache.Stream, bool) {
+	userData := s.getUserFromSocket(sock)
+	if userData == nil {
+		s.emitError(sock, "UNAUTHORIZED", "user context missing")
+		return nil, false
+	}
+
+	stream, ok := s.streamCache.GetStream(streamID)
+	if !ok || stream == nil || !stream.IsLive {
+		s.emitError(sock, "STREAM_NOT_FOUND", "stream not found")
+		return nil, false
+	}
+
+	if stream.HostID != userData.ID.String() {
+		s.emitError(sock, "UNAUTHORIZED", "only the host can moderate the chat")
+		return nil, false
+	}
+	return stream, true
+}
+
+func (s *Server) handleDeleteStreamMessage(sock *socket.Socket, e deleteStreamMessageEvent) {
+	streamID, messageID := e.StreamID, e.MessageID
+	if _, ok := s.moderatedStream(sock, streamID); !ok {
+		return
+	}
+
+	// Messages are not stored server side; clients drop the message from their view
+	s.emitToStream(streamID, "streamMessageDeleted", map[string]any{
+		"streamId":  streamID,
+		"messageId": messageID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) handleMuteStreamUser(sock *socket.Socket, e muteStreamUserEvent) {
+	streamID, userID := e.StreamID, e.UserID
+	stream, ok := s.moderatedStream(sock, streamID)
+	if !ok {
+		return
+	}
+	if userID == stream.HostID {
+		s.emitError(sock, "INVALID_INPUT", "the host cannot be muted")
+		return
+	}
+
+	until := time.Now().UTC().Add(time.Duration(*e.Minutes) * time.Minute)
+	if err := s.streamCache.MuteChatUser(streamID, userID, until); err != nil {
+		s.emitError(sock, "MUTE_FAILED", err.Error())
+		return
+	}
+
+	s.emitToStream(streamID, "streamUserMuted", map[string]any{
+		"streamId":   streamID,
+		"userId":     userID,
+		"mutedUntil": until.Format(time.RFC3339),
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) handleUnmuteStreamUser(sock *socket.Socket, e unmuteStreamUserEvent) {
+	streamID, userID := e.StreamID, e.UserID
+	if _, ok := s.moderatedStream(sock, streamID); !ok {
+		return
+	}
+
+	if err := s.streamCache.UnmuteChatUser(streamID, userID); err != nil {
+		s.emitError(sock, "UNMUTE_FAILED", err.Error())
+		return
+	}
+
+	s.emitToStream(streamID, "streamUserUnmuted", map[string]any{
+		"streamId":  streamID,
+		"userId":    userID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) handleSetSlowMode(sock *socket.Socket, e slowModeEvent) {
+	streamID := e.StreamID
+	if _, ok := s.moderatedStream(sock, streamID); !ok {
+		return
+	}
+
+	updated, err := s.streamCache.SetSlowMode(streamID, e.Seconds)
+	if err != nil {
+		s.emitError(sock, "UPDATE_FAILED", err.Error())
+		return
+	}
+
+	s.emitToStream(streamID, "streamSlowModeUpdated", map[string]any{
+		"streamId":        streamID,
+		"slowModeSeconds": updated.SlowModeSeconds,
+		"timestamp":       time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// checkChatMessage applies mutes and slow mode, emitting the reason to the sender when
+// the message is rejected.
+func (s *Server) checkChatMessage(sock *socket.Socket, streamID, userID string) bool {
+	wait, err := s.streamCache.CheckChatMessage(streamID, userID, time.Now().UTC())
+	if err == nil {
+		return true
+	}
+
+	switch {
+	case errors.Is(err, streamcache.ErrStreamNotFound):
+		s.emitError(sock, "STREAM_NOT_FOUND", "stream not found")
+	case errors.Is(err, streamcache.ErrChatDisabled):
+		s.emitError(sock, "CHAT_DISABLED", err.Error())
+	default:
+		code := "CHAT_MUTED"
+		if errors.Is(err, streamcache.ErrSlowMode) {
+			code = "SLOW_MODE"
+		}
+		if emitErr := sock.Emit("error", map[string]any{
+			"code":       code,
+			"message":    err.Error(),
+			"retryAfter": int(math.Ceil(wait.Seconds())),
+		}); emitErr != nil {
+			s.logger.Debug("failed to emit error", slog.String("error", emitErr.Error()))
+		}
+	}
+	return false
+}
+
+func (s *Server) emitToStream(streamID, event string, payload map[string]any) {
+	if err := s.io.To(streamRoom(streamID)).Emit(event, payload); err != nil {
+		s.logger.Warn("failed to broadcast stream chat event",
+			slog.String("event", event),
+			slog.String("streamId", streamID),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,30 @@
+package socketio
+
+import (
+	"context"
+
+	socket "github.com/zishang520/socket.io/socket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/mo-amir99/lms-server-go/pkg/socketio")
+
+// startEventSpan starts the span covering one client event. Events arrive
+// outside any HTTP request, so each begins its own trace.
+func (s *Server) startEventSpan(sock *socket.Socket, event string) trace.Span {
+	attrs := []attribute.KeyValue{
+		attribute.String("socketio.event", event),
+		attribute.String("socketio.socket_id", s.socketID(sock)),
+	}
+	if userData := s.getUserFromSocket(sock); userData != nil {
+		attrs = append(attrs, attribute.String("user.id", userData.ID.String()))
+	}
+
+	_, span := tracer.Start(context.Background(), "socketio "+event,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+	return span
+}
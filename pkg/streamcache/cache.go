@@ -27,6 +27,22 @@ type Stream struct {
 	HasAudio       bool       `json:"hasAudio"`
 	HasScreenShare bool       `json:"hasScreenShare"`
 	ChatEnabled    bool       `json:"chatEnabled"`
+	SubscriptionID string     `json:"subscriptionId,omitempty"`
+	// GroupAccess limits the audience to members of these access groups when set.
+	GroupAccess []string `json:"groupAccess,omitempty"`
+	// SlowModeSeconds is the minimum gap between two chat messages of one viewer; 0 disables it.
+	SlowModeSeconds int `json:"slowModeSeconds"`
+
+	// ViewerSessions is only filled on the snapshot returned when a stream ends so
+	// end-of-stream hooks can persist audience analytics.
+	ViewerSessions []ViewerSession `json:"-"`
+}
+
+// ViewerSession is one continuous stay of a viewer in a stream; rejoining opens a new one.
+type ViewerSession struct {
+	ViewerID string     `json:"viewerId"`
+	JoinedAt time.Time  `json:"joinedAt"`
+	LeftAt   *time.Time `json:"leftAt,omitempty"`
 }
 
 // StreamOptions configures a new stream when it is started.
@@ -36,6 +52,10 @@ type StreamOptions struct {
 	HostName    string
 	IsPublic    bool
 	ChatEnabled *bool
+	// SubscriptionID scopes chat settings such as banned words; empty for platform admins.
+	SubscriptionID string
+	// GroupAccess restricts the stream to members of these access groups of the subscription.
+	GroupAccess []string
 }
 
 // MediaState updates the media flags for a running stream.
@@ -51,6 +71,8 @@ type Cache struct {
 	streams map[string]*Stream
 	viewers map[string]map[string]struct{}
 	hosts   map[string]string
+	history map[string][]ViewerSession
+	chat    map[string]*chatState
 }
 
 var globalCache = New()
@@ -66,6 +88,8 @@ func New() *Cache {
 		streams: make(map[string]*Stream),
 		viewers: make(map[string]map[string]struct{}),
 		hosts:   make(map[string]string),
+		history: make(map[string][]ViewerSession),
+		chat:    make(map[string]*chatState),
 	}
 }
 
@@ -93,11 +117,15 @@ func (c *Cache) StartStream(streamID, hostID string, opts StreamOptions) *Stream
 		HasAudio:       false,
 		HasScreenShare: false,
 		ChatEnabled:    enabledChat,
+		SubscriptionID: opts.SubscriptionID,
+		GroupAccess:    opts.GroupAccess,
 	}
 
 	c.streams[streamID] = stream
 	c.viewers[streamID] = make(map[string]struct{})
 	c.hosts[streamID] = hostID
+	c.history[streamID] = nil
+	c.chat[streamID] = newChatState()
 
 	copy := *stream
 	return &copy
@@ -117,6 +145,7 @@ func (c *Cache) JoinStream(streamID, viewerID string) (*Stream, error) {
 	if _, exists := viewers[viewerID]; !exists {
 		viewers[viewerID] = struct{}{}
 		stream.ViewerCount = len(viewers)
+		c.history[streamID] = append(c.history[streamID], ViewerSession{ViewerID: viewerID, JoinedAt: time.Now().UTC()})
 	}
 
 	copy := *stream
@@ -142,6 +171,7 @@ func (c *Cache) LeaveStream(streamID, userID string) (*Stream, error) {
 		if _, watching := viewers[userID]; watching {
 			delete(viewers, userID)
 			stream.ViewerCount = len(viewers)
+			c.closeViewerSessionLocked(streamID, userID, time.Now().UTC())
 		}
 	}
 
@@ -200,6 +230,18 @@ func (c *Cache) GetStream(streamID string) (*Stream, bool) {
 	return &copy, true
 }
 
+// ViewerSessions returns a copy of the viewer history of a live stream. Sessions of
+// viewers still watching have no LeftAt.
+func (c *Cache) ViewerSessions(streamID string) ([]ViewerSession, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.streams[streamID]; !ok {
+		return nil, false
+	}
+	return append([]ViewerSession(nil), c.history[streamID]...), true
+}
+
 // GetAllStreams returns snapshots of all live streams currently registered.
 func (c *Cache) GetAllStreams() []Stream {
 	c.mu.RLock()
@@ -216,6 +258,20 @@ func (c *Cache) GetAllStreams() []Stream {
 	return result
 }
 
+// Stats returns the number of live streams and their viewers.
+func (c *Cache) Stats() (streams, viewers int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, stream := range c.streams {
+		if stream.IsLive {
+			streams++
+			viewers += stream.ViewerCount
+		}
+	}
+	return streams, viewers
+}
+
 // Reset clears the cache. Primarily useful for tests.
 func (c *Cache) Reset() {
 	c.mu.Lock()
@@ -224,6 +280,8 @@ func (c *Cache) Reset() {
 	c.streams = make(map[string]*Stream)
 	c.viewers = make(map[string]map[string]struct{})
 	c.hosts = make(map[string]string)
+	c.history = make(map[string][]ViewerSession)
+	c.chat = make(map[string]*chatState)
 }
 
 func (c *Cache) ensureViewerSet(streamID string) map[string]struct{} {
@@ -240,11 +298,18 @@ func (c *Cache) endStreamLocked(streamID string, stream *Stream) (*Stream, error
 	stream.IsLive = false
 	stream.EndTime = &now
 
+	for viewerID := range c.viewers[streamID] {
+		c.closeViewerSessionLocked(streamID, viewerID, now)
+	}
+
 	copy := *stream
+	copy.ViewerSessions = c.history[streamID]
 
 	delete(c.streams, streamID)
 	delete(c.viewers, streamID)
 	delete(c.hosts, streamID)
+	delete(c.history, streamID)
+	delete(c.chat, streamID)
 
 	return &copy, nil
 }
@@ -255,3 +320,14 @@ func defaultString(value, fallback string) string {
 	}
 	return value
 }
+
+func (c *Cache) closeViewerSessionLocked(streamID, viewerID string, at time.Time) {
+	sessions := c.history[streamID]
+	for i := len(sessions) - 1; i >= 0; i-- {
+		if sessions[i].ViewerID == viewerID && sessions[i].LeftAt == nil {
+			left := at
+			sessions[i].LeftAt = &left
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package streamcache
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrChatDisabled indicates the host turned chat off for the stream.
+	ErrChatDisabled = errors.New("chat is disabled for this stream")
+	// ErrChatMuted indicates the sender was muted by the host.
+	ErrChatMuted = errors.New("you are muted in this stream")
+	// ErrSlowMode indicates the sender must wait before posting again.
+	ErrSlowMode = errors.New("slow mode is on, wait before sending another message")
+)
+
+// chatState holds the moderation state of a live stream's chat.
+type chatState struct {
+	mutedUntil  map[string]time.Time
+	lastMessage map[string]time.Time
+}
+
+func newChatState() *chatState {
+	return &chatState{
+		mutedUntil:  make(map[string]time.Time),
+		lastMessage: make(map[string]time.Time),
+	}
+}
+
+// CheckChatMessage reports whether userID may post to the stream chat now and records
+// the message for slow mode. The host is exempt from mutes and slow mode. On
+// ErrChatMuted and ErrSlowMode the returned duration is how long the sender must wait.
+func (c *Cache) CheckChatMessage(streamID, userID string, now time.Time) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stream, ok := c.streams[streamID]
+	if !ok || !stream.IsLive {
+		return 0, ErrStreamNotFound
+	}
+	if stream.HostID == userID {
+		return 0, nil
+	}
+	if !stream.ChatEnabled {
+		return 0, ErrChatDisabled
+	}
+
+	chat := c.ensureChatState(streamID)
+	if until, muted := chat.mutedUntil[userID]; muted {
+		if now.Before(until) {
+			return until.Sub(now), ErrChatMuted
+		}
+		delete(chat.mutedUntil, userID)
+	}
+
+	if stream.SlowModeSeconds > 0 {
+		if last, posted := chat.lastMessage[userID]; posted {
+			next := last.Add(time.Duration(stream.SlowModeSeconds) * time.Second)
+			if now.Before(next) {
+				return next.Sub(now), ErrSlowMode
+			}
+		}
+	}
+
+	chat.lastMessage[userID] = now
+	return 0, nil
+}
+
+// MuteChatUser blocks userID from posting to the stream chat until the given time.
+func (c *Cache) MuteChatUser(streamID, userID string, until time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stream, ok := c.streams[streamID]
+	if !ok || !stream.IsLive {
+		return ErrStreamNotFound
+	}
+	c.ensureChatState(streamID).mutedUntil[userID] = until
+	return nil
+}
+
+// UnmuteChatUser lifts a mute before it expires.
+func (c *Cache) UnmuteChatUser(streamID, userID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stream, ok := c.streams[streamID]
+	if !ok || !stream.IsLive {
+		return ErrStreamNotFound
+	}
+	delete(c.ensureChatState(streamID).mutedUntil, userID)
+	return nil
+}
+
+// SetSlowMode sets the minimum gap between a viewer's messages; 0 turns slow mode off.
+func (c *Cache) SetSlowMode(streamID string, seconds int) (*Stream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stream, ok := c.streams[streamID]
+	if !ok || !stream.IsLive {
+		return nil, ErrStreamNotFound
+	}
+	if seconds < 0 {
+		seconds = 0
+	}
+	stream.SlowModeSeconds = seconds
+
+	copy := *stream
+	return &copy, nil
+}
+
+func (c *Cache) ensureChatState(streamID string) *chatState {
+	if chat, ok := c.chat[streamID]; ok {
+		return chat
+	}
+	chat := newChatState()
+	c.chat[streamID] = chat
+	return chat
+}
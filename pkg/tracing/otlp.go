@@ -0,0 +1,261 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// otlpExporter sends spans to an OTLP/HTTP collector using the JSON encoding
+// of the OTLP trace protocol.
+type otlpExporter struct {
+	url        string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+func newOTLPExporter(endpoint string, headers map[string]string) *otlpExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+
+	return &otlpExporter{
+		url:        url,
+		headers:    headers,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *otlpExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return fmt.Errorf("encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("export spans: collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (e *otlpExporter) Shutdown(context.Context) error {
+	e.httpClient.CloseIdleConnections()
+	return nil
+}
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resourceJSON `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+	SchemaURL  string       `json:"schemaUrl,omitempty"`
+}
+
+type resourceJSON struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope     scopeJSON  `json:"scope"`
+	Spans     []spanJSON `json:"spans"`
+	SchemaURL string     `json:"schemaUrl,omitempty"`
+}
+
+type scopeJSON struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type spanJSON struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []keyValue  `json:"attributes,omitempty"`
+	Events            []eventJSON `json:"events,omitempty"`
+	Status            statusJSON  `json:"status"`
+}
+
+type eventJSON struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+}
+
+type statusJSON struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []anyValue `json:"values"`
+}
+
+// encodeSpans groups spans by resource and instrumentation scope as the
+// protocol requires.
+func encodeSpans(spans []sdktrace.ReadOnlySpan) exportRequest {
+	var req exportRequest
+	resourceIndex := make(map[attribute.Distinct]int)
+	scopeIndex := make(map[attribute.Distinct]map[string]int)
+
+	for _, span := range spans {
+		res := span.Resource()
+		key := res.Equivalent()
+		ri, ok := resourceIndex[key]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resourceIndex[key] = ri
+			scopeIndex[key] = make(map[string]int)
+			req.ResourceSpans = append(req.ResourceSpans, resourceSpans{
+				Resource:  resourceJSON{Attributes: encodeAttributes(res.Attributes())},
+				SchemaURL: res.SchemaURL(),
+			})
+		}
+
+		scope := span.InstrumentationScope()
+		scopeKey := scope.Name + "@" + scope.Version
+		si, ok := scopeIndex[key][scopeKey]
+		if !ok {
+			si = len(req.ResourceSpans[ri].ScopeSpans)
+			scopeIndex[key][scopeKey] = si
+			req.ResourceSpans[ri].ScopeSpans = append(req.ResourceSpans[ri].ScopeSpans, scopeSpans{
+				Scope:     scopeJSON{Name: scope.Name, Version: scope.Version},
+				SchemaURL: scope.SchemaURL,
+			})
+		}
+
+		ss := &req.ResourceSpans[ri].ScopeSpans[si]
+		ss.Spans = append(ss.Spans, encodeSpan(span))
+	}
+
+	return req
+}
+
+func encodeSpan(span sdktrace.ReadOnlySpan) spanJSON {
+	encoded := spanJSON{
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: unixNano(span.StartTime()),
+		EndTimeUnixNano:   unixNano(span.EndTime()),
+		Attributes:        encodeAttributes(span.Attributes()),
+	}
+	if parent := span.Parent(); parent.HasSpanID() {
+		encoded.ParentSpanID = parent.SpanID().String()
+	}
+
+	for _, event := range span.Events() {
+		encoded.Events = append(encoded.Events, eventJSON{
+			TimeUnixNano: unixNano(event.Time),
+			Name:         event.Name,
+			Attributes:   encodeAttributes(event.Attributes),
+		})
+	}
+
+	// OTLP numbers status codes differently from the Go API
+	switch span.Status().Code {
+	case codes.Ok:
+		encoded.Status = statusJSON{Code: 1}
+	case codes.Error:
+		encoded.Status = statusJSON{Code: 2, Message: span.Status().Description}
+	}
+
+	return encoded
+}
+
+func encodeAttributes(attrs []attribute.KeyValue) []keyValue {
+	encoded := make([]keyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		encoded = append(encoded, keyValue{Key: string(attr.Key), Value: encodeValue(attr.Value)})
+	}
+	return encoded
+}
+
+func encodeValue(value attribute.Value) anyValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		v := value.AsBool()
+		return anyValue{BoolValue: &v}
+	case attribute.INT64:
+		v := strconv.FormatInt(value.AsInt64(), 10)
+		return anyValue{IntValue: &v}
+	case attribute.FLOAT64:
+		v := value.AsFloat64()
+		return anyValue{DoubleValue: &v}
+	case attribute.BOOLSLICE:
+		return encodeArray(value.AsBoolSlice(), attribute.BoolValue)
+	case attribute.INT64SLICE:
+		return encodeArray(value.AsInt64Slice(), attribute.Int64Value)
+	case attribute.FLOAT64SLICE:
+		return encodeArray(value.AsFloat64Slice(), attribute.Float64Value)
+	case attribute.STRINGSLICE:
+		return encodeArray(value.AsStringSlice(), attribute.StringValue)
+	default:
+		v := value.Emit()
+		return anyValue{StringValue: &v}
+	}
+}
+
+func encodeArray[T any](values []T, wrap func(T) attribute.Value) anyValue {
+	array := &arrayValue{Values: make([]anyValue, 0, len(values))}
+	for _, v := range values {
+		array.Values = append(array.Values, encodeValue(wrap(v)))
+	}
+	return anyValue{ArrayValue: array}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
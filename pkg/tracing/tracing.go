@@ -0,0 +1,49 @@
+// Package tracing configures OpenTelemetry tracing. Instrumented packages
+// create spans through the global tracer provider, which stays a no-op until
+// Setup installs an exporting one.
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+
+	"github.com/mo-amir99/lms-server-go/pkg/config"
+)
+
+// Setup installs a tracer provider exporting spans to the configured OTLP
+// endpoint and W3C trace context propagation. It returns a function flushing
+// buffered spans on shutdown. Without an endpoint tracing stays disabled.
+func Setup(cfg config.TracingConfig, version string, logger *slog.Logger) func(context.Context) error {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing error", slog.String("error", err.Error()))
+	}))
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newOTLPExporter(cfg.Endpoint, cfg.Headers)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(version),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	logger.Info("tracing enabled",
+		slog.String("endpoint", cfg.Endpoint),
+		slog.Float64("sample_ratio", cfg.SampleRatio),
+	)
+
+	return provider.Shutdown
+}
@@ -2,12 +2,15 @@ package types
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+
+	"github.com/mo-amir99/lms-server-go/pkg/pagination"
 )
 
 // UserType represents user role levels
@@ -29,6 +32,16 @@ const (
 	UserTypeOwner   UserType = UserTypeAdmin
 )
 
+// ContentStatus is the review state of a course or lesson. Only published
+// content reaches students, whatever its isActive flag says.
+type ContentStatus string
+
+const (
+	ContentStatusDraft     ContentStatus = "draft"
+	ContentStatusInReview  ContentStatus = "in_review"
+	ContentStatusPublished ContentStatus = "published"
+)
+
 // PaymentStatus represents payment state
 type PaymentStatus string
 
@@ -88,6 +101,11 @@ type BaseModel struct {
 	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
 }
 
+// CursorKey returns the keyset position of the row for cursor pagination.
+func (m BaseModel) CursorKey() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: m.CreatedAt, ID: m.ID}
+}
+
 // TimestampModel contains only timestamp fields (for models with custom IDs)
 type TimestampModel struct {
 	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
@@ -172,6 +190,11 @@ func (m Money) Mul(scalar float64) Money {
 	return Money(decimal.Decimal(m).Mul(decimal.NewFromFloat(scalar)))
 }
 
+// Round rounds m to the given number of decimal places
+func (m Money) Round(places int32) Money {
+	return Money(decimal.Decimal(m).Round(places))
+}
+
 // GreaterThan returns true if m > other
 func (m Money) GreaterThan(other Money) bool {
 	return decimal.Decimal(m).GreaterThan(decimal.Decimal(other))
@@ -260,3 +283,36 @@ func (j *JSON) UnmarshalJSON(data []byte) error {
 	*j = append((*j)[:0], data...)
 	return nil
 }
+
+// ImageVariant is one resized copy of an uploaded image.
+type ImageVariant struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+// ImageVariants lists the resized copies of an image, smallest first, stored as JSONB.
+type ImageVariants []ImageVariant
+
+// Value implements driver.Valuer for JSONB serialization.
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// Scan implements sql.Scanner for JSONB deserialization.
+func (v *ImageVariants) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("types.ImageVariants: unsupported scan type %T", value)
+	}
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Optional is a request field that distinguishes "absent" from "null" from a
+// value, which partial updates need. Binding rules apply to the value only, so
+// tag such fields with omitnil: `binding:"omitnil,notblank"`.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON records that the field was sent and whether it was null.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Ptr returns the value, or nil when the field was absent or null.
+func (o Optional[T]) Ptr() *T {
+	if !o.Set || o.Null {
+		return nil
+	}
+	value := o.Value
+	return &value
+}
+
+func (o Optional[T]) validationValue() interface{} {
+	return o.Ptr()
+}
+
+type optional interface {
+	validationValue() interface{}
+}
+
+func optionalValue(field reflect.Value) interface{} {
+	if opt, ok := field.Interface().(optional); ok {
+		return opt.validationValue()
+	}
+	return nil
+}
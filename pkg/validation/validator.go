@@ -0,0 +1,157 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"github.com/mo-amir99/lms-server-go/pkg/types"
+)
+
+// FieldError describes one invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Setup configures the validator used by gin's binding: fields are reported by
+// their JSON names, Optional fields are validated by their value, and the
+// custom rules below become available in `binding` tags.
+//
+//	id          a UUID in any form uuid.Parse accepts
+//	identifier  3-20 lowercase letters, numbers or hyphens (see NormalizeIdentifier)
+//	usertype    a known user type
+//	rfc3339     an RFC3339 timestamp
+//	notblank    not empty after trimming whitespace
+func Setup() error {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected gin validator engine")
+	}
+
+	engine.RegisterTagNameFunc(jsonFieldName)
+	engine.RegisterCustomTypeFunc(optionalValue,
+		Optional[string]{}, Optional[int]{}, Optional[float64]{}, Optional[bool]{})
+
+	rules := map[string]validator.Func{
+		"id": func(fl validator.FieldLevel) bool {
+			_, err := uuid.Parse(fl.Field().String())
+			return err == nil
+		},
+		"identifier": func(fl validator.FieldLevel) bool {
+			_, err := NormalizeIdentifier(fl.Field().String())
+			return err == nil
+		},
+		"usertype": func(fl validator.FieldLevel) bool {
+			return isUserType(types.UserType(fl.Field().String()))
+		},
+		"rfc3339": func(fl validator.FieldLevel) bool {
+			_, err := time.Parse(time.RFC3339, fl.Field().String())
+			return err == nil
+		},
+		"notblank": func(fl validator.FieldLevel) bool {
+			return strings.TrimSpace(fl.Field().String()) != ""
+		},
+	}
+	for tag, fn := range rules {
+		if err := engine.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("register %s rule: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// Fields converts a binding validation error into field errors. It reports
+// false when err did not come from the validator.
+func Fields(err error) ([]FieldError, bool) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+	return fields, true
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "id", "uuid", "uuid4":
+		return "must be a valid id"
+	case "identifier":
+		return "must be 3-20 lowercase letters, numbers or hyphens"
+	case "usertype":
+		return "must be a valid user type"
+	case "rfc3339":
+		return "must be an RFC3339 timestamp"
+	case "timezone":
+		return "must be an IANA timezone name"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		switch fe.Kind() {
+		case reflect.String:
+			return "must be at most " + fe.Param() + " characters"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return "must have at most " + fe.Param() + " items"
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
+
+// fieldPath drops the root struct name from a validator namespace.
+func fieldPath(namespace string) string {
+	if _, rest, found := strings.Cut(namespace, "."); found {
+		return rest
+	}
+	return namespace
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+func isUserType(userType types.UserType) bool {
+	switch userType {
+	case types.UserTypeReferrer, types.UserTypeStudent, types.UserTypeAssistant,
+		types.UserTypeInstructor, types.UserTypeAdmin, types.UserTypeSuperAdmin:
+		return true
+	default:
+		return false
+	}
+}
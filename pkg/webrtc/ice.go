@@ -0,0 +1,77 @@
+package webrtc
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+// ICEServer mirrors the RTCIceServer dictionary clients pass to RTCPeerConnection.
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+// Credentials is the ICE configuration handed to one user.
+type Credentials struct {
+	ICEServers []ICEServer `json:"iceServers"`
+	TTL        int         `json:"ttl"` // seconds
+	ExpiresAt  *time.Time  `json:"expiresAt,omitempty"`
+}
+
+// Provider issues ICE server lists with short-lived TURN credentials using the
+// coturn REST API scheme (use-auth-secret / static-auth-secret): the username is
+// "<expiry unix>:<user id>" and the password is base64(HMAC-SHA1(secret, username)).
+type Provider struct {
+	stunURLs []string
+	turnURLs []string
+	secret   string
+	ttl      time.Duration
+}
+
+// NewProvider constructs a provider. TURN servers are only advertised when both
+// turnURLs and secret are set.
+func NewProvider(stunURLs, turnURLs []string, secret string, ttl time.Duration) *Provider {
+	if ttl <= 0 {
+		ttl = 12 * time.Hour
+	}
+	return &Provider{stunURLs: stunURLs, turnURLs: turnURLs, secret: secret, ttl: ttl}
+}
+
+// TURNEnabled reports whether TURN relays are configured.
+func (p *Provider) TURNEnabled() bool {
+	return len(p.turnURLs) > 0 && p.secret != ""
+}
+
+// Credentials returns the ICE servers for userID, valid from now for the configured TTL.
+func (p *Provider) Credentials(userID string, now time.Time) Credentials {
+	creds := Credentials{ICEServers: make([]ICEServer, 0, 2)}
+
+	if len(p.stunURLs) > 0 {
+		creds.ICEServers = append(creds.ICEServers, ICEServer{URLs: p.stunURLs})
+	}
+
+	if p.TURNEnabled() {
+		expiresAt := now.Add(p.ttl).UTC()
+		username := strconv.FormatInt(expiresAt.Unix(), 10) + ":" + userID
+
+		creds.ICEServers = append(creds.ICEServers, ICEServer{
+			URLs:       p.turnURLs,
+			Username:   username,
+			Credential: sign(p.secret, username),
+		})
+		creds.TTL = int(p.ttl.Seconds())
+		creds.ExpiresAt = &expiresAt
+	}
+
+	return creds
+}
+
+func sign(secret, username string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -2,7 +2,7 @@
 # This script creates a new super admin account
 
 Write-Host "Creating super admin user..." -ForegroundColor Cyan
-go run ./scripts/create-superadmin/main.go
+go run ./cmd/lmsctl superadmin @args
 
 if ($LASTEXITCODE -eq 0) {
     Write-Host "`nSuper admin created successfully!" -ForegroundColor Green
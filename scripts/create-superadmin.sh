@@ -3,7 +3,7 @@
 # This script creates a new super admin account
 
 echo "Creating super admin user..."
-go run ./scripts/create-superadmin/main.go
+go run ./cmd/lmsctl superadmin "$@"
 
 if [ $? -eq 0 ]; then
     echo -e "\nSuper admin created successfully!"
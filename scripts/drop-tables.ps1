@@ -2,7 +2,7 @@
 # ⚠️  WARNING: This will DELETE ALL DATA!
 
 Write-Host "⚠️  WARNING: This will drop all database tables!" -ForegroundColor Red
-go run ./scripts/drop-tables/main.go
+go run ./cmd/lmsctl drop @args
 
 if ($LASTEXITCODE -eq 0) {
     Write-Host "`nTables dropped successfully!" -ForegroundColor Green
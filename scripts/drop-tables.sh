@@ -3,7 +3,7 @@
 # ⚠️  WARNING: This will DELETE ALL DATA!
 
 echo -e "\n⚠️  WARNING: This will drop all database tables!"
-go run ./scripts/drop-tables/main.go
+go run ./cmd/lmsctl drop "$@"
 
 if [ $? -eq 0 ]; then
     echo -e "\nTables dropped successfully!"
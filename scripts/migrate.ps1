@@ -2,7 +2,7 @@
 # This script creates/updates all database tables
 
 Write-Host "Running database migrations..." -ForegroundColor Cyan
-go run ./scripts/migrate/main.go
+go run ./cmd/lmsctl migrate @args
 
 if ($LASTEXITCODE -eq 0) {
     Write-Host "`nMigrations completed successfully!" -ForegroundColor Green
@@ -3,7 +3,7 @@
 # This script creates/updates all database tables
 
 echo "Running database migrations..."
-go run ./scripts/migrate/main.go
+go run ./cmd/lmsctl migrate "$@"
 
 if [ $? -eq 0 ]; then
     echo -e "\nMigrations completed successfully!"